@@ -0,0 +1,535 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// cliUserAgent is stored as the user agent on every audit row adminctl
+// writes, so CLI changes can be told apart from portal ones.
+const cliUserAgent = "adminctl"
+
+// newFlagSet returns a flag set that reports parse errors instead of
+// exiting, so main can pick the exit code.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: adminctl %s %s\n\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args and checks the required -email flag when given.
+func parse(fs *flag.FlagSet, args []string, email *string) error {
+	if err := fs.Parse(args); err != nil {
+		// The flag package has already printed the error and usage.
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected argument %q\n", fs.Arg(0))
+		fs.Usage()
+		return errUsage
+	}
+	if email != nil {
+		*email = strings.TrimSpace(*email)
+		if *email == "" {
+			fmt.Fprintln(os.Stderr, "Error: -email is required")
+			fs.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
+
+// passwordFlags are the non-interactive password sources shared by
+// create and rotate-password.
+type passwordFlags struct {
+	file string
+	env  string
+}
+
+func (p *passwordFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.file, "password-file", "", `Read the password from this file ("-" for stdin)`)
+	fs.StringVar(&p.env, "password-env", "", "Read the password from this environment variable")
+}
+
+// read returns the new password from -password-file, -password-env or,
+// failing both, an interactive prompt with confirmation.
+func (p *passwordFlags) read() ([]byte, error) {
+	var pw []byte
+	switch {
+	case p.file != "" && p.env != "":
+		return nil, errors.New("use only one of -password-file and -password-env")
+	case p.file == "-":
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return nil, fmt.Errorf("read password from stdin: %w", err)
+		}
+		pw = []byte(strings.TrimRight(line, "\r\n"))
+	case p.file != "":
+		data, err := os.ReadFile(p.file)
+		if err != nil {
+			return nil, fmt.Errorf("read password file: %w", err)
+		}
+		pw = []byte(strings.TrimRight(string(data), "\r\n"))
+	case p.env != "":
+		v, ok := os.LookupEnv(p.env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", p.env)
+		}
+		pw = []byte(v)
+	default:
+		var err error
+		if pw, err = promptPassword("Enter password: "); err != nil {
+			return nil, err
+		}
+		confirm, err := promptPassword("Confirm password: ")
+		if err != nil {
+			return nil, err
+		}
+		if string(pw) != string(confirm) {
+			return nil, errors.New("passwords do not match")
+		}
+	}
+	if len(pw) < 8 {
+		return nil, errors.New("password must be at least 8 characters")
+	}
+	return pw, nil
+}
+
+func promptPassword(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+	pw, err := term.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		// Fallback for environments without a terminal.
+		line, rerr := bufio.NewReader(os.Stdin).ReadString('\n')
+		if rerr != nil && line == "" {
+			return nil, fmt.Errorf("read password: %w", rerr)
+		}
+		pw = []byte(strings.TrimSpace(line))
+	}
+	return pw, nil
+}
+
+// confirm asks a y/n question unless -yes was given.
+func confirm(yes bool, question string) bool {
+	if yes {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s (y/n): ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer)) == "y"
+}
+
+// lookupAdmin resolves an admin_users row by email. App users are never
+// touched by this tool.
+func (e *env) lookupAdmin(ctx context.Context, email string) (*models.User, error) {
+	u, err := e.users.GetAdminByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("look up %s: %w", email, err)
+	}
+	if u == nil {
+		return nil, fmt.Errorf("no admin with email %s", email)
+	}
+	return u, nil
+}
+
+// audit writes one admin_audit_log row with the CLI actor marker. The
+// acting admin is ADMINCTL_ACTOR when it names an existing admin;
+// otherwise admin_id is left NULL and the OS user is the only identity.
+func (e *env) audit(ctx context.Context, action string, target uuid.UUID, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["actor"] = "cli"
+	if u := os.Getenv("USER"); u != "" {
+		details["os_user"] = u
+	}
+	if host, err := os.Hostname(); err == nil {
+		details["host"] = host
+	}
+	actorID := uuid.Nil
+	if email := strings.TrimSpace(os.Getenv("ADMINCTL_ACTOR")); email != "" {
+		details["actor_email"] = email
+		if u, err := e.users.GetAdminByEmail(ctx, email); err == nil && u != nil {
+			actorID = u.ID
+		}
+	}
+	if err := e.admins.LogAction(ctx, actorID, action, "user", target, details, "", cliUserAgent); err != nil {
+		return fmt.Errorf("change applied but audit log write failed: %w", err)
+	}
+	return nil
+}
+
+// revokeSessions signs the admin out everywhere on this env. Sessions on
+// the mirror env expire on their own; the mirror role can't reach them.
+func (e *env) revokeSessions(ctx context.Context, id uuid.UUID) error {
+	if err := e.sessions.RevokeForUserKind(ctx, id, models.SessionKindAdmin); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func runCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("create", "-email <email> -first-name <name> [flags]")
+	email := fs.String("email", "", "Admin email address (required)")
+	firstName := fs.String("first-name", "", "Admin first name (required for new admins)")
+	lastName := fs.String("last-name", "", "Admin last name")
+	role := fs.String("role", string(models.SystemRoleSuperAdmin), "System role (super_admin, support, marketing, partner, auditor)")
+	yes := fs.Bool("yes", false, "Update the role of an existing admin without asking")
+	var pwf passwordFlags
+	pwf.register(fs)
+	if err := parse(fs, args, email); err != nil {
+		return err
+	}
+	if !models.IsValidSystemRole(*role) {
+		return fmt.Errorf("invalid role %q; valid roles: super_admin, support, marketing, partner, auditor", *role)
+	}
+
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	// Only admin_users matters here; a parent who is also an admin has a
+	// separate app_users row created through /register.
+	existing, err := e.users.GetAdminByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("check existing admin: %w", err)
+	}
+	if existing != nil {
+		fmt.Printf("Admin '%s' already exists with role %s.\n", *email, existing.SystemRole.String)
+		if !confirm(*yes, "Update their system role to "+*role+"?") {
+			fmt.Println("Aborted.")
+			return nil
+		}
+		if err := e.admins.UpdateAdminRole(ctx, existing.ID, models.SystemRole(*role)); err != nil {
+			return fmt.Errorf("update role: %w", err)
+		}
+		if err := e.audit(ctx, "update_admin_role", existing.ID, map[string]interface{}{
+			"email": *email, "old_role": existing.SystemRole.String, "new_role": *role,
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("Updated %s to %s.\n", *email, *role)
+		return nil
+	}
+
+	if strings.TrimSpace(*firstName) == "" {
+		return errors.New("-first-name is required when creating an admin")
+	}
+	pw, err := pwf.read()
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword(pw, bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	view, err := e.admins.CreateAdminUser(ctx, *email, string(hash), *firstName, *lastName, models.SystemRole(*role))
+	if err != nil {
+		return fmt.Errorf("create admin: %w", err)
+	}
+	if err := e.audit(ctx, "create_admin", view.ID, map[string]interface{}{
+		"email": view.Email, "role": *role,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println("Admin user created.")
+	fmt.Printf("ID:    %s\n", view.ID)
+	fmt.Printf("Email: %s\n", view.Email)
+	fmt.Printf("Name:  %s %s\n", view.FirstName, view.LastName)
+	fmt.Printf("Role:  %s\n", view.SystemRole.String)
+	return nil
+}
+
+func runList(ctx context.Context, args []string) error {
+	fs := newFlagSet("list", "[-json]")
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	if err := parse(fs, args, nil); err != nil {
+		return err
+	}
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	admins, err := e.admins.ListAdminUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("list admins: %w", err)
+	}
+	if *asJSON {
+		if admins == nil {
+			admins = []repository.AdminUserView{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(admins)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "EMAIL\tNAME\tROLE\tSTATUS\tLAST LOGIN")
+	for _, a := range admins {
+		lastLogin := "never"
+		if a.LastLoginAt.Valid {
+			lastLogin = a.LastLoginAt.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", a.Email,
+			strings.TrimSpace(a.FirstName+" "+a.LastName), a.SystemRole.String, a.Status, lastLogin)
+	}
+	return tw.Flush()
+}
+
+func runDisable(ctx context.Context, args []string) error {
+	fs := newFlagSet("disable", "-email <email> [-yes]")
+	email := fs.String("email", "", "Admin email address (required)")
+	yes := fs.Bool("yes", false, "Don't ask for confirmation")
+	if err := parse(fs, args, email); err != nil {
+		return err
+	}
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	u, err := e.lookupAdmin(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if u.Status == models.UserStatusSuspended {
+		fmt.Printf("%s is already suspended.\n", *email)
+		return nil
+	}
+	if !confirm(*yes, "Suspend "+*email+" and sign them out?") {
+		fmt.Println("Aborted.")
+		return nil
+	}
+	if err := e.admins.UpdateUserStatus(ctx, u.ID, models.UserStatusSuspended); err != nil {
+		return fmt.Errorf("suspend: %w", err)
+	}
+	if err := e.revokeSessions(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := e.audit(ctx, "update_user_status", u.ID, map[string]interface{}{
+		"email": *email, "status": models.UserStatusSuspended, "previous_status": u.Status,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Suspended %s and revoked their sessions.\n", *email)
+	return nil
+}
+
+func runRotatePassword(ctx context.Context, args []string) error {
+	fs := newFlagSet("rotate-password", "-email <email> [-password-file <path|-> | -password-env <VAR>]")
+	email := fs.String("email", "", "Admin email address (required)")
+	var pwf passwordFlags
+	pwf.register(fs)
+	if err := parse(fs, args, email); err != nil {
+		return err
+	}
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	u, err := e.lookupAdmin(ctx, *email)
+	if err != nil {
+		return err
+	}
+	pw, err := pwf.read()
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword(pw, bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := e.admins.ResetUserPassword(ctx, u.ID, string(hash)); err != nil {
+		return fmt.Errorf("reset password: %w", err)
+	}
+	if err := e.revokeSessions(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := e.audit(ctx, "reset_user_password", u.ID, map[string]interface{}{"email": *email}); err != nil {
+		return err
+	}
+	fmt.Printf("Password rotated for %s; existing sessions revoked.\n", *email)
+	return nil
+}
+
+func runForceMFAReset(ctx context.Context, args []string) error {
+	fs := newFlagSet("force-mfa-reset", "-email <email> [-yes]")
+	email := fs.String("email", "", "Admin email address (required)")
+	yes := fs.Bool("yes", false, "Don't ask for confirmation")
+	if err := parse(fs, args, email); err != nil {
+		return err
+	}
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	u, err := e.lookupAdmin(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if !confirm(*yes, "Clear MFA for "+*email+" and sign them out?") {
+		fmt.Println("Aborted.")
+		return nil
+	}
+	if err := e.admins.ResetUserMFA(ctx, u.ID); err != nil {
+		return fmt.Errorf("reset MFA: %w", err)
+	}
+	if err := e.revokeSessions(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := e.audit(ctx, "reset_user_mfa", u.ID, map[string]interface{}{"email": *email}); err != nil {
+		return err
+	}
+	fmt.Printf("MFA reset for %s; they will re-enroll at next sign-in.\n", *email)
+	return nil
+}
+
+func runImport(ctx context.Context, args []string) error {
+	fs := newFlagSet("import", "-file <path|-> [-dry-run] [-json]")
+	file := fs.String("file", "", "CSV to import, or - for stdin (required)")
+	dryRun := fs.Bool("dry-run", false, "Validate every row without creating anything")
+	asJSON := fs.Bool("json", false, "Print the JSON report instead of a table")
+	if err := parse(fs, args, nil); err != nil {
+		return err
+	}
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "Error: -file is required")
+		fs.Usage()
+		return errUsage
+	}
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	rows, bad, err := service.ParseAdminCSV(in)
+	if err != nil {
+		return err
+	}
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	report := e.invites.Import(ctx, rows, bad, service.AdminImportOptions{DryRun: *dryRun})
+	if !report.DryRun {
+		for _, row := range report.Rows {
+			if row.Status == "invited" {
+				if err := e.audit(ctx, "create_admin", *row.AdminID, map[string]interface{}{
+					"email": row.Email, "role": row.Role, "via": "import", "batch_id": report.BatchID,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "LINE\tEMAIL\tROLE\tSTATUS\tDETAIL")
+		for _, row := range report.Rows {
+			detail := row.Error
+			if row.InviteURL != "" {
+				detail = row.InviteURL
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", row.Line, row.Email, row.Role, row.Status, detail)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if report.DryRun {
+			fmt.Printf("\nDry run: %d valid, %d with errors; nothing was created.\n", len(report.Rows)-report.Failed, report.Failed)
+		} else {
+			fmt.Printf("\nBatch %s: %d invited, %d failed. Links expire in 7 days and are not shown again.\n",
+				report.BatchID, report.Invited, report.Failed)
+		}
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d row(s) failed", report.Failed)
+	}
+	return nil
+}
+
+func runInvitations(ctx context.Context, args []string) error {
+	fs := newFlagSet("invitations", "[-batch <id>] [-json]")
+	batch := fs.String("batch", "", "Only invitations from this import batch")
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	if err := parse(fs, args, nil); err != nil {
+		return err
+	}
+	var batchID uuid.UUID
+	if *batch != "" {
+		id, err := uuid.Parse(*batch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -batch %q\n", *batch)
+			return errUsage
+		}
+		batchID = id
+	}
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	invs, err := e.invites.ListInvitations(ctx, batchID, 500)
+	if err != nil {
+		return fmt.Errorf("list invitations: %w", err)
+	}
+	if *asJSON {
+		if invs == nil {
+			invs = []repository.AdminInvitation{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(invs)
+	}
+	now := time.Now()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "EMAIL\tROLE\tSTATUS\tSENT\tEXPIRES")
+	for _, inv := range invs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", inv.Email, inv.Role, inv.Status(now),
+			inv.CreatedAt.Format("2006-01-02 15:04"), inv.ExpiresAt.Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,136 @@
+// Command adminctl manages admin portal accounts from the shell.
+//
+//	adminctl create          -email <email> -first-name <name> [-last-name <name>] [-role <role>]
+//	                         [-password-file <path|-> | -password-env <VAR>] [-yes]
+//	adminctl list            [-json]
+//	adminctl disable         -email <email> [-yes]
+//	adminctl rotate-password -email <email> [-password-file <path|-> | -password-env <VAR>]
+//	adminctl force-mfa-reset -email <email> [-yes]
+//	adminctl import          -file <path|-> [-dry-run] [-json]
+//	adminctl invitations     [-batch <id>] [-json]
+//
+// With -password-file or -password-env (and -yes for confirmations) every
+// command runs unattended; otherwise the password is prompted for. Each change is written to
+// admin_audit_log with actor "cli"; set ADMINCTL_ACTOR to an admin email to
+// attribute it to a person as well.
+//
+// import reads a CSV with an email, name (or first_name/last_name) and
+// role column and prints a one-time invitation link per created admin;
+// adminctl sends no email, so hand the links over yourself.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// command is one adminctl subcommand.
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"create":          {"create an admin (or change an existing admin's role)", runCreate},
+	"list":            {"list admin accounts", runList},
+	"disable":         {"suspend an admin and revoke their sessions", runDisable},
+	"rotate-password": {"set a new password and revoke sessions", runRotatePassword},
+	"force-mfa-reset": {"clear MFA enrollment and revoke sessions", runForceMFAReset},
+	"import":          {"create admins from a CSV with invitation links", runImport},
+	"invitations":     {"list invitation links and whether they were accepted", runInvitations},
+}
+
+// errUsage means the arguments were wrong; the subcommand has already
+// printed its flag defaults.
+var errUsage = errors.New("usage")
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: adminctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'adminctl <command> -h' for a command's flags.")
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(context.Background(), os.Args[2:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// env is the database side of a command, opened only once the flags
+// have validated.
+type env struct {
+	admins   repository.AdminRepository
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+	invites  *service.AdminInviteService
+	close    func()
+}
+
+// connect opens the local database and, if ADMIN_MIRROR_DB_DSN is set,
+// routes admin_users writes through the dual-write wrapper so changes
+// replicate to the other env immediately instead of drifting until the
+// next boot-sync.
+func connect() (*env, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	e := &env{close: func() { db.Close() }}
+
+	baseAdmin := repository.NewAdminRepo(db.DB, db.DB)
+	e.admins = baseAdmin
+	if cfg.Database.AdminMirrorDSN != "" {
+		mirrorDB, err := database.NewWithDSN(
+			cfg.Database.AdminMirrorDSN,
+			cfg.Database.MaxOpenConns,
+			cfg.Database.MaxIdleConns,
+			cfg.Database.ConnMaxLifetime,
+		)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("connect to admin-mirror DB: %w", err)
+		}
+		e.admins = repository.NewReplicatingAdminRepo(baseAdmin, db.DB, mirrorDB.DB)
+		e.close = func() { mirrorDB.Close(); db.Close() }
+		fmt.Fprintln(os.Stderr, "Replication ON: writes will dual-target local + mirror.")
+	}
+	e.users = repository.NewUserRepo(db.DB)
+	e.sessions = repository.NewSessionRepo(db.DB)
+	e.invites = service.NewAdminInviteService(e.admins, e.users, repository.NewAdminInvitationRepo(db.DB), nil, cfg.App.URL)
+	return e, nil
+}
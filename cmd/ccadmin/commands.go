@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// cliUserAgent is stored as the user agent on every audit row ccadmin
+// writes, so scripted changes can be told apart from portal ones.
+const cliUserAgent = "ccadmin"
+
+// newFlagSet returns a flag set that reports parse errors instead of
+// exiting, so main can pick the exit code.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccadmin %s %s\n\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args and rejects stray positional arguments.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		// The flag package has already printed the error and usage.
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected argument %q\n", fs.Arg(0))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// usageError prints msg and the command's flags.
+func usageError(fs *flag.FlagSet, msg string) error {
+	fmt.Fprintln(os.Stderr, "Error: "+msg)
+	fs.Usage()
+	return errUsage
+}
+
+// confirm asks a y/n question unless -yes was given.
+func confirm(yes bool, question string) bool {
+	if yes {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s (y/n): ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer)) == "y"
+}
+
+// actorID resolves CCADMIN_ACTOR to an admin ID. Unset means uuid.Nil
+// (stored as NULL); set to an unknown email is an error so a typo doesn't
+// silently drop attribution.
+func (e *env) actorID(ctx context.Context) (uuid.UUID, error) {
+	email := strings.TrimSpace(os.Getenv("CCADMIN_ACTOR"))
+	if email == "" {
+		return uuid.Nil, nil
+	}
+	u, err := e.users.GetAdminByEmail(ctx, email)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("look up CCADMIN_ACTOR: %w", err)
+	}
+	if u == nil {
+		return uuid.Nil, fmt.Errorf("CCADMIN_ACTOR %s is not an admin", email)
+	}
+	return u.ID, nil
+}
+
+// audit writes one admin_audit_log row with the CLI actor marker.
+func (e *env) audit(ctx context.Context, actor uuid.UUID, action, targetType string, target uuid.UUID, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["actor"] = "cli"
+	if u := os.Getenv("USER"); u != "" {
+		details["os_user"] = u
+	}
+	if host, err := os.Hostname(); err == nil {
+		details["host"] = host
+	}
+	if email := strings.TrimSpace(os.Getenv("CCADMIN_ACTOR")); email != "" {
+		details["actor_email"] = email
+	}
+	if err := e.admins.LogAction(ctx, actor, action, targetType, target, details, "", cliUserAgent); err != nil {
+		return fmt.Errorf("change applied but audit log write failed: %w", err)
+	}
+	return nil
+}
+
+// open connects and resolves the actor, the preamble of every command.
+func open(ctx context.Context) (*env, uuid.UUID, error) {
+	e, err := connect()
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	actor, err := e.actorID(ctx)
+	if err != nil {
+		e.close()
+		return nil, uuid.Nil, err
+	}
+	return e, actor, nil
+}
+
+func runUserUnlock(ctx context.Context, args []string) error {
+	fs := newFlagSet("user unlock", "-email <email> [-yes]")
+	email := fs.String("email", "", "App account email (required)")
+	yes := fs.Bool("yes", false, "Don't ask for confirmation")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*email) == "" {
+		return usageError(fs, "-email is required")
+	}
+	e, actor, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	u, err := e.users.GetAppByEmail(ctx, strings.TrimSpace(*email))
+	if err != nil {
+		return fmt.Errorf("look up %s: %w", *email, err)
+	}
+	if u == nil {
+		return fmt.Errorf("no app account with email %s", *email)
+	}
+	switch u.Status {
+	case models.UserStatusActive:
+		fmt.Printf("%s is already active.\n", u.Email)
+		return nil
+	case models.UserStatusPendingVerification:
+		return fmt.Errorf("%s has not verified their email yet; unlocking would skip verification", u.Email)
+	}
+	if !confirm(*yes, fmt.Sprintf("Reactivate %s (currently %s)?", u.Email, u.Status)) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+	if err := e.admins.UpdateUserStatus(ctx, u.ID, models.UserStatusActive); err != nil {
+		return fmt.Errorf("reactivate: %w", err)
+	}
+	if err := e.audit(ctx, actor, "update_user_status", "user", u.ID, map[string]interface{}{
+		"email": u.Email, "status": models.UserStatusActive, "previous_status": u.Status,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Reactivated %s.\n", u.Email)
+	return nil
+}
+
+func runSubscriptionExtend(ctx context.Context, args []string) error {
+	fs := newFlagSet("subscription extend", "(-family <id> | -email <parent email>) (-days <n> | -until <YYYY-MM-DD>) -reason <text>")
+	familyFlag := fs.String("family", "", "Family ID")
+	email := fs.String("email", "", "Email of a parent in the family (instead of -family)")
+	days := fs.Int("days", 0, "Extend by this many days from the current end (or from today if already past)")
+	untilFlag := fs.String("until", "", "Extend to the end of this date (UTC)")
+	reason := fs.String("reason", "", "Why, for the audit log (required)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if (*familyFlag == "") == (*email == "") {
+		return usageError(fs, "give exactly one of -family and -email")
+	}
+	if (*days == 0) == (*untilFlag == "") || *days < 0 {
+		return usageError(fs, "give exactly one of -days (positive) and -until")
+	}
+	if strings.TrimSpace(*reason) == "" {
+		return usageError(fs, "-reason is required")
+	}
+	var until time.Time
+	if *untilFlag != "" {
+		d, err := time.Parse("2006-01-02", *untilFlag)
+		if err != nil {
+			return usageError(fs, "bad -until (need YYYY-MM-DD)")
+		}
+		// End of day in UTC, matching the comp button.
+		until = time.Date(d.Year(), d.Month(), d.Day(), 23, 59, 59, 0, time.UTC)
+	}
+
+	e, actor, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	familyID, err := e.resolveFamily(ctx, *familyFlag, *email)
+	if err != nil {
+		return err
+	}
+	sub, err := e.admins.GetFamilySubscriptionByFamilyID(ctx, familyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("family %s has no subscription", familyID)
+	}
+	if err != nil {
+		return fmt.Errorf("load subscription: %w", err)
+	}
+	if *days > 0 {
+		from := sub.CurrentPeriodEnd
+		if now := time.Now().UTC(); from.Before(now) {
+			from = now
+		}
+		until = from.AddDate(0, 0, *days)
+	}
+	if !until.After(sub.CurrentPeriodEnd) {
+		return fmt.Errorf("%s is not after the current end %s", until.Format(time.RFC3339), sub.CurrentPeriodEnd.Format(time.RFC3339))
+	}
+
+	ok, err := e.admins.ExtendFamilySubscription(ctx, familyID, until)
+	if err != nil {
+		return fmt.Errorf("extend: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%s subscription (%s) can't be extended here: only trials and comps not billed through Stripe can; comp the family instead",
+			sub.FamilyName, sub.Status)
+	}
+	if err := e.audit(ctx, actor, "subscription.extend", "family_subscription", sub.ID, map[string]interface{}{
+		"family":         sub.FamilyName,
+		"plan":           sub.PlanName,
+		"status":         string(sub.Status),
+		"old_period_end": sub.CurrentPeriodEnd.Format(time.RFC3339),
+		"period_end":     until.Format(time.RFC3339),
+		"reason":         strings.TrimSpace(*reason),
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Extended %s (%s) from %s to %s.\n", sub.FamilyName, sub.Status,
+		sub.CurrentPeriodEnd.Format("2006-01-02"), until.Format("2006-01-02"))
+	return nil
+}
+
+// resolveFamily turns -family or a parent's -email into a family ID. A
+// parent in several families must use -family.
+func (e *env) resolveFamily(ctx context.Context, familyFlag, email string) (uuid.UUID, error) {
+	if familyFlag != "" {
+		id, err := uuid.Parse(familyFlag)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("bad -family: %w", err)
+		}
+		return id, nil
+	}
+	u, err := e.users.GetAppByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("look up %s: %w", email, err)
+	}
+	if u == nil {
+		return uuid.Nil, fmt.Errorf("no app account with email %s", email)
+	}
+	memberships, err := e.families.GetUserFamilies(ctx, u.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("load families: %w", err)
+	}
+	switch len(memberships) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("%s is not in any family", email)
+	case 1:
+		return memberships[0].FamilyID, nil
+	}
+	var b strings.Builder
+	for _, m := range memberships {
+		fmt.Fprintf(&b, "\n  %s  %s", m.FamilyID, m.Family.Name)
+	}
+	return uuid.Nil, fmt.Errorf("%s is in %d families; pick one with -family:%s", email, len(memberships), b.String())
+}
+
+func runTicketAssign(ctx context.Context, args []string) error {
+	fs := newFlagSet("ticket assign", "-ticket <id|number> -to <admin email>")
+	ticketFlag := fs.String("ticket", "", "Ticket ID or number (required)")
+	to := fs.String("to", "", "Assignee admin email (required)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *ticketFlag == "" || strings.TrimSpace(*to) == "" {
+		return usageError(fs, "-ticket and -to are required")
+	}
+	e, actor, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	var ticket *repository.SupportTicket
+	if n, perr := strconv.ParseInt(strings.TrimPrefix(*ticketFlag, "#"), 10, 64); perr == nil {
+		ticket, err = e.admins.GetTicketByNumber(ctx, n)
+	} else if id, perr := uuid.Parse(*ticketFlag); perr == nil {
+		ticket, err = e.admins.GetTicketByID(ctx, id)
+	} else {
+		return fmt.Errorf("bad -ticket %q: want a ticket number or ID", *ticketFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("load ticket: %w", err)
+	}
+	if ticket == nil {
+		return fmt.Errorf("ticket %s not found", *ticketFlag)
+	}
+	assignee, err := e.users.GetAdminByEmail(ctx, strings.TrimSpace(*to))
+	if err != nil {
+		return fmt.Errorf("look up %s: %w", *to, err)
+	}
+	if assignee == nil {
+		return fmt.Errorf("no admin with email %s", *to)
+	}
+	if err := e.admins.AssignTicket(ctx, ticket.ID, assignee.ID); err != nil {
+		return fmt.Errorf("assign: %w", err)
+	}
+	if err := e.audit(ctx, actor, "assign_ticket", "ticket", ticket.ID, map[string]interface{}{
+		"assignee_id": assignee.ID.String(), "assignee_email": assignee.Email, "ticket_number": ticket.Number,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Assigned #%d %q to %s.\n", ticket.Number, ticket.Subject, assignee.Email)
+	return nil
+}
+
+func runErrorsList(ctx context.Context, args []string) error {
+	fs := newFlagSet("errors list", "[-limit <n>] [-json]")
+	limit := fs.Int("limit", 25, "Maximum number of groups")
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	e, err := connect()
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	groups, err := e.admins.ListErrorFingerprints(ctx, *limit)
+	if err != nil {
+		return fmt.Errorf("list errors: %w", err)
+	}
+	if *asJSON {
+		if groups == nil {
+			groups = []repository.ErrorFingerprint{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groups)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FINGERPRINT\tCOUNT\tLAST SEEN\tSTATUS\tREQUEST\tMESSAGE")
+	for _, g := range groups {
+		msg := g.Message
+		if len(msg) > 60 {
+			msg = msg[:57] + "..."
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s %s\t%s\n", g.Fingerprint, g.Count,
+			g.LastSeen.Format("2006-01-02 15:04"), g.StatusCode, g.Method, g.Path, msg)
+	}
+	return tw.Flush()
+}
+
+func runErrorsAck(ctx context.Context, args []string) error {
+	fs := newFlagSet("errors ack", "-fingerprint <fp> [-notes <text>]")
+	fingerprint := fs.String("fingerprint", "", "Fingerprint from 'ccadmin errors list' (required)")
+	notes := fs.String("notes", "", "Acknowledgement notes")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	fp := strings.ToLower(strings.TrimSpace(*fingerprint))
+	if len(fp) != 12 {
+		return usageError(fs, "-fingerprint must be the 12-character value shown by 'errors list'")
+	}
+	e, actor, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	n, err := e.admins.AcknowledgeErrorLogsByFingerprint(ctx, fp, actor, *notes)
+	if err != nil {
+		return fmt.Errorf("acknowledge: %w", err)
+	}
+	if n == 0 {
+		fmt.Printf("No open errors with fingerprint %s.\n", fp)
+		return nil
+	}
+	if err := e.audit(ctx, actor, "acknowledge_error_logs", "error_log", uuid.Nil, map[string]interface{}{
+		"fingerprint": fp, "count": n, "notes": *notes,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Acknowledged %d error(s) with fingerprint %s.\n", n, fp)
+	return nil
+}
+
+func runMetricsRefresh(ctx context.Context, args []string) error {
+	fs := newFlagSet("metrics refresh", "")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	e, actor, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+
+	start := time.Now()
+	if err := e.admins.RefreshMetrics(ctx); err != nil {
+		return fmt.Errorf("refresh metrics: %w", err)
+	}
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err := e.audit(ctx, actor, "refresh_metrics", "metrics", uuid.Nil, map[string]interface{}{
+		"duration_ms": elapsed.Milliseconds(),
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Metrics refreshed in %s.\n", elapsed)
+	return nil
+}
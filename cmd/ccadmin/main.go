@@ -0,0 +1,124 @@
+// Command ccadmin runs common support operations against the database so
+// they can be scripted and pasted into runbooks.
+//
+//	ccadmin user unlock          -email <email> [-yes]
+//	ccadmin subscription extend  (-family <id> | -email <parent email>) (-days <n> | -until <YYYY-MM-DD>) -reason <text>
+//	ccadmin ticket assign        -ticket <id|number> -to <admin email>
+//	ccadmin errors list          [-limit <n>] [-json]
+//	ccadmin errors ack           -fingerprint <fp> [-notes <text>]
+//	ccadmin metrics refresh
+//
+// Every change is written to admin_audit_log with actor "cli". Set
+// CCADMIN_ACTOR to your admin email so audit entries and error
+// acknowledgements are attributed to you. user commands act on app
+// (parent) accounts; admin accounts are managed with adminctl.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+	"carecompanion/internal/repository"
+)
+
+// command is one "<group> <verb>" operation.
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"user unlock":         {"reactivate a suspended or inactive app account", runUserUnlock},
+	"subscription extend": {"push out the end of a trial or comp", runSubscriptionExtend},
+	"ticket assign":       {"assign a support ticket to an admin", runTicketAssign},
+	"errors list":         {"list open error groups by fingerprint", runErrorsList},
+	"errors ack":          {"acknowledge every open error with a fingerprint", runErrorsAck},
+	"metrics refresh":     {"recompute the dashboard metrics cache", runMetricsRefresh},
+}
+
+// errUsage means the arguments were wrong; usage has already been printed.
+var errUsage = errors.New("usage")
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: ccadmin <group> <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'ccadmin <group> <command> -h' for a command's flags.")
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 3 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1] + " " + os.Args[2]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(context.Background(), os.Args[3:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// env is the database side of a command, opened only once the flags
+// have validated.
+type env struct {
+	admins   repository.AdminRepository
+	users    repository.UserRepository
+	families repository.FamilyRepository
+	close    func()
+}
+
+// connect opens the main database and, when SUPPORT_DB_DSN is set, the
+// separate support-ticket pool, mirroring cmd/server.
+func connect() (*env, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	e := &env{close: func() { db.Close() }}
+
+	supportDB := db.DB
+	if cfg.Database.SupportDSN != "" {
+		s, err := database.NewWithDSN(
+			cfg.Database.SupportDSN,
+			cfg.Database.MaxOpenConns,
+			cfg.Database.MaxIdleConns,
+			cfg.Database.ConnMaxLifetime,
+		)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("connect to support database: %w", err)
+		}
+		supportDB = s.DB
+		e.close = func() { s.Close(); db.Close() }
+	}
+	e.admins = repository.NewAdminRepo(db.DB, supportDB)
+	e.users = repository.NewUserRepo(db.DB)
+	e.families = repository.NewFamilyRepo(db.DB)
+	return e, nil
+}
@@ -1,40 +1,39 @@
+// Command dbtest checks that the server's database settings connect: it
+// loads the same config, opens the main pool the way the server does
+// (password file, previous password or IAM token included) and runs
+// SELECT 1. Use it after a rotation to confirm the new credential before
+// dropping DB_PASSWORD_PREVIOUS.
 package main
 
 import (
 	"context"
 	"log"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
 )
 
 func main() {
-	config, err := pgx.ParseConfig("")
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("ParseConfig failed: %v", err)
+		log.Fatalf("load config: %v", err)
 	}
+	log.Printf("Connecting to %s:%s/%s as %s (IAM auth %v, password file %q, previous password set %v)",
+		cfg.Database.Host, cfg.Database.Port, cfg.Database.Name, cfg.Database.User,
+		cfg.Database.IAMAuth, cfg.Database.PasswordFile, cfg.Database.PasswordPrevious != "")
 
-	config.Host = "172.28.0.10"
-	config.Port = 5432
-	config.Database = "carecompanion"
-	config.User = "carecomp_app"
-	config.Password = "CareCompApp2025\\!"  // Single backslash
-	
-	log.Printf("Password: %q", config.Password)
-	for i, c := range config.Password {
-		log.Printf("  [%d] %c = %d", i, c, c)
-	}
-	
-	conn, err := pgx.ConnectConfig(context.Background(), config)
+	db, err := database.New(&cfg.Database)
 	if err != nil {
 		log.Fatalf("Connect failed: %v", err)
 	}
-	defer conn.Close(context.Background())
-	
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	var result int
-	err = conn.QueryRow(context.Background(), "SELECT 1").Scan(&result)
-	if err != nil {
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&result); err != nil {
 		log.Fatalf("Query failed: %v", err)
 	}
-	
 	log.Printf("SUCCESS! Result: %d", result)
 }
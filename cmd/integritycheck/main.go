@@ -0,0 +1,118 @@
+// Command integritycheck scans the database for rows whose references
+// don't resolve — logs of missing or deactivated children, memberships of
+// missing users or families, subscription payments with no subscription —
+// and prints a count and sample row IDs per check. The run is recorded in
+// integrity_check_runs for the admin Integrity page.
+//
+//	integritycheck [-quarantine] [-samples 5] [-check name,...] [-json] [-list]
+//
+// With -quarantine the orphans of quarantinable checks are moved into
+// integrity_quarantine (report-only checks are never touched). Exits 1 when
+// any check finds problems or fails.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	quarantine := flag.Bool("quarantine", false, "move orphans of quarantinable checks into integrity_quarantine")
+	samples := flag.Int("samples", 0, "sample row IDs to keep per check (default 5, max 50)")
+	only := flag.String("check", "", "comma-separated check names to run (default all)")
+	asJSON := flag.Bool("json", false, "print the run as JSON")
+	list := flag.Bool("list", false, "list the checks and exit")
+	flag.Parse()
+
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, c := range repository.IntegrityChecks {
+			mode := "report-only"
+			if c.Quarantinable {
+				mode = "quarantinable"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, mode, c.Description)
+		}
+		w.Flush()
+		return
+	}
+
+	var checks []string
+	if *only != "" {
+		for _, n := range strings.Split(*only, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				checks = append(checks, n)
+			}
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error: load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Error: connect to database: %v", err)
+	}
+	defer db.Close()
+
+	svc := service.NewIntegrityService(repository.NewIntegrityRepo(db.DB))
+	host, _ := os.Hostname()
+	run, err := svc.Run(ctx, service.IntegrityOptions{
+		Quarantine: *quarantine,
+		Samples:    *samples,
+		Checks:     checks,
+		RunBy:      os.Getenv("USER") + "@" + host,
+	})
+	if err != nil && run == nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(run); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	} else {
+		fmt.Printf("Integrity check %s: %s\n", run.ID, run.Status)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CHECK\tCOUNT\tQUARANTINED\tSAMPLE IDS")
+		for _, res := range run.Results {
+			if res.Error != "" {
+				fmt.Fprintf(w, "%s\t-\t-\terror: %s\n", res.Name, res.Error)
+				continue
+			}
+			quarantined := "-"
+			if res.Quarantinable && run.Quarantine {
+				quarantined = fmt.Sprint(res.Quarantined)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", res.Name, res.Count, quarantined, strings.Join(res.SampleIDs, ","))
+		}
+		w.Flush()
+	}
+	if run.Status != repository.IntegrityStatusClean {
+		os.Exit(1)
+	}
+}
@@ -5,18 +5,17 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
 
 	"carecompanion/internal/auth"
 	"carecompanion/internal/config"
@@ -27,11 +26,10 @@ import (
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/migrate"
 	"carecompanion/internal/repository"
+	"carecompanion/internal/rpc"
 	"carecompanion/internal/service"
 )
 
-const transferDir = "transfers"
-
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -39,8 +37,11 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Connect to database
-	db, err := database.New(&cfg.Database)
+	// Connect to database. A database still starting (compose, a failover)
+	// is waited for with backoff rather than crashing the boot.
+	db, err := database.Retry("PostgreSQL", cfg.Database.StartupWait, func() (*database.DB, error) {
+		return database.New(&cfg.Database)
+	})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -123,7 +124,9 @@ func main() {
 	migCancel()
 
 	// Connect to Redis
-	redis, err := database.NewRedis(&cfg.Redis)
+	redis, err := database.Retry("Redis", cfg.Redis.StartupWait, func() (*database.Redis, error) {
+		return database.NewRedis(&cfg.Redis)
+	})
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
@@ -131,6 +134,16 @@ func main() {
 	log.Println("Connected to Redis")
 
 	// Initialize repositories
+	repository.SetQueryTagOptions(repository.QueryTagOptions{
+		Enabled:   cfg.Database.QueryTags,
+		RequestID: cfg.Database.QueryTagRequestID,
+	})
+	repository.SetFamilyRLS(cfg.Database.FamilyRLS)
+	repository.SetTimeoutPolicy(repository.TimeoutPolicy{
+		Request:    cfg.Database.StatementTimeout,
+		Methods:    cfg.Database.MethodTimeouts,
+		Operations: cfg.Database.OperationTimeouts,
+	})
 	repos := repository.NewRepositories(db.DB, supportDB, sessionsProdDB, adminMirrorDB)
 
 	// One-shot bidirectional reconciliation of admin_users between local and
@@ -161,24 +174,37 @@ func main() {
 		log.Printf("Warning: Templates not loaded: %v", err)
 	}
 
-	// Create transfers directory for file transfer utility
-	os.MkdirAll(transferDir, 0755)
-
 	// Create router
 	r := chi.NewRouter()
 
 	// Initialize error tracker
 	errorTracker := middleware.NewErrorTracker(db.DB)
+	errorTracker.SetScannerClassifier(services.Abuse)
+	repository.SetTimeoutObserver(errorTracker)
+
+	// Lapsed-subscription grace period; the expiry sweeper is given the
+	// same value in NewServices.
+	middleware.SetReadOnlyGrace(time.Duration(cfg.Subscription.ReadOnlyGraceDays) * 24 * time.Hour)
 
 	// Global middleware
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
 	r.Use(errorTracker.Middleware) // Track errors and response times
+	// Abuse detection: blocked IPs get 429 before anything else runs.
+	// The admin panel and health checks are never blocked or counted.
+	r.Use(middleware.AbuseGuard(services.Abuse, "/admin", "/api/admin", "/health", "/ready", "/static"))
 	r.Use(middleware.LoggingMiddleware)
 	r.Use(middleware.RecoverMiddleware)
-	r.Use(middleware.SecurityHeaders)
 	r.Use(middleware.CORSMiddleware(nil))
 	r.Use(chimiddleware.Compress(5))
+	// Security headers sit inside Compress: they fill the CSP nonce into
+	// HTML responses, which has to happen before gzip.
+	securityHeaders := middleware.NewSecurityHeaders(middleware.SecurityHeadersOptions{
+		CSP:        cfg.Security.CSP,
+		ReportOnly: cfg.Security.CSPReportOnly,
+		ReportURI:  "/csp-report",
+	}, services.SecurityHeaders)
+	r.Use(securityHeaders.Middleware)
 
 	// Dev-gate: in non-prod environments, fronts the app with a one-time
 	// passphrase so casual visitors who find the dev URL can't reach the
@@ -190,11 +216,19 @@ func main() {
 		os.Getenv("DEV_GATE_APP_UA_MARKER"),
 	))
 
+	// CSRF: signed double-submit token for cookie-authenticated form and
+	// fetch requests. Bearer-auth API calls are not checked. Stripe and
+	// browser CSP reports post with no page behind them.
+	r.Use(middleware.NewCSRF(cfg.JWT.Secret, "/webhooks/", "/csp-report", "/internal/").Middleware)
+
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{"status":"ok"}`))
 	})
+	// Readiness: 503 while the database or Redis circuit breaker is open,
+	// with each breaker's state.
+	r.Get("/ready", middleware.Readiness(db.Breaker, redis.Breaker))
 
 	// Maintenance status endpoint (no auth required, used by public pages)
 	r.Get("/api/maintenance-status", func(w http.ResponseWriter, r *http.Request) {
@@ -221,10 +255,22 @@ func main() {
 		})
 	})
 
+	// CSP violation reports from browsers (no auth; rate-limited per IP)
+	r.With(middleware.RateLimit(60, 1*time.Minute)).Post("/csp-report", securityHeaders.ReportHandler)
+
 	// API routes
 	r.Route("/api", func(r chi.Router) {
 		r.Use(middleware.ContentTypeJSON)
-		api.SetupRoutes(r, apiHandlers, services.Auth, db.DB)
+		// While the database breaker is open, API calls get a fast 503
+		// rather than a 500 from whichever query hit it first.
+		r.Use(middleware.DependencyGuard(db.Breaker))
+		// Builds below the minimum app version get 426 + upgrade details.
+		// /api/app/* (config, version check) and /api/client-config stay
+		// open so they can still find out why.
+		r.Use(middleware.AppVersionGate(services.AppVersion, "/api/app/", "/api/client-config"))
+		// Requests per client and route, for the admin API usage report.
+		r.Use(middleware.APIUsage(services.APIUsage))
+		api.SetupRoutes(r, apiHandlers, services.Auth, services.Captcha, db.DB)
 	})
 
 	// Public report PDF — signed URL, no auth. SFSafariViewController and
@@ -232,6 +278,36 @@ func main() {
 	// embeds a short-lived HMAC signature instead.
 	r.Get("/r/signed/{reportID}", apiHandlers.Report.ServeSignedPDF)
 
+	// Public shared report — the link a caregiver sends a clinician. The
+	// expiry is kept on the share so it can be extended or revoked, and
+	// each open is recorded as a read receipt.
+	r.Get("/r/share/{shareID}", apiHandlers.ReportShare.ServeShared)
+
+	// Public ticket attachment / thumbnail — same signed-URL scheme, so
+	// <img> tags in the support thread (app and admin portal) can load
+	// without an Authorization header.
+	r.Get("/a/signed/{attachmentID}", apiHandlers.Support.ServeSignedAttachment)
+
+	// Public image variants (child photos, headshots, marketing images) —
+	// same scheme, so srcset candidates load without auth.
+	r.Get("/i/signed/{variantID}", apiHandlers.Image.ServeSignedVariant)
+
+	// Public invoice PDF — same scheme, opened from billing history in the
+	// system browser.
+	r.Get("/inv/signed/{invoiceID}", apiHandlers.Invoice.ServeSignedPDF)
+
+	// Public exit package download — the link emailed when a family's
+	// subscription ends, valid for the package's 90-day window.
+	r.Get("/exit/signed/{packageID}", apiHandlers.ExitPackage.ServeSigned)
+
+	// Public seizure incident summary — the link a family shares with the
+	// child's neurologist.
+	r.Get("/sz/signed/{eventID}", apiHandlers.SeizureEvent.ServeSignedSummary)
+
+	// Public respite handoff PDF — the link a family gives a babysitter
+	// or respite carer, valid for the lifetime they picked.
+	r.Get("/handoff/signed/{childID}", apiHandlers.RespiteHandoff.ServeSigned)
+
 	// Web routes
 	web.SetupRoutes(r, webHandlers, services.Auth, db.DB)
 
@@ -253,6 +329,8 @@ func main() {
 				"app/carecompanion-alb/ec4daecf3b14c818",                                                                        // ALB suffix for CloudWatch metrics
 				"arn:aws:elasticloadbalancing:us-east-1:943431294725:targetgroup/carecompanion-tg/bade3e56ae036ce7",             // Full Target group ARN for ELB API
 			)
+			// Confirmation tokens for the ASG write operations.
+			cwService.SetConfirmSecret(cfg.JWT.Secret)
 			adminHandler.SetCloudWatchService(cwService)
 			log.Println("CloudWatch service initialized for metrics collection")
 		}
@@ -260,6 +338,10 @@ func main() {
 
 	// Initialize Marketing service for material generation
 	marketingService := service.NewMarketingService(repos.Marketing, "static/marketing")
+	marketingService.SetFontStorage(service.NewBlobStorage(&cfg.Storage, "brand_fonts", cfg.Storage.S3Prefix+"brand-fonts/"))
+	marketingService.SetScanService(services.UploadScan)
+	marketingService.SetAssetSigner(services.AssetSigner)
+	marketingService.SetImageService(services.Images)
 	adminHandler.SetMarketingService(marketingService)
 	log.Println("Marketing service initialized")
 
@@ -275,6 +357,62 @@ func main() {
 	// Wire ticket-attachment service into admin handlers
 	adminHandler.SetTicketAttachmentService(services.TicketAttachment)
 
+	// Wire ticket category/tag taxonomy into admin handlers
+	adminHandler.SetTicketTaxonomyService(services.TicketTaxonomy)
+
+	// Wire help center knowledge base into admin handlers
+	adminHandler.SetKnowledgeBaseService(services.KnowledgeBase)
+
+	// Wire NPS / in-app feedback analytics into admin handlers
+	adminHandler.SetFeedbackService(services.Feedback)
+	adminHandler.SetCampaignService(services.Campaign)
+
+	// Wire testimonials into admin handlers and the brochure/social generators
+	adminHandler.SetTestimonialService(services.Testimonial)
+	marketingService.SetTestimonialSource(services.Testimonial)
+
+	// Wire the admin file transfer drop (/admin/filextfer)
+	adminHandler.SetFileTransferService(services.FileTransfer)
+	adminHandler.SetUploadScanService(services.UploadScan)
+	adminHandler.SetBackupService(services.Backup)
+	adminHandler.SetWarehouseExportService(services.Warehouse)
+	adminHandler.SetProductAnalyticsService(services.Analytics)
+	adminHandler.SetEngagementService(services.Engagement)
+	adminHandler.SetSegmentService(services.Segments)
+	adminHandler.SetOnboardingService(services.Onboarding)
+	adminHandler.SetCostService(services.Cost)
+	adminHandler.SetLogSearchService(services.LogSearch)
+	adminHandler.SetLogRetentionService(services.LogRetention)
+	adminHandler.SetPerformanceService(services.Performance)
+	adminHandler.SetAPIUsageService(services.APIUsage)
+	adminHandler.SetAbuseService(services.Abuse)
+	adminHandler.SetCaptchaService(services.Captcha)
+	adminHandler.SetQueryStatsService(services.QueryStats)
+	adminHandler.SetSecurityHeadersService(services.SecurityHeaders, cfg.Security.CSP, cfg.Security.CSPReportOnly)
+	adminHandler.SetAppVersionService(services.AppVersion)
+	adminHandler.SetLegalService(services.Legal)
+	adminHandler.SetResearchConsentService(services.Research)
+	adminHandler.SetOrganizationService(services.Organizations)
+	adminHandler.SetOrganizationBillingService(services.OrgBilling)
+	adminHandler.SetInvoiceService(services.Invoices)
+	adminHandler.SetTaxService(services.Tax)
+	adminHandler.SetDisputeService(services.Disputes)
+	adminHandler.SetExitPackageService(services.ExitPackages)
+	adminHandler.SetLogUsageService(services.LogUsage)
+	adminHandler.SetMetricAnomalyService(services.MetricAnomalies)
+	adminHandler.SetAdminNotificationService(services.AdminNotifications)
+	adminHandler.SetIntegrityService(services.Integrity)
+	adminHandler.SetPendingActionService(services.PendingActions)
+	adminHandler.SetPromoCodeService(services.PromoCodes)
+	adminHandler.SetPromoFraudService(services.PromoFraud)
+	adminHandler.SetAccessReviewService(services.AccessReviews)
+	adminHandler.SetElevationService(services.Elevations)
+	adminHandler.SetComplianceExportService(services.ComplianceExports)
+	adminHandler.SetSessionPolicyService(services.SessionPolicy)
+	adminHandler.SetTicketMergeService(services.TicketMerge)
+	adminHandler.SetTaskQueue(services.Tasks)
+	adminHandler.SetUploadService(services.Upload)
+
 	// Wire beta-invitation service into admin handlers
 	adminHandler.SetBetaService(services.Beta)
 
@@ -305,10 +443,15 @@ func main() {
 	adminHandler.SetLiveSessionsService(services.LiveSessions)
 	adminHandler.SetProQAService(services.ProQA)
 	adminHandler.SetRoleService(services.Role)
+	adminHandler.SetAdminInviteService(services.AdminInvites)
 	// Wire the role service as the custom-role resolver consulted by
 	// auth.Matrix(). Setting it AFTER services init ensures the pool is
 	// connected and migrations have run.
 	auth.SetCustomResolver(services.Role)
+	// Approved just-in-time elevations let RequireSection past the matrix.
+	middleware.SetElevationResolver(services.Elevations)
+	// Sensitive admin changes need a recent password (session policy).
+	middleware.SetStepUpChecker(services.Auth)
 	log.Println("Development Mode service initialized")
 
 	// Internal endpoints for cross-server dev mode session management
@@ -352,11 +495,13 @@ func main() {
 	// AFTER the access token has lapsed.
 	r.With(middleware.ContentTypeJSON).Post("/api/admin/auth/refresh", adminHandler.AdminRefreshToken)
 
+	// Admin responses are scanned for the PHI canaries (see PHIWatchdog).
 	r.Route("/api/admin", func(r chi.Router) {
 		r.Use(middleware.ContentTypeJSON)
+		r.Use(middleware.PHICanaryWatch(services.PHIWatchdog))
 		r.Mount("/", adminHandler.Routes())
 	})
-	r.Mount("/admin", adminHandler.UIRoutes())
+	r.With(middleware.PHICanaryWatch(services.PHIWatchdog)).Mount("/admin", adminHandler.UIRoutes())
 
 	// Public beta onboarding page (no auth — tokenized URL is the access control)
 	r.Get("/beta/onboard/{token}", adminHandler.BetaOnboardPage)
@@ -365,17 +510,31 @@ func main() {
 	// Public bounty/rewards criteria page (no auth — purely informational)
 	r.Get("/rewards", adminHandler.RewardsPage)
 
-	// File transfer utility (keep for development)
-	r.Get("/filextfer", handleFileTransfer)
-	r.Post("/filextfer/upload", handleUpload)
-	r.Post("/filextfer/save", handleSaveText)
-	r.Get("/filextfer/download/*", handleDownload)
-	r.Get("/filextfer/delete/*", handleDelete)
-	r.Get("/filextfer/view/*", handleView)
+	// Embeddable stats widget for the marketing site (no auth — bucketed
+	// aggregates only, cached server-side)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimit(120, 1*time.Minute))
+		r.Get("/widget/stats.json", adminHandler.PublicStatsJSON)
+		r.Get("/widget/stats.js", adminHandler.PublicStatsWidgetJS)
+		r.Get("/public/testimonials", adminHandler.PublicTestimonials)
+	})
 
-	// Static files
+	// File transfer: the admin page lives at /admin/filextfer; share links
+	// are public (no auth — the token, plus the optional password, is the
+	// access control), rate limited to blunt password guessing.
+	r.Get("/filextfer", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/admin/filextfer", http.StatusMovedPermanently)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimit(30, 1*time.Minute))
+		r.Get("/filextfer/s/{token}", adminHandler.FileTransferSharePage)
+		r.Post("/filextfer/s/{token}", adminHandler.FileTransferShareDownload)
+	})
+
+	// Static files. Protected prefixes (generated marketing files) need a
+	// signed, expiring URL from the admin API.
 	fileServer := http.FileServer(http.Dir("static"))
-	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
+	r.With(middleware.SignedStatic(services.AssetSigner)).Handle("/static/*", http.StripPrefix("/static/", fileServer))
 
 	// 404 handler
 	r.NotFound(middleware.NotFoundHandler())
@@ -389,6 +548,9 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Shutdown waits for active handlers; end the SSE streams so it
+	// doesn't wait on them.
+	server.RegisterOnShutdown(services.ChatHub.Close)
 
 	// Start server in goroutine
 	go func() {
@@ -399,10 +561,34 @@ func main() {
 		}
 	}()
 
-	// Start background services
+	// Internal gRPC API for service-to-service callers (analytics worker),
+	// on its own port with mTLS. Off unless GRPC_ADDR is set.
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled() {
+		grpcServer, err = rpc.NewServer(cfg.GRPC, services)
+		if err != nil {
+			log.Fatalf("gRPC server: %v", err)
+		}
+		lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			log.Fatalf("gRPC listen on %s: %v", cfg.GRPC.Addr, err)
+		}
+		go func() {
+			log.Printf("Starting internal gRPC server on %s", cfg.GRPC.Addr)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Printf("gRPC server error: %v", err)
+			}
+		}()
+	}
+
+	// Start background services. With more than one instance behind the
+	// ASG, every scheduled run goes through services.Jobs (Redis) so each
+	// tick runs on exactly one instance. Each runs under services.Drain so
+	// shutdown can wait for in-flight work.
 	schedulerCtx, schedulerCancel := context.WithCancel(context.Background())
-	reportScheduler := service.NewReportScheduler(services.Report)
-	go reportScheduler.Start(schedulerCtx)
+	drain := services.Drain
+	reportScheduler := service.NewReportScheduler(services.Report, services.Jobs)
+	drain.Go("report scheduler", func() { reportScheduler.Start(schedulerCtx) })
 
 	// Create AI insight service if Claude is configured. Phase 5 swapped the
 	// transport to AWS Bedrock — auth comes from the EC2 instance role's
@@ -419,6 +605,7 @@ func main() {
 		// (always strips free-text by default), and the gate itself short-
 		// circuits to false when AI_NARRATIVE_OPT_IN_AVAILABLE is unset.
 		aiInsightService.SetNarrativeConsent(services.AINarrativeConsent)
+		aiInsightService.SetAnnotations(services.Annotations)
 		log.Printf("Claude AI insights enabled (model=%s, via AWS Bedrock)", cfg.Claude.Model)
 	} else {
 		log.Println("Claude AI insights disabled (set CLAUDE_ENABLED=true to enable)")
@@ -431,14 +618,15 @@ func main() {
 	clinicalRuleScanner := service.NewClinicalRuleScanner(repos.Medication, repos.Correlation, repos.Child, repos.Insight, services.Alert, services.DrugDatabase)
 
 	insightGen := service.NewInsightGenerator(services.Alert, repos.Log, repos.Medication, repos.Alert, db.DB, aiInsightService, cfg.Claude.DailyRunHour, autoCorrScanner, perMetricScanner, clinicalRuleScanner)
-	go insightGen.Start(schedulerCtx)
+	insightGen.SetJobLocker(services.Jobs)
+	drain.Go("insight generator", func() { insightGen.Start(schedulerCtx) })
 
 	// Subscription expiry sweeper — transitions trialing→past_due and
 	// past_due→terminated. No-op when the subscription service couldn't
 	// initialize (e.g. plan rows missing).
 	if services.Subscription != nil {
-		subScheduler := service.NewSubscriptionScheduler(services.Subscription)
-		go subScheduler.Start(schedulerCtx)
+		subScheduler := service.NewSubscriptionScheduler(services.Subscription, services.Jobs)
+		drain.Go("subscription scheduler", func() { subScheduler.Start(schedulerCtx) })
 	}
 
 	// Daily revenue snapshot — aggregates yesterday's payments at 01:00 UTC
@@ -447,169 +635,156 @@ func main() {
 	// the Stripe webhooks, so it works whether or not Stripe is enabled
 	// (just produces zeros until payments start landing).
 	revSvc := service.NewRevenueSnapshotService(db.DB)
-	revScheduler := service.NewRevenueSnapshotScheduler(revSvc)
-	go revScheduler.Start(schedulerCtx)
+	revScheduler := service.NewRevenueSnapshotScheduler(revSvc, services.Jobs)
+	drain.Go("revenue snapshot scheduler", func() { revScheduler.Start(schedulerCtx) })
+
+	// Dispute evidence reminders — emails finance admins 7, 3 and 1 days
+	// before a Stripe dispute's evidence deadline until evidence is in.
+	disputeScheduler := service.NewDisputeReminderScheduler(services.Disputes, services.Jobs)
+	drain.Go("dispute reminder scheduler", func() { disputeScheduler.Start(schedulerCtx) })
+
+	// Admin metrics — refreshes the dashboard counts hourly and records
+	// the day's point for the trend charts.
+	metricsScheduler := service.NewMetricsRefreshScheduler(repos.Admin, services.Jobs)
+	drain.Go("metrics refresh scheduler", func() { metricsScheduler.Start(schedulerCtx) })
+
+	// Quarterly admin access reviews and the inactive-admin sweep (daily)
+	accessReviewScheduler := service.NewAccessReviewScheduler(services.AccessReviews, services.Jobs)
+	drain.Go("access review scheduler", func() { accessReviewScheduler.Start(schedulerCtx) })
+
+	// Metric anomalies — hourly, opens an admin notification when signups,
+	// revenue, server errors or response times deviate sharply.
+	anomalyScheduler := service.NewMetricAnomalyScheduler(services.MetricAnomalies, services.Jobs)
+	drain.Go("metric anomaly scheduler", func() { anomalyScheduler.Start(schedulerCtx) })
+
+	// Exit packages — builds and emails a cancelled family's data export
+	// once their access ends, and deletes it after the 90-day window.
+	exitPackageScheduler := service.NewExitPackageScheduler(services.ExitPackages, services.Jobs)
+	drain.Go("exit package scheduler", func() { exitPackageScheduler.Start(schedulerCtx) })
+
+	// File transfer expiry sweeper — deletes files (bytes and rows) once
+	// their per-file expiry passes, which also frees quota.
+	fileTransferSweeper := service.NewFileTransferSweeper(services.FileTransfer, services.Jobs)
+	drain.Go("file transfer sweeper", func() { fileTransferSweeper.Start(schedulerCtx) })
+
+	// Log drafts — hourly: deletes autosaved log forms past LOG_DRAFT_TTL.
+	logDraftSweeper := service.NewLogDraftSweeper(services.LogDrafts, services.Jobs)
+	drain.Go("log draft sweeper", func() { logDraftSweeper.Start(schedulerCtx) })
+
+	// Weekly digest — Sunday 18:00 UTC: each caregiver's week of logging,
+	// with streaks and badges unless they opted out.
+	weeklyDigestScheduler := service.NewWeeklyDigestScheduler(services.Gamification, services.Jobs)
+	drain.Go("weekly digest scheduler", func() { weeklyDigestScheduler.Start(schedulerCtx) })
+
+	// API usage — every 5 minutes: saves the per-client request counters
+	// from Redis to api_usage_hourly and prunes rows past 90 days.
+	apiUsageFlusher := service.NewAPIUsageFlusher(services.APIUsage, services.Jobs)
+	drain.Go("api usage flusher", func() { apiUsageFlusher.Start(schedulerCtx) })
+
+	// PHI watchdog — every instance: loads the PHI canary rows, rereading
+	// them every 10 minutes, for its admin query and response checks.
+	drain.Go("phi watchdog", func() { services.PHIWatchdog.Start(schedulerCtx) })
+
+	// Resumable upload GC — removes sessions (and their chunk blobs) that
+	// stopped receiving data, plus finished sessions once clients have had
+	// time to poll the result.
+	uploadSweeper := service.NewUploadSweeper(services.Upload, services.Jobs)
+	drain.Go("upload sweeper", func() { uploadSweeper.Start(schedulerCtx) })
+
+	// Backups — nightly config-table dump to S3, plus RDS snapshot status
+	// sync when BACKUP_RDS_INSTANCE_ID is set.
+	backupScheduler := service.NewBackupScheduler(services.Backup, services.Jobs)
+	drain.Go("backup scheduler", func() { backupScheduler.Start(schedulerCtx) })
+
+	// AWS cost history — daily Cost Explorer sync for the cost panel on
+	// the infrastructure page. No-op unless COST_EXPLORER_ENABLED is set.
+	costScheduler := service.NewCostScheduler(services.Cost, services.Jobs)
+	drain.Go("cost scheduler", func() { costScheduler.Start(schedulerCtx) })
+
+	// Log retention — hourly rollups of response_time_logs / error_logs
+	// for the dashboard, then pruning per the log_retention setting.
+	logRetentionScheduler := service.NewLogRetentionScheduler(services.LogRetention, services.Jobs)
+	drain.Go("log retention scheduler", func() { logRetentionScheduler.Start(schedulerCtx) })
+
+	// Slow queries — pg_stat_statements snapshots for the infrastructure
+	// page. Skips quietly (one log line) while the extension is missing.
+	if cfg.QueryStats.Enabled {
+		queryStatsScheduler := service.NewQueryStatsScheduler(services.QueryStats, services.Jobs, cfg.QueryStats.Interval)
+		drain.Go("query stats collector", func() { queryStatsScheduler.Start(schedulerCtx) })
+	}
+
+	// Analytics warehouse — nightly de-identified Parquet export of the
+	// previous day for research/marketing (WAREHOUSE_EXPORT_ENABLED).
+	warehouseScheduler := service.NewWarehouseExportScheduler(services.Warehouse, services.Jobs)
+	drain.Go("warehouse export scheduler", func() { warehouseScheduler.Start(schedulerCtx) })
+
+	// Product analytics — hourly refresh of the cached retention /
+	// DAU-WAU-MAU / adoption / activation snapshot.
+	analyticsScheduler := service.NewProductAnalyticsScheduler(services.Analytics, services.Jobs)
+	drain.Go("product analytics scheduler", func() { analyticsScheduler.Start(schedulerCtx) })
+
+	// Engagement — nightly per-family engagement scores behind the
+	// churn-risk list.
+	engagementScheduler := service.NewEngagementScheduler(services.Engagement, services.Jobs)
+	drain.Go("engagement scheduler", func() { engagementScheduler.Start(schedulerCtx) })
+
+	// Onboarding — hourly: record new users' completed setup steps and
+	// push one reminder per step to users who've stalled.
+	onboardingScheduler := service.NewOnboardingScheduler(services.Onboarding, services.Jobs)
+	drain.Go("onboarding scheduler", func() { onboardingScheduler.Start(schedulerCtx) })
+
+	// Devices — nightly: deactivate push devices not seen in 270 days and
+	// delete long-deactivated ones.
+	devicePruneScheduler := service.NewDevicePruneScheduler(services.Push, services.Jobs)
+	drain.Go("device prune scheduler", func() { devicePruneScheduler.Start(schedulerCtx) })
+
+	// Sleep — nightly: reconcile imported device sleep records against
+	// manual sleep logs for the last two weeks.
+	sleepSyncScheduler := service.NewSleepSyncScheduler(services.SleepSync, services.Jobs)
+	drain.Go("sleep sync scheduler", func() { sleepSyncScheduler.Start(schedulerCtx) })
+
+	// Domain events — relays event_outbox to Kinesis/Kafka (EVENTS_SINK).
+	// Runs without a sink too, to keep pruning the outbox.
+	eventRelayScheduler := service.NewEventRelayScheduler(services.Events, services.Jobs, cfg.Events.Interval)
+	drain.Go("event relay", func() { eventRelayScheduler.Start(schedulerCtx) })
+
+	// Background task workers — one pool per registered task type, fed
+	// from Redis Streams. Task types register on services.Tasks above.
+	if cfg.Tasks.WorkersEnabled {
+		drain.Go("task workers", func() { services.Tasks.Start(schedulerCtx) })
+	} else {
+		log.Println("[TASKS] Workers disabled on this instance (TASK_WORKERS_ENABLED=false)")
+	}
 
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	// Stop taking new work: schedulers finish their current run and exit,
+	// task workers stop reading, SSE streams are told to reconnect
+	// elsewhere, and the listener stops accepting while in-flight requests
+	// complete. Background work gets until the drain deadline; whatever is
+	// still running then is cancelled and checkpoints (tasks are requeued).
+	log.Printf("Shutting down server (drain timeout %s)...", cfg.App.DrainTimeout)
+	drain.Begin()
 	schedulerCancel()
-	log.Println("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.App.DrainTimeout)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server shutdown error: %v", err)
-	}
+	drained := make(chan []string, 1)
+	go func() { drained <- drain.Wait(cfg.App.DrainTimeout, 5*time.Second) }()
 
-	log.Println("Server stopped")
-}
-
-// File transfer utility handlers (kept for development convenience)
-func handleFileTransfer(w http.ResponseWriter, r *http.Request) {
-	files, _ := os.ReadDir(transferDir)
-
-	var fileList strings.Builder
-	for _, f := range files {
-		if !f.IsDir() {
-			info, _ := f.Info()
-			size := info.Size()
-			sizeStr := fmt.Sprintf("%d B", size)
-			if size > 1024 {
-				sizeStr = fmt.Sprintf("%.1f KB", float64(size)/1024)
-			}
-			fileList.WriteString(fmt.Sprintf(`
-				<tr>
-					<td style="padding:5px;border:1px solid #ccc;">%s</td>
-					<td style="padding:5px;border:1px solid #ccc;">%s</td>
-					<td style="padding:5px;border:1px solid #ccc;">
-						<a href="/filextfer/download/%s">Download</a> |
-						<a href="/filextfer/view/%s">View</a> |
-						<a href="/filextfer/delete/%s" onclick="return confirm('Delete?')">Delete</a>
-					</td>
-				</tr>`, f.Name(), sizeStr, f.Name(), f.Name(), f.Name()))
-		}
-	}
-
-	w.Header().Set("Content-Type", "text/html")
-	fmt.Fprintf(w, `<!DOCTYPE html>
-<html>
-<head><title>File Transfer</title></head>
-<body style="font-family:monospace;max-width:900px;margin:20px auto;padding:0 20px;">
-<h2>File Transfer Utility</h2>
-
-<h3>Upload File</h3>
-<form action="/filextfer/upload" method="post" enctype="multipart/form-data">
-	<input type="file" name="file" required>
-	<button type="submit">Upload</button>
-</form>
-
-<h3>Save Text as File</h3>
-<form action="/filextfer/save" method="post">
-	<input type="text" name="filename" placeholder="filename.md" required style="width:200px;">
-	<br><br>
-	<textarea name="content" rows="10" style="width:100%%;font-family:monospace;" placeholder="Paste content here..."></textarea>
-	<br>
-	<button type="submit">Save</button>
-</form>
-
-<h3>Files (%d)</h3>
-<table style="border-collapse:collapse;width:100%%;">
-	<tr style="background:#eee;">
-		<th style="padding:5px;border:1px solid #ccc;text-align:left;">Name</th>
-		<th style="padding:5px;border:1px solid #ccc;text-align:left;">Size</th>
-		<th style="padding:5px;border:1px solid #ccc;text-align:left;">Actions</th>
-	</tr>
-	%s
-</table>
-
-<p><a href="/">Back to Home</a></p>
-</body>
-</html>`, len(files), fileList.String())
-}
-
-func handleUpload(w http.ResponseWriter, r *http.Request) {
-	r.ParseMultipartForm(10 << 20)
-	file, handler, err := r.FormFile("file")
-	if err != nil {
-		http.Error(w, "Error reading file", http.StatusBadRequest)
-		return
-	}
-	defer file.Close()
-
-	filename := filepath.Base(handler.Filename)
-	dst, err := os.Create(filepath.Join(transferDir, filename))
-	if err != nil {
-		http.Error(w, "Error saving file", http.StatusInternalServerError)
-		return
-	}
-	defer dst.Close()
-
-	io.Copy(dst, file)
-	http.Redirect(w, r, "/filextfer", http.StatusSeeOther)
-}
-
-func handleSaveText(w http.ResponseWriter, r *http.Request) {
-	filename := filepath.Base(r.FormValue("filename"))
-	content := r.FormValue("content")
-
-	if filename == "" {
-		http.Error(w, "Filename required", http.StatusBadRequest)
-		return
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
 	}
-
-	err := os.WriteFile(filepath.Join(transferDir, filename), []byte(content), 0644)
-	if err != nil {
-		http.Error(w, "Error saving file", http.StatusInternalServerError)
-		return
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
 	}
-
-	http.Redirect(w, r, "/filextfer", http.StatusSeeOther)
-}
-
-func handleDownload(w http.ResponseWriter, r *http.Request) {
-	filename := filepath.Base(strings.TrimPrefix(r.URL.Path, "/filextfer/download/"))
-	fp := filepath.Join(transferDir, filename)
-
-	if _, err := os.Stat(fp); os.IsNotExist(err) {
-		http.Error(w, "File not found", http.StatusNotFound)
-		return
+	if left := <-drained; len(left) > 0 {
+		log.Printf("Background work still running at exit: %v", left)
 	}
 
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	http.ServeFile(w, r, fp)
-}
-
-func handleView(w http.ResponseWriter, r *http.Request) {
-	filename := filepath.Base(strings.TrimPrefix(r.URL.Path, "/filextfer/view/"))
-	fp := filepath.Join(transferDir, filename)
-
-	content, err := os.ReadFile(fp)
-	if err != nil {
-		http.Error(w, "File not found", http.StatusNotFound)
-		return
-	}
-
-	w.Header().Set("Content-Type", "text/html")
-	fmt.Fprintf(w, `<!DOCTYPE html>
-<html>
-<head><title>%s</title></head>
-<body style="font-family:monospace;max-width:900px;margin:20px auto;padding:0 20px;">
-<h2>%s</h2>
-<p><a href="/filextfer">Back</a> | <a href="/filextfer/download/%s">Download</a></p>
-<hr>
-<pre style="background:#f5f5f5;padding:15px;overflow-x:auto;white-space:pre-wrap;">%s</pre>
-</body>
-</html>`, filename, filename, filename, strings.ReplaceAll(string(content), "<", "&lt;"))
-}
-
-func handleDelete(w http.ResponseWriter, r *http.Request) {
-	filename := filepath.Base(strings.TrimPrefix(r.URL.Path, "/filextfer/delete/"))
-	fp := filepath.Join(transferDir, filename)
-
-	os.Remove(fp)
-	http.Redirect(w, r, "/filextfer", http.StatusSeeOther)
+	log.Println("Server stopped")
 }
@@ -0,0 +1,129 @@
+// Package apperr is the domain error vocabulary shared by repositories,
+// services and handlers. A service error belongs to one of a few kinds
+// (ErrNotFound, ErrConflict, ErrForbidden, ErrValidation, ErrUnavailable),
+// matched with errors.Is, and its message is safe to show to the user.
+// Handlers map kinds to HTTP statuses in one place instead of switching
+// over every service's sentinels.
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// The kinds. Wrap them with New or the helpers below rather than returning
+// them bare, so the message says what wasn't found or what conflicted.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrForbidden   = errors.New("forbidden")
+	ErrValidation  = errors.New("invalid request")
+	ErrUnavailable = errors.New("temporarily unavailable")
+)
+
+// Error is a domain error: a user-facing message of one kind, optionally
+// wrapping the underlying cause.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches the error's kind, so errors.Is(err, ErrNotFound) holds for
+// every not-found error while each sentinel stays distinct.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns a domain error of kind with a user-facing message. Declared
+// at package level it serves as a sentinel:
+//
+//	var ErrChildNotFound = apperr.NotFound("child not found")
+func New(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap is New for an error with a cause. The cause stays reachable with
+// errors.Is and errors.As but is never shown to the user.
+func Wrap(kind error, message string, err error) error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func NotFound(message string) error    { return New(ErrNotFound, message) }
+func Conflict(message string) error    { return New(ErrConflict, message) }
+func Forbidden(message string) error   { return New(ErrForbidden, message) }
+func Validation(message string) error  { return New(ErrValidation, message) }
+func Unavailable(message string) error { return New(ErrUnavailable, message) }
+
+var kinds = []error{ErrNotFound, ErrConflict, ErrForbidden, ErrValidation, ErrUnavailable}
+
+// KindOf classifies err. Domain errors carry their kind; raw repository
+// errors are classified by what they say about the request: no rows is
+// not found, a unique or exclusion violation a conflict, a foreign key,
+// check, not-null or bad-value error invalid input. It returns nil for
+// anything else, which callers treat as an internal error.
+func KindOf(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k) {
+			return k
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505", pgErr.Code == "23P01", pgErr.Code == "40001":
+			return ErrConflict
+		case pgErr.Code == "23503", pgErr.Code == "23514", pgErr.Code == "23502",
+			strings.HasPrefix(pgErr.Code, "22"):
+			return ErrValidation
+		case pgErr.Code == "57014":
+			// Statement timeout.
+			return ErrUnavailable
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// Message is the text to show the user for err: its own message when it's
+// a domain error, otherwise a generic one for its kind. Raw database and
+// driver errors never reach the user.
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Message != "" {
+		return e.Message
+	}
+	switch KindOf(err) {
+	case ErrNotFound:
+		return "The requested resource was not found"
+	case ErrConflict:
+		return "The request conflicts with existing data"
+	case ErrValidation:
+		return "The request contains invalid data"
+	case ErrForbidden:
+		return "You don't have access to this resource"
+	case ErrUnavailable:
+		return "The service is temporarily unavailable. Please try again shortly."
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package apperr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestKindOf(t *testing.T) {
+	errChildNotFound := NotFound("child not found")
+	errThreadNotFound := NotFound("thread not found")
+	if !errors.Is(errChildNotFound, ErrNotFound) || errors.Is(errChildNotFound, errThreadNotFound) {
+		t.Error("sentinels should share their kind but stay distinct")
+	}
+	cause := &pgconn.PgError{Code: "23505", Message: `duplicate key value violates unique constraint "users_email_key"`}
+	wrapped := Wrap(ErrConflict, "email already registered", cause)
+	var pgErr *pgconn.PgError
+	if !errors.As(wrapped, &pgErr) || Message(wrapped) != "email already registered" {
+		t.Errorf("Wrap lost the cause or message: %v", wrapped)
+	}
+
+	for _, tc := range []struct {
+		err  error
+		want error
+	}{
+		{nil, nil},
+		{errors.New("boom"), nil},
+		{fmt.Errorf("get child: %w", errChildNotFound), ErrNotFound},
+		{Forbidden("not a member of this family"), ErrForbidden},
+		{fmt.Errorf("scan: %w", sql.ErrNoRows), ErrNotFound},
+		{cause, ErrConflict},
+		{&pgconn.PgError{Code: "23503"}, ErrValidation},
+		{&pgconn.PgError{Code: "22P02"}, ErrValidation},
+		{&pgconn.PgError{Code: "42P01"}, nil},
+	} {
+		if got := KindOf(tc.err); got != tc.want {
+			t.Errorf("KindOf(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+	if msg := Message(cause); msg != "The request conflicts with existing data" {
+		t.Errorf("raw driver error shown as %q", msg)
+	}
+}
@@ -41,10 +41,11 @@ func RankAtLeast(have, need Level) bool { return levelRank(have) >= levelRank(ne
 var Sections = []string{
 	"dashboard", "tickets", "users", "families",
 	"metrics_dashboard", "copy_materials", "beta_program", "bounty_program",
-	"promo_codes", "infrastructure_status", "error_logs", "development_mode",
-	"product_roadmap", "financials", "subscriptions",
+	"promo_codes", "campaigns", "infrastructure_status", "error_logs",
+	"development_mode", "product_roadmap", "financials", "subscriptions",
 	"admin_users", "system_settings", "audit_log", "version_log",
-	"live_sessions", "pro_qa",
+	"live_sessions", "pro_qa", "knowledge_base", "file_transfer",
+	"upload_scans", "legal", "research_consents",
 }
 
 // SectionLabels gives a human-readable name for each section, used by the
@@ -59,6 +60,7 @@ var SectionLabels = map[string]string{
 	"beta_program":          "Beta Program",
 	"bounty_program":        "Bounty Program",
 	"promo_codes":           "Promo Codes",
+	"campaigns":             "Campaigns",
 	"infrastructure_status": "Infrastructure Status",
 	"error_logs":            "Error Logs",
 	"development_mode":      "Development Mode",
@@ -71,6 +73,11 @@ var SectionLabels = map[string]string{
 	"version_log":           "Version Log",
 	"live_sessions":         "Live Sessions",
 	"pro_qa":                "Pro QA Workspace",
+	"knowledge_base":        "Help Center",
+	"file_transfer":         "File Transfer",
+	"upload_scans":          "Upload Scans",
+	"legal":                 "Legal Documents",
+	"research_consents":     "Research Consents",
 }
 
 // PermResolver is consulted by Matrix() when it sees a role name that
@@ -139,6 +146,11 @@ var matrix = map[string]map[models.SystemRole]Level{
 		models.SystemRoleMarketing:  LevelRead,
 		models.SystemRolePartner:    LevelRead,
 	},
+	"campaigns": {
+		models.SystemRoleSuperAdmin: LevelFull,
+		models.SystemRoleMarketing:  LevelFull,
+		models.SystemRolePartner:    LevelRead,
+	},
 	"infrastructure_status": {
 		models.SystemRoleSuperAdmin: LevelFull,
 		models.SystemRolePartner:    LevelRead,
@@ -166,11 +178,15 @@ var matrix = map[string]map[models.SystemRole]Level{
 		models.SystemRoleSuperAdmin: LevelFull,
 		models.SystemRolePartner:    LevelRead,
 	},
+	// Auditors review settings history and the audit log (and export
+	// them) for compliance.
 	"system_settings": {
 		models.SystemRoleSuperAdmin: LevelFull,
+		models.SystemRoleAuditor:    LevelRead,
 	},
 	"audit_log": {
 		models.SystemRoleSuperAdmin: LevelFull,
+		models.SystemRoleAuditor:    LevelRead,
 	},
 	"version_log": {
 		models.SystemRoleSuperAdmin: LevelFull,
@@ -181,18 +197,60 @@ var matrix = map[string]map[models.SystemRole]Level{
 		models.SystemRoleSupport:    LevelFull,
 		models.SystemRolePartner:    LevelFull,
 	},
+	// Support writes most articles; marketing can draft and edit copy but
+	// not delete.
+	"knowledge_base": {
+		models.SystemRoleSuperAdmin: LevelFull,
+		models.SystemRoleSupport:    LevelFull,
+		models.SystemRoleMarketing:  LevelWrite,
+		models.SystemRolePartner:    LevelFull,
+	},
+	"file_transfer": {
+		models.SystemRoleSuperAdmin: LevelFull,
+		models.SystemRolePartner:    LevelFull,
+	},
+	// Support sees why a customer's attachment was refused; releasing or
+	// purging quarantined files stays with partners.
+	"upload_scans": {
+		models.SystemRoleSuperAdmin: LevelFull,
+		models.SystemRoleSupport:    LevelRead,
+		models.SystemRolePartner:    LevelFull,
+	},
+	// Support can look up whether a customer accepted the current terms;
+	// drafting and publishing new versions stays with partners.
+	"legal": {
+		models.SystemRoleSuperAdmin: LevelFull,
+		models.SystemRoleSupport:    LevelRead,
+		models.SystemRolePartner:    LevelFull,
+	},
+	// Signed research consents identify the signer (name, IP address,
+	// user agent); only super admins read them.
+	"research_consents": {
+		models.SystemRoleSuperAdmin: LevelFull,
+	},
+}
+
+// ReadOnly reports whether role may never write, whatever the matrix
+// says. Matrix caps these roles at LevelRead and the admin API rejects
+// their non-read requests outright (middleware.RejectReadOnlyWrites).
+func ReadOnly(role models.SystemRole) bool {
+	return role == models.SystemRoleAuditor
 }
 
 // Matrix returns the access level for (role, section). Super admin is always
 // LevelFull regardless of the table. Built-in roles consult the hardcoded
 // matrix below. Unknown role names fall through to the custom-role resolver
-// (if wired). Unknown section returns LevelNone — fail closed.
+// (if wired). Unknown section returns LevelNone — fail closed. ReadOnly
+// roles never get more than LevelRead.
 func Matrix(role models.SystemRole, section string) Level {
 	if role == models.SystemRoleSuperAdmin {
 		return LevelFull
 	}
 	if row, ok := matrix[section]; ok {
 		if lvl, ok := row[role]; ok {
+			if ReadOnly(role) && !RankAtLeast(LevelRead, lvl) {
+				return LevelRead
+			}
 			return lvl
 		}
 	}
@@ -29,6 +29,7 @@ func TestMatrix_PartnerLockedRows(t *testing.T) {
 		"beta_program":          LevelFull,
 		"bounty_program":        LevelRead,
 		"promo_codes":           LevelRead,
+		"campaigns":             LevelRead,
 		"infrastructure_status": LevelRead,
 		"error_logs":            LevelRead,
 		"development_mode":      LevelNone,
@@ -40,6 +41,11 @@ func TestMatrix_PartnerLockedRows(t *testing.T) {
 		"audit_log":             LevelNone,
 		"version_log":           LevelRead,
 		"live_sessions":         LevelFull,
+		"knowledge_base":        LevelFull,
+		"file_transfer":         LevelFull,
+		"upload_scans":          LevelFull,
+		"legal":                 LevelFull,
+		"research_consents":     LevelNone,
 	}
 	for sec, want := range cases {
 		if got := Matrix(models.SystemRolePartner, sec); got != want {
@@ -84,3 +90,25 @@ func TestAllows(t *testing.T) {
 		t.Error("partner must NOT GET on development_mode")
 	}
 }
+
+func TestMatrix_AuditorReadOnly(t *testing.T) {
+	for _, sec := range Sections {
+		want := LevelNone
+		if sec == "audit_log" || sec == "system_settings" {
+			want = LevelRead
+		}
+		if got := Matrix(models.SystemRoleAuditor, sec); got != want {
+			t.Errorf("auditor on %s = %q, want %q", sec, got, want)
+		}
+	}
+	if !ReadOnly(models.SystemRoleAuditor) || ReadOnly(models.SystemRolePartner) {
+		t.Error("only the auditor is read-only")
+	}
+
+	// A matrix edit granting more stays capped at read.
+	matrix["audit_log"][models.SystemRoleAuditor] = LevelFull
+	defer func() { matrix["audit_log"][models.SystemRoleAuditor] = LevelRead }()
+	if Allows(models.SystemRoleAuditor, "audit_log", http.MethodPost) {
+		t.Error("auditor allowed to write audit_log")
+	}
+}
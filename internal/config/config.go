@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -17,9 +18,25 @@ type Config struct {
 	Storage          StorageConfig
 	SMTP             SMTPConfig
 	FCM              FCMConfig
+	APNs             APNsConfig
 	Claude           ClaudeConfig
 	AppStoreConnect  AppStoreConnectConfig
 	Stripe           StripeConfig
+	Tax              TaxConfig
+	Subscription     SubscriptionConfig
+	Backup           BackupConfig
+	Cost             CostConfig
+	LogSearch        LogSearchConfig
+	Tasks            TaskQueueConfig
+	QueryStats       QueryStatsConfig
+	Security         SecurityConfig
+	GRPC             GRPCConfig
+	Events           EventsConfig
+	Warehouse        WarehouseExportConfig
+	FoodVision       FoodVisionConfig
+	LogDrafts        LogDraftsConfig
+	Abuse            AbuseConfig
+	Captcha          CaptchaConfig
 }
 
 // StripeConfig holds the test/live API keys + webhook signing secret.
@@ -32,6 +49,10 @@ type StripeConfig struct {
 	SecretKey      string // sk_test_... or sk_live_... (server-side; never exposed to client)
 	PublishableKey string // pk_test_... or pk_live_... (safe to embed in HTML)
 	WebhookSecret  string // whsec_... — verifies signatures on POST /webhooks/stripe
+	// AutomaticTax turns on Stripe Tax for Checkout and subscriptions: the
+	// customer's address is collected and tax added on top of plan prices.
+	// Requires Stripe Tax to be activated on the account.
+	AutomaticTax bool
 }
 
 // Enabled returns true when the Stripe SDK can be invoked. SecretKey alone
@@ -41,6 +62,23 @@ func (s StripeConfig) Enabled() bool {
 	return s.SecretKey != ""
 }
 
+// TaxConfig picks how prices are quoted with tax before checkout.
+// Provider is "table" (the tax_rates table), "stripe" (Stripe Tax
+// calculations; needs Stripe) or "none". Collection itself happens in
+// Stripe either way.
+type TaxConfig struct {
+	Provider string
+}
+
+// SubscriptionConfig tunes what happens when a family's subscription
+// lapses. For ReadOnlyGraceDays after a failed payment, an ended trial or
+// the end of a cancelled period the family keeps reading, exporting and
+// deleting its data but can't add to it; after that the subscription is
+// terminated. Paying at any point restores full access.
+type SubscriptionConfig struct {
+	ReadOnlyGraceDays int
+}
+
 // AppStoreConnectConfig holds the team-level API key Apple issues from
 // App Store Connect → Users and Access → Integrations → Team Keys.
 // All four fields must be set for the beta-invite auto-add flow to work;
@@ -64,6 +102,219 @@ type StorageConfig struct {
 	S3Region       string
 	S3Prefix       string // ticket attachments
 	ReportS3Prefix string // reports
+	// Admin file transfer (/filextfer). Files past their expiry are swept;
+	// uploads that would push the stored total past TransferQuotaBytes are
+	// refused.
+	TransferS3Prefix     string
+	TransferMaxFileBytes int64
+	TransferQuotaBytes   int64
+	TransferDefaultTTL   time.Duration
+	TransferMaxTTL       time.Duration
+	// Resumable uploads: clients PATCH chunks of at most UploadChunkBytes;
+	// sessions idle for UploadAbandonAfter are garbage collected.
+	UploadS3Prefix     string
+	UploadChunkBytes   int64
+	UploadMaxBytes     int64
+	UploadAbandonAfter time.Duration
+	// Antivirus: uploads are streamed to the clamd at ClamAVAddr (host:port
+	// or unix socket path; empty disables scanning). Files over
+	// ScanMaxBytes are recorded as skipped — keep it at or below clamd's
+	// StreamMaxLength. Infected files are copied under QuarantineS3Prefix.
+	ClamAVAddr         string
+	ClamAVTimeout      time.Duration
+	ScanMaxBytes       int64
+	ScanFailClosed     bool
+	QuarantineS3Prefix string
+	// Image pipeline (child photos, headshots, marketing images): uploads
+	// up to ImageMaxBytes become responsive variants under ImageS3Prefix.
+	// WebP/AVIF need the cwebp/avifenc binaries; "off" disables a format.
+	ImageS3Prefix string
+	ImageMaxBytes int64
+	ImageCWebP    string
+	ImageAVIFEnc  string
+}
+
+// BackupConfig drives the backup module. RDSInstanceID empty disables
+// snapshot triggering and sync; config dumps only need the storage bucket.
+// The Drill* settings place the scratch instance cmd/restore-drill restores
+// into — it must land in a subnet group and security group the drill host
+// can reach.
+type BackupConfig struct {
+	RDSInstanceID string
+	RDSRegion     string
+	// Nightly logical dumps of non-PHI config tables. DumpTables can only
+	// narrow the built-in allowlist, never extend it.
+	DumpS3Prefix string
+	DumpTables   []string
+	DumpHourUTC  int
+
+	DrillInstanceClass    string
+	DrillSubnetGroup      string
+	DrillSecurityGroupIDs []string
+	DrillTimeout          time.Duration
+}
+
+// CostConfig drives the AWS cost panel. Cost Explorer bills per request,
+// so it is opt-in and synced once a day; MonthlyBudgetUSD of zero turns
+// the budget alerts off.
+type CostConfig struct {
+	ExplorerEnabled  bool
+	MonthlyBudgetUSD float64
+	// AlertThresholds are percentages of the budget that raise an alert
+	// on the infrastructure page once month-to-date spend crosses them.
+	AlertThresholds []int
+	SyncHourUTC     int
+	BackfillDays    int
+}
+
+// LogSearchConfig points the admin log search at the CloudWatch Logs group
+// the app container ships stdout to. LogGroup empty disables the search.
+type LogSearchConfig struct {
+	LogGroup string
+	Region   string
+}
+
+// TaskQueueConfig controls the background task workers. Every instance
+// can enqueue; WorkersEnabled=false keeps an instance from consuming, e.g.
+// to keep slow jobs off a web-only host.
+type TaskQueueConfig struct {
+	WorkersEnabled bool
+}
+
+// QueryStatsConfig drives the pg_stat_statements collector behind the
+// slow query panel. Each run snapshots the TopN statements by total time;
+// snapshots older than RetentionDays are pruned.
+type QueryStatsConfig struct {
+	Enabled       bool
+	Interval      time.Duration
+	TopN          int
+	RetentionDays int
+}
+
+// EventsConfig drives the domain event relay, which publishes the
+// event_outbox table to a stream for analytics and the warehouse. Sink is
+// "kinesis", "kafka-rest" or "off" (the default); with it off the outbox is
+// still pruned. Retention is how long published rows are kept; pending
+// rows older than PendingMaxAge are dropped.
+type EventsConfig struct {
+	Sink          string
+	KinesisStream string
+	KinesisRegion string
+	KafkaRESTURL  string
+	KafkaTopic    string
+	Interval      time.Duration
+	BatchSize     int
+	Retention     time.Duration
+	PendingMaxAge time.Duration
+}
+
+// WarehouseExportConfig drives the nightly analytics export: de-identified
+// aggregates written as Parquet under S3Prefix (in S3Bucket, or the
+// storage bucket when empty) at HourUTC for the previous UTC day.
+// Aggregate cells covering fewer than MinCellSize children or families
+// are suppressed. See docs/ANALYTICS-EXPORT.md.
+type WarehouseExportConfig struct {
+	Enabled      bool
+	S3Bucket     string
+	S3Prefix     string
+	HourUTC      int
+	MinCellSize  int
+	CohortMonths int
+}
+
+// FoodVisionConfig picks the vision provider that suggests foods from meal
+// photos for diet logs. Provider is "bedrock" (Claude on AWS Bedrock, under
+// the same BAA as AI insights) or "none", which turns the feature off.
+// Photos larger than MaxImageBytes are rejected.
+type FoodVisionConfig struct {
+	Provider      string
+	Model         string
+	MaxImageBytes int
+}
+
+// LogDraftsConfig bounds autosaved log form drafts: each expires TTL after
+// its last save, and a draft's data can be at most MaxBytes.
+type LogDraftsConfig struct {
+	TTL      time.Duration
+	MaxBytes int
+}
+
+// AbuseConfig tunes abuse and scraping detection (service.AbuseService).
+// Per client IP: more than VelocityPerMinute requests in a minute, 403s
+// and 404s on more than EnumerationIDs distinct IDs in ten minutes, or
+// more than ProbePaths 404s on paths no route serves in ten minutes block
+// the IP for BlockDuration, doubling for each block it had in the last 30
+// days. Allowlist IPs and CIDRs are never blocked.
+type AbuseConfig struct {
+	Enabled           bool
+	VelocityPerMinute int
+	EnumerationIDs    int
+	ProbePaths        int
+	BlockDuration     time.Duration
+	Allowlist         []string
+}
+
+// CaptchaConfig is the CAPTCHA provider (service.CaptchaService):
+// "hcaptcha", "turnstile" or "off". Which endpoints ask for one is the
+// "captcha" system setting, so each environment switches it on or off
+// without a deploy; nothing is asked for while Provider is off or
+// SecretKey is empty.
+type CaptchaConfig struct {
+	Provider  string
+	SiteKey   string
+	SecretKey string
+	Timeout   time.Duration
+}
+
+// GRPCConfig is the internal gRPC listener (internal/rpc) for
+// service-to-service callers such as the analytics worker. It is off
+// unless Addr is set, and only accepts clients presenting a certificate
+// signed by ClientCAFile. AllowedClients, when set, further limits callers
+// to those certificate common names.
+type GRPCConfig struct {
+	Addr           string
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string
+	AllowedClients []string
+}
+
+// Enabled reports whether the gRPC listener should start.
+func (g GRPCConfig) Enabled() bool {
+	return g.Addr != ""
+}
+
+// SecurityConfig is the Content-Security-Policy sent with every response.
+// "{nonce}" in CSP is replaced per request with the nonce the templates
+// put on their inline scripts; CSP "off" sends no policy. With
+// CSPReportOnly the policy goes out as Content-Security-Policy-Report-Only,
+// so violations are reported to /csp-report but nothing is blocked. HSTS
+// and frame options are admin settings, not config.
+//
+// SignedStaticPrefixes are the /static paths served only with a signed,
+// expiring URL (service.AssetSigner).
+type SecurityConfig struct {
+	CSP                  string
+	CSPReportOnly        bool
+	SignedStaticPrefixes []string
+}
+
+// DefaultCSP is the policy for env. It allows the hCaptcha and Turnstile
+// widgets; development also allows websocket connections for live
+// reload.
+func DefaultCSP(env string) string {
+	connect := "'self'"
+	if env != "production" {
+		connect += " ws: wss:"
+	}
+	return "default-src 'self'; " +
+		"script-src 'self' 'nonce-{nonce}' https://cdn.tailwindcss.com https://unpkg.com https://js.hcaptcha.com https://challenges.cloudflare.com; " +
+		"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com https://hcaptcha.com https://*.hcaptcha.com; " +
+		"frame-src https://hcaptcha.com https://*.hcaptcha.com https://challenges.cloudflare.com; " +
+		"font-src 'self' data: https://fonts.gstatic.com; " +
+		"img-src 'self' data: blob: https:; " +
+		"connect-src " + connect + " https://hcaptcha.com https://*.hcaptcha.com; " +
+		"frame-ancestors 'self'; object-src 'none'; base-uri 'self'; form-action 'self'"
 }
 
 type AppConfig struct {
@@ -72,6 +323,10 @@ type AppConfig struct {
 	Port  string
 	Host  string
 	URL   string
+	// DrainTimeout is how long shutdown waits for in-flight requests and
+	// background work before cancelling them. Keep it under the ASG
+	// lifecycle hook / systemd stop timeout.
+	DrainTimeout time.Duration
 }
 
 type DatabaseConfig struct {
@@ -106,6 +361,53 @@ type DatabaseConfig struct {
 	// admin EC2 (via private-IP SG ingress). When unset, replication is
 	// disabled and admin CRUD is local-only.
 	AdminMirrorDSN string
+
+	// QueryTags prefixes every repository statement with a
+	// /* method=... */ comment so pg_stat_statements, the slow
+	// query log and Performance Insights show where it came from.
+	// QueryTagRequestID adds the request id; it makes statement text unique
+	// per request, which defeats pgx's prepared statement cache, so it is
+	// off by default.
+	QueryTags         bool
+	QueryTagRequestID bool
+	// FamilyRLS runs family-scoped API requests under the row-level
+	// security policies on children and their tables (migration 00106).
+	// Off by default: every scoped statement costs a connection checkout,
+	// a set_config and a reset, which hasn't been measured under load yet.
+	FamilyRLS bool
+
+	// Credential rotation. The main pool picks its password per new
+	// connection, so none of these need a restart to take effect:
+	//
+	// PasswordFile, when set, is reread for every connection and wins over
+	// Password; point it at the file the secret rotation writes.
+	// PasswordPrevious is tried when the current password is refused, so a
+	// rotation can change the role's password and the app's copy in either
+	// order. Drop it once the rotation has settled.
+	// IAMAuth connects with short-lived RDS IAM auth tokens (rds-db:connect
+	// for User) instead of a password; IAMRegion is the instance's region.
+	PasswordFile     string
+	PasswordPrevious string
+	IAMAuth          bool
+	IAMRegion        string
+
+	// StartupWait is how long the server retries the first connection
+	// before giving up. BreakerFailures consecutive connection failures
+	// open the pool's circuit breaker for BreakerCooldown, failing calls
+	// fast with database.ErrUnavailable.
+	StartupWait     time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+
+	// StatementTimeout caps each statement run for an HTTP request; zero
+	// leaves them to the request's own deadline. MethodTimeouts override it
+	// per repository method ("childRepo.GetDashboard=2s") or repository
+	// ("reportRepo.*=60s"), and bound background jobs too.
+	// OperationTimeouts are budgets for service operations made of several
+	// statements ("dashboard=8s"). See repository.TimeoutPolicy.
+	StatementTimeout  time.Duration
+	MethodTimeouts    map[string]time.Duration
+	OperationTimeouts map[string]time.Duration
 }
 
 type RedisConfig struct {
@@ -113,6 +415,11 @@ type RedisConfig struct {
 	Port     string
 	Password string
 	DB       int
+
+	// As for DatabaseConfig.
+	StartupWait     time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
 }
 
 type JWTConfig struct {
@@ -142,6 +449,17 @@ type FCMConfig struct {
 	ServiceAccountKeyFile  string
 }
 
+// APNsConfig configures direct APNs delivery for iOS devices that register
+// raw APNs tokens instead of FCM tokens. Token-based (.p8) auth; disabled
+// unless KeyFile, KeyID, TeamID and BundleID are all set.
+type APNsConfig struct {
+	KeyFile    string
+	KeyID      string
+	TeamID     string
+	BundleID   string
+	Production bool // api.push.apple.com vs the sandbox gateway
+}
+
 type ClaudeConfig struct {
 	APIKey         string
 	Model          string
@@ -170,6 +488,8 @@ func Load() (*Config, error) {
 			Port:  getEnv("APP_PORT", "8080"),
 			Host:  getEnv("APP_HOST", "0.0.0.0"),
 			URL:   getEnv("APP_URL", "http://localhost:8080"),
+
+			DrainTimeout: getEnvDuration("SHUTDOWN_DRAIN_TIMEOUT", 30*time.Second),
 		},
 		Database: DatabaseConfig{
 			Host:            getEnv("DB_HOST", "172.28.0.10"),
@@ -184,12 +504,33 @@ func Load() (*Config, error) {
 			SupportDSN:      getEnv("SUPPORT_DB_DSN", ""),
 			SessionsProdDSN: getEnv("SESSIONS_PROD_DB_DSN", ""),
 			AdminMirrorDSN:  getEnv("ADMIN_MIRROR_DB_DSN", ""),
+
+			QueryTags:         getEnvBool("DB_QUERY_TAGS", true),
+			QueryTagRequestID: getEnvBool("DB_QUERY_TAG_REQUEST_ID", false),
+			FamilyRLS:         getEnvBool("DB_FAMILY_RLS", false),
+
+			PasswordFile:     getEnv("DB_PASSWORD_FILE", ""),
+			PasswordPrevious: getEnv("DB_PASSWORD_PREVIOUS", ""),
+			IAMAuth:          getEnvBool("DB_IAM_AUTH", false),
+			IAMRegion:        getEnv("DB_IAM_REGION", getEnv("AWS_REGION", "us-east-1")),
+
+			StartupWait:     getEnvDuration("DB_STARTUP_WAIT", time.Minute),
+			BreakerFailures: getEnvInt("DB_BREAKER_FAILURES", 5),
+			BreakerCooldown: getEnvDuration("DB_BREAKER_COOLDOWN", 10*time.Second),
+
+			StatementTimeout:  getEnvDuration("DB_STATEMENT_TIMEOUT", 15*time.Second),
+			MethodTimeouts:    getEnvDurations("DB_METHOD_TIMEOUTS", "reportRepo.*=60s"),
+			OperationTimeouts: getEnvDurations("DB_OPERATION_TIMEOUTS", "dashboard=8s"),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "172.28.0.30"),
 			Port:     getEnv("REDIS_PORT", "6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvInt("REDIS_DB", 0),
+
+			StartupWait:     getEnvDuration("REDIS_STARTUP_WAIT", time.Minute),
+			BreakerFailures: getEnvInt("REDIS_BREAKER_FAILURES", 5),
+			BreakerCooldown: getEnvDuration("REDIS_BREAKER_COOLDOWN", 10*time.Second),
 		},
 		JWT: JWTConfig{
 			Secret: getEnv("JWT_SECRET", ""),
@@ -214,11 +555,36 @@ func Load() (*Config, error) {
 			S3Region:            getEnv("ATTACHMENT_S3_REGION", "us-east-1"),
 			S3Prefix:            getEnv("ATTACHMENT_S3_PREFIX", "ticket-attachments/"),
 			ReportS3Prefix:      getEnv("REPORT_S3_PREFIX", "reports/"),
+			TransferS3Prefix:     getEnv("FILE_TRANSFER_S3_PREFIX", "file-transfers/"),
+			TransferMaxFileBytes: int64(getEnvInt("FILE_TRANSFER_MAX_FILE_BYTES", 5*1024*1024*1024)), // 5GB per file
+			TransferQuotaBytes:   int64(getEnvInt("FILE_TRANSFER_QUOTA_BYTES", 20*1024*1024*1024)),   // 20GB total
+			TransferDefaultTTL:   getEnvDuration("FILE_TRANSFER_DEFAULT_TTL", 7*24*time.Hour),
+			TransferMaxTTL:       getEnvDuration("FILE_TRANSFER_MAX_TTL", 30*24*time.Hour),
+			UploadS3Prefix:       getEnv("UPLOAD_CHUNK_S3_PREFIX", "upload-chunks/"),
+			UploadChunkBytes:     int64(getEnvInt("UPLOAD_CHUNK_BYTES", 8*1024*1024)),     // 8MB per PATCH
+			UploadMaxBytes:       int64(getEnvInt("UPLOAD_MAX_BYTES", 10*1024*1024*1024)), // 10GB per upload
+			UploadAbandonAfter:   getEnvDuration("UPLOAD_ABANDON_AFTER", 24*time.Hour),
+			ClamAVAddr:           getEnv("CLAMAV_ADDR", ""),
+			ClamAVTimeout:        getEnvDuration("CLAMAV_TIMEOUT", 2*time.Minute),
+			ScanMaxBytes:         int64(getEnvInt("CLAMAV_MAX_SCAN_BYTES", 25*1024*1024)), // clamd StreamMaxLength default
+			ScanFailClosed:       getEnvBool("CLAMAV_FAIL_CLOSED", false),
+			QuarantineS3Prefix:   getEnv("QUARANTINE_S3_PREFIX", "quarantine/"),
+			ImageS3Prefix:        getEnv("IMAGE_S3_PREFIX", "images/"),
+			ImageMaxBytes:        int64(getEnvInt("IMAGE_MAX_BYTES", 20*1024*1024)), // 20MB per source image
+			ImageCWebP:           getEnv("IMAGE_CWEBP", "cwebp"),
+			ImageAVIFEnc:         getEnv("IMAGE_AVIFENC", "avifenc"),
 		},
 		FCM: FCMConfig{
 			ServerKey:             getEnv("FCM_SERVER_KEY", ""),
 			ServiceAccountKeyFile: getEnv("FIREBASE_SERVICE_ACCOUNT_KEY", ""),
 		},
+		APNs: APNsConfig{
+			KeyFile:    getEnv("APNS_KEY_FILE", ""),
+			KeyID:      getEnv("APNS_KEY_ID", ""),
+			TeamID:     getEnv("APNS_TEAM_ID", ""),
+			BundleID:   getEnv("APNS_BUNDLE_ID", ""),
+			Production: getEnvBool("APNS_PRODUCTION", true),
+		},
 		SMTP: SMTPConfig{
 			Enabled:     getEnvBool("SMTP_ENABLED", false),
 			Host:        getEnv("SMTP_HOST", "smtp.office365.com"),
@@ -248,19 +614,121 @@ func Load() (*Config, error) {
 			SecretKey:      getEnv("STRIPE_SECRET_KEY", ""),
 			PublishableKey: getEnv("STRIPE_PUBLISHABLE_KEY", ""),
 			WebhookSecret:  getEnv("STRIPE_WEBHOOK_SECRET", ""),
+			AutomaticTax:   getEnvBool("STRIPE_AUTOMATIC_TAX", false),
+		},
+		Tax: TaxConfig{
+			Provider: getEnv("TAX_PROVIDER", "table"),
+		},
+		Subscription: SubscriptionConfig{
+			ReadOnlyGraceDays: getEnvInt("SUBSCRIPTION_READ_ONLY_GRACE_DAYS", 14),
 		},
+		Backup: BackupConfig{
+			RDSInstanceID:         getEnv("BACKUP_RDS_INSTANCE_ID", ""),
+			RDSRegion:             getEnv("BACKUP_RDS_REGION", "us-east-1"),
+			DumpS3Prefix:          getEnv("BACKUP_DUMP_S3_PREFIX", "config-dumps/"),
+			DumpTables:            getEnvList("BACKUP_DUMP_TABLES"),
+			DumpHourUTC:           getEnvInt("BACKUP_DUMP_HOUR_UTC", 3),
+			DrillInstanceClass:    getEnv("RESTORE_DRILL_INSTANCE_CLASS", "db.t3.micro"),
+			DrillSubnetGroup:      getEnv("RESTORE_DRILL_SUBNET_GROUP", ""),
+			DrillSecurityGroupIDs: getEnvList("RESTORE_DRILL_SECURITY_GROUP_IDS"),
+			DrillTimeout:          getEnvDuration("RESTORE_DRILL_TIMEOUT", 90*time.Minute),
+		},
+		Cost: CostConfig{
+			ExplorerEnabled:  getEnvBool("COST_EXPLORER_ENABLED", false),
+			MonthlyBudgetUSD: getEnvFloat("COST_MONTHLY_BUDGET_USD", 0),
+			AlertThresholds:  getEnvIntList("COST_ALERT_THRESHOLDS", []int{50, 80, 100}),
+			SyncHourUTC:      getEnvInt("COST_SYNC_HOUR_UTC", 8),
+			BackfillDays:     getEnvInt("COST_BACKFILL_DAYS", 90),
+		},
+		LogSearch: LogSearchConfig{
+			LogGroup: getEnv("LOGS_INSIGHTS_LOG_GROUP", ""),
+			Region:   getEnv("LOGS_INSIGHTS_REGION", "us-east-1"),
+		},
+		Tasks: TaskQueueConfig{
+			WorkersEnabled: getEnvBool("TASK_WORKERS_ENABLED", true),
+		},
+		QueryStats: QueryStatsConfig{
+			Enabled:       getEnvBool("QUERY_STATS_ENABLED", true),
+			Interval:      getEnvDuration("QUERY_STATS_INTERVAL", 15*time.Minute),
+			TopN:          getEnvInt("QUERY_STATS_TOP_N", 100),
+			RetentionDays: getEnvInt("QUERY_STATS_RETENTION_DAYS", 90),
+		},
+	}
+	cfg.GRPC = GRPCConfig{
+		Addr:           getEnv("GRPC_ADDR", ""),
+		CertFile:       getEnv("GRPC_TLS_CERT", ""),
+		KeyFile:        getEnv("GRPC_TLS_KEY", ""),
+		ClientCAFile:   getEnv("GRPC_CLIENT_CA", ""),
+		AllowedClients: getEnvList("GRPC_ALLOWED_CLIENTS"),
+	}
+	cfg.Warehouse = WarehouseExportConfig{
+		Enabled:      getEnvBool("WAREHOUSE_EXPORT_ENABLED", true),
+		S3Bucket:     getEnv("WAREHOUSE_EXPORT_S3_BUCKET", ""),
+		S3Prefix:     getEnv("WAREHOUSE_EXPORT_S3_PREFIX", "warehouse/"),
+		HourUTC:      getEnvInt("WAREHOUSE_EXPORT_HOUR_UTC", 4),
+		MinCellSize:  getEnvInt("WAREHOUSE_EXPORT_MIN_CELL_SIZE", 10),
+		CohortMonths: getEnvInt("WAREHOUSE_EXPORT_COHORT_MONTHS", 24),
+	}
+	cfg.FoodVision = FoodVisionConfig{
+		Provider:      getEnv("FOOD_VISION_PROVIDER", "none"),
+		Model:         getEnv("FOOD_VISION_MODEL", cfg.Claude.Model),
+		MaxImageBytes: getEnvInt("FOOD_VISION_MAX_IMAGE_BYTES", 5*1024*1024),
+	}
+	cfg.LogDrafts = LogDraftsConfig{
+		TTL:      getEnvDuration("LOG_DRAFT_TTL", 7*24*time.Hour),
+		MaxBytes: getEnvInt("LOG_DRAFT_MAX_BYTES", 64*1024),
+	}
+	cfg.Abuse = AbuseConfig{
+		Enabled:           getEnvBool("ABUSE_DETECTION_ENABLED", true),
+		VelocityPerMinute: getEnvInt("ABUSE_VELOCITY_PER_MINUTE", 600),
+		EnumerationIDs:    getEnvInt("ABUSE_ENUMERATION_IDS", 30),
+		ProbePaths:        getEnvInt("ABUSE_PROBE_PATHS", 30),
+		BlockDuration:     getEnvDuration("ABUSE_BLOCK_DURATION", time.Hour),
+		Allowlist:         getEnvList("ABUSE_ALLOWLIST"),
+	}
+	cfg.Captcha = CaptchaConfig{
+		Provider:  strings.ToLower(getEnv("CAPTCHA_PROVIDER", "off")),
+		SiteKey:   getEnv("CAPTCHA_SITE_KEY", ""),
+		SecretKey: getEnv("CAPTCHA_SECRET_KEY", ""),
+		Timeout:   getEnvDuration("CAPTCHA_TIMEOUT", 5*time.Second),
+	}
+	cfg.Events = EventsConfig{
+		Sink:          getEnv("EVENTS_SINK", "off"),
+		KinesisStream: getEnv("EVENTS_KINESIS_STREAM", ""),
+		KinesisRegion: getEnv("EVENTS_KINESIS_REGION", "us-east-1"),
+		KafkaRESTURL:  getEnv("EVENTS_KAFKA_REST_URL", ""),
+		KafkaTopic:    getEnv("EVENTS_KAFKA_TOPIC", "carecompanion.events"),
+		Interval:      getEnvDuration("EVENTS_RELAY_INTERVAL", 10*time.Second),
+		BatchSize:     getEnvInt("EVENTS_BATCH_SIZE", 100),
+		Retention:     getEnvDuration("EVENTS_RETENTION", 7*24*time.Hour),
+		PendingMaxAge: getEnvDuration("EVENTS_PENDING_MAX_AGE", 14*24*time.Hour),
+	}
+	cfg.Security = SecurityConfig{
+		CSP:           getEnv("CSP_POLICY", DefaultCSP(cfg.App.Env)),
+		CSPReportOnly: getEnvBool("CSP_REPORT_ONLY", true),
+		// Generated marketing files land in static/marketing.
+		SignedStaticPrefixes: []string{"/static/marketing/"},
+	}
+	if prefixes := getEnvList("STATIC_SIGNED_PREFIXES"); len(prefixes) > 0 {
+		cfg.Security.SignedStaticPrefixes = prefixes
 	}
 
 	return cfg, nil
 }
 
+// DSN is the keyword/value connection string. Values are quoted, so a
+// password with spaces, quotes or backslashes reaches the server as set.
 func (c *DatabaseConfig) DSN() string {
-	return "host=" + c.Host +
-		" port=" + c.Port +
-		" user=" + c.User +
-		" password=" + c.Password +
-		" dbname=" + c.Name +
-		" sslmode=" + c.SSLMode
+	return "host=" + dsnQuote(c.Host) +
+		" port=" + dsnQuote(c.Port) +
+		" user=" + dsnQuote(c.User) +
+		" password=" + dsnQuote(c.Password) +
+		" dbname=" + dsnQuote(c.Name) +
+		" sslmode=" + dsnQuote(c.SSLMode)
+}
+
+func dsnQuote(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
 }
 
 func (c *RedisConfig) Addr() string {
@@ -309,3 +777,48 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvDurations parses a comma-separated list of name=duration pairs,
+// skipping entries that don't parse.
+func getEnvDurations(key, defaultValue string) map[string]time.Duration {
+	out := make(map[string]time.Duration)
+	for _, v := range strings.Split(getEnv(key, defaultValue), ",") {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			continue
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+			out[strings.TrimSpace(name)] = d
+		}
+	}
+	return out
+}
+
+// getEnvList splits a comma-separated variable, dropping blanks.
+func getEnvList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// getEnvIntList parses a comma-separated list of integers, falling back to
+// defaultValue when the variable is unset or any entry is not a number.
+func getEnvIntList(key string, defaultValue []int) []int {
+	parts := getEnvList(key)
+	if len(parts) == 0 {
+		return defaultValue
+	}
+	out := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return defaultValue
+		}
+		out = append(out, n)
+	}
+	return out
+}
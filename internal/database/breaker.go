@@ -0,0 +1,282 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/apperr"
+)
+
+// ErrUnavailable is what calls fail with while a dependency's breaker is
+// open. Match it with errors.Is; the concrete *UnavailableError says which
+// dependency and when to retry.
+var ErrUnavailable = errors.New("temporarily unavailable")
+
+// UnavailableError is returned instead of trying a dependency that has
+// just failed repeatedly.
+type UnavailableError struct {
+	Dependency string
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s temporarily unavailable, retry in %s", e.Dependency, e.RetryAfter.Round(time.Second))
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable || target == apperr.ErrUnavailable
+}
+
+// Breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// Breaker is a circuit breaker around one dependency. After Failures
+// consecutive outage errors it opens and fails calls fast with
+// *UnavailableError for Cooldown; then one call is let through as a probe,
+// which closes it on success and reopens it on failure. Errors that say
+// nothing about the dependency's health (no rows, constraint violations,
+// the caller giving up) don't count.
+type Breaker struct {
+	Name     string
+	Failures int
+	Cooldown time.Duration
+
+	now func() time.Time
+
+	mu          sync.Mutex
+	state       string
+	consecutive int
+	openedAt    time.Time
+	probing     bool
+	lastErr     string
+	trips       int
+}
+
+// NewBreaker returns a closed breaker. Zero failures or cooldown take the
+// defaults of 5 and 10s.
+func NewBreaker(name string, failures int, cooldown time.Duration) *Breaker {
+	if failures <= 0 {
+		failures = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 10 * time.Second
+	}
+	return &Breaker{Name: name, Failures: failures, Cooldown: cooldown, now: time.Now, state: BreakerClosed}
+}
+
+// Allow reports whether a call may go ahead. A nil breaker allows
+// everything.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		wait := b.Cooldown - b.now().Sub(b.openedAt)
+		if wait > 0 {
+			return &UnavailableError{Dependency: b.Name, RetryAfter: wait}
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return &UnavailableError{Dependency: b.Name, RetryAfter: time.Second}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Check is Allow without taking the half-open probe: it fails only while
+// the breaker is open and cooling down. For callers in front of the
+// dependency, like an HTTP guard, whose own calls go through Allow.
+func (b *Breaker) Check() *UnavailableError {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerOpen {
+		return nil
+	}
+	if wait := b.Cooldown - b.now().Sub(b.openedAt); wait > 0 {
+		return &UnavailableError{Dependency: b.Name, RetryAfter: wait}
+	}
+	return nil
+}
+
+// Record notes a call's outcome; only outage errors count against the
+// dependency.
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	outage := isOutage(err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !outage {
+		if b.state != BreakerClosed && err == nil {
+			log.Printf("[BREAKER] %s recovered; closing", b.Name)
+		}
+		if err == nil || b.state == BreakerClosed {
+			b.state, b.consecutive, b.probing = BreakerClosed, 0, false
+		} else {
+			// The probe ended without telling us anything; let another.
+			b.probing = false
+		}
+		return
+	}
+	b.lastErr = err.Error()
+	b.consecutive++
+	if b.state == BreakerHalfOpen || b.consecutive >= b.Failures {
+		if b.state != BreakerOpen {
+			b.trips++
+			log.Printf("[BREAKER] %s open for %s after %d failures: %v", b.Name, b.Cooldown, b.consecutive, err)
+		}
+		b.state, b.openedAt, b.probing = BreakerOpen, b.now(), false
+	}
+}
+
+// BreakerStatus is a breaker's state for the readiness endpoint.
+type BreakerStatus struct {
+	Name                string    `json:"name"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Trips               int       `json:"trips"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// Status snapshots the breaker. An open breaker past its cooldown reports
+// half_open: the next call probes.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := BreakerStatus{Name: b.Name, State: b.state, ConsecutiveFailures: b.consecutive, Trips: b.trips, LastError: b.lastErr}
+	if b.state == BreakerOpen {
+		s.OpenedAt = b.openedAt
+		if b.now().Sub(b.openedAt) >= b.Cooldown {
+			s.State = BreakerHalfOpen
+		}
+	}
+	return s
+}
+
+// Ready reports whether the breaker would let a call through now.
+func (b *Breaker) Ready() bool {
+	return b.Status().State != BreakerOpen
+}
+
+// isOutage reports whether err says the dependency is down or unreachable,
+// as opposed to the call itself failing.
+func isOutage(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, redis.Nil) || errors.Is(err, ErrUnavailable) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, redis.ErrClosed) || errors.Is(err, redis.ErrPoolTimeout) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 08: connection exception; 57P0x: shutdown or not yet accepting
+		// connections; 53300: too many connections.
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P0") || pgErr.Code == "53300"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.HasPrefix(msg, "LOADING ") || strings.HasPrefix(msg, "MASTERDOWN ") || strings.HasPrefix(msg, "CLUSTERDOWN ")
+}
+
+// breakerConnector runs every new connection through a breaker. Statements
+// on a dead server fail their connection, database/sql discards it and
+// asks for a new one, so an outage reaches Connect within a few calls and
+// from then on calls fail fast instead of waiting out a dial timeout.
+type breakerConnector struct {
+	driver.Connector
+	breaker *Breaker
+}
+
+func (c *breakerConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	conn, err := c.Connector.Connect(ctx)
+	c.breaker.Record(err)
+	return conn, err
+}
+
+// redisBreakerHook runs every Redis command through a breaker.
+type redisBreakerHook struct {
+	breaker *Breaker
+}
+
+func (h redisBreakerHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h redisBreakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		err := next(ctx, cmd)
+		h.breaker.Record(err)
+		return err
+	}
+}
+
+func (h redisBreakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err := next(ctx, cmds)
+		h.breaker.Record(err)
+		return err
+	}
+}
+
+// Retry calls open until it succeeds or wait runs out, sleeping a jittered,
+// doubling backoff (1s up to 15s) between attempts, so a server booting
+// alongside its database or Redis waits for them instead of crashing.
+func Retry[T any](name string, wait time.Duration, open func() (T, error)) (T, error) {
+	deadline := time.Now().Add(wait)
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		v, err := open()
+		if err == nil || time.Now().Add(backoff).After(deadline) {
+			return v, err
+		}
+		sleep := backoff/2 + rand.N(backoff/2+1)
+		log.Printf("[STARTUP] %s not reachable (attempt %d): %v; retrying in %s", name, attempt, err, sleep.Round(time.Millisecond))
+		time.Sleep(sleep)
+		backoff = min(backoff*2, 15*time.Second)
+	}
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	b := NewBreaker("postgres", 2, 10*time.Second)
+	b.now = func() time.Time { return now }
+	down := &pgconn.PgError{Code: "57P03", Message: "the database system is starting up"}
+
+	b.Record(down)
+	b.Record(sql.ErrNoRows) // a query result, not an outage: resets the run
+	b.Record(down)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("open after non-consecutive failures: %v", err)
+	}
+	b.Record(down)
+	err := b.Allow()
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) || !errors.Is(err, ErrUnavailable) || unavailable.Dependency != "postgres" {
+		t.Fatalf("Allow while open = %v", err)
+	}
+	if b.Check() == nil || b.Ready() {
+		t.Error("open breaker reads as ready")
+	}
+
+	now = now.Add(11 * time.Second)
+	if b.Check() != nil {
+		t.Error("Check fails past the cooldown")
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if err := b.Allow(); err == nil {
+		t.Error("second call let through during the probe")
+	}
+	b.Record(down)
+	if b.Status().State != BreakerOpen || b.Status().Trips != 2 {
+		t.Errorf("failed probe: %+v", b.Status())
+	}
+
+	now = now.Add(11 * time.Second)
+	b.Allow()
+	b.Record(nil)
+	if s := b.Status(); s.State != BreakerClosed || s.ConsecutiveFailures != 0 {
+		t.Errorf("after a good probe: %+v", s)
+	}
+}
+
+func TestIsOutage(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{redis.Nil, false},
+		{context.Canceled, false},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{fmt.Errorf("get: %w", &pgconn.PgError{Code: "57P03"}), true},
+		{&pgconn.PgError{Code: "08006"}, true},
+		{redis.ErrPoolTimeout, true},
+		{errors.New("LOADING Redis is loading the dataset in memory"), true},
+		{&UnavailableError{Dependency: "redis"}, false},
+	} {
+		if got := isOutage(tc.err); got != tc.want {
+			t.Errorf("isOutage(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"carecompanion/internal/config"
+)
+
+// rdsTokenLifetime is how long an RDS IAM auth token is valid for. Tokens
+// are only checked at connect time, so open connections outlive them.
+const rdsTokenLifetime = 15 * time.Minute
+
+// rdsTokenReuse is how long a token is reused before signing a new one.
+const rdsTokenReuse = 10 * time.Minute
+
+// emptyPayloadHash is the SHA-256 of an empty body, which the RDS auth
+// token is signed over.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// credentials picks the password for each new connection of the main pool,
+// so rotating it never needs a restart: the password file is reread, the
+// previous password covers the window where the role and the app disagree,
+// and IAM tokens are signed fresh.
+type credentials struct {
+	password string
+	previous string
+	file     string
+	iam      *rdsTokens
+}
+
+func newCredentials(cfg *config.DatabaseConfig) (*credentials, error) {
+	c := &credentials{password: cfg.Password, previous: cfg.PasswordPrevious, file: cfg.PasswordFile}
+	if cfg.IAMAuth {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.IAMRegion))
+		if err != nil {
+			return nil, fmt.Errorf("load AWS config for IAM database auth: %w", err)
+		}
+		c.iam = &rdsTokens{creds: awsCfg.Credentials, region: cfg.IAMRegion, now: time.Now}
+	}
+	return c, nil
+}
+
+// candidates are the passwords to try for user at host:port, in order.
+func (c *credentials) candidates(ctx context.Context, host string, port uint16, user string) ([]string, error) {
+	if c.iam != nil {
+		token, err := c.iam.token(ctx, net.JoinHostPort(host, strconv.Itoa(int(port))), user)
+		if err != nil {
+			return nil, fmt.Errorf("IAM database auth token: %w", err)
+		}
+		return []string{token}, nil
+	}
+	current := c.password
+	if c.file != "" {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			return nil, fmt.Errorf("read database password file: %w", err)
+		}
+		current = strings.TrimRight(string(b), "\r\n")
+	}
+	out := []string{current}
+	if c.previous != "" && c.previous != current {
+		out = append(out, c.previous)
+	}
+	return out, nil
+}
+
+type passwordKey struct{}
+
+// beforeConnect applies the password rotatingConnector chose for this
+// attempt.
+func beforeConnect(ctx context.Context, cc *pgx.ConnConfig) error {
+	if pw, ok := ctx.Value(passwordKey{}).(string); ok {
+		cc.Password = pw
+	}
+	return nil
+}
+
+// rotatingConnector connects with each of the credentials' candidates in
+// turn until the server accepts one. The connections themselves are the
+// pgx driver's, so conn.Raw callers still get a *stdlib.Conn.
+type rotatingConnector struct {
+	driver.Connector
+	creds         *credentials
+	host, user    string
+	port          uint16
+	mu            sync.Mutex
+	usingPrevious bool
+}
+
+func (c *rotatingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	passwords, err := c.creds.candidates(ctx, c.host, c.port, c.user)
+	if err != nil {
+		return nil, err
+	}
+	for i, pw := range passwords {
+		conn, err := c.Connector.Connect(context.WithValue(ctx, passwordKey{}, pw))
+		if err == nil {
+			c.noteCredential(i > 0)
+			return conn, nil
+		}
+		if !authFailed(err) || i == len(passwords)-1 {
+			return nil, err
+		}
+	}
+	return nil, errors.New("database: no credentials to connect with")
+}
+
+// noteCredential logs switches between the current and previous password,
+// once each way rather than per connection.
+func (c *rotatingConnector) noteCredential(previous bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if previous == c.usingPrevious {
+		return
+	}
+	c.usingPrevious = previous
+	if previous {
+		log.Printf("[DB] current password refused for %s; connecting with DB_PASSWORD_PREVIOUS until the rotation completes", c.user)
+	} else {
+		log.Printf("[DB] current password accepted for %s; DB_PASSWORD_PREVIOUS is no longer needed", c.user)
+	}
+}
+
+// authFailed reports whether the server refused the credentials.
+func authFailed(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "28P01" || pgErr.Code == "28000"
+	}
+	return false
+}
+
+// rdsTokens signs RDS IAM auth tokens, reusing each for rdsTokenReuse.
+type rdsTokens struct {
+	creds  aws.CredentialsProvider
+	region string
+	now    func() time.Time
+
+	mu     sync.Mutex
+	cached map[string]cachedToken
+}
+
+type cachedToken struct {
+	token  string
+	signed time.Time
+}
+
+// token returns an auth token for user at endpoint (host:port): the
+// SigV4-presigned rds-db connect URL without its scheme, as the RDS
+// feature/rds/auth package builds it.
+func (t *rdsTokens) token(ctx context.Context, endpoint, user string) (string, error) {
+	key := endpoint + "/" + user
+	now := t.now()
+	t.mu.Lock()
+	if c, ok := t.cached[key]; ok && now.Sub(c.signed) < rdsTokenReuse {
+		t.mu.Unlock()
+		return c.token, nil
+	}
+	t.mu.Unlock()
+
+	creds, err := t.creds.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("Action", "connect")
+	q.Set("DBUser", user)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(rdsTokenLifetime/time.Second)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+endpoint+"/?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	signed, _, err := v4.NewSigner().PresignHTTP(ctx, creds, req, emptyPayloadHash, "rds-db", t.region, now)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimPrefix(signed, "https://")
+
+	t.mu.Lock()
+	if t.cached == nil {
+		t.cached = map[string]cachedToken{}
+	}
+	t.cached[key] = cachedToken{token: token, signed: now}
+	t.mu.Unlock()
+	return token, nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	awscreds "github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/jackc/pgx/v5"
+
+	"carecompanion/internal/config"
+)
+
+func TestDSNQuotesPassword(t *testing.T) {
+	for _, pw := range []string{`CareCompApp2025\!`, `it's a pass word`, ""} {
+		cfg := config.DatabaseConfig{Host: "db", Port: "5432", Name: "carecompanion", User: "app", Password: pw, SSLMode: "disable"}
+		cc, err := pgx.ParseConfig(cfg.DSN())
+		if err != nil {
+			t.Fatalf("%q: %v", pw, err)
+		}
+		if cc.Password != pw || cc.User != "app" || cc.Database != "carecompanion" {
+			t.Errorf("%q parsed as password %q, user %q, db %q", pw, cc.Password, cc.User, cc.Database)
+		}
+	}
+}
+
+func TestCredentialCandidates(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "db-password")
+	c := &credentials{password: "env", previous: "old", file: file}
+	if _, err := c.candidates(ctx, "db", 5432, "app"); err == nil {
+		t.Error("missing password file accepted")
+	}
+	if err := os.WriteFile(file, []byte("new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.candidates(ctx, "db", 5432, "app")
+	if err != nil || !slices.Equal(got, []string{"new", "old"}) {
+		t.Errorf("candidates = %q, %v", got, err)
+	}
+	// Rotated again: the file is reread per connection.
+	if err := os.WriteFile(file, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.candidates(ctx, "db", 5432, "app"); !slices.Equal(got, []string{"old"}) {
+		t.Errorf("after rotation = %q", got)
+	}
+}
+
+func TestRDSToken(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tokens := &rdsTokens{
+		creds:  awscreds.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		region: "us-east-1",
+		now:    func() time.Time { return now },
+	}
+	ctx := context.Background()
+	c := &credentials{iam: tokens}
+	got, err := c.candidates(ctx, "db.example.rds.amazonaws.com", 5432, "carecomp_app")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("candidates = %q, %v", got, err)
+	}
+	tok := got[0]
+	for _, want := range []string{"db.example.rds.amazonaws.com:5432/?", "Action=connect", "DBUser=carecomp_app", "X-Amz-Expires=900",
+		"X-Amz-Credential=AKIDEXAMPLE%2F20261017%2Fus-east-1%2Frds-db%2Faws4_request", "X-Amz-Signature="} {
+		if !strings.Contains(tok, want) {
+			t.Errorf("token %q lacks %q", tok, want)
+		}
+	}
+	if strings.HasPrefix(tok, "https://") {
+		t.Error("token keeps the scheme")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if again, _ := tokens.token(ctx, "db.example.rds.amazonaws.com:5432", "carecomp_app"); again != tok {
+		t.Error("token re-signed inside the reuse window")
+	}
+	now = now.Add(rdsTokenReuse)
+	if fresh, _ := tokens.token(ctx, "db.example.rds.amazonaws.com:5432", "carecomp_app"); fresh == tok {
+		t.Error("token reused past the reuse window")
+	}
+}
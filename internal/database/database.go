@@ -3,31 +3,67 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 
 	"carecompanion/internal/config"
+	"carecompanion/internal/repository"
 )
 
 type DB struct {
 	*sql.DB
+	// Breaker guards the pool's new connections; see breakerConnector.
+	Breaker *Breaker
 }
 
+// New opens the main pool. Unlike NewWithDSN, it picks the password per
+// new connection (see credentials), so DB_PASSWORD_FILE,
+// DB_PASSWORD_PREVIOUS and DB_IAM_AUTH let the password rotate under a
+// running server. IAM auth needs DB_SSLMODE=require or stricter.
 func New(cfg *config.DatabaseConfig) (*DB, error) {
-	return NewWithDSN(cfg.DSN(), cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
+	connConfig, err := pgx.ParseConfig(cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	creds, err := newCredentials(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	connector := &rotatingConnector{
+		Connector: stdlib.GetConnector(*connConfig,
+			stdlib.OptionBeforeConnect(beforeConnect),
+			stdlib.OptionResetSession(repository.ResetFamilyScope)),
+		creds: creds,
+		host:  connConfig.Host,
+		port:  connConfig.Port,
+		user:  connConfig.User,
+	}
+	breaker := NewBreaker("postgres", cfg.BreakerFailures, cfg.BreakerCooldown)
+	return openPool(&breakerConnector{Connector: connector, breaker: breaker}, breaker, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
 }
 
 // NewWithDSN opens a pool against an explicit DSN. Used both by New() for the
 // main DB and by main() to open a second pool for SUPPORT_DB_DSN when the
 // dev environment is configured to share prod's support tickets.
+//
+// Connections are reset with repository.ResetFamilyScope as the pool hands
+// them out, so a family-scoped statement's setting never reaches the next.
 func NewWithDSN(dsn string, maxOpen, maxIdle int, connLife time.Duration) (*DB, error) {
-	db, err := sql.Open("pgx", dsn)
+	connConfig, err := pgx.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	breaker := NewBreaker("postgres "+connConfig.Host, 0, 0)
+	connector := stdlib.GetConnector(*connConfig, stdlib.OptionResetSession(repository.ResetFamilyScope))
+	return openPool(&breakerConnector{Connector: connector, breaker: breaker}, breaker, maxOpen, maxIdle, connLife)
+}
 
+func openPool(connector driver.Connector, breaker *Breaker, maxOpen, maxIdle int, connLife time.Duration) (*DB, error) {
+	db := sql.OpenDB(connector)
 	db.SetMaxOpenConns(maxOpen)
 	db.SetMaxIdleConns(maxIdle)
 	db.SetConnMaxLifetime(connLife)
@@ -36,10 +72,11 @@ func NewWithDSN(dsn string, maxOpen, maxIdle int, connLife time.Duration) (*DB,
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, Breaker: breaker}, nil
 }
 
 func (db *DB) Close() error {
@@ -12,6 +12,8 @@ import (
 
 type Redis struct {
 	*redis.Client
+	// Breaker fails commands fast while Redis is down.
+	Breaker *Breaker
 }
 
 func NewRedis(cfg *config.RedisConfig) (*Redis, error) {
@@ -20,6 +22,8 @@ func NewRedis(cfg *config.RedisConfig) (*Redis, error) {
 		Password: cfg.Password,
 		DB:       cfg.DB,
 	})
+	breaker := NewBreaker("redis", cfg.BreakerFailures, cfg.BreakerCooldown)
+	client.AddHook(redisBreakerHook{breaker: breaker})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -28,7 +32,7 @@ func NewRedis(cfg *config.RedisConfig) (*Redis, error) {
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	return &Redis{client}, nil
+	return &Redis{Client: client, Breaker: breaker}, nil
 }
 
 func (r *Redis) Close() error {
@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// ABUSE — temporary blocks from abuse and scraping detection, and the
+// queue admins confirm or lift them from.
+// ============================================================================
+
+// abuseErrorStatus maps abuse review errors to HTTP status codes.
+func abuseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAbuseBlockNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrAbuseReviewInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListAbuseBlocks handles GET /api/admin/abuse. ?view=queue (the default)
+// lists blocks awaiting review; ?view=all lists recent blocks, optionally
+// filtered by ?status= (active, expired, lifted) and ?ip=.
+func (h *Handler) ListAbuseBlocks(w http.ResponseWriter, r *http.Request) {
+	if h.abuseService == nil {
+		http.Error(w, "Abuse detection unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	f := repository.AbuseBlockFilter{
+		Queue:  q.Get("view") != "all",
+		Status: q.Get("status"),
+		IP:     q.Get("ip"),
+	}
+	if v := q.Get("limit"); v != "" {
+		f.Limit, _ = strconv.Atoi(v)
+	}
+	list, err := h.abuseService.List(r.Context(), f)
+	if err != nil {
+		http.Error(w, "Failed to list blocks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, list)
+}
+
+// ReviewAbuseBlock handles POST /api/admin/abuse/{id}/review with
+// {"action": "confirm"|"unblock", "note": "..."}. Unblock lifts the block
+// and exempts the IP from detection for a day.
+func (h *Handler) ReviewAbuseBlock(w http.ResponseWriter, r *http.Request) {
+	if h.abuseService == nil {
+		http.Error(w, "Abuse detection unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid block ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	b, err := h.abuseService.Review(r.Context(), id, req.Action, claims.UserID, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), abuseErrorStatus(err))
+		return
+	}
+	h.logAction(r, "review_abuse_block", "abuse_block", id, map[string]interface{}{
+		"action": req.Action, "ip_address": b.IPAddress, "reason": b.Reason,
+	})
+	respondJSON(w, b)
+}
+
+// AbusePage renders the abuse block review queue.
+func (h *Handler) AbusePage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID: claims.UserID, Email: claims.Email, FirstName: claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "abuse.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Abuse Blocks",
+		CurrentUser: currentUser,
+	})
+}
@@ -0,0 +1,219 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// ACCESS REVIEWS — each quarter super admins attest or revoke every admin
+// account from a snapshot of its role, permissions and last sign-in, then
+// complete the review. Decisions and completed reviews are immutable. The
+// same daily job deactivates admins who stop signing in.
+// ============================================================================
+
+// ListAccessReviews handles GET /super/access-reviews: reviews newest
+// first with their decision counts.
+func (h *Handler) ListAccessReviews(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	reviews, err := h.accessReviewService.List(r.Context(), getIntParam(r, "limit", 20))
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to list access reviews")
+		return
+	}
+	if reviews == nil {
+		reviews = []repository.AdminAccessReview{}
+	}
+	respondJSON(w, map[string]interface{}{"reviews": reviews})
+}
+
+// OpenAccessReview handles POST /super/access-reviews: opens this
+// quarter's review now instead of waiting for the daily job.
+func (h *Handler) OpenAccessReview(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	review, err := h.accessReviewService.OpenReview(r.Context())
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to open access review")
+		return
+	}
+	if review == nil {
+		http.Error(w, "This quarter's access review is already open", http.StatusConflict)
+		return
+	}
+	h.logAction(r, "open_access_review", "access_review", review.ID, map[string]interface{}{
+		"period": review.Period, "accounts": review.Items,
+	})
+	respondJSON(w, review)
+}
+
+// GetAccessReview handles GET /super/access-reviews/{reviewID}: the review
+// and its checklist.
+func (h *Handler) GetAccessReview(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "reviewID"))
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+	review, items, err := h.accessReviewService.Get(r.Context(), id)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to load access review")
+		return
+	}
+	if items == nil {
+		items = []repository.AdminAccessReviewItem{}
+	}
+	respondJSON(w, map[string]interface{}{"review": review, "items": items})
+}
+
+// DecideAccessReviewItem handles POST
+// /super/access-reviews/{reviewID}/items/{itemID} with
+// {"decision": "attest"|"revoke", "note": "..."}. Revoking suspends the
+// admin immediately.
+func (h *Handler) DecideAccessReviewItem(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	reviewID, err := uuid.Parse(chi.URLParam(r, "reviewID"))
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemID"))
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Decision string `json:"decision"`
+		Note     string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	item, err := h.accessReviewService.Decide(r.Context(), reviewID, itemID, req.Decision, claims.UserID, req.Note)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to record decision")
+		return
+	}
+	h.logAction(r, "access_review_"+item.Decision, "user", item.AdminID, map[string]interface{}{
+		"review_id": reviewID, "email": item.Email, "role": item.Role, "note": item.Note,
+	})
+	respondJSON(w, item)
+}
+
+// CompleteAccessReview handles POST /super/access-reviews/{reviewID}/complete.
+func (h *Handler) CompleteAccessReview(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "reviewID"))
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	review, err := h.accessReviewService.Complete(r.Context(), id, claims.UserID)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to complete access review")
+		return
+	}
+	h.logAction(r, "complete_access_review", "access_review", review.ID, map[string]interface{}{
+		"period": review.Period, "attested": review.Attested, "revoked": review.Revoked,
+	})
+	respondJSON(w, review)
+}
+
+// DeactivateInactiveAdmins handles POST /super/access-reviews/deactivate-inactive:
+// runs the inactivity sweep now.
+func (h *Handler) DeactivateInactiveAdmins(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	done, err := h.accessReviewService.DeactivateInactive(r.Context())
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to deactivate inactive admins")
+		return
+	}
+	emails := make([]string, 0, len(done))
+	for _, a := range done {
+		emails = append(emails, a.Email)
+	}
+	h.logAction(r, "deactivate_inactive_admins", "admin", uuid.Nil, map[string]interface{}{"emails": emails})
+	respondJSON(w, map[string]interface{}{"deactivated": emails})
+}
+
+// GetAccessReviewPolicy handles GET /super/access-reviews/policy.
+func (h *Handler) GetAccessReviewPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	policy, err := h.accessReviewService.Policy(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load access review policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, policy)
+}
+
+// UpdateAccessReviewPolicy handles PUT /super/access-reviews/policy with
+// {"inactive_days", "due_days"}.
+func (h *Handler) UpdateAccessReviewPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.accessReviewService == nil {
+		http.Error(w, "Access reviews unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var policy service.AccessReviewPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	if err := h.accessReviewService.UpdatePolicy(r.Context(), policy, claims.UserID); err != nil {
+		middleware.WriteError(w, err, "Failed to save access review policy")
+		return
+	}
+	h.logAction(r, "update_access_review_policy", "settings", uuid.Nil, map[string]interface{}{"policy": policy})
+	respondJSON(w, policy)
+}
+
+// AccessReviewsPage renders the access review checklist.
+func (h *Handler) AccessReviewsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	tmpl, err := parseTemplates("layout.html", "access_reviews.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title: "Access Reviews",
+		CurrentUser: AdminUser{
+			ID:         claims.UserID,
+			Email:      claims.Email,
+			FirstName:  claims.FirstName,
+			SystemRole: string(claims.SystemRole),
+		},
+	})
+}
@@ -0,0 +1,249 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// ADMIN IMPORT — onboard a team from a CSV (email, name, role). Each admin
+// gets a one-time invitation link to choose their password instead of a
+// password someone has to hand them; the links come back once, in the
+// import report, and are emailed unless send_email is false.
+// ============================================================================
+
+// maxAdminImportBytes bounds the uploaded CSV.
+const maxAdminImportBytes = 1 << 20
+
+// ImportAdminUsersRequest is the JSON form of an import. A text/csv body
+// with ?dry_run= and ?send_email= does the same from curl.
+type ImportAdminUsersRequest struct {
+	CSV       string `json:"csv"`
+	DryRun    bool   `json:"dry_run"`
+	SendEmail *bool  `json:"send_email"`
+}
+
+// ImportAdminUsers handles POST /api/admin/super/admins/import. Rows are
+// created one by one; the report says what happened to each, so a bad row
+// doesn't stop the rest. Validate first with dry_run.
+func (h *Handler) ImportAdminUsers(w http.ResponseWriter, r *http.Request) {
+	if h.adminInviteService == nil {
+		http.Error(w, "Admin import is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxAdminImportBytes)
+	req := ImportAdminUsersRequest{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Could not read the CSV (max 1 MB)", http.StatusBadRequest)
+			return
+		}
+		req.CSV = string(body)
+		req.DryRun, _ = strconv.ParseBool(r.URL.Query().Get("dry_run"))
+		if v, err := strconv.ParseBool(r.URL.Query().Get("send_email")); err == nil {
+			req.SendEmail = &v
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rows, bad, err := service.ParseAdminCSV(strings.NewReader(req.CSV))
+	if err != nil {
+		middleware.WriteError(w, err, "Could not read the CSV")
+		return
+	}
+	opts := service.AdminImportOptions{
+		InvitedBy: middleware.GetUserID(r.Context()),
+		DryRun:    req.DryRun,
+		SendEmail: req.SendEmail == nil || *req.SendEmail,
+	}
+	report := h.adminInviteService.Import(r.Context(), rows, bad, opts)
+
+	if !report.DryRun {
+		for _, row := range report.Rows {
+			if row.Status == "invited" {
+				h.logAction(r, "create_admin", "user", *row.AdminID, map[string]interface{}{
+					"email": row.Email, "role": row.Role, "via": "import", "batch_id": report.BatchID,
+				})
+			}
+		}
+	}
+	h.logAction(r, "import_admins", "admin", uuid.Nil, map[string]interface{}{
+		"batch_id": report.BatchID, "dry_run": report.DryRun,
+		"rows": len(report.Rows), "invited": report.Invited, "failed": report.Failed,
+	})
+	respondJSON(w, report)
+}
+
+// adminInvitationView is an invitation with its status spelled out.
+type adminInvitationView struct {
+	ID         uuid.UUID  `json:"id"`
+	AdminID    uuid.UUID  `json:"admin_id"`
+	BatchID    *uuid.UUID `json:"batch_id,omitempty"`
+	Email      string     `json:"email"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ListAdminInvitations handles GET /api/admin/super/admins/invitations,
+// optionally ?batch_id= for one import, so a super admin can see who has
+// accepted and whose links have lapsed.
+func (h *Handler) ListAdminInvitations(w http.ResponseWriter, r *http.Request) {
+	if h.adminInviteService == nil {
+		http.Error(w, "Admin import is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	var batchID uuid.UUID
+	if s := r.URL.Query().Get("batch_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			http.Error(w, "Invalid batch_id", http.StatusBadRequest)
+			return
+		}
+		batchID = id
+	}
+	invs, err := h.adminInviteService.ListInvitations(r.Context(), batchID, getIntParam(r, "limit", 100))
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to list invitations")
+		return
+	}
+	now := time.Now()
+	out := make([]adminInvitationView, 0, len(invs))
+	counts := map[string]int{}
+	for _, inv := range invs {
+		v := adminInvitationView{
+			ID: inv.ID, AdminID: inv.AdminID, Email: inv.Email,
+			Name: strings.TrimSpace(inv.FirstName + " " + inv.LastName), Role: inv.Role,
+			Status: inv.Status(now), ExpiresAt: inv.ExpiresAt, AcceptedAt: inv.AcceptedAt, CreatedAt: inv.CreatedAt,
+		}
+		if inv.BatchID.Valid {
+			v.BatchID = &inv.BatchID.UUID
+		}
+		counts[v.Status]++
+		out = append(out, v)
+	}
+	respondJSON(w, map[string]interface{}{"invitations": out, "counts": counts})
+}
+
+// ReinviteAdminUser handles POST /api/admin/super/admins/{id}/invite: a
+// fresh link for an admin who hasn't accepted theirs. The old link stops
+// working.
+func (h *Handler) ReinviteAdminUser(w http.ResponseWriter, r *http.Request) {
+	if h.adminInviteService == nil {
+		http.Error(w, "Admin import is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	sendEmail := true
+	if v, err := strconv.ParseBool(r.URL.Query().Get("send_email")); err == nil {
+		sendEmail = v
+	}
+	res, err := h.adminInviteService.Reinvite(r.Context(), id, service.AdminImportOptions{
+		InvitedBy: middleware.GetUserID(r.Context()),
+		SendEmail: sendEmail,
+	})
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to issue a new invitation")
+		return
+	}
+	h.logAction(r, "reinvite_admin", "user", id, map[string]interface{}{"email": res.Email})
+	respondJSON(w, res)
+}
+
+// AdminInvitePage renders the public page where an invited admin chooses
+// their password. Like /admin/login it needs no session; the link's token
+// is the only access control.
+func (h *Handler) AdminInvitePage(w http.ResponseWriter, r *http.Request) {
+	if h.adminInviteService == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	inv, err := h.adminInviteService.Lookup(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		h.renderAdminInvite(w, r, "", inviteProblem(err), true)
+		return
+	}
+	h.renderAdminInvite(w, r, inv.FirstName, "", false)
+}
+
+// AdminInviteSubmit accepts the invitation and sends the admin to sign in.
+func (h *Handler) AdminInviteSubmit(w http.ResponseWriter, r *http.Request) {
+	if h.adminInviteService == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	token := chi.URLParam(r, "token")
+	password := r.FormValue("password")
+	if password != r.FormValue("confirm_password") {
+		inv, err := h.adminInviteService.Lookup(r.Context(), token)
+		if err != nil {
+			h.renderAdminInvite(w, r, "", inviteProblem(err), true)
+			return
+		}
+		h.renderAdminInvite(w, r, inv.FirstName, "The passwords don't match.", false)
+		return
+	}
+	inv, err := h.adminInviteService.Accept(r.Context(), token, password)
+	if err != nil {
+		if errors.Is(err, apperr.ErrValidation) && !errors.Is(err, service.ErrAdminInviteExpired) {
+			// A password that's too short: let them try again.
+			h.renderAdminInvite(w, r, "", apperr.Message(err), false)
+			return
+		}
+		h.renderAdminInvite(w, r, "", inviteProblem(err), true)
+		return
+	}
+	// There's no admin session yet, so this can't go through logAction.
+	h.adminRepo.LogAction(r.Context(), inv.AdminID, "accept_admin_invitation", "user", inv.AdminID,
+		map[string]interface{}{"email": inv.Email, "invitation_id": inv.ID}, clientIP(r), r.UserAgent())
+	http.Redirect(w, r, "/admin/login?invited=1", http.StatusSeeOther)
+}
+
+// inviteProblem is the message for a link that can't be used.
+func inviteProblem(err error) string {
+	if apperr.KindOf(err) == nil {
+		log.Printf("[ADMIN-INVITE] %v", err)
+		return "Something went wrong. Please try the link again in a few minutes."
+	}
+	return apperr.Message(err)
+}
+
+func (h *Handler) renderAdminInvite(w http.ResponseWriter, r *http.Request, firstName, flash string, dead bool) {
+	tmpl, err := parseTemplates("invite.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if dead {
+		w.WriteHeader(http.StatusGone)
+	}
+	tmpl.Execute(w, map[string]interface{}{
+		"Token":     chi.URLParam(r, "token"),
+		"FirstName": firstName,
+		"Flash":     flash,
+		"Dead":      dead,
+	})
+}
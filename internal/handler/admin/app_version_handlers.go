@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// APP VERSIONS — minimum supported mobile build per platform, shown as a
+// panel on the settings page. Builds below the minimum get 426 from the API.
+// ============================================================================
+
+// GetAppVersions handles GET /api/admin/super/app-versions. Alongside the
+// policy it counts active devices below each platform's minimum, from the
+// device registry, so an admin can see who a change would lock out.
+func (h *Handler) GetAppVersions(w http.ResponseWriter, r *http.Request) {
+	if h.appVersionService == nil {
+		http.Error(w, "App version policy not configured", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	policy, err := h.appVersionService.Policy(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load app versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]interface{}{"policy": policy}
+	if h.pushService != nil {
+		if stats, err := h.pushService.DeviceStats(ctx); err == nil {
+			below := map[string]int{service.AppPlatformIOS: 0, service.AppPlatformAndroid: 0}
+			for _, v := range stats.ByVersion {
+				pv, ok := policy.Platform(v.Platform)
+				if ok && v.AppVersion != "" && pv.MinVersion != "" && service.CompareAppVersions(v.AppVersion, pv.MinVersion) < 0 {
+					below[v.Platform] += v.Devices
+				}
+			}
+			resp["devices_below_min"] = below
+			resp["by_version"] = stats.ByVersion
+		}
+	}
+	respondJSON(w, resp)
+}
+
+// UpdateAppVersions handles PUT /api/admin/super/app-versions.
+func (h *Handler) UpdateAppVersions(w http.ResponseWriter, r *http.Request) {
+	if h.appVersionService == nil {
+		http.Error(w, "App version policy not configured", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	var req service.AppVersionPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	before, err := h.appVersionService.Policy(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load app versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	claims := middleware.GetAuthClaims(ctx)
+	if err := h.appVersionService.UpdatePolicy(ctx, req, claims.UserID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAppVersionPolicyInvalid) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h.logAction(r, "update_app_versions", "system", uuid.Nil, map[string]interface{}{
+		"before": before, "after": req,
+	})
+	respondJSON(w, req)
+}
@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// ASG OPERATIONS — blue/green deploy helpers (super_admin only). Each POST
+// without confirm_token returns the plan and a token; repeating it with
+// the token executes. Only executions are audit logged.
+// ============================================================================
+
+// asgErrorStatus maps ASG operation errors to HTTP status codes.
+func asgErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrASGNotConfigured):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrASGGuardrail):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrASGConfirmInvalid):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, service.ErrASGInstanceNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// respondASGPlan writes the plan and, once executed, audit logs it.
+func (h *Handler) respondASGPlan(w http.ResponseWriter, r *http.Request, plan *service.ASGPlan, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), asgErrorStatus(err))
+		return
+	}
+	if plan.Executed {
+		details := map[string]interface{}{"summary": plan.Summary, "result": plan.Result}
+		for k, v := range plan.Params {
+			details[k] = v
+		}
+		h.logAction(r, plan.Action, "asg", uuid.Nil, details)
+	}
+	respondJSON(w, plan)
+}
+
+// ListInstanceRefreshes handles GET /api/admin/asg/instance-refreshes.
+func (h *Handler) ListInstanceRefreshes(w http.ResponseWriter, r *http.Request) {
+	if h.cloudwatchService == nil {
+		http.Error(w, service.ErrASGNotConfigured.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	refreshes, err := h.cloudwatchService.ListInstanceRefreshes(r.Context(), 10)
+	if err != nil {
+		http.Error(w, err.Error(), asgErrorStatus(err))
+		return
+	}
+	respondJSON(w, refreshes)
+}
+
+// StartInstanceRefresh handles POST /api/admin/asg/instance-refresh.
+// Body: {min_healthy_percentage?, instance_warmup_seconds?, confirm_token?}.
+func (h *Handler) StartInstanceRefresh(w http.ResponseWriter, r *http.Request) {
+	if h.cloudwatchService == nil {
+		http.Error(w, service.ErrASGNotConfigured.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	req := struct {
+		MinHealthy   int    `json:"min_healthy_percentage"`
+		Warmup       *int   `json:"instance_warmup_seconds"`
+		ConfirmToken string `json:"confirm_token"`
+	}{MinHealthy: 90}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	warmup := 300
+	if req.Warmup != nil {
+		warmup = *req.Warmup
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	plan, err := h.cloudwatchService.StartInstanceRefresh(r.Context(), claims.UserID, req.MinHealthy, warmup, req.ConfirmToken)
+	h.respondASGPlan(w, r, plan, err)
+}
+
+// SetASGDesiredCapacity handles POST /api/admin/asg/desired-capacity.
+// Body: {desired_capacity, confirm_token?}.
+func (h *Handler) SetASGDesiredCapacity(w http.ResponseWriter, r *http.Request) {
+	if h.cloudwatchService == nil {
+		http.Error(w, service.ErrASGNotConfigured.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Desired      *int   `json:"desired_capacity"`
+		ConfirmToken string `json:"confirm_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Desired == nil {
+		http.Error(w, "desired_capacity is required", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	plan, err := h.cloudwatchService.SetDesiredCapacity(r.Context(), claims.UserID, *req.Desired, req.ConfirmToken)
+	h.respondASGPlan(w, r, plan, err)
+}
+
+// DrainASGInstance handles POST /api/admin/asg/instances/{id}/drain.
+// Body: {terminate?, confirm_token?}.
+func (h *Handler) DrainASGInstance(w http.ResponseWriter, r *http.Request) {
+	if h.cloudwatchService == nil {
+		http.Error(w, service.ErrASGNotConfigured.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Terminate    bool   `json:"terminate"`
+		ConfirmToken string `json:"confirm_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	plan, err := h.cloudwatchService.DrainInstance(r.Context(), claims.UserID, chi.URLParam(r, "id"), req.Terminate, req.ConfirmToken)
+	h.respondASGPlan(w, r, plan, err)
+}
@@ -0,0 +1,235 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// ===== MUTATION AUDIT =====
+//
+// auditMutations wraps every admin route, so each POST/PUT/PATCH/DELETE by
+// a signed-in admin lands in admin_audit_log whether or not its handler
+// remembered to call logAction. Handlers still call logAction to name the
+// action and target; on a mutating request those calls are held and
+// written when the handler returns, with the method, route, URL target and
+// response status added. A request whose handler logged nothing gets one
+// generic "METHOD /route" entry.
+
+type auditCtxKey struct{}
+
+// pendingAction is one logAction call held for the end of the request.
+type pendingAction struct {
+	action, targetType string
+	targetID           uuid.UUID
+	details            map[string]interface{}
+}
+
+// requestAudit collects what the handler supplied for one request.
+type requestAudit struct {
+	mu      sync.Mutex
+	actions []pendingAction
+	diff    map[string]interface{}
+	// done is set once the entries are written; later logAction calls
+	// (from goroutines the handler started) are written directly.
+	done bool
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// auditMutations is the admin router middleware described above. It is a
+// no-op inside another auditMutations, so sub-routers may repeat it.
+func (h *Handler) auditMutations(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, nested := r.Context().Value(auditCtxKey{}).(*requestAudit); nested || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ra := &requestAudit{}
+		sw := &auditStatusWriter{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(context.WithValue(r.Context(), auditCtxKey{}, ra))
+		defer h.writeRequestAudit(r, ra, sw)
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// writeRequestAudit records the request's held actions, or a generic
+// entry when there were none.
+func (h *Handler) writeRequestAudit(r *http.Request, ra *requestAudit, sw *auditStatusWriter) {
+	ra.mu.Lock()
+	actions, diff := ra.actions, ra.diff
+	ra.done = true
+	ra.mu.Unlock()
+
+	claims := middleware.GetAuthClaims(r.Context())
+	if claims == nil {
+		return
+	}
+	route := r.URL.Path
+	targetID := uuid.Nil
+	var params map[string]string
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if p := rctx.RoutePattern(); p != "" {
+			route = p
+		}
+		for i, key := range rctx.URLParams.Keys {
+			val := rctx.URLParams.Values[i]
+			if key == "*" || val == "" {
+				continue
+			}
+			if id, err := uuid.Parse(val); err == nil && targetID == uuid.Nil {
+				targetID = id
+				continue
+			}
+			if params == nil {
+				params = map[string]string{}
+			}
+			params[key] = val
+		}
+	}
+
+	if len(actions) == 0 {
+		action := r.Method + " " + route
+		if len(action) > 100 {
+			action = action[:100]
+		}
+		actions = []pendingAction{{action: action, targetType: routeTargetType(route), targetID: targetID}}
+	}
+	ip, ua := clientIP(r), r.UserAgent()
+	elevated := h.elevationIDs(r, claims)
+	for _, a := range actions {
+		details := make(map[string]interface{}, len(a.details)+6)
+		for k, v := range a.details {
+			details[k] = v
+		}
+		details["http_method"] = r.Method
+		details["route"] = route
+		details["status"] = sw.status
+		if params != nil {
+			details["route_params"] = params
+		}
+		if diff != nil {
+			details["diff"] = diff
+		}
+		if len(elevated) > 0 {
+			details["elevation_ids"] = elevated
+		}
+		if a.targetID == uuid.Nil {
+			a.targetID = targetID
+		}
+		h.adminRepo.LogAction(r.Context(), claims.UserID, a.action, a.targetType, a.targetID, details, ip, ua)
+	}
+}
+
+// elevationIDs returns the admin's active elevations so every audit row
+// written while one is open carries it. Super admins can't hold any.
+func (h *Handler) elevationIDs(r *http.Request, claims *service.AuthClaims) []uuid.UUID {
+	if h.elevationService == nil || claims.SystemRole == models.SystemRoleSuperAdmin {
+		return nil
+	}
+	return h.elevationService.ActiveIDs(r.Context(), claims.UserID)
+}
+
+// holdAction queues a logAction call on a mutating request. It reports
+// false when there is no pending audit and the caller should write
+// directly.
+func holdAction(r *http.Request, a pendingAction) bool {
+	ra, _ := r.Context().Value(auditCtxKey{}).(*requestAudit)
+	if ra == nil {
+		return false
+	}
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if ra.done {
+		return false
+	}
+	ra.actions = append(ra.actions, a)
+	return true
+}
+
+// auditDiff attaches a field-level diff of before and after to the
+// request's audit entry: {"field": {"from": old, "to": new}} for each key
+// whose value changed. Unchanged keys are left out.
+func auditDiff(r *http.Request, before, after map[string]interface{}) {
+	ra, _ := r.Context().Value(auditCtxKey{}).(*requestAudit)
+	if ra == nil {
+		return
+	}
+	diff := map[string]interface{}{}
+	for k, to := range after {
+		if from, ok := before[k]; !ok || !reflect.DeepEqual(from, to) {
+			diff[k] = map[string]interface{}{"from": before[k], "to": to}
+		}
+	}
+	for k, from := range before {
+		if _, ok := after[k]; !ok {
+			diff[k] = map[string]interface{}{"from": from, "to": nil}
+		}
+	}
+	ra.mu.Lock()
+	ra.diff = diff
+	ra.mu.Unlock()
+}
+
+// routeTargetType names a generic entry's target after the first literal
+// route segment below the admin group prefixes: "admins" for
+// /api/admin/super/admins/{id}.
+func routeTargetType(route string) string {
+	for _, seg := range strings.Split(route, "/") {
+		switch {
+		case seg == "", strings.HasPrefix(seg, "{"), seg == "*":
+		case seg == "api", seg == "admin", seg == "super", seg == "support", seg == "marketing":
+		default:
+			if len(seg) > 50 {
+				seg = seg[:50]
+			}
+			return seg
+		}
+	}
+	return "admin"
+}
+
+// auditStatusWriter remembers the response status for the audit entry.
+type auditStatusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *auditStatusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditStatusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditStatusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *auditStatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
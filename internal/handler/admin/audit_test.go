@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// auditExempt lists mutating routes that run before anyone is signed in,
+// so there is no admin to attribute them to. Accepting an invitation logs
+// its own row, with the new admin as the actor.
+var auditExempt = map[string]bool{
+	"UI POST /login":          true,
+	"UI POST /invite/{token}": true,
+}
+
+// TestMutatingRoutesAudited fails when a POST/PUT/PATCH/DELETE admin route
+// is registered somewhere auditMutations doesn't reach.
+func TestMutatingRoutesAudited(t *testing.T) {
+	h := NewHandler(nil, nil)
+	for name, router := range map[string]chi.Router{"API": h.Routes(), "UI": h.UIRoutes()} {
+		mutating := 0
+		err := chi.Walk(router, func(method, route string, _ http.Handler, mws ...func(http.Handler) http.Handler) error {
+			if !isMutating(method) {
+				return nil
+			}
+			mutating++
+			key := name + " " + method + " " + route
+			if auditExempt[key] {
+				return nil
+			}
+			for _, mw := range mws {
+				if strings.HasSuffix(runtime.FuncForPC(reflect.ValueOf(mw).Pointer()).Name(), ".auditMutations-fm") {
+					return nil
+				}
+			}
+			t.Errorf("%s has no audit middleware", key)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mutating == 0 {
+			t.Errorf("%s: walked no mutating routes", name)
+		}
+	}
+}
+
+type auditEntry struct {
+	action, targetType string
+	targetID           uuid.UUID
+	details            map[string]interface{}
+}
+
+// auditRepo records LogAction calls; everything else is unimplemented.
+type auditRepo struct {
+	repository.AdminRepository
+	entries []auditEntry
+}
+
+func (r *auditRepo) LogAction(ctx context.Context, adminID uuid.UUID, action, targetType string, targetID uuid.UUID, details map[string]interface{}, ip, userAgent string) error {
+	r.entries = append(r.entries, auditEntry{action, targetType, targetID, details})
+	return nil
+}
+
+func TestAuditMutations(t *testing.T) {
+	repo := &auditRepo{}
+	h := NewHandler(repo, nil)
+	admin := &service.AuthClaims{UserID: uuid.New()}
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), middleware.AuthClaimsKey, admin)))
+		})
+	})
+	r.Use(h.auditMutations)
+	r.Put("/super/settings/{key}", func(w http.ResponseWriter, r *http.Request) {
+		auditDiff(r, map[string]interface{}{"a": 1, "b": 2}, map[string]interface{}{"a": 1, "b": 3})
+		h.logAction(r, "update_setting", "system", uuid.Nil, map[string]interface{}{"key": "k"})
+	})
+	r.Delete("/super/tasks/{type}/dead/{entryID}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	})
+	r.Get("/super/admins", func(w http.ResponseWriter, r *http.Request) {
+		h.logAction(r, "list_admins", "admin", uuid.Nil, nil)
+	})
+
+	serve := func(method, path string) {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	}
+
+	// A handler's own entry gets the request context and diff attached.
+	serve(http.MethodPut, "/super/settings/k")
+	if len(repo.entries) != 1 {
+		t.Fatalf("entries = %+v, want one", repo.entries)
+	}
+	e := repo.entries[0]
+	if e.action != "update_setting" || e.details["key"] != "k" || e.details["route"] != "/super/settings/{key}" || e.details["status"] != http.StatusOK {
+		t.Errorf("handler entry = %+v", e)
+	}
+	diff, _ := e.details["diff"].(map[string]interface{})
+	if len(diff) != 1 || diff["b"] == nil {
+		t.Errorf("diff = %v, want only b", diff)
+	}
+
+	// A handler that logs nothing still gets a generic entry.
+	entryID := uuid.New()
+	serve(http.MethodDelete, "/super/tasks/email/dead/"+entryID.String())
+	e = repo.entries[1]
+	if e.action != "DELETE /super/tasks/{type}/dead/{entryID}" || e.targetType != "tasks" || e.targetID != entryID || e.details["status"] != http.StatusNotFound {
+		t.Errorf("generic entry = %+v", e)
+	}
+	if params, _ := e.details["route_params"].(map[string]string); params["type"] != "email" {
+		t.Errorf("route params = %v", e.details["route_params"])
+	}
+
+	// Reads are left to their handlers.
+	serve(http.MethodGet, "/super/admins")
+	if len(repo.entries) != 3 || repo.entries[2].action != "list_admins" || repo.entries[2].details != nil {
+		t.Errorf("read entry = %+v", repo.entries[2:])
+	}
+}
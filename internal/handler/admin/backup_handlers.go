@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// BACKUPS — RDS snapshots, nightly config dumps and restore drill results.
+// ============================================================================
+
+// BackupsPage renders /admin/backups.
+func (h *Handler) BackupsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID:         claims.UserID,
+		Email:      claims.Email,
+		FirstName:  claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "backups.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Backups",
+		CurrentUser: currentUser,
+	})
+}
+
+// ListBackups handles GET /api/admin/backups: recent snapshots, config
+// dumps and restore drills in one payload for the Backups page.
+func (h *Handler) ListBackups(w http.ResponseWriter, r *http.Request) {
+	if h.backupService == nil {
+		http.Error(w, "Backups unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	snapshots, err := h.backupService.List(ctx, repository.BackupKindSnapshot, 50)
+	if err != nil {
+		http.Error(w, "Failed to list snapshots: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dumps, err := h.backupService.List(ctx, repository.BackupKindConfigDump, 30)
+	if err != nil {
+		http.Error(w, "Failed to list dumps: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	drills, err := h.backupService.ListDrills(ctx, 20)
+	if err != nil {
+		http.Error(w, "Failed to list drills: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if snapshots == nil {
+		snapshots = []repository.DBBackup{}
+	}
+	if dumps == nil {
+		dumps = []repository.DBBackup{}
+	}
+	if drills == nil {
+		drills = []repository.RestoreDrill{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"snapshots_enabled": h.backupService.SnapshotsEnabled(),
+		"instance_id":       h.backupService.InstanceID(),
+		"dump_tables":       h.backupService.DumpTables(),
+		"snapshots":         snapshots,
+		"dumps":             dumps,
+		"drills":            drills,
+	})
+}
+
+// TriggerSnapshot handles POST /api/admin/backups/snapshot.
+func (h *Handler) TriggerSnapshot(w http.ResponseWriter, r *http.Request) {
+	if h.backupService == nil {
+		http.Error(w, "Backups unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	b, err := h.backupService.TriggerSnapshot(r.Context(), claims.UserID)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, service.ErrSnapshotsDisabled) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	h.logAction(r, "trigger_db_snapshot", "db_backup", b.ID, map[string]interface{}{
+		"identifier": b.Identifier,
+	})
+	respondJSON(w, b)
+}
+
+// SyncSnapshots handles POST /api/admin/backups/sync — pull snapshot status
+// from RDS now instead of waiting for the scheduler.
+func (h *Handler) SyncSnapshots(w http.ResponseWriter, r *http.Request) {
+	if h.backupService == nil {
+		http.Error(w, "Backups unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	n, err := h.backupService.SyncSnapshots(r.Context())
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, service.ErrSnapshotsDisabled) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	respondJSON(w, map[string]int{"snapshots": n})
+}
+
+// RunConfigDump handles POST /api/admin/backups/config-dump.
+func (h *Handler) RunConfigDump(w http.ResponseWriter, r *http.Request) {
+	if h.backupService == nil {
+		http.Error(w, "Backups unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	b, err := h.backupService.DumpConfigTables(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, "Config dump failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "run_config_dump", "db_backup", b.ID, map[string]interface{}{
+		"identifier": b.Identifier, "tables": len(b.Tables), "size_bytes": b.SizeBytes,
+	})
+	respondJSON(w, b)
+}
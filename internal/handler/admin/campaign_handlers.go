@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// campaignErrorStatus maps campaign service errors to HTTP status codes.
+func campaignErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCampaignNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCampaignInvalid), errors.Is(err, service.ErrCampaignReportRange):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrCampaignUTMTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListCampaigns handles GET /api/admin/marketing/campaigns?archived=1
+func (h *Handler) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	if h.campaignService == nil {
+		http.Error(w, "Campaign service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	archived := r.URL.Query().Get("archived") == "1" || r.URL.Query().Get("archived") == "true"
+	list, err := h.campaignService.ListCampaigns(r.Context(), archived)
+	if err != nil {
+		http.Error(w, "Failed to list campaigns: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"campaigns": list})
+}
+
+// CreateCampaign handles POST /api/admin/marketing/campaigns
+func (h *Handler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	if h.campaignService == nil {
+		http.Error(w, "Campaign service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var in service.CampaignInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var adminID uuid.UUID
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil {
+		adminID = claims.UserID
+	}
+	c, err := h.campaignService.CreateCampaign(r.Context(), in, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), campaignErrorStatus(err))
+		return
+	}
+	h.logAction(r, "create_campaign", "marketing_campaign", c.ID, map[string]interface{}{"utm_campaign": c.UTMCampaign})
+	respondJSON(w, c)
+}
+
+// GetCampaign handles GET /api/admin/marketing/campaigns/{id}
+func (h *Handler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	if h.campaignService == nil {
+		http.Error(w, "Campaign service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+	c, err := h.campaignService.GetCampaign(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), campaignErrorStatus(err))
+		return
+	}
+	spend, err := h.campaignService.ListSpend(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list spend: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"campaign": c, "spend": spend})
+}
+
+// UpdateCampaign handles PUT /api/admin/marketing/campaigns/{id}
+func (h *Handler) UpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	if h.campaignService == nil {
+		http.Error(w, "Campaign service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+	var in service.CampaignInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	c, err := h.campaignService.UpdateCampaign(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), campaignErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_campaign", "marketing_campaign", id, map[string]interface{}{"utm_campaign": c.UTMCampaign, "archived": c.IsArchived})
+	respondJSON(w, c)
+}
+
+// AddCampaignSpend handles POST /api/admin/marketing/campaigns/{id}/spend
+// {"spent_on":"YYYY-MM-DD","amount_cents":12500,"note":"..."}
+func (h *Handler) AddCampaignSpend(w http.ResponseWriter, r *http.Request) {
+	if h.campaignService == nil {
+		http.Error(w, "Campaign service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+	var in service.SpendInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var adminID uuid.UUID
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil {
+		adminID = claims.UserID
+	}
+	sp, err := h.campaignService.AddSpend(r.Context(), id, in, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), campaignErrorStatus(err))
+		return
+	}
+	h.logAction(r, "add_campaign_spend", "marketing_campaign", id, map[string]interface{}{
+		"spend_id":     sp.ID,
+		"amount_cents": sp.AmountCents,
+		"spent_on":     sp.SpentOn.Format("2006-01-02"),
+	})
+	respondJSON(w, sp)
+}
+
+// DeleteCampaignSpend handles DELETE /api/admin/marketing/campaigns/{id}/spend/{spendID}
+func (h *Handler) DeleteCampaignSpend(w http.ResponseWriter, r *http.Request) {
+	if h.campaignService == nil {
+		http.Error(w, "Campaign service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+	spendID, err := uuid.Parse(chi.URLParam(r, "spendID"))
+	if err != nil {
+		http.Error(w, "Invalid spend ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.campaignService.DeleteSpend(r.Context(), id, spendID); err != nil {
+		http.Error(w, err.Error(), campaignErrorStatus(err))
+		return
+	}
+	h.logAction(r, "delete_campaign_spend", "marketing_campaign", id, map[string]interface{}{"spend_id": spendID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCampaignROIReport handles GET /api/admin/marketing/campaigns/report
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD — defaults to the last 90 days.
+func (h *Handler) GetCampaignROIReport(w http.ResponseWriter, r *http.Request) {
+	if h.campaignService == nil {
+		http.Error(w, "Campaign service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	from, to, err := reportWindowFromQuery(r.URL.Query(), 90)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := h.campaignService.ROIReport(r.Context(), from, to)
+	if err != nil {
+		status := campaignErrorStatus(err)
+		msg := err.Error()
+		if status == http.StatusInternalServerError {
+			msg = "Failed to build campaign report: " + msg
+		}
+		http.Error(w, msg, status)
+		return
+	}
+	respondJSON(w, report)
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// CAPTCHA — which public endpoints ask for one in this environment, and
+// how verification has gone, shown as a panel on the settings page. The
+// provider and keys are config (CAPTCHA_PROVIDER, CAPTCHA_SITE_KEY,
+// CAPTCHA_SECRET_KEY).
+// ============================================================================
+
+// GetCaptcha handles GET /api/admin/super/captcha?days=7.
+func (h *Handler) GetCaptcha(w http.ResponseWriter, r *http.Request) {
+	if h.captchaService == nil {
+		http.Error(w, "CAPTCHA unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	settings, err := h.captchaService.Settings(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load CAPTCHA settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	metrics, err := h.captchaService.Metrics(r.Context(), getIntParam(r, "days", 7))
+	if err != nil {
+		http.Error(w, "Failed to load CAPTCHA metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"settings": settings,
+		"provider": h.captchaService.Provider(),
+		"metrics":  metrics,
+	})
+}
+
+// UpdateCaptcha handles PUT /api/admin/super/captcha.
+func (h *Handler) UpdateCaptcha(w http.ResponseWriter, r *http.Request) {
+	if h.captchaService == nil {
+		http.Error(w, "CAPTCHA unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	var req service.CaptchaSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Enabled && h.captchaService.Provider() == "" {
+		http.Error(w, "No CAPTCHA provider is configured (CAPTCHA_PROVIDER, CAPTCHA_SECRET_KEY)", http.StatusBadRequest)
+		return
+	}
+	before, err := h.captchaService.Settings(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load CAPTCHA settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	claims := middleware.GetAuthClaims(ctx)
+	if err := h.captchaService.UpdateSettings(ctx, req, claims.UserID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logAction(r, "update_captcha_settings", "system", uuid.Nil, map[string]interface{}{
+		"before": before, "after": req,
+	})
+	respondJSON(w, req)
+}
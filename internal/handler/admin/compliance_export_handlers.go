@@ -0,0 +1,224 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// COMPLIANCE EXPORTS — CSV downloads of the audit log, settings history and
+// PHI access alerts for compliance auditors (and super admins). Each file
+// opens with a watermark naming who pulled it and when, every row carries
+// the export id, and the download itself is audited. Rate limited in
+// routes.go.
+// ============================================================================
+
+// complianceFilter reads ?from= and ?to= (YYYY-MM-DD, both inclusive),
+// ?admin_id= and ?action=, writing 400 on a bad date.
+func complianceFilter(w http.ResponseWriter, r *http.Request) (repository.ComplianceExportFilter, bool) {
+	q := r.URL.Query()
+	var f repository.ComplianceExportFilter
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+		add  time.Duration
+	}{{"from", &f.From, 0}, {"to", &f.To, 24 * time.Hour}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				http.Error(w, "Invalid "+p.name+" date (use YYYY-MM-DD)", http.StatusBadRequest)
+				return f, false
+			}
+			*p.dst = t.Add(p.add)
+		}
+	}
+	f.AdminID, _ = uuid.Parse(q.Get("admin_id"))
+	f.Action = q.Get("action")
+	return f, true
+}
+
+// csvCell defuses spreadsheet formulas: audit details and user agents are
+// attacker-influenced and auditors open these files in Excel.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// watermarkedCSV writes rows under export's watermark line, appending the
+// export id to each.
+type watermarkedCSV struct {
+	w      *csv.Writer
+	export service.ComplianceExport
+	rows   int
+}
+
+func newWatermarkedCSV(w http.ResponseWriter, export service.ComplianceExport, header []string) *watermarkedCSV {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=carecompanion_"+export.Kind+"_"+
+		export.ExportedAt.UTC().Format("2006-01-02")+"_"+export.ID.String()[:8]+".csv")
+	w.Header().Set("X-Export-ID", export.ID.String())
+	out := &watermarkedCSV{w: csv.NewWriter(w), export: export}
+	out.w.Write([]string{"# " + export.Watermark()})
+	out.w.Write(append(header, "Export ID"))
+	return out
+}
+
+func (c *watermarkedCSV) Write(cells ...string) error {
+	for i, s := range cells {
+		cells[i] = csvCell(s)
+	}
+	c.rows++
+	return c.w.Write(append(cells, c.export.ID.String()))
+}
+
+// Close flushes, noting err (a failure partway through) in the file so a
+// truncated export can't pass for a complete one.
+func (c *watermarkedCSV) Close(err error) {
+	if err != nil {
+		log.Printf("[COMPLIANCE] %s export %s failed after %d rows: %v", c.export.Kind, c.export.ID, c.rows, err)
+		c.w.Write([]string{"# EXPORT INCOMPLETE: stopped after " + strconv.Itoa(c.rows) + " rows"})
+	} else if c.rows >= repository.ComplianceExportMaxRows {
+		c.w.Write([]string{"# EXPORT TRUNCATED at " + strconv.Itoa(c.rows) + " rows; narrow the date range"})
+	}
+	c.w.Flush()
+}
+
+func (h *Handler) startComplianceExport(w http.ResponseWriter, r *http.Request, kind string) (service.ComplianceExport, repository.ComplianceExportFilter, bool) {
+	if h.complianceExportService == nil {
+		http.Error(w, "Compliance exports unavailable", http.StatusServiceUnavailable)
+		return service.ComplianceExport{}, repository.ComplianceExportFilter{}, false
+	}
+	f, ok := complianceFilter(w, r)
+	if !ok {
+		return service.ComplianceExport{}, f, false
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	export, err := h.complianceExportService.Start(kind, claims.Email, f)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to start export")
+		return export, f, false
+	}
+	return export, f, true
+}
+
+// logComplianceExport audits a finished download.
+func (h *Handler) logComplianceExport(r *http.Request, c *watermarkedCSV, f repository.ComplianceExportFilter) {
+	details := map[string]interface{}{"kind": c.export.Kind, "rows": c.rows}
+	if !f.From.IsZero() {
+		details["from"] = f.From.Format("2006-01-02")
+	}
+	if !f.To.IsZero() {
+		details["to"] = f.To.Add(-24 * time.Hour).Format("2006-01-02")
+	}
+	if f.AdminID != uuid.Nil {
+		details["admin_id"] = f.AdminID
+	}
+	if f.Action != "" {
+		details["action"] = f.Action
+	}
+	h.logAction(r, "compliance_export", "compliance_export", c.export.ID, details)
+}
+
+func auditEntryCells(e repository.AuditEntry) []string {
+	target, details := "", ""
+	if e.TargetID.Valid {
+		target = e.TargetID.UUID.String()
+	}
+	if e.Details != nil {
+		b, _ := json.Marshal(e.Details)
+		details = string(b)
+	}
+	admin := ""
+	if e.AdminID != uuid.Nil {
+		admin = e.AdminID.String()
+	}
+	return []string{e.ID.String(), e.CreatedAt.UTC().Format(time.RFC3339), admin, e.AdminEmail,
+		e.Action, e.TargetType, target, details, e.IPAddress, e.UserAgent}
+}
+
+var auditEntryHeader = []string{"Entry ID", "Time (UTC)", "Admin ID", "Admin Email", "Action",
+	"Target Type", "Target ID", "Details", "IP Address", "User Agent"}
+
+// ExportAuditLog handles GET /compliance/exports/audit-log.csv with
+// ?from, ?to, ?admin_id and ?action.
+func (h *Handler) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	export, f, ok := h.startComplianceExport(w, r, service.ComplianceExportAuditLog)
+	if !ok {
+		return
+	}
+	out := newWatermarkedCSV(w, export, auditEntryHeader)
+	err := h.complianceExportService.AuditLog(r.Context(), f, func(e repository.AuditEntry) error {
+		return out.Write(auditEntryCells(e)...)
+	})
+	out.Close(err)
+	h.logComplianceExport(r, out, f)
+}
+
+// ExportSettingsHistory handles GET /compliance/exports/settings-history.csv
+// with ?from and ?to: every settings and policy change from the audit log.
+func (h *Handler) ExportSettingsHistory(w http.ResponseWriter, r *http.Request) {
+	export, f, ok := h.startComplianceExport(w, r, service.ComplianceExportSettingsHistory)
+	if !ok {
+		return
+	}
+	out := newWatermarkedCSV(w, export, auditEntryHeader)
+	err := h.complianceExportService.SettingsHistory(r.Context(), f, func(e repository.AuditEntry) error {
+		return out.Write(auditEntryCells(e)...)
+	})
+	out.Close(err)
+	h.logComplianceExport(r, out, f)
+}
+
+// ExportPHIAccess handles GET /compliance/exports/phi-access.csv with
+// ?from and ?to: the PHI watchdog's alerts.
+func (h *Handler) ExportPHIAccess(w http.ResponseWriter, r *http.Request) {
+	export, f, ok := h.startComplianceExport(w, r, service.ComplianceExportPHIAccess)
+	if !ok {
+		return
+	}
+	out := newWatermarkedCSV(w, export, []string{"Alert ID", "Time (UTC)", "Severity", "Title", "Message",
+		"Details", "Acknowledged At (UTC)", "Acknowledged By"})
+	err := h.complianceExportService.PHIAccess(r.Context(), f, func(a repository.PHIAccessAlert) error {
+		acked := ""
+		if a.AcknowledgedAt != nil {
+			acked = a.AcknowledgedAt.UTC().Format(time.RFC3339)
+		}
+		return out.Write(a.ID.String(), a.CreatedAt.UTC().Format(time.RFC3339), a.Severity, a.Title, a.Message,
+			string(a.Details), acked, a.AcknowledgedBy)
+	})
+	out.Close(err)
+	h.logComplianceExport(r, out, f)
+}
+
+// ComplianceExportsPage renders the export picker.
+func (h *Handler) ComplianceExportsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	tmpl, err := parseTemplates("layout.html", "compliance_exports.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title: "Compliance Exports",
+		CurrentUser: AdminUser{
+			ID:         claims.UserID,
+			Email:      claims.Email,
+			FirstName:  claims.FirstName,
+			SystemRole: string(claims.SystemRole),
+		},
+		Data: map[string]interface{}{"MaxRows": repository.ComplianceExportMaxRows},
+	})
+}
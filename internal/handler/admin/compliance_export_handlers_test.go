@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/service"
+)
+
+func TestWatermarkedCSV(t *testing.T) {
+	export := service.ComplianceExport{ID: uuid.New(), Kind: "audit_log", ExportedBy: "a@example.com", ExportedAt: time.Now()}
+	rec := httptest.NewRecorder()
+	out := newWatermarkedCSV(rec, export, []string{"Action", "User Agent"})
+	out.Write("update_setting", "=HYPERLINK(\"http://x\")")
+	out.Close(nil)
+
+	if rec.Header().Get("X-Export-ID") != export.ID.String() {
+		t.Errorf("X-Export-ID = %q", rec.Header().Get("X-Export-ID"))
+	}
+	r := csv.NewReader(strings.NewReader(rec.Body.String()))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || !strings.Contains(rows[0][0], export.ID.String()) {
+		t.Fatalf("rows = %q", rows)
+	}
+	if got := rows[1]; len(got) != 3 || got[2] != "Export ID" {
+		t.Errorf("header = %q", got)
+	}
+	if got := rows[2]; got[1] != "'=HYPERLINK(\"http://x\")" || got[2] != export.ID.String() {
+		t.Errorf("row = %q", got)
+	}
+}
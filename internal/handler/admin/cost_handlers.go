@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// AWS COSTS — daily cost by service from Cost Explorer, budget tracking and
+// cost per active user, shown as a panel on the infrastructure page.
+// ============================================================================
+
+// GetCostSummary handles GET /api/admin/super/costs.
+func (h *Handler) GetCostSummary(w http.ResponseWriter, r *http.Request) {
+	if h.costService == nil {
+		http.Error(w, "Cost tracking unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	sum, err := h.costService.Summary(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load costs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, sum)
+}
+
+// SyncCosts handles POST /api/admin/super/costs/sync — pull from Cost
+// Explorer now instead of waiting for the daily sync. Each call is billed
+// by AWS, hence the audit entry.
+func (h *Handler) SyncCosts(w http.ResponseWriter, r *http.Request) {
+	if h.costService == nil {
+		http.Error(w, "Cost tracking unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	n, err := h.costService.Sync(r.Context())
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, service.ErrCostExplorerDisabled) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	h.logAction(r, "sync_aws_costs", "aws_costs", uuid.Nil, map[string]interface{}{"rows": n})
+	respondJSON(w, map[string]int{"rows": n})
+}
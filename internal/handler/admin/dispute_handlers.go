@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ListDisputes handles GET /financials/disputes?status=open|closed&page=.
+// Open disputes come first, most urgent deadline first.
+func (h *Handler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	if h.disputeService == nil {
+		http.Error(w, "Disputes unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "open" && status != "closed" {
+		http.Error(w, "status must be open or closed", http.StatusBadRequest)
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	const limit = 25
+	disputes, total, err := h.disputeService.List(r.Context(), repository.DisputeFilter{
+		Status: status,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		http.Error(w, "Failed to load disputes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if disputes == nil {
+		disputes = []repository.Dispute{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"disputes": disputes,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// UpdateDispute handles PUT /financials/disputes/{id} with
+// {"notes": "...", "evidence_submitted": true}; either field may be
+// omitted. Marking evidence submitted stops deadline reminders.
+func (h *Handler) UpdateDispute(w http.ResponseWriter, r *http.Request) {
+	if h.disputeService == nil {
+		http.Error(w, "Disputes unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Notes             *string `json:"notes"`
+		EvidenceSubmitted *bool   `json:"evidence_submitted"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var d *repository.Dispute
+	if req.Notes != nil {
+		d, err = h.disputeService.SetNotes(r.Context(), id, *req.Notes)
+	}
+	if err == nil && req.EvidenceSubmitted != nil {
+		d, err = h.disputeService.MarkEvidenceSubmitted(r.Context(), id, *req.EvidenceSubmitted)
+	}
+	if errors.Is(err, service.ErrDisputeNotFound) {
+		http.Error(w, "Dispute not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update dispute: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if d == nil {
+		http.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+	details := map[string]interface{}{"stripe_dispute_id": d.StripeDisputeID}
+	if req.EvidenceSubmitted != nil {
+		details["evidence_submitted"] = *req.EvidenceSubmitted
+	}
+	if req.Notes != nil {
+		details["notes_updated"] = true
+	}
+	h.logAction(r, "update_dispute", "dispute", d.ID, details)
+	respondJSON(w, d)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
@@ -125,6 +126,69 @@ func (h *Handler) ListTicketAttachments(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, atts)
 }
 
+// UploadTicketAttachment handles POST /api/admin/support/tickets/{id}/attachments
+// (multipart, field "file"). The upload is pending until it is referenced
+// in attachment_ids on the next AddTicketMessage.
+func (h *Handler) UploadTicketAttachment(w http.ResponseWriter, r *http.Request) {
+	if h.attachService == nil {
+		http.Error(w, "Attachment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket id", http.StatusBadRequest)
+		return
+	}
+	ticket, err := h.adminRepo.GetTicketByID(ctx, id)
+	if err != nil || ticket == nil {
+		http.Error(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachService.MaxBytes()+1*1024*1024)
+	if err := r.ParseMultipartForm(8 * 1024 * 1024); err != nil {
+		http.Error(w, "Upload too large or malformed", http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	claims := middleware.GetAuthClaims(ctx)
+	att, err := h.attachService.Upload(ctx, service.UploadInput{
+		TicketID:    id,
+		UploaderID:  claims.UserID,
+		Filename:    filepath.Base(header.Filename),
+		ContentType: header.Header.Get("Content-Type"),
+		Body:        file,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAttachmentTooBig),
+			errors.Is(err, service.ErrAttachmentTypeNotAllowed),
+			errors.Is(err, service.ErrAttachmentContentMismatch),
+			errors.Is(err, service.ErrAttachmentLimitReached):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrUploadQuarantined):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrScanUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, "Upload failed: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	h.logAction(r, "upload_ticket_attachment", "ticket", id, map[string]interface{}{
+		"attachment_id": att.ID, "content_type": att.ContentType, "size_bytes": att.SizeBytes,
+		"scan_status": att.ScanStatus,
+	})
+	respondJSON(w, att)
+}
+
 // FetchTicketAttachment streams an attachment for admin view (no ownership
 // check; auth is enforced by the support / super_admin middleware).
 func (h *Handler) FetchTicketAttachment(w http.ResponseWriter, r *http.Request) {
@@ -143,8 +207,13 @@ func (h *Handler) FetchTicketAttachment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	defer body.Close()
+	disposition := "inline"
+	if att.Kind == "log" {
+		disposition = "attachment"
+	}
 	w.Header().Set("Content-Type", att.ContentType)
-	w.Header().Set("Content-Disposition", "inline; filename=\""+att.OriginalName+"\"")
+	w.Header().Set("Content-Disposition", disposition+"; filename=\""+att.OriginalName+"\"")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
 	if att.SizeBytes > 0 {
 		w.Header().Set("Content-Length", strconv.FormatInt(att.SizeBytes, 10))
 	}
@@ -0,0 +1,243 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/auth"
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// JUST-IN-TIME ELEVATION — an admin asks for one section at a higher level
+// than their role grants (e.g. financials at write for two hours) with a
+// reason; a super admin approves or denies it. RequireSection honours an
+// approved elevation until it expires or is revoked, and every audit row
+// the admin writes meanwhile carries elevation_ids.
+// ============================================================================
+
+// elevationID parses the {elevationID} URL param, writing 400 on failure.
+func elevationID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "elevationID"))
+	if err != nil {
+		http.Error(w, "Invalid elevation ID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// ListElevations handles GET /elevations. Admins see their own requests;
+// super admins see everyone's, filtered by ?status= (pending, active,
+// expired, approved, denied, cancelled, revoked) and ?admin_id=.
+func (h *Handler) ListElevations(w http.ResponseWriter, r *http.Request) {
+	if h.elevationService == nil {
+		http.Error(w, "Elevation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	f := repository.AdminElevationFilter{Status: r.URL.Query().Get("status"), Limit: getIntParam(r, "limit", 100)}
+	if claims.SystemRole != models.SystemRoleSuperAdmin {
+		f.AdminID = claims.UserID
+	} else if id, err := uuid.Parse(r.URL.Query().Get("admin_id")); err == nil {
+		f.AdminID = id
+	}
+	list, err := h.elevationService.List(r.Context(), f)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to list elevations")
+		return
+	}
+	if list == nil {
+		list = []repository.AdminElevation{}
+	}
+	respondJSON(w, map[string]interface{}{"elevations": list})
+}
+
+// ListActiveElevations handles GET /elevations/active: the dashboard of
+// elevations in force right now.
+func (h *Handler) ListActiveElevations(w http.ResponseWriter, r *http.Request) {
+	if h.elevationService == nil {
+		http.Error(w, "Elevation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := h.elevationService.List(r.Context(), repository.AdminElevationFilter{Status: "active", Limit: 500})
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to list active elevations")
+		return
+	}
+	if list == nil {
+		list = []repository.AdminElevation{}
+	}
+	respondJSON(w, map[string]interface{}{"elevations": list})
+}
+
+// RequestElevation handles POST /elevations with {"section", "level",
+// "duration_minutes", "reason"}.
+func (h *Handler) RequestElevation(w http.ResponseWriter, r *http.Request) {
+	if h.elevationService == nil {
+		http.Error(w, "Elevation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req service.ElevationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	e, err := h.elevationService.Request(r.Context(), claims.UserID, claims.SystemRole, req)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to request elevation")
+		return
+	}
+	h.logAction(r, "request_elevation", "elevation", e.ID, map[string]interface{}{
+		"section": e.Section, "level": e.Level, "duration_minutes": e.DurationMinutes, "reason": e.Reason,
+	})
+	w.WriteHeader(http.StatusCreated)
+	respondJSON(w, e)
+}
+
+// decideNote reads the optional {"note"} body shared by approve and
+// deny.
+func decideNote(r *http.Request) string {
+	var req struct {
+		Note string `json:"note"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	return req.Note
+}
+
+// ApproveElevation handles POST /elevations/{elevationID}/approve. The
+// window starts now.
+func (h *Handler) ApproveElevation(w http.ResponseWriter, r *http.Request) {
+	if h.elevationService == nil {
+		http.Error(w, "Elevation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := elevationID(w, r)
+	if !ok {
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	e, err := h.elevationService.Approve(r.Context(), id, claims.UserID, decideNote(r))
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to approve elevation")
+		return
+	}
+	h.logAction(r, "approve_elevation", "elevation", e.ID, map[string]interface{}{
+		"admin_id": e.AdminID, "email": e.AdminEmail, "section": e.Section, "level": e.Level,
+		"expires_at": e.ExpiresAt, "note": e.DecisionNote,
+	})
+	respondJSON(w, e)
+}
+
+// DenyElevation handles POST /elevations/{elevationID}/deny.
+func (h *Handler) DenyElevation(w http.ResponseWriter, r *http.Request) {
+	if h.elevationService == nil {
+		http.Error(w, "Elevation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := elevationID(w, r)
+	if !ok {
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	e, err := h.elevationService.Deny(r.Context(), id, claims.UserID, decideNote(r))
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to deny elevation")
+		return
+	}
+	h.logAction(r, "deny_elevation", "elevation", e.ID, map[string]interface{}{
+		"admin_id": e.AdminID, "email": e.AdminEmail, "section": e.Section, "level": e.Level, "note": e.DecisionNote,
+	})
+	respondJSON(w, e)
+}
+
+// CancelElevation handles POST /elevations/{elevationID}/cancel: the
+// requester withdraws a pending request.
+func (h *Handler) CancelElevation(w http.ResponseWriter, r *http.Request) {
+	if h.elevationService == nil {
+		http.Error(w, "Elevation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := elevationID(w, r)
+	if !ok {
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	e, err := h.elevationService.Cancel(r.Context(), id, claims.UserID)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to cancel elevation")
+		return
+	}
+	h.logAction(r, "cancel_elevation", "elevation", e.ID, map[string]interface{}{"section": e.Section})
+	respondJSON(w, e)
+}
+
+// RevokeElevation handles POST /elevations/{elevationID}/revoke: ends an
+// active elevation early. The requester may end their own; super admins
+// may end anyone's.
+func (h *Handler) RevokeElevation(w http.ResponseWriter, r *http.Request) {
+	if h.elevationService == nil {
+		http.Error(w, "Elevation unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := elevationID(w, r)
+	if !ok {
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	e, err := h.elevationService.Revoke(r.Context(), id, claims.UserID, claims.SystemRole == models.SystemRoleSuperAdmin)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to revoke elevation")
+		return
+	}
+	h.logAction(r, "revoke_elevation", "elevation", e.ID, map[string]interface{}{
+		"admin_id": e.AdminID, "email": e.AdminEmail, "section": e.Section, "level": e.Level,
+	})
+	respondJSON(w, e)
+}
+
+// elevationSection is one row of the request form's section picker.
+type elevationSection struct {
+	Key     string
+	Label   string
+	Current string
+}
+
+// ElevationsPage renders the request form and the admin's requests; super
+// admins also get the approval queue and the active elevations dashboard.
+func (h *Handler) ElevationsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	tmpl, err := parseTemplates("layout.html", "elevations.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var sections []elevationSection
+	for _, s := range auth.Sections {
+		current := auth.Matrix(claims.SystemRole, s)
+		if current == auth.LevelFull {
+			continue
+		}
+		label := auth.SectionLabels[s]
+		if label == "" {
+			label = s
+		}
+		sections = append(sections, elevationSection{Key: s, Label: label, Current: string(current)})
+	}
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title: "Elevated Access",
+		CurrentUser: AdminUser{
+			ID:         claims.UserID,
+			Email:      claims.Email,
+			FirstName:  claims.FirstName,
+			SystemRole: string(claims.SystemRole),
+		},
+		Data: map[string]interface{}{"Sections": sections},
+	})
+}
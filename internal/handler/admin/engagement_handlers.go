@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// CHURN RISK — families whose nightly engagement score is dropping with a
+// paid renewal coming up, and the CSV segment export for outreach.
+// ============================================================================
+
+// ChurnRiskPage renders /admin/marketing/churn-risk.
+func (h *Handler) ChurnRiskPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID:         claims.UserID,
+		Email:      claims.Email,
+		FirstName:  claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "churn_risk.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Churn Risk",
+		CurrentUser: currentUser,
+	})
+}
+
+func churnRiskParams(r *http.Request) service.ChurnRiskParams {
+	return service.ChurnRiskParams{
+		RenewalDays: getIntParam(r, "renewal_days", 0),
+		MinDrop:     getIntParam(r, "min_drop", 0),
+		LowScore:    getIntParam(r, "low_score", 0),
+		CompareDays: getIntParam(r, "compare_days", 0),
+	}
+}
+
+// ListChurnRisk handles GET /api/admin/marketing/churn-risk
+// ?renewal_days=&min_drop=&low_score=&compare_days= (all optional).
+func (h *Handler) ListChurnRisk(w http.ResponseWriter, r *http.Request) {
+	if h.engagementService == nil {
+		http.Error(w, "Engagement scoring unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := h.engagementService.ChurnRisk(r.Context(), churnRiskParams(r))
+	if err != nil {
+		http.Error(w, "Failed to load churn risk: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, list)
+}
+
+// ExportChurnRisk handles GET /api/admin/marketing/churn-risk/export — the
+// same segment as CSV for an outreach campaign. Only the contact's name and
+// email, the plan and renewal, and the engagement numbers are exported;
+// nothing about the children or what was logged.
+func (h *Handler) ExportChurnRisk(w http.ResponseWriter, r *http.Request) {
+	if h.engagementService == nil {
+		http.Error(w, "Engagement scoring unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := h.engagementService.ChurnRisk(r.Context(), churnRiskParams(r))
+	if err != nil {
+		http.Error(w, "Failed to load churn risk: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	day := time.Now().UTC()
+	if list.ScoreDate != nil {
+		day = *list.ScoreDate
+	}
+	h.logAction(r, "export_churn_risk", "engagement", uuid.Nil, map[string]interface{}{
+		"score_date":   day.Format("2006-01-02"),
+		"families":     len(list.Families),
+		"renewal_days": list.Filter.RenewalDays,
+		"min_drop":     list.Filter.MinDrop,
+		"low_score":    list.Filter.LowScore,
+	})
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=carecompanion_churn_risk_"+day.Format("2006-01-02")+".csv")
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	writer.Write([]string{"Family ID", "Contact Email", "Contact First Name", "Plan", "Status", "Renews On",
+		"Cancels At Period End", "Score", "Previous Score", "Last Active On"})
+	for _, f := range list.Families {
+		prev, lastActive := "", ""
+		if f.PreviousScore != nil {
+			prev = strconv.Itoa(*f.PreviousScore)
+		}
+		if f.LastActiveOn != nil {
+			lastActive = f.LastActiveOn.Format("2006-01-02")
+		}
+		writer.Write([]string{
+			f.FamilyID.String(),
+			f.ContactEmail,
+			f.ContactFirstName,
+			f.PlanName,
+			f.Status,
+			f.RenewsAt.Format("2006-01-02"),
+			strconv.FormatBool(f.CancelAtPeriodEnd),
+			strconv.Itoa(f.Score),
+			prev,
+			lastActive,
+		})
+	}
+}
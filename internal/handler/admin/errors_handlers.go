@@ -2,6 +2,8 @@ package admin
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,6 +13,8 @@ import (
 
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
 )
 
 // ListErrorLogs returns paginated error logs with filtering
@@ -47,17 +51,32 @@ func (h *Handler) ListErrorLogs(w http.ResponseWriter, r *http.Request) {
 	// Check if include_noise is set to show all errors
 	includeNoise := r.URL.Query().Get("include_noise") == "true"
 
-	logs, total, err := h.adminRepo.GetErrorLogs(r.Context(), page, limit, errorType, acknowledged, sources, includeNoise)
+	// Sparse fieldset, e.g. fields=error_type,path,created_at to skip the
+	// stack traces in the list view
+	fields := middleware.SparseFields(r)
+
+	logs, total, err := h.adminRepo.GetErrorLogs(r.Context(), page, limit, errorType, acknowledged, sources, includeNoise, fields)
+	if errors.Is(err, repository.ErrUnknownField) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch error logs: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	var logsJSON any = logs
+	if fields != nil {
+		if logsJSON, err = middleware.ProjectJSON(logs, fields); err != nil {
+			http.Error(w, "Failed to fetch error logs: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	// Get source counts for the UI
 	sourceCounts, _ := h.adminRepo.GetErrorLogSourceCounts(r.Context())
 
 	response := map[string]interface{}{
-		"logs":          logs,
+		"logs":          logsJSON,
 		"total":         total,
 		"page":          page,
 		"limit":         limit,
@@ -178,16 +197,13 @@ func (h *Handler) DeleteErrorLogsBulk(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := middleware.GetUserID(r.Context())
-	if err := h.adminRepo.DeleteErrorLogsBulk(r.Context(), req.IDs, userID); err != nil {
-		http.Error(w, "Failed to delete error logs: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "deleted",
-		"count":  len(req.IDs),
+	// Held until the admin confirms by typing "delete N errors".
+	h.requestPendingAction(w, r, service.PendingActionRequest{
+		Action:        pendingBulkDeleteErrorLog,
+		TargetType:    "error_log",
+		Params:        map[string]interface{}{"ids": req.IDs},
+		Summary:       fmt.Sprintf("Delete %d error logs", len(req.IDs)),
+		ConfirmPhrase: fmt.Sprintf("delete %d errors", len(req.IDs)),
 	})
 }
 
@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// GetNPSReport handles GET /api/admin/super/feedback/nps/report
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD&interval=week|month|quarter
+// Defaults to the last 12 months, bucketed by month.
+func (h *Handler) GetNPSReport(w http.ResponseWriter, r *http.Request) {
+	if h.feedbackService == nil {
+		http.Error(w, "Feedback service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	from, to, err := reportWindowFromQuery(q, 365)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := h.feedbackService.NPSReport(r.Context(), from, to, q.Get("interval"))
+	if err != nil {
+		if errors.Is(err, service.ErrFeedbackReportRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to build NPS report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, report)
+}
+
+// ListFeedback handles GET /api/admin/super/feedback
+// ?kind=nps|feedback&min_score=&max_score=&with_comment=1&from=&to=&page=&limit=
+func (h *Handler) ListFeedback(w http.ResponseWriter, r *http.Request) {
+	if h.feedbackService == nil {
+		http.Error(w, "Feedback service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	f := repository.FeedbackFilter{
+		Kind:        q.Get("kind"),
+		WithComment: q.Get("with_comment") == "1" || q.Get("with_comment") == "true",
+	}
+	if q.Get("from") != "" || q.Get("to") != "" {
+		from, to, err := reportWindowFromQuery(q, 365)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.From, f.To = from, to
+	}
+	if v, err := strconv.Atoi(q.Get("min_score")); err == nil {
+		f.MinScore = &v
+	}
+	if v, err := strconv.Atoi(q.Get("max_score")); err == nil {
+		f.MaxScore = &v
+	}
+	page := getIntParam(r, "page", 1)
+	limit := getIntParam(r, "limit", 25)
+
+	items, total, err := h.feedbackService.ListFeedback(r.Context(), f, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to list feedback: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"feedback": items,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
@@ -0,0 +1,378 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// FILE TRANSFER (/filextfer) — admin file drop with expiring share links
+// ============================================================================
+
+// fileTransferErrorStatus maps file transfer errors to HTTP status codes.
+func fileTransferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTransferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTransferInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTransferTooLarge), errors.Is(err, service.ErrTransferQuotaExceeded):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrTransferExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrTransferPasswordRequired), errors.Is(err, service.ErrTransferPasswordWrong):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrUploadQuarantined):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrScanUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ttlHours reads an optional whole-hours lifetime; 0 means the default.
+func ttlHours(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	h, err := strconv.Atoi(v)
+	if err != nil || h < 0 {
+		return 0, fmt.Errorf("%w: ttl_hours must be a whole number of hours", service.ErrTransferInvalid)
+	}
+	return time.Duration(h) * time.Hour, nil
+}
+
+// transferIOTimeout replaces the server's 15s read/write timeouts for
+// upload and download requests, which would otherwise cut off large files.
+const transferIOTimeout = 15 * time.Minute
+
+func extendTransferDeadline(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(transferIOTimeout)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+}
+
+// serveTransfer streams a stored file as an attachment with its checksum.
+func serveTransfer(w http.ResponseWriter, f *repository.FileTransfer, rc io.ReadCloser) {
+	defer rc.Close()
+	extendTransferDeadline(w)
+	w.Header().Set("Content-Type", f.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Filename}))
+	w.Header().Set("Content-Length", strconv.FormatInt(f.SizeBytes, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Checksum-SHA256", f.SHA256)
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("[FILEXFER] download of %s interrupted: %v", f.ID, err)
+	}
+}
+
+// ListFileTransfers handles GET /api/admin/file-transfers
+func (h *Handler) ListFileTransfers(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	files, usage, err := h.fileTransferService.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list files: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"files": files, "usage": usage})
+}
+
+// UploadFileTransfer handles POST /api/admin/file-transfers (multipart: file,
+// ttl_hours, note). The body is streamed to storage, not buffered.
+func (h *Handler) UploadFileTransfer(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	extendTransferDeadline(w)
+	// Allow some slack over the file limit for the multipart envelope; the
+	// service enforces the exact limit while streaming.
+	r.Body = http.MaxBytesReader(w, r.Body, h.fileTransferService.Limits().MaxFileBytes+1<<20)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected a multipart upload", http.StatusBadRequest)
+		return
+	}
+
+	claims := middleware.GetAuthClaims(r.Context())
+	in := service.TransferUploadInput{UploadedBy: claims.UserID}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch part.FormName() {
+		case "ttl_hours", "note":
+			v, _ := io.ReadAll(io.LimitReader(part, 1024))
+			if part.FormName() == "note" {
+				in.Note = string(v)
+			} else if in.TTL, err = ttlHours(string(v)); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case "file":
+			// Fields must precede the file part; browsers send FormData in
+			// append order and the page appends the file last.
+			in.Filename = part.FileName()
+			in.ContentType = part.Header.Get("Content-Type")
+			in.Body = part
+			f, err := h.fileTransferService.Upload(r.Context(), in)
+			if err != nil {
+				http.Error(w, err.Error(), fileTransferErrorStatus(err))
+				return
+			}
+			h.logAction(r, "upload_file_transfer", "file_transfer", f.ID, map[string]interface{}{
+				"filename": f.Filename, "size_bytes": f.SizeBytes, "sha256": f.SHA256, "expires_at": f.ExpiresAt,
+				"scan_status": f.ScanStatus,
+			})
+			respondJSON(w, f)
+			return
+		}
+	}
+	http.Error(w, "File is required", http.StatusBadRequest)
+}
+
+// SaveFileTransferText handles POST /api/admin/file-transfers/text
+// {"filename":"notes.md","content":"…","ttl_hours":24,"note":""}
+func (h *Handler) SaveFileTransferText(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Filename string `json:"filename"`
+		Content  string `json:"content"`
+		TTLHours int    `json:"ttl_hours"`
+		Note     string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	f, err := h.fileTransferService.SaveText(r.Context(), req.Filename, req.Content, req.Note,
+		time.Duration(req.TTLHours)*time.Hour, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), fileTransferErrorStatus(err))
+		return
+	}
+	h.logAction(r, "upload_file_transfer", "file_transfer", f.ID, map[string]interface{}{
+		"filename": f.Filename, "size_bytes": f.SizeBytes, "sha256": f.SHA256, "expires_at": f.ExpiresAt,
+		"scan_status": f.ScanStatus,
+	})
+	respondJSON(w, f)
+}
+
+// DownloadFileTransfer handles GET /api/admin/file-transfers/{id}/download
+func (h *Handler) DownloadFileTransfer(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		return
+	}
+	f, rc, err := h.fileTransferService.Open(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), fileTransferErrorStatus(err))
+		return
+	}
+	serveTransfer(w, f, rc)
+}
+
+// PreviewFileTransfer handles GET /api/admin/file-transfers/{id}/preview — small
+// UTF-8 files only, returned as JSON so the page renders it as text.
+func (h *Handler) PreviewFileTransfer(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		return
+	}
+	f, content, err := h.fileTransferService.Preview(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), fileTransferErrorStatus(err))
+		return
+	}
+	respondJSON(w, map[string]interface{}{"file": f, "content": content})
+}
+
+// DeleteFileTransfer handles DELETE /api/admin/file-transfers/{id}
+func (h *Handler) DeleteFileTransfer(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		return
+	}
+	f, err := h.fileTransferService.Delete(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), fileTransferErrorStatus(err))
+		return
+	}
+	h.logAction(r, "delete_file_transfer", "file_transfer", id, map[string]interface{}{"filename": f.Filename})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateFileTransferShare handles POST /api/admin/file-transfers/{id}/share
+// {"password":"optional","ttl_hours":48}. Replaces any existing link.
+func (h *Handler) CreateFileTransferShare(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+		TTLHours int    `json:"ttl_hours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	link, err := h.fileTransferService.CreateShare(r.Context(), id, req.Password,
+		time.Duration(req.TTLHours)*time.Hour, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), fileTransferErrorStatus(err))
+		return
+	}
+	h.logAction(r, "share_file_transfer", "file_transfer", id, map[string]interface{}{
+		"expires_at": link.ExpiresAt, "password": link.HasPassword,
+	})
+	respondJSON(w, map[string]interface{}{
+		"url":          "/filextfer/s/" + link.Token,
+		"expires_at":   link.ExpiresAt,
+		"has_password": link.HasPassword,
+	})
+}
+
+// RevokeFileTransferShare handles DELETE /api/admin/file-transfers/{id}/share
+func (h *Handler) RevokeFileTransferShare(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.fileTransferService.RevokeShare(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), fileTransferErrorStatus(err))
+		return
+	}
+	h.logAction(r, "revoke_file_transfer_share", "file_transfer", id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FileTransferPage renders the file transfer admin page.
+func (h *Handler) FileTransferPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID: claims.UserID, Email: claims.Email, FirstName: claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "file_transfer.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "File Transfer",
+		CurrentUser: currentUser,
+	})
+}
+
+// renderSharePage shows the public share landing page.
+func renderSharePage(w http.ResponseWriter, status int, data map[string]interface{}) {
+	tmpl, err := parsePublicTemplate("filextfer_share.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = tmpl.ExecuteTemplate(w, "filextfer_share.html", data)
+}
+
+// FileTransferSharePage handles GET /filextfer/s/{token} (no auth — the
+// token, and the password when set, are the access control).
+func (h *Handler) FileTransferSharePage(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer is not available.", http.StatusServiceUnavailable)
+		return
+	}
+	token := chi.URLParam(r, "token")
+	f, err := h.fileTransferService.LookupShare(r.Context(), token)
+	if err != nil {
+		status := fileTransferErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("[FILEXFER] share lookup failed: %v", err)
+		}
+		renderSharePage(w, status, map[string]interface{}{"Unavailable": true})
+		return
+	}
+	renderSharePage(w, http.StatusOK, map[string]interface{}{"Token": token, "File": f})
+}
+
+// FileTransferShareDownload handles POST /filextfer/s/{token} with an
+// optional password form field and streams the file.
+func (h *Handler) FileTransferShareDownload(w http.ResponseWriter, r *http.Request) {
+	if h.fileTransferService == nil {
+		http.Error(w, "File transfer is not available.", http.StatusServiceUnavailable)
+		return
+	}
+	token := chi.URLParam(r, "token")
+	r.Body = http.MaxBytesReader(w, r.Body, 4<<10)
+	f, rc, err := h.fileTransferService.OpenShared(r.Context(), token, r.PostFormValue("password"))
+	if err != nil {
+		if errors.Is(err, service.ErrTransferPasswordRequired) || errors.Is(err, service.ErrTransferPasswordWrong) {
+			file, _ := h.fileTransferService.LookupShare(r.Context(), token)
+			renderSharePage(w, http.StatusUnauthorized, map[string]interface{}{
+				"Token": token, "File": file, "Error": "That password isn't right.",
+			})
+			return
+		}
+		renderSharePage(w, fileTransferErrorStatus(err), map[string]interface{}{"Unavailable": true})
+		return
+	}
+	serveTransfer(w, f, rc)
+}
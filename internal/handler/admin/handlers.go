@@ -210,12 +210,14 @@ func (h *Handler) UpdateSetting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before, _ := h.adminRepo.GetSetting(ctx, key)
 	claims := middleware.GetAuthClaims(ctx)
 	if err := h.adminRepo.UpdateSetting(ctx, key, value, claims.UserID); err != nil {
 		http.Error(w, "Failed to update setting: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	auditDiff(r, map[string]interface{}{key: before}, map[string]interface{}{key: value})
 	h.logAction(r, "update_setting", "system", uuid.Nil, map[string]interface{}{"key": key})
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"success": true}`))
@@ -283,10 +285,8 @@ func (h *Handler) ListTickets(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	page := getIntParam(r, "page", 1)
 	limit := getIntParam(r, "limit", 20)
-	status := r.URL.Query().Get("status")
-	ticketType := r.URL.Query().Get("type")
 
-	tickets, total, err := h.adminRepo.GetTickets(ctx, status, ticketType, page, limit)
+	tickets, total, err := h.adminRepo.GetTickets(ctx, ticketFilterFromQuery(r), page, limit)
 	if err != nil {
 		http.Error(w, "Failed to list tickets: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -577,6 +577,11 @@ func (h *Handler) GetTicketMessages(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to get messages: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if h.attachService != nil {
+		if err := h.attachService.AttachToMessages(ctx, id, messages, true); err != nil {
+			log.Printf("[admin/tickets/%s] attachments for messages: %v", id, err)
+		}
+	}
 
 	respondJSON(w, messages)
 }
@@ -584,6 +589,9 @@ func (h *Handler) GetTicketMessages(w http.ResponseWriter, r *http.Request) {
 type AddMessageRequest struct {
 	Message    string `json:"message"`
 	IsInternal bool   `json:"is_internal"`
+	// AttachmentIDs are files uploaded via POST /tickets/{id}/attachments
+	// to send with this message.
+	AttachmentIDs []uuid.UUID `json:"attachment_ids"`
 }
 
 func (h *Handler) AddTicketMessage(w http.ResponseWriter, r *http.Request) {
@@ -606,10 +614,16 @@ func (h *Handler) AddTicketMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	claims := middleware.GetAuthClaims(ctx)
-	if err := h.adminRepo.AddTicketMessage(ctx, id, claims.UserID, req.Message, req.IsInternal); err != nil {
+	messageID, err := h.adminRepo.CreateTicketMessage(ctx, id, claims.UserID, req.Message, req.IsInternal)
+	if err != nil {
 		http.Error(w, "Failed to add message: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if len(req.AttachmentIDs) > 0 && h.attachService != nil {
+		if err := h.attachService.LinkToMessage(ctx, id, claims.UserID, messageID, req.AttachmentIDs); err != nil {
+			log.Printf("[admin/tickets/%s] link attachments: %v", id, err)
+		}
+	}
 
 	// Send push notification to ticket owner (only for non-internal messages)
 	if h.pushService != nil && !req.IsInternal {
@@ -636,8 +650,7 @@ func (h *Handler) AddTicketMessage(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"success": true}`))
+	respondJSON(w, map[string]interface{}{"success": true, "message_id": messageID})
 }
 
 func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
@@ -700,6 +713,28 @@ func (h *Handler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	status := models.UserStatus(req.Status)
+	// Anything but reactivation locks the user out, so it waits for the
+	// admin to confirm by typing the user's email.
+	if status != models.UserStatusActive {
+		user, err := h.adminRepo.GetUserByID(ctx, id)
+		if err != nil {
+			http.Error(w, "Failed to get user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.requestPendingAction(w, r, service.PendingActionRequest{
+			Action:        pendingDeactivateUser,
+			TargetType:    "user",
+			TargetID:      id.String(),
+			Params:        map[string]interface{}{"status": req.Status},
+			Summary:       fmt.Sprintf("Set user %s to %s", user.Email, req.Status),
+			ConfirmPhrase: user.Email,
+		})
+		return
+	}
 	if err := h.adminRepo.UpdateUserStatus(ctx, id, status); err != nil {
 		http.Error(w, "Failed to update user status: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -826,6 +861,11 @@ func (h *Handler) GetMarketingMetrics(w http.ResponseWriter, r *http.Request) {
 // ============================================================================
 
 func (h *Handler) logAction(r *http.Request, action, targetType string, targetID uuid.UUID, details map[string]interface{}) {
+	// Mutating requests are written by auditMutations once the handler
+	// returns, with the route and response status attached.
+	if holdAction(r, pendingAction{action: action, targetType: targetType, targetID: targetID, details: details}) {
+		return
+	}
 	ctx := r.Context()
 	claims := middleware.GetAuthClaims(ctx)
 	if claims == nil {
@@ -835,6 +875,14 @@ func (h *Handler) logAction(r *http.Request, action, targetType string, targetID
 	// Strip the port if present (and prefer X-Forwarded-For when behind ALB).
 	ip := clientIP(r)
 	userAgent := r.UserAgent()
+	if elevated := h.elevationIDs(r, claims); len(elevated) > 0 {
+		tagged := make(map[string]interface{}, len(details)+1)
+		for k, v := range details {
+			tagged[k] = v
+		}
+		tagged["elevation_ids"] = elevated
+		details = tagged
+	}
 	h.adminRepo.LogAction(ctx, claims.UserID, action, targetType, targetID, details, ip, userAgent)
 }
 
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// DATA INTEGRITY — scans for rows whose references don't resolve (see
+// cmd/integritycheck). Results carry counts and row IDs, never row data.
+// ============================================================================
+
+// ListIntegrityChecks handles GET /integrity/checks.
+func (h *Handler) ListIntegrityChecks(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, map[string]interface{}{"checks": repository.IntegrityChecks})
+}
+
+// ListIntegrityRuns handles GET /integrity/runs?limit=N, newest first.
+func (h *Handler) ListIntegrityRuns(w http.ResponseWriter, r *http.Request) {
+	if h.integrityService == nil {
+		http.Error(w, "Integrity checks unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	runs, err := h.integrityService.ListRuns(r.Context(), getIntParam(r, "limit", 20))
+	if err != nil {
+		http.Error(w, "Failed to list integrity runs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []repository.IntegrityRun{}
+	}
+	respondJSON(w, map[string]interface{}{"runs": runs})
+}
+
+// GetIntegrityRun handles GET /integrity/runs/{runID}.
+func (h *Handler) GetIntegrityRun(w http.ResponseWriter, r *http.Request) {
+	if h.integrityService == nil {
+		http.Error(w, "Integrity checks unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "runID"))
+	if err != nil {
+		http.Error(w, "Invalid run ID", http.StatusBadRequest)
+		return
+	}
+	run, err := h.integrityService.GetRun(r.Context(), id)
+	if errors.Is(err, service.ErrIntegrityRunNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get integrity run: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, run)
+}
+
+type integrityRunRequest struct {
+	Checks  []string `json:"checks"`
+	Samples int      `json:"samples"`
+}
+
+// RunIntegrityCheck handles POST /integrity/runs: a report-only run.
+// Body (optional): {"checks": ["payments.missing_subscription"], "samples": 10}.
+func (h *Handler) RunIntegrityCheck(w http.ResponseWriter, r *http.Request) {
+	h.runIntegrityCheck(w, r, false)
+}
+
+// QuarantineIntegrityOrphans handles POST /integrity/quarantine: a run that
+// also moves the orphans of quarantinable checks into integrity_quarantine.
+// Super admin only; takes the same body as RunIntegrityCheck.
+func (h *Handler) QuarantineIntegrityOrphans(w http.ResponseWriter, r *http.Request) {
+	h.runIntegrityCheck(w, r, true)
+}
+
+func (h *Handler) runIntegrityCheck(w http.ResponseWriter, r *http.Request, quarantine bool) {
+	if h.integrityService == nil {
+		http.Error(w, "Integrity checks unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req integrityRunRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	run, err := h.integrityService.Run(r.Context(), service.IntegrityOptions{
+		Quarantine: quarantine,
+		Samples:    req.Samples,
+		Checks:     req.Checks,
+		RunBy:      claims.Email,
+	})
+	if errors.Is(err, service.ErrIntegrityUnknownCheck) || errors.Is(err, service.ErrIntegrityInvalidSample) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if run == nil {
+		http.Error(w, "Integrity check failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	action := "run_integrity_check"
+	if quarantine {
+		action = "quarantine_integrity_orphans"
+	}
+	var found, quarantined int64
+	for _, res := range run.Results {
+		found += res.Count
+		quarantined += res.Quarantined
+	}
+	h.logAction(r, action, "integrity_check_run", run.ID, map[string]interface{}{
+		"status": run.Status, "found": found, "quarantined": quarantined,
+	})
+	respondJSON(w, run)
+}
@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ListInvoices handles GET /financials/invoices?q=&page=. q matches the
+// invoice number, bill-to name or email, or the Stripe invoice ID.
+func (h *Handler) ListInvoices(w http.ResponseWriter, r *http.Request) {
+	if h.invoiceService == nil {
+		http.Error(w, "Invoices unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	const limit = 25
+	invs, total, err := h.invoiceService.List(r.Context(), repository.InvoiceFilter{
+		Query:  strings.TrimSpace(r.URL.Query().Get("q")),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		http.Error(w, "Failed to load invoices: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if invs == nil {
+		invs = []repository.Invoice{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"invoices": invs,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// DownloadInvoice handles GET /financials/invoices/{id}/pdf.
+func (h *Handler) DownloadInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	rc, err := h.invoiceService.OpenPDF(r.Context(), inv)
+	if err != nil {
+		http.Error(w, "Invoice file not available: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	defer rc.Close()
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\""+service.InvoiceFilename(inv)+"\"")
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("[admin] DownloadInvoice %s: %v", inv.Number, err)
+	}
+}
+
+// RegenerateInvoice handles POST /financials/invoices/{id}/regenerate:
+// re-snapshots the bill-to details from the account and renders a new PDF.
+func (h *Handler) RegenerateInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	updated, err := h.invoiceService.Regenerate(r.Context(), inv.ID)
+	if err != nil {
+		http.Error(w, "Failed to regenerate invoice: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "regenerate_invoice", "invoice", inv.ID, map[string]interface{}{
+		"number":      inv.Number,
+		"pdf_version": updated.PDFVersion,
+	})
+	respondJSON(w, updated)
+}
+
+// ResendInvoice handles POST /financials/invoices/{id}/resend with an
+// optional {"to": "..."}; without one it goes to the bill-to email.
+func (h *Handler) ResendInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		To string `json:"to"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.To != "" && !strings.Contains(req.To, "@") {
+		http.Error(w, "Invalid email address", http.StatusBadRequest)
+		return
+	}
+	updated, err := h.invoiceService.Send(r.Context(), inv.ID, req.To)
+	switch {
+	case errors.Is(err, service.ErrInvoiceNoRecipient):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrInvoiceEmailDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, "Failed to send invoice: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "resend_invoice", "invoice", inv.ID, map[string]interface{}{
+		"number": inv.Number,
+		"to":     updated.LastSentTo,
+	})
+	respondJSON(w, updated)
+}
+
+func (h *Handler) loadInvoice(w http.ResponseWriter, r *http.Request) (*repository.Invoice, bool) {
+	if h.invoiceService == nil {
+		http.Error(w, "Invoices unavailable", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+		return nil, false
+	}
+	inv, err := h.invoiceService.Get(r.Context(), id)
+	if errors.Is(err, service.ErrInvoiceNotFound) {
+		http.Error(w, "Invoice not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to load invoice: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return inv, true
+}
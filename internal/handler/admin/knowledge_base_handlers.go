@@ -0,0 +1,253 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// kbErrorStatus maps KB service errors to HTTP status codes.
+func kbErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrKBArticleNotFound), errors.Is(err, service.ErrKBCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrKBInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ============================================================================
+// CATEGORIES
+// ============================================================================
+
+// ListKBCategories handles GET /api/admin/kb/categories.
+func (h *Handler) ListKBCategories(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	cats, err := h.kbService.ListCategories(r.Context(), false)
+	if err != nil {
+		http.Error(w, "Failed to list categories: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, cats)
+}
+
+func (h *Handler) CreateKBCategory(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var in service.KBCategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	cat, err := h.kbService.CreateCategory(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), kbErrorStatus(err))
+		return
+	}
+	h.logAction(r, "create_kb_category", "kb_category", cat.ID, map[string]interface{}{"slug": cat.Slug})
+	respondJSON(w, cat)
+}
+
+func (h *Handler) UpdateKBCategory(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+	var in service.KBCategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	cat, err := h.kbService.UpdateCategory(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), kbErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_kb_category", "kb_category", id, nil)
+	respondJSON(w, cat)
+}
+
+func (h *Handler) DeleteKBCategory(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.kbService.DeleteCategory(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete category: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "delete_kb_category", "kb_category", id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ============================================================================
+// ARTICLES
+// ============================================================================
+
+// ListKBArticles handles GET /api/admin/kb/articles
+// ?status=&category=<uuid>&q=&page=&limit=
+func (h *Handler) ListKBArticles(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	f := repository.KBArticleFilter{Status: q.Get("status"), Query: q.Get("q")}
+	if c := q.Get("category"); c != "" {
+		if id, err := uuid.Parse(c); err == nil {
+			f.CategoryID = id
+		}
+	}
+	page := getIntParam(r, "page", 1)
+	limit := getIntParam(r, "limit", 25)
+	articles, total, err := h.kbService.ListArticles(r.Context(), f, page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), kbErrorStatus(err))
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"articles": articles,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetKBArticle returns the full article including markdown source and a
+// rendered HTML preview.
+func (h *Handler) GetKBArticle(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+	a, err := h.kbService.GetArticle(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), kbErrorStatus(err))
+		return
+	}
+	respondJSON(w, a)
+}
+
+func (h *Handler) CreateKBArticle(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	var in service.KBArticleInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(ctx)
+	a, err := h.kbService.CreateArticle(ctx, in, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), kbErrorStatus(err))
+		return
+	}
+	h.logAction(r, "create_kb_article", "kb_article", a.ID, map[string]interface{}{
+		"slug": a.Slug, "status": a.Status,
+	})
+	respondJSON(w, a)
+}
+
+func (h *Handler) UpdateKBArticle(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+	var in service.KBArticleInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(ctx)
+	a, err := h.kbService.UpdateArticle(ctx, id, in, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), kbErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_kb_article", "kb_article", id, map[string]interface{}{
+		"slug": a.Slug, "status": a.Status,
+	})
+	respondJSON(w, a)
+}
+
+func (h *Handler) DeleteKBArticle(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.kbService.DeleteArticle(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete article: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "delete_kb_article", "kb_article", id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SuggestKBArticlesForTicket handles GET /api/admin/support/tickets/{id}/kb-suggestions
+// so agents can link an existing article instead of writing a reply.
+func (h *Handler) SuggestKBArticlesForTicket(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		http.Error(w, "Help center service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket ID", http.StatusBadRequest)
+		return
+	}
+	ticket, err := h.adminRepo.GetTicketByID(ctx, id)
+	if err != nil || ticket == nil {
+		http.Error(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+	articles, err := h.kbService.SuggestForText(ctx, ticket.Subject+" "+ticket.Description)
+	if err != nil {
+		http.Error(w, "Failed to suggest articles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, articles)
+}
@@ -0,0 +1,240 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// LEGAL — versioned Terms of Service / Privacy Policy, publishing with an
+// effective date, and acceptance coverage for legal.
+// ============================================================================
+
+// legalErrorStatus maps legal document errors to HTTP status codes.
+func legalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrLegalDocumentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrLegalDocumentPublished):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrLegalInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListLegalDocuments handles GET /api/admin/legal: every version (newest
+// first per kind, without bodies) and acceptance coverage of the published
+// ones.
+func (h *Handler) ListLegalDocuments(w http.ResponseWriter, r *http.Request) {
+	if h.legalService == nil {
+		http.Error(w, "Legal documents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	docs, err := h.legalService.List(r.Context(), r.URL.Query().Get("kind"))
+	if err != nil {
+		http.Error(w, err.Error(), legalErrorStatus(err))
+		return
+	}
+	coverage, err := h.legalService.Coverage(r.Context())
+	if err != nil {
+		log.Printf("[LEGAL] coverage: %v", err)
+	}
+	respondJSON(w, map[string]interface{}{
+		"documents": docs,
+		"coverage":  coverage,
+	})
+}
+
+// GetLegalDocument handles GET /api/admin/legal/{id}, with the body.
+func (h *Handler) GetLegalDocument(w http.ResponseWriter, r *http.Request) {
+	if h.legalService == nil {
+		http.Error(w, "Legal documents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	d, err := h.legalService.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), legalErrorStatus(err))
+		return
+	}
+	respondJSON(w, d)
+}
+
+// CreateLegalDocument handles POST /api/admin/legal, saving a draft.
+func (h *Handler) CreateLegalDocument(w http.ResponseWriter, r *http.Request) {
+	if h.legalService == nil {
+		http.Error(w, "Legal documents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var in service.LegalDocumentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	d, err := h.legalService.CreateDraft(r.Context(), in, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), legalErrorStatus(err))
+		return
+	}
+	h.logAction(r, "create_legal_document", "legal_document", d.ID, map[string]interface{}{
+		"kind": d.Kind, "version": d.Version,
+	})
+	respondJSON(w, d)
+}
+
+// UpdateLegalDocument handles PUT /api/admin/legal/{id}. Drafts only.
+func (h *Handler) UpdateLegalDocument(w http.ResponseWriter, r *http.Request) {
+	if h.legalService == nil {
+		http.Error(w, "Legal documents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	var in service.LegalDocumentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	d, err := h.legalService.UpdateDraft(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), legalErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_legal_document", "legal_document", d.ID, map[string]interface{}{
+		"kind": d.Kind, "version": d.Version,
+	})
+	respondJSON(w, d)
+}
+
+// DeleteLegalDocument handles DELETE /api/admin/legal/{id}. Drafts only.
+func (h *Handler) DeleteLegalDocument(w http.ResponseWriter, r *http.Request) {
+	if h.legalService == nil {
+		http.Error(w, "Legal documents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.legalService.DeleteDraft(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), legalErrorStatus(err))
+		return
+	}
+	h.logAction(r, "delete_legal_document", "legal_document", id, nil)
+	respondJSON(w, map[string]string{"status": "deleted"})
+}
+
+// PublishLegalDocument handles POST /api/admin/legal/{id}/publish with
+// {"effective_at": RFC 3339 time}. Omitted means effective immediately.
+// Once published the version can't be edited or deleted.
+func (h *Handler) PublishLegalDocument(w http.ResponseWriter, r *http.Request) {
+	if h.legalService == nil {
+		http.Error(w, "Legal documents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		EffectiveAt *time.Time `json:"effective_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var effectiveAt time.Time
+	if req.EffectiveAt != nil {
+		effectiveAt = *req.EffectiveAt
+	}
+	d, err := h.legalService.Publish(r.Context(), id, effectiveAt)
+	if err != nil {
+		http.Error(w, err.Error(), legalErrorStatus(err))
+		return
+	}
+	h.logAction(r, "publish_legal_document", "legal_document", d.ID, map[string]interface{}{
+		"kind": d.Kind, "version": d.Version, "effective_at": d.EffectiveAt,
+		"requires_acceptance": d.RequiresAcceptance,
+	})
+	respondJSON(w, d)
+}
+
+// ExportLegalAcceptances handles GET /api/admin/legal/{id}/acceptances.csv:
+// every user who accepted the version, with time, IP and user agent.
+func (h *Handler) ExportLegalAcceptances(w http.ResponseWriter, r *http.Request) {
+	if h.legalService == nil {
+		http.Error(w, "Legal documents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	d, rows, err := h.legalService.Acceptances(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load acceptances: "+err.Error(), legalErrorStatus(err))
+		return
+	}
+	h.logAction(r, "export_legal_acceptances", "legal_document", id, map[string]interface{}{
+		"kind": d.Kind, "version": d.Version, "rows": len(rows),
+	})
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=carecompanion_"+d.Kind+"_"+d.Version+"_acceptances.csv")
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	writer.Write([]string{"User ID", "Email", "Accepted At", "IP Address", "User Agent"})
+	for _, a := range rows {
+		writer.Write([]string{
+			a.UserID.String(),
+			a.Email,
+			a.AcceptedAt.UTC().Format(time.RFC3339),
+			a.IPAddress,
+			a.UserAgent,
+		})
+	}
+}
+
+// LegalPage renders the legal documents editor and coverage report.
+func (h *Handler) LegalPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID: claims.UserID, Email: claims.Email, FirstName: claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "legal.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Legal Documents",
+		CurrentUser: currentUser,
+	})
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// LOG RETENTION — retention windows for response_time_logs, error_logs and
+// their hourly rollups, shown as a panel on the settings page.
+// ============================================================================
+
+// GetLogRetention handles GET /api/admin/super/log-retention.
+func (h *Handler) GetLogRetention(w http.ResponseWriter, r *http.Request) {
+	status, err := h.logRetention.Status(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load log retention: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, status)
+}
+
+// UpdateLogRetention handles PUT /api/admin/super/log-retention.
+func (h *Handler) UpdateLogRetention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req service.LogRetention
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	before, err := h.logRetention.Settings(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load log retention: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	claims := middleware.GetAuthClaims(ctx)
+	if err := h.logRetention.UpdateSettings(ctx, req, claims.UserID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrLogRetentionInvalid) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h.logAction(r, "update_log_retention", "system", uuid.Nil, map[string]interface{}{
+		"before": before, "after": req,
+	})
+	respondJSON(w, req)
+}
+
+// RunLogRetention handles POST /api/admin/super/log-retention/run — roll
+// up and prune now rather than at the next hourly run.
+func (h *Handler) RunLogRetention(w http.ResponseWriter, r *http.Request) {
+	run, err := h.logRetention.RunNow(r.Context())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrLogRetentionRunning) {
+			status = http.StatusConflict
+		}
+		http.Error(w, "Log retention run failed: "+err.Error(), status)
+		return
+	}
+	h.logAction(r, "run_log_retention", "system", uuid.Nil, map[string]interface{}{
+		"pruned_response_times": run.PrunedResponseTimes,
+		"pruned_error_logs":     run.PrunedErrorLogs,
+		"pruned_rollup_rows":    run.PrunedRollupRows,
+	})
+	respondJSON(w, run)
+}
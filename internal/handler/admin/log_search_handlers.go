@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// APPLICATION LOG SEARCH — Logs Insights queries over the app log group by
+// request ID, user hash and path, shown in the error-log detail view.
+// ============================================================================
+
+// logSearchErrorStatus maps log search errors to HTTP status codes.
+func logSearchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrLogSearchDisabled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrLogSearchInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// SearchAppLogs handles GET /api/admin/super/logs/search.
+// Query: request_id, user_hash or user_id, path; from/to (RFC 3339) or
+// minutes back from now (default 60); limit.
+func (h *Handler) SearchAppLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p := service.LogSearchParams{
+		RequestID: q.Get("request_id"),
+		UserHash:  q.Get("user_hash"),
+		Path:      q.Get("path"),
+		Limit:     getIntParam(r, "limit", 100),
+	}
+	if v := q.Get("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+		p.UserHash = middleware.LogUserHash(id)
+	}
+	p.To = time.Now()
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "Invalid to (RFC 3339)", http.StatusBadRequest)
+			return
+		}
+		p.To = t
+	}
+	p.From = p.To.Add(-time.Duration(getIntParam(r, "minutes", 60)) * time.Minute)
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "Invalid from (RFC 3339)", http.StatusBadRequest)
+			return
+		}
+		p.From = t
+	}
+
+	res, err := h.logSearchService.Search(r.Context(), p)
+	if err != nil {
+		http.Error(w, err.Error(), logSearchErrorStatus(err))
+		return
+	}
+	h.logAction(r, "search_app_logs", "app_logs", uuid.Nil, map[string]interface{}{
+		"request_id": p.RequestID, "user_hash": p.UserHash, "path": p.Path,
+		"from": p.From, "to": p.To, "matched": res.RecordsMatched,
+	})
+	respondJSON(w, res)
+}
+
+// GetErrorLogContext handles GET /api/admin/super/errors/{id}/logs — the
+// application log around one error. by=request (default) pulls the lines
+// for the error's request ID; by=user and by=path widen to the same user
+// or path within 15 minutes either side.
+func (h *Handler) GetErrorLogContext(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid error log ID", http.StatusBadRequest)
+		return
+	}
+	e, err := h.adminRepo.GetErrorLogByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to fetch error log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if e == nil {
+		http.Error(w, "Error log not found", http.StatusNotFound)
+		return
+	}
+
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = "request"
+	}
+	p := service.LogSearchParams{
+		From:  e.CreatedAt.Add(-15 * time.Minute),
+		To:    e.CreatedAt.Add(15 * time.Minute),
+		Limit: getIntParam(r, "limit", 200),
+	}
+	switch by {
+	case "request":
+		if !e.RequestID.Valid || e.RequestID.String == "" {
+			http.Error(w, "This error has no request ID; search by user or path", http.StatusConflict)
+			return
+		}
+		p.RequestID = e.RequestID.String
+		// The request line is logged when the request finishes, just
+		// before the error row is written.
+		p.From, p.To = e.CreatedAt.Add(-10*time.Minute), e.CreatedAt.Add(2*time.Minute)
+	case "user":
+		if !e.UserID.Valid {
+			http.Error(w, "This error has no user", http.StatusConflict)
+			return
+		}
+		p.UserHash = middleware.LogUserHash(e.UserID.UUID)
+	case "path":
+		p.Path = e.Path
+	default:
+		http.Error(w, "by must be request, user or path", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.logSearchService.Search(r.Context(), p)
+	if err != nil {
+		http.Error(w, err.Error(), logSearchErrorStatus(err))
+		return
+	}
+	h.logAction(r, "search_app_logs", "error_log", id, map[string]interface{}{
+		"by": by, "matched": res.RecordsMatched,
+	})
+	respondJSON(w, map[string]interface{}{
+		"by":        by,
+		"user_hash": p.UserHash,
+		"result":    res,
+	})
+}
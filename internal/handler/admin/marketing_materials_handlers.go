@@ -2,15 +2,18 @@ package admin
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/models"
+	"carecompanion/internal/service"
 )
 
 // ============================================================================
@@ -58,6 +61,10 @@ type UpdateBrandConfigRequest struct {
 	LinkedInURL       string `json:"linkedinUrl"`
 	CopyrightText     string `json:"copyrightText"`
 	DisclaimerText    string `json:"disclaimerText"`
+	// QR settings are optional so older clients that don't send them
+	// don't wipe the stored values.
+	QRTargetURL   *string `json:"qrTargetUrl"`
+	QRUTMCampaign *string `json:"qrUtmCampaign"`
 }
 
 // UpdateBrandConfig updates the brand configuration (super_admin only)
@@ -106,6 +113,20 @@ func (h *Handler) UpdateBrandConfig(w http.ResponseWriter, r *http.Request) {
 		LinkedInURL:       req.LinkedInURL,
 		CopyrightText:     req.CopyrightText,
 		DisclaimerText:    req.DisclaimerText,
+		QRTargetURL:       current.QRTargetURL,
+		QRUTMCampaign:     current.QRUTMCampaign,
+	}
+	if req.QRTargetURL != nil {
+		if *req.QRTargetURL != "" {
+			if _, err := service.BuildQRTargetURL(*req.QRTargetURL, service.UTMParams{}); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		config.QRTargetURL = *req.QRTargetURL
+	}
+	if req.QRUTMCampaign != nil {
+		config.QRUTMCampaign = *req.QRUTMCampaign
 	}
 
 	userID := middleware.GetUserID(r.Context())
@@ -163,6 +184,37 @@ func (h *Handler) DownloadAsset(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// AssetSignedURL handles GET /api/admin/marketing/materials/assets/{id}/signed-url.
+// Generated files live under /static/marketing, which only serves signed
+// links; this mints one for previews and for sharing outside the portal.
+func (h *Handler) AssetSignedURL(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid asset ID", http.StatusBadRequest)
+		return
+	}
+
+	url, exp, err := h.marketingService.SignedAssetURL(r.Context(), id)
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, service.ErrAssetSignature) {
+			status = http.StatusConflict
+		}
+		http.Error(w, "Failed to sign asset URL: "+err.Error(), status)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"url":        url,
+		"expires_at": exp.Format(time.RFC3339),
+	})
+}
+
 // ListSocialTemplates returns available social media templates
 func (h *Handler) ListSocialTemplates(w http.ResponseWriter, r *http.Request) {
 	if h.marketingService == nil {
@@ -184,6 +236,9 @@ type GenerateSocialGraphicRequest struct {
 	TemplateID uuid.UUID `json:"templateId"`
 	Headline   string    `json:"headline"`
 	Body       string    `json:"body"`
+	// TestimonialID, when set, replaces Body with that testimonial's quote
+	// and byline. It must be approved with social consent.
+	TestimonialID *uuid.UUID `json:"testimonialId,omitempty"`
 }
 
 // GenerateSocialGraphic generates a custom social media graphic
@@ -204,6 +259,25 @@ func (h *Handler) GenerateSocialGraphic(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.TestimonialID != nil {
+		body, err := h.marketingService.SocialQuoteText(r.Context(), *req.TestimonialID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, service.ErrTestimonialNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, service.ErrTestimonialNotPublishable):
+				status = http.StatusConflict
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		req.Body = body
+		if req.Headline == "" {
+			req.Headline = "What families are saying"
+		}
+	}
+
 	if req.Headline == "" {
 		http.Error(w, "Headline is required", http.StatusBadRequest)
 		return
@@ -272,6 +346,27 @@ func (h *Handler) RegenerateAsset(w http.ResponseWriter, r *http.Request) {
 		} else {
 			_, err = h.marketingService.SaveAsset(ctx, "Tri-Fold Brochure", models.AssetTypeBrochure, models.FormatPDF, content, 792, 612)
 		}
+	case "logo-sheet":
+		content, genErr := h.marketingService.GenerateLogoSheetPDF(ctx)
+		if genErr != nil {
+			err = genErr
+		} else {
+			_, err = h.marketingService.SaveAsset(ctx, "Logo Sheet", models.AssetTypeLogo, models.FormatPDF, content, 612, 792)
+		}
+	case "letterhead":
+		content, genErr := h.marketingService.GenerateLetterheadPDF(ctx)
+		if genErr != nil {
+			err = genErr
+		} else {
+			_, err = h.marketingService.SaveAsset(ctx, "Letterhead", models.AssetTypePrintCollateral, models.FormatPDF, content, 630, 810)
+		}
+	case "pricing-sheet":
+		content, genErr := h.marketingService.GeneratePricingSheetPDF(ctx)
+		if genErr != nil {
+			err = genErr
+		} else {
+			_, err = h.marketingService.SaveAsset(ctx, "Pricing Sheet", models.AssetTypePrintCollateral, models.FormatPDF, content, 630, 810)
+		}
 	case "style-guide":
 		content, genErr := h.marketingService.GenerateStyleGuidePDF(ctx)
 		if genErr != nil {
@@ -293,20 +388,64 @@ func (h *Handler) RegenerateAsset(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, map[string]string{"status": "success", "message": "Asset regenerated successfully"})
 }
 
-// RegenerateAllAssets regenerates all marketing assets (super_admin only)
+// RegenerateAllAssets queues a background regeneration of all marketing
+// assets and returns the job immediately (202). Poll
+// GET /super/materials/jobs/{id} for progress.
 func (h *Handler) RegenerateAllAssets(w http.ResponseWriter, r *http.Request) {
 	if h.marketingService == nil {
 		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
 		return
 	}
 
-	if err := h.marketingService.RegenerateAllAssets(r.Context()); err != nil {
-		http.Error(w, "Failed to regenerate assets: "+err.Error(), http.StatusInternalServerError)
+	claims := middleware.GetAuthClaims(r.Context())
+	job, err := h.marketingService.StartRegenerateAll(claims.UserID)
+	if errors.Is(err, service.ErrAssetRegenInProgress) {
+		// Hand back the running job so the UI can attach to it.
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to start regeneration: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logAction(r, "regenerate_all_assets", "marketing_assets", job.ID, nil)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetAssetRegenJob returns progress for a regeneration job. The id
+// "latest" returns the most recent job.
+func (h *Handler) GetAssetRegenJob(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
 		return
 	}
 
-	h.logAction(r, "regenerate_all_assets", "marketing_assets", uuid.Nil, nil)
-	respondJSON(w, map[string]string{"status": "success", "message": "All assets regenerated successfully"})
+	var job *service.AssetRegenJob
+	var err error
+	if idStr := chi.URLParam(r, "id"); idStr == "latest" {
+		job, err = h.marketingService.LatestRegenJob()
+	} else {
+		id, perr := uuid.Parse(idStr)
+		if perr != nil {
+			http.Error(w, "Invalid job ID", http.StatusBadRequest)
+			return
+		}
+		job, err = h.marketingService.GetRegenJob(id)
+	}
+	if errors.Is(err, service.ErrAssetJobNotFound) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, job)
 }
 
 // GenerateBrochure generates a brochure PDF and returns it
@@ -367,6 +506,140 @@ func (h *Handler) GenerateStyleGuide(w http.ResponseWriter, r *http.Request) {
 	w.Write(content)
 }
 
+// GenerateLogoSheet returns the logo sheet PDF
+func (h *Handler) GenerateLogoSheet(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	content, err := h.marketingService.GenerateLogoSheetPDF(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to generate logo sheet: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"carecompanion_logo_sheet.pdf\"")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Write(content)
+}
+
+// GeneratePressKit returns the press kit ZIP, assembled on demand from the
+// current brand config.
+func (h *Handler) GeneratePressKit(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	content, err := h.marketingService.GeneratePressKit(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to generate press kit: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"carecompanion_press_kit.zip\"")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Write(content)
+}
+
+// GeneratePrintCollateral returns a print-ready PDF (trim + 1/8" bleed).
+// GET /materials/print?type=business-card|letterhead|pricing-sheet
+// Business cards also take name, title, email and phone.
+func (h *Handler) GeneratePrintCollateral(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	var content []byte
+	var err error
+	var filename string
+
+	switch q.Get("type") {
+	case "business-card":
+		content, err = h.marketingService.GenerateBusinessCardPDF(r.Context(), service.BusinessCardInfo{
+			Name:  q.Get("name"),
+			Title: q.Get("title"),
+			Email: q.Get("email"),
+			Phone: q.Get("phone"),
+		})
+		filename = "carecompanion_business_card.pdf"
+	case "letterhead":
+		content, err = h.marketingService.GenerateLetterheadPDF(r.Context())
+		filename = "carecompanion_letterhead.pdf"
+	case "pricing-sheet":
+		content, err = h.marketingService.GeneratePricingSheetPDF(r.Context())
+		filename = "carecompanion_pricing_sheet.pdf"
+	default:
+		http.Error(w, "Invalid print collateral type", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		if errors.Is(err, service.ErrPrintInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrNoPricingPlans) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to generate print collateral: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Write(content)
+}
+
+// GenerateQRCode renders a brand-colored QR code PNG.
+// GET /api/admin/marketing/qr?url=&utm_source=&utm_medium=&utm_campaign=&utm_content=&size=&fg=&bg=
+// url defaults to the brand QR target (or website). The encoded URL is
+// echoed in X-QR-Target so callers can verify the UTM tagging.
+func (h *Handler) GenerateQRCode(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	req := service.QRRequest{
+		TargetURL: q.Get("url"),
+		UTM: service.UTMParams{
+			Source:   q.Get("utm_source"),
+			Medium:   q.Get("utm_medium"),
+			Campaign: q.Get("utm_campaign"),
+			Content:  q.Get("utm_content"),
+		},
+		SizePx:     getIntParam(r, "size", 512),
+		Foreground: q.Get("fg"),
+		Background: q.Get("bg"),
+	}
+	content, target, err := h.marketingService.GenerateQRCode(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrQRInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to generate QR code: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("X-QR-Target", target)
+	if q.Get("download") == "1" {
+		w.Header().Set("Content-Disposition", "attachment; filename=\"qr_code.png\"")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Write(content)
+}
+
 // GenerateLogo generates a logo in the specified format and variant
 func (h *Handler) GenerateLogo(w http.ResponseWriter, r *http.Request) {
 	if h.marketingService == nil {
@@ -421,3 +694,266 @@ func (h *Handler) GenerateLogo(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
 	w.Write(content)
 }
+
+// ListNewsletterMergeFields returns the placeholders newsletter copy may use
+func (h *Handler) ListNewsletterMergeFields(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, map[string]interface{}{
+		"kinds":        []string{service.NewsletterAnnouncement, service.NewsletterFeatureLaunch, service.NewsletterTestimonial},
+		"exports":      []string{service.NewsletterExportRaw, service.NewsletterExportMailchimp, service.NewsletterExportSES},
+		"merge_fields": service.NewsletterMergeFields(),
+	})
+}
+
+// GenerateNewsletter renders a branded email template. With ?download=1 the
+// export is sent as a file: HTML for raw/Mailchimp, the CreateTemplate JSON
+// for SES.
+func (h *Handler) GenerateNewsletter(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req service.NewsletterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tmpl, err := h.marketingService.GenerateNewsletter(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrNewsletterInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to generate newsletter: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("download") != "1" {
+		respondJSON(w, tmpl)
+		return
+	}
+	if tmpl.Export == service.NewsletterExportSES {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+tmpl.Name+"_ses.json\"")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(tmpl.Payload)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+tmpl.Name+".html\"")
+	io.WriteString(w, tmpl.HTML)
+}
+
+// ListBrandFonts returns uploaded brand fonts and which families the brand
+// config currently uses.
+func (h *Handler) ListBrandFonts(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	fonts, err := h.marketingService.ListBrandFonts(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list fonts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if fonts == nil {
+		fonts = []models.BrandFont{}
+	}
+	resp := map[string]interface{}{"fonts": fonts}
+	if config, err := h.marketingService.GetBrandConfig(r.Context()); err == nil && config != nil {
+		resp["headingFont"] = config.HeadingFont
+		resp["bodyFont"] = config.BodyFont
+	}
+	respondJSON(w, resp)
+}
+
+// UploadBrandFont stores a licensed font file (multipart: file, family,
+// style, license_note). Generated materials use it when the family matches
+// the brand config's heading or body font.
+func (h *Handler) UploadBrandFont(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := r.ParseMultipartForm(12 << 20); err != nil {
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, hdr, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Font file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	claims := middleware.GetAuthClaims(r.Context())
+	font, err := h.marketingService.UploadBrandFont(r.Context(),
+		r.FormValue("family"), r.FormValue("style"), r.FormValue("license_note"),
+		hdr.Filename, file, claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFontInvalid):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrFontStorageUnavailable), errors.Is(err, service.ErrScanUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, service.ErrUploadQuarantined):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, "Failed to upload font: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logAction(r, "upload_brand_font", "brand_font", font.ID, map[string]interface{}{
+		"family": font.Family, "style": font.Style, "sha256": font.SHA256,
+		"scan_status": font.ScanStatus,
+	})
+	respondJSON(w, font)
+}
+
+// DeleteBrandFont removes an uploaded font; generation falls back to the
+// default fonts for that family/style.
+func (h *Handler) DeleteBrandFont(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid font ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.marketingService.DeleteBrandFont(r.Context(), id); err != nil {
+		if errors.Is(err, service.ErrFontNotFound) {
+			http.Error(w, "Font not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete font: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logAction(r, "delete_brand_font", "brand_font", id, nil)
+	respondJSON(w, map[string]string{"status": "success"})
+}
+
+// screenshotErrorStatus maps screenshot/listing errors to HTTP status codes.
+func screenshotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrScreenshotInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrScreenshotNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUploadQuarantined):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrScanUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListScreenshots returns uploaded raw app screenshots and the store
+// listing sizes they can be framed at.
+func (h *Handler) ListScreenshots(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	shots, err := h.marketingService.ListScreenshots(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list screenshots: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if shots == nil {
+		shots = []models.MarketingAsset{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"screenshots": shots,
+		"targets":     service.StoreListingTargets(),
+	})
+}
+
+// UploadScreenshot stores a raw app screenshot (multipart: file, label).
+func (h *Handler) UploadScreenshot(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := r.ParseMultipartForm(16 << 20); err != nil {
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Screenshot file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	asset, err := h.marketingService.UploadScreenshot(r.Context(), r.FormValue("label"), file)
+	if err != nil {
+		http.Error(w, err.Error(), screenshotErrorStatus(err))
+		return
+	}
+
+	h.logAction(r, "upload_screenshot", "marketing_asset", asset.ID, map[string]interface{}{
+		"name": asset.Name, "width": asset.WidthPx, "height": asset.HeightPx,
+		"scan_status": asset.ScanStatus,
+	})
+	respondJSON(w, asset)
+}
+
+// DeleteScreenshot removes a raw screenshot from the listing picker and
+// press kit.
+func (h *Handler) DeleteScreenshot(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid screenshot ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.marketingService.DeleteScreenshot(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), screenshotErrorStatus(err))
+		return
+	}
+
+	h.logAction(r, "delete_screenshot", "marketing_asset", id, nil)
+	respondJSON(w, map[string]string{"status": "success"})
+}
+
+// GenerateStoreListing frames the chosen screenshots at App Store / Play
+// Store sizes and returns the full set as a ZIP.
+// {"shots":[{"screenshotId":"…","caption":"Log a day in seconds"}],"targets":["ios_6_9"]}
+func (h *Handler) GenerateStoreListing(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req service.StoreListingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	content, err := h.marketingService.GenerateStoreListingSet(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), screenshotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"carecompanion_store_listing.zip\"")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Write(content)
+}
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// LANDING PAGE SNIPPET + PUBLIC STATS WIDGET
+// ============================================================================
+
+// requestBaseURL is the public origin this request arrived on, honoring the
+// ALB's X-Forwarded-Proto.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// GetLandingSnippet returns the hero HTML/CSS and widget embed tag for the
+// public marketing site.
+func (h *Handler) GetLandingSnippet(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Marketing service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	snippet, err := h.marketingService.GenerateLandingSnippet(r.Context(), requestBaseURL(r))
+	if err != nil {
+		http.Error(w, "Failed to generate landing snippet: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, snippet)
+}
+
+// PublicStatsJSON serves bucketed aggregate stats for the embeddable
+// widget. Mounted at GET /widget/stats.json (no auth; CORS is open
+// globally and the data is already public-safe).
+func (h *Handler) PublicStatsJSON(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Stats unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	stats, err := h.marketingService.GetPublicStats(r.Context())
+	if err != nil {
+		http.Error(w, "Stats unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, stats)
+}
+
+// PublicStatsWidgetJS serves the widget script. Mounted at
+// GET /widget/stats.js.
+func (h *Handler) PublicStatsWidgetJS(w http.ResponseWriter, r *http.Request) {
+	if h.marketingService == nil {
+		http.Error(w, "Widget unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	script, err := h.marketingService.StatsWidgetScript(r.Context())
+	if err != nil {
+		http.Error(w, "Widget unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Header().Set("Content-Length", strconv.Itoa(len(script)))
+	w.Write(script)
+}
@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// METRIC ANOMALIES — the detector flags sharp deviations in signups,
+// revenue, server errors and response times as admin notifications.
+// ============================================================================
+
+// ListAdminNotifications handles GET /notifications?all=true&limit=N:
+// open notifications, or all of them, newest first.
+func (h *Handler) ListAdminNotifications(w http.ResponseWriter, r *http.Request) {
+	if h.adminNotificationService == nil {
+		http.Error(w, "Notifications unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	limit := getIntParam(r, "limit", 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	all := r.URL.Query().Get("all") == "true"
+	notifications, err := h.adminNotificationService.List(r.Context(), all, limit)
+	if err != nil {
+		http.Error(w, "Failed to list notifications: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if notifications == nil {
+		notifications = []repository.AdminNotification{}
+	}
+	respondJSON(w, map[string]interface{}{"notifications": notifications})
+}
+
+// AcknowledgeAdminNotification handles
+// POST /notifications/{notificationID}/acknowledge.
+func (h *Handler) AcknowledgeAdminNotification(w http.ResponseWriter, r *http.Request) {
+	if h.adminNotificationService == nil {
+		http.Error(w, "Notifications unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "notificationID"))
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	n, err := h.adminNotificationService.Acknowledge(r.Context(), id, claims.UserID)
+	if errors.Is(err, service.ErrAdminNotificationNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to acknowledge notification: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "acknowledge_admin_notification", "admin_notification", id, map[string]interface{}{
+		"kind": n.Kind, "title": n.Title,
+	})
+	respondJSON(w, n)
+}
+
+// GetAnomalySettings handles GET /metrics/anomalies/settings: the
+// detector's sensitivity per metric.
+func (h *Handler) GetAnomalySettings(w http.ResponseWriter, r *http.Request) {
+	if h.metricAnomalyService == nil {
+		http.Error(w, "Anomaly detection unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	settings, err := h.metricAnomalyService.Settings(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load anomaly settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"settings": settings,
+		"defaults": service.DefaultAnomalySettings,
+	})
+}
+
+// UpdateAnomalySettings handles PUT /metrics/anomalies/settings with a
+// map of metric to config. Metrics left out keep their config.
+func (h *Handler) UpdateAnomalySettings(w http.ResponseWriter, r *http.Request) {
+	if h.metricAnomalyService == nil {
+		http.Error(w, "Anomaly detection unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	var req map[string]service.AnomalyMetricConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	before, err := h.metricAnomalyService.Settings(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load anomaly settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	claims := middleware.GetAuthClaims(ctx)
+	after, err := h.metricAnomalyService.UpdateSettings(ctx, req, claims.UserID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAnomalySettingsInvalid) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	h.logAction(r, "update_anomaly_settings", "system", uuid.Nil, map[string]interface{}{
+		"before": before, "after": after,
+	})
+	respondJSON(w, map[string]interface{}{"settings": after})
+}
+
+// ScanMetricAnomalies handles POST /metrics/anomalies/scan — run the
+// detector now instead of waiting for the hourly run.
+func (h *Handler) ScanMetricAnomalies(w http.ResponseWriter, r *http.Request) {
+	if h.metricAnomalyService == nil {
+		http.Error(w, "Anomaly detection unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	anomalies, err := h.metricAnomalyService.Scan(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to scan metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"anomalies": anomalies})
+}
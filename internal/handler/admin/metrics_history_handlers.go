@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/repository"
+)
+
+// metricsHistoryMaxDays caps how far back a trend chart can reach.
+const metricsHistoryMaxDays = 730
+
+// metricsSeriesValues picks one metric out of a history point, by the name
+// used in GET /metrics/history/{metric}.
+var metricsSeriesValues = map[string]func(p *repository.MetricsHistoryPoint) float64{
+	"total_users":         func(p *repository.MetricsHistoryPoint) float64 { return float64(p.TotalUsers) },
+	"active_users_24h":    func(p *repository.MetricsHistoryPoint) float64 { return float64(p.ActiveUsers24h) },
+	"active_users_7d":     func(p *repository.MetricsHistoryPoint) float64 { return float64(p.ActiveUsers7d) },
+	"total_families":      func(p *repository.MetricsHistoryPoint) float64 { return float64(p.TotalFamilies) },
+	"total_entries":       func(p *repository.MetricsHistoryPoint) float64 { return float64(p.TotalEntries) },
+	"entries_per_day":     func(p *repository.MetricsHistoryPoint) float64 { return float64(p.Entries) },
+	"new_users":           func(p *repository.MetricsHistoryPoint) float64 { return float64(p.NewUsers) },
+	"user_growth_percent": func(p *repository.MetricsHistoryPoint) float64 { return p.UserGrowthPct },
+}
+
+// metricsSeriesPoint is one day of a drill-down series. Value is null on
+// days with no refresh, so charts show a gap rather than a drop to zero.
+type metricsSeriesPoint struct {
+	Date  string   `json:"date"`
+	Value *float64 `json:"value"`
+}
+
+// metricsHistoryRange is the last ?days=N days (default 90) ending today,
+// UTC.
+func metricsHistoryRange(r *http.Request) (from, to time.Time, ok bool) {
+	days := getIntParam(r, "days", 90)
+	if days < 1 || days > metricsHistoryMaxDays {
+		return from, to, false
+	}
+	now := time.Now().UTC()
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return to.AddDate(0, 0, 1-days), to, true
+}
+
+// GetMetricsHistory handles GET /metrics/history?days=N: every daily
+// metrics point recorded in the last N days, for the dashboard's charts.
+func (h *Handler) GetMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := metricsHistoryRange(r)
+	if !ok {
+		http.Error(w, "days must be between 1 and 730", http.StatusBadRequest)
+		return
+	}
+	points, err := h.adminRepo.GetMetricsHistory(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, "Failed to get metrics history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if points == nil {
+		points = []repository.MetricsHistoryPoint{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"from":   from.Format("2006-01-02"),
+		"to":     to.Format("2006-01-02"),
+		"points": points,
+	})
+}
+
+// GetMetricSeries handles GET /metrics/history/{metric}?days=N: one
+// metric as a daily series with a point for every day in the range.
+func (h *Handler) GetMetricSeries(w http.ResponseWriter, r *http.Request) {
+	metric := chi.URLParam(r, "metric")
+	if _, ok := metricsSeriesValues[metric]; !ok {
+		http.Error(w, "Unknown metric", http.StatusNotFound)
+		return
+	}
+	from, to, ok := metricsHistoryRange(r)
+	if !ok {
+		http.Error(w, "days must be between 1 and 730", http.StatusBadRequest)
+		return
+	}
+	points, err := h.adminRepo.GetMetricsHistory(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, "Failed to get metrics history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"metric": metric,
+		"from":   from.Format("2006-01-02"),
+		"to":     to.Format("2006-01-02"),
+		"points": metricsSeries(points, metric, from, to),
+	})
+}
+
+// GetLogUsage handles GET /metrics/log-usage?days=N: entries per log type
+// over the last N days, with platform split and daily series, for deciding
+// which log types are worth investing in.
+func (h *Handler) GetLogUsage(w http.ResponseWriter, r *http.Request) {
+	if h.logUsageService == nil {
+		http.Error(w, "Log usage unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	from, to, ok := metricsHistoryRange(r)
+	if !ok {
+		http.Error(w, "days must be between 1 and 730", http.StatusBadRequest)
+		return
+	}
+	report, err := h.logUsageService.Report(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, "Failed to get log usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, report)
+}
+
+// metricsSeries lays metric out over every day from..to, leaving days
+// without a recorded point null.
+func metricsSeries(points []repository.MetricsHistoryPoint, metric string, from, to time.Time) []metricsSeriesPoint {
+	value := metricsSeriesValues[metric]
+	byDay := make(map[string]*repository.MetricsHistoryPoint, len(points))
+	for i := range points {
+		byDay[points[i].Date.Format("2006-01-02")] = &points[i]
+	}
+	var out []metricsSeriesPoint
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := d.Format("2006-01-02")
+		sp := metricsSeriesPoint{Date: day}
+		if p, ok := byDay[day]; ok {
+			v := value(p)
+			sp.Value = &v
+		}
+		out = append(out, sp)
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+func TestMetricsSeriesFillsGaps(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	points := []repository.MetricsHistoryPoint{
+		{Date: day(1), TotalUsers: 10, Entries: 4, UserGrowthPct: 12.5},
+		{Date: day(3), TotalUsers: 12, Entries: 0, UserGrowthPct: -5},
+	}
+	series := metricsSeries(points, "entries_per_day", day(1), day(4))
+	if len(series) != 4 {
+		t.Fatalf("got %d points, want one per day", len(series))
+	}
+	want := []*float64{ptr(4), nil, ptr(0), nil}
+	for i, p := range series {
+		if p.Date != day(i+1).Format("2006-01-02") {
+			t.Errorf("point %d dated %s", i, p.Date)
+		}
+		if (p.Value == nil) != (want[i] == nil) || (p.Value != nil && *p.Value != *want[i]) {
+			t.Errorf("%s: value %v, want %v", p.Date, p.Value, want[i])
+		}
+	}
+	if g := metricsSeries(points, "user_growth_percent", day(3), day(3)); len(g) != 1 || *g[0].Value != -5 {
+		t.Errorf("growth series %+v", g)
+	}
+}
+
+func TestMetricsHistoryRange(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		days  int
+		ok    bool
+	}{{"", 90, true}, {"?days=1", 1, true}, {"?days=0", 0, false}, {"?days=731", 0, false}} {
+		from, to, ok := metricsHistoryRange(httptest.NewRequest(http.MethodGet, "/metrics/history"+c.query, nil))
+		if ok != c.ok {
+			t.Errorf("%q: ok=%v", c.query, ok)
+			continue
+		}
+		if ok && int(to.Sub(from).Hours()/24)+1 != c.days {
+			t.Errorf("%q: %s..%s is not %d days", c.query, from, to, c.days)
+		}
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
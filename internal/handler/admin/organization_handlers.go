@@ -0,0 +1,385 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// ORGANIZATIONS — clinics whose subscription covers their client families.
+// Support creates them, sets the seat limit and adds the first org admin;
+// org admins take it from there in the app.
+// ============================================================================
+
+// ListOrganizations handles GET /api/admin/organizations.
+func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	orgs, err := h.organizationService.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list organizations: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"organizations": orgs})
+}
+
+// GetOrganization handles GET /api/admin/organizations/{id}: the
+// organization with its staff, client families and 30-day report.
+func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseOrganizationID(w, r)
+	if !ok {
+		return
+	}
+	org, err := h.organizationService.Get(r.Context(), id)
+	if errors.Is(err, service.ErrOrgNotFound) {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load organization: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	members, err := h.organizationService.Members(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load members: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	families, err := h.organizationService.Families(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load families: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report, err := h.organizationService.Report(r.Context(), id, getIntParam(r, "days", 30))
+	if err != nil {
+		http.Error(w, "Failed to build report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var seats *service.OrgSeatSummary
+	if h.orgBillingService != nil {
+		if seats, err = h.orgBillingService.Seats(r.Context(), id); err != nil {
+			http.Error(w, "Failed to load seats: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	respondJSON(w, map[string]interface{}{
+		"organization": org,
+		"members":      members,
+		"families":     families,
+		"report":       report,
+		"seats":        seats,
+	})
+}
+
+// CreateOrganization handles POST /api/admin/organizations. An optional
+// admin_email makes that app user the first org admin.
+func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		service.OrganizationInput
+		AdminEmail string `json:"admin_email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	org, err := h.organizationService.Create(r.Context(), req.OrganizationInput, claims.UserID)
+	if errors.Is(err, service.ErrOrgInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create organization: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "create_organization", "organization", org.ID, map[string]interface{}{
+		"name":       org.Name,
+		"seat_limit": org.SeatLimit,
+	})
+
+	resp := map[string]interface{}{"organization": org}
+	if req.AdminEmail != "" {
+		if _, err := h.organizationService.AddMember(r.Context(), org.ID, req.AdminEmail, "admin", claims.UserID); err != nil {
+			// The organization exists either way; say why the admin
+			// wasn't added so support can retry from the detail view.
+			resp["admin_error"] = err.Error()
+		} else {
+			h.logAction(r, "add_organization_member", "organization", org.ID, map[string]interface{}{
+				"email": req.AdminEmail,
+				"role":  "admin",
+			})
+		}
+	}
+	respondJSON(w, resp)
+}
+
+// UpdateOrganization handles PUT /api/admin/organizations/{id}.
+func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseOrganizationID(w, r)
+	if !ok {
+		return
+	}
+	var req service.OrganizationInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	org, err := h.organizationService.Update(r.Context(), id, req)
+	switch {
+	case errors.Is(err, service.ErrOrgInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrOrgNotFound):
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "Failed to update organization: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "update_organization", "organization", id, map[string]interface{}{
+		"name":   org.Name,
+		"status": org.Status,
+	})
+	respondJSON(w, org)
+}
+
+// RotateOrganizationJoinCode handles POST /api/admin/organizations/{id}/join-code.
+func (h *Handler) RotateOrganizationJoinCode(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseOrganizationID(w, r)
+	if !ok {
+		return
+	}
+	code, err := h.organizationService.RotateJoinCode(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to rotate join code: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "rotate_organization_join_code", "organization", id, nil)
+	respondJSON(w, map[string]string{"join_code": code})
+}
+
+// AddOrganizationMember handles POST /api/admin/organizations/{id}/members.
+func (h *Handler) AddOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseOrganizationID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	m, err := h.organizationService.AddMember(r.Context(), id, req.Email, req.Role, claims.UserID)
+	if !writeOrganizationMemberError(w, err) {
+		return
+	}
+	h.logAction(r, "add_organization_member", "organization", id, map[string]interface{}{
+		"email": req.Email,
+		"role":  req.Role,
+	})
+	respondJSON(w, m)
+}
+
+// RemoveOrganizationMember handles DELETE /api/admin/organizations/{id}/members/{userID}.
+func (h *Handler) RemoveOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseOrganizationID(w, r)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if !writeOrganizationMemberError(w, h.organizationService.RemoveMember(r.Context(), id, userID)) {
+		return
+	}
+	h.logAction(r, "remove_organization_member", "organization", id, map[string]interface{}{
+		"user_id": userID.String(),
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveOrganizationFamily handles DELETE /api/admin/organizations/{id}/families/{familyID}.
+func (h *Handler) RemoveOrganizationFamily(w http.ResponseWriter, r *http.Request) {
+	if h.organizationService == nil {
+		http.Error(w, "Organizations unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseOrganizationID(w, r)
+	if !ok {
+		return
+	}
+	familyID, err := uuid.Parse(chi.URLParam(r, "familyID"))
+	if err != nil {
+		http.Error(w, "Invalid family ID", http.StatusBadRequest)
+		return
+	}
+	err = h.organizationService.RemoveFamily(r.Context(), id, familyID)
+	if errors.Is(err, service.ErrOrgFamilyNotLinked) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to remove family: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "remove_organization_family", "organization", id, map[string]interface{}{
+		"family_id": familyID.String(),
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetOrganizationSeats handles POST /organizations/{id}/seats with
+// {"seats": n}. For organizations paying through Stripe the subscription
+// quantity changes too and the proration is invoiced immediately.
+func (h *Handler) SetOrganizationSeats(w http.ResponseWriter, r *http.Request) {
+	if h.orgBillingService == nil {
+		http.Error(w, "Organization billing unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseOrganizationID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Seats int `json:"seats"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	sum, err := h.orgBillingService.SetSeats(r.Context(), id, req.Seats, claims.UserID, repository.OrgSeatSourceAdmin)
+	switch {
+	case errors.Is(err, service.ErrOrgSeatCount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrOrgNotFound):
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrOrgSeatsInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrOrgSeatBillingDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case errors.Is(err, service.ErrOrgSeatBillingFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	case err != nil:
+		http.Error(w, "Failed to change seats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	details := map[string]interface{}{"seats": sum.SeatLimit}
+	if len(sum.Changes) > 0 {
+		details["proration_cents"] = sum.Changes[0].ProrationCents
+	}
+	h.logAction(r, "set_organization_seats", "organization", id, details)
+	respondJSON(w, sum)
+}
+
+// GetOrganizationSeatUtilization returns seats licensed and used per
+// organization, with totals and seat MRR, for the financials page.
+func (h *Handler) GetOrganizationSeatUtilization(w http.ResponseWriter, r *http.Request) {
+	if h.orgBillingService == nil {
+		http.Error(w, "Organization billing unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	totals, rows, err := h.orgBillingService.Utilization(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load seat utilization: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == nil {
+		rows = []repository.OrgSeatUsage{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"totals":        totals,
+		"organizations": rows,
+	})
+}
+
+func parseOrganizationID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid organization ID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// writeOrganizationMemberError maps staff-change errors; false when it
+// wrote one.
+func writeOrganizationMemberError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrOrgInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrOrgUserNotFound), errors.Is(err, service.ErrOrgNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrOrgLastAdmin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Failed to update members: "+err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
+// OrganizationsPage renders the organizations list and detail view.
+func (h *Handler) OrganizationsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID: claims.UserID, Email: claims.Email, FirstName: claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "organizations.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Organizations",
+		CurrentUser: currentUser,
+	})
+}
@@ -0,0 +1,267 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// PENDING ACTIONS — destructive operations (deactivating a user, bulk
+// deleting error logs, deactivating a promo code with active subscribers)
+// answer 202 with a pending action instead of acting. The requesting admin
+// confirms it by typing its confirm_phrase; if the approval policy says so,
+// a second super admin must then approve it. Every step is audit logged.
+// ============================================================================
+
+// Pending action types.
+const (
+	pendingDeactivateUser     = "deactivate_user"
+	pendingBulkDeleteErrorLog = "bulk_delete_error_logs"
+	pendingDeactivatePromo    = "deactivate_promo_code"
+)
+
+// registerPendingActions registers the action types this handler holds
+// for confirmation. Deactivating a promo code others are paying with
+// needs a second admin by default.
+func (h *Handler) registerPendingActions(s *service.PendingActionService) {
+	s.Register(service.PendingActionSpec{
+		Action: pendingDeactivateUser,
+		Execute: func(ctx context.Context, a *repository.PendingAdminAction) (map[string]interface{}, error) {
+			id, err := uuid.Parse(a.TargetID)
+			if err != nil {
+				return nil, err
+			}
+			status, _ := a.Params["status"].(string)
+			if err := h.adminRepo.UpdateUserStatus(ctx, id, models.UserStatus(status)); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"status": status}, nil
+		},
+	})
+	s.Register(service.PendingActionSpec{
+		Action: pendingBulkDeleteErrorLog,
+		Execute: func(ctx context.Context, a *repository.PendingAdminAction) (map[string]interface{}, error) {
+			// Params round-trip through JSONB, so re-decode the IDs.
+			raw, _ := json.Marshal(a.Params["ids"])
+			var ids []uuid.UUID
+			if err := json.Unmarshal(raw, &ids); err != nil || len(ids) == 0 {
+				return nil, fmt.Errorf("invalid ids: %v", err)
+			}
+			if err := h.adminRepo.DeleteErrorLogsBulk(ctx, ids, a.RequestedBy); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"count": len(ids)}, nil
+		},
+	})
+	s.Register(service.PendingActionSpec{
+		Action:           pendingDeactivatePromo,
+		RequiresApproval: true,
+		Execute: func(ctx context.Context, a *repository.PendingAdminAction) (map[string]interface{}, error) {
+			id, err := uuid.Parse(a.TargetID)
+			if err != nil {
+				return nil, err
+			}
+			reason, _ := a.Params["reason"].(string)
+			if err := h.adminRepo.DeactivatePromoCode(ctx, id, a.RequestedBy, reason); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"status": "deactivated"}, nil
+		},
+	})
+}
+
+// requestPendingAction holds req for confirmation and answers 202 with
+// {"pending_action": ...}.
+func (h *Handler) requestPendingAction(w http.ResponseWriter, r *http.Request, req service.PendingActionRequest) {
+	if h.pendingActionService == nil {
+		http.Error(w, "Confirmation workflow unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	req.RequestedBy = middleware.GetAuthClaims(r.Context()).UserID
+	a, err := h.pendingActionService.Request(r.Context(), req)
+	if err != nil {
+		http.Error(w, "Failed to record pending action: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "request_pending_action", "pending_admin_action", a.ID, map[string]interface{}{
+		"action": a.Action, "target_type": a.TargetType, "target_id": a.TargetID,
+		"requires_approval": a.RequiresApproval,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{"pending_action": a})
+}
+
+// pendingActionErrorStatus maps workflow errors to HTTP status codes.
+func pendingActionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPendingActionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPendingActionForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrPendingActionState):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrPendingActionConfirmMismatch), errors.Is(err, service.ErrPendingActionUnknown):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListPendingActions handles GET /pending-actions?all=true&limit=N: open
+// actions, or all of them, newest first.
+func (h *Handler) ListPendingActions(w http.ResponseWriter, r *http.Request) {
+	if h.pendingActionService == nil {
+		http.Error(w, "Confirmation workflow unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	actions, err := h.pendingActionService.List(r.Context(), r.URL.Query().Get("all") == "true", getIntParam(r, "limit", 50))
+	if err != nil {
+		http.Error(w, "Failed to list pending actions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if actions == nil {
+		actions = []repository.PendingAdminAction{}
+	}
+	respondJSON(w, map[string]interface{}{"pending_actions": actions})
+}
+
+// GetPendingAction handles GET /pending-actions/{actionID}.
+func (h *Handler) GetPendingAction(w http.ResponseWriter, r *http.Request) {
+	if h.pendingActionService == nil {
+		http.Error(w, "Confirmation workflow unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "actionID"))
+	if err != nil {
+		http.Error(w, "Invalid pending action ID", http.StatusBadRequest)
+		return
+	}
+	a, err := h.pendingActionService.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), pendingActionErrorStatus(err))
+		return
+	}
+	respondJSON(w, a)
+}
+
+type pendingActionStepRequest struct {
+	ConfirmText string `json:"confirm_text"`
+	Note        string `json:"note"`
+}
+
+// ConfirmPendingAction handles POST /pending-actions/{actionID}/confirm.
+// Body: {"confirm_text": "<the action's confirm_phrase>"}.
+func (h *Handler) ConfirmPendingAction(w http.ResponseWriter, r *http.Request) {
+	h.pendingActionStep(w, r, "confirm_pending_action", func(ctx context.Context, id, adminID uuid.UUID, req pendingActionStepRequest) (*repository.PendingAdminAction, error) {
+		return h.pendingActionService.Confirm(ctx, id, adminID, req.ConfirmText)
+	})
+}
+
+// ApprovePendingAction handles POST /pending-actions/{actionID}/approve
+// (super_admin, not the requester). Body (optional): {"note": "..."}.
+func (h *Handler) ApprovePendingAction(w http.ResponseWriter, r *http.Request) {
+	h.pendingActionStep(w, r, "approve_pending_action", func(ctx context.Context, id, adminID uuid.UUID, req pendingActionStepRequest) (*repository.PendingAdminAction, error) {
+		return h.pendingActionService.Approve(ctx, id, adminID, req.Note)
+	})
+}
+
+// RejectPendingAction handles POST /pending-actions/{actionID}/reject
+// (super_admin, not the requester). Body (optional): {"note": "..."}.
+func (h *Handler) RejectPendingAction(w http.ResponseWriter, r *http.Request) {
+	h.pendingActionStep(w, r, "reject_pending_action", func(ctx context.Context, id, adminID uuid.UUID, req pendingActionStepRequest) (*repository.PendingAdminAction, error) {
+		return h.pendingActionService.Reject(ctx, id, adminID, req.Note)
+	})
+}
+
+// CancelPendingAction handles POST /pending-actions/{actionID}/cancel
+// (the requester).
+func (h *Handler) CancelPendingAction(w http.ResponseWriter, r *http.Request) {
+	h.pendingActionStep(w, r, "cancel_pending_action", func(ctx context.Context, id, adminID uuid.UUID, req pendingActionStepRequest) (*repository.PendingAdminAction, error) {
+		return h.pendingActionService.Cancel(ctx, id, adminID)
+	})
+}
+
+func (h *Handler) pendingActionStep(w http.ResponseWriter, r *http.Request, auditAction string,
+	step func(ctx context.Context, id, adminID uuid.UUID, req pendingActionStepRequest) (*repository.PendingAdminAction, error)) {
+	if h.pendingActionService == nil {
+		http.Error(w, "Confirmation workflow unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "actionID"))
+	if err != nil {
+		http.Error(w, "Invalid pending action ID", http.StatusBadRequest)
+		return
+	}
+	var req pendingActionStepRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	a, err := step(r.Context(), id, claims.UserID, req)
+	if a == nil {
+		http.Error(w, err.Error(), pendingActionErrorStatus(err))
+		return
+	}
+	// The step went through even when the action then failed to run.
+	h.logAction(r, auditAction, "pending_admin_action", a.ID, map[string]interface{}{
+		"action": a.Action, "target_type": a.TargetType, "target_id": a.TargetID,
+		"status": a.Status, "error": a.Error,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), pendingActionErrorStatus(err))
+		return
+	}
+	respondJSON(w, a)
+}
+
+// GetPendingActionPolicy handles GET /pending-actions/policy: whether each
+// action needs a second admin's approval.
+func (h *Handler) GetPendingActionPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.pendingActionService == nil {
+		http.Error(w, "Confirmation workflow unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	policy, err := h.pendingActionService.ApprovalPolicy(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load approval policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, policy)
+}
+
+// UpdatePendingActionPolicy handles PUT /pending-actions/policy.
+// Body: {"deactivate_user": true, ...}; unlisted actions are unchanged.
+func (h *Handler) UpdatePendingActionPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.pendingActionService == nil {
+		http.Error(w, "Confirmation workflow unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var update map[string]bool
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	policy, err := h.pendingActionService.UpdateApprovalPolicy(r.Context(), update, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), pendingActionErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_pending_action_policy", "settings", uuid.Nil, map[string]interface{}{"policy": policy})
+	respondJSON(w, policy)
+}
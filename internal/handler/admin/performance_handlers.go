@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"net/http"
+
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// PERFORMANCE — per-route p50/p95/p99 from the hourly latency histograms,
+// with week-over-week regressions, shown as a panel on the infrastructure
+// page.
+// ============================================================================
+
+// GetRouteLatency handles GET /api/admin/performance/routes?window=24h&limit=50.
+// window accepts hours or days ("6h", "7d"), up to 30 days.
+func (h *Handler) GetRouteLatency(w http.ResponseWriter, r *http.Request) {
+	window, err := service.ParsePerformanceWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep, err := h.performanceService.RouteLatency(r.Context(), window, getIntParam(r, "limit", 50))
+	if err != nil {
+		http.Error(w, "Failed to load route latency: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, rep)
+}
+
+// GetAPIUsage handles GET /api/admin/performance/clients?window=24h&routes=5:
+// requests, error rate and latency per client (mobile platform and build,
+// web, or another User-Agent) with each one's busiest routes, flagging
+// integrations or old builds that misbehave.
+func (h *Handler) GetAPIUsage(w http.ResponseWriter, r *http.Request) {
+	window, err := service.ParsePerformanceWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep, err := h.apiUsageService.Report(r.Context(), window, getIntParam(r, "routes", 5))
+	if err != nil {
+		http.Error(w, "Failed to load API usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, rep)
+}
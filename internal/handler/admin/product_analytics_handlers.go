@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// PRODUCT ANALYTICS — weekly retention cohorts, DAU/WAU/MAU, log-type
+// adoption and the activation funnel, from the hourly cached snapshot.
+// ============================================================================
+
+// ProductAnalyticsPage renders /admin/analytics.
+func (h *Handler) ProductAnalyticsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID:         claims.UserID,
+		Email:      claims.Email,
+		FirstName:  claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	var analytics *service.ProductAnalytics
+	if h.analyticsService != nil {
+		analytics, _ = h.analyticsService.Get(r.Context())
+	}
+
+	tmpl, err := parseTemplates("layout.html", "analytics.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Product Analytics",
+		CurrentUser: currentUser,
+		Data:        analytics,
+	})
+}
+
+// productAnalytics loads the cached snapshot, writing the error response
+// and returning nil if it can't.
+func (h *Handler) productAnalytics(w http.ResponseWriter, r *http.Request) *service.ProductAnalytics {
+	if h.analyticsService == nil {
+		http.Error(w, "Product analytics unavailable", http.StatusServiceUnavailable)
+		return nil
+	}
+	analytics, err := h.analyticsService.Get(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to compute analytics: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	return analytics
+}
+
+// GetProductAnalytics handles GET /api/admin/analytics — the whole snapshot.
+func (h *Handler) GetProductAnalytics(w http.ResponseWriter, r *http.Request) {
+	if a := h.productAnalytics(w, r); a != nil {
+		respondJSON(w, a)
+	}
+}
+
+// GetRetentionCohorts handles GET /api/admin/analytics/retention.
+func (h *Handler) GetRetentionCohorts(w http.ResponseWriter, r *http.Request) {
+	if a := h.productAnalytics(w, r); a != nil {
+		respondJSON(w, map[string]interface{}{
+			"computed_at": a.ComputedAt,
+			"as_of":       a.AsOf,
+			"cohorts":     a.Retention,
+		})
+	}
+}
+
+// GetActiveUsers handles GET /api/admin/analytics/active-users.
+func (h *Handler) GetActiveUsers(w http.ResponseWriter, r *http.Request) {
+	if a := h.productAnalytics(w, r); a != nil {
+		respondJSON(w, map[string]interface{}{
+			"computed_at": a.ComputedAt,
+			"as_of":       a.AsOf,
+			"days":        a.ActiveUsers,
+		})
+	}
+}
+
+// GetFeatureAdoption handles GET /api/admin/analytics/feature-adoption.
+func (h *Handler) GetFeatureAdoption(w http.ResponseWriter, r *http.Request) {
+	if a := h.productAnalytics(w, r); a != nil {
+		respondJSON(w, map[string]interface{}{
+			"computed_at": a.ComputedAt,
+			"as_of":       a.AsOf,
+			"window_days": a.AdoptionWindowDays,
+			"adoption":    a.Adoption,
+		})
+	}
+}
+
+// GetActivationFunnel handles GET /api/admin/analytics/activation.
+func (h *Handler) GetActivationFunnel(w http.ResponseWriter, r *http.Request) {
+	if a := h.productAnalytics(w, r); a != nil {
+		respondJSON(w, map[string]interface{}{
+			"computed_at":          a.ComputedAt,
+			"as_of":                a.AsOf,
+			"activation_week_days": a.ActivationWeekDays,
+			"cohorts":              a.Funnel,
+		})
+	}
+}
+
+// GetOnboardingFunnel handles GET /api/admin/analytics/onboarding?days=30 —
+// how far recent signups got through the guided setup steps. Computed
+// live rather than cached; the window is at most 90 days of signups.
+func (h *Handler) GetOnboardingFunnel(w http.ResponseWriter, r *http.Request) {
+	if h.onboardingService == nil {
+		http.Error(w, "Onboarding tracking unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	funnel, err := h.onboardingService.Funnel(r.Context(), getIntParam(r, "days", 30))
+	if err != nil {
+		http.Error(w, "Failed to load onboarding funnel: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, funnel)
+}
+
+// GetDeviceStats handles GET /api/admin/analytics/devices — active push
+// devices by platform and app version, from the device registry.
+func (h *Handler) GetDeviceStats(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	stats, err := h.pushService.DeviceStats(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load device stats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, stats)
+}
+
+// RefreshProductAnalytics handles POST /api/admin/analytics/refresh —
+// recompute now instead of waiting for the hourly refresh.
+func (h *Handler) RefreshProductAnalytics(w http.ResponseWriter, r *http.Request) {
+	if h.analyticsService == nil {
+		http.Error(w, "Product analytics unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	analytics, err := h.analyticsService.Refresh(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to refresh analytics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "refresh_product_analytics", "system", uuid.Nil, nil)
+	respondJSON(w, analytics)
+}
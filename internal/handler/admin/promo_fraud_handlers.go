@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// PROMO FRAUD REVIEW — promo code checks flagged as likely abuse (shared
+// IP or device, disposable email, stacked or cycled codes) wait here for
+// an admin. Approving releases any held discount; rejecting refuses the
+// code to that user.
+// ============================================================================
+
+// promoFraudErrorStatus maps fraud review errors to HTTP status codes.
+func promoFraudErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPromoFraudFlagNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPromoFraudFlagReviewed):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrPromoFraudInvalidStatus), errors.Is(err, service.ErrPromoFraudPolicyInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListPromoFraudFlags handles GET /promo-codes/fraud-flags?status=pending&limit=N:
+// the review queue, newest first. status defaults to pending; "all" lists
+// every flag.
+func (h *Handler) ListPromoFraudFlags(w http.ResponseWriter, r *http.Request) {
+	if h.promoFraudService == nil {
+		http.Error(w, "Promo fraud review unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = repository.PromoFraudPending
+	case "all":
+		status = ""
+	}
+	flags, err := h.promoFraudService.List(r.Context(), status, getIntParam(r, "limit", 100))
+	if err != nil {
+		http.Error(w, err.Error(), promoFraudErrorStatus(err))
+		return
+	}
+	if flags == nil {
+		flags = []repository.PromoFraudFlag{}
+	}
+	respondJSON(w, map[string]interface{}{"flags": flags})
+}
+
+// GetPromoFraudFlag handles GET /promo-codes/fraud-flags/{flagID}.
+func (h *Handler) GetPromoFraudFlag(w http.ResponseWriter, r *http.Request) {
+	if h.promoFraudService == nil {
+		http.Error(w, "Promo fraud review unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "flagID"))
+	if err != nil {
+		http.Error(w, "Invalid flag ID", http.StatusBadRequest)
+		return
+	}
+	flag, err := h.promoFraudService.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), promoFraudErrorStatus(err))
+		return
+	}
+	respondJSON(w, flag)
+}
+
+// ApprovePromoFraudFlag handles POST /promo-codes/fraud-flags/{flagID}/approve.
+// Body (optional): {"note": "..."}.
+func (h *Handler) ApprovePromoFraudFlag(w http.ResponseWriter, r *http.Request) {
+	h.reviewPromoFraudFlag(w, r, true)
+}
+
+// RejectPromoFraudFlag handles POST /promo-codes/fraud-flags/{flagID}/reject.
+// Body (optional): {"note": "..."}.
+func (h *Handler) RejectPromoFraudFlag(w http.ResponseWriter, r *http.Request) {
+	h.reviewPromoFraudFlag(w, r, false)
+}
+
+func (h *Handler) reviewPromoFraudFlag(w http.ResponseWriter, r *http.Request, approve bool) {
+	if h.promoFraudService == nil {
+		http.Error(w, "Promo fraud review unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "flagID"))
+	if err != nil {
+		http.Error(w, "Invalid flag ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Note string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	flag, err := h.promoFraudService.Review(r.Context(), id, approve, claims.UserID, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), promoFraudErrorStatus(err))
+		return
+	}
+	action := "reject_promo_fraud_flag"
+	if approve {
+		action = "approve_promo_fraud_flag"
+	}
+	h.logAction(r, action, "promo_fraud_flag", flag.ID, map[string]interface{}{
+		"promo_code_id": flag.PromoCodeID, "user_id": flag.UserID, "held": flag.Held, "note": flag.ReviewNote,
+	})
+	respondJSON(w, flag)
+}
+
+// GetPromoFraudPolicy handles GET /promo-codes/fraud-policy.
+func (h *Handler) GetPromoFraudPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.promoFraudService == nil {
+		http.Error(w, "Promo fraud review unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	policy, err := h.promoFraudService.Policy(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load promo fraud policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, policy)
+}
+
+// UpdatePromoFraudPolicy handles PUT /promo-codes/fraud-policy with a full
+// policy: {"hold_discounts", "window_hours", "max_accounts_per_ip",
+// "max_accounts_per_device", "max_codes_per_user", "disposable_domains"}.
+func (h *Handler) UpdatePromoFraudPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.promoFraudService == nil {
+		http.Error(w, "Promo fraud review unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var policy service.PromoFraudPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	if err := h.promoFraudService.UpdatePolicy(r.Context(), policy, claims.UserID); err != nil {
+		http.Error(w, err.Error(), promoFraudErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_promo_fraud_policy", "settings", uuid.Nil, map[string]interface{}{"policy": policy})
+	respondJSON(w, policy)
+}
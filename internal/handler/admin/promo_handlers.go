@@ -3,6 +3,8 @@ package admin
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,6 +15,7 @@ import (
 
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/models"
+	"carecompanion/internal/service"
 )
 
 // ListPromoCodes returns paginated list of promo codes
@@ -368,6 +371,30 @@ func (h *Handler) DeactivatePromoCode(w http.ResponseWriter, r *http.Request) {
 		json.NewDecoder(r.Body).Decode(&req)
 	}
 
+	// Cutting off a code people are subscribed with waits for typed
+	// confirmation (and, by default, a second admin).
+	subscribers, err := h.adminRepo.CountActivePromoSubscribers(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to count promo code subscribers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if subscribers > 0 {
+		promo, err := h.adminRepo.GetPromoCodeByID(r.Context(), id)
+		if err != nil || promo == nil {
+			http.Error(w, "Promo code not found", http.StatusNotFound)
+			return
+		}
+		h.requestPendingAction(w, r, service.PendingActionRequest{
+			Action:        pendingDeactivatePromo,
+			TargetType:    "promo_code",
+			TargetID:      id.String(),
+			Params:        map[string]interface{}{"reason": req.Reason, "active_subscribers": subscribers},
+			Summary:       fmt.Sprintf("Deactivate promo code %s (%d active subscribers)", promo.Code, subscribers),
+			ConfirmPhrase: promo.Code,
+		})
+		return
+	}
+
 	userID := middleware.GetUserID(r.Context())
 	if err := h.adminRepo.DeactivatePromoCode(r.Context(), id, userID, req.Reason); err != nil {
 		http.Error(w, "Failed to deactivate promo code: "+err.Error(), http.StatusInternalServerError)
@@ -378,6 +405,37 @@ func (h *Handler) DeactivatePromoCode(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "deactivated"})
 }
 
+// GetPromoCodeAnalytics handles GET /promo-codes/{id}/analytics?days=N:
+// the code's redemption curve, validation → redemption conversion, revenue
+// against discount, plan/interval breakdown and top referring campaigns
+// over the last N days (default 90).
+func (h *Handler) GetPromoCodeAnalytics(w http.ResponseWriter, r *http.Request) {
+	if h.promoCodeService == nil {
+		http.Error(w, "Promo analytics unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid promo code ID", http.StatusBadRequest)
+		return
+	}
+	from, to, ok := metricsHistoryRange(r)
+	if !ok {
+		http.Error(w, "days must be between 1 and 730", http.StatusBadRequest)
+		return
+	}
+	analytics, err := h.promoCodeService.Analytics(r.Context(), id, from, to)
+	if errors.Is(err, service.ErrPromoCodeNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get promo code analytics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, analytics)
+}
+
 // GetPromoCodeUsages returns usage history for a promo code
 func (h *Handler) GetPromoCodeUsages(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// SLOW QUERIES — pg_stat_statements history: the statements costing the
+// database the most time, tagged with the repository method that issued
+// them, shown as a panel on the infrastructure page.
+// ============================================================================
+
+// ListSlowQueries handles GET /api/admin/super/db/queries?window=24h&sort=total&limit=25.
+// sort is total, mean or calls.
+func (h *Handler) ListSlowQueries(w http.ResponseWriter, r *http.Request) {
+	window, err := service.ParsePerformanceWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep, err := h.queryStatsService.Worst(r.Context(), window, r.URL.Query().Get("sort"), getIntParam(r, "limit", 25))
+	if err != nil {
+		http.Error(w, "Failed to load slow queries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, rep)
+}
+
+// GetSlowQueryTrend handles GET /api/admin/super/db/queries/{queryID}/trend?days=30.
+func (h *Handler) GetSlowQueryTrend(w http.ResponseWriter, r *http.Request) {
+	queryID, err := strconv.ParseInt(chi.URLParam(r, "queryID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid query id", http.StatusBadRequest)
+		return
+	}
+	points, err := h.queryStatsService.Trend(r.Context(), queryID, getIntParam(r, "days", 30))
+	if err != nil {
+		http.Error(w, "Failed to load query trend: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"points": points})
+}
+
+// CollectQueryStats handles POST /api/admin/super/db/queries/collect — take
+// a snapshot now instead of waiting for the collector.
+func (h *Handler) CollectQueryStats(w http.ResponseWriter, r *http.Request) {
+	n, err := h.queryStatsService.Collect(r.Context())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrQueryStatsUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, "Failed to collect query stats: "+err.Error(), status)
+		return
+	}
+	h.logAction(r, "collect_query_stats", "system", uuid.Nil, map[string]interface{}{"statements": n})
+	respondJSON(w, map[string]interface{}{"statements": n})
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// RESEARCH CONSENTS — which families contribute de-identified data to the
+// warehouse export, and the consent artifacts they signed. Shown on the
+// warehouse exports page.
+// ============================================================================
+
+// ListResearchConsents handles GET /api/admin/research-consents.
+func (h *Handler) ListResearchConsents(w http.ResponseWriter, r *http.Request) {
+	if h.researchService == nil {
+		http.Error(w, "Research consents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	summary, err := h.researchService.Summary(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load research summary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	consents, err := h.researchService.Recent(r.Context(), getIntParam(r, "limit", 100))
+	if err != nil {
+		http.Error(w, "Failed to list research consents: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"summary":            summary,
+		"consents":           consents,
+		"disclosure_version": service.CurrentResearchDisclosureVersion,
+	})
+}
+
+// GetResearchConsent handles GET /api/admin/research-consents/{id}: the
+// full artifact, including the disclosure text the parent signed.
+func (h *Handler) GetResearchConsent(w http.ResponseWriter, r *http.Request) {
+	if h.researchService == nil {
+		http.Error(w, "Research consents unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid consent ID", http.StatusBadRequest)
+		return
+	}
+	c, err := h.researchService.Get(r.Context(), id)
+	if errors.Is(err, service.ErrResearchConsentNotFound) {
+		http.Error(w, "Consent not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load consent: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, c)
+}
@@ -0,0 +1,260 @@
+package admin
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// RESUMABLE UPLOADS — tus 1.0 core plus the creation, checksum and
+// termination extensions, so stock tus clients work as well as our pages.
+// Mounted per purpose (see resumableUploadRoutes); the purpose's section
+// gate applies to every request.
+// ============================================================================
+
+const tusVersion = "1.0.0"
+
+// statusChecksumMismatch is the tus checksum extension's "460 Checksum
+// Mismatch"; net/http has no constant for it.
+const statusChecksumMismatch = 460
+
+// uploadErrorStatus maps upload errors to HTTP status codes. Anything else
+// came from the sink.
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUploadInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUploadOffsetConflict), errors.Is(err, service.ErrUploadClosed):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUploadChecksum):
+		return statusChecksumMismatch
+	default:
+		return fileTransferErrorStatus(err)
+	}
+}
+
+// parseUploadMetadata decodes tus Upload-Metadata: comma-separated
+// "key base64(value)" pairs, where the value may be omitted.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	meta := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, enc, _ := strings.Cut(pair, " ")
+		val, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, errors.New("Upload-Metadata values must be base64")
+		}
+		meta[key] = string(val)
+	}
+	return meta, nil
+}
+
+// tusHeaders advertises the protocol on every response and refuses
+// clients speaking another version.
+func tusHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		if v := r.Header.Get("Tus-Resumable"); v != "" && v != tusVersion && r.Method != http.MethodOptions {
+			w.Header().Set("Tus-Version", tusVersion)
+			http.Error(w, "Unsupported tus version", http.StatusPreconditionFailed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// resumableUploadRoutes mounts the upload protocol for one sink purpose.
+func (h *Handler) resumableUploadRoutes(purpose string) func(chi.Router) {
+	return func(r chi.Router) {
+		r.Use(tusHeaders)
+		r.Options("/", h.UploadOptions)
+		r.Post("/", h.createUpload(purpose))
+		r.Head("/{uploadID}", h.uploadOffset(purpose))
+		r.Get("/{uploadID}", h.uploadStatus(purpose))
+		r.Patch("/{uploadID}", h.writeUploadChunk(purpose))
+		r.Delete("/{uploadID}", h.abortUpload(purpose))
+	}
+}
+
+// UploadOptions handles OPTIONS on an upload endpoint (tus discovery).
+func (h *Handler) UploadOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", "creation,checksum,termination")
+	w.Header().Set("Tus-Checksum-Algorithm", "sha256")
+	if h.uploadService != nil {
+		limits := h.uploadService.Limits()
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(limits.MaxBytes, 10))
+		w.Header().Set("Upload-Chunk-Max", strconv.FormatInt(limits.ChunkBytes, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadUpload resolves {uploadID} for the calling admin, writing the error
+// response itself when it can't.
+func (h *Handler) loadUpload(w http.ResponseWriter, r *http.Request, purpose string) *repository.UploadSession {
+	if h.uploadService == nil {
+		http.Error(w, "Upload service unavailable", http.StatusServiceUnavailable)
+		return nil
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "uploadID"))
+	if err != nil {
+		http.Error(w, "Invalid upload ID", http.StatusBadRequest)
+		return nil
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	u, err := h.uploadService.Get(r.Context(), id, purpose, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), uploadErrorStatus(err))
+		return nil
+	}
+	return u
+}
+
+// createUpload handles POST (tus creation). Upload-Length is required;
+// Upload-Metadata carries filename, filetype, optional sha256 (hex) and
+// any sink fields such as note and ttl_hours.
+func (h *Handler) createUpload(purpose string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.uploadService == nil {
+			http.Error(w, "Upload service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil {
+			http.Error(w, "Upload-Length header is required", http.StatusBadRequest)
+			return
+		}
+		meta, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		in := service.CreateUploadInput{
+			Purpose:     purpose,
+			Filename:    meta["filename"],
+			ContentType: meta["filetype"],
+			TotalBytes:  length,
+			SHA256:      meta["sha256"],
+			CreatedBy:   middleware.GetAuthClaims(r.Context()).UserID,
+		}
+		delete(meta, "filename")
+		delete(meta, "filetype")
+		delete(meta, "sha256")
+		in.Metadata = meta
+
+		u, err := h.uploadService.Create(r.Context(), in)
+		if err != nil {
+			http.Error(w, err.Error(), uploadErrorStatus(err))
+			return
+		}
+		h.logAction(r, "start_upload", "upload_session", u.ID, map[string]interface{}{
+			"purpose": purpose, "filename": u.Filename, "total_bytes": u.TotalBytes,
+		})
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+u.ID.String())
+		w.Header().Set("Upload-Offset", "0")
+		w.WriteHeader(http.StatusCreated)
+		respondJSON(w, u)
+	}
+}
+
+// uploadOffset handles HEAD — where to resume from.
+func (h *Handler) uploadOffset(purpose string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := h.loadUpload(w, r, purpose)
+		if u == nil {
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.FormatInt(u.ReceivedBytes, 10))
+		w.Header().Set("Upload-Length", strconv.FormatInt(u.TotalBytes, 10))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// uploadStatus handles GET — the session as JSON, including status and,
+// once assembled, the sink's result_id.
+func (h *Handler) uploadStatus(purpose string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := h.loadUpload(w, r, purpose)
+		if u == nil {
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		respondJSON(w, u)
+	}
+}
+
+// writeUploadChunk handles PATCH with an application/offset+octet-stream
+// body at Upload-Offset, optionally verified by "Upload-Checksum: sha256
+// <base64>".
+func (h *Handler) writeUploadChunk(purpose string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+			http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+			return
+		}
+		offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+		if err != nil {
+			http.Error(w, "Upload-Offset header is required", http.StatusBadRequest)
+			return
+		}
+		var checksum []byte
+		if v := r.Header.Get("Upload-Checksum"); v != "" {
+			algo, enc, _ := strings.Cut(v, " ")
+			if algo != "sha256" {
+				http.Error(w, "Only sha256 checksums are supported", http.StatusBadRequest)
+				return
+			}
+			if checksum, err = base64.StdEncoding.DecodeString(enc); err != nil {
+				http.Error(w, "Upload-Checksum must be base64", http.StatusBadRequest)
+				return
+			}
+		}
+		u := h.loadUpload(w, r, purpose)
+		if u == nil {
+			return
+		}
+		extendTransferDeadline(w)
+		u, err = h.uploadService.WriteChunk(r.Context(), u, offset, r.Body, checksum)
+		if err != nil {
+			http.Error(w, err.Error(), uploadErrorStatus(err))
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.FormatInt(u.ReceivedBytes, 10))
+		w.Header().Set("Upload-Status", u.Status)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// abortUpload handles DELETE (tus termination).
+func (h *Handler) abortUpload(purpose string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := h.loadUpload(w, r, purpose)
+		if u == nil {
+			return
+		}
+		if err := h.uploadService.Abort(r.Context(), u); err != nil {
+			http.Error(w, err.Error(), uploadErrorStatus(err))
+			return
+		}
+		h.logAction(r, "abort_upload", "upload_session", u.ID, map[string]interface{}{"purpose": purpose})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"time"
+
 	"github.com/go-chi/chi/v5"
 
 	"carecompanion/internal/middleware"
@@ -10,19 +12,67 @@ import (
 
 // Handler holds dependencies for admin handlers
 type Handler struct {
-	adminRepo         repository.AdminRepository
-	authService       *service.AuthService
-	cloudwatchService *service.CloudWatchService
-	marketingService  *service.MarketingService
-	pushService       *service.PushService
-	roadmapService    *service.RoadmapService
-	dupService        *service.TicketDuplicateService
-	attachService     *service.TicketAttachmentService
-	betaService       *service.BetaService
-	bountyService     *service.BountyService
-	liveSessionsService *service.LiveSessionsService
-	proQAService        *service.ProQAService
-	roleService         *service.RoleService
+	adminRepo                repository.AdminRepository
+	authService              *service.AuthService
+	cloudwatchService        *service.CloudWatchService
+	marketingService         *service.MarketingService
+	pushService              *service.PushService
+	roadmapService           *service.RoadmapService
+	dupService               *service.TicketDuplicateService
+	attachService            *service.TicketAttachmentService
+	taxonomyService          *service.TicketTaxonomyService
+	kbService                *service.KnowledgeBaseService
+	feedbackService          *service.FeedbackService
+	campaignService          *service.CampaignService
+	testimonialService       *service.TestimonialService
+	fileTransferService      *service.FileTransferService
+	uploadService            *service.UploadService
+	uploadScanService        *service.UploadScanService
+	backupService            *service.BackupService
+	costService              *service.CostService
+	warehouseService         *service.WarehouseExportService
+	analyticsService         *service.ProductAnalyticsService
+	engagementService        *service.EngagementService
+	segmentService           *service.SegmentService
+	onboardingService        *service.OnboardingService
+	logSearchService         *service.LogSearchService
+	logRetention             *service.LogRetentionService
+	performanceService       *service.PerformanceService
+	apiUsageService          *service.APIUsageService
+	abuseService             *service.AbuseService
+	captchaService           *service.CaptchaService
+	queryStatsService        *service.QueryStatsService
+	securityHeaders          *service.SecurityHeadersService
+	appVersionService        *service.AppVersionService
+	legalService             *service.LegalService
+	researchService          *service.ResearchConsentService
+	organizationService      *service.OrganizationService
+	orgBillingService        *service.OrganizationBillingService
+	invoiceService           *service.InvoiceService
+	taxService               *service.TaxService
+	disputeService           *service.DisputeService
+	exitPackageService       *service.ExitPackageService
+	logUsageService          *service.LogUsageService
+	metricAnomalyService     *service.MetricAnomalyService
+	adminNotificationService *service.AdminNotificationService
+	integrityService         *service.IntegrityService
+	pendingActionService     *service.PendingActionService
+	promoCodeService         *service.PromoCodeService
+	promoFraudService        *service.PromoFraudService
+	cspPolicy                string
+	cspReportOnly            bool
+	taskQueue                *service.TaskQueue
+	betaService              *service.BetaService
+	bountyService            *service.BountyService
+	liveSessionsService      *service.LiveSessionsService
+	proQAService             *service.ProQAService
+	roleService              *service.RoleService
+	adminInviteService       *service.AdminInviteService
+	accessReviewService      *service.AdminAccessReviewService
+	elevationService         *service.AdminElevationService
+	complianceExportService  *service.ComplianceExportService
+	sessionPolicyService     *service.AdminSessionPolicyService
+	mergeService             *service.TicketMergeService
 }
 
 // SetRoleService wires the custom-role service for the role-builder UI.
@@ -30,6 +80,36 @@ func (h *Handler) SetRoleService(s *service.RoleService) {
 	h.roleService = s
 }
 
+// SetAdminInviteService wires the CSV admin import and invitation links.
+func (h *Handler) SetAdminInviteService(s *service.AdminInviteService) {
+	h.adminInviteService = s
+}
+
+// SetAccessReviewService wires quarterly admin access reviews.
+func (h *Handler) SetAccessReviewService(s *service.AdminAccessReviewService) {
+	h.accessReviewService = s
+}
+
+// SetElevationService wires just-in-time elevation requests.
+func (h *Handler) SetElevationService(s *service.AdminElevationService) {
+	h.elevationService = s
+}
+
+// SetComplianceExportService wires the auditor CSV exports.
+func (h *Handler) SetComplianceExportService(s *service.ComplianceExportService) {
+	h.complianceExportService = s
+}
+
+// SetSessionPolicyService wires the admin session policy settings.
+func (h *Handler) SetSessionPolicyService(s *service.AdminSessionPolicyService) {
+	h.sessionPolicyService = s
+}
+
+// SetTicketMergeService wires ticket merge and duplicate suggestions.
+func (h *Handler) SetTicketMergeService(s *service.TicketMergeService) {
+	h.mergeService = s
+}
+
 // SetProQAService wires the Pro QA workspace service.
 func (h *Handler) SetProQAService(s *service.ProQAService) {
 	h.proQAService = s
@@ -65,6 +145,213 @@ func (h *Handler) SetTicketAttachmentService(s *service.TicketAttachmentService)
 	h.attachService = s
 }
 
+// SetTicketTaxonomyService wires the ticket category/tag service.
+func (h *Handler) SetTicketTaxonomyService(s *service.TicketTaxonomyService) {
+	h.taxonomyService = s
+}
+
+// SetKnowledgeBaseService wires the help center article service.
+func (h *Handler) SetKnowledgeBaseService(s *service.KnowledgeBaseService) {
+	h.kbService = s
+}
+
+// SetFeedbackService wires the NPS / in-app feedback service.
+func (h *Handler) SetFeedbackService(s *service.FeedbackService) {
+	h.feedbackService = s
+}
+
+// SetCampaignService wires campaign attribution + ROI reporting.
+func (h *Handler) SetCampaignService(s *service.CampaignService) {
+	h.campaignService = s
+}
+
+// SetTestimonialService wires the testimonial / case-study manager.
+func (h *Handler) SetTestimonialService(s *service.TestimonialService) {
+	h.testimonialService = s
+}
+
+// SetFileTransferService wires the /filextfer file drop.
+func (h *Handler) SetFileTransferService(s *service.FileTransferService) {
+	h.fileTransferService = s
+}
+
+// SetUploadService wires resumable (chunked) uploads.
+func (h *Handler) SetUploadService(s *service.UploadService) {
+	h.uploadService = s
+}
+
+// SetUploadScanService wires the antivirus verdict log and quarantine queue.
+func (h *Handler) SetUploadScanService(s *service.UploadScanService) {
+	h.uploadScanService = s
+}
+
+// SetLegalService wires versioned Terms/Privacy documents and acceptances.
+func (h *Handler) SetLegalService(s *service.LegalService) {
+	h.legalService = s
+}
+
+// SetResearchConsentService wires families' research participation consents.
+func (h *Handler) SetResearchConsentService(s *service.ResearchConsentService) {
+	h.researchService = s
+}
+
+// SetOrganizationService wires clinics, their staff and seat usage.
+func (h *Handler) SetOrganizationService(s *service.OrganizationService) {
+	h.organizationService = s
+}
+
+// SetOrganizationBillingService wires organization seat changes and seat
+// utilization reporting.
+func (h *Handler) SetOrganizationBillingService(s *service.OrganizationBillingService) {
+	h.orgBillingService = s
+}
+
+// SetDisputeService wires the payment dispute list and updates.
+func (h *Handler) SetDisputeService(s *service.DisputeService) {
+	h.disputeService = s
+}
+
+// SetExitPackageService wires the data export scheduled when an admin
+// cancels a family's subscription.
+func (h *Handler) SetExitPackageService(s *service.ExitPackageService) {
+	h.exitPackageService = s
+}
+
+// SetLogUsageService wires the per-log-type usage report.
+func (h *Handler) SetLogUsageService(s *service.LogUsageService) {
+	h.logUsageService = s
+}
+
+// SetMetricAnomalyService wires the metric anomaly detector's settings and
+// manual scan.
+func (h *Handler) SetMetricAnomalyService(s *service.MetricAnomalyService) {
+	h.metricAnomalyService = s
+}
+
+// SetAdminNotificationService wires the admin notification list.
+func (h *Handler) SetAdminNotificationService(s *service.AdminNotificationService) {
+	h.adminNotificationService = s
+}
+
+// SetIntegrityService wires the data integrity checker.
+func (h *Handler) SetIntegrityService(s *service.IntegrityService) {
+	h.integrityService = s
+}
+
+// SetPromoCodeService wires the promo code analytics.
+func (h *Handler) SetPromoCodeService(s *service.PromoCodeService) {
+	h.promoCodeService = s
+}
+
+// SetPromoFraudService wires the promo fraud review queue.
+func (h *Handler) SetPromoFraudService(s *service.PromoFraudService) {
+	h.promoFraudService = s
+}
+
+// SetPendingActionService wires the confirmation workflow for destructive
+// actions and registers the action types this handler holds.
+func (h *Handler) SetPendingActionService(s *service.PendingActionService) {
+	h.pendingActionService = s
+	h.registerPendingActions(s)
+}
+
+// SetTaxService wires the tax-collected report and rate table.
+func (h *Handler) SetTaxService(s *service.TaxService) {
+	h.taxService = s
+}
+
+// SetInvoiceService wires invoice search, download, regenerate and resend.
+func (h *Handler) SetInvoiceService(s *service.InvoiceService) {
+	h.invoiceService = s
+}
+
+// SetBackupService wires RDS snapshots, config dumps and restore drills.
+func (h *Handler) SetBackupService(s *service.BackupService) {
+	h.backupService = s
+}
+
+// SetWarehouseExportService wires the analytics warehouse export history.
+func (h *Handler) SetWarehouseExportService(s *service.WarehouseExportService) {
+	h.warehouseService = s
+}
+
+// SetProductAnalyticsService wires the retention/engagement analytics.
+func (h *Handler) SetProductAnalyticsService(s *service.ProductAnalyticsService) {
+	h.analyticsService = s
+}
+
+// SetEngagementService wires the engagement scores / churn-risk list.
+func (h *Handler) SetEngagementService(s *service.EngagementService) {
+	h.engagementService = s
+}
+
+// SetSegmentService wires the saved-segment builder.
+func (h *Handler) SetSegmentService(s *service.SegmentService) {
+	h.segmentService = s
+}
+
+// SetOnboardingService wires the guided setup funnel report.
+func (h *Handler) SetOnboardingService(s *service.OnboardingService) {
+	h.onboardingService = s
+}
+
+// SetCostService wires the AWS cost panel and budget alerts.
+func (h *Handler) SetCostService(s *service.CostService) {
+	h.costService = s
+}
+
+// SetLogSearchService wires the Logs Insights application log search.
+func (h *Handler) SetLogSearchService(s *service.LogSearchService) {
+	h.logSearchService = s
+}
+
+// SetLogRetentionService wires the request/error log retention settings.
+func (h *Handler) SetLogRetentionService(s *service.LogRetentionService) {
+	h.logRetention = s
+}
+
+// SetPerformanceService wires the per-route latency report.
+func (h *Handler) SetPerformanceService(s *service.PerformanceService) {
+	h.performanceService = s
+}
+
+// SetAPIUsageService wires the per-client API usage report.
+func (h *Handler) SetAPIUsageService(s *service.APIUsageService) {
+	h.apiUsageService = s
+}
+
+// SetAbuseService wires the abuse block review queue.
+func (h *Handler) SetAbuseService(s *service.AbuseService) {
+	h.abuseService = s
+}
+
+// SetCaptchaService wires the CAPTCHA settings and failure metrics.
+func (h *Handler) SetCaptchaService(s *service.CaptchaService) {
+	h.captchaService = s
+}
+
+// SetQueryStatsService wires the pg_stat_statements slow query panel.
+func (h *Handler) SetQueryStatsService(s *service.QueryStatsService) {
+	h.queryStatsService = s
+}
+
+// SetSecurityHeadersService wires the HSTS/frame options settings. csp and
+// reportOnly are the configured policy, shown read-only next to them.
+func (h *Handler) SetSecurityHeadersService(s *service.SecurityHeadersService, csp string, reportOnly bool) {
+	h.securityHeaders = s
+	h.cspPolicy, h.cspReportOnly = csp, reportOnly
+}
+
+// SetAppVersionService wires the minimum app version policy.
+func (h *Handler) SetAppVersionService(s *service.AppVersionService) {
+	h.appVersionService = s
+}
+
+// SetTaskQueue wires the background task queue admin view.
+func (h *Handler) SetTaskQueue(q *service.TaskQueue) {
+	h.taskQueue = q
+}
+
 // SetLiveSessionsService wires the live-sessions aggregator.
 func (h *Handler) SetLiveSessionsService(s *service.LiveSessionsService) {
 	h.liveSessionsService = s
@@ -92,13 +379,21 @@ func (h *Handler) SetMarketingService(ms *service.MarketingService) {
 func (h *Handler) Routes() chi.Router {
 	r := chi.NewRouter()
 
-	// All admin routes require authentication
+	// All admin routes require authentication, and every mutation is
+	// audited (see audit.go). Read-only roles (auditor) can't mutate
+	// anything, whatever the route's own gate.
 	r.Use(middleware.AuthMiddleware(h.authService))
+	r.Use(h.auditMutations)
+	r.Use(middleware.RejectReadOnlyWrites())
 
 	// Lightweight liveness probe used by admin_session_guard.js. AuthMiddleware
 	// returns 401 on missing/expired/revoked session — handler just confirms 200.
 	r.Get("/auth/check", h.AdminAuthCheck)
 
+	// Step-up re-auth for the session policy's sensitive-action window.
+	// Rate limited: it's a password check.
+	r.With(middleware.RateLimit(5, time.Minute)).Post("/auth/reauth", h.AdminReauth)
+
 	// Sessions: super_admin + support can revoke individual sessions.
 	// Role check is in the handler (not via middleware) so we can extend the
 	// allowed roles in a later slice without restructuring the route tree.
@@ -111,15 +406,86 @@ func (h *Handler) Routes() chi.Router {
 	r.Post("/sessions/revoke", h.BulkRevokeSessions)
 	r.Post("/sessions/ssh/kill", h.KillSSHSessionJSON)
 
+	// Pending destructive actions (see pending_action_handlers.go). The
+	// requester confirms or cancels their own; approving, rejecting and the
+	// approval policy are super_admin only.
+	r.Route("/pending-actions", func(r chi.Router) {
+		r.Get("/", h.ListPendingActions)
+		r.Get("/{actionID}", h.GetPendingAction)
+		r.Post("/{actionID}/confirm", h.ConfirmPendingAction)
+		r.Post("/{actionID}/cancel", h.CancelPendingAction)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireRecentAuth())
+			r.Post("/{actionID}/approve", h.ApprovePendingAction)
+			r.Post("/{actionID}/reject", h.RejectPendingAction)
+			r.Get("/policy", h.GetPendingActionPolicy)
+			r.Put("/policy", h.UpdatePendingActionPolicy)
+		})
+	})
+
+	// Compliance exports for auditors: watermarked CSVs, rate limited so
+	// nobody walks off with the whole history in a loop.
+	r.Route("/compliance/exports", func(r chi.Router) {
+		r.Use(middleware.RateLimit(10, time.Minute))
+		r.With(middleware.RequireSection("audit_log")).Get("/audit-log.csv", h.ExportAuditLog)
+		r.With(middleware.RequireSection("audit_log")).Get("/phi-access.csv", h.ExportPHIAccess)
+		r.With(middleware.RequireSection("system_settings")).Get("/settings-history.csv", h.ExportSettingsHistory)
+	})
+
+	// Just-in-time elevation. Any admin requests, cancels and ends their
+	// own; super admins decide and see the active dashboard.
+	r.Route("/elevations", func(r chi.Router) {
+		r.Get("/", h.ListElevations)
+		r.Post("/", h.RequestElevation)
+		r.Post("/{elevationID}/cancel", h.CancelElevation)
+		r.Post("/{elevationID}/revoke", h.RevokeElevation)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireRecentAuth())
+			r.Get("/active", h.ListActiveElevations)
+			r.Post("/{elevationID}/approve", h.ApproveElevation)
+			r.Post("/{elevationID}/deny", h.DenyElevation)
+		})
+	})
+
+	// Request latency by route — read from the hourly rollups, so it
+	// sits with the infrastructure pages.
+	r.Route("/performance", func(r chi.Router) {
+		r.Use(middleware.RequireSection("infrastructure_status"))
+		r.Get("/routes", h.GetRouteLatency)
+		r.Get("/clients", h.GetAPIUsage)
+	})
+
+	// Product analytics — cached retention, DAU/WAU/MAU, adoption and
+	// activation aggregates, gated with the metrics dashboard.
+	r.Route("/analytics", func(r chi.Router) {
+		r.Use(middleware.RequireSection("metrics_dashboard"))
+		r.Get("/", h.GetProductAnalytics)
+		r.Get("/retention", h.GetRetentionCohorts)
+		r.Get("/active-users", h.GetActiveUsers)
+		r.Get("/feature-adoption", h.GetFeatureAdoption)
+		r.Get("/activation", h.GetActivationFunnel)
+		r.Get("/onboarding", h.GetOnboardingFunnel)
+		r.Get("/devices", h.GetDeviceStats)
+		r.Post("/refresh", h.RefreshProductAnalytics)
+	})
+
 	// Super admin routes — gates set per-section below (matrix-driven).
+	// Groups with RequireRecentAuth are the sensitive changes that need a
+	// recent password under the session policy.
 	r.Route("/super", func(r chi.Router) {
 		// No blanket gate — each sub-section sets its own gate below.
 
 		// Admin Users
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("admin_users"))
+			r.Use(middleware.RequireRecentAuth())
 			r.Get("/admins", h.ListAdminUsers)
 			r.Post("/admins", h.CreateAdminUser)
+			r.Post("/admins/import", h.ImportAdminUsers)
+			r.Get("/admins/invitations", h.ListAdminInvitations)
+			r.Post("/admins/{id}/invite", h.ReinviteAdminUser)
 			r.Get("/admins/{id}", h.GetAdminUser)
 			r.Put("/admins/{id}", h.UpdateAdminUser)
 			r.Delete("/admins/{id}", h.DeleteAdminUser)
@@ -129,23 +495,59 @@ func (h *Handler) Routes() chi.Router {
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("metrics_dashboard"))
 			r.Get("/metrics", h.GetSystemMetrics)
+			r.Get("/metrics/history", h.GetMetricsHistory)
+			r.Get("/metrics/history/{metric}", h.GetMetricSeries)
+			r.Get("/metrics/log-usage", h.GetLogUsage)
 			r.Post("/metrics/refresh", h.RefreshMetrics)
+			r.Get("/metrics/anomalies/settings", h.GetAnomalySettings)
+			r.Put("/metrics/anomalies/settings", h.UpdateAnomalySettings)
+			r.Post("/metrics/anomalies/scan", h.ScanMetricAnomalies)
+			r.Get("/notifications", h.ListAdminNotifications)
+			r.Post("/notifications/{notificationID}/acknowledge", h.AcknowledgeAdminNotification)
+			r.Get("/feedback", h.ListFeedback)
+			r.Get("/feedback/nps/report", h.GetNPSReport)
 		})
 
 		// System Settings (super_admin only)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireRecentAuth())
 			r.Get("/settings", h.GetSettings)
 			r.Put("/settings/{key}", h.UpdateSetting)
+			r.Get("/log-retention", h.GetLogRetention)
+			r.Put("/log-retention", h.UpdateLogRetention)
+			r.Post("/log-retention/run", h.RunLogRetention)
+			r.Get("/security-headers", h.GetSecurityHeaders)
+			r.Put("/security-headers", h.UpdateSecurityHeaders)
+			r.Get("/captcha", h.GetCaptcha)
+			r.Put("/captcha", h.UpdateCaptcha)
+			r.Get("/app-versions", h.GetAppVersions)
+			r.Put("/app-versions", h.UpdateAppVersions)
 			r.Post("/maintenance", h.ToggleMaintenanceMode)
+			r.Get("/session-policy", h.GetSessionPolicy)
+			r.Put("/session-policy", h.UpdateSessionPolicy)
 		})
 
-		// Audit Log (super_admin only)
+		// Audit Log (super_admin full, auditor read)
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireSection("audit_log"))
 			r.Get("/audit-log", h.GetAuditLog)
 		})
 
+		// Access reviews (super_admin only)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireRecentAuth())
+			r.Get("/access-reviews", h.ListAccessReviews)
+			r.Post("/access-reviews", h.OpenAccessReview)
+			r.Get("/access-reviews/policy", h.GetAccessReviewPolicy)
+			r.Put("/access-reviews/policy", h.UpdateAccessReviewPolicy)
+			r.Post("/access-reviews/deactivate-inactive", h.DeactivateInactiveAdmins)
+			r.Get("/access-reviews/{reviewID}", h.GetAccessReview)
+			r.Post("/access-reviews/{reviewID}/items/{itemID}", h.DecideAccessReviewItem)
+			r.Post("/access-reviews/{reviewID}/complete", h.CompleteAccessReview)
+		})
+
 		// Infrastructure Status
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("infrastructure_status"))
@@ -155,6 +557,44 @@ func (h *Handler) Routes() chi.Router {
 			r.Get("/infra-files/download", h.DownloadInfraFile)
 			r.Post("/infra-files/upload", h.UploadInfraFile)
 			r.Get("/capacity", h.GetCapacity)
+			r.Get("/backups", h.ListBackups)
+			r.Post("/backups/snapshot", h.TriggerSnapshot)
+			r.Post("/backups/sync", h.SyncSnapshots)
+			r.Post("/backups/config-dump", h.RunConfigDump)
+			r.Get("/warehouse-exports", h.ListWarehouseExports)
+			r.Post("/warehouse-exports/run", h.RunWarehouseExport)
+			r.Post("/warehouse-exports/rebuild-withdrawn", h.RebuildWithdrawnWarehouseExports)
+			r.Get("/costs", h.GetCostSummary)
+			r.Post("/costs/sync", h.SyncCosts)
+			r.Get("/db/queries", h.ListSlowQueries)
+			r.Get("/db/queries/{queryID}/trend", h.GetSlowQueryTrend)
+			r.Post("/db/queries/collect", h.CollectQueryStats)
+			r.Get("/tasks", h.GetTaskQueueStats)
+			r.Get("/tasks/{type}/dead", h.ListDeadTasks)
+			r.Post("/tasks/{type}/dead/{entryID}/retry", h.RetryDeadTask)
+			r.Delete("/tasks/{type}/dead/{entryID}", h.DiscardDeadTask)
+			r.Get("/integrity/checks", h.ListIntegrityChecks)
+			r.Get("/integrity/runs", h.ListIntegrityRuns)
+			r.Get("/integrity/runs/{runID}", h.GetIntegrityRun)
+			r.Post("/integrity/runs", h.RunIntegrityCheck)
+		})
+
+		// Integrity quarantine deletes rows (super_admin only)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireRecentAuth())
+			r.Post("/integrity/quarantine", h.QuarantineIntegrityOrphans)
+		})
+
+		// ASG operations — blue/green deploy helpers (super_admin only,
+		// two-step confirmation; see asg_handlers.go)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireRecentAuth())
+			r.Get("/asg/instance-refreshes", h.ListInstanceRefreshes)
+			r.Post("/asg/instance-refresh", h.StartInstanceRefresh)
+			r.Post("/asg/desired-capacity", h.SetASGDesiredCapacity)
+			r.Post("/asg/instances/{id}/drain", h.DrainASGInstance)
 		})
 
 		// Error Logs
@@ -163,11 +603,59 @@ func (h *Handler) Routes() chi.Router {
 			r.Get("/errors", h.ListErrorLogs)
 			r.Get("/errors/unacknowledged-count", h.GetUnacknowledgedErrorCount)
 			r.Get("/errors/{id}", h.GetErrorLog)
+			r.Get("/errors/{id}/logs", h.GetErrorLogContext)
+			r.Get("/logs/search", h.SearchAppLogs)
 			r.Post("/errors/{id}/acknowledge", h.AcknowledgeErrorLog)
 			r.Post("/errors/acknowledge-bulk", h.AcknowledgeErrorLogsBulk)
 			r.Delete("/errors/{id}", h.DeleteErrorLog)
 			r.Post("/errors/delete-bulk", h.DeleteErrorLogsBulk)
 			r.Post("/errors/{id}/create-ticket", h.CreateTicketFromError)
+			r.Get("/abuse", h.ListAbuseBlocks)
+			r.Post("/abuse/{id}/review", h.ReviewAbuseBlock)
+		})
+
+		// File Transfer (/filextfer) — expiring file drop with share links
+		r.Route("/file-transfers", func(r chi.Router) {
+			r.Use(middleware.RequireSection("file_transfer"))
+			r.Get("/", h.ListFileTransfers)
+			r.Post("/", h.UploadFileTransfer)
+			r.Post("/text", h.SaveFileTransferText)
+			r.Get("/{id}/download", h.DownloadFileTransfer)
+			r.Get("/{id}/preview", h.PreviewFileTransfer)
+			r.Delete("/{id}", h.DeleteFileTransfer)
+			r.Post("/{id}/share", h.CreateFileTransferShare)
+			r.Delete("/{id}/share", h.RevokeFileTransferShare)
+			r.Route("/uploads", h.resumableUploadRoutes(service.UploadPurposeFileTransfer))
+		})
+
+		// Upload Scans — antivirus verdicts + quarantine review queue
+		r.Route("/upload-scans", func(r chi.Router) {
+			r.Use(middleware.RequireSection("upload_scans"))
+			r.Get("/", h.ListUploadScans)
+			r.Get("/health", h.UploadScannerHealth)
+			r.Get("/{id}/file", h.DownloadQuarantinedUpload)
+			r.Post("/{id}/review", h.ReviewUploadScan)
+		})
+
+		// Research consents — signed artifacts carry the signer's name, IP
+		// and user agent, so they get their own section (super admin only)
+		// rather than riding on the warehouse export page's.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("research_consents"))
+			r.Get("/research-consents", h.ListResearchConsents)
+			r.Get("/research-consents/{id}", h.GetResearchConsent)
+		})
+
+		// Legal — Terms/Privacy versions + acceptance coverage
+		r.Route("/legal", func(r chi.Router) {
+			r.Use(middleware.RequireSection("legal"))
+			r.Get("/", h.ListLegalDocuments)
+			r.Post("/", h.CreateLegalDocument)
+			r.Get("/{id}", h.GetLegalDocument)
+			r.Put("/{id}", h.UpdateLegalDocument)
+			r.Delete("/{id}", h.DeleteLegalDocument)
+			r.Post("/{id}/publish", h.PublishLegalDocument)
+			r.Get("/{id}/acceptances.csv", h.ExportLegalAcceptances)
 		})
 
 		// Financials + Subscriptions (Partner=full)
@@ -178,6 +666,15 @@ func (h *Handler) Routes() chi.Router {
 			r.Get("/financials/payments", h.GetRecentPayments)
 			r.Get("/financials/subscriptions", h.GetRecentSubscriptions)
 			r.Get("/financials/plans", h.GetSubscriptionPlans)
+			r.Get("/financials/seats", h.GetOrganizationSeatUtilization)
+			r.Get("/financials/invoices", h.ListInvoices)
+			r.Get("/financials/invoices/{id}/pdf", h.DownloadInvoice)
+			r.Post("/financials/invoices/{id}/regenerate", h.RegenerateInvoice)
+			r.Post("/financials/invoices/{id}/resend", h.ResendInvoice)
+			r.Get("/financials/tax", h.GetTaxReport)
+			r.Get("/financials/tax/rates", h.ListTaxRates)
+			r.Get("/financials/disputes", h.ListDisputes)
+			r.Put("/financials/disputes/{id}", h.UpdateDispute)
 			r.Get("/financials/report", h.GenerateFinancialReport)
 
 			// Family-subscription admin tooling (Phase 1 of billing build).
@@ -197,11 +694,19 @@ func (h *Handler) Routes() chi.Router {
 			r.Put("/promo-codes/{id}", h.UpdatePromoCode)
 			r.Post("/promo-codes/{id}/deactivate", h.DeactivatePromoCode)
 			r.Get("/promo-codes/{id}/usages", h.GetPromoCodeUsages)
+			r.Get("/promo-codes/{id}/analytics", h.GetPromoCodeAnalytics)
+			r.Get("/promo-codes/fraud-flags", h.ListPromoFraudFlags)
+			r.Get("/promo-codes/fraud-flags/{flagID}", h.GetPromoFraudFlag)
+			r.Post("/promo-codes/fraud-flags/{flagID}/approve", h.ApprovePromoFraudFlag)
+			r.Post("/promo-codes/fraud-flags/{flagID}/reject", h.RejectPromoFraudFlag)
+			r.Get("/promo-codes/fraud-policy", h.GetPromoFraudPolicy)
+			r.Put("/promo-codes/fraud-policy", h.UpdatePromoFraudPolicy)
 		})
 
 		// Development Mode (super_admin only)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSuperAdmin())
+			r.Use(middleware.RequireRecentAuth())
 			r.Post("/dev-mode/toggle", h.DevModeToggle)
 			r.Post("/dev-mode/kill-session", h.DevModeKillSession)
 			r.Get("/dev-mode/sessions", h.DevModeSessions)
@@ -239,14 +744,34 @@ func (h *Handler) Routes() chi.Router {
 		})
 	})
 
+	// Help center knowledge base (articles + categories)
+	r.Route("/kb", func(r chi.Router) {
+		r.Use(middleware.RequireSection("knowledge_base"))
+		r.Get("/categories", h.ListKBCategories)
+		r.Post("/categories", h.CreateKBCategory)
+		r.Put("/categories/{id}", h.UpdateKBCategory)
+		r.Delete("/categories/{id}", h.DeleteKBCategory)
+		r.Get("/articles", h.ListKBArticles)
+		r.Post("/articles", h.CreateKBArticle)
+		r.Get("/articles/{id}", h.GetKBArticle)
+		r.Put("/articles/{id}", h.UpdateKBArticle)
+		r.Delete("/articles/{id}", h.DeleteKBArticle)
+	})
+
 	// Support routes — gated per section (tickets / users / families)
 	r.Route("/support", func(r chi.Router) {
 		// Tickets
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("tickets"))
 			r.Get("/tickets/open-count", h.GetOpenTicketCount)
+			r.Get("/tickets/report", h.GetTicketReport)
 			r.Get("/tickets", h.ListTickets)
 			r.Post("/tickets", h.CreateTicket)
+			r.Get("/ticket-categories", h.ListTicketCategories)
+			r.Post("/ticket-categories", h.CreateTicketCategory)
+			r.Put("/ticket-categories/{id}", h.UpdateTicketCategory)
+			r.Delete("/ticket-categories/{id}", h.DeleteTicketCategory)
+			r.Get("/ticket-tags", h.ListTicketTags)
 			r.Get("/duplicate-targets", h.SearchDuplicateTargets)
 			r.Get("/tickets/{id}", h.GetTicket)
 			r.Put("/tickets/{id}", h.UpdateTicket)
@@ -256,7 +781,14 @@ func (h *Handler) Routes() chi.Router {
 			r.Post("/tickets/{id}/messages", h.AddTicketMessage)
 			r.Post("/tickets/{id}/mark-duplicate", h.MarkTicketDuplicate)
 			r.Get("/tickets/{id}/duplicates", h.ListTicketDuplicates)
+			r.Post("/tickets/{id}/merge", h.MergeTicket)
+			r.Get("/tickets/{id}/merges", h.ListTicketMerges)
+			r.Get("/tickets/{id}/duplicate-suggestions", h.SuggestTicketDuplicates)
 			r.Get("/tickets/{id}/attachments", h.ListTicketAttachments)
+			r.Post("/tickets/{id}/attachments", h.UploadTicketAttachment)
+			r.Put("/tickets/{id}/tags", h.SetTicketTags)
+			r.Put("/tickets/{id}/category", h.SetTicketCategory)
+			r.Get("/tickets/{id}/kb-suggestions", h.SuggestKBArticlesForTicket)
 			r.Get("/attachments/{id}", h.FetchTicketAttachment)
 		})
 
@@ -275,6 +807,17 @@ func (h *Handler) Routes() chi.Router {
 			r.Use(middleware.RequireSection("families"))
 			r.Get("/families", h.ListFamilies)
 			r.Get("/families/{id}", h.GetFamily)
+
+			// Organizations (clinics covering their client families)
+			r.Get("/organizations", h.ListOrganizations)
+			r.Post("/organizations", h.CreateOrganization)
+			r.Get("/organizations/{id}", h.GetOrganization)
+			r.Put("/organizations/{id}", h.UpdateOrganization)
+			r.Post("/organizations/{id}/join-code", h.RotateOrganizationJoinCode)
+			r.Post("/organizations/{id}/seats", h.SetOrganizationSeats)
+			r.Post("/organizations/{id}/members", h.AddOrganizationMember)
+			r.Delete("/organizations/{id}/members/{userID}", h.RemoveOrganizationMember)
+			r.Delete("/organizations/{id}/families/{familyID}", h.RemoveOrganizationFamily)
 		})
 	})
 
@@ -285,6 +828,9 @@ func (h *Handler) Routes() chi.Router {
 			r.Use(middleware.RequireSection("metrics_dashboard"))
 			r.Get("/dashboard", h.GetMarketingDashboard)
 			r.Get("/metrics", h.GetMarketingMetrics)
+			r.Get("/metrics/history", h.GetMetricsHistory)
+			r.Get("/metrics/history/{metric}", h.GetMetricSeries)
+			r.Get("/metrics/log-usage", h.GetLogUsage)
 		})
 
 		// Tickets — read-only access via section gate (Marketing=read, Partner=full).
@@ -301,6 +847,9 @@ func (h *Handler) Routes() chi.Router {
 			r.Get("/promo-codes", h.ListPromoCodes)
 			r.Get("/promo-codes/{id}", h.GetPromoCode)
 			r.Get("/promo-codes/{id}/usages", h.GetPromoCodeUsages)
+			r.Get("/promo-codes/{id}/analytics", h.GetPromoCodeAnalytics)
+			r.Get("/promo-codes/fraud-flags", h.ListPromoFraudFlags)
+			r.Get("/promo-codes/fraud-flags/{flagID}", h.GetPromoFraudFlag)
 		})
 
 		// Marketing Materials (Partner=read; Marketing/SuperAdmin=full)
@@ -309,11 +858,22 @@ func (h *Handler) Routes() chi.Router {
 			r.Get("/materials", h.ListMarketingAssets)
 			r.Get("/materials/brand-config", h.GetBrandConfig)
 			r.Get("/materials/assets/{id}/download", h.DownloadAsset)
+			r.Get("/materials/assets/{id}/signed-url", h.AssetSignedURL)
 			r.Get("/materials/social-templates", h.ListSocialTemplates)
 			r.Post("/materials/social-graphic", h.GenerateSocialGraphic)
 			r.Get("/materials/brochure", h.GenerateBrochure)
 			r.Get("/materials/style-guide", h.GenerateStyleGuide)
 			r.Get("/materials/logo", h.GenerateLogo)
+			r.Get("/materials/logo-sheet", h.GenerateLogoSheet)
+			r.Get("/materials/print", h.GeneratePrintCollateral)
+			r.Get("/materials/press-kit", h.GeneratePressKit)
+			r.Get("/materials/screenshots", h.ListScreenshots)
+			r.Post("/materials/store-listing", h.GenerateStoreListing)
+			r.Get("/materials/fonts", h.ListBrandFonts)
+			r.Get("/materials/landing-snippet", h.GetLandingSnippet)
+			r.Get("/materials/newsletter/merge-fields", h.ListNewsletterMergeFields)
+			r.Post("/materials/newsletter", h.GenerateNewsletter)
+			r.Get("/qr", h.GenerateQRCode)
 		})
 
 		// Beta program (marketing-managed TestFlight invites)
@@ -331,6 +891,43 @@ func (h *Handler) Routes() chi.Router {
 			r.Post("/bounty/select", h.SelectBountyCandidate)
 			r.Post("/bounty/thanks-anyway", h.ThanksAnywayBountyCandidate)
 		})
+
+		// Campaigns + UTM attribution / ROI — Partner=read; Marketing=full.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("campaigns"))
+			r.Get("/campaigns", h.ListCampaigns)
+			r.Post("/campaigns", h.CreateCampaign)
+			r.Get("/campaigns/report", h.GetCampaignROIReport)
+			r.Get("/campaigns/{id}", h.GetCampaign)
+			r.Put("/campaigns/{id}", h.UpdateCampaign)
+			r.Post("/campaigns/{id}/spend", h.AddCampaignSpend)
+			r.Delete("/campaigns/{id}/spend/{spendID}", h.DeleteCampaignSpend)
+			r.Get("/churn-risk", h.ListChurnRisk)
+			r.Get("/churn-risk/export", h.ExportChurnRisk)
+			r.Get("/segments", h.ListSegments)
+			r.Post("/segments", h.CreateSegment)
+			r.Get("/segments/options", h.GetSegmentOptions)
+			r.Get("/segments/preview", h.PreviewSegment)
+			r.Get("/segments/{id}", h.GetSegment)
+			r.Put("/segments/{id}", h.UpdateSegment)
+			r.Delete("/segments/{id}", h.DeleteSegment)
+		})
+
+		// Testimonials / case studies — content lives with the other copy
+		// materials, so Partner=read; Marketing=full.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("copy_materials"))
+			r.Get("/testimonials", h.ListTestimonials)
+			r.Post("/testimonials", h.CreateTestimonial)
+			r.Get("/testimonials/{id}", h.GetTestimonial)
+			r.Put("/testimonials/{id}", h.UpdateTestimonial)
+			r.Delete("/testimonials/{id}", h.DeleteTestimonial)
+			r.Post("/testimonials/{id}/consent", h.RecordTestimonialConsent)
+			r.Post("/testimonials/{id}/status", h.SetTestimonialStatus)
+			r.Post("/testimonials/{id}/feature", h.SetTestimonialFeatured)
+			r.Get("/testimonials/{id}/headshot", h.GetTestimonialHeadshot)
+			r.Post("/testimonials/{id}/headshot", h.UploadTestimonialHeadshot)
+		})
 	})
 
 	// Super admin marketing material management (mutations on copy_materials).
@@ -341,6 +938,11 @@ func (h *Handler) Routes() chi.Router {
 		r.Put("/brand-config", h.UpdateBrandConfig)
 		r.Post("/regenerate/{type}", h.RegenerateAsset)
 		r.Post("/regenerate-all", h.RegenerateAllAssets)
+		r.Get("/jobs/{id}", h.GetAssetRegenJob)
+		r.Post("/fonts", h.UploadBrandFont)
+		r.Delete("/fonts/{id}", h.DeleteBrandFont)
+		r.Post("/screenshots", h.UploadScreenshot)
+		r.Delete("/screenshots/{id}", h.DeleteScreenshot)
 	})
 
 	return r
@@ -354,10 +956,18 @@ func (h *Handler) UIRoutes() chi.Router {
 	r.Get("/login", h.AdminLoginPage)
 	r.Post("/login", h.AdminLoginSubmit)
 
+	// Invitation links from the CSV import (no auth required; the token is the credential)
+	r.Get("/invite/{token}", h.AdminInvitePage)
+	r.Post("/invite/{token}", h.AdminInviteSubmit)
+
 	// Protected UI routes — section gates per page (matrix-driven)
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware(h.authService))
 		r.Use(middleware.RequireAnyAdminRole())
+		// Sub-routers below repeat auditMutations: chi.Walk doesn't report a
+		// group's middleware on a mounted sub-router, so
+		// TestMutatingRoutesAudited can't see it otherwise.
+		r.Use(h.auditMutations)
 
 		r.Get("/", h.AdminDashboard)
 		r.Get("/dashboard", h.AdminDashboard)
@@ -374,6 +984,7 @@ func (h *Handler) UIRoutes() chi.Router {
 		// privilege-escalation footgun.
 		r.Route("/user-roles", func(r chi.Router) {
 			r.Use(middleware.RequireSuperAdmin())
+			r.Use(h.auditMutations)
 			r.Get("/", h.UserRolesPage)
 			r.Get("/new", h.UserRoleFormPage)
 			r.Post("/", h.UserRoleCreate)
@@ -382,17 +993,28 @@ func (h *Handler) UIRoutes() chi.Router {
 			r.Post("/{id}/delete", h.UserRoleDelete)
 		})
 
+		// Audit log and compliance exports (super_admin full, auditor read)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("audit_log"))
+			r.Get("/audit", h.AuditLogPage)
+			r.Get("/compliance", h.ComplianceExportsPage)
+		})
+
+		// Elevated access — every admin can ask; the page shows super
+		// admins the approval queue too.
+		r.Get("/elevations", h.ElevationsPage)
+
 		// Live Sessions (Partner=full, super_admin/support=full)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("live_sessions"))
 			r.Get("/sessions", h.LiveSessionsPage)
 		})
 
-		// System pages (super_admin only — Settings, Audit, Development)
+		// System pages (super_admin only — Settings, Access Reviews, Development)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSuperAdmin())
 			r.Get("/settings", h.SettingsPage)
-			r.Get("/audit", h.AuditLogPage)
+			r.Get("/access-reviews", h.AccessReviewsPage)
 			r.Get("/development", h.DevelopmentPage)
 		})
 
@@ -401,12 +1023,33 @@ func (h *Handler) UIRoutes() chi.Router {
 			r.Use(middleware.RequireSection("infrastructure_status"))
 			r.Get("/status", h.StatusPage)
 			r.Get("/capacity", h.CapacityPage)
+			r.Get("/backups", h.BackupsPage)
+			r.Get("/warehouse-exports", h.WarehouseExportsPage)
 		})
 
 		// Errors page (Partner=read)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("error_logs"))
 			r.Get("/errors", h.ErrorsPage)
+			r.Get("/abuse", h.AbusePage)
+		})
+
+		// File Transfer (Partner=full)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("file_transfer"))
+			r.Get("/filextfer", h.FileTransferPage)
+		})
+
+		// Upload Scans (Partner=full, Support=read)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("upload_scans"))
+			r.Get("/upload-scans", h.UploadScansPage)
+		})
+
+		// Legal documents (Partner=full, Support=read)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("legal"))
+			r.Get("/legal", h.LegalPage)
 		})
 
 		// Financials (Partner=full)
@@ -453,6 +1096,7 @@ func (h *Handler) UIRoutes() chi.Router {
 		// pro_qa read/write granted via /admin/user-roles.
 		r.Route("/pro-qa", func(r chi.Router) {
 			r.Use(middleware.RequireSection("pro_qa"))
+			r.Use(h.auditMutations)
 
 			r.Get("/", h.ProQAIntroPage)
 			r.Get("/intro", h.ProQAIntroPage)
@@ -494,12 +1138,14 @@ func (h *Handler) UIRoutes() chi.Router {
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("families"))
 			r.Get("/families", h.FamiliesPage)
+			r.Get("/organizations", h.OrganizationsPage)
 		})
 
 		// Marketing dashboard
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireSection("metrics_dashboard"))
 			r.Get("/marketing", h.MarketingDashboardPage)
+			r.Get("/analytics", h.ProductAnalyticsPage)
 		})
 		// Marketing tickets pages (read-only for marketing/partner)
 		r.Group(func(r chi.Router) {
@@ -522,6 +1168,12 @@ func (h *Handler) UIRoutes() chi.Router {
 			r.Use(middleware.RequireSection("bounty_program"))
 			r.Get("/marketing/bounty", h.BountyProgramPage)
 		})
+		// Churn-risk and saved-segment pages
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSection("campaigns"))
+			r.Get("/marketing/churn-risk", h.ChurnRiskPage)
+			r.Get("/marketing/segments", h.SegmentsPage)
+		})
 	})
 
 	return r
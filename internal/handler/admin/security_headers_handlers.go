@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// SECURITY HEADERS — HSTS and X-Frame-Options, shown as a panel on the
+// settings page. The CSP itself is per-environment config (CSP_POLICY) and
+// is shown read-only.
+// ============================================================================
+
+// GetSecurityHeaders handles GET /api/admin/super/security-headers.
+func (h *Handler) GetSecurityHeaders(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.securityHeaders.Settings(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load security headers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"settings":        settings,
+		"csp":             h.cspPolicy,
+		"csp_report_only": h.cspReportOnly,
+	})
+}
+
+// UpdateSecurityHeaders handles PUT /api/admin/super/security-headers.
+func (h *Handler) UpdateSecurityHeaders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req service.SecurityHeaderSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	before, err := h.securityHeaders.Settings(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load security headers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	claims := middleware.GetAuthClaims(ctx)
+	if err := h.securityHeaders.UpdateSettings(ctx, req, claims.UserID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrSecurityHeadersInvalid) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h.logAction(r, "update_security_headers", "system", uuid.Nil, map[string]interface{}{
+		"before": before, "after": req,
+	})
+	respondJSON(w, req)
+}
@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// SEGMENTS — saved audience filters (plan, tenure, engagement, promo usage,
+// locale) that targeted sends resolve instead of messaging every user.
+// ============================================================================
+
+// segmentErrorStatus maps segment service errors to HTTP status codes.
+func segmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrSegmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrSegmentInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrSegmentNameTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// SegmentsPage renders /admin/marketing/segments.
+func (h *Handler) SegmentsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID:         claims.UserID,
+		Email:      claims.Email,
+		FirstName:  claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "segments.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Segments",
+		CurrentUser: currentUser,
+	})
+}
+
+// ListSegments handles GET /api/admin/marketing/segments
+func (h *Handler) ListSegments(w http.ResponseWriter, r *http.Request) {
+	if h.segmentService == nil {
+		http.Error(w, "Segment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := h.segmentService.ListSegments(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list segments: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"segments": list})
+}
+
+// GetSegmentOptions handles GET /api/admin/marketing/segments/options —
+// the plans and locales the builder offers.
+func (h *Handler) GetSegmentOptions(w http.ResponseWriter, r *http.Request) {
+	if h.segmentService == nil {
+		http.Error(w, "Segment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	opts, err := h.segmentService.Options(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load segment options: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, opts)
+}
+
+// PreviewSegment handles GET /api/admin/marketing/segments/preview
+// ?filters={...} (URL-encoded JSON), returning {"users": n} for the
+// builder's live count. It's a GET so read-only roles can explore too.
+func (h *Handler) PreviewSegment(w http.ResponseWriter, r *http.Request) {
+	if h.segmentService == nil {
+		http.Error(w, "Segment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var f repository.SegmentFilters
+	if raw := r.URL.Query().Get("filters"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &f); err != nil {
+			http.Error(w, "Invalid filters", http.StatusBadRequest)
+			return
+		}
+	}
+	n, err := h.segmentService.Preview(r.Context(), f)
+	if err != nil {
+		http.Error(w, err.Error(), segmentErrorStatus(err))
+		return
+	}
+	respondJSON(w, map[string]int{"users": n})
+}
+
+// CreateSegment handles POST /api/admin/marketing/segments
+func (h *Handler) CreateSegment(w http.ResponseWriter, r *http.Request) {
+	if h.segmentService == nil {
+		http.Error(w, "Segment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var in service.SegmentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var adminID uuid.UUID
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil {
+		adminID = claims.UserID
+	}
+	seg, err := h.segmentService.CreateSegment(r.Context(), in, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), segmentErrorStatus(err))
+		return
+	}
+	h.logAction(r, "create_segment", "user_segment", seg.ID, map[string]interface{}{"name": seg.Name, "filters": seg.Filters})
+	respondJSON(w, seg)
+}
+
+// GetSegment handles GET /api/admin/marketing/segments/{id}
+func (h *Handler) GetSegment(w http.ResponseWriter, r *http.Request) {
+	if h.segmentService == nil {
+		http.Error(w, "Segment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid segment ID", http.StatusBadRequest)
+		return
+	}
+	seg, err := h.segmentService.GetSegment(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), segmentErrorStatus(err))
+		return
+	}
+	respondJSON(w, seg)
+}
+
+// UpdateSegment handles PUT /api/admin/marketing/segments/{id}
+func (h *Handler) UpdateSegment(w http.ResponseWriter, r *http.Request) {
+	if h.segmentService == nil {
+		http.Error(w, "Segment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid segment ID", http.StatusBadRequest)
+		return
+	}
+	var in service.SegmentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	seg, err := h.segmentService.UpdateSegment(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), segmentErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_segment", "user_segment", id, map[string]interface{}{"name": seg.Name, "filters": seg.Filters})
+	respondJSON(w, seg)
+}
+
+// DeleteSegment handles DELETE /api/admin/marketing/segments/{id}
+func (h *Handler) DeleteSegment(w http.ResponseWriter, r *http.Request) {
+	if h.segmentService == nil {
+		http.Error(w, "Segment service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid segment ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.segmentService.DeleteSegment(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), segmentErrorStatus(err))
+		return
+	}
+	h.logAction(r, "delete_segment", "user_segment", id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// ADMIN SESSION POLICY — max duration, idle timeout, concurrent sessions and
+// step-up re-auth for the admin portal. AuthService enforces the limits;
+// RequireRecentAuth (routes.go) gates sensitive changes on the step-up
+// window, and AdminReauth below reopens it.
+// ============================================================================
+
+// GetSessionPolicy handles GET /super/session-policy.
+func (h *Handler) GetSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.sessionPolicyService == nil {
+		http.Error(w, "Session policy unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	policy, err := h.sessionPolicyService.Policy(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load session policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, policy)
+}
+
+// UpdateSessionPolicy handles PUT /super/session-policy with
+// {"max_session_minutes", "idle_timeout_minutes", "max_concurrent_sessions",
+// "step_up_minutes"}.
+func (h *Handler) UpdateSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.sessionPolicyService == nil {
+		http.Error(w, "Session policy unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var policy service.AdminSessionPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	if err := h.sessionPolicyService.UpdatePolicy(r.Context(), policy, claims.UserID); err != nil {
+		middleware.WriteError(w, err, "Failed to save session policy")
+		return
+	}
+	h.logAction(r, "update_session_policy", "settings", uuid.Nil, map[string]interface{}{"policy": policy})
+	respondJSON(w, policy)
+}
+
+// AdminReauth handles POST /auth/reauth with {"password"}: step-up auth
+// for the current session. A wrong password is 403, not 401, so
+// admin_session_guard.js doesn't take it for an expired session.
+func (h *Handler) AdminReauth(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		middleware.JSONError(w, "Password required", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	err := h.authService.Reauthenticate(r.Context(), claims, req.Password)
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials):
+		h.logAction(r, "reauthenticate_failed", "session", claims.Sid, nil)
+		middleware.JSONError(w, "Incorrect password", http.StatusForbidden)
+		return
+	case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrUserInactive):
+		middleware.JSONError(w, "Sign in again to continue", http.StatusForbidden)
+		return
+	case err != nil:
+		middleware.WriteError(w, err, "Failed to re-authenticate")
+		return
+	}
+	h.logAction(r, "reauthenticate", "session", claims.Sid, nil)
+	respondJSON(w, map[string]bool{"reauthenticated": true})
+}
@@ -86,6 +86,15 @@ func (h *Handler) GetInfrastructureStatus(w http.ResponseWriter, r *http.Request
 	// Generate alerts based on metrics
 	generateAlerts(status, errorCount, now)
 
+	// Budget alerts from the AWS cost panel
+	if h.costService != nil {
+		if costs, err := h.costService.Summary(dbCtx); err != nil {
+			log.Printf("Cost summary error: %v", err)
+		} else {
+			status.Alerts = append(status.Alerts, costs.Alerts...)
+		}
+	}
+
 	// Calculate overall health
 	status.OverallHealth, status.HealthSummary, status.AlertCount, status.WarningCount = calculateOverallHealth(status)
 
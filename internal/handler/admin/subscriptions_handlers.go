@@ -273,6 +273,13 @@ func (h *Handler) CancelFamilySubscription(w http.ResponseWriter, r *http.Reques
 	if req.Immediate {
 		mode = "immediate"
 	}
+	if h.exitPackageService != nil {
+		accessEnds := time.Now()
+		if !req.Immediate {
+			accessEnds = sub.CurrentPeriodEnd
+		}
+		h.exitPackageService.ScheduleForFamily(r.Context(), familyID, accessEnds)
+	}
 	h.logAction(r, "subscription.cancel", "family_subscription", sub.ID,
 		map[string]interface{}{
 			"family": sub.FamilyName,
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// TASK QUEUE — depth per task type and the dead letter list, with retry and
+// discard, shown as a panel on the infrastructure page.
+// ============================================================================
+
+// taskQueueErrorStatus maps task queue errors to HTTP status codes.
+func taskQueueErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTaskQueueUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrUnknownTaskType), errors.Is(err, service.ErrDeadTaskNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetTaskQueueStats handles GET /api/admin/super/tasks.
+func (h *Handler) GetTaskQueueStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.taskQueue.Stats(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load task queues: "+err.Error(), taskQueueErrorStatus(err))
+		return
+	}
+	respondJSON(w, map[string]interface{}{"queues": stats})
+}
+
+// ListDeadTasks handles GET /api/admin/super/tasks/{type}/dead.
+func (h *Handler) ListDeadTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.taskQueue.DeadTasks(r.Context(), chi.URLParam(r, "type"), getIntParam(r, "limit", 50))
+	if err != nil {
+		http.Error(w, "Failed to load failed tasks: "+err.Error(), taskQueueErrorStatus(err))
+		return
+	}
+	respondJSON(w, map[string]interface{}{"tasks": tasks})
+}
+
+// RetryDeadTask handles POST /api/admin/super/tasks/{type}/dead/{entryID}/retry.
+func (h *Handler) RetryDeadTask(w http.ResponseWriter, r *http.Request) {
+	t, err := h.taskQueue.RetryDead(r.Context(), chi.URLParam(r, "type"), chi.URLParam(r, "entryID"))
+	if err != nil {
+		http.Error(w, "Failed to retry task: "+err.Error(), taskQueueErrorStatus(err))
+		return
+	}
+	taskID, _ := uuid.Parse(t.ID)
+	h.logAction(r, "retry_task", "task", taskID, map[string]interface{}{
+		"type": t.Type, "last_error": t.LastError,
+	})
+	respondJSON(w, t)
+}
+
+// DiscardDeadTask handles DELETE /api/admin/super/tasks/{type}/dead/{entryID}.
+func (h *Handler) DiscardDeadTask(w http.ResponseWriter, r *http.Request) {
+	t, err := h.taskQueue.DiscardDead(r.Context(), chi.URLParam(r, "type"), chi.URLParam(r, "entryID"))
+	if err != nil {
+		http.Error(w, "Failed to discard task: "+err.Error(), taskQueueErrorStatus(err))
+		return
+	}
+	taskID, _ := uuid.Parse(t.ID)
+	h.logAction(r, "discard_task", "task", taskID, map[string]interface{}{
+		"type": t.Type, "last_error": t.LastError,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"carecompanion/internal/service"
+)
+
+// GetTaxReport handles GET /financials/tax?start_date=&end_date=&period=
+// &format=json|csv: tax collected by jurisdiction and period. Dates are
+// inclusive and default to the current year to date.
+func (h *Handler) GetTaxReport(w http.ResponseWriter, r *http.Request) {
+	if h.taxService == nil {
+		http.Error(w, "Tax reporting unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var err error
+	if s := r.URL.Query().Get("start_date"); s != "" {
+		if from, err = time.Parse("2006-01-02", s); err != nil {
+			http.Error(w, "Invalid start_date format", http.StatusBadRequest)
+			return
+		}
+	}
+	if s := r.URL.Query().Get("end_date"); s != "" {
+		if to, err = time.Parse("2006-01-02", s); err != nil {
+			http.Error(w, "Invalid end_date format", http.StatusBadRequest)
+			return
+		}
+	}
+	if to.Before(from) {
+		http.Error(w, "end_date is before start_date", http.StatusBadRequest)
+		return
+	}
+	report, err := h.taxService.Report(r.Context(), from, to, r.URL.Query().Get("period"))
+	if errors.Is(err, service.ErrTaxReportPeriod) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to build tax report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Query().Get("format") != "csv" {
+		respondJSON(w, report)
+		return
+	}
+
+	filename := fmt.Sprintf("carecompanion_tax_report_%s_to_%s.csv", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+	writer.Write([]string{"Period Start", "Country", "Region", "Jurisdiction", "Tax Type", "Currency", "Invoices", "Taxable Amount", "Tax Collected"})
+	for _, row := range report.Rows {
+		writer.Write([]string{
+			row.PeriodStart.Format("2006-01-02"),
+			row.Country,
+			row.Region,
+			row.Jurisdiction,
+			row.TaxType,
+			row.Currency,
+			strconv.Itoa(row.Invoices),
+			fmt.Sprintf("%.2f", float64(row.TaxableCents)/100),
+			fmt.Sprintf("%.2f", float64(row.TaxCents)/100),
+		})
+	}
+}
+
+// ListTaxRates handles GET /financials/tax/rates: the built-in rate table
+// used for price quotes and tax-inclusive display.
+func (h *Handler) ListTaxRates(w http.ResponseWriter, r *http.Request) {
+	if h.taxService == nil {
+		http.Error(w, "Tax reporting unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	rates, err := h.taxService.Rates(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load tax rates: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"rates": rates})
+}
@@ -0,0 +1,314 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// testimonialErrorStatus maps testimonial service errors to HTTP status codes.
+func testimonialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTestimonialNotFound), errors.Is(err, service.ErrImageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTestimonialInvalid), errors.Is(err, service.ErrImageInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUploadQuarantined):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrScanUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrTestimonialTransition), errors.Is(err, service.ErrTestimonialNoConsent):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListTestimonials handles GET /api/admin/marketing/testimonials
+// ?status=&consent=&kind=&tag=&featured=1&q=&page=&limit=
+func (h *Handler) ListTestimonials(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	f := repository.TestimonialFilter{
+		Status:        q.Get("status"),
+		ConsentStatus: q.Get("consent"),
+		Kind:          q.Get("kind"),
+		Tag:           q.Get("tag"),
+		FeaturedOnly:  q.Get("featured") == "1" || q.Get("featured") == "true",
+		Query:         q.Get("q"),
+	}
+	page := getIntParam(r, "page", 1)
+	limit := getIntParam(r, "limit", 25)
+	items, total, err := h.testimonialService.ListTestimonials(r.Context(), f, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to list testimonials: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"testimonials": items,
+		"total":        total,
+		"page":         page,
+		"limit":        limit,
+	})
+}
+
+// CreateTestimonial handles POST /api/admin/marketing/testimonials
+func (h *Handler) CreateTestimonial(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var in service.TestimonialInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var adminID uuid.UUID
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil {
+		adminID = claims.UserID
+	}
+	t, err := h.testimonialService.CreateTestimonial(r.Context(), in, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	h.logAction(r, "create_testimonial", "testimonial", t.ID, map[string]interface{}{"kind": t.Kind})
+	respondJSON(w, t)
+}
+
+// GetTestimonial handles GET /api/admin/marketing/testimonials/{id}
+func (h *Handler) GetTestimonial(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	t, err := h.testimonialService.GetTestimonial(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	respondJSON(w, t)
+}
+
+// UpdateTestimonial handles PUT /api/admin/marketing/testimonials/{id}
+func (h *Handler) UpdateTestimonial(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	var in service.TestimonialInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	t, err := h.testimonialService.UpdateTestimonial(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_testimonial", "testimonial", id, map[string]interface{}{"status": t.Status})
+	respondJSON(w, t)
+}
+
+// DeleteTestimonial handles DELETE /api/admin/marketing/testimonials/{id}
+func (h *Handler) DeleteTestimonial(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.testimonialService.DeleteTestimonial(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	h.logAction(r, "delete_testimonial", "testimonial", id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RecordTestimonialConsent handles POST /api/admin/marketing/testimonials/{id}/consent
+// {"status":"granted","method":"signed_release","channels":["web","print"],"expires_on":"","note":""}
+func (h *Handler) RecordTestimonialConsent(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	var in service.ConsentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	t, err := h.testimonialService.RecordConsent(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	h.logAction(r, "record_testimonial_consent", "testimonial", id, map[string]interface{}{
+		"consent_status": t.ConsentStatus,
+		"method":         t.ConsentMethod,
+		"channels":       t.ConsentChannels,
+	})
+	respondJSON(w, t)
+}
+
+// SetTestimonialStatus handles POST /api/admin/marketing/testimonials/{id}/status
+// {"status":"approved","note":""}
+func (h *Handler) SetTestimonialStatus(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	var in service.ReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var adminID uuid.UUID
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil {
+		adminID = claims.UserID
+	}
+	t, err := h.testimonialService.SetStatus(r.Context(), id, in, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	h.logAction(r, "set_testimonial_status", "testimonial", id, map[string]interface{}{"status": t.Status})
+	respondJSON(w, t)
+}
+
+// SetTestimonialFeatured handles POST /api/admin/marketing/testimonials/{id}/feature
+// {"featured":true,"rank":1}
+func (h *Handler) SetTestimonialFeatured(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	var in service.FeatureInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	t, err := h.testimonialService.SetFeatured(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	h.logAction(r, "feature_testimonial", "testimonial", id, map[string]interface{}{"featured": t.IsFeatured, "rank": t.FeaturedRank})
+	respondJSON(w, t)
+}
+
+// GetTestimonialHeadshot handles GET /api/admin/marketing/testimonials/{id}/headshot
+func (h *Handler) GetTestimonialHeadshot(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	set, err := h.testimonialService.Headshot(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	respondJSON(w, set)
+}
+
+// UploadTestimonialHeadshot handles POST /api/admin/marketing/testimonials/{id}/headshot
+// (multipart: file). Replaces any previous headshot.
+func (h *Handler) UploadTestimonialHeadshot(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid testimonial ID", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseMultipartForm(16 << 20); err != nil {
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Headshot file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var adminID uuid.UUID
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil {
+		adminID = claims.UserID
+	}
+	set, err := h.testimonialService.UploadHeadshot(r.Context(), id, adminID, header.Filename, file)
+	if err != nil {
+		http.Error(w, err.Error(), testimonialErrorStatus(err))
+		return
+	}
+	h.logAction(r, "upload_testimonial_headshot", "testimonial", id, map[string]interface{}{
+		"width": set.SourceWidth, "height": set.SourceHeight, "variants": len(set.Variants),
+	})
+	respondJSON(w, set)
+}
+
+// PublicTestimonials handles GET /public/testimonials?tag=&featured=1&limit=
+// — unauthenticated, for the marketing website. Only approved entries with
+// web consent are returned.
+func (h *Handler) PublicTestimonials(w http.ResponseWriter, r *http.Request) {
+	if h.testimonialService == nil {
+		http.Error(w, "Testimonial service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	featured := q.Get("featured") == "1" || q.Get("featured") == "true"
+	items, err := h.testimonialService.ListPublic(r.Context(), q.Get("tag"), featured, limit)
+	if err != nil {
+		http.Error(w, "Failed to load testimonials", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, map[string]interface{}{"testimonials": items})
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// TICKET MERGE — fold a user's tickets about the same issue into one thread.
+// The merged ticket closes with a redirect to the one it went into; see
+// service.TicketMergeService.
+// ============================================================================
+
+// MergeTicket handles POST /api/admin/support/tickets/{id}/merge with
+// {"target_ticket_id"}: ticket {id} is merged into the target.
+func (h *Handler) MergeTicket(w http.ResponseWriter, r *http.Request) {
+	if h.mergeService == nil {
+		http.Error(w, "Ticket merge unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket id", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		TargetTicketID string `json:"target_ticket_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	targetID, err := uuid.Parse(body.TargetTicketID)
+	if err != nil {
+		http.Error(w, "Invalid target_ticket_id", http.StatusBadRequest)
+		return
+	}
+
+	claims := middleware.GetAuthClaims(r.Context())
+	merge, err := h.mergeService.Merge(r.Context(), id, targetID, claims.UserID, claims.Email)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to merge ticket")
+		return
+	}
+	h.logAction(r, "merge_ticket", "ticket", id, map[string]interface{}{
+		"target_ticket_id":  targetID,
+		"messages_moved":    merge.MessagesMoved,
+		"attachments_moved": merge.AttachmentsMoved,
+	})
+	respondJSON(w, merge)
+}
+
+// ListTicketMerges handles GET /api/admin/support/tickets/{id}/merges:
+// the tickets merged into this one.
+func (h *Handler) ListTicketMerges(w http.ResponseWriter, r *http.Request) {
+	if h.mergeService == nil {
+		http.Error(w, "Ticket merge unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket id", http.StatusBadRequest)
+		return
+	}
+	merges, err := h.mergeService.ListMerges(r.Context(), id)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to list merges")
+		return
+	}
+	respondJSON(w, merges)
+}
+
+// SuggestTicketDuplicates handles GET
+// /api/admin/support/tickets/{id}/duplicate-suggestions?days=14: the same
+// user's tickets from around the same time with a similar subject.
+func (h *Handler) SuggestTicketDuplicates(w http.ResponseWriter, r *http.Request) {
+	if h.mergeService == nil {
+		http.Error(w, "Ticket merge unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket id", http.StatusBadRequest)
+		return
+	}
+	window := service.DefaultDuplicateWindow
+	if d := r.URL.Query().Get("days"); d != "" {
+		if n, err := strconv.Atoi(d); err == nil && n > 0 && n <= 90 {
+			window = time.Duration(n) * 24 * time.Hour
+		}
+	}
+	suggestions, err := h.mergeService.SuggestDuplicates(r.Context(), id, window)
+	if err != nil {
+		middleware.WriteError(w, err, "Failed to find similar tickets")
+		return
+	}
+	respondJSON(w, suggestions)
+}
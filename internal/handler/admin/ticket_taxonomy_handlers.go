@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ticketFilterFromQuery reads the shared ticket list filters
+// (?status=&type=&tag=&category=) used by both the JSON list and the page.
+// An unparseable category id is ignored rather than rejected so a stale
+// bookmarked URL still renders the unfiltered list.
+func ticketFilterFromQuery(r *http.Request) repository.TicketFilter {
+	q := r.URL.Query()
+	f := repository.TicketFilter{
+		Status: q.Get("status"),
+		Type:   q.Get("type"),
+		Tag:    strings.ToLower(strings.TrimSpace(q.Get("tag"))),
+	}
+	if c := q.Get("category"); c != "" {
+		if id, err := uuid.Parse(c); err == nil {
+			f.CategoryID = id
+		}
+	}
+	return f
+}
+
+// taxonomyErrorStatus maps service validation errors to 400/404; anything
+// else is a genuine 500.
+func taxonomyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTicketNotFound), errors.Is(err, service.ErrTicketCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTicketTagInvalid), errors.Is(err, service.ErrTicketTooManyTags),
+		errors.Is(err, service.ErrTicketCategoryInvalid), errors.Is(err, service.ErrTicketReportRange):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ============================================================================
+// CATEGORIES
+// ============================================================================
+
+func (h *Handler) ListTicketCategories(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	cats, err := h.taxonomyService.ListCategories(r.Context(), r.URL.Query().Get("include_inactive") == "true")
+	if err != nil {
+		http.Error(w, "Failed to list categories: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, cats)
+}
+
+func (h *Handler) CreateTicketCategory(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var in service.TicketCategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	cat, err := h.taxonomyService.CreateCategory(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), taxonomyErrorStatus(err))
+		return
+	}
+	h.logAction(r, "create_ticket_category", "ticket_category", cat.ID, map[string]interface{}{"slug": cat.Slug})
+	respondJSON(w, cat)
+}
+
+func (h *Handler) UpdateTicketCategory(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+	var in service.TicketCategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	cat, err := h.taxonomyService.UpdateCategory(r.Context(), id, in)
+	if err != nil {
+		http.Error(w, err.Error(), taxonomyErrorStatus(err))
+		return
+	}
+	h.logAction(r, "update_ticket_category", "ticket_category", id, nil)
+	respondJSON(w, cat)
+}
+
+func (h *Handler) DeleteTicketCategory(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.taxonomyService.DeleteCategory(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete category: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "delete_ticket_category", "ticket_category", id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ============================================================================
+// PER-TICKET TAGS / CATEGORY
+// ============================================================================
+
+type setTicketCategoryRequest struct {
+	// Empty string clears the category.
+	CategoryID string `json:"category_id"`
+}
+
+func (h *Handler) SetTicketCategory(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	ticketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket ID", http.StatusBadRequest)
+		return
+	}
+	var req setTicketCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	categoryID := uuid.Nil
+	if req.CategoryID != "" {
+		if categoryID, err = uuid.Parse(req.CategoryID); err != nil {
+			http.Error(w, "Invalid category ID", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := h.taxonomyService.SetTicketCategory(ctx, ticketID, categoryID); err != nil {
+		http.Error(w, err.Error(), taxonomyErrorStatus(err))
+		return
+	}
+	h.logAction(r, "set_ticket_category", "ticket", ticketID, map[string]interface{}{"category_id": req.CategoryID})
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"success": true}`))
+}
+
+type setTicketTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (h *Handler) SetTicketTags(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	ticketID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket ID", http.StatusBadRequest)
+		return
+	}
+	var req setTicketTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(ctx)
+	tags, err := h.taxonomyService.SetTicketTags(ctx, ticketID, req.Tags, claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), taxonomyErrorStatus(err))
+		return
+	}
+	h.logAction(r, "set_ticket_tags", "ticket", ticketID, map[string]interface{}{"tags": tags})
+	respondJSON(w, map[string]interface{}{"tags": tags})
+}
+
+func (h *Handler) ListTicketTags(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	tags, err := h.taxonomyService.ListTags(r.Context(), r.URL.Query().Get("q"), getIntParam(r, "limit", 25))
+	if err != nil {
+		http.Error(w, "Failed to list tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, tags)
+}
+
+// ============================================================================
+// REPORTING
+// ============================================================================
+
+// GetTicketReport handles GET /api/admin/support/tickets/report
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD&interval=day|week|month. Defaults to the
+// last 90 days bucketed by week. `to` is inclusive of the whole day.
+func (h *Handler) GetTicketReport(w http.ResponseWriter, r *http.Request) {
+	if h.taxonomyService == nil {
+		http.Error(w, "Ticket taxonomy service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	from, to, err := reportWindowFromQuery(q, 90)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.taxonomyService.SupportLoadReport(r.Context(), from, to, q.Get("interval"))
+	if err != nil {
+		http.Error(w, err.Error(), taxonomyErrorStatus(err))
+		return
+	}
+	respondJSON(w, report)
+}
+
+// reportWindowFromQuery parses ?from=YYYY-MM-DD&to=YYYY-MM-DD into a
+// half-open [from, to) UTC window. 'to' is inclusive on the wire, so one
+// day is added. Missing bounds default to the last defaultDays days.
+func reportWindowFromQuery(q url.Values, defaultDays int) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	to := now.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -defaultDays)
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid 'from' date (want YYYY-MM-DD)")
+		}
+		from = t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid 'to' date (want YYYY-MM-DD)")
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
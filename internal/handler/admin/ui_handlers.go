@@ -36,6 +36,16 @@ type AdminUser struct {
 
 // templateFuncs provides custom functions for admin templates
 var templateFuncs = template.FuncMap{
+	// cspNonce marks an inline <script> as allowed by the CSP; the security
+	// headers middleware swaps the placeholder for the request's nonce.
+	"cspNonce": func() string { return middleware.CSPNoncePlaceholder },
+	// csrfToken and csrfField carry the CSRF token (see middleware.CSRF):
+	// csrfField goes inside every form that POSTs, csrfToken into the
+	// csrf-token meta tag that static/js/csrf.js reads.
+	"csrfToken": func() string { return middleware.CSRFTokenPlaceholder },
+	"csrfField": func() template.HTML {
+		return template.HTML(`<input type="hidden" name="` + middleware.CSRFFieldName + `" value="` + middleware.CSRFTokenPlaceholder + `">`)
+	},
 	// canSee reports whether a role can see a sidebar section. Reads the
 	// permission matrix in internal/auth/perm.go.
 	"canSee": func(role string, section string) bool {
@@ -156,7 +166,12 @@ func (h *Handler) AdminLoginPage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	tmpl.Execute(w, AdminPageData{Title: "Admin Login"})
+	data := AdminPageData{Title: "Admin Login"}
+	if r.URL.Query().Get("invited") == "1" {
+		// Back from an accepted invitation link.
+		data.Data = "Your password is set. Sign in to continue."
+	}
+	tmpl.Execute(w, data)
 }
 
 // AdminLoginSubmit handles admin login form submission
@@ -212,9 +227,13 @@ func (h *Handler) AdminLoginSubmit(w http.ResponseWriter, r *http.Request) {
 
 // AdminLogout revokes the admin session row and clears the admin cookie.
 // POSTed from the layout's logout button. Redirects to /admin/login.
+// Only this session ends: the session policy may allow others elsewhere.
 func (h *Handler) AdminLogout(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.GetUserID(r.Context())
-	_ = h.authService.LogoutAdmin(r.Context(), userID)
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil && claims.Sid != uuid.Nil {
+		_ = h.authService.RevokeSession(r.Context(), claims.Sid)
+	} else {
+		_ = h.authService.LogoutAdmin(r.Context(), middleware.GetUserID(r.Context()))
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:     "admin_access_token",
 		Value:    "",
@@ -371,9 +390,7 @@ func (h *Handler) TicketsPage(w http.ResponseWriter, r *http.Request) {
 		SystemRole: string(claims.SystemRole),
 	}
 
-	status := r.URL.Query().Get("status")
-	ticketType := r.URL.Query().Get("type")
-	tickets, total, err := h.adminRepo.GetTickets(r.Context(), status, ticketType, 1, 50)
+	tickets, total, err := h.adminRepo.GetTickets(r.Context(), ticketFilterFromQuery(r), 1, 50)
 	if err != nil {
 		// Don't render an empty list silently — that masked a permission-grant
 		// gap on the cross-env support DB role for ~30 minutes after the
@@ -449,6 +466,11 @@ func (h *Handler) TicketDetailPage(w http.ResponseWriter, r *http.Request) {
 			dupCanonicalRoadmap, _ = h.roadmapService.Get(r.Context(), ticket.DuplicateOfRoadmapID.UUID)
 		}
 	}
+	// A merged ticket's thread lives on its target; link there.
+	var mergedInto interface{}
+	if ticket.MergedIntoTicketID.Valid {
+		mergedInto, _ = h.adminRepo.GetTicketByID(r.Context(), ticket.MergedIntoTicketID.UUID)
+	}
 
 	tmpl, err := parseTemplates("layout.html", "ticket_detail.html")
 	if err != nil {
@@ -466,6 +488,7 @@ func (h *Handler) TicketDetailPage(w http.ResponseWriter, r *http.Request) {
 			"is_super":          currentUser.SystemRole == "super_admin",
 			"dup_canonical_t":   dupCanonicalTicket,
 			"dup_canonical_r":   dupCanonicalRoadmap,
+			"merged_into_t":     mergedInto,
 		},
 	})
 }
@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// UPLOAD SCANS — antivirus verdicts for every upload path and the review
+// queue for quarantined (infected) files.
+// ============================================================================
+
+// uploadScanErrorStatus maps upload scan errors to HTTP status codes.
+func uploadScanErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUploadScanNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUploadScanReviewed):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUploadInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListUploadScans handles GET /api/admin/upload-scans. ?view=queue (the
+// default) lists quarantined files awaiting review; ?view=all lists recent
+// verdicts, optionally filtered by ?status= and ?source=.
+func (h *Handler) ListUploadScans(w http.ResponseWriter, r *http.Request) {
+	if h.uploadScanService == nil {
+		http.Error(w, "Upload scanning unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	f := repository.UploadScanFilter{
+		Queue:  q.Get("view") != "all",
+		Status: q.Get("status"),
+		Source: q.Get("source"),
+	}
+	if v := q.Get("limit"); v != "" {
+		f.Limit, _ = strconv.Atoi(v)
+	}
+	scans, err := h.uploadScanService.List(r.Context(), f)
+	if err != nil {
+		http.Error(w, "Failed to list scans: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if scans == nil {
+		scans = []repository.UploadScan{}
+	}
+	summary, err := h.uploadScanService.Summary(r.Context(), 7*24*time.Hour)
+	if err != nil {
+		log.Printf("[SCAN] summary: %v", err)
+	}
+	respondJSON(w, map[string]interface{}{
+		"scans":   scans,
+		"summary": summary,
+		"enabled": h.uploadScanService.Enabled(),
+	})
+}
+
+// UploadScannerHealth handles GET /api/admin/upload-scans/health — whether
+// clamd answers, and with which signature version.
+func (h *Handler) UploadScannerHealth(w http.ResponseWriter, r *http.Request) {
+	if h.uploadScanService == nil {
+		http.Error(w, "Upload scanning unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	out := map[string]interface{}{"enabled": h.uploadScanService.Enabled()}
+	if version, err := h.uploadScanService.ScannerVersion(r.Context()); err != nil {
+		out["error"] = err.Error()
+	} else {
+		out["version"] = version
+	}
+	respondJSON(w, out)
+}
+
+// DownloadQuarantinedUpload handles GET /api/admin/upload-scans/{id}/file.
+// The file is served as opaque bytes with a renamed extension so it can't
+// be opened by accident.
+func (h *Handler) DownloadQuarantinedUpload(w http.ResponseWriter, r *http.Request) {
+	if h.uploadScanService == nil {
+		http.Error(w, "Upload scanning unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid scan ID", http.StatusBadRequest)
+		return
+	}
+	body, rec, err := h.uploadScanService.OpenQuarantined(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), uploadScanErrorStatus(err))
+		return
+	}
+	defer body.Close()
+
+	h.logAction(r, "download_quarantined_upload", "upload_scan", id, map[string]interface{}{
+		"filename": rec.Filename, "signature": rec.Signature,
+	})
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+id.String()+`.quarantined"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("[SCAN] quarantine download %s: %v", id, err)
+	}
+}
+
+// ReviewUploadScan handles POST /api/admin/upload-scans/{id}/review with
+// {"outcome": "released"|"deleted", "note": "..."}. Released marks a false
+// positive so the uploader can send the same file again; either outcome
+// purges the quarantined copy.
+func (h *Handler) ReviewUploadScan(w http.ResponseWriter, r *http.Request) {
+	if h.uploadScanService == nil {
+		http.Error(w, "Upload scanning unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid scan ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Outcome string `json:"outcome"`
+		Note    string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	rec, err := h.uploadScanService.Review(r.Context(), id, req.Outcome, claims.UserID, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), uploadScanErrorStatus(err))
+		return
+	}
+	h.logAction(r, "review_quarantined_upload", "upload_scan", id, map[string]interface{}{
+		"outcome": req.Outcome, "filename": rec.Filename, "source": rec.Source,
+		"signature": rec.Signature, "sha256": rec.SHA256,
+	})
+	respondJSON(w, rec)
+}
+
+// UploadScansPage renders the quarantine review queue.
+func (h *Handler) UploadScansPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID: claims.UserID, Email: claims.Email, FirstName: claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "upload_scans.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Upload Scans",
+		CurrentUser: currentUser,
+	})
+}
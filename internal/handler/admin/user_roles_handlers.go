@@ -31,12 +31,14 @@ func builtinRoleViews() []builtinRoleView {
 		models.SystemRoleSupport,
 		models.SystemRoleMarketing,
 		models.SystemRolePartner,
+		models.SystemRoleAuditor,
 	}
 	labels := map[models.SystemRole]struct{ display, desc string }{
 		models.SystemRoleSuperAdmin: {"Super Admin", "Full access to every admin section. Cannot be edited or removed."},
 		models.SystemRoleSupport:    {"Support", "Customer-support staff — tickets, users, families, live sessions."},
 		models.SystemRoleMarketing:  {"Marketing", "Marketing materials, beta program, bounty program, metrics."},
 		models.SystemRolePartner:    {"Partner", "Broad read access plus full access to ops + roadmap surfaces."},
+		models.SystemRoleAuditor:    {"Auditor", "Compliance review — reads and exports the audit log, settings history and PHI access notifications. Can't change anything."},
 	}
 	out := make([]builtinRoleView, 0, len(all))
 	for _, r := range all {
@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ============================================================================
+// WAREHOUSE EXPORTS — history of the nightly de-identified Parquet export
+// for research/marketing, and re-running a day.
+// ============================================================================
+
+// WarehouseExportsPage renders /admin/warehouse-exports.
+func (h *Handler) WarehouseExportsPage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetAuthClaims(r.Context())
+	currentUser := AdminUser{
+		ID:         claims.UserID,
+		Email:      claims.Email,
+		FirstName:  claims.FirstName,
+		SystemRole: string(claims.SystemRole),
+	}
+
+	tmpl, err := parseTemplates("layout.html", "warehouse_exports.html")
+	if err != nil {
+		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl.ExecuteTemplate(w, "layout.html", AdminPageData{
+		Title:       "Warehouse Exports",
+		CurrentUser: currentUser,
+	})
+}
+
+// ListWarehouseExports handles GET /api/admin/warehouse-exports.
+func (h *Handler) ListWarehouseExports(w http.ResponseWriter, r *http.Request) {
+	if h.warehouseService == nil {
+		http.Error(w, "Warehouse export unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	exports, err := h.warehouseService.List(r.Context(), getIntParam(r, "limit", 90))
+	if err != nil {
+		http.Error(w, "Failed to list exports: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exports == nil {
+		exports = []repository.WarehouseExport{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"enabled":       h.warehouseService.Enabled(),
+		"min_cell_size": h.warehouseService.MinCellSize(),
+		"datasets":      service.WarehouseDatasets,
+		"exports":       exports,
+	})
+}
+
+// RunWarehouseExport handles POST /api/admin/warehouse-exports/run
+// with {"date": "2026-03-01"} — export (or re-export) one completed day.
+func (h *Handler) RunWarehouseExport(w http.ResponseWriter, r *http.Request) {
+	if h.warehouseService == nil {
+		http.Error(w, "Warehouse export unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Date string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	day, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	claims := middleware.GetAuthClaims(r.Context())
+	exports, err := h.warehouseService.Run(r.Context(), day, claims.UserID)
+	switch {
+	case errors.Is(err, service.ErrWarehouseExportDisabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrWarehouseDayIncomplete):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case len(exports) == 0:
+		http.Error(w, "Export failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, "run_warehouse_export", "warehouse_export", exports[0].ID, map[string]interface{}{
+		"date": req.Date,
+	})
+	resp := map[string]interface{}{"exports": exports}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	respondJSON(w, resp)
+}
+
+// RebuildWithdrawnWarehouseExports handles
+// POST /api/admin/warehouse-exports/rebuild-withdrawn — rewrite the files
+// research withdrawals affect now instead of waiting for the nightly run.
+func (h *Handler) RebuildWithdrawnWarehouseExports(w http.ResponseWriter, r *http.Request) {
+	if h.warehouseService == nil {
+		http.Error(w, "Warehouse export unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	n, err := h.warehouseService.RebuildWithdrawn(r.Context())
+	if errors.Is(err, service.ErrWarehouseExportDisabled) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	h.logAction(r, "rebuild_warehouse_withdrawn", "warehouse_export", uuid.Nil, map[string]interface{}{
+		"files": n,
+	})
+	resp := map[string]interface{}{"rebuilt": n}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	respondJSON(w, resp)
+}
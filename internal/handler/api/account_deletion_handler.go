@@ -28,12 +28,12 @@ func (h *AccountDeletionHandler) GetStatus(w http.ResponseWriter, r *http.Reques
 	userID := middleware.GetUserID(r.Context())
 	roles, err := h.repo.GetFamilyRolesForUser(r.Context(), userID)
 	if err != nil {
-		respondInternalError(w, "Failed to load account status")
+		respondServiceError(w, err, "Failed to load account status")
 		return
 	}
 	req, err := h.repo.GetActiveByUser(r.Context(), userID)
 	if err != nil {
-		respondInternalError(w, "Failed to load account status")
+		respondServiceError(w, err, "Failed to load account status")
 		return
 	}
 
@@ -47,7 +47,7 @@ func (h *AlertHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	alerts, err := h.alertService.GetByChildID(r.Context(), childID, status)
 	if err != nil {
-		respondInternalError(w, "Failed to get alerts")
+		respondServiceError(w, err, "Failed to get alerts")
 		return
 	}
 
@@ -193,7 +193,7 @@ func (h *AlertHandler) CreateFeedback(w http.ResponseWriter, r *http.Request) {
 
 	feedback, err := h.alertService.CreateFeedback(r.Context(), alertID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create feedback")
+		respondServiceError(w, err, "Failed to create feedback")
 		return
 	}
 
@@ -228,7 +228,7 @@ func (h *AlertHandler) GetFeedback(w http.ResponseWriter, r *http.Request) {
 
 	feedback, err := h.alertService.GetFeedback(r.Context(), alertID)
 	if err != nil {
-		respondInternalError(w, "Failed to get feedback")
+		respondServiceError(w, err, "Failed to get feedback")
 		return
 	}
 
@@ -251,7 +251,7 @@ func (h *AlertHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := h.alertService.GetStats(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to get stats")
+		respondServiceError(w, err, "Failed to get stats")
 		return
 	}
 
@@ -277,14 +277,14 @@ func (h *AlertHandler) DashboardInsights(w http.ResponseWriter, r *http.Request)
 	activeStatus := models.AlertStatusActive
 	activeAlerts, err := h.alertService.GetByChildID(r.Context(), childID, &activeStatus)
 	if err != nil {
-		respondInternalError(w, "Failed to get insights")
+		respondServiceError(w, err, "Failed to get insights")
 		return
 	}
 
 	// Also include recently created info alerts (positive feedback)
 	infoAlerts, err := h.alertService.GetByChildID(r.Context(), childID, nil)
 	if err != nil {
-		respondInternalError(w, "Failed to get insights")
+		respondServiceError(w, err, "Failed to get insights")
 		return
 	}
 
@@ -328,7 +328,7 @@ func (h *AlertHandler) GetAlertsPage(w http.ResponseWriter, r *http.Request) {
 
 	page, err := h.alertService.GetAlertsPage(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to get alerts page")
+		respondServiceError(w, err, "Failed to get alerts page")
 		return
 	}
 
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// annotationDefaultDays is the listing window when no dates are given.
+const annotationDefaultDays = 90
+
+// AnnotationHandler serves a child's chart annotations under
+// /api/children/{childID}/annotations.
+type AnnotationHandler struct {
+	annotations  *service.AnnotationService
+	childService *service.ChildService
+	userService  *service.UserService
+}
+
+func NewAnnotationHandler(annotations *service.AnnotationService, childService *service.ChildService, userService *service.UserService) *AnnotationHandler {
+	return &AnnotationHandler{annotations: annotations, childService: childService, userService: userService}
+}
+
+func (h *AnnotationHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+func (h *AnnotationHandler) annotation(w http.ResponseWriter, r *http.Request, child *models.Child) (*models.Annotation, bool) {
+	id, err := parseUUID(chi.URLParam(r, "annotationID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid annotation ID")
+		return nil, false
+	}
+	a, err := h.annotations.Get(r.Context(), child.ID, id)
+	if errors.Is(err, service.ErrAnnotationNotFound) {
+		respondNotFound(w, "Annotation not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load annotation")
+		return nil, false
+	}
+	return a, true
+}
+
+// List handles GET /api/children/{childID}/annotations?start_date=
+// &end_date=, defaulting to the last annotationDefaultDays days.
+func (h *AnnotationHandler) List(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now().In(getUserTimezone(r.Context(), h.userService, middleware.GetUserID(r.Context())))
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -annotationDefaultDays)
+	for param, dst := range map[string]*time.Time{"start_date": &start, "end_date": &end} {
+		if v := r.URL.Query().Get(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				respondBadRequest(w, "Invalid date format, use YYYY-MM-DD")
+				return
+			}
+			*dst = t
+		}
+	}
+	if end.Before(start) {
+		respondBadRequest(w, "end_date must be after start_date")
+		return
+	}
+	list, err := h.annotations.ListForRange(r.Context(), child.ID, start, end)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load annotations")
+		return
+	}
+	respondOK(w, map[string]interface{}{"annotations": list, "categories": models.AnnotationCategories})
+}
+
+// Create handles POST /api/children/{childID}/annotations.
+func (h *AnnotationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.AnnotationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	a, err := h.annotations.Create(r.Context(), child.ID, middleware.GetUserID(r.Context()), &req)
+	if errors.Is(err, service.ErrAnnotationInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to create annotation")
+		return
+	}
+	respondCreated(w, a)
+}
+
+// Get handles GET /api/children/{childID}/annotations/{annotationID}.
+func (h *AnnotationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	if a, ok := h.annotation(w, r, child); ok {
+		respondOK(w, a)
+	}
+}
+
+// Update handles PUT /api/children/{childID}/annotations/{annotationID}.
+func (h *AnnotationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	a, ok := h.annotation(w, r, child)
+	if !ok {
+		return
+	}
+	var req models.AnnotationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	err := h.annotations.Update(r.Context(), a, &req)
+	if errors.Is(err, service.ErrAnnotationInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to update annotation")
+		return
+	}
+	respondOK(w, a)
+}
+
+// Delete handles DELETE /api/children/{childID}/annotations/{annotationID}.
+func (h *AnnotationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	a, ok := h.annotation(w, r, child)
+	if !ok {
+		return
+	}
+	if err := h.annotations.Delete(r.Context(), a.ID); err != nil {
+		respondInternalError(w, "Failed to delete annotation")
+		return
+	}
+	respondOK(w, map[string]string{"message": "Annotation deleted"})
+}
@@ -16,13 +16,15 @@ import (
 type AuthHandler struct {
 	authService *service.AuthService
 	adminRepo   repository.AdminRepository
+	legal       *service.LegalService
 	appEnv      string
 }
 
-func NewAuthHandler(authService *service.AuthService, adminRepo repository.AdminRepository, appEnv string) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, adminRepo repository.AdminRepository, legal *service.LegalService, appEnv string) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
 		adminRepo:   adminRepo,
+		legal:       legal,
 		appEnv:      appEnv,
 	}
 }
@@ -96,6 +98,14 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Signing up is agreeing to the terms in effect; record it like any
+	// other acceptance. A failure here only means the user is asked again.
+	if h.legal != nil {
+		if err := h.legal.AcceptCurrent(r.Context(), user.ID, clientIP(r), r.UserAgent()); err != nil {
+			log.Printf("Registration: record legal acceptance for %s: %v", user.ID, err)
+		}
+	}
+
 	// Set cookies for web clients
 	h.setUserAuthCookies(w, r, tokens)
 
@@ -268,6 +278,7 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 		"status":           ent.Status,
 		"is_admin":         ent.IsAdminOverride,
 		"has_subscription": ent.HasSubscription,
+		"via_organization": ent.ViaOrganization,
 	}
 	if ent.TrialEnd != nil {
 		entJSON["trial_end"] = ent.TrialEnd.Format("2006-01-02T15:04:05Z07:00")
@@ -278,6 +289,9 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	if ent.ReadOnlyUntil != nil {
 		entJSON["read_only_until"] = ent.ReadOnlyUntil.Format("2006-01-02T15:04:05Z07:00")
 	}
+	if msg := middleware.EntitlementMessage(ent); msg != "" {
+		entJSON["message"] = msg
+	}
 	response["entitlement"] = entJSON
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// MaxBatchRequests caps the GETs in one POST /api/batch.
+	MaxBatchRequests = 20
+	// batchTimeout bounds the whole batch; a slow or streaming endpoint
+	// shouldn't hold the others' results hostage.
+	batchTimeout = 15 * time.Second
+)
+
+// batchForwardHeaders are the caller's headers each embedded request
+// carries: its auth (bearer or cookies) and what shapes the response.
+var batchForwardHeaders = []string{"Authorization", "Cookie", "Accept-Language", "User-Agent", "X-Timezone", "X-Forwarded-For", "X-Forwarded-Proto"}
+
+// BatchHandler serves POST /api/batch: several GETs against the API in one
+// round trip, for the mobile dashboard's cold start. Each embedded request
+// is dispatched through the API router, so it goes through the same auth,
+// entitlement and access checks as if it had been sent on its own.
+type BatchHandler struct {
+	mu     sync.RWMutex
+	router http.Handler
+}
+
+// NewBatchHandler creates a new batch handler. The router is set by
+// SetupRoutes once the API routes exist.
+func NewBatchHandler() *BatchHandler {
+	return &BatchHandler{}
+}
+
+// SetRouter sets the /api router embedded requests are dispatched to.
+// Paths are relative to it, without the /api prefix.
+func (h *BatchHandler) SetRouter(router http.Handler) {
+	h.mu.Lock()
+	h.router = router
+	h.mu.Unlock()
+}
+
+// BatchRequest is one embedded GET. Headers may only carry the
+// conditional-request headers, so a batched call can still get a 304.
+type BatchRequest struct {
+	ID      string            `json:"id"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// BatchResponse is one embedded response. Body is the JSON the endpoint
+// wrote, or a string for non-JSON bodies.
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+var batchAllowedRequestHeaders = map[string]bool{
+	"If-None-Match":     true,
+	"If-Modified-Since": true,
+}
+
+var batchReturnedHeaders = []string{"ETag", "Last-Modified", "Cache-Control", "Retry-After"}
+
+// Handle handles POST /api/batch with {"requests": [{"id", "path"}]}, and
+// answers {"responses": {"<id>": {"status", "headers", "body"}}}. The
+// embedded requests run concurrently; a failing one doesn't fail the batch.
+func (h *BatchHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	router := h.router
+	h.mu.RUnlock()
+	if router == nil {
+		respondError(w, "Batch requests are unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Requests []BatchRequest `json:"requests"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	if len(req.Requests) == 0 {
+		respondBadRequest(w, "requests is required")
+		return
+	}
+	if len(req.Requests) > MaxBatchRequests {
+		respondBadRequest(w, fmt.Sprintf("At most %d requests per batch", MaxBatchRequests))
+		return
+	}
+	targets := make([]*url.URL, len(req.Requests))
+	seen := make(map[string]bool, len(req.Requests))
+	for i, br := range req.Requests {
+		if br.ID == "" || seen[br.ID] {
+			respondBadRequest(w, "Each request needs a unique id")
+			return
+		}
+		seen[br.ID] = true
+		u, err := batchTarget(br.Path)
+		if err != nil {
+			respondBadRequest(w, fmt.Sprintf("Request %q: %v", br.ID, err))
+			return
+		}
+		for name := range br.Headers {
+			if !batchAllowedRequestHeaders[http.CanonicalHeaderKey(name)] {
+				respondBadRequest(w, fmt.Sprintf("Request %q: header %s is not allowed", br.ID, name))
+				return
+			}
+		}
+		targets[i] = u
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), batchTimeout)
+	defer cancel()
+
+	responses := make([]BatchResponse, len(req.Requests))
+	var wg sync.WaitGroup
+	for i := range req.Requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = dispatchBatch(ctx, router, r, targets[i], req.Requests[i].Headers)
+		}(i)
+	}
+	wg.Wait()
+
+	out := make(map[string]BatchResponse, len(responses))
+	for i, br := range req.Requests {
+		out[br.ID] = responses[i]
+	}
+	respondOK(w, map[string]interface{}{"responses": out})
+}
+
+// batchTarget parses an embedded path. It must be an /api path on this
+// server; the batch endpoint itself can't be nested.
+func batchTarget(p string) (*url.URL, error) {
+	u, err := url.Parse(p)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/api/") {
+		return nil, fmt.Errorf("path must start with /api/")
+	}
+	u.Path = strings.TrimPrefix(u.Path, "/api")
+	u.RawPath = ""
+	if u.Path == "/batch" {
+		return nil, fmt.Errorf("batch requests can't be nested")
+	}
+	return u, nil
+}
+
+func dispatchBatch(ctx context.Context, router http.Handler, parent *http.Request, target *url.URL, headers map[string]string) (resp BatchResponse) {
+	defer func() {
+		if p := recover(); p != nil {
+			resp = BatchResponse{Status: http.StatusInternalServerError, Body: batchErrorBody("Internal server error")}
+		}
+	}()
+
+	// A fresh route context, or chi would keep routing the parent's
+	// /batch match instead of target.
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, chi.NewRouteContext())
+	sub, err := http.NewRequestWithContext(ctx, http.MethodGet, target.RequestURI(), nil)
+	if err != nil {
+		return BatchResponse{Status: http.StatusBadRequest, Body: batchErrorBody("Invalid path")}
+	}
+	sub.RemoteAddr = parent.RemoteAddr
+	sub.Host = parent.Host
+	sub.TLS = parent.TLS
+	for _, name := range batchForwardHeaders {
+		if vals := parent.Header.Values(name); len(vals) > 0 {
+			sub.Header[name] = vals
+		}
+	}
+	for name, v := range headers {
+		sub.Header.Set(name, v)
+	}
+	sub.Header.Set("Accept", "application/json")
+
+	rec := &batchRecorder{header: http.Header{}}
+	router.ServeHTTP(rec, sub)
+	if ctx.Err() != nil && rec.status == 0 {
+		return BatchResponse{Status: http.StatusGatewayTimeout, Body: batchErrorBody("Request timed out")}
+	}
+
+	resp.Status = rec.status
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	for _, name := range batchReturnedHeaders {
+		if v := rec.header.Get(name); v != "" {
+			if resp.Headers == nil {
+				resp.Headers = map[string]string{}
+			}
+			resp.Headers[name] = v
+		}
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case json.Valid(body):
+		resp.Body = body
+	default:
+		resp.Body, _ = json.Marshal(string(body))
+	}
+	return resp
+}
+
+func batchErrorBody(message string) json.RawMessage {
+	b, _ := json.Marshal(map[string]string{"message": message})
+	return b
+}
+
+// batchRecorder collects one embedded response in memory.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *batchRecorder) Header() http.Header { return rec.header }
+
+func (rec *batchRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *batchRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestBatchHandler(t *testing.T) {
+	h := NewBatchHandler()
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer good" {
+					respondError(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Get("/children/{childID}", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			respondOK(w, map[string]string{"id": chi.URLParam(r, "childID"), "q": r.URL.Query().Get("q")})
+		})
+		r.Post("/batch", h.Handle)
+	})
+	h.SetRouter(r)
+
+	post := func(auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("Bearer good", `{"requests": [
+		{"id": "a", "path": "/api/children/1?q=x"},
+		{"id": "b", "path": "/api/children/2", "headers": {"If-None-Match": "\"v1\""}},
+		{"id": "c", "path": "/api/nope"}
+	]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got struct {
+		Responses map[string]BatchResponse `json:"responses"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if a := got.Responses["a"]; a.Status != 200 || string(a.Body) != `{"id":"1","q":"x"}` || a.Headers["ETag"] != `"v1"` {
+		t.Errorf("a = %d %s %v", a.Status, a.Body, a.Headers)
+	}
+	if b := got.Responses["b"]; b.Status != http.StatusNotModified || len(b.Body) != 0 {
+		t.Errorf("b = %d %s", b.Status, b.Body)
+	}
+	if c := got.Responses["c"]; c.Status != http.StatusNotFound {
+		t.Errorf("c = %d", c.Status)
+	}
+
+	// Embedded requests carry the caller's credentials, not their own.
+	rec = post("Bearer good", `{"requests": [{"id": "a", "path": "/api/children/1", "headers": {"Authorization": "Bearer other"}}]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Authorization override: status %d, want 400", rec.Code)
+	}
+	if rec := post("Bearer bad", `{"requests": [{"id": "a", "path": "/api/children/1"}]}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad caller auth: status %d, want 401", rec.Code)
+	}
+
+	var many []string
+	for i := 0; i <= MaxBatchRequests; i++ {
+		many = append(many, fmt.Sprintf(`{"id": "r%d", "path": "/api/children/%d"}`, i, i))
+	}
+	for name, body := range map[string]string{
+		"too many":      `{"requests": [` + strings.Join(many, ",") + `]}`,
+		"empty":         `{"requests": []}`,
+		"duplicate id":  `{"requests": [{"id": "a", "path": "/api/children/1"}, {"id": "a", "path": "/api/children/2"}]}`,
+		"external host": `{"requests": [{"id": "a", "path": "https://example.com/api/children/1"}]}`,
+		"outside api":   `{"requests": [{"id": "a", "path": "/admin/users"}]}`,
+		"nested batch":  `{"requests": [{"id": "a", "path": "/api/batch"}]}`,
+	} {
+		if rec := post("Bearer good", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, rec.Code)
+		}
+	}
+}
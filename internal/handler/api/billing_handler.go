@@ -1,21 +1,32 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
 
 	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
 	"carecompanion/internal/service"
 )
 
 // BillingHandler handles billing-related API endpoints
 type BillingHandler struct {
-	billingService *service.BillingService
+	billingService    *service.BillingService
+	taxService        *service.TaxService
+	planChangeService *service.PlanChangeService
+	promoCodeService  *service.PromoCodeService
 }
 
 // NewBillingHandler creates a new billing handler
-func NewBillingHandler(billingService *service.BillingService) *BillingHandler {
+func NewBillingHandler(billingService *service.BillingService, taxService *service.TaxService, planChangeService *service.PlanChangeService, promoCodeService *service.PromoCodeService) *BillingHandler {
 	return &BillingHandler{
-		billingService: billingService,
+		billingService:    billingService,
+		taxService:        taxService,
+		planChangeService: planChangeService,
+		promoCodeService:  promoCodeService,
 	}
 }
 
@@ -26,7 +37,7 @@ func (h *BillingHandler) GetFamilyBilling(w http.ResponseWriter, r *http.Request
 
 	info, err := h.billingService.GetFamilyBillingInfo(r.Context(), familyID)
 	if err != nil {
-		respondInternalError(w, "Failed to get billing information")
+		respondServiceError(w, err, "Failed to get billing information")
 		return
 	}
 	if info == nil {
@@ -42,7 +53,7 @@ func (h *BillingHandler) GetFamilyBilling(w http.ResponseWriter, r *http.Request
 func (h *BillingHandler) GetPlans(w http.ResponseWriter, r *http.Request) {
 	plans, err := h.billingService.GetAvailablePlans(r.Context())
 	if err != nil {
-		respondInternalError(w, "Failed to get subscription plans")
+		respondServiceError(w, err, "Failed to get subscription plans")
 		return
 	}
 
@@ -56,9 +67,164 @@ func (h *BillingHandler) CanAddChild(w http.ResponseWriter, r *http.Request) {
 
 	canAdd, err := h.billingService.CanAddChild(r.Context(), familyID)
 	if err != nil {
-		respondInternalError(w, "Failed to check child limit")
+		respondServiceError(w, err, "Failed to check child limit")
 		return
 	}
 
 	respondOK(w, map[string]bool{"can_add_child": canAdd})
 }
+
+// GetLocalizedPrices returns plan prices for a location with tax quoted:
+// tax-inclusive where the country requires consumers to see the full
+// price, otherwise net with the tax to be added at checkout.
+// GET /api/billing/prices?country=GB&region=
+func (h *BillingHandler) GetLocalizedPrices(w http.ResponseWriter, r *http.Request) {
+	plans, err := h.billingService.GetAvailablePlans(r.Context())
+	if err != nil {
+		respondServiceError(w, err, "Failed to get subscription plans")
+		return
+	}
+	prices, err := h.taxService.LocalizePlans(r.Context(), plans, r.URL.Query().Get("country"), r.URL.Query().Get("region"))
+	if errors.Is(err, service.ErrTaxLocation) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to price plans")
+		return
+	}
+	respondOK(w, prices)
+}
+
+// PreviewPlanChange prices switching the family to another plan for the
+// rest of the billing period and lists anything blocking the switch.
+// Parents only.
+// GET /api/family/billing/change-plan/preview?plan_id=
+func (h *BillingHandler) PreviewPlanChange(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can change the family's plan")
+		return
+	}
+	planID, err := parseUUID(r.URL.Query().Get("plan_id"))
+	if err != nil {
+		respondBadRequest(w, "Invalid plan ID")
+		return
+	}
+	preview, err := h.planChangeService.Preview(r.Context(), middleware.GetFamilyID(r.Context()), planID)
+	if !respondPlanChangeError(w, err) {
+		return
+	}
+	respondOK(w, preview)
+}
+
+// ChangePlan switches the family to another plan mid-cycle; the proration
+// is invoiced straight away. Pass the preview's proration_date to be
+// charged what the preview showed. Parents only.
+// POST /api/family/billing/change-plan {"plan_id", "proration_date"}
+func (h *BillingHandler) ChangePlan(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can change the family's plan")
+		return
+	}
+	var req struct {
+		PlanID        string `json:"plan_id"`
+		ProrationDate int64  `json:"proration_date"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	planID, err := parseUUID(req.PlanID)
+	if err != nil {
+		respondBadRequest(w, "Invalid plan ID")
+		return
+	}
+	ctx := r.Context()
+	change, err := h.planChangeService.Change(ctx, middleware.GetFamilyID(ctx), planID, middleware.GetUserID(ctx), req.ProrationDate)
+	if !respondPlanChangeError(w, err) {
+		return
+	}
+	info, err := h.billingService.GetFamilyBillingInfo(ctx, middleware.GetFamilyID(ctx))
+	if err != nil {
+		respondServiceError(w, err, "Plan changed, but failed to reload billing information")
+		return
+	}
+	respondOK(w, map[string]interface{}{"change": change, "billing": info})
+}
+
+// PlanChanges lists the family's recent plan switches.
+// GET /api/family/billing/plan-changes
+func (h *BillingHandler) PlanChanges(w http.ResponseWriter, r *http.Request) {
+	changes, err := h.planChangeService.History(r.Context(), middleware.GetFamilyID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to get plan changes")
+		return
+	}
+	respondOK(w, changes)
+}
+
+// ValidatePromoCode tells the user whether a promo code applies to them
+// and, when plan_id is given, to that plan. Every check is recorded for
+// the admin promo analytics and screened for abuse, using the client IP
+// and the app's device_id; held_for_review in the answer means the
+// discount waits on an admin.
+// POST /api/family/billing/promo-codes/validate {"code", "plan_id", "device_id"}
+func (h *BillingHandler) ValidatePromoCode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code     string `json:"code"`
+		PlanID   string `json:"plan_id"`
+		DeviceID string `json:"device_id"`
+	}
+	if err := decodeJSON(r, &req); err != nil || strings.TrimSpace(req.Code) == "" {
+		respondBadRequest(w, "code is required")
+		return
+	}
+	var planID *uuid.UUID
+	if req.PlanID != "" {
+		id, err := parseUUID(req.PlanID)
+		if err != nil {
+			respondBadRequest(w, "Invalid plan ID")
+			return
+		}
+		planID = &id
+	}
+	if len(req.DeviceID) > 255 {
+		respondBadRequest(w, "device_id is too long")
+		return
+	}
+	ctx := r.Context()
+	check, err := h.promoCodeService.Check(ctx, service.PromoCheckRequest{
+		UserID:    middleware.GetUserID(ctx),
+		Email:     middleware.GetEmail(ctx),
+		Code:      req.Code,
+		PlanID:    planID,
+		IPAddress: clientIP(r),
+		DeviceID:  strings.TrimSpace(req.DeviceID),
+	})
+	if err != nil {
+		respondServiceError(w, err, "Failed to check promo code")
+		return
+	}
+	respondOK(w, check)
+}
+
+// respondPlanChangeError maps plan change errors; false when it wrote one.
+func respondPlanChangeError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrPlanChangePlanInvalid), errors.Is(err, service.ErrPlanChangeSamePlan):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrPlanChangeNotAllowed), errors.Is(err, service.ErrPlanChangeEntitlements):
+		respondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrPlanChangeNoSubscription):
+		respondNotFound(w, err.Error())
+	case errors.Is(err, service.ErrPlanChangeBillingOff):
+		respondError(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, service.ErrPlanChangeBillingFailed):
+		respondError(w, err.Error(), http.StatusBadGateway)
+	default:
+		respondInternalError(w, "Failed to change plan")
+	}
+	return false
+}
@@ -50,7 +50,7 @@ func (h *ChatHandler) ListThreads(w http.ResponseWriter, r *http.Request) {
 
 	threads, err := h.chatService.GetThreads(r.Context(), familyID, userID)
 	if err != nil {
-		respondInternalError(w, "Failed to get chat threads")
+		respondServiceError(w, err, "Failed to get chat threads")
 		return
 	}
 
@@ -76,7 +76,7 @@ func (h *ChatHandler) CreateThread(w http.ResponseWriter, r *http.Request) {
 
 	thread, err := h.chatService.CreateThread(r.Context(), familyID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create thread")
+		respondServiceError(w, err, "Failed to create thread")
 		return
 	}
 
@@ -296,7 +296,7 @@ func (h *ChatHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
 
 	count, err := h.chatService.GetTotalUnreadCount(r.Context(), familyID, userID)
 	if err != nil {
-		respondInternalError(w, "Failed to get unread count")
+		respondServiceError(w, err, "Failed to get unread count")
 		return
 	}
 
@@ -435,7 +435,7 @@ func (h *ChatHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	// Create destination file
 	dst, err := os.Create(uploadPath)
 	if err != nil {
-		respondInternalError(w, "Failed to save file")
+		respondServiceError(w, err, "Failed to save file")
 		return
 	}
 	defer dst.Close()
@@ -589,6 +589,13 @@ func (h *ChatHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
 		select {
 		case <-ctx.Done():
 			return
+		case <-h.hub.Closing():
+			// Server is shutting down: ask the client to reconnect
+			// shortly (to another instance) rather than after the
+			// browser's default backoff.
+			io.WriteString(w, "retry: 1000\nevent: reconnect\ndata: {}\n\n")
+			flusher.Flush()
+			return
 		case <-heartbeat.C:
 			// Comment line — clients ignore this; only purpose is to
 			// keep the TCP connection alive and detect a dead peer.
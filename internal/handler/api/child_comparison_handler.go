@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// comparisonDefaultDays is the comparison window when no dates are given.
+const comparisonDefaultDays = 30
+
+// ChildComparisonHandler serves the multi-child comparison under
+// /api/family/children/compare.
+type ChildComparisonHandler struct {
+	comparisons *service.ChildComparisonService
+	userService *service.UserService
+}
+
+func NewChildComparisonHandler(comparisons *service.ChildComparisonService, userService *service.UserService) *ChildComparisonHandler {
+	return &ChildComparisonHandler{comparisons: comparisons, userService: userService}
+}
+
+// queryList reads a query parameter given either repeated or comma
+// separated.
+func queryList(r *http.Request, name string) []string {
+	var out []string
+	for _, v := range r.URL.Query()[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// Compare handles GET /api/family/children/compare?child_ids=&metrics=
+// &start_date=&end_date=: the metrics' daily series for the family's
+// children, aligned on the same dates. child_ids defaults to every child
+// in the family, metrics to sleep, meltdowns and adherence, and the range
+// to the last comparisonDefaultDays days.
+func (h *ChildComparisonHandler) Compare(w http.ResponseWriter, r *http.Request) {
+	var childIDs []uuid.UUID
+	for _, v := range queryList(r, "child_ids") {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondBadRequest(w, "Invalid child ID")
+			return
+		}
+		childIDs = append(childIDs, id)
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	now := time.Now().In(getUserTimezone(r.Context(), h.userService, userID))
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -(comparisonDefaultDays - 1))
+	for param, dst := range map[string]*time.Time{"start_date": &start, "end_date": &end} {
+		if v := r.URL.Query().Get(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				respondBadRequest(w, "Invalid date format, use YYYY-MM-DD")
+				return
+			}
+			*dst = t
+		}
+	}
+
+	cmp, err := h.comparisons.Compare(r.Context(), middleware.GetFamilyID(r.Context()), userID,
+		childIDs, queryList(r, "metrics"), start, end)
+	switch {
+	case errors.Is(err, service.ErrComparisonAccess):
+		respondForbidden(w, "Access denied")
+	case errors.Is(err, service.ErrComparisonInvalid):
+		respondBadRequest(w, err.Error())
+	case err != nil:
+		respondInternalError(w, "Failed to compare children")
+	default:
+		respondOK(w, cmp)
+	}
+}
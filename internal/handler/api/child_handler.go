@@ -21,9 +21,15 @@ func NewChildHandler(childService *service.ChildService) *ChildHandler {
 func (h *ChildHandler) List(w http.ResponseWriter, r *http.Request) {
 	familyID := middleware.GetFamilyID(r.Context())
 
+	if stamp, err := h.childService.FamilyChangeStamp(r.Context(), familyID); err == nil {
+		if notModified(w, r, "children:"+familyID.String(), stamp) {
+			return
+		}
+	}
+
 	children, err := h.childService.GetByFamilyID(r.Context(), familyID)
 	if err != nil {
-		respondInternalError(w, "Failed to get children")
+		respondServiceError(w, err, "Failed to get children")
 		return
 	}
 
@@ -82,7 +88,7 @@ func (h *ChildHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	child, err := h.childService.Create(r.Context(), familyID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create child")
+		respondServiceError(w, err, "Failed to create child")
 		return
 	}
 
@@ -118,7 +124,7 @@ func (h *ChildHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	child, err := h.childService.Update(r.Context(), childID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to update child")
+		respondServiceError(w, err, "Failed to update child")
 		return
 	}
 
@@ -190,7 +196,7 @@ func (h *ChildHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 
 	dashboard, err := h.childService.GetDashboardForDate(r.Context(), childID, date)
 	if err != nil {
-		respondInternalError(w, "Failed to get dashboard")
+		respondServiceError(w, err, "Failed to get dashboard")
 		return
 	}
 
@@ -238,7 +244,7 @@ func (h *ChildHandler) AddCondition(w http.ResponseWriter, r *http.Request) {
 
 	condition, err := h.childService.AddCondition(r.Context(), childID, req.ConditionName)
 	if err != nil {
-		respondInternalError(w, "Failed to add condition")
+		respondServiceError(w, err, "Failed to add condition")
 		return
 	}
 
@@ -268,7 +274,7 @@ func (h *ChildHandler) GetConditions(w http.ResponseWriter, r *http.Request) {
 
 	conditions, err := h.childService.GetConditions(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to get conditions")
+		respondServiceError(w, err, "Failed to get conditions")
 		return
 	}
 
@@ -311,7 +317,7 @@ func (h *ChildHandler) UpdateCondition(w http.ResponseWriter, r *http.Request) {
 	// where conditionID from another child/family is swapped into the URL).
 	existingConditions, err := h.childService.GetConditions(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to verify condition")
+		respondServiceError(w, err, "Failed to verify condition")
 		return
 	}
 	conditionBelongs := false
@@ -413,7 +419,7 @@ func (h *ChildHandler) RemoveCondition(w http.ResponseWriter, r *http.Request) {
 	// Verify the condition actually belongs to this child (prevents IDOR).
 	existingConditions, err := h.childService.GetConditions(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to verify condition")
+		respondServiceError(w, err, "Failed to verify condition")
 		return
 	}
 	conditionBelongs := false
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"carecompanion/internal/service"
+)
+
+// ClientConfigHandler serves the remote configuration the apps load at
+// launch.
+type ClientConfigHandler struct {
+	svc *service.ClientConfigService
+}
+
+func NewClientConfigHandler(svc *service.ClientConfigService) *ClientConfigHandler {
+	return &ClientConfigHandler{svc: svc}
+}
+
+// Get handles GET /api/client-config. Public — the apps need it before
+// sign-in (maintenance, registration, minimum version). The response is
+// the same for every caller, so it's cacheable by shared caches for a
+// minute and revalidated by ETag after that.
+func (h *ClientConfigHandler) Get(w http.ResponseWriter, r *http.Request) {
+	cfg := *h.svc.Current(r.Context())
+	cfg.Limits.MaxBatchRequests = MaxBatchRequests
+	cfg.Limits.MaxTicketDescriptionLen = maxTicketDescriptionLen
+	cfg.Limits.MaxTicketMessageLen = maxTicketMessageLen
+
+	// generated_at changes with every rebuild; leave it out of the tag so
+	// an unchanged config still revalidates.
+	tagged := cfg
+	tagged.GeneratedAt = time.Time{}
+	raw, err := json.Marshal(tagged)
+	if err != nil {
+		respondServiceError(w, err, "Failed to build client config")
+		return
+	}
+	sum := sha256.Sum256(raw)
+	etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagListMatches(inm, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	respondOK(w, cfg)
+}
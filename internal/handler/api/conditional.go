@@ -0,0 +1,71 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"carecompanion/internal/models"
+)
+
+// stampETag is a weak validator for a response built from the rows stamp
+// covers. key carries whatever else shapes the response (the requested
+// date, the family) so two URLs with equal stamps don't share a tag.
+func stampETag(key string, stamp models.ChangeStamp) string {
+	sum := sha256.Sum256([]byte(key + "|" +
+		strconv.FormatInt(stamp.Count, 10) + "|" +
+		strconv.FormatInt(stamp.Version, 10) + "|" +
+		strconv.FormatInt(stamp.LastModified.UnixNano(), 10)))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// notModified sets ETag, Last-Modified and Cache-Control for a response
+// built from the rows stamp covers and, when the client's copy is still
+// current, writes 304 and returns true. Call it after access checks and
+// before loading the payload, so an unchanged resource costs one query.
+//
+// If-None-Match wins over If-Modified-Since (RFC 9110 13.2.2); the date
+// alone can miss a delete that left the newest timestamp unchanged, so
+// it's only trusted when the client sent no ETag.
+func notModified(w http.ResponseWriter, r *http.Request, key string, stamp models.ChangeStamp) bool {
+	etag := stampETag(key, stamp)
+	h := w.Header()
+	h.Set("ETag", etag)
+	// private: per-user data. no-cache: revalidate every time, which is
+	// the point — the 304 is what saves the bandwidth.
+	h.Set("Cache-Control", "private, no-cache")
+	if !stamp.LastModified.IsZero() {
+		h.Set("Last-Modified", stamp.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	match := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		match = etagListMatches(inm, etag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !stamp.LastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			match = !stamp.LastModified.Truncate(time.Second).After(t)
+		}
+	}
+	if match {
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return match
+}
+
+// etagListMatches does the weak comparison If-None-Match calls for.
+func etagListMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
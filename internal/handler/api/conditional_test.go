@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"carecompanion/internal/models"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+	stamp := models.ChangeStamp{Count: 3, Version: 7, LastModified: modified}
+	etag := stampETag("k", stamp)
+
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		stamp   models.ChangeStamp
+		want    bool
+	}{
+		{"no validators", nil, stamp, false},
+		{"etag match", map[string]string{"If-None-Match": etag}, stamp, true},
+		{"strong form of weak tag", map[string]string{"If-None-Match": `"x", ` + etag[2:]}, stamp, true},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, stamp, true},
+		{"version bumped", map[string]string{"If-None-Match": etag}, models.ChangeStamp{Count: 3, Version: 8, LastModified: modified}, false},
+		{"row deleted", map[string]string{"If-None-Match": etag}, models.ChangeStamp{Count: 2, Version: 7, LastModified: modified}, false},
+		{"since same second", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, stamp, true},
+		{"since earlier", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, stamp, false},
+		{"etag beats date", map[string]string{"If-None-Match": `W/"old"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, stamp, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/children", nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			got := notModified(w, r, "k", tc.stamp)
+			if got != tc.want {
+				t.Fatalf("notModified = %v, want %v", got, tc.want)
+			}
+			if got && w.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", w.Code)
+			}
+			if w.Header().Get("ETag") != stampETag("k", tc.stamp) || w.Header().Get("Last-Modified") == "" {
+				t.Errorf("validators not set: %v", w.Header())
+			}
+		})
+	}
+
+	if stampETag("a", stamp) == stampETag("b", stamp) {
+		t.Error("different keys share an ETag")
+	}
+}
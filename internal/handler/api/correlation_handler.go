@@ -40,7 +40,7 @@ func (h *CorrelationHandler) GetInsights(w http.ResponseWriter, r *http.Request)
 
 	insights, err := h.correlationService.GetInsightsPage(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to get insights")
+		respondServiceError(w, err, "Failed to get insights")
 		return
 	}
 
@@ -74,7 +74,7 @@ func (h *CorrelationHandler) CreateCorrelationRequest(w http.ResponseWriter, r *
 
 	correlation, err := h.correlationService.CreateCorrelationRequest(r.Context(), childID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create correlation request")
+		respondServiceError(w, err, "Failed to create correlation request")
 		return
 	}
 
@@ -147,7 +147,7 @@ func (h *CorrelationHandler) ListCorrelationRequests(w http.ResponseWriter, r *h
 
 	correlations, err := h.correlationService.GetCorrelationRequests(r.Context(), childID, status)
 	if err != nil {
-		respondInternalError(w, "Failed to get correlation requests")
+		respondServiceError(w, err, "Failed to get correlation requests")
 		return
 	}
 
@@ -171,7 +171,7 @@ func (h *CorrelationHandler) GetPatterns(w http.ResponseWriter, r *http.Request)
 	activeOnly := r.URL.Query().Get("active_only") != "false"
 	patterns, err := h.correlationService.GetPatterns(r.Context(), childID, activeOnly)
 	if err != nil {
-		respondInternalError(w, "Failed to get patterns")
+		respondServiceError(w, err, "Failed to get patterns")
 		return
 	}
 
@@ -238,7 +238,7 @@ func (h *CorrelationHandler) GetBaselines(w http.ResponseWriter, r *http.Request
 
 	baselines, err := h.correlationService.GetBaselines(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to get baselines")
+		respondServiceError(w, err, "Failed to get baselines")
 		return
 	}
 
@@ -261,7 +261,7 @@ func (h *CorrelationHandler) RecalculateBaselines(w http.ResponseWriter, r *http
 
 	baselines, err := h.correlationService.CalculateBaselines(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to calculate baselines")
+		respondServiceError(w, err, "Failed to calculate baselines")
 		return
 	}
 
@@ -284,7 +284,7 @@ func (h *CorrelationHandler) GetValidations(w http.ResponseWriter, r *http.Reque
 
 	validations, err := h.correlationService.GetValidations(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to get validations")
+		respondServiceError(w, err, "Failed to get validations")
 		return
 	}
 
@@ -338,7 +338,7 @@ func (h *CorrelationHandler) GetTopPatterns(w http.ResponseWriter, r *http.Reque
 
 	patterns, err := h.correlationService.GetTopPatterns(r.Context(), childID, 5)
 	if err != nil {
-		respondInternalError(w, "Failed to get top patterns")
+		respondServiceError(w, err, "Failed to get top patterns")
 		return
 	}
 
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"strings"
 
 	"carecompanion/internal/config"
 	"carecompanion/internal/middleware"
@@ -11,19 +12,25 @@ import (
 
 // DeviceHandler handles device registration and app config endpoints
 type DeviceHandler struct {
-	pushService *service.PushService
-	appConfig   *config.AppConfig
+	pushService  *service.PushService
+	appVersions  *service.AppVersionService
+	clientConfig *service.ClientConfigService
+	appConfig    *config.AppConfig
 }
 
 // NewDeviceHandler creates a new device handler
-func NewDeviceHandler(pushService *service.PushService, appConfig *config.AppConfig) *DeviceHandler {
+func NewDeviceHandler(pushService *service.PushService, appVersions *service.AppVersionService, clientConfig *service.ClientConfigService, appConfig *config.AppConfig) *DeviceHandler {
 	return &DeviceHandler{
-		pushService: pushService,
-		appConfig:   appConfig,
+		pushService:  pushService,
+		appVersions:  appVersions,
+		clientConfig: clientConfig,
+		appConfig:    appConfig,
 	}
 }
 
-// RegisterDevice registers a device token for push notifications
+// RegisterDevice registers a device token for push notifications. Apps
+// call it on every launch; re-registering refreshes the device's app
+// version and last-seen time.
 func (h *DeviceHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
 	claims := middleware.GetAuthClaims(r.Context())
 	if claims == nil {
@@ -47,11 +54,33 @@ func (h *DeviceHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	switch req.Provider {
+	case "":
+		req.Provider = models.PushProviderFCM
+	case models.PushProviderFCM:
+	case models.PushProviderAPNs:
+		if req.Platform != "ios" {
+			respondBadRequest(w, "Provider 'apns' is only valid for iOS devices")
+			return
+		}
+	default:
+		respondBadRequest(w, "Provider must be 'fcm' or 'apns'")
+		return
+	}
+
+	if len(req.AppVersion) > 32 || len(req.OSVersion) > 32 || len(req.DeviceName) > 100 {
+		respondBadRequest(w, "Device name or version is too long")
+		return
+	}
+
 	token := &models.DeviceToken{
 		UserID:     claims.UserID,
 		Token:      req.Token,
 		Platform:   req.Platform,
+		Provider:   req.Provider,
 		DeviceName: req.DeviceName,
+		AppVersion: req.AppVersion,
+		OSVersion:  req.OSVersion,
 	}
 
 	if err := h.pushService.RegisterDevice(r.Context(), token); err != nil {
@@ -89,18 +118,57 @@ func (h *DeviceHandler) UnregisterDevice(w http.ResponseWriter, r *http.Request)
 	respondOK(w, SuccessResponse{Success: true, Message: "Device unregistered"})
 }
 
-// GetAppConfig returns app configuration for mobile clients
+// GetAppConfig returns app configuration for mobile clients. The minimum
+// version is the requesting platform's (?platform= or X-App-Platform).
+// Superseded by GET /api/client-config; kept for builds that predate it.
 func (h *DeviceHandler) GetAppConfig(w http.ResponseWriter, r *http.Request) {
 	env := "production"
 	if h.appConfig.Env == "development" {
 		env = "development"
 	}
 
+	platform, _ := middleware.AppClient(r)
+	if p := r.URL.Query().Get("platform"); p != "" {
+		platform = p
+	}
+	check := h.appVersions.Check(r.Context(), platform, "")
+	minVersion := check.MinVersion
+	if minVersion == "" {
+		minVersion = service.DefaultAppVersionPolicy.IOS.MinVersion
+	}
+
 	cfg := models.AppConfig{
 		Environment:   env,
-		MinAppVersion: "1.0.0",
-		Maintenance:   false, // TODO: read from admin settings
+		MinAppVersion: minVersion,
+	}
+	if h.clientConfig != nil {
+		cfg.Maintenance = h.clientConfig.Current(r.Context()).Maintenance.Active
 	}
 
 	respondOK(w, cfg)
 }
+
+// VersionCheck handles GET /api/app/version-check. The build comes from
+// ?platform=&version= or the X-App-Platform / X-App-Version headers. Apps
+// call it at launch to show a blocking update screen (supported=false) or
+// a dismissible nudge (update_available=true); it always answers 200 so
+// old builds can read it.
+func (h *DeviceHandler) VersionCheck(w http.ResponseWriter, r *http.Request) {
+	platform, version := middleware.AppClient(r)
+	q := r.URL.Query()
+	if p := q.Get("platform"); p != "" {
+		platform = strings.ToLower(p)
+	}
+	if v := q.Get("version"); v != "" {
+		version = v
+	}
+	if version == "" {
+		respondBadRequest(w, "version is required")
+		return
+	}
+	if platform != service.AppPlatformIOS && platform != service.AppPlatformAndroid {
+		respondBadRequest(w, "Platform must be 'ios' or 'android'")
+		return
+	}
+	respondOK(w, h.appVersions.Check(r.Context(), platform, version))
+}
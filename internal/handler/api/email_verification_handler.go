@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// EmailVerificationHandler serves the verification status, resend and
+// verify-link endpoints.
+type EmailVerificationHandler struct {
+	svc *service.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(svc *service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{svc: svc}
+}
+
+// GetStatus handles GET /api/users/me/email-verification
+func (h *EmailVerificationHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.svc.Status(r.Context(), middleware.GetUserID(r.Context()))
+	if errors.Is(err, service.ErrUserNotFound) {
+		respondNotFound(w, "User not found")
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to load verification status")
+		return
+	}
+	respondOK(w, status)
+}
+
+// Resend handles POST /api/users/me/email-verification/resend
+func (h *EmailVerificationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	status, err := h.svc.Resend(r.Context(), middleware.GetUserID(r.Context()))
+	switch {
+	case errors.Is(err, service.ErrEmailVerifyResendLimited):
+		respondError(w, "Too many verification emails requested. Please try again later.", http.StatusTooManyRequests)
+		return
+	case errors.Is(err, service.ErrEmailAlreadyVerified):
+		respondError(w, "Your email address is already verified", http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		respondNotFound(w, "User not found")
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to send verification email")
+		return
+	}
+	respondOK(w, map[string]interface{}{
+		"status":  status,
+		"message": "Verification email sent to " + status.Email,
+	})
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// Verify handles POST /api/auth/verify-email. Public — the token is the
+// credential, so the app can verify a link opened while signed out.
+func (h *EmailVerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := decodeJSON(r, &req); err != nil || req.Token == "" {
+		respondBadRequest(w, "Token is required")
+		return
+	}
+	err := h.svc.Verify(r.Context(), req.Token)
+	switch {
+	case errors.Is(err, service.ErrEmailVerifyTokenExpired):
+		respondError(w, "This verification link has expired. Request a new one from Settings.", http.StatusGone)
+		return
+	case errors.Is(err, service.ErrEmailVerifyTokenUsed), errors.Is(err, service.ErrEmailVerifyTokenInvalid):
+		respondError(w, "This verification link is invalid or has already been used.", http.StatusGone)
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to verify email")
+		return
+	}
+	respondOK(w, SuccessResponse{Success: true, Message: "Email verified"})
+}
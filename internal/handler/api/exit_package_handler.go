@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// ExitPackageHandler serves the data exports built when a family cancels:
+// the list and download under /api/family/billing/exit-packages, and the
+// emailed link under /exit/signed.
+type ExitPackageHandler struct {
+	packages *service.ExitPackageService
+}
+
+func NewExitPackageHandler(packages *service.ExitPackageService) *ExitPackageHandler {
+	return &ExitPackageHandler{packages: packages}
+}
+
+// exitPackageEntry is a package with its download link while one works.
+type exitPackageEntry struct {
+	repository.ExitPackage
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// FamilyExitPackages handles GET /api/family/billing/exit-packages.
+// Parents only.
+func (h *ExitPackageHandler) FamilyExitPackages(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can download the family's data")
+		return
+	}
+	pkgs, err := h.packages.ListForFamily(r.Context(), middleware.GetFamilyID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load data exports")
+		return
+	}
+	now := time.Now()
+	entries := make([]exitPackageEntry, 0, len(pkgs))
+	for _, p := range pkgs {
+		e := exitPackageEntry{ExitPackage: p}
+		if p.Status == "ready" && p.ExpiresAt != nil && now.Before(*p.ExpiresAt) {
+			e.DownloadURL = h.packages.SignedDownloadURL(&p)
+		}
+		entries = append(entries, e)
+	}
+	respondOK(w, map[string]interface{}{"exit_packages": entries})
+}
+
+// FamilyExitPackageDownload handles
+// GET /api/family/billing/exit-packages/{packageID}/download.
+func (h *ExitPackageHandler) FamilyExitPackageDownload(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can download the family's data")
+		return
+	}
+	p, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if p.FamilyID != middleware.GetFamilyID(r.Context()) {
+		respondNotFound(w, "Data export not found")
+		return
+	}
+	h.stream(w, r, p)
+}
+
+// ServeSigned handles GET /exit/signed/{packageID}?exp=&sig=, the link in
+// the email. No JWT: the signed URL is the credential, as for invoices.
+func (h *ExitPackageHandler) ServeSigned(w http.ResponseWriter, r *http.Request) {
+	expRaw := r.URL.Query().Get("exp")
+	sig := r.URL.Query().Get("sig")
+	if expRaw == "" || sig == "" {
+		respondBadRequest(w, "Missing signature")
+		return
+	}
+	expUnix, err := strconv.ParseInt(expRaw, 10, 64)
+	if err != nil {
+		respondBadRequest(w, "Bad expiry")
+		return
+	}
+	id, err := parseUUID(chi.URLParam(r, "packageID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid data export ID")
+		return
+	}
+	if err := h.packages.VerifySignedDownload(id, expUnix, sig); err != nil {
+		respondError(w, "Link expired or invalid", http.StatusForbidden)
+		return
+	}
+	p, err := h.packages.Get(r.Context(), id)
+	if err != nil {
+		respondNotFound(w, "Data export not found")
+		return
+	}
+	h.stream(w, r, p)
+}
+
+func (h *ExitPackageHandler) load(w http.ResponseWriter, r *http.Request) (*repository.ExitPackage, bool) {
+	id, err := parseUUID(chi.URLParam(r, "packageID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid data export ID")
+		return nil, false
+	}
+	p, err := h.packages.Get(r.Context(), id)
+	if errors.Is(err, service.ErrExitPackageNotFound) {
+		respondNotFound(w, "Data export not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load data export")
+		return nil, false
+	}
+	return p, true
+}
+
+func (h *ExitPackageHandler) stream(w http.ResponseWriter, r *http.Request, p *repository.ExitPackage) {
+	rc, err := h.packages.Open(r.Context(), p)
+	if errors.Is(err, service.ErrExitPackageUnavailable) {
+		respondError(w, "This data export is no longer available", http.StatusGone)
+		return
+	}
+	if err != nil {
+		log.Printf("[EXIT] open package %s: %v", p.ID, err)
+		respondNotFound(w, "Data export file not available")
+		return
+	}
+	defer rc.Close()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+service.ExitPackageFilename(p)+"\"")
+	if p.SizeBytes != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*p.SizeBytes, 10))
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("[EXIT] streaming package %s failed: %v", p.ID, err)
+	}
+}
@@ -64,7 +64,7 @@ func (h *FamilyHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
 
 	family, err := h.familyService.GetByID(r.Context(), familyID)
 	if err != nil {
-		respondInternalError(w, "Failed to get family info")
+		respondServiceError(w, err, "Failed to get family info")
 		return
 	}
 	if family == nil {
@@ -81,7 +81,7 @@ func (h *FamilyHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
 
 	members, err := h.familyService.GetMembers(r.Context(), familyID)
 	if err != nil {
-		respondInternalError(w, "Failed to get family members")
+		respondServiceError(w, err, "Failed to get family members")
 		return
 	}
 
@@ -138,7 +138,7 @@ func (h *FamilyHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 	// Look up user by email
 	user, err := h.userService.GetByEmail(r.Context(), req.Email)
 	if err != nil {
-		respondInternalError(w, "Failed to look up user")
+		respondServiceError(w, err, "Failed to look up user")
 		return
 	}
 
@@ -148,7 +148,7 @@ func (h *FamilyHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 			// Create pending invitation (store in database for when they register)
 			err := h.familyService.CreateInvitation(r.Context(), familyID, req.Email, req.FirstName, req.LastName, role)
 			if err != nil {
-				respondInternalError(w, "Failed to create invitation")
+				respondServiceError(w, err, "Failed to create invitation")
 				return
 			}
 
@@ -186,7 +186,7 @@ func (h *FamilyHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 	// Check if already a member
 	existingMembership, err := h.familyService.GetMembership(r.Context(), familyID, user.ID)
 	if err != nil {
-		respondInternalError(w, "Failed to check membership")
+		respondServiceError(w, err, "Failed to check membership")
 		return
 	}
 	if existingMembership != nil {
@@ -267,7 +267,7 @@ func (h *FamilyHandler) LookupUser(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.userService.GetByEmail(r.Context(), req.Email)
 	if err != nil {
-		respondInternalError(w, "Failed to look up user")
+		respondServiceError(w, err, "Failed to look up user")
 		return
 	}
 
@@ -417,7 +417,7 @@ func (h *FamilyHandler) GetMember(w http.ResponseWriter, r *http.Request) {
 
 	member, err := h.familyService.GetMemberByID(r.Context(), memberID)
 	if err != nil {
-		respondInternalError(w, "Failed to get member")
+		respondServiceError(w, err, "Failed to get member")
 		return
 	}
 	if member == nil {
@@ -441,7 +441,7 @@ func (h *FamilyHandler) GetUserPreferences(w http.ResponseWriter, r *http.Reques
 
 	prefs, err := h.userService.GetPreferences(r.Context(), userID)
 	if err != nil {
-		respondInternalError(w, "Failed to get preferences")
+		respondServiceError(w, err, "Failed to get preferences")
 		return
 	}
 
@@ -471,7 +471,7 @@ func (h *FamilyHandler) UpdateUserPreferences(w http.ResponseWriter, r *http.Req
 
 	err := h.userService.UpdatePreferences(r.Context(), userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to update preferences")
+		respondServiceError(w, err, "Failed to update preferences")
 		return
 	}
 
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// FeedbackHandler handles in-app NPS and free-text feedback endpoints
+type FeedbackHandler struct {
+	feedbackService *service.FeedbackService
+}
+
+// NewFeedbackHandler creates a new feedback handler
+func NewFeedbackHandler(feedbackService *service.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{feedbackService: feedbackService}
+}
+
+// GetNPSStatus handles GET /api/feedback/nps/status. The app checks this
+// before showing the NPS prompt; users are asked at most once a quarter.
+func (h *FeedbackHandler) GetNPSStatus(w http.ResponseWriter, r *http.Request) {
+	st, err := h.feedbackService.PromptStatus(r.Context(), middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to get NPS status")
+		return
+	}
+	respondOK(w, st)
+}
+
+// SubmitNPS handles POST /api/feedback/nps
+func (h *FeedbackHandler) SubmitNPS(w http.ResponseWriter, r *http.Request) {
+	var req service.FeedbackInput
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	ctx := r.Context()
+	fb, err := h.feedbackService.SubmitNPS(ctx, middleware.GetUserID(ctx), middleware.GetFamilyID(ctx), req)
+	if err != nil {
+		h.respondFeedbackError(w, err)
+		return
+	}
+	respondCreated(w, fb)
+}
+
+// DismissNPS handles POST /api/feedback/nps/dismiss
+func (h *FeedbackHandler) DismissNPS(w http.ResponseWriter, r *http.Request) {
+	var req service.FeedbackInput
+	// Body is optional — context/platform only.
+	_ = decodeJSON(r, &req)
+	ctx := r.Context()
+	if err := h.feedbackService.DismissNPS(ctx, middleware.GetUserID(ctx), middleware.GetFamilyID(ctx), req); err != nil {
+		h.respondFeedbackError(w, err)
+		return
+	}
+	respondOK(w, map[string]bool{"dismissed": true})
+}
+
+// SubmitFeedback handles POST /api/feedback
+func (h *FeedbackHandler) SubmitFeedback(w http.ResponseWriter, r *http.Request) {
+	var req service.FeedbackInput
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	ctx := r.Context()
+	fb, err := h.feedbackService.SubmitFeedback(ctx, middleware.GetUserID(ctx), middleware.GetFamilyID(ctx), req)
+	if err != nil {
+		h.respondFeedbackError(w, err)
+		return
+	}
+	respondCreated(w, fb)
+}
+
+func (h *FeedbackHandler) respondFeedbackError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrFeedbackInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrNPSThrottled):
+		respondError(w, "NPS was already collected this quarter", http.StatusConflict)
+	case errors.Is(err, service.ErrFeedbackRateLimited):
+		respondError(w, "Too much feedback submitted today", http.StatusTooManyRequests)
+	default:
+		respondInternalError(w, "Failed to save feedback")
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// FoodPhotoHandler turns meal photos into foods_eaten suggestions for the
+// diet log form.
+type FoodPhotoHandler struct {
+	foodPhotos   *service.FoodPhotoService
+	childService *service.ChildService
+}
+
+func NewFoodPhotoHandler(foodPhotos *service.FoodPhotoService, childService *service.ChildService) *FoodPhotoHandler {
+	return &FoodPhotoHandler{foodPhotos: foodPhotos, childService: childService}
+}
+
+// Suggest handles POST /api/children/{childID}/logs/diet/photo-suggestions
+// (multipart: photo). The response's id goes in the diet log's
+// photo_recognition_id so the saved foods carry their provenance.
+func (h *FoodPhotoHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return
+	}
+	userID := middleware.GetUserID(r.Context())
+	if _, err := h.childService.VerifyChildAccess(r.Context(), childID, userID); err != nil {
+		respondForbidden(w, "Access denied")
+		return
+	}
+	if !h.foodPhotos.Enabled() {
+		respondError(w, service.ErrFoodVisionUnavailable.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(h.foodPhotos.MaxBytes())+1*1024*1024)
+	if err := r.ParseMultipartForm(8 * 1024 * 1024); err != nil {
+		respondBadRequest(w, "Upload too large or malformed")
+		return
+	}
+	file, _, err := r.FormFile("photo")
+	if err != nil {
+		respondBadRequest(w, "Missing photo field")
+		return
+	}
+	defer file.Close()
+
+	rec, err := h.foodPhotos.Recognize(r.Context(), childID, userID, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFoodPhotoInvalid):
+			respondBadRequest(w, err.Error())
+		case errors.Is(err, service.ErrFoodVisionUnavailable):
+			respondError(w, service.ErrFoodVisionUnavailable.Error(), http.StatusServiceUnavailable)
+		default:
+			log.Printf("[FOOD] meal photo recognition for %s failed: %v", childID, err)
+			respondInternalError(w, "Failed to recognize meal photo")
+		}
+		return
+	}
+	respondCreated(w, rec)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// GamificationHandler serves the caller's logging streaks, badges and
+// progress stats, and their opt-outs, at /api/users/me/gamification.
+type GamificationHandler struct {
+	gamification *service.GamificationService
+}
+
+func NewGamificationHandler(gamification *service.GamificationService) *GamificationHandler {
+	return &GamificationHandler{gamification: gamification}
+}
+
+// Get handles GET /api/users/me/gamification.
+func (h *GamificationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	st, err := h.gamification.Status(r.Context(), middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load progress")
+		return
+	}
+	respondOK(w, st)
+}
+
+// Update handles PUT /api/users/me/gamification, {"enabled": false} to
+// opt out of streaks and badges, {"weekly_digest": false} to stop the
+// digest email.
+func (h *GamificationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateGamificationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	st, err := h.gamification.Update(r.Context(), middleware.GetUserID(r.Context()), &req)
+	if err != nil {
+		respondServiceError(w, err, "Failed to save settings")
+		return
+	}
+	respondOK(w, st)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/service"
+)
+
+// HelpCenterHandler serves the public, read-only help center: published
+// knowledge-base articles and their categories.
+type HelpCenterHandler struct {
+	kbService *service.KnowledgeBaseService
+}
+
+// NewHelpCenterHandler creates a new help center handler
+func NewHelpCenterHandler(kbService *service.KnowledgeBaseService) *HelpCenterHandler {
+	return &HelpCenterHandler{kbService: kbService}
+}
+
+// ListCategories handles GET /api/help/categories
+func (h *HelpCenterHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	if stamp, err := h.kbService.CategoriesChangeStamp(r.Context()); err == nil {
+		if notModified(w, r, "help-categories", stamp) {
+			return
+		}
+	}
+	cats, err := h.kbService.ListCategories(r.Context(), true)
+	if err != nil {
+		respondServiceError(w, err, "Failed to get help categories")
+		return
+	}
+	respondOK(w, cats)
+}
+
+// SearchArticles handles GET /api/help/articles?q=&category=&page=&limit=
+// An empty q lists published articles, most recently published first.
+func (h *HelpCenterHandler) SearchArticles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page := 1
+	if p := q.Get("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+	limit := 20
+	if l := q.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+
+	articles, total, err := h.kbService.SearchPublished(r.Context(), q.Get("q"), q.Get("category"), page, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrKBInvalid) {
+			respondBadRequest(w, err.Error())
+			return
+		}
+		respondInternalError(w, "Failed to search help articles")
+		return
+	}
+
+	respondOK(w, map[string]interface{}{
+		"articles": articles,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetArticle handles GET /api/help/articles/{slug}. Each successful read
+// bumps the article's view counter.
+func (h *HelpCenterHandler) GetArticle(w http.ResponseWriter, r *http.Request) {
+	article, err := h.kbService.GetPublishedBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		if errors.Is(err, service.ErrKBArticleNotFound) {
+			respondNotFound(w, "Article not found")
+			return
+		}
+		respondInternalError(w, "Failed to get article")
+		return
+	}
+	respondOK(w, article)
+}
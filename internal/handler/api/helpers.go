@@ -95,6 +95,12 @@ func respondInternalError(w http.ResponseWriter, message string) {
 	respondError(w, message, http.StatusInternalServerError)
 }
 
+// respondServiceError writes err as problem+json with the status its
+// apperr kind maps to; errors of no kind get a 500 with message.
+func respondServiceError(w http.ResponseWriter, err error, message string) {
+	middleware.WriteError(w, err, message)
+}
+
 // decodeJSON decodes JSON from request body
 func decodeJSON(r *http.Request, v interface{}) error {
 	return json.NewDecoder(r.Body).Decode(v)
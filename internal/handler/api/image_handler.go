@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// ImageHandler handles child photo uploads and serves image variants.
+type ImageHandler struct {
+	images       *service.ImageService
+	childService *service.ChildService
+}
+
+func NewImageHandler(images *service.ImageService, childService *service.ChildService) *ImageHandler {
+	return &ImageHandler{images: images, childService: childService}
+}
+
+// verifyChild writes the error response and returns false when the user
+// can't access the child in the URL.
+func (h *ImageHandler) verifyChild(w http.ResponseWriter, r *http.Request) (ok bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return false
+	}
+	userID := middleware.GetUserID(r.Context())
+	if _, err := h.childService.VerifyChildAccess(r.Context(), childID, userID); err != nil {
+		switch err {
+		case service.ErrChildNotFound:
+			respondNotFound(w, "Child not found")
+		case service.ErrNotFamilyMember:
+			respondForbidden(w, "Access denied")
+		default:
+			respondInternalError(w, "Failed to get child")
+		}
+		return false
+	}
+	return true
+}
+
+// UploadChildPhoto replaces the child's photo (multipart: file). The
+// response lists the generated variants with signed URLs.
+func (h *ImageHandler) UploadChildPhoto(w http.ResponseWriter, r *http.Request) {
+	if !h.verifyChild(w, r) {
+		return
+	}
+	childID, _ := getChildIDFromURL(r)
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.images.MaxBytes()+1*1024*1024)
+	if err := r.ParseMultipartForm(8 * 1024 * 1024); err != nil {
+		respondBadRequest(w, "Upload too large or malformed")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondBadRequest(w, "Missing file field")
+		return
+	}
+	defer file.Close()
+
+	set, err := h.images.Process(r.Context(), service.ImageUpload{
+		OwnerType:  service.ImageOwnerChildPhoto,
+		OwnerID:    childID,
+		UploadedBy: middleware.GetUserID(r.Context()),
+		Filename:   filepath.Base(header.Filename),
+		Body:       file,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImageInvalid):
+			respondBadRequest(w, err.Error())
+		case errors.Is(err, service.ErrUploadQuarantined):
+			respondError(w, "File rejected by virus scan", http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrScanUnavailable):
+			respondError(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			log.Printf("[IMAGES] child photo upload for %s failed: %v", childID, err)
+			respondInternalError(w, "Failed to process photo")
+		}
+		return
+	}
+	respondCreated(w, set)
+}
+
+// GetChildPhoto returns the child's current photo variants.
+func (h *ImageHandler) GetChildPhoto(w http.ResponseWriter, r *http.Request) {
+	if !h.verifyChild(w, r) {
+		return
+	}
+	childID, _ := getChildIDFromURL(r)
+	set, err := h.images.Latest(r.Context(), service.ImageOwnerChildPhoto, childID)
+	if err != nil {
+		if errors.Is(err, service.ErrImageNotFound) {
+			respondNotFound(w, "No photo")
+			return
+		}
+		respondInternalError(w, "Failed to get photo")
+		return
+	}
+	respondOK(w, set)
+}
+
+// DeleteChildPhoto removes the child's photo and all its variants.
+func (h *ImageHandler) DeleteChildPhoto(w http.ResponseWriter, r *http.Request) {
+	if !h.verifyChild(w, r) {
+		return
+	}
+	childID, _ := getChildIDFromURL(r)
+	if err := h.images.DeleteOwner(r.Context(), service.ImageOwnerChildPhoto, childID); err != nil {
+		respondInternalError(w, "Failed to delete photo")
+		return
+	}
+	respondNoContent(w)
+}
+
+// ServeSignedVariant streams an image variant when the URL carries a valid
+// HMAC signature. No JWT, so <img srcset> and <picture> work; URLs are
+// only minted for callers already allowed to see the image.
+func (h *ImageHandler) ServeSignedVariant(w http.ResponseWriter, r *http.Request) {
+	variantID, err := parseUUID(chi.URLParam(r, "variantID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid image ID")
+		return
+	}
+	q := r.URL.Query()
+	expUnix, err := strconv.ParseInt(q.Get("exp"), 10, 64)
+	if err != nil || q.Get("sig") == "" {
+		respondBadRequest(w, "Missing signature")
+		return
+	}
+	if err := h.images.VerifySignedURL(variantID, expUnix, q.Get("sig")); err != nil {
+		respondError(w, "Link expired or invalid", http.StatusForbidden)
+		return
+	}
+
+	body, v, err := h.images.OpenVariant(r.Context(), variantID)
+	if err != nil {
+		if errors.Is(err, service.ErrImageNotFound) {
+			respondNotFound(w, "Image not found")
+			return
+		}
+		respondInternalError(w, "Failed to open image")
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", v.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Variants never change once written; a new upload makes new IDs.
+	w.Header().Set("Cache-Control", "private, max-age=3600, immutable")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("[IMAGES] ServeSignedVariant io.Copy failed for %s: %v", variantID, err)
+	}
+}
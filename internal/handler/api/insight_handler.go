@@ -37,7 +37,7 @@ func (h *InsightHandler) GetInsightsByTier(w http.ResponseWriter, r *http.Reques
 
 	insights, err := h.insightService.GetInsightsForChild(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to get insights")
+		respondServiceError(w, err, "Failed to get insights")
 		return
 	}
 
@@ -62,7 +62,7 @@ func (h *InsightHandler) GetTopInsights(w http.ResponseWriter, r *http.Request)
 	limit := 5
 	insights, err := h.insightService.GetTopInsights(r.Context(), childID, limit)
 	if err != nil {
-		respondInternalError(w, "Failed to get top insights")
+		respondServiceError(w, err, "Failed to get top insights")
 		return
 	}
 
@@ -108,7 +108,7 @@ func (h *InsightHandler) CreateMedicalInsight(w http.ResponseWriter, r *http.Req
 
 	insight, err := h.insightService.CreateMedicalInsight(r.Context(), &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create medical insight")
+		respondServiceError(w, err, "Failed to create medical insight")
 		return
 	}
 
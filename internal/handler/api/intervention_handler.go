@@ -0,0 +1,172 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// InterventionHandler serves a child's intervention experiments under
+// /api/children/{childID}/interventions and their before/during/after
+// evaluations.
+type InterventionHandler struct {
+	interventions *service.InterventionService
+	childService  *service.ChildService
+	userService   *service.UserService
+}
+
+func NewInterventionHandler(interventions *service.InterventionService, childService *service.ChildService, userService *service.UserService) *InterventionHandler {
+	return &InterventionHandler{interventions: interventions, childService: childService, userService: userService}
+}
+
+func (h *InterventionHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+func (h *InterventionHandler) intervention(w http.ResponseWriter, r *http.Request, child *models.Child) (*models.Intervention, bool) {
+	id, err := parseUUID(chi.URLParam(r, "interventionID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid intervention ID")
+		return nil, false
+	}
+	i, err := h.interventions.Get(r.Context(), child.ID, id)
+	if errors.Is(err, service.ErrInterventionNotFound) {
+		respondNotFound(w, "Intervention not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load intervention")
+		return nil, false
+	}
+	return i, true
+}
+
+// List handles GET /api/children/{childID}/interventions.
+func (h *InterventionHandler) List(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.interventions.List(r.Context(), child.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load interventions")
+		return
+	}
+	respondOK(w, map[string]interface{}{"interventions": list})
+}
+
+// Create handles POST /api/children/{childID}/interventions.
+func (h *InterventionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.InterventionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	i, err := h.interventions.Create(r.Context(), child.ID, middleware.GetUserID(r.Context()), &req)
+	if errors.Is(err, service.ErrInterventionInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to create intervention")
+		return
+	}
+	respondCreated(w, i)
+}
+
+// Get handles GET /api/children/{childID}/interventions/{interventionID}.
+func (h *InterventionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	if i, ok := h.intervention(w, r, child); ok {
+		respondOK(w, i)
+	}
+}
+
+// Update handles PUT /api/children/{childID}/interventions/{interventionID}.
+func (h *InterventionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	i, ok := h.intervention(w, r, child)
+	if !ok {
+		return
+	}
+	var req models.InterventionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	err := h.interventions.Update(r.Context(), i, &req)
+	if errors.Is(err, service.ErrInterventionInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to update intervention")
+		return
+	}
+	respondOK(w, i)
+}
+
+// Delete handles DELETE /api/children/{childID}/interventions/{interventionID}.
+func (h *InterventionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	i, ok := h.intervention(w, r, child)
+	if !ok {
+		return
+	}
+	if err := h.interventions.Delete(r.Context(), i.ID); err != nil {
+		respondInternalError(w, "Failed to delete intervention")
+		return
+	}
+	respondOK(w, map[string]string{"message": "Intervention deleted"})
+}
+
+// Evaluate handles GET
+// /api/children/{childID}/interventions/{interventionID}/evaluation: the
+// target metrics before, during and after the intervention, as of today
+// in the user's timezone.
+func (h *InterventionHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	i, ok := h.intervention(w, r, child)
+	if !ok {
+		return
+	}
+	today := time.Now().In(getUserTimezone(r.Context(), h.userService, middleware.GetUserID(r.Context())))
+	eval, err := h.interventions.Evaluate(r.Context(), i, today)
+	if err != nil {
+		respondServiceError(w, err, "Failed to evaluate intervention")
+		return
+	}
+	respondOK(w, eval)
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// InvoiceHandler serves billing history: a family's invoices under
+// /api/family/billing/invoices, an organization's under
+// /api/orgs/{orgID}/invoices, and signed PDF downloads under /inv/signed.
+type InvoiceHandler struct {
+	invoices *service.InvoiceService
+	orgs     *service.OrganizationService
+}
+
+func NewInvoiceHandler(invoices *service.InvoiceService, orgs *service.OrganizationService) *InvoiceHandler {
+	return &InvoiceHandler{invoices: invoices, orgs: orgs}
+}
+
+// invoiceEntry is an invoice in billing history with a short-lived link
+// the app can open in the system browser.
+type invoiceEntry struct {
+	repository.Invoice
+	DownloadURL       string    `json:"download_url"`
+	DownloadExpiresAt time.Time `json:"download_expires_at"`
+}
+
+func (h *InvoiceHandler) history(invs []repository.Invoice) map[string]interface{} {
+	entries := make([]invoiceEntry, 0, len(invs))
+	for _, inv := range invs {
+		path, exp := h.invoices.SignedPDFURL(inv.ID, signedURLTTL)
+		entries = append(entries, invoiceEntry{Invoice: inv, DownloadURL: path, DownloadExpiresAt: exp})
+	}
+	return map[string]interface{}{"invoices": entries}
+}
+
+// FamilyInvoices handles GET /api/family/billing/invoices. Parents only.
+func (h *InvoiceHandler) FamilyInvoices(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can view billing history")
+		return
+	}
+	invs, err := h.invoices.FamilyHistory(r.Context(), middleware.GetFamilyID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load invoices")
+		return
+	}
+	respondOK(w, h.history(invs))
+}
+
+// FamilyInvoicePDF handles GET /api/family/billing/invoices/{invoiceID}/pdf.
+func (h *InvoiceHandler) FamilyInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can view billing history")
+		return
+	}
+	inv, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if inv.OrganizationID != nil || inv.FamilyID == nil || *inv.FamilyID != middleware.GetFamilyID(r.Context()) {
+		respondNotFound(w, "Invoice not found")
+		return
+	}
+	h.stream(w, r, inv)
+}
+
+// OrganizationInvoices handles GET /api/orgs/{orgID}/invoices. Org admins
+// only.
+func (h *InvoiceHandler) OrganizationInvoices(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := h.authorizeOrg(w, r)
+	if !ok {
+		return
+	}
+	invs, err := h.invoices.OrganizationHistory(r.Context(), orgID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load invoices")
+		return
+	}
+	respondOK(w, h.history(invs))
+}
+
+// OrganizationInvoicePDF handles GET /api/orgs/{orgID}/invoices/{invoiceID}/pdf.
+func (h *InvoiceHandler) OrganizationInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := h.authorizeOrg(w, r)
+	if !ok {
+		return
+	}
+	inv, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if inv.OrganizationID == nil || *inv.OrganizationID != orgID {
+		respondNotFound(w, "Invoice not found")
+		return
+	}
+	h.stream(w, r, inv)
+}
+
+// ServeSignedPDF handles GET /inv/signed/{invoiceID}?exp=&sig=. No JWT: the
+// signed URL is the credential, as for report PDFs.
+func (h *InvoiceHandler) ServeSignedPDF(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "invoiceID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid invoice ID")
+		return
+	}
+	expRaw := r.URL.Query().Get("exp")
+	sig := r.URL.Query().Get("sig")
+	if expRaw == "" || sig == "" {
+		respondBadRequest(w, "Missing signature")
+		return
+	}
+	expUnix, err := strconv.ParseInt(expRaw, 10, 64)
+	if err != nil {
+		respondBadRequest(w, "Bad expiry")
+		return
+	}
+	if err := h.invoices.VerifySignedPDF(id, expUnix, sig); err != nil {
+		respondError(w, "Link expired or invalid", http.StatusForbidden)
+		return
+	}
+	inv, err := h.invoices.Get(r.Context(), id)
+	if err != nil {
+		respondNotFound(w, "Invoice not found")
+		return
+	}
+	h.stream(w, r, inv)
+}
+
+func (h *InvoiceHandler) authorizeOrg(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	orgID, err := parseUUID(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid organization ID")
+		return uuid.Nil, false
+	}
+	_, err = h.orgs.Authorize(r.Context(), orgID, middleware.GetUserID(r.Context()), true)
+	switch {
+	case errors.Is(err, service.ErrOrgNotFound):
+		respondNotFound(w, "Organization not found")
+		return uuid.Nil, false
+	case errors.Is(err, service.ErrOrgForbidden):
+		respondForbidden(w, "Only organization admins can view invoices")
+		return uuid.Nil, false
+	case err != nil:
+		respondInternalError(w, "Failed to load organization")
+		return uuid.Nil, false
+	}
+	return orgID, true
+}
+
+func (h *InvoiceHandler) load(w http.ResponseWriter, r *http.Request) (*repository.Invoice, bool) {
+	id, err := parseUUID(chi.URLParam(r, "invoiceID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid invoice ID")
+		return nil, false
+	}
+	inv, err := h.invoices.Get(r.Context(), id)
+	if errors.Is(err, service.ErrInvoiceNotFound) {
+		respondNotFound(w, "Invoice not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load invoice")
+		return nil, false
+	}
+	return inv, true
+}
+
+func (h *InvoiceHandler) stream(w http.ResponseWriter, r *http.Request, inv *repository.Invoice) {
+	rc, err := h.invoices.OpenPDF(r.Context(), inv)
+	if err != nil {
+		log.Printf("[INVOICE] open PDF for %s: %v", inv.Number, err)
+		respondNotFound(w, "Invoice file not available")
+		return
+	}
+	defer rc.Close()
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\""+service.InvoiceFilename(inv)+"\"")
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("[INVOICE] streaming %s failed: %v", inv.Number, err)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// LegalHandler serves the Terms of Service / Privacy Policy versions in
+// effect and records users' acceptance of them.
+type LegalHandler struct {
+	svc *service.LegalService
+}
+
+func NewLegalHandler(svc *service.LegalService) *LegalHandler {
+	return &LegalHandler{svc: svc}
+}
+
+// Documents handles GET /api/legal/documents. Public, so the signup screen
+// can show what the user is agreeing to.
+func (h *LegalHandler) Documents(w http.ResponseWriter, r *http.Request) {
+	docs, err := h.svc.Current(r.Context())
+	if err != nil {
+		respondServiceError(w, err, "Failed to load legal documents")
+		return
+	}
+	respondOK(w, docs)
+}
+
+// GetStatus handles GET /api/users/me/legal
+func (h *LegalHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.svc.Status(r.Context(), middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load legal status")
+		return
+	}
+	respondOK(w, status)
+}
+
+type AcceptLegalRequest struct {
+	DocumentIDs []uuid.UUID `json:"document_ids"`
+}
+
+// Accept handles POST /api/users/me/legal/accept
+func (h *LegalHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	var req AcceptLegalRequest
+	if err := decodeJSON(r, &req); err != nil || len(req.DocumentIDs) == 0 {
+		respondBadRequest(w, "document_ids is required")
+		return
+	}
+	userID := middleware.GetUserID(r.Context())
+	err := h.svc.Accept(r.Context(), userID, req.DocumentIDs, clientIP(r), r.UserAgent())
+	switch {
+	case errors.Is(err, service.ErrLegalNotCurrent):
+		respondError(w, "These terms have been updated. Please review the current version.", http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrLegalInvalid):
+		respondBadRequest(w, err.Error())
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to record acceptance")
+		return
+	}
+	status, err := h.svc.Status(r.Context(), userID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load legal status")
+		return
+	}
+	respondOK(w, status)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// LogDraftHandler serves the caller's autosaved log form drafts under
+// /api/children/{childID}/log-drafts, one per log type.
+type LogDraftHandler struct {
+	drafts       *service.LogDraftService
+	childService *service.ChildService
+}
+
+func NewLogDraftHandler(drafts *service.LogDraftService, childService *service.ChildService) *LogDraftHandler {
+	return &LogDraftHandler{drafts: drafts, childService: childService}
+}
+
+// child writes the error response and returns false when the user can't
+// access the child in the URL.
+func (h *LogDraftHandler) child(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return uuid.Nil, false
+	}
+	if _, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context())); err != nil {
+		respondForbidden(w, "Access denied")
+		return uuid.Nil, false
+	}
+	return childID, true
+}
+
+// List handles GET /api/children/{childID}/log-drafts, for offering to
+// resume any form left unfinished.
+func (h *LogDraftHandler) List(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	drafts, err := h.drafts.List(r.Context(), middleware.GetUserID(r.Context()), childID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load drafts")
+		return
+	}
+	respondOK(w, drafts)
+}
+
+// Get handles GET /api/children/{childID}/log-drafts/{logType}.
+func (h *LogDraftHandler) Get(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	draft, err := h.drafts.Get(r.Context(), middleware.GetUserID(r.Context()), childID, chi.URLParam(r, "logType"))
+	if errors.Is(err, service.ErrLogDraftInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to load draft")
+		return
+	}
+	if draft == nil {
+		respondNotFound(w, "No draft saved")
+		return
+	}
+	respondOK(w, draft)
+}
+
+// Save handles PUT /api/children/{childID}/log-drafts/{logType}.
+func (h *LogDraftHandler) Save(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.SaveLogDraftRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	draft, err := h.drafts.Save(r.Context(), middleware.GetUserID(r.Context()), childID, chi.URLParam(r, "logType"), &req)
+	if errors.Is(err, service.ErrLogDraftInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to save draft")
+		return
+	}
+	respondOK(w, draft)
+}
+
+// FromPrevious handles POST
+// /api/children/{childID}/log-drafts/{logType}/from-previous?date=YYYY-MM-DD:
+// "same as yesterday". The day's latest entry of the type, yesterday's by
+// default, becomes the caller's draft, ready to adjust and save.
+func (h *LogDraftHandler) FromPrevious(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	day := time.Now().AddDate(0, 0, -1)
+	if s := r.URL.Query().Get("date"); s != "" {
+		var err error
+		if day, err = parseDate(s); err != nil {
+			respondBadRequest(w, "date must be YYYY-MM-DD")
+			return
+		}
+	}
+	draft, err := h.drafts.FromPrevious(r.Context(), middleware.GetUserID(r.Context()), childID, chi.URLParam(r, "logType"), day)
+	switch {
+	case errors.Is(err, service.ErrLogDraftInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrNoPreviousLog):
+		respondNotFound(w, "No entry of that type on "+day.Format("2006-01-02"))
+	case err != nil:
+		respondInternalError(w, "Failed to copy entry")
+	default:
+		respondOK(w, draft)
+	}
+}
+
+// Delete handles DELETE /api/children/{childID}/log-drafts/{logType}.
+func (h *LogDraftHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	err := h.drafts.Delete(r.Context(), middleware.GetUserID(r.Context()), childID, chi.URLParam(r, "logType"))
+	if errors.Is(err, service.ErrLogDraftInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to delete draft")
+		return
+	}
+	respondNoContent(w)
+}
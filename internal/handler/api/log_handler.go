@@ -1,16 +1,24 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	stdlog "log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
 	"carecompanion/internal/service"
 )
 
@@ -20,16 +28,28 @@ type LogHandler struct {
 	userService         *service.UserService
 	realtimeService     *service.RealtimeDetectionService
 	transparencyService *service.TransparencyService
+	usageService        *service.LogUsageService
 }
 
-func NewLogHandler(logService *service.LogService, childService *service.ChildService, userService *service.UserService, realtimeService *service.RealtimeDetectionService, transparencyService *service.TransparencyService) *LogHandler {
+func NewLogHandler(logService *service.LogService, childService *service.ChildService, userService *service.UserService, realtimeService *service.RealtimeDetectionService, transparencyService *service.TransparencyService, usageService *service.LogUsageService) *LogHandler {
 	return &LogHandler{
 		logService:          logService,
 		childService:        childService,
 		userService:         userService,
 		realtimeService:     realtimeService,
 		transparencyService: transparencyService,
+		usageService:        usageService,
+	}
+}
+
+// recordUsage counts a created entry of logType against the client
+// platform that sent it, for the admin log usage report.
+func (h *LogHandler) recordUsage(r *http.Request, logType string) {
+	if h.usageService == nil {
+		return
 	}
+	platform, _ := middleware.AppClient(r)
+	h.usageService.RecordEntry(r.Context(), logType, platform)
 }
 
 // triggerDetection runs realtime detection asynchronously after a log is created
@@ -59,6 +79,31 @@ func (h *LogHandler) triggerDetection(childID interface{ String() string }, logT
 	}()
 }
 
+// respondSparseLogs answers a list request that carries ?fields= with only
+// those fields, selected as such in the query, and reports whether it did.
+// Without fields the caller serves the full list as before.
+func (h *LogHandler) respondSparseLogs(w http.ResponseWriter, r *http.Request, logType string, childID uuid.UUID, startDate, endDate time.Time) bool {
+	fields := middleware.SparseFields(r)
+	if fields == nil {
+		return false
+	}
+	logs, err := h.logService.ListLogFields(r.Context(), logType, childID, startDate, endDate, fields)
+	if errors.Is(err, repository.ErrUnknownField) {
+		respondBadRequest(w, err.Error())
+		return true
+	}
+	var body json.RawMessage
+	if err == nil {
+		body, err = middleware.ProjectJSON(logs, fields)
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to get "+strings.ReplaceAll(logType, "_", " ")+" logs")
+		return true
+	}
+	respondOK(w, body)
+	return true
+}
+
 // GetDailyLogs returns all logs for a specific day
 func (h *LogHandler) GetDailyLogs(w http.ResponseWriter, r *http.Request) {
 	childID, err := getChildIDFromURL(r)
@@ -85,9 +130,15 @@ func (h *LogHandler) GetDailyLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if stamp, err := h.logService.DailyLogsChangeStamp(r.Context(), childID, date, date); err == nil {
+		if notModified(w, r, "daily-logs:"+childID.String()+":"+date.Format("2006-01-02"), stamp) {
+			return
+		}
+	}
+
 	logs, err := h.logService.GetDailyLogs(r.Context(), childID, date)
 	if err != nil {
-		respondInternalError(w, "Failed to get daily logs")
+		respondServiceError(w, err, "Failed to get daily logs")
 		return
 	}
 
@@ -112,7 +163,7 @@ func (h *LogHandler) GetDatesWithLogs(w http.ResponseWriter, r *http.Request) {
 	limit := 30
 	dates, err := h.logService.GetDatesWithLogs(r.Context(), childID, limit)
 	if err != nil {
-		respondInternalError(w, "Failed to get dates with logs")
+		respondServiceError(w, err, "Failed to get dates with logs")
 		return
 	}
 
@@ -140,24 +191,8 @@ func (h *LogHandler) CreateBehaviorLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !req.LogDate.Time.IsZero() && req.LogDate.Time.After(time.Now()) {
-		respondBadRequest(w, "Log date cannot be in the future")
-		return
-	}
-	if len(req.Notes) > 5000 {
-		respondBadRequest(w, "Notes must be 5000 characters or fewer")
-		return
-	}
-	if req.MoodLevel != nil && (*req.MoodLevel < 1 || *req.MoodLevel > 10) {
-		respondBadRequest(w, "mood_level must be between 1 and 10")
-		return
-	}
-	if req.EnergyLevel != nil && (*req.EnergyLevel < 1 || *req.EnergyLevel > 10) {
-		respondBadRequest(w, "energy_level must be between 1 and 10")
-		return
-	}
-	if req.AnxietyLevel != nil && (*req.AnxietyLevel < 1 || *req.AnxietyLevel > 10) {
-		respondBadRequest(w, "anxiety_level must be between 1 and 10")
+	if msg := behaviorLogRequestError(&req); msg != "" {
+		respondBadRequest(w, msg)
 		return
 	}
 
@@ -168,10 +203,29 @@ func (h *LogHandler) CreateBehaviorLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "behavior")
 	h.triggerDetection(childID, "behavior")
 }
 
+// behaviorLogRequestError returns why a new behavior log is invalid, or "".
+func behaviorLogRequestError(req *models.CreateBehaviorLogRequest) string {
+	switch {
+	case !req.LogDate.Time.IsZero() && req.LogDate.Time.After(time.Now()):
+		return "Log date cannot be in the future"
+	case len(req.Notes) > 5000:
+		return "Notes must be 5000 characters or fewer"
+	case req.MoodLevel != nil && (*req.MoodLevel < 1 || *req.MoodLevel > 10):
+		return "mood_level must be between 1 and 10"
+	case req.EnergyLevel != nil && (*req.EnergyLevel < 1 || *req.EnergyLevel > 10):
+		return "energy_level must be between 1 and 10"
+	case req.AnxietyLevel != nil && (*req.AnxietyLevel < 1 || *req.AnxietyLevel > 10):
+		return "anxiety_level must be between 1 and 10"
+	}
+	return ""
+}
+
 func (h *LogHandler) GetBehaviorLogs(w http.ResponseWriter, r *http.Request) {
 	childID, err := getChildIDFromURL(r)
 	if err != nil {
@@ -205,9 +259,13 @@ func (h *LogHandler) GetBehaviorLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "behavior", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetBehaviorLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get behavior logs")
+		respondServiceError(w, err, "Failed to get behavior logs")
 		return
 	}
 
@@ -249,8 +307,8 @@ func (h *LogHandler) UpdateBehaviorLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateBehaviorLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -298,10 +356,12 @@ func (h *LogHandler) UpdateBehaviorLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateBehaviorLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update behavior log")
+	existing.Version = version
+	if err := h.logService.UpdateBehaviorLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update behavior log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -341,11 +401,13 @@ func (h *LogHandler) CreateBowelLog(w http.ResponseWriter, r *http.Request) {
 
 	log, err := h.logService.CreateBowelLog(r.Context(), childID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create bowel log")
+		respondServiceError(w, err, "Failed to create bowel log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "bowel")
 	h.triggerDetection(childID, "bowel")
 }
 
@@ -382,9 +444,13 @@ func (h *LogHandler) GetBowelLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "bowel", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetBowelLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get bowel logs")
+		respondServiceError(w, err, "Failed to get bowel logs")
 		return
 	}
 
@@ -426,8 +492,8 @@ func (h *LogHandler) UpdateBowelLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateBowelLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -458,10 +524,12 @@ func (h *LogHandler) UpdateBowelLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateBowelLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update bowel log")
+	existing.Version = version
+	if err := h.logService.UpdateBowelLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update bowel log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -502,7 +570,9 @@ func (h *LogHandler) CreateSpeechLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "speech")
 	h.triggerDetection(childID, "speech")
 }
 
@@ -539,9 +609,13 @@ func (h *LogHandler) GetSpeechLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "speech", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetSpeechLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get speech logs")
+		respondServiceError(w, err, "Failed to get speech logs")
 		return
 	}
 
@@ -568,8 +642,8 @@ func (h *LogHandler) UpdateSpeechLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateSpeechLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -597,10 +671,12 @@ func (h *LogHandler) UpdateSpeechLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateSpeechLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update speech log")
+	existing.Version = version
+	if err := h.logService.UpdateSpeechLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update speech log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -658,12 +734,18 @@ func (h *LogHandler) CreateDietLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log, err := h.logService.CreateDietLog(r.Context(), childID, userID, &req)
+	if errors.Is(err, service.ErrFoodRecognitionNotFound) {
+		respondBadRequest(w, "photo_recognition_id doesn't match a meal photo for this child")
+		return
+	}
 	if err != nil {
-		respondInternalError(w, "Failed to create diet log")
+		respondServiceError(w, err, "Failed to create diet log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "diet")
 	h.triggerDetection(childID, "meal")
 }
 
@@ -700,9 +782,13 @@ func (h *LogHandler) GetDietLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "diet", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetDietLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get diet logs")
+		respondServiceError(w, err, "Failed to get diet logs")
 		return
 	}
 
@@ -729,8 +815,8 @@ func (h *LogHandler) UpdateDietLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateDietLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -775,11 +861,21 @@ func (h *LogHandler) UpdateDietLog(w http.ResponseWriter, r *http.Request) {
 	if !req.LogDate.Time.IsZero() {
 		existing.LogDate = req.LogDate.Time
 	}
+	if req.PhotoRecognitionID != nil {
+		existing.PhotoRecognitionID = req.PhotoRecognitionID
+	}
 
-	if err := h.logService.UpdateDietLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update diet log")
+	existing.Version = version
+	err = h.logService.UpdateDietLog(r.Context(), existing, userID)
+	if errors.Is(err, service.ErrFoodRecognitionNotFound) {
+		respondBadRequest(w, "photo_recognition_id doesn't match a meal photo for this child")
+		return
+	}
+	if err != nil {
+		respondLogUpdateError(w, err, "Failed to update diet log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -838,11 +934,13 @@ func (h *LogHandler) CreateWeightLog(w http.ResponseWriter, r *http.Request) {
 
 	log, err := h.logService.CreateWeightLog(r.Context(), childID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create weight log")
+		respondServiceError(w, err, "Failed to create weight log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "weight")
 	h.triggerDetection(childID, "weight")
 }
 
@@ -879,9 +977,13 @@ func (h *LogHandler) GetWeightLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "weight", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetWeightLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get weight logs")
+		respondServiceError(w, err, "Failed to get weight logs")
 		return
 	}
 
@@ -908,8 +1010,8 @@ func (h *LogHandler) UpdateWeightLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateWeightLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -940,10 +1042,12 @@ func (h *LogHandler) UpdateWeightLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateWeightLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update weight log")
+	existing.Version = version
+	if err := h.logService.UpdateWeightLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update weight log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -998,11 +1102,13 @@ func (h *LogHandler) CreateSleepLog(w http.ResponseWriter, r *http.Request) {
 
 	log, err := h.logService.CreateSleepLog(r.Context(), childID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create sleep log")
+		respondServiceError(w, err, "Failed to create sleep log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "sleep")
 	h.triggerDetection(childID, "sleep")
 }
 
@@ -1039,9 +1145,13 @@ func (h *LogHandler) GetSleepLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "sleep", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetSleepLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get sleep logs")
+		respondServiceError(w, err, "Failed to get sleep logs")
 		return
 	}
 
@@ -1068,8 +1178,8 @@ func (h *LogHandler) UpdateSleepLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateSleepLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -1107,10 +1217,12 @@ func (h *LogHandler) UpdateSleepLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateSleepLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update sleep log")
+	existing.Version = version
+	if err := h.logService.UpdateSleepLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update sleep log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -1165,11 +1277,13 @@ func (h *LogHandler) CreateSensoryLog(w http.ResponseWriter, r *http.Request) {
 
 	log, err := h.logService.CreateSensoryLog(r.Context(), childID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create sensory log")
+		respondServiceError(w, err, "Failed to create sensory log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "sensory")
 	h.triggerDetection(childID, "sensory")
 }
 
@@ -1206,9 +1320,13 @@ func (h *LogHandler) GetSensoryLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "sensory", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetSensoryLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get sensory logs")
+		respondServiceError(w, err, "Failed to get sensory logs")
 		return
 	}
 
@@ -1235,8 +1353,8 @@ func (h *LogHandler) UpdateSensoryLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateSensoryLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -1269,10 +1387,12 @@ func (h *LogHandler) UpdateSensoryLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateSensoryLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update sensory log")
+	existing.Version = version
+	if err := h.logService.UpdateSensoryLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update sensory log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -1331,11 +1451,13 @@ func (h *LogHandler) CreateSocialLog(w http.ResponseWriter, r *http.Request) {
 
 	log, err := h.logService.CreateSocialLog(r.Context(), childID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create social log")
+		respondServiceError(w, err, "Failed to create social log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "social")
 	h.triggerDetection(childID, "social")
 }
 
@@ -1372,9 +1494,13 @@ func (h *LogHandler) GetSocialLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "social", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetSocialLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get social logs")
+		respondServiceError(w, err, "Failed to get social logs")
 		return
 	}
 
@@ -1401,8 +1527,8 @@ func (h *LogHandler) UpdateSocialLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateSocialLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -1438,10 +1564,12 @@ func (h *LogHandler) UpdateSocialLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateSocialLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update social log")
+	existing.Version = version
+	if err := h.logService.UpdateSocialLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update social log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -1481,37 +1609,44 @@ func (h *LogHandler) CreateTherapyLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !req.LogDate.Time.IsZero() && req.LogDate.Time.After(time.Now()) {
-		respondBadRequest(w, "Log date cannot be in the future")
-		return
-	}
-	if len(req.ParentNotes) > 5000 {
-		respondBadRequest(w, "parent_notes must be 5000 characters or fewer")
-		return
-	}
-	if len(req.ProgressNotes) > 2000 {
-		respondBadRequest(w, "progress_notes must be 2000 characters or fewer")
-		return
-	}
-	if len(req.HomeworkAssigned) > 2000 {
-		respondBadRequest(w, "homework_assigned must be 2000 characters or fewer")
-		return
-	}
-	if req.DurationMinutes != nil && (*req.DurationMinutes < 0 || *req.DurationMinutes > 480) {
-		respondBadRequest(w, "duration_minutes must be between 0 and 480")
+	if msg := therapyLogRequestError(&req); msg != "" {
+		respondBadRequest(w, msg)
 		return
 	}
 
 	log, err := h.logService.CreateTherapyLog(r.Context(), childID, userID, &req)
+	if errors.Is(err, service.ErrTherapyGoalInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
 	if err != nil {
-		respondInternalError(w, "Failed to create therapy log")
+		respondServiceError(w, err, "Failed to create therapy log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "therapy")
 	h.triggerDetection(childID, "therapy")
 }
 
+// therapyLogRequestError returns why a new therapy log is invalid, or "".
+func therapyLogRequestError(req *models.CreateTherapyLogRequest) string {
+	switch {
+	case !req.LogDate.Time.IsZero() && req.LogDate.Time.After(time.Now()):
+		return "Log date cannot be in the future"
+	case len(req.ParentNotes) > 5000:
+		return "parent_notes must be 5000 characters or fewer"
+	case len(req.ProgressNotes) > 2000:
+		return "progress_notes must be 2000 characters or fewer"
+	case len(req.HomeworkAssigned) > 2000:
+		return "homework_assigned must be 2000 characters or fewer"
+	case req.DurationMinutes != nil && (*req.DurationMinutes < 0 || *req.DurationMinutes > 480):
+		return "duration_minutes must be between 0 and 480"
+	}
+	return ""
+}
+
 func (h *LogHandler) GetTherapyLogs(w http.ResponseWriter, r *http.Request) {
 	childID, err := getChildIDFromURL(r)
 	if err != nil {
@@ -1545,9 +1680,13 @@ func (h *LogHandler) GetTherapyLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "therapy", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetTherapyLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get therapy logs")
+		respondServiceError(w, err, "Failed to get therapy logs")
 		return
 	}
 
@@ -1574,8 +1713,8 @@ func (h *LogHandler) UpdateTherapyLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateTherapyLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -1614,14 +1753,24 @@ func (h *LogHandler) UpdateTherapyLog(w http.ResponseWriter, r *http.Request) {
 	existing.HomeworkAssigned.Valid = req.HomeworkAssigned != ""
 	existing.ParentNotes.String = req.ParentNotes
 	existing.ParentNotes.Valid = req.ParentNotes != ""
+	if req.Goals != nil {
+		existing.Goals = req.Goals
+	}
 	if !req.LogDate.Time.IsZero() {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateTherapyLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update therapy log")
+	existing.Version = version
+	err = h.logService.UpdateTherapyLog(r.Context(), existing, userID)
+	if errors.Is(err, service.ErrTherapyGoalInvalid) {
+		respondBadRequest(w, err.Error())
 		return
 	}
+	if err != nil {
+		respondLogUpdateError(w, err, "Failed to update therapy log")
+		return
+	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -1686,7 +1835,9 @@ func (h *LogHandler) CreateSeizureLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "seizure")
 	h.triggerDetection(childID, "seizure")
 }
 
@@ -1723,9 +1874,13 @@ func (h *LogHandler) GetSeizureLogs(w http.ResponseWriter, r *http.Request) {
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "seizure", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetSeizureLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get seizure logs")
+		respondServiceError(w, err, "Failed to get seizure logs")
 		return
 	}
 
@@ -1752,8 +1907,8 @@ func (h *LogHandler) UpdateSeizureLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateSeizureLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -1794,10 +1949,12 @@ func (h *LogHandler) UpdateSeizureLog(w http.ResponseWriter, r *http.Request) {
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateSeizureLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update seizure log")
+	existing.Version = version
+	if err := h.logService.UpdateSeizureLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update seizure log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -1864,11 +2021,13 @@ func (h *LogHandler) CreateHealthEventLog(w http.ResponseWriter, r *http.Request
 
 	log, err := h.logService.CreateHealthEventLog(r.Context(), childID, userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create health event log")
+		respondServiceError(w, err, "Failed to create health event log")
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	h.recordUsage(r, "health_event")
 	h.triggerDetection(childID, "symptom")
 }
 
@@ -1905,9 +2064,13 @@ func (h *LogHandler) GetHealthEventLogs(w http.ResponseWriter, r *http.Request)
 		endDate = t
 	}
 
+	if h.respondSparseLogs(w, r, "health_event", childID, startDate, endDate) {
+		return
+	}
+
 	logs, err := h.logService.GetHealthEventLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get health event logs")
+		respondServiceError(w, err, "Failed to get health event logs")
 		return
 	}
 
@@ -1934,8 +2097,8 @@ func (h *LogHandler) UpdateHealthEventLog(w http.ResponseWriter, r *http.Request
 	}
 
 	var req models.CreateHealthEventLogRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -1990,10 +2153,12 @@ func (h *LogHandler) UpdateHealthEventLog(w http.ResponseWriter, r *http.Request
 		existing.LogDate = req.LogDate.Time
 	}
 
-	if err := h.logService.UpdateHealthEventLog(r.Context(), existing); err != nil {
-		respondInternalError(w, "Failed to update health event log")
+	existing.Version = version
+	if err := h.logService.UpdateHealthEventLog(r.Context(), existing, userID); err != nil {
+		respondLogUpdateError(w, err, "Failed to update health event log")
 		return
 	}
+	setLogETag(w, existing.Version)
 
 	respondOK(w, existing)
 }
@@ -2013,6 +2178,126 @@ func (h *LogHandler) DeleteHealthEventLog(w http.ResponseWriter, r *http.Request
 	respondNoContent(w)
 }
 
+// GetLogHistory handles GET /api/logs/{logType}/{id}/history: every edit
+// made to the log, with the previous and new value of each field changed,
+// who made it and when.
+func (h *LogHandler) GetLogHistory(w http.ResponseWriter, r *http.Request) {
+	logID, err := getIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid log ID")
+		return
+	}
+
+	history, err := h.logService.History(r.Context(), chi.URLParam(r, "logType"), logID)
+	if errors.Is(err, service.ErrLogNotFound) {
+		respondNotFound(w, "Log not found")
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to get log history")
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if _, err := h.childService.VerifyChildAccess(r.Context(), history.ChildID, userID); err != nil {
+		respondForbidden(w, "Access denied")
+		return
+	}
+
+	respondOK(w, history)
+}
+
+// CreateFromData creates a log of logType for the child in the URL from
+// data, exactly as if data had been posted to that type's create
+// endpoint: the same validation, side effects and response. It returns
+// false, having written nothing, for a type without one.
+func (h *LogHandler) CreateFromData(w http.ResponseWriter, r *http.Request, logType string, data []byte) bool {
+	creates := map[string]http.HandlerFunc{
+		"behavior":     h.CreateBehaviorLog,
+		"bowel":        h.CreateBowelLog,
+		"speech":       h.CreateSpeechLog,
+		"diet":         h.CreateDietLog,
+		"weight":       h.CreateWeightLog,
+		"sleep":        h.CreateSleepLog,
+		"sensory":      h.CreateSensoryLog,
+		"social":       h.CreateSocialLog,
+		"therapy":      h.CreateTherapyLog,
+		"seizure":      h.CreateSeizureLog,
+		"health_event": h.CreateHealthEventLog,
+	}
+	create, ok := creates[logType]
+	if !ok {
+		return false
+	}
+	req := r.Clone(r.Context())
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	create(w, req)
+	return true
+}
+
+// decodeLogUpdate decodes a log update body into req and returns the log
+// version the update was made against: If-Match ("3", or W/"3" as echoed
+// from the ETag), else "version" in the body. Updates without one are
+// refused with 428, so no edit can overwrite a change it never saw.
+func decodeLogUpdate(w http.ResponseWriter, r *http.Request, req any) (int, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || json.Unmarshal(body, req) != nil {
+		respondBadRequest(w, "Invalid request body")
+		return 0, false
+	}
+	if match := r.Header.Get("If-Match"); match != "" {
+		version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(match, "W/"), `"`))
+		if err != nil || version < 1 {
+			respondBadRequest(w, "If-Match must be the log's version")
+			return 0, false
+		}
+		return version, true
+	}
+	var v struct {
+		Version int `json:"version"`
+	}
+	if json.Unmarshal(body, &v) != nil || v.Version < 1 {
+		respondError(w, "Send the version of the log being edited in If-Match or as \"version\"", http.StatusPreconditionRequired)
+		return 0, false
+	}
+	return v.Version, true
+}
+
+// setLogETag sends a log's version as its ETag, for the next If-Match.
+func setLogETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// logConflictResponse is the 409 body for a log update made against an
+// old version: the usual error fields plus the current log and how the
+// edit merges onto it.
+type logConflictResponse struct {
+	middleware.ErrorResponse
+	Conflict *models.LogUpdateConflict `json:"conflict"`
+}
+
+// respondLogUpdateError writes the response for a failed log update.
+func respondLogUpdateError(w http.ResponseWriter, err error, message string) {
+	var conflict *service.LogConflictError
+	switch {
+	case errors.As(err, &conflict):
+		setLogETag(w, conflict.Conflict.CurrentVersion)
+		respondJSON(w, logConflictResponse{
+			ErrorResponse: middleware.ErrorResponse{
+				Error:   http.StatusText(http.StatusConflict),
+				Message: "This log was changed by someone else since you opened it",
+				Code:    http.StatusConflict,
+			},
+			Conflict: conflict.Conflict,
+		}, http.StatusConflict)
+	case errors.Is(err, service.ErrLogNotFound):
+		respondNotFound(w, "Log not found")
+	default:
+		respondInternalError(w, message)
+	}
+}
+
 // QuickSummaryResponse represents the response for quick summary
 type QuickSummaryResponse struct {
 	Category    string       `json:"category"`
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// LogTemplateHandler serves the family's log entry templates: managed
+// under /api/family/log-templates, and logged for a child in one call at
+// /api/children/{childID}/logs/templates/{templateID}.
+type LogTemplateHandler struct {
+	templates    *service.LogTemplateService
+	logs         *LogHandler
+	childService *service.ChildService
+}
+
+func NewLogTemplateHandler(templates *service.LogTemplateService, logs *LogHandler, childService *service.ChildService) *LogTemplateHandler {
+	return &LogTemplateHandler{templates: templates, logs: logs, childService: childService}
+}
+
+// respondTemplateError writes the response for a failed template call.
+func respondTemplateError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrLogTemplateInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrLogTemplateNotFound):
+		respondNotFound(w, "Template not found")
+	default:
+		respondInternalError(w, message)
+	}
+}
+
+// List handles GET /api/family/log-templates?log_type=.
+func (h *LogTemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templates.List(r.Context(), middleware.GetFamilyID(r.Context()), r.URL.Query().Get("log_type"))
+	if err != nil {
+		respondTemplateError(w, err, "Failed to load templates")
+		return
+	}
+	respondOK(w, templates)
+}
+
+// Create handles POST /api/family/log-templates.
+func (h *LogTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.LogTemplateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	t, err := h.templates.Create(r.Context(), middleware.GetFamilyID(r.Context()), middleware.GetUserID(r.Context()), &req)
+	if err != nil {
+		respondTemplateError(w, err, "Failed to save template")
+		return
+	}
+	respondCreated(w, t)
+}
+
+// Update handles PUT /api/family/log-templates/{templateID}.
+func (h *LogTemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "templateID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid template ID")
+		return
+	}
+	var req models.LogTemplateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	t, err := h.templates.Update(r.Context(), middleware.GetFamilyID(r.Context()), id, &req)
+	if err != nil {
+		respondTemplateError(w, err, "Failed to save template")
+		return
+	}
+	respondOK(w, t)
+}
+
+// Delete handles DELETE /api/family/log-templates/{templateID}.
+func (h *LogTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "templateID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid template ID")
+		return
+	}
+	if err := h.templates.Delete(r.Context(), middleware.GetFamilyID(r.Context()), id); err != nil {
+		respondTemplateError(w, err, "Failed to delete template")
+		return
+	}
+	respondNoContent(w)
+}
+
+// Apply handles POST /api/children/{childID}/logs/templates/{templateID}:
+// logs the template for the child, dated today unless the optional body,
+// fields laid over the template's such as {"log_date": ..., "notes":
+// ...}, says otherwise. The response is the create endpoint's.
+func (h *LogTemplateHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return
+	}
+	templateID, err := parseUUID(chi.URLParam(r, "templateID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid template ID")
+		return
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return
+	}
+	t, err := h.templates.Get(r.Context(), child.FamilyID, templateID)
+	if err != nil {
+		respondTemplateError(w, err, "Failed to load template")
+		return
+	}
+	overrides, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	data, err := h.templates.EntryData(t, overrides)
+	if err != nil {
+		respondTemplateError(w, err, "Failed to use template")
+		return
+	}
+	if !h.logs.CreateFromData(w, r, t.LogType, data) {
+		respondBadRequest(w, "Templates of this log type can't be logged")
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLogUpdate(t *testing.T) {
+	// A create response's ETag, sent back as If-Match, names the version.
+	created := httptest.NewRecorder()
+	setLogETag(created, 1)
+
+	for _, tc := range []struct {
+		name    string
+		ifMatch string
+		body    string
+		version int
+		status  int
+	}{
+		{"etag from create", created.Header().Get("ETag"), `{}`, 1, 0},
+		{"weak etag", `W/"4"`, `{}`, 4, 0},
+		{"body version", "", `{"version": 2}`, 2, 0},
+		{"no version", "", `{}`, 0, http.StatusPreconditionRequired},
+		{"zero version", "", `{"version": 0}`, 0, http.StatusPreconditionRequired},
+		{"bad if-match", `"x"`, `{}`, 0, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/logs/bowel/1", strings.NewReader(tc.body))
+		if tc.ifMatch != "" {
+			req.Header.Set("If-Match", tc.ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		var body struct{}
+		version, ok := decodeLogUpdate(rec, req, &body)
+		if ok != (tc.status == 0) || version != tc.version {
+			t.Errorf("%s: version %d, ok %v; want %d", tc.name, version, ok, tc.version)
+		}
+		if tc.status != 0 && rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.status)
+		}
+	}
+}
@@ -25,9 +25,10 @@ type MedicationHandler struct {
 	drugDBService   *service.DrugDatabaseService
 	insightService  *service.InsightService
 	realtimeService *service.RealtimeDetectionService
+	usageService    *service.LogUsageService
 }
 
-func NewMedicationHandler(medService *service.MedicationService, childService *service.ChildService, userService *service.UserService, drugDBService *service.DrugDatabaseService, insightService *service.InsightService, realtimeService *service.RealtimeDetectionService) *MedicationHandler {
+func NewMedicationHandler(medService *service.MedicationService, childService *service.ChildService, userService *service.UserService, drugDBService *service.DrugDatabaseService, insightService *service.InsightService, realtimeService *service.RealtimeDetectionService, usageService *service.LogUsageService) *MedicationHandler {
 	return &MedicationHandler{
 		medService:      medService,
 		childService:    childService,
@@ -35,6 +36,7 @@ func NewMedicationHandler(medService *service.MedicationService, childService *s
 		drugDBService:   drugDBService,
 		insightService:  insightService,
 		realtimeService: realtimeService,
+		usageService:    usageService,
 	}
 }
 
@@ -55,7 +57,7 @@ func (h *MedicationHandler) List(w http.ResponseWriter, r *http.Request) {
 	activeOnly := r.URL.Query().Get("active_only") != "false"
 	medications, err := h.medService.GetByChildID(r.Context(), childID, activeOnly)
 	if err != nil {
-		respondInternalError(w, "Failed to get medications")
+		respondServiceError(w, err, "Failed to get medications")
 		return
 	}
 
@@ -123,7 +125,7 @@ func (h *MedicationHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	med, err := h.medService.Create(r.Context(), childID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create medication")
+		respondServiceError(w, err, "Failed to create medication")
 		return
 	}
 
@@ -366,7 +368,12 @@ func (h *MedicationHandler) Log(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setLogETag(w, log.Version)
 	respondCreated(w, log)
+	if h.usageService != nil {
+		platform, _ := middleware.AppClient(r)
+		h.usageService.RecordEntry(r.Context(), "medication", platform)
+	}
 
 	// Trigger missed medication detection if status is "missed"
 	if req.Status == "missed" && h.realtimeService != nil {
@@ -409,7 +416,7 @@ func (h *MedicationHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 
 	logs, err := h.medService.GetLogs(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to get logs")
+		respondServiceError(w, err, "Failed to get logs")
 		return
 	}
 
@@ -448,8 +455,8 @@ func (h *MedicationHandler) UpdateLog(w http.ResponseWriter, r *http.Request) {
 		DosageGiven string           `json:"dosage_given,omitempty"`
 		Notes       string           `json:"notes,omitempty"`
 	}
-	if err := decodeJSON(r, &req); err != nil {
-		respondBadRequest(w, "Invalid request body")
+	version, ok := decodeLogUpdate(w, r, &req)
+	if !ok {
 		return
 	}
 
@@ -461,14 +468,16 @@ func (h *MedicationHandler) UpdateLog(w http.ResponseWriter, r *http.Request) {
 	existingLog.DosageGiven.Valid = req.DosageGiven != ""
 	existingLog.Notes.String = req.Notes
 	existingLog.Notes.Valid = req.Notes != ""
+	existingLog.Version = version
 
 	// Update with tracking for audit log
 	loc := getUserTimezone(r.Context(), h.userService, userID)
 	if err := h.medService.UpdateLogWithTracking(r.Context(), &oldLog, existingLog, userID, loc); err != nil {
 		log.Printf("UpdateLog error: %v", err)
-		respondInternalError(w, "Failed to update medication log")
+		respondLogUpdateError(w, err, "Failed to update medication log")
 		return
 	}
+	setLogETag(w, existingLog.Version)
 
 	respondOK(w, existingLog)
 }
@@ -524,7 +533,7 @@ func (h *MedicationHandler) GetAdherence(w http.ResponseWriter, r *http.Request)
 
 	adherence, err := h.medService.CalculateAdherence(r.Context(), childID, startDate, endDate)
 	if err != nil {
-		respondInternalError(w, "Failed to calculate adherence")
+		respondServiceError(w, err, "Failed to calculate adherence")
 		return
 	}
 
@@ -567,7 +576,7 @@ func (h *MedicationHandler) SearchReferences(w http.ResponseWriter, r *http.Requ
 	// Fall back to local database if FDA API fails
 	refs, err := h.medService.SearchMedicationReferences(r.Context(), query)
 	if err != nil {
-		respondInternalError(w, "Failed to search medications")
+		respondServiceError(w, err, "Failed to search medications")
 		return
 	}
 
@@ -584,7 +593,7 @@ func (h *MedicationHandler) ValidateDrug(w http.ResponseWriter, r *http.Request)
 
 	result, err := h.drugDBService.ValidateMedication(r.Context(), drugName)
 	if err != nil {
-		respondInternalError(w, "Failed to validate medication")
+		respondServiceError(w, err, "Failed to validate medication")
 		return
 	}
 
@@ -603,7 +612,7 @@ func (h *MedicationHandler) GetDrugInfo(w http.ResponseWriter, r *http.Request)
 
 	info, err := h.drugDBService.LookupDrugWithDosage(r.Context(), drugName, dosage)
 	if err != nil {
-		respondInternalError(w, "Failed to get drug information")
+		respondServiceError(w, err, "Failed to get drug information")
 		return
 	}
 
@@ -673,7 +682,7 @@ func (h *MedicationHandler) CheckInteractions(w http.ResponseWriter, r *http.Req
 	// Get active medications for child
 	medications, err := h.medService.GetByChildID(r.Context(), childID, true)
 	if err != nil {
-		respondInternalError(w, "Failed to get medications")
+		respondServiceError(w, err, "Failed to get medications")
 		return
 	}
 
@@ -686,7 +695,7 @@ func (h *MedicationHandler) CheckInteractions(w http.ResponseWriter, r *http.Req
 	// Check interactions
 	warnings, err := h.drugDBService.CheckInteractions(r.Context(), drugNames)
 	if err != nil {
-		respondInternalError(w, "Failed to check interactions")
+		respondServiceError(w, err, "Failed to check interactions")
 		return
 	}
 
@@ -716,7 +725,7 @@ func (h *MedicationHandler) GetMedicalInsights(w http.ResponseWriter, r *http.Re
 	// Get active medications for child
 	medications, err := h.medService.GetByChildID(r.Context(), childID, true)
 	if err != nil {
-		respondInternalError(w, "Failed to get medications")
+		respondServiceError(w, err, "Failed to get medications")
 		return
 	}
 
@@ -1,20 +1,24 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/service"
 )
 
 // OnboardingHandler handles per-user onboarding state transitions.
 type OnboardingHandler struct {
-	userService *service.UserService
+	userService       *service.UserService
+	onboardingService *service.OnboardingService
 }
 
 // NewOnboardingHandler creates a new onboarding handler.
-func NewOnboardingHandler(userService *service.UserService) *OnboardingHandler {
-	return &OnboardingHandler{userService: userService}
+func NewOnboardingHandler(userService *service.UserService, onboardingService *service.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{userService: userService, onboardingService: onboardingService}
 }
 
 // Complete handles POST /api/onboarding/complete — marks the wizard finished.
@@ -56,3 +60,54 @@ func (h *OnboardingHandler) InviteDone(w http.ResponseWriter, r *http.Request) {
 	}
 	respondOK(w, SuccessResponse{Success: true, Message: "Invite step done"})
 }
+
+// respondOnboardingStepError maps guided setup errors to responses.
+func respondOnboardingStepError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrOnboardingStepUnknown):
+		respondNotFound(w, err.Error())
+	case errors.Is(err, service.ErrOnboardingStepRequired):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrOnboardingStepNotDone):
+		respondError(w, err.Error(), http.StatusConflict)
+	default:
+		respondInternalError(w, "Failed to update onboarding")
+	}
+}
+
+// GetSteps handles GET /api/onboarding/steps — the guided setup checklist
+// with each step's status and the next step to show.
+func (h *OnboardingHandler) GetSteps(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	progress, err := h.onboardingService.Progress(r.Context(), userID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load onboarding steps")
+		return
+	}
+	respondOK(w, progress)
+}
+
+// CompleteStep handles POST /api/onboarding/steps/{step}/complete. The
+// server checks the user's data, so this returns 409 if the step isn't
+// actually done yet.
+func (h *OnboardingHandler) CompleteStep(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	progress, err := h.onboardingService.CompleteStep(r.Context(), userID, chi.URLParam(r, "step"))
+	if err != nil {
+		respondOnboardingStepError(w, err)
+		return
+	}
+	respondOK(w, progress)
+}
+
+// SkipStep handles POST /api/onboarding/steps/{step}/skip (optional steps
+// only).
+func (h *OnboardingHandler) SkipStep(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	progress, err := h.onboardingService.SkipStep(r.Context(), userID, chi.URLParam(r, "step"))
+	if err != nil {
+		respondOnboardingStepError(w, err)
+		return
+	}
+	respondOK(w, progress)
+}
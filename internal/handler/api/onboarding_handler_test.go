@@ -3,7 +3,7 @@ package api
 import "testing"
 
 func TestNewOnboardingHandler_Constructs(t *testing.T) {
-	h := NewOnboardingHandler(nil)
+	h := NewOnboardingHandler(nil, nil)
 	if h == nil {
 		t.Fatal("NewOnboardingHandler returned nil")
 	}
@@ -0,0 +1,388 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// OrganizationHandler serves the organization (clinic) endpoints: the
+// staff side under /api/orgs and the family side under
+// /api/family/organization.
+type OrganizationHandler struct {
+	svc     *service.OrganizationService
+	billing *service.OrganizationBillingService
+}
+
+func NewOrganizationHandler(svc *service.OrganizationService, billing *service.OrganizationBillingService) *OrganizationHandler {
+	return &OrganizationHandler{svc: svc, billing: billing}
+}
+
+// authorize parses {orgID} and checks the caller is on its staff (an
+// admin, when adminOnly). It writes the error response itself.
+func (h *OrganizationHandler) authorize(w http.ResponseWriter, r *http.Request, adminOnly bool) (uuid.UUID, string, bool) {
+	orgID, err := parseUUID(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid organization ID")
+		return uuid.Nil, "", false
+	}
+	role, err := h.svc.Authorize(r.Context(), orgID, middleware.GetUserID(r.Context()), adminOnly)
+	switch {
+	case errors.Is(err, service.ErrOrgNotFound):
+		respondNotFound(w, "Organization not found")
+		return uuid.Nil, "", false
+	case errors.Is(err, service.ErrOrgForbidden):
+		respondForbidden(w, "Only organization admins can do that")
+		return uuid.Nil, "", false
+	case err != nil:
+		respondInternalError(w, "Failed to load organization")
+		return uuid.Nil, "", false
+	}
+	return orgID, role, true
+}
+
+// Mine handles GET /api/orgs: the organizations the caller is staff of.
+func (h *OrganizationHandler) Mine(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.svc.ForUser(r.Context(), middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load organizations")
+		return
+	}
+	if orgs == nil {
+		orgs = []repository.OrganizationMembership{}
+	}
+	respondOK(w, map[string]interface{}{"organizations": orgs})
+}
+
+// Get handles GET /api/orgs/{orgID}. Only admins see the join code.
+func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	orgID, role, ok := h.authorize(w, r, false)
+	if !ok {
+		return
+	}
+	org, err := h.svc.Get(r.Context(), orgID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load organization")
+		return
+	}
+	if role != repository.OrgRoleAdmin {
+		org.JoinCode = ""
+	}
+	respondOK(w, repository.OrganizationMembership{Organization: *org, Role: role})
+}
+
+// ListFamilies handles GET /api/orgs/{orgID}/families: the client
+// families with child counts. No family data beyond that is exposed.
+func (h *OrganizationHandler) ListFamilies(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, false)
+	if !ok {
+		return
+	}
+	families, err := h.svc.Families(r.Context(), orgID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load families")
+		return
+	}
+	if families == nil {
+		families = []repository.OrganizationFamily{}
+	}
+	respondOK(w, map[string]interface{}{"families": families})
+}
+
+// RemoveFamily handles DELETE /api/orgs/{orgID}/families/{familyID},
+// freeing the family's seat. Org admins only.
+func (h *OrganizationHandler) RemoveFamily(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, true)
+	if !ok {
+		return
+	}
+	familyID, err := parseUUID(chi.URLParam(r, "familyID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid family ID")
+		return
+	}
+	err = h.svc.RemoveFamily(r.Context(), orgID, familyID)
+	switch {
+	case errors.Is(err, service.ErrOrgFamilyNotLinked):
+		respondNotFound(w, err.Error())
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to remove family")
+		return
+	}
+	respondNoContent(w)
+}
+
+// ListMembers handles GET /api/orgs/{orgID}/members.
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, false)
+	if !ok {
+		return
+	}
+	members, err := h.svc.Members(r.Context(), orgID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load members")
+		return
+	}
+	if members == nil {
+		members = []repository.OrganizationMember{}
+	}
+	respondOK(w, map[string]interface{}{"members": members})
+}
+
+// AddMember handles POST /api/orgs/{orgID}/members, adding an existing
+// app user by email or changing their role. Org admins only.
+func (h *OrganizationHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, true)
+	if !ok {
+		return
+	}
+	var req struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	m, err := h.svc.AddMember(r.Context(), orgID, req.Email, req.Role, middleware.GetUserID(r.Context()))
+	if !respondOrgMemberError(w, err) {
+		return
+	}
+	respondOK(w, m)
+}
+
+// RemoveMember handles DELETE /api/orgs/{orgID}/members/{userID}. Org
+// admins only; members can't remove the last admin.
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, true)
+	if !ok {
+		return
+	}
+	userID, err := parseUUID(chi.URLParam(r, "userID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid user ID")
+		return
+	}
+	if !respondOrgMemberError(w, h.svc.RemoveMember(r.Context(), orgID, userID)) {
+		return
+	}
+	respondNoContent(w)
+}
+
+// respondOrgMemberError maps staff-change errors; false when it wrote one.
+func respondOrgMemberError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrOrgInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrOrgUserNotFound):
+		respondNotFound(w, err.Error())
+	case errors.Is(err, service.ErrOrgLastAdmin):
+		respondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrOrgNotFound):
+		respondNotFound(w, "Organization not found")
+	default:
+		respondInternalError(w, "Failed to update members")
+	}
+	return false
+}
+
+// RotateJoinCode handles POST /api/orgs/{orgID}/join-code. The old code
+// stops working; linked families are unaffected. Org admins only.
+func (h *OrganizationHandler) RotateJoinCode(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, true)
+	if !ok {
+		return
+	}
+	code, err := h.svc.RotateJoinCode(r.Context(), orgID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to rotate join code")
+		return
+	}
+	respondOK(w, map[string]string{"join_code": code})
+}
+
+// Report handles GET /api/orgs/{orgID}/report?days=30: counts across the
+// organization's families, with small cells suppressed.
+func (h *OrganizationHandler) Report(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, false)
+	if !ok {
+		return
+	}
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	rep, err := h.svc.Report(r.Context(), orgID, days)
+	if err != nil {
+		respondServiceError(w, err, "Failed to build report")
+		return
+	}
+	respondOK(w, rep)
+}
+
+// Seats handles GET /api/orgs/{orgID}/seats: seat limit, seats in use,
+// the plan and recent seat changes.
+func (h *OrganizationHandler) Seats(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, false)
+	if !ok {
+		return
+	}
+	sum, err := h.billing.Seats(r.Context(), orgID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load seats")
+		return
+	}
+	respondOK(w, sum)
+}
+
+// AllocateSeats handles POST /api/orgs/{orgID}/seats/allocate with
+// {"count": n}. When the organization pays through Stripe the prorated
+// difference is invoiced immediately. Org admins only.
+func (h *OrganizationHandler) AllocateSeats(w http.ResponseWriter, r *http.Request) {
+	h.changeSeats(w, r, h.billing.Allocate)
+}
+
+// ReleaseSeats handles POST /api/orgs/{orgID}/seats/release with
+// {"count": n}. Seats held by linked families can't be released. Org
+// admins only.
+func (h *OrganizationHandler) ReleaseSeats(w http.ResponseWriter, r *http.Request) {
+	h.changeSeats(w, r, h.billing.Release)
+}
+
+func (h *OrganizationHandler) changeSeats(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, orgID uuid.UUID, n int, by uuid.UUID, source string) (*service.OrgSeatSummary, error)) {
+	orgID, _, ok := h.authorize(w, r, true)
+	if !ok {
+		return
+	}
+	var req struct {
+		Count int `json:"count"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	sum, err := change(r.Context(), orgID, req.Count, middleware.GetUserID(r.Context()), repository.OrgSeatSourceOrgAdmin)
+	if !respondOrgSeatError(w, err) {
+		return
+	}
+	respondOK(w, sum)
+}
+
+// SeatCheckout handles POST /api/orgs/{orgID}/billing/checkout with
+// {"plan_id", "seats"}, returning the Stripe Checkout URL for a seat-based
+// subscription. Org admins only.
+func (h *OrganizationHandler) SeatCheckout(w http.ResponseWriter, r *http.Request) {
+	orgID, _, ok := h.authorize(w, r, true)
+	if !ok {
+		return
+	}
+	var req struct {
+		PlanID string `json:"plan_id"`
+		Seats  int    `json:"seats"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	planID, err := parseUUID(req.PlanID)
+	if err != nil {
+		respondBadRequest(w, "Invalid plan ID")
+		return
+	}
+	url, err := h.billing.Checkout(r.Context(), orgID, planID, req.Seats, "")
+	if !respondOrgSeatError(w, err) {
+		return
+	}
+	respondOK(w, map[string]string{"checkout_url": url})
+}
+
+// respondOrgSeatError maps seat and seat-billing errors; false when it
+// wrote one.
+func respondOrgSeatError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrOrgSeatCount), errors.Is(err, service.ErrOrgSeatPlanInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrOrgSeatsInUse), errors.Is(err, service.ErrOrgAlreadySubscribed):
+		respondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrOrgSeatBillingDisabled):
+		respondError(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, service.ErrOrgSeatBillingFailed):
+		respondError(w, err.Error(), http.StatusBadGateway)
+	case errors.Is(err, service.ErrOrgNotFound):
+		respondNotFound(w, "Organization not found")
+	default:
+		respondInternalError(w, "Failed to update seats")
+	}
+	return false
+}
+
+// GetFamilyOrganization handles GET /api/family/organization.
+func (h *OrganizationHandler) GetFamilyOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := h.svc.FamilyOrganization(r.Context(), middleware.GetFamilyID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load organization")
+		return
+	}
+	respondOK(w, map[string]interface{}{"organization": org})
+}
+
+// JoinOrganization handles POST /api/family/organization with the join
+// code a clinic gave the family. Parents only.
+func (h *OrganizationHandler) JoinOrganization(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can link the family to an organization")
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	familyID := middleware.GetFamilyID(r.Context())
+	org, err := h.svc.JoinByCode(r.Context(), familyID, middleware.GetUserID(r.Context()), req.Code)
+	switch {
+	case errors.Is(err, service.ErrOrgJoinCodeInvalid):
+		respondNotFound(w, err.Error())
+		return
+	case errors.Is(err, service.ErrOrgSeatsFull), errors.Is(err, service.ErrFamilyHasOrg):
+		respondError(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to join organization")
+		return
+	}
+	respondOK(w, map[string]interface{}{"organization": org})
+}
+
+// LeaveOrganization handles DELETE /api/family/organization. The family
+// falls back to its own subscription. Parents only.
+func (h *OrganizationHandler) LeaveOrganization(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can unlink the family from an organization")
+		return
+	}
+	err := h.svc.Leave(r.Context(), middleware.GetFamilyID(r.Context()))
+	switch {
+	case errors.Is(err, service.ErrOrgFamilyNotLinked):
+		respondNotFound(w, "Your family isn't linked to an organization")
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to leave organization")
+		return
+	}
+	respondNoContent(w)
+}
@@ -64,7 +64,7 @@ func (h *PasswordResetHandler) ValidateToken(w http.ResponseWriter, r *http.Requ
 
 	valid, err := h.passwordResetService.ValidateToken(r.Context(), token)
 	if err != nil {
-		respondInternalError(w, "Failed to validate token")
+		respondServiceError(w, err, "Failed to validate token")
 		return
 	}
 
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"errors"
+	stdlog "log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// ProviderAccessHandler serves school and ABA provider access: the
+// family's side under /api/children/{childID}/providers, where parents
+// invite providers and revoke them, and the provider portal under
+// /api/provider, where providers record logs for the children they were
+// granted.
+type ProviderAccessHandler struct {
+	providers    *service.ProviderAccessService
+	childService *service.ChildService
+	userService  *service.UserService
+	// logs records usage and runs realtime detection for provider
+	// entries, as for logs the family enters.
+	logs *LogHandler
+}
+
+func NewProviderAccessHandler(providers *service.ProviderAccessService, childService *service.ChildService, userService *service.UserService, logs *LogHandler) *ProviderAccessHandler {
+	return &ProviderAccessHandler{providers: providers, childService: childService, userService: userService, logs: logs}
+}
+
+func (h *ProviderAccessHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+// parentChild is child for the endpoints that change who has access,
+// which only parents may use.
+func (h *ProviderAccessHandler) parentChild(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can manage provider access")
+		return nil, false
+	}
+	return h.child(w, r)
+}
+
+func (h *ProviderAccessHandler) childGrant(w http.ResponseWriter, r *http.Request, child *models.Child) (*models.ProviderGrant, bool) {
+	id, err := parseUUID(chi.URLParam(r, "grantID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid provider access ID")
+		return nil, false
+	}
+	g, err := h.providers.GetForChild(r.Context(), child.ID, id)
+	if errors.Is(err, service.ErrProviderGrantNotFound) {
+		respondNotFound(w, "Provider access not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load provider access")
+		return nil, false
+	}
+	return g, true
+}
+
+// List handles GET /api/children/{childID}/providers.
+func (h *ProviderAccessHandler) List(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	grants, err := h.providers.ListForChild(r.Context(), child.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load providers")
+		return
+	}
+	if grants == nil {
+		grants = []models.ProviderGrant{}
+	}
+	respondOK(w, map[string]interface{}{"providers": grants})
+}
+
+// Invite handles POST /api/children/{childID}/providers with
+// {"email", "organization_name", "provider_type", "log_types"}.
+func (h *ProviderAccessHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.parentChild(w, r)
+	if !ok {
+		return
+	}
+	var req models.ProviderInviteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	inviterName := "A parent"
+	if claims := middleware.GetAuthClaims(r.Context()); claims != nil && claims.FirstName != "" {
+		inviterName = claims.FirstName
+	}
+	g, err := h.providers.Invite(r.Context(), child, middleware.GetUserID(r.Context()), inviterName, &req)
+	switch {
+	case errors.Is(err, service.ErrProviderInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrProviderGrantExists):
+		respondError(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		respondInternalError(w, "Failed to invite provider")
+	default:
+		respondCreated(w, g)
+	}
+}
+
+// Update handles PUT /api/children/{childID}/providers/{grantID} with
+// {"log_types": [...]}.
+func (h *ProviderAccessHandler) Update(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.parentChild(w, r)
+	if !ok {
+		return
+	}
+	g, ok := h.childGrant(w, r, child)
+	if !ok {
+		return
+	}
+	var req struct {
+		LogTypes []string `json:"log_types"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	err := h.providers.UpdateLogTypes(r.Context(), g, req.LogTypes)
+	if errors.Is(err, service.ErrProviderInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to update provider access")
+		return
+	}
+	respondOK(w, g)
+}
+
+// Revoke handles DELETE /api/children/{childID}/providers/{grantID}.
+func (h *ProviderAccessHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.parentChild(w, r)
+	if !ok {
+		return
+	}
+	g, ok := h.childGrant(w, r, child)
+	if !ok {
+		return
+	}
+	if err := h.providers.Revoke(r.Context(), g, middleware.GetUserID(r.Context())); err != nil {
+		respondInternalError(w, "Failed to revoke provider access")
+		return
+	}
+	respondOK(w, g)
+}
+
+// Entries handles GET /api/children/{childID}/providers/{grantID}/entries,
+// the logs the provider entered for the child.
+func (h *ProviderAccessHandler) Entries(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	g, ok := h.childGrant(w, r, child)
+	if !ok {
+		return
+	}
+	h.respondEntries(w, r, g)
+}
+
+func (h *ProviderAccessHandler) respondEntries(w http.ResponseWriter, r *http.Request, g *models.ProviderGrant) {
+	entries, err := h.providers.Entries(r.Context(), g.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load provider entries")
+		return
+	}
+	if entries == nil {
+		entries = []models.ProviderLogEntry{}
+	}
+	respondOK(w, map[string]interface{}{"entries": entries})
+}
+
+// --- Provider portal ---
+
+// Accept handles POST /api/provider/invitations/accept with {"token"},
+// signed in with the invited address.
+func (h *ProviderAccessHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := decodeJSON(r, &req); err != nil || req.Token == "" {
+		respondBadRequest(w, "token is required")
+		return
+	}
+	userID := middleware.GetUserID(r.Context())
+	user, err := h.userService.GetByID(r.Context(), userID)
+	if err != nil || user == nil {
+		respondInternalError(w, "Failed to load user")
+		return
+	}
+	g, err := h.providers.Accept(r.Context(), userID, user.Email, req.Token)
+	switch {
+	case errors.Is(err, service.ErrProviderInviteInvalid), errors.Is(err, service.ErrProviderInviteExpired):
+		respondError(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrProviderInviteEmail):
+		respondForbidden(w, err.Error())
+	case err != nil:
+		respondInternalError(w, "Failed to accept invitation")
+	default:
+		respondOK(w, g)
+	}
+}
+
+// Me handles GET /api/provider, the provider's branding and the children
+// they can record for.
+func (h *ProviderAccessHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	profile, err := h.providers.Profile(r.Context(), userID)
+	if errors.Is(err, service.ErrProviderProfileMissing) {
+		respondNotFound(w, "You don't have provider access yet")
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to load provider profile")
+		return
+	}
+	grants, err := h.providers.Grants(r.Context(), userID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load provider access")
+		return
+	}
+	if grants == nil {
+		grants = []models.ProviderGrant{}
+	}
+	respondOK(w, map[string]interface{}{"profile": profile, "grants": grants})
+}
+
+// UpdateProfile handles PUT /api/provider/profile.
+func (h *ProviderAccessHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if _, err := h.providers.Profile(r.Context(), userID); errors.Is(err, service.ErrProviderProfileMissing) {
+		respondForbidden(w, "Accept a family's invitation before setting up your profile")
+		return
+	} else if err != nil {
+		respondInternalError(w, "Failed to load provider profile")
+		return
+	}
+	var req models.ProviderProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	p, err := h.providers.SetProfile(r.Context(), userID, &req)
+	if errors.Is(err, service.ErrProviderInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to save provider profile")
+		return
+	}
+	respondOK(w, p)
+}
+
+func (h *ProviderAccessHandler) providerGrant(w http.ResponseWriter, r *http.Request) (*models.ProviderGrant, bool) {
+	id, err := parseUUID(chi.URLParam(r, "grantID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid provider access ID")
+		return nil, false
+	}
+	g, err := h.providers.Grant(r.Context(), middleware.GetUserID(r.Context()), id)
+	if errors.Is(err, service.ErrProviderGrantNotFound) {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load provider access")
+		return nil, false
+	}
+	return g, true
+}
+
+// ProviderEntries handles GET /api/provider/grants/{grantID}/entries.
+func (h *ProviderAccessHandler) ProviderEntries(w http.ResponseWriter, r *http.Request) {
+	if g, ok := h.providerGrant(w, r); ok {
+		h.respondEntries(w, r, g)
+	}
+}
+
+// respondProviderWriteError answers a failed provider log write.
+func respondProviderWriteError(w http.ResponseWriter, err error, logType string) {
+	switch {
+	case errors.Is(err, service.ErrProviderGrantNotFound):
+		respondForbidden(w, "Access denied")
+	case errors.Is(err, service.ErrProviderLogTypeDenied):
+		respondForbidden(w, err.Error())
+	default:
+		stdlog.Printf("Provider %s log error: %v", logType, err)
+		respondInternalError(w, "Failed to create "+logType+" log")
+	}
+}
+
+// CreateBehaviorLog handles POST /api/provider/grants/{grantID}/logs/behavior.
+func (h *ProviderAccessHandler) CreateBehaviorLog(w http.ResponseWriter, r *http.Request) {
+	grantID, err := parseUUID(chi.URLParam(r, "grantID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid provider access ID")
+		return
+	}
+	var req models.CreateBehaviorLogRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	if msg := behaviorLogRequestError(&req); msg != "" {
+		respondBadRequest(w, msg)
+		return
+	}
+	log, g, err := h.providers.CreateBehaviorLog(r.Context(), middleware.GetUserID(r.Context()), grantID, &req)
+	if err != nil {
+		respondProviderWriteError(w, err, "behavior")
+		return
+	}
+	respondCreated(w, log)
+	h.logs.recordUsage(r, "behavior")
+	h.logs.triggerDetection(g.ChildID, "behavior")
+}
+
+// CreateTherapyLog handles POST /api/provider/grants/{grantID}/logs/therapy.
+func (h *ProviderAccessHandler) CreateTherapyLog(w http.ResponseWriter, r *http.Request) {
+	grantID, err := parseUUID(chi.URLParam(r, "grantID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid provider access ID")
+		return
+	}
+	var req models.CreateTherapyLogRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	if msg := therapyLogRequestError(&req); msg != "" {
+		respondBadRequest(w, msg)
+		return
+	}
+	log, g, err := h.providers.CreateTherapyLog(r.Context(), middleware.GetUserID(r.Context()), grantID, &req)
+	if err != nil {
+		respondProviderWriteError(w, err, "therapy")
+		return
+	}
+	respondCreated(w, log)
+	h.logs.recordUsage(r, "therapy")
+	h.logs.triggerDetection(g.ChildID, "therapy")
+}
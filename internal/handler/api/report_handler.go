@@ -95,7 +95,7 @@ func (h *ReportHandler) ListReports(w http.ResponseWriter, r *http.Request) {
 
 	reports, err := h.reportService.ListReports(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to list reports")
+		respondServiceError(w, err, "Failed to list reports")
 		return
 	}
 
@@ -200,7 +200,7 @@ func (h *ReportHandler) ViewReportData(w http.ResponseWriter, r *http.Request) {
 
 	viewData, err := h.reportService.GetViewData(r.Context(), report)
 	if err != nil {
-		respondInternalError(w, "Failed to get report data")
+		respondServiceError(w, err, "Failed to get report data")
 		return
 	}
 
@@ -327,7 +327,7 @@ func (h *ReportHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
 
 	schedules, err := h.reportService.ListSchedules(r.Context(), childID)
 	if err != nil {
-		respondInternalError(w, "Failed to list schedules")
+		respondServiceError(w, err, "Failed to list schedules")
 		return
 	}
 
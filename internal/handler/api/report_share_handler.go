@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// ReportShareHandler shares generated reports with clinicians: creating
+// shares under /api/children/{childID}/reports/{reportID}/shares, the
+// caregiver's list of shares and read receipts under
+// /api/children/{childID}/report-shares, and the shared link itself at
+// /r/share/{shareID}.
+type ReportShareHandler struct {
+	shares        *service.ReportShareService
+	reportService *service.ReportService
+	childService  *service.ChildService
+}
+
+func NewReportShareHandler(shares *service.ReportShareService, reportService *service.ReportService, childService *service.ChildService) *ReportShareHandler {
+	return &ReportShareHandler{shares: shares, reportService: reportService, childService: childService}
+}
+
+// child writes the error response and returns false when the user can't
+// access the child in the URL.
+func (h *ReportShareHandler) child(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return uuid.Nil, false
+	}
+	if _, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context())); err != nil {
+		respondForbidden(w, "Access denied")
+		return uuid.Nil, false
+	}
+	return childID, true
+}
+
+// report loads the child's report named in the URL.
+func (h *ReportShareHandler) report(w http.ResponseWriter, r *http.Request, childID uuid.UUID) (*models.Report, bool) {
+	reportID, err := parseUUID(chi.URLParam(r, "reportID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid report ID")
+		return nil, false
+	}
+	report, err := h.reportService.GetByID(r.Context(), reportID)
+	if err != nil || report == nil || report.ChildID != childID {
+		respondNotFound(w, "Report not found")
+		return nil, false
+	}
+	return report, true
+}
+
+// Create handles POST /api/children/{childID}/reports/{reportID}/shares.
+// The response includes the link; it is also emailed when the request
+// has recipient_email.
+func (h *ReportShareHandler) Create(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	report, ok := h.report(w, r, childID)
+	if !ok {
+		return
+	}
+	var req models.CreateReportShareRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	share, err := h.shares.Share(r.Context(), report, middleware.GetUserID(r.Context()), &req)
+	if errors.Is(err, service.ErrReportShareInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to share report")
+		return
+	}
+	respondCreated(w, share)
+}
+
+// ListForReport handles GET /api/children/{childID}/reports/{reportID}/shares.
+func (h *ReportShareHandler) ListForReport(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	report, ok := h.report(w, r, childID)
+	if !ok {
+		return
+	}
+	shares, err := h.shares.List(r.Context(), childID, &report.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load report shares")
+		return
+	}
+	respondOK(w, map[string]interface{}{"shares": shares})
+}
+
+// List handles GET /api/children/{childID}/report-shares: every report
+// shared for the child, with who opened it and when.
+func (h *ReportShareHandler) List(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	shares, err := h.shares.List(r.Context(), childID, nil)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load report shares")
+		return
+	}
+	respondOK(w, map[string]interface{}{"shares": shares})
+}
+
+// Extend handles POST /api/children/{childID}/report-shares/{shareID}/extend.
+// The link already sent keeps working.
+func (h *ReportShareHandler) Extend(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	shareID, err := parseUUID(chi.URLParam(r, "shareID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid share ID")
+		return
+	}
+	var req models.ExtendReportShareRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	share, err := h.shares.Extend(r.Context(), childID, shareID, req.Days)
+	switch {
+	case errors.Is(err, service.ErrReportShareInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrReportShareNotFound):
+		respondNotFound(w, "Report share not found")
+	case err != nil:
+		respondInternalError(w, "Failed to extend report share")
+	default:
+		respondOK(w, share)
+	}
+}
+
+// Revoke handles DELETE /api/children/{childID}/report-shares/{shareID}.
+// The share and its read receipts are kept; the link stops working.
+func (h *ReportShareHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	childID, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	shareID, err := parseUUID(chi.URLParam(r, "shareID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid share ID")
+		return
+	}
+	err = h.shares.Revoke(r.Context(), childID, shareID)
+	if errors.Is(err, service.ErrReportShareNotFound) {
+		respondNotFound(w, "Report share not found")
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to revoke report share")
+		return
+	}
+	respondNoContent(w)
+}
+
+// ServeShared handles GET /r/share/{shareID}?sig=, the link sent to the
+// recipient. No JWT: the signed URL is the credential. Each successful
+// open is recorded as a read receipt.
+func (h *ReportShareHandler) ServeShared(w http.ResponseWriter, r *http.Request) {
+	shareID, err := parseUUID(chi.URLParam(r, "shareID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid share ID")
+		return
+	}
+	sig := r.URL.Query().Get("sig")
+	if sig == "" {
+		respondBadRequest(w, "Missing signature")
+		return
+	}
+	report, rc, err := h.shares.Open(r.Context(), shareID, sig, clientIP(r), r.UserAgent())
+	switch {
+	case errors.Is(err, service.ErrReportShareNotFound):
+		respondNotFound(w, "Shared report not found")
+		return
+	case errors.Is(err, service.ErrReportShareUnavailable):
+		respondError(w, "This link has expired or been turned off. Ask the family for a new one.", http.StatusGone)
+		return
+	case err != nil:
+		respondNotFound(w, "Report file not available")
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\""+report.Title+".pdf\"")
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("[REPORT] ServeShared io.Copy failed for share %s: %v", shareID, err)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// ResearchConsentHandler lets parents opt the family into (and out of)
+// contributing de-identified data to research.
+type ResearchConsentHandler struct {
+	svc *service.ResearchConsentService
+}
+
+func NewResearchConsentHandler(svc *service.ResearchConsentService) *ResearchConsentHandler {
+	return &ResearchConsentHandler{svc: svc}
+}
+
+// Get handles GET /api/family/research-consent. The disclosure and scope
+// descriptions come with the family's state so the UI can show exactly
+// what is being agreed to.
+func (h *ResearchConsentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	status, err := h.svc.Status(r.Context(), middleware.GetFamilyID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load research consent")
+		return
+	}
+	respondOK(w, status)
+}
+
+// Put handles PUT /api/family/research-consent. It grants consent for the
+// scopes given, replacing the family's current consent; the client must
+// echo the disclosure SHA it showed.
+func (h *ResearchConsentHandler) Put(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can change research participation")
+		return
+	}
+	var req service.ResearchConsentInput
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	familyID := middleware.GetFamilyID(r.Context())
+	_, err := h.svc.Grant(r.Context(), familyID, middleware.GetUserID(r.Context()), req, clientIP(r), r.UserAgent())
+	switch {
+	case errors.Is(err, service.ErrResearchDisclosureChanged):
+		respondError(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrResearchScopeInvalid), errors.Is(err, service.ErrResearchSignatureRequired):
+		respondBadRequest(w, err.Error())
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to record research consent")
+		return
+	}
+	h.respondStatus(w, r)
+}
+
+// Delete handles DELETE /api/family/research-consent, withdrawing the
+// family from research. Data already exported is rebuilt without them.
+func (h *ResearchConsentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can change research participation")
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	// The reason is optional, so an empty body is fine.
+	_ = decodeJSON(r, &req)
+	familyID := middleware.GetFamilyID(r.Context())
+	_, err := h.svc.Withdraw(r.Context(), familyID, middleware.GetUserID(r.Context()), req.Reason)
+	switch {
+	case errors.Is(err, service.ErrResearchConsentNotFound):
+		respondNotFound(w, "Your family isn't taking part in research")
+		return
+	case err != nil:
+		respondInternalError(w, "Failed to withdraw research consent")
+		return
+	}
+	h.respondStatus(w, r)
+}
+
+func (h *ResearchConsentHandler) respondStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.svc.Status(r.Context(), middleware.GetFamilyID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load research consent")
+		return
+	}
+	respondOK(w, status)
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"errors"
+	stdlog "log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// RespiteHandoffHandler serves the babysitter/respite care handoff under
+// /api/children/{childID}/handoff, and the shared copy under
+// /handoff/signed.
+type RespiteHandoffHandler struct {
+	handoffs     *service.RespiteHandoffService
+	childService *service.ChildService
+	userService  *service.UserService
+}
+
+func NewRespiteHandoffHandler(handoffs *service.RespiteHandoffService, childService *service.ChildService, userService *service.UserService) *RespiteHandoffHandler {
+	return &RespiteHandoffHandler{handoffs: handoffs, childService: childService, userService: userService}
+}
+
+func (h *RespiteHandoffHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+// parentChild is child for the profile edits and share links, which hand
+// the child's medications and contacts to someone outside the family and
+// so are for parents only.
+func (h *RespiteHandoffHandler) parentChild(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can change or share the handoff")
+		return nil, false
+	}
+	return h.child(w, r)
+}
+
+// GetProfile handles GET /api/children/{childID}/handoff/profile.
+func (h *RespiteHandoffHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.handoffs.Profile(r.Context(), child.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load handoff profile")
+		return
+	}
+	respondOK(w, p)
+}
+
+// UpdateProfile handles PUT /api/children/{childID}/handoff/profile.
+func (h *RespiteHandoffHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.parentChild(w, r)
+	if !ok {
+		return
+	}
+	var req models.HandoffProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	p, err := h.handoffs.SaveProfile(r.Context(), child.ID, middleware.GetUserID(r.Context()), &req)
+	if errors.Is(err, service.ErrHandoffInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to save handoff profile")
+		return
+	}
+	respondOK(w, p)
+}
+
+// Get handles GET /api/children/{childID}/handoff, the assembled handoff
+// for preview.
+func (h *RespiteHandoffHandler) Get(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	handoff, err := h.handoffs.Build(r.Context(), child)
+	if err != nil {
+		respondServiceError(w, err, "Failed to build handoff")
+		return
+	}
+	respondOK(w, handoff)
+}
+
+// PDF handles GET /api/children/{childID}/handoff/pdf.
+func (h *RespiteHandoffHandler) PDF(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	handoff, err := h.handoffs.Build(r.Context(), child)
+	if err != nil {
+		respondServiceError(w, err, "Failed to build handoff")
+		return
+	}
+	tz := getUserTimezone(r.Context(), h.userService, middleware.GetUserID(r.Context()))
+	h.writePDF(w, handoff, tz.String())
+}
+
+// Share handles POST /api/children/{childID}/handoff/share with an
+// optional {"expires_in_hours": n}, returning a link to the handoff PDF
+// that works without an account until it expires.
+func (h *RespiteHandoffHandler) Share(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.parentChild(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		ExpiresInHours int `json:"expires_in_hours"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondBadRequest(w, "Invalid request body")
+			return
+		}
+	}
+	url, exp, err := h.handoffs.ShareURL(child.ID, time.Duration(req.ExpiresInHours)*time.Hour)
+	if errors.Is(err, service.ErrHandoffLinkTTL) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to create share link")
+		return
+	}
+	respondOK(w, map[string]interface{}{"url": url, "expires_at": exp})
+}
+
+// ServeSigned handles GET /handoff/signed/{childID}?exp=&sig=, the shared
+// handoff PDF. No JWT: the signed URL is the credential, as for seizure
+// summaries.
+func (h *RespiteHandoffHandler) ServeSigned(w http.ResponseWriter, r *http.Request) {
+	expRaw := r.URL.Query().Get("exp")
+	sig := r.URL.Query().Get("sig")
+	if expRaw == "" || sig == "" {
+		respondBadRequest(w, "Missing signature")
+		return
+	}
+	expUnix, err := strconv.ParseInt(expRaw, 10, 64)
+	if err != nil {
+		respondBadRequest(w, "Bad expiry")
+		return
+	}
+	childID, err := parseUUID(chi.URLParam(r, "childID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return
+	}
+	if err := h.handoffs.VerifySignedHandoff(childID, expUnix, sig); err != nil {
+		respondError(w, "Link expired or invalid", http.StatusForbidden)
+		return
+	}
+	handoff, err := h.handoffs.SignedHandoff(r.Context(), childID)
+	if errors.Is(err, service.ErrHandoffNotFound) {
+		respondNotFound(w, "Handoff not found")
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to build handoff")
+		return
+	}
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	h.writePDF(w, handoff, "")
+}
+
+func (h *RespiteHandoffHandler) writePDF(w http.ResponseWriter, handoff *models.RespiteHandoff, timezone string) {
+	pdf, err := service.RespiteHandoffPDF(handoff, timezone)
+	if err != nil {
+		stdlog.Printf("[HANDOFF] PDF for child %s: %v", handoff.ChildID, err)
+		respondInternalError(w, "Failed to generate handoff")
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\"care-handoff.pdf\"")
+	w.Write(pdf)
+}
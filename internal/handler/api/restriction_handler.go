@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// RestrictionHandler serves a child's allergy and dietary restriction
+// registry under /api/children/{childID}/restrictions.
+type RestrictionHandler struct {
+	restrictions *service.RestrictionService
+	childService *service.ChildService
+}
+
+func NewRestrictionHandler(restrictions *service.RestrictionService, childService *service.ChildService) *RestrictionHandler {
+	return &RestrictionHandler{restrictions: restrictions, childService: childService}
+}
+
+func (h *RestrictionHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+func (h *RestrictionHandler) restriction(w http.ResponseWriter, r *http.Request, child *models.Child) (*models.ChildRestriction, bool) {
+	id, err := parseUUID(chi.URLParam(r, "restrictionID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid restriction ID")
+		return nil, false
+	}
+	cr, err := h.restrictions.Get(r.Context(), child.ID, id)
+	if errors.Is(err, service.ErrRestrictionNotFound) {
+		respondNotFound(w, "Restriction not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load restriction")
+		return nil, false
+	}
+	return cr, true
+}
+
+// respondRestrictionSaveError writes the response for a failed create or
+// update.
+func respondRestrictionSaveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrRestrictionInvalid):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrRestrictionExists):
+		respondError(w, err.Error(), http.StatusConflict)
+	default:
+		respondInternalError(w, "Failed to save restriction")
+	}
+}
+
+// List handles GET /api/children/{childID}/restrictions.
+func (h *RestrictionHandler) List(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.restrictions.List(r.Context(), child.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load restrictions")
+		return
+	}
+	respondOK(w, map[string]interface{}{"restrictions": list})
+}
+
+// Create handles POST /api/children/{childID}/restrictions.
+func (h *RestrictionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.ChildRestrictionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	cr, err := h.restrictions.Create(r.Context(), child.ID, middleware.GetUserID(r.Context()), &req)
+	if err != nil {
+		respondRestrictionSaveError(w, err)
+		return
+	}
+	respondCreated(w, cr)
+}
+
+// Get handles GET /api/children/{childID}/restrictions/{restrictionID}.
+func (h *RestrictionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	if cr, ok := h.restriction(w, r, child); ok {
+		respondOK(w, cr)
+	}
+}
+
+// Update handles PUT /api/children/{childID}/restrictions/{restrictionID}.
+func (h *RestrictionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	cr, ok := h.restriction(w, r, child)
+	if !ok {
+		return
+	}
+	var req models.ChildRestrictionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	if err := h.restrictions.Update(r.Context(), cr, &req); err != nil {
+		respondRestrictionSaveError(w, err)
+		return
+	}
+	respondOK(w, cr)
+}
+
+// Delete handles DELETE /api/children/{childID}/restrictions/{restrictionID}.
+func (h *RestrictionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	cr, ok := h.restriction(w, r, child)
+	if !ok {
+		return
+	}
+	if err := h.restrictions.Delete(r.Context(), cr.ID); err != nil {
+		respondInternalError(w, "Failed to delete restriction")
+		return
+	}
+	respondOK(w, map[string]string{"message": "Restriction deleted"})
+}
+
+// Check handles POST /api/children/{childID}/restrictions/check: the
+// foods in {"foods": [...]} that match the registry, so the diet log form
+// can warn before it is saved.
+func (h *RestrictionHandler) Check(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Foods []string `json:"foods"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	if len(req.Foods) > 100 {
+		respondBadRequest(w, "At most 100 foods can be checked at once")
+		return
+	}
+	list, err := h.restrictions.List(r.Context(), child.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load restrictions")
+		return
+	}
+	matches := service.MatchRestrictions(list, req.Foods)
+	if matches == nil {
+		matches = []models.RestrictionMatch{}
+	}
+	respondOK(w, map[string]interface{}{"matches": matches})
+}
@@ -33,55 +33,122 @@ type Handlers struct {
 	AccountDeletion *AccountDeletionHandler
 	NarrativeConsent *NarrativeConsentHandler
 	Onboarding       *OnboardingHandler
+	HelpCenter       *HelpCenterHandler
+	Feedback         *FeedbackHandler
+	Image            *ImageHandler
+	Batch            *BatchHandler
+	EmailVerification *EmailVerificationHandler
+	ClientConfig      *ClientConfigHandler
+	Legal             *LegalHandler
+	Research          *ResearchConsentHandler
+	Organization      *OrganizationHandler
+	Invoice           *InvoiceHandler
+	ExitPackage       *ExitPackageHandler
+	SeizureEvent      *SeizureEventHandler
+	TherapyGoal       *TherapyGoalHandler
+	ProviderAccess    *ProviderAccessHandler
+	Routine           *RoutineHandler
+	RespiteHandoff    *RespiteHandoffHandler
+	ChildComparison   *ChildComparisonHandler
+	Annotation        *AnnotationHandler
+	Intervention      *InterventionHandler
+	Restriction       *RestrictionHandler
+	SleepSync         *SleepSyncHandler
+	Rubric            *RubricHandler
+	FoodPhoto         *FoodPhotoHandler
+	ReportShare       *ReportShareHandler
+	LogDraft          *LogDraftHandler
+	LogTemplate       *LogTemplateHandler
+	Gamification      *GamificationHandler
 }
 
 // NewHandlers creates all API handlers
 func NewHandlers(services *service.Services, cfg *config.Config) *Handlers {
+	logHandler := NewLogHandler(services.Log, services.Child, services.User, services.RealtimeDetection, services.Transparency, services.LogUsage)
 	return &Handlers{
-		Auth:         NewAuthHandler(services.Auth, services.AdminRepo, cfg.App.Env),
+		Auth:         NewAuthHandler(services.Auth, services.AdminRepo, services.Legal, cfg.App.Env),
 		Child:        NewChildHandler(services.Child),
 		Family:       NewFamilyHandler(services.Family, services.User, services.Email, services.Push, cfg.App.URL),
-		Medication:   NewMedicationHandler(services.Medication, services.Child, services.User, services.DrugDatabase, services.Insight, services.RealtimeDetection),
-		Log:          NewLogHandler(services.Log, services.Child, services.User, services.RealtimeDetection, services.Transparency),
+		Medication:   NewMedicationHandler(services.Medication, services.Child, services.User, services.DrugDatabase, services.Insight, services.RealtimeDetection, services.LogUsage),
+		Log:          logHandler,
 		Alert:        NewAlertHandler(services.Alert, services.Child),
 		Correlation:  NewCorrelationHandler(services.Correlation, services.Child),
 		Insight:      NewInsightHandler(services.Insight, services.Child),
 		Chat:         NewChatHandler(services.Chat, services.Family, services.Push, &cfg.Storage, services.ChatHub),
 		Transparency: NewTransparencyHandler(services.Transparency),
-		Support:      NewSupportHandler(services.UserSupport, services.TicketAttachment),
-		Billing:       NewBillingHandler(services.Billing),
+		Support:      NewSupportHandler(services.UserSupport, services.TicketAttachment, services.KnowledgeBase),
+		Billing:       NewBillingHandler(services.Billing, services.Tax, services.PlanChanges, services.PromoCodes),
 		PasswordReset: NewPasswordResetHandler(services.PasswordReset),
-		Device:        NewDeviceHandler(services.Push, &cfg.App),
+		Device:        NewDeviceHandler(services.Push, services.AppVersion, services.ClientConfig, &cfg.App),
 		User:          NewUserHandler(services.User),
 		Report:        NewReportHandler(services.Report, services.Child),
 		Search:        NewSearchHandler(services.Search),
 		AccountDeletion: NewAccountDeletionHandler(services.AccountDeletion, services.AccountDeletionRepo),
 		NarrativeConsent: NewNarrativeConsentHandler(services.AINarrativeConsent),
-		Onboarding:       NewOnboardingHandler(services.User),
+		Onboarding:       NewOnboardingHandler(services.User, services.Onboarding),
+		HelpCenter:       NewHelpCenterHandler(services.KnowledgeBase),
+		Feedback:         NewFeedbackHandler(services.Feedback),
+		Image:            NewImageHandler(services.Images, services.Child),
+		Batch:            NewBatchHandler(),
+		EmailVerification: NewEmailVerificationHandler(services.EmailVerification),
+		ClientConfig:      NewClientConfigHandler(services.ClientConfig),
+		Legal:             NewLegalHandler(services.Legal),
+		Research:          NewResearchConsentHandler(services.Research),
+		Organization:      NewOrganizationHandler(services.Organizations, services.OrgBilling),
+		Invoice:           NewInvoiceHandler(services.Invoices, services.Organizations),
+		ExitPackage:       NewExitPackageHandler(services.ExitPackages),
+		SeizureEvent:      NewSeizureEventHandler(services.SeizureEvents, services.Child, logHandler),
+		TherapyGoal:       NewTherapyGoalHandler(services.TherapyGoals, services.Child, services.User),
+		ProviderAccess:    NewProviderAccessHandler(services.ProviderAccess, services.Child, services.User, logHandler),
+		Routine:           NewRoutineHandler(services.Routines, services.Child, services.User),
+		RespiteHandoff:    NewRespiteHandoffHandler(services.RespiteHandoffs, services.Child, services.User),
+		ChildComparison:   NewChildComparisonHandler(services.ChildComparison, services.User),
+		Annotation:        NewAnnotationHandler(services.Annotations, services.Child, services.User),
+		Intervention:      NewInterventionHandler(services.Interventions, services.Child, services.User),
+		Restriction:       NewRestrictionHandler(services.Restrictions, services.Child),
+		SleepSync:         NewSleepSyncHandler(services.SleepSync, services.Child, services.User),
+		Rubric:            NewRubricHandler(services.Rubrics, services.Child),
+		FoodPhoto:         NewFoodPhotoHandler(services.FoodPhotos, services.Child),
+		ReportShare:       NewReportShareHandler(services.ReportShares, services.Report, services.Child),
+		LogDraft:          NewLogDraftHandler(services.LogDrafts, services.Child),
+		LogTemplate:       NewLogTemplateHandler(services.LogTemplates, logHandler, services.Child),
+		Gamification:      NewGamificationHandler(services.Gamification),
 	}
 }
 
 // SetupRoutes configures all API routes. db is required for the
-// entitlement middleware that reads family_subscriptions per request.
-func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthService, db *sql.DB) {
+// entitlement middleware that reads family_subscriptions per request;
+// captcha guards signup, password reset and promo code validation.
+func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthService, captcha *service.CaptchaService, db *sql.DB) {
 	// Public routes
 	r.Group(func(r chi.Router) {
-		r.Post("/auth/register", handlers.Auth.Register)
+		r.With(middleware.RequireCaptcha(captcha, service.CaptchaActionRegister)).Post("/auth/register", handlers.Auth.Register)
 		r.Post("/auth/login", handlers.Auth.Login)
 		r.Post("/auth/refresh", handlers.Auth.RefreshToken)
 
 		// App config (public, used by mobile app)
 		r.Get("/app/config", handlers.Device.GetAppConfig)
+		r.Get("/app/version-check", handlers.Device.VersionCheck)
+
+		// Remote client config (public, cached; see ClientConfigHandler)
+		r.Get("/client-config", handlers.ClientConfig.Get)
+
+		// Terms of Service / Privacy Policy in effect (public, for signup)
+		r.Get("/legal/documents", handlers.Legal.Documents)
 
 		// Password reset (public, no auth required)
 		// Rate limited: 5 requests per minute per IP to prevent brute-force and email flooding
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RateLimit(5, 1*time.Minute))
-			r.Post("/auth/request-reset", handlers.PasswordReset.RequestReset)
+			r.With(middleware.RequireCaptcha(captcha, service.CaptchaActionPasswordReset)).Post("/auth/request-reset", handlers.PasswordReset.RequestReset)
 			r.Get("/auth/validate-reset-token", handlers.PasswordReset.ValidateToken)
-			r.Post("/auth/reset-password", handlers.PasswordReset.ResetPassword)
+			r.With(middleware.RequireCaptcha(captcha, service.CaptchaActionPasswordReset)).Post("/auth/reset-password", handlers.PasswordReset.ResetPassword)
 		})
 
+		// Email verification link. Public — the token is the credential.
+		// Rate limited like password reset for token-guessing defense.
+		r.With(middleware.RateLimit(10, 1*time.Minute)).Post("/auth/verify-email", handlers.EmailVerification.Verify)
+
 		// Account restore via email-link token. Public — the user is signed
 		// out (we revoked their sessions when the deletion was confirmed) and
 		// the token IS the credential. Rate-limited the same way as password
@@ -90,12 +157,25 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 			r.Use(middleware.RateLimit(10, 1*time.Minute))
 			r.Get("/account/deletion/restore", handlers.AccountDeletion.RestoreByToken)
 		})
+
+		// Help center (published knowledge-base articles). Public so the
+		// marketing site and signed-out app screens can link to it; search
+		// is rate limited since it hits full-text indexes.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RateLimit(60, 1*time.Minute))
+			r.Get("/help/categories", handlers.HelpCenter.ListCategories)
+			r.Get("/help/articles", handlers.HelpCenter.SearchArticles)
+			r.Get("/help/articles/{slug}", handlers.HelpCenter.GetArticle)
+		})
 	})
 
 	// Protected routes
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware(authService))
 		r.Use(middleware.LoadEntitlement(db))
+		// Users who haven't accepted the current terms can only accept
+		// them, sign out, or delete their account.
+		r.Use(middleware.RequireLegalConsent(db, "/api/auth/", "/api/users/me/legal", "/api/account/"))
 
 		// Auth routes
 		r.Post("/auth/logout", handlers.Auth.Logout)
@@ -103,10 +183,23 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 		r.Get("/auth/me", handlers.Auth.Me)
 		r.Post("/auth/switch-family", handlers.Auth.SwitchFamily)
 
+		// Batched GETs for the mobile dashboard. Each embedded request is
+		// routed through this router again with the caller's credentials.
+		r.Post("/batch", handlers.Batch.Handle)
+
 		// User profile and password
 		r.Patch("/users/profile", handlers.User.UpdateProfile)
 		r.Post("/users/password", handlers.User.ChangePassword)
 
+		// Email verification status + resend. Resend is also limited per
+		// user by the service (1/minute, 5/day).
+		r.Get("/users/me/email-verification", handlers.EmailVerification.GetStatus)
+		r.With(middleware.RateLimit(5, 1*time.Minute)).Post("/users/me/email-verification/resend", handlers.EmailVerification.Resend)
+
+		// Terms / privacy acceptance (exempt from RequireLegalConsent)
+		r.Get("/users/me/legal", handlers.Legal.GetStatus)
+		r.Post("/users/me/legal/accept", handlers.Legal.Accept)
+
 		// Account deletion — user-initiated flow per App Store 5.1.1(v).
 		// Status read + request-OTP + confirm-with-OTP, all behind auth.
 		r.Route("/account", func(r chi.Router) {
@@ -128,10 +221,14 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 		// Family routes - require family context
 		r.Route("/family", func(r chi.Router) {
 			r.Use(middleware.RequireFamilyContext())
+			r.Use(middleware.FamilyScope())
 			r.Get("/info", handlers.Family.GetInfo)
 			r.Delete("/me", handlers.Family.Leave)
 			r.Get("/members", handlers.Family.ListMembers)
-			r.Post("/members", handlers.Family.AddMember)
+			// Invites, report sharing and scheduled reports email other
+			// people, so they need a verified address once the grace
+			// period is over.
+			r.With(middleware.RequireVerifiedEmail(db)).Post("/members", handlers.Family.AddMember)
 			r.Post("/members/lookup", handlers.Family.LookupUser)
 			r.Get("/members/{memberID}", handlers.Family.GetMember)
 			r.Patch("/members/{memberID}", handlers.Family.UpdateMemberRole)
@@ -140,16 +237,88 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 			// Billing routes (family context required)
 			r.Get("/billing", handlers.Billing.GetFamilyBilling)
 			r.Get("/billing/can-add-child", handlers.Billing.CanAddChild)
+			r.Get("/billing/change-plan/preview", handlers.Billing.PreviewPlanChange)
+			r.Post("/billing/change-plan", handlers.Billing.ChangePlan)
+			r.Get("/billing/plan-changes", handlers.Billing.PlanChanges)
+			r.With(middleware.RequireCaptcha(captcha, service.CaptchaActionPromoValidation)).Post("/billing/promo-codes/validate", handlers.Billing.ValidatePromoCode)
+			r.Get("/billing/invoices", handlers.Invoice.FamilyInvoices)
+			r.Get("/billing/invoices/{invoiceID}/pdf", handlers.Invoice.FamilyInvoicePDF)
+			r.Get("/billing/exit-packages", handlers.ExitPackage.FamilyExitPackages)
+			r.Get("/billing/exit-packages/{packageID}/download", handlers.ExitPackage.FamilyExitPackageDownload)
+
+			// Metric series across the family's children on the same
+			// dates. Every child must be in this family.
+			r.Get("/children/compare", handlers.ChildComparison.Compare)
+
+			// Research participation (de-identified data opt-in)
+			r.Get("/research-consent", handlers.Research.Get)
+			r.Put("/research-consent", handlers.Research.Put)
+			r.Delete("/research-consent", handlers.Research.Delete)
+
+			// Scoring rubric behind mood, energy and anxiety levels;
+			// saving adds a version
+			r.Get("/rubric", handlers.Rubric.Get)
+			r.Put("/rubric", handlers.Rubric.Put)
+
+			// Pre-filled log entries ("school day breakfast") any of the
+			// family's caregivers can log in one call
+			r.Route("/log-templates", func(r chi.Router) {
+				r.Get("/", handlers.LogTemplate.List)
+				r.Post("/", handlers.LogTemplate.Create)
+				r.Put("/{templateID}", handlers.LogTemplate.Update)
+				r.Delete("/{templateID}", handlers.LogTemplate.Delete)
+			})
+
+			// Organization (clinic) link, joined with the clinic's code
+			r.Get("/organization", handlers.Organization.GetFamilyOrganization)
+			r.Post("/organization", handlers.Organization.JoinOrganization)
+			r.Delete("/organization", handlers.Organization.LeaveOrganization)
+		})
+
+		// Organization staff. Membership and role are checked per request;
+		// staff see client-family counts and aggregates, never family logs.
+		r.Route("/orgs", func(r chi.Router) {
+			r.Get("/", handlers.Organization.Mine)
+			r.Route("/{orgID}", func(r chi.Router) {
+				r.Get("/", handlers.Organization.Get)
+				r.Get("/families", handlers.Organization.ListFamilies)
+				r.Delete("/families/{familyID}", handlers.Organization.RemoveFamily)
+				r.Get("/members", handlers.Organization.ListMembers)
+				r.Post("/members", handlers.Organization.AddMember)
+				r.Delete("/members/{userID}", handlers.Organization.RemoveMember)
+				r.Post("/join-code", handlers.Organization.RotateJoinCode)
+				r.Get("/report", handlers.Organization.Report)
+				r.Get("/seats", handlers.Organization.Seats)
+				r.Post("/seats/allocate", handlers.Organization.AllocateSeats)
+				r.Post("/seats/release", handlers.Organization.ReleaseSeats)
+				r.Post("/billing/checkout", handlers.Organization.SeatCheckout)
+				r.Get("/invoices", handlers.Invoice.OrganizationInvoices)
+				r.Get("/invoices/{invoiceID}/pdf", handlers.Invoice.OrganizationInvoicePDF)
+			})
+		})
+
+		// Provider portal for schools and ABA providers. No family context:
+		// every request is checked against the provider's grants, each
+		// scoped to one child and the log types the family shared.
+		r.Route("/provider", func(r chi.Router) {
+			r.Get("/", handlers.ProviderAccess.Me)
+			r.Put("/profile", handlers.ProviderAccess.UpdateProfile)
+			r.Post("/invitations/accept", handlers.ProviderAccess.Accept)
+			r.Get("/grants/{grantID}/entries", handlers.ProviderAccess.ProviderEntries)
+			r.Post("/grants/{grantID}/logs/behavior", handlers.ProviderAccess.CreateBehaviorLog)
+			r.Post("/grants/{grantID}/logs/therapy", handlers.ProviderAccess.CreateTherapyLog)
 		})
 
 		// Billing routes - public plans endpoint (no family context required)
 		r.Get("/billing/plans", handlers.Billing.GetPlans)
+		r.Get("/billing/prices", handlers.Billing.GetLocalizedPrices)
 
 		// Child routes - require family context. Writes (POST) are also
 		// gated by subscription entitlement — read-only families can list
 		// children but can't add new ones.
 		r.Route("/children", func(r chi.Router) {
 			r.Use(middleware.RequireFamilyContext())
+			r.Use(middleware.FamilyScope())
 			r.Use(middleware.EnforceWriteEntitlement())
 			r.Get("/", handlers.Child.List)
 			r.Post("/", handlers.Child.Create)
@@ -157,8 +326,10 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 
 		// Child-specific routes — entitlement gated. DELETE always passes
 		// in read-only mode (data deletion right). Writes other than DELETE
-		// (PUT/PATCH/POST) are blocked when read_only or blocked.
+		// (PUT/PATCH/POST) are blocked when read_only or blocked. Scoped
+		// to the child's own family, which may not be the token's.
 		r.Route("/children/{childID}", func(r chi.Router) {
+			r.Use(middleware.ChildFamilyScope(handlers.Child.childService))
 			r.Use(middleware.EnforceWriteEntitlement())
 			r.Get("/", handlers.Child.Get)
 			r.Put("/", handlers.Child.Update)
@@ -167,6 +338,11 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 			r.Get("/dashboard/insights", handlers.Alert.DashboardInsights)
 			r.Get("/treatment-changes", handlers.Transparency.GetTreatmentChangesByDate)
 
+			// Photo — responsive variants, EXIF stripped
+			r.Get("/photo", handlers.Image.GetChildPhoto)
+			r.Post("/photo", handlers.Image.UploadChildPhoto)
+			r.Delete("/photo", handlers.Image.DeleteChildPhoto)
+
 			// Conditions
 			r.Get("/conditions", handlers.Child.GetConditions)
 			r.Post("/conditions", handlers.Child.AddCondition)
@@ -225,6 +401,8 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 				r.Post("/diet", handlers.Log.CreateDietLog)
 				r.Put("/diet/{id}", handlers.Log.UpdateDietLog)
 				r.Delete("/diet/{id}", handlers.Log.DeleteDietLog)
+				r.Post("/diet/photo-suggestions", handlers.FoodPhoto.Suggest)
+				r.Post("/templates/{templateID}", handlers.LogTemplate.Apply)
 
 				// Weight logs
 				r.Get("/weight", handlers.Log.GetWeightLogs)
@@ -269,6 +447,117 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 				r.Delete("/health/{id}", handlers.Log.DeleteHealthEventLog)
 			})
 
+			// Seizure emergency mode — live timer whose stop writes a
+			// seizure log
+			r.Route("/seizure-events", func(r chi.Router) {
+				r.Get("/", handlers.SeizureEvent.List)
+				r.Get("/active", handlers.SeizureEvent.Active)
+				r.Post("/start", handlers.SeizureEvent.Start)
+				r.Get("/{eventID}", handlers.SeizureEvent.Get)
+				r.Post("/{eventID}/checklist", handlers.SeizureEvent.Check)
+				r.Post("/{eventID}/stop", handlers.SeizureEvent.Stop)
+				r.Get("/{eventID}/summary", handlers.SeizureEvent.Summary)
+				r.Post("/{eventID}/share", handlers.SeizureEvent.Share)
+			})
+
+			// Therapy goals — therapy logs rate progress on them
+			r.Route("/therapy-goals", func(r chi.Router) {
+				r.Get("/", handlers.TherapyGoal.List)
+				r.Post("/", handlers.TherapyGoal.Create)
+				r.Get("/trends", handlers.TherapyGoal.Trends)
+				r.Post("/progress-report", handlers.TherapyGoal.ProgressReport)
+				r.Get("/{goalID}", handlers.TherapyGoal.Get)
+				r.Put("/{goalID}", handlers.TherapyGoal.Update)
+				r.Delete("/{goalID}", handlers.TherapyGoal.Delete)
+				r.Get("/{goalID}/trend", handlers.TherapyGoal.Trend)
+			})
+
+			// Daily routines (visual schedules)
+			r.Route("/routines", func(r chi.Router) {
+				r.Get("/", handlers.Routine.List)
+				r.Post("/", handlers.Routine.Create)
+				r.Get("/day", handlers.Routine.Day)
+				r.Get("/stats", handlers.Routine.Stats)
+				r.Get("/printable", handlers.Routine.Printable)
+				r.Get("/{routineID}", handlers.Routine.Get)
+				r.Put("/{routineID}", handlers.Routine.Update)
+				r.Delete("/{routineID}", handlers.Routine.Delete)
+				r.Put("/{routineID}/steps/{stepID}/done", handlers.Routine.CompleteStep)
+				r.Delete("/{routineID}/steps/{stepID}/done", handlers.Routine.CompleteStep)
+			})
+
+			// Babysitter/respite care handoff document
+			r.Route("/handoff", func(r chi.Router) {
+				r.Get("/", handlers.RespiteHandoff.Get)
+				r.Get("/pdf", handlers.RespiteHandoff.PDF)
+				r.Get("/profile", handlers.RespiteHandoff.GetProfile)
+				r.Put("/profile", handlers.RespiteHandoff.UpdateProfile)
+				r.Post("/share", handlers.RespiteHandoff.Share)
+			})
+
+			// Dated annotations and milestone markers on the charts
+			r.Route("/annotations", func(r chi.Router) {
+				r.Get("/", handlers.Annotation.List)
+				r.Post("/", handlers.Annotation.Create)
+				r.Get("/{annotationID}", handlers.Annotation.Get)
+				r.Put("/{annotationID}", handlers.Annotation.Update)
+				r.Delete("/{annotationID}", handlers.Annotation.Delete)
+			})
+
+			// Intervention experiments and their before/during/after evaluation
+			r.Route("/interventions", func(r chi.Router) {
+				r.Get("/", handlers.Intervention.List)
+				r.Post("/", handlers.Intervention.Create)
+				r.Get("/{interventionID}", handlers.Intervention.Get)
+				r.Put("/{interventionID}", handlers.Intervention.Update)
+				r.Delete("/{interventionID}", handlers.Intervention.Delete)
+				r.Get("/{interventionID}/evaluation", handlers.Intervention.Evaluate)
+			})
+
+			// Allergy and dietary restriction registry; diet logs are
+			// screened against it
+			r.Route("/restrictions", func(r chi.Router) {
+				r.Get("/", handlers.Restriction.List)
+				r.Post("/", handlers.Restriction.Create)
+				r.Post("/check", handlers.Restriction.Check)
+				r.Get("/{restrictionID}", handlers.Restriction.Get)
+				r.Put("/{restrictionID}", handlers.Restriction.Update)
+				r.Delete("/{restrictionID}", handlers.Restriction.Delete)
+			})
+
+			// Device sleep imports, reconciled nightly against manual sleep
+			// logs using the child's preferred source
+			r.Route("/sleep-sync", func(r chi.Router) {
+				r.Get("/records", handlers.SleepSync.ListRecords)
+				r.Post("/records", handlers.SleepSync.Import)
+				r.Get("/preference", handlers.SleepSync.GetPreference)
+				r.Put("/preference", handlers.SleepSync.UpdatePreference)
+				r.Post("/reconcile", handlers.SleepSync.Reconcile)
+			})
+
+			// The family's scoring rubric, for labelling levels while logging
+			r.Get("/rubric", handlers.Rubric.ForChild)
+
+			// The caller's autosaved log forms, one per log type, so an
+			// unfinished entry can be resumed on any device
+			r.Route("/log-drafts", func(r chi.Router) {
+				r.Get("/", handlers.LogDraft.List)
+				r.Get("/{logType}", handlers.LogDraft.Get)
+				r.Put("/{logType}", handlers.LogDraft.Save)
+				r.Delete("/{logType}", handlers.LogDraft.Delete)
+				r.Post("/{logType}/from-previous", handlers.LogDraft.FromPrevious)
+			})
+
+			// School/ABA providers recording for this child; parents
+			// invite and revoke them
+			r.Route("/providers", func(r chi.Router) {
+				r.Get("/", handlers.ProviderAccess.List)
+				r.With(middleware.RequireVerifiedEmail(db)).Post("/", handlers.ProviderAccess.Invite)
+				r.Put("/{grantID}", handlers.ProviderAccess.Update)
+				r.Delete("/{grantID}", handlers.ProviderAccess.Revoke)
+				r.Get("/{grantID}/entries", handlers.ProviderAccess.Entries)
+			})
+
 			// Alerts
 			r.Route("/alerts", func(r chi.Router) {
 				r.Get("/", handlers.Alert.List)
@@ -316,7 +605,7 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 			r.Route("/reports", func(r chi.Router) {
 				r.Post("/generate", handlers.Report.GenerateReport)
 				r.Get("/", handlers.Report.ListReports)
-				r.Post("/schedules", handlers.Report.CreateSchedule)
+				r.With(middleware.RequireVerifiedEmail(db)).Post("/schedules", handlers.Report.CreateSchedule)
 				r.Get("/schedules", handlers.Report.ListSchedules)
 				r.Delete("/schedules/{scheduleID}", handlers.Report.DeleteSchedule)
 
@@ -325,10 +614,20 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 					r.Get("/download", handlers.Report.DownloadReport)
 					r.Get("/view", handlers.Report.ViewReportData)
 					r.Get("/sign-url", handlers.Report.GetSignedURL)
-					r.Post("/share", handlers.Report.ShareReport)
+					r.With(middleware.RequireVerifiedEmail(db)).Post("/share", handlers.Report.ShareReport)
+					r.Get("/shares", handlers.ReportShare.ListForReport)
+					r.With(middleware.RequireVerifiedEmail(db)).Post("/shares", handlers.ReportShare.Create)
 					r.Delete("/", handlers.Report.DeleteReport)
 				})
 			})
+
+			// Reports shared with clinicians: who has a link, and who
+			// opened what and when.
+			r.Route("/report-shares", func(r chi.Router) {
+				r.Get("/", handlers.ReportShare.List)
+				r.Post("/{shareID}/extend", handlers.ReportShare.Extend)
+				r.Delete("/{shareID}", handlers.ReportShare.Revoke)
+			})
 		})
 
 		r.Route("/correlations/{correlationID}", func(r chi.Router) {
@@ -338,12 +637,16 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 		// Global search (requires family context)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireFamilyContext())
+			r.Use(middleware.FamilyScope())
 			r.Get("/search", handlers.Search.Search)
 		})
 
 		// Report file serving
 		r.Get("/reports/{reportID}/file", handlers.Report.ServeReportPDF)
 
+		// Log amendment history, for any log type
+		r.Get("/logs/{logType}/{id}/history", handlers.Log.GetLogHistory)
+
 		// Medication reference search
 		r.Get("/medication-references", handlers.Medication.SearchReferences)
 
@@ -358,6 +661,7 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 		// for new messages (read-only families can still see history).
 		r.Route("/chat", func(r chi.Router) {
 			r.Use(middleware.RequireFamilyContext())
+			r.Use(middleware.FamilyScope())
 			r.Use(middleware.EnforceWriteEntitlement())
 			r.Get("/threads", handlers.Chat.ListThreads)
 			r.Post("/threads", handlers.Chat.CreateThread)
@@ -403,6 +707,11 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 		r.Get("/users/me/preferences", handlers.Family.GetUserPreferences)
 		r.Put("/users/me/preferences", handlers.Family.UpdateUserPreferences)
 
+		// Logging streaks, badges and progress, with the opt-out and the
+		// weekly digest setting
+		r.Get("/users/me/gamification", handlers.Gamification.Get)
+		r.Put("/users/me/gamification", handlers.Gamification.Update)
+
 		// AI narrative consent — Phase 3 opt-in for free-text fields in
 		// outbound LLM calls. Returns disclosure text + version alongside
 		// state so the Settings UI can render the agreement inline.
@@ -415,6 +724,9 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 		r.Post("/onboarding/checklist/dismiss", handlers.Onboarding.DismissChecklist)
 		r.Post("/onboarding/settings-done", handlers.Onboarding.SettingsDone)
 		r.Post("/onboarding/invite-done", handlers.Onboarding.InviteDone)
+		r.Get("/onboarding/steps", handlers.Onboarding.GetSteps)
+		r.Post("/onboarding/steps/{step}/complete", handlers.Onboarding.CompleteStep)
+		r.Post("/onboarding/steps/{step}/skip", handlers.Onboarding.SkipStep)
 
 		// Support ticket routes (user-facing)
 		r.Route("/support", func(r chi.Router) {
@@ -422,6 +734,7 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 			r.Post("/tickets", handlers.Support.CreateTicket)
 			r.Get("/unread", handlers.Support.GetUnread)
 			r.Get("/attachment-limits", handlers.Support.GetAttachmentLimits)
+			r.Get("/suggested-articles", handlers.Support.SuggestArticles)
 
 			r.Route("/tickets/{ticketID}", func(r chi.Router) {
 				r.Get("/", handlers.Support.GetTicket)
@@ -439,5 +752,16 @@ func SetupRoutes(r chi.Router, handlers *Handlers, authService *service.AuthServ
 			r.Get("/attachments/{attachmentID}", handlers.Support.FetchAttachment)
 			r.Delete("/attachments/{attachmentID}", handlers.Support.DeleteAttachment)
 		})
+
+		// In-app feedback + NPS. The NPS prompt is throttled server-side to
+		// once per quarter; free-text feedback is always accepted.
+		r.Route("/feedback", func(r chi.Router) {
+			r.Post("/", handlers.Feedback.SubmitFeedback)
+			r.Get("/nps/status", handlers.Feedback.GetNPSStatus)
+			r.Post("/nps", handlers.Feedback.SubmitNPS)
+			r.Post("/nps/dismiss", handlers.Feedback.DismissNPS)
+		})
 	})
+
+	handlers.Batch.SetRouter(r)
 }
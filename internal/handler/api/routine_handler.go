@@ -0,0 +1,311 @@
+package api
+
+import (
+	"errors"
+	stdlog "log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// routineStatsDefaultDays is the stats window when no dates are given.
+const routineStatsDefaultDays = 30
+
+// RoutineHandler serves a child's daily routines under
+// /api/children/{childID}/routines: building them, ticking steps off
+// each day, completion stats and the printable visual schedule.
+type RoutineHandler struct {
+	routines     *service.RoutineService
+	childService *service.ChildService
+	userService  *service.UserService
+}
+
+func NewRoutineHandler(routines *service.RoutineService, childService *service.ChildService, userService *service.UserService) *RoutineHandler {
+	return &RoutineHandler{routines: routines, childService: childService, userService: userService}
+}
+
+func (h *RoutineHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+func (h *RoutineHandler) routine(w http.ResponseWriter, r *http.Request, child *models.Child) (*models.Routine, bool) {
+	id, err := parseUUID(chi.URLParam(r, "routineID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid routine ID")
+		return nil, false
+	}
+	rt, err := h.routines.Get(r.Context(), child.ID, id)
+	if errors.Is(err, service.ErrRoutineNotFound) {
+		respondNotFound(w, "Routine not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load routine")
+		return nil, false
+	}
+	return rt, true
+}
+
+// today is the current date in the user's timezone, as UTC midnight like
+// the dates routines are tracked on.
+func (h *RoutineHandler) today(r *http.Request) time.Time {
+	now := time.Now().In(getUserTimezone(r.Context(), h.userService, middleware.GetUserID(r.Context())))
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// date reads the ?date= parameter, defaulting to today.
+func (h *RoutineHandler) date(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	v := r.URL.Query().Get("date")
+	if v == "" {
+		return h.today(r), true
+	}
+	d, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		respondBadRequest(w, "Invalid date format, use YYYY-MM-DD")
+		return d, false
+	}
+	return d, true
+}
+
+// List handles GET /api/children/{childID}/routines?active=true, with the
+// icon keys routines can use.
+func (h *RoutineHandler) List(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	activeOnly, _ := strconv.ParseBool(r.URL.Query().Get("active"))
+	routines, err := h.routines.List(r.Context(), child.ID, activeOnly)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load routines")
+		return
+	}
+	if routines == nil {
+		routines = []models.Routine{}
+	}
+	respondOK(w, map[string]interface{}{"routines": routines, "icons": service.RoutineIcons})
+}
+
+// Create handles POST /api/children/{childID}/routines.
+func (h *RoutineHandler) Create(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.RoutineRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	rt, err := h.routines.Create(r.Context(), child.ID, middleware.GetUserID(r.Context()), &req)
+	if errors.Is(err, service.ErrRoutineInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to create routine")
+		return
+	}
+	respondCreated(w, rt)
+}
+
+// Get handles GET /api/children/{childID}/routines/{routineID}.
+func (h *RoutineHandler) Get(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	if rt, ok := h.routine(w, r, child); ok {
+		respondOK(w, rt)
+	}
+}
+
+// Update handles PUT /api/children/{childID}/routines/{routineID}.
+func (h *RoutineHandler) Update(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	rt, ok := h.routine(w, r, child)
+	if !ok {
+		return
+	}
+	var req models.RoutineRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	err := h.routines.Update(r.Context(), rt, &req)
+	if errors.Is(err, service.ErrRoutineInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to update routine")
+		return
+	}
+	respondOK(w, rt)
+}
+
+// Delete handles DELETE /api/children/{childID}/routines/{routineID}.
+func (h *RoutineHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	rt, ok := h.routine(w, r, child)
+	if !ok {
+		return
+	}
+	if err := h.routines.Delete(r.Context(), rt.ID); err != nil {
+		respondInternalError(w, "Failed to delete routine")
+		return
+	}
+	respondOK(w, map[string]string{"message": "Routine deleted"})
+}
+
+// Day handles GET /api/children/{childID}/routines/day?date=, the routines
+// scheduled that day (default today) with the steps done.
+func (h *RoutineHandler) Day(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	date, ok := h.date(w, r)
+	if !ok {
+		return
+	}
+	days, err := h.routines.Day(r.Context(), child.ID, date)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load routines")
+		return
+	}
+	respondOK(w, map[string]interface{}{"date": date.Format("2006-01-02"), "routines": days})
+}
+
+// CompleteStep handles PUT (done) and DELETE (not done)
+// /api/children/{childID}/routines/{routineID}/steps/{stepID}/done?date=.
+func (h *RoutineHandler) CompleteStep(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	rt, ok := h.routine(w, r, child)
+	if !ok {
+		return
+	}
+	stepID, err := parseUUID(chi.URLParam(r, "stepID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid step ID")
+		return
+	}
+	date, ok := h.date(w, r)
+	if !ok {
+		return
+	}
+	done := r.Method != http.MethodDelete
+	err = h.routines.SetStepDone(r.Context(), rt, stepID, date, h.today(r), middleware.GetUserID(r.Context()), done)
+	switch {
+	case errors.Is(err, service.ErrRoutineStepNotFound):
+		respondNotFound(w, err.Error())
+	case errors.Is(err, service.ErrRoutineFutureDate):
+		respondBadRequest(w, err.Error())
+	case err != nil:
+		respondInternalError(w, "Failed to update routine step")
+	default:
+		respondOK(w, map[string]interface{}{"step_id": stepID, "date": date.Format("2006-01-02"), "completed": done})
+	}
+}
+
+// Stats handles GET /api/children/{childID}/routines/stats?start_date=&end_date=,
+// defaulting to the last routineStatsDefaultDays days.
+func (h *RoutineHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	end := h.today(r)
+	start := end.AddDate(0, 0, -(routineStatsDefaultDays - 1))
+	for param, dst := range map[string]*time.Time{"start_date": &start, "end_date": &end} {
+		if v := r.URL.Query().Get(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				respondBadRequest(w, "Invalid date format, use YYYY-MM-DD")
+				return
+			}
+			*dst = t
+		}
+	}
+	if end.Before(start) || end.Sub(start) > 366*24*time.Hour {
+		respondBadRequest(w, "end_date must be after start_date and within a year of it")
+		return
+	}
+	stats, err := h.routines.Stats(r.Context(), child.ID, start, end)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load routine stats")
+		return
+	}
+	respondOK(w, stats)
+}
+
+// Printable handles GET /api/children/{childID}/routines/printable, the
+// visual schedule PDF of the active routines, or of those given as
+// ?routine_id= (repeatable).
+func (h *RoutineHandler) Printable(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var routines []models.Routine
+	if ids := r.URL.Query()["routine_id"]; len(ids) > 0 {
+		for _, v := range ids {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				respondBadRequest(w, "Invalid routine ID")
+				return
+			}
+			rt, err := h.routines.Get(r.Context(), child.ID, id)
+			if errors.Is(err, service.ErrRoutineNotFound) {
+				respondNotFound(w, "Routine not found")
+				return
+			}
+			if err != nil {
+				respondServiceError(w, err, "Failed to load routine")
+				return
+			}
+			routines = append(routines, *rt)
+		}
+	} else {
+		var err error
+		if routines, err = h.routines.List(r.Context(), child.ID, true); err != nil {
+			respondInternalError(w, "Failed to load routines")
+			return
+		}
+	}
+	pdf, err := service.RoutineSchedulePDF(child, routines)
+	if err != nil {
+		stdlog.Printf("[ROUTINES] schedule PDF for child %s: %v", child.ID, err)
+		respondInternalError(w, "Failed to generate schedule")
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\"visual-schedule.pdf\"")
+	w.Write(pdf)
+}
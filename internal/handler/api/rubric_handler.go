@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// RubricHandler serves the family's scoring rubric for mood, energy and
+// anxiety levels: /api/family/rubric for parents to edit, and
+// /api/children/{childID}/rubric for the logging screens.
+type RubricHandler struct {
+	rubrics      *service.RubricService
+	childService *service.ChildService
+}
+
+func NewRubricHandler(rubrics *service.RubricService, childService *service.ChildService) *RubricHandler {
+	return &RubricHandler{rubrics: rubrics, childService: childService}
+}
+
+// Get handles GET /api/family/rubric.
+func (h *RubricHandler) Get(w http.ResponseWriter, r *http.Request) {
+	rubric, err := h.rubrics.Current(r.Context(), middleware.GetFamilyID(r.Context()))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load scoring rubric")
+		return
+	}
+	respondOK(w, rubric)
+}
+
+// Put handles PUT /api/family/rubric. It saves a new version; behavior
+// logs already entered keep the version they were entered under.
+func (h *RubricHandler) Put(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetRole(r.Context()) != models.FamilyRoleParent {
+		respondForbidden(w, "Only parents can change the scoring rubric")
+		return
+	}
+	var req models.ScoringRubricRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	rubric, err := h.rubrics.Save(r.Context(), middleware.GetFamilyID(r.Context()), middleware.GetUserID(r.Context()), &req)
+	if errors.Is(err, service.ErrRubricInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to save scoring rubric")
+		return
+	}
+	respondOK(w, rubric)
+}
+
+// ForChild handles GET /api/children/{childID}/rubric, the rubric new
+// behavior logs for the child are entered under.
+func (h *RubricHandler) ForChild(w http.ResponseWriter, r *http.Request) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return
+	}
+	rubric, err := h.rubrics.Current(r.Context(), child.FamilyID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load scoring rubric")
+		return
+	}
+	respondOK(w, rubric)
+}
@@ -31,7 +31,7 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 
 	results, err := h.searchService.Search(r.Context(), familyID, userID, query)
 	if err != nil {
-		respondInternalError(w, "Search failed")
+		respondServiceError(w, err, "Search failed")
 		return
 	}
 
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"errors"
+	"html/template"
+	stdlog "log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+	"carecompanion/internal/service"
+)
+
+// SeizureEventHandler serves seizure emergency mode under
+// /api/children/{childID}/seizure-events, and the incident summary shared
+// with the neurologist under /sz/signed.
+type SeizureEventHandler struct {
+	events       *service.SeizureEventService
+	childService *service.ChildService
+	// logs records usage and runs realtime detection for the seizure log
+	// written on stop, as for one logged by hand.
+	logs *LogHandler
+}
+
+func NewSeizureEventHandler(events *service.SeizureEventService, childService *service.ChildService, logs *LogHandler) *SeizureEventHandler {
+	return &SeizureEventHandler{events: events, childService: childService, logs: logs}
+}
+
+// child resolves the URL's child and checks the caller's access to it.
+func (h *SeizureEventHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+// event loads the URL's event and checks it belongs to child.
+func (h *SeizureEventHandler) event(w http.ResponseWriter, r *http.Request, child *models.Child) (*repository.SeizureEvent, bool) {
+	id, err := parseUUID(chi.URLParam(r, "eventID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid seizure event ID")
+		return nil, false
+	}
+	e, err := h.events.Get(r.Context(), id)
+	if errors.Is(err, service.ErrSeizureEventNotFound) || (err == nil && e.ChildID != child.ID) {
+		respondNotFound(w, "Seizure event not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load seizure event")
+		return nil, false
+	}
+	return e, true
+}
+
+// List handles GET /api/children/{childID}/seizure-events.
+func (h *SeizureEventHandler) List(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	events, err := h.events.List(r.Context(), child.ID, limit)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load seizure events")
+		return
+	}
+	if events == nil {
+		events = []repository.SeizureEvent{}
+	}
+	respondOK(w, map[string]interface{}{"seizure_events": events})
+}
+
+// Active handles GET /api/children/{childID}/seizure-events/active, which
+// the app polls to show a seizure another caregiver is timing.
+func (h *SeizureEventHandler) Active(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	e, err := h.events.Active(r.Context(), child.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load seizure event")
+		return
+	}
+	respondOK(w, map[string]interface{}{"seizure_event": e})
+}
+
+// Start handles POST /api/children/{childID}/seizure-events/start. The
+// body is optional; timezone falls back to the X-Timezone header.
+func (h *SeizureEventHandler) Start(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Timezone string `json:"timezone"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondBadRequest(w, "Invalid request body")
+			return
+		}
+	}
+	if req.Timezone == "" {
+		req.Timezone = r.Header.Get("X-Timezone")
+	}
+	e, err := h.events.Start(r.Context(), child, middleware.GetUserID(r.Context()), req.Timezone)
+	switch {
+	case errors.Is(err, service.ErrSeizureEventActive):
+		// Two caregivers tapping start at once both land on the same timer.
+		respondOK(w, map[string]interface{}{"seizure_event": e, "already_active": true})
+	case errors.Is(err, service.ErrSeizureEventInvalidZone):
+		respondBadRequest(w, err.Error())
+	case err != nil:
+		stdlog.Printf("Failed to start seizure event for child %s: %v", child.ID, err)
+		respondInternalError(w, "Failed to start seizure timer")
+	default:
+		respondCreated(w, map[string]interface{}{"seizure_event": e})
+	}
+}
+
+// Get handles GET /api/children/{childID}/seizure-events/{eventID}.
+func (h *SeizureEventHandler) Get(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.event(w, r, child)
+	if !ok {
+		return
+	}
+	respondOK(w, map[string]interface{}{"seizure_event": e})
+}
+
+// Check handles POST /api/children/{childID}/seizure-events/{eventID}/checklist
+// with {"key": "...", "done": true}.
+func (h *SeizureEventHandler) Check(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.event(w, r, child)
+	if !ok {
+		return
+	}
+	var req struct {
+		Key  string `json:"key"`
+		Done bool   `json:"done"`
+	}
+	if err := decodeJSON(r, &req); err != nil || req.Key == "" {
+		respondBadRequest(w, "key is required")
+		return
+	}
+	e, err := h.events.Check(r.Context(), e.ID, req.Key, req.Done, middleware.GetUserID(r.Context()))
+	switch {
+	case errors.Is(err, service.ErrSeizureChecklistItem):
+		respondBadRequest(w, err.Error())
+	case errors.Is(err, service.ErrSeizureEventEnded):
+		respondError(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		respondInternalError(w, "Failed to update checklist")
+	default:
+		respondOK(w, map[string]interface{}{"seizure_event": e})
+	}
+}
+
+// Stop handles POST /api/children/{childID}/seizure-events/{eventID}/stop.
+// The body carries the seizure log details beyond the timed duration.
+func (h *SeizureEventHandler) Stop(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.event(w, r, child)
+	if !ok {
+		return
+	}
+	var req service.SeizureStopDetails
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondBadRequest(w, "Invalid request body")
+			return
+		}
+	}
+	if len(req.Notes) > 5000 {
+		respondBadRequest(w, "Notes must be 5000 characters or fewer")
+		return
+	}
+	e, sl, err := h.events.Stop(r.Context(), child, e.ID, middleware.GetUserID(r.Context()), req)
+	switch {
+	case errors.Is(err, service.ErrSeizureEventEnded):
+		respondError(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil && e == nil:
+		respondInternalError(w, "Failed to stop seizure timer")
+		return
+	case err != nil:
+		// The timer stopped; only the log is missing. Hand back the event
+		// so the app can offer the manual seizure log form.
+		stdlog.Printf("Seizure event %s ended without a log: %v", e.ID, err)
+		respondOK(w, map[string]interface{}{"seizure_event": e, "seizure_log": sl, "log_error": "Failed to save the seizure log"})
+	default:
+		respondOK(w, map[string]interface{}{"seizure_event": e, "seizure_log": sl})
+	}
+	if sl != nil {
+		h.logs.recordUsage(r, "seizure")
+		h.logs.triggerDetection(child.ID, "seizure")
+	}
+}
+
+// Summary handles GET /api/children/{childID}/seizure-events/{eventID}/summary.
+func (h *SeizureEventHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.event(w, r, child)
+	if !ok {
+		return
+	}
+	sum, err := h.events.Summary(r.Context(), child, e)
+	if errors.Is(err, service.ErrSeizureSummaryUnavailable) {
+		respondError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to build incident summary")
+		return
+	}
+	respondOK(w, sum)
+}
+
+// Share handles POST /api/children/{childID}/seizure-events/{eventID}/share,
+// returning a link to the incident summary for the neurologist that works
+// without an account.
+func (h *SeizureEventHandler) Share(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.event(w, r, child)
+	if !ok {
+		return
+	}
+	url, exp, err := h.events.ShareURL(e)
+	if errors.Is(err, service.ErrSeizureSummaryUnavailable) {
+		respondError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to create share link")
+		return
+	}
+	respondOK(w, map[string]interface{}{"url": url, "expires_at": exp})
+}
+
+// ServeSignedSummary handles GET /sz/signed/{eventID}?exp=&sig=, the
+// shared incident summary as a printable page. No JWT: the signed URL is
+// the credential, as for reports.
+func (h *SeizureEventHandler) ServeSignedSummary(w http.ResponseWriter, r *http.Request) {
+	expRaw := r.URL.Query().Get("exp")
+	sig := r.URL.Query().Get("sig")
+	if expRaw == "" || sig == "" {
+		respondBadRequest(w, "Missing signature")
+		return
+	}
+	expUnix, err := strconv.ParseInt(expRaw, 10, 64)
+	if err != nil {
+		respondBadRequest(w, "Bad expiry")
+		return
+	}
+	id, err := parseUUID(chi.URLParam(r, "eventID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid seizure event ID")
+		return
+	}
+	if err := h.events.VerifySignedSummary(id, expUnix, sig); err != nil {
+		respondError(w, "Link expired or invalid", http.StatusForbidden)
+		return
+	}
+	sum, err := h.events.SignedSummary(r.Context(), id)
+	if errors.Is(err, service.ErrSeizureEventNotFound) {
+		respondNotFound(w, "Incident summary not found")
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to build incident summary")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	if err := seizureSummaryTemplate.Execute(w, sum); err != nil {
+		stdlog.Printf("Render seizure summary %s: %v", id, err)
+	}
+}
+
+var seizureSummaryTemplate = template.Must(template.New("seizure_summary").Funcs(template.FuncMap{
+	"duration": service.FormatSeizureDuration,
+	"durationp": func(p *int) string {
+		if p == nil {
+			return "—"
+		}
+		return service.FormatSeizureDuration(*p)
+	},
+	"local": func(t time.Time, zone string) string {
+		if loc, err := time.LoadLocation(zone); err == nil {
+			t = t.In(loc)
+		}
+		return t.Format("Mon Jan 2, 2006 3:04:05 PM MST")
+	},
+	"join": func(s []string) string {
+		if len(s) == 0 {
+			return "—"
+		}
+		return strings.Join(s, ", ")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Seizure incident summary — {{.ChildName}}</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; max-width: 720px; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { font-size: 1.4em; } h2 { font-size: 1.1em; margin-top: 1.6em; border-bottom: 1px solid #ddd; }
+table { border-collapse: collapse; width: 100%; } td, th { text-align: left; padding: 4px 8px; vertical-align: top; }
+th { width: 40%; color: #555; font-weight: normal; }
+</style>
+</head>
+<body>
+<h1>Seizure incident summary</h1>
+<p>{{.ChildName}}, age {{.AgeYears}}</p>
+<table>
+<tr><th>Started</th><td>{{local .StartedAt .Timezone}}</td></tr>
+<tr><th>Ended</th><td>{{local .EndedAt .Timezone}}</td></tr>
+<tr><th>Duration (timed)</th><td>{{duration .DurationSeconds}}</td></tr>
+{{with .Log}}
+<tr><th>Seizure type</th><td>{{if .SeizureType.Valid}}{{.SeizureType.String}}{{else}}—{{end}}</td></tr>
+<tr><th>Rescue medication</th><td>{{if .RescueMedicationGiven}}{{if .RescueMedicationName.Valid}}{{.RescueMedicationName.String}}{{else}}Given{{end}}{{else}}Not given{{end}}</td></tr>
+<tr><th>911 called</th><td>{{if .Called911}}Yes{{else}}No{{end}}</td></tr>
+<tr><th>Possible triggers</th><td>{{join .Triggers}}</td></tr>
+<tr><th>Warning signs</th><td>{{join .WarningSigns}}</td></tr>
+<tr><th>After the seizure</th><td>{{join .PostIctalSymptoms}}</td></tr>
+{{if .Notes.Valid}}<tr><th>Notes</th><td>{{.Notes.String}}</td></tr>{{end}}
+{{end}}
+</table>
+<h2>Timeline</h2>
+<table>
+{{range .Timeline}}<tr><th>+{{duration .OffsetSeconds}}</th><td>{{.Label}}</td></tr>
+{{end}}
+</table>
+<h2>Previous {{.History.Days}} days</h2>
+<table>
+<tr><th>Other seizures logged</th><td>{{.History.Seizures}}</td></tr>
+<tr><th>Average duration</th><td>{{durationp .History.AverageDurationSeconds}}</td></tr>
+<tr><th>Longest</th><td>{{durationp .History.LongestDurationSeconds}}</td></tr>
+<tr><th>Rescue medication given</th><td>{{.History.RescueMedicationUses}}</td></tr>
+<tr><th>911 called</th><td>{{.History.Called911}}</td></tr>
+</table>
+<p><small>Recorded by the family in MyCareCompanion. Times are in {{.Timezone}}.</small></p>
+</body>
+</html>
+`))
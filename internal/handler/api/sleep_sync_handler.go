@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// sleepSyncDefaultDays is the range device records are listed and
+// reconciled over when the request doesn't give one.
+const sleepSyncDefaultDays = 14
+
+// SleepSyncHandler serves device sleep imports, the child's preferred
+// sleep source and on-demand reconciliation under
+// /api/children/{childID}/sleep-sync.
+type SleepSyncHandler struct {
+	sleepSync    *service.SleepSyncService
+	childService *service.ChildService
+	userService  *service.UserService
+}
+
+func NewSleepSyncHandler(sleepSync *service.SleepSyncService, childService *service.ChildService, userService *service.UserService) *SleepSyncHandler {
+	return &SleepSyncHandler{sleepSync: sleepSync, childService: childService, userService: userService}
+}
+
+func (h *SleepSyncHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+// dateRange reads start_date and end_date, defaulting to the last
+// sleepSyncDefaultDays days in the user's timezone.
+func (h *SleepSyncHandler) dateRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	now := time.Now().In(getUserTimezone(r.Context(), h.userService, middleware.GetUserID(r.Context())))
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -sleepSyncDefaultDays)
+	for param, dst := range map[string]*time.Time{"start_date": &start, "end_date": &end} {
+		if v := r.URL.Query().Get(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				respondBadRequest(w, "Invalid date format, use YYYY-MM-DD")
+				return start, end, false
+			}
+			*dst = t
+		}
+	}
+	if end.Before(start) {
+		respondBadRequest(w, "end_date must be after start_date")
+		return start, end, false
+	}
+	return start, end, true
+}
+
+func respondSleepSyncError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, service.ErrSleepSyncInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	respondInternalError(w, msg)
+}
+
+// ListRecords handles GET /api/children/{childID}/sleep-sync/records.
+func (h *SleepSyncHandler) ListRecords(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	start, end, ok := h.dateRange(w, r)
+	if !ok {
+		return
+	}
+	recs, err := h.sleepSync.ListRecords(r.Context(), child.ID, start, end)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load sleep records")
+		return
+	}
+	respondOK(w, map[string]interface{}{"records": recs})
+}
+
+// Import handles POST /api/children/{childID}/sleep-sync/records. Night
+// dates are computed in the importing user's timezone.
+func (h *SleepSyncHandler) Import(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.SleepDeviceImportRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	userID := middleware.GetUserID(r.Context())
+	loc := getUserTimezone(r.Context(), h.userService, userID)
+	recs, err := h.sleepSync.Import(r.Context(), child.ID, userID, loc, &req)
+	if err != nil {
+		respondSleepSyncError(w, err, "Failed to import sleep records")
+		return
+	}
+	respondCreated(w, map[string]interface{}{"imported": len(recs), "records": recs})
+}
+
+// GetPreference handles GET /api/children/{childID}/sleep-sync/preference.
+func (h *SleepSyncHandler) GetPreference(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.sleepSync.Preference(r.Context(), child.ID)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load sleep source preference")
+		return
+	}
+	respondOK(w, map[string]interface{}{"preference": p, "sources": models.SleepDeviceSources})
+}
+
+// UpdatePreference handles PUT /api/children/{childID}/sleep-sync/preference.
+func (h *SleepSyncHandler) UpdatePreference(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.SleepSourcePreferenceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	p, err := h.sleepSync.SavePreference(r.Context(), child.ID, middleware.GetUserID(r.Context()), &req)
+	if err != nil {
+		respondSleepSyncError(w, err, "Failed to save sleep source preference")
+		return
+	}
+	respondOK(w, p)
+}
+
+// Reconcile handles POST /api/children/{childID}/sleep-sync/reconcile,
+// running the nightly reconciliation now for the requested range.
+func (h *SleepSyncHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	start, end, ok := h.dateRange(w, r)
+	if !ok {
+		return
+	}
+	res, err := h.sleepSync.ReconcileChild(r.Context(), child.ID, start, end)
+	if err != nil {
+		respondServiceError(w, err, "Failed to reconcile sleep data")
+		return
+	}
+	respondOK(w, res)
+}
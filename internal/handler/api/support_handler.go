@@ -3,11 +3,13 @@ package api
 import (
 	"errors"
 	"io"
+	"log"
 	"net/http"
 	"path/filepath"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/service"
@@ -19,19 +21,24 @@ import (
 const (
 	maxTicketDescriptionLen = 50000
 	maxTicketMessageLen     = 10000
+	// maxAttachmentsPerMessage bounds attachment_ids on a reply; the
+	// per-ticket cap in the attachment service is the real limit.
+	maxAttachmentsPerMessage = 10
 )
 
 // SupportHandler handles user-facing support ticket API endpoints
 type SupportHandler struct {
 	supportService *service.UserSupportService
 	attachService  *service.TicketAttachmentService
+	kbService      *service.KnowledgeBaseService
 }
 
 // NewSupportHandler creates a new support handler
-func NewSupportHandler(supportService *service.UserSupportService, attachService *service.TicketAttachmentService) *SupportHandler {
+func NewSupportHandler(supportService *service.UserSupportService, attachService *service.TicketAttachmentService, kbService *service.KnowledgeBaseService) *SupportHandler {
 	return &SupportHandler{
 		supportService: supportService,
 		attachService:  attachService,
+		kbService:      kbService,
 	}
 }
 
@@ -41,7 +48,7 @@ func (h *SupportHandler) ListTickets(w http.ResponseWriter, r *http.Request) {
 
 	tickets, err := h.supportService.GetTickets(r.Context(), userID)
 	if err != nil {
-		respondInternalError(w, "Failed to get support tickets")
+		respondServiceError(w, err, "Failed to get support tickets")
 		return
 	}
 
@@ -73,7 +80,7 @@ func (h *SupportHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 
 	ticket, err := h.supportService.CreateTicket(r.Context(), userID, &req)
 	if err != nil {
-		respondInternalError(w, "Failed to create ticket")
+		respondServiceError(w, err, "Failed to create ticket")
 		return
 	}
 
@@ -99,11 +106,53 @@ func (h *SupportHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 		respondInternalError(w, "Failed to get ticket")
 		return
 	}
+	if h.attachService != nil {
+		if err := h.attachService.AttachToMessages(r.Context(), ticket.ID, messages, false); err != nil {
+			log.Printf("[SUPPORT] attachments for ticket %s: %v", ticket.ID, err)
+		}
+	}
 
-	respondOK(w, map[string]interface{}{
+	resp := map[string]interface{}{
 		"ticket":   ticket,
 		"messages": messages,
-	})
+	}
+	// Old links to a ticket that support merged into another land on that
+	// one; tell the app so it can swap the ID it holds.
+	if ticket.ID != ticketID {
+		resp["redirected_from"] = ticketID
+	}
+	// While the ticket is still open, point the user at help articles that
+	// might answer it before an agent gets to it.
+	if h.kbService != nil && ticket.Status != "resolved" && ticket.Status != "closed" {
+		suggested, err := h.kbService.SuggestForText(r.Context(), ticket.Subject+" "+ticket.Description)
+		if err != nil {
+			log.Printf("[SUPPORT] kb suggestions for ticket %s: %v", ticketID, err)
+		} else {
+			resp["suggested_articles"] = suggested
+		}
+	}
+
+	respondOK(w, resp)
+}
+
+// SuggestArticles handles GET /api/support/suggested-articles?q=...
+// The app calls this while the user is typing a new ticket so matching
+// help articles can be offered before they submit.
+func (h *SupportHandler) SuggestArticles(w http.ResponseWriter, r *http.Request) {
+	if h.kbService == nil {
+		respondOK(w, []interface{}{})
+		return
+	}
+	q := r.URL.Query().Get("q")
+	if len(q) > maxTicketDescriptionLen {
+		q = q[:maxTicketDescriptionLen]
+	}
+	articles, err := h.kbService.SuggestForText(r.Context(), q)
+	if err != nil {
+		respondServiceError(w, err, "Failed to suggest articles")
+		return
+	}
+	respondOK(w, articles)
 }
 
 // AddMessage adds a reply to a ticket
@@ -118,6 +167,9 @@ func (h *SupportHandler) AddMessage(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		Message string `json:"message"`
+		// AttachmentIDs are files already uploaded via POST .../attachments
+		// that should be shown with this reply.
+		AttachmentIDs []string `json:"attachment_ids"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		respondBadRequest(w, "Invalid request body")
@@ -132,8 +184,14 @@ func (h *SupportHandler) AddMessage(w http.ResponseWriter, r *http.Request) {
 		respondBadRequest(w, "Message is too long")
 		return
 	}
+	attIDs, ok := parseAttachmentIDs(req.AttachmentIDs)
+	if !ok {
+		respondBadRequest(w, "Invalid attachment_ids")
+		return
+	}
 
-	if err := h.supportService.AddReply(r.Context(), ticketID, userID, req.Message); err != nil {
+	messageID, err := h.supportService.AddReply(r.Context(), ticketID, userID, req.Message)
+	if err != nil {
 		if err == service.ErrTicketNotFound {
 			respondNotFound(w, "Ticket not found")
 			return
@@ -141,8 +199,17 @@ func (h *SupportHandler) AddMessage(w http.ResponseWriter, r *http.Request) {
 		respondInternalError(w, "Failed to add message")
 		return
 	}
+	if len(attIDs) > 0 && h.attachService != nil {
+		// The reply went to the merge target if this ticket was merged.
+		if t, err := h.supportService.GetTicketByID(r.Context(), ticketID, userID); err == nil {
+			ticketID = t.ID
+		}
+		if err := h.attachService.LinkToMessage(r.Context(), ticketID, userID, messageID, attIDs); err != nil {
+			log.Printf("[SUPPORT] link attachments to message %s: %v", messageID, err)
+		}
+	}
 
-	respondOK(w, map[string]string{"status": "sent"})
+	respondOK(w, map[string]string{"status": "sent", "message_id": messageID.String()})
 }
 
 // MarkRead marks a ticket as read
@@ -186,10 +253,12 @@ func (h *SupportHandler) UploadAttachment(w http.ResponseWriter, r *http.Request
 
 	// Verify ownership before accepting bytes — cheap, and avoids spending
 	// time uploading something we'd reject anyway.
-	if _, err := h.supportService.GetTicketByID(r.Context(), ticketID, userID); err != nil {
+	ticket, err := h.supportService.GetTicketByID(r.Context(), ticketID, userID)
+	if err != nil {
 		respondNotFound(w, "Ticket not found")
 		return
 	}
+	ticketID = ticket.ID // a merged ticket's uploads go to its target
 
 	// Cap the multipart body. ParseMultipartForm reads up to maxMemory in
 	// memory; the rest spills to temp files. Add a safety margin (1 MB) over
@@ -228,8 +297,13 @@ func (h *SupportHandler) UploadAttachment(w http.ResponseWriter, r *http.Request
 		switch {
 		case errors.Is(err, service.ErrAttachmentTooBig),
 			errors.Is(err, service.ErrAttachmentTypeNotAllowed),
+			errors.Is(err, service.ErrAttachmentContentMismatch),
 			errors.Is(err, service.ErrAttachmentLimitReached):
 			respondBadRequest(w, err.Error())
+		case errors.Is(err, service.ErrUploadQuarantined):
+			respondError(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrScanUnavailable):
+			respondError(w, err.Error(), http.StatusServiceUnavailable)
 		default:
 			respondInternalError(w, "Upload failed: "+err.Error())
 		}
@@ -251,13 +325,14 @@ func (h *SupportHandler) ListAttachments(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	userID := middleware.GetUserID(r.Context())
-	if _, err := h.supportService.GetTicketByID(r.Context(), ticketID, userID); err != nil {
+	ticket, err := h.supportService.GetTicketByID(r.Context(), ticketID, userID)
+	if err != nil {
 		respondNotFound(w, "Ticket not found")
 		return
 	}
-	atts, err := h.attachService.List(r.Context(), ticketID)
+	atts, err := h.attachService.ListForOwner(r.Context(), ticket.ID)
 	if err != nil {
-		respondInternalError(w, "Failed to list attachments")
+		respondServiceError(w, err, "Failed to list attachments")
 		return
 	}
 	respondOK(w, atts)
@@ -290,14 +365,89 @@ func (h *SupportHandler) FetchAttachment(w http.ResponseWriter, r *http.Request)
 	}
 	defer body.Close()
 
-	w.Header().Set("Content-Type", att.ContentType)
-	w.Header().Set("Content-Disposition", "inline; filename=\""+att.OriginalName+"\"")
+	writeAttachmentHeaders(w, att.ContentType, att.Kind, att.OriginalName)
 	if att.SizeBytes > 0 {
 		w.Header().Set("Content-Length", strconv.FormatInt(att.SizeBytes, 10))
 	}
 	_, _ = io.Copy(w, body)
 }
 
+// ServeSignedAttachment streams a ticket attachment (or its thumbnail,
+// ?v=thumb) when the URL carries a valid HMAC signature. No JWT — the
+// signed URL is the credential, so <img src> and system share sheets work.
+// URLs are only minted for callers already allowed to see the file.
+func (h *SupportHandler) ServeSignedAttachment(w http.ResponseWriter, r *http.Request) {
+	if h.attachService == nil {
+		respondInternalError(w, "Attachment service unavailable")
+		return
+	}
+	attID, err := parseUUID(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid attachment ID")
+		return
+	}
+	q := r.URL.Query()
+	variant := q.Get("v")
+	if variant != service.AttachmentVariantThumb {
+		variant = service.AttachmentVariantOriginal
+	}
+	expUnix, err := strconv.ParseInt(q.Get("exp"), 10, 64)
+	if err != nil || q.Get("sig") == "" {
+		respondBadRequest(w, "Missing signature")
+		return
+	}
+	if err := h.attachService.VerifySignedURL(attID, variant, expUnix, q.Get("sig")); err != nil {
+		respondError(w, "Link expired or invalid", http.StatusForbidden)
+		return
+	}
+
+	body, contentType, att, err := h.attachService.OpenSigned(r.Context(), attID, variant)
+	if err != nil {
+		if errors.Is(err, service.ErrAttachmentNotFound) {
+			respondNotFound(w, "Attachment not found")
+			return
+		}
+		respondInternalError(w, "Failed to open attachment")
+		return
+	}
+	defer body.Close()
+
+	writeAttachmentHeaders(w, contentType, att.Kind, att.OriginalName)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("[SUPPORT] ServeSignedAttachment io.Copy failed for %s: %v", attID, err)
+	}
+}
+
+// writeAttachmentHeaders sets the type/disposition headers for serving an
+// attachment. Logs are always downloaded rather than rendered, and nosniff
+// stops the browser second-guessing the stored type.
+func writeAttachmentHeaders(w http.ResponseWriter, contentType, kind, name string) {
+	disposition := "inline"
+	if kind == "log" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", disposition+"; filename=\""+name+"\"")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
+
+// parseAttachmentIDs parses the attachment_ids list sent with a message.
+func parseAttachmentIDs(raw []string) ([]uuid.UUID, bool) {
+	if len(raw) > maxAttachmentsPerMessage {
+		return nil, false
+	}
+	ids := make([]uuid.UUID, 0, len(raw))
+	for _, s := range raw {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
 // DeleteAttachment lets a user remove their own attachment from their ticket.
 func (h *SupportHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
 	if h.attachService == nil {
@@ -344,7 +494,7 @@ func (h *SupportHandler) GetUnread(w http.ResponseWriter, r *http.Request) {
 
 	hasUnread, err := h.supportService.HasUnreadSupportMessages(r.Context(), userID)
 	if err != nil {
-		respondInternalError(w, "Failed to check unread status")
+		respondServiceError(w, err, "Failed to check unread status")
 		return
 	}
 
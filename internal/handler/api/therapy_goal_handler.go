@@ -0,0 +1,260 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+// goalTrendDefaultDays is the trend window when no dates are given.
+const goalTrendDefaultDays = 90
+
+// TherapyGoalHandler serves a child's therapy goals under
+// /api/children/{childID}/therapy-goals: the goals, their progress trends
+// from therapy logs, and the quarterly progress report.
+type TherapyGoalHandler struct {
+	goals        *service.TherapyGoalService
+	childService *service.ChildService
+	userService  *service.UserService
+}
+
+func NewTherapyGoalHandler(goals *service.TherapyGoalService, childService *service.ChildService, userService *service.UserService) *TherapyGoalHandler {
+	return &TherapyGoalHandler{goals: goals, childService: childService, userService: userService}
+}
+
+func (h *TherapyGoalHandler) child(w http.ResponseWriter, r *http.Request) (*models.Child, bool) {
+	childID, err := getChildIDFromURL(r)
+	if err != nil {
+		respondBadRequest(w, "Invalid child ID")
+		return nil, false
+	}
+	child, err := h.childService.VerifyChildAccess(r.Context(), childID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		respondForbidden(w, "Access denied")
+		return nil, false
+	}
+	return child, true
+}
+
+func (h *TherapyGoalHandler) goal(w http.ResponseWriter, r *http.Request, child *models.Child) (*models.TherapyGoal, bool) {
+	id, err := parseUUID(chi.URLParam(r, "goalID"))
+	if err != nil {
+		respondBadRequest(w, "Invalid goal ID")
+		return nil, false
+	}
+	g, err := h.goals.Get(r.Context(), child.ID, id)
+	if errors.Is(err, service.ErrTherapyGoalNotFound) {
+		respondNotFound(w, "Therapy goal not found")
+		return nil, false
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to load therapy goal")
+		return nil, false
+	}
+	return g, true
+}
+
+// dateRange reads ?start_date=&end_date= in the user's timezone, defaulting
+// to the last goalTrendDefaultDays days.
+func (h *TherapyGoalHandler) dateRange(w http.ResponseWriter, r *http.Request) (start, end time.Time, ok bool) {
+	loc := getUserTimezone(r.Context(), h.userService, middleware.GetUserID(r.Context()))
+	now := time.Now().In(loc)
+	end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start = end.AddDate(0, 0, -goalTrendDefaultDays)
+	for param, dst := range map[string]*time.Time{"start_date": &start, "end_date": &end} {
+		if v := r.URL.Query().Get(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				respondBadRequest(w, "Invalid date format, use YYYY-MM-DD")
+				return start, end, false
+			}
+			*dst = t
+		}
+	}
+	if end.Before(start) {
+		respondBadRequest(w, "end_date must be after start_date")
+		return start, end, false
+	}
+	return start, end, true
+}
+
+// List handles GET /api/children/{childID}/therapy-goals?status=.
+func (h *TherapyGoalHandler) List(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	goals, err := h.goals.List(r.Context(), child.ID, r.URL.Query().Get("status"))
+	if err != nil {
+		respondServiceError(w, err, "Failed to load therapy goals")
+		return
+	}
+	if goals == nil {
+		goals = []models.TherapyGoal{}
+	}
+	respondOK(w, map[string]interface{}{"goals": goals})
+}
+
+// Create handles POST /api/children/{childID}/therapy-goals.
+func (h *TherapyGoalHandler) Create(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req models.TherapyGoalRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	g, err := h.goals.Create(r.Context(), child.ID, middleware.GetUserID(r.Context()), &req)
+	if errors.Is(err, service.ErrTherapyGoalInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to create therapy goal")
+		return
+	}
+	respondCreated(w, g)
+}
+
+// Get handles GET /api/children/{childID}/therapy-goals/{goalID}.
+func (h *TherapyGoalHandler) Get(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	if g, ok := h.goal(w, r, child); ok {
+		respondOK(w, g)
+	}
+}
+
+// Update handles PUT /api/children/{childID}/therapy-goals/{goalID}.
+func (h *TherapyGoalHandler) Update(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	g, ok := h.goal(w, r, child)
+	if !ok {
+		return
+	}
+	var req models.TherapyGoalRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondBadRequest(w, "Invalid request body")
+		return
+	}
+	err := h.goals.Update(r.Context(), g, &req)
+	if errors.Is(err, service.ErrTherapyGoalInvalid) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err, "Failed to update therapy goal")
+		return
+	}
+	respondOK(w, g)
+}
+
+// Delete handles DELETE /api/children/{childID}/therapy-goals/{goalID}.
+func (h *TherapyGoalHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	g, ok := h.goal(w, r, child)
+	if !ok {
+		return
+	}
+	if err := h.goals.Delete(r.Context(), g.ID); err != nil {
+		respondInternalError(w, "Failed to delete therapy goal")
+		return
+	}
+	respondOK(w, map[string]string{"message": "Therapy goal deleted"})
+}
+
+// Trend handles GET /api/children/{childID}/therapy-goals/{goalID}/trend,
+// the goal's sessions for its trend chart.
+func (h *TherapyGoalHandler) Trend(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	g, ok := h.goal(w, r, child)
+	if !ok {
+		return
+	}
+	start, end, ok := h.dateRange(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.goals.Trend(r.Context(), g, start, end)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load goal progress")
+		return
+	}
+	respondOK(w, t)
+}
+
+// Trends handles GET /api/children/{childID}/therapy-goals/trends, every
+// goal's trend over the period.
+func (h *TherapyGoalHandler) Trends(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	start, end, ok := h.dateRange(w, r)
+	if !ok {
+		return
+	}
+	trends, err := h.goals.Trends(r.Context(), child.ID, start, end)
+	if err != nil {
+		respondServiceError(w, err, "Failed to load goal progress")
+		return
+	}
+	respondOK(w, map[string]interface{}{
+		"start_date": start.Format("2006-01-02"),
+		"end_date":   end.Format("2006-01-02"),
+		"trends":     trends,
+	})
+}
+
+// ProgressReport handles POST /api/children/{childID}/therapy-goals/progress-report
+// with {"year": 2026, "quarter": 2}, defaulting to the current quarter.
+// The PDF is downloaded through the reports endpoints.
+func (h *TherapyGoalHandler) ProgressReport(w http.ResponseWriter, r *http.Request) {
+	child, ok := h.child(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Year    int `json:"year"`
+		Quarter int `json:"quarter"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondBadRequest(w, "Invalid request body")
+			return
+		}
+	}
+	if req.Year == 0 || req.Quarter == 0 {
+		now := time.Now()
+		req.Year, req.Quarter = now.Year(), (int(now.Month())-1)/3+1
+	}
+	report, err := h.goals.ProgressReport(r.Context(), child, middleware.GetUserID(r.Context()), req.Year, req.Quarter)
+	if errors.Is(err, service.ErrGoalReportQuarter) {
+		respondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "Failed to generate progress report: "+err.Error())
+		return
+	}
+	respondCreated(w, report)
+}
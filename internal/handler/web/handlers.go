@@ -2,7 +2,12 @@ package web
 
 import (
 	"context"
+	"errors"
+	"html/template"
+	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -88,7 +93,35 @@ func (h *WebHandlers) AccountRestore(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Register renders the register page, or a "closed" page when the
+// VerifyEmail is the public landing for the email verification link.
+// No auth required — the token is the credential (one-shot, 48 hours).
+func (h *WebHandlers) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		renderTemplate(w, "verify_email", map[string]interface{}{
+			"Success":      false,
+			"ErrorMessage": "Missing token in the URL. The link should look like /verify-email?token=...",
+		})
+		return
+	}
+	if err := h.services.EmailVerification.Verify(r.Context(), token); err != nil {
+		msg := "This verification link is invalid or has already been used."
+		if errors.Is(err, service.ErrEmailVerifyTokenExpired) {
+			msg = "This verification link has expired."
+		}
+		renderTemplate(w, "verify_email", map[string]interface{}{
+			"Success":      false,
+			"ErrorMessage": msg,
+		})
+		return
+	}
+	renderTemplate(w, "verify_email", map[string]interface{}{
+		"Success": true,
+	})
+}
+
+// Register renders the register page, with the CAPTCHA widget when signup
+// asks for one, or a "closed" page when the
 // dev_registration_open setting is off (non-prod only). Avoids letting
 // the user fill out a form that the API would reject.
 func (h *WebHandlers) Register(w http.ResponseWriter, r *http.Request) {
@@ -98,7 +131,11 @@ func (h *WebHandlers) Register(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	renderTemplate(w, "register", nil)
+	data := map[string]interface{}{"CaptchaProvider": "", "CaptchaSiteKey": ""}
+	if c := h.services.Captcha.Client(r.Context()); c != nil && slices.Contains(c.Actions, service.CaptchaActionRegister) {
+		data["CaptchaProvider"], data["CaptchaSiteKey"] = c.Provider, c.SiteKey
+	}
+	renderTemplate(w, "register", data)
 }
 
 // registrationOpen mirrors the API handler's check — same setting key
@@ -125,12 +162,54 @@ func (h *WebHandlers) registrationOpen(ctx context.Context) bool {
 
 // Privacy renders the privacy policy page
 func (h *WebHandlers) Privacy(w http.ResponseWriter, r *http.Request) {
-	renderTemplate(w, "privacy", nil)
+	h.renderLegal(w, r, service.LegalKindPrivacy, "privacy")
 }
 
 // Terms renders the terms of service page
 func (h *WebHandlers) Terms(w http.ResponseWriter, r *http.Request) {
-	renderTemplate(w, "terms", nil)
+	h.renderLegal(w, r, service.LegalKindTerms, "terms")
+}
+
+// renderLegal renders the version of kind in effect. Versions published
+// from the admin have a markdown body; the original 1.0 documents don't,
+// and are served from their static template.
+func (h *WebHandlers) renderLegal(w http.ResponseWriter, r *http.Request, kind, static string) {
+	docs, err := h.services.Legal.Current(r.Context())
+	if err != nil {
+		log.Printf("[LEGAL] load %s: %v", kind, err)
+	}
+	if d := docs[kind]; d != nil && d.BodyHTML != "" {
+		renderTemplate(w, "legal_document", map[string]interface{}{
+			"Document": d,
+			// Rendered by goldmark, which escapes raw HTML in the source.
+			"Body": template.HTML(d.BodyHTML),
+		})
+		return
+	}
+	renderTemplate(w, static, nil)
+}
+
+// LegalAccept is the interstitial RequireLegalConsent redirects to when a
+// new Terms or Privacy version needs the user's acceptance. It posts to
+// /api/users/me/legal/accept and then returns the user to next.
+func (h *WebHandlers) LegalAccept(w http.ResponseWriter, r *http.Request) {
+	next := r.URL.Query().Get("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = "/dashboard"
+	}
+	pending, err := h.services.Legal.Pending(r.Context(), middleware.GetUserID(r.Context()))
+	if err != nil {
+		renderError(w, "Failed to load the updated terms", http.StatusInternalServerError)
+		return
+	}
+	if len(pending) == 0 {
+		http.Redirect(w, r, next, http.StatusSeeOther)
+		return
+	}
+	renderTemplate(w, "legal_accept", map[string]interface{}{
+		"Documents": pending,
+		"Next":      next,
+	})
 }
 
 // Help renders the public support / FAQ landing page. Public so an App Store
@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/middleware"
 	"carecompanion/internal/models"
 )
 
@@ -223,6 +224,16 @@ var templates *template.Template
 
 // Template functions
 var templateFuncs = template.FuncMap{
+	// cspNonce marks an inline <script> as allowed by the CSP; the security
+	// headers middleware swaps the placeholder for the request's nonce.
+	"cspNonce": func() string { return middleware.CSPNoncePlaceholder },
+	// csrfToken and csrfField carry the CSRF token (see middleware.CSRF):
+	// csrfField goes inside every form that POSTs, csrfToken into the
+	// csrf-token meta tag that static/js/csrf.js reads.
+	"csrfToken": func() string { return middleware.CSRFTokenPlaceholder },
+	"csrfField": func() template.HTML {
+		return template.HTML(`<input type="hidden" name="` + middleware.CSRFFieldName + `" value="` + middleware.CSRFTokenPlaceholder + `">`)
+	},
 	// toJSON converts a value to JSON for use in JavaScript
 	"toJSON": func(v interface{}) template.JS {
 		bytes, err := json.Marshal(v)
@@ -2,6 +2,7 @@ package web
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
@@ -30,12 +31,21 @@ func SetupRoutes(r chi.Router, handlers *WebHandlers, authService *service.AuthS
 		// from the email; the pending page is informational.
 		r.Get("/account/restore", handlers.AccountRestore)
 		r.Get("/account/deletion-pending", handlers.AccountDeletionPending)
+		// Email verification link landing. Public so it works from any
+		// device; rate limited for token-guessing defense.
+		r.With(middleware.RateLimit(10, 1*time.Minute)).Get("/verify-email", handlers.VerifyEmail)
 	})
 
 	// Protected routes
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware(authService))
 		r.Use(middleware.LoadEntitlement(db))
+		// Settings stays reachable so a user who doesn't accept can still
+		// sign out or delete their account.
+		r.Use(middleware.RequireLegalConsent(db, "/legal/", "/settings"))
+
+		// Re-acceptance interstitial for new Terms / Privacy versions.
+		r.Get("/legal/accept", handlers.LegalAccept)
 
 		r.Get("/dashboard", handlers.Dashboard)
 		r.Get("/onboarding", handlers.Onboarding)
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"carecompanion/internal/service"
+)
+
+// remoteIP is the client IP of a request once chi's RealIP has run.
+func remoteIP(r *http.Request) string {
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return ip
+	}
+	return r.RemoteAddr
+}
+
+// AbuseGuard answers requests from IPs svc has blocked with 429 and a
+// Retry-After, and hands every other finished request to svc.Observe.
+// Paths under the exempt prefixes are neither blocked nor counted. A nil
+// svc disables it.
+func AbuseGuard(svc *service.AbuseService, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if svc == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range exempt {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			ip := remoteIP(r)
+			if retry, blocked := svc.Blocked(r.Context(), ip); blocked {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", strconv.Itoa(int(retry.Round(time.Second).Seconds())))
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error":"Too many requests. Please try again later."}`))
+				return
+			}
+
+			wrapped := newResponseWriter(w)
+			next.ServeHTTP(wrapped, r)
+			o := service.AbuseObservation{
+				IP:        ip,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Route:     routeTemplate(r),
+				Status:    wrapped.statusCode,
+				UserAgent: r.UserAgent(),
+			}
+			go func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						log.Printf("[ABUSE] observe goroutine panic: %v", rec)
+					}
+				}()
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				svc.Observe(ctx, o)
+			}()
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+	"carecompanion/internal/service"
+)
+
+func TestAbuseGuard(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := &database.Redis{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	svc := service.NewAbuseService(rdb, nil, config.AbuseConfig{Enabled: true})
+	mr.Set("abuse:block:203.0.113.7", "velocity")
+	mr.SetTTL("abuse:block:203.0.113.7", 90*time.Second)
+
+	h := AbuseGuard(svc, "/api/admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(ip, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = ip + ":4321"
+		r.Header.Set("User-Agent", "Mozilla/5.0")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("203.0.113.7", "/api/children")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "90" {
+		t.Errorf("blocked IP: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("203.0.113.7", "/api/admin/abuse"); w.Code != http.StatusNoContent {
+		t.Errorf("exempt path: %d", w.Code)
+	}
+	if w := serve("203.0.113.8", "/api/children"); w.Code != http.StatusNoContent {
+		t.Errorf("other IP: %d", w.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"carecompanion/internal/service"
+)
+
+// APIClient names the client behind a request for usage analytics:
+// mobile builds by platform and version (see AppClient), browsers as
+// "web", and anything else, scripts and integrations, as "other" with
+// the product from its User-Agent ("okhttp", "python-requests").
+func APIClient(r *http.Request) (platform, version string) {
+	platform, version = AppClient(r)
+	switch {
+	case version != "":
+		if platform == "" {
+			platform = "unknown"
+		}
+	case strings.Contains(r.UserAgent(), "Mozilla/"):
+		platform = "web"
+	default:
+		platform = "other"
+		version, _, _ = strings.Cut(strings.TrimSpace(r.UserAgent()), "/")
+		version, _, _ = strings.Cut(version, " ")
+	}
+	return clientLabel(platform, 20), clientLabel(version, 40)
+}
+
+// clientLabel keeps a client-supplied label short and printable.
+func clientLabel(s string, n int) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > n {
+		s = strings.ToValidUTF8(s[:n], "")
+	}
+	return s
+}
+
+// APIUsage counts each request per client (APIClient) and route in
+// svc, with its status and latency, after the response is written. A nil
+// svc disables it.
+func APIUsage(svc *service.APIUsageService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if svc == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapped := newResponseWriter(w)
+			next.ServeHTTP(wrapped, r)
+			if r.Method == http.MethodOptions {
+				return
+			}
+			elapsed := time.Since(start)
+			platform, version := APIClient(r)
+			route := routeTemplate(r)
+			go func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						log.Printf("[API_USAGE] record goroutine panic: %v", rec)
+					}
+				}()
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				svc.Record(ctx, platform, version, r.Method, route, wrapped.statusCode, elapsed)
+			}()
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIClient(t *testing.T) {
+	cases := []struct {
+		headers           map[string]string
+		platform, version string
+	}{
+		{map[string]string{"X-App-Platform": "iOS", "X-App-Version": "2.3.0"}, "ios", "2.3.0"},
+		{map[string]string{"X-App-Version": "2.1.0", "User-Agent": "okhttp/4.12.0"}, "android", "2.1.0"},
+		{map[string]string{"X-App-Version": "2.1.0", "User-Agent": "Toaster"}, "unknown", "2.1.0"},
+		{map[string]string{"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5)"}, "web", ""},
+		{map[string]string{"User-Agent": "python-requests/2.31.0"}, "other", "python-requests"},
+		{map[string]string{"User-Agent": "curl/8.4.0 extra"}, "other", "curl"},
+		{map[string]string{"User-Agent": "bad\x1fagent name"}, "other", "badagent"},
+		{nil, "other", ""},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/api/children", nil)
+		r.Header.Del("User-Agent")
+		for k, v := range c.headers {
+			r.Header.Set(k, v)
+		}
+		platform, version := APIClient(r)
+		if platform != c.platform || version != c.version {
+			t.Errorf("%v: got %q %q, want %q %q", c.headers, platform, version, c.platform, c.version)
+		}
+	}
+}
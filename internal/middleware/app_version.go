@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"carecompanion/internal/service"
+)
+
+// AppClient returns the platform and build a mobile client reports in the
+// X-App-Platform and X-App-Version headers. Builds that only send the
+// version get their platform from the User-Agent. Browsers send neither,
+// so both come back empty.
+func AppClient(r *http.Request) (platform, version string) {
+	version = strings.TrimSpace(r.Header.Get("X-App-Version"))
+	platform = strings.ToLower(strings.TrimSpace(r.Header.Get("X-App-Platform")))
+	if platform == "" && version != "" {
+		ua := strings.ToLower(r.UserAgent())
+		switch {
+		case strings.Contains(ua, "android") || strings.Contains(ua, "okhttp"):
+			platform = service.AppPlatformAndroid
+		case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") ||
+			strings.Contains(ua, "ios") || strings.Contains(ua, "cfnetwork"):
+			platform = service.AppPlatformIOS
+		}
+	}
+	return platform, version
+}
+
+// AppVersionGate answers 426 Upgrade Required to mobile builds older than
+// the platform's minimum in the app_min_versions setting, with a JSON body
+// the app turns into its update screen — instead of letting an old build
+// hit endpoints whose shape has moved on and fail in odd ways.
+//
+// Requests without X-App-Version (browsers, the web app, scripts) pass, as
+// does anything the service can't judge (see AppVersionService.Check).
+// exempt are path prefixes that must stay reachable from old builds, such
+// as the version check itself. A nil svc disables the gate.
+func AppVersionGate(svc *service.AppVersionService, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			platform, version := AppClient(r)
+			if svc == nil || version == "" || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, prefix := range exempt {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			check := svc.Check(r.Context(), platform, version)
+			if check.Supported {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUpgradeRequired)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":           "upgrade_required",
+				"message":         check.Message,
+				"platform":        check.Platform,
+				"current_version": check.Version,
+				"min_version":     check.MinVersion,
+				"latest_version":  check.LatestVersion,
+				"store_url":       check.StoreURL,
+			})
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+func TestAppVersionGate(t *testing.T) {
+	svc := service.NewAppVersionService(settingsMap{})
+	if err := svc.UpdatePolicy(context.Background(), service.AppVersionPolicy{
+		IOS:     service.AppPlatformVersions{MinVersion: "2.0.0", StoreURL: "https://apps.apple.com/app/id1"},
+		Android: service.AppPlatformVersions{MinVersion: "1.5.0"},
+		Message: "Time to update",
+	}, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	handler := middleware.AppVersionGate(svc, "/api/app/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, c := range []struct {
+		name, path, platform, version, ua string
+		want                              int
+	}{
+		{"old ios", "/api/children", "ios", "1.9.3", "", http.StatusUpgradeRequired},
+		{"current ios", "/api/children", "ios", "2.0.0", "", http.StatusNoContent},
+		{"platform from user agent", "/api/children", "", "1.4.0", "CareCompanion/1.4.0 (Android 14; okhttp/4.12)", http.StatusUpgradeRequired},
+		{"browser", "/api/children", "", "", "Mozilla/5.0", http.StatusNoContent},
+		{"exempt path", "/api/app/version-check", "ios", "1.0.0", "", http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.platform != "" {
+			req.Header.Set("X-App-Platform", c.platform)
+		}
+		if c.version != "" {
+			req.Header.Set("X-App-Version", c.version)
+		}
+		if c.ua != "" {
+			req.Header.Set("User-Agent", c.ua)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s: status %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/logs", nil)
+	req.Header.Set("X-App-Platform", "ios")
+	req.Header.Set("X-App-Version", "1.0")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "upgrade_required" || body["message"] != "Time to update" ||
+		body["min_version"] != "2.0.0" || body["store_url"] == "" || body["current_version"] != "1.0" {
+		t.Errorf("426 body = %v", body)
+	}
+}
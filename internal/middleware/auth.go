@@ -8,9 +8,11 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
 	"carecompanion/internal/service"
 )
 
@@ -27,6 +29,11 @@ const (
 	AuthClaimsKey  contextKey = "authClaims"
 )
 
+// BackgroundRequestHeader marks a request the page made on its own (a
+// polling badge) rather than for the user, so it doesn't count as activity
+// for the admin idle timeout.
+const BackgroundRequestHeader = "X-Background-Request"
+
 const (
 	cookieUser   = "user_access_token"
 	cookieAdmin  = "admin_access_token"
@@ -52,6 +59,8 @@ func errSuffix(err error) string {
 		return "revoked"
 	case service.ErrSessionExpired:
 		return "expired"
+	case service.ErrSessionIdle:
+		return "idle"
 	case service.ErrSessionNotFound:
 		return "missing"
 	default:
@@ -126,7 +135,11 @@ func AuthMiddleware(authService *service.AuthService) func(http.Handler) http.Ha
 					unauthorized(w, r, "session_"+errSuffix(err))
 					return
 				}
-				authService.TouchSession(claims.Sid)
+				// Background polls (badge counts) don't keep a session
+				// from going idle.
+				if r.Header.Get(BackgroundRequestHeader) == "" {
+					authService.TouchSession(claims.Sid)
+				}
 			}
 			// claims.Sid == uuid.Nil → legacy pre-migration JWT. Accept on signature
 			// alone; this branch goes away once all legacy sessions have expired.
@@ -139,6 +152,7 @@ func AuthMiddleware(authService *service.AuthService) func(http.Handler) http.Ha
 			ctx = context.WithValue(ctx, SystemRoleKey, claims.SystemRole)
 			ctx = context.WithValue(ctx, FirstNameKey, claims.FirstName)
 			ctx = context.WithValue(ctx, AuthClaimsKey, claims)
+			setRequestLogUser(ctx, claims.UserID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -180,6 +194,7 @@ func OptionalAuthMiddleware(authService *service.AuthService) func(http.Handler)
 						ctx = context.WithValue(ctx, SystemRoleKey, claims.SystemRole)
 						ctx = context.WithValue(ctx, FirstNameKey, claims.FirstName)
 						ctx = context.WithValue(ctx, AuthClaimsKey, claims)
+						setRequestLogUser(ctx, claims.UserID)
 						r = r.WithContext(ctx)
 					}
 				}
@@ -252,6 +267,52 @@ func RequireFamilyContext() func(http.Handler) http.Handler {
 	}
 }
 
+// FamilyScope runs the request's repository statements under the
+// row-level security policies for the token's family
+// (repository.WithFamilyScope), so children and their rows from any other
+// family read as absent even if an access check is missed. Requests
+// without a family context are left unscoped, as are route groups that
+// don't use it: the policies only narrow scoped statements, so this is
+// defence in depth on the routes that opt in, not a replacement for the
+// access checks anywhere.
+func FamilyScope() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if familyID := GetFamilyID(r.Context()); familyID != uuid.Nil {
+				r = r.WithContext(repository.WithFamilyScope(r.Context(), familyID))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ChildFamilyScope is FamilyScope for routes under {childID}: it scopes
+// to the family the child belongs to rather than the token's, so a member
+// of several families can reach any of their children without switching
+// first. The lookup runs unscoped and only when family RLS is on; if it
+// fails (unknown child, not a member) the request falls back to the
+// token's family and the handler's own access check answers it.
+func ChildFamilyScope(children *service.ChildService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !repository.FamilyRLS() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			familyID := GetFamilyID(r.Context())
+			if childID, err := uuid.Parse(chi.URLParam(r, "childID")); err == nil {
+				if child, err := children.VerifyChildAccess(r.Context(), childID, GetUserID(r.Context())); err == nil {
+					familyID = child.FamilyID
+				}
+			}
+			if familyID != uuid.Nil {
+				r = r.WithContext(repository.WithFamilyScope(r.Context(), familyID))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // Helper functions for extracting context values
 func GetUserID(ctx context.Context) uuid.UUID {
 	if id, ok := ctx.Value(UserIDKey).(uuid.UUID); ok {
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"carecompanion/internal/service"
+)
+
+// HeaderCaptchaToken carries the CAPTCHA widget's response token.
+const HeaderCaptchaToken = "X-Captcha-Token"
+
+// RequireCaptcha verifies the request's X-Captcha-Token for action when
+// svc says the action asks for one. Otherwise it answers 400 with error
+// "captcha_required" or "captcha_failed" and the provider and site key,
+// so clients know to show (or reset) the widget and retry.
+func RequireCaptcha(svc *service.CaptchaService, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := svc.Verify(r.Context(), action, r.Header.Get(HeaderCaptchaToken), remoteIP(r))
+			if err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			code, message := "captcha_failed", "The CAPTCHA check failed. Please try again."
+			if errors.Is(err, service.ErrCaptchaRequired) {
+				code, message = "captcha_required", "Please complete the CAPTCHA."
+			}
+			var siteKey, provider string
+			if c := svc.Client(r.Context()); c != nil {
+				provider, siteKey = c.Provider, c.SiteKey
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":    code,
+				"message":  message,
+				"code":     http.StatusBadRequest,
+				"action":   action,
+				"provider": provider,
+				"site_key": siteKey,
+			})
+		})
+	}
+}
@@ -10,6 +10,7 @@ type CORSConfig struct {
 	AllowedOrigins   []string
 	AllowedMethods   []string
 	AllowedHeaders   []string
+	ExposedHeaders   []string
 	AllowCredentials bool
 	MaxAge           int
 }
@@ -19,7 +20,8 @@ func DefaultCORSConfig() *CORSConfig {
 	return &CORSConfig{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Request-ID", "HX-Request", "HX-Target", "HX-Current-URL"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Request-ID", HeaderCaptchaToken, "HX-Request", "HX-Target", "HX-Current-URL", "If-None-Match", "If-Modified-Since", "If-Match"},
+		ExposedHeaders:   []string{"ETag", "Last-Modified", HeaderSubscriptionMode, HeaderReadOnlyUntil}, // conditional GET validators; restricted-subscription signals
 		AllowCredentials: true,
 		MaxAge:           86400,
 	}
@@ -51,6 +53,10 @@ func CORSMiddleware(config *CORSConfig) func(http.Handler) http.Handler {
 				w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 			}
 
+			if allowedOrigin != "" && len(config.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+			}
+
 			if config.AllowCredentials && allowedOrigin != "*" {
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 			}
@@ -86,19 +92,3 @@ func ContentTypeHTML(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
-
-// SecurityHeaders adds common security headers.
-//
-// X-Frame-Options is SAMEORIGIN (not DENY) so the parent-facing /reports
-// page can embed the signed PDF report into its own iframe for inline
-// viewing. SAMEORIGIN still blocks cross-origin clickjacking — only pages
-// served from mycarecompanion.net can frame mycarecompanion.net content.
-func SecurityHeaders(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-		next.ServeHTTP(w, r)
-	})
-}
@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"carecompanion/internal/middleware"
+)
+
+func TestCSRF(t *testing.T) {
+	csrf := middleware.NewCSRF("secret", "/webhooks/")
+	handler := csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<input name="csrf_token" value="`+middleware.CSRFTokenPlaceholder+`">`)
+	}))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A page load issues the cookie and fills the same token into the page.
+	rec := serve(httptest.NewRequest(http.MethodGet, "/admin/dashboard", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf_token" || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v, want one HttpOnly csrf_token", cookies)
+	}
+	token := cookies[0].Value
+	if got := rec.Body.String(); got != `<input name="csrf_token" value="`+token+`">` {
+		t.Errorf("body = %q", got)
+	}
+
+	post := func(path, body string, mutate func(*http.Request)) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "admin_access_token", Value: "jwt"})
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		if mutate != nil {
+			mutate(req)
+		}
+		return serve(req).Code
+	}
+	form := url.Values{"csrf_token": {token}}.Encode()
+
+	cases := []struct {
+		name   string
+		path   string
+		body   string
+		mutate func(*http.Request)
+		want   int
+	}{
+		{"form field", "/admin/logout", form, nil, http.StatusOK},
+		{"header", "/api/admin/super/settings", "", func(r *http.Request) { r.Header.Set(middleware.CSRFHeader, token) }, http.StatusOK},
+		{"missing", "/admin/logout", "", nil, http.StatusForbidden},
+		{"wrong", "/admin/logout", "csrf_token=nope", nil, http.StatusForbidden},
+		{"forged cookie", "/admin/logout", "csrf_token=abc.def", func(r *http.Request) {
+			r.Header.Set("Cookie", "admin_access_token=jwt; csrf_token=abc.def")
+		}, http.StatusForbidden},
+		{"bearer", "/api/admin/super/settings", "", func(r *http.Request) { r.Header.Set("Authorization", "Bearer jwt") }, http.StatusOK},
+		{"no auth cookie", "/admin/login", "", func(r *http.Request) { r.Header.Del("Cookie") }, http.StatusOK},
+		{"exempt path", "/webhooks/stripe", "", nil, http.StatusOK},
+	}
+	for _, tc := range cases {
+		if got := post(tc.path, tc.body, tc.mutate); got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"carecompanion/internal/database"
+)
+
+// DependencyGuard answers 503 with Retry-After while any of the breakers
+// is open, without running the handler. Clients get a typed
+// "temporarily_unavailable" error to back off on instead of a 500 after a
+// dial timeout.
+func DependencyGuard(breakers ...*database.Breaker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, b := range breakers {
+				if b == nil {
+					continue
+				}
+				if err := b.Check(); err != nil {
+					WriteUnavailable(w, err)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteUnavailable writes the 503 for a dependency that's down.
+func WriteUnavailable(w http.ResponseWriter, err *database.UnavailableError) {
+	secs := int(err.RetryAfter.Seconds())
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   "temporarily_unavailable",
+		Message: "The service is temporarily unavailable. Please try again shortly.",
+		Code:    http.StatusServiceUnavailable,
+	})
+}
+
+// Readiness reports each breaker's state, with 503 while any is open, so
+// the load balancer stops routing to an instance that can't reach its
+// database or Redis.
+func Readiness(breakers ...*database.Breaker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := "ok"
+		deps := make([]database.BreakerStatus, 0, len(breakers))
+		for _, b := range breakers {
+			if b == nil {
+				continue
+			}
+			s := b.Status()
+			if s.State == database.BreakerOpen {
+				status = "unavailable"
+			}
+			deps = append(deps, s)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": status, "dependencies": deps})
+	}
+}
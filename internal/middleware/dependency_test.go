@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"carecompanion/internal/database"
+)
+
+func TestDependencyGuard(t *testing.T) {
+	b := database.NewBreaker("postgres", 1, 0)
+	ran := false
+	h := DependencyGuard(b, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ran = true }))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/children", nil))
+	if !ran {
+		t.Fatal("closed breaker blocked the request")
+	}
+
+	b.Record(&pgconn.PgError{Code: "08006"})
+	ran = false
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/api/children", nil))
+	if ran || rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" ||
+		!strings.Contains(rec.Body.String(), `"temporarily_unavailable"`) {
+		t.Errorf("open breaker: ran %v, %d %q %s", ran, rec.Code, rec.Header().Get("Retry-After"), rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	Readiness(b, database.NewBreaker("redis", 0, 0))(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"state":"open"`) {
+		t.Errorf("readiness = %d %s", rec.Code, rec.Body)
+	}
+}
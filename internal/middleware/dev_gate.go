@@ -109,13 +109,23 @@ func isBypassedPath(path string) bool {
 	// SFSafariViewController / Chrome Custom Tabs when opening report PDFs
 	// — those contexts don't carry the MyCareCompanionApp UA marker or the
 	// dev_gate_ok cookie, so without this bypass the user gets the gate
-	// page instead of the PDF.
+	// page instead of the PDF. /inv/signed/* (invoice PDFs),
+	// /exit/signed/* (emailed data exports), /sz/signed/* (seizure
+	// summaries shared with a neurologist), /handoff/signed/* (respite
+	// handoffs given to a sitter) and /r/share/* (reports shared with a
+	// clinician) are the same.
 	switch {
 	case path == "/health",
+		path == "/ready",
 		path == "/api/maintenance-status",
 		path == "/favicon.ico",
 		strings.HasPrefix(path, "/static/"),
-		strings.HasPrefix(path, "/r/signed/"):
+		strings.HasPrefix(path, "/r/signed/"),
+		strings.HasPrefix(path, "/inv/signed/"),
+		strings.HasPrefix(path, "/exit/signed/"),
+		strings.HasPrefix(path, "/sz/signed/"),
+		strings.HasPrefix(path, "/handoff/signed/"),
+		strings.HasPrefix(path, "/r/share/"):
 		return true
 	}
 	return false
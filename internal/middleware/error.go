@@ -2,9 +2,14 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/database"
 )
 
 // ErrorResponse represents a standardized error response
@@ -46,6 +51,62 @@ func JSONError(w http.ResponseWriter, message string, code int) {
 	})
 }
 
+// Problem is an RFC 9457 problem+json body. The error, message and code
+// members repeat the ErrorResponse fields, error keeping its status text,
+// so clients reading those keep working. Kind is a stable slug for the
+// failure ("not_found", "validation_failed", ...) to branch on.
+type Problem struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Status  int    `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+	Kind    string `json:"kind"`
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code"`
+}
+
+// problemKinds maps apperr kinds to their status and error slug.
+var problemKinds = []struct {
+	kind   error
+	status int
+	slug   string
+}{
+	{apperr.ErrNotFound, http.StatusNotFound, "not_found"},
+	{apperr.ErrConflict, http.StatusConflict, "conflict"},
+	{apperr.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{apperr.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{apperr.ErrUnavailable, http.StatusServiceUnavailable, "temporarily_unavailable"},
+}
+
+// WriteError is the one place service and repository errors become HTTP
+// responses. The error's apperr kind picks the status and its message the
+// detail; an error of no kind is logged and answered 500 with fallback,
+// so driver text never reaches the client.
+func WriteError(w http.ResponseWriter, err error, fallback string) {
+	p := Problem{Type: "about:blank", Status: http.StatusInternalServerError, Kind: "internal_server_error", Detail: fallback}
+	kind := apperr.KindOf(err)
+	for _, k := range problemKinds {
+		if k.kind == kind {
+			p.Status, p.Kind, p.Detail = k.status, k.slug, apperr.Message(err)
+			break
+		}
+	}
+	if kind == nil {
+		log.Printf("[ERROR] %s: %v", fallback, err)
+	}
+	var unavailable *database.UnavailableError
+	if errors.As(err, &unavailable) {
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(unavailable.RetryAfter.Seconds()))))
+	}
+	p.Title = http.StatusText(p.Status)
+	p.Error = p.Title
+	p.Message, p.Code = p.Detail, p.Status
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
 // NotFoundHandler returns a JSON 404 response
 func NotFoundHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
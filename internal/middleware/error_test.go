@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"carecompanion/internal/apperr"
+)
+
+func TestWriteError(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+		kind   string
+		detail string
+	}{
+		{fmt.Errorf("load: %w", apperr.NotFound("routine not found")), 404, "not_found", "routine not found"},
+		{apperr.Validation("invalid routine"), 400, "validation_failed", "invalid routine"},
+		{&pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"}, 409, "conflict", "The request conflicts with existing data"},
+		{errors.New("pq: connection reset by peer"), 500, "internal_server_error", "Failed to save routine"},
+	} {
+		rec := httptest.NewRecorder()
+		WriteError(rec, tc.err, "Failed to save routine")
+		var p Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != tc.status || p.Status != tc.status || p.Code != tc.status || p.Detail != tc.detail || p.Message != tc.detail {
+			t.Errorf("WriteError(%v) = %d %+v", tc.err, rec.Code, p)
+		}
+		if p.Kind != tc.kind || p.Error != http.StatusText(tc.status) {
+			t.Errorf("WriteError(%v): kind %q, error %q", tc.err, p.Kind, p.Error)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if strings.Contains(rec.Body.String(), "duplicate key") || strings.Contains(rec.Body.String(), "connection reset") {
+			t.Errorf("driver text leaked: %s", rec.Body)
+		}
+	}
+}
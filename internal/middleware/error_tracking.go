@@ -10,14 +10,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
 )
 
 // ErrorTracker handles error logging and automatic ticket creation
 type ErrorTracker struct {
-	db *sql.DB
-	mu sync.Mutex
+	db       *sql.DB
+	mu       sync.Mutex
+	scanners ScannerClassifier
+
+	timeoutMu      sync.Mutex
+	timeoutsLogged map[string]time.Time
 }
 
 // NewErrorTracker creates a new error tracker
@@ -25,6 +32,45 @@ func NewErrorTracker(db *sql.DB) *ErrorTracker {
 	return &ErrorTracker{db: db}
 }
 
+// ScannerClassifier tells scanner traffic apart from real clients
+// (service.AbuseService).
+type ScannerClassifier interface {
+	IsSuspicious(ctx context.Context, ip, userAgent string) bool
+}
+
+// SetScannerClassifier lets the tracker file errors from scanner traffic
+// as noise.
+func (et *ErrorTracker) SetScannerClassifier(c ScannerClassifier) {
+	et.scanners = c
+}
+
+// Auto-delete periods for noisy error sources, matching
+// models.ErrorSource. Acknowledged rows are kept.
+const (
+	scannerErrorTTL   = 7 * 24 * time.Hour
+	anonymousErrorTTL = 30 * 24 * time.Hour
+)
+
+// errorSource classifies an error for the error log view: infrastructure
+// (5xx, whoever hit it), a signed-in user's, scanner noise, or anonymous.
+// Only scanner and anonymous rows get an auto-delete date.
+func (et *ErrorTracker) errorSource(ctx context.Context, statusCode int, userID *uuid.UUID, ip, userAgent string) (source string, noise bool, autoDelete *time.Time) {
+	expires := func(ttl time.Duration) *time.Time {
+		t := time.Now().Add(ttl)
+		return &t
+	}
+	switch {
+	case statusCode >= 500:
+		return "infrastructure", false, nil
+	case userID != nil:
+		return "user", false, nil
+	case et.scanners != nil && et.scanners.IsSuspicious(ctx, ip, userAgent):
+		return "scanner", true, expires(scannerErrorTTL)
+	default:
+		return "anonymous", false, expires(anonymousErrorTTL)
+	}
+}
+
 // errorResponseWriter captures response body for error responses
 type errorResponseWriter struct {
 	http.ResponseWriter
@@ -57,6 +103,7 @@ func (w *errorResponseWriter) Flush() {
 func (et *ErrorTracker) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		r, rl := withRequestLog(r)
 
 		// Wrap response writer
 		wrapped := &errorResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
@@ -66,6 +113,7 @@ func (et *ErrorTracker) Middleware(next http.Handler) http.Handler {
 
 		// Calculate response time
 		responseTime := float64(time.Since(start).Milliseconds())
+		route := routeTemplate(r)
 
 		// Log response time (async)
 		go func() {
@@ -74,7 +122,7 @@ func (et *ErrorTracker) Middleware(next http.Handler) http.Handler {
 					log.Printf("[error_tracking] logResponseTime goroutine panic: %v", rec)
 				}
 			}()
-			et.logResponseTime(r.URL.Path, r.Method, responseTime, wrapped.statusCode)
+			et.logResponseTime(r.URL.Path, route, r.Method, responseTime, wrapped.statusCode)
 		}()
 
 		// Check for errors
@@ -85,19 +133,32 @@ func (et *ErrorTracker) Middleware(next http.Handler) http.Handler {
 						log.Printf("[error_tracking] handleError goroutine panic: %v", rec)
 					}
 				}()
-				et.handleError(r, wrapped)
+				et.handleError(r, wrapped, rl.userID)
 			}()
 		}
 	})
 }
 
-func (et *ErrorTracker) logResponseTime(path, method string, responseTimeMs float64, statusCode int) {
+// routeTemplate is the chi route pattern the request matched
+// ("/api/children/{id}"), read after the router has run. Requests that
+// matched nothing share "unmatched" so scanners probing random paths
+// don't each get their own row in the latency rollups.
+func routeTemplate(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if p := rctx.RoutePattern(); p != "" && p != "/*" {
+			return p
+		}
+	}
+	return "unmatched"
+}
+
+func (et *ErrorTracker) logResponseTime(path, route, method string, responseTimeMs float64, statusCode int) {
 	if et.db == nil {
 		return
 	}
 
 	// Skip static assets and health checks
-	if strings.HasPrefix(path, "/static") || path == "/health" {
+	if strings.HasPrefix(path, "/static") || path == "/health" || path == "/ready" {
 		return
 	}
 
@@ -105,19 +166,16 @@ func (et *ErrorTracker) logResponseTime(path, method string, responseTimeMs floa
 	defer cancel()
 
 	_, err := et.db.ExecContext(ctx,
-		`INSERT INTO response_time_logs (path, method, response_time_ms, status_code) VALUES ($1, $2, $3, $4)`,
-		path, method, responseTimeMs, statusCode)
+		`INSERT INTO response_time_logs (path, route, method, response_time_ms, status_code) VALUES ($1, $2, $3, $4, $5)`,
+		path, route, method, responseTimeMs, statusCode)
 	if err != nil {
 		log.Printf("Failed to log response time: %v", err)
 	}
-
-	// Cleanup old logs (keep only last 24 hours)
-	if _, err := et.db.ExecContext(ctx, `DELETE FROM response_time_logs WHERE created_at < NOW() - INTERVAL '24 hours'`); err != nil {
-		log.Printf("Failed to cleanup old response time logs: %v", err)
-	}
+	// Old rows are rolled up and pruned by the hourly log retention job
+	// (service.LogRetentionService), not per request.
 }
 
-func (et *ErrorTracker) handleError(r *http.Request, wrapped *errorResponseWriter) {
+func (et *ErrorTracker) handleError(r *http.Request, wrapped *errorResponseWriter, authUserID uuid.UUID) {
 	if et.db == nil {
 		return
 	}
@@ -140,10 +198,13 @@ func (et *ErrorTracker) handleError(r *http.Request, wrapped *errorResponseWrite
 		errorType = "unknown"
 	}
 
-	// Get user ID from context if available
+	// Get user ID from context if available. The auth middleware runs
+	// inside this one, so its claims only reach us through the request log.
 	var userID *uuid.UUID
 	if claims := GetAuthClaims(r.Context()); claims != nil {
 		userID = &claims.UserID
+	} else if authUserID != uuid.Nil {
+		userID = &authUserID
 	}
 
 	// Get request ID
@@ -165,13 +226,15 @@ func (et *ErrorTracker) handleError(r *http.Request, wrapped *errorResponseWrite
 		ipAddress = ipAddress[:idx]
 	}
 
+	source, noise, autoDelete := et.errorSource(ctx, wrapped.statusCode, userID, ipAddress, r.UserAgent())
+
 	// Insert error log
 	var errorLogID uuid.UUID
 	err := et.db.QueryRowContext(ctx,
-		`INSERT INTO error_logs (user_id, error_type, status_code, path, method, error_message, user_agent, ip_address, request_id)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8::inet, $9)
+		`INSERT INTO error_logs (user_id, error_type, status_code, path, method, error_message, user_agent, ip_address, request_id, error_source, is_noise, auto_delete_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8::inet, $9, $10, $11, $12)
 		 RETURNING id`,
-		userID, errorType, wrapped.statusCode, r.URL.Path, r.Method, errorMessage, r.UserAgent(), ipAddress, requestID,
+		userID, errorType, wrapped.statusCode, r.URL.Path, r.Method, errorMessage, r.UserAgent(), ipAddress, requestID, source, noise, autoDelete,
 	).Scan(&errorLogID)
 	if err != nil {
 		log.Printf("Failed to log error: %v", err)
@@ -184,6 +247,67 @@ func (et *ErrorTracker) handleError(r *http.Request, wrapped *errorResponseWrite
 	}
 }
 
+// timeoutLogInterval is how often one repository method's timeouts are
+// logged; a slow database times out every request at once.
+const timeoutLogInterval = time.Minute
+
+// StatementTimedOut logs a database statement that ran out of time as an
+// infrastructure error (repository.TimeoutObserver), under the path
+// "db:<method>" so the error log groups timeouts by query rather than by
+// the endpoints that ran it.
+func (et *ErrorTracker) StatementTimedOut(ctx context.Context, t repository.StatementTimeout) {
+	if et.db == nil {
+		return
+	}
+	method := t.Method
+	if method == "" {
+		method = "unknown"
+	}
+	et.timeoutMu.Lock()
+	if last, ok := et.timeoutsLogged[method]; ok && time.Since(last) < timeoutLogInterval {
+		et.timeoutMu.Unlock()
+		return
+	}
+	if et.timeoutsLogged == nil {
+		et.timeoutsLogged = make(map[string]time.Time)
+	}
+	et.timeoutsLogged[method] = time.Now()
+	et.timeoutMu.Unlock()
+
+	msg := "statement timed out"
+	switch {
+	case t.Limit > 0:
+		msg = "statement exceeded its " + t.Limit.String() + " limit"
+	case t.Operation != "":
+		msg = "statement ran out of the " + t.Operation + " operation budget"
+	case chimiddleware.GetReqID(ctx) != "":
+		msg = "statement ran out of the request's deadline"
+	}
+	var userID *uuid.UUID
+	if claims := GetAuthClaims(ctx); claims != nil {
+		userID = &claims.UserID
+	}
+	requestID := chimiddleware.GetReqID(ctx)
+
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("[error_tracking] StatementTimedOut goroutine panic: %v", rec)
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := et.db.ExecContext(ctx,
+			`INSERT INTO error_logs (user_id, error_type, status_code, path, method, error_message, request_id, error_source, is_noise)
+			 VALUES ($1, 'timeout', $2, $3, 'DB', $4, $5, 'infrastructure', false)`,
+			userID, http.StatusGatewayTimeout, "db:"+method, msg, requestID)
+		if err != nil {
+			log.Printf("Failed to log statement timeout: %v", err)
+		}
+	}()
+	log.Printf("[TIMEOUT] %s: %s (req %s)", method, msg, requestID)
+}
+
 func (et *ErrorTracker) createErrorTicket(ctx context.Context, errorLogID uuid.UUID, r *http.Request, errorType string, statusCode int, errorMessage string) {
 	et.mu.Lock()
 	defer et.mu.Unlock()
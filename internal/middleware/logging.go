@@ -1,9 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
 	"net/http"
 	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 )
 
 // responseWriter wraps http.ResponseWriter to capture the status code
@@ -39,10 +45,50 @@ func (rw *responseWriter) Flush() {
 	}
 }
 
-// LoggingMiddleware logs HTTP requests
+// requestLog carries per-request fields that are only known further down
+// the chain (the authenticated user) back up to the logging and error
+// tracking middleware, which wrap the auth middleware and so never see the
+// context it derives.
+type requestLog struct {
+	userID uuid.UUID
+}
+
+type requestLogKey struct{}
+
+// withRequestLog returns r carrying a requestLog, reusing one installed by
+// an outer middleware.
+func withRequestLog(r *http.Request) (*http.Request, *requestLog) {
+	if rl, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+		return r, rl
+	}
+	rl := &requestLog{}
+	return r.WithContext(context.WithValue(r.Context(), requestLogKey{}, rl)), rl
+}
+
+// setRequestLogUser records the authenticated user for the request log line.
+func setRequestLogUser(ctx context.Context, userID uuid.UUID) {
+	if rl, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		rl.userID = userID
+	}
+}
+
+// LogUserHash is the pseudonymous user identifier written to request logs
+// (uid=...). Logs ship to CloudWatch, so they carry this instead of the
+// user ID; admins compute the same hash to search the logs for a user.
+func LogUserHash(userID uuid.UUID) string {
+	if userID == uuid.Nil {
+		return "-"
+	}
+	sum := sha256.Sum256([]byte(userID.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoggingMiddleware logs HTTP requests. The trailing rid= and uid= fields
+// are what the admin log search (Logs Insights) filters on.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		r, rl := withRequestLog(r)
 
 		// Wrap response writer
 		wrapped := newResponseWriter(w)
@@ -52,14 +98,20 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		// Log request
 		duration := time.Since(start)
+		requestID := chimiddleware.GetReqID(r.Context())
+		if requestID == "" {
+			requestID = "-"
+		}
 		log.Printf(
-			"%s %s %s %d %d %s",
+			"%s %s %s %d %d %s rid=%s uid=%s",
 			r.RemoteAddr,
 			r.Method,
 			r.RequestURI,
 			wrapped.statusCode,
 			wrapped.size,
 			duration,
+			requestID,
+			LogUserHash(rl.userID),
 		)
 	})
 }
@@ -0,0 +1,47 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+)
+
+func TestLogUserHash(t *testing.T) {
+	id := uuid.MustParse("6f1c2a3e-5b7d-4e8f-9a0b-1c2d3e4f5a6b")
+	h := middleware.LogUserHash(id)
+	if len(h) != 16 || h != middleware.LogUserHash(id) {
+		t.Errorf("hash = %q, want a stable 16-char hex string", h)
+	}
+	if strings.Contains(h, id.String()[:8]) {
+		t.Errorf("hash %q leaks the user ID", h)
+	}
+	if got := middleware.LogUserHash(uuid.Nil); got != "-" {
+		t.Errorf("nil user = %q, want -", got)
+	}
+}
+
+// The request line ends with the rid= and uid= fields the admin log
+// search filters on.
+func TestLoggingMiddleware_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(orig)
+
+	h := middleware.LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/children?x=1", nil))
+
+	line := buf.String()
+	if !strings.Contains(line, "GET /api/children?x=1 418") || !strings.HasSuffix(strings.TrimSpace(line), "rid=- uid=-") {
+		t.Errorf("log line = %q", line)
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"carecompanion/internal/service"
+)
+
+// PHICanaryWatch scans admin responses for the PHI canaries' tokens and
+// row ids and reports any it finds to the watchdog. The response is sent
+// unchanged. A nil watchdog, or one with no canaries loaded, disables it.
+func PHICanaryWatch(w *service.PHIWatchdog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if w == nil {
+			return next
+		}
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			scan := newTokenScanner(w.Tokens())
+			if scan == nil {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			r, rl := withRequestLog(r)
+			sw := &scanningWriter{responseWriter: newResponseWriter(rw), scan: scan}
+			next.ServeHTTP(sw, r)
+			if sw.found != "" {
+				w.ReportResponse(r.Method, routeTemplate(r), rl.userID, sw.found)
+			}
+		})
+	}
+}
+
+// scanningWriter feeds everything written to a token scanner, stopping at
+// the first hit.
+type scanningWriter struct {
+	*responseWriter
+	scan  *tokenScanner
+	found string
+}
+
+func (sw *scanningWriter) Write(b []byte) (int, error) {
+	if sw.found == "" {
+		if t, ok := sw.scan.Scan(b); ok {
+			sw.found = t
+		}
+	}
+	return sw.responseWriter.Write(b)
+}
+
+// tokenScanner looks for any of a set of tokens in a byte stream fed in
+// chunks, carrying enough of each chunk's tail to catch a token split
+// across two.
+type tokenScanner struct {
+	tokens [][]byte
+	tail   []byte
+	keep   int
+}
+
+// newTokenScanner returns a scanner for tokens, nil when there are none.
+func newTokenScanner(tokens []string) *tokenScanner {
+	s := &tokenScanner{}
+	for _, t := range tokens {
+		if t == "" {
+			continue
+		}
+		s.tokens = append(s.tokens, []byte(t))
+		s.keep = max(s.keep, len(t)-1)
+	}
+	if len(s.tokens) == 0 {
+		return nil
+	}
+	return s
+}
+
+// Scan reports the first token in everything written so far, p included.
+func (s *tokenScanner) Scan(p []byte) (string, bool) {
+	buf := append(s.tail, p...)
+	for _, t := range s.tokens {
+		if bytes.Contains(buf, t) {
+			return string(t), true
+		}
+	}
+	if len(buf) > s.keep {
+		buf = buf[len(buf)-s.keep:]
+	}
+	s.tail = append([]byte(nil), buf...)
+	return "", false
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import "testing"
+
+func TestTokenScannerAcrossChunks(t *testing.T) {
+	if newTokenScanner(nil) != nil {
+		t.Error("scanner without tokens")
+	}
+	s := newTokenScanner([]string{"phi-canary-abc", "", "0f8fad5b"})
+	for _, chunk := range []string{`{"families":[{"name":"Smith"`, `,"note":"phi-ca`} {
+		if tok, ok := s.Scan([]byte(chunk)); ok {
+			t.Fatalf("found %q early", tok)
+		}
+	}
+	if tok, ok := s.Scan([]byte(`nary-abc"}]}`)); !ok || tok != "phi-canary-abc" {
+		t.Errorf("Scan = %q, %v; want the split token", tok, ok)
+	}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// placeholderWriter replaces a fixed placeholder with a per-request value
+// in text/html bodies. Templates are parsed once and rendered without the
+// request, so per-request values (the CSP nonce, the CSRF token) go into
+// the page as placeholders and are filled in here as it is written.
+//
+// The tail of each write that could be the start of the placeholder is
+// held back until the next write, so a placeholder split across writes is
+// still found. Callers must call finish after the handler returns.
+type placeholderWriter struct {
+	http.ResponseWriter
+	placeholder []byte
+	value       []byte
+	checked     bool
+	rewrite     bool
+	held        []byte
+	// status is a WriteHeader held until the first Write, when the handler
+	// hadn't set a Content-Type yet and the body has to be sniffed.
+	status int
+}
+
+func newPlaceholderWriter(w http.ResponseWriter, placeholder, value string) *placeholderWriter {
+	return &placeholderWriter{ResponseWriter: w, placeholder: []byte(placeholder), value: []byte(value)}
+}
+
+func (w *placeholderWriter) check(first []byte) {
+	if w.checked {
+		return
+	}
+	w.checked = true
+	ct := w.Header().Get("Content-Type")
+	if ct == "" && first != nil {
+		// What net/http would sniff anyway; set it so we can decide.
+		ct = http.DetectContentType(first)
+		w.Header().Set("Content-Type", ct)
+	}
+	w.rewrite = strings.HasPrefix(ct, "text/html")
+	if w.rewrite {
+		w.Header().Del("Content-Length")
+	}
+}
+
+func (w *placeholderWriter) WriteHeader(code int) {
+	if !w.checked && w.Header().Get("Content-Type") == "" && code >= http.StatusOK {
+		w.status = code
+		return
+	}
+	w.check(nil)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *placeholderWriter) Write(b []byte) (int, error) {
+	w.check(b)
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.status = 0
+	}
+	if !w.rewrite {
+		return w.ResponseWriter.Write(b)
+	}
+	data := append(w.held, b...)
+	data = bytes.ReplaceAll(data, w.placeholder, w.value)
+	keep := w.partial(data)
+	w.held = append([]byte(nil), data[len(data)-keep:]...)
+	if _, err := w.ResponseWriter.Write(data[:len(data)-keep]); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// partial is the length of the longest suffix of data that is a proper
+// prefix of the placeholder.
+func (w *placeholderWriter) partial(data []byte) int {
+	for n := min(len(w.placeholder)-1, len(data)); n > 0; n-- {
+		if bytes.HasPrefix(w.placeholder, data[len(data)-n:]) {
+			return n
+		}
+	}
+	return 0
+}
+
+func (w *placeholderWriter) finish() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.status = 0
+	}
+	if len(w.held) > 0 {
+		w.ResponseWriter.Write(w.held)
+		w.held = nil
+	}
+}
+
+func (w *placeholderWriter) Flush() {
+	if w.status != 0 {
+		w.check(nil)
+		w.ResponseWriter.WriteHeader(w.status)
+		w.status = 0
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *placeholderWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
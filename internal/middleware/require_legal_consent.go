@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// pendingLegalDocument is a legal document version the user has yet to
+// accept, as listed in the 403 body.
+type pendingLegalDocument struct {
+	ID      uuid.UUID `json:"id"`
+	Kind    string    `json:"kind"`
+	Version string    `json:"version"`
+	Title   string    `json:"title"`
+	Summary string    `json:"summary"`
+}
+
+// RequireLegalConsent blocks app users who haven't accepted the Terms of
+// Service / Privacy Policy versions in effect (see legal_documents) until
+// they do. A version only blocks once its effective date has passed and
+// only if it was published with requires_acceptance; admins are never
+// blocked.
+//
+// exempt are path prefixes that must stay reachable while acceptance is
+// pending: the acceptance endpoints themselves, logout, and account
+// deletion for users who'd rather leave than agree. Fails OPEN on a DB
+// error, same as LoadEntitlement.
+//
+// On block, returns 403 + JSON body listing the pending documents for
+// /api/* requests, or a 303 redirect to /legal/accept for web pages.
+func RequireLegalConsent(db *sql.DB, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := GetUserID(r.Context())
+			if userID == uuid.Nil || HasSystemRole(r.Context()) || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, prefix := range exempt {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			pending, err := pendingLegalDocuments(r, db, userID)
+			if err != nil || len(pending) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeLegalConsentBlock(w, r, pending)
+		})
+	}
+}
+
+func pendingLegalDocuments(r *http.Request, db *sql.DB, userID uuid.UUID) ([]pendingLegalDocument, error) {
+	rows, err := db.QueryContext(r.Context(), `
+        WITH in_effect AS (
+            SELECT DISTINCT ON (kind) id, kind, version, title, summary, requires_acceptance
+            FROM legal_documents
+            WHERE published_at IS NOT NULL AND effective_at <= NOW()
+            ORDER BY kind, effective_at DESC, published_at DESC
+        )
+        SELECT d.id, d.kind, d.version, d.title, d.summary
+        FROM in_effect d
+        WHERE d.requires_acceptance
+          AND NOT EXISTS (SELECT 1 FROM legal_acceptances a WHERE a.document_id = d.id AND a.user_id = $1)
+        ORDER BY d.kind`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []pendingLegalDocument
+	for rows.Next() {
+		var d pendingLegalDocument
+		if err := rows.Scan(&d.ID, &d.Kind, &d.Version, &d.Title, &d.Summary); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func writeLegalConsentBlock(w http.ResponseWriter, r *http.Request, pending []pendingLegalDocument) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":     "legal_acceptance_required",
+			"message":   "Our terms have changed. Please review and accept them to continue.",
+			"documents": pending,
+		})
+		return
+	}
+	target := "/legal/accept"
+	if r.Method == http.MethodGet {
+		target += "?next=" + url.QueryEscape(r.URL.RequestURI())
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
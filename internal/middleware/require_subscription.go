@@ -32,17 +32,39 @@ type Entitlement struct {
 	TrialEnd         *time.Time
 	PeriodEnd        *time.Time
 	PastDueSince     *time.Time
-	ReadOnlyUntil    *time.Time // past_due_since + 14d
+	CancelledAt      *time.Time
+	ReadOnlyUntil    *time.Time // when the lapse began + the read-only grace period
 	IsAdminOverride  bool       // true when system_role bypassed the check
 	HasSubscription  bool       // false if family has no row (treat as full per current alpha behavior)
+	ViaOrganization  bool       // true when an active organization covers the family
 }
 
 const EntitlementKey contextKey = "entitlement"
 
-// readOnlyWindow is the grace period after trial-end / payment-failure
-// during which the family can read + export + delete + contact support
-// but cannot create new content. Locked to 14 days per the billing spec.
-const readOnlyWindow = 14 * 24 * time.Hour
+// readOnlyWindow is the grace period after trial-end / payment-failure /
+// the end of a cancelled period during which the family can read + export
+// + delete + contact support but cannot create new content. 14 days per
+// the billing spec unless SUBSCRIPTION_READ_ONLY_GRACE_DAYS says otherwise
+// (see SetReadOnlyGrace).
+var readOnlyWindow = 14 * 24 * time.Hour
+
+// SetReadOnlyGrace sets the read-only grace period. Call once at startup,
+// before serving; non-positive values keep the default. The expiry
+// sweeper must use the same value (SubscriptionService.SetReadOnlyGrace)
+// or families are terminated while still shown a grace deadline.
+func SetReadOnlyGrace(d time.Duration) {
+	if d > 0 {
+		readOnlyWindow = d
+	}
+}
+
+// Headers set on every /api/* response while the family's access is
+// restricted, so clients can switch to read-only UI without waiting for a
+// write to be refused.
+const (
+	HeaderSubscriptionMode = "X-Subscription-Mode"
+	HeaderReadOnlyUntil    = "X-Subscription-Read-Only-Until"
+)
 
 // LoadEntitlement reads the family's subscription row (if any) and stores
 // an Entitlement in the request context. Always continues to the next
@@ -54,6 +76,12 @@ func LoadEntitlement(db *sql.DB) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ent := computeEntitlement(r.Context(), db)
+			if ent.Mode != EntitlementFull && strings.HasPrefix(r.URL.Path, "/api/") {
+				w.Header().Set(HeaderSubscriptionMode, string(ent.Mode))
+				if ent.ReadOnlyUntil != nil {
+					w.Header().Set(HeaderReadOnlyUntil, ent.ReadOnlyUntil.Format(time.RFC3339))
+				}
+			}
 			ctx := context.WithValue(r.Context(), EntitlementKey, ent)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -78,17 +106,34 @@ func computeEntitlement(ctx context.Context, db *sql.DB) Entitlement {
 		return Entitlement{Mode: EntitlementFull, HasSubscription: false}
 	}
 
+	// A family linked to an active organization is covered by the
+	// organization's subscription, whatever the state of its own. A
+	// suspended organization, or one whose seat subscription has lapsed
+	// (cancelled or unpaid), stops covering it and its own row applies.
+	// Organizations support set up without Stripe have no billing status.
+	var covered bool
+	if err := db.QueryRowContext(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM organization_families f
+            JOIN organizations o ON o.id = f.organization_id
+            WHERE f.family_id = $1 AND o.status = 'active'
+              AND COALESCE(o.billing_status, '') NOT IN ('canceled', 'unpaid', 'incomplete_expired'))`, familyID,
+	).Scan(&covered); err == nil && covered {
+		return Entitlement{Mode: EntitlementFull, HasSubscription: true, ViaOrganization: true}
+	}
+
 	var (
 		status        string
 		trialEnd      sql.NullTime
 		periodEnd     sql.NullTime
 		pastDueSince  sql.NullTime
+		cancelledAt   sql.NullTime
 	)
 	err := db.QueryRowContext(ctx, `
-        SELECT status, trial_end, current_period_end, past_due_since
+        SELECT status, trial_end, current_period_end, past_due_since, cancelled_at
         FROM family_subscriptions
         WHERE family_id = $1`, familyID,
-	).Scan(&status, &trialEnd, &periodEnd, &pastDueSince)
+	).Scan(&status, &trialEnd, &periodEnd, &pastDueSince, &cancelledAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		// Family has no subscription row. During alpha we permit access
 		// (Phase 2 fires StartTrialIfNew on every signup, but legacy rows
@@ -117,47 +162,84 @@ func computeEntitlement(ctx context.Context, db *sql.DB) Entitlement {
 	if pastDueSince.Valid {
 		t := pastDueSince.Time
 		ent.PastDueSince = &t
-		deadline := t.Add(readOnlyWindow)
-		ent.ReadOnlyUntil = &deadline
 	}
+	if cancelledAt.Valid {
+		t := cancelledAt.Time
+		ent.CancelledAt = &t
+	}
+	ent.Mode, ent.ReadOnlyUntil = EntitlementModeAt(ent, time.Now())
+	return ent
+}
 
-	now := time.Now()
-	switch status {
+// EntitlementModeAt decides the mode for a family's own subscription at
+// `now` from ent's Status and dates and, when access is restricted, until
+// when it stays read-only. A lapse never
+// locks a family out of reading its records: it is read-only for
+// readOnlyWindow from when the lapse began, then blocked (reads still
+// pass; see EnforceWriteEntitlement).
+func EntitlementModeAt(ent Entitlement, now time.Time) (EntitlementMode, *time.Time) {
+	lapsed := func(since *time.Time) (EntitlementMode, *time.Time) {
+		if since == nil {
+			return EntitlementBlocked, nil
+		}
+		deadline := since.Add(readOnlyWindow)
+		if deadline.After(now) {
+			return EntitlementReadOnly, &deadline
+		}
+		return EntitlementBlocked, &deadline
+	}
+	switch ent.Status {
 	case "active", "comped":
 		// period_end may be in the past on `active` if the periodic Stripe
 		// renewal hasn't landed yet — be lenient by 24h to absorb that lag.
 		if ent.PeriodEnd == nil || ent.PeriodEnd.Add(24*time.Hour).After(now) {
-			ent.Mode = EntitlementFull
-		} else {
-			ent.Mode = EntitlementBlocked
+			return EntitlementFull, nil
 		}
+		return lapsed(ent.PeriodEnd)
 	case "trialing":
 		if ent.TrialEnd != nil && ent.TrialEnd.After(now) {
-			ent.Mode = EntitlementFull
-		} else {
-			// Trial has lapsed but the hourly sweeper hasn't flipped to
-			// past_due yet. Treat as read-only with a synthetic deadline
-			// so the user isn't fully blocked between sweeps.
-			ent.Mode = EntitlementReadOnly
-			if ent.TrialEnd != nil {
-				deadline := ent.TrialEnd.Add(readOnlyWindow)
-				ent.ReadOnlyUntil = &deadline
-			}
+			return EntitlementFull, nil
 		}
-	case "past_due":
-		if ent.ReadOnlyUntil != nil && ent.ReadOnlyUntil.After(now) {
-			ent.Mode = EntitlementReadOnly
-		} else {
-			ent.Mode = EntitlementBlocked
+		// Trial has lapsed but the hourly sweeper hasn't flipped to
+		// past_due yet. Count the grace period from trial_end so the
+		// user isn't fully blocked between sweeps.
+		if ent.TrialEnd == nil {
+			return EntitlementReadOnly, nil
 		}
+		return lapsed(ent.TrialEnd)
+	case "past_due":
+		return lapsed(ent.PastDueSince)
 	case "paused":
-		ent.Mode = EntitlementReadOnly
-	case "cancelled", "expired", "terminated":
-		ent.Mode = EntitlementBlocked
+		return EntitlementReadOnly, nil
+	case "cancelled", "expired":
+		// Access was paid for until period_end; the grace period starts
+		// there, or at the cancellation if that came first.
+		since := ent.PeriodEnd
+		if since == nil || (ent.CancelledAt != nil && ent.CancelledAt.Before(*since)) {
+			since = ent.CancelledAt
+		}
+		return lapsed(since)
+	case "terminated":
+		return EntitlementBlocked, nil
 	default:
-		ent.Mode = EntitlementFull
+		return EntitlementFull, nil
 	}
-	return ent
+}
+
+// EntitlementMessage explains a restricted entitlement to the family in
+// one sentence; "" when access is full.
+func EntitlementMessage(ent Entitlement) string {
+	switch ent.Mode {
+	case EntitlementReadOnly:
+		msg := "Your subscription has lapsed, so your account is read-only: you can still view, export and delete your family's records, but not add to them."
+		if ent.ReadOnlyUntil != nil {
+			msg += " Subscribe by " + ent.ReadOnlyUntil.UTC().Format("Jan 2, 2006") + " to keep full access."
+		}
+		return msg + " Paying restores full access right away."
+	case EntitlementBlocked:
+		return "Your subscription has ended. You can still view your family's records; subscribe to make changes again."
+	}
+	return ""
 }
 
 // GetEntitlement extracts the entitlement set by LoadEntitlement. Falls
@@ -215,9 +297,10 @@ func writeEntitlementBlock(w http.ResponseWriter, r *http.Request, ent Entitleme
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusPaymentRequired)
 		body := map[string]interface{}{
-			"error":  "subscription_required",
-			"mode":   ent.Mode,
-			"status": ent.Status,
+			"error":   "subscription_required",
+			"message": EntitlementMessage(ent),
+			"mode":    ent.Mode,
+			"status":  ent.Status,
 		}
 		if ent.ReadOnlyUntil != nil {
 			body["read_only_until"] = ent.ReadOnlyUntil.Format(time.RFC3339)
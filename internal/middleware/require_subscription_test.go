@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"carecompanion/internal/middleware"
+)
+
+func TestEntitlementModeAt(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	for _, c := range []struct {
+		name  string
+		ent   middleware.Entitlement
+		want  middleware.EntitlementMode
+		until *time.Time
+	}{
+		{"active", middleware.Entitlement{Status: "active", PeriodEnd: at(10 * day)}, middleware.EntitlementFull, nil},
+		{"active, renewal late by hours", middleware.Entitlement{Status: "active", PeriodEnd: at(-2 * time.Hour)}, middleware.EntitlementFull, nil},
+		{"active, renewal never came", middleware.Entitlement{Status: "active", PeriodEnd: at(-3 * day)}, middleware.EntitlementReadOnly, at(11 * day)},
+		{"trial running", middleware.Entitlement{Status: "trialing", TrialEnd: at(day)}, middleware.EntitlementFull, nil},
+		{"trial lapsed before sweep", middleware.Entitlement{Status: "trialing", TrialEnd: at(-time.Hour)}, middleware.EntitlementReadOnly, at(14*day - time.Hour)},
+		{"payment failed", middleware.Entitlement{Status: "past_due", PastDueSince: at(-2 * day)}, middleware.EntitlementReadOnly, at(12 * day)},
+		{"grace over", middleware.Entitlement{Status: "past_due", PastDueSince: at(-15 * day)}, middleware.EntitlementBlocked, at(-day)},
+		{"cancelled, period paid for", middleware.Entitlement{Status: "cancelled", PeriodEnd: at(-day), CancelledAt: at(-20 * day)}, middleware.EntitlementBlocked, at(-6 * day)},
+		{"cancelled at period end", middleware.Entitlement{Status: "cancelled", PeriodEnd: at(-day), CancelledAt: at(-day)}, middleware.EntitlementReadOnly, at(13 * day)},
+		{"expired without dates", middleware.Entitlement{Status: "expired"}, middleware.EntitlementBlocked, nil},
+		{"terminated", middleware.Entitlement{Status: "terminated"}, middleware.EntitlementBlocked, nil},
+		{"comped", middleware.Entitlement{Status: "comped"}, middleware.EntitlementFull, nil},
+	} {
+		mode, until := middleware.EntitlementModeAt(c.ent, now)
+		if mode != c.want {
+			t.Errorf("%s: mode %s, want %s", c.name, mode, c.want)
+		}
+		if (until == nil) != (c.until == nil) || (until != nil && !until.Equal(*c.until)) {
+			t.Errorf("%s: read-only until %v, want %v", c.name, until, c.until)
+		}
+	}
+}
+
+func TestEnforceWriteEntitlement(t *testing.T) {
+	until := time.Now().Add(5 * 24 * time.Hour)
+	readOnly := middleware.Entitlement{Mode: middleware.EntitlementReadOnly, Status: "past_due", ReadOnlyUntil: &until}
+	blocked := middleware.Entitlement{Mode: middleware.EntitlementBlocked, Status: "terminated"}
+	handler := middleware.EnforceWriteEntitlement()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, c := range []struct {
+		name, method string
+		ent          middleware.Entitlement
+		want         int
+	}{
+		{"read-only can read", http.MethodGet, readOnly, http.StatusNoContent},
+		{"read-only can delete", http.MethodDelete, readOnly, http.StatusNoContent},
+		{"read-only can't write", http.MethodPost, readOnly, http.StatusPaymentRequired},
+		{"blocked can still read", http.MethodGet, blocked, http.StatusNoContent},
+		{"blocked can't delete", http.MethodDelete, blocked, http.StatusPaymentRequired},
+	} {
+		req := httptest.NewRequest(c.method, "/api/logs", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.EntitlementKey, c.ent))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/logs", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.EntitlementKey, readOnly))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "subscription_required" || body["mode"] != "read_only" || body["read_only_until"] == "" ||
+		!strings.Contains(body["message"], "read-only") {
+		t.Errorf("402 body = %v", body)
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequireVerifiedEmail blocks state-mutating requests from app users whose
+// email verification grace period (app_users.email_verify_due_at) has
+// passed without a verified address. Reads always pass, as do admins and
+// users still inside the grace period.
+//
+// Apply per route, on features that send mail to other people on the
+// user's behalf (invites, report sharing, scheduled reports) — an
+// unverified address there is exactly what hurts deliverability. Fails
+// OPEN on a DB error, same as LoadEntitlement.
+//
+// On block, returns 403 + JSON body for /api/* requests, or a 303 redirect
+// to /settings (where the resend button lives) for web form submits.
+func RequireVerifiedEmail(db *sql.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID := GetUserID(r.Context())
+			if userID == uuid.Nil || HasSystemRole(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var dueAt time.Time
+			err := db.QueryRowContext(r.Context(), `
+                SELECT email_verify_due_at FROM app_users
+                WHERE id = $1 AND email_verified_at IS NULL AND email_verify_due_at <= NOW()`, userID,
+			).Scan(&dueAt)
+			if err != nil {
+				// sql.ErrNoRows means verified or still in grace.
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeVerificationBlock(w, r, dueAt)
+		})
+	}
+}
+
+func writeVerificationBlock(w http.ResponseWriter, r *http.Request, dueAt time.Time) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   "email_verification_required",
+			"message": "Please confirm your email address to use this feature. You can resend the link from Settings.",
+			"due_at":  dueAt.Format(time.RFC3339),
+		})
+		return
+	}
+	http.Redirect(w, r, "/settings?verify_email=1", http.StatusSeeOther)
+}
@@ -1,11 +1,36 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/google/uuid"
+
 	"carecompanion/internal/auth"
 )
 
+// ElevationResolver reports temporary access an admin has been granted to
+// a section beyond their role: the level and the elevation's id, or
+// uuid.Nil when there's none. See service.AdminElevationService.
+type ElevationResolver interface {
+	ElevatedLevel(ctx context.Context, adminID uuid.UUID, section string) (auth.Level, uuid.UUID)
+}
+
+var elevations ElevationResolver
+
+// SetElevationResolver wires just-in-time elevation into RequireSection
+// from main.go. nil turns it off.
+func SetElevationResolver(r ElevationResolver) { elevations = r }
+
+type elevationKey struct{}
+
+// GetElevationID returns the elevation that let this request through
+// RequireSection, uuid.Nil when the admin's role was enough.
+func GetElevationID(ctx context.Context) uuid.UUID {
+	id, _ := ctx.Value(elevationKey{}).(uuid.UUID)
+	return id
+}
+
 // RequireSection gates a route by the permission matrix in internal/auth.
 // The required level is derived from the HTTP method (GET/HEAD/OPTIONS need
 // read, DELETE needs full, others need write). Super admin short-circuits
@@ -28,6 +53,13 @@ func RequireSection(section string) func(http.Handler) http.Handler {
 				return
 			}
 			if !auth.Allows(claims.SystemRole, section, r.Method) {
+				if elevations != nil {
+					lvl, id := elevations.ElevatedLevel(r.Context(), claims.UserID, section)
+					if id != uuid.Nil && auth.RankAtLeast(lvl, auth.RequiredLevelForMethod(r.Method)) {
+						next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), elevationKey{}, id)))
+						return
+					}
+				}
 				JSONError(w, "Forbidden: section_"+section+"_denied", http.StatusForbidden)
 				return
 			}
@@ -35,3 +67,20 @@ func RequireSection(section string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// RejectReadOnlyWrites refuses every non-read request from a role
+// auth.ReadOnly reports (the auditor), including routes that gate by role
+// inside the handler rather than by section. Requests without claims pass
+// through for the route's own auth to handle.
+func RejectReadOnlyWrites() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetAuthClaims(r.Context())
+			if claims != nil && auth.ReadOnly(claims.SystemRole) && auth.RequiredLevelForMethod(r.Method) != auth.LevelRead {
+				JSONError(w, "Forbidden: read_only_role", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
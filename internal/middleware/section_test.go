@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/google/uuid"
+
+	"carecompanion/internal/auth"
 	"carecompanion/internal/middleware"
 	"carecompanion/internal/models"
 	"carecompanion/internal/service"
@@ -85,3 +88,69 @@ func TestRequireSection_NoClaims401(t *testing.T) {
 		t.Fatalf("status = %d, want 401", rec.Code)
 	}
 }
+
+type fakeElevations struct {
+	level auth.Level
+	id    uuid.UUID
+}
+
+func (f fakeElevations) ElevatedLevel(context.Context, uuid.UUID, string) (auth.Level, uuid.UUID) {
+	return f.level, f.id
+}
+
+func TestRequireSection_Elevation(t *testing.T) {
+	grant := uuid.New()
+	middleware.SetElevationResolver(fakeElevations{level: auth.LevelWrite, id: grant})
+	defer middleware.SetElevationResolver(nil)
+
+	var seen uuid.UUID
+	h := middleware.RequireSection("development_mode")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = middleware.GetElevationID(r.Context())
+	}))
+	for _, m := range []string{"GET", "POST"} {
+		seen = uuid.Nil
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, reqWithRole(m, "/x", models.SystemRolePartner))
+		if seen != grant {
+			t.Errorf("elevated partner %s: status %d, elevation %v", m, rec.Code, seen)
+		}
+	}
+
+	// Write doesn't cover DELETE.
+	rec := httptest.NewRecorder()
+	seen = uuid.Nil
+	h.ServeHTTP(rec, reqWithRole("DELETE", "/x", models.SystemRolePartner))
+	if rec.Code != http.StatusForbidden || seen != uuid.Nil {
+		t.Fatalf("DELETE status = %d, want 403", rec.Code)
+	}
+
+	// Without a grant the matrix stands.
+	middleware.SetElevationResolver(fakeElevations{level: auth.LevelNone})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, reqWithRole("GET", "/x", models.SystemRolePartner))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("no elevation: status = %d, want 403", rec.Code)
+	}
+}
+
+func TestRejectReadOnlyWrites(t *testing.T) {
+	h := middleware.RejectReadOnlyWrites()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, c := range []struct {
+		method string
+		role   models.SystemRole
+		want   int
+	}{
+		{"GET", models.SystemRoleAuditor, http.StatusOK},
+		{"HEAD", models.SystemRoleAuditor, http.StatusOK},
+		{"POST", models.SystemRoleAuditor, http.StatusForbidden},
+		{"PUT", models.SystemRoleAuditor, http.StatusForbidden},
+		{"DELETE", models.SystemRoleAuditor, http.StatusForbidden},
+		{"POST", models.SystemRoleSupport, http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, reqWithRole(c.method, "/x", c.role))
+		if rec.Code != c.want {
+			t.Errorf("%s as %s: status %d, want %d", c.method, c.role, rec.Code, c.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"carecompanion/internal/service"
+)
+
+// CSPNoncePlaceholder is what the cspNonce template function emits. The
+// templates are parsed once and rendered without the request, so they
+// can't know the nonce; SecurityHeaders swaps the placeholder for the
+// request's nonce as the HTML is written.
+const CSPNoncePlaceholder = "csp-nonce-9c1f7e2ab4"
+
+type cspNonceKey struct{}
+
+// CSPNonce returns the request's script nonce, or "" when the policy uses
+// none.
+func CSPNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(cspNonceKey{}).(string)
+	return nonce
+}
+
+// SecurityHeadersOptions is the per-environment part of the headers, from
+// config.SecurityConfig.
+type SecurityHeadersOptions struct {
+	// CSP is the policy; "{nonce}" is replaced per request. Empty or "off"
+	// sends no policy.
+	CSP        string
+	ReportOnly bool
+	// ReportURI is where browsers POST violation reports.
+	ReportURI string
+}
+
+// SecurityHeaders sets the security response headers: the CSP from config,
+// and HSTS and X-Frame-Options from the admin settings.
+type SecurityHeaders struct {
+	opts     SecurityHeadersOptions
+	settings *service.SecurityHeadersService
+}
+
+// NewSecurityHeaders creates the middleware. settings may be nil, in which
+// case the service defaults apply.
+func NewSecurityHeaders(opts SecurityHeadersOptions, settings *service.SecurityHeadersService) *SecurityHeaders {
+	if opts.CSP == "off" {
+		opts.CSP = ""
+	}
+	return &SecurityHeaders{opts: opts, settings: settings}
+}
+
+// Middleware sets the headers and, when the policy has a nonce, fills it
+// into HTML responses. It must sit inside the Compress middleware so it
+// sees the HTML before it is gzipped.
+func (sh *SecurityHeaders) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-XSS-Protection", "1; mode=block")
+		h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+
+		settings := service.DefaultSecurityHeaderSettings
+		if sh.settings != nil {
+			settings = sh.settings.Current(r.Context())
+		}
+		if settings.FrameOptions != service.FrameOptionsOff {
+			h.Set("X-Frame-Options", settings.FrameOptions)
+		}
+		// Browsers ignore HSTS over plain HTTP; TLS ends at the ALB.
+		if settings.HSTSEnabled && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+			v := "max-age=" + strconv.Itoa(settings.HSTSMaxAgeDays*24*60*60)
+			if settings.HSTSIncludeSubdomains {
+				v += "; includeSubDomains"
+			}
+			h.Set("Strict-Transport-Security", v)
+		}
+
+		if sh.opts.CSP == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		policy := sh.opts.CSP
+		var nonce string
+		if strings.Contains(policy, "{nonce}") {
+			nonce = newCSPNonce()
+			policy = strings.ReplaceAll(policy, "{nonce}", nonce)
+			r = r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, nonce))
+		}
+		if sh.opts.ReportURI != "" {
+			policy += "; report-uri " + sh.opts.ReportURI + "; report-to csp"
+			h.Set("Reporting-Endpoints", `csp="`+sh.opts.ReportURI+`"`)
+		}
+		if sh.opts.ReportOnly {
+			h.Set("Content-Security-Policy-Report-Only", policy)
+		} else {
+			h.Set("Content-Security-Policy", policy)
+		}
+
+		if nonce == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pw := newPlaceholderWriter(w, CSPNoncePlaceholder, nonce)
+		defer pw.finish()
+		next.ServeHTTP(pw, r)
+	})
+}
+
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// ReportHandler receives CSP violation reports at opts.ReportURI, in either
+// the report-uri format ({"csp-report": {...}}) or the Reporting API
+// format ([{"type": "csp-violation", "body": {...}}]), and records them
+// through the settings service. It always answers 204; browsers don't
+// retry or show failures.
+func (sh *SecurityHeaders) ReportHandler(w http.ResponseWriter, r *http.Request) {
+	defer w.WriteHeader(http.StatusNoContent)
+	if sh.settings == nil {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		return
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	for _, v := range parseCSPReports(body) {
+		if _, err := sh.settings.RecordCSPViolation(r.Context(), v, r.UserAgent(), ip); err != nil {
+			log.Printf("[CSP] record violation: %v", err)
+		}
+	}
+}
+
+// cspReportBody covers both formats' field names.
+type cspReportBody struct {
+	DocumentURI         string `json:"document-uri"`
+	DocumentURL         string `json:"documentURL"`
+	ViolatedDirective   string `json:"violated-directive"`
+	EffectiveDirective  string `json:"effective-directive"`
+	EffectiveDirective2 string `json:"effectiveDirective"`
+	BlockedURI          string `json:"blocked-uri"`
+	BlockedURL          string `json:"blockedURL"`
+	SourceFile          string `json:"source-file"`
+	SourceFile2         string `json:"sourceFile"`
+	LineNumber          int    `json:"line-number"`
+	LineNumber2         int    `json:"lineNumber"`
+	Disposition         string `json:"disposition"`
+}
+
+func (b cspReportBody) violation() service.CSPViolation {
+	first := func(vals ...string) string {
+		for _, v := range vals {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	line := b.LineNumber
+	if line == 0 {
+		line = b.LineNumber2
+	}
+	return service.CSPViolation{
+		DocumentURI: first(b.DocumentURI, b.DocumentURL),
+		Directive:   first(b.EffectiveDirective, b.EffectiveDirective2, b.ViolatedDirective),
+		BlockedURI:  first(b.BlockedURI, b.BlockedURL),
+		SourceFile:  first(b.SourceFile, b.SourceFile2),
+		Line:        line,
+		Disposition: b.Disposition,
+	}
+}
+
+func parseCSPReports(body []byte) []service.CSPViolation {
+	var legacy struct {
+		Report *cspReportBody `json:"csp-report"`
+	}
+	if json.Unmarshal(body, &legacy) == nil && legacy.Report != nil {
+		return []service.CSPViolation{legacy.Report.violation()}
+	}
+	var batch []struct {
+		Type string        `json:"type"`
+		Body cspReportBody `json:"body"`
+	}
+	if json.Unmarshal(body, &batch) != nil {
+		return nil
+	}
+	var out []service.CSPViolation
+	for _, rep := range batch {
+		if rep.Type == "csp-violation" && len(out) < 20 {
+			out = append(out, rep.Body.violation())
+		}
+	}
+	return out
+}
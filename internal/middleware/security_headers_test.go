@@ -0,0 +1,135 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+// settingsMap is an in-memory system_settings store.
+type settingsMap map[string]interface{}
+
+func (m settingsMap) GetSetting(ctx context.Context, key string) (interface{}, error) {
+	return m[key], nil
+}
+
+func (m settingsMap) UpdateSetting(ctx context.Context, key string, value interface{}, updatedBy uuid.UUID) error {
+	m[key] = value
+	return nil
+}
+
+// violations records the CSP reports the handler passes on.
+type violations struct{ messages []string }
+
+func (v *violations) RecordViolation(ctx context.Context, path, message, userAgent, ip string) error {
+	v.messages = append(v.messages, path+" "+message)
+	return nil
+}
+
+func TestSecurityHeadersNonce(t *testing.T) {
+	svc := service.NewSecurityHeadersService(settingsMap{}, &violations{})
+	sh := middleware.NewSecurityHeaders(middleware.SecurityHeadersOptions{
+		CSP:        "script-src 'self' 'nonce-{nonce}'",
+		ReportOnly: true,
+		ReportURI:  "/csp-report",
+	}, svc)
+
+	var ctxNonce string
+	handler := sh.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxNonce = middleware.CSPNonce(r.Context())
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		// Split the placeholder across writes, as template output may.
+		p := middleware.CSPNoncePlaceholder
+		io.WriteString(w, `<script nonce="`+p[:5])
+		io.WriteString(w, p[5:]+`">a()</script><script nonce="`+p+`">`)
+		io.WriteString(w, "b()</script>csp-")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+
+	if ctxNonce == "" {
+		t.Fatal("no nonce in request context")
+	}
+	want := `<script nonce="` + ctxNonce + `">a()</script><script nonce="` + ctxNonce + `">b()</script>csp-`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	policy := rec.Header().Get("Content-Security-Policy-Report-Only")
+	if policy != "script-src 'self' 'nonce-"+ctxNonce+"'; report-uri /csp-report; report-to csp" {
+		t.Errorf("policy = %q", policy)
+	}
+	if rec.Header().Get("Content-Security-Policy") != "" {
+		t.Error("enforced policy sent in report-only mode")
+	}
+	if got := rec.Header().Get("X-Frame-Options"); got != "SAMEORIGIN" {
+		t.Errorf("X-Frame-Options = %q, want SAMEORIGIN", got)
+	}
+}
+
+func TestSecurityHeadersFromSettings(t *testing.T) {
+	settings := settingsMap{}
+	svc := service.NewSecurityHeadersService(settings, &violations{})
+	if err := svc.UpdateSettings(context.Background(), service.SecurityHeaderSettings{
+		HSTSEnabled: true, HSTSMaxAgeDays: 365, HSTSIncludeSubdomains: true, FrameOptions: service.FrameOptionsOff,
+	}, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	sh := middleware.NewSecurityHeaders(middleware.SecurityHeadersOptions{CSP: "off"}, svc)
+	handler := sh.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/children", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("HSTS over plain HTTP = %q", got)
+	}
+
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=31536000; includeSubDomains" {
+		t.Errorf("HSTS = %q", got)
+	}
+	if rec.Header().Get("X-Frame-Options") != "" || rec.Header().Get("Content-Security-Policy") != "" {
+		t.Errorf("headers = %v, want no frame options or CSP", rec.Header())
+	}
+	if rec.Body.String() != `{"ok":true}` {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
+
+func TestCSPReportHandler(t *testing.T) {
+	reports := &violations{}
+	svc := service.NewSecurityHeadersService(settingsMap{}, reports)
+	sh := middleware.NewSecurityHeaders(middleware.SecurityHeadersOptions{}, svc)
+
+	for _, body := range []string{
+		`{"csp-report":{"document-uri":"https://app.example.com/settings","violated-directive":"script-src-elem","blocked-uri":"inline","disposition":"report"}}`,
+		`[{"type":"csp-violation","body":{"documentURL":"https://app.example.com/reports","effectiveDirective":"img-src","blockedURL":"http://cdn.example.net/a.png","disposition":"enforce"}},{"type":"deprecation","body":{}}]`,
+		`not json`,
+	} {
+		rec := httptest.NewRecorder()
+		sh.ReportHandler(rec, httptest.NewRequest(http.MethodPost, "/csp-report", strings.NewReader(body)))
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("status = %d, want 204", rec.Code)
+		}
+	}
+	want := []string{
+		"/settings [report-only] script-src-elem blocked inline",
+		"/reports img-src blocked http://cdn.example.net/a.png",
+	}
+	if strings.Join(reports.messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("recorded %q, want %q", reports.messages, want)
+	}
+}
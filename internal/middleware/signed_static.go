@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"carecompanion/internal/service"
+)
+
+// SignedStatic guards the /static file server: paths the signer marks as
+// protected are served only with a valid exp/sig pair (see
+// service.AssetSigner), everything else passes through. Signed responses
+// are private and cacheable only until the link expires.
+func SignedStatic(signer *service.AssetSigner) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if signer == nil || !signer.Protected(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			q := r.URL.Query()
+			expUnix, err := strconv.ParseInt(q.Get("exp"), 10, 64)
+			if err != nil || signer.Verify(r.URL.Path, expUnix, q.Get("sig")) != nil {
+				http.Error(w, "Link expired or invalid", http.StatusForbidden)
+				return
+			}
+			maxAge := max(expUnix-time.Now().Unix(), 0)
+			w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(maxAge, 10))
+			w.Header().Set("X-Robots-Tag", "noindex")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/service"
+)
+
+func TestSignedStatic(t *testing.T) {
+	signer := service.NewAssetSigner("secret", "/static/marketing/")
+	handler := middleware.SignedStatic(signer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("file"))
+	}))
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	if rec := get("/static/css/calm.css"); rec.Code != http.StatusOK {
+		t.Errorf("public file: status %d", rec.Code)
+	}
+	if rec := get("/static/marketing/brochures/a.pdf"); rec.Code != http.StatusForbidden {
+		t.Errorf("unsigned protected file: status %d, want 403", rec.Code)
+	}
+	signed, _ := signer.SignedURL("/static/marketing/brochures/a.pdf", time.Minute)
+	rec := get(signed)
+	if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") == "" {
+		t.Errorf("signed file: status %d, headers %v", rec.Code, rec.Header())
+	}
+	other, _ := signer.SignedURL("/static/marketing/brochures/b.pdf", time.Minute)
+	if rec := get("/static/marketing/brochures/a.pdf?" + other[len("/static/marketing/brochures/b.pdf?"):]); rec.Code != http.StatusForbidden {
+		t.Errorf("signature for another file: status %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// SparseFields parses the JSON:API-style fields query parameter
+// ("?fields=log_date,mood_level"). It returns nil when the client asked for
+// the full representation.
+func SparseFields(r *http.Request) []string {
+	var fields []string
+	for _, f := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// ProjectJSON drops every key except fields (and "id") from v's JSON form,
+// an object or an array of objects. The repository projection leaves the
+// unselected struct fields zero; this keeps those zeros off the wire so a
+// missing key means "not requested" rather than "empty".
+func ProjectJSON(v any, fields []string) (json.RawMessage, error) {
+	raw, err := json.Marshal(v)
+	if err != nil || len(fields) == 0 {
+		return raw, err
+	}
+	keep := map[string]bool{"id": true}
+	for _, f := range fields {
+		keep[f] = true
+	}
+	filter := func(obj map[string]json.RawMessage) {
+		for k := range obj {
+			if !keep[k] {
+				delete(obj, k)
+			}
+		}
+	}
+
+	var list []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		if list == nil {
+			return raw, nil
+		}
+		for _, obj := range list {
+			filter(obj)
+		}
+		return json.Marshal(list)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	filter(obj)
+	return json.Marshal(obj)
+}
@@ -0,0 +1,48 @@
+package middleware_test
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"carecompanion/internal/middleware"
+)
+
+func TestSparseFields(t *testing.T) {
+	r := httptest.NewRequest("GET", "/logs?fields=log_date,+mood_level,,", nil)
+	if got := middleware.SparseFields(r); !reflect.DeepEqual(got, []string{"log_date", "mood_level"}) {
+		t.Errorf("SparseFields = %v", got)
+	}
+	if got := middleware.SparseFields(httptest.NewRequest("GET", "/logs", nil)); got != nil {
+		t.Errorf("no fields param: %v, want nil", got)
+	}
+}
+
+func TestProjectJSON(t *testing.T) {
+	type row struct {
+		ID    string `json:"id"`
+		Date  string `json:"log_date"`
+		Mood  int    `json:"mood_level"`
+		Notes string `json:"notes"`
+	}
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"list", []row{{ID: "a", Date: "2026-01-02", Mood: 3}}, `[{"id":"a","mood_level":3}]`},
+		{"object", row{ID: "a", Notes: "x"}, `{"id":"a","mood_level":0}`},
+		{"nil list", []row(nil), `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := middleware.ProjectJSON(tt.v, []string{"mood_level"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ProjectJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/auth"
+	"carecompanion/internal/service"
+)
+
+// StepUpChecker reports whether the session's owner entered their password
+// recently enough for a sensitive action: nil, service.ErrStepUpRequired,
+// or a lookup error. See service.AuthService.CheckRecentAuth.
+type StepUpChecker interface {
+	CheckRecentAuth(ctx context.Context, sid uuid.UUID) error
+}
+
+var stepUp StepUpChecker
+
+// SetStepUpChecker wires step-up auth into RequireRecentAuth from main.go.
+// nil turns it off.
+func SetStepUpChecker(c StepUpChecker) { stepUp = c }
+
+// StepUpRequiredHeader is set on the 403 RequireRecentAuth answers with, so
+// admin_session_guard.js can ask for the password and retry.
+const StepUpRequiredHeader = "X-Step-Up-Required"
+
+// RequireRecentAuth gates sensitive admin changes behind step-up auth:
+// mutating requests from an admin who last entered their password outside
+// the session policy's window get 403 with StepUpRequiredHeader set until
+// they re-authenticate (POST /api/admin/auth/reauth). Reads pass through.
+func RequireRecentAuth() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetAuthClaims(r.Context())
+			if stepUp == nil || claims == nil || auth.RequiredLevelForMethod(r.Method) == auth.LevelRead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := stepUp.CheckRecentAuth(r.Context(), claims.Sid); err != nil {
+				if errors.Is(err, service.ErrStepUpRequired) {
+					w.Header().Set(StepUpRequiredHeader, "true")
+				}
+				WriteError(w, err, "Failed to check recent sign-in")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/middleware"
+	"carecompanion/internal/models"
+	"carecompanion/internal/service"
+)
+
+type stubStepUp map[uuid.UUID]bool
+
+func (s stubStepUp) CheckRecentAuth(ctx context.Context, sid uuid.UUID) error {
+	if !s[sid] {
+		return service.ErrStepUpRequired
+	}
+	return nil
+}
+
+func TestRequireRecentAuth(t *testing.T) {
+	fresh, stale := uuid.New(), uuid.New()
+	middleware.SetStepUpChecker(stubStepUp{fresh: true})
+	defer middleware.SetStepUpChecker(nil)
+
+	for _, tc := range []struct {
+		method   string
+		sid      uuid.UUID
+		wantPass bool
+	}{
+		{"GET", stale, true},
+		{"POST", fresh, true},
+		{"PUT", stale, false},
+		{"DELETE", stale, false},
+	} {
+		called := false
+		h := middleware.RequireRecentAuth()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+		req := reqWithRole(tc.method, "/x", models.SystemRoleSuperAdmin)
+		middleware.GetAuthClaims(req.Context()).Sid = tc.sid
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if called != tc.wantPass {
+			t.Errorf("%s fresh=%v: passed = %v, want %v", tc.method, tc.sid == fresh, called, tc.wantPass)
+		}
+		if !tc.wantPass && (rec.Code != http.StatusForbidden || rec.Header().Get(middleware.StepUpRequiredHeader) != "true") {
+			t.Errorf("%s: status %d, step-up header %q; want 403 with the header",
+				tc.method, rec.Code, rec.Header().Get(middleware.StepUpRequiredHeader))
+		}
+	}
+}
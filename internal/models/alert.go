@@ -38,8 +38,22 @@ const (
 	AlertTypeSleepPattern        = "sleep_pattern"
 	AlertTypePatternDiscovered   = "pattern_discovered"
 	AlertTypeMissedLog           = "missed_log"
+	// AlertTypeAllergyIncident is raised when a diet log matches one of
+	// the child's registered restrictions or records an allergic reaction.
+	AlertTypeAllergyIncident = "allergy_incident"
 )
 
+// AlertTypes lists the alert types above, for clients that label them.
+var AlertTypes = []string{
+	AlertTypeMedicationAdherence,
+	AlertTypeBehaviorChange,
+	AlertTypeWeightChange,
+	AlertTypeSleepPattern,
+	AlertTypePatternDiscovered,
+	AlertTypeMissedLog,
+	AlertTypeAllergyIncident,
+}
+
 type AlertFeedback struct {
 	ID           uuid.UUID  `json:"id"`
 	AlertID      uuid.UUID  `json:"alert_id"`
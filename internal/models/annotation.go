@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Annotation categories
+const (
+	AnnotationCategoryMedication = "medication"
+	AnnotationCategoryDiet       = "diet"
+	AnnotationCategoryTherapy    = "therapy"
+	AnnotationCategorySchool     = "school"
+	AnnotationCategoryHealth     = "health"
+	AnnotationCategoryHome       = "home"
+	AnnotationCategoryMilestone  = "milestone"
+	AnnotationCategoryOther      = "other"
+)
+
+// AnnotationCategories lists the valid annotation categories.
+var AnnotationCategories = []string{
+	AnnotationCategoryMedication, AnnotationCategoryDiet, AnnotationCategoryTherapy, AnnotationCategorySchool,
+	AnnotationCategoryHealth, AnnotationCategoryHome, AnnotationCategoryMilestone, AnnotationCategoryOther,
+}
+
+// Annotation is a dated marker a caregiver puts on a child's charts, such
+// as "Started melatonin" or "Began GFCF diet".
+type Annotation struct {
+	ID             uuid.UUID  `json:"id"`
+	ChildID        uuid.UUID  `json:"child_id"`
+	AnnotationDate time.Time  `json:"annotation_date"`
+	Category       string     `json:"category"`
+	Title          string     `json:"title"`
+	Notes          NullString `json:"notes,omitempty"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AnnotationRequest creates or replaces an annotation. Date is
+// YYYY-MM-DD.
+type AnnotationRequest struct {
+	Date     string `json:"date"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	Notes    string `json:"notes,omitempty"`
+}
@@ -79,6 +79,7 @@ type SubscriptionPlan struct {
 	MaxChildren      int             `json:"max_children"`
 	MaxFamilyMembers int             `json:"max_family_members"`
 	IsActive         bool            `json:"is_active"`
+	SeatBased        bool            `json:"seat_based"` // organization plan: PriceCents is per seat, Stripe quantity = seats
 	StripePriceID    NullString      `json:"stripe_price_id,omitempty"`
 	StripeProductID  NullString      `json:"stripe_product_id,omitempty"`
 	CreatedAt        time.Time       `json:"created_at"`
@@ -269,6 +270,17 @@ type FinancialOverview struct {
 
 	// Promo impact
 	TotalDiscountsYTDCents int64 `json:"total_discounts_ytd_cents"`
+
+	// Disputes. Adjustments are the net balance impact (usually negative:
+	// the disputed amount plus Stripe's fee, less anything reinstated) of
+	// disputes closed in the period; net revenue is revenue plus them.
+	OpenDisputes              int     `json:"open_disputes"`
+	OpenDisputedCents         int64   `json:"open_disputed_cents"`
+	DisputeAdjustmentMTDCents int64   `json:"dispute_adjustment_mtd_cents"`
+	DisputeAdjustmentYTDCents int64   `json:"dispute_adjustment_ytd_cents"`
+	NetRevenueMTDCents        int64   `json:"net_revenue_mtd_cents"`
+	NetRevenueYTDCents        int64   `json:"net_revenue_ytd_cents"`
+	DisputeRate90dPct         float64 `json:"dispute_rate_90d_pct"` // chargebacks per 100 payments, trailing 90 days
 }
 
 type PlanSubscriptionCount struct {
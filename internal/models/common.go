@@ -326,12 +326,15 @@ const (
 	SystemRoleSupport    SystemRole = "support"
 	SystemRoleMarketing  SystemRole = "marketing"
 	SystemRolePartner    SystemRole = "partner"
+	// SystemRoleAuditor reads and exports compliance records and can't
+	// change anything (see auth.ReadOnly).
+	SystemRoleAuditor SystemRole = "auditor"
 )
 
 // IsValidSystemRole checks if a string is a valid system role
 func IsValidSystemRole(role string) bool {
 	switch SystemRole(role) {
-	case SystemRoleSuperAdmin, SystemRoleSupport, SystemRoleMarketing, SystemRolePartner:
+	case SystemRoleSuperAdmin, SystemRoleSupport, SystemRoleMarketing, SystemRolePartner, SystemRoleAuditor:
 		return true
 	}
 	return false
@@ -405,3 +408,15 @@ const (
 	CorrelationStatusCompleted  CorrelationStatus = "completed"
 	CorrelationStatusFailed     CorrelationStatus = "failed"
 )
+
+// ChangeStamp summarises when the rows behind a response last changed,
+// cheaply enough to read before building the response itself. The API
+// derives ETag and Last-Modified from it.
+type ChangeStamp struct {
+	// Count is the number of rows covered, so deletes change the stamp
+	// even when they don't move LastModified.
+	Count int64
+	// Version is a counter bumped on every write, where the table has one.
+	Version      int64
+	LastModified time.Time
+}
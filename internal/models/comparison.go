@@ -0,0 +1,39 @@
+package models
+
+import "github.com/google/uuid"
+
+// ComparedChild identifies a child in a comparison. Only the first name
+// is included; the rest of the child's record stays on its own screens.
+// Annotations are the child's chart annotations in the compared range.
+type ComparedChild struct {
+	ID          uuid.UUID    `json:"id"`
+	FirstName   string       `json:"first_name"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+// ComparisonSeries is one child's daily values for a metric, aligned to
+// ChildComparison.Dates; a nil value is a day with nothing logged.
+type ComparisonSeries struct {
+	ChildID    uuid.UUID  `json:"child_id"`
+	Values     []*float64 `json:"values"`
+	Average    *float64   `json:"average,omitempty"`
+	DaysLogged int        `json:"days_logged"`
+}
+
+// ComparisonMetric is a metric compared across the children.
+type ComparisonMetric struct {
+	Key    string             `json:"key"`
+	Label  string             `json:"label"`
+	Unit   string             `json:"unit"`
+	Series []ComparisonSeries `json:"series"`
+}
+
+// ChildComparison lines up daily metric series for several of a family's
+// children over the same dates.
+type ChildComparison struct {
+	StartDate string             `json:"start_date"`
+	EndDate   string             `json:"end_date"`
+	Dates     []string           `json:"dates"`
+	Children  []ComparedChild    `json:"children"`
+	Metrics   []ComparisonMetric `json:"metrics"`
+}
@@ -6,23 +6,48 @@ import (
 	"github.com/google/uuid"
 )
 
+// Push providers a device token can come from. iOS builds that use
+// Firebase register FCM tokens; builds that talk to APNs directly register
+// the raw APNs device token.
+const (
+	PushProviderFCM  = "fcm"
+	PushProviderAPNs = "apns"
+)
+
+// Why a device token was deactivated.
+const (
+	DeviceDeactivatedUnregistered = "unregistered" // user signed out / app unregistered
+	DeviceDeactivatedInvalid      = "invalid"      // provider reported the token invalid
+	DeviceDeactivatedStale        = "stale"        // not seen in the prune horizon
+	DeviceDeactivatedReassigned   = "reassigned"   // another account signed in on the device
+)
+
 // DeviceToken represents a registered mobile device for push notifications
 type DeviceToken struct {
 	ID         uuid.UUID `json:"id"`
 	UserID     uuid.UUID `json:"user_id"`
 	Token      string    `json:"token"`
 	Platform   string    `json:"platform"` // "ios" or "android"
+	Provider   string    `json:"provider"` // "fcm" or "apns"
 	DeviceName string    `json:"device_name,omitempty"`
+	AppVersion string    `json:"app_version,omitempty"`
+	OSVersion  string    `json:"os_version,omitempty"`
 	Active     bool      `json:"active"`
+	LastSeenAt time.Time `json:"last_seen_at"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
-// RegisterDeviceRequest is the request body for device registration
+// RegisterDeviceRequest is the request body for device registration.
+// Clients re-register on every launch so the app version and last-seen
+// time stay current. Provider defaults to "fcm".
 type RegisterDeviceRequest struct {
 	Token      string `json:"token"`
 	Platform   string `json:"platform"`
+	Provider   string `json:"provider,omitempty"`
 	DeviceName string `json:"device_name,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+	OSVersion  string `json:"os_version,omitempty"`
 }
 
 // UnregisterDeviceRequest is the request body for device unregistration
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FoodSuggestion is one food a vision provider saw in a meal photo.
+// Confidence is 0-1.
+type FoodSuggestion struct {
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"`
+	Portion    string  `json:"portion,omitempty"`
+}
+
+// FoodSuggestions is a slice of FoodSuggestion for PostgreSQL JSONB
+// columns, most confident first.
+type FoodSuggestions []FoodSuggestion
+
+func (f FoodSuggestions) Value() (driver.Value, error) {
+	if f == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *FoodSuggestions) Scan(value interface{}) error {
+	if value == nil {
+		*f = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+// FoodRecognition is a meal photo run through the vision provider. The
+// photo isn't stored; ImageSHA256 identifies it. DietLogID is set once a
+// diet log is saved from the suggestions.
+type FoodRecognition struct {
+	ID          uuid.UUID       `json:"id"`
+	ChildID     uuid.UUID       `json:"child_id"`
+	Provider    string          `json:"provider"`
+	Model       NullString      `json:"model,omitempty"`
+	ImageSHA256 string          `json:"image_sha256"`
+	Suggestions FoodSuggestions `json:"suggestions"`
+	DietLogID   *uuid.UUID      `json:"diet_log_id,omitempty"`
+	CreatedBy   *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Food provenance sources
+const (
+	FoodSourceManual      = "manual"       // typed by the caregiver
+	FoodSourcePhoto       = "photo"        // a photo suggestion confirmed as is
+	FoodSourcePhotoEdited = "photo_edited" // a photo suggestion the caregiver reworded
+)
+
+// FoodProvenance records where one foods_eaten entry came from.
+// Confidence is the suggestion's, for photo sources.
+type FoodProvenance struct {
+	Food       string   `json:"food"`
+	Source     string   `json:"source"`
+	Confidence *float64 `json:"confidence,omitempty"`
+}
+
+// FoodProvenanceList is a slice of FoodProvenance for PostgreSQL JSONB
+// columns, in foods_eaten order.
+type FoodProvenanceList []FoodProvenance
+
+func (f FoodProvenanceList) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *FoodProvenanceList) Scan(value interface{}) error {
+	if value == nil {
+		*f = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, f)
+}
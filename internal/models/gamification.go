@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// GamificationSettings are a caregiver's opt-outs. Both are on until
+// turned off.
+type GamificationSettings struct {
+	// Enabled shows streaks, badges and progress stats, in the app and in
+	// the weekly digest.
+	Enabled      bool `json:"enabled"`
+	WeeklyDigest bool `json:"weekly_digest"`
+}
+
+// UpdateGamificationRequest changes the fields that are set.
+type UpdateGamificationRequest struct {
+	Enabled      *bool `json:"enabled,omitempty"`
+	WeeklyDigest *bool `json:"weekly_digest,omitempty"`
+}
+
+// LoggingBadge is a logging milestone: a number of entries or a streak of
+// days. Progress counts towards Target and stops there once earned.
+type LoggingBadge struct {
+	Key      string     `json:"key"`
+	Name     string     `json:"name"`
+	Target   int        `json:"target"`
+	Progress int        `json:"progress"`
+	EarnedOn *time.Time `json:"earned_on,omitempty"`
+}
+
+// LoggingProgress is a caregiver's logging across all children and log
+// types, by day in their timezone. Weeks are the last 7 days including
+// today and the 7 before. A streak runs to today or, while today has no
+// entries yet, to yesterday.
+type LoggingProgress struct {
+	TotalEntries    int            `json:"total_entries"`
+	DaysLogged      int            `json:"days_logged"`
+	CurrentStreak   int            `json:"current_streak"`
+	LongestStreak   int            `json:"longest_streak"`
+	EntriesThisWeek int            `json:"entries_this_week"`
+	EntriesLastWeek int            `json:"entries_last_week"`
+	DaysThisWeek    int            `json:"days_this_week"`
+	Badges          []LoggingBadge `json:"badges"`
+}
+
+// GamificationStatus is what GET /api/users/me/gamification returns.
+// Progress is absent when the caregiver has opted out.
+type GamificationStatus struct {
+	GamificationSettings
+	Progress *LoggingProgress `json:"progress,omitempty"`
+}
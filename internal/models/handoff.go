@@ -0,0 +1,107 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmergencyContact is someone a sitter calls when the parents can't be
+// reached.
+type EmergencyContact struct {
+	Name         string `json:"name"`
+	Relationship string `json:"relationship,omitempty"`
+	Phone        string `json:"phone"`
+	Notes        string `json:"notes,omitempty"`
+}
+
+// EmergencyContacts is a slice of EmergencyContact for PostgreSQL JSONB
+// columns
+type EmergencyContacts []EmergencyContact
+
+func (c EmergencyContacts) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *EmergencyContacts) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// HandoffProfile is what the family tells a respite carer beyond what the
+// app already records about the child.
+type HandoffProfile struct {
+	ChildID            uuid.UUID         `json:"child_id"`
+	EmergencyContacts  EmergencyContacts `json:"emergency_contacts"`
+	CommunicationNotes NullString        `json:"communication_notes,omitempty"`
+	CalmingNotes       NullString        `json:"calming_notes,omitempty"`
+	AllergyNotes       NullString        `json:"allergy_notes,omitempty"`
+	CareNotes          NullString        `json:"care_notes,omitempty"`
+	UpdatedBy          NullUUID          `json:"updated_by,omitempty"`
+	UpdatedAt          *time.Time        `json:"updated_at,omitempty"`
+}
+
+// HandoffProfileRequest replaces a child's handoff profile.
+type HandoffProfileRequest struct {
+	EmergencyContacts  []EmergencyContact `json:"emergency_contacts"`
+	CommunicationNotes string             `json:"communication_notes"`
+	CalmingNotes       string             `json:"calming_notes"`
+	AllergyNotes       string             `json:"allergy_notes"`
+	CareNotes          string             `json:"care_notes"`
+}
+
+// HandoffMedication is a current medication with when it's given.
+type HandoffMedication struct {
+	Name         string   `json:"name"`
+	Dose         string   `json:"dose"`
+	Instructions string   `json:"instructions,omitempty"`
+	Times        []string `json:"times"`
+	// AsNeeded is set for medications without a schedule.
+	AsNeeded bool `json:"as_needed"`
+}
+
+// HandoffAllergy is an allergy from the child's restriction registry, the
+// family's notes or a logged reaction.
+type HandoffAllergy struct {
+	Description string `json:"description"`
+	// LastReaction is the date of the latest logged reaction, when it
+	// comes from the diet log.
+	LastReaction string `json:"last_reaction,omitempty"`
+}
+
+// HandoffCalmingStrategy is a calming strategy and how often the logs
+// show it being used lately.
+type HandoffCalmingStrategy struct {
+	Strategy  string `json:"strategy"`
+	TimesUsed int    `json:"times_used"`
+}
+
+// RespiteHandoff is the handoff document for a babysitter or respite
+// carer: the child's medications, allergies, how to calm and communicate
+// with them, and who to call.
+type RespiteHandoff struct {
+	ChildID            uuid.UUID                `json:"child_id"`
+	ChildName          string                   `json:"child_name"`
+	AgeYears           int                      `json:"age_years"`
+	Conditions         []string                 `json:"conditions"`
+	Medications        []HandoffMedication      `json:"medications"`
+	Allergies          []HandoffAllergy         `json:"allergies"`
+	CalmingStrategies  []HandoffCalmingStrategy `json:"calming_strategies"`
+	CalmingNotes       string                   `json:"calming_notes,omitempty"`
+	CommunicationNotes string                   `json:"communication_notes,omitempty"`
+	CareNotes          string                   `json:"care_notes,omitempty"`
+	EmergencyContacts  []EmergencyContact       `json:"emergency_contacts"`
+	GeneratedAt        time.Time                `json:"generated_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImageSet is one processed upload: the responsive variants generated from
+// a single source image for an owner (a child's photo, a testimonial
+// headshot, a marketing image). Uploading again for the same owner
+// replaces the set.
+type ImageSet struct {
+	ID           uuid.UUID      `json:"id"`
+	OwnerType    string         `json:"owner_type"`
+	OwnerID      uuid.UUID      `json:"owner_id"`
+	SourceWidth  int            `json:"source_width"`
+	SourceHeight int            `json:"source_height"`
+	CreatedBy    NullUUID       `json:"created_by,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Variants     []ImageVariant `json:"variants"`
+}
+
+// ImageVariant is one encoding of an ImageSet at one width. Clients pick
+// by format (avif, webp, jpeg) and width, e.g. from a <picture> srcset.
+type ImageVariant struct {
+	ID          uuid.UUID `json:"id"`
+	ImageSetID  uuid.UUID `json:"image_set_id"`
+	Format      string    `json:"format"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	ContentType string    `json:"content_type"`
+	StoragePath string    `json:"-"`
+	SizeBytes   int64     `json:"size_bytes"`
+	CreatedAt   time.Time `json:"created_at"`
+	// URL is a signed, expiring link to the variant, set on responses only.
+	URL string `json:"url,omitempty"`
+}
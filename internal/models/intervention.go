@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Intervention effect directions.
+const (
+	InterventionImproved         = "improved"
+	InterventionWorsened         = "worsened"
+	InterventionNoChange         = "no_change"
+	InterventionInsufficientData = "insufficient_data"
+)
+
+// Intervention is something a family tried for a while — "melatonin for
+// two weeks", "no screens after 6pm" — and the metrics it was meant to
+// move. EndDate is nil while it's still running.
+type Intervention struct {
+	ID            uuid.UUID  `json:"id"`
+	ChildID       uuid.UUID  `json:"child_id"`
+	Name          string     `json:"name"`
+	Description   NullString `json:"description,omitempty"`
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       *time.Time `json:"end_date,omitempty"`
+	TargetMetrics []string   `json:"target_metrics"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// InterventionRequest creates or updates an intervention. TargetMetrics
+// are comparison metric keys (sleep, meltdowns, ...).
+type InterventionRequest struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	StartDate     FlexDate `json:"start_date"`
+	EndDate       FlexDate `json:"end_date,omitempty"`
+	TargetMetrics []string `json:"target_metrics"`
+}
+
+// InterventionPeriod is a metric's daily average over one period of an
+// evaluation. Average is nil when nothing was logged.
+type InterventionPeriod struct {
+	StartDate  string   `json:"start_date"`
+	EndDate    string   `json:"end_date"`
+	Average    *float64 `json:"average,omitempty"`
+	DaysLogged int      `json:"days_logged"`
+}
+
+// InterventionMetricResult compares one target metric before, during and
+// (once the intervention has ended) after it.
+type InterventionMetricResult struct {
+	Key    string              `json:"key"`
+	Label  string              `json:"label"`
+	Unit   string              `json:"unit"`
+	Before InterventionPeriod  `json:"before"`
+	During InterventionPeriod  `json:"during"`
+	After  *InterventionPeriod `json:"after,omitempty"`
+	// ChangePercent is During against Before; nil when Before averaged 0
+	// or either period lacks data.
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+	Direction     string   `json:"direction"`
+	Summary       string   `json:"summary"`
+}
+
+// InterventionEvaluation is the before/during/after comparison of an
+// intervention's target metrics with a plain-language summary.
+type InterventionEvaluation struct {
+	Intervention Intervention               `json:"intervention"`
+	Metrics      []InterventionMetricResult `json:"metrics"`
+	Summary      string                     `json:"summary"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogDraft is a user's autosaved, unsubmitted log form for a child. There
+// is at most one per log type; LogID is set when it edits an existing
+// log. Data is the form as the client sent it.
+type LogDraft struct {
+	UserID    uuid.UUID       `json:"user_id"`
+	ChildID   uuid.UUID       `json:"child_id"`
+	LogType   string          `json:"log_type"`
+	LogID     *uuid.UUID      `json:"log_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// SaveLogDraftRequest replaces the draft for a log type.
+type SaveLogDraftRequest struct {
+	LogID *uuid.UUID      `json:"log_id,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// LogTemplate is a family's named, pre-filled log entry of one type, such
+// as "school day breakfast". Data holds the type's create request fields;
+// the log date is set when it is used.
+type LogTemplate struct {
+	ID        uuid.UUID       `json:"id"`
+	FamilyID  uuid.UUID       `json:"family_id"`
+	LogType   string          `json:"log_type"`
+	Name      string          `json:"name"`
+	Data      json.RawMessage `json:"data"`
+	CreatedBy *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// LogTemplateRequest creates or replaces a log template.
+type LogTemplateRequest struct {
+	LogType string          `json:"log_type"`
+	Name    string          `json:"name"`
+	Data    json.RawMessage `json:"data"`
+}
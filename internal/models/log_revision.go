@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogFieldChange is one field an edit changed, by its JSON name. From and
+// To are the JSON values; null when the field was empty.
+type LogFieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// LogFieldChanges is a slice of LogFieldChange for PostgreSQL JSONB
+// columns, in field name order.
+type LogFieldChanges []LogFieldChange
+
+func (c LogFieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *LogFieldChanges) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// LogRevision is one edit of a log entry. EditedBy is nil for edits the
+// system made, such as device sleep sync. Version is the log version the
+// edit produced; 0 for edits made before logs were versioned.
+type LogRevision struct {
+	ID         uuid.UUID       `json:"id"`
+	LogType    string          `json:"log_type"`
+	LogID      uuid.UUID       `json:"log_id"`
+	ChildID    uuid.UUID       `json:"child_id"`
+	Version    int             `json:"version,omitempty"`
+	Changes    LogFieldChanges `json:"changes"`
+	EditedBy   *uuid.UUID      `json:"edited_by,omitempty"`
+	EditorName string          `json:"editor_name,omitempty"`
+	EditedAt   time.Time       `json:"edited_at"`
+}
+
+// LogHistory is a log entry's revision history, oldest edit first.
+type LogHistory struct {
+	LogType   string        `json:"log_type"`
+	LogID     uuid.UUID     `json:"log_id"`
+	ChildID   uuid.UUID     `json:"child_id"`
+	LoggedBy  uuid.UUID     `json:"logged_by"`
+	CreatedAt time.Time     `json:"created_at"`
+	AmendedAt *time.Time    `json:"amended_at,omitempty"`
+	Revisions []LogRevision `json:"revisions"`
+}
+
+// LogFieldConflict is a field that both a rejected edit and a later save
+// changed, to different values. Base is the value the edit started from;
+// it is omitted when the log's history doesn't say.
+type LogFieldConflict struct {
+	Field  string          `json:"field"`
+	Base   json.RawMessage `json:"base,omitempty"`
+	Mine   json.RawMessage `json:"mine"`
+	Theirs json.RawMessage `json:"theirs"`
+}
+
+// LogUpdateConflict is the answer to an update made against an old
+// version of a log. Current is the log as saved now, at CurrentVersion,
+// and TheirChanges the revisions since YourVersion. Merged is Current with the edit's changes
+// to fields nobody else touched applied; when Conflicts is empty it can be
+// sent back as is.
+type LogUpdateConflict struct {
+	LogType        string                     `json:"log_type"`
+	LogID          uuid.UUID                  `json:"log_id"`
+	YourVersion    int                        `json:"your_version"`
+	CurrentVersion int                        `json:"current_version"`
+	Current        any                        `json:"current"`
+	TheirChanges   []LogRevision              `json:"their_changes"`
+	Conflicts      []LogFieldConflict         `json:"conflicts"`
+	Merged         map[string]json.RawMessage `json:"merged,omitempty"`
+}
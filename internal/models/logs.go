@@ -27,9 +27,18 @@ type BehaviorLog struct {
 	Triggers              StringArray `json:"triggers,omitempty"`
 	PositiveBehaviors     StringArray `json:"positive_behaviors,omitempty"`
 	Notes                 NullString  `json:"notes,omitempty"`
+	RubricVersion         int         `json:"rubric_version"` // family scoring rubric the levels were entered under
 	LoggedBy              uuid.UUID   `json:"logged_by"`
 	CreatedAt             time.Time   `json:"created_at"`
 	UpdatedAt             time.Time   `json:"updated_at"`
+	// Provider is set when a school or ABA provider entered the log.
+	Provider *ProviderAttribution `json:"provider,omitempty"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Bowel Log
@@ -46,6 +55,12 @@ type BowelLog struct {
 	Notes        NullString `json:"notes,omitempty"`
 	LoggedBy     uuid.UUID  `json:"logged_by"`
 	CreatedAt    time.Time  `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Speech Log
@@ -65,6 +80,12 @@ type SpeechLog struct {
 	Notes                    NullString  `json:"notes,omitempty"`
 	LoggedBy                 uuid.UUID   `json:"logged_by"`
 	CreatedAt                time.Time   `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Diet Log
@@ -88,6 +109,22 @@ type DietLog struct {
 	Notes            NullString  `json:"notes,omitempty"`
 	LoggedBy         uuid.UUID   `json:"logged_by"`
 	CreatedAt        time.Time   `json:"created_at"`
+	// RestrictionMatches are the registered restrictions the foods
+	// matched when the log was saved.
+	RestrictionMatches StringArray `json:"restriction_matches,omitempty"`
+	// PhotoRecognitionID is the meal photo recognition the foods were
+	// confirmed from; FoodProvenance says which foods came from it.
+	PhotoRecognitionID *uuid.UUID        `json:"photo_recognition_id,omitempty"`
+	FoodProvenance     FoodProvenanceList `json:"food_provenance,omitempty"`
+	// RestrictionWarnings details the matches; only set on the response
+	// to a create or update.
+	RestrictionWarnings []RestrictionMatch `json:"restriction_warnings,omitempty"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Weight Log
@@ -102,26 +139,43 @@ type WeightLog struct {
 	Notes        NullString `json:"notes,omitempty"`
 	LoggedBy     uuid.UUID  `json:"logged_by"`
 	CreatedAt    time.Time  `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
-// Sleep Log
+// Sleep Log. Source is manual for caregiver entries or the device a
+// reconciled night was taken from; the reconciliation fields are set by
+// SleepReconciliationService.
 type SleepLog struct {
-	ID                uuid.UUID  `json:"id"`
-	ChildID           uuid.UUID  `json:"child_id"`
-	LogDate           time.Time  `json:"log_date"`
-	TimeScope         NullString `json:"time_scope,omitempty"`
-	Bedtime           NullString `json:"bedtime,omitempty"`
-	WakeTime          NullString `json:"wake_time,omitempty"`
-	TotalSleepMinutes *int       `json:"total_sleep_minutes,omitempty"`
-	NightWakings      int        `json:"night_wakings"`
-	SleepQuality      NullString `json:"sleep_quality,omitempty"`
-	TookSleepAid      bool       `json:"took_sleep_aid"`
-	SleepAidName      NullString `json:"sleep_aid_name,omitempty"`
-	Nightmares        bool       `json:"nightmares"`
-	BedWetting        bool       `json:"bed_wetting"`
-	Notes             NullString `json:"notes,omitempty"`
-	LoggedBy          uuid.UUID  `json:"logged_by"`
-	CreatedAt         time.Time  `json:"created_at"`
+	ID                   uuid.UUID  `json:"id"`
+	ChildID              uuid.UUID  `json:"child_id"`
+	LogDate              time.Time  `json:"log_date"`
+	TimeScope            NullString `json:"time_scope,omitempty"`
+	Bedtime              NullString `json:"bedtime,omitempty"`
+	WakeTime             NullString `json:"wake_time,omitempty"`
+	TotalSleepMinutes    *int       `json:"total_sleep_minutes,omitempty"`
+	NightWakings         int        `json:"night_wakings"`
+	SleepQuality         NullString `json:"sleep_quality,omitempty"`
+	TookSleepAid         bool       `json:"took_sleep_aid"`
+	SleepAidName         NullString `json:"sleep_aid_name,omitempty"`
+	Nightmares           bool       `json:"nightmares"`
+	BedWetting           bool       `json:"bed_wetting"`
+	Notes                NullString `json:"notes,omitempty"`
+	Source               string     `json:"source"`
+	ReconciliationStatus NullString `json:"reconciliation_status,omitempty"`
+	ReconciliationNote   NullString `json:"reconciliation_note,omitempty"`
+	LoggedBy             uuid.UUID  `json:"logged_by"`
+	CreatedAt            time.Time  `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Sensory Log
@@ -140,6 +194,12 @@ type SensoryLog struct {
 	Notes                    NullString  `json:"notes,omitempty"`
 	LoggedBy                 uuid.UUID   `json:"logged_by"`
 	CreatedAt                time.Time   `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Social Log
@@ -159,6 +219,12 @@ type SocialLog struct {
 	Notes                  NullString `json:"notes,omitempty"`
 	LoggedBy               uuid.UUID  `json:"logged_by"`
 	CreatedAt              time.Time  `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Therapy Log
@@ -177,6 +243,16 @@ type TherapyLog struct {
 	ParentNotes      NullString  `json:"parent_notes,omitempty"`
 	LoggedBy         uuid.UUID   `json:"logged_by"`
 	CreatedAt        time.Time   `json:"created_at"`
+	// Goals are the child's therapy goals worked on in the session.
+	Goals []TherapyLogGoal `json:"goals,omitempty"`
+	// Provider is set when a school or ABA provider entered the log.
+	Provider *ProviderAttribution `json:"provider,omitempty"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Seizure Log
@@ -197,6 +273,12 @@ type SeizureLog struct {
 	Notes                 NullString  `json:"notes,omitempty"`
 	LoggedBy              uuid.UUID   `json:"logged_by"`
 	CreatedAt             time.Time   `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Health Event Log
@@ -217,6 +299,12 @@ type HealthEventLog struct {
 	Notes        NullString  `json:"notes,omitempty"`
 	LoggedBy     uuid.UUID   `json:"logged_by"`
 	CreatedAt    time.Time   `json:"created_at"`
+	// AmendedAt is when the log was last corrected; the changes are in its
+	// revision history.
+	AmendedAt *time.Time `json:"amended_at,omitempty"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 // Daily Log Page combines all logs for a day or date range
@@ -306,6 +394,9 @@ type CreateDietLogRequest struct {
 	AllergicReaction  bool      `json:"allergic_reaction"`
 	ReactionDetails   string    `json:"reaction_details,omitempty"`
 	Notes             string    `json:"notes,omitempty"`
+	// PhotoRecognitionID links the log to the meal photo whose suggestions
+	// the caregiver confirmed.
+	PhotoRecognitionID *uuid.UUID `json:"photo_recognition_id,omitempty"`
 }
 
 type CreateWeightLogRequest struct {
@@ -367,6 +458,9 @@ type CreateTherapyLogRequest struct {
 	ProgressNotes    string    `json:"progress_notes,omitempty"`
 	HomeworkAssigned string    `json:"homework_assigned,omitempty"`
 	ParentNotes      string    `json:"parent_notes,omitempty"`
+	// Goals replaces the session's linked therapy goals; nil leaves them
+	// as they are on update.
+	Goals []TherapyLogGoal `json:"goals,omitempty"`
 }
 
 type CreateSeizureLogRequest struct {
@@ -41,6 +41,10 @@ type BrandConfig struct {
 	InstagramURL string `json:"instagramUrl"`
 	LinkedInURL  string `json:"linkedinUrl"`
 
+	// QR codes on generated materials. Empty QRTargetURL means WebsiteURL.
+	QRTargetURL   string `json:"qrTargetUrl"`
+	QRUTMCampaign string `json:"qrUtmCampaign"`
+
 	// Legal
 	CopyrightText  string `json:"copyrightText"`
 	DisclaimerText string `json:"disclaimerText"`
@@ -51,10 +55,12 @@ type BrandConfig struct {
 
 // AssetType constants
 const (
-	AssetTypeLogo          = "logo"
-	AssetTypeBrochure      = "brochure"
-	AssetTypeSocialGraphic = "social_graphic"
-	AssetTypeStyleGuide    = "style_guide"
+	AssetTypeLogo            = "logo"
+	AssetTypeBrochure        = "brochure"
+	AssetTypeSocialGraphic   = "social_graphic"
+	AssetTypeStyleGuide      = "style_guide"
+	AssetTypePrintCollateral = "print_collateral"
+	AssetTypeScreenshot      = "screenshot"
 )
 
 // Format constants
@@ -90,8 +96,43 @@ type MarketingAsset struct {
 	IsActive           bool       `json:"isActive"`
 	CreatedAt          time.Time  `json:"createdAt"`
 	UpdatedAt          time.Time  `json:"updatedAt"`
+	// ScanStatus is the antivirus verdict for uploaded assets, set on the
+	// upload response only.
+	ScanStatus string `json:"scanStatus,omitempty"`
+	// URL is a signed, expiring link to the file under /static, set on
+	// listings when the file lives there.
+	URL string `json:"url,omitempty"`
+	// Variants are the responsive encodings of uploaded images, with
+	// signed URLs.
+	Variants []ImageVariant `json:"variants,omitempty"`
 }
 
+// BrandFont is an uploaded font file for one family/style
+type BrandFont struct {
+	ID               uuid.UUID  `json:"id"`
+	Family           string     `json:"family"`
+	Style            string     `json:"style"`
+	Format           string     `json:"format"`
+	OriginalFilename string     `json:"originalFilename"`
+	StorageDriver    string     `json:"-"`
+	StoragePath      string     `json:"-"`
+	SizeBytes        int64      `json:"sizeBytes"`
+	SHA256           string     `json:"sha256"`
+	LicenseNote      string     `json:"licenseNote"`
+	UploadedBy       *uuid.UUID `json:"uploadedBy,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	// ScanStatus is the antivirus verdict, set on the upload response only.
+	ScanStatus string `json:"scanStatus,omitempty"`
+}
+
+// Font style constants
+const (
+	FontStyleRegular    = "regular"
+	FontStyleBold       = "bold"
+	FontStyleItalic     = "italic"
+	FontStyleBoldItalic = "bold_italic"
+)
+
 // SocialTemplate defines dimensions and config for social media graphics
 type SocialTemplate struct {
 	ID               uuid.UUID `json:"id"`
@@ -126,6 +167,17 @@ type MarketingStats struct {
 	InsightsGenerated    int     `json:"insightsGenerated"`
 }
 
+// PricingPlan is a live subscription plan as shown on the pricing sheet
+type PricingPlan struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	PriceCents       int      `json:"priceCents"`
+	BillingInterval  string   `json:"billingInterval"`
+	Features         []string `json:"features"`
+	MaxChildren      int      `json:"maxChildren"`
+	MaxFamilyMembers int      `json:"maxFamilyMembers"`
+}
+
 // FeatureHighlight for brochure content
 type FeatureHighlight struct {
 	Title       string `json:"title"`
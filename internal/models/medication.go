@@ -62,6 +62,9 @@ type MedicationLog struct {
 	LoggedBy       uuid.UUID  `json:"logged_by"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
+	// Version goes up with every update; updates must name the version
+	// they were made against.
+	Version int `json:"version"`
 }
 
 type MedicationDue struct {
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Provider grant statuses
+const (
+	ProviderGrantPending = "pending"
+	ProviderGrantActive  = "active"
+	ProviderGrantRevoked = "revoked"
+)
+
+// Provider types
+const (
+	ProviderTypeSchool = "school"
+	ProviderTypeABA    = "aba"
+	ProviderTypeOther  = "other"
+)
+
+// ProviderProfile is an external contributor's branding: the school or
+// ABA provider they record for, shown in their portal and on their
+// entries.
+type ProviderProfile struct {
+	UserID           uuid.UUID  `json:"user_id"`
+	OrganizationName string     `json:"organization_name"`
+	ProviderType     string     `json:"provider_type"`
+	BrandColor       NullString `json:"brand_color,omitempty"`
+	LogoURL          NullString `json:"logo_url,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// ProviderProfileRequest sets a provider's branding.
+type ProviderProfileRequest struct {
+	OrganizationName string `json:"organization_name"`
+	ProviderType     string `json:"provider_type"`
+	BrandColor       string `json:"brand_color,omitempty"`
+	LogoURL          string `json:"logo_url,omitempty"`
+}
+
+// ProviderGrant lets one provider record the listed log types for one
+// child. It is pending until the invited address accepts it.
+type ProviderGrant struct {
+	ID               uuid.UUID   `json:"id"`
+	ChildID          uuid.UUID   `json:"child_id"`
+	FamilyID         uuid.UUID   `json:"family_id"`
+	ProviderUserID   *uuid.UUID  `json:"provider_user_id,omitempty"`
+	InviteEmail      string      `json:"invite_email"`
+	OrganizationName string      `json:"organization_name"`
+	ProviderType     string      `json:"provider_type"`
+	LogTypes         StringArray `json:"log_types"`
+	Status           string      `json:"status"`
+	InviteExpiresAt  *time.Time  `json:"invite_expires_at,omitempty"`
+	InvitedBy        *uuid.UUID  `json:"invited_by,omitempty"`
+	AcceptedAt       *time.Time  `json:"accepted_at,omitempty"`
+	RevokedAt        *time.Time  `json:"revoked_at,omitempty"`
+	RevokedBy        *uuid.UUID  `json:"revoked_by,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+
+	// Filled in for the provider's own grant list.
+	ChildFirstName string `json:"child_first_name,omitempty"`
+	// Branding is the provider's profile, once they have one.
+	Branding *ProviderProfile `json:"branding,omitempty"`
+}
+
+// AllowsLogType reports whether the grant covers logType.
+func (g *ProviderGrant) AllowsLogType(logType string) bool {
+	for _, t := range g.LogTypes {
+		if t == logType {
+			return true
+		}
+	}
+	return false
+}
+
+// ProviderInviteRequest invites a provider to record logs for a child.
+type ProviderInviteRequest struct {
+	Email            string   `json:"email"`
+	OrganizationName string   `json:"organization_name"`
+	ProviderType     string   `json:"provider_type"`
+	LogTypes         []string `json:"log_types"`
+}
+
+// ProviderAttribution names the provider that entered a log.
+type ProviderAttribution struct {
+	GrantID          uuid.UUID  `json:"grant_id"`
+	ProviderUserID   *uuid.UUID `json:"provider_user_id,omitempty"`
+	OrganizationName string     `json:"organization_name"`
+	BrandColor       NullString `json:"brand_color,omitempty"`
+	LogoURL          NullString `json:"logo_url,omitempty"`
+}
+
+// ProviderLogEntry is a log a provider entered under a grant.
+type ProviderLogEntry struct {
+	LogType string    `json:"log_type"`
+	LogID   uuid.UUID `json:"log_id"`
+	ProviderAttribution
+	CreatedAt time.Time `json:"created_at"`
+}
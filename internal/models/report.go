@@ -73,10 +73,13 @@ type ChartDataPoint struct {
 
 // ReportChartData holds chart series for the frontend
 type ReportChartData struct {
-	ReportID  uuid.UUID                    `json:"report_id"`
-	ChildName string                       `json:"child_name"`
-	StartDate string                       `json:"start_date"`
-	EndDate   string                       `json:"end_date"`
-	Charts    map[string][]ChartDataPoint  `json:"charts"`
-	Logs      *DailyLogPage                `json:"logs"`
+	ReportID     uuid.UUID                   `json:"report_id"`
+	ChildName    string                      `json:"child_name"`
+	StartDate    string                      `json:"start_date"`
+	EndDate      string                      `json:"end_date"`
+	Charts       map[string][]ChartDataPoint `json:"charts"`
+	Logs         *DailyLogPage               `json:"logs"`
+	Annotations  []Annotation                `json:"annotations"`  // chart annotations in the report's range
+	Restrictions []ChildRestriction          `json:"restrictions"` // allergy and dietary restriction registry
+	Rubrics      []ScoringRubric             `json:"rubrics"`      // scoring rubric versions the behavior logs were entered under
 }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReportShare is a generated report shared with someone outside the
+// family, usually a clinician, through a signed link. The view fields
+// are the read receipts: how often and when the link was opened.
+type ReportShare struct {
+	ID             uuid.UUID         `json:"id"`
+	ReportID       uuid.UUID         `json:"report_id"`
+	ReportTitle    string            `json:"report_title"`
+	ChildID        uuid.UUID         `json:"child_id"`
+	SharedBy       *uuid.UUID        `json:"shared_by,omitempty"`
+	RecipientName  string            `json:"recipient_name"`
+	RecipientEmail NullString        `json:"recipient_email,omitempty"`
+	Note           NullString        `json:"note,omitempty"`
+	ExpiresAt      time.Time         `json:"expires_at"`
+	RevokedAt      *time.Time        `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	ViewCount      int               `json:"view_count"`
+	FirstViewedAt  *time.Time        `json:"first_viewed_at,omitempty"`
+	LastViewedAt   *time.Time        `json:"last_viewed_at,omitempty"`
+	Views          []ReportShareView `json:"views,omitempty"`
+	// URL is the shared link, set while the share is active.
+	URL string `json:"url,omitempty"`
+}
+
+// ReportShareView is one open of a shared report link.
+type ReportShareView struct {
+	ID        uuid.UUID  `json:"id"`
+	ShareID   uuid.UUID  `json:"share_id"`
+	ViewedAt  time.Time  `json:"viewed_at"`
+	IPAddress NullString `json:"ip_address,omitempty"`
+	UserAgent NullString `json:"user_agent,omitempty"`
+}
+
+// CreateReportShareRequest shares a report. RecipientEmail is optional;
+// when given, the link is emailed. ExpiresInDays defaults to 14.
+type CreateReportShareRequest struct {
+	RecipientName  string `json:"recipient_name"`
+	RecipientEmail string `json:"recipient_email,omitempty"`
+	Note           string `json:"note,omitempty"`
+	ExpiresInDays  int    `json:"expires_in_days,omitempty"`
+}
+
+// ExtendReportShareRequest pushes a share's expiry back by Days.
+type ExtendReportShareRequest struct {
+	Days int `json:"days"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Restriction kinds
+const (
+	RestrictionAllergy     = "allergy"
+	RestrictionIntolerance = "intolerance"
+	RestrictionDietary     = "dietary"
+)
+
+// Restriction severities
+const (
+	RestrictionMild     = "mild"
+	RestrictionModerate = "moderate"
+	RestrictionSevere   = "severe"
+)
+
+// ChildRestriction is an allergy, intolerance or dietary restriction on a
+// child's registry. Aliases are other names the item shows up under in
+// diet logs ("PB" for peanut).
+type ChildRestriction struct {
+	ID        uuid.UUID  `json:"id"`
+	ChildID   uuid.UUID  `json:"child_id"`
+	Item      string     `json:"item"`
+	Kind      string     `json:"kind"`
+	Severity  NullString `json:"severity,omitempty"`
+	Aliases   []string   `json:"aliases"`
+	Notes     NullString `json:"notes,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ChildRestrictionRequest creates or replaces a registry entry.
+type ChildRestrictionRequest struct {
+	Item     string   `json:"item"`
+	Kind     string   `json:"kind"`
+	Severity string   `json:"severity,omitempty"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Notes    string   `json:"notes,omitempty"`
+}
+
+// RestrictionMatch is a food from a diet log that matched a registered
+// restriction.
+type RestrictionMatch struct {
+	RestrictionID uuid.UUID `json:"restriction_id"`
+	Item          string    `json:"item"`
+	Kind          string    `json:"kind"`
+	Severity      string    `json:"severity,omitempty"`
+	Food          string    `json:"food"`
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Routine is one of a child's recurring daily routines, e.g. the morning
+// routine, as ordered steps.
+type Routine struct {
+	ID      uuid.UUID `json:"id"`
+	ChildID uuid.UUID `json:"child_id"`
+	Name    string    `json:"name"`
+	Icon    string    `json:"icon"`
+	// StartTime is "HH:MM" when set.
+	StartTime NullString `json:"start_time,omitempty"`
+	// DaysOfWeek holds 0 (Sunday) to 6; empty means every day.
+	DaysOfWeek []int         `json:"days_of_week"`
+	Active     bool          `json:"active"`
+	CreatedBy  *uuid.UUID    `json:"created_by,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Steps      []RoutineStep `json:"steps"`
+}
+
+// RunsOn reports whether the routine is scheduled on day's weekday.
+func (r *Routine) RunsOn(day time.Time) bool {
+	if len(r.DaysOfWeek) == 0 {
+		return true
+	}
+	for _, d := range r.DaysOfWeek {
+		if d == int(day.Weekday()) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutineStep is one step of a routine.
+type RoutineStep struct {
+	ID              uuid.UUID `json:"id"`
+	RoutineID       uuid.UUID `json:"routine_id"`
+	Position        int       `json:"position"`
+	Title           string    `json:"title"`
+	Icon            string    `json:"icon"`
+	DurationMinutes *int      `json:"duration_minutes,omitempty"`
+}
+
+// RoutineRequest creates or replaces a routine. Steps are in order; a
+// step with an ID keeps that step (and its completion history), one
+// without is added, and existing steps left out are removed.
+type RoutineRequest struct {
+	Name       string               `json:"name"`
+	Icon       string               `json:"icon,omitempty"`
+	StartTime  string               `json:"start_time,omitempty"`
+	DaysOfWeek []int                `json:"days_of_week,omitempty"`
+	Active     *bool                `json:"active,omitempty"`
+	Steps      []RoutineStepRequest `json:"steps"`
+}
+
+// RoutineStepRequest is a step of a RoutineRequest.
+type RoutineStepRequest struct {
+	ID              *uuid.UUID `json:"id,omitempty"`
+	Title           string     `json:"title"`
+	Icon            string     `json:"icon,omitempty"`
+	DurationMinutes *int       `json:"duration_minutes,omitempty"`
+}
+
+// RoutineCompletion records a step done on a day.
+type RoutineCompletion struct {
+	StepID         uuid.UUID  `json:"step_id"`
+	RoutineID      uuid.UUID  `json:"routine_id"`
+	ChildID        uuid.UUID  `json:"child_id"`
+	CompletionDate time.Time  `json:"completion_date"`
+	CompletedBy    *uuid.UUID `json:"completed_by,omitempty"`
+	CompletedAt    time.Time  `json:"completed_at"`
+}
+
+// RoutineDayStep is a step with whether it was done that day.
+type RoutineDayStep struct {
+	RoutineStep
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// RoutineDay is a routine as scheduled on one day, with its progress.
+type RoutineDay struct {
+	RoutineID  uuid.UUID        `json:"routine_id"`
+	Name       string           `json:"name"`
+	Icon       string           `json:"icon"`
+	StartTime  NullString       `json:"start_time,omitempty"`
+	Steps      []RoutineDayStep `json:"steps"`
+	StepsDone  int              `json:"steps_done"`
+	StepsTotal int              `json:"steps_total"`
+}
+
+// RoutineStats is one routine's completion over a period.
+type RoutineStats struct {
+	RoutineID      uuid.UUID `json:"routine_id"`
+	Name           string    `json:"name"`
+	DaysScheduled  int       `json:"days_scheduled"`
+	DaysCompleted  int       `json:"days_completed"`
+	CompletionRate float64   `json:"completion_rate"`
+	CurrentStreak  int       `json:"current_streak"`
+}
+
+// RoutineCompletionSummary is a child's routine completion over a period:
+// the share of scheduled steps done, overall and per day.
+type RoutineCompletionSummary struct {
+	StartDate      string           `json:"start_date"`
+	EndDate        string           `json:"end_date"`
+	CompletionRate *float64         `json:"completion_rate,omitempty"`
+	Daily          []ChartDataPoint `json:"daily"`
+	Routines       []RoutineStats   `json:"routines"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rubric metrics: the behavior log levels a rubric gives meaning to.
+const (
+	RubricMetricMood    = "mood"
+	RubricMetricEnergy  = "energy"
+	RubricMetricAnxiety = "anxiety"
+)
+
+// RubricMetricNames lists the metrics every rubric defines.
+var RubricMetricNames = []string{RubricMetricMood, RubricMetricEnergy, RubricMetricAnxiety}
+
+// Range of the behavior log levels a rubric covers.
+const (
+	RubricMinLevel = 1
+	RubricMaxLevel = 10
+)
+
+// RubricLevel describes the levels from Min to Max inclusive, e.g. 7-8
+// is "Good" 🙂.
+type RubricLevel struct {
+	Min         int    `json:"min"`
+	Max         int    `json:"max"`
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	Emoji       string `json:"emoji,omitempty"`
+}
+
+// RubricMetrics maps each metric to its levels, lowest first, for
+// PostgreSQL JSONB columns.
+type RubricMetrics map[string][]RubricLevel
+
+func (m RubricMetrics) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+func (m *RubricMetrics) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+// ScoringRubric is one version of a family's rubric. Saving a rubric adds
+// a version rather than changing one, so behavior logs keep the meaning
+// they were entered with. Version 0 is the built-in default.
+type ScoringRubric struct {
+	FamilyID  uuid.UUID     `json:"family_id"`
+	Version   int           `json:"version"`
+	Metrics   RubricMetrics `json:"metrics"`
+	CreatedBy *uuid.UUID    `json:"created_by,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// ScoringRubricRequest saves a new rubric version. Each metric's levels
+// must cover 1-10 without gaps or overlaps; metrics left out keep their
+// current levels.
+type ScoringRubricRequest struct {
+	Metrics RubricMetrics `json:"metrics"`
+}
@@ -30,6 +30,9 @@ type Session struct {
 	LastSeenAt time.Time   `json:"last_seen_at"`
 	RevokedAt  *time.Time  `json:"revoked_at,omitempty"`
 	ExpiresAt  time.Time   `json:"expires_at"`
+	// LastAuthAt is when the user last entered their password for this
+	// session: sign-in, or an admin's step-up re-auth.
+	LastAuthAt time.Time `json:"last_auth_at"`
 }
 
 // IsActive returns true when the session is neither revoked nor expired.
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sleep sources. Manual is a caregiver-entered sleep log; the rest are
+// wearables and health apps records are imported from.
+const (
+	SleepSourceManual        = "manual"
+	SleepSourceAppleHealth   = "apple_health"
+	SleepSourceGoogleFit     = "google_fit"
+	SleepSourceHealthConnect = "health_connect"
+	SleepSourceFitbit        = "fitbit"
+	SleepSourceGarmin        = "garmin"
+	SleepSourceOura          = "oura"
+	SleepSourceOther         = "other"
+)
+
+// SleepDeviceSources lists the sources device records can be imported from.
+var SleepDeviceSources = []string{
+	SleepSourceAppleHealth, SleepSourceGoogleFit, SleepSourceHealthConnect,
+	SleepSourceFitbit, SleepSourceGarmin, SleepSourceOura, SleepSourceOther,
+}
+
+// Sleep log reconciliation statuses
+const (
+	SleepReconciliationConsistent = "consistent"
+	SleepReconciliationConflict   = "conflict"
+	SleepReconciliationResolved   = "resolved"
+)
+
+// SleepDeviceRecord is one sleep session imported from a device. NightDate
+// is the local date the child woke up on, matching the date caregivers log
+// the night under; Timezone is the importer's zone it was computed in.
+type SleepDeviceRecord struct {
+	ID                uuid.UUID  `json:"id"`
+	ChildID           uuid.UUID  `json:"child_id"`
+	Source            string     `json:"source"`
+	ExternalID        string     `json:"external_id"`
+	SleepStart        time.Time  `json:"sleep_start"`
+	SleepEnd          time.Time  `json:"sleep_end"`
+	TotalSleepMinutes int        `json:"total_sleep_minutes"`
+	NightWakings      *int       `json:"night_wakings,omitempty"`
+	NightDate         time.Time  `json:"night_date"`
+	Timezone          string     `json:"timezone"`
+	ImportedBy        *uuid.UUID `json:"imported_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// SleepDeviceRecordInput is one session in an import batch. TotalSleepMinutes
+// is the device's asleep time; when omitted the span from start to end is
+// used. Re-importing the same ExternalID replaces the earlier record.
+type SleepDeviceRecordInput struct {
+	Source            string    `json:"source"`
+	ExternalID        string    `json:"external_id"`
+	SleepStart        time.Time `json:"sleep_start"`
+	SleepEnd          time.Time `json:"sleep_end"`
+	TotalSleepMinutes *int      `json:"total_sleep_minutes,omitempty"`
+	NightWakings      *int      `json:"night_wakings,omitempty"`
+}
+
+// SleepDeviceImportRequest is a batch of device sleep sessions.
+type SleepDeviceImportRequest struct {
+	Records []SleepDeviceRecordInput `json:"records"`
+}
+
+// SleepSourcePreference is which source wins when a child's manual and
+// device sleep data disagree by more than ConflictThresholdMinutes.
+type SleepSourcePreference struct {
+	ChildID                  uuid.UUID  `json:"child_id"`
+	PreferredSource          string     `json:"preferred_source"`
+	ConflictThresholdMinutes int        `json:"conflict_threshold_minutes"`
+	UpdatedBy                *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+}
+
+// SleepSourcePreferenceRequest replaces a child's preference; omitted
+// fields keep their current value.
+type SleepSourcePreferenceRequest struct {
+	PreferredSource          string `json:"preferred_source"`
+	ConflictThresholdMinutes *int   `json:"conflict_threshold_minutes,omitempty"`
+}
+
+// SleepNightReconciliation is the outcome for one night that had a device
+// record.
+type SleepNightReconciliation struct {
+	Date   time.Time  `json:"date"`
+	LogID  *uuid.UUID `json:"log_id,omitempty"`
+	Status string     `json:"status"`
+	Note   string     `json:"note,omitempty"`
+}
+
+// SleepReconciliationResult summarizes a reconciliation run for a child.
+type SleepReconciliationResult struct {
+	ChildID    uuid.UUID                  `json:"child_id"`
+	Start      time.Time                  `json:"start"`
+	End        time.Time                  `json:"end"`
+	Consistent int                        `json:"consistent"`
+	Conflicts  int                        `json:"conflicts"`
+	Resolved   int                        `json:"resolved"`
+	Created    int                        `json:"created"`
+	Nights     []SleepNightReconciliation `json:"nights"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Therapy goal statuses
+const (
+	TherapyGoalActive       = "active"
+	TherapyGoalMet          = "met"
+	TherapyGoalDiscontinued = "discontinued"
+)
+
+// TherapyGoal is one of a child's therapy goals, e.g. "Requests help
+// using a 3-word phrase", measured in TargetMetric from Baseline towards
+// TargetValue by TargetDate.
+type TherapyGoal struct {
+	ID           uuid.UUID  `json:"id"`
+	ChildID      uuid.UUID  `json:"child_id"`
+	GoalText     string     `json:"goal_text"`
+	TherapyType  NullString `json:"therapy_type,omitempty"`
+	TargetMetric string     `json:"target_metric"`
+	Baseline     *float64   `json:"baseline,omitempty"`
+	TargetValue  *float64   `json:"target_value,omitempty"`
+	TargetDate   *time.Time `json:"target_date,omitempty"`
+	Status       string     `json:"status"`
+	MetAt        *time.Time `json:"met_at,omitempty"`
+	CreatedBy    *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TherapyGoalRequest creates or updates a therapy goal. Status is only
+// read on update.
+type TherapyGoalRequest struct {
+	GoalText     string   `json:"goal_text"`
+	TherapyType  string   `json:"therapy_type,omitempty"`
+	TargetMetric string   `json:"target_metric,omitempty"`
+	Baseline     *float64 `json:"baseline,omitempty"`
+	TargetValue  *float64 `json:"target_value,omitempty"`
+	TargetDate   FlexDate `json:"target_date,omitempty"`
+	Status       string   `json:"status,omitempty"`
+}
+
+// TherapyLogGoal is a goal worked on in a therapy session: a 1-5
+// progress rating and/or a value measured in the goal's metric.
+type TherapyLogGoal struct {
+	GoalID         uuid.UUID `json:"goal_id"`
+	GoalText       string    `json:"goal_text,omitempty"`
+	ProgressRating *int      `json:"progress_rating,omitempty"`
+	MeasuredValue  *float64  `json:"measured_value,omitempty"`
+	Note           string    `json:"note,omitempty"`
+}
+
+// TherapyGoalPoint is one session's progress on a goal.
+type TherapyGoalPoint struct {
+	TherapyLogID   uuid.UUID `json:"therapy_log_id"`
+	Date           time.Time `json:"date"`
+	ProgressRating *int      `json:"progress_rating,omitempty"`
+	MeasuredValue  *float64  `json:"measured_value,omitempty"`
+	Note           string    `json:"note,omitempty"`
+}
+
+// TherapyGoalTrend rolls a goal's sessions over a period up for its trend
+// chart and the progress report.
+type TherapyGoalTrend struct {
+	Goal          TherapyGoal        `json:"goal"`
+	Points        []TherapyGoalPoint `json:"points"`
+	Sessions      int                `json:"sessions"`
+	AverageRating *float64           `json:"average_rating,omitempty"`
+	LatestValue   *float64           `json:"latest_value,omitempty"`
+	// PercentToTarget is how far LatestValue has come from Baseline to
+	// TargetValue, when all three are known.
+	PercentToTarget *float64 `json:"percent_to_target,omitempty"`
+	// Direction is improving, steady, declining or insufficient_data,
+	// from the first and second half of the period's ratings.
+	Direction string `json:"direction"`
+}
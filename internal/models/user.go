@@ -189,3 +189,30 @@ type OnboardingState struct {
 	SettingsDoneAt       *time.Time `json:"settings_done_at,omitempty"`
 	InviteDoneAt         *time.Time `json:"invite_done_at,omitempty"`
 }
+
+// Guided setup steps, in the order the client walks a new user through
+// them. Completion is detected server-side from the user's data.
+const (
+	OnboardingStepVerifyEmail   = "verify_email"
+	OnboardingStepCreateFamily  = "create_family"
+	OnboardingStepAddChild      = "add_child"
+	OnboardingStepAddMedication = "add_medication"
+	OnboardingStepFirstLog      = "first_log"
+)
+
+// OnboardingSteps lists the guided setup steps in order.
+var OnboardingSteps = []string{
+	OnboardingStepVerifyEmail,
+	OnboardingStepCreateFamily,
+	OnboardingStepAddChild,
+	OnboardingStepAddMedication,
+	OnboardingStepFirstLog,
+}
+
+// OnboardingStepRecord is one resolved guided setup step. Skipped is set
+// when the user chose to skip an optional step rather than complete it.
+type OnboardingStepRecord struct {
+	Step        string    `json:"step"`
+	CompletedAt time.Time `json:"completed_at"`
+	Skipped     bool      `json:"skipped"`
+}
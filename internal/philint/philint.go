@@ -0,0 +1,261 @@
+// Package philint is the static check behind the admin repository's PHI
+// isolation rule. It parses admin-context Go files, joins the constant
+// parts of every string expression, and reports SQL that reaches a table
+// in repository.PHITables beyond counting it (repository.PHIViolations),
+// plus any other string naming a PHI table outright. Reviewed exceptions
+// live in allowlist.txt; philint_test.go runs the check over Targets, so
+// `go test ./...` and the Docker build fail on a new reference.
+package philint
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"carecompanion/internal/repository"
+)
+
+// Targets are the admin-context files checked, as globs relative to the
+// repository root. Test files are skipped.
+var Targets = []string{
+	"internal/repository/admin_*.go",
+	"internal/repository/replicating_admin_repo.go",
+	"internal/handler/admin/*.go",
+}
+
+var tableRE = regexp.MustCompile(`\b(` + strings.Join(repository.PHITables, "|") + `)\b`)
+
+// Finding is one reference to a PHI table.
+type Finding struct {
+	Pos token.Position
+	// File is relative to the repository root, slash-separated.
+	File string
+	// Scope is the enclosing function ("adminRepo.GetCapacityCounts") or
+	// package-level variable.
+	Scope   string
+	Table   string
+	Problem string
+	Text    string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (in %s): %q", f.File, f.Pos.Line, f.Table, f.Problem, f.Scope, f.Text)
+}
+
+// AllowEntry is one reviewed exception from the allowlist.
+type AllowEntry struct {
+	File, Scope, Table, Reason string
+	Line                       int
+}
+
+func (e AllowEntry) matches(f Finding) bool {
+	return e.File == f.File && e.Scope == f.Scope && e.Table == f.Table
+}
+
+// ParseAllowlist reads allowlist lines of the form
+//
+//	<file> <scope> <table>  # reason
+//
+// Blank lines and lines starting with # are skipped. Every entry needs a
+// reason.
+func ParseAllowlist(r io.Reader) ([]AllowEntry, error) {
+	var out []AllowEntry
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, reason, _ := strings.Cut(line, "#")
+		fields := strings.Fields(entry)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("allowlist line %d: want <file> <scope> <table>, got %q", n, entry)
+		}
+		if strings.TrimSpace(reason) == "" {
+			return nil, fmt.Errorf("allowlist line %d: no reason given", n)
+		}
+		out = append(out, AllowEntry{File: fields[0], Scope: fields[1], Table: fields[2], Reason: strings.TrimSpace(reason), Line: n})
+	}
+	return out, sc.Err()
+}
+
+// Run checks the files matching patterns under root. It returns the
+// findings no allowlist entry covers, and the entries that covered
+// nothing, so the allowlist can't outlive the code it excuses.
+func Run(root string, patterns []string, allow []AllowEntry) (findings []Finding, unused []AllowEntry, err error) {
+	used := make([]bool, len(allow))
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return nil, nil, err
+			}
+			found, err := LintFile(file, filepath.ToSlash(rel))
+			if err != nil {
+				return nil, nil, err
+			}
+		next:
+			for _, f := range found {
+				for i, e := range allow {
+					if e.matches(f) {
+						used[i] = true
+						continue next
+					}
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+	for i, e := range allow {
+		if !used[i] {
+			unused = append(unused, e)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Pos.Offset < findings[j].Pos.Offset
+	})
+	return findings, unused, nil
+}
+
+// LintFile checks one Go file; rel is the name findings carry.
+func LintFile(path, rel string) ([]Finding, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	var out []Finding
+	check := func(e ast.Expr, scope string) {
+		text := fold(e)
+		pos := fset.Position(e.Pos())
+		if repository.PHIReferences(text) {
+			for _, v := range repository.PHIViolations(text) {
+				out = append(out, Finding{Pos: pos, File: rel, Scope: scope, Table: v.Table, Problem: v.String(), Text: text})
+			}
+			return
+		}
+		for _, table := range uniq(tableRE.FindAllString(text, -1)) {
+			out = append(out, Finding{Pos: pos, File: rel, Scope: scope, Table: table, Problem: "names a PHI table", Text: text})
+		}
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Body != nil {
+				inspectStrings(d.Body, funcScope(d), check)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for i, v := range vs.Values {
+					scope := vs.Names[0].Name
+					if i < len(vs.Names) {
+						scope = vs.Names[i].Name
+					}
+					inspectStrings(v, scope, check)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// inspectStrings hands check every maximal string expression under n: a
+// string literal, or a chain of + joining one.
+func inspectStrings(n ast.Node, scope string, check func(ast.Expr, string)) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BinaryExpr:
+			if n.Op == token.ADD && hasString(n) {
+				check(n, scope)
+				return false
+			}
+		case *ast.BasicLit:
+			if n.Kind == token.STRING {
+				check(n, scope)
+			}
+		}
+		return true
+	})
+}
+
+func hasString(e ast.Expr) bool {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.STRING
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && (hasString(e.X) || hasString(e.Y))
+	case *ast.ParenExpr:
+		return hasString(e.X)
+	}
+	return false
+}
+
+// fold joins the constant parts of a string expression; anything else
+// becomes "?".
+func fold(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			if s, err := strconv.Unquote(e.Value); err == nil {
+				return s
+			}
+		}
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return fold(e.X) + fold(e.Y)
+		}
+	case *ast.ParenExpr:
+		return fold(e.X)
+	}
+	return "?"
+}
+
+// funcScope names a function as the query tags do: "adminRepo.Method"
+// for methods.
+func funcScope(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+	t := d.Recv.List[0].Type
+	if s, ok := t.(*ast.StarExpr); ok {
+		t = s.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name + "." + d.Name.Name
+	}
+	return d.Name.Name
+}
+
+func uniq(s []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, x := range s {
+		if !seen[x] {
+			seen[x] = true
+			out = append(out, x)
+		}
+	}
+	return out
+}
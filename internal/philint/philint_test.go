@@ -0,0 +1,88 @@
+package philint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAdminCodePHI is the lint itself: it fails on any reference to a PHI
+// table from admin-context code that allowlist.txt doesn't excuse.
+func TestAdminCodePHI(t *testing.T) {
+	f, err := os.Open("allowlist.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	allow, err := ParseAllowlist(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, unused, err := Run("../..", Targets, allow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range findings {
+		t.Errorf("%s\n\tAdmin code must not reach PHI tables. Count rows instead, or add a reviewed exception to internal/philint/allowlist.txt.", f)
+	}
+	for _, e := range unused {
+		t.Errorf("allowlist.txt:%d: %s %s %s matches nothing; remove it", e.Line, e.File, e.Scope, e.Table)
+	}
+}
+
+func TestLintFile(t *testing.T) {
+	dir := t.TempDir()
+	src := `package repository
+
+var countedTables = []string{"sleep_logs"}
+
+func (r *adminRepo) Counts() {
+	r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM children WHERE is_active")
+	r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM " + table)
+}
+
+func (r *adminRepo) Leak(id string) {
+	q := "SELECT first_name FROM children " +
+		"WHERE id = $1"
+	r.db.QueryRowContext(ctx, q, id)
+	r.db.ExecContext(ctx, ` + "`UPDATE medications SET is_active = false`" + `)
+}
+`
+	path := filepath.Join(dir, "admin_fixture.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	findings, err := LintFile(path, "admin_fixture.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Scope+" "+f.Table+" "+f.Problem)
+	}
+	want := []string{
+		"countedTables sleep_logs names a PHI table",
+		"adminRepo.Leak children reads children rows",
+		"adminRepo.Leak medications writes medications",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	allow, err := ParseAllowlist(strings.NewReader("admin_fixture.go countedTables sleep_logs # counted\nadmin_fixture.go adminRepo.Gone children # was counted\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, unused, err := Run(dir, []string{"admin_*.go"}, allow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 2 || len(unused) != 1 || unused[0].Scope != "adminRepo.Gone" {
+		t.Errorf("Run = %v, unused %v", rest, unused)
+	}
+
+	if _, err := ParseAllowlist(strings.NewReader("a.go f children\n")); err == nil {
+		t.Error("entry without a reason accepted")
+	}
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// Abuse block statuses. A block is active until it expires or is lifted;
+// List reports active blocks past their expiry as expired.
+const (
+	AbuseBlockActive  = "active"
+	AbuseBlockExpired = "expired"
+	AbuseBlockLifted  = "lifted"
+)
+
+// AbuseBlock is a temporary block abuse detection put on a client IP.
+// Blocks not yet reviewed make up the admin review queue.
+type AbuseBlock struct {
+	ID              uuid.UUID       `json:"id"`
+	IPAddress       string          `json:"ip_address"`
+	Reason          string          `json:"reason"`
+	Details         json.RawMessage `json:"details"`
+	BlockedAt       time.Time       `json:"blocked_at"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+	Status          string          `json:"status"`
+	ReviewedBy      models.NullUUID `json:"reviewed_by,omitempty"`
+	ReviewedByEmail string          `json:"reviewed_by_email,omitempty"`
+	ReviewedAt      *time.Time      `json:"reviewed_at,omitempty"`
+	ReviewNote      string          `json:"review_note,omitempty"`
+}
+
+// AbuseBlockFilter narrows List. Queue selects blocks not yet reviewed
+// and ignores Status.
+type AbuseBlockFilter struct {
+	Queue  bool
+	Status string
+	IP     string
+	Limit  int
+}
+
+// AbuseBlockRepository owns abuse_blocks. Enforcement lives in Redis; the
+// service keeps the two in step.
+type AbuseBlockRepository interface {
+	// Create records a block; ID and BlockedAt are filled in.
+	Create(ctx context.Context, b *AbuseBlock) error
+	GetByID(ctx context.Context, id uuid.UUID) (*AbuseBlock, error)
+	List(ctx context.Context, f AbuseBlockFilter) ([]AbuseBlock, error)
+	// CountSince counts the IP's blocks since the given time.
+	CountSince(ctx context.Context, ip string, since time.Time) (int, error)
+	// QueueSize counts blocks not yet reviewed.
+	QueueSize(ctx context.Context) (int, error)
+	// Review records an admin's review of the block, setting its status
+	// when status isn't empty. It reports false if the block doesn't
+	// exist.
+	Review(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error)
+}
+
+type abuseBlockRepo struct {
+	db *DB
+}
+
+// NewAbuseBlockRepo creates an AbuseBlockRepository on the main pool.
+func NewAbuseBlockRepo(db *sql.DB) AbuseBlockRepository {
+	return &abuseBlockRepo{db: WrapDB(db)}
+}
+
+const abuseBlockCols = `
+    ab.id, HOST(ab.ip_address), ab.reason, ab.details, ab.blocked_at, ab.expires_at,
+    CASE WHEN ab.status = 'active' AND ab.expires_at <= NOW() THEN 'expired' ELSE ab.status END,
+    ab.reviewed_by, COALESCE(au.email, ''), ab.reviewed_at, COALESCE(ab.review_note, '')`
+
+const abuseBlockFrom = `
+    FROM abuse_blocks ab
+    LEFT JOIN admin_users au ON au.id = ab.reviewed_by`
+
+func scanAbuseBlock(s rowScannerLike) (*AbuseBlock, error) {
+	b := &AbuseBlock{}
+	var details []byte
+	var reviewedAt sql.NullTime
+	err := s.Scan(&b.ID, &b.IPAddress, &b.Reason, &details, &b.BlockedAt, &b.ExpiresAt,
+		&b.Status, &b.ReviewedBy, &b.ReviewedByEmail, &reviewedAt, &b.ReviewNote)
+	if err != nil {
+		return nil, err
+	}
+	b.Details = details
+	if reviewedAt.Valid {
+		b.ReviewedAt = &reviewedAt.Time
+	}
+	return b, nil
+}
+
+func (r *abuseBlockRepo) Create(ctx context.Context, b *AbuseBlock) error {
+	details := []byte(b.Details)
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+	b.Status = AbuseBlockActive
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO abuse_blocks (ip_address, reason, details, expires_at)
+        VALUES ($1::inet, $2, $3, $4)
+        RETURNING id, blocked_at
+    `, b.IPAddress, b.Reason, details, b.ExpiresAt,
+	).Scan(&b.ID, &b.BlockedAt)
+}
+
+func (r *abuseBlockRepo) GetByID(ctx context.Context, id uuid.UUID) (*AbuseBlock, error) {
+	b, err := scanAbuseBlock(r.db.QueryRowContext(ctx,
+		"SELECT "+abuseBlockCols+abuseBlockFrom+" WHERE ab.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (r *abuseBlockRepo) List(ctx context.Context, f AbuseBlockFilter) ([]AbuseBlock, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+	q := "SELECT " + abuseBlockCols + abuseBlockFrom + " WHERE 1=1"
+	args := []interface{}{}
+	switch {
+	case f.Queue:
+		q += " AND ab.reviewed_at IS NULL"
+	case f.Status == AbuseBlockActive:
+		q += " AND ab.status = 'active' AND ab.expires_at > NOW()"
+	case f.Status == AbuseBlockExpired:
+		q += " AND ab.status = 'active' AND ab.expires_at <= NOW()"
+	case f.Status != "":
+		args = append(args, f.Status)
+		q += " AND ab.status = $1"
+	}
+	if f.IP != "" {
+		args = append(args, f.IP)
+		q += " AND ab.ip_address = $" + itoa(len(args)) + "::inet"
+	}
+	args = append(args, limit)
+	q += " ORDER BY ab.blocked_at DESC LIMIT $" + itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AbuseBlock
+	for rows.Next() {
+		b, err := scanAbuseBlock(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *b)
+	}
+	return out, rows.Err()
+}
+
+func (r *abuseBlockRepo) CountSince(ctx context.Context, ip string, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM abuse_blocks WHERE ip_address = $1::inet AND blocked_at >= $2
+    `, ip, since).Scan(&n)
+	return n, err
+}
+
+func (r *abuseBlockRepo) QueueSize(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM abuse_blocks WHERE reviewed_at IS NULL`).Scan(&n)
+	return n, err
+}
+
+func (r *abuseBlockRepo) Review(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE abuse_blocks
+        SET status = COALESCE(NULLIF($2, ''), status), reviewed_by = $3, reviewed_at = NOW(), review_note = NULLIF($4, '')
+        WHERE id = $1
+    `, id, status, by, note)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -89,11 +89,11 @@ type AccountDeletionRepository interface {
 }
 
 type accountDeletionRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewAccountDeletionRepository(db *sql.DB) AccountDeletionRepository {
-	return &accountDeletionRepo{db: db}
+	return &accountDeletionRepo{db: WrapDB(db)}
 }
 
 func (r *accountDeletionRepo) CreateRequest(ctx context.Context, req *models.AccountDeletionRequest) error {
@@ -587,7 +587,7 @@ func scanADR(row adrRowScanner) (*models.AccountDeletionRequest, error) {
 	return &r, nil
 }
 
-func scanADRList(rows *sql.Rows) ([]models.AccountDeletionRequest, error) {
+func scanADRList(rows *Rows) ([]models.AccountDeletionRequest, error) {
 	var out []models.AccountDeletionRequest
 	for rows.Next() {
 		r, err := scanADR(rows)
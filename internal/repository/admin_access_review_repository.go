@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Access review decisions.
+const (
+	AccessReviewAttest = "attest"
+	AccessReviewRevoke = "revoke"
+)
+
+// AdminAccessReview is one quarter's review of admin access.
+type AdminAccessReview struct {
+	ID          uuid.UUID  `json:"id"`
+	Period      string     `json:"period"`
+	OpenedAt    time.Time  `json:"opened_at"`
+	DueAt       time.Time  `json:"due_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CompletedBy *uuid.UUID `json:"completed_by,omitempty"`
+
+	// Counted from the items.
+	Items    int `json:"items"`
+	Attested int `json:"attested"`
+	Revoked  int `json:"revoked"`
+}
+
+// Pending is how many items still need a decision.
+func (r *AdminAccessReview) Pending() int {
+	return r.Items - r.Attested - r.Revoked
+}
+
+// AdminAccessReviewItem is one admin account as it stood when the review
+// opened, and the decision on it.
+type AdminAccessReviewItem struct {
+	ID          uuid.UUID         `json:"id"`
+	ReviewID    uuid.UUID         `json:"review_id"`
+	AdminID     uuid.UUID         `json:"admin_id"`
+	Email       string            `json:"email"`
+	Name        string            `json:"name"`
+	Role        string            `json:"role"`
+	Status      string            `json:"status"`
+	LastLoginAt *time.Time        `json:"last_login_at,omitempty"`
+	Permissions map[string]string `json:"permissions"`
+	Decision    string            `json:"decision,omitempty"`
+	DecidedBy   *uuid.UUID        `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time        `json:"decided_at,omitempty"`
+	Note        string            `json:"note,omitempty"`
+}
+
+// AdminAccount is an admin_users row as access reviews and the inactivity
+// sweep see it.
+type AdminAccount struct {
+	ID          uuid.UUID
+	Email       string
+	FirstName   string
+	LastName    string
+	Role        string
+	Status      string
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+}
+
+// AdminAccessReviewRepository stores access reviews. Decided items and
+// completed reviews are immutable; the database refuses changes to them.
+type AdminAccessReviewRepository interface {
+	// ListAccounts returns every admin account, oldest first.
+	ListAccounts(ctx context.Context) ([]AdminAccount, error)
+	// Open creates the review for review.Period with its items, and
+	// reports false when that period already has one.
+	Open(ctx context.Context, review *AdminAccessReview, items []AdminAccessReviewItem) (bool, error)
+	// Get returns nil, nil when there is no such review.
+	Get(ctx context.Context, id uuid.UUID) (*AdminAccessReview, error)
+	// List returns reviews newest first.
+	List(ctx context.Context, limit int) ([]AdminAccessReview, error)
+	Items(ctx context.Context, reviewID uuid.UUID) ([]AdminAccessReviewItem, error)
+	// GetItem returns nil, nil when the item isn't in the review.
+	GetItem(ctx context.Context, reviewID, itemID uuid.UUID) (*AdminAccessReviewItem, error)
+	// Decide records the decision on an undecided item of an open review,
+	// reporting false when the item was already decided.
+	Decide(ctx context.Context, itemID uuid.UUID, decision string, by uuid.UUID, note string) (bool, error)
+	// Complete closes a review whose items are all decided, reporting
+	// false when it was already complete or items are still pending.
+	Complete(ctx context.Context, id, by uuid.UUID) (bool, error)
+}
+
+type adminAccessReviewRepo struct {
+	db *DB
+}
+
+// NewAdminAccessReviewRepo wires the DB.
+func NewAdminAccessReviewRepo(db *sql.DB) AdminAccessReviewRepository {
+	return &adminAccessReviewRepo{db: WrapDB(db)}
+}
+
+func (r *adminAccessReviewRepo) ListAccounts(ctx context.Context) ([]AdminAccount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, email, first_name, last_name, system_role::text, status::text, last_login_at, created_at
+		FROM admin_users
+		ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AdminAccount
+	for rows.Next() {
+		var a AdminAccount
+		if err := rows.Scan(&a.ID, &a.Email, &a.FirstName, &a.LastName, &a.Role, &a.Status, &a.LastLoginAt, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *adminAccessReviewRepo) Open(ctx context.Context, review *AdminAccessReview, items []AdminAccessReviewItem) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO admin_access_reviews (period, due_at)
+		VALUES ($1, $2)
+		ON CONFLICT (period) DO NOTHING
+		RETURNING id, opened_at`, review.Period, review.DueAt,
+	).Scan(&review.ID, &review.OpenedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for i := range items {
+		perms, err := json.Marshal(items[i].Permissions)
+		if err != nil {
+			return false, err
+		}
+		items[i].ReviewID = review.ID
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO admin_access_review_items (review_id, admin_id, email, name, role, status, last_login_at, permissions)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			review.ID, items[i].AdminID, items[i].Email, items[i].Name, items[i].Role, items[i].Status,
+			items[i].LastLoginAt, perms,
+		).Scan(&items[i].ID); err != nil {
+			return false, err
+		}
+	}
+	review.Items = len(items)
+	return true, tx.Commit()
+}
+
+const adminAccessReviewColumns = `
+	r.id, r.period, r.opened_at, r.due_at, r.completed_at, r.completed_by,
+	COUNT(i.id),
+	COUNT(i.id) FILTER (WHERE i.decision = 'attest'),
+	COUNT(i.id) FILTER (WHERE i.decision = 'revoke')
+	FROM admin_access_reviews r
+	LEFT JOIN admin_access_review_items i ON i.review_id = r.id`
+
+func scanAdminAccessReview(row interface{ Scan(...any) error }) (*AdminAccessReview, error) {
+	var rv AdminAccessReview
+	if err := row.Scan(&rv.ID, &rv.Period, &rv.OpenedAt, &rv.DueAt, &rv.CompletedAt, &rv.CompletedBy,
+		&rv.Items, &rv.Attested, &rv.Revoked); err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (r *adminAccessReviewRepo) Get(ctx context.Context, id uuid.UUID) (*AdminAccessReview, error) {
+	rv, err := scanAdminAccessReview(r.db.QueryRowContext(ctx,
+		`SELECT `+adminAccessReviewColumns+` WHERE r.id = $1 GROUP BY r.id`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rv, err
+}
+
+func (r *adminAccessReviewRepo) List(ctx context.Context, limit int) ([]AdminAccessReview, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+adminAccessReviewColumns+` GROUP BY r.id ORDER BY r.opened_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AdminAccessReview
+	for rows.Next() {
+		rv, err := scanAdminAccessReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rv)
+	}
+	return out, rows.Err()
+}
+
+const adminAccessReviewItemColumns = `
+	id, review_id, admin_id, email, name, role, status, last_login_at, permissions,
+	COALESCE(decision, ''), decided_by, decided_at, COALESCE(note, '')
+	FROM admin_access_review_items`
+
+func scanAdminAccessReviewItem(row interface{ Scan(...any) error }) (*AdminAccessReviewItem, error) {
+	var it AdminAccessReviewItem
+	var perms []byte
+	if err := row.Scan(&it.ID, &it.ReviewID, &it.AdminID, &it.Email, &it.Name, &it.Role, &it.Status,
+		&it.LastLoginAt, &perms, &it.Decision, &it.DecidedBy, &it.DecidedAt, &it.Note); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(perms, &it.Permissions); err != nil {
+		return nil, err
+	}
+	return &it, nil
+}
+
+func (r *adminAccessReviewRepo) Items(ctx context.Context, reviewID uuid.UUID) ([]AdminAccessReviewItem, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+adminAccessReviewItemColumns+` WHERE review_id = $1 ORDER BY email`, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AdminAccessReviewItem
+	for rows.Next() {
+		it, err := scanAdminAccessReviewItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *it)
+	}
+	return out, rows.Err()
+}
+
+func (r *adminAccessReviewRepo) GetItem(ctx context.Context, reviewID, itemID uuid.UUID) (*AdminAccessReviewItem, error) {
+	it, err := scanAdminAccessReviewItem(r.db.QueryRowContext(ctx,
+		`SELECT `+adminAccessReviewItemColumns+` WHERE id = $1 AND review_id = $2`, itemID, reviewID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return it, err
+}
+
+func (r *adminAccessReviewRepo) Decide(ctx context.Context, itemID uuid.UUID, decision string, by uuid.UUID, note string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE admin_access_review_items
+		SET decision = $2, decided_by = $3, decided_at = NOW(), note = NULLIF($4, '')
+		WHERE id = $1 AND decision IS NULL`, itemID, decision, by, note)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *adminAccessReviewRepo) Complete(ctx context.Context, id, by uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE admin_access_reviews SET completed_at = NOW(), completed_by = $2
+		WHERE id = $1 AND completed_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM admin_access_review_items WHERE review_id = $1 AND decision IS NULL)`, id, by)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Elevation statuses. Expired isn't stored: it's an approved elevation
+// past its expires_at.
+const (
+	ElevationPending   = "pending"
+	ElevationApproved  = "approved"
+	ElevationDenied    = "denied"
+	ElevationCancelled = "cancelled"
+	ElevationRevoked   = "revoked"
+	ElevationExpired   = "expired"
+)
+
+// AdminElevation is a request for temporary access to one section of the
+// permission matrix above what the admin's role grants.
+type AdminElevation struct {
+	ID              uuid.UUID  `json:"id"`
+	AdminID         uuid.UUID  `json:"admin_id"`
+	Section         string     `json:"section"`
+	Level           string     `json:"level"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Reason          string     `json:"reason"`
+	Status          string     `json:"status"`
+	RequestedAt     time.Time  `json:"requested_at"`
+	DecidedBy       *uuid.UUID `json:"decided_by,omitempty"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	DecisionNote    string     `json:"decision_note,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	RevokedBy       *uuid.UUID `json:"revoked_by,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+
+	// Joined from admin_users.
+	AdminEmail   string `json:"admin_email"`
+	AdminRole    string `json:"admin_role"`
+	DecidedEmail string `json:"decided_by_email,omitempty"`
+}
+
+// CurrentStatus is Status with expiry applied.
+func (e *AdminElevation) CurrentStatus(now time.Time) string {
+	if e.Status == ElevationApproved && e.ExpiresAt != nil && !now.Before(*e.ExpiresAt) {
+		return ElevationExpired
+	}
+	return e.Status
+}
+
+// AdminElevationFilter selects elevations for List. AdminID limits to
+// one admin; Status is a stored status, or ElevationExpired, or "active"
+// for approved and unexpired; empty is all.
+type AdminElevationFilter struct {
+	AdminID uuid.UUID
+	Status  string
+	Limit   int
+}
+
+// AdminElevationRepository stores elevation requests. State changes are
+// conditional on the current state, so two super admins acting on the
+// same request can't both win.
+type AdminElevationRepository interface {
+	Create(ctx context.Context, e *AdminElevation) error
+	// Get returns nil, nil when there is no such elevation.
+	Get(ctx context.Context, id uuid.UUID) (*AdminElevation, error)
+	List(ctx context.Context, f AdminElevationFilter) ([]AdminElevation, error)
+	// Active returns the admin's approved, unexpired elevations.
+	Active(ctx context.Context, adminID uuid.UUID) ([]AdminElevation, error)
+	// Open reports whether the admin already has a pending or active
+	// elevation for section.
+	Open(ctx context.Context, adminID uuid.UUID, section string) (bool, error)
+	// Decide moves a pending elevation to approved (starting its window
+	// now), denied or cancelled, reporting false when it wasn't pending.
+	Decide(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error)
+	// Revoke ends an active elevation early, reporting false when it
+	// wasn't active.
+	Revoke(ctx context.Context, id, by uuid.UUID) (bool, error)
+}
+
+type adminElevationRepo struct {
+	db *DB
+}
+
+// NewAdminElevationRepo wires the DB.
+func NewAdminElevationRepo(db *sql.DB) AdminElevationRepository {
+	return &adminElevationRepo{db: WrapDB(db)}
+}
+
+const adminElevationColumns = `
+	e.id, e.admin_id, e.section, e.level, e.duration_minutes, e.reason, e.status, e.requested_at,
+	e.decided_by, e.decided_at, COALESCE(e.decision_note, ''), e.expires_at, e.revoked_by, e.revoked_at,
+	a.email, a.system_role::text, COALESCE(d.email, '')
+	FROM admin_elevations e
+	JOIN admin_users a ON a.id = e.admin_id
+	LEFT JOIN admin_users d ON d.id = e.decided_by`
+
+func scanAdminElevation(row interface{ Scan(...any) error }) (*AdminElevation, error) {
+	var e AdminElevation
+	if err := row.Scan(&e.ID, &e.AdminID, &e.Section, &e.Level, &e.DurationMinutes, &e.Reason, &e.Status, &e.RequestedAt,
+		&e.DecidedBy, &e.DecidedAt, &e.DecisionNote, &e.ExpiresAt, &e.RevokedBy, &e.RevokedAt,
+		&e.AdminEmail, &e.AdminRole, &e.DecidedEmail); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *adminElevationRepo) queryElevations(ctx context.Context, query string, args ...any) ([]AdminElevation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AdminElevation
+	for rows.Next() {
+		e, err := scanAdminElevation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+func (r *adminElevationRepo) Create(ctx context.Context, e *AdminElevation) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO admin_elevations (admin_id, section, level, duration_minutes, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, requested_at`,
+		e.AdminID, e.Section, e.Level, e.DurationMinutes, e.Reason,
+	).Scan(&e.ID, &e.Status, &e.RequestedAt)
+}
+
+func (r *adminElevationRepo) Get(ctx context.Context, id uuid.UUID) (*AdminElevation, error) {
+	e, err := scanAdminElevation(r.db.QueryRowContext(ctx, `SELECT `+adminElevationColumns+` WHERE e.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+func (r *adminElevationRepo) List(ctx context.Context, f AdminElevationFilter) ([]AdminElevation, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	return r.queryElevations(ctx, `
+		SELECT `+adminElevationColumns+`
+		WHERE ($1::uuid IS NULL OR e.admin_id = $1)
+		  AND CASE $2
+		        WHEN '' THEN TRUE
+		        WHEN 'active' THEN e.status = 'approved' AND e.expires_at > NOW()
+		        WHEN 'expired' THEN e.status = 'approved' AND e.expires_at <= NOW()
+		        ELSE e.status = $2
+		      END
+		ORDER BY e.requested_at DESC
+		LIMIT $3`, uuid.NullUUID{UUID: f.AdminID, Valid: f.AdminID != uuid.Nil}, f.Status, f.Limit)
+}
+
+func (r *adminElevationRepo) Active(ctx context.Context, adminID uuid.UUID) ([]AdminElevation, error) {
+	return r.queryElevations(ctx, `
+		SELECT `+adminElevationColumns+`
+		WHERE e.admin_id = $1 AND e.status = 'approved' AND e.expires_at > NOW()
+		ORDER BY e.expires_at`, adminID)
+}
+
+func (r *adminElevationRepo) Open(ctx context.Context, adminID uuid.UUID, section string) (bool, error) {
+	var open bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM admin_elevations
+			WHERE admin_id = $1 AND section = $2
+			  AND (status = 'pending' OR (status = 'approved' AND expires_at > NOW())))`, adminID, section).Scan(&open)
+	return open, err
+}
+
+func (r *adminElevationRepo) Decide(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE admin_elevations
+		SET status = $2, decided_by = $3, decided_at = NOW(), decision_note = NULLIF($4, ''),
+		    expires_at = CASE WHEN $2 = 'approved' THEN NOW() + make_interval(mins => duration_minutes) END
+		WHERE id = $1 AND status = 'pending'`, id, status, by, note)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *adminElevationRepo) Revoke(ctx context.Context, id, by uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE admin_elevations SET status = 'revoked', revoked_by = $2, revoked_at = NOW()
+		WHERE id = $1 AND status = 'approved' AND expires_at > NOW()`, id, by)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminInvitation is one row of admin_invitations: a one-time link for an
+// imported admin to choose their password.
+type AdminInvitation struct {
+	ID         uuid.UUID     `json:"id"`
+	AdminID    uuid.UUID     `json:"admin_id"`
+	BatchID    uuid.NullUUID `json:"batch_id"`
+	InvitedBy  uuid.NullUUID `json:"invited_by"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	AcceptedAt *time.Time    `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+
+	// Joined from admin_users.
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Role      string `json:"role"`
+}
+
+// Status is pending, accepted, expired or revoked.
+func (inv *AdminInvitation) Status(now time.Time) string {
+	switch {
+	case inv.AcceptedAt != nil:
+		return "accepted"
+	case inv.RevokedAt != nil:
+		return "revoked"
+	case now.After(inv.ExpiresAt):
+		return "expired"
+	}
+	return "pending"
+}
+
+// AdminInvitationRepository stores admin invitation links by token hash.
+type AdminInvitationRepository interface {
+	// Create stores an invitation, revoking the admin's earlier unaccepted
+	// ones so only the newest link works.
+	Create(ctx context.Context, inv *AdminInvitation, tokenHash string) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*AdminInvitation, error)
+	// List returns invitations newest first, optionally for one batch.
+	List(ctx context.Context, batchID uuid.UUID, limit int) ([]AdminInvitation, error)
+	// MarkAccepted accepts a pending, unexpired invitation. It reports
+	// false when the invitation was no longer pending, so two submits of
+	// the same link can't both set a password.
+	MarkAccepted(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type adminInvitationRepo struct {
+	db *DB
+}
+
+// NewAdminInvitationRepo wires the DB.
+func NewAdminInvitationRepo(db *sql.DB) AdminInvitationRepository {
+	return &adminInvitationRepo{db: WrapDB(db)}
+}
+
+const adminInvitationColumns = `
+	ai.id, ai.admin_id, ai.batch_id, ai.invited_by, ai.expires_at, ai.accepted_at, ai.revoked_at, ai.created_at,
+	au.email, au.first_name, au.last_name, au.system_role::text
+	FROM admin_invitations ai
+	JOIN admin_users au ON au.id = ai.admin_id`
+
+func scanAdminInvitation(row interface{ Scan(...any) error }) (*AdminInvitation, error) {
+	var inv AdminInvitation
+	err := row.Scan(&inv.ID, &inv.AdminID, &inv.BatchID, &inv.InvitedBy, &inv.ExpiresAt, &inv.AcceptedAt, &inv.RevokedAt, &inv.CreatedAt,
+		&inv.Email, &inv.FirstName, &inv.LastName, &inv.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *adminInvitationRepo) Create(ctx context.Context, inv *AdminInvitation, tokenHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE admin_invitations SET revoked_at = NOW()
+		WHERE admin_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`, inv.AdminID); err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO admin_invitations (admin_id, token_hash, batch_id, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		inv.AdminID, tokenHash, inv.BatchID, inv.InvitedBy, inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *adminInvitationRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*AdminInvitation, error) {
+	inv, err := scanAdminInvitation(r.db.QueryRowContext(ctx,
+		`SELECT `+adminInvitationColumns+` WHERE ai.token_hash = $1`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return inv, err
+}
+
+func (r *adminInvitationRepo) List(ctx context.Context, batchID uuid.UUID, limit int) ([]AdminInvitation, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+adminInvitationColumns+`
+		WHERE $1::uuid IS NULL OR ai.batch_id = $1
+		ORDER BY ai.created_at DESC
+		LIMIT $2`, uuid.NullUUID{UUID: batchID, Valid: batchID != uuid.Nil}, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AdminInvitation
+	for rows.Next() {
+		inv, err := scanAdminInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *inv)
+	}
+	return out, rows.Err()
+}
+
+func (r *adminInvitationRepo) MarkAccepted(ctx context.Context, id uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE admin_invitations SET accepted_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminNotification is a notice for admins raised by a background check.
+// It stays open until an admin acknowledges it.
+type AdminNotification struct {
+	ID             uuid.UUID       `json:"id"`
+	Kind           string          `json:"kind"`
+	Severity       string          `json:"severity"`
+	Title          string          `json:"title"`
+	Message        string          `json:"message"`
+	DedupeKey      string          `json:"-"`
+	Details        json.RawMessage `json:"details"`
+	CreatedAt      time.Time       `json:"created_at"`
+	AcknowledgedAt *time.Time      `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *uuid.UUID      `json:"acknowledged_by,omitempty"`
+}
+
+// AdminNotificationRepository stores admin notifications.
+type AdminNotificationRepository interface {
+	// Open records n unless a notification with its DedupeKey exists, and
+	// reports whether it was created. ID and CreatedAt are filled in when
+	// it was.
+	Open(ctx context.Context, n *AdminNotification) (bool, error)
+	// List returns the newest notifications first; open only unless all.
+	List(ctx context.Context, all bool, limit int) ([]AdminNotification, error)
+	// Acknowledge closes an open notification. It returns nil, nil when
+	// there is no such open notification.
+	Acknowledge(ctx context.Context, id, by uuid.UUID) (*AdminNotification, error)
+}
+
+type adminNotificationRepo struct {
+	db *DB
+}
+
+// NewAdminNotificationRepo creates an AdminNotificationRepository on the
+// main pool.
+func NewAdminNotificationRepo(db *sql.DB) AdminNotificationRepository {
+	return &adminNotificationRepo{db: WrapDB(db)}
+}
+
+const adminNotificationCols = `id, kind, severity, title, message, dedupe_key, details,
+        created_at, acknowledged_at, acknowledged_by`
+
+func scanAdminNotification(row interface{ Scan(...any) error }, n *AdminNotification) error {
+	var details []byte
+	if err := row.Scan(&n.ID, &n.Kind, &n.Severity, &n.Title, &n.Message, &n.DedupeKey, &details,
+		&n.CreatedAt, &n.AcknowledgedAt, &n.AcknowledgedBy); err != nil {
+		return err
+	}
+	n.Details = json.RawMessage(details)
+	return nil
+}
+
+func (r *adminNotificationRepo) Open(ctx context.Context, n *AdminNotification) (bool, error) {
+	details := []byte(n.Details)
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO admin_notifications (kind, severity, title, message, dedupe_key, details)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (dedupe_key) DO NOTHING
+        RETURNING id, created_at`,
+		n.Kind, n.Severity, n.Title, n.Message, n.DedupeKey, details).Scan(&n.ID, &n.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *adminNotificationRepo) List(ctx context.Context, all bool, limit int) ([]AdminNotification, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+adminNotificationCols+`
+        FROM admin_notifications
+        WHERE $1 OR acknowledged_at IS NULL
+        ORDER BY created_at DESC
+        LIMIT $2`, all, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AdminNotification
+	for rows.Next() {
+		var n AdminNotification
+		if err := scanAdminNotification(rows, &n); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+func (r *adminNotificationRepo) Acknowledge(ctx context.Context, id, by uuid.UUID) (*AdminNotification, error) {
+	var n AdminNotification
+	err := scanAdminNotification(r.db.QueryRowContext(ctx, `
+        UPDATE admin_notifications SET acknowledged_at = NOW(), acknowledged_by = $2
+        WHERE id = $1 AND acknowledged_at IS NULL
+        RETURNING `+adminNotificationCols, id, by), &n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
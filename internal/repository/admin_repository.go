@@ -20,15 +20,14 @@ import (
 // ADMIN REPOSITORY - PHI ISOLATION CRITICAL
 // ============================================================================
 // This repository MUST NEVER access tables containing Protected Health Information.
-// The following tables are OFF-LIMITS:
-// - children, child_conditions
-// - behavior_entries, diet_entries, sleep_entries, bowel_entries
-// - speech_entries, sensory_entries, social_entries, therapy_entries
-// - seizure_entries, weight_entries, medication_log_entries
-// - medications, medication_interactions
-// - pattern_analysis, correlation_analysis, health_alerts, alert_correlations
-// - chat_threads, chat_messages, chat_participants
-// - daily_summary_cache
+// The tables in PHITables (children, every child_id-keyed table such as the
+// *_logs, medications and alerts, chat and insights) are OFF-LIMITS except
+// for COUNT-style aggregates; PHIViolations spells out the rule.
+//
+// internal/philint checks the SQL in this file against it, failing the build
+// on anything not in its reviewed allowlist. At runtime every statement
+// passes the AdminQueryWatcher, which raises a critical admin notification
+// on a violation or when a query names a PHI canary row.
 // ============================================================================
 
 // AdminUserView is a safe view of user data (no PHI)
@@ -43,6 +42,11 @@ type AdminUserView struct {
 	CreatedAt   time.Time         `json:"created_at"`
 	LastLoginAt models.NullTime   `json:"last_login_at,omitempty"`
 	FamilyCount int               `json:"family_count"` // COUNT only, no details
+	// Email verification. EmailVerifyDueAt is when an unverified app user's
+	// grace period ends; EmailRestricted is true once it has.
+	EmailVerifiedAt  models.NullTime `json:"email_verified_at,omitempty"`
+	EmailVerifyDueAt models.NullTime `json:"email_verify_due_at,omitempty"`
+	EmailRestricted  bool            `json:"email_restricted"`
 }
 
 // AdminFamilyView is a safe view of family data (no PHI)
@@ -52,6 +56,9 @@ type AdminFamilyView struct {
 	CreatedAt   time.Time `json:"created_at"`
 	MemberCount int       `json:"member_count"` // COUNT only
 	ChildCount  int       `json:"child_count"`  // COUNT only, no names/details
+	// Organization the family is linked to (clinic covering it), if any.
+	OrganizationID   *uuid.UUID `json:"organization_id,omitempty"`
+	OrganizationName string     `json:"organization_name,omitempty"`
 }
 
 // SupportTicket represents a support ticket
@@ -75,10 +82,28 @@ type SupportTicket struct {
 	ResolvedBy           models.NullUUID   `json:"resolved_by,omitempty"`
 	DuplicateOfTicketID  models.NullUUID   `json:"duplicate_of_ticket_id,omitempty"`
 	DuplicateOfRoadmapID models.NullUUID   `json:"duplicate_of_roadmap_id,omitempty"`
+	CategoryID           models.NullUUID   `json:"category_id,omitempty"`
+	// MergedIntoTicketID is set once this ticket was merged into another
+	// (migration 00112); reads of it redirect there.
+	MergedIntoTicketID   models.NullUUID   `json:"merged_into_ticket_id,omitempty"`
+	MergedAt             models.NullTime   `json:"merged_at,omitempty"`
 	// Populated when needed
-	UserEmail      string `json:"user_email,omitempty"`
-	AssigneeName   string `json:"assignee_name,omitempty"`
-	DuplicateCount int    `json:"duplicate_count,omitempty"`
+	UserEmail      string   `json:"user_email,omitempty"`
+	AssigneeName   string   `json:"assignee_name,omitempty"`
+	DuplicateCount int      `json:"duplicate_count,omitempty"`
+	MergedCount    int      `json:"merged_count,omitempty"`
+	CategoryName   string   `json:"category_name,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+}
+
+// TicketFilter narrows GetTickets. Zero values mean "no filter" for that
+// field. CategoryID matches the category itself and its direct children so
+// filtering on a parent category returns the whole branch.
+type TicketFilter struct {
+	Status     string
+	Type       string
+	Tag        string
+	CategoryID uuid.UUID
 }
 
 // TicketMessage represents a message in a support ticket
@@ -92,6 +117,9 @@ type TicketMessage struct {
 	// Populated
 	SenderName  string `json:"sender_name,omitempty"`
 	SenderEmail string `json:"sender_email,omitempty"`
+	// Attachments sent with this message (filled in by the attachment
+	// service, with signed URLs).
+	Attachments []TicketAttachment `json:"attachments,omitempty"`
 }
 
 // AuditEntry represents an admin audit log entry
@@ -129,6 +157,22 @@ type SystemMetrics struct {
 	ErrorCount24h        int     `json:"error_count_24h"`
 }
 
+// MetricsHistoryPoint is one day of system_metrics_history: the counts
+// from that day's last refresh, plus entries and new users created on the
+// day itself.
+type MetricsHistoryPoint struct {
+	Date           time.Time `json:"date"`
+	TotalUsers     int       `json:"total_users"`
+	ActiveUsers24h int       `json:"active_users_24h"`
+	ActiveUsers7d  int       `json:"active_users_7d"`
+	TotalFamilies  int       `json:"total_families"`
+	TotalEntries   int       `json:"total_entries"`
+	Entries        int       `json:"entries"`
+	NewUsers       int       `json:"new_users"`
+	UserGrowthPct  float64   `json:"user_growth_percent"`
+	RecordedAt     time.Time `json:"recorded_at"`
+}
+
 // CapacityCounts is the DB-side snapshot for the /admin/capacity page —
 // activity-driven indicators that tell us when to upgrade infra. Pairs
 // with CloudWatch (CPU/memory/RDS connection) data on the same page.
@@ -168,8 +212,9 @@ type AdminRepository interface {
 
 	// Support tickets
 	CreateTicket(ctx context.Context, userID uuid.UUID, subject, description, priority, ticketType string) (*SupportTicket, error)
-	GetTickets(ctx context.Context, status, ticketType string, page, limit int) ([]SupportTicket, int, error)
+	GetTickets(ctx context.Context, filter TicketFilter, page, limit int) ([]SupportTicket, int, error)
 	GetTicketByID(ctx context.Context, id uuid.UUID) (*SupportTicket, error)
+	GetTicketByNumber(ctx context.Context, number int64) (*SupportTicket, error)
 	GetOpenTicketCount(ctx context.Context) (int, error)
 	UpdateTicketStatus(ctx context.Context, id uuid.UUID, status string) error
 	UpdateTicketPriority(ctx context.Context, id uuid.UUID, priority string) error
@@ -179,6 +224,9 @@ type AdminRepository interface {
 	DeleteTickets(ctx context.Context, ids []uuid.UUID) (int64, error)
 	GetTicketMessages(ctx context.Context, ticketID uuid.UUID) ([]TicketMessage, error)
 	AddTicketMessage(ctx context.Context, ticketID, senderID uuid.UUID, message string, isInternal bool) error
+	// CreateTicketMessage is AddTicketMessage but returns the new message ID
+	// (needed to link attachments sent with the message).
+	CreateTicketMessage(ctx context.Context, ticketID, senderID uuid.UUID, message string, isInternal bool) (uuid.UUID, error)
 
 	// Duplicate handling
 	SetTicketDuplicate(ctx context.Context, ticketID uuid.UUID, dupTicketID, dupRoadmapID *uuid.UUID) error
@@ -189,6 +237,9 @@ type AdminRepository interface {
 	// Metrics (aggregates only, NO individual PHI data)
 	GetCachedMetrics(ctx context.Context) (*SystemMetrics, error)
 	RefreshMetrics(ctx context.Context) error
+	// GetMetricsHistory returns the daily metrics recorded between from and
+	// to (inclusive dates), oldest first. Days without a refresh are absent.
+	GetMetricsHistory(ctx context.Context, from, to time.Time) ([]MetricsHistoryPoint, error)
 	UpdateSystemHealthMetrics(ctx context.Context, cpuUtil, dbStorageUtil float64) error
 
 	// Capacity (Phase 4 admin monitoring) — DB-side activity counts that
@@ -205,7 +256,7 @@ type AdminRepository interface {
 	GetAuditLog(ctx context.Context, adminID uuid.UUID, action string, page, limit int) ([]AuditEntry, int, error)
 
 	// Error Log Management
-	GetErrorLogs(ctx context.Context, page, limit int, errorType string, acknowledged *bool, sources []models.ErrorSource, includeNoise bool) ([]models.ErrorLogView, int, error)
+	GetErrorLogs(ctx context.Context, page, limit int, errorType string, acknowledged *bool, sources []models.ErrorSource, includeNoise bool, fields []string) ([]models.ErrorLogView, int, error)
 	GetErrorLogByID(ctx context.Context, id uuid.UUID) (*models.ErrorLogView, error)
 	AcknowledgeErrorLog(ctx context.Context, id, acknowledgedBy uuid.UUID, notes string) error
 	AcknowledgeErrorLogsBulk(ctx context.Context, ids []uuid.UUID, acknowledgedBy uuid.UUID, notes string) error
@@ -215,6 +266,8 @@ type AdminRepository interface {
 	GetUnacknowledgedErrorCount(ctx context.Context) (int, error)
 	GetErrorLogSourceCounts(ctx context.Context) (map[models.ErrorSource]int, error)
 	CleanupExpiredErrorLogs(ctx context.Context) (int, error)
+	ListErrorFingerprints(ctx context.Context, limit int) ([]ErrorFingerprint, error)
+	AcknowledgeErrorLogsByFingerprint(ctx context.Context, fingerprint string, acknowledgedBy uuid.UUID, notes string) (int, error)
 
 	// Promo Code Management
 	ListPromoCodes(ctx context.Context, page, limit int, activeOnly bool, search string) ([]models.PromoCode, int, error)
@@ -223,6 +276,9 @@ type AdminRepository interface {
 	CreatePromoCode(ctx context.Context, promo *models.PromoCode) (*models.PromoCode, error)
 	UpdatePromoCode(ctx context.Context, promo *models.PromoCode) error
 	DeactivatePromoCode(ctx context.Context, id, deactivatedBy uuid.UUID, reason string) error
+	// CountActivePromoSubscribers counts live user and family
+	// subscriptions using the promo code.
+	CountActivePromoSubscribers(ctx context.Context, id uuid.UUID) (int, error)
 	GetPromoCodeUsages(ctx context.Context, promoCodeID uuid.UUID, page, limit int) ([]models.PromoCodeUsage, int, error)
 
 	// Subscription Plan Management
@@ -245,12 +301,13 @@ type AdminRepository interface {
 	UpdateFamilySubscription(ctx context.Context, sub *models.FamilySubscription) error
 	CompFamilySubscription(ctx context.Context, familyID, planID, compedBy uuid.UUID, reason string, until time.Time) (*models.FamilySubscription, error)
 	CancelFamilySubscription(ctx context.Context, familyID, cancelledBy uuid.UUID, immediate bool) error
+	ExtendFamilySubscription(ctx context.Context, familyID uuid.UUID, until time.Time) (bool, error)
 }
 
 // adminRepo implements AdminRepository
 type adminRepo struct {
-	db        *sql.DB // main DB — used for everything except support tables
-	supportDB *sql.DB // support_tickets / ticket_messages / ticket_attachments
+	db        *DB // main DB — used for everything except support tables
+	supportDB *DB // support_tickets / ticket_messages / ticket_attachments
 }
 
 // NewAdminRepo creates a new admin repository.
@@ -260,7 +317,7 @@ func NewAdminRepo(db, supportDB *sql.DB) AdminRepository {
 	if supportDB == nil {
 		supportDB = db
 	}
-	return &adminRepo{db: db, supportDB: supportDB}
+	return &adminRepo{db: wrapAdminDB(db), supportDB: wrapAdminDB(supportDB)}
 }
 
 // lookupUserDenorm fetches a user's email + name from the LOCAL users table
@@ -287,14 +344,18 @@ func (r *adminRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*AdminUserVi
 	query := `
 		SELECT u.id, u.email, u.first_name, u.last_name, u.phone, u.status, u.system_role,
 		       u.created_at, u.last_login_at,
-		       (SELECT COUNT(*) FROM family_memberships WHERE user_id = u.id AND is_active = true) as family_count
+		       (SELECT COUNT(*) FROM family_memberships WHERE user_id = u.id AND is_active = true) as family_count,
+		       u.email_verified_at, a.email_verify_due_at,
+		       COALESCE(u.email_verified_at IS NULL AND a.email_verify_due_at <= NOW(), false)
 		FROM users u
+		LEFT JOIN app_users a ON a.id = u.id AND u.email_verified_at IS NULL
 		WHERE u.id = $1
 	`
 	user := &AdminUserView{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone,
 		&user.Status, &user.SystemRole, &user.CreatedAt, &user.LastLoginAt, &user.FamilyCount,
+		&user.EmailVerifiedAt, &user.EmailVerifyDueAt, &user.EmailRestricted,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -323,9 +384,12 @@ func (r *adminRepo) SearchUsers(ctx context.Context, query string, page, limit i
 	usersSQL := `
 		SELECT u.id, u.email, u.first_name, u.last_name, u.phone, u.status, u.system_role,
 		       u.created_at, u.last_login_at,
-		       (SELECT COUNT(*) FROM family_memberships WHERE user_id = u.id AND is_active = true) as family_count
+		       (SELECT COUNT(*) FROM family_memberships WHERE user_id = u.id AND is_active = true) as family_count,
+		       u.email_verified_at, a.email_verify_due_at,
+		       COALESCE(u.email_verified_at IS NULL AND a.email_verify_due_at <= NOW(), false)
 		FROM users u
-		WHERE email ILIKE $1 OR first_name ILIKE $1 OR last_name ILIKE $1
+		LEFT JOIN app_users a ON a.id = u.id AND u.email_verified_at IS NULL
+		WHERE u.email ILIKE $1 OR u.first_name ILIKE $1 OR u.last_name ILIKE $1
 		ORDER BY u.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -339,7 +403,8 @@ func (r *adminRepo) SearchUsers(ctx context.Context, query string, page, limit i
 	for rows.Next() {
 		var u AdminUserView
 		if err := rows.Scan(&u.ID, &u.Email, &u.FirstName, &u.LastName, &u.Phone,
-			&u.Status, &u.SystemRole, &u.CreatedAt, &u.LastLoginAt, &u.FamilyCount); err != nil {
+			&u.Status, &u.SystemRole, &u.CreatedAt, &u.LastLoginAt, &u.FamilyCount,
+			&u.EmailVerifiedAt, &u.EmailVerifyDueAt, &u.EmailRestricted); err != nil {
 			return nil, 0, err
 		}
 		users = append(users, u)
@@ -398,7 +463,8 @@ func (r *adminRepo) ListAdminUsers(ctx context.Context) ([]AdminUserView, error)
 	for rows.Next() {
 		var u AdminUserView
 		if err := rows.Scan(&u.ID, &u.Email, &u.FirstName, &u.LastName, &u.Phone,
-			&u.Status, &u.SystemRole, &u.CreatedAt, &u.LastLoginAt, &u.FamilyCount); err != nil {
+			&u.Status, &u.SystemRole, &u.CreatedAt, &u.LastLoginAt, &u.FamilyCount,
+			&u.EmailVerifiedAt, &u.EmailVerifyDueAt, &u.EmailRestricted); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -455,8 +521,11 @@ func (r *adminRepo) ListFamilies(ctx context.Context, page, limit int) ([]AdminF
 	query := `
 		SELECT f.id, f.name, f.created_at,
 		       (SELECT COUNT(*) FROM family_memberships WHERE family_id = f.id AND is_active = true) as member_count,
-		       (SELECT COUNT(*) FROM children WHERE family_id = f.id) as child_count
+		       (SELECT COUNT(*) FROM children WHERE family_id = f.id) as child_count,
+		       o.id, COALESCE(o.name, '')
 		FROM families f
+		LEFT JOIN organization_families ofam ON ofam.family_id = f.id
+		LEFT JOIN organizations o ON o.id = ofam.organization_id
 		ORDER BY f.created_at DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -469,7 +538,7 @@ func (r *adminRepo) ListFamilies(ctx context.Context, page, limit int) ([]AdminF
 	var families []AdminFamilyView
 	for rows.Next() {
 		var f AdminFamilyView
-		if err := rows.Scan(&f.ID, &f.Name, &f.CreatedAt, &f.MemberCount, &f.ChildCount); err != nil {
+		if err := rows.Scan(&f.ID, &f.Name, &f.CreatedAt, &f.MemberCount, &f.ChildCount, &f.OrganizationID, &f.OrganizationName); err != nil {
 			return nil, 0, err
 		}
 		families = append(families, f)
@@ -481,12 +550,15 @@ func (r *adminRepo) GetFamilyByID(ctx context.Context, id uuid.UUID) (*AdminFami
 	query := `
 		SELECT f.id, f.name, f.created_at,
 		       (SELECT COUNT(*) FROM family_memberships WHERE family_id = f.id AND is_active = true) as member_count,
-		       (SELECT COUNT(*) FROM children WHERE family_id = f.id) as child_count
+		       (SELECT COUNT(*) FROM children WHERE family_id = f.id) as child_count,
+		       o.id, COALESCE(o.name, '')
 		FROM families f
+		LEFT JOIN organization_families ofam ON ofam.family_id = f.id
+		LEFT JOIN organizations o ON o.id = ofam.organization_id
 		WHERE f.id = $1
 	`
 	f := &AdminFamilyView{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&f.ID, &f.Name, &f.CreatedAt, &f.MemberCount, &f.ChildCount)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&f.ID, &f.Name, &f.CreatedAt, &f.MemberCount, &f.ChildCount, &f.OrganizationID, &f.OrganizationName)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -528,41 +600,42 @@ func (r *adminRepo) CreateTicket(ctx context.Context, userID uuid.UUID, subject,
 	return r.GetTicketByID(ctx, id)
 }
 
-func (r *adminRepo) GetTickets(ctx context.Context, status, ticketType string, page, limit int) ([]SupportTicket, int, error) {
+func (r *adminRepo) GetTickets(ctx context.Context, filter TicketFilter, page, limit int) ([]SupportTicket, int, error) {
 	offset := (page - 1) * limit
 
-	// Build WHERE clause and args (shared by count + select).
+	// Build WHERE clause and args (shared by count + select). Both queries
+	// alias support_tickets as t so the same conditions apply verbatim.
 	var whereParts []string
 	var filterArgs []interface{}
-	if status != "" {
-		filterArgs = append(filterArgs, status)
-		whereParts = append(whereParts, fmt.Sprintf("status = $%d", len(filterArgs)))
+	if filter.Status != "" {
+		filterArgs = append(filterArgs, filter.Status)
+		whereParts = append(whereParts, fmt.Sprintf("t.status = $%d", len(filterArgs)))
+	}
+	if filter.Type != "" {
+		filterArgs = append(filterArgs, filter.Type)
+		whereParts = append(whereParts, fmt.Sprintf("t.type = $%d", len(filterArgs)))
 	}
-	if ticketType != "" {
-		filterArgs = append(filterArgs, ticketType)
-		whereParts = append(whereParts, fmt.Sprintf("type = $%d", len(filterArgs)))
+	if filter.Tag != "" {
+		filterArgs = append(filterArgs, filter.Tag)
+		whereParts = append(whereParts, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM ticket_tags tt WHERE tt.ticket_id = t.id AND tt.tag = $%d)", len(filterArgs)))
+	}
+	if filter.CategoryID != uuid.Nil {
+		filterArgs = append(filterArgs, filter.CategoryID)
+		whereParts = append(whereParts, fmt.Sprintf(
+			"t.category_id IN (SELECT id FROM ticket_categories WHERE id = $%[1]d OR parent_id = $%[1]d)", len(filterArgs)))
 	}
 	whereClause := ""
 	if len(whereParts) > 0 {
 		whereClause = " WHERE " + strings.Join(whereParts, " AND ")
 	}
 
-	countSQL := "SELECT COUNT(*) FROM support_tickets" + whereClause
+	countSQL := "SELECT COUNT(*) FROM support_tickets t" + whereClause
 	var total int
 	if err := r.supportDB.QueryRowContext(ctx, countSQL, filterArgs...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
-	// Get tickets — reuse the same WHERE filters built above, then append paging args.
-	selectWhere := ""
-	if len(whereParts) > 0 {
-		// Re-prefix the WHERE conditions with the table alias used in the SELECT.
-		var aliased []string
-		for _, p := range whereParts {
-			aliased = append(aliased, "t."+p)
-		}
-		selectWhere = " WHERE " + strings.Join(aliased, " AND ")
-	}
 	args := append([]interface{}{}, filterArgs...)
 	args = append(args, limit, offset)
 	limitPlaceholder := fmt.Sprintf("$%d", len(args)-1)
@@ -573,16 +646,7 @@ func (r *adminRepo) GetTickets(ctx context.Context, status, ticketType string, p
 	// prod's users and only resolves users who exist on prod. Either way the
 	// denorm column carries the correct email regardless of which env the
 	// row originated from.
-	query := `
-		SELECT t.id, t.ticket_number, t.user_id, t.subject, t.description, t.status, t.priority, t.type,
-		       t.assigned_to, t.created_at, t.updated_at, t.resolved_at, t.resolved_by,
-		       t.duplicate_of_ticket_id, t.duplicate_of_roadmap_id,
-		       COALESCE(NULLIF(t.user_email, ''), u.email, '') as user_email,
-		       COALESCE(a.first_name || ' ' || a.last_name, '') as assignee_name,
-		       (SELECT COUNT(*) FROM support_tickets d WHERE d.duplicate_of_ticket_id = t.id) AS duplicate_count
-		FROM support_tickets t
-		LEFT JOIN users u ON t.user_id = u.id
-		LEFT JOIN users a ON t.assigned_to = a.id` + selectWhere +
+	query := "SELECT " + ticketSelectCols + ticketFromJoins + whereClause +
 		" ORDER BY t.created_at DESC LIMIT " + limitPlaceholder + " OFFSET " + offsetPlaceholder
 
 	rows, err := r.supportDB.QueryContext(ctx, query, args...)
@@ -591,40 +655,29 @@ func (r *adminRepo) GetTickets(ctx context.Context, status, ticketType string, p
 	}
 	defer rows.Close()
 
-	var tickets []SupportTicket
-	for rows.Next() {
-		var t SupportTicket
-		if err := rows.Scan(&t.ID, &t.Number, &t.UserID, &t.Subject, &t.Description, &t.Status, &t.Priority, &t.Type,
-			&t.AssignedTo, &t.CreatedAt, &t.UpdatedAt, &t.ResolvedAt, &t.ResolvedBy,
-			&t.DuplicateOfTicketID, &t.DuplicateOfRoadmapID,
-			&t.UserEmail, &t.AssigneeName, &t.DuplicateCount); err != nil {
-			return nil, 0, err
-		}
-		tickets = append(tickets, t)
+	tickets, err := scanTickets(rows)
+	if err != nil {
+		return nil, 0, err
 	}
-	return tickets, total, rows.Err()
+	return tickets, total, nil
 }
 
 func (r *adminRepo) GetTicketByID(ctx context.Context, id uuid.UUID) (*SupportTicket, error) {
-	query := `
-		SELECT t.id, t.ticket_number, t.user_id, t.subject, t.description, t.status, t.priority, t.type,
-		       t.assigned_to, t.created_at, t.updated_at, t.resolved_at, t.resolved_by,
-		       t.duplicate_of_ticket_id, t.duplicate_of_roadmap_id,
-		       COALESCE(NULLIF(t.user_email, ''), u.email, '') as user_email,
-		       COALESCE(a.first_name || ' ' || a.last_name, '') as assignee_name,
-		       (SELECT COUNT(*) FROM support_tickets d WHERE d.duplicate_of_ticket_id = t.id) AS duplicate_count
-		FROM support_tickets t
-		LEFT JOIN users u ON t.user_id = u.id
-		LEFT JOIN users a ON t.assigned_to = a.id
-		WHERE t.id = $1
-	`
-	t := &SupportTicket{}
-	err := r.supportDB.QueryRowContext(ctx, query, id).Scan(
-		&t.ID, &t.Number, &t.UserID, &t.Subject, &t.Description, &t.Status, &t.Priority, &t.Type,
-		&t.AssignedTo, &t.CreatedAt, &t.UpdatedAt, &t.ResolvedAt, &t.ResolvedBy,
-		&t.DuplicateOfTicketID, &t.DuplicateOfRoadmapID,
-		&t.UserEmail, &t.AssigneeName, &t.DuplicateCount,
-	)
+	query := "SELECT " + ticketSelectCols + ticketFromJoins + " WHERE t.id = $1"
+	t, err := scanTicketRow(r.supportDB.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTicketByNumber looks a ticket up by its human-shareable number.
+func (r *adminRepo) GetTicketByNumber(ctx context.Context, number int64) (*SupportTicket, error) {
+	query := "SELECT " + ticketSelectCols + ticketFromJoins + " WHERE t.ticket_number = $1"
+	t, err := scanTicketRow(r.supportDB.QueryRowContext(ctx, query, number))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -717,16 +770,21 @@ func (r *adminRepo) GetTicketMessages(ctx context.Context, ticketID uuid.UUID) (
 }
 
 func (r *adminRepo) AddTicketMessage(ctx context.Context, ticketID, senderID uuid.UUID, message string, isInternal bool) error {
+	_, err := r.CreateTicketMessage(ctx, ticketID, senderID, message, isInternal)
+	return err
+}
+
+func (r *adminRepo) CreateTicketMessage(ctx context.Context, ticketID, senderID uuid.UUID, message string, isInternal bool) (uuid.UUID, error) {
 	id := uuid.New()
 	email, firstName, lastName := r.lookupUserDenorm(ctx, senderID)
 	query := `INSERT INTO ticket_messages (id, ticket_id, sender_id, message, is_internal, created_at, sender_email, sender_first_name, sender_last_name) VALUES ($1, $2, $3, $4, $5, NOW(), $6, $7, $8)`
 	_, err := r.supportDB.ExecContext(ctx, query, id, ticketID, senderID, message, isInternal, email, firstName, lastName)
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 	// Update ticket updated_at
 	_, err = r.supportDB.ExecContext(ctx, "UPDATE support_tickets SET updated_at = NOW() WHERE id = $1", ticketID)
-	return err
+	return id, err
 }
 
 // SetTicketDuplicate sets exactly one of duplicate_of_ticket_id or
@@ -765,20 +823,11 @@ func (r *adminRepo) SearchTicketsByText(ctx context.Context, query string, limit
 		limit = 10
 	}
 	pattern := "%" + strings.ToLower(query) + "%"
-	rows, err := r.supportDB.QueryContext(ctx, `
-        SELECT t.id, t.ticket_number, t.user_id, t.subject, t.description, t.status, t.priority, t.type,
-               t.assigned_to, t.created_at, t.updated_at, t.resolved_at, t.resolved_by,
-               t.duplicate_of_ticket_id, t.duplicate_of_roadmap_id,
-               COALESCE(NULLIF(t.user_email, ''), u.email, '') as user_email,
-               COALESCE(a.first_name || ' ' || a.last_name, '') as assignee_name,
-               (SELECT COUNT(*) FROM support_tickets d WHERE d.duplicate_of_ticket_id = t.id) AS duplicate_count
-        FROM support_tickets t
-        LEFT JOIN users u ON t.user_id = u.id
-        LEFT JOIN users a ON t.assigned_to = a.id
+	rows, err := r.supportDB.QueryContext(ctx,
+		"SELECT "+ticketSelectCols+ticketFromJoins+`
         WHERE LOWER(t.subject) LIKE $1
         ORDER BY t.created_at DESC
-        LIMIT $2
-    `, pattern, limit)
+        LIMIT $2`, pattern, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -789,16 +838,7 @@ func (r *adminRepo) SearchTicketsByText(ctx context.Context, query string, limit
 // queryTicketsBy is a small helper to avoid repeating the column list +
 // joins for one-arg WHERE-clause lookups.
 func (r *adminRepo) queryTicketsBy(ctx context.Context, whereClause string, arg interface{}) ([]SupportTicket, error) {
-	q := `
-        SELECT t.id, t.ticket_number, t.user_id, t.subject, t.description, t.status, t.priority, t.type,
-               t.assigned_to, t.created_at, t.updated_at, t.resolved_at, t.resolved_by,
-               t.duplicate_of_ticket_id, t.duplicate_of_roadmap_id,
-               COALESCE(NULLIF(t.user_email, ''), u.email, '') as user_email,
-               COALESCE(a.first_name || ' ' || a.last_name, '') as assignee_name,
-               (SELECT COUNT(*) FROM support_tickets d WHERE d.duplicate_of_ticket_id = t.id) AS duplicate_count
-        FROM support_tickets t
-        LEFT JOIN users u ON t.user_id = u.id
-        LEFT JOIN users a ON t.assigned_to = a.id
+	q := "SELECT " + ticketSelectCols + ticketFromJoins + `
         WHERE ` + whereClause + `
         ORDER BY t.created_at DESC
     `
@@ -810,17 +850,49 @@ func (r *adminRepo) queryTicketsBy(ctx context.Context, whereClause string, arg
 	return scanTickets(rows)
 }
 
-func scanTickets(rows *sql.Rows) ([]SupportTicket, error) {
+// ticketSelectCols / ticketFromJoins are the shared column list + joins for
+// every admin-side ticket read. Keep scanTicketRow in lockstep.
+const ticketSelectCols = `
+        t.id, t.ticket_number, t.user_id, t.subject, t.description, t.status, t.priority, t.type,
+        t.assigned_to, t.created_at, t.updated_at, t.resolved_at, t.resolved_by,
+        t.duplicate_of_ticket_id, t.duplicate_of_roadmap_id, t.category_id,
+        t.merged_into_ticket_id, t.merged_at,
+        COALESCE(NULLIF(t.user_email, ''), u.email, '') as user_email,
+        COALESCE(a.first_name || ' ' || a.last_name, '') as assignee_name,
+        (SELECT COUNT(*) FROM support_tickets d WHERE d.duplicate_of_ticket_id = t.id) AS duplicate_count,
+        (SELECT COUNT(*) FROM support_tickets mg WHERE mg.merged_into_ticket_id = t.id) AS merged_count,
+        COALESCE(c.name, '') AS category_name,
+        COALESCE((SELECT array_agg(tt.tag ORDER BY tt.tag) FROM ticket_tags tt WHERE tt.ticket_id = t.id), '{}') AS tags
+`
+
+const ticketFromJoins = `
+        FROM support_tickets t
+        LEFT JOIN users u ON t.user_id = u.id
+        LEFT JOIN users a ON t.assigned_to = a.id
+        LEFT JOIN ticket_categories c ON t.category_id = c.id
+`
+
+func scanTicketRow(s rowScannerLike) (*SupportTicket, error) {
+	t := &SupportTicket{}
+	if err := s.Scan(&t.ID, &t.Number, &t.UserID, &t.Subject, &t.Description, &t.Status, &t.Priority, &t.Type,
+		&t.AssignedTo, &t.CreatedAt, &t.UpdatedAt, &t.ResolvedAt, &t.ResolvedBy,
+		&t.DuplicateOfTicketID, &t.DuplicateOfRoadmapID, &t.CategoryID,
+		&t.MergedIntoTicketID, &t.MergedAt,
+		&t.UserEmail, &t.AssigneeName, &t.DuplicateCount, &t.MergedCount,
+		&t.CategoryName, pq.Array(&t.Tags)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func scanTickets(rows *Rows) ([]SupportTicket, error) {
 	var out []SupportTicket
 	for rows.Next() {
-		var t SupportTicket
-		if err := rows.Scan(&t.ID, &t.Number, &t.UserID, &t.Subject, &t.Description, &t.Status, &t.Priority, &t.Type,
-			&t.AssignedTo, &t.CreatedAt, &t.UpdatedAt, &t.ResolvedAt, &t.ResolvedBy,
-			&t.DuplicateOfTicketID, &t.DuplicateOfRoadmapID,
-			&t.UserEmail, &t.AssigneeName, &t.DuplicateCount); err != nil {
+		t, err := scanTicketRow(rows)
+		if err != nil {
 			return nil, err
 		}
-		out = append(out, t)
+		out = append(out, *t)
 	}
 	return out, rows.Err()
 }
@@ -932,16 +1004,37 @@ func (r *adminRepo) GetCachedMetrics(ctx context.Context) (*SystemMetrics, error
 		}
 	}
 
-	// Get avg response time from response_time_logs (last 24 hours)
-	if err := r.db.QueryRowContext(ctx,
-		"SELECT COALESCE(AVG(response_time_ms), 0) FROM response_time_logs WHERE created_at > NOW() - INTERVAL '24 hours'",
+	// Avg response time and error count over the last 24 hours come from
+	// the hourly rollups, plus the raw rows of the hour(s) the log
+	// retention job hasn't rolled up yet.
+	if err := r.db.QueryRowContext(ctx, `
+		WITH mark AS (
+			SELECT COALESCE(MAX(hour) + INTERVAL '1 hour', NOW() - INTERVAL '24 hours') AS t
+			FROM response_time_hourly
+		)
+		SELECT COALESCE(SUM(total_ms) / NULLIF(SUM(n), 0), 0) FROM (
+			SELECT SUM(request_count) AS n, SUM(total_ms) AS total_ms
+			FROM response_time_hourly
+			WHERE hour >= date_trunc('hour', NOW() - INTERVAL '24 hours')
+			UNION ALL
+			SELECT COUNT(*), SUM(response_time_ms)
+			FROM response_time_logs, mark
+			WHERE created_at >= GREATEST(mark.t, NOW() - INTERVAL '24 hours')
+		) x`,
 	).Scan(&metrics.AvgResponseTimeMs); err != nil && err != sql.ErrNoRows {
 		log.Printf("[admin-metrics] query avg response time: %v", err)
 	}
 
-	// Get error count from error_logs (last 24 hours)
-	if err := r.db.QueryRowContext(ctx,
-		"SELECT COUNT(*) FROM error_logs WHERE created_at > NOW() - INTERVAL '24 hours'",
+	if err := r.db.QueryRowContext(ctx, `
+		WITH mark AS (
+			SELECT COALESCE(MAX(hour) + INTERVAL '1 hour', NOW() - INTERVAL '24 hours') AS t
+			FROM error_log_hourly
+		)
+		SELECT
+			(SELECT COALESCE(SUM(error_count), 0) FROM error_log_hourly
+			 WHERE hour >= date_trunc('hour', NOW() - INTERVAL '24 hours'))
+			+ (SELECT COUNT(*) FROM error_logs, mark
+			   WHERE created_at >= GREATEST(mark.t, NOW() - INTERVAL '24 hours'))`,
 	).Scan(&metrics.ErrorCount24h); err != nil && err != sql.ErrNoRows {
 		log.Printf("[admin-metrics] query 24h error count: %v", err)
 	}
@@ -1048,10 +1141,7 @@ func (r *adminRepo) RefreshMetrics(ctx context.Context) error {
 
 	// Refresh entry counts (aggregate across all log tables - NO individual data)
 	var totalEntries, entriesThisWeek int
-	entryTables := []string{
-		"behavior_logs", "diet_logs", "sleep_logs", "bowel_logs", "medication_logs",
-	}
-	for _, table := range entryTables {
+	for _, table := range metricsEntryTables {
 		var count int
 		if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
 			log.Printf("[admin-metrics] refresh: query total %s: %v", table, err)
@@ -1086,9 +1176,77 @@ func (r *adminRepo) RefreshMetrics(ctx context.Context) error {
 		log.Printf("[admin-metrics] refresh: update growth_metrics cache: %v", err)
 	}
 
+	// Record today's point in the history the trend charts read.
+	if _, err := r.db.ExecContext(ctx, `
+        INSERT INTO system_metrics_history (metric_date, total_users, active_users_24h, active_users_7d,
+            total_families, total_entries, user_growth_pct, recorded_at)
+        VALUES (($1::timestamptz AT TIME ZONE 'UTC')::date, $2, $3, $4, $5, $6, $7, $1)
+        ON CONFLICT (metric_date) DO UPDATE SET
+            total_users      = EXCLUDED.total_users,
+            active_users_24h = EXCLUDED.active_users_24h,
+            active_users_7d  = EXCLUDED.active_users_7d,
+            total_families   = EXCLUDED.total_families,
+            total_entries    = EXCLUDED.total_entries,
+            user_growth_pct  = EXCLUDED.user_growth_pct,
+            recorded_at      = EXCLUDED.recorded_at`,
+		now, totalUsers, active24h, active7d, totalFamilies, totalEntries, growthPct); err != nil {
+		log.Printf("[admin-metrics] refresh: record history: %v", err)
+	}
+	if _, err := r.db.ExecContext(ctx, metricsHistoryDayCountsSQL, now); err != nil {
+		log.Printf("[admin-metrics] refresh: history day counts: %v", err)
+	}
+	if _, err := r.db.ExecContext(ctx, logUsageDailySQL, now, pq.Array(LogUsageTypes)); err != nil {
+		log.Printf("[admin-metrics] refresh: log usage: %v", err)
+	}
+
 	return nil
 }
 
+// metricsEntryTables are the log tables counted as entries.
+var metricsEntryTables = []string{
+	"behavior_logs", "diet_logs", "sleep_logs", "bowel_logs", "medication_logs",
+}
+
+// metricsHistoryDayCountsSQL recounts entries and new users created on the
+// history rows for today and yesterday (UTC) — yesterday's last refresh
+// may have come before the day was over.
+var metricsHistoryDayCountsSQL = func() string {
+	const day = `created_at >= h.metric_date::timestamp AT TIME ZONE 'UTC'
+                  AND created_at < (h.metric_date + 1)::timestamp AT TIME ZONE 'UTC'`
+	entries := make([]string, len(metricsEntryTables))
+	for i, table := range metricsEntryTables {
+		entries[i] = "(SELECT COUNT(*) FROM " + table + " WHERE " + day + ")"
+	}
+	return `
+        UPDATE system_metrics_history h SET
+            entries   = ` + strings.Join(entries, " + ") + `,
+            new_users = (SELECT COUNT(*) FROM users WHERE ` + day + `)
+        WHERE h.metric_date >= ($1::timestamptz AT TIME ZONE 'UTC')::date - 1`
+}()
+
+func (r *adminRepo) GetMetricsHistory(ctx context.Context, from, to time.Time) ([]MetricsHistoryPoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT metric_date, total_users, active_users_24h, active_users_7d, total_families,
+               total_entries, entries, new_users, user_growth_pct, recorded_at
+        FROM system_metrics_history
+        WHERE metric_date BETWEEN $1::date AND $2::date
+        ORDER BY metric_date`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []MetricsHistoryPoint
+	for rows.Next() {
+		var p MetricsHistoryPoint
+		if err := rows.Scan(&p.Date, &p.TotalUsers, &p.ActiveUsers24h, &p.ActiveUsers7d, &p.TotalFamilies,
+			&p.TotalEntries, &p.Entries, &p.NewUsers, &p.UserGrowthPct, &p.RecordedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
 // UpdateSystemHealthMetrics updates system health metrics from CloudWatch
 func (r *adminRepo) UpdateSystemHealthMetrics(ctx context.Context, cpuUtil, dbStorageUtil float64) error {
 	now := time.Now()
@@ -1176,11 +1334,17 @@ func (r *adminRepo) LogAction(ctx context.Context, adminID uuid.UUID, action, ta
 	if targetID != uuid.Nil {
 		targetIDPtr = &targetID
 	}
+	// Actions with no signed-in admin (adminctl, background jobs) pass
+	// uuid.Nil and no IP; both are stored as NULL.
+	var adminIDPtr *uuid.UUID
+	if adminID != uuid.Nil {
+		adminIDPtr = &adminID
+	}
 	query := `
 		INSERT INTO admin_audit_log (id, admin_id, action, target_type, target_id, details, ip_address, user_agent, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::inet, $8, NOW())
 	`
-	_, err = r.db.ExecContext(ctx, query, id, adminID, action, targetType, targetIDPtr, detailsJSON, ip, userAgent)
+	_, err = r.db.ExecContext(ctx, query, id, adminIDPtr, action, targetType, targetIDPtr, detailsJSON, ip, userAgent)
 	return err
 }
 
@@ -1257,9 +1421,38 @@ func (r *adminRepo) GetOpenTicketCount(ctx context.Context) (int, error) {
 // ERROR LOG MANAGEMENT
 // ============================================================================
 
+// errorLogColumns are the GetErrorLogs columns; fields narrows them (the
+// list view usually leaves out stack_trace).
+var errorLogColumns = Columns[models.ErrorLogView]{
+	{"id", "e.id", func(l *models.ErrorLogView) any { return &l.ID }},
+	{"error_type", "e.error_type", func(l *models.ErrorLogView) any { return &l.ErrorType }},
+	{"status_code", "COALESCE(e.status_code, 0)", func(l *models.ErrorLogView) any { return &l.StatusCode }},
+	{"method", "COALESCE(e.method, '')", func(l *models.ErrorLogView) any { return &l.Method }},
+	{"path", "COALESCE(e.path, '')", func(l *models.ErrorLogView) any { return &l.Path }},
+	{"message", "COALESCE(e.error_message, '')", func(l *models.ErrorLogView) any { return &l.Message }},
+	{"stack_trace", "e.stack_trace", func(l *models.ErrorLogView) any { return &l.StackTrace }},
+	{"user_id", "e.user_id", func(l *models.ErrorLogView) any { return &l.UserID }},
+	{"request_id", "e.request_id", func(l *models.ErrorLogView) any { return &l.RequestID }},
+	{"user_agent", "e.user_agent", func(l *models.ErrorLogView) any { return &l.UserAgent }},
+	{"ip_address", "e.ip_address", func(l *models.ErrorLogView) any { return &l.IPAddress }},
+	{"created_at", "e.created_at", func(l *models.ErrorLogView) any { return &l.CreatedAt }},
+	{"error_source", "COALESCE(e.error_source, 'unknown')", func(l *models.ErrorLogView) any { return &l.ErrorSource }},
+	{"is_noise", "COALESCE(e.is_noise, false)", func(l *models.ErrorLogView) any { return &l.IsNoise }},
+	{"auto_delete_at", "e.auto_delete_at", func(l *models.ErrorLogView) any { return &l.AutoDeleteAt }},
+	{"acknowledged_at", "e.acknowledged_at", func(l *models.ErrorLogView) any { return &l.AcknowledgedAt }},
+	{"acknowledged_by", "e.acknowledged_by", func(l *models.ErrorLogView) any { return &l.AcknowledgedBy }},
+	{"acknowledged_notes", "e.acknowledged_notes", func(l *models.ErrorLogView) any { return &l.AcknowledgedNotes }},
+	{"is_deleted", "COALESCE(e.is_deleted, false)", func(l *models.ErrorLogView) any { return &l.IsDeleted }},
+	{"deleted_at", "e.deleted_at", func(l *models.ErrorLogView) any { return &l.DeletedAt }},
+	{"deleted_by", "e.deleted_by", func(l *models.ErrorLogView) any { return &l.DeletedBy }},
+	{"acknowledged_by_email", "COALESCE(u.email, '')", func(l *models.ErrorLogView) any { return &l.AcknowledgedByEmail }},
+	{"acknowledged_by_name", "COALESCE(u.first_name || ' ' || u.last_name, '')", func(l *models.ErrorLogView) any { return &l.AcknowledgedByName }},
+	{"user_email", "COALESCE(eu.email, '')", func(l *models.ErrorLogView) any { return &l.UserEmail }},
+}
+
 // GetErrorLogs returns filtered error logs with pagination
 // By default (when sources is empty), only returns 'user' and 'infrastructure' errors
-func (r *adminRepo) GetErrorLogs(ctx context.Context, page, limit int, errorType string, acknowledged *bool, sources []models.ErrorSource, includeNoise bool) ([]models.ErrorLogView, int, error) {
+func (r *adminRepo) GetErrorLogs(ctx context.Context, page, limit int, errorType string, acknowledged *bool, sources []models.ErrorSource, includeNoise bool, fields []string) ([]models.ErrorLogView, int, error) {
 	offset := (page - 1) * limit
 
 	// Build WHERE clause
@@ -1307,17 +1500,12 @@ func (r *adminRepo) GetErrorLogs(ctx context.Context, page, limit int, errorType
 		return nil, 0, err
 	}
 
-	// Get logs with new columns
+	cols, err := errorLogColumns.Project(fields)
+	if err != nil {
+		return nil, 0, err
+	}
 	query := `
-		SELECT e.id, e.error_type, COALESCE(e.status_code, 0), COALESCE(e.method, ''),
-		       COALESCE(e.path, ''), COALESCE(e.error_message, ''), e.stack_trace, e.user_id, e.request_id,
-		       e.user_agent, e.ip_address, e.created_at,
-		       COALESCE(e.error_source, 'unknown'), COALESCE(e.is_noise, false), e.auto_delete_at,
-		       e.acknowledged_at, e.acknowledged_by, e.acknowledged_notes,
-		       COALESCE(e.is_deleted, false), e.deleted_at, e.deleted_by,
-		       COALESCE(u.email, '') as acknowledged_by_email,
-		       COALESCE(u.first_name || ' ' || u.last_name, '') as acknowledged_by_name,
-		       COALESCE(eu.email, '') as user_email
+		SELECT ` + cols.SelectList() + `
 		FROM error_logs e
 		LEFT JOIN users u ON e.acknowledged_by = u.id
 		LEFT JOIN users eu ON e.user_id = eu.id
@@ -1335,15 +1523,7 @@ func (r *adminRepo) GetErrorLogs(ctx context.Context, page, limit int, errorType
 	var logs []models.ErrorLogView
 	for rows.Next() {
 		var log models.ErrorLogView
-		if err := rows.Scan(
-			&log.ID, &log.ErrorType, &log.StatusCode, &log.Method, &log.Path,
-			&log.Message, &log.StackTrace, &log.UserID, &log.RequestID,
-			&log.UserAgent, &log.IPAddress, &log.CreatedAt,
-			&log.ErrorSource, &log.IsNoise, &log.AutoDeleteAt,
-			&log.AcknowledgedAt, &log.AcknowledgedBy, &log.AcknowledgedNotes,
-			&log.IsDeleted, &log.DeletedAt, &log.DeletedBy,
-			&log.AcknowledgedByEmail, &log.AcknowledgedByName, &log.UserEmail,
-		); err != nil {
+		if err := rows.Scan(cols.Targets(&log)...); err != nil {
 			return nil, 0, err
 		}
 		logs = append(logs, log)
@@ -1394,6 +1574,86 @@ func (r *adminRepo) CleanupExpiredErrorLogs(ctx context.Context) (int, error) {
 	return int(count), nil
 }
 
+// errorFingerprintSQL groups error_logs rows that are "the same error":
+// type, method, status and message plus the path with UUIDs and numeric
+// segments folded, so /families/<id>/children errors share one print.
+const errorFingerprintSQL = `left(md5(
+	COALESCE(e.error_type, '') || ' ' || COALESCE(e.method, '') || ' ' || COALESCE(e.status_code, 0)::text || ' ' ||
+	regexp_replace(regexp_replace(COALESCE(e.path, ''),
+		'[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}', ':id', 'gi'),
+		'/[0-9]+(/|$)', '/:n\1', 'g') || ' ' ||
+	COALESCE(e.error_message, '')), 12)`
+
+// ErrorFingerprint is one group of unacknowledged errors sharing a
+// fingerprint, for acknowledging a whole class of error at once.
+type ErrorFingerprint struct {
+	Fingerprint string    `json:"fingerprint"`
+	ErrorType   string    `json:"error_type"`
+	StatusCode  int       `json:"status_code"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"` // most recent example
+	Message     string    `json:"message"`
+	Count       int       `json:"count"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// ListErrorFingerprints returns the unacknowledged error groups, most
+// frequent first.
+func (r *adminRepo) ListErrorFingerprints(ctx context.Context, limit int) ([]ErrorFingerprint, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT fp, error_type, status_code, method, path, message, n, last_seen
+		FROM (
+			SELECT `+errorFingerprintSQL+` AS fp,
+			       COALESCE(e.error_type, '') AS error_type, COALESCE(e.status_code, 0) AS status_code,
+			       COALESCE(e.method, '') AS method, COALESCE(e.path, '') AS path,
+			       LEFT(COALESCE(e.error_message, ''), 200) AS message,
+			       COUNT(*) OVER w AS n, MAX(e.created_at) OVER w AS last_seen,
+			       ROW_NUMBER() OVER (PARTITION BY `+errorFingerprintSQL+` ORDER BY e.created_at DESC) AS rn
+			FROM error_logs e
+			WHERE e.is_deleted = FALSE AND e.acknowledged_at IS NULL
+			WINDOW w AS (PARTITION BY `+errorFingerprintSQL+`)
+		) g
+		WHERE rn = 1
+		ORDER BY n DESC, last_seen DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ErrorFingerprint
+	for rows.Next() {
+		var f ErrorFingerprint
+		if err := rows.Scan(&f.Fingerprint, &f.ErrorType, &f.StatusCode, &f.Method, &f.Path, &f.Message, &f.Count, &f.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// AcknowledgeErrorLogsByFingerprint acknowledges every open error with the
+// given fingerprint (see ListErrorFingerprints). A uuid.Nil acknowledger is
+// stored as NULL for scripted acks with no admin identity.
+func (r *adminRepo) AcknowledgeErrorLogsByFingerprint(ctx context.Context, fingerprint string, acknowledgedBy uuid.UUID, notes string) (int, error) {
+	var by *uuid.UUID
+	if acknowledgedBy != uuid.Nil {
+		by = &acknowledgedBy
+	}
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE error_logs e
+		SET acknowledged_at = NOW(), acknowledged_by = $2, acknowledged_notes = $3
+		WHERE e.is_deleted = FALSE AND e.acknowledged_at IS NULL
+		  AND `+errorFingerprintSQL+` = $1`, fingerprint, by, notes)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
 func (r *adminRepo) GetErrorLogByID(ctx context.Context, id uuid.UUID) (*models.ErrorLogView, error) {
 	query := `
 		SELECT e.id, e.error_type, COALESCE(e.status_code, 0), COALESCE(e.method, ''),
@@ -1818,6 +2078,19 @@ func (r *adminRepo) DeactivatePromoCode(ctx context.Context, id, deactivatedBy u
 	return err
 }
 
+func (r *adminRepo) CountActivePromoSubscribers(ctx context.Context, id uuid.UUID) (int, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM user_subscriptions
+			 WHERE promo_code_id = $1 AND status IN ('active', 'trialing', 'past_due'))
+			+ (SELECT COUNT(*) FROM family_subscriptions
+			 WHERE promo_code_id = $1 AND status IN ('active', 'trialing', 'past_due'))
+	`
+	var n int
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&n)
+	return n, err
+}
+
 func (r *adminRepo) GetPromoCodeUsages(ctx context.Context, promoCodeID uuid.UUID, page, limit int) ([]models.PromoCodeUsage, int, error) {
 	offset := (page - 1) * limit
 
@@ -1869,7 +2142,7 @@ func (r *adminRepo) GetPromoCodeUsages(ctx context.Context, promoCodeID uuid.UUI
 func (r *adminRepo) ListSubscriptionPlans(ctx context.Context, activeOnly bool) ([]models.SubscriptionPlan, error) {
 	query := `
 		SELECT id, name, description, price_cents, billing_interval, features,
-		       max_children, max_family_members, is_active,
+		       max_children, max_family_members, is_active, seat_based,
 		       stripe_price_id, stripe_product_id, created_at, updated_at
 		FROM subscription_plans
 	`
@@ -1889,7 +2162,7 @@ func (r *adminRepo) ListSubscriptionPlans(ctx context.Context, activeOnly bool)
 		var p models.SubscriptionPlan
 		if err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.PriceCents, &p.BillingInterval, &p.Features,
-			&p.MaxChildren, &p.MaxFamilyMembers, &p.IsActive,
+			&p.MaxChildren, &p.MaxFamilyMembers, &p.IsActive, &p.SeatBased,
 			&p.StripePriceID, &p.StripeProductID, &p.CreatedAt, &p.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -1902,7 +2175,7 @@ func (r *adminRepo) ListSubscriptionPlans(ctx context.Context, activeOnly bool)
 func (r *adminRepo) GetSubscriptionPlanByID(ctx context.Context, id uuid.UUID) (*models.SubscriptionPlan, error) {
 	query := `
 		SELECT id, name, description, price_cents, billing_interval, features,
-		       max_children, max_family_members, is_active,
+		       max_children, max_family_members, is_active, seat_based,
 		       stripe_price_id, stripe_product_id, created_at, updated_at
 		FROM subscription_plans
 		WHERE id = $1
@@ -1910,7 +2183,7 @@ func (r *adminRepo) GetSubscriptionPlanByID(ctx context.Context, id uuid.UUID) (
 	p := &models.SubscriptionPlan{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&p.ID, &p.Name, &p.Description, &p.PriceCents, &p.BillingInterval, &p.Features,
-		&p.MaxChildren, &p.MaxFamilyMembers, &p.IsActive,
+		&p.MaxChildren, &p.MaxFamilyMembers, &p.IsActive, &p.SeatBased,
 		&p.StripePriceID, &p.StripeProductID, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -1999,6 +2272,39 @@ func (r *adminRepo) GetFinancialOverview(ctx context.Context) (*models.Financial
 		WHERE created_at >= DATE_TRUNC('year', NOW())
 	`).Scan(&overview.TotalDiscountsYTDCents)
 
+	// Disputes. Inquiries (warning_*) move no money and aren't chargebacks,
+	// so they count as open but not toward the dispute rate.
+	r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(amount_cents), 0)
+		FROM payment_disputes
+		WHERE closed_at IS NULL
+	`).Scan(&overview.OpenDisputes, &overview.OpenDisputedCents)
+
+	r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(balance_net_cents) FILTER (WHERE closed_at >= DATE_TRUNC('month', NOW())), 0),
+		       COALESCE(SUM(balance_net_cents), 0)
+		FROM payment_disputes
+		WHERE closed_at >= DATE_TRUNC('year', NOW())
+	`).Scan(&overview.DisputeAdjustmentMTDCents, &overview.DisputeAdjustmentYTDCents)
+	overview.NetRevenueMTDCents = overview.RevenueMTDCents + overview.DisputeAdjustmentMTDCents
+	overview.NetRevenueYTDCents = overview.RevenueYTDCents + overview.DisputeAdjustmentYTDCents
+
+	// Organization seat invoices have no payments row, so they're counted
+	// from invoices.
+	var chargebacks, charges int
+	r.db.QueryRowContext(ctx, `
+		SELECT
+		    (SELECT COUNT(*) FROM payment_disputes
+		     WHERE opened_at > NOW() - INTERVAL '90 days' AND status NOT LIKE 'warning\_%'),
+		    (SELECT COUNT(*) FROM payments
+		     WHERE status = 'succeeded' AND created_at > NOW() - INTERVAL '90 days')
+		  + (SELECT COUNT(*) FROM invoices
+		     WHERE organization_id IS NOT NULL AND paid_at > NOW() - INTERVAL '90 days')
+	`).Scan(&chargebacks, &charges)
+	if charges > 0 {
+		overview.DisputeRate90dPct = float64(chargebacks) * 100 / float64(charges)
+	}
+
 	return overview, nil
 }
 
@@ -2364,6 +2670,32 @@ func (r *adminRepo) CompFamilySubscription(ctx context.Context, familyID, planID
 	return r.GetFamilySubscriptionByFamilyID(ctx, familyID)
 }
 
+// ExtendFamilySubscription pushes the end of a trial or comp out to
+// `until`. A lapsed trial (past_due with no Stripe subscription) goes back
+// to trialing with its termination clock cleared. Stripe-billed
+// subscriptions are left alone — the next webhook would overwrite the
+// period — and report false, as does a family with no subscription row.
+func (r *adminRepo) ExtendFamilySubscription(ctx context.Context, familyID uuid.UUID, until time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE family_subscriptions SET
+            current_period_end = $2,
+            trial_end          = CASE WHEN status IN ('trialing', 'past_due') THEN $2 ELSE trial_end END,
+            comp_until         = CASE WHEN status = 'comped' THEN $2 ELSE comp_until END,
+            status             = CASE WHEN status = 'past_due' THEN 'trialing' ELSE status END,
+            past_due_since     = CASE WHEN status = 'past_due' THEN NULL ELSE past_due_since END,
+            updated_at         = NOW()
+        WHERE family_id = $1
+          AND status IN ('trialing', 'past_due', 'comped')
+          AND stripe_subscription_id IS NULL`,
+		familyID, until,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
 // CancelFamilySubscription marks a subscription cancelled. If immediate is
 // true, the period_end is also moved to NOW() (forces enforcement to kick
 // in immediately on the next request).
@@ -11,11 +11,11 @@ import (
 )
 
 type alertRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewAlertRepo(db *sql.DB) AlertRepository {
-	return &alertRepo{db: db}
+	return &alertRepo{db: WrapDB(db)}
 }
 
 func (r *alertRepo) Create(ctx context.Context, alert *models.Alert) error {
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// AnnotationRepository stores the dated annotations caregivers put on a
+// child's charts.
+type AnnotationRepository interface {
+	// Create inserts the annotation; ID and timestamps are filled in.
+	Create(ctx context.Context, a *models.Annotation) error
+	// GetByID returns the annotation, or nil, nil.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Annotation, error)
+	// ListByChild returns the child's annotations dated in [start, end],
+	// oldest first.
+	ListByChild(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.Annotation, error)
+	Update(ctx context.Context, a *models.Annotation) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type annotationRepo struct {
+	db *DB
+}
+
+// NewAnnotationRepo creates an AnnotationRepository on the main pool.
+func NewAnnotationRepo(db *sql.DB) AnnotationRepository {
+	return &annotationRepo{db: WrapDB(db)}
+}
+
+const annotationCols = `id, child_id, annotation_date, category, title, notes, created_by, created_at, updated_at`
+
+func scanAnnotation(row interface{ Scan(...any) error }, a *models.Annotation) error {
+	return row.Scan(&a.ID, &a.ChildID, &a.AnnotationDate, &a.Category, &a.Title, &a.Notes, &a.CreatedBy,
+		&a.CreatedAt, &a.UpdatedAt)
+}
+
+func (r *annotationRepo) Create(ctx context.Context, a *models.Annotation) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO annotations (child_id, annotation_date, category, title, notes, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at, updated_at
+    `, a.ChildID, a.AnnotationDate, a.Category, a.Title, a.Notes, a.CreatedBy,
+	).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+}
+
+func (r *annotationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Annotation, error) {
+	var a models.Annotation
+	err := scanAnnotation(r.db.QueryRowContext(ctx, `SELECT `+annotationCols+` FROM annotations WHERE id = $1`, id), &a)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *annotationRepo) ListByChild(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.Annotation, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+annotationCols+`
+        FROM annotations
+        WHERE child_id = $1 AND annotation_date BETWEEN $2 AND $3
+        ORDER BY annotation_date, created_at`, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Annotation
+	for rows.Next() {
+		var a models.Annotation
+		if err := scanAnnotation(rows, &a); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *annotationRepo) Update(ctx context.Context, a *models.Annotation) error {
+	return r.db.QueryRowContext(ctx, `
+        UPDATE annotations
+        SET annotation_date = $2, category = $3, title = $4, notes = $5, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, a.ID, a.AnnotationDate, a.Category, a.Title, a.Notes).Scan(&a.UpdatedAt)
+}
+
+func (r *annotationRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM annotations WHERE id = $1`, id)
+	return err
+}
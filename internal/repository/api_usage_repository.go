@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// APIUsage is one client's requests to one route: an hour of them when
+// saved, a window's worth when read back.
+type APIUsage struct {
+	Hour          time.Time
+	Platform      string
+	ClientVersion string
+	Method        string
+	Route         string
+	Requests      int64
+	ClientErrors  int64
+	ServerErrors  int64
+	TotalMs       int64
+	Slow          int64
+}
+
+// APIUsageRepository stores hourly API usage per client and route.
+type APIUsageRepository interface {
+	// Save writes the hours' counts, replacing what was saved for them
+	// before: the counters are running totals, so saving an hour again
+	// as it fills up is safe.
+	Save(ctx context.Context, usage []APIUsage) error
+	// Usage sums the hours in [from, to) per client and route. Hour is
+	// left zero.
+	Usage(ctx context.Context, from, to time.Time) ([]APIUsage, error)
+	// DeleteBefore removes hours before t and returns how many rows.
+	DeleteBefore(ctx context.Context, t time.Time) (int, error)
+}
+
+type apiUsageRepo struct {
+	db *DB
+}
+
+// NewAPIUsageRepo creates an APIUsageRepository on the main pool.
+func NewAPIUsageRepo(db *sql.DB) APIUsageRepository {
+	return &apiUsageRepo{db: WrapDB(db)}
+}
+
+func (r *apiUsageRepo) Save(ctx context.Context, usage []APIUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, u := range usage {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO api_usage_hourly (hour, platform, client_version, method, route,
+                                          requests, client_errors, server_errors, total_ms, slow)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+            ON CONFLICT (hour, platform, client_version, method, route) DO UPDATE SET
+                requests      = EXCLUDED.requests,
+                client_errors = EXCLUDED.client_errors,
+                server_errors = EXCLUDED.server_errors,
+                total_ms      = EXCLUDED.total_ms,
+                slow          = EXCLUDED.slow
+        `, u.Hour, u.Platform, u.ClientVersion, u.Method, u.Route,
+			u.Requests, u.ClientErrors, u.ServerErrors, u.TotalMs, u.Slow); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *apiUsageRepo) Usage(ctx context.Context, from, to time.Time) ([]APIUsage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT platform, client_version, method, route,
+               SUM(requests), SUM(client_errors), SUM(server_errors), SUM(total_ms), SUM(slow)
+        FROM api_usage_hourly
+        WHERE hour >= $1 AND hour < $2
+        GROUP BY 1, 2, 3, 4
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []APIUsage
+	for rows.Next() {
+		var u APIUsage
+		if err := rows.Scan(&u.Platform, &u.ClientVersion, &u.Method, &u.Route,
+			&u.Requests, &u.ClientErrors, &u.ServerErrors, &u.TotalMs, &u.Slow); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *apiUsageRepo) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM api_usage_hourly WHERE hour < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
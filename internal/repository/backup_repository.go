@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// Backup kinds and statuses.
+const (
+	BackupKindSnapshot   = "snapshot"
+	BackupKindConfigDump = "config_dump"
+
+	BackupStatusCreating  = "creating"
+	BackupStatusAvailable = "available"
+	BackupStatusFailed    = "failed"
+	BackupStatusDeleted   = "deleted"
+)
+
+// Restore drill outcomes.
+const (
+	DrillStatusRunning = "running"
+	DrillStatusPassed  = "passed"
+	DrillStatusFailed  = "failed"
+)
+
+// DBBackup is one RDS snapshot or logical config dump.
+type DBBackup struct {
+	ID               uuid.UUID       `json:"id"`
+	Kind             string          `json:"kind"`
+	Identifier       string          `json:"identifier"`
+	Source           string          `json:"source,omitempty"`
+	SnapshotType     string          `json:"snapshot_type,omitempty"`
+	Status           string          `json:"status"`
+	SizeBytes        int64           `json:"size_bytes"`
+	Tables           []string        `json:"tables,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	TriggeredBy      models.NullUUID `json:"triggered_by,omitempty"`
+	TriggeredByEmail string          `json:"triggered_by_email,omitempty"`
+	StartedAt        time.Time       `json:"started_at"`
+	CompletedAt      *time.Time      `json:"completed_at,omitempty"`
+}
+
+// DrillCheck is one sanity query run against a restored snapshot. Detail
+// carries counts or a short reason, never row data.
+type DrillCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// RestoreDrill is one run of cmd/restore-drill.
+type RestoreDrill struct {
+	ID                 uuid.UUID    `json:"id"`
+	SnapshotIdentifier string       `json:"snapshot_identifier"`
+	SnapshotCreatedAt  *time.Time   `json:"snapshot_created_at,omitempty"`
+	ScratchInstance    string       `json:"scratch_instance"`
+	Status             string       `json:"status"`
+	Checks             []DrillCheck `json:"checks"`
+	Error              string       `json:"error,omitempty"`
+	RestoreSeconds     int          `json:"restore_seconds,omitempty"`
+	RunBy              string       `json:"run_by,omitempty"`
+	StartedAt          time.Time    `json:"started_at"`
+	FinishedAt         *time.Time   `json:"finished_at,omitempty"`
+}
+
+// BackupRepository owns db_backups and restore_drills.
+type BackupRepository interface {
+	// Create inserts a backup row; ID and StartedAt are filled in.
+	Create(ctx context.Context, b *DBBackup) error
+	// UpsertSnapshot records a snapshot seen in RDS, inserting it if the
+	// sync hasn't met it before. It never overwrites a manual trigger's
+	// triggered_by.
+	UpsertSnapshot(ctx context.Context, b *DBBackup) error
+	// Finish stores b's terminal status, identifier, size and error.
+	Finish(ctx context.Context, b *DBBackup) error
+	List(ctx context.Context, kind string, limit int) ([]DBBackup, error)
+	// Pending lists snapshots still being created, for the sync job.
+	Pending(ctx context.Context, kind string) ([]DBBackup, error)
+	// Latest returns the newest backup of a kind with the given status, or
+	// nil if there is none.
+	Latest(ctx context.Context, kind, status string) (*DBBackup, error)
+
+	CreateDrill(ctx context.Context, d *RestoreDrill) error
+	FinishDrill(ctx context.Context, d *RestoreDrill) error
+	ListDrills(ctx context.Context, limit int) ([]RestoreDrill, error)
+}
+
+type backupRepo struct {
+	db *DB
+}
+
+// NewBackupRepo creates a BackupRepository on the main pool.
+func NewBackupRepo(db *sql.DB) BackupRepository {
+	return &backupRepo{db: WrapDB(db)}
+}
+
+const dbBackupCols = `
+    b.id, b.kind, b.identifier, b.source, b.snapshot_type, b.status, b.size_bytes, b.tables,
+    b.error, b.triggered_by, COALESCE(au.email, ''), b.started_at, b.completed_at`
+
+const dbBackupFrom = `
+    FROM db_backups b
+    LEFT JOIN admin_users au ON au.id = b.triggered_by`
+
+func scanDBBackup(s rowScannerLike) (*DBBackup, error) {
+	b := &DBBackup{}
+	var completed sql.NullTime
+	err := s.Scan(&b.ID, &b.Kind, &b.Identifier, &b.Source, &b.SnapshotType, &b.Status, &b.SizeBytes,
+		pq.Array(&b.Tables), &b.Error, &b.TriggeredBy, &b.TriggeredByEmail, &b.StartedAt, &completed)
+	if err != nil {
+		return nil, err
+	}
+	if completed.Valid {
+		b.CompletedAt = &completed.Time
+	}
+	return b, nil
+}
+
+func (r *backupRepo) Create(ctx context.Context, b *DBBackup) error {
+	if b.Tables == nil {
+		b.Tables = []string{}
+	}
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO db_backups (kind, identifier, source, snapshot_type, status, size_bytes, tables, error, triggered_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id, started_at
+    `, b.Kind, b.Identifier, b.Source, b.SnapshotType, b.Status, b.SizeBytes, pq.Array(b.Tables), b.Error, b.TriggeredBy,
+	).Scan(&b.ID, &b.StartedAt)
+}
+
+func (r *backupRepo) UpsertSnapshot(ctx context.Context, b *DBBackup) error {
+	var completed *time.Time
+	if b.Status != BackupStatusCreating {
+		now := time.Now()
+		completed = &now
+	}
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO db_backups (kind, identifier, source, snapshot_type, status, size_bytes, error, started_at, completed_at)
+        VALUES ('snapshot', $1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (kind, identifier) DO UPDATE SET
+            snapshot_type = EXCLUDED.snapshot_type,
+            status        = EXCLUDED.status,
+            size_bytes    = EXCLUDED.size_bytes,
+            error         = EXCLUDED.error,
+            completed_at  = COALESCE(db_backups.completed_at, EXCLUDED.completed_at)
+        RETURNING id
+    `, b.Identifier, b.Source, b.SnapshotType, b.Status, b.SizeBytes, b.Error, b.StartedAt, completed,
+	).Scan(&b.ID)
+}
+
+func (r *backupRepo) Finish(ctx context.Context, b *DBBackup) error {
+	return r.db.QueryRowContext(ctx, `
+        UPDATE db_backups
+        SET status = $2, identifier = $3, size_bytes = $4, error = $5,
+            completed_at = COALESCE(completed_at, NOW())
+        WHERE id = $1
+        RETURNING completed_at
+    `, b.ID, b.Status, b.Identifier, b.SizeBytes, b.Error,
+	).Scan(&b.CompletedAt)
+}
+
+func (r *backupRepo) List(ctx context.Context, kind string, limit int) ([]DBBackup, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	q := "SELECT " + dbBackupCols + dbBackupFrom
+	args := []interface{}{}
+	if kind != "" {
+		args = append(args, kind)
+		q += " WHERE b.kind = $1"
+	}
+	args = append(args, limit)
+	q += " ORDER BY b.started_at DESC LIMIT $" + itoa(len(args))
+	return r.query(ctx, q, args...)
+}
+
+func (r *backupRepo) Pending(ctx context.Context, kind string) ([]DBBackup, error) {
+	return r.query(ctx, "SELECT "+dbBackupCols+dbBackupFrom+
+		" WHERE b.kind = $1 AND b.status = 'creating' ORDER BY b.started_at", kind)
+}
+
+func (r *backupRepo) Latest(ctx context.Context, kind, status string) (*DBBackup, error) {
+	b, err := scanDBBackup(r.db.QueryRowContext(ctx, "SELECT "+dbBackupCols+dbBackupFrom+
+		" WHERE b.kind = $1 AND b.status = $2 ORDER BY b.started_at DESC LIMIT 1", kind, status))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (r *backupRepo) query(ctx context.Context, q string, args ...interface{}) ([]DBBackup, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DBBackup
+	for rows.Next() {
+		b, err := scanDBBackup(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *b)
+	}
+	return out, rows.Err()
+}
+
+func (r *backupRepo) CreateDrill(ctx context.Context, d *RestoreDrill) error {
+	if d.Status == "" {
+		d.Status = DrillStatusRunning
+	}
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO restore_drills (snapshot_identifier, snapshot_created_at, scratch_instance, status, run_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, started_at
+    `, d.SnapshotIdentifier, d.SnapshotCreatedAt, d.ScratchInstance, d.Status, d.RunBy,
+	).Scan(&d.ID, &d.StartedAt)
+}
+
+func (r *backupRepo) FinishDrill(ctx context.Context, d *RestoreDrill) error {
+	checks, err := json.Marshal(d.Checks)
+	if err != nil {
+		return err
+	}
+	var restoreSecs sql.NullInt64
+	if d.RestoreSeconds > 0 {
+		restoreSecs = sql.NullInt64{Int64: int64(d.RestoreSeconds), Valid: true}
+	}
+	return r.db.QueryRowContext(ctx, `
+        UPDATE restore_drills
+        SET status = $2, checks = $3, error = $4, restore_seconds = $5, finished_at = NOW()
+        WHERE id = $1
+        RETURNING finished_at
+    `, d.ID, d.Status, checks, d.Error, restoreSecs,
+	).Scan(&d.FinishedAt)
+}
+
+func (r *backupRepo) ListDrills(ctx context.Context, limit int) ([]RestoreDrill, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, snapshot_identifier, snapshot_created_at, scratch_instance, status, checks, error,
+               COALESCE(restore_seconds, 0), run_by, started_at, finished_at
+        FROM restore_drills
+        ORDER BY started_at DESC
+        LIMIT $1
+    `, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []RestoreDrill
+	for rows.Next() {
+		var d RestoreDrill
+		var snapAt, finished sql.NullTime
+		var checks []byte
+		if err := rows.Scan(&d.ID, &d.SnapshotIdentifier, &snapAt, &d.ScratchInstance, &d.Status, &checks,
+			&d.Error, &d.RestoreSeconds, &d.RunBy, &d.StartedAt, &finished); err != nil {
+			return nil, err
+		}
+		if snapAt.Valid {
+			d.SnapshotCreatedAt = &snapAt.Time
+		}
+		if finished.Valid {
+			d.FinishedAt = &finished.Time
+		}
+		if err := json.Unmarshal(checks, &d.Checks); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
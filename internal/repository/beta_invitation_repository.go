@@ -49,12 +49,12 @@ type BetaInvitationRepository interface {
 }
 
 type betaInvitationRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewBetaInvitationRepo wires the DB.
 func NewBetaInvitationRepo(db *sql.DB) BetaInvitationRepository {
-	return &betaInvitationRepo{db: db}
+	return &betaInvitationRepo{db: WrapDB(db)}
 }
 
 const betaSelectColumns = `
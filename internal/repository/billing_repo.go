@@ -19,12 +19,12 @@ type BillingRepository interface {
 }
 
 type billingRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewBillingRepo creates a new billing repository
 func NewBillingRepo(db *sql.DB) BillingRepository {
-	return &billingRepo{db: db}
+	return &billingRepo{db: WrapDB(db)}
 }
 
 // GetFamilySubscription retrieves the subscription for a family
@@ -68,7 +68,7 @@ func (r *billingRepo) GetActivePlans(ctx context.Context) ([]models.Subscription
 	query := `
 		SELECT
 			id, name, description, price_cents, billing_interval,
-			features, max_children, max_family_members, is_active,
+			features, max_children, max_family_members, is_active, seat_based,
 			stripe_price_id, stripe_product_id, created_at, updated_at
 		FROM subscription_plans
 		WHERE is_active = TRUE
@@ -86,7 +86,7 @@ func (r *billingRepo) GetActivePlans(ctx context.Context) ([]models.Subscription
 		var p models.SubscriptionPlan
 		if err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.PriceCents, &p.BillingInterval,
-			&p.Features, &p.MaxChildren, &p.MaxFamilyMembers, &p.IsActive,
+			&p.Features, &p.MaxChildren, &p.MaxFamilyMembers, &p.IsActive, &p.SeatBased,
 			&p.StripePriceID, &p.StripeProductID, &p.CreatedAt, &p.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan plan: %w", err)
@@ -82,11 +82,11 @@ type BountyAwardRepository interface {
 }
 
 type bountyAwardRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewBountyAwardRepo(db *sql.DB) BountyAwardRepository {
-	return &bountyAwardRepo{db: db}
+	return &bountyAwardRepo{db: WrapDB(db)}
 }
 
 func (r *bountyAwardRepo) EligibleBugs(ctx context.Context, windowStart time.Time, awardMonth time.Time) ([]BountyCandidate, error) {
@@ -174,7 +174,7 @@ func (r *bountyAwardRepo) EligibleFeatures(ctx context.Context, windowStart time
 	return scanCandidates(rows)
 }
 
-func scanCandidates(rows *sql.Rows) ([]BountyCandidate, error) {
+func scanCandidates(rows *Rows) ([]BountyCandidate, error) {
 	var out []BountyCandidate
 	for rows.Next() {
 		c := BountyCandidate{}
@@ -244,7 +244,7 @@ func (r *bountyAwardRepo) HistoryAwards(ctx context.Context, limit int) ([]Bount
 	return scanAwards(rows)
 }
 
-func scanAwards(rows *sql.Rows) ([]BountyAward, error) {
+func scanAwards(rows *Rows) ([]BountyAward, error) {
 	var out []BountyAward
 	for rows.Next() {
 		a := BountyAward{}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// Campaign is a marketing campaign. UTMCampaign is the lower-cased key
+// matched against signup_attributions.utm_campaign and, for promo
+// linkage, LOWER(promo_codes.campaign_name).
+type Campaign struct {
+	ID          uuid.UUID       `json:"id"`
+	Name        string          `json:"name"`
+	UTMCampaign string          `json:"utm_campaign"`
+	Channel     string          `json:"channel"`
+	StartsOn    *time.Time      `json:"starts_on,omitempty"`
+	EndsOn      *time.Time      `json:"ends_on,omitempty"`
+	Notes       string          `json:"notes"`
+	IsArchived  bool            `json:"is_archived"`
+	CreatedBy   models.NullUUID `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	// Joined for lists
+	TotalSpendCents int64 `json:"total_spend_cents"`
+}
+
+// CampaignSpend is one recorded spend entry.
+type CampaignSpend struct {
+	ID          uuid.UUID       `json:"id"`
+	CampaignID  uuid.UUID       `json:"campaign_id"`
+	SpentOn     time.Time       `json:"spent_on"`
+	AmountCents int64           `json:"amount_cents"`
+	Note        string          `json:"note"`
+	RecordedBy  models.NullUUID `json:"recorded_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// SignupAttribution is the first-touch UTM data captured at registration.
+type SignupAttribution struct {
+	UserID      uuid.UUID `json:"user_id"`
+	UTMSource   string    `json:"utm_source"`
+	UTMMedium   string    `json:"utm_medium"`
+	UTMCampaign string    `json:"utm_campaign"`
+	UTMContent  string    `json:"utm_content"`
+	UTMTerm     string    `json:"utm_term"`
+	Referrer    string    `json:"referrer"`
+	LandingPath string    `json:"landing_path"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CampaignTally is the raw attribution count for one campaign over a
+// window. ROI figures are derived by the service.
+type CampaignTally struct {
+	CampaignID uuid.UUID `json:"campaign_id"`
+	// Signups attributed by UTM at registration.
+	UTMSignups int `json:"utm_signups"`
+	// Users without UTM attribution who redeemed one of the campaign's
+	// promo codes.
+	PromoSignups int `json:"promo_signups"`
+	// PromoRedemptions counts every redemption of the campaign's codes,
+	// whoever the user is attributed to.
+	PromoRedemptions int `json:"promo_redemptions"`
+	// Conversions are attributed users with at least one successful payment.
+	Conversions  int   `json:"conversions"`
+	RevenueCents int64 `json:"revenue_cents"`
+	SpendCents   int64 `json:"spend_cents"`
+}
+
+// UTMCampaignCount is a utm_campaign value seen at signup.
+type UTMCampaignCount struct {
+	UTMCampaign string `json:"utm_campaign"`
+	Signups     int    `json:"signups"`
+}
+
+// CampaignRepository owns marketing_campaigns, campaign_spend and
+// signup_attributions. Reporting joins payments and promo_code_usages on
+// the main DB.
+type CampaignRepository interface {
+	Create(ctx context.Context, c *Campaign) error
+	Update(ctx context.Context, c *Campaign) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Campaign, error)
+	List(ctx context.Context, includeArchived bool) ([]Campaign, error)
+
+	AddSpend(ctx context.Context, s *CampaignSpend) error
+	ListSpend(ctx context.Context, campaignID uuid.UUID) ([]CampaignSpend, error)
+	DeleteSpend(ctx context.Context, campaignID, spendID uuid.UUID) error
+
+	// RecordSignup stores first-touch attribution; a second call for the
+	// same user is a no-op.
+	RecordSignup(ctx context.Context, a *SignupAttribution) error
+
+	// Tallies covers users acquired in [from, to). Revenue is everything
+	// those users have paid to date, net of refunds; spend is limited to
+	// entries dated inside the window.
+	Tallies(ctx context.Context, from, to time.Time) ([]CampaignTally, error)
+	// UntrackedUTMCampaigns lists utm_campaign values seen at signup in
+	// [from, to) that no campaign claims.
+	UntrackedUTMCampaigns(ctx context.Context, from, to time.Time) ([]UTMCampaignCount, error)
+}
+
+// ErrCampaignUTMTaken is returned when utm_campaign is already claimed.
+var ErrCampaignUTMTaken = apperr.Conflict("utm_campaign already used by another campaign")
+
+type campaignRepo struct {
+	db *DB
+}
+
+// NewCampaignRepo creates a CampaignRepository on the main pool.
+func NewCampaignRepo(db *sql.DB) CampaignRepository {
+	return &campaignRepo{db: WrapDB(db)}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr interface{ SQLState() string }
+	return errors.As(err, &pqErr) && pqErr.SQLState() == "23505"
+}
+
+func (r *campaignRepo) Create(ctx context.Context, c *Campaign) error {
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO marketing_campaigns (name, utm_campaign, channel, starts_on, ends_on, notes, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at
+    `, c.Name, c.UTMCampaign, c.Channel, c.StartsOn, c.EndsOn, c.Notes, c.CreatedBy,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrCampaignUTMTaken
+	}
+	return err
+}
+
+func (r *campaignRepo) Update(ctx context.Context, c *Campaign) error {
+	err := r.db.QueryRowContext(ctx, `
+        UPDATE marketing_campaigns SET
+            name = $2, utm_campaign = $3, channel = $4, starts_on = $5,
+            ends_on = $6, notes = $7, is_archived = $8, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, c.ID, c.Name, c.UTMCampaign, c.Channel, c.StartsOn, c.EndsOn, c.Notes, c.IsArchived,
+	).Scan(&c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrCampaignUTMTaken
+	}
+	return err
+}
+
+const campaignColumns = `
+    c.id, c.name, c.utm_campaign, c.channel, c.starts_on, c.ends_on, c.notes,
+    c.is_archived, c.created_by, c.created_at, c.updated_at,
+    COALESCE((SELECT SUM(amount_cents) FROM campaign_spend s WHERE s.campaign_id = c.id), 0)`
+
+func scanCampaign(row rowScannerLike) (*Campaign, error) {
+	var c Campaign
+	var starts, ends sql.NullTime
+	if err := row.Scan(&c.ID, &c.Name, &c.UTMCampaign, &c.Channel, &starts, &ends, &c.Notes,
+		&c.IsArchived, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt, &c.TotalSpendCents); err != nil {
+		return nil, err
+	}
+	if starts.Valid {
+		c.StartsOn = &starts.Time
+	}
+	if ends.Valid {
+		c.EndsOn = &ends.Time
+	}
+	return &c, nil
+}
+
+func (r *campaignRepo) GetByID(ctx context.Context, id uuid.UUID) (*Campaign, error) {
+	c, err := scanCampaign(r.db.QueryRowContext(ctx,
+		`SELECT `+campaignColumns+` FROM marketing_campaigns c WHERE c.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *campaignRepo) List(ctx context.Context, includeArchived bool) ([]Campaign, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+campaignColumns+`
+        FROM marketing_campaigns c
+        WHERE $1 OR NOT c.is_archived
+        ORDER BY c.starts_on DESC NULLS LAST, c.created_at DESC`, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *campaignRepo) AddSpend(ctx context.Context, s *CampaignSpend) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO campaign_spend (campaign_id, spent_on, amount_cents, note, recorded_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `, s.CampaignID, s.SpentOn, s.AmountCents, s.Note, s.RecordedBy,
+	).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *campaignRepo) ListSpend(ctx context.Context, campaignID uuid.UUID) ([]CampaignSpend, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, campaign_id, spent_on, amount_cents, note, recorded_by, created_at
+        FROM campaign_spend WHERE campaign_id = $1
+        ORDER BY spent_on DESC, created_at DESC`, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CampaignSpend
+	for rows.Next() {
+		var s CampaignSpend
+		if err := rows.Scan(&s.ID, &s.CampaignID, &s.SpentOn, &s.AmountCents, &s.Note, &s.RecordedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *campaignRepo) DeleteSpend(ctx context.Context, campaignID, spendID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM campaign_spend WHERE id = $1 AND campaign_id = $2`, spendID, campaignID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *campaignRepo) RecordSignup(ctx context.Context, a *SignupAttribution) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO signup_attributions (user_id, utm_source, utm_medium, utm_campaign,
+                                         utm_content, utm_term, referrer, landing_path)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (user_id) DO NOTHING
+    `, a.UserID, a.UTMSource, a.UTMMedium, a.UTMCampaign, a.UTMContent, a.UTMTerm, a.Referrer, a.LandingPath)
+	return err
+}
+
+func (r *campaignRepo) Tallies(ctx context.Context, from, to time.Time) ([]CampaignTally, error) {
+	// First touch wins: a user with any UTM campaign at signup is never
+	// credited to another campaign through a promo code. UNION (not ALL)
+	// collapses repeat redemptions of the same campaign's codes.
+	rows, err := r.db.QueryContext(ctx, `
+        WITH attributed AS (
+            SELECT mc.id AS campaign_id, sa.user_id, 'utm' AS via
+            FROM marketing_campaigns mc
+            JOIN signup_attributions sa ON sa.utm_campaign = mc.utm_campaign
+            WHERE sa.created_at >= $1 AND sa.created_at < $2
+            UNION
+            SELECT mc.id, pu.user_id, 'promo'
+            FROM marketing_campaigns mc
+            JOIN promo_codes pc ON LOWER(pc.campaign_name) = mc.utm_campaign
+            JOIN promo_code_usages pu ON pu.promo_code_id = pc.id
+            WHERE pu.used_at >= $1 AND pu.used_at < $2
+              AND NOT EXISTS (SELECT 1 FROM signup_attributions sa
+                              WHERE sa.user_id = pu.user_id AND sa.utm_campaign <> '')
+        ),
+        per_user AS (
+            SELECT a.campaign_id, a.user_id, a.via,
+                   COUNT(p.id) > 0 AS paid,
+                   COALESCE(SUM(p.amount_cents - COALESCE(p.refund_amount_cents, 0)), 0) AS net_cents
+            FROM attributed a
+            LEFT JOIN payments p ON p.user_id = a.user_id
+                                AND p.status IN ('succeeded', 'partially_refunded')
+            GROUP BY a.campaign_id, a.user_id, a.via
+        ),
+        redemptions AS (
+            SELECT mc.id AS campaign_id, COUNT(*) AS n
+            FROM marketing_campaigns mc
+            JOIN promo_codes pc ON LOWER(pc.campaign_name) = mc.utm_campaign
+            JOIN promo_code_usages pu ON pu.promo_code_id = pc.id
+            WHERE pu.used_at >= $1 AND pu.used_at < $2
+            GROUP BY mc.id
+        ),
+        spend AS (
+            SELECT campaign_id, SUM(amount_cents) AS cents
+            FROM campaign_spend
+            WHERE spent_on >= $1::date AND spent_on < $2::date
+            GROUP BY campaign_id
+        )
+        SELECT mc.id,
+               COUNT(pu.user_id) FILTER (WHERE pu.via = 'utm'),
+               COUNT(pu.user_id) FILTER (WHERE pu.via = 'promo'),
+               COALESCE(MAX(rd.n), 0),
+               COUNT(pu.user_id) FILTER (WHERE pu.paid),
+               COALESCE(SUM(pu.net_cents), 0),
+               COALESCE(MAX(sp.cents), 0)
+        FROM marketing_campaigns mc
+        LEFT JOIN per_user pu ON pu.campaign_id = mc.id
+        LEFT JOIN redemptions rd ON rd.campaign_id = mc.id
+        LEFT JOIN spend sp ON sp.campaign_id = mc.id
+        GROUP BY mc.id
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CampaignTally
+	for rows.Next() {
+		var t CampaignTally
+		if err := rows.Scan(&t.CampaignID, &t.UTMSignups, &t.PromoSignups, &t.PromoRedemptions,
+			&t.Conversions, &t.RevenueCents, &t.SpendCents); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *campaignRepo) UntrackedUTMCampaigns(ctx context.Context, from, to time.Time) ([]UTMCampaignCount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT sa.utm_campaign, COUNT(*)
+        FROM signup_attributions sa
+        WHERE sa.utm_campaign <> '' AND sa.created_at >= $1 AND sa.created_at < $2
+          AND NOT EXISTS (SELECT 1 FROM marketing_campaigns mc WHERE mc.utm_campaign = sa.utm_campaign)
+        GROUP BY sa.utm_campaign
+        ORDER BY COUNT(*) DESC
+        LIMIT 50`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []UTMCampaignCount
+	for rows.Next() {
+		var c UTMCampaignCount
+		if err := rows.Scan(&c.UTMCampaign, &c.Signups); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
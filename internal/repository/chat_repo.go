@@ -11,11 +11,11 @@ import (
 )
 
 type chatRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewChatRepo(db *sql.DB) ChatRepository {
-	return &chatRepo{db: db}
+	return &chatRepo{db: WrapDB(db)}
 }
 
 // CreateThread creates a new chat thread
@@ -12,11 +12,11 @@ import (
 )
 
 type childRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewChildRepo(db *sql.DB) ChildRepository {
-	return &childRepo{db: db}
+	return &childRepo{db: WrapDB(db)}
 }
 
 func (r *childRepo) Create(ctx context.Context, child *models.Child) error {
@@ -87,6 +87,21 @@ func (r *childRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Child, e
 	return child, nil
 }
 
+// FamilyChangeStamp counts the family's active children and takes the
+// newest updated_at over all of them; a soft delete bumps updated_at on
+// the row it hides.
+func (r *childRepo) FamilyChangeStamp(ctx context.Context, familyID uuid.UUID) (models.ChangeStamp, error) {
+	var st models.ChangeStamp
+	var last sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE is_active), MAX(updated_at)
+		FROM children
+		WHERE family_id = $1
+	`, familyID).Scan(&st.Count, &last)
+	st.LastModified = last.Time
+	return st, err
+}
+
 func (r *childRepo) GetByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Child, error) {
 	query := `
 		SELECT id, family_id, first_name, last_name, date_of_birth, gender, photo_url, notes, settings, is_active, created_at, updated_at
@@ -414,5 +429,10 @@ func (r *childRepo) GetDashboard(ctx context.Context, childID uuid.UUID, date ti
 		}
 	}
 
+	// The summaries above are best-effort, but one that was cut off by the
+	// deadline would show as a day with nothing logged.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return dashboard, nil
 }
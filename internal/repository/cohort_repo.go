@@ -11,11 +11,11 @@ import (
 )
 
 type cohortRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewCohortRepo(db *sql.DB) CohortRepository {
-	return &cohortRepo{db: db}
+	return &cohortRepo{db: WrapDB(db)}
 }
 
 // CreateCohort creates a new cohort definition
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ComplianceExportMaxRows caps one export. Auditors narrow the date range
+// for more.
+const ComplianceExportMaxRows = 100000
+
+// ComplianceExportFilter narrows a compliance export. Zero From/To leave
+// that end open; AdminID and Action apply to audit log exports only.
+type ComplianceExportFilter struct {
+	From    time.Time
+	To      time.Time
+	AdminID uuid.UUID
+	Action  string
+}
+
+func (f ComplianceExportFilter) args() (from, to sql.NullTime) {
+	return sql.NullTime{Time: f.From, Valid: !f.From.IsZero()}, sql.NullTime{Time: f.To, Valid: !f.To.IsZero()}
+}
+
+// PHIAccessAlert is one phi_access admin notification: the PHI watchdog
+// saw admin-context code reach PHI.
+type PHIAccessAlert struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Severity       string
+	Title          string
+	Message        string
+	Details        json.RawMessage
+	AcknowledgedAt *time.Time
+	AcknowledgedBy string
+}
+
+// ComplianceExportRepository streams the records auditors export, oldest
+// first, calling fn per row and stopping at the first error it returns.
+type ComplianceExportRepository interface {
+	AuditLog(ctx context.Context, f ComplianceExportFilter, fn func(AuditEntry) error) error
+	// SettingsHistory is the audit trail of settings and policy changes.
+	SettingsHistory(ctx context.Context, f ComplianceExportFilter, fn func(AuditEntry) error) error
+	PHIAccess(ctx context.Context, f ComplianceExportFilter, fn func(PHIAccessAlert) error) error
+}
+
+type complianceExportRepo struct {
+	db *DB
+}
+
+// NewComplianceExportRepo wires the DB.
+func NewComplianceExportRepo(db *sql.DB) ComplianceExportRepository {
+	return &complianceExportRepo{db: WrapDB(db)}
+}
+
+func (r *complianceExportRepo) auditRows(ctx context.Context, where string, args []any, fn func(AuditEntry) error) error {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.id, a.admin_id, a.action, COALESCE(a.target_type, ''), a.target_id, a.details,
+		       COALESCE(a.ip_address::text, ''), COALESCE(a.user_agent, ''), a.created_at,
+		       COALESCE(u.email, '')
+		FROM admin_audit_log a
+		LEFT JOIN admin_users u ON u.id = a.admin_id
+		WHERE ($1::timestamptz IS NULL OR a.created_at >= $1)
+		  AND ($2::timestamptz IS NULL OR a.created_at < $2)
+		  AND `+where+`
+		ORDER BY a.created_at, a.id
+		LIMIT `+itoa(ComplianceExportMaxRows), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e AuditEntry
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.AdminID, &e.Action, &e.TargetType, &e.TargetID, &details,
+			&e.IPAddress, &e.UserAgent, &e.CreatedAt, &e.AdminEmail); err != nil {
+			return err
+		}
+		if details != nil {
+			json.Unmarshal(details, &e.Details)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *complianceExportRepo) AuditLog(ctx context.Context, f ComplianceExportFilter, fn func(AuditEntry) error) error {
+	from, to := f.args()
+	return r.auditRows(ctx, `($3::uuid IS NULL OR a.admin_id = $3) AND ($4 = '' OR a.action = $4)`,
+		[]any{from, to, uuid.NullUUID{UUID: f.AdminID, Valid: f.AdminID != uuid.Nil}, f.Action}, fn)
+}
+
+// SettingsHistory picks the settings and policy changes out of the audit
+// log: they're logged against the "system" or "settings" target type.
+func (r *complianceExportRepo) SettingsHistory(ctx context.Context, f ComplianceExportFilter, fn func(AuditEntry) error) error {
+	from, to := f.args()
+	return r.auditRows(ctx, `a.target_type IN ('system', 'settings')
+		  AND (a.action LIKE 'update\_%' OR a.action = 'toggle_maintenance' OR a.action LIKE 'PUT %')`,
+		[]any{from, to}, fn)
+}
+
+func (r *complianceExportRepo) PHIAccess(ctx context.Context, f ComplianceExportFilter, fn func(PHIAccessAlert) error) error {
+	from, to := f.args()
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT n.id, n.created_at, n.severity, n.title, n.message, n.details, n.acknowledged_at, COALESCE(u.email, '')
+		FROM admin_notifications n
+		LEFT JOIN admin_users u ON u.id = n.acknowledged_by
+		WHERE n.kind = 'phi_access'
+		  AND ($1::timestamptz IS NULL OR n.created_at >= $1)
+		  AND ($2::timestamptz IS NULL OR n.created_at < $2)
+		ORDER BY n.created_at, n.id
+		LIMIT `+itoa(ComplianceExportMaxRows), from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a PHIAccessAlert
+		var details []byte
+		if err := rows.Scan(&a.ID, &a.CreatedAt, &a.Severity, &a.Title, &a.Message, &details, &a.AcknowledgedAt, &a.AcknowledgedBy); err != nil {
+			return err
+		}
+		a.Details = details
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -11,11 +11,11 @@ import (
 )
 
 type correlationRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewCorrelationRepo(db *sql.DB) CorrelationRepository {
-	return &correlationRepo{db: db}
+	return &correlationRepo{db: WrapDB(db)}
 }
 
 // Baselines
@@ -575,5 +575,31 @@ func (r *correlationRepo) GetCorrelationData(ctx context.Context, childID uuid.U
 	}
 	rows.Close()
 
+	// Get routine completion: the share of the active routines' steps
+	// scheduled that day that were ticked off, from the first day anything
+	// was ticked (before that the family wasn't tracking routines)
+	routineQuery := `
+		SELECT d.day, COUNT(c.step_id)::float / COUNT(s.id)
+		FROM (SELECT generate_series($2::date, $3::date, interval '1 day')::date AS day) d
+		JOIN routines r ON r.child_id = $1 AND r.active AND r.created_at::date <= d.day
+			AND (cardinality(r.days_of_week) = 0 OR EXTRACT(DOW FROM d.day)::int = ANY(r.days_of_week))
+		JOIN routine_steps s ON s.routine_id = r.id
+		LEFT JOIN routine_completions c ON c.step_id = s.id AND c.completion_date = d.day
+		WHERE d.day >= (SELECT MIN(completion_date) FROM routine_completions WHERE child_id = $1)
+		GROUP BY d.day
+		ORDER BY d.day ASC
+	`
+	rows, err = r.db.QueryContext(ctx, routineQuery, childID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var day time.Time
+		var rate float64
+		rows.Scan(&day, &rate)
+		data["routine_completion"] = append(data["routine_completion"], models.DataPoint{Date: day, Value: rate})
+	}
+	rows.Close()
+
 	return data, nil
 }
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// AWSDailyCost is one day's unblended cost for one AWS service, as reported
+// by Cost Explorer. Estimated is true until AWS finalizes the day.
+type AWSDailyCost struct {
+	Day       time.Time `json:"day"`
+	Service   string    `json:"service"`
+	AmountUSD float64   `json:"amount_usd"`
+	Estimated bool      `json:"estimated"`
+}
+
+// DailyCostTotal is the all-services total for one day.
+type DailyCostTotal struct {
+	Day       time.Time `json:"day"`
+	AmountUSD float64   `json:"amount_usd"`
+	Estimated bool      `json:"estimated"`
+}
+
+// ServiceCostTotal is one service's total over a period.
+type ServiceCostTotal struct {
+	Service   string  `json:"service"`
+	AmountUSD float64 `json:"amount_usd"`
+}
+
+// MonthlyCost is one calendar month's total with the 30-day active user
+// count recorded closest to the month's end.
+type MonthlyCost struct {
+	Month       time.Time `json:"month"`
+	AmountUSD   float64   `json:"amount_usd"`
+	ActiveUsers int       `json:"active_users"`
+}
+
+// CostRepository owns aws_daily_costs and aws_cost_active_users.
+type CostRepository interface {
+	// UpsertDaily stores costs, overwriting earlier figures for the same
+	// day and service (AWS revises recent days as billing settles).
+	UpsertDaily(ctx context.Context, costs []AWSDailyCost) error
+	// LatestDay is the newest day with stored costs, or nil when empty.
+	LatestDay(ctx context.Context) (*time.Time, error)
+	// DailyTotals sums all services per day for [from, to).
+	DailyTotals(ctx context.Context, from, to time.Time) ([]DailyCostTotal, error)
+	// ServiceTotals sums each service over [from, to), largest first.
+	ServiceTotals(ctx context.Context, from, to time.Time) ([]ServiceCostTotal, error)
+	// RecordActiveUsers counts app users seen in the 30 days up to day and
+	// stores the count against day.
+	RecordActiveUsers(ctx context.Context, day time.Time) (int, error)
+	// MonthlyTotals returns the last n calendar months, oldest first.
+	MonthlyTotals(ctx context.Context, months int) ([]MonthlyCost, error)
+}
+
+type costRepo struct {
+	db *DB
+}
+
+// NewCostRepo creates a CostRepository on the main pool.
+func NewCostRepo(db *sql.DB) CostRepository {
+	return &costRepo{db: WrapDB(db)}
+}
+
+func (r *costRepo) UpsertDaily(ctx context.Context, costs []AWSDailyCost) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO aws_daily_costs (day, service, amount_usd, estimated)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (day, service) DO UPDATE SET
+            amount_usd = EXCLUDED.amount_usd,
+            estimated  = EXCLUDED.estimated,
+            fetched_at = NOW()
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, c := range costs {
+		if _, err := stmt.ExecContext(ctx, c.Day, c.Service, c.AmountUSD, c.Estimated); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *costRepo) LatestDay(ctx context.Context) (*time.Time, error) {
+	var day sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(day) FROM aws_daily_costs`).Scan(&day); err != nil {
+		return nil, err
+	}
+	if !day.Valid {
+		return nil, nil
+	}
+	return &day.Time, nil
+}
+
+func (r *costRepo) DailyTotals(ctx context.Context, from, to time.Time) ([]DailyCostTotal, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT day, SUM(amount_usd)::float8, BOOL_OR(estimated)
+        FROM aws_daily_costs
+        WHERE day >= $1 AND day < $2
+        GROUP BY day
+        ORDER BY day
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DailyCostTotal
+	for rows.Next() {
+		var d DailyCostTotal
+		if err := rows.Scan(&d.Day, &d.AmountUSD, &d.Estimated); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *costRepo) ServiceTotals(ctx context.Context, from, to time.Time) ([]ServiceCostTotal, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT service, SUM(amount_usd)::float8 AS total
+        FROM aws_daily_costs
+        WHERE day >= $1 AND day < $2
+        GROUP BY service
+        HAVING SUM(amount_usd) > 0
+        ORDER BY total DESC
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ServiceCostTotal
+	for rows.Next() {
+		var s ServiceCostTotal
+		if err := rows.Scan(&s.Service, &s.AmountUSD); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *costRepo) RecordActiveUsers(ctx context.Context, day time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO aws_cost_active_users (day, active_users_30d)
+        SELECT $1::date, COUNT(DISTINCT user_id)
+        FROM sessions
+        WHERE kind = 'user'
+          AND last_seen_at >  $1::date - INTERVAL '30 days'
+          AND last_seen_at <= $1::date + INTERVAL '1 day'
+        ON CONFLICT (day) DO UPDATE SET
+            active_users_30d = EXCLUDED.active_users_30d,
+            recorded_at      = NOW()
+        RETURNING active_users_30d
+    `, day).Scan(&n)
+	return n, err
+}
+
+func (r *costRepo) MonthlyTotals(ctx context.Context, months int) ([]MonthlyCost, error) {
+	if months <= 0 || months > 36 {
+		months = 6
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        WITH m AS (
+            SELECT generate_series(
+                date_trunc('month', CURRENT_DATE) - ($1::int - 1) * INTERVAL '1 month',
+                date_trunc('month', CURRENT_DATE),
+                INTERVAL '1 month')::date AS month
+        )
+        SELECT m.month,
+               COALESCE((SELECT SUM(c.amount_usd) FROM aws_daily_costs c
+                         WHERE c.day >= m.month AND c.day < m.month + INTERVAL '1 month'), 0)::float8,
+               COALESCE((SELECT a.active_users_30d FROM aws_cost_active_users a
+                         WHERE a.day >= m.month AND a.day < m.month + INTERVAL '1 month'
+                         ORDER BY a.day DESC LIMIT 1), 0)
+        FROM m
+        ORDER BY m.month
+    `, months)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []MonthlyCost
+	for rows.Next() {
+		var m MonthlyCost
+		if err := rows.Scan(&m.Month, &m.AmountUSD, &m.ActiveUsers); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// CSPReportRepository records Content-Security-Policy violation reports.
+// They go into error_logs (error_type csp_violation) so they show up on the
+// admin Errors page next to everything else the browser got wrong.
+type CSPReportRepository interface {
+	RecordViolation(ctx context.Context, path, message, userAgent, ip string) error
+}
+
+type cspReportRepo struct {
+	db *DB
+}
+
+// NewCSPReportRepo creates a CSPReportRepository.
+func NewCSPReportRepo(db *sql.DB) CSPReportRepository {
+	return &cspReportRepo{db: WrapDB(db)}
+}
+
+func (r *cspReportRepo) RecordViolation(ctx context.Context, path, message, userAgent, ip string) error {
+	if len(path) > 500 {
+		path = path[:500]
+	}
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO error_logs (error_type, status_code, path, method, error_message, user_agent, ip_address)
+        VALUES ('csp_violation', 0, $1, 'CSP', $2, $3, NULLIF($4, '')::inet)
+    `, path, message, userAgent, ip)
+	return err
+}
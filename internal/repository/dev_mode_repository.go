@@ -19,12 +19,12 @@ type DevModeRepository interface {
 
 // DevModeRepo implements DevModeRepository
 type DevModeRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewDevModeRepo creates a new DevModeRepo
 func NewDevModeRepo(db *sql.DB) *DevModeRepo {
-	return &DevModeRepo{db: db}
+	return &DevModeRepo{db: WrapDB(db)}
 }
 
 // Get returns the current dev mode settings
@@ -10,61 +10,123 @@ import (
 	"carecompanion/internal/models"
 )
 
+// DeviceVersionCount is how many active devices run one app version on one
+// platform. AppVersion is empty for devices that registered without one.
+type DeviceVersionCount struct {
+	Platform   string `json:"platform"`
+	AppVersion string `json:"app_version"`
+	Devices    int    `json:"devices"`
+}
+
+// DevicePlatformCount is the active devices and distinct users on one
+// platform.
+type DevicePlatformCount struct {
+	Platform string `json:"platform"`
+	Devices  int    `json:"devices"`
+	Users    int    `json:"users"`
+}
+
+// DeviceStats summarizes the device registry for the admin portal.
+type DeviceStats struct {
+	Active              int                   `json:"active"`
+	SeenLast7Days       int                   `json:"seen_last_7_days"`
+	RegisteredLast7Days int                   `json:"registered_last_7_days"`
+	InvalidLast30Days   int                   `json:"invalid_last_30_days"`
+	ByPlatform          []DevicePlatformCount `json:"by_platform"`
+	ByVersion           []DeviceVersionCount  `json:"by_version"`
+}
+
 // DeviceTokenRepository handles device token operations
 type DeviceTokenRepository interface {
+	// Upsert registers token for its user, reactivating it and refreshing
+	// its versions and last-seen time. The same token registered to any
+	// other user is deactivated as reassigned.
 	Upsert(ctx context.Context, token *models.DeviceToken) error
 	Deactivate(ctx context.Context, userID uuid.UUID, token string) error
 	DeactivateAll(ctx context.Context, userID uuid.UUID) error
 	GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.DeviceToken, error)
-	DeactivateByToken(ctx context.Context, token string) error
+	// DeactivateByToken deactivates token for every user, recording why.
+	DeactivateByToken(ctx context.Context, token, reason string) error
+	// RecordPush records a delivery attempt to device id: success resets
+	// its failure count, failure increments it.
+	RecordPush(ctx context.Context, id uuid.UUID, ok bool) error
+	// Prune deactivates active devices last seen before staleBefore and
+	// deletes devices inactive since before deleteBefore.
+	Prune(ctx context.Context, staleBefore, deleteBefore time.Time) (deactivated, deleted int64, err error)
+	Stats(ctx context.Context, now time.Time) (*DeviceStats, error)
 }
 
 type deviceTokenRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewDeviceTokenRepo creates a new device token repository
 func NewDeviceTokenRepo(db *sql.DB) DeviceTokenRepository {
-	return &deviceTokenRepo{db: db}
+	return &deviceTokenRepo{db: WrapDB(db)}
 }
 
 func (r *deviceTokenRepo) Upsert(ctx context.Context, token *models.DeviceToken) error {
 	query := `
-		INSERT INTO device_tokens (id, user_id, token, platform, device_name, active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, true, $6, $6)
+		INSERT INTO device_tokens (id, user_id, token, platform, provider, device_name, app_version, os_version,
+		                           active, last_seen_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), true, $9, $9, $9)
 		ON CONFLICT (user_id, token) DO UPDATE SET
 			platform = EXCLUDED.platform,
+			provider = EXCLUDED.provider,
 			device_name = EXCLUDED.device_name,
+			app_version = COALESCE(EXCLUDED.app_version, device_tokens.app_version),
+			os_version = COALESCE(EXCLUDED.os_version, device_tokens.os_version),
 			active = true,
+			deactivated_reason = NULL,
+			last_seen_at = EXCLUDED.last_seen_at,
 			updated_at = EXCLUDED.updated_at`
 
 	now := time.Now()
 	if token.ID == uuid.Nil {
 		token.ID = uuid.New()
 	}
+	if token.Provider == "" {
+		token.Provider = models.PushProviderFCM
+	}
 	token.Active = true
+	token.LastSeenAt = now
 	token.CreatedAt = now
 	token.UpdatedAt = now
 
-	_, err := r.db.ExecContext(ctx, query,
-		token.ID, token.UserID, token.Token, token.Platform, token.DeviceName, now)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, query,
+		token.ID, token.UserID, token.Token, token.Platform, token.Provider, token.DeviceName,
+		token.AppVersion, token.OSVersion, now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE device_tokens SET active = false, deactivated_reason = $3, updated_at = NOW()
+		WHERE token = $1 AND user_id <> $2 AND active`,
+		token.Token, token.UserID, models.DeviceDeactivatedReassigned); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *deviceTokenRepo) Deactivate(ctx context.Context, userID uuid.UUID, token string) error {
-	query := `UPDATE device_tokens SET active = false, updated_at = NOW() WHERE user_id = $1 AND token = $2`
-	_, err := r.db.ExecContext(ctx, query, userID, token)
+	query := `UPDATE device_tokens SET active = false, deactivated_reason = $3, updated_at = NOW() WHERE user_id = $1 AND token = $2`
+	_, err := r.db.ExecContext(ctx, query, userID, token, models.DeviceDeactivatedUnregistered)
 	return err
 }
 
 func (r *deviceTokenRepo) DeactivateAll(ctx context.Context, userID uuid.UUID) error {
-	query := `UPDATE device_tokens SET active = false, updated_at = NOW() WHERE user_id = $1`
-	_, err := r.db.ExecContext(ctx, query, userID)
+	query := `UPDATE device_tokens SET active = false, deactivated_reason = $2, updated_at = NOW() WHERE user_id = $1 AND active`
+	_, err := r.db.ExecContext(ctx, query, userID, models.DeviceDeactivatedUnregistered)
 	return err
 }
 
 func (r *deviceTokenRepo) GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.DeviceToken, error) {
-	query := `SELECT id, user_id, token, platform, COALESCE(device_name, ''), active, created_at, updated_at
+	query := `SELECT id, user_id, token, platform, provider, COALESCE(device_name, ''),
+		       COALESCE(app_version, ''), COALESCE(os_version, ''), active, last_seen_at, created_at, updated_at
 		FROM device_tokens WHERE user_id = $1 AND active = true`
 
 	rows, err := r.db.QueryContext(ctx, query, userID)
@@ -76,7 +138,8 @@ func (r *deviceTokenRepo) GetActiveByUserID(ctx context.Context, userID uuid.UUI
 	var tokens []models.DeviceToken
 	for rows.Next() {
 		var t models.DeviceToken
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Token, &t.Platform, &t.DeviceName, &t.Active, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Token, &t.Platform, &t.Provider, &t.DeviceName,
+			&t.AppVersion, &t.OSVersion, &t.Active, &t.LastSeenAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
 			return nil, err
 		}
 		tokens = append(tokens, t)
@@ -84,8 +147,87 @@ func (r *deviceTokenRepo) GetActiveByUserID(ctx context.Context, userID uuid.UUI
 	return tokens, rows.Err()
 }
 
-func (r *deviceTokenRepo) DeactivateByToken(ctx context.Context, token string) error {
-	query := `UPDATE device_tokens SET active = false, updated_at = NOW() WHERE token = $1`
-	_, err := r.db.ExecContext(ctx, query, token)
+func (r *deviceTokenRepo) DeactivateByToken(ctx context.Context, token, reason string) error {
+	query := `UPDATE device_tokens SET active = false, deactivated_reason = $2, updated_at = NOW() WHERE token = $1 AND active`
+	_, err := r.db.ExecContext(ctx, query, token, reason)
 	return err
 }
+
+func (r *deviceTokenRepo) RecordPush(ctx context.Context, id uuid.UUID, ok bool) error {
+	query := `UPDATE device_tokens SET last_push_at = NOW(), failure_count = 0 WHERE id = $1`
+	if !ok {
+		query = `UPDATE device_tokens SET failure_count = failure_count + 1 WHERE id = $1`
+	}
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *deviceTokenRepo) Prune(ctx context.Context, staleBefore, deleteBefore time.Time) (int64, int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE device_tokens SET active = false, deactivated_reason = $2, updated_at = NOW()
+		WHERE active AND last_seen_at < $1`, staleBefore, models.DeviceDeactivatedStale)
+	if err != nil {
+		return 0, 0, err
+	}
+	deactivated, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+	res, err = r.db.ExecContext(ctx, `
+		DELETE FROM device_tokens WHERE NOT active AND updated_at < $1`, deleteBefore)
+	if err != nil {
+		return deactivated, 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return deactivated, deleted, err
+}
+
+func (r *deviceTokenRepo) Stats(ctx context.Context, now time.Time) (*DeviceStats, error) {
+	weekAgo, monthAgo := now.AddDate(0, 0, -7), now.AddDate(0, 0, -30)
+	s := &DeviceStats{ByPlatform: []DevicePlatformCount{}, ByVersion: []DeviceVersionCount{}}
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE active),
+		       COUNT(*) FILTER (WHERE active AND last_seen_at >= $1),
+		       COUNT(*) FILTER (WHERE created_at >= $1),
+		       COUNT(*) FILTER (WHERE NOT active AND deactivated_reason = $3 AND updated_at >= $2)
+		FROM device_tokens`, weekAgo, monthAgo, models.DeviceDeactivatedInvalid,
+	).Scan(&s.Active, &s.SeenLast7Days, &s.RegisteredLast7Days, &s.InvalidLast30Days); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT platform, COUNT(*), COUNT(DISTINCT user_id)
+		FROM device_tokens WHERE active
+		GROUP BY platform ORDER BY COUNT(*) DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p DevicePlatformCount
+		if err := rows.Scan(&p.Platform, &p.Devices, &p.Users); err != nil {
+			return nil, err
+		}
+		s.ByPlatform = append(s.ByPlatform, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	vrows, err := r.db.QueryContext(ctx, `
+		SELECT platform, COALESCE(app_version, ''), COUNT(*)
+		FROM device_tokens WHERE active
+		GROUP BY 1, 2 ORDER BY 1, 3 DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer vrows.Close()
+	for vrows.Next() {
+		var v DeviceVersionCount
+		if err := vrows.Scan(&v.Platform, &v.AppVersion, &v.Devices); err != nil {
+			return nil, err
+		}
+		s.ByVersion = append(s.ByVersion, v)
+	}
+	return s, vrows.Err()
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dispute is a Stripe dispute (chargeback or inquiry) on one of our
+// charges. Customer is the payer's email or the organization's name,
+// joined in for display.
+type Dispute struct {
+	ID                    uuid.UUID  `json:"id"`
+	StripeDisputeID       string     `json:"stripe_dispute_id"`
+	StripeChargeID        string     `json:"stripe_charge_id"`
+	StripePaymentIntentID string     `json:"stripe_payment_intent_id,omitempty"`
+	StripeInvoiceID       string     `json:"stripe_invoice_id,omitempty"`
+	PaymentID             *uuid.UUID `json:"payment_id,omitempty"`
+	OrganizationID        *uuid.UUID `json:"organization_id,omitempty"`
+	AmountCents           int64      `json:"amount_cents"`
+	Currency              string     `json:"currency"`
+	Reason                string     `json:"reason"`
+	Status                string     `json:"status"`
+	BalanceNetCents       int64      `json:"balance_net_cents"`
+	EvidenceDueBy         *time.Time `json:"evidence_due_by,omitempty"`
+	EvidenceSubmittedAt   *time.Time `json:"evidence_submitted_at,omitempty"`
+	LastReminderDays      *int       `json:"last_reminder_days,omitempty"`
+	Notes                 string     `json:"notes"`
+	OpenedAt              time.Time  `json:"opened_at"`
+	ClosedAt              *time.Time `json:"closed_at,omitempty"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+	Customer              string     `json:"customer"`
+}
+
+// DisputeFilter narrows the admin dispute list. Status is "open",
+// "closed" or "" for all.
+type DisputeFilter struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+// AdminRecipient is an active admin user who may be emailed about
+// billing events, with the role that decides whether they should be.
+type AdminRecipient struct {
+	Email      string
+	FirstName  string
+	SystemRole string
+}
+
+// DisputeRepository stores disputes mirrored from Stripe.
+type DisputeRepository interface {
+	// Upsert inserts or refreshes a dispute by its Stripe ID and reports the
+	// status it had before ("" when new). The payment is linked through the
+	// Stripe invoice; the organization falls back to our invoice for it.
+	// Evidence submission, closure and links, once set, are kept.
+	Upsert(ctx context.Context, d *Dispute) (prevStatus string, err error)
+	Get(ctx context.Context, id uuid.UUID) (*Dispute, error)
+	List(ctx context.Context, f DisputeFilter) ([]Dispute, int, error)
+	// AwaitingEvidence returns open disputes still needing a response whose
+	// deadline falls before `before`.
+	AwaitingEvidence(ctx context.Context, before time.Time) ([]Dispute, error)
+	MarkReminded(ctx context.Context, id uuid.UUID, days int) error
+	SetEvidenceSubmitted(ctx context.Context, id uuid.UUID, at *time.Time) error
+	SetNotes(ctx context.Context, id uuid.UUID, notes string) error
+	ActiveAdmins(ctx context.Context) ([]AdminRecipient, error)
+}
+
+type disputeRepo struct {
+	db *DB
+}
+
+// NewDisputeRepo creates a DisputeRepository on the main pool.
+func NewDisputeRepo(db *sql.DB) DisputeRepository {
+	return &disputeRepo{db: WrapDB(db)}
+}
+
+const disputeCols = `d.id, d.stripe_dispute_id, d.stripe_charge_id, d.stripe_payment_intent_id,
+        d.stripe_invoice_id, d.payment_id, d.organization_id, d.amount_cents, d.currency, d.reason,
+        d.status, d.balance_net_cents, d.evidence_due_by, d.evidence_submitted_at,
+        d.last_reminder_days, d.notes, d.opened_at, d.closed_at, d.updated_at,
+        COALESCE(u.email, o.name, '')`
+
+const disputeFrom = `
+        FROM payment_disputes d
+        LEFT JOIN payments p ON p.id = d.payment_id
+        LEFT JOIN app_users u ON u.id = p.user_id
+        LEFT JOIN organizations o ON o.id = d.organization_id`
+
+func scanDispute(row interface{ Scan(...any) error }, d *Dispute) error {
+	return row.Scan(&d.ID, &d.StripeDisputeID, &d.StripeChargeID, &d.StripePaymentIntentID,
+		&d.StripeInvoiceID, &d.PaymentID, &d.OrganizationID, &d.AmountCents, &d.Currency, &d.Reason,
+		&d.Status, &d.BalanceNetCents, &d.EvidenceDueBy, &d.EvidenceSubmittedAt,
+		&d.LastReminderDays, &d.Notes, &d.OpenedAt, &d.ClosedAt, &d.UpdatedAt, &d.Customer)
+}
+
+func (r *disputeRepo) Upsert(ctx context.Context, d *Dispute) (string, error) {
+	var prev string
+	err := r.db.QueryRowContext(ctx, `
+        WITH prev AS (SELECT status FROM payment_disputes WHERE stripe_dispute_id = $1)
+        INSERT INTO payment_disputes (
+            stripe_dispute_id, stripe_charge_id, stripe_payment_intent_id, stripe_invoice_id,
+            payment_id, organization_id, amount_cents, currency, reason, status,
+            balance_net_cents, evidence_due_by, evidence_submitted_at, opened_at, closed_at
+        ) VALUES (
+            $1, $2, $3, $4,
+            (SELECT id FROM payments WHERE stripe_invoice_id = NULLIF($4, '') ORDER BY created_at LIMIT 1),
+            COALESCE($5, (SELECT organization_id FROM invoices WHERE stripe_invoice_id = NULLIF($4, '') LIMIT 1)),
+            $6, $7, $8, $9, $10, $11, $12, $13, $14
+        )
+        ON CONFLICT (stripe_dispute_id) DO UPDATE SET
+            stripe_invoice_id     = COALESCE(NULLIF(payment_disputes.stripe_invoice_id, ''), EXCLUDED.stripe_invoice_id),
+            payment_id            = COALESCE(payment_disputes.payment_id, EXCLUDED.payment_id),
+            organization_id       = COALESCE(payment_disputes.organization_id, EXCLUDED.organization_id),
+            amount_cents          = EXCLUDED.amount_cents,
+            reason                = EXCLUDED.reason,
+            status                = EXCLUDED.status,
+            balance_net_cents     = EXCLUDED.balance_net_cents,
+            evidence_due_by       = EXCLUDED.evidence_due_by,
+            evidence_submitted_at = COALESCE(payment_disputes.evidence_submitted_at, EXCLUDED.evidence_submitted_at),
+            closed_at             = COALESCE(payment_disputes.closed_at, EXCLUDED.closed_at),
+            updated_at            = NOW()
+        RETURNING id, payment_id, organization_id, COALESCE((SELECT status FROM prev), '')`,
+		d.StripeDisputeID, d.StripeChargeID, d.StripePaymentIntentID, d.StripeInvoiceID,
+		d.OrganizationID, d.AmountCents, d.Currency, d.Reason, d.Status,
+		d.BalanceNetCents, d.EvidenceDueBy, d.EvidenceSubmittedAt, d.OpenedAt, d.ClosedAt,
+	).Scan(&d.ID, &d.PaymentID, &d.OrganizationID, &prev)
+	return prev, err
+}
+
+func (r *disputeRepo) Get(ctx context.Context, id uuid.UUID) (*Dispute, error) {
+	var d Dispute
+	err := scanDispute(r.db.QueryRowContext(ctx, `SELECT `+disputeCols+disputeFrom+` WHERE d.id = $1`, id), &d)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *disputeRepo) List(ctx context.Context, f DisputeFilter) ([]Dispute, int, error) {
+	const where = `
+        WHERE $1 = ''
+           OR ($1 = 'open' AND d.closed_at IS NULL)
+           OR ($1 = 'closed' AND d.closed_at IS NOT NULL)`
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM payment_disputes d`+where, f.Status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	// Open disputes sort by deadline so the most urgent come first.
+	out, err := r.query(ctx, `SELECT `+disputeCols+disputeFrom+where+`
+        ORDER BY d.closed_at IS NOT NULL, d.evidence_due_by NULLS LAST, d.opened_at DESC
+        LIMIT $2 OFFSET $3`, f.Status, f.Limit, f.Offset)
+	return out, total, err
+}
+
+func (r *disputeRepo) AwaitingEvidence(ctx context.Context, before time.Time) ([]Dispute, error) {
+	return r.query(ctx, `SELECT `+disputeCols+disputeFrom+`
+        WHERE d.closed_at IS NULL
+          AND d.status IN ('needs_response', 'warning_needs_response')
+          AND d.evidence_submitted_at IS NULL
+          AND d.evidence_due_by IS NOT NULL AND d.evidence_due_by < $1
+        ORDER BY d.evidence_due_by`, before)
+}
+
+func (r *disputeRepo) query(ctx context.Context, q string, args ...any) ([]Dispute, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Dispute
+	for rows.Next() {
+		var d Dispute
+		if err := scanDispute(rows, &d); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *disputeRepo) MarkReminded(ctx context.Context, id uuid.UUID, days int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE payment_disputes SET last_reminder_days = $2, updated_at = NOW() WHERE id = $1`, id, days)
+	return err
+}
+
+func (r *disputeRepo) SetEvidenceSubmitted(ctx context.Context, id uuid.UUID, at *time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE payment_disputes SET evidence_submitted_at = $2, updated_at = NOW() WHERE id = $1`, id, at)
+	return err
+}
+
+func (r *disputeRepo) SetNotes(ctx context.Context, id uuid.UUID, notes string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE payment_disputes SET notes = $2, updated_at = NOW() WHERE id = $1`, id, notes)
+	return err
+}
+
+func (r *disputeRepo) ActiveAdmins(ctx context.Context) ([]AdminRecipient, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT email, first_name, system_role::text FROM admin_users
+        WHERE status = 'active' ORDER BY email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AdminRecipient
+	for rows.Next() {
+		var a AdminRecipient
+		if err := rows.Scan(&a.Email, &a.FirstName, &a.SystemRole); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailVerificationState is an app user's address and where it stands.
+// DueAt is the end of the grace period, nil once verified.
+type EmailVerificationState struct {
+	Email      string
+	FirstName  string
+	VerifiedAt *time.Time
+	DueAt      *time.Time
+}
+
+// EmailVerificationToken is one emailed verification link, looked up by
+// the hash of its token.
+type EmailVerificationToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// EmailVerificationRepository owns email_verification_tokens and the
+// verification columns on app_users.
+type EmailVerificationRepository interface {
+	// State returns userID's verification state, nil if there's no such
+	// app user.
+	State(ctx context.Context, userID uuid.UUID) (*EmailVerificationState, error)
+	// Require marks userID's current address unverified with a grace period
+	// ending at dueAt, and voids any links still outstanding.
+	Require(ctx context.Context, userID uuid.UUID, dueAt time.Time) error
+	// CreateToken stores a link sent to email.
+	CreateToken(ctx context.Context, userID uuid.UUID, email, tokenHash string, expiresAt time.Time) error
+	// SendsSince returns how many links userID has been sent since since,
+	// and when the latest was sent.
+	SendsSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, *time.Time, error)
+	// GetToken returns the link with tokenHash, nil if there's none.
+	GetToken(ctx context.Context, tokenHash string) (*EmailVerificationToken, error)
+	// MarkVerified uses the link and verifies its user, provided the link
+	// is unused and the user's address is still the one it was sent to.
+	// Reports whether it did.
+	MarkVerified(ctx context.Context, t *EmailVerificationToken) (bool, error)
+}
+
+type emailVerificationRepo struct {
+	db *DB
+}
+
+// NewEmailVerificationRepo creates an EmailVerificationRepository on the
+// main pool.
+func NewEmailVerificationRepo(db *sql.DB) EmailVerificationRepository {
+	return &emailVerificationRepo{db: WrapDB(db)}
+}
+
+func (r *emailVerificationRepo) State(ctx context.Context, userID uuid.UUID) (*EmailVerificationState, error) {
+	var s EmailVerificationState
+	var verifiedAt, dueAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+        SELECT email, first_name, email_verified_at, email_verify_due_at
+        FROM app_users WHERE id = $1 AND deleted_at IS NULL`, userID,
+	).Scan(&s.Email, &s.FirstName, &verifiedAt, &dueAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		s.VerifiedAt = &verifiedAt.Time
+	} else if dueAt.Valid {
+		s.DueAt = &dueAt.Time
+	}
+	return &s, nil
+}
+
+func (r *emailVerificationRepo) Require(ctx context.Context, userID uuid.UUID, dueAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE app_users SET email_verified_at = NULL, email_verify_due_at = $2
+        WHERE id = $1`, userID, dueAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE email_verification_tokens SET used_at = NOW()
+        WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *emailVerificationRepo) CreateToken(ctx context.Context, userID uuid.UUID, email, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4)`, userID, email, tokenHash, expiresAt)
+	return err
+}
+
+func (r *emailVerificationRepo) SendsSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, *time.Time, error) {
+	var n int
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*), MAX(created_at) FROM email_verification_tokens
+        WHERE user_id = $1 AND created_at >= $2`, userID, since,
+	).Scan(&n, &last); err != nil {
+		return 0, nil, err
+	}
+	if !last.Valid {
+		return n, nil, nil
+	}
+	return n, &last.Time, nil
+}
+
+func (r *emailVerificationRepo) GetToken(ctx context.Context, tokenHash string) (*EmailVerificationToken, error) {
+	var t EmailVerificationToken
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, user_id, email, expires_at, used_at
+        FROM email_verification_tokens WHERE token_hash = $1`, tokenHash,
+	).Scan(&t.ID, &t.UserID, &t.Email, &t.ExpiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+	return &t, nil
+}
+
+func (r *emailVerificationRepo) MarkVerified(ctx context.Context, t *EmailVerificationToken) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+        UPDATE email_verification_tokens SET used_at = NOW()
+        WHERE id = $1 AND used_at IS NULL`, t.ID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	res, err = tx.ExecContext(ctx, `
+        UPDATE app_users SET email_verified_at = NOW(), email_verify_due_at = NULL, updated_at = NOW()
+        WHERE id = $1 AND LOWER(email) = LOWER($2) AND deleted_at IS NULL`, t.UserID, t.Email)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EngagementSignals are one family's activity counts as of a day — the
+// inputs to its engagement score.
+type EngagementSignals struct {
+	FamilyID uuid.UUID `json:"family_id"`
+	// ActiveDays14d is days with a log entry in the 14 days ending the day.
+	ActiveDays14d int `json:"active_days_14d"`
+	Entries14d    int `json:"entries_14d"`
+	// EntriesPrev14d is entries in the 14 days before that.
+	EntriesPrev14d int `json:"entries_prev_14d"`
+	// Features30d is distinct log types plus chat, reports and
+	// correlations used in the 30 days ending the day.
+	Features30d  int        `json:"features_30d"`
+	LastActiveOn *time.Time `json:"last_active_on,omitempty"`
+}
+
+// EngagementScore is a family's scored signals for one day.
+type EngagementScore struct {
+	EngagementSignals
+	Score int `json:"score"`
+}
+
+// ChurnRiskFamily is a family on the churn-risk list: its score, the score
+// it's compared against, and its upcoming renewal. Contact details are the
+// family creator's, for outreach; nothing about the children is included.
+type ChurnRiskFamily struct {
+	EngagementScore
+	FamilyName        string    `json:"family_name"`
+	ContactEmail      string    `json:"contact_email"`
+	ContactFirstName  string    `json:"contact_first_name"`
+	PlanName          string    `json:"plan_name"`
+	Status            string    `json:"status"`
+	RenewsAt          time.Time `json:"renews_at"`
+	CancelAtPeriodEnd bool      `json:"cancel_at_period_end"`
+	// PreviousScore is the score CompareDays earlier, or nil if the family
+	// wasn't scored then.
+	PreviousScore *int `json:"previous_score,omitempty"`
+}
+
+// ChurnRiskFilter selects the churn-risk list: families scored on Day
+// whose paid subscription renews within RenewalDays, and whose score fell
+// by at least MinDrop since CompareDays earlier or is below LowScore.
+type ChurnRiskFilter struct {
+	Day         time.Time
+	CompareDays int
+	RenewalDays int
+	MinDrop     int
+	LowScore    int
+	Limit       int
+}
+
+// EngagementRepository owns family_engagement_scores.
+type EngagementRepository interface {
+	// Signals returns every live family's signals as of day.
+	Signals(ctx context.Context, day time.Time) ([]EngagementSignals, error)
+	// SaveScores upserts day's scores.
+	SaveScores(ctx context.Context, day time.Time, scores []EngagementScore) error
+	// Prune deletes scores dated before before.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+	// LatestScoreDate returns the most recent scored day, or nil.
+	LatestScoreDate(ctx context.Context) (*time.Time, error)
+	// ChurnRisk returns the at-risk families, biggest drop first.
+	ChurnRisk(ctx context.Context, f ChurnRiskFilter) ([]ChurnRiskFamily, error)
+}
+
+type engagementRepo struct {
+	db *DB
+}
+
+// NewEngagementRepo creates an EngagementRepository on the main pool.
+func NewEngagementRepo(db *sql.DB) EngagementRepository {
+	return &engagementRepo{db: WrapDB(db)}
+}
+
+func (r *engagementRepo) Signals(ctx context.Context, day time.Time) ([]EngagementSignals, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        WITH events AS (
+            SELECT c.family_id, e.log_type AS feature, e.log_date AS day, TRUE AS is_log
+            FROM (`+warehouseLogEntries("log_date > $1::date - 30 AND log_date <= $1::date")+`) e
+            JOIN children c ON c.id = e.child_id
+            UNION ALL
+            SELECT t.family_id, 'chat', (m.created_at AT TIME ZONE 'UTC')::date, FALSE
+            FROM chat_messages m JOIN chat_threads t ON t.id = m.thread_id
+            WHERE m.created_at >= $1::date - 29 AND m.created_at < $1::date + 1
+            UNION ALL
+            SELECT family_id, 'reports', (created_at AT TIME ZONE 'UTC')::date, FALSE FROM reports
+            WHERE created_at >= $1::date - 29 AND created_at < $1::date + 1
+            UNION ALL
+            SELECT c.family_id, 'correlations', (cr.created_at AT TIME ZONE 'UTC')::date, FALSE
+            FROM correlation_requests cr JOIN children c ON c.id = cr.child_id
+            WHERE cr.created_at >= $1::date - 29 AND cr.created_at < $1::date + 1
+        )
+        SELECT f.id,
+               COUNT(DISTINCT ev.day) FILTER (WHERE ev.is_log AND ev.day > $1::date - 14),
+               COUNT(*) FILTER (WHERE ev.is_log AND ev.day > $1::date - 14),
+               COUNT(*) FILTER (WHERE ev.is_log AND ev.day <= $1::date - 14 AND ev.day > $1::date - 28),
+               COUNT(DISTINCT ev.feature),
+               MAX(ev.day)
+        FROM families f
+        LEFT JOIN events ev ON ev.family_id = f.id
+        WHERE f.deleted_at IS NULL AND f.created_at < $1::date + 1
+        GROUP BY f.id`, day.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []EngagementSignals
+	for rows.Next() {
+		var s EngagementSignals
+		var last sql.NullTime
+		if err := rows.Scan(&s.FamilyID, &s.ActiveDays14d, &s.Entries14d, &s.EntriesPrev14d, &s.Features30d, &last); err != nil {
+			return nil, err
+		}
+		if last.Valid {
+			s.LastActiveOn = &last.Time
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *engagementRepo) SaveScores(ctx context.Context, day time.Time, scores []EngagementScore) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO family_engagement_scores
+            (family_id, score_date, score, active_days_14d, entries_14d, entries_prev_14d, features_30d, last_active_on)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (family_id, score_date) DO UPDATE SET
+            score            = EXCLUDED.score,
+            active_days_14d  = EXCLUDED.active_days_14d,
+            entries_14d      = EXCLUDED.entries_14d,
+            entries_prev_14d = EXCLUDED.entries_prev_14d,
+            features_30d     = EXCLUDED.features_30d,
+            last_active_on   = EXCLUDED.last_active_on,
+            computed_at      = NOW()
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	d := day.Format("2006-01-02")
+	for _, s := range scores {
+		if _, err := stmt.ExecContext(ctx, s.FamilyID, d, s.Score, s.ActiveDays14d, s.Entries14d,
+			s.EntriesPrev14d, s.Features30d, s.LastActiveOn); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *engagementRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM family_engagement_scores WHERE score_date < $1`, before.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *engagementRepo) LatestScoreDate(ctx context.Context) (*time.Time, error) {
+	var day sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(score_date) FROM family_engagement_scores`).Scan(&day); err != nil {
+		return nil, err
+	}
+	if !day.Valid {
+		return nil, nil
+	}
+	return &day.Time, nil
+}
+
+func (r *engagementRepo) ChurnRisk(ctx context.Context, f ChurnRiskFilter) ([]ChurnRiskFamily, error) {
+	// Comped subscriptions don't renew into a payment, so they're left out.
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT s.family_id, s.score, s.active_days_14d, s.entries_14d, s.entries_prev_14d,
+               s.features_30d, s.last_active_on, f.name, COALESCE(u.email, ''), COALESCE(u.first_name, ''),
+               COALESCE(sp.name, ''), fs.status::text, fs.current_period_end, fs.cancel_at_period_end,
+               p.score
+        FROM family_engagement_scores s
+        JOIN families f ON f.id = s.family_id AND f.deleted_at IS NULL
+        JOIN family_subscriptions fs ON fs.family_id = s.family_id
+        LEFT JOIN subscription_plans sp ON sp.id = fs.plan_id
+        LEFT JOIN app_users u ON u.id = f.created_by
+        LEFT JOIN family_engagement_scores p ON p.family_id = s.family_id AND p.score_date = $1::date - $2::int
+        WHERE s.score_date = $1
+          AND fs.status IN ('active', 'trialing')
+          AND fs.comp_reason IS NULL
+          AND fs.current_period_end >= $1::date
+          AND fs.current_period_end < $1::date + 1 + $3::int
+          AND (p.score - s.score >= $4 OR s.score < $5)
+        ORDER BY COALESCE(p.score - s.score, 0) DESC, s.score, fs.current_period_end
+        LIMIT $6`,
+		f.Day.Format("2006-01-02"), f.CompareDays, f.RenewalDays, f.MinDrop, f.LowScore, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChurnRiskFamily
+	for rows.Next() {
+		var c ChurnRiskFamily
+		var last sql.NullTime
+		var prev sql.NullInt64
+		if err := rows.Scan(&c.FamilyID, &c.Score, &c.ActiveDays14d, &c.Entries14d, &c.EntriesPrev14d,
+			&c.Features30d, &last, &c.FamilyName, &c.ContactEmail, &c.ContactFirstName,
+			&c.PlanName, &c.Status, &c.RenewsAt, &c.CancelAtPeriodEnd, &prev); err != nil {
+			return nil, err
+		}
+		if last.Valid {
+			c.LastActiveOn = &last.Time
+		}
+		if prev.Valid {
+			p := int(prev.Int64)
+			c.PreviousScore = &p
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// OutboxEvent is one row of event_outbox: a domain event written by a
+// trigger in the same transaction as the change behind it.
+type OutboxEvent struct {
+	ID            int64
+	EventID       uuid.UUID
+	EventType     string
+	SchemaVersion int
+	AggregateType string
+	AggregateID   uuid.UUID
+	FamilyID      uuid.NullUUID
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	Attempts      int
+}
+
+// OutboxStats summarises the outbox for the relay's health log.
+type OutboxStats struct {
+	Pending       int64
+	Failing       int64 // pending rows with at least one failed attempt
+	OldestPending sql.NullTime
+}
+
+// EventOutboxRepository owns event_outbox (migration 00062).
+type EventOutboxRepository interface {
+	// Pending returns up to limit unpublished events, oldest first. Rows
+	// aren't locked; the relay runs under JobLocker so there is one reader.
+	Pending(ctx context.Context, limit int) ([]OutboxEvent, error)
+	// MarkPublished stamps published_at on the given rows.
+	MarkPublished(ctx context.Context, ids []int64) error
+	// MarkFailed counts a failed delivery attempt on the given rows.
+	MarkFailed(ctx context.Context, ids []int64, reason string) error
+	// Prune deletes rows published before publishedBefore, and unpublished
+	// rows that occurred before pendingBefore.
+	Prune(ctx context.Context, publishedBefore, pendingBefore time.Time) (int64, error)
+	Stats(ctx context.Context) (*OutboxStats, error)
+}
+
+type eventOutboxRepo struct {
+	db *DB
+}
+
+// NewEventOutboxRepo creates an EventOutboxRepository on the main pool.
+func NewEventOutboxRepo(db *sql.DB) EventOutboxRepository {
+	return &eventOutboxRepo{db: WrapDB(db)}
+}
+
+func (r *eventOutboxRepo) Pending(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, event_id, event_type, schema_version, aggregate_type, aggregate_id,
+               family_id, payload, occurred_at, attempts
+        FROM event_outbox
+        WHERE published_at IS NULL
+        ORDER BY id
+        LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.EventID, &e.EventType, &e.SchemaVersion, &e.AggregateType,
+			&e.AggregateID, &e.FamilyID, &payload, &e.OccurredAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (r *eventOutboxRepo) MarkPublished(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE event_outbox
+        SET published_at = NOW(), attempts = attempts + 1, last_error = NULL
+        WHERE id = ANY($1) AND published_at IS NULL`, pq.Array(ids))
+	return err
+}
+
+func (r *eventOutboxRepo) MarkFailed(ctx context.Context, ids []int64, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if len(reason) > 1000 {
+		reason = reason[:1000]
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE event_outbox
+        SET attempts = attempts + 1, last_error = $2
+        WHERE id = ANY($1) AND published_at IS NULL`, pq.Array(ids), reason)
+	return err
+}
+
+func (r *eventOutboxRepo) Prune(ctx context.Context, publishedBefore, pendingBefore time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+        DELETE FROM event_outbox
+        WHERE (published_at IS NOT NULL AND published_at < $1)
+           OR (published_at IS NULL AND occurred_at < $2)`, publishedBefore, pendingBefore)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *eventOutboxRepo) Stats(ctx context.Context) (*OutboxStats, error) {
+	var s OutboxStats
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*), COUNT(*) FILTER (WHERE attempts > 0), MIN(occurred_at)
+        FROM event_outbox
+        WHERE published_at IS NULL`).Scan(&s.Pending, &s.Failing, &s.OldestPending)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExitPackage is the data export built for a family when their
+// subscription is cancelled. Current reports whether the cancellation it
+// was scheduled for still stands; it is only filled in by Due.
+type ExitPackage struct {
+	ID                   uuid.UUID  `json:"id"`
+	FamilyID             uuid.UUID  `json:"family_id"`
+	FamilySubscriptionID uuid.UUID  `json:"-"`
+	Source               string     `json:"source"`
+	CancelledAt          time.Time  `json:"cancelled_at"`
+	BuildAfter           time.Time  `json:"build_after"`
+	Status               string     `json:"status"`
+	StoragePath          string     `json:"-"`
+	SizeBytes            *int64     `json:"size_bytes,omitempty"`
+	Attempts             int        `json:"-"`
+	LastError            string     `json:"-"`
+	BuiltAt              *time.Time `json:"built_at,omitempty"`
+	ExpiresAt            *time.Time `json:"expires_at,omitempty"`
+	EmailedAt            *time.Time `json:"emailed_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	FamilyName           string     `json:"-"`
+	Current              bool       `json:"-"`
+}
+
+// ExitPackageRecipient is a parent the exit package is emailed to.
+type ExitPackageRecipient struct {
+	Email     string
+	FirstName string
+}
+
+// ExitPackageRepository stores exit packages.
+type ExitPackageRepository interface {
+	// ScheduleForFamily and ScheduleForStripeSubscription record a package
+	// for the subscription's current cancellation, to be built after
+	// buildAfter. Scheduling the same cancellation again only brings
+	// buildAfter forward while the package is pending. They return nil
+	// when the subscription isn't cancelled or the package is past pending.
+	ScheduleForFamily(ctx context.Context, familyID uuid.UUID, source string, buildAfter time.Time) (*ExitPackage, error)
+	ScheduleForStripeSubscription(ctx context.Context, stripeSubscriptionID, source string, buildAfter time.Time) (*ExitPackage, error)
+	Get(ctx context.Context, id uuid.UUID) (*ExitPackage, error)
+	ListForFamily(ctx context.Context, familyID uuid.UUID) ([]ExitPackage, error)
+	// Due returns pending packages whose build time has passed, oldest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]ExitPackage, error)
+	// Expiring returns ready packages whose download window has closed.
+	Expiring(ctx context.Context, now time.Time, limit int) ([]ExitPackage, error)
+	MarkReady(ctx context.Context, id uuid.UUID, storagePath string, size int64, builtAt, expiresAt time.Time) error
+	// MarkAttemptFailed records a failed build; final gives up on it.
+	MarkAttemptFailed(ctx context.Context, id uuid.UUID, msg string, final bool) error
+	MarkEmailed(ctx context.Context, id uuid.UUID) error
+	SetStatus(ctx context.Context, id uuid.UUID, status string) error
+	// Recipients returns the family's active parents.
+	Recipients(ctx context.Context, familyID uuid.UUID) ([]ExitPackageRecipient, error)
+}
+
+type exitPackageRepo struct {
+	db *DB
+}
+
+// NewExitPackageRepo creates an ExitPackageRepository on the main pool.
+func NewExitPackageRepo(db *sql.DB) ExitPackageRepository {
+	return &exitPackageRepo{db: WrapDB(db)}
+}
+
+const exitPackageCols = `p.id, p.family_id, p.family_subscription_id, p.source, p.cancelled_at,
+        p.build_after, p.status, COALESCE(p.storage_path, ''), p.size_bytes, p.attempts, p.last_error,
+        p.built_at, p.expires_at, p.emailed_at, p.created_at, f.name`
+
+const exitPackageFrom = `
+        FROM exit_packages p
+        JOIN families f ON f.id = p.family_id`
+
+func scanExitPackage(row interface{ Scan(...any) error }, p *ExitPackage, extra ...any) error {
+	return row.Scan(append([]any{&p.ID, &p.FamilyID, &p.FamilySubscriptionID, &p.Source, &p.CancelledAt,
+		&p.BuildAfter, &p.Status, &p.StoragePath, &p.SizeBytes, &p.Attempts, &p.LastError,
+		&p.BuiltAt, &p.ExpiresAt, &p.EmailedAt, &p.CreatedAt, &p.FamilyName}, extra...)...)
+}
+
+func (r *exitPackageRepo) ScheduleForFamily(ctx context.Context, familyID uuid.UUID, source string, buildAfter time.Time) (*ExitPackage, error) {
+	return r.schedule(ctx, "family_id", familyID, source, buildAfter)
+}
+
+func (r *exitPackageRepo) ScheduleForStripeSubscription(ctx context.Context, stripeSubscriptionID, source string, buildAfter time.Time) (*ExitPackage, error) {
+	return r.schedule(ctx, "stripe_subscription_id", stripeSubscriptionID, source, buildAfter)
+}
+
+// schedule upserts the package keyed by the subscription row matching
+// keyCol = key. keyCol is one of two constants above, never user input.
+func (r *exitPackageRepo) schedule(ctx context.Context, keyCol string, key any, source string, buildAfter time.Time) (*ExitPackage, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO exit_packages (family_id, family_subscription_id, source, cancelled_at, build_after)
+        SELECT family_id, id, $2, cancelled_at, $3 FROM family_subscriptions
+        WHERE `+keyCol+` = $1 AND cancelled_at IS NOT NULL
+        ON CONFLICT (family_subscription_id, cancelled_at) DO UPDATE SET
+            build_after = LEAST(exit_packages.build_after, EXCLUDED.build_after)
+        WHERE exit_packages.status = 'pending'
+        RETURNING id`, key, source, buildAfter).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *exitPackageRepo) Get(ctx context.Context, id uuid.UUID) (*ExitPackage, error) {
+	var p ExitPackage
+	err := scanExitPackage(r.db.QueryRowContext(ctx, `SELECT `+exitPackageCols+exitPackageFrom+` WHERE p.id = $1`, id), &p)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *exitPackageRepo) ListForFamily(ctx context.Context, familyID uuid.UUID) ([]ExitPackage, error) {
+	return r.query(ctx, `SELECT `+exitPackageCols+exitPackageFrom+`
+        WHERE p.family_id = $1 ORDER BY p.created_at DESC`, familyID)
+}
+
+func (r *exitPackageRepo) Due(ctx context.Context, now time.Time, limit int) ([]ExitPackage, error) {
+	// The cancellation still stands while the subscription carries the
+	// same cancelled_at and is cancelled or set to cancel. Resubscribing
+	// clears cancelled_at; resuming clears cancel_at_period_end.
+	rows, err := r.db.QueryContext(ctx, `SELECT `+exitPackageCols+`,
+            fs.cancelled_at IS NOT DISTINCT FROM p.cancelled_at
+                AND (fs.status IN ('cancelled', 'expired') OR fs.cancel_at_period_end)`+exitPackageFrom+`
+        JOIN family_subscriptions fs ON fs.id = p.family_subscription_id
+        WHERE p.status = 'pending' AND p.build_after <= $1
+        ORDER BY p.build_after
+        LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ExitPackage
+	for rows.Next() {
+		var p ExitPackage
+		if err := scanExitPackage(rows, &p, &p.Current); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *exitPackageRepo) Expiring(ctx context.Context, now time.Time, limit int) ([]ExitPackage, error) {
+	return r.query(ctx, `SELECT `+exitPackageCols+exitPackageFrom+`
+        WHERE p.status = 'ready' AND p.expires_at <= $1
+        ORDER BY p.expires_at
+        LIMIT $2`, now, limit)
+}
+
+func (r *exitPackageRepo) query(ctx context.Context, q string, args ...any) ([]ExitPackage, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ExitPackage
+	for rows.Next() {
+		var p ExitPackage
+		if err := scanExitPackage(rows, &p); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *exitPackageRepo) MarkReady(ctx context.Context, id uuid.UUID, storagePath string, size int64, builtAt, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE exit_packages SET
+            status = 'ready', storage_path = $2, size_bytes = $3, built_at = $4, expires_at = $5,
+            attempts = attempts + 1, last_error = ''
+        WHERE id = $1`, id, storagePath, size, builtAt, expiresAt)
+	return err
+}
+
+func (r *exitPackageRepo) MarkAttemptFailed(ctx context.Context, id uuid.UUID, msg string, final bool) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE exit_packages SET
+            attempts = attempts + 1, last_error = $2,
+            status = CASE WHEN $3 THEN 'failed' ELSE status END
+        WHERE id = $1`, id, msg, final)
+	return err
+}
+
+func (r *exitPackageRepo) MarkEmailed(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE exit_packages SET emailed_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func (r *exitPackageRepo) SetStatus(ctx context.Context, id uuid.UUID, status string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE exit_packages SET status = $2 WHERE id = $1`, id, status)
+	return err
+}
+
+func (r *exitPackageRepo) Recipients(ctx context.Context, familyID uuid.UUID) ([]ExitPackageRecipient, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT u.email, u.first_name
+        FROM family_memberships fm
+        JOIN app_users u ON u.id = fm.user_id
+        WHERE fm.family_id = $1 AND fm.is_active AND fm.role = 'parent'
+        ORDER BY fm.created_at`, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ExitPackageRecipient
+	for rows.Next() {
+		var rc ExitPackageRecipient
+		if err := rows.Scan(&rc.Email, &rc.FirstName); err != nil {
+			return nil, err
+		}
+		out = append(out, rc)
+	}
+	return out, rows.Err()
+}
@@ -12,11 +12,11 @@ import (
 )
 
 type familyRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewFamilyRepo(db *sql.DB) FamilyRepository {
-	return &familyRepo{db: db}
+	return &familyRepo{db: WrapDB(db)}
 }
 
 func (r *familyRepo) Create(ctx context.Context, family *models.Family) error {
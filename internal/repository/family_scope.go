@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// familyScopeSetting is the session setting the row-level security
+// policies from migration 00106 read. Unset or empty, the policies allow
+// every row, so background jobs, the admin console and migrations are
+// unaffected; set, children and every child_id table show only that
+// family's rows.
+//
+// The scoping is opt-in: only contexts passed through WithFamilyScope (the
+// API route groups behind middleware.FamilyScope) are limited, and a
+// statement run under any other context sees every family. It backs up the
+// services' access checks on those routes; it doesn't replace them
+// anywhere else.
+const familyScopeSetting = "app.family_id"
+
+// familyScopeMark is the pgconn CustomData key recording that a pooled
+// connection carries a family setting ResetFamilyScope must clear.
+const familyScopeMark = "carecompanion.family_scope"
+
+var familyRLS = false
+
+// SetFamilyRLS turns family scoping on or off; it starts off. Off, scoped
+// contexts run like any other and only the service-layer access checks
+// apply. Not safe to call once repositories are serving queries.
+func SetFamilyRLS(enabled bool) {
+	familyRLS = enabled
+}
+
+// FamilyRLS reports whether family scoping is on.
+func FamilyRLS() bool {
+	return familyRLS
+}
+
+type familyScopeKey struct{}
+
+// WithFamilyScope returns ctx with every repository statement run under it
+// limited, by the database's row-level security policies, to familyID's
+// children and their rows. It's a second line of defence behind the
+// services' access checks: a handler that forgets one still can't read or
+// write another family's child. uuid.Nil leaves ctx unscoped.
+func WithFamilyScope(ctx context.Context, familyID uuid.UUID) context.Context {
+	if familyID == uuid.Nil {
+		return ctx
+	}
+	return context.WithValue(ctx, familyScopeKey{}, familyID)
+}
+
+// FamilyScope reports the family ctx is scoped to.
+func FamilyScope(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(familyScopeKey{}).(uuid.UUID)
+	return id, ok && familyRLS
+}
+
+var errScopedPrepare = errors.New("repository: statements can't be prepared on the pool under a family scope; prepare them in a transaction")
+
+// scopedConn takes a connection from the pool and sets the family on it for
+// the session. Statements outside a transaction autocommit, so SET LOCAL
+// wouldn't outlive them; ResetFamilyScope clears the setting before the
+// connection serves anyone else.
+func (db *DB) scopedConn(ctx context.Context, familyID uuid.UUID) (*sql.Conn, error) {
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("family scope: expected *stdlib.Conn, got %T", driverConn)
+		}
+		pc := stdConn.Conn()
+		if _, err := pc.Exec(ctx, "SELECT set_config('"+familyScopeSetting+"', $1, false)", familyID.String()); err != nil {
+			return fmt.Errorf("family scope: %w", err)
+		}
+		pc.PgConn().CustomData()[familyScopeMark] = true
+		return nil
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// ResetFamilyScope clears the family setting a scoped statement left on a
+// pooled connection. database.NewWithDSN runs it whenever the pool hands a
+// connection out; it costs a round trip only after a scoped statement.
+func ResetFamilyScope(ctx context.Context, conn *pgx.Conn) error {
+	data := conn.PgConn().CustomData()
+	if data[familyScopeMark] == nil {
+		return nil
+	}
+	if _, err := conn.Exec(ctx, "SELECT set_config('"+familyScopeSetting+"', '', false)"); err != nil {
+		// The pool discards the connection rather than hand it out still
+		// scoped to a family.
+		return err
+	}
+	delete(data, familyScopeMark)
+	return nil
+}
+
+func (db *DB) scopedExec(ctx context.Context, familyID uuid.UUID, query string, args []any) (sql.Result, error) {
+	conn, err := db.scopedConn(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) scopedQuery(ctx context.Context, familyID uuid.UUID, query string, args []any) (*Rows, error) {
+	conn, err := db.scopedConn(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Rows{Rows: rows, conn: conn}, nil
+}
+
+func (db *DB) scopedQueryRow(ctx context.Context, familyID uuid.UUID, query string, args []any) *Row {
+	conn, err := db.scopedConn(ctx, familyID)
+	if err != nil {
+		return &Row{err: err}
+	}
+	row := conn.QueryRowContext(ctx, query, args...)
+	if row.Err() != nil {
+		conn.Close()
+		return &Row{Row: row}
+	}
+	return &Row{Row: row, conn: conn}
+}
+
+// Rows is the *sql.Rows a DB or Tx query returns. A family-scoped query
+// runs on a connection held for its family setting, which goes back to the
+// pool when the rows are closed or read to the end; the statement's
+// timeout timer is released then too.
+type Rows struct {
+	*sql.Rows
+	conn   *sql.Conn
+	cancel context.CancelFunc
+}
+
+// Next is sql.Rows.Next, releasing the connection after the last row.
+func (r *Rows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.Close()
+	return false
+}
+
+// Close is sql.Rows.Close, releasing the connection and the timer.
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	release(&r.conn, &r.cancel)
+	return err
+}
+
+// Row is the *sql.Row a DB or Tx query returns; see Rows. A row releases
+// its connection and timer when it's scanned, and carries the error when
+// the connection couldn't be scoped.
+type Row struct {
+	*sql.Row
+	conn   *sql.Conn
+	cancel context.CancelFunc
+	err    error
+}
+
+// Scan is sql.Row.Scan, releasing the connection and the timer.
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		release(&r.conn, &r.cancel)
+		return r.err
+	}
+	err := r.Row.Scan(dest...)
+	release(&r.conn, &r.cancel)
+	return err
+}
+
+// release hands a held connection back to the pool and stops a
+// statement's timer, once.
+func release(conn **sql.Conn, cancel *context.CancelFunc) {
+	if *conn != nil {
+		(*conn).Close()
+		*conn = nil
+	}
+	if *cancel != nil {
+		(*cancel)()
+		*cancel = nil
+	}
+}
+
+// Err is sql.Row.Err.
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.Row.Err()
+}
+
+// scopeTx sets the family for the rest of tx, as SET LOCAL does.
+func scopeTx(ctx context.Context, tx *sql.Tx, familyID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('"+familyScopeSetting+"', $1, true)", familyID.String()); err != nil {
+		return fmt.Errorf("family scope: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// Feedback kinds stored in user_feedback.kind.
+const (
+	FeedbackKindNPS          = "nps"
+	FeedbackKindNPSDismissed = "nps_dismissed"
+	FeedbackKindText         = "feedback"
+)
+
+// UserFeedback is one NPS response, NPS prompt dismissal, or free-text
+// feedback entry. PlanName and TenureDays are snapshotted on insert.
+type UserFeedback struct {
+	ID         uuid.UUID       `json:"id"`
+	UserID     uuid.UUID       `json:"user_id"`
+	FamilyID   models.NullUUID `json:"family_id,omitempty"`
+	Kind       string          `json:"kind"`
+	Score      sql.NullInt32   `json:"-"`
+	Comment    string          `json:"comment"`
+	Context    string          `json:"context"`
+	PlanName   string          `json:"plan_name"`
+	TenureDays int             `json:"tenure_days"`
+	Platform   string          `json:"platform"`
+	AppVersion string          `json:"app_version"`
+	CreatedAt  time.Time       `json:"created_at"`
+	// Joined for admin lists
+	UserEmail string `json:"user_email,omitempty"`
+	// ScoreValue mirrors Score for JSON; nil for non-NPS rows.
+	ScoreValue *int `json:"score"`
+}
+
+// NPSBucket is the raw promoter/passive/detractor tally for one group —
+// a time period for trends or a plan/tenure segment.
+type NPSBucket struct {
+	Period     time.Time `json:"period,omitempty"`
+	Segment    string    `json:"segment,omitempty"`
+	Responses  int       `json:"responses"`
+	Promoters  int       `json:"promoters"`
+	Passives   int       `json:"passives"`
+	Detractors int       `json:"detractors"`
+	AvgScore   float64   `json:"avg_score"`
+	// NPS is filled in by the service from the tallies above.
+	NPS float64 `json:"nps"`
+}
+
+// FeedbackFilter narrows the admin feedback list. Zero values mean "any".
+type FeedbackFilter struct {
+	Kind     string
+	MinScore *int
+	MaxScore *int
+	From     time.Time
+	To       time.Time
+	// WithComment limits to rows that have free text.
+	WithComment bool
+}
+
+// FeedbackRepository owns user_feedback. It lives on the main DB next to
+// app_users and family_subscriptions so plan/tenure can be snapshotted in
+// the insert itself.
+type FeedbackRepository interface {
+	Create(ctx context.Context, f *UserFeedback) error
+	// LastNPSPrompt returns when the user last answered or dismissed the
+	// NPS prompt, or nil if never.
+	LastNPSPrompt(ctx context.Context, userID uuid.UUID) (*time.Time, error)
+	CountTextSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	List(ctx context.Context, f FeedbackFilter, limit, offset int) ([]UserFeedback, int, error)
+
+	// Reporting. interval is a date_trunc unit (week/month/quarter) —
+	// callers must validate it; it's interpolated into SQL.
+	NPSTrend(ctx context.Context, from, to time.Time, interval string) ([]NPSBucket, error)
+	NPSByPlan(ctx context.Context, from, to time.Time) ([]NPSBucket, error)
+	NPSByTenure(ctx context.Context, from, to time.Time) ([]NPSBucket, error)
+}
+
+type feedbackRepo struct {
+	db *DB
+}
+
+// NewFeedbackRepo creates a FeedbackRepository on the main pool.
+func NewFeedbackRepo(db *sql.DB) FeedbackRepository {
+	return &feedbackRepo{db: WrapDB(db)}
+}
+
+func (r *feedbackRepo) Create(ctx context.Context, f *UserFeedback) error {
+	// Plan and tenure are resolved in SQL so a single round trip captures a
+	// consistent snapshot. Comped/trial families still report their plan.
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO user_feedback (user_id, family_id, kind, score, comment, context,
+                                   plan_name, tenure_days, platform, app_version)
+        VALUES ($1, $2, $3, $4, $5, $6,
+                COALESCE((SELECT sp.name FROM family_subscriptions fs
+                          JOIN subscription_plans sp ON fs.plan_id = sp.id
+                          WHERE fs.family_id = $2), 'none'),
+                COALESCE((SELECT GREATEST(0, EXTRACT(DAY FROM NOW() - u.created_at))::INT
+                          FROM app_users u WHERE u.id = $1), 0),
+                $7, $8)
+        RETURNING id, plan_name, tenure_days, created_at
+    `, f.UserID, f.FamilyID, f.Kind, f.Score, f.Comment, f.Context, f.Platform, f.AppVersion,
+	).Scan(&f.ID, &f.PlanName, &f.TenureDays, &f.CreatedAt)
+}
+
+func (r *feedbackRepo) LastNPSPrompt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	var t sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+        SELECT MAX(created_at) FROM user_feedback
+        WHERE user_id = $1 AND kind IN ('nps', 'nps_dismissed')
+    `, userID).Scan(&t)
+	if err != nil || !t.Valid {
+		return nil, err
+	}
+	return &t.Time, nil
+}
+
+func (r *feedbackRepo) CountTextSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM user_feedback
+        WHERE user_id = $1 AND kind = 'feedback' AND created_at >= $2
+    `, userID, since).Scan(&n)
+	return n, err
+}
+
+func (r *feedbackRepo) List(ctx context.Context, f FeedbackFilter, limit, offset int) ([]UserFeedback, int, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Kind != "" {
+		add("f.kind = $%d", f.Kind)
+	}
+	if f.MinScore != nil {
+		add("f.score >= $%d", *f.MinScore)
+	}
+	if f.MaxScore != nil {
+		add("f.score <= $%d", *f.MaxScore)
+	}
+	if !f.From.IsZero() {
+		add("f.created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("f.created_at < $%d", f.To)
+	}
+	if f.WithComment {
+		where = append(where, "f.comment <> ''")
+	}
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM user_feedback f"+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+        SELECT f.id, f.user_id, f.family_id, f.kind, f.score, f.comment, f.context,
+               f.plan_name, f.tenure_days, f.platform, f.app_version, f.created_at,
+               COALESCE(u.email, '')
+        FROM user_feedback f
+        LEFT JOIN app_users u ON u.id = f.user_id
+        %s
+        ORDER BY f.created_at DESC
+        LIMIT $%d OFFSET $%d
+    `, whereSQL, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []UserFeedback{}
+	for rows.Next() {
+		var fb UserFeedback
+		if err := rows.Scan(&fb.ID, &fb.UserID, &fb.FamilyID, &fb.Kind, &fb.Score, &fb.Comment, &fb.Context,
+			&fb.PlanName, &fb.TenureDays, &fb.Platform, &fb.AppVersion, &fb.CreatedAt, &fb.UserEmail); err != nil {
+			return nil, 0, err
+		}
+		if fb.Score.Valid {
+			v := int(fb.Score.Int32)
+			fb.ScoreValue = &v
+		}
+		out = append(out, fb)
+	}
+	return out, total, rows.Err()
+}
+
+// npsTallyCols aggregates score into promoter/passive/detractor counts.
+const npsTallyCols = `
+    COUNT(*) AS responses,
+    COUNT(*) FILTER (WHERE score >= 9) AS promoters,
+    COUNT(*) FILTER (WHERE score BETWEEN 7 AND 8) AS passives,
+    COUNT(*) FILTER (WHERE score <= 6) AS detractors,
+    COALESCE(AVG(score), 0)::FLOAT8 AS avg_score
+`
+
+func (r *feedbackRepo) NPSTrend(ctx context.Context, from, to time.Time, interval string) ([]NPSBucket, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT date_trunc('`+interval+`', created_at) AS period, `+npsTallyCols+`
+        FROM user_feedback
+        WHERE kind = 'nps' AND created_at >= $1 AND created_at < $2
+        GROUP BY period
+        ORDER BY period ASC
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []NPSBucket
+	for rows.Next() {
+		var b NPSBucket
+		if err := rows.Scan(&b.Period, &b.Responses, &b.Promoters, &b.Passives, &b.Detractors, &b.AvgScore); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *feedbackRepo) NPSByPlan(ctx context.Context, from, to time.Time) ([]NPSBucket, error) {
+	return r.segmented(ctx, `
+        SELECT plan_name AS segment, `+npsTallyCols+`
+        FROM user_feedback
+        WHERE kind = 'nps' AND created_at >= $1 AND created_at < $2
+        GROUP BY segment
+        ORDER BY responses DESC, segment ASC
+    `, from, to)
+}
+
+func (r *feedbackRepo) NPSByTenure(ctx context.Context, from, to time.Time) ([]NPSBucket, error) {
+	// Buckets are ordered by their lower bound, not alphabetically.
+	return r.segmented(ctx, `
+        SELECT segment, `+npsTallyCols+`
+        FROM (
+            SELECT score,
+                   CASE WHEN tenure_days <= 30  THEN '0-30 days'
+                        WHEN tenure_days <= 90  THEN '31-90 days'
+                        WHEN tenure_days <= 180 THEN '91-180 days'
+                        WHEN tenure_days <= 365 THEN '181-365 days'
+                        ELSE '1+ year' END AS segment,
+                   CASE WHEN tenure_days <= 30  THEN 1
+                        WHEN tenure_days <= 90  THEN 2
+                        WHEN tenure_days <= 180 THEN 3
+                        WHEN tenure_days <= 365 THEN 4
+                        ELSE 5 END AS ord
+            FROM user_feedback
+            WHERE kind = 'nps' AND created_at >= $1 AND created_at < $2
+        ) t
+        GROUP BY segment, ord
+        ORDER BY ord ASC
+    `, from, to)
+}
+
+func (r *feedbackRepo) segmented(ctx context.Context, query string, from, to time.Time) ([]NPSBucket, error) {
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []NPSBucket
+	for rows.Next() {
+		var b NPSBucket
+		if err := rows.Scan(&b.Segment, &b.Responses, &b.Promoters, &b.Passives, &b.Detractors, &b.AvgScore); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// FileTransfer is one file in the admin file drop. SharePasswordHash never
+// leaves the server; HasSharePassword is what the UI sees.
+type FileTransfer struct {
+	ID                uuid.UUID       `json:"id"`
+	Filename          string          `json:"filename"`
+	ContentType       string          `json:"content_type"`
+	SizeBytes         int64           `json:"size_bytes"`
+	SHA256            string          `json:"sha256"`
+	StorageDriver     string          `json:"-"`
+	StoragePath       string          `json:"-"`
+	Note              string          `json:"note"`
+	UploadedBy        models.NullUUID `json:"uploaded_by,omitempty"`
+	UploadedByEmail   string          `json:"uploaded_by_email,omitempty"`
+	ExpiresAt         time.Time       `json:"expires_at"`
+	ShareToken        string          `json:"share_token,omitempty"`
+	SharePasswordHash string          `json:"-"`
+	HasSharePassword  bool            `json:"has_share_password"`
+	ShareExpiresAt    *time.Time      `json:"share_expires_at,omitempty"`
+	DownloadCount     int             `json:"download_count"`
+	LastDownloadedAt  *time.Time      `json:"last_downloaded_at,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	// ScanStatus is the antivirus verdict, set on the upload response only.
+	ScanStatus string `json:"scan_status,omitempty"`
+}
+
+// FileTransferRepository owns the file_transfers table. The bytes live in
+// BlobStorage; the service keeps the two in step.
+type FileTransferRepository interface {
+	Create(ctx context.Context, f *FileTransfer) error
+	GetByID(ctx context.Context, id uuid.UUID) (*FileTransfer, error)
+	GetByShareToken(ctx context.Context, token string) (*FileTransfer, error)
+	List(ctx context.Context) ([]FileTransfer, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// TotalBytes is the stored size of every row, expired-but-unswept
+	// included, since those bytes are still on disk.
+	TotalBytes(ctx context.Context) (int64, error)
+	SetShare(ctx context.Context, id uuid.UUID, token, passwordHash string, expiresAt time.Time, by uuid.UUID) error
+	ClearShare(ctx context.Context, id uuid.UUID) error
+	RecordDownload(ctx context.Context, id uuid.UUID) error
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]FileTransfer, error)
+}
+
+type fileTransferRepo struct {
+	db *DB
+}
+
+// NewFileTransferRepo creates a FileTransferRepository on the main pool.
+func NewFileTransferRepo(db *sql.DB) FileTransferRepository {
+	return &fileTransferRepo{db: WrapDB(db)}
+}
+
+const fileTransferCols = `
+    ft.id, ft.filename, ft.content_type, ft.size_bytes, ft.sha256, ft.storage_driver, ft.storage_path,
+    ft.note, ft.uploaded_by, COALESCE(au.email, ''), ft.expires_at,
+    COALESCE(ft.share_token, ''), COALESCE(ft.share_password_hash, ''), ft.share_expires_at,
+    ft.download_count, ft.last_downloaded_at, ft.created_at`
+
+const fileTransferFrom = `
+    FROM file_transfers ft
+    LEFT JOIN admin_users au ON au.id = ft.uploaded_by`
+
+func scanFileTransfer(s rowScannerLike) (*FileTransfer, error) {
+	f := &FileTransfer{}
+	var shareExp, lastDL sql.NullTime
+	err := s.Scan(&f.ID, &f.Filename, &f.ContentType, &f.SizeBytes, &f.SHA256, &f.StorageDriver, &f.StoragePath,
+		&f.Note, &f.UploadedBy, &f.UploadedByEmail, &f.ExpiresAt,
+		&f.ShareToken, &f.SharePasswordHash, &shareExp,
+		&f.DownloadCount, &lastDL, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if shareExp.Valid {
+		f.ShareExpiresAt = &shareExp.Time
+	}
+	if lastDL.Valid {
+		f.LastDownloadedAt = &lastDL.Time
+	}
+	f.HasSharePassword = f.SharePasswordHash != ""
+	return f, nil
+}
+
+func (r *fileTransferRepo) Create(ctx context.Context, f *FileTransfer) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO file_transfers
+            (filename, content_type, size_bytes, sha256, storage_driver, storage_path, note, uploaded_by, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id, created_at
+    `, f.Filename, f.ContentType, f.SizeBytes, f.SHA256, f.StorageDriver, f.StoragePath, f.Note,
+		f.UploadedBy, f.ExpiresAt,
+	).Scan(&f.ID, &f.CreatedAt)
+}
+
+func (r *fileTransferRepo) getOne(ctx context.Context, where string, arg interface{}) (*FileTransfer, error) {
+	f, err := scanFileTransfer(r.db.QueryRowContext(ctx,
+		"SELECT "+fileTransferCols+fileTransferFrom+" WHERE "+where, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return f, err
+}
+
+func (r *fileTransferRepo) GetByID(ctx context.Context, id uuid.UUID) (*FileTransfer, error) {
+	return r.getOne(ctx, "ft.id = $1", id)
+}
+
+func (r *fileTransferRepo) GetByShareToken(ctx context.Context, token string) (*FileTransfer, error) {
+	return r.getOne(ctx, "ft.share_token = $1", token)
+}
+
+func (r *fileTransferRepo) List(ctx context.Context) ([]FileTransfer, error) {
+	return r.query(ctx, "SELECT "+fileTransferCols+fileTransferFrom+" ORDER BY ft.created_at DESC")
+}
+
+func (r *fileTransferRepo) query(ctx context.Context, q string, args ...interface{}) ([]FileTransfer, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []FileTransfer
+	for rows.Next() {
+		f, err := scanFileTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *f)
+	}
+	return out, rows.Err()
+}
+
+func (r *fileTransferRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM file_transfers WHERE id = $1", id)
+	return err
+}
+
+func (r *fileTransferRepo) TotalBytes(ctx context.Context) (int64, error) {
+	var total int64
+	err := r.db.QueryRowContext(ctx, "SELECT COALESCE(SUM(size_bytes), 0) FROM file_transfers").Scan(&total)
+	return total, err
+}
+
+func (r *fileTransferRepo) SetShare(ctx context.Context, id uuid.UUID, token, passwordHash string, expiresAt time.Time, by uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE file_transfers
+           SET share_token = $2, share_password_hash = NULLIF($3, ''), share_expires_at = $4,
+               share_created_by = $5
+         WHERE id = $1
+    `, id, token, passwordHash, expiresAt, models.NullUUID{UUID: by, Valid: by != uuid.Nil})
+	return err
+}
+
+func (r *fileTransferRepo) ClearShare(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE file_transfers
+           SET share_token = NULL, share_password_hash = NULL, share_expires_at = NULL, share_created_by = NULL
+         WHERE id = $1
+    `, id)
+	return err
+}
+
+func (r *fileTransferRepo) RecordDownload(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE file_transfers SET download_count = download_count + 1, last_downloaded_at = NOW()
+         WHERE id = $1
+    `, id)
+	return err
+}
+
+func (r *fileTransferRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]FileTransfer, error) {
+	return r.query(ctx, "SELECT "+fileTransferCols+fileTransferFrom+
+		" WHERE ft.expires_at <= $1 ORDER BY ft.expires_at LIMIT $2", now, limit)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// FoodRecognitionRepository stores the food suggestions made for meal
+// photos.
+type FoodRecognitionRepository interface {
+	// Create inserts the recognition; ID and CreatedAt are filled in.
+	Create(ctx context.Context, fr *models.FoodRecognition) error
+	// GetByID returns the recognition, or nil, nil.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.FoodRecognition, error)
+	// SetDietLog records the diet log saved from the recognition.
+	SetDietLog(ctx context.Context, id, dietLogID uuid.UUID) error
+}
+
+type foodRecognitionRepo struct {
+	db *DB
+}
+
+// NewFoodRecognitionRepo creates a FoodRecognitionRepository on the main
+// pool.
+func NewFoodRecognitionRepo(db *sql.DB) FoodRecognitionRepository {
+	return &foodRecognitionRepo{db: WrapDB(db)}
+}
+
+func (r *foodRecognitionRepo) Create(ctx context.Context, fr *models.FoodRecognition) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO food_recognitions (child_id, provider, model, image_sha256, suggestions, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `, fr.ChildID, fr.Provider, fr.Model, fr.ImageSHA256, fr.Suggestions, fr.CreatedBy,
+	).Scan(&fr.ID, &fr.CreatedAt)
+}
+
+func (r *foodRecognitionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.FoodRecognition, error) {
+	var fr models.FoodRecognition
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, child_id, provider, model, image_sha256, suggestions, diet_log_id, created_by, created_at
+        FROM food_recognitions WHERE id = $1`, id,
+	).Scan(&fr.ID, &fr.ChildID, &fr.Provider, &fr.Model, &fr.ImageSHA256, &fr.Suggestions, &fr.DietLogID,
+		&fr.CreatedBy, &fr.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &fr, nil
+}
+
+func (r *foodRecognitionRepo) SetDietLog(ctx context.Context, id, dietLogID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE food_recognitions SET diet_log_id = $2 WHERE id = $1`, id, dietLogID)
+	return err
+}
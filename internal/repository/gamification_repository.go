@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// LoggingDay is how many entries a caregiver logged on one day.
+type LoggingDay struct {
+	Date    time.Time
+	Entries int
+}
+
+// DigestRecipient is a caregiver the weekly digest goes to.
+type DigestRecipient struct {
+	UserID       uuid.UUID
+	Email        string
+	FirstName    string
+	Timezone     string
+	Gamification bool
+}
+
+// GamificationRepository reads caregivers' logging activity, across every
+// log type, and stores their gamification opt-outs.
+type GamificationRepository interface {
+	// GetSettings returns the user's settings; both are on without a row.
+	GetSettings(ctx context.Context, userID uuid.UUID) (*models.GamificationSettings, error)
+	SaveSettings(ctx context.Context, userID uuid.UUID, s *models.GamificationSettings) error
+	// LoggingDays returns the days the user logged entries on, as dates in
+	// the IANA zone tz (UTC midnight), oldest first.
+	LoggingDays(ctx context.Context, userID uuid.UUID, tz string) ([]LoggingDay, error)
+	// DigestRecipients returns the active users who logged an entry since
+	// and haven't turned the weekly digest off.
+	DigestRecipients(ctx context.Context, since time.Time) ([]DigestRecipient, error)
+}
+
+type gamificationRepo struct {
+	db *DB
+}
+
+// NewGamificationRepo creates a GamificationRepository on the main pool.
+func NewGamificationRepo(db *sql.DB) GamificationRepository {
+	return &gamificationRepo{db: WrapDB(db)}
+}
+
+func (r *gamificationRepo) GetSettings(ctx context.Context, userID uuid.UUID) (*models.GamificationSettings, error) {
+	s := &models.GamificationSettings{Enabled: true, WeeklyDigest: true}
+	err := r.db.QueryRowContext(ctx, `
+        SELECT enabled, weekly_digest FROM gamification_preferences WHERE user_id = $1
+    `, userID).Scan(&s.Enabled, &s.WeeklyDigest)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *gamificationRepo) SaveSettings(ctx context.Context, userID uuid.UUID, s *models.GamificationSettings) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO gamification_preferences (user_id, enabled, weekly_digest)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE
+        SET enabled = EXCLUDED.enabled, weekly_digest = EXCLUDED.weekly_digest, updated_at = NOW()
+    `, userID, s.Enabled, s.WeeklyDigest)
+	return err
+}
+
+// loggedAtSQL selects created_at from every log table for entries logged
+// by $1.
+var loggedAtSQL = func() string {
+	parts := make([]string, len(LogUsageTypes))
+	for i, t := range LogUsageTypes {
+		parts[i] = `SELECT created_at FROM ` + logUsageTables[t] + ` WHERE logged_by = $1`
+	}
+	return strings.Join(parts, " UNION ALL ")
+}()
+
+func (r *gamificationRepo) LoggingDays(ctx context.Context, userID uuid.UUID, tz string) ([]LoggingDay, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT (created_at AT TIME ZONE $2)::date AS day, COUNT(*)
+        FROM (`+loggedAtSQL+`) l
+        GROUP BY day
+        ORDER BY day`, userID, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LoggingDay
+	for rows.Next() {
+		var d LoggingDay
+		if err := rows.Scan(&d.Date, &d.Entries); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// recentLoggersSQL selects logged_by from every log table for entries
+// created since $1.
+var recentLoggersSQL = func() string {
+	parts := make([]string, len(LogUsageTypes))
+	for i, t := range LogUsageTypes {
+		parts[i] = `SELECT logged_by FROM ` + logUsageTables[t] + ` WHERE created_at >= $1`
+	}
+	return strings.Join(parts, " UNION ")
+}()
+
+func (r *gamificationRepo) DigestRecipients(ctx context.Context, since time.Time) ([]DigestRecipient, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT u.id, u.email, COALESCE(u.first_name, ''), COALESCE(u.timezone, ''), COALESCE(gp.enabled, TRUE)
+        FROM app_users u
+        LEFT JOIN gamification_preferences gp ON gp.user_id = u.id
+        WHERE u.deleted_at IS NULL AND u.status = 'active'
+          AND COALESCE(gp.weekly_digest, TRUE)
+          AND u.id IN (`+recentLoggersSQL+`)
+        ORDER BY u.id`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DigestRecipient
+	for rows.Next() {
+		var d DigestRecipient
+		if err := rows.Scan(&d.UserID, &d.Email, &d.FirstName, &d.Timezone, &d.Gamification); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
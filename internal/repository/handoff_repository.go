@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// HandoffRepository stores each child's respite handoff profile.
+type HandoffRepository interface {
+	// GetProfile returns the child's handoff profile, or nil, nil.
+	GetProfile(ctx context.Context, childID uuid.UUID) (*models.HandoffProfile, error)
+	// SaveProfile creates or replaces the profile; UpdatedAt is filled in.
+	SaveProfile(ctx context.Context, p *models.HandoffProfile) error
+}
+
+type handoffRepo struct {
+	db *DB
+}
+
+// NewHandoffRepo creates a HandoffRepository on the main pool.
+func NewHandoffRepo(db *sql.DB) HandoffRepository {
+	return &handoffRepo{db: WrapDB(db)}
+}
+
+func (r *handoffRepo) GetProfile(ctx context.Context, childID uuid.UUID) (*models.HandoffProfile, error) {
+	var p models.HandoffProfile
+	var updatedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+        SELECT child_id, emergency_contacts, communication_notes, calming_notes, allergy_notes, care_notes,
+               updated_by, updated_at
+        FROM child_handoff_profiles
+        WHERE child_id = $1
+    `, childID).Scan(&p.ChildID, &p.EmergencyContacts, &p.CommunicationNotes, &p.CalmingNotes, &p.AllergyNotes,
+		&p.CareNotes, &p.UpdatedBy, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updatedAt.Valid {
+		p.UpdatedAt = &updatedAt.Time
+	}
+	return &p, nil
+}
+
+func (r *handoffRepo) SaveProfile(ctx context.Context, p *models.HandoffProfile) error {
+	var updatedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO child_handoff_profiles
+            (child_id, emergency_contacts, communication_notes, calming_notes, allergy_notes, care_notes, updated_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (child_id) DO UPDATE SET
+            emergency_contacts = EXCLUDED.emergency_contacts,
+            communication_notes = EXCLUDED.communication_notes,
+            calming_notes = EXCLUDED.calming_notes,
+            allergy_notes = EXCLUDED.allergy_notes,
+            care_notes = EXCLUDED.care_notes,
+            updated_by = EXCLUDED.updated_by,
+            updated_at = NOW()
+        RETURNING updated_at
+    `, p.ChildID, p.EmergencyContacts, p.CommunicationNotes, p.CalmingNotes, p.AllergyNotes, p.CareNotes,
+		p.UpdatedBy).Scan(&updatedAt)
+	if err != nil {
+		return err
+	}
+	p.UpdatedAt = &updatedAt.Time
+	return nil
+}
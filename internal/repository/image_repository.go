@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// ImageRepository owns image_sets and image_variants, the responsive
+// variants generated by the image pipeline.
+type ImageRepository interface {
+	// CreateSet inserts the set and its variants in one transaction and
+	// fills in their IDs and timestamps.
+	CreateSet(ctx context.Context, set *models.ImageSet) error
+	// LatestSet returns the owner's newest set with its variants, or nil.
+	LatestSet(ctx context.Context, ownerType string, ownerID uuid.UUID) (*models.ImageSet, error)
+	// ListSets returns all of the owner's sets, newest first, with variants.
+	ListSets(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]models.ImageSet, error)
+	GetVariant(ctx context.Context, id uuid.UUID) (*models.ImageVariant, error)
+	// DeleteSet removes a set; its variants go with it (ON DELETE CASCADE).
+	DeleteSet(ctx context.Context, id uuid.UUID) error
+}
+
+type imageRepo struct {
+	db *DB
+}
+
+// NewImageRepo creates an ImageRepository.
+func NewImageRepo(db *sql.DB) ImageRepository {
+	return &imageRepo{db: WrapDB(db)}
+}
+
+const imageVariantCols = `id, image_set_id, format, width, height, content_type, storage_path, size_bytes, created_at`
+
+func scanImageVariant(row interface{ Scan(...any) error }) (*models.ImageVariant, error) {
+	var v models.ImageVariant
+	err := row.Scan(&v.ID, &v.ImageSetID, &v.Format, &v.Width, &v.Height, &v.ContentType, &v.StoragePath, &v.SizeBytes, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *imageRepo) CreateSet(ctx context.Context, set *models.ImageSet) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO image_sets (owner_type, owner_id, source_width, source_height, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `, set.OwnerType, set.OwnerID, set.SourceWidth, set.SourceHeight, set.CreatedBy).Scan(&set.ID, &set.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for i := range set.Variants {
+		v := &set.Variants[i]
+		v.ImageSetID = set.ID
+		err := tx.QueryRowContext(ctx, `
+            INSERT INTO image_variants (image_set_id, format, width, height, content_type, storage_path, size_bytes)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            RETURNING id, created_at
+        `, v.ImageSetID, v.Format, v.Width, v.Height, v.ContentType, v.StoragePath, v.SizeBytes).Scan(&v.ID, &v.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *imageRepo) LatestSet(ctx context.Context, ownerType string, ownerID uuid.UUID) (*models.ImageSet, error) {
+	sets, err := r.listSets(ctx, ownerType, ownerID, 1)
+	if err != nil || len(sets) == 0 {
+		return nil, err
+	}
+	return &sets[0], nil
+}
+
+func (r *imageRepo) ListSets(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]models.ImageSet, error) {
+	return r.listSets(ctx, ownerType, ownerID, 0)
+}
+
+// listSets loads the owner's sets (all when limit is 0) and then their
+// variants, smallest first within each format.
+func (r *imageRepo) listSets(ctx context.Context, ownerType string, ownerID uuid.UUID, limit int) ([]models.ImageSet, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, owner_type, owner_id, source_width, source_height, created_by, created_at
+        FROM image_sets
+        WHERE owner_type = $1 AND owner_id = $2
+        ORDER BY created_at DESC
+        LIMIT NULLIF($3, 0)
+    `, ownerType, ownerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sets []models.ImageSet
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var s models.ImageSet
+		if err := rows.Scan(&s.ID, &s.OwnerType, &s.OwnerID, &s.SourceWidth, &s.SourceHeight, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Variants = []models.ImageVariant{}
+		index[s.ID] = len(sets)
+		sets = append(sets, s)
+	}
+	if err := rows.Err(); err != nil || len(sets) == 0 {
+		return sets, err
+	}
+
+	ids := make([]string, len(sets))
+	for i, s := range sets {
+		ids[i] = s.ID.String()
+	}
+	vrows, err := r.db.QueryContext(ctx, `
+        SELECT `+imageVariantCols+`
+        FROM image_variants
+        WHERE image_set_id = ANY($1::uuid[])
+        ORDER BY format, width
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer vrows.Close()
+	for vrows.Next() {
+		v, err := scanImageVariant(vrows)
+		if err != nil {
+			return nil, err
+		}
+		i := index[v.ImageSetID]
+		sets[i].Variants = append(sets[i].Variants, *v)
+	}
+	return sets, vrows.Err()
+}
+
+func (r *imageRepo) GetVariant(ctx context.Context, id uuid.UUID) (*models.ImageVariant, error) {
+	v, err := scanImageVariant(r.db.QueryRowContext(ctx,
+		"SELECT "+imageVariantCols+" FROM image_variants WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+func (r *imageRepo) DeleteSet(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM image_sets WHERE id = $1`, id)
+	return err
+}
@@ -11,11 +11,11 @@ import (
 )
 
 type insightRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewInsightRepo(db *sql.DB) InsightRepository {
-	return &insightRepo{db: db}
+	return &insightRepo{db: WrapDB(db)}
 }
 
 func (r *insightRepo) Create(ctx context.Context, insight *models.Insight) error {
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Integrity check run outcomes.
+const (
+	IntegrityStatusRunning  = "running"
+	IntegrityStatusClean    = "clean"
+	IntegrityStatusProblems = "problems"
+	IntegrityStatusFailed   = "failed"
+)
+
+// IntegrityCheck finds rows of Table whose references don't resolve.
+// Where is a predicate on the row, aliased t. Only Quarantinable checks
+// may have their rows moved to integrity_quarantine; the rest (such as
+// payments, which are financial records) are report-only.
+type IntegrityCheck struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Table         string `json:"table"`
+	Where         string `json:"-"`
+	Quarantinable bool   `json:"quarantinable"`
+}
+
+// IntegrityChecks are the checks a run performs, in order. Log tables
+// keep their rows when a child is deactivated (children are soft
+// deleted), so those are reported but never quarantined.
+var IntegrityChecks = func() []IntegrityCheck {
+	var checks []IntegrityCheck
+	for _, t := range LogUsageTypes {
+		table := logUsageTables[t]
+		checks = append(checks, IntegrityCheck{
+			Name:          table + ".missing_child",
+			Description:   t + " logs whose child no longer exists",
+			Table:         table,
+			Where:         `NOT EXISTS (SELECT 1 FROM children c WHERE c.id = t.child_id)`,
+			Quarantinable: true,
+		}, IntegrityCheck{
+			Name:        table + ".deactivated_child",
+			Description: t + " logs of a deactivated child",
+			Table:       table,
+			Where:       `EXISTS (SELECT 1 FROM children c WHERE c.id = t.child_id AND c.is_active = false)`,
+		})
+	}
+	return append(checks,
+		IntegrityCheck{
+			Name:          "family_memberships.missing_user",
+			Description:   "Family memberships whose user no longer exists",
+			Table:         "family_memberships",
+			Where:         `NOT EXISTS (SELECT 1 FROM app_users u WHERE u.id = t.user_id)`,
+			Quarantinable: true,
+		},
+		IntegrityCheck{
+			Name:          "family_memberships.missing_family",
+			Description:   "Family memberships whose family no longer exists",
+			Table:         "family_memberships",
+			Where:         `NOT EXISTS (SELECT 1 FROM families f WHERE f.id = t.family_id)`,
+			Quarantinable: true,
+		},
+		IntegrityCheck{
+			Name:        "payments.missing_subscription",
+			Description: "Subscription payments not linked to any family or user subscription",
+			Table:       "payments",
+			Where: `t.payment_type = 'subscription' AND (t.subscription_id IS NULL OR (
+                NOT EXISTS (SELECT 1 FROM family_subscriptions fs WHERE fs.id = t.subscription_id)
+                AND NOT EXISTS (SELECT 1 FROM user_subscriptions us WHERE us.id = t.subscription_id)))`,
+		},
+	)
+}()
+
+// IntegrityCheckResult is one check's outcome in a run. It carries counts
+// and row IDs only, never row data.
+type IntegrityCheckResult struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Table         string   `json:"table"`
+	Count         int64    `json:"count"`
+	SampleIDs     []string `json:"sample_ids,omitempty"`
+	Quarantinable bool     `json:"quarantinable"`
+	Quarantined   int64    `json:"quarantined,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// IntegrityRun is one run of the integrity checker.
+type IntegrityRun struct {
+	ID         uuid.UUID              `json:"id"`
+	Status     string                 `json:"status"`
+	Quarantine bool                   `json:"quarantine"`
+	Results    []IntegrityCheckResult `json:"results"`
+	Error      string                 `json:"error,omitempty"`
+	RunBy      string                 `json:"run_by,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+// IntegrityRepository runs integrity checks and records their runs.
+type IntegrityRepository interface {
+	// Count returns how many rows check matches and up to samples of
+	// their IDs.
+	Count(ctx context.Context, check IntegrityCheck, samples int) (int64, []string, error)
+	// Quarantine moves the rows check matches into integrity_quarantine
+	// and returns how many were moved.
+	Quarantine(ctx context.Context, runID uuid.UUID, check IntegrityCheck) (int64, error)
+
+	// CreateRun inserts a run; ID and StartedAt are filled in.
+	CreateRun(ctx context.Context, run *IntegrityRun) error
+	// FinishRun stores run's status, results and error.
+	FinishRun(ctx context.Context, run *IntegrityRun) error
+	ListRuns(ctx context.Context, limit int) ([]IntegrityRun, error)
+	// GetRun returns nil, nil when there is no such run.
+	GetRun(ctx context.Context, id uuid.UUID) (*IntegrityRun, error)
+}
+
+type integrityRepo struct {
+	db *DB
+}
+
+// NewIntegrityRepo creates an IntegrityRepository on the main pool.
+func NewIntegrityRepo(db *sql.DB) IntegrityRepository {
+	return &integrityRepo{db: WrapDB(db)}
+}
+
+// Table and Where come from IntegrityChecks, never from input.
+
+func (r *integrityRepo) Count(ctx context.Context, check IntegrityCheck, samples int) (int64, []string, error) {
+	var count int64
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM `+check.Table+` t WHERE `+check.Where).Scan(&count); err != nil {
+		return 0, nil, err
+	}
+	if count == 0 || samples <= 0 {
+		return count, nil, nil
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT t.id::text FROM `+check.Table+` t WHERE `+check.Where+` ORDER BY t.id LIMIT $1`, samples)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return 0, nil, err
+		}
+		ids = append(ids, id)
+	}
+	return count, ids, rows.Err()
+}
+
+func (r *integrityRepo) Quarantine(ctx context.Context, runID uuid.UUID, check IntegrityCheck) (int64, error) {
+	if !check.Quarantinable {
+		return 0, errors.New("check " + check.Name + " is report-only")
+	}
+	res, err := r.db.ExecContext(ctx, `
+        WITH moved AS (
+            DELETE FROM `+check.Table+` t WHERE `+check.Where+`
+            RETURNING t.id::text AS row_id, to_jsonb(t.*) AS row_data
+        )
+        INSERT INTO integrity_quarantine (run_id, check_name, source_table, row_id, row_data)
+        SELECT $1, $2, $3, row_id, row_data FROM moved`, runID, check.Name, check.Table)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *integrityRepo) CreateRun(ctx context.Context, run *IntegrityRun) error {
+	if run.Status == "" {
+		run.Status = IntegrityStatusRunning
+	}
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO integrity_check_runs (status, quarantine, run_by)
+        VALUES ($1, $2, $3)
+        RETURNING id, started_at
+    `, run.Status, run.Quarantine, run.RunBy,
+	).Scan(&run.ID, &run.StartedAt)
+}
+
+func (r *integrityRepo) FinishRun(ctx context.Context, run *IntegrityRun) error {
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRowContext(ctx, `
+        UPDATE integrity_check_runs
+        SET status = $2, results = $3, error = $4, finished_at = NOW()
+        WHERE id = $1
+        RETURNING finished_at
+    `, run.ID, run.Status, results, run.Error,
+	).Scan(&run.FinishedAt)
+}
+
+const integrityRunCols = `id, status, quarantine, results, error, run_by, started_at, finished_at`
+
+func scanIntegrityRun(row interface{ Scan(...any) error }, run *IntegrityRun) error {
+	var results []byte
+	if err := row.Scan(&run.ID, &run.Status, &run.Quarantine, &results, &run.Error, &run.RunBy,
+		&run.StartedAt, &run.FinishedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal(results, &run.Results)
+}
+
+func (r *integrityRepo) ListRuns(ctx context.Context, limit int) ([]IntegrityRun, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+integrityRunCols+`
+        FROM integrity_check_runs
+        ORDER BY started_at DESC
+        LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []IntegrityRun
+	for rows.Next() {
+		var run IntegrityRun
+		if err := scanIntegrityRun(rows, &run); err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+func (r *integrityRepo) GetRun(ctx context.Context, id uuid.UUID) (*IntegrityRun, error) {
+	var run IntegrityRun
+	err := scanIntegrityRun(r.db.QueryRowContext(ctx, `SELECT `+integrityRunCols+`
+        FROM integrity_check_runs WHERE id = $1`, id), &run)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// InterventionRepository stores intervention experiments: what a family
+// tried for a child, when, and which metrics it was meant to move.
+type InterventionRepository interface {
+	// Create inserts the intervention; ID and timestamps are filled in.
+	Create(ctx context.Context, i *models.Intervention) error
+	// GetByID returns the intervention, or nil, nil.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Intervention, error)
+	// ListByChild returns the child's interventions, most recent start
+	// first.
+	ListByChild(ctx context.Context, childID uuid.UUID) ([]models.Intervention, error)
+	Update(ctx context.Context, i *models.Intervention) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type interventionRepo struct {
+	db *DB
+}
+
+// NewInterventionRepo creates an InterventionRepository on the main pool.
+func NewInterventionRepo(db *sql.DB) InterventionRepository {
+	return &interventionRepo{db: WrapDB(db)}
+}
+
+const interventionCols = `id, child_id, name, description, start_date, end_date, target_metrics, created_by, created_at, updated_at`
+
+func scanIntervention(row interface{ Scan(...any) error }, i *models.Intervention) error {
+	return row.Scan(&i.ID, &i.ChildID, &i.Name, &i.Description, &i.StartDate, &i.EndDate, pq.Array(&i.TargetMetrics),
+		&i.CreatedBy, &i.CreatedAt, &i.UpdatedAt)
+}
+
+func (r *interventionRepo) Create(ctx context.Context, i *models.Intervention) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO interventions (child_id, name, description, start_date, end_date, target_metrics, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at
+    `, i.ChildID, i.Name, i.Description, i.StartDate, i.EndDate, pq.Array(i.TargetMetrics), i.CreatedBy,
+	).Scan(&i.ID, &i.CreatedAt, &i.UpdatedAt)
+}
+
+func (r *interventionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Intervention, error) {
+	var i models.Intervention
+	err := scanIntervention(r.db.QueryRowContext(ctx, `SELECT `+interventionCols+` FROM interventions WHERE id = $1`, id), &i)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *interventionRepo) ListByChild(ctx context.Context, childID uuid.UUID) ([]models.Intervention, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+interventionCols+`
+        FROM interventions
+        WHERE child_id = $1
+        ORDER BY start_date DESC, created_at DESC`, childID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Intervention
+	for rows.Next() {
+		var i models.Intervention
+		if err := scanIntervention(rows, &i); err != nil {
+			return nil, err
+		}
+		out = append(out, i)
+	}
+	return out, rows.Err()
+}
+
+func (r *interventionRepo) Update(ctx context.Context, i *models.Intervention) error {
+	return r.db.QueryRowContext(ctx, `
+        UPDATE interventions
+        SET name = $2, description = $3, start_date = $4, end_date = $5, target_metrics = $6, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, i.ID, i.Name, i.Description, i.StartDate, i.EndDate, pq.Array(i.TargetMetrics)).Scan(&i.UpdatedAt)
+}
+
+func (r *interventionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM interventions WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,362 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+)
+
+// ErrInvoiceExists is returned when the payment or Stripe invoice already
+// has an invoice.
+var ErrInvoiceExists = apperr.Conflict("invoice already issued")
+
+// InvoiceLine is one line on an invoice. AmountCents is the line total;
+// negative for credits such as a proration for removed seats.
+type InvoiceLine struct {
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// Invoice is an issued invoice. Exactly one of PaymentID (family payments)
+// or OrganizationID with StripeInvoiceID (organization seat billing) says
+// where it came from.
+type Invoice struct {
+	ID              uuid.UUID     `json:"id"`
+	Number          string        `json:"number"`
+	PaymentID       *uuid.UUID    `json:"payment_id,omitempty"`
+	StripeInvoiceID string        `json:"stripe_invoice_id,omitempty"`
+	FamilyID        *uuid.UUID    `json:"family_id,omitempty"`
+	OrganizationID  *uuid.UUID    `json:"organization_id,omitempty"`
+	UserID          *uuid.UUID    `json:"user_id,omitempty"`
+	BillToName      string        `json:"bill_to_name"`
+	BillToEmail     string        `json:"bill_to_email"`
+	Lines           []InvoiceLine `json:"lines"`
+	SubtotalCents   int64         `json:"subtotal_cents"`
+	DiscountCents   int64         `json:"discount_cents"`
+	TaxCents        int64         `json:"tax_cents"`
+	TaxInclusive    bool          `json:"tax_inclusive"`
+	TotalCents      int64         `json:"total_cents"`
+	Currency        string        `json:"currency"`
+	PeriodStart     *time.Time    `json:"period_start,omitempty"`
+	PeriodEnd       *time.Time    `json:"period_end,omitempty"`
+	PaidAt          time.Time     `json:"paid_at"`
+	IssuedAt        time.Time     `json:"issued_at"`
+	PDFPath         string        `json:"-"`
+	PDFSize         int64         `json:"pdf_size,omitempty"`
+	PDFVersion      int           `json:"pdf_version"`
+	PDFGeneratedAt  *time.Time    `json:"pdf_generated_at,omitempty"`
+	SentCount       int           `json:"sent_count"`
+	LastSentAt      *time.Time    `json:"last_sent_at,omitempty"`
+	LastSentTo      string        `json:"last_sent_to,omitempty"`
+}
+
+// InvoicePaymentSource is a payments row with what an invoice needs from
+// around it: the payer, their family and the plan.
+type InvoicePaymentSource struct {
+	PaymentID       uuid.UUID
+	UserID          *uuid.UUID
+	FamilyID        *uuid.UUID
+	Status          string
+	AmountCents     int64
+	DiscountCents   int64
+	TaxCents        int64
+	TaxInclusive    bool
+	Currency        string
+	Description     string
+	StripeInvoiceID string
+	PaidAt          time.Time
+	PayerName       string
+	PayerEmail      string
+	PlanName        string
+	BillingInterval string
+}
+
+// InvoiceFilter narrows the admin invoice list. Query matches the invoice
+// number or bill-to name/email.
+type InvoiceFilter struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// InvoiceRepository stores issued invoices.
+type InvoiceRepository interface {
+	// Create assigns the number and inserts the invoice, or returns
+	// ErrInvoiceExists.
+	Create(ctx context.Context, inv *Invoice) error
+	Get(ctx context.Context, id uuid.UUID) (*Invoice, error)
+	GetByPayment(ctx context.Context, paymentID uuid.UUID) (*Invoice, error)
+	GetByStripeInvoice(ctx context.Context, stripeInvoiceID string) (*Invoice, error)
+	ListForFamily(ctx context.Context, familyID uuid.UUID) ([]Invoice, error)
+	ListForOrganization(ctx context.Context, orgID uuid.UUID) ([]Invoice, error)
+	List(ctx context.Context, f InvoiceFilter) ([]Invoice, int, error)
+
+	// PaymentSource loads a payment for invoicing; nil if it doesn't exist.
+	PaymentSource(ctx context.Context, paymentID uuid.UUID) (*InvoicePaymentSource, error)
+	// PaymentForStripeInvoice returns the payment recorded for a Stripe
+	// invoice; nil if there is none.
+	PaymentForStripeInvoice(ctx context.Context, stripeInvoiceID string) (*uuid.UUID, error)
+	// UninvoicedPayments lists the family's successful payments that have
+	// no invoice yet, oldest first.
+	UninvoicedPayments(ctx context.Context, familyID uuid.UUID) ([]uuid.UUID, error)
+	// CurrentBillTo returns who an invoice would be addressed to now: the
+	// organization when orgID is set, otherwise the user.
+	CurrentBillTo(ctx context.Context, userID, orgID *uuid.UUID) (name, email string, err error)
+
+	SetBillTo(ctx context.Context, id uuid.UUID, name, email string) error
+	// SetPDF records a newly rendered PDF and returns its version.
+	SetPDF(ctx context.Context, id uuid.UUID, path string, size int64) (int, error)
+	MarkSent(ctx context.Context, id uuid.UUID, to string) error
+}
+
+type invoiceRepo struct {
+	db *DB
+}
+
+// NewInvoiceRepo creates an InvoiceRepository on the main pool.
+func NewInvoiceRepo(db *sql.DB) InvoiceRepository {
+	return &invoiceRepo{db: WrapDB(db)}
+}
+
+const invoiceCols = `id, number, payment_id, COALESCE(stripe_invoice_id, ''), family_id, organization_id,
+       user_id, bill_to_name, bill_to_email, lines, subtotal_cents, discount_cents, total_cents,
+       currency, period_start, period_end, paid_at, issued_at, COALESCE(pdf_path, ''),
+       COALESCE(pdf_size, 0), pdf_version, pdf_generated_at, sent_count, last_sent_at,
+       COALESCE(last_sent_to, ''), tax_cents, tax_inclusive`
+
+func scanInvoice(row interface{ Scan(...any) error }) (*Invoice, error) {
+	var inv Invoice
+	var lines []byte
+	err := row.Scan(&inv.ID, &inv.Number, &inv.PaymentID, &inv.StripeInvoiceID, &inv.FamilyID,
+		&inv.OrganizationID, &inv.UserID, &inv.BillToName, &inv.BillToEmail, &lines,
+		&inv.SubtotalCents, &inv.DiscountCents, &inv.TotalCents, &inv.Currency,
+		&inv.PeriodStart, &inv.PeriodEnd, &inv.PaidAt, &inv.IssuedAt, &inv.PDFPath,
+		&inv.PDFSize, &inv.PDFVersion, &inv.PDFGeneratedAt, &inv.SentCount, &inv.LastSentAt,
+		&inv.LastSentTo, &inv.TaxCents, &inv.TaxInclusive)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(lines, &inv.Lines); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *invoiceRepo) Create(ctx context.Context, inv *Invoice) error {
+	lines, err := json.Marshal(inv.Lines)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx, `
+        INSERT INTO invoices (
+            number, payment_id, stripe_invoice_id, family_id, organization_id, user_id,
+            bill_to_name, bill_to_email, lines, subtotal_cents, discount_cents, total_cents,
+            currency, period_start, period_end, paid_at, tax_cents, tax_inclusive
+        ) VALUES (
+            'MCC-' || to_char(NOW(), 'YYYY') || '-' || lpad(nextval('invoice_number_seq')::text, 6, '0'),
+            $1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+        )
+        RETURNING id, number, issued_at`,
+		inv.PaymentID, inv.StripeInvoiceID, inv.FamilyID, inv.OrganizationID, inv.UserID,
+		inv.BillToName, inv.BillToEmail, lines, inv.SubtotalCents, inv.DiscountCents, inv.TotalCents,
+		inv.Currency, inv.PeriodStart, inv.PeriodEnd, inv.PaidAt, inv.TaxCents, inv.TaxInclusive,
+	).Scan(&inv.ID, &inv.Number, &inv.IssuedAt)
+	if isUniqueViolation(err) {
+		return ErrInvoiceExists
+	}
+	return err
+}
+
+func (r *invoiceRepo) getWhere(ctx context.Context, cond string, arg any) (*Invoice, error) {
+	inv, err := scanInvoice(r.db.QueryRowContext(ctx, `SELECT `+invoiceCols+` FROM invoices WHERE `+cond, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return inv, err
+}
+
+func (r *invoiceRepo) Get(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	return r.getWhere(ctx, "id = $1", id)
+}
+
+func (r *invoiceRepo) GetByPayment(ctx context.Context, paymentID uuid.UUID) (*Invoice, error) {
+	return r.getWhere(ctx, "payment_id = $1", paymentID)
+}
+
+func (r *invoiceRepo) GetByStripeInvoice(ctx context.Context, stripeInvoiceID string) (*Invoice, error) {
+	return r.getWhere(ctx, "stripe_invoice_id = $1", stripeInvoiceID)
+}
+
+func (r *invoiceRepo) queryInvoices(ctx context.Context, query string, args ...any) ([]Invoice, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Invoice{}
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *inv)
+	}
+	return out, rows.Err()
+}
+
+func (r *invoiceRepo) ListForFamily(ctx context.Context, familyID uuid.UUID) ([]Invoice, error) {
+	return r.queryInvoices(ctx, `
+        SELECT `+invoiceCols+` FROM invoices
+        WHERE family_id = $1 AND organization_id IS NULL
+        ORDER BY paid_at DESC, number DESC`, familyID)
+}
+
+func (r *invoiceRepo) ListForOrganization(ctx context.Context, orgID uuid.UUID) ([]Invoice, error) {
+	return r.queryInvoices(ctx, `
+        SELECT `+invoiceCols+` FROM invoices
+        WHERE organization_id = $1
+        ORDER BY paid_at DESC, number DESC`, orgID)
+}
+
+func (r *invoiceRepo) List(ctx context.Context, f InvoiceFilter) ([]Invoice, int, error) {
+	const where = `
+        WHERE $1 = ''
+           OR number ILIKE '%' || $1 || '%'
+           OR bill_to_name ILIKE '%' || $1 || '%'
+           OR bill_to_email ILIKE '%' || $1 || '%'`
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM invoices`+where, f.Query).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	invs, err := r.queryInvoices(ctx, `SELECT `+invoiceCols+` FROM invoices`+where+`
+        ORDER BY issued_at DESC, number DESC
+        LIMIT $2 OFFSET $3`, f.Query, f.Limit, f.Offset)
+	return invs, total, err
+}
+
+func (r *invoiceRepo) PaymentSource(ctx context.Context, paymentID uuid.UUID) (*InvoicePaymentSource, error) {
+	var p InvoicePaymentSource
+	// Payments made before a family subscription existed have no
+	// subscription_id; fall back to the payer's oldest family.
+	err := r.db.QueryRowContext(ctx, `
+        SELECT p.id, p.user_id,
+               COALESCE(fs.family_id, (
+                   SELECT fm.family_id FROM family_memberships fm
+                   WHERE fm.user_id = p.user_id AND fm.is_active
+                   ORDER BY fm.created_at LIMIT 1)),
+               p.status::text, p.amount_cents, COALESCE(p.discount_amount_cents, 0),
+               p.tax_amount_cents, p.tax_inclusive,
+               COALESCE(p.currency, 'USD'), COALESCE(p.description, ''),
+               COALESCE(p.stripe_invoice_id, ''), p.created_at,
+               TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')),
+               COALESCE(u.email, ''), COALESCE(sp.name, ''), COALESCE(sp.billing_interval::text, '')
+        FROM payments p
+        LEFT JOIN app_users u ON u.id = p.user_id
+        LEFT JOIN family_subscriptions fs ON fs.id = p.subscription_id
+        LEFT JOIN subscription_plans sp ON sp.id = fs.plan_id
+        WHERE p.id = $1`, paymentID,
+	).Scan(&p.PaymentID, &p.UserID, &p.FamilyID, &p.Status, &p.AmountCents, &p.DiscountCents,
+		&p.TaxCents, &p.TaxInclusive,
+		&p.Currency, &p.Description, &p.StripeInvoiceID, &p.PaidAt,
+		&p.PayerName, &p.PayerEmail, &p.PlanName, &p.BillingInterval)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *invoiceRepo) PaymentForStripeInvoice(ctx context.Context, stripeInvoiceID string) (*uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id FROM payments WHERE stripe_invoice_id = $1
+        ORDER BY created_at LIMIT 1`, stripeInvoiceID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *invoiceRepo) UninvoicedPayments(ctx context.Context, familyID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT p.id
+        FROM payments p
+        JOIN family_subscriptions fs ON fs.id = p.subscription_id
+        WHERE fs.family_id = $1
+          AND p.status IN ('succeeded', 'refunded', 'partially_refunded')
+          AND NOT EXISTS (SELECT 1 FROM invoices i WHERE i.payment_id = p.id)
+        ORDER BY p.created_at`, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *invoiceRepo) CurrentBillTo(ctx context.Context, userID, orgID *uuid.UUID) (string, string, error) {
+	var name, email string
+	var err error
+	switch {
+	case orgID != nil:
+		err = r.db.QueryRowContext(ctx, `
+            SELECT name, COALESCE(contact_email, '') FROM organizations WHERE id = $1`, *orgID,
+		).Scan(&name, &email)
+	case userID != nil:
+		err = r.db.QueryRowContext(ctx, `
+            SELECT TRIM(COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')), email
+            FROM app_users WHERE id = $1`, *userID,
+		).Scan(&name, &email)
+	default:
+		return "", "", nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	return name, email, err
+}
+
+func (r *invoiceRepo) SetBillTo(ctx context.Context, id uuid.UUID, name, email string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE invoices SET bill_to_name = $2, bill_to_email = $3 WHERE id = $1`, id, name, email)
+	return err
+}
+
+func (r *invoiceRepo) SetPDF(ctx context.Context, id uuid.UUID, path string, size int64) (int, error) {
+	var version int
+	err := r.db.QueryRowContext(ctx, `
+        UPDATE invoices
+        SET pdf_path = $2, pdf_size = $3, pdf_version = pdf_version + 1, pdf_generated_at = NOW()
+        WHERE id = $1
+        RETURNING pdf_version`, id, path, size,
+	).Scan(&version)
+	return version, err
+}
+
+func (r *invoiceRepo) MarkSent(ctx context.Context, id uuid.UUID, to string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE invoices
+        SET sent_count = sent_count + 1, last_sent_at = NOW(), last_sent_to = $2
+        WHERE id = $1`, id, to)
+	return err
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// KBCategory groups help center articles.
+type KBCategory struct {
+	ID          uuid.UUID `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	SortOrder   int       `json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Joined — published articles only on public reads, all on admin reads.
+	ArticleCount int `json:"article_count"`
+}
+
+// KBArticle is one help center article. BodyMD is the source of truth;
+// BodyHTML is rendered by the service on read and never stored.
+type KBArticle struct {
+	ID          uuid.UUID       `json:"id"`
+	Slug        string          `json:"slug"`
+	Title       string          `json:"title"`
+	Summary     string          `json:"summary"`
+	BodyMD      string          `json:"body_md,omitempty"`
+	CategoryID  models.NullUUID `json:"category_id,omitempty"`
+	Status      string          `json:"status"`
+	Keywords    []string        `json:"keywords"`
+	ViewCount   int64           `json:"view_count"`
+	PublishedAt models.NullTime `json:"published_at,omitempty"`
+	CreatedBy   models.NullUUID `json:"created_by,omitempty"`
+	UpdatedBy   models.NullUUID `json:"updated_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// Joined / computed (not in DB).
+	CategorySlug string  `json:"category_slug,omitempty"`
+	CategoryName string  `json:"category_name,omitempty"`
+	BodyHTML     string  `json:"body_html,omitempty"`
+	Rank         float64 `json:"rank,omitempty"`
+}
+
+// KBArticleFilter narrows ListArticles. Zero values mean "any".
+type KBArticleFilter struct {
+	Status       string
+	CategoryID   uuid.UUID
+	CategorySlug string
+	// Query is a websearch-style full-text query ("reset password -email").
+	// When set, results are ordered by relevance instead of recency.
+	Query string
+}
+
+// KnowledgeBaseRepository persists kb_categories and kb_articles.
+type KnowledgeBaseRepository interface {
+	ListCategories(ctx context.Context, publishedOnly bool) ([]KBCategory, error)
+	// CategoriesChangeStamp covers ListCategories, article counts included.
+	CategoriesChangeStamp(ctx context.Context) (models.ChangeStamp, error)
+	GetCategory(ctx context.Context, id uuid.UUID) (*KBCategory, error)
+	CreateCategory(ctx context.Context, c *KBCategory) error
+	UpdateCategory(ctx context.Context, c *KBCategory) error
+	DeleteCategory(ctx context.Context, id uuid.UUID) error
+
+	// ListArticles omits BodyMD to keep list payloads small.
+	ListArticles(ctx context.Context, f KBArticleFilter, limit, offset int) ([]KBArticle, int, error)
+	GetArticle(ctx context.Context, id uuid.UUID) (*KBArticle, error)
+	GetArticleBySlug(ctx context.Context, slug string) (*KBArticle, error)
+	CreateArticle(ctx context.Context, a *KBArticle) error
+	UpdateArticle(ctx context.Context, a *KBArticle) error
+	DeleteArticle(ctx context.Context, id uuid.UUID) error
+	IncrementViews(ctx context.Context, id uuid.UUID) error
+
+	// SuggestArticles ranks published articles against a set of
+	// already-normalized terms (OR semantics), boosting keyword hits.
+	SuggestArticles(ctx context.Context, terms []string, limit int) ([]KBArticle, error)
+}
+
+type knowledgeBaseRepo struct {
+	db *DB
+}
+
+// NewKnowledgeBaseRepo creates a KnowledgeBaseRepository.
+func NewKnowledgeBaseRepo(db *sql.DB) KnowledgeBaseRepository {
+	return &knowledgeBaseRepo{db: WrapDB(db)}
+}
+
+// ----------------------------------------------------------------------------
+// categories
+// ----------------------------------------------------------------------------
+
+func (r *knowledgeBaseRepo) ListCategories(ctx context.Context, publishedOnly bool) ([]KBCategory, error) {
+	countFilter := ""
+	if publishedOnly {
+		countFilter = " AND a.status = 'published'"
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT c.id, c.slug, c.name, c.description, c.sort_order, c.created_at, c.updated_at,
+               (SELECT COUNT(*) FROM kb_articles a WHERE a.category_id = c.id`+countFilter+`)
+        FROM kb_categories c
+        ORDER BY c.sort_order, c.name
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []KBCategory
+	for rows.Next() {
+		var c KBCategory
+		if err := rows.Scan(&c.ID, &c.Slug, &c.Name, &c.Description, &c.SortOrder,
+			&c.CreatedAt, &c.UpdatedAt, &c.ArticleCount); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *knowledgeBaseRepo) CategoriesChangeStamp(ctx context.Context) (models.ChangeStamp, error) {
+	var st models.ChangeStamp
+	var last sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+        SELECT (SELECT COUNT(*) FROM kb_categories) + (SELECT COUNT(*) FROM kb_articles),
+               GREATEST((SELECT MAX(updated_at) FROM kb_categories), (SELECT MAX(updated_at) FROM kb_articles))
+    `).Scan(&st.Count, &last)
+	st.LastModified = last.Time
+	return st, err
+}
+
+func (r *knowledgeBaseRepo) GetCategory(ctx context.Context, id uuid.UUID) (*KBCategory, error) {
+	var c KBCategory
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, slug, name, description, sort_order, created_at, updated_at
+        FROM kb_categories WHERE id = $1
+    `, id).Scan(&c.ID, &c.Slug, &c.Name, &c.Description, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *knowledgeBaseRepo) CreateCategory(ctx context.Context, c *KBCategory) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO kb_categories (id, slug, name, description, sort_order)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING created_at, updated_at
+    `, c.ID, c.Slug, c.Name, c.Description, c.SortOrder).Scan(&c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *knowledgeBaseRepo) UpdateCategory(ctx context.Context, c *KBCategory) error {
+	return r.db.QueryRowContext(ctx, `
+        UPDATE kb_categories
+           SET slug = $2, name = $3, description = $4, sort_order = $5, updated_at = NOW()
+         WHERE id = $1
+        RETURNING updated_at
+    `, c.ID, c.Slug, c.Name, c.Description, c.SortOrder).Scan(&c.UpdatedAt)
+}
+
+func (r *knowledgeBaseRepo) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM kb_categories WHERE id = $1", id)
+	return err
+}
+
+// ----------------------------------------------------------------------------
+// articles
+// ----------------------------------------------------------------------------
+
+// kbArticleCols is the shared projection. %s is the body expression —
+// a.body_md for single reads, an empty string literal for lists. Queries
+// that rank append their own rank column.
+const kbArticleCols = `
+    a.id, a.slug, a.title, a.summary, %s, a.category_id, a.status, a.keywords,
+    a.view_count, a.published_at, a.created_by, a.updated_by, a.created_at, a.updated_at,
+    COALESCE(c.slug, '') AS category_slug,
+    COALESCE(c.name, '') AS category_name
+`
+
+const kbArticleFrom = `
+    FROM kb_articles a
+    LEFT JOIN kb_categories c ON c.id = a.category_id
+`
+
+func scanKBArticle(s rowScannerLike, withRank bool) (*KBArticle, error) {
+	a := &KBArticle{}
+	dest := []interface{}{
+		&a.ID, &a.Slug, &a.Title, &a.Summary, &a.BodyMD, &a.CategoryID, &a.Status, pq.Array(&a.Keywords),
+		&a.ViewCount, &a.PublishedAt, &a.CreatedBy, &a.UpdatedBy, &a.CreatedAt, &a.UpdatedAt,
+		&a.CategorySlug, &a.CategoryName,
+	}
+	if withRank {
+		dest = append(dest, &a.Rank)
+	}
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *knowledgeBaseRepo) ListArticles(ctx context.Context, f KBArticleFilter, limit, offset int) ([]KBArticle, int, error) {
+	var where []string
+	var args []interface{}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		where = append(where, fmt.Sprintf("a.status = $%d", len(args)))
+	}
+	if f.CategoryID != uuid.Nil {
+		args = append(args, f.CategoryID)
+		where = append(where, fmt.Sprintf("a.category_id = $%d", len(args)))
+	}
+	if f.CategorySlug != "" {
+		args = append(args, f.CategorySlug)
+		where = append(where, fmt.Sprintf("c.slug = $%d", len(args)))
+	}
+	rankExpr := "0::float8"
+	order := "COALESCE(a.published_at, a.updated_at) DESC"
+	if q := strings.TrimSpace(f.Query); q != "" {
+		args = append(args, q)
+		n := len(args)
+		where = append(where, fmt.Sprintf("a.search_vector @@ websearch_to_tsquery('english', $%d)", n))
+		rankExpr = fmt.Sprintf("ts_rank(a.search_vector, websearch_to_tsquery('english', $%d))::float8", n)
+		order = "rank DESC, a.view_count DESC"
+	}
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*)"+kbArticleFrom+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	query := "SELECT " + fmt.Sprintf(kbArticleCols, "''") + ", " + rankExpr + " AS rank" +
+		kbArticleFrom + whereSQL +
+		fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", order, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var out []KBArticle
+	for rows.Next() {
+		a, err := scanKBArticle(rows, true)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, *a)
+	}
+	return out, total, rows.Err()
+}
+
+func (r *knowledgeBaseRepo) getArticleWhere(ctx context.Context, cond string, arg interface{}) (*KBArticle, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+fmt.Sprintf(kbArticleCols, "a.body_md")+kbArticleFrom+" WHERE "+cond, arg)
+	a, err := scanKBArticle(row, false)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+func (r *knowledgeBaseRepo) GetArticle(ctx context.Context, id uuid.UUID) (*KBArticle, error) {
+	return r.getArticleWhere(ctx, "a.id = $1", id)
+}
+
+func (r *knowledgeBaseRepo) GetArticleBySlug(ctx context.Context, slug string) (*KBArticle, error) {
+	return r.getArticleWhere(ctx, "a.slug = $1", slug)
+}
+
+func (r *knowledgeBaseRepo) CreateArticle(ctx context.Context, a *KBArticle) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	if a.Keywords == nil {
+		a.Keywords = []string{}
+	}
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO kb_articles
+            (id, slug, title, summary, body_md, category_id, status, keywords,
+             published_at, created_by, updated_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+        RETURNING created_at, updated_at
+    `, a.ID, a.Slug, a.Title, a.Summary, a.BodyMD, a.CategoryID, a.Status, pq.Array(a.Keywords),
+		a.PublishedAt, a.CreatedBy).Scan(&a.CreatedAt, &a.UpdatedAt)
+}
+
+func (r *knowledgeBaseRepo) UpdateArticle(ctx context.Context, a *KBArticle) error {
+	if a.Keywords == nil {
+		a.Keywords = []string{}
+	}
+	return r.db.QueryRowContext(ctx, `
+        UPDATE kb_articles
+           SET slug = $2, title = $3, summary = $4, body_md = $5, category_id = $6,
+               status = $7, keywords = $8, published_at = $9, updated_by = $10,
+               updated_at = NOW()
+         WHERE id = $1
+        RETURNING updated_at
+    `, a.ID, a.Slug, a.Title, a.Summary, a.BodyMD, a.CategoryID, a.Status, pq.Array(a.Keywords),
+		a.PublishedAt, a.UpdatedBy).Scan(&a.UpdatedAt)
+}
+
+func (r *knowledgeBaseRepo) DeleteArticle(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM kb_articles WHERE id = $1", id)
+	return err
+}
+
+func (r *knowledgeBaseRepo) IncrementViews(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE kb_articles SET view_count = view_count + 1 WHERE id = $1", id)
+	return err
+}
+
+func (r *knowledgeBaseRepo) SuggestArticles(ctx context.Context, terms []string, limit int) ([]KBArticle, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	// Terms are pre-filtered to [a-z0-9] by the service, so joining them
+	// into to_tsquery syntax cannot inject operators.
+	tsq := strings.Join(terms, " | ")
+	query := "SELECT " + fmt.Sprintf(kbArticleCols, "''") + `,
+               (ts_rank(a.search_vector, to_tsquery('english', $1))
+                + 0.5 * cardinality(ARRAY(SELECT unnest(a.keywords) INTERSECT SELECT unnest($2::text[]))))::float8 AS rank
+        ` + kbArticleFrom + `
+        WHERE a.status = 'published'
+          AND (a.search_vector @@ to_tsquery('english', $1) OR a.keywords && $2::text[])
+        ORDER BY rank DESC, a.view_count DESC
+        LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, tsq, pq.Array(terms), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []KBArticle
+	for rows.Next() {
+		a, err := scanKBArticle(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
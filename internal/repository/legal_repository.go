@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+)
+
+// ErrLegalVersionTaken is returned when the kind already has the version.
+var ErrLegalVersionTaken = apperr.Conflict("legal document version already exists")
+
+// LegalDocument is one version of the Terms of Service or Privacy Policy.
+// PublishedAt and EffectiveAt are both nil for a draft.
+type LegalDocument struct {
+	ID                 uuid.UUID  `json:"id"`
+	Kind               string     `json:"kind"`
+	Version            string     `json:"version"`
+	Title              string     `json:"title"`
+	Summary            string     `json:"summary"`
+	BodyMD             string     `json:"body_md,omitempty"`
+	RequiresAcceptance bool       `json:"requires_acceptance"`
+	PublishedAt        *time.Time `json:"published_at,omitempty"`
+	EffectiveAt        *time.Time `json:"effective_at,omitempty"`
+	CreatedBy          *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// BodyHTML is rendered by the service on read and never stored.
+	BodyHTML string `json:"body_html,omitempty"`
+}
+
+// LegalAcceptance is one user's acceptance of one document version.
+type LegalAcceptance struct {
+	DocumentID uuid.UUID `json:"document_id"`
+	Kind       string    `json:"kind"`
+	Version    string    `json:"version"`
+	AcceptedAt time.Time `json:"accepted_at"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+// LegalCoverage is how many active app users have accepted one published
+// document version. InEffect marks the version currently in effect for
+// its kind.
+type LegalCoverage struct {
+	DocumentID         uuid.UUID `json:"document_id"`
+	Kind               string    `json:"kind"`
+	Version            string    `json:"version"`
+	Title              string    `json:"title"`
+	RequiresAcceptance bool      `json:"requires_acceptance"`
+	EffectiveAt        time.Time `json:"effective_at"`
+	InEffect           bool      `json:"in_effect"`
+	ActiveUsers        int       `json:"active_users"`
+	Accepted           int       `json:"accepted"`
+}
+
+// LegalAcceptanceRow is one line of a document's acceptance export.
+type LegalAcceptanceRow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Email      string    `json:"email"`
+	AcceptedAt time.Time `json:"accepted_at"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+// LegalRepository owns legal_documents and legal_acceptances.
+type LegalRepository interface {
+	// ListDocuments returns every version of kind ("" for all), newest
+	// first. BodyMD is left empty.
+	ListDocuments(ctx context.Context, kind string) ([]LegalDocument, error)
+	// GetDocument returns nil, nil when id doesn't exist.
+	GetDocument(ctx context.Context, id uuid.UUID) (*LegalDocument, error)
+	CreateDocument(ctx context.Context, d *LegalDocument) error
+	// UpdateDraft saves d's text fields if it's still a draft; false means
+	// it doesn't exist or was published.
+	UpdateDraft(ctx context.Context, d *LegalDocument) (bool, error)
+	DeleteDraft(ctx context.Context, id uuid.UUID) (bool, error)
+	// Publish schedules a draft to take effect at effectiveAt; false means
+	// it doesn't exist or was already published.
+	Publish(ctx context.Context, id uuid.UUID, effectiveAt time.Time) (bool, error)
+	// InEffect returns, per kind, the latest published version whose
+	// effective date has passed.
+	InEffect(ctx context.Context, now time.Time) ([]LegalDocument, error)
+	// Upcoming returns published versions not yet in effect.
+	Upcoming(ctx context.Context, now time.Time) ([]LegalDocument, error)
+	// Pending returns the in-effect versions requiring acceptance that
+	// userID hasn't accepted. BodyMD is left empty.
+	Pending(ctx context.Context, userID uuid.UUID, now time.Time) ([]LegalDocument, error)
+	// Accept records userID's acceptance of each document; repeats are
+	// ignored.
+	Accept(ctx context.Context, userID uuid.UUID, documentIDs []uuid.UUID, ip, userAgent string) error
+	AcceptancesForUser(ctx context.Context, userID uuid.UUID) ([]LegalAcceptance, error)
+	Coverage(ctx context.Context, now time.Time) ([]LegalCoverage, error)
+	ListAcceptances(ctx context.Context, documentID uuid.UUID) ([]LegalAcceptanceRow, error)
+}
+
+type legalRepo struct {
+	db *DB
+}
+
+// NewLegalRepo creates a LegalRepository on the main pool.
+func NewLegalRepo(db *sql.DB) LegalRepository {
+	return &legalRepo{db: WrapDB(db)}
+}
+
+const legalDocumentColumns = `id, kind, version, title, summary, requires_acceptance,
+       published_at, effective_at, created_by, created_at, updated_at`
+
+func scanLegalDocument(row interface{ Scan(...any) error }, d *LegalDocument) error {
+	return row.Scan(&d.ID, &d.Kind, &d.Version, &d.Title, &d.Summary, &d.RequiresAcceptance,
+		&d.PublishedAt, &d.EffectiveAt, &d.CreatedBy, &d.CreatedAt, &d.UpdatedAt)
+}
+
+func (r *legalRepo) queryDocuments(ctx context.Context, query string, args ...any) ([]LegalDocument, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	docs := []LegalDocument{}
+	for rows.Next() {
+		var d LegalDocument
+		if err := scanLegalDocument(rows, &d); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+func (r *legalRepo) ListDocuments(ctx context.Context, kind string) ([]LegalDocument, error) {
+	return r.queryDocuments(ctx, `
+        SELECT `+legalDocumentColumns+`
+        FROM legal_documents
+        WHERE $1 = '' OR kind = $1
+        ORDER BY kind, COALESCE(effective_at, 'infinity'::timestamptz) DESC, created_at DESC`, kind)
+}
+
+func (r *legalRepo) GetDocument(ctx context.Context, id uuid.UUID) (*LegalDocument, error) {
+	var d LegalDocument
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, kind, version, title, summary, requires_acceptance,
+               published_at, effective_at, created_by, created_at, updated_at, body_md
+        FROM legal_documents WHERE id = $1`, id,
+	).Scan(&d.ID, &d.Kind, &d.Version, &d.Title, &d.Summary, &d.RequiresAcceptance,
+		&d.PublishedAt, &d.EffectiveAt, &d.CreatedBy, &d.CreatedAt, &d.UpdatedAt, &d.BodyMD)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *legalRepo) CreateDocument(ctx context.Context, d *LegalDocument) error {
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO legal_documents (kind, version, title, summary, body_md, requires_acceptance, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at`,
+		d.Kind, d.Version, d.Title, d.Summary, d.BodyMD, d.RequiresAcceptance, d.CreatedBy,
+	).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrLegalVersionTaken
+	}
+	return err
+}
+
+func (r *legalRepo) UpdateDraft(ctx context.Context, d *LegalDocument) (bool, error) {
+	err := r.db.QueryRowContext(ctx, `
+        UPDATE legal_documents
+        SET version = $2, title = $3, summary = $4, body_md = $5, requires_acceptance = $6, updated_at = NOW()
+        WHERE id = $1 AND published_at IS NULL
+        RETURNING updated_at`,
+		d.ID, d.Version, d.Title, d.Summary, d.BodyMD, d.RequiresAcceptance,
+	).Scan(&d.UpdatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	case isUniqueViolation(err):
+		return false, ErrLegalVersionTaken
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *legalRepo) DeleteDraft(ctx context.Context, id uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM legal_documents WHERE id = $1 AND published_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *legalRepo) Publish(ctx context.Context, id uuid.UUID, effectiveAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE legal_documents
+        SET published_at = NOW(), effective_at = $2, updated_at = NOW()
+        WHERE id = $1 AND published_at IS NULL`, id, effectiveAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// inEffectCTE selects the version in effect per kind as of $1.
+const inEffectCTE = `
+        WITH in_effect AS (
+            SELECT DISTINCT ON (kind) *
+            FROM legal_documents
+            WHERE published_at IS NOT NULL AND effective_at <= $1
+            ORDER BY kind, effective_at DESC, published_at DESC
+        )`
+
+func (r *legalRepo) InEffect(ctx context.Context, now time.Time) ([]LegalDocument, error) {
+	rows, err := r.db.QueryContext(ctx, inEffectCTE+`
+        SELECT `+legalDocumentColumns+`, body_md FROM in_effect ORDER BY kind`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	docs := []LegalDocument{}
+	for rows.Next() {
+		var d LegalDocument
+		if err := rows.Scan(&d.ID, &d.Kind, &d.Version, &d.Title, &d.Summary, &d.RequiresAcceptance,
+			&d.PublishedAt, &d.EffectiveAt, &d.CreatedBy, &d.CreatedAt, &d.UpdatedAt, &d.BodyMD); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+func (r *legalRepo) Upcoming(ctx context.Context, now time.Time) ([]LegalDocument, error) {
+	return r.queryDocuments(ctx, `
+        SELECT `+legalDocumentColumns+`
+        FROM legal_documents
+        WHERE published_at IS NOT NULL AND effective_at > $1
+        ORDER BY effective_at`, now)
+}
+
+func (r *legalRepo) Pending(ctx context.Context, userID uuid.UUID, now time.Time) ([]LegalDocument, error) {
+	return r.queryDocuments(ctx, inEffectCTE+`
+        SELECT `+legalDocumentColumns+`
+        FROM in_effect d
+        WHERE d.requires_acceptance
+          AND NOT EXISTS (SELECT 1 FROM legal_acceptances a WHERE a.document_id = d.id AND a.user_id = $2)
+        ORDER BY d.kind`, now, userID)
+}
+
+func (r *legalRepo) Accept(ctx context.Context, userID uuid.UUID, documentIDs []uuid.UUID, ip, userAgent string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range documentIDs {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO legal_acceptances (user_id, document_id, ip_address, user_agent)
+            VALUES ($1, $2, NULLIF($3, '')::inet, NULLIF($4, ''))
+            ON CONFLICT (user_id, document_id) DO NOTHING`,
+			userID, id, ip, userAgent); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *legalRepo) AcceptancesForUser(ctx context.Context, userID uuid.UUID) ([]LegalAcceptance, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT d.id, d.kind, d.version, a.accepted_at,
+               COALESCE(host(a.ip_address), ''), COALESCE(a.user_agent, '')
+        FROM legal_acceptances a
+        JOIN legal_documents d ON d.id = a.document_id
+        WHERE a.user_id = $1
+        ORDER BY a.accepted_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []LegalAcceptance{}
+	for rows.Next() {
+		var a LegalAcceptance
+		if err := rows.Scan(&a.DocumentID, &a.Kind, &a.Version, &a.AcceptedAt, &a.IPAddress, &a.UserAgent); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *legalRepo) Coverage(ctx context.Context, now time.Time) ([]LegalCoverage, error) {
+	rows, err := r.db.QueryContext(ctx, inEffectCTE+`,
+        active AS (
+            SELECT id FROM app_users WHERE deleted_at IS NULL AND status = 'active'
+        )
+        SELECT d.id, d.kind, d.version, d.title, d.requires_acceptance, d.effective_at,
+               d.id IN (SELECT id FROM in_effect),
+               (SELECT COUNT(*) FROM active),
+               (SELECT COUNT(*) FROM legal_acceptances a JOIN active u ON u.id = a.user_id
+                WHERE a.document_id = d.id)
+        FROM legal_documents d
+        WHERE d.published_at IS NOT NULL
+        ORDER BY d.kind, d.effective_at DESC`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []LegalCoverage{}
+	for rows.Next() {
+		var c LegalCoverage
+		if err := rows.Scan(&c.DocumentID, &c.Kind, &c.Version, &c.Title, &c.RequiresAcceptance, &c.EffectiveAt,
+			&c.InEffect, &c.ActiveUsers, &c.Accepted); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *legalRepo) ListAcceptances(ctx context.Context, documentID uuid.UUID) ([]LegalAcceptanceRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT a.user_id, u.email, a.accepted_at,
+               COALESCE(host(a.ip_address), ''), COALESCE(a.user_agent, '')
+        FROM legal_acceptances a
+        JOIN app_users u ON u.id = a.user_id
+        WHERE a.document_id = $1
+        ORDER BY a.accepted_at`, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []LegalAcceptanceRow{}
+	for rows.Next() {
+		var a LegalAcceptanceRow
+		if err := rows.Scan(&a.UserID, &a.Email, &a.AcceptedAt, &a.IPAddress, &a.UserAgent); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
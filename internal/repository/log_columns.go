@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// ErrUnknownLogType is returned by ListLogFields for a log type it doesn't
+// list.
+var ErrUnknownLogType = errors.New("unknown log type")
+
+// listLogs is the date-range list query shared by every log type: the
+// child's rows between startDate and endDate inclusive, newest first.
+// fields narrows the SELECT to a sparse fieldset; the other struct fields
+// are left zero.
+func listLogs[T any](ctx context.Context, db *DB, table string, cols Columns[T], childID uuid.UUID, startDate, endDate time.Time, fields ...string) ([]T, error) {
+	cols, err := cols.Project(fields)
+	if err != nil {
+		return nil, err
+	}
+	// Format dates as strings to avoid timezone conversion issues
+	query := `
+		SELECT ` + cols.SelectList() + `
+		FROM ` + table + `
+		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
+		ORDER BY log_date DESC, created_at DESC
+	`
+	rows, err := db.QueryContext(ctx, query, childID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []T
+	for rows.Next() {
+		var log T
+		if err := rows.Scan(cols.Targets(&log)...); err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
+
+// ListLogFields lists one log type ("behavior", "diet", "health_event", ...)
+// selecting only fields. The result is the same typed slice the matching
+// Get*Logs returns, with unselected fields zero.
+func (r *logRepo) ListLogFields(ctx context.Context, logType string, childID uuid.UUID, startDate, endDate time.Time, fields []string) (any, error) {
+	switch logType {
+	case "behavior":
+		return listLogs(ctx, r.db, "behavior_logs", behaviorLogColumns, childID, startDate, endDate, fields...)
+	case "bowel":
+		return listLogs(ctx, r.db, "bowel_logs", bowelLogColumns, childID, startDate, endDate, fields...)
+	case "speech":
+		return listLogs(ctx, r.db, "speech_logs", speechLogColumns, childID, startDate, endDate, fields...)
+	case "diet":
+		return listLogs(ctx, r.db, "diet_logs", dietLogColumns, childID, startDate, endDate, fields...)
+	case "weight":
+		return listLogs(ctx, r.db, "weight_logs", weightLogColumns, childID, startDate, endDate, fields...)
+	case "sleep":
+		return listLogs(ctx, r.db, "sleep_logs", sleepLogColumns, childID, startDate, endDate, fields...)
+	case "sensory":
+		return listLogs(ctx, r.db, "sensory_logs", sensoryLogColumns, childID, startDate, endDate, fields...)
+	case "social":
+		return listLogs(ctx, r.db, "social_logs", socialLogColumns, childID, startDate, endDate, fields...)
+	case "therapy":
+		return listLogs(ctx, r.db, "therapy_logs", therapyLogColumns, childID, startDate, endDate, fields...)
+	case "seizure":
+		return listLogs(ctx, r.db, "seizure_logs", seizureLogColumns, childID, startDate, endDate, fields...)
+	case "health_event":
+		return listLogs(ctx, r.db, "health_event_logs", healthEventLogColumns, childID, startDate, endDate, fields...)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownLogType, logType)
+}
+
+// behaviorLogColumns are the behavior_logs columns, in the order the list queries select them.
+var behaviorLogColumns = Columns[models.BehaviorLog]{
+	{"id", "id", func(l *models.BehaviorLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.BehaviorLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.BehaviorLog) any { return &l.LogDate }},
+	{"log_time", "log_time", func(l *models.BehaviorLog) any { return &l.LogTime }},
+	{"time_scope", "time_scope", func(l *models.BehaviorLog) any { return &l.TimeScope }},
+	{"mood_level", "mood_level", func(l *models.BehaviorLog) any { return &l.MoodLevel }},
+	{"energy_level", "energy_level", func(l *models.BehaviorLog) any { return &l.EnergyLevel }},
+	{"anxiety_level", "anxiety_level", func(l *models.BehaviorLog) any { return &l.AnxietyLevel }},
+	{"interpersonal_behavior", "interpersonal_behavior", func(l *models.BehaviorLog) any { return &l.InterpersonalBehavior }},
+	{"meltdowns", "meltdowns", func(l *models.BehaviorLog) any { return &l.Meltdowns }},
+	{"stimming_episodes", "stimming_episodes", func(l *models.BehaviorLog) any { return &l.StimmingEpisodes }},
+	{"stimming_level", "stimming_level", func(l *models.BehaviorLog) any { return &l.StimmingLevel }},
+	{"aggression_incidents", "aggression_incidents", func(l *models.BehaviorLog) any { return &l.AggressionIncidents }},
+	{"self_injury_incidents", "self_injury_incidents", func(l *models.BehaviorLog) any { return &l.SelfInjuryIncidents }},
+	{"location", "location", func(l *models.BehaviorLog) any { return &l.Location }},
+	{"location_other", "location_other", func(l *models.BehaviorLog) any { return &l.LocationOther }},
+	{"triggers", "triggers", func(l *models.BehaviorLog) any { return &l.Triggers }},
+	{"positive_behaviors", "positive_behaviors", func(l *models.BehaviorLog) any { return &l.PositiveBehaviors }},
+	{"notes", "notes", func(l *models.BehaviorLog) any { return &l.Notes }},
+	{"rubric_version", "rubric_version", func(l *models.BehaviorLog) any { return &l.RubricVersion }},
+	{"logged_by", "logged_by", func(l *models.BehaviorLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.BehaviorLog) any { return &l.CreatedAt }},
+	{"updated_at", "updated_at", func(l *models.BehaviorLog) any { return &l.UpdatedAt }},
+	{"amended_at", "amended_at", func(l *models.BehaviorLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.BehaviorLog) any { return &l.Version }},
+}
+
+// bowelLogColumns are the bowel_logs columns, in the order the list queries select them.
+var bowelLogColumns = Columns[models.BowelLog]{
+	{"id", "id", func(l *models.BowelLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.BowelLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.BowelLog) any { return &l.LogDate }},
+	{"log_time", "log_time", func(l *models.BowelLog) any { return &l.LogTime }},
+	{"time_scope", "time_scope", func(l *models.BowelLog) any { return &l.TimeScope }},
+	{"bristol_scale", "bristol_scale", func(l *models.BowelLog) any { return &l.BristolScale }},
+	{"had_accident", "had_accident", func(l *models.BowelLog) any { return &l.HadAccident }},
+	{"pain_level", "pain_level", func(l *models.BowelLog) any { return &l.PainLevel }},
+	{"blood_present", "blood_present", func(l *models.BowelLog) any { return &l.BloodPresent }},
+	{"notes", "notes", func(l *models.BowelLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.BowelLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.BowelLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.BowelLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.BowelLog) any { return &l.Version }},
+}
+
+// speechLogColumns are the speech_logs columns, in the order the list queries select them.
+var speechLogColumns = Columns[models.SpeechLog]{
+	{"id", "id", func(l *models.SpeechLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.SpeechLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.SpeechLog) any { return &l.LogDate }},
+	{"time_scope", "time_scope", func(l *models.SpeechLog) any { return &l.TimeScope }},
+	{"verbal_output_level", "verbal_output_level", func(l *models.SpeechLog) any { return &l.VerbalOutputLevel }},
+	{"clarity_level", "clarity_level", func(l *models.SpeechLog) any { return &l.ClarityLevel }},
+	{"new_words", "new_words", func(l *models.SpeechLog) any { return &l.NewWords }},
+	{"lost_words", "lost_words", func(l *models.SpeechLog) any { return &l.LostWords }},
+	{"echolalia_level", "echolalia_level", func(l *models.SpeechLog) any { return &l.EcholaliaLevel }},
+	{"communication_attempts", "communication_attempts", func(l *models.SpeechLog) any { return &l.CommunicationAttempts }},
+	{"successful_communications", "successful_communications", func(l *models.SpeechLog) any { return &l.SuccessfulCommunications }},
+	{"notes", "notes", func(l *models.SpeechLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.SpeechLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.SpeechLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.SpeechLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.SpeechLog) any { return &l.Version }},
+}
+
+// dietLogColumns are the diet_logs columns, in the order the list queries select them.
+var dietLogColumns = Columns[models.DietLog]{
+	{"id", "id", func(l *models.DietLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.DietLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.DietLog) any { return &l.LogDate }},
+	{"time_scope", "time_scope", func(l *models.DietLog) any { return &l.TimeScope }},
+	{"meal_type", "meal_type", func(l *models.DietLog) any { return &l.MealType }},
+	{"meal_time", "meal_time", func(l *models.DietLog) any { return &l.MealTime }},
+	{"foods_eaten", "foods_eaten", func(l *models.DietLog) any { return &l.FoodsEaten }},
+	{"foods_refused", "foods_refused", func(l *models.DietLog) any { return &l.FoodsRefused }},
+	{"appetite_level", "appetite_level", func(l *models.DietLog) any { return &l.AppetiteLevel }},
+	{"water_intake_oz", "water_intake_oz", func(l *models.DietLog) any { return &l.WaterIntakeOz }},
+	{"supplements_taken", "supplements_taken", func(l *models.DietLog) any { return &l.SupplementsTaken }},
+	{"new_food_tried", "new_food_tried", func(l *models.DietLog) any { return &l.NewFoodTried }},
+	{"new_food_acceptance", "new_food_acceptance", func(l *models.DietLog) any { return &l.NewFoodAcceptance }},
+	{"allergic_reaction", "allergic_reaction", func(l *models.DietLog) any { return &l.AllergicReaction }},
+	{"reaction_details", "reaction_details", func(l *models.DietLog) any { return &l.ReactionDetails }},
+	{"notes", "notes", func(l *models.DietLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.DietLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.DietLog) any { return &l.CreatedAt }},
+	{"restriction_matches", "restriction_matches", func(l *models.DietLog) any { return &l.RestrictionMatches }},
+	{"photo_recognition_id", "photo_recognition_id", func(l *models.DietLog) any { return &l.PhotoRecognitionID }},
+	{"food_provenance", "food_provenance", func(l *models.DietLog) any { return &l.FoodProvenance }},
+	{"amended_at", "amended_at", func(l *models.DietLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.DietLog) any { return &l.Version }},
+}
+
+// weightLogColumns are the weight_logs columns, in the order the list queries select them.
+var weightLogColumns = Columns[models.WeightLog]{
+	{"id", "id", func(l *models.WeightLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.WeightLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.WeightLog) any { return &l.LogDate }},
+	{"time_scope", "time_scope", func(l *models.WeightLog) any { return &l.TimeScope }},
+	{"weight_lbs", "weight_lbs", func(l *models.WeightLog) any { return &l.WeightLbs }},
+	{"height_inches", "height_inches", func(l *models.WeightLog) any { return &l.HeightInches }},
+	{"notes", "notes", func(l *models.WeightLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.WeightLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.WeightLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.WeightLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.WeightLog) any { return &l.Version }},
+}
+
+// sleepLogColumns are the sleep_logs columns, in the order the list queries select them.
+var sleepLogColumns = Columns[models.SleepLog]{
+	{"id", "id", func(l *models.SleepLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.SleepLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.SleepLog) any { return &l.LogDate }},
+	{"time_scope", "time_scope", func(l *models.SleepLog) any { return &l.TimeScope }},
+	{"bedtime", "bedtime", func(l *models.SleepLog) any { return &l.Bedtime }},
+	{"wake_time", "wake_time", func(l *models.SleepLog) any { return &l.WakeTime }},
+	{"total_sleep_minutes", "total_sleep_minutes", func(l *models.SleepLog) any { return &l.TotalSleepMinutes }},
+	{"night_wakings", "night_wakings", func(l *models.SleepLog) any { return &l.NightWakings }},
+	{"sleep_quality", "sleep_quality", func(l *models.SleepLog) any { return &l.SleepQuality }},
+	{"took_sleep_aid", "took_sleep_aid", func(l *models.SleepLog) any { return &l.TookSleepAid }},
+	{"sleep_aid_name", "sleep_aid_name", func(l *models.SleepLog) any { return &l.SleepAidName }},
+	{"nightmares", "nightmares", func(l *models.SleepLog) any { return &l.Nightmares }},
+	{"bed_wetting", "bed_wetting", func(l *models.SleepLog) any { return &l.BedWetting }},
+	{"notes", "notes", func(l *models.SleepLog) any { return &l.Notes }},
+	{"source", "source", func(l *models.SleepLog) any { return &l.Source }},
+	{"reconciliation_status", "reconciliation_status", func(l *models.SleepLog) any { return &l.ReconciliationStatus }},
+	{"reconciliation_note", "reconciliation_note", func(l *models.SleepLog) any { return &l.ReconciliationNote }},
+	{"logged_by", "logged_by", func(l *models.SleepLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.SleepLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.SleepLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.SleepLog) any { return &l.Version }},
+}
+
+// sensoryLogColumns are the sensory_logs columns, in the order the list queries select them.
+var sensoryLogColumns = Columns[models.SensoryLog]{
+	{"id", "id", func(l *models.SensoryLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.SensoryLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.SensoryLog) any { return &l.LogDate }},
+	{"log_time", "log_time", func(l *models.SensoryLog) any { return &l.LogTime }},
+	{"time_scope", "time_scope", func(l *models.SensoryLog) any { return &l.TimeScope }},
+	{"sensory_seeking_behaviors", "sensory_seeking_behaviors", func(l *models.SensoryLog) any { return &l.SensorySeekingBehaviors }},
+	{"sensory_avoiding_behaviors", "sensory_avoiding_behaviors", func(l *models.SensoryLog) any { return &l.SensoryAvoidingBehaviors }},
+	{"overload_triggers", "overload_triggers", func(l *models.SensoryLog) any { return &l.OverloadTriggers }},
+	{"calming_strategies_used", "calming_strategies_used", func(l *models.SensoryLog) any { return &l.CalmingStrategiesUsed }},
+	{"overload_episodes", "overload_episodes", func(l *models.SensoryLog) any { return &l.OverloadEpisodes }},
+	{"overall_regulation", "overall_regulation", func(l *models.SensoryLog) any { return &l.OverallRegulation }},
+	{"notes", "notes", func(l *models.SensoryLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.SensoryLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.SensoryLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.SensoryLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.SensoryLog) any { return &l.Version }},
+}
+
+// socialLogColumns are the social_logs columns, in the order the list queries select them.
+var socialLogColumns = Columns[models.SocialLog]{
+	{"id", "id", func(l *models.SocialLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.SocialLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.SocialLog) any { return &l.LogDate }},
+	{"time_scope", "time_scope", func(l *models.SocialLog) any { return &l.TimeScope }},
+	{"eye_contact_level", "eye_contact_level", func(l *models.SocialLog) any { return &l.EyeContactLevel }},
+	{"social_engagement_level", "social_engagement_level", func(l *models.SocialLog) any { return &l.SocialEngagementLevel }},
+	{"peer_interactions", "peer_interactions", func(l *models.SocialLog) any { return &l.PeerInteractions }},
+	{"positive_interactions", "positive_interactions", func(l *models.SocialLog) any { return &l.PositiveInteractions }},
+	{"conflicts", "conflicts", func(l *models.SocialLog) any { return &l.Conflicts }},
+	{"parallel_play_minutes", "parallel_play_minutes", func(l *models.SocialLog) any { return &l.ParallelPlayMinutes }},
+	{"cooperative_play_minutes", "cooperative_play_minutes", func(l *models.SocialLog) any { return &l.CooperativePlayMinutes }},
+	{"notes", "notes", func(l *models.SocialLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.SocialLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.SocialLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.SocialLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.SocialLog) any { return &l.Version }},
+}
+
+// therapyLogColumns are the therapy_logs columns, in the order the list queries select them.
+var therapyLogColumns = Columns[models.TherapyLog]{
+	{"id", "id", func(l *models.TherapyLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.TherapyLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.TherapyLog) any { return &l.LogDate }},
+	{"time_scope", "time_scope", func(l *models.TherapyLog) any { return &l.TimeScope }},
+	{"therapy_type", "therapy_type", func(l *models.TherapyLog) any { return &l.TherapyType }},
+	{"therapist_name", "therapist_name", func(l *models.TherapyLog) any { return &l.TherapistName }},
+	{"duration_minutes", "duration_minutes", func(l *models.TherapyLog) any { return &l.DurationMinutes }},
+	{"goals_worked_on", "goals_worked_on", func(l *models.TherapyLog) any { return &l.GoalsWorkedOn }},
+	{"progress_notes", "progress_notes", func(l *models.TherapyLog) any { return &l.ProgressNotes }},
+	{"homework_assigned", "homework_assigned", func(l *models.TherapyLog) any { return &l.HomeworkAssigned }},
+	{"parent_notes", "parent_notes", func(l *models.TherapyLog) any { return &l.ParentNotes }},
+	{"logged_by", "logged_by", func(l *models.TherapyLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.TherapyLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.TherapyLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.TherapyLog) any { return &l.Version }},
+}
+
+// seizureLogColumns are the seizure_logs columns, in the order the list queries select them.
+var seizureLogColumns = Columns[models.SeizureLog]{
+	{"id", "id", func(l *models.SeizureLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.SeizureLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.SeizureLog) any { return &l.LogDate }},
+	{"log_time", "log_time", func(l *models.SeizureLog) any { return &l.LogTime }},
+	{"time_scope", "time_scope", func(l *models.SeizureLog) any { return &l.TimeScope }},
+	{"seizure_type", "seizure_type", func(l *models.SeizureLog) any { return &l.SeizureType }},
+	{"duration_seconds", "duration_seconds", func(l *models.SeizureLog) any { return &l.DurationSeconds }},
+	{"triggers", "triggers", func(l *models.SeizureLog) any { return &l.Triggers }},
+	{"warning_signs", "warning_signs", func(l *models.SeizureLog) any { return &l.WarningSigns }},
+	{"post_ictal_symptoms", "post_ictal_symptoms", func(l *models.SeizureLog) any { return &l.PostIctalSymptoms }},
+	{"rescue_medication_given", "rescue_medication_given", func(l *models.SeizureLog) any { return &l.RescueMedicationGiven }},
+	{"rescue_medication_name", "rescue_medication_name", func(l *models.SeizureLog) any { return &l.RescueMedicationName }},
+	{"called_911", "called_911", func(l *models.SeizureLog) any { return &l.Called911 }},
+	{"notes", "notes", func(l *models.SeizureLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.SeizureLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.SeizureLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.SeizureLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.SeizureLog) any { return &l.Version }},
+}
+
+// healthEventLogColumns are the health_event_logs columns, in the order the list queries select them.
+var healthEventLogColumns = Columns[models.HealthEventLog]{
+	{"id", "id", func(l *models.HealthEventLog) any { return &l.ID }},
+	{"child_id", "child_id", func(l *models.HealthEventLog) any { return &l.ChildID }},
+	{"log_date", "log_date", func(l *models.HealthEventLog) any { return &l.LogDate }},
+	{"time_scope", "time_scope", func(l *models.HealthEventLog) any { return &l.TimeScope }},
+	{"event_type", "event_type", func(l *models.HealthEventLog) any { return &l.EventType }},
+	{"description", "description", func(l *models.HealthEventLog) any { return &l.Description }},
+	{"symptoms", "symptoms", func(l *models.HealthEventLog) any { return &l.Symptoms }},
+	{"temperature_f", "temperature_f", func(l *models.HealthEventLog) any { return &l.TemperatureF }},
+	{"provider_name", "provider_name", func(l *models.HealthEventLog) any { return &l.ProviderName }},
+	{"diagnosis", "diagnosis", func(l *models.HealthEventLog) any { return &l.Diagnosis }},
+	{"treatment", "treatment", func(l *models.HealthEventLog) any { return &l.Treatment }},
+	{"follow_up_date", "follow_up_date", func(l *models.HealthEventLog) any { return &l.FollowUpDate }},
+	{"notes", "notes", func(l *models.HealthEventLog) any { return &l.Notes }},
+	{"logged_by", "logged_by", func(l *models.HealthEventLog) any { return &l.LoggedBy }},
+	{"created_at", "created_at", func(l *models.HealthEventLog) any { return &l.CreatedAt }},
+	{"amended_at", "amended_at", func(l *models.HealthEventLog) any { return &l.AmendedAt }},
+	{"version", "version", func(l *models.HealthEventLog) any { return &l.Version }},
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// LogDraftRepository stores autosaved log form drafts. Expired drafts are
+// never returned, even before they are swept.
+type LogDraftRepository interface {
+	// Save inserts or replaces the user's draft for the child and log
+	// type; UpdatedAt is filled in.
+	Save(ctx context.Context, d *models.LogDraft) error
+	// Get returns the draft, or nil, nil.
+	Get(ctx context.Context, userID, childID uuid.UUID, logType string) (*models.LogDraft, error)
+	// ListForChild returns the user's drafts for the child, most recently
+	// saved first.
+	ListForChild(ctx context.Context, userID, childID uuid.UUID) ([]models.LogDraft, error)
+	// Delete removes the draft, if there is one.
+	Delete(ctx context.Context, userID, childID uuid.UUID, logType string) error
+	// DeleteExpired removes drafts that expired before now and returns how
+	// many.
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type logDraftRepo struct {
+	db *DB
+}
+
+// NewLogDraftRepo creates a LogDraftRepository on the main pool.
+func NewLogDraftRepo(db *sql.DB) LogDraftRepository {
+	return &logDraftRepo{db: WrapDB(db)}
+}
+
+func (r *logDraftRepo) Save(ctx context.Context, d *models.LogDraft) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO log_drafts (user_id, child_id, log_type, log_id, data, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id, child_id, log_type) DO UPDATE
+        SET log_id = EXCLUDED.log_id, data = EXCLUDED.data, updated_at = NOW(), expires_at = EXCLUDED.expires_at
+        RETURNING updated_at
+    `, d.UserID, d.ChildID, d.LogType, d.LogID, []byte(d.Data), d.ExpiresAt,
+	).Scan(&d.UpdatedAt)
+}
+
+const logDraftColumns = `user_id, child_id, log_type, log_id, data, updated_at, expires_at`
+
+func scanLogDraft(row interface{ Scan(...any) error }, d *models.LogDraft) error {
+	var data []byte
+	if err := row.Scan(&d.UserID, &d.ChildID, &d.LogType, &d.LogID, &data, &d.UpdatedAt, &d.ExpiresAt); err != nil {
+		return err
+	}
+	d.Data = data
+	return nil
+}
+
+func (r *logDraftRepo) Get(ctx context.Context, userID, childID uuid.UUID, logType string) (*models.LogDraft, error) {
+	var d models.LogDraft
+	err := scanLogDraft(r.db.QueryRowContext(ctx, `
+        SELECT `+logDraftColumns+` FROM log_drafts
+        WHERE user_id = $1 AND child_id = $2 AND log_type = $3 AND expires_at > NOW()`,
+		userID, childID, logType), &d)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *logDraftRepo) ListForChild(ctx context.Context, userID, childID uuid.UUID) ([]models.LogDraft, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+logDraftColumns+` FROM log_drafts
+        WHERE user_id = $1 AND child_id = $2 AND expires_at > NOW()
+        ORDER BY updated_at DESC`, userID, childID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.LogDraft
+	for rows.Next() {
+		var d models.LogDraft
+		if err := scanLogDraft(rows, &d); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *logDraftRepo) Delete(ctx context.Context, userID, childID uuid.UUID, logType string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM log_drafts WHERE user_id = $1 AND child_id = $2 AND log_type = $3`,
+		userID, childID, logType)
+	return err
+}
+
+func (r *logDraftRepo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM log_drafts WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
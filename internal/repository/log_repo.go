@@ -3,29 +3,46 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 )
 
 type logRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewLogRepo(db *sql.DB) LogRepository {
-	return &logRepo{db: db}
+	return &logRepo{db: WrapDB(db)}
+}
+
+// ErrLogVersionConflict is returned by the Update*Log methods when the log
+// is no longer at the version being updated: someone else saved it first,
+// or it was deleted.
+var ErrLogVersionConflict = apperr.Conflict("log was changed by someone else")
+
+// versionedUpdate maps an Update*Log's RETURNING version finding no row,
+// because the version didn't match, to ErrLogVersionConflict.
+func versionedUpdate(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLogVersionConflict
+	}
+	return err
 }
 
 // Behavior Logs
 func (r *logRepo) CreateBehaviorLog(ctx context.Context, log *models.BehaviorLog) error {
 	query := `
-		INSERT INTO behavior_logs (id, child_id, log_date, log_time, time_scope, mood_level, energy_level, anxiety_level, interpersonal_behavior, meltdowns, stimming_episodes, stimming_level, aggression_incidents, self_injury_incidents, location, location_other, triggers, positive_behaviors, notes, logged_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		INSERT INTO behavior_logs (id, child_id, log_date, log_time, time_scope, mood_level, energy_level, anxiety_level, interpersonal_behavior, meltdowns, stimming_episodes, stimming_level, aggression_incidents, self_injury_incidents, location, location_other, triggers, positive_behaviors, notes, rubric_version, logged_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 	log.UpdatedAt = time.Now()
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -34,51 +51,19 @@ func (r *logRepo) CreateBehaviorLog(ctx context.Context, log *models.BehaviorLog
 		log.Meltdowns, log.StimmingEpisodes, log.StimmingLevel,
 		log.AggressionIncidents, log.SelfInjuryIncidents,
 		log.Location, log.LocationOther,
-		log.Triggers, log.PositiveBehaviors, log.Notes, log.LoggedBy,
+		log.Triggers, log.PositiveBehaviors, log.Notes, log.RubricVersion, log.LoggedBy,
 		log.CreatedAt, log.UpdatedAt,
 	)
 	return err
 }
 
 func (r *logRepo) GetBehaviorLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.BehaviorLog, error) {
-	// Format dates as strings to avoid timezone conversion issues
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, mood_level, energy_level, anxiety_level, interpersonal_behavior, meltdowns, stimming_episodes, stimming_level, aggression_incidents, self_injury_incidents, location, location_other, triggers, positive_behaviors, notes, logged_by, created_at, updated_at
-		FROM behavior_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.BehaviorLog
-	for rows.Next() {
-		var log models.BehaviorLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.LogTime, &log.TimeScope,
-			&log.MoodLevel, &log.EnergyLevel, &log.AnxietyLevel, &log.InterpersonalBehavior,
-			&log.Meltdowns, &log.StimmingEpisodes, &log.StimmingLevel,
-			&log.AggressionIncidents, &log.SelfInjuryIncidents,
-			&log.Location, &log.LocationOther,
-			&log.Triggers, &log.PositiveBehaviors, &log.Notes, &log.LoggedBy,
-			&log.CreatedAt, &log.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "behavior_logs", behaviorLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetBehaviorLogByID(ctx context.Context, id uuid.UUID) (*models.BehaviorLog, error) {
 	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, mood_level, energy_level, anxiety_level, interpersonal_behavior, meltdowns, stimming_episodes, stimming_level, aggression_incidents, self_injury_incidents, location, location_other, triggers, positive_behaviors, notes, logged_by, created_at, updated_at
+		SELECT id, child_id, log_date, log_time, time_scope, mood_level, energy_level, anxiety_level, interpersonal_behavior, meltdowns, stimming_episodes, stimming_level, aggression_incidents, self_injury_incidents, location, location_other, triggers, positive_behaviors, notes, rubric_version, logged_by, created_at, updated_at, amended_at, version
 		FROM behavior_logs
 		WHERE id = $1
 	`
@@ -89,8 +74,8 @@ func (r *logRepo) GetBehaviorLogByID(ctx context.Context, id uuid.UUID) (*models
 		&log.Meltdowns, &log.StimmingEpisodes, &log.StimmingLevel,
 		&log.AggressionIncidents, &log.SelfInjuryIncidents,
 		&log.Location, &log.LocationOther,
-		&log.Triggers, &log.PositiveBehaviors, &log.Notes, &log.LoggedBy,
-		&log.CreatedAt, &log.UpdatedAt,
+		&log.Triggers, &log.PositiveBehaviors, &log.Notes, &log.RubricVersion, &log.LoggedBy,
+		&log.CreatedAt, &log.UpdatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -101,18 +86,19 @@ func (r *logRepo) GetBehaviorLogByID(ctx context.Context, id uuid.UUID) (*models
 func (r *logRepo) UpdateBehaviorLog(ctx context.Context, log *models.BehaviorLog) error {
 	query := `
 		UPDATE behavior_logs
-		SET log_date = $2, log_time = $3, time_scope = $4, mood_level = $5, energy_level = $6, anxiety_level = $7, interpersonal_behavior = $8, meltdowns = $9, stimming_episodes = $10, stimming_level = $11, aggression_incidents = $12, self_injury_incidents = $13, triggers = $14, positive_behaviors = $15, notes = $16, updated_at = $17
-		WHERE id = $1
+		SET log_date = $2, log_time = $3, time_scope = $4, mood_level = $5, energy_level = $6, anxiety_level = $7, interpersonal_behavior = $8, meltdowns = $9, stimming_episodes = $10, stimming_level = $11, aggression_incidents = $12, self_injury_incidents = $13, triggers = $14, positive_behaviors = $15, notes = $16, updated_at = $17, rubric_version = $18, version = version + 1
+		WHERE id = $1 AND version = $19
+		RETURNING version
 	`
 	log.UpdatedAt = time.Now()
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.LogTime, log.TimeScope,
 		log.MoodLevel, log.EnergyLevel, log.AnxietyLevel, log.InterpersonalBehavior,
 		log.Meltdowns, log.StimmingEpisodes, log.StimmingLevel,
 		log.AggressionIncidents, log.SelfInjuryIncidents,
-		log.Triggers, log.PositiveBehaviors, log.Notes, log.UpdatedAt,
-	)
-	return err
+		log.Triggers, log.PositiveBehaviors, log.Notes, log.UpdatedAt, log.RubricVersion, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteBehaviorLog(ctx context.Context, id uuid.UUID) error {
@@ -128,6 +114,7 @@ func (r *logRepo) CreateBowelLog(ctx context.Context, log *models.BowelLog) erro
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.LogTime, log.TimeScope,
@@ -138,39 +125,12 @@ func (r *logRepo) CreateBowelLog(ctx context.Context, log *models.BowelLog) erro
 }
 
 func (r *logRepo) GetBowelLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.BowelLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, bristol_scale, had_accident, pain_level, blood_present, notes, logged_by, created_at
-		FROM bowel_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.BowelLog
-	for rows.Next() {
-		var log models.BowelLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.LogTime, &log.TimeScope,
-			&log.BristolScale, &log.HadAccident, &log.PainLevel, &log.BloodPresent,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "bowel_logs", bowelLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetBowelLogByID(ctx context.Context, id uuid.UUID) (*models.BowelLog, error) {
 	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, bristol_scale, had_accident, pain_level, blood_present, notes, logged_by, created_at
+		SELECT id, child_id, log_date, log_time, time_scope, bristol_scale, had_accident, pain_level, blood_present, notes, logged_by, created_at, amended_at, version
 		FROM bowel_logs
 		WHERE id = $1
 	`
@@ -178,7 +138,7 @@ func (r *logRepo) GetBowelLogByID(ctx context.Context, id uuid.UUID) (*models.Bo
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&log.ID, &log.ChildID, &log.LogDate, &log.LogTime, &log.TimeScope,
 		&log.BristolScale, &log.HadAccident, &log.PainLevel, &log.BloodPresent,
-		&log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -189,13 +149,14 @@ func (r *logRepo) GetBowelLogByID(ctx context.Context, id uuid.UUID) (*models.Bo
 func (r *logRepo) UpdateBowelLog(ctx context.Context, log *models.BowelLog) error {
 	query := `
 		UPDATE bowel_logs
-		SET log_date = $2, log_time = $3, time_scope = $4, bristol_scale = $5, had_accident = $6, pain_level = $7, blood_present = $8, notes = $9
-		WHERE id = $1
+		SET log_date = $2, log_time = $3, time_scope = $4, bristol_scale = $5, had_accident = $6, pain_level = $7, blood_present = $8, notes = $9, version = version + 1
+		WHERE id = $1 AND version = $10
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
-		log.ID, log.LogDate, log.LogTime, log.TimeScope, log.BristolScale, log.HadAccident, log.PainLevel, log.BloodPresent, log.Notes,
-	)
-	return err
+	err := r.db.QueryRowContext(ctx, query,
+		log.ID, log.LogDate, log.LogTime, log.TimeScope, log.BristolScale, log.HadAccident, log.PainLevel, log.BloodPresent, log.Notes, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteBowelLog(ctx context.Context, id uuid.UUID) error {
@@ -211,6 +172,7 @@ func (r *logRepo) CreateSpeechLog(ctx context.Context, log *models.SpeechLog) er
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.TimeScope,
@@ -222,40 +184,12 @@ func (r *logRepo) CreateSpeechLog(ctx context.Context, log *models.SpeechLog) er
 }
 
 func (r *logRepo) GetSpeechLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SpeechLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, time_scope, verbal_output_level, clarity_level, new_words, lost_words, echolalia_level, communication_attempts, successful_communications, notes, logged_by, created_at
-		FROM speech_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.SpeechLog
-	for rows.Next() {
-		var log models.SpeechLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope,
-			&log.VerbalOutputLevel, &log.ClarityLevel, &log.NewWords, &log.LostWords,
-			&log.EcholaliaLevel, &log.CommunicationAttempts, &log.SuccessfulCommunications,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "speech_logs", speechLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetSpeechLogByID(ctx context.Context, id uuid.UUID) (*models.SpeechLog, error) {
 	query := `
-		SELECT id, child_id, log_date, time_scope, verbal_output_level, clarity_level, new_words, lost_words, echolalia_level, communication_attempts, successful_communications, notes, logged_by, created_at
+		SELECT id, child_id, log_date, time_scope, verbal_output_level, clarity_level, new_words, lost_words, echolalia_level, communication_attempts, successful_communications, notes, logged_by, created_at, amended_at, version
 		FROM speech_logs
 		WHERE id = $1
 	`
@@ -264,7 +198,7 @@ func (r *logRepo) GetSpeechLogByID(ctx context.Context, id uuid.UUID) (*models.S
 		&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope,
 		&log.VerbalOutputLevel, &log.ClarityLevel, &log.NewWords, &log.LostWords,
 		&log.EcholaliaLevel, &log.CommunicationAttempts, &log.SuccessfulCommunications,
-		&log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -275,14 +209,15 @@ func (r *logRepo) GetSpeechLogByID(ctx context.Context, id uuid.UUID) (*models.S
 func (r *logRepo) UpdateSpeechLog(ctx context.Context, log *models.SpeechLog) error {
 	query := `
 		UPDATE speech_logs
-		SET log_date = $2, time_scope = $3, verbal_output_level = $4, clarity_level = $5, new_words = $6, lost_words = $7, echolalia_level = $8, communication_attempts = $9, successful_communications = $10, notes = $11
-		WHERE id = $1
+		SET log_date = $2, time_scope = $3, verbal_output_level = $4, clarity_level = $5, new_words = $6, lost_words = $7, echolalia_level = $8, communication_attempts = $9, successful_communications = $10, notes = $11, version = version + 1
+		WHERE id = $1 AND version = $12
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.TimeScope, log.VerbalOutputLevel, log.ClarityLevel, log.NewWords, log.LostWords,
-		log.EcholaliaLevel, log.CommunicationAttempts, log.SuccessfulCommunications, log.Notes,
-	)
-	return err
+		log.EcholaliaLevel, log.CommunicationAttempts, log.SuccessfulCommunications, log.Notes, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteSpeechLog(ctx context.Context, id uuid.UUID) error {
@@ -293,56 +228,29 @@ func (r *logRepo) DeleteSpeechLog(ctx context.Context, id uuid.UUID) error {
 // Diet Logs
 func (r *logRepo) CreateDietLog(ctx context.Context, log *models.DietLog) error {
 	query := `
-		INSERT INTO diet_logs (id, child_id, log_date, time_scope, meal_type, meal_time, foods_eaten, foods_refused, appetite_level, water_intake_oz, supplements_taken, new_food_tried, new_food_acceptance, allergic_reaction, reaction_details, notes, logged_by, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		INSERT INTO diet_logs (id, child_id, log_date, time_scope, meal_type, meal_time, foods_eaten, foods_refused, appetite_level, water_intake_oz, supplements_taken, new_food_tried, new_food_acceptance, allergic_reaction, reaction_details, notes, logged_by, created_at, restriction_matches, photo_recognition_id, food_provenance)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.TimeScope, log.MealType, log.MealTime,
 		log.FoodsEaten, log.FoodsRefused, log.AppetiteLevel, log.WaterIntakeOz,
 		log.SupplementsTaken, log.NewFoodTried, log.NewFoodAcceptance, log.AllergicReaction, log.ReactionDetails,
-		log.Notes, log.LoggedBy, log.CreatedAt,
+		log.Notes, log.LoggedBy, log.CreatedAt, log.RestrictionMatches, log.PhotoRecognitionID, log.FoodProvenance,
 	)
 	return err
 }
 
 func (r *logRepo) GetDietLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.DietLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, time_scope, meal_type, meal_time, foods_eaten, foods_refused, appetite_level, water_intake_oz, supplements_taken, new_food_tried, new_food_acceptance, allergic_reaction, reaction_details, notes, logged_by, created_at
-		FROM diet_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.DietLog
-	for rows.Next() {
-		var log models.DietLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope, &log.MealType, &log.MealTime,
-			&log.FoodsEaten, &log.FoodsRefused, &log.AppetiteLevel, &log.WaterIntakeOz,
-			&log.SupplementsTaken, &log.NewFoodTried, &log.NewFoodAcceptance, &log.AllergicReaction, &log.ReactionDetails,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "diet_logs", dietLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetDietLogByID(ctx context.Context, id uuid.UUID) (*models.DietLog, error) {
 	query := `
-		SELECT id, child_id, log_date, time_scope, meal_type, meal_time, foods_eaten, foods_refused, appetite_level, water_intake_oz, supplements_taken, new_food_tried, new_food_acceptance, allergic_reaction, reaction_details, notes, logged_by, created_at
+		SELECT id, child_id, log_date, time_scope, meal_type, meal_time, foods_eaten, foods_refused, appetite_level, water_intake_oz, supplements_taken, new_food_tried, new_food_acceptance, allergic_reaction, reaction_details, notes, logged_by, created_at, restriction_matches, photo_recognition_id, food_provenance, amended_at, version
 		FROM diet_logs
 		WHERE id = $1
 	`
@@ -351,7 +259,7 @@ func (r *logRepo) GetDietLogByID(ctx context.Context, id uuid.UUID) (*models.Die
 		&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope, &log.MealType, &log.MealTime,
 		&log.FoodsEaten, &log.FoodsRefused, &log.AppetiteLevel, &log.WaterIntakeOz,
 		&log.SupplementsTaken, &log.NewFoodTried, &log.NewFoodAcceptance, &log.AllergicReaction, &log.ReactionDetails,
-		&log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.RestrictionMatches, &log.PhotoRecognitionID, &log.FoodProvenance, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -362,15 +270,16 @@ func (r *logRepo) GetDietLogByID(ctx context.Context, id uuid.UUID) (*models.Die
 func (r *logRepo) UpdateDietLog(ctx context.Context, log *models.DietLog) error {
 	query := `
 		UPDATE diet_logs
-		SET log_date = $2, time_scope = $3, meal_type = $4, meal_time = $5, foods_eaten = $6, foods_refused = $7, appetite_level = $8, water_intake_oz = $9, supplements_taken = $10, new_food_tried = $11, new_food_acceptance = $12, allergic_reaction = $13, reaction_details = $14, notes = $15
-		WHERE id = $1
+		SET log_date = $2, time_scope = $3, meal_type = $4, meal_time = $5, foods_eaten = $6, foods_refused = $7, appetite_level = $8, water_intake_oz = $9, supplements_taken = $10, new_food_tried = $11, new_food_acceptance = $12, allergic_reaction = $13, reaction_details = $14, notes = $15, restriction_matches = $16, photo_recognition_id = $17, food_provenance = $18, version = version + 1
+		WHERE id = $1 AND version = $19
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.TimeScope, log.MealType, log.MealTime, log.FoodsEaten, log.FoodsRefused, log.AppetiteLevel,
 		log.WaterIntakeOz, log.SupplementsTaken, log.NewFoodTried, log.NewFoodAcceptance,
-		log.AllergicReaction, log.ReactionDetails, log.Notes,
-	)
-	return err
+		log.AllergicReaction, log.ReactionDetails, log.Notes, log.RestrictionMatches, log.PhotoRecognitionID, log.FoodProvenance, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteDietLog(ctx context.Context, id uuid.UUID) error {
@@ -386,6 +295,7 @@ func (r *logRepo) CreateWeightLog(ctx context.Context, log *models.WeightLog) er
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.TimeScope,
@@ -395,45 +305,19 @@ func (r *logRepo) CreateWeightLog(ctx context.Context, log *models.WeightLog) er
 }
 
 func (r *logRepo) GetWeightLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.WeightLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, time_scope, weight_lbs, height_inches, notes, logged_by, created_at
-		FROM weight_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.WeightLog
-	for rows.Next() {
-		var log models.WeightLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope,
-			&log.WeightLbs, &log.HeightInches, &log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "weight_logs", weightLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetWeightLogByID(ctx context.Context, id uuid.UUID) (*models.WeightLog, error) {
 	query := `
-		SELECT id, child_id, log_date, time_scope, weight_lbs, height_inches, notes, logged_by, created_at
+		SELECT id, child_id, log_date, time_scope, weight_lbs, height_inches, notes, logged_by, created_at, amended_at, version
 		FROM weight_logs
 		WHERE id = $1
 	`
 	log := &models.WeightLog{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope,
-		&log.WeightLbs, &log.HeightInches, &log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.WeightLbs, &log.HeightInches, &log.Notes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -444,11 +328,12 @@ func (r *logRepo) GetWeightLogByID(ctx context.Context, id uuid.UUID) (*models.W
 func (r *logRepo) UpdateWeightLog(ctx context.Context, log *models.WeightLog) error {
 	query := `
 		UPDATE weight_logs
-		SET log_date = $2, time_scope = $3, weight_lbs = $4, height_inches = $5, notes = $6
-		WHERE id = $1
+		SET log_date = $2, time_scope = $3, weight_lbs = $4, height_inches = $5, notes = $6, version = version + 1
+		WHERE id = $1 AND version = $7
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query, log.ID, log.LogDate, log.TimeScope, log.WeightLbs, log.HeightInches, log.Notes)
-	return err
+	err := r.db.QueryRowContext(ctx, query, log.ID, log.LogDate, log.TimeScope, log.WeightLbs, log.HeightInches, log.Notes, log.Version).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteWeightLog(ctx context.Context, id uuid.UUID) error {
@@ -459,56 +344,32 @@ func (r *logRepo) DeleteWeightLog(ctx context.Context, id uuid.UUID) error {
 // Sleep Logs
 func (r *logRepo) CreateSleepLog(ctx context.Context, log *models.SleepLog) error {
 	query := `
-		INSERT INTO sleep_logs (id, child_id, log_date, time_scope, bedtime, wake_time, total_sleep_minutes, night_wakings, sleep_quality, took_sleep_aid, sleep_aid_name, nightmares, bed_wetting, notes, logged_by, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		INSERT INTO sleep_logs (id, child_id, log_date, time_scope, bedtime, wake_time, total_sleep_minutes, night_wakings, sleep_quality, took_sleep_aid, sleep_aid_name, nightmares, bed_wetting, notes, source, reconciliation_status, reconciliation_note, logged_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
+	if log.Source == "" {
+		log.Source = models.SleepSourceManual
+	}
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.TimeScope, log.Bedtime, log.WakeTime,
 		log.TotalSleepMinutes, log.NightWakings, log.SleepQuality,
 		log.TookSleepAid, log.SleepAidName, log.Nightmares, log.BedWetting,
-		log.Notes, log.LoggedBy, log.CreatedAt,
+		log.Notes, log.Source, log.ReconciliationStatus, log.ReconciliationNote, log.LoggedBy, log.CreatedAt,
 	)
 	return err
 }
 
 func (r *logRepo) GetSleepLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SleepLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, time_scope, bedtime, wake_time, total_sleep_minutes, night_wakings, sleep_quality, took_sleep_aid, sleep_aid_name, nightmares, bed_wetting, notes, logged_by, created_at
-		FROM sleep_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.SleepLog
-	for rows.Next() {
-		var log models.SleepLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope, &log.Bedtime, &log.WakeTime,
-			&log.TotalSleepMinutes, &log.NightWakings, &log.SleepQuality,
-			&log.TookSleepAid, &log.SleepAidName, &log.Nightmares, &log.BedWetting,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "sleep_logs", sleepLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetSleepLogByID(ctx context.Context, id uuid.UUID) (*models.SleepLog, error) {
 	query := `
-		SELECT id, child_id, log_date, time_scope, bedtime, wake_time, total_sleep_minutes, night_wakings, sleep_quality, took_sleep_aid, sleep_aid_name, nightmares, bed_wetting, notes, logged_by, created_at
+		SELECT id, child_id, log_date, time_scope, bedtime, wake_time, total_sleep_minutes, night_wakings, sleep_quality, took_sleep_aid, sleep_aid_name, nightmares, bed_wetting, notes, source, reconciliation_status, reconciliation_note, logged_by, created_at, amended_at, version
 		FROM sleep_logs
 		WHERE id = $1
 	`
@@ -517,7 +378,7 @@ func (r *logRepo) GetSleepLogByID(ctx context.Context, id uuid.UUID) (*models.Sl
 		&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope, &log.Bedtime, &log.WakeTime,
 		&log.TotalSleepMinutes, &log.NightWakings, &log.SleepQuality,
 		&log.TookSleepAid, &log.SleepAidName, &log.Nightmares, &log.BedWetting,
-		&log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Notes, &log.Source, &log.ReconciliationStatus, &log.ReconciliationNote, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -528,14 +389,19 @@ func (r *logRepo) GetSleepLogByID(ctx context.Context, id uuid.UUID) (*models.Sl
 func (r *logRepo) UpdateSleepLog(ctx context.Context, log *models.SleepLog) error {
 	query := `
 		UPDATE sleep_logs
-		SET log_date = $2, time_scope = $3, bedtime = $4, wake_time = $5, total_sleep_minutes = $6, night_wakings = $7, sleep_quality = $8, took_sleep_aid = $9, sleep_aid_name = $10, nightmares = $11, bed_wetting = $12, notes = $13
-		WHERE id = $1
+		SET log_date = $2, time_scope = $3, bedtime = $4, wake_time = $5, total_sleep_minutes = $6, night_wakings = $7, sleep_quality = $8, took_sleep_aid = $9, sleep_aid_name = $10, nightmares = $11, bed_wetting = $12, notes = $13, source = $14, reconciliation_status = $15, reconciliation_note = $16, version = version + 1
+		WHERE id = $1 AND version = $17
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	if log.Source == "" {
+		log.Source = models.SleepSourceManual
+	}
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.TimeScope, log.Bedtime, log.WakeTime, log.TotalSleepMinutes, log.NightWakings,
 		log.SleepQuality, log.TookSleepAid, log.SleepAidName, log.Nightmares, log.BedWetting, log.Notes,
-	)
-	return err
+		log.Source, log.ReconciliationStatus, log.ReconciliationNote, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteSleepLog(ctx context.Context, id uuid.UUID) error {
@@ -551,6 +417,7 @@ func (r *logRepo) CreateSensoryLog(ctx context.Context, log *models.SensoryLog)
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.LogTime, log.TimeScope,
@@ -563,41 +430,12 @@ func (r *logRepo) CreateSensoryLog(ctx context.Context, log *models.SensoryLog)
 }
 
 func (r *logRepo) GetSensoryLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SensoryLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, sensory_seeking_behaviors, sensory_avoiding_behaviors, overload_triggers, calming_strategies_used, overload_episodes, overall_regulation, notes, logged_by, created_at
-		FROM sensory_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.SensoryLog
-	for rows.Next() {
-		var log models.SensoryLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.LogTime, &log.TimeScope,
-			&log.SensorySeekingBehaviors, &log.SensoryAvoidingBehaviors,
-			&log.OverloadTriggers, &log.CalmingStrategiesUsed,
-			&log.OverloadEpisodes, &log.OverallRegulation,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "sensory_logs", sensoryLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetSensoryLogByID(ctx context.Context, id uuid.UUID) (*models.SensoryLog, error) {
 	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, sensory_seeking_behaviors, sensory_avoiding_behaviors, overload_triggers, calming_strategies_used, overload_episodes, overall_regulation, notes, logged_by, created_at
+		SELECT id, child_id, log_date, log_time, time_scope, sensory_seeking_behaviors, sensory_avoiding_behaviors, overload_triggers, calming_strategies_used, overload_episodes, overall_regulation, notes, logged_by, created_at, amended_at, version
 		FROM sensory_logs
 		WHERE id = $1
 	`
@@ -607,7 +445,7 @@ func (r *logRepo) GetSensoryLogByID(ctx context.Context, id uuid.UUID) (*models.
 		&log.SensorySeekingBehaviors, &log.SensoryAvoidingBehaviors,
 		&log.OverloadTriggers, &log.CalmingStrategiesUsed,
 		&log.OverloadEpisodes, &log.OverallRegulation,
-		&log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -618,14 +456,15 @@ func (r *logRepo) GetSensoryLogByID(ctx context.Context, id uuid.UUID) (*models.
 func (r *logRepo) UpdateSensoryLog(ctx context.Context, log *models.SensoryLog) error {
 	query := `
 		UPDATE sensory_logs
-		SET log_date = $2, log_time = $3, time_scope = $4, sensory_seeking_behaviors = $5, sensory_avoiding_behaviors = $6, overload_triggers = $7, calming_strategies_used = $8, overload_episodes = $9, overall_regulation = $10, notes = $11
-		WHERE id = $1
+		SET log_date = $2, log_time = $3, time_scope = $4, sensory_seeking_behaviors = $5, sensory_avoiding_behaviors = $6, overload_triggers = $7, calming_strategies_used = $8, overload_episodes = $9, overall_regulation = $10, notes = $11, version = version + 1
+		WHERE id = $1 AND version = $12
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.LogTime, log.TimeScope, log.SensorySeekingBehaviors, log.SensoryAvoidingBehaviors,
-		log.OverloadTriggers, log.CalmingStrategiesUsed, log.OverloadEpisodes, log.OverallRegulation, log.Notes,
-	)
-	return err
+		log.OverloadTriggers, log.CalmingStrategiesUsed, log.OverloadEpisodes, log.OverallRegulation, log.Notes, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteSensoryLog(ctx context.Context, id uuid.UUID) error {
@@ -641,6 +480,7 @@ func (r *logRepo) CreateSocialLog(ctx context.Context, log *models.SocialLog) er
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.TimeScope,
@@ -653,41 +493,12 @@ func (r *logRepo) CreateSocialLog(ctx context.Context, log *models.SocialLog) er
 }
 
 func (r *logRepo) GetSocialLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SocialLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, time_scope, eye_contact_level, social_engagement_level, peer_interactions, positive_interactions, conflicts, parallel_play_minutes, cooperative_play_minutes, notes, logged_by, created_at
-		FROM social_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.SocialLog
-	for rows.Next() {
-		var log models.SocialLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope,
-			&log.EyeContactLevel, &log.SocialEngagementLevel,
-			&log.PeerInteractions, &log.PositiveInteractions, &log.Conflicts,
-			&log.ParallelPlayMinutes, &log.CooperativePlayMinutes,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "social_logs", socialLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetSocialLogByID(ctx context.Context, id uuid.UUID) (*models.SocialLog, error) {
 	query := `
-		SELECT id, child_id, log_date, time_scope, eye_contact_level, social_engagement_level, peer_interactions, positive_interactions, conflicts, parallel_play_minutes, cooperative_play_minutes, notes, logged_by, created_at
+		SELECT id, child_id, log_date, time_scope, eye_contact_level, social_engagement_level, peer_interactions, positive_interactions, conflicts, parallel_play_minutes, cooperative_play_minutes, notes, logged_by, created_at, amended_at, version
 		FROM social_logs
 		WHERE id = $1
 	`
@@ -697,7 +508,7 @@ func (r *logRepo) GetSocialLogByID(ctx context.Context, id uuid.UUID) (*models.S
 		&log.EyeContactLevel, &log.SocialEngagementLevel,
 		&log.PeerInteractions, &log.PositiveInteractions, &log.Conflicts,
 		&log.ParallelPlayMinutes, &log.CooperativePlayMinutes,
-		&log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -708,14 +519,15 @@ func (r *logRepo) GetSocialLogByID(ctx context.Context, id uuid.UUID) (*models.S
 func (r *logRepo) UpdateSocialLog(ctx context.Context, log *models.SocialLog) error {
 	query := `
 		UPDATE social_logs
-		SET log_date = $2, time_scope = $3, eye_contact_level = $4, social_engagement_level = $5, peer_interactions = $6, positive_interactions = $7, conflicts = $8, parallel_play_minutes = $9, cooperative_play_minutes = $10, notes = $11
-		WHERE id = $1
+		SET log_date = $2, time_scope = $3, eye_contact_level = $4, social_engagement_level = $5, peer_interactions = $6, positive_interactions = $7, conflicts = $8, parallel_play_minutes = $9, cooperative_play_minutes = $10, notes = $11, version = version + 1
+		WHERE id = $1 AND version = $12
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.TimeScope, log.EyeContactLevel, log.SocialEngagementLevel, log.PeerInteractions,
-		log.PositiveInteractions, log.Conflicts, log.ParallelPlayMinutes, log.CooperativePlayMinutes, log.Notes,
-	)
-	return err
+		log.PositiveInteractions, log.Conflicts, log.ParallelPlayMinutes, log.CooperativePlayMinutes, log.Notes, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteSocialLog(ctx context.Context, id uuid.UUID) error {
@@ -731,6 +543,7 @@ func (r *logRepo) CreateTherapyLog(ctx context.Context, log *models.TherapyLog)
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.TimeScope,
@@ -742,40 +555,12 @@ func (r *logRepo) CreateTherapyLog(ctx context.Context, log *models.TherapyLog)
 }
 
 func (r *logRepo) GetTherapyLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.TherapyLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, time_scope, therapy_type, therapist_name, duration_minutes, goals_worked_on, progress_notes, homework_assigned, parent_notes, logged_by, created_at
-		FROM therapy_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.TherapyLog
-	for rows.Next() {
-		var log models.TherapyLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope,
-			&log.TherapyType, &log.TherapistName, &log.DurationMinutes,
-			&log.GoalsWorkedOn, &log.ProgressNotes, &log.HomeworkAssigned,
-			&log.ParentNotes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "therapy_logs", therapyLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetTherapyLogByID(ctx context.Context, id uuid.UUID) (*models.TherapyLog, error) {
 	query := `
-		SELECT id, child_id, log_date, time_scope, therapy_type, therapist_name, duration_minutes, goals_worked_on, progress_notes, homework_assigned, parent_notes, logged_by, created_at
+		SELECT id, child_id, log_date, time_scope, therapy_type, therapist_name, duration_minutes, goals_worked_on, progress_notes, homework_assigned, parent_notes, logged_by, created_at, amended_at, version
 		FROM therapy_logs
 		WHERE id = $1
 	`
@@ -784,7 +569,7 @@ func (r *logRepo) GetTherapyLogByID(ctx context.Context, id uuid.UUID) (*models.
 		&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope,
 		&log.TherapyType, &log.TherapistName, &log.DurationMinutes,
 		&log.GoalsWorkedOn, &log.ProgressNotes, &log.HomeworkAssigned,
-		&log.ParentNotes, &log.LoggedBy, &log.CreatedAt,
+		&log.ParentNotes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -795,14 +580,15 @@ func (r *logRepo) GetTherapyLogByID(ctx context.Context, id uuid.UUID) (*models.
 func (r *logRepo) UpdateTherapyLog(ctx context.Context, log *models.TherapyLog) error {
 	query := `
 		UPDATE therapy_logs
-		SET log_date = $2, time_scope = $3, therapy_type = $4, therapist_name = $5, duration_minutes = $6, goals_worked_on = $7, progress_notes = $8, homework_assigned = $9, parent_notes = $10
-		WHERE id = $1
+		SET log_date = $2, time_scope = $3, therapy_type = $4, therapist_name = $5, duration_minutes = $6, goals_worked_on = $7, progress_notes = $8, homework_assigned = $9, parent_notes = $10, version = version + 1
+		WHERE id = $1 AND version = $11
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.TimeScope, log.TherapyType, log.TherapistName, log.DurationMinutes,
-		log.GoalsWorkedOn, log.ProgressNotes, log.HomeworkAssigned, log.ParentNotes,
-	)
-	return err
+		log.GoalsWorkedOn, log.ProgressNotes, log.HomeworkAssigned, log.ParentNotes, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteTherapyLog(ctx context.Context, id uuid.UUID) error {
@@ -818,6 +604,7 @@ func (r *logRepo) CreateSeizureLog(ctx context.Context, log *models.SeizureLog)
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.LogTime, log.TimeScope,
@@ -829,40 +616,12 @@ func (r *logRepo) CreateSeizureLog(ctx context.Context, log *models.SeizureLog)
 }
 
 func (r *logRepo) GetSeizureLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SeizureLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, seizure_type, duration_seconds, triggers, warning_signs, post_ictal_symptoms, rescue_medication_given, rescue_medication_name, called_911, notes, logged_by, created_at
-		FROM seizure_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.SeizureLog
-	for rows.Next() {
-		var log models.SeizureLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.LogTime, &log.TimeScope,
-			&log.SeizureType, &log.DurationSeconds, &log.Triggers, &log.WarningSigns,
-			&log.PostIctalSymptoms, &log.RescueMedicationGiven, &log.RescueMedicationName,
-			&log.Called911, &log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "seizure_logs", seizureLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetSeizureLogByID(ctx context.Context, id uuid.UUID) (*models.SeizureLog, error) {
 	query := `
-		SELECT id, child_id, log_date, log_time, time_scope, seizure_type, duration_seconds, triggers, warning_signs, post_ictal_symptoms, rescue_medication_given, rescue_medication_name, called_911, notes, logged_by, created_at
+		SELECT id, child_id, log_date, log_time, time_scope, seizure_type, duration_seconds, triggers, warning_signs, post_ictal_symptoms, rescue_medication_given, rescue_medication_name, called_911, notes, logged_by, created_at, amended_at, version
 		FROM seizure_logs
 		WHERE id = $1
 	`
@@ -871,7 +630,7 @@ func (r *logRepo) GetSeizureLogByID(ctx context.Context, id uuid.UUID) (*models.
 		&log.ID, &log.ChildID, &log.LogDate, &log.LogTime, &log.TimeScope,
 		&log.SeizureType, &log.DurationSeconds, &log.Triggers, &log.WarningSigns,
 		&log.PostIctalSymptoms, &log.RescueMedicationGiven, &log.RescueMedicationName,
-		&log.Called911, &log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Called911, &log.Notes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -882,15 +641,16 @@ func (r *logRepo) GetSeizureLogByID(ctx context.Context, id uuid.UUID) (*models.
 func (r *logRepo) UpdateSeizureLog(ctx context.Context, log *models.SeizureLog) error {
 	query := `
 		UPDATE seizure_logs
-		SET log_date = $2, log_time = $3, time_scope = $4, seizure_type = $5, duration_seconds = $6, triggers = $7, warning_signs = $8, post_ictal_symptoms = $9, rescue_medication_given = $10, rescue_medication_name = $11, called_911 = $12, notes = $13
-		WHERE id = $1
+		SET log_date = $2, log_time = $3, time_scope = $4, seizure_type = $5, duration_seconds = $6, triggers = $7, warning_signs = $8, post_ictal_symptoms = $9, rescue_medication_given = $10, rescue_medication_name = $11, called_911 = $12, notes = $13, version = version + 1
+		WHERE id = $1 AND version = $14
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.LogTime, log.TimeScope, log.SeizureType, log.DurationSeconds, log.Triggers,
 		log.WarningSigns, log.PostIctalSymptoms, log.RescueMedicationGiven, log.RescueMedicationName,
-		log.Called911, log.Notes,
-	)
-	return err
+		log.Called911, log.Notes, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteSeizureLog(ctx context.Context, id uuid.UUID) error {
@@ -906,6 +666,7 @@ func (r *logRepo) CreateHealthEventLog(ctx context.Context, log *models.HealthEv
 	`
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.ChildID, log.LogDate, log.TimeScope, log.EventType, log.Description,
@@ -916,39 +677,12 @@ func (r *logRepo) CreateHealthEventLog(ctx context.Context, log *models.HealthEv
 }
 
 func (r *logRepo) GetHealthEventLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.HealthEventLog, error) {
-	startStr := startDate.Format("2006-01-02")
-	endStr := endDate.Format("2006-01-02")
-	query := `
-		SELECT id, child_id, log_date, time_scope, event_type, description, symptoms, temperature_f, provider_name, diagnosis, treatment, follow_up_date, notes, logged_by, created_at
-		FROM health_event_logs
-		WHERE child_id = $1 AND log_date >= $2 AND log_date <= $3
-		ORDER BY log_date DESC, created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, childID, startStr, endStr)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []models.HealthEventLog
-	for rows.Next() {
-		var log models.HealthEventLog
-		err := rows.Scan(
-			&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope, &log.EventType, &log.Description,
-			&log.Symptoms, &log.TemperatureF, &log.ProviderName, &log.Diagnosis,
-			&log.Treatment, &log.FollowUpDate, &log.Notes, &log.LoggedBy, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-	return logs, rows.Err()
+	return listLogs(ctx, r.db, "health_event_logs", healthEventLogColumns, childID, startDate, endDate)
 }
 
 func (r *logRepo) GetHealthEventLogByID(ctx context.Context, id uuid.UUID) (*models.HealthEventLog, error) {
 	query := `
-		SELECT id, child_id, log_date, time_scope, event_type, description, symptoms, temperature_f, provider_name, diagnosis, treatment, follow_up_date, notes, logged_by, created_at
+		SELECT id, child_id, log_date, time_scope, event_type, description, symptoms, temperature_f, provider_name, diagnosis, treatment, follow_up_date, notes, logged_by, created_at, amended_at, version
 		FROM health_event_logs
 		WHERE id = $1
 	`
@@ -956,7 +690,7 @@ func (r *logRepo) GetHealthEventLogByID(ctx context.Context, id uuid.UUID) (*mod
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&log.ID, &log.ChildID, &log.LogDate, &log.TimeScope, &log.EventType, &log.Description,
 		&log.Symptoms, &log.TemperatureF, &log.ProviderName, &log.Diagnosis,
-		&log.Treatment, &log.FollowUpDate, &log.Notes, &log.LoggedBy, &log.CreatedAt,
+		&log.Treatment, &log.FollowUpDate, &log.Notes, &log.LoggedBy, &log.CreatedAt, &log.AmendedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -967,14 +701,15 @@ func (r *logRepo) GetHealthEventLogByID(ctx context.Context, id uuid.UUID) (*mod
 func (r *logRepo) UpdateHealthEventLog(ctx context.Context, log *models.HealthEventLog) error {
 	query := `
 		UPDATE health_event_logs
-		SET log_date = $2, time_scope = $3, event_type = $4, description = $5, symptoms = $6, temperature_f = $7, provider_name = $8, diagnosis = $9, treatment = $10, follow_up_date = $11, notes = $12
-		WHERE id = $1
+		SET log_date = $2, time_scope = $3, event_type = $4, description = $5, symptoms = $6, temperature_f = $7, provider_name = $8, diagnosis = $9, treatment = $10, follow_up_date = $11, notes = $12, version = version + 1
+		WHERE id = $1 AND version = $13
+		RETURNING version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		log.ID, log.LogDate, log.TimeScope, log.EventType, log.Description, log.Symptoms, log.TemperatureF,
-		log.ProviderName, log.Diagnosis, log.Treatment, log.FollowUpDate, log.Notes,
-	)
-	return err
+		log.ProviderName, log.Diagnosis, log.Treatment, log.FollowUpDate, log.Notes, log.Version,
+	).Scan(&log.Version)
+	return versionedUpdate(err)
 }
 
 func (r *logRepo) DeleteHealthEventLog(ctx context.Context, id uuid.UUID) error {
@@ -1092,6 +827,24 @@ func (r *logRepo) GetDailyLogs(ctx context.Context, childID uuid.UUID, date time
 	return page, nil
 }
 
+// DailyLogsChangeStamp reads the change stamp for a child's logs between
+// two dates (inclusive). Stamps come from the log_change_stamps triggers.
+func (r *logRepo) DailyLogsChangeStamp(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (models.ChangeStamp, error) {
+	var st models.ChangeStamp
+	var last sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(s.log_date), COALESCE(SUM(s.version), 0),
+		       GREATEST(MAX(c.updated_at), MAX(s.changed_at),
+		                (SELECT MAX(updated_at) FROM medications WHERE child_id = $1))
+		FROM children c
+		LEFT JOIN log_change_stamps s
+		       ON s.child_id = c.id AND s.log_date >= $2 AND s.log_date <= $3
+		WHERE c.id = $1
+	`, childID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).Scan(&st.Count, &st.Version, &last)
+	st.LastModified = last.Time
+	return st, err
+}
+
 // GetLogsForDateRange returns all logs for a child within a date range (for weekly view)
 func (r *logRepo) GetLogsForDateRange(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (*models.DailyLogPage, error) {
 	// Get child first
@@ -1207,7 +960,7 @@ func (r *logRepo) getMedicationLogsForDateRange(ctx context.Context, childID uui
 	startStr := startDate.Format("2006-01-02")
 	endStr := endDate.Format("2006-01-02")
 	query := `
-		SELECT ml.id, ml.medication_id, COALESCE(m.name, 'Unknown'), ml.child_id, ml.schedule_id, ml.log_date, ml.scheduled_time::text, ml.actual_time::text, ml.status, ml.dosage_given, ml.notes, ml.logged_by, ml.created_at, ml.updated_at
+		SELECT ml.id, ml.medication_id, COALESCE(m.name, 'Unknown'), ml.child_id, ml.schedule_id, ml.log_date, ml.scheduled_time::text, ml.actual_time::text, ml.status, ml.dosage_given, ml.notes, ml.logged_by, ml.created_at, ml.updated_at, ml.version
 		FROM medication_logs ml
 		LEFT JOIN medications m ON ml.medication_id = m.id
 		WHERE ml.child_id = $1 AND ml.log_date >= $2 AND ml.log_date <= $3
@@ -1225,7 +978,7 @@ func (r *logRepo) getMedicationLogsForDateRange(ctx context.Context, childID uui
 		err := rows.Scan(
 			&log.ID, &log.MedicationID, &log.MedicationName, &log.ChildID, &log.ScheduleID, &log.LogDate,
 			&log.ScheduledTime, &log.ActualTime, &log.Status, &log.DosageGiven,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt,
+			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt, &log.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -1237,7 +990,7 @@ func (r *logRepo) getMedicationLogsForDateRange(ctx context.Context, childID uui
 
 func (r *logRepo) getMedicationLogsForDate(ctx context.Context, childID uuid.UUID, date time.Time) ([]models.MedicationLog, error) {
 	query := `
-		SELECT ml.id, ml.medication_id, COALESCE(m.name, 'Unknown'), ml.child_id, ml.schedule_id, ml.log_date, ml.scheduled_time::text, ml.actual_time::text, ml.status, ml.dosage_given, ml.notes, ml.logged_by, ml.created_at, ml.updated_at
+		SELECT ml.id, ml.medication_id, COALESCE(m.name, 'Unknown'), ml.child_id, ml.schedule_id, ml.log_date, ml.scheduled_time::text, ml.actual_time::text, ml.status, ml.dosage_given, ml.notes, ml.logged_by, ml.created_at, ml.updated_at, ml.version
 		FROM medication_logs ml
 		LEFT JOIN medications m ON ml.medication_id = m.id
 		WHERE ml.child_id = $1 AND ml.log_date = $2
@@ -1255,7 +1008,7 @@ func (r *logRepo) getMedicationLogsForDate(ctx context.Context, childID uuid.UUI
 		err := rows.Scan(
 			&log.ID, &log.MedicationID, &log.MedicationName, &log.ChildID, &log.ScheduleID, &log.LogDate,
 			&log.ScheduledTime, &log.ActualTime, &log.Status, &log.DosageGiven,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt,
+			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt, &log.Version,
 		)
 		if err != nil {
 			return nil, err
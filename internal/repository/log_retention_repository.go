@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LogTableStats describes one raw log or rollup table for the retention
+// panel. Rows is the planner's estimate (pg_class.reltuples), which is
+// cheap on a big table and close enough for sizing.
+type LogTableStats struct {
+	Table     string     `json:"table"`
+	Rows      int64      `json:"rows_estimate"`
+	Oldest    *time.Time `json:"oldest,omitempty"`
+	SizeBytes int64      `json:"size_bytes"`
+}
+
+// LogRetentionRepository owns the hourly rollups of response_time_logs and
+// error_logs and the pruning of both raw tables.
+type LogRetentionRepository interface {
+	// ResponseRollupEnd is the end of the newest rolled-up hour in
+	// response_time_hourly, or nil when nothing has been rolled up.
+	ResponseRollupEnd(ctx context.Context) (*time.Time, error)
+	// ErrorRollupEnd is the same for error_log_hourly.
+	ErrorRollupEnd(ctx context.Context) (*time.Time, error)
+	// RollupResponseTimes (re)builds response_time_hourly for the hours in
+	// [from, to) from the raw rows. Returns the rollup rows written.
+	RollupResponseTimes(ctx context.Context, from, to time.Time) (int64, error)
+	// RollupErrorLogs (re)builds error_log_hourly for [from, to).
+	RollupErrorLogs(ctx context.Context, from, to time.Time) (int64, error)
+	// PruneResponseTimes deletes up to limit raw rows created before cutoff.
+	PruneResponseTimes(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	// PruneErrorLogs deletes up to limit error_logs rows created before
+	// cutoff or, unless acknowledged, past their auto_delete_at (scanner
+	// and anonymous noise).
+	PruneErrorLogs(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	// PruneRollups deletes rollup hours before cutoff from both rollup tables.
+	PruneRollups(ctx context.Context, cutoff time.Time) (int64, error)
+	// TableStats sizes the raw and rollup tables.
+	TableStats(ctx context.Context) ([]LogTableStats, error)
+}
+
+type logRetentionRepo struct {
+	db *DB
+}
+
+// NewLogRetentionRepo creates a LogRetentionRepository on the main pool.
+func NewLogRetentionRepo(db *sql.DB) LogRetentionRepository {
+	return &logRetentionRepo{db: WrapDB(db)}
+}
+
+func (r *logRetentionRepo) ResponseRollupEnd(ctx context.Context) (*time.Time, error) {
+	return r.rollupEnd(ctx, `SELECT MAX(hour) + INTERVAL '1 hour' FROM response_time_hourly`)
+}
+
+func (r *logRetentionRepo) ErrorRollupEnd(ctx context.Context) (*time.Time, error) {
+	return r.rollupEnd(ctx, `SELECT MAX(hour) + INTERVAL '1 hour' FROM error_log_hourly`)
+}
+
+func (r *logRetentionRepo) rollupEnd(ctx context.Context, query string) (*time.Time, error) {
+	var end sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query).Scan(&end); err != nil {
+		return nil, err
+	}
+	if !end.Valid {
+		return nil, nil
+	}
+	return &end.Time, nil
+}
+
+// rollupPathSQL collapses UUID and numeric path segments so /children/<id>
+// rolls up as one endpoint rather than one row per child. Only used for
+// rows logged before the route template was recorded.
+const rollupPathSQL = `regexp_replace(
+        regexp_replace(path, '[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}', '{id}', 'g'),
+        '/[0-9]+(?=/|$)', '/{n}', 'g')`
+
+func (r *logRetentionRepo) RollupResponseTimes(ctx context.Context, from, to time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+        WITH buckets AS (
+            SELECT date_trunc('hour', created_at) AS hour,
+                   LEFT(COALESCE(route, `+rollupPathSQL+`), 500) AS path,
+                   method,
+                   status_code / 100 AS status_class,
+                   width_bucket(response_time_ms, $3::float8[]) AS bucket,
+                   COUNT(*) AS n,
+                   COUNT(*) FILTER (WHERE status_code >= 500) AS errors,
+                   SUM(response_time_ms) AS total_ms,
+                   MAX(response_time_ms) AS max_ms
+            FROM response_time_logs
+            WHERE created_at >= $1 AND created_at < $2
+            GROUP BY 1, 2, 3, 4, 5
+        )
+        INSERT INTO response_time_hourly
+            (hour, path, method, status_class, request_count, error_count, total_ms, max_ms, histogram)
+        SELECT hour, path, method, status_class,
+               SUM(n), SUM(errors), SUM(total_ms), MAX(max_ms),
+               jsonb_object_agg(bucket, n)
+        FROM buckets
+        GROUP BY 1, 2, 3, 4
+        ON CONFLICT (hour, path, method, status_class) DO UPDATE SET
+            request_count = EXCLUDED.request_count,
+            error_count   = EXCLUDED.error_count,
+            total_ms      = EXCLUDED.total_ms,
+            max_ms        = EXCLUDED.max_ms,
+            histogram     = EXCLUDED.histogram
+    `, from, to, pq.Array(LatencyBucketBoundsMs))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *logRetentionRepo) RollupErrorLogs(ctx context.Context, from, to time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+        INSERT INTO error_log_hourly (hour, error_type, status_code, error_count)
+        SELECT date_trunc('hour', created_at), error_type, status_code, COUNT(*)
+        FROM error_logs
+        WHERE created_at >= $1 AND created_at < $2
+        GROUP BY 1, 2, 3
+        ON CONFLICT (hour, error_type, status_code) DO UPDATE SET
+            error_count = EXCLUDED.error_count
+    `, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *logRetentionRepo) PruneResponseTimes(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+        DELETE FROM response_time_logs
+        WHERE id IN (SELECT id FROM response_time_logs WHERE created_at < $1 LIMIT $2)
+    `, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *logRetentionRepo) PruneErrorLogs(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+        DELETE FROM error_logs
+        WHERE id IN (
+            SELECT id FROM error_logs
+            WHERE created_at < $1 OR (auto_delete_at < NOW() AND acknowledged_at IS NULL)
+            LIMIT $2
+        )
+    `, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *logRetentionRepo) PruneRollups(ctx context.Context, cutoff time.Time) (int64, error) {
+	var total int64
+	for _, q := range []string{
+		`DELETE FROM response_time_hourly WHERE hour < $1`,
+		`DELETE FROM error_log_hourly WHERE hour < $1`,
+	} {
+		res, err := r.db.ExecContext(ctx, q, cutoff)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
+
+func (r *logRetentionRepo) TableStats(ctx context.Context) ([]LogTableStats, error) {
+	tables := []struct{ name, oldest string }{
+		{"response_time_logs", `SELECT MIN(created_at) FROM response_time_logs`},
+		{"error_logs", `SELECT MIN(created_at) FROM error_logs`},
+		{"response_time_hourly", `SELECT MIN(hour) FROM response_time_hourly`},
+		{"error_log_hourly", `SELECT MIN(hour) FROM error_log_hourly`},
+	}
+	out := make([]LogTableStats, 0, len(tables))
+	for _, t := range tables {
+		s := LogTableStats{Table: t.name}
+		var rows float64
+		if err := r.db.QueryRowContext(ctx, `
+            SELECT GREATEST(c.reltuples, 0), pg_total_relation_size(c.oid)
+            FROM pg_class c WHERE c.oid = to_regclass($1)
+        `, t.name).Scan(&rows, &s.SizeBytes); err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		s.Rows = int64(rows)
+		var oldest sql.NullTime
+		if err := r.db.QueryRowContext(ctx, t.oldest).Scan(&oldest); err != nil {
+			return nil, err
+		}
+		if oldest.Valid {
+			s.Oldest = &oldest.Time
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// logRevisionTables maps the log types that keep a revision history to
+// their tables.
+var logRevisionTables = map[string]string{
+	"behavior":     "behavior_logs",
+	"bowel":        "bowel_logs",
+	"speech":       "speech_logs",
+	"diet":         "diet_logs",
+	"weight":       "weight_logs",
+	"sleep":        "sleep_logs",
+	"sensory":      "sensory_logs",
+	"social":       "social_logs",
+	"therapy":      "therapy_logs",
+	"seizure":      "seizure_logs",
+	"health_event": "health_event_logs",
+}
+
+// LogRevisionRepository stores the amendment history of log entries.
+type LogRevisionRepository interface {
+	// Record inserts the revision and stamps the log's amended_at with its
+	// time; ID and EditedAt are filled in.
+	Record(ctx context.Context, rev *models.LogRevision) error
+	// ListForLog returns a log's revisions, oldest first, with editor
+	// names.
+	ListForLog(ctx context.Context, logType string, logID uuid.UUID) ([]models.LogRevision, error)
+}
+
+type logRevisionRepo struct {
+	db *DB
+}
+
+// NewLogRevisionRepo creates a LogRevisionRepository on the main pool.
+func NewLogRevisionRepo(db *sql.DB) LogRevisionRepository {
+	return &logRevisionRepo{db: WrapDB(db)}
+}
+
+func (r *logRevisionRepo) Record(ctx context.Context, rev *models.LogRevision) error {
+	table, ok := logRevisionTables[rev.LogType]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownLogType, rev.LogType)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO log_revisions (log_type, log_id, child_id, version, changes, edited_by)
+        VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6)
+        RETURNING id, edited_at
+    `, rev.LogType, rev.LogID, rev.ChildID, rev.Version, rev.Changes, rev.EditedBy,
+	).Scan(&rev.ID, &rev.EditedAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET amended_at = $2 WHERE id = $1`, rev.LogID, rev.EditedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *logRevisionRepo) ListForLog(ctx context.Context, logType string, logID uuid.UUID) ([]models.LogRevision, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT lr.id, lr.log_type, lr.log_id, lr.child_id, COALESCE(lr.version, 0), lr.changes, lr.edited_by,
+               COALESCE(TRIM(u.first_name || ' ' || COALESCE(u.last_name, '')), ''), lr.edited_at
+        FROM log_revisions lr
+        LEFT JOIN users u ON u.id = lr.edited_by
+        WHERE lr.log_type = $1 AND lr.log_id = $2
+        ORDER BY lr.edited_at, lr.id
+    `, logType, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.LogRevision
+	for rows.Next() {
+		var rev models.LogRevision
+		if err := rows.Scan(&rev.ID, &rev.LogType, &rev.LogID, &rev.ChildID, &rev.Version, &rev.Changes, &rev.EditedBy,
+			&rev.EditorName, &rev.EditedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rev)
+	}
+	return out, rows.Err()
+}
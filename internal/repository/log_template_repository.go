@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// ErrLogTemplateNameTaken is returned when the family already has a
+// template of that log type with the name.
+var ErrLogTemplateNameTaken = apperr.Conflict("a template with that name already exists")
+
+// LogTemplateRepository stores families' log entry templates.
+type LogTemplateRepository interface {
+	// Create inserts the template; ID, CreatedAt and UpdatedAt are filled
+	// in. ErrLogTemplateNameTaken on a duplicate name.
+	Create(ctx context.Context, t *models.LogTemplate) error
+	// GetByID returns the template, or nil, nil.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.LogTemplate, error)
+	// ListForFamily returns the family's templates by name, only of
+	// logType when it isn't empty.
+	ListForFamily(ctx context.Context, familyID uuid.UUID, logType string) ([]models.LogTemplate, error)
+	// CountForFamily returns how many templates the family has.
+	CountForFamily(ctx context.Context, familyID uuid.UUID) (int, error)
+	// Update saves the template's log type, name and data.
+	// ErrLogTemplateNameTaken on a duplicate name.
+	Update(ctx context.Context, t *models.LogTemplate) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type logTemplateRepo struct {
+	db *DB
+}
+
+// NewLogTemplateRepo creates a LogTemplateRepository on the main pool.
+func NewLogTemplateRepo(db *sql.DB) LogTemplateRepository {
+	return &logTemplateRepo{db: WrapDB(db)}
+}
+
+func (r *logTemplateRepo) Create(ctx context.Context, t *models.LogTemplate) error {
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO log_templates (family_id, log_type, name, data, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, updated_at
+    `, t.FamilyID, t.LogType, t.Name, []byte(t.Data), t.CreatedBy,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrLogTemplateNameTaken
+	}
+	return err
+}
+
+const logTemplateColumns = `id, family_id, log_type, name, data, created_by, created_at, updated_at`
+
+func scanLogTemplate(row interface{ Scan(...any) error }, t *models.LogTemplate) error {
+	var data []byte
+	if err := row.Scan(&t.ID, &t.FamilyID, &t.LogType, &t.Name, &data, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return err
+	}
+	t.Data = data
+	return nil
+}
+
+func (r *logTemplateRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.LogTemplate, error) {
+	var t models.LogTemplate
+	err := scanLogTemplate(r.db.QueryRowContext(ctx, `SELECT `+logTemplateColumns+` FROM log_templates WHERE id = $1`, id), &t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *logTemplateRepo) ListForFamily(ctx context.Context, familyID uuid.UUID, logType string) ([]models.LogTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+logTemplateColumns+` FROM log_templates
+        WHERE family_id = $1 AND ($2 = '' OR log_type = $2)
+        ORDER BY LOWER(name), log_type`, familyID, logType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.LogTemplate
+	for rows.Next() {
+		var t models.LogTemplate
+		if err := scanLogTemplate(rows, &t); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *logTemplateRepo) CountForFamily(ctx context.Context, familyID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM log_templates WHERE family_id = $1`, familyID).Scan(&n)
+	return n, err
+}
+
+func (r *logTemplateRepo) Update(ctx context.Context, t *models.LogTemplate) error {
+	err := r.db.QueryRowContext(ctx, `
+        UPDATE log_templates SET log_type = $2, name = $3, data = $4, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, t.ID, t.LogType, t.Name, []byte(t.Data),
+	).Scan(&t.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrLogTemplateNameTaken
+	}
+	return err
+}
+
+func (r *logTemplateRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM log_templates WHERE id = $1`, id)
+	return err
+}
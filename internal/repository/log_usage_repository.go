@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// LogUsageTypes are the log types usage is tracked for, in display order,
+// keyed by the name used in log_usage_daily and log_entry_platforms.
+var LogUsageTypes = []string{
+	"behavior", "medication", "diet", "sleep", "bowel", "speech",
+	"weight", "sensory", "social", "therapy", "seizure", "health_event",
+}
+
+// logUsageTables maps each of LogUsageTypes to its table.
+var logUsageTables = map[string]string{
+	"behavior":     "behavior_logs",
+	"medication":   "medication_logs",
+	"diet":         "diet_logs",
+	"sleep":        "sleep_logs",
+	"bowel":        "bowel_logs",
+	"speech":       "speech_logs",
+	"weight":       "weight_logs",
+	"sensory":      "sensory_logs",
+	"social":       "social_logs",
+	"therapy":      "therapy_logs",
+	"seizure":      "seizure_logs",
+	"health_event": "health_event_logs",
+}
+
+// LogUsageDay is one log type's entries on one day (UTC), and how many
+// families created them.
+type LogUsageDay struct {
+	Date     time.Time `json:"date"`
+	LogType  string    `json:"log_type"`
+	Entries  int       `json:"entries"`
+	Families int       `json:"families"`
+}
+
+// LogPlatformDay is one log type's entries from one client platform on
+// one day (UTC).
+type LogPlatformDay struct {
+	Date     time.Time `json:"date"`
+	LogType  string    `json:"log_type"`
+	Platform string    `json:"platform"`
+	Entries  int       `json:"entries"`
+}
+
+// LogUsageRepository reads and writes per-log-type usage aggregates. No
+// PHI. log_usage_daily itself is written by adminRepo.RefreshMetrics.
+type LogUsageRepository interface {
+	// IncrementPlatform counts one entry of logType created from platform.
+	IncrementPlatform(ctx context.Context, at time.Time, logType, platform string) error
+	// Daily and Platforms return the rows between from and to (inclusive
+	// dates), oldest first.
+	Daily(ctx context.Context, from, to time.Time) ([]LogUsageDay, error)
+	Platforms(ctx context.Context, from, to time.Time) ([]LogPlatformDay, error)
+	// LastUsed returns when each log type last had an entry created, read
+	// from the log tables so it reaches back before tracking began. Types
+	// never used are absent.
+	LastUsed(ctx context.Context) (map[string]time.Time, error)
+}
+
+type logUsageRepo struct {
+	db *DB
+}
+
+// NewLogUsageRepo creates a LogUsageRepository on the main pool.
+func NewLogUsageRepo(db *sql.DB) LogUsageRepository {
+	return &logUsageRepo{db: WrapDB(db)}
+}
+
+// logUsageDailySQL recounts log_usage_daily for today and yesterday (UTC)
+// from the log tables, every log type in one statement; $2 is
+// LogUsageTypes. Families are counted through the entry's child.
+var logUsageDailySQL = func() string {
+	parts := make([]string, len(LogUsageTypes))
+	for i, t := range LogUsageTypes {
+		parts[i] = `
+            SELECT (l.created_at AT TIME ZONE 'UTC')::date AS usage_date, '` + t + `' AS log_type,
+                   COUNT(*) AS entries, COUNT(DISTINCT c.family_id) AS families
+            FROM ` + logUsageTables[t] + ` l
+            JOIN children c ON c.id = l.child_id
+            WHERE l.created_at >= (($1::timestamptz AT TIME ZONE 'UTC')::date - 1)::timestamp AT TIME ZONE 'UTC'
+            GROUP BY 1`
+	}
+	return `
+        INSERT INTO log_usage_daily (usage_date, log_type, entries, families, recorded_at)
+        SELECT d.usage_date, t.log_type, COALESCE(u.entries, 0), COALESCE(u.families, 0), $1
+        FROM (VALUES (($1::timestamptz AT TIME ZONE 'UTC')::date - 1), (($1::timestamptz AT TIME ZONE 'UTC')::date)) AS d(usage_date)
+        CROSS JOIN UNNEST($2::text[]) AS t(log_type)
+        LEFT JOIN (` + strings.Join(parts, `
+            UNION ALL`) + `
+        ) u ON u.usage_date = d.usage_date AND u.log_type = t.log_type
+        ON CONFLICT (usage_date, log_type) DO UPDATE SET
+            entries     = EXCLUDED.entries,
+            families    = EXCLUDED.families,
+            recorded_at = EXCLUDED.recorded_at`
+}()
+
+func (r *logUsageRepo) IncrementPlatform(ctx context.Context, at time.Time, logType, platform string) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO log_entry_platforms (usage_date, log_type, platform, entries)
+        VALUES (($1::timestamptz AT TIME ZONE 'UTC')::date, $2, $3, 1)
+        ON CONFLICT (usage_date, log_type, platform) DO UPDATE SET
+            entries = log_entry_platforms.entries + 1`, at, logType, platform)
+	return err
+}
+
+func (r *logUsageRepo) Daily(ctx context.Context, from, to time.Time) ([]LogUsageDay, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT usage_date, log_type, entries, families
+        FROM log_usage_daily
+        WHERE usage_date BETWEEN $1::date AND $2::date
+        ORDER BY usage_date, log_type`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LogUsageDay
+	for rows.Next() {
+		var d LogUsageDay
+		if err := rows.Scan(&d.Date, &d.LogType, &d.Entries, &d.Families); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *logUsageRepo) Platforms(ctx context.Context, from, to time.Time) ([]LogPlatformDay, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT usage_date, log_type, platform, entries
+        FROM log_entry_platforms
+        WHERE usage_date BETWEEN $1::date AND $2::date
+        ORDER BY usage_date, log_type, platform`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LogPlatformDay
+	for rows.Next() {
+		var d LogPlatformDay
+		if err := rows.Scan(&d.Date, &d.LogType, &d.Platform, &d.Entries); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *logUsageRepo) LastUsed(ctx context.Context) (map[string]time.Time, error) {
+	parts := make([]string, len(LogUsageTypes))
+	for i, t := range LogUsageTypes {
+		parts[i] = `SELECT '` + t + `', MAX(created_at) FROM ` + logUsageTables[t]
+	}
+	rows, err := r.db.QueryContext(ctx, strings.Join(parts, " UNION ALL "))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]time.Time{}
+	for rows.Next() {
+		var t string
+		var last sql.NullTime
+		if err := rows.Scan(&t, &last); err != nil {
+			return nil, err
+		}
+		if last.Valid {
+			out[t] = last.Time
+		}
+	}
+	return out, rows.Err()
+}
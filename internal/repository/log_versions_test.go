@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// TestLogCreateThenUpdate edits a log at the version its create returned,
+// as a client using the create response's ETag does.
+func TestLogCreateThenUpdate(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	var childID, userID uuid.UUID
+	if err := db.QueryRowContext(ctx, `
+		SELECT c.id, fm.user_id FROM children c
+		JOIN family_memberships fm ON fm.family_id = c.family_id
+		LIMIT 1`).Scan(&childID, &userID); err != nil {
+		t.Skipf("need a child with a family member: %v", err)
+	}
+	repo := repository.NewLogRepo(db)
+
+	log := &models.BowelLog{ChildID: childID, LogDate: time.Now(), LoggedBy: userID}
+	if err := repo.CreateBowelLog(ctx, log); err != nil {
+		t.Fatal(err)
+	}
+	defer repo.DeleteBowelLog(ctx, log.ID)
+	if log.Version != 1 {
+		t.Fatalf("created at version %d, want 1", log.Version)
+	}
+	stored, err := repo.GetBowelLogByID(ctx, log.ID)
+	if err != nil || stored == nil || stored.Version != log.Version {
+		t.Fatalf("stored = %+v, %v; want version %d", stored, err, log.Version)
+	}
+
+	log.HadAccident = true
+	if err := repo.UpdateBowelLog(ctx, log); err != nil {
+		t.Fatalf("update at the created version: %v", err)
+	}
+	if log.Version != 2 {
+		t.Errorf("updated to version %d, want 2", log.Version)
+	}
+	stale := *log
+	stale.Version = 1
+	if err := repo.UpdateBowelLog(ctx, &stale); !errors.Is(err, repository.ErrLogVersionConflict) {
+		t.Errorf("stale update = %v, want ErrLogVersionConflict", err)
+	}
+}
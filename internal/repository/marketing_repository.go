@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,16 +31,25 @@ type MarketingRepository interface {
 
 	// Statistics for dynamic content
 	GetMarketingStats(ctx context.Context) (*models.MarketingStats, error)
+
+	// Pricing for print collateral
+	ListPricingPlans(ctx context.Context) ([]models.PricingPlan, error)
+
+	// Brand fonts
+	ListBrandFonts(ctx context.Context) ([]models.BrandFont, error)
+	GetBrandFont(ctx context.Context, id uuid.UUID) (*models.BrandFont, error)
+	UpsertBrandFont(ctx context.Context, font *models.BrandFont) (replaced *models.BrandFont, err error)
+	DeleteBrandFont(ctx context.Context, id uuid.UUID) error
 }
 
 // MarketingRepo implements MarketingRepository
 type MarketingRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewMarketingRepo creates a new marketing repository
 func NewMarketingRepo(db *sql.DB) *MarketingRepo {
-	return &MarketingRepo{db: db}
+	return &MarketingRepo{db: WrapDB(db)}
 }
 
 // GetBrandConfig retrieves the brand configuration (there's only one row)
@@ -54,6 +64,7 @@ func (r *MarketingRepo) GetBrandConfig(ctx context.Context) (*models.BrandConfig
 			website_url, support_email, contact_phone,
 			facebook_url, twitter_url, instagram_url, linkedin_url,
 			copyright_text, disclaimer_text,
+			qr_target_url, qr_utm_campaign,
 			updated_at, updated_by
 		FROM brand_config
 		LIMIT 1
@@ -63,6 +74,7 @@ func (r *MarketingRepo) GetBrandConfig(ctx context.Context) (*models.BrandConfig
 	var missionStatement, brandVoice, writingGuidelines sql.NullString
 	var contactPhone, facebookURL, twitterURL, instagramURL, linkedInURL sql.NullString
 	var copyrightText, disclaimerText sql.NullString
+	var qrTargetURL, qrUTMCampaign sql.NullString
 	var updatedBy sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query).Scan(
@@ -75,6 +87,7 @@ func (r *MarketingRepo) GetBrandConfig(ctx context.Context) (*models.BrandConfig
 		&config.WebsiteURL, &config.SupportEmail, &contactPhone,
 		&facebookURL, &twitterURL, &instagramURL, &linkedInURL,
 		&copyrightText, &disclaimerText,
+		&qrTargetURL, &qrUTMCampaign,
 		&config.UpdatedAt, &updatedBy,
 	)
 	if err != nil {
@@ -92,6 +105,8 @@ func (r *MarketingRepo) GetBrandConfig(ctx context.Context) (*models.BrandConfig
 	config.LinkedInURL = linkedInURL.String
 	config.CopyrightText = copyrightText.String
 	config.DisclaimerText = disclaimerText.String
+	config.QRTargetURL = qrTargetURL.String
+	config.QRUTMCampaign = qrUTMCampaign.String
 
 	if updatedBy.Valid {
 		id, _ := uuid.Parse(updatedBy.String)
@@ -128,9 +143,11 @@ func (r *MarketingRepo) UpdateBrandConfig(ctx context.Context, config *models.Br
 			linkedin_url = $21,
 			copyright_text = $22,
 			disclaimer_text = $23,
+			qr_target_url = $24,
+			qr_utm_campaign = $25,
 			updated_at = NOW(),
-			updated_by = $24
-		WHERE id = $25
+			updated_by = $26
+		WHERE id = $27
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -144,6 +161,7 @@ func (r *MarketingRepo) UpdateBrandConfig(ctx context.Context, config *models.Br
 		nullIfEmpty(config.FacebookURL), nullIfEmpty(config.TwitterURL),
 		nullIfEmpty(config.InstagramURL), nullIfEmpty(config.LinkedInURL),
 		nullIfEmpty(config.CopyrightText), nullIfEmpty(config.DisclaimerText),
+		nullIfEmpty(config.QRTargetURL), nullIfEmpty(config.QRUTMCampaign),
 		updatedBy, config.ID,
 	)
 
@@ -513,3 +531,127 @@ func nullIfZero64(i int64) interface{} {
 	}
 	return i
 }
+
+// ListPricingPlans returns active subscription plans, cheapest first.
+// Features is a JSON array of strings in subscription_plans.
+func (r *MarketingRepo) ListPricingPlans(ctx context.Context) ([]models.PricingPlan, error) {
+	query := `
+		SELECT name, COALESCE(description, ''), price_cents, billing_interval,
+			COALESCE(features, '[]'::jsonb), max_children, max_family_members
+		FROM subscription_plans
+		WHERE is_active = TRUE
+		ORDER BY billing_interval, price_cents
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []models.PricingPlan
+	for rows.Next() {
+		var p models.PricingPlan
+		var features []byte
+		if err := rows.Scan(&p.Name, &p.Description, &p.PriceCents, &p.BillingInterval,
+			&features, &p.MaxChildren, &p.MaxFamilyMembers); err != nil {
+			return nil, err
+		}
+		// Older rows may hold an object; treat anything but a list as empty.
+		_ = json.Unmarshal(features, &p.Features)
+		plans = append(plans, p)
+	}
+	return plans, rows.Err()
+}
+
+const brandFontColumns = `id, family, style, format, original_filename, storage_driver,
+	storage_path, size_bytes, sha256, COALESCE(license_note, ''), uploaded_by, created_at`
+
+func scanBrandFont(row rowScannerLike) (*models.BrandFont, error) {
+	var f models.BrandFont
+	var uploadedBy uuid.NullUUID
+	if err := row.Scan(&f.ID, &f.Family, &f.Style, &f.Format, &f.OriginalFilename, &f.StorageDriver,
+		&f.StoragePath, &f.SizeBytes, &f.SHA256, &f.LicenseNote, &uploadedBy, &f.CreatedAt); err != nil {
+		return nil, err
+	}
+	if uploadedBy.Valid {
+		f.UploadedBy = &uploadedBy.UUID
+	}
+	return &f, nil
+}
+
+// ListBrandFonts returns all uploaded fonts ordered by family then style
+func (r *MarketingRepo) ListBrandFonts(ctx context.Context) ([]models.BrandFont, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+brandFontColumns+` FROM brand_fonts ORDER BY LOWER(family), style`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fonts []models.BrandFont
+	for rows.Next() {
+		f, err := scanBrandFont(rows)
+		if err != nil {
+			return nil, err
+		}
+		fonts = append(fonts, *f)
+	}
+	return fonts, rows.Err()
+}
+
+// GetBrandFont returns one font, or nil if it doesn't exist
+func (r *MarketingRepo) GetBrandFont(ctx context.Context, id uuid.UUID) (*models.BrandFont, error) {
+	f, err := scanBrandFont(r.db.QueryRowContext(ctx, `SELECT `+brandFontColumns+` FROM brand_fonts WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return f, err
+}
+
+// UpsertBrandFont stores a font, replacing any existing file for the same
+// family (case-insensitive) and style. The replaced row is returned so the
+// caller can delete its blob.
+func (r *MarketingRepo) UpsertBrandFont(ctx context.Context, font *models.BrandFont) (*models.BrandFont, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	replaced, err := scanBrandFont(tx.QueryRowContext(ctx,
+		`SELECT `+brandFontColumns+` FROM brand_fonts WHERE LOWER(family) = LOWER($1) AND style = $2 FOR UPDATE`,
+		font.Family, font.Style))
+	if err == sql.ErrNoRows {
+		replaced = nil
+	} else if err != nil {
+		return nil, err
+	}
+	if replaced != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM brand_fonts WHERE id = $1`, replaced.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	var uploadedBy interface{}
+	if font.UploadedBy != nil {
+		uploadedBy = *font.UploadedBy
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO brand_fonts (family, style, format, original_filename, storage_driver,
+			storage_path, size_bytes, sha256, license_note, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+		RETURNING id, created_at
+	`, font.Family, font.Style, font.Format, font.OriginalFilename, font.StorageDriver,
+		font.StoragePath, font.SizeBytes, font.SHA256, font.LicenseNote, uploadedBy,
+	).Scan(&font.ID, &font.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return replaced, tx.Commit()
+}
+
+// DeleteBrandFont removes a font row
+func (r *MarketingRepo) DeleteBrandFont(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM brand_fonts WHERE id = $1`, id)
+	return err
+}
@@ -12,11 +12,11 @@ import (
 )
 
 type medicationRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewMedicationRepo(db *sql.DB) MedicationRepository {
-	return &medicationRepo{db: db}
+	return &medicationRepo{db: WrapDB(db)}
 }
 
 func (r *medicationRepo) Create(ctx context.Context, med *models.Medication) error {
@@ -294,6 +294,7 @@ func (r *medicationRepo) CreateLog(ctx context.Context, log *models.MedicationLo
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
 	log.UpdatedAt = time.Now()
+	log.Version = 1
 
 	_, err := r.db.ExecContext(ctx, query,
 		log.ID, log.MedicationID, log.ChildID, log.ScheduleID, log.LogDate,
@@ -305,7 +306,7 @@ func (r *medicationRepo) CreateLog(ctx context.Context, log *models.MedicationLo
 
 func (r *medicationRepo) GetLogByID(ctx context.Context, id uuid.UUID) (*models.MedicationLog, error) {
 	query := `
-		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at
+		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at, version
 		FROM medication_logs
 		WHERE id = $1
 	`
@@ -313,7 +314,7 @@ func (r *medicationRepo) GetLogByID(ctx context.Context, id uuid.UUID) (*models.
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&log.ID, &log.MedicationID, &log.ChildID, &log.ScheduleID, &log.LogDate,
 		&log.ScheduledTime, &log.ActualTime, &log.Status, &log.DosageGiven,
-		&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt,
+		&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt, &log.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -326,7 +327,7 @@ func (r *medicationRepo) GetLogByID(ctx context.Context, id uuid.UUID) (*models.
 
 func (r *medicationRepo) GetLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.MedicationLog, error) {
 	query := `
-		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at
+		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at, version
 		FROM medication_logs
 		WHERE child_id = $1 AND log_date BETWEEN $2 AND $3
 		ORDER BY log_date DESC, created_at DESC
@@ -343,7 +344,7 @@ func (r *medicationRepo) GetLogs(ctx context.Context, childID uuid.UUID, startDa
 		err := rows.Scan(
 			&log.ID, &log.MedicationID, &log.ChildID, &log.ScheduleID, &log.LogDate,
 			&log.ScheduledTime, &log.ActualTime, &log.Status, &log.DosageGiven,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt,
+			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt, &log.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -355,7 +356,7 @@ func (r *medicationRepo) GetLogs(ctx context.Context, childID uuid.UUID, startDa
 
 func (r *medicationRepo) GetLogsByMedication(ctx context.Context, medicationID uuid.UUID, startDate, endDate time.Time) ([]models.MedicationLog, error) {
 	query := `
-		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at
+		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at, version
 		FROM medication_logs
 		WHERE medication_id = $1 AND log_date BETWEEN $2 AND $3
 		ORDER BY log_date DESC, created_at DESC
@@ -372,7 +373,7 @@ func (r *medicationRepo) GetLogsByMedication(ctx context.Context, medicationID u
 		err := rows.Scan(
 			&log.ID, &log.MedicationID, &log.ChildID, &log.ScheduleID, &log.LogDate,
 			&log.ScheduledTime, &log.ActualTime, &log.Status, &log.DosageGiven,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt,
+			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt, &log.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -384,7 +385,7 @@ func (r *medicationRepo) GetLogsByMedication(ctx context.Context, medicationID u
 
 func (r *medicationRepo) GetLogsByMedicationSince(ctx context.Context, medicationID uuid.UUID, since time.Time) ([]models.MedicationLog, error) {
 	query := `
-		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at
+		SELECT id, medication_id, child_id, schedule_id, log_date, scheduled_time::text, actual_time::text, status, dosage_given, notes, logged_by, created_at, updated_at, version
 		FROM medication_logs
 		WHERE medication_id = $1 AND log_date >= $2
 		ORDER BY log_date DESC, created_at DESC
@@ -401,7 +402,7 @@ func (r *medicationRepo) GetLogsByMedicationSince(ctx context.Context, medicatio
 		err := rows.Scan(
 			&log.ID, &log.MedicationID, &log.ChildID, &log.ScheduleID, &log.LogDate,
 			&log.ScheduledTime, &log.ActualTime, &log.Status, &log.DosageGiven,
-			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt,
+			&log.Notes, &log.LoggedBy, &log.CreatedAt, &log.UpdatedAt, &log.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -414,14 +415,19 @@ func (r *medicationRepo) GetLogsByMedicationSince(ctx context.Context, medicatio
 func (r *medicationRepo) UpdateLog(ctx context.Context, log *models.MedicationLog) error {
 	query := `
 		UPDATE medication_logs
-		SET status = $2, actual_time = $3, dosage_given = $4, notes = $5, updated_at = $6
-		WHERE id = $1
+		SET status = $2, actual_time = $3, dosage_given = $4, notes = $5, updated_at = $6, version = version + 1
+		WHERE id = $1 AND version = $7
+		RETURNING version
 	`
-	log.UpdatedAt = time.Now()
-	_, err := r.db.ExecContext(ctx, query,
-		log.ID, log.Status, log.ActualTime, log.DosageGiven, log.Notes, log.UpdatedAt,
-	)
-	return err
+	updatedAt := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		log.ID, log.Status, log.ActualTime, log.DosageGiven, log.Notes, updatedAt, log.Version,
+	).Scan(&log.Version)
+	if err := versionedUpdate(err); err != nil {
+		return err
+	}
+	log.UpdatedAt = updatedAt
+	return nil
 }
 
 func (r *medicationRepo) DeleteLog(ctx context.Context, id uuid.UUID) error {
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// MetricSample is one bucket (a UTC day or hour, by its start) of a system
+// metric series. Buckets with nothing recorded are absent.
+type MetricSample struct {
+	Bucket time.Time `json:"bucket"`
+	Value  float64   `json:"value"`
+}
+
+// MetricSeriesRepository reads system metric series from the tables they
+// are already stored in, for anomaly detection. Each returns the buckets
+// starting in [from, to), oldest first. Aggregates only, no PHI.
+type MetricSeriesRepository interface {
+	// DailySignups counts new users per day.
+	DailySignups(ctx context.Context, from, to time.Time) ([]MetricSample, error)
+	// DailyRevenue is total revenue per day in cents, from the snapshots the
+	// revenue snapshot job writes each morning for the day before.
+	DailyRevenue(ctx context.Context, from, to time.Time) ([]MetricSample, error)
+	// HourlyServerErrors counts 5xx errors per hour from error_log_hourly,
+	// so only hours the log retention job has rolled up are included.
+	HourlyServerErrors(ctx context.Context, from, to time.Time) ([]MetricSample, error)
+	// HourlyResponseTimes is the mean response time in ms per hour from
+	// response_time_hourly.
+	HourlyResponseTimes(ctx context.Context, from, to time.Time) ([]MetricSample, error)
+}
+
+type metricSeriesRepo struct {
+	db *DB
+}
+
+// NewMetricSeriesRepo creates a MetricSeriesRepository on the main pool.
+func NewMetricSeriesRepo(db *sql.DB) MetricSeriesRepository {
+	return &metricSeriesRepo{db: WrapDB(db)}
+}
+
+func (r *metricSeriesRepo) DailySignups(ctx context.Context, from, to time.Time) ([]MetricSample, error) {
+	return r.query(ctx, `
+        SELECT DATE_TRUNC('day', created_at AT TIME ZONE 'UTC') AS bucket, COUNT(*)
+        FROM users
+        WHERE created_at >= $1 AND created_at < $2
+        GROUP BY 1 ORDER BY 1`, from, to)
+}
+
+func (r *metricSeriesRepo) DailyRevenue(ctx context.Context, from, to time.Time) ([]MetricSample, error) {
+	return r.query(ctx, `
+        SELECT snapshot_date::timestamp, total_revenue_cents
+        FROM daily_revenue_snapshots
+        WHERE snapshot_date >= $1::date AND snapshot_date < $2::date
+        ORDER BY snapshot_date`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+func (r *metricSeriesRepo) HourlyServerErrors(ctx context.Context, from, to time.Time) ([]MetricSample, error) {
+	return r.query(ctx, `
+        SELECT hour AT TIME ZONE 'UTC', SUM(error_count)
+        FROM error_log_hourly
+        WHERE status_code >= 500 AND hour >= $1 AND hour < $2
+        GROUP BY hour ORDER BY hour`, from, to)
+}
+
+func (r *metricSeriesRepo) HourlyResponseTimes(ctx context.Context, from, to time.Time) ([]MetricSample, error) {
+	return r.query(ctx, `
+        SELECT hour AT TIME ZONE 'UTC', SUM(total_ms) / SUM(request_count)
+        FROM response_time_hourly
+        WHERE hour >= $1 AND hour < $2
+        GROUP BY hour
+        HAVING SUM(request_count) > 0
+        ORDER BY hour`, from, to)
+}
+
+// query scans (bucket, value) rows. Buckets come back as UTC timestamps
+// without a zone and are read as UTC.
+func (r *metricSeriesRepo) query(ctx context.Context, q string, args ...any) ([]MetricSample, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []MetricSample
+	for rows.Next() {
+		var s MetricSample
+		if err := rows.Scan(&s.Bucket, &s.Value); err != nil {
+			return nil, err
+		}
+		s.Bucket = time.Date(s.Bucket.Year(), s.Bucket.Month(), s.Bucket.Day(), s.Bucket.Hour(), 0, 0, 0, time.UTC)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// OnboardingCandidate is a new user who may be stalled in guided setup:
+// the steps they've resolved and the steps they've already been nudged
+// about. Timezone is the user's IANA zone, or empty.
+type OnboardingCandidate struct {
+	UserID   uuid.UUID
+	Timezone string
+	Resolved []string
+	Nudged   []string
+}
+
+// OnboardingFunnelStep is how many of a signup cohort resolved one setup
+// step. MedianHours is signup to completion, nil when nobody completed it.
+type OnboardingFunnelStep struct {
+	Step        string   `json:"step"`
+	Completed   int      `json:"completed"`
+	Skipped     int      `json:"skipped"`
+	Nudged      int      `json:"nudged"`
+	MedianHours *float64 `json:"median_hours"`
+}
+
+// OnboardingRepository owns user_onboarding_steps and onboarding_nudges.
+type OnboardingRepository interface {
+	// ReconcileUser records any setup steps userID has completed since the
+	// last check.
+	ReconcileUser(ctx context.Context, userID uuid.UUID) error
+	// ReconcileSince does the same for every user who signed up at or
+	// after since, returning how many steps were newly recorded.
+	ReconcileSince(ctx context.Context, since time.Time) (int64, error)
+	// Steps returns userID's resolved steps.
+	Steps(ctx context.Context, userID uuid.UUID) ([]models.OnboardingStepRecord, error)
+	// Skip records step as skipped; a step already resolved is unchanged.
+	Skip(ctx context.Context, userID uuid.UUID, step string) error
+	// Stalled returns users who signed up at or after signedUpSince, still
+	// have unresolved steps, haven't dismissed the setup checklist, and
+	// have had no setup progress or nudge since idleBefore.
+	Stalled(ctx context.Context, signedUpSince, idleBefore time.Time, limit int) ([]OnboardingCandidate, error)
+	// RecordNudge records that userID was reminded about step.
+	RecordNudge(ctx context.Context, userID uuid.UUID, step string) error
+	// Funnel returns the users who signed up in [from, to) and, per step,
+	// how many of them resolved it.
+	Funnel(ctx context.Context, from, to time.Time) (signedUp int, steps []OnboardingFunnelStep, err error)
+}
+
+type onboardingRepo struct {
+	db *DB
+}
+
+// NewOnboardingRepo creates an OnboardingRepository on the main pool.
+func NewOnboardingRepo(db *sql.DB) OnboardingRepository {
+	return &onboardingRepo{db: WrapDB(db)}
+}
+
+// onboardingReconcileSQL inserts each detectable step's first completion
+// for the users selected by cond (over app_users). Membership in any live
+// family counts as create_family, so invited caregivers aren't asked to
+// make a second one. A skipped step that's later done for real is flipped
+// to completed.
+func onboardingReconcileSQL(cond string) string {
+	logs := make([]string, len(warehouseLogTables))
+	for i, t := range warehouseLogTables {
+		logs[i] = "SELECT logged_by, created_at FROM " + t.table + " WHERE logged_by IN (SELECT id FROM users)"
+	}
+	return `
+        WITH users AS (
+            SELECT id FROM app_users WHERE deleted_at IS NULL AND ` + cond + `
+        ), memberships AS (
+            SELECT m.user_id, m.family_id, m.created_at
+            FROM family_memberships m
+            JOIN families f ON f.id = m.family_id AND f.deleted_at IS NULL
+            WHERE m.is_active AND m.user_id IN (SELECT id FROM users)
+        ), logs AS (
+            ` + strings.Join(logs, "\n            UNION ALL ") + `
+        )
+        INSERT INTO user_onboarding_steps (user_id, step, completed_at)
+        SELECT id, '` + models.OnboardingStepVerifyEmail + `', email_verified_at
+        FROM app_users WHERE id IN (SELECT id FROM users) AND email_verified_at IS NOT NULL
+        UNION ALL
+        SELECT user_id, '` + models.OnboardingStepCreateFamily + `', COALESCE(MIN(created_at), NOW())
+        FROM memberships GROUP BY user_id
+        UNION ALL
+        SELECT m.user_id, '` + models.OnboardingStepAddChild + `', COALESCE(MIN(c.created_at), NOW())
+        FROM memberships m JOIN children c ON c.family_id = m.family_id
+        GROUP BY m.user_id
+        UNION ALL
+        SELECT m.user_id, '` + models.OnboardingStepAddMedication + `', COALESCE(MIN(md.created_at), NOW())
+        FROM memberships m JOIN children c ON c.family_id = m.family_id JOIN medications md ON md.child_id = c.id
+        GROUP BY m.user_id
+        UNION ALL
+        SELECT logged_by, '` + models.OnboardingStepFirstLog + `', COALESCE(MIN(created_at), NOW())
+        FROM logs GROUP BY logged_by
+        ON CONFLICT (user_id, step) DO UPDATE SET
+            completed_at = EXCLUDED.completed_at,
+            skipped      = FALSE
+        WHERE user_onboarding_steps.skipped`
+}
+
+func (r *onboardingRepo) ReconcileUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, onboardingReconcileSQL("id = $1"), userID)
+	return err
+}
+
+func (r *onboardingRepo) ReconcileSince(ctx context.Context, since time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, onboardingReconcileSQL("created_at >= $1"), since)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *onboardingRepo) Steps(ctx context.Context, userID uuid.UUID) ([]models.OnboardingStepRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT step, completed_at, skipped FROM user_onboarding_steps WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.OnboardingStepRecord
+	for rows.Next() {
+		var s models.OnboardingStepRecord
+		if err := rows.Scan(&s.Step, &s.CompletedAt, &s.Skipped); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *onboardingRepo) Skip(ctx context.Context, userID uuid.UUID, step string) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO user_onboarding_steps (user_id, step, completed_at, skipped)
+        VALUES ($1, $2, NOW(), TRUE)
+        ON CONFLICT (user_id, step) DO NOTHING`, userID, step)
+	return err
+}
+
+func (r *onboardingRepo) Stalled(ctx context.Context, signedUpSince, idleBefore time.Time, limit int) ([]OnboardingCandidate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT u.id, COALESCE(u.timezone, ''),
+               ARRAY(SELECT step FROM user_onboarding_steps s WHERE s.user_id = u.id),
+               ARRAY(SELECT step FROM onboarding_nudges n WHERE n.user_id = u.id)
+        FROM app_users u
+        WHERE u.deleted_at IS NULL
+          AND u.status IN ('active', 'pending_verification')
+          AND u.onboarding_checklist_dismissed_at IS NULL
+          AND u.created_at >= $1
+          AND (SELECT COUNT(*) FROM user_onboarding_steps s WHERE s.user_id = u.id) < $3
+          AND GREATEST(
+                u.created_at,
+                (SELECT MAX(completed_at) FROM user_onboarding_steps s WHERE s.user_id = u.id),
+                (SELECT MAX(sent_at) FROM onboarding_nudges n WHERE n.user_id = u.id)
+              ) < $2
+        ORDER BY u.created_at
+        LIMIT $4`, signedUpSince, idleBefore, len(models.OnboardingSteps), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []OnboardingCandidate
+	for rows.Next() {
+		var c OnboardingCandidate
+		if err := rows.Scan(&c.UserID, &c.Timezone, pq.Array(&c.Resolved), pq.Array(&c.Nudged)); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *onboardingRepo) RecordNudge(ctx context.Context, userID uuid.UUID, step string) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO onboarding_nudges (user_id, step) VALUES ($1, $2)
+        ON CONFLICT (user_id, step) DO NOTHING`, userID, step)
+	return err
+}
+
+func (r *onboardingRepo) Funnel(ctx context.Context, from, to time.Time) (int, []OnboardingFunnelStep, error) {
+	var signedUp int
+	if err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM app_users
+        WHERE deleted_at IS NULL AND created_at >= $1 AND created_at < $2`, from, to).Scan(&signedUp); err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        WITH cohort AS (
+            SELECT id, created_at FROM app_users
+            WHERE deleted_at IS NULL AND created_at >= $1 AND created_at < $2
+        )
+        SELECT st.step,
+               COUNT(c.id) FILTER (WHERE NOT s.skipped),
+               COUNT(c.id) FILTER (WHERE s.skipped),
+               (SELECT COUNT(*) FROM onboarding_nudges n JOIN cohort nc ON nc.id = n.user_id WHERE n.step = st.step),
+               percentile_cont(0.5) WITHIN GROUP (
+                   ORDER BY GREATEST(0, EXTRACT(EPOCH FROM s.completed_at - c.created_at) / 3600)
+               ) FILTER (WHERE NOT s.skipped)
+        FROM unnest($3::text[]) AS st(step)
+        LEFT JOIN (user_onboarding_steps s JOIN cohort c ON c.id = s.user_id) ON s.step = st.step
+        GROUP BY st.step`, from, to, pq.Array(models.OnboardingSteps))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	byStep := map[string]OnboardingFunnelStep{}
+	for rows.Next() {
+		var s OnboardingFunnelStep
+		var median sql.NullFloat64
+		if err := rows.Scan(&s.Step, &s.Completed, &s.Skipped, &s.Nudged, &median); err != nil {
+			return 0, nil, err
+		}
+		if median.Valid {
+			s.MedianHours = &median.Float64
+		}
+		byStep[s.Step] = s
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	steps := make([]OnboardingFunnelStep, len(models.OnboardingSteps))
+	for i, step := range models.OnboardingSteps {
+		steps[i] = byStep[step]
+		steps[i].Step = step
+	}
+	return signedUp, steps, nil
+}
@@ -0,0 +1,643 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+)
+
+// Organization roles.
+const (
+	OrgRoleAdmin     = "admin"
+	OrgRoleClinician = "clinician"
+)
+
+// Organization statuses.
+const (
+	OrgStatusActive    = "active"
+	OrgStatusSuspended = "suspended"
+)
+
+// Who changed an organization's seat limit.
+const (
+	OrgSeatSourceOrgAdmin = "org_admin"
+	OrgSeatSourceAdmin    = "admin"
+	OrgSeatSourceStripe   = "stripe"
+)
+
+var (
+	// ErrOrgJoinCodeTaken is returned when a generated join code collides.
+	ErrOrgJoinCodeTaken = apperr.Conflict("organization join code already in use")
+	// ErrOrgSeatsFull is returned when linking a family would exceed the
+	// organization's seat limit.
+	ErrOrgSeatsFull = apperr.Conflict("organization has no free seats")
+	// ErrFamilyHasOrganization is returned when the family is already
+	// linked to an organization.
+	ErrFamilyHasOrganization = apperr.Conflict("family already belongs to an organization")
+	// ErrOrgSeatsInUse is returned when a seat change would leave fewer
+	// seats than families linked.
+	ErrOrgSeatsInUse = apperr.Conflict("more families are linked than that many seats")
+)
+
+// Organization is a clinic or practice whose subscription covers its
+// client families.
+type Organization struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	ContactEmail string    `json:"contact_email,omitempty"`
+	Status       string    `json:"status"`
+	SeatLimit    int       `json:"seat_limit"`
+	SeatsUsed    int       `json:"seats_used"`
+	MemberCount  int       `json:"member_count"`
+	// PlanID and BillingStatus are set once the organization subscribes to
+	// a seat-based plan; BillingStatus is the Stripe subscription status.
+	PlanID        *uuid.UUID `json:"plan_id,omitempty"`
+	BillingStatus string     `json:"billing_status,omitempty"`
+	// JoinCode is what a parent enters to link their family. Only shown to
+	// org admins.
+	JoinCode  string     `json:"join_code,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// OrganizationMembership is an organization as seen by one of its members.
+type OrganizationMembership struct {
+	Organization
+	Role string `json:"role"`
+}
+
+// OrganizationMember is a user on an organization's staff.
+type OrganizationMember struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrganizationFamily is a client family, counts only.
+type OrganizationFamily struct {
+	FamilyID    uuid.UUID `json:"family_id"`
+	Name        string    `json:"name"`
+	MemberCount int       `json:"member_count"`
+	ChildCount  int       `json:"child_count"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// OrgEntryCount is log entries of one type across an organization's
+// families.
+type OrgEntryCount struct {
+	LogType  string `json:"log_type"`
+	Entries  int64  `json:"entries"`
+	Children int64  `json:"children"`
+}
+
+// OrganizationReport is an organization's aggregate activity since a date.
+type OrganizationReport struct {
+	Families       int             `json:"families"`
+	Children       int             `json:"children"`
+	ActiveFamilies int             `json:"active_families"`
+	Entries        []OrgEntryCount `json:"entries"`
+}
+
+// OrganizationBilling is an organization's seat-based subscription.
+type OrganizationBilling struct {
+	OrganizationID           uuid.UUID
+	PlanID                   *uuid.UUID
+	Status                   string
+	CurrentPeriodEnd         *time.Time
+	StripeCustomerID         string
+	StripeSubscriptionID     string
+	StripeSubscriptionItemID string
+}
+
+// OrgSeatChange is one change to an organization's seat limit.
+type OrgSeatChange struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	FromSeats      int        `json:"from_seats"`
+	ToSeats        int        `json:"to_seats"`
+	ProrationCents int64      `json:"proration_cents"`
+	Source         string     `json:"source"`
+	ChangedBy      *uuid.UUID `json:"changed_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// OrgSeatUsage is one organization's seat utilization.
+type OrgSeatUsage struct {
+	OrganizationID    uuid.UUID `json:"organization_id"`
+	Name              string    `json:"name"`
+	Status            string    `json:"status"`
+	PlanName          string    `json:"plan_name,omitempty"`
+	PricePerSeatCents int       `json:"price_per_seat_cents"`
+	BillingStatus     string    `json:"billing_status,omitempty"`
+	SeatLimit         int       `json:"seat_limit"`
+	SeatsUsed         int       `json:"seats_used"`
+}
+
+// OrganizationRepository owns organizations, their members and the
+// family links (seats).
+type OrganizationRepository interface {
+	List(ctx context.Context) ([]Organization, error)
+	// Get returns the organization with its seat and member counts, or nil.
+	Get(ctx context.Context, id uuid.UUID) (*Organization, error)
+	// GetByJoinCode returns the organization with code, or nil.
+	GetByJoinCode(ctx context.Context, code string) (*Organization, error)
+	Create(ctx context.Context, o *Organization) error
+	// Update saves name, contact email and status; false when the
+	// organization doesn't exist. Seat limits change through ChangeSeats.
+	Update(ctx context.Context, o *Organization) (bool, error)
+	SetJoinCode(ctx context.Context, id uuid.UUID, code string) error
+
+	// ForUser returns the organizations the user is on the staff of.
+	ForUser(ctx context.Context, userID uuid.UUID) ([]OrganizationMembership, error)
+	// MemberRole returns the user's role in the organization, or "".
+	MemberRole(ctx context.Context, orgID, userID uuid.UUID) (string, error)
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]OrganizationMember, error)
+	// AddMember adds (or changes the role of) the app user with email; nil
+	// when there is no such user.
+	AddMember(ctx context.Context, orgID uuid.UUID, email, role string, addedBy uuid.UUID) (*OrganizationMember, error)
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error)
+
+	// LinkFamily takes a seat for the family. It fails with
+	// ErrOrgSeatsFull or ErrFamilyHasOrganization.
+	LinkFamily(ctx context.Context, orgID, familyID, addedBy uuid.UUID) error
+	UnlinkFamily(ctx context.Context, orgID, familyID uuid.UUID) (bool, error)
+	// FamilyOrganization returns the organization the family belongs to,
+	// or nil.
+	FamilyOrganization(ctx context.Context, familyID uuid.UUID) (*Organization, error)
+	ListFamilies(ctx context.Context, orgID uuid.UUID) ([]OrganizationFamily, error)
+	// Report counts the organization's families and their log entries
+	// dated on or after since.
+	Report(ctx context.Context, orgID uuid.UUID, since time.Time) (*OrganizationReport, error)
+
+	// GetBilling returns the organization's subscription fields, or nil.
+	GetBilling(ctx context.Context, orgID uuid.UUID) (*OrganizationBilling, error)
+	// GetBillingByStripeSubscription returns the organization billed by
+	// the Stripe subscription, or nil.
+	GetBillingByStripeSubscription(ctx context.Context, stripeSubscriptionID string) (*OrganizationBilling, error)
+	SaveBilling(ctx context.Context, b *OrganizationBilling) error
+	// ChangeSeats sets the seat limit to c.ToSeats and records the change,
+	// filling in c.FromSeats. Unless the change came from Stripe it fails
+	// with ErrOrgSeatsInUse when fewer seats than linked families remain.
+	ChangeSeats(ctx context.Context, c *OrgSeatChange) error
+	ListSeatChanges(ctx context.Context, orgID uuid.UUID, limit int) ([]OrgSeatChange, error)
+	// SeatUsage returns every organization's seats and per-seat price.
+	SeatUsage(ctx context.Context) ([]OrgSeatUsage, error)
+}
+
+type organizationRepo struct {
+	db *DB
+}
+
+// NewOrganizationRepo creates an OrganizationRepository on the main pool.
+func NewOrganizationRepo(db *sql.DB) OrganizationRepository {
+	return &organizationRepo{db: WrapDB(db)}
+}
+
+const organizationCols = `o.id, o.name, COALESCE(o.contact_email, ''), o.status, o.seat_limit,
+       (SELECT COUNT(*) FROM organization_families WHERE organization_id = o.id),
+       (SELECT COUNT(*) FROM organization_members WHERE organization_id = o.id),
+       o.plan_id, COALESCE(o.billing_status, ''),
+       o.join_code, o.created_by, o.created_at, o.updated_at`
+
+func scanOrganization(row interface{ Scan(...any) error }, extra ...any) (*Organization, error) {
+	o := &Organization{}
+	dest := []any{&o.ID, &o.Name, &o.ContactEmail, &o.Status, &o.SeatLimit,
+		&o.SeatsUsed, &o.MemberCount, &o.PlanID, &o.BillingStatus,
+		&o.JoinCode, &o.CreatedBy, &o.CreatedAt, &o.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *organizationRepo) List(ctx context.Context) ([]Organization, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+organizationCols+`
+        FROM organizations o
+        ORDER BY o.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Organization{}
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *o)
+	}
+	return out, rows.Err()
+}
+
+func (r *organizationRepo) getWhere(ctx context.Context, cond string, arg any) (*Organization, error) {
+	o, err := scanOrganization(r.db.QueryRowContext(ctx, `
+        SELECT `+organizationCols+`
+        FROM organizations o WHERE `+cond, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return o, err
+}
+
+func (r *organizationRepo) Get(ctx context.Context, id uuid.UUID) (*Organization, error) {
+	return r.getWhere(ctx, "o.id = $1", id)
+}
+
+func (r *organizationRepo) GetByJoinCode(ctx context.Context, code string) (*Organization, error) {
+	return r.getWhere(ctx, "o.join_code = UPPER($1)", code)
+}
+
+func (r *organizationRepo) Create(ctx context.Context, o *Organization) error {
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO organizations (name, contact_email, status, seat_limit, join_code, created_by)
+        VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)
+        RETURNING id, created_at, updated_at`,
+		o.Name, o.ContactEmail, o.Status, o.SeatLimit, o.JoinCode, o.CreatedBy,
+	).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrOrgJoinCodeTaken
+	}
+	return err
+}
+
+func (r *organizationRepo) Update(ctx context.Context, o *Organization) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE organizations
+        SET name = $2, contact_email = NULLIF($3, ''), status = $4, updated_at = NOW()
+        WHERE id = $1`,
+		o.ID, o.Name, o.ContactEmail, o.Status)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *organizationRepo) SetJoinCode(ctx context.Context, id uuid.UUID, code string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE organizations SET join_code = $2, updated_at = NOW() WHERE id = $1`, id, code)
+	if isUniqueViolation(err) {
+		return ErrOrgJoinCodeTaken
+	}
+	return err
+}
+
+func (r *organizationRepo) ForUser(ctx context.Context, userID uuid.UUID) ([]OrganizationMembership, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+organizationCols+`, m.role
+        FROM organization_members m
+        JOIN organizations o ON o.id = m.organization_id
+        WHERE m.user_id = $1
+        ORDER BY o.name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []OrganizationMembership{}
+	for rows.Next() {
+		var role string
+		o, err := scanOrganization(rows, &role)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, OrganizationMembership{Organization: *o, Role: role})
+	}
+	return out, rows.Err()
+}
+
+func (r *organizationRepo) MemberRole(ctx context.Context, orgID, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx, `
+        SELECT role FROM organization_members
+        WHERE organization_id = $1 AND user_id = $2`, orgID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+const organizationMemberSelect = `
+        SELECT u.id, u.email, u.first_name, u.last_name, m.role, m.created_at
+        FROM organization_members m
+        JOIN app_users u ON u.id = m.user_id`
+
+func (r *organizationRepo) ListMembers(ctx context.Context, orgID uuid.UUID) ([]OrganizationMember, error) {
+	rows, err := r.db.QueryContext(ctx, organizationMemberSelect+`
+        WHERE m.organization_id = $1
+        ORDER BY m.role, u.last_name, u.first_name`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []OrganizationMember{}
+	for rows.Next() {
+		var m OrganizationMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.FirstName, &m.LastName, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (r *organizationRepo) AddMember(ctx context.Context, orgID uuid.UUID, email, role string, addedBy uuid.UUID) (*OrganizationMember, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO organization_members (organization_id, user_id, role, added_by)
+        SELECT $1, id, $3, $4 FROM app_users
+        WHERE LOWER(email) = LOWER($2) AND deleted_at IS NULL
+        ON CONFLICT (organization_id, user_id) DO UPDATE SET role = EXCLUDED.role
+        RETURNING user_id`, orgID, email, role, addedBy).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m OrganizationMember
+	err = r.db.QueryRowContext(ctx, organizationMemberSelect+`
+        WHERE m.organization_id = $1 AND m.user_id = $2`, orgID, userID,
+	).Scan(&m.UserID, &m.Email, &m.FirstName, &m.LastName, &m.Role, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *organizationRepo) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`, orgID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *organizationRepo) LinkFamily(ctx context.Context, orgID, familyID, addedBy uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the organization so two families can't take the last seat.
+	var limit, used int
+	if err := tx.QueryRowContext(ctx, `
+        SELECT seat_limit FROM organizations WHERE id = $1 FOR UPDATE`, orgID).Scan(&limit); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM organization_families WHERE organization_id = $1`, orgID).Scan(&used); err != nil {
+		return err
+	}
+	var linked bool
+	if err := tx.QueryRowContext(ctx, `
+        SELECT EXISTS (SELECT 1 FROM organization_families WHERE family_id = $1)`, familyID).Scan(&linked); err != nil {
+		return err
+	}
+	if linked {
+		return ErrFamilyHasOrganization
+	}
+	if used >= limit {
+		return ErrOrgSeatsFull
+	}
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO organization_families (family_id, organization_id, added_by)
+        VALUES ($1, $2, $3)`, familyID, orgID, addedBy); err != nil {
+		if isUniqueViolation(err) {
+			return ErrFamilyHasOrganization
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *organizationRepo) UnlinkFamily(ctx context.Context, orgID, familyID uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        DELETE FROM organization_families WHERE organization_id = $1 AND family_id = $2`, orgID, familyID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *organizationRepo) FamilyOrganization(ctx context.Context, familyID uuid.UUID) (*Organization, error) {
+	return r.getWhere(ctx, "o.id = (SELECT organization_id FROM organization_families WHERE family_id = $1)", familyID)
+}
+
+func (r *organizationRepo) ListFamilies(ctx context.Context, orgID uuid.UUID) ([]OrganizationFamily, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT f.id, f.name,
+               (SELECT COUNT(*) FROM family_memberships WHERE family_id = f.id AND is_active = true),
+               (SELECT COUNT(*) FROM children WHERE family_id = f.id),
+               ofm.created_at
+        FROM organization_families ofm
+        JOIN families f ON f.id = ofm.family_id AND f.deleted_at IS NULL
+        WHERE ofm.organization_id = $1
+        ORDER BY f.name`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []OrganizationFamily{}
+	for rows.Next() {
+		var f OrganizationFamily
+		if err := rows.Scan(&f.FamilyID, &f.Name, &f.MemberCount, &f.ChildCount, &f.JoinedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// orgEntriesCond limits each log table to entries dated on or after $2
+// for children in organization $1's families.
+const orgEntriesCond = `log_date >= $2 AND child_id IN (
+            SELECT c.id FROM children c
+            JOIN organization_families ofm ON ofm.family_id = c.family_id
+            WHERE ofm.organization_id = $1)`
+
+func (r *organizationRepo) Report(ctx context.Context, orgID uuid.UUID, since time.Time) (*OrganizationReport, error) {
+	day := since.Format("2006-01-02")
+	rep := &OrganizationReport{Entries: []OrgEntryCount{}}
+	if err := r.db.QueryRowContext(ctx, `
+        WITH fams AS (
+            SELECT f.id FROM organization_families ofm
+            JOIN families f ON f.id = ofm.family_id AND f.deleted_at IS NULL
+            WHERE ofm.organization_id = $1
+        ), entries AS (
+        `+warehouseLogEntries(orgEntriesCond)+`
+        )
+        SELECT (SELECT COUNT(*) FROM fams),
+               (SELECT COUNT(*) FROM children c WHERE c.family_id IN (SELECT id FROM fams)),
+               (SELECT COUNT(DISTINCT c.family_id) FROM entries e
+                JOIN children c ON c.id = e.child_id
+                WHERE c.family_id IN (SELECT id FROM fams))`, orgID, day,
+	).Scan(&rep.Families, &rep.Children, &rep.ActiveFamilies); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        WITH entries AS (
+        `+warehouseLogEntries(orgEntriesCond)+`
+        )
+        SELECT e.log_type, COUNT(*), COUNT(DISTINCT e.child_id)
+        FROM entries e
+        JOIN children c ON c.id = e.child_id
+        JOIN organization_families ofm ON ofm.family_id = c.family_id AND ofm.organization_id = $1
+        JOIN families f ON f.id = c.family_id AND f.deleted_at IS NULL
+        GROUP BY 1
+        ORDER BY 2 DESC`, orgID, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e OrgEntryCount
+		if err := rows.Scan(&e.LogType, &e.Entries, &e.Children); err != nil {
+			return nil, err
+		}
+		rep.Entries = append(rep.Entries, e)
+	}
+	return rep, rows.Err()
+}
+
+const organizationBillingCols = `id, plan_id, COALESCE(billing_status, ''), current_period_end,
+       COALESCE(stripe_customer_id, ''), COALESCE(stripe_subscription_id, ''),
+       COALESCE(stripe_subscription_item_id, '')`
+
+func (r *organizationRepo) getBillingWhere(ctx context.Context, cond string, arg any) (*OrganizationBilling, error) {
+	b := &OrganizationBilling{}
+	err := r.db.QueryRowContext(ctx, `
+        SELECT `+organizationBillingCols+` FROM organizations WHERE `+cond, arg,
+	).Scan(&b.OrganizationID, &b.PlanID, &b.Status, &b.CurrentPeriodEnd,
+		&b.StripeCustomerID, &b.StripeSubscriptionID, &b.StripeSubscriptionItemID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *organizationRepo) GetBilling(ctx context.Context, orgID uuid.UUID) (*OrganizationBilling, error) {
+	return r.getBillingWhere(ctx, "id = $1", orgID)
+}
+
+func (r *organizationRepo) GetBillingByStripeSubscription(ctx context.Context, stripeSubscriptionID string) (*OrganizationBilling, error) {
+	return r.getBillingWhere(ctx, "stripe_subscription_id = $1", stripeSubscriptionID)
+}
+
+func (r *organizationRepo) SaveBilling(ctx context.Context, b *OrganizationBilling) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE organizations SET
+            plan_id                     = $2,
+            billing_status              = NULLIF($3, ''),
+            current_period_end          = $4,
+            stripe_customer_id          = NULLIF($5, ''),
+            stripe_subscription_id      = NULLIF($6, ''),
+            stripe_subscription_item_id = NULLIF($7, ''),
+            updated_at                  = NOW()
+        WHERE id = $1`,
+		b.OrganizationID, b.PlanID, b.Status, b.CurrentPeriodEnd,
+		b.StripeCustomerID, b.StripeSubscriptionID, b.StripeSubscriptionItemID)
+	return err
+}
+
+func (r *organizationRepo) ChangeSeats(ctx context.Context, c *OrgSeatChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Same lock LinkFamily takes, so a family can't join mid-change.
+	var used int
+	if err := tx.QueryRowContext(ctx, `
+        SELECT seat_limit FROM organizations WHERE id = $1 FOR UPDATE`, c.OrganizationID).Scan(&c.FromSeats); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM organization_families WHERE organization_id = $1`, c.OrganizationID).Scan(&used); err != nil {
+		return err
+	}
+	if c.ToSeats < used && c.Source != OrgSeatSourceStripe {
+		return ErrOrgSeatsInUse
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE organizations SET seat_limit = $2, updated_at = NOW() WHERE id = $1`,
+		c.OrganizationID, c.ToSeats); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO organization_seat_changes
+            (organization_id, from_seats, to_seats, proration_cents, source, changed_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at`,
+		c.OrganizationID, c.FromSeats, c.ToSeats, c.ProrationCents, c.Source, c.ChangedBy,
+	).Scan(&c.ID, &c.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *organizationRepo) ListSeatChanges(ctx context.Context, orgID uuid.UUID, limit int) ([]OrgSeatChange, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, organization_id, from_seats, to_seats, proration_cents, source, changed_by, created_at
+        FROM organization_seat_changes
+        WHERE organization_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2`, orgID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []OrgSeatChange{}
+	for rows.Next() {
+		var c OrgSeatChange
+		if err := rows.Scan(&c.ID, &c.OrganizationID, &c.FromSeats, &c.ToSeats,
+			&c.ProrationCents, &c.Source, &c.ChangedBy, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *organizationRepo) SeatUsage(ctx context.Context) ([]OrgSeatUsage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT o.id, o.name, o.status, COALESCE(p.name, ''),
+               CASE WHEN p.seat_based THEN p.price_cents ELSE 0 END,
+               COALESCE(o.billing_status, ''), o.seat_limit,
+               (SELECT COUNT(*) FROM organization_families WHERE organization_id = o.id)
+        FROM organizations o
+        LEFT JOIN subscription_plans p ON p.id = o.plan_id
+        ORDER BY o.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []OrgSeatUsage{}
+	for rows.Next() {
+		var u OrgSeatUsage
+		if err := rows.Scan(&u.OrganizationID, &u.Name, &u.Status, &u.PlanName,
+			&u.PricePerSeatCents, &u.BillingStatus, &u.SeatLimit, &u.SeatsUsed); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Pending admin action statuses. Actions start awaiting confirmation; the
+// two awaiting statuses are open and lapse to expired at ExpiresAt.
+const (
+	PendingActionAwaitingConfirmation = "awaiting_confirmation"
+	PendingActionAwaitingApproval     = "awaiting_approval"
+	PendingActionExecuting            = "executing"
+	PendingActionExecuted             = "executed"
+	PendingActionFailed               = "failed"
+	PendingActionRejected             = "rejected"
+	PendingActionCancelled            = "cancelled"
+	PendingActionExpired              = "expired"
+)
+
+// PendingAdminAction is a destructive admin action held until it has been
+// confirmed (and, when required, approved by a second admin).
+type PendingAdminAction struct {
+	ID               uuid.UUID              `json:"id"`
+	Action           string                 `json:"action"`
+	TargetType       string                 `json:"target_type"`
+	TargetID         string                 `json:"target_id,omitempty"`
+	Params           map[string]interface{} `json:"params"`
+	Summary          string                 `json:"summary"`
+	ConfirmPhrase    string                 `json:"confirm_phrase"`
+	RequiresApproval bool                   `json:"requires_approval"`
+	Status           string                 `json:"status"`
+	RequestedBy      uuid.UUID              `json:"requested_by"`
+	ConfirmedAt      *time.Time             `json:"confirmed_at,omitempty"`
+	DecidedBy        *uuid.UUID             `json:"decided_by,omitempty"`
+	DecidedAt        *time.Time             `json:"decided_at,omitempty"`
+	DecisionNote     string                 `json:"decision_note,omitempty"`
+	Result           map[string]interface{} `json:"result,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+	ExecutedAt       *time.Time             `json:"executed_at,omitempty"`
+	ExpiresAt        time.Time              `json:"expires_at"`
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// PendingActionRepository stores pending admin actions. The status changes
+// are compare-and-set: each reports false when the action was no longer in
+// the expected status (or had expired), so an action runs at most once.
+type PendingActionRepository interface {
+	// Create inserts a; ID, Status and CreatedAt are filled in.
+	Create(ctx context.Context, a *PendingAdminAction) error
+	// Get returns nil, nil when there is no such action.
+	Get(ctx context.Context, id uuid.UUID) (*PendingAdminAction, error)
+	// List returns the newest actions first, only those in statuses when
+	// given.
+	List(ctx context.Context, statuses []string, limit int) ([]PendingAdminAction, error)
+
+	// Confirm moves an action awaiting confirmation, unexpired at now, to
+	// status with a new expiry.
+	Confirm(ctx context.Context, id uuid.UUID, status string, expiresAt, now time.Time) (bool, error)
+	// Decide moves an open action in one of from, unexpired at now, to
+	// status, recording who decided and why.
+	Decide(ctx context.Context, id uuid.UUID, from []string, status string, by uuid.UUID, note string, now time.Time) (bool, error)
+	// Finish records the outcome of an executing action.
+	Finish(ctx context.Context, id uuid.UUID, status string, result map[string]interface{}, errMsg string) error
+	// Expire marks open actions past their expiry as expired.
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
+
+type pendingActionRepo struct {
+	db *DB
+}
+
+// NewPendingActionRepo creates a PendingActionRepository on the main pool.
+func NewPendingActionRepo(db *sql.DB) PendingActionRepository {
+	return &pendingActionRepo{db: WrapDB(db)}
+}
+
+func (r *pendingActionRepo) Create(ctx context.Context, a *PendingAdminAction) error {
+	params, err := json.Marshal(a.Params)
+	if err != nil {
+		return err
+	}
+	a.Status = PendingActionAwaitingConfirmation
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO pending_admin_actions
+            (action, target_type, target_id, params, summary, confirm_phrase,
+             requires_approval, status, requested_by, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id, created_at
+    `, a.Action, a.TargetType, a.TargetID, params, a.Summary, a.ConfirmPhrase,
+		a.RequiresApproval, a.Status, a.RequestedBy, a.ExpiresAt,
+	).Scan(&a.ID, &a.CreatedAt)
+}
+
+const pendingActionCols = `id, action, target_type, target_id, params, summary, confirm_phrase,
+        requires_approval, status, requested_by, confirmed_at, decided_by, decided_at,
+        decision_note, result, error, executed_at, expires_at, created_at`
+
+func scanPendingAction(row interface{ Scan(...any) error }, a *PendingAdminAction) error {
+	var params, result []byte
+	if err := row.Scan(&a.ID, &a.Action, &a.TargetType, &a.TargetID, &params, &a.Summary, &a.ConfirmPhrase,
+		&a.RequiresApproval, &a.Status, &a.RequestedBy, &a.ConfirmedAt, &a.DecidedBy, &a.DecidedAt,
+		&a.DecisionNote, &result, &a.Error, &a.ExecutedAt, &a.ExpiresAt, &a.CreatedAt); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(params, &a.Params); err != nil {
+		return err
+	}
+	if result != nil {
+		return json.Unmarshal(result, &a.Result)
+	}
+	return nil
+}
+
+func (r *pendingActionRepo) Get(ctx context.Context, id uuid.UUID) (*PendingAdminAction, error) {
+	var a PendingAdminAction
+	err := scanPendingAction(r.db.QueryRowContext(ctx, `SELECT `+pendingActionCols+`
+        FROM pending_admin_actions WHERE id = $1`, id), &a)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *pendingActionRepo) List(ctx context.Context, statuses []string, limit int) ([]PendingAdminAction, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+pendingActionCols+`
+        FROM pending_admin_actions
+        WHERE cardinality($1::text[]) = 0 OR status = ANY($1)
+        ORDER BY created_at DESC
+        LIMIT $2`, pq.Array(statuses), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PendingAdminAction
+	for rows.Next() {
+		var a PendingAdminAction
+		if err := scanPendingAction(rows, &a); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *pendingActionRepo) Confirm(ctx context.Context, id uuid.UUID, status string, expiresAt, now time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE pending_admin_actions
+        SET status = $2, confirmed_at = $4, expires_at = $3
+        WHERE id = $1 AND status = 'awaiting_confirmation' AND expires_at > $4
+    `, id, status, expiresAt, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *pendingActionRepo) Decide(ctx context.Context, id uuid.UUID, from []string, status string, by uuid.UUID, note string, now time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE pending_admin_actions
+        SET status = $3, decided_by = $4, decided_at = $6, decision_note = $5
+        WHERE id = $1 AND status = ANY($2) AND expires_at > $6
+    `, id, pq.Array(from), status, by, note, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *pendingActionRepo) Finish(ctx context.Context, id uuid.UUID, status string, result map[string]interface{}, errMsg string) error {
+	var resultJSON []byte
+	if result != nil {
+		var err error
+		if resultJSON, err = json.Marshal(result); err != nil {
+			return err
+		}
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE pending_admin_actions
+        SET status = $2, result = $3, error = $4, executed_at = NOW()
+        WHERE id = $1 AND status = 'executing'
+    `, id, status, resultJSON, errMsg)
+	return err
+}
+
+func (r *pendingActionRepo) Expire(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE pending_admin_actions
+        SET status = 'expired'
+        WHERE status IN ('awaiting_confirmation', 'awaiting_approval') AND expires_at <= $1
+    `, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// LatencyBucketBoundsMs are the lower bounds of the latency histogram
+// buckets in response_time_hourly.histogram. Bucket i (1-based, as
+// Postgres width_bucket numbers them) holds requests with
+// bounds[i-1] <= ms < bounds[i]; the last bucket is everything from the
+// last bound up. Changing the bounds invalidates stored histograms.
+var LatencyBucketBoundsMs = []float64{
+	0, 1, 2, 3, 5, 7, 10, 15, 20, 30, 50, 75, 100, 150, 200, 300,
+	500, 750, 1000, 1500, 2000, 3000, 5000, 7500, 10000, 15000, 30000,
+}
+
+// RouteLatency is one route, method and status class summed over a window
+// of hourly rollups. Buckets maps histogram bucket index to request count;
+// it is empty for hours rolled up before histograms were recorded.
+type RouteLatency struct {
+	Route       string
+	Method      string
+	StatusClass int
+	Requests    int64
+	Errors      int64
+	TotalMs     float64
+	MaxMs       float64
+	Buckets     map[int]int64
+}
+
+// PerformanceRepository reads request latency from response_time_hourly.
+type PerformanceRepository interface {
+	// RouteLatency sums the hourly rollups in [from, to) per route, method
+	// and status class.
+	RouteLatency(ctx context.Context, from, to time.Time) ([]RouteLatency, error)
+}
+
+type performanceRepo struct {
+	db *DB
+}
+
+// NewPerformanceRepo creates a PerformanceRepository on the main pool.
+func NewPerformanceRepo(db *sql.DB) PerformanceRepository {
+	return &performanceRepo{db: WrapDB(db)}
+}
+
+type routeLatencyKey struct {
+	route, method string
+	class         int
+}
+
+func (r *performanceRepo) RouteLatency(ctx context.Context, from, to time.Time) ([]RouteLatency, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT path, method, status_class,
+               SUM(request_count), SUM(error_count), SUM(total_ms), MAX(max_ms)
+        FROM response_time_hourly
+        WHERE hour >= $1 AND hour < $2
+        GROUP BY 1, 2, 3
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RouteLatency
+	index := map[routeLatencyKey]int{}
+	for rows.Next() {
+		l := RouteLatency{Buckets: map[int]int64{}}
+		if err := rows.Scan(&l.Route, &l.Method, &l.StatusClass, &l.Requests, &l.Errors, &l.TotalMs, &l.MaxMs); err != nil {
+			return nil, err
+		}
+		index[routeLatencyKey{l.Route, l.Method, l.StatusClass}] = len(out)
+		out = append(out, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	bucketRows, err := r.db.QueryContext(ctx, `
+        SELECT h.path, h.method, h.status_class, b.key::int, SUM(b.value::bigint)
+        FROM response_time_hourly h, jsonb_each_text(h.histogram) b
+        WHERE h.hour >= $1 AND h.hour < $2
+        GROUP BY 1, 2, 3, 4
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer bucketRows.Close()
+	for bucketRows.Next() {
+		var k routeLatencyKey
+		var bucket int
+		var n int64
+		if err := bucketRows.Scan(&k.route, &k.method, &k.class, &bucket, &n); err != nil {
+			return nil, err
+		}
+		if i, ok := index[k]; ok {
+			out[i].Buckets[bucket] = n
+		}
+	}
+	return out, bucketRows.Err()
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// PHITables are the tables holding Protected Health Information: every
+// table keyed by child_id, plus the children themselves, chat and
+// insights. Admin-context code may count their rows but never read them;
+// see PHIViolations.
+var PHITables = []string{
+	"ai_analysis_log", "alerts", "annotations", "behavior_logs", "bowel_logs",
+	"chat_messages", "chat_participants", "chat_threads", "child_baselines",
+	"child_conditions", "child_handoff_profiles", "child_restrictions", "children",
+	"clinical_validations", "correlation_requests", "diet_logs", "family_patterns",
+	"food_recognitions", "health_event_logs", "insights", "interventions",
+	"log_change_stamps", "log_drafts", "log_revisions", "medication_logs",
+	"medications", "provider_grants", "report_shares", "reports",
+	"routine_completions", "routines", "scheduled_reports", "seizure_events",
+	"seizure_logs", "sensory_logs", "sleep_device_records", "sleep_logs",
+	"sleep_source_preferences", "social_logs", "speech_logs", "therapy_goals",
+	"therapy_logs", "treatment_changes", "weight_logs",
+}
+
+var (
+	phiRefRE = regexp.MustCompile(`(?i)\b(from|join|update|into)\s+(` + strings.Join(PHITables, "|") + `)\b`)
+	// phiAggregateRE opens a call whose result reveals no row's values.
+	phiAggregateRE = regexp.MustCompile(`(?i)\b(count|sum|avg|min|max)\s*\(`)
+	phiLiteralRE   = regexp.MustCompile(`'[^']*'`)
+	phiAliasRE     = regexp.MustCompile(`(?i)\bas\s+\w+`)
+	phiQualifierRE = regexp.MustCompile(`\w+\.`)
+	phiIdentRE     = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+)
+
+// phiBucketIdents may appear in a select list over a PHI table outside an
+// aggregate: enough to bucket counts by day, nothing about the rows.
+var phiBucketIdents = map[string]bool{
+	"created_at": true, "log_date": true, "at": true, "time": true,
+	"zone": true, "date": true, "timestamp": true, "timestamptz": true,
+	"date_trunc": true,
+}
+
+// PHIViolation is one way a statement reaches a PHI table.
+type PHIViolation struct {
+	Table string
+	// Access is "writes", "reads" (row values) or "reads outside a
+	// SELECT".
+	Access string
+	// Column is the column read outside an aggregate, when one is.
+	Column string
+}
+
+func (v PHIViolation) String() string {
+	switch {
+	case v.Column != "":
+		return v.Access + " " + v.Table + "." + v.Column
+	case v.Access == "reads":
+		return "reads " + v.Table + " rows"
+	case v.Access == "reads outside a SELECT":
+		return "reads " + v.Table + " outside a SELECT"
+	}
+	return v.Access + " " + v.Table
+}
+
+// PHIReferences reports whether query names a PHI table at all, as a
+// FROM, JOIN, UPDATE or INSERT INTO target.
+func PHIReferences(query string) bool {
+	return phiRefRE.MatchString(query)
+}
+
+// PHIViolations lists how query reads or writes PHI tables in a way admin
+// code may not: any write, and any read whose select list is more than
+// aggregates (COUNT, SUM, AVG, MIN, MAX) and day buckets of created_at or
+// log_date. It returns nil for a query that only counts.
+func PHIViolations(query string) []PHIViolation {
+	var out []PHIViolation
+	for _, m := range phiRefRE.FindAllStringSubmatchIndex(query, -1) {
+		kw := strings.ToLower(query[m[2]:m[3]])
+		table := query[m[4]:m[5]]
+		if kw == "update" || kw == "into" || (kw == "from" && precedingWord(query, m[0]) == "delete") {
+			out = append(out, PHIViolation{Table: table, Access: "writes"})
+			continue
+		}
+		list, ok := selectList(query, m[0])
+		if !ok {
+			out = append(out, PHIViolation{Table: table, Access: "reads outside a SELECT"})
+			continue
+		}
+		if !phiAggregateRE.MatchString(list) {
+			out = append(out, PHIViolation{Table: table, Access: "reads"})
+			continue
+		}
+		rest := phiLiteralRE.ReplaceAllString(stripAggregates(list), "")
+		rest = phiAliasRE.ReplaceAllString(rest, "")
+		rest = phiQualifierRE.ReplaceAllString(rest, "")
+		if strings.Contains(rest, "*") {
+			out = append(out, PHIViolation{Table: table, Access: "reads"})
+			continue
+		}
+		for _, id := range phiIdentRE.FindAllString(rest, -1) {
+			if !phiBucketIdents[strings.ToLower(id)] {
+				out = append(out, PHIViolation{Table: table, Access: "reads", Column: id})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// precedingWord is the lower-cased word before position i.
+func precedingWord(s string, i int) string {
+	s = strings.TrimRight(s[:i], " \t\r\n")
+	j := len(s)
+	for j > 0 && isIdentByte(s[j-1]) {
+		j--
+	}
+	return strings.ToLower(s[j:])
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// hasWord reports whether the keyword kw starts at s[i], on word
+// boundaries.
+func hasWord(s string, i int, kw string) bool {
+	if i+len(kw) > len(s) || !strings.EqualFold(s[i:i+len(kw)], kw) {
+		return false
+	}
+	if i > 0 && isIdentByte(s[i-1]) {
+		return false
+	}
+	return i+len(kw) == len(s) || !isIdentByte(s[i+len(kw)])
+}
+
+// selectList finds the SELECT that the FROM or JOIN at pos belongs to,
+// the nearest one before it at the same parenthesis depth, and returns
+// its select list.
+func selectList(query string, pos int) (string, bool) {
+	depth, start := 0, -1
+	for i := pos - 1; i >= 0 && start < 0; i-- {
+		switch query[i] {
+		case ')':
+			depth++
+		case '(':
+			if depth == 0 {
+				return "", false
+			}
+			depth--
+		default:
+			if depth == 0 && hasWord(query, i, "select") {
+				start = i + len("select")
+			}
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+	depth = 0
+	for i := start; i < pos; i++ {
+		switch query[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && hasWord(query, i, "from") {
+				return query[start:i], true
+			}
+		}
+	}
+	return query[start:pos], true
+}
+
+// stripAggregates drops every aggregate call, arguments included.
+func stripAggregates(s string) string {
+	for {
+		loc := phiAggregateRE.FindStringIndex(s)
+		if loc == nil {
+			return s
+		}
+		depth, end := 1, len(s)
+		for i := loc[1]; i < len(s); i++ {
+			if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' {
+				if depth--; depth == 0 {
+					end = i + 1
+					break
+				}
+			}
+		}
+		s = s[:loc[0]] + s[end:]
+	}
+}
+
+// AdminQuery is a statement admin-context code is about to run.
+type AdminQuery struct {
+	// Method is the repository method running it, as in its SQL tag.
+	Method string
+	SQL    string
+	Args   []any
+}
+
+// AdminQueryWatcher is shown every statement the admin repositories run,
+// before it runs.
+type AdminQueryWatcher interface {
+	WatchAdminQuery(ctx context.Context, q AdminQuery)
+}
+
+var adminQueryWatcher atomic.Pointer[AdminQueryWatcher]
+
+// SetAdminQueryWatcher installs w as the admin statement watcher; nil
+// removes it.
+func SetAdminQueryWatcher(w AdminQueryWatcher) {
+	if w == nil {
+		adminQueryWatcher.Store(nil)
+		return
+	}
+	adminQueryWatcher.Store(&w)
+}
+
+// wrapAdminDB is WrapDB for the admin repositories: statements go past the
+// admin query watcher first.
+func wrapAdminDB(db *sql.DB) *DB {
+	d := WrapDB(db)
+	if d != nil {
+		d.watch = watchAdminQuery
+	}
+	return d
+}
+
+func watchAdminQuery(ctx context.Context, query string, args []any) {
+	w := adminQueryWatcher.Load()
+	if w == nil {
+		return
+	}
+	(*w).WatchAdminQuery(ctx, AdminQuery{Method: callerMethod(), SQL: query, Args: args})
+}
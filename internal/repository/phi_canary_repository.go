@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// PHICanary is a canary row planted in a PHI table (migration 00105).
+// Token is the random text written into the row; it is empty for the
+// family and user rows that only support the others.
+type PHICanary struct {
+	Table string
+	RowID uuid.UUID
+	Token string
+}
+
+// PHICanaryRepository reads phi_canaries, never the canary rows
+// themselves.
+type PHICanaryRepository interface {
+	List(ctx context.Context) ([]PHICanary, error)
+}
+
+type phiCanaryRepo struct {
+	db *DB
+}
+
+// NewPHICanaryRepo creates a PHICanaryRepository on the main pool.
+func NewPHICanaryRepo(db *sql.DB) PHICanaryRepository {
+	return &phiCanaryRepo{db: WrapDB(db)}
+}
+
+func (r *phiCanaryRepo) List(ctx context.Context) ([]PHICanary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT table_name, row_id, COALESCE(token, '')
+        FROM phi_canaries
+        ORDER BY table_name, row_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PHICanary
+	for rows.Next() {
+		var c PHICanary
+		if err := rows.Scan(&c.Table, &c.RowID, &c.Token); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// TestAdminBuiltSQLPHI checks the statements adminRepo builds at init,
+// which the source lint (internal/philint) only sees in pieces.
+func TestAdminBuiltSQLPHI(t *testing.T) {
+	for name, q := range map[string]string{
+		"metricsHistoryDayCountsSQL": metricsHistoryDayCountsSQL,
+		"logUsageDailySQL":           logUsageDailySQL,
+	} {
+		if v := PHIViolations(q); len(v) > 0 {
+			t.Errorf("%s: %v", name, v)
+		}
+	}
+}
+
+// TestPHITablesCoverMigrations keeps PHITables complete: every table the
+// migrations give a child_id column is PHI.
+func TestPHITablesCoverMigrations(t *testing.T) {
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	createRE := regexp.MustCompile(`(?s)CREATE TABLE (?:IF NOT EXISTS )?(\w+)\s*\((.*?)\n\);`)
+	alterRE := regexp.MustCompile(`ALTER TABLE (?:IF EXISTS )?(\w+)\s+ADD COLUMN (?:IF NOT EXISTS )?child_id\b`)
+	childRE := regexp.MustCompile(`\bchild_id\b`)
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tables []string
+		for _, m := range createRE.FindAllStringSubmatch(string(raw), -1) {
+			if childRE.MatchString(m[2]) {
+				tables = append(tables, m[1])
+			}
+		}
+		for _, m := range alterRE.FindAllStringSubmatch(string(raw), -1) {
+			tables = append(tables, m[1])
+		}
+		for _, table := range tables {
+			if !slices.Contains(PHITables, table) {
+				t.Errorf("%s: table %s has a child_id column but isn't in PHITables", filepath.Base(file), table)
+			}
+		}
+	}
+}
+
+func TestPHIViolations(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"SELECT COUNT(*) FROM children WHERE is_active = TRUE", nil},
+		{"SELECT f.id, (SELECT count(*) FROM children c WHERE c.family_id = f.id) AS child_count FROM families f", nil},
+		{`SELECT (l.created_at AT TIME ZONE 'UTC')::date AS usage_date, 'sleep' AS log_type,
+		         COUNT(*) AS entries, COUNT(DISTINCT c.family_id) AS families
+		  FROM sleep_logs l JOIN children c ON c.id = l.child_id GROUP BY 1`, nil},
+		{"SELECT id, email FROM app_users WHERE id = $1", nil},
+		{"SELECT first_name, date_of_birth FROM children WHERE family_id = $1", []string{"reads children rows"}},
+		{"SELECT * FROM medications", []string{"reads medications rows"}},
+		{"SELECT COUNT(*), c.first_name FROM children c GROUP BY 2", []string{"reads children.first_name"}},
+		{"SELECT COUNT(*) * 1, * FROM children", []string{"reads children rows"}},
+		{"SELECT f.name FROM families f JOIN children c ON c.family_id = f.id", []string{"reads children rows"}},
+		{"SELECT EXISTS (SELECT 1 FROM behavior_logs WHERE child_id = $1)", []string{"reads behavior_logs rows"}},
+		{"UPDATE children SET is_active = false", []string{"writes children"}},
+		{"DELETE FROM chat_messages WHERE id = $1", []string{"writes chat_messages"}},
+		{"INSERT INTO alerts (child_id) VALUES ($1)", []string{"writes alerts"}},
+		// Names that only start like a PHI table.
+		{"SELECT id FROM reports_admin_view", nil},
+	} {
+		var got []string
+		for _, v := range PHIViolations(tc.query) {
+			got = append(got, v.String())
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("PHIViolations(%q) = %q, want %q", tc.query, got, tc.want)
+		}
+	}
+}
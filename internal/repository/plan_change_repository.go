@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// ErrPlanChangeConflict means the subscription's plan moved while a change
+// was in flight (another change, or a Stripe webhook).
+var ErrPlanChangeConflict = apperr.Conflict("the subscription's plan changed in the meantime")
+
+// PlanChange is one family plan switch.
+type PlanChange struct {
+	ID                   uuid.UUID  `json:"id"`
+	FamilySubscriptionID uuid.UUID  `json:"family_subscription_id"`
+	FamilyID             uuid.UUID  `json:"family_id"`
+	FromPlanID           uuid.UUID  `json:"from_plan_id"`
+	ToPlanID             uuid.UUID  `json:"to_plan_id"`
+	FromPlanName         string     `json:"from_plan_name"`
+	ToPlanName           string     `json:"to_plan_name"`
+	Direction            string     `json:"direction"` // upgrade, downgrade
+	ProrationCents       int64      `json:"proration_cents"`
+	Source               string     `json:"source"` // app, stripe
+	ChangedBy            *uuid.UUID `json:"changed_by,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// FamilyUsage is what a family currently uses of its plan's entitlements.
+type FamilyUsage struct {
+	Children int `json:"children"`
+	Members  int `json:"members"`
+}
+
+// PlanChangeRepository stores family plan switches.
+type PlanChangeRepository interface {
+	// Apply moves the family subscription from c.FromPlanID to c.ToPlanID
+	// and records the change, atomically. ErrPlanChangeConflict if the
+	// subscription is no longer on FromPlanID.
+	Apply(ctx context.Context, c *PlanChange) error
+	ListForFamily(ctx context.Context, familyID uuid.UUID, limit int) ([]PlanChange, error)
+	FamilyUsage(ctx context.Context, familyID uuid.UUID) (*FamilyUsage, error)
+	// SubscriptionByStripeID returns the family subscription's id, family,
+	// plan and status, or nil if no family has that Stripe subscription.
+	SubscriptionByStripeID(ctx context.Context, stripeSubscriptionID string) (*models.FamilySubscription, error)
+}
+
+type planChangeRepo struct {
+	db *DB
+}
+
+// NewPlanChangeRepo creates a PlanChangeRepository on the main pool.
+func NewPlanChangeRepo(db *sql.DB) PlanChangeRepository {
+	return &planChangeRepo{db: WrapDB(db)}
+}
+
+func (r *planChangeRepo) Apply(ctx context.Context, c *PlanChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE family_subscriptions SET plan_id = $3, updated_at = NOW()
+        WHERE id = $1 AND plan_id = $2`, c.FamilySubscriptionID, c.FromPlanID, c.ToPlanID)
+	if err != nil {
+		return fmt.Errorf("switch plan: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPlanChangeConflict
+	}
+	if c.Source == "" {
+		c.Source = "app"
+	}
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO subscription_plan_changes (
+            family_subscription_id, family_id, from_plan_id, to_plan_id,
+            direction, proration_cents, source, changed_by
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at`,
+		c.FamilySubscriptionID, c.FamilyID, c.FromPlanID, c.ToPlanID,
+		c.Direction, c.ProrationCents, c.Source, c.ChangedBy,
+	).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("record plan change: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *planChangeRepo) ListForFamily(ctx context.Context, familyID uuid.UUID, limit int) ([]PlanChange, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT c.id, c.family_subscription_id, c.family_id, c.from_plan_id, c.to_plan_id,
+               fp.name, tp.name, c.direction, c.proration_cents, c.source, c.changed_by, c.created_at
+        FROM subscription_plan_changes c
+        JOIN subscription_plans fp ON fp.id = c.from_plan_id
+        JOIN subscription_plans tp ON tp.id = c.to_plan_id
+        WHERE c.family_id = $1
+        ORDER BY c.created_at DESC
+        LIMIT $2`, familyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PlanChange
+	for rows.Next() {
+		var c PlanChange
+		if err := rows.Scan(&c.ID, &c.FamilySubscriptionID, &c.FamilyID, &c.FromPlanID, &c.ToPlanID,
+			&c.FromPlanName, &c.ToPlanName, &c.Direction, &c.ProrationCents, &c.Source, &c.ChangedBy,
+			&c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *planChangeRepo) FamilyUsage(ctx context.Context, familyID uuid.UUID) (*FamilyUsage, error) {
+	var u FamilyUsage
+	err := r.db.QueryRowContext(ctx, `
+        SELECT (SELECT COUNT(*) FROM children WHERE family_id = $1),
+               (SELECT COUNT(*) FROM family_memberships WHERE family_id = $1 AND is_active)`,
+		familyID).Scan(&u.Children, &u.Members)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *planChangeRepo) SubscriptionByStripeID(ctx context.Context, stripeSubscriptionID string) (*models.FamilySubscription, error) {
+	var sub models.FamilySubscription
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, family_id, plan_id, status FROM family_subscriptions
+        WHERE stripe_subscription_id = $1`, stripeSubscriptionID,
+	).Scan(&sub.ID, &sub.FamilyID, &sub.PlanID, &sub.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
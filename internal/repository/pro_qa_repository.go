@@ -46,11 +46,11 @@ type ProQARepository interface {
 // proQARepo routes all SQL through supportDB so the records live on the
 // shared support cluster (same physical rows visible from dev and prod).
 type proQARepo struct {
-	supportDB *sql.DB
+	supportDB *DB
 }
 
 func NewProQARepo(supportDB *sql.DB) ProQARepository {
-	return &proQARepo{supportDB: supportDB}
+	return &proQARepo{supportDB: WrapDB(supportDB)}
 }
 
 // ---------- Info ----------
@@ -267,7 +267,7 @@ func (r *proQARepo) ListIssues(ctx context.Context, filterStatus string) ([]mode
 	         (SELECT COUNT(*) FROM pro_qa_issue_comments c WHERE c.issue_id = i.id) AS comment_count,
 	         (SELECT COUNT(*) FROM pro_qa_issue_attachments a WHERE a.issue_id = i.id) AS attachment_count
 	      FROM pro_qa_issues i`
-	var rows *sql.Rows
+	var rows *Rows
 	var err error
 	if filterStatus != "" && filterStatus != "all" {
 		q += ` WHERE status = $1 ORDER BY created_at DESC`
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// productAnalyticsMetric is the system_metrics_cache row holding the last
+// computed analytics snapshot.
+const productAnalyticsMetric = "product_analytics"
+
+// WeeklyRetentionRow is how many of the parents who signed up in one week
+// were active some weeks later.
+type WeeklyRetentionRow struct {
+	CohortWeek       time.Time `json:"cohort_week"`
+	WeeksSinceSignup int       `json:"weeks_since_signup"`
+	CohortSize       int       `json:"cohort_size"`
+	ActiveUsers      int       `json:"active_users"`
+}
+
+// ActiveUsersRow is DAU/WAU/MAU as of one day: distinct active parents on
+// that day and in the trailing 7 and 30 days.
+type ActiveUsersRow struct {
+	Day time.Time `json:"day"`
+	DAU int       `json:"dau"`
+	WAU int       `json:"wau"`
+	MAU int       `json:"mau"`
+}
+
+// LogTypeAdoption is how many families logged one log type.
+type LogTypeAdoption struct {
+	LogType  string `json:"log_type"`
+	Families int    `json:"families"`
+	Entries  int    `json:"entries"`
+}
+
+// AdoptionBreadth is how many families used exactly LogTypes log types.
+type AdoptionBreadth struct {
+	LogTypes int `json:"log_types"`
+	Families int `json:"families"`
+}
+
+// FeatureAdoption is which log types logging families actually use.
+type FeatureAdoption struct {
+	ActiveFamilies int               `json:"active_families"`
+	ByLogType      []LogTypeAdoption `json:"by_log_type"`
+	Breadth        []AdoptionBreadth `json:"breadth"`
+}
+
+// ActivationFunnelRow is one weekly signup cohort's progress through
+// activation: signed up → a child in their family → their first log entry
+// → logging on enough days of the week after that first entry.
+type ActivationFunnelRow struct {
+	CohortWeek time.Time `json:"cohort_week"`
+	SignedUp   int       `json:"signed_up"`
+	AddedChild int       `json:"added_child"`
+	FirstLog   int       `json:"first_log"`
+	FirstWeek  int       `json:"first_week"`
+}
+
+// ProductAnalyticsRepository computes the product analytics aggregates and
+// caches the assembled snapshot in system_metrics_cache. A parent counts as
+// active when they log an entry, send a chat message, generate a report or
+// request a correlation; app opens alone don't count. Days and weeks are
+// UTC, weeks start Monday. Aggregates only — no PHI leaves these queries.
+type ProductAnalyticsRepository interface {
+	// WeeklyRetention returns signup cohorts from the last weeks weeks up
+	// to asOf, with their activity in each week since.
+	WeeklyRetention(ctx context.Context, asOf time.Time, weeks int) ([]WeeklyRetentionRow, error)
+	// ActiveUsers returns DAU/WAU/MAU for each day from..to inclusive.
+	ActiveUsers(ctx context.Context, from, to time.Time) ([]ActiveUsersRow, error)
+	// FeatureAdoption counts families by the log types they logged since.
+	FeatureAdoption(ctx context.Context, since time.Time) (*FeatureAdoption, error)
+	// ActivationFunnel returns weekly signup cohorts from from up to asOf.
+	// A cohort reaches FirstWeek when a parent logs on at least weekDays of
+	// the 7 days starting with their first entry.
+	ActivationFunnel(ctx context.Context, from, asOf time.Time, weekDays int) ([]ActivationFunnelRow, error)
+
+	// CachedSnapshot returns the cached snapshot JSON and when it was
+	// computed, or nil if there is none.
+	CachedSnapshot(ctx context.Context) ([]byte, time.Time, error)
+	SaveSnapshot(ctx context.Context, snapshot []byte, computedAt time.Time) error
+}
+
+type productAnalyticsRepo struct {
+	db *DB
+}
+
+// NewProductAnalyticsRepo creates a ProductAnalyticsRepository on the main pool.
+func NewProductAnalyticsRepo(db *sql.DB) ProductAnalyticsRepository {
+	return &productAnalyticsRepo{db: WrapDB(db)}
+}
+
+// parentActivity is a UNION of every (user_id, at) activity event whose
+// created_at satisfies cond.
+func parentActivity(cond string) string {
+	var parts []string
+	for _, t := range warehouseLogTables {
+		parts = append(parts, "SELECT logged_by AS user_id, created_at AS at FROM "+t.table+" WHERE logged_by IS NOT NULL AND "+cond)
+	}
+	parts = append(parts,
+		"SELECT sender_id, created_at FROM chat_messages WHERE "+cond,
+		"SELECT created_by, created_at FROM reports WHERE created_by IS NOT NULL AND "+cond,
+		"SELECT requested_by, created_at FROM correlation_requests WHERE "+cond,
+	)
+	return strings.Join(parts, "\n        UNION ALL ")
+}
+
+func (r *productAnalyticsRepo) WeeklyRetention(ctx context.Context, asOf time.Time, weeks int) ([]WeeklyRetentionRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        WITH bounds AS (
+            SELECT DATE_TRUNC('week', $1::date)::date AS this_week
+        ), cohorts AS (
+            SELECT u.id AS user_id, DATE_TRUNC('week', u.created_at AT TIME ZONE 'UTC')::date AS cohort_week
+            FROM app_users u, bounds b
+            WHERE u.created_at >= b.this_week - $2::int * 7
+              AND u.created_at < $1::date + 1
+        ), sizes AS (
+            SELECT cohort_week, COUNT(*) AS cohort_size FROM cohorts GROUP BY 1
+        ), activity AS (
+            SELECT DISTINCT user_id, DATE_TRUNC('week', at AT TIME ZONE 'UTC')::date AS active_week
+            FROM (`+parentActivity("created_at >= (SELECT this_week FROM bounds) - $2::int * 7 AND created_at < $1::date + 1")+`) a
+        )
+        SELECT s.cohort_week, w.n, s.cohort_size, COUNT(a.user_id)
+        FROM sizes s
+        CROSS JOIN bounds b
+        CROSS JOIN LATERAL generate_series(0, (b.this_week - s.cohort_week) / 7) AS w(n)
+        LEFT JOIN cohorts c ON c.cohort_week = s.cohort_week
+        LEFT JOIN activity a ON a.user_id = c.user_id AND a.active_week = s.cohort_week + w.n * 7
+        GROUP BY s.cohort_week, w.n, s.cohort_size
+        ORDER BY s.cohort_week, w.n`, asOf.Format("2006-01-02"), weeks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WeeklyRetentionRow
+	for rows.Next() {
+		var w WeeklyRetentionRow
+		if err := rows.Scan(&w.CohortWeek, &w.WeeksSinceSignup, &w.CohortSize, &w.ActiveUsers); err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (r *productAnalyticsRepo) ActiveUsers(ctx context.Context, from, to time.Time) ([]ActiveUsersRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        WITH activity AS (
+            SELECT DISTINCT user_id, (at AT TIME ZONE 'UTC')::date AS day
+            FROM (`+parentActivity("created_at >= $1::date - 29 AND created_at < $2::date + 1")+`) a
+        ), days AS (
+            SELECT d::date AS day FROM generate_series($1::date, $2::date, INTERVAL '1 day') AS d
+        )
+        SELECT d.day,
+               COUNT(DISTINCT a.user_id) FILTER (WHERE a.day = d.day),
+               COUNT(DISTINCT a.user_id) FILTER (WHERE a.day > d.day - 7),
+               COUNT(DISTINCT a.user_id)
+        FROM days d
+        LEFT JOIN activity a ON a.day > d.day - 30 AND a.day <= d.day
+        GROUP BY d.day
+        ORDER BY d.day`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ActiveUsersRow
+	for rows.Next() {
+		var a ActiveUsersRow
+		if err := rows.Scan(&a.Day, &a.DAU, &a.WAU, &a.MAU); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *productAnalyticsRepo) FeatureAdoption(ctx context.Context, since time.Time) (*FeatureAdoption, error) {
+	with := `
+        WITH entries AS (
+            SELECT e.log_type, c.family_id
+            FROM (` + warehouseLogEntries("log_date >= $1") + `) e
+            JOIN children c ON c.id = e.child_id
+            JOIN families f ON f.id = c.family_id AND f.deleted_at IS NULL
+        )`
+	day := since.Format("2006-01-02")
+
+	out := &FeatureAdoption{ByLogType: []LogTypeAdoption{}, Breadth: []AdoptionBreadth{}}
+	if err := r.db.QueryRowContext(ctx, with+`
+        SELECT COUNT(DISTINCT family_id) FROM entries`, day).Scan(&out.ActiveFamilies); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, with+`
+        SELECT log_type, COUNT(DISTINCT family_id), COUNT(*)
+        FROM entries
+        GROUP BY 1
+        ORDER BY 2 DESC, 1`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a LogTypeAdoption
+		if err := rows.Scan(&a.LogType, &a.Families, &a.Entries); err != nil {
+			return nil, err
+		}
+		out.ByLogType = append(out.ByLogType, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, with+`
+        SELECT types, COUNT(*)
+        FROM (SELECT family_id, COUNT(DISTINCT log_type) AS types FROM entries GROUP BY 1) x
+        GROUP BY 1
+        ORDER BY 1`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b AdoptionBreadth
+		if err := rows.Scan(&b.LogTypes, &b.Families); err != nil {
+			return nil, err
+		}
+		out.Breadth = append(out.Breadth, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *productAnalyticsRepo) ActivationFunnel(ctx context.Context, from, asOf time.Time, weekDays int) ([]ActivationFunnelRow, error) {
+	logDates := make([]string, len(warehouseLogTables))
+	for i, t := range warehouseLogTables {
+		logDates[i] = "SELECT logged_by AS user_id, log_date FROM " + t.table + " WHERE logged_by IN (SELECT user_id FROM signups)"
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        WITH signups AS (
+            SELECT id AS user_id, DATE_TRUNC('week', created_at AT TIME ZONE 'UTC')::date AS cohort_week
+            FROM app_users
+            WHERE created_at >= DATE_TRUNC('week', $1::date) AND created_at < $2::date + 1
+        ), with_child AS (
+            SELECT DISTINCT m.user_id
+            FROM family_memberships m
+            JOIN families f ON f.id = m.family_id AND f.deleted_at IS NULL
+            JOIN children c ON c.family_id = m.family_id
+            WHERE m.user_id IN (SELECT user_id FROM signups)
+        ), entries AS (
+            `+strings.Join(logDates, "\n            UNION ALL ")+`
+        ), first_log AS (
+            SELECT user_id, MIN(log_date) AS first_day FROM entries GROUP BY 1
+        ), first_week AS (
+            SELECT f.user_id
+            FROM first_log f
+            JOIN entries e ON e.user_id = f.user_id AND e.log_date >= f.first_day AND e.log_date < f.first_day + 7
+            GROUP BY f.user_id
+            HAVING COUNT(DISTINCT e.log_date) >= $3
+        )
+        SELECT s.cohort_week, COUNT(*), COUNT(wc.user_id), COUNT(fl.user_id), COUNT(fw.user_id)
+        FROM signups s
+        LEFT JOIN with_child wc ON wc.user_id = s.user_id
+        LEFT JOIN first_log fl ON fl.user_id = s.user_id
+        LEFT JOIN first_week fw ON fw.user_id = s.user_id
+        GROUP BY s.cohort_week
+        ORDER BY s.cohort_week`, from.Format("2006-01-02"), asOf.Format("2006-01-02"), weekDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ActivationFunnelRow
+	for rows.Next() {
+		var f ActivationFunnelRow
+		if err := rows.Scan(&f.CohortWeek, &f.SignedUp, &f.AddedChild, &f.FirstLog, &f.FirstWeek); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (r *productAnalyticsRepo) CachedSnapshot(ctx context.Context) ([]byte, time.Time, error) {
+	var value []byte
+	var at time.Time
+	err := r.db.QueryRowContext(ctx,
+		"SELECT metric_value, calculated_at FROM system_metrics_cache WHERE metric_name = $1",
+		productAnalyticsMetric).Scan(&value, &at)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	return value, at, err
+}
+
+func (r *productAnalyticsRepo) SaveSnapshot(ctx context.Context, snapshot []byte, computedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO system_metrics_cache (metric_name, metric_value, calculated_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (metric_name) DO UPDATE
+        SET metric_value = EXCLUDED.metric_value, calculated_at = EXCLUDED.calculated_at`,
+		productAnalyticsMetric, snapshot, computedAt)
+	return err
+}
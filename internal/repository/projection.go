@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownField is returned by Columns.Project for a requested field the
+// resource doesn't have.
+var ErrUnknownField = errors.New("unknown field")
+
+// Column maps one JSON field of T to the SQL expression that fills it.
+type Column[T any] struct {
+	Field string
+	Expr  string
+	Ptr   func(*T) any
+}
+
+// Columns is the full, ordered column list of a list query. Project narrows
+// it to the fields a client asked for (sparse fieldsets), so the SELECT and
+// the Scan targets are built from the same list and can't drift apart.
+type Columns[T any] []Column[T]
+
+// Project returns the columns for fields, in declared order. No fields
+// means all columns. "id" is always kept so clients can still key rows.
+func (cs Columns[T]) Project(fields []string) (Columns[T], error) {
+	if len(fields) == 0 {
+		return cs, nil
+	}
+	want := map[string]bool{"id": true}
+	for _, f := range fields {
+		want[f] = true
+	}
+	var out Columns[T]
+	for _, c := range cs {
+		if want[c.Field] {
+			out = append(out, c)
+			delete(want, c.Field)
+		}
+	}
+	delete(want, "id")
+	for _, f := range fields {
+		if want[f] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, f)
+		}
+	}
+	return out, nil
+}
+
+// Fields returns the JSON field names, in order.
+func (cs Columns[T]) Fields() []string {
+	out := make([]string, len(cs))
+	for i, c := range cs {
+		out[i] = c.Field
+	}
+	return out
+}
+
+// SelectList is the comma-separated SQL expression list.
+func (cs Columns[T]) SelectList() string {
+	exprs := make([]string, len(cs))
+	for i, c := range cs {
+		exprs[i] = c.Expr
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// Targets returns the Scan destinations in v for the columns.
+func (cs Columns[T]) Targets(v *T) []any {
+	out := make([]any, len(cs))
+	for i, c := range cs {
+		out[i] = c.Ptr(v)
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"carecompanion/internal/models"
+)
+
+func TestColumnsProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    []string
+		wantErr bool
+	}{
+		{"all", nil, behaviorLogColumns.Fields(), false},
+		{"keeps id and declared order", []string{"mood_level", "log_date"}, []string{"id", "log_date", "mood_level"}, false},
+		{"duplicates", []string{"notes", "notes", "id"}, []string{"id", "notes"}, false},
+		{"unknown", []string{"mood_level", "password_hash"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := behaviorLogColumns.Project(tt.fields)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownField) {
+					t.Fatalf("err = %v, want ErrUnknownField", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cols.Fields(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	cols, _ := behaviorLogColumns.Project([]string{"mood_level"})
+	if got := cols.SelectList(); got != "id, mood_level" {
+		t.Errorf("SelectList = %q", got)
+	}
+	var log models.BehaviorLog
+	targets := cols.Targets(&log)
+	if targets[0] != &log.ID || targets[1] != &log.MoodLevel {
+		t.Errorf("Targets don't point at ID and MoodLevel")
+	}
+}
+
+// Every column spec must name a field by its JSON tag, or ?fields= would
+// reject names the full response uses.
+func TestLogColumnsMatchJSONTags(t *testing.T) {
+	check := func(name string, v any, fields []string) {
+		tags := map[string]bool{}
+		rt := reflect.TypeOf(v)
+		for i := 0; i < rt.NumField(); i++ {
+			tag, _, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+			tags[tag] = true
+		}
+		for _, f := range fields {
+			if !tags[f] {
+				t.Errorf("%s: column %q has no matching JSON field", name, f)
+			}
+		}
+	}
+	check("behavior", models.BehaviorLog{}, behaviorLogColumns.Fields())
+	check("diet", models.DietLog{}, dietLogColumns.Fields())
+	check("sleep", models.SleepLog{}, sleepLogColumns.Fields())
+	check("health_event", models.HealthEventLog{}, healthEventLogColumns.Fields())
+	check("error_logs", models.ErrorLogView{}, errorLogColumns.Fields())
+}
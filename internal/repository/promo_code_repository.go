@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromoValidation is one promo code check by a user. PromoCodeID is nil
+// when the code doesn't exist.
+type PromoValidation struct {
+	ID          uuid.UUID  `json:"id"`
+	PromoCodeID *uuid.UUID `json:"promo_code_id,omitempty"`
+	Code        string     `json:"code"`
+	UserID      uuid.UUID  `json:"user_id"`
+	PlanID      *uuid.UUID `json:"plan_id,omitempty"`
+	Valid       bool       `json:"valid"`
+	Reason      string     `json:"reason,omitempty"`
+	IPAddress   string     `json:"ip_address,omitempty"`
+	DeviceID    string     `json:"device_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// PromoDailyRedemptions is one UTC day of a code's redemptions.
+type PromoDailyRedemptions struct {
+	Day           time.Time `json:"day"`
+	Redemptions   int       `json:"redemptions"`
+	DiscountCents int64     `json:"discount_cents"`
+}
+
+// PromoFunnel counts validations of a code and how many of the users who
+// validated it successfully went on to redeem it.
+type PromoFunnel struct {
+	Validations      int `json:"validations"`
+	ValidValidations int `json:"valid_validations"`
+	ValidatedUsers   int `json:"validated_users"`
+	RedeemedUsers    int `json:"redeemed_users"`
+}
+
+// PromoRevenue is what a code's payments brought in against what it gave
+// away.
+type PromoRevenue struct {
+	Payments      int   `json:"payments"`
+	RevenueCents  int64 `json:"revenue_cents"`
+	DiscountCents int64 `json:"discount_cents"`
+}
+
+// PromoPlanBreakdown counts the subscriptions using a code by plan.
+type PromoPlanBreakdown struct {
+	PlanID          uuid.UUID `json:"plan_id"`
+	PlanName        string    `json:"plan_name"`
+	BillingInterval string    `json:"billing_interval"`
+	Subscriptions   int       `json:"subscriptions"`
+	Active          int       `json:"active"`
+}
+
+// PromoCampaignRedemptions counts a code's redemptions by the redeeming
+// user's signup attribution. Users with none are under empty strings.
+type PromoCampaignRedemptions struct {
+	UTMCampaign string `json:"utm_campaign"`
+	UTMSource   string `json:"utm_source"`
+	Redemptions int    `json:"redemptions"`
+}
+
+// PromoCodeRepository records promo code validations and reads the
+// aggregates behind the admin promo analytics. Aggregates only, no PHI.
+type PromoCodeRepository interface {
+	RecordValidation(ctx context.Context, v *PromoValidation) error
+	// UserRedemptions counts a user's redemptions of a code.
+	UserRedemptions(ctx context.Context, promoCodeID, userID uuid.UUID) (int, error)
+	// UserHasPaid reports whether a user has ever made a successful payment.
+	UserHasPaid(ctx context.Context, userID uuid.UUID) (bool, error)
+	// PlanInterval returns a plan's billing interval, "" when there is no
+	// such plan.
+	PlanInterval(ctx context.Context, planID uuid.UUID) (string, error)
+
+	// The rest report on [from, to).
+	DailyRedemptions(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) ([]PromoDailyRedemptions, error)
+	Funnel(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) (PromoFunnel, error)
+	Revenue(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) (PromoRevenue, error)
+	// PlanBreakdown covers user and family subscriptions created in range.
+	PlanBreakdown(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) ([]PromoPlanBreakdown, error)
+	TopCampaigns(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time, limit int) ([]PromoCampaignRedemptions, error)
+}
+
+type promoCodeRepo struct {
+	db *DB
+}
+
+// NewPromoCodeRepo creates a PromoCodeRepository on the main pool.
+func NewPromoCodeRepo(db *sql.DB) PromoCodeRepository {
+	return &promoCodeRepo{db: WrapDB(db)}
+}
+
+func (r *promoCodeRepo) RecordValidation(ctx context.Context, v *PromoValidation) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO promo_code_validations
+            (promo_code_id, code, user_id, plan_id, valid, reason, ip_address, device_id)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::inet, $8)
+        RETURNING id, created_at
+    `, v.PromoCodeID, v.Code, v.UserID, v.PlanID, v.Valid, v.Reason, v.IPAddress, v.DeviceID,
+	).Scan(&v.ID, &v.CreatedAt)
+}
+
+func (r *promoCodeRepo) UserRedemptions(ctx context.Context, promoCodeID, userID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM promo_code_usages WHERE promo_code_id = $1 AND user_id = $2
+    `, promoCodeID, userID).Scan(&n)
+	return n, err
+}
+
+func (r *promoCodeRepo) UserHasPaid(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var paid bool
+	err := r.db.QueryRowContext(ctx, `
+        SELECT EXISTS (SELECT 1 FROM payments WHERE user_id = $1 AND status = 'succeeded')
+    `, userID).Scan(&paid)
+	return paid, err
+}
+
+func (r *promoCodeRepo) PlanInterval(ctx context.Context, planID uuid.UUID) (string, error) {
+	var interval string
+	err := r.db.QueryRowContext(ctx, `
+        SELECT billing_interval::text FROM subscription_plans WHERE id = $1
+    `, planID).Scan(&interval)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return interval, err
+}
+
+func (r *promoCodeRepo) DailyRedemptions(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) ([]PromoDailyRedemptions, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT DATE_TRUNC('day', used_at AT TIME ZONE 'UTC'), COUNT(*), COALESCE(SUM(discount_applied_cents), 0)
+        FROM promo_code_usages
+        WHERE promo_code_id = $1 AND used_at >= $2 AND used_at < $3
+        GROUP BY 1 ORDER BY 1
+    `, promoCodeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PromoDailyRedemptions
+	for rows.Next() {
+		var d PromoDailyRedemptions
+		if err := rows.Scan(&d.Day, &d.Redemptions, &d.DiscountCents); err != nil {
+			return nil, err
+		}
+		d.Day = time.Date(d.Day.Year(), d.Day.Month(), d.Day.Day(), 0, 0, 0, 0, time.UTC)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *promoCodeRepo) Funnel(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) (PromoFunnel, error) {
+	// A validated user counts as redeemed if they redeemed the code at or
+	// after their first successful validation, whenever that was.
+	var f PromoFunnel
+	err := r.db.QueryRowContext(ctx, `
+        WITH v AS (
+            SELECT * FROM promo_code_validations
+            WHERE promo_code_id = $1 AND created_at >= $2 AND created_at < $3
+        ), validated AS (
+            SELECT user_id, MIN(created_at) AS first_valid
+            FROM v WHERE valid AND user_id IS NOT NULL
+            GROUP BY user_id
+        )
+        SELECT
+            (SELECT COUNT(*) FROM v),
+            (SELECT COUNT(*) FROM v WHERE valid),
+            (SELECT COUNT(*) FROM validated),
+            (SELECT COUNT(*) FROM validated vu WHERE EXISTS (
+                SELECT 1 FROM promo_code_usages pu
+                WHERE pu.promo_code_id = $1 AND pu.user_id = vu.user_id AND pu.used_at >= vu.first_valid))
+    `, promoCodeID, from, to).Scan(&f.Validations, &f.ValidValidations, &f.ValidatedUsers, &f.RedeemedUsers)
+	return f, err
+}
+
+func (r *promoCodeRepo) Revenue(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) (PromoRevenue, error) {
+	var rev PromoRevenue
+	err := r.db.QueryRowContext(ctx, `
+        SELECT
+            COUNT(*),
+            COALESCE(SUM(amount_cents - COALESCE(refund_amount_cents, 0)), 0),
+            (SELECT COALESCE(SUM(discount_applied_cents), 0) FROM promo_code_usages
+             WHERE promo_code_id = $1 AND used_at >= $2 AND used_at < $3)
+        FROM payments
+        WHERE promo_code_id = $1 AND status IN ('succeeded', 'partially_refunded')
+          AND created_at >= $2 AND created_at < $3
+    `, promoCodeID, from, to).Scan(&rev.Payments, &rev.RevenueCents, &rev.DiscountCents)
+	return rev, err
+}
+
+func (r *promoCodeRepo) PlanBreakdown(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time) ([]PromoPlanBreakdown, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        WITH subs AS (
+            SELECT plan_id, status::text AS status FROM user_subscriptions
+            WHERE promo_code_id = $1 AND created_at >= $2 AND created_at < $3
+            UNION ALL
+            SELECT plan_id, status::text FROM family_subscriptions
+            WHERE promo_code_id = $1 AND created_at >= $2 AND created_at < $3
+        )
+        SELECT sp.id, sp.name, sp.billing_interval::text, COUNT(*),
+               COUNT(*) FILTER (WHERE subs.status IN ('active', 'trialing', 'past_due'))
+        FROM subs
+        JOIN subscription_plans sp ON sp.id = subs.plan_id
+        GROUP BY sp.id, sp.name, sp.billing_interval
+        ORDER BY COUNT(*) DESC, sp.name
+    `, promoCodeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PromoPlanBreakdown
+	for rows.Next() {
+		var b PromoPlanBreakdown
+		if err := rows.Scan(&b.PlanID, &b.PlanName, &b.BillingInterval, &b.Subscriptions, &b.Active); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *promoCodeRepo) TopCampaigns(ctx context.Context, promoCodeID uuid.UUID, from, to time.Time, limit int) ([]PromoCampaignRedemptions, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT COALESCE(sa.utm_campaign, ''), COALESCE(sa.utm_source, ''), COUNT(*)
+        FROM promo_code_usages pu
+        LEFT JOIN signup_attributions sa ON sa.user_id = pu.user_id
+        WHERE pu.promo_code_id = $1 AND pu.used_at >= $2 AND pu.used_at < $3
+        GROUP BY 1, 2
+        ORDER BY 3 DESC, 1, 2
+        LIMIT $4
+    `, promoCodeID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PromoCampaignRedemptions
+	for rows.Next() {
+		var c PromoCampaignRedemptions
+		if err := rows.Scan(&c.UTMCampaign, &c.UTMSource, &c.Redemptions); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
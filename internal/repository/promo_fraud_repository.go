@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Promo fraud flag statuses. Flags start pending; an admin approves
+// (the use is fine, any held discount is released) or rejects them.
+const (
+	PromoFraudPending  = "pending"
+	PromoFraudApproved = "approved"
+	PromoFraudRejected = "rejected"
+)
+
+// PromoFraudSignal is one abuse check a promo code check tripped.
+type PromoFraudSignal struct {
+	Signal string `json:"signal"`
+	Detail string `json:"detail"`
+}
+
+// PromoFraudFlag is a promo code check flagged for review. Code and
+// UserEmail are filled in on reads.
+type PromoFraudFlag struct {
+	ID           uuid.UUID          `json:"id"`
+	PromoCodeID  uuid.UUID          `json:"promo_code_id"`
+	Code         string             `json:"code,omitempty"`
+	ValidationID *uuid.UUID         `json:"validation_id,omitempty"`
+	UserID       uuid.UUID          `json:"user_id"`
+	UserEmail    string             `json:"user_email,omitempty"`
+	Signals      []PromoFraudSignal `json:"signals"`
+	IPAddress    string             `json:"ip_address,omitempty"`
+	DeviceID     string             `json:"device_id,omitempty"`
+	Held         bool               `json:"held"`
+	Status       string             `json:"status"`
+	ReviewedBy   *uuid.UUID         `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time         `json:"reviewed_at,omitempty"`
+	ReviewNote   string             `json:"review_note,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// PromoFraudRepository reads the abuse signals for promo code checks and
+// stores the flags they raise.
+type PromoFraudRepository interface {
+	// UsersSharingIP counts other users who successfully checked the code
+	// from ip since the given time.
+	UsersSharingIP(ctx context.Context, promoCodeID, userID uuid.UUID, ip string, since time.Time) (int, error)
+	// UsersSharingDevice is UsersSharingIP for a device identifier.
+	UsersSharingDevice(ctx context.Context, promoCodeID, userID uuid.UUID, deviceID string, since time.Time) (int, error)
+	// DiscountedByOtherCode reports whether the user's own or family
+	// subscription is active on a different promo code.
+	DiscountedByOtherCode(ctx context.Context, promoCodeID, userID uuid.UUID) (bool, error)
+	// CodesChecked counts the distinct codes the user successfully checked
+	// since the given time.
+	CodesChecked(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+
+	// CreateFlag inserts f unless the user already has a pending flag on
+	// the code, and reports whether it did. ID, Status and CreatedAt are
+	// filled in when it did.
+	CreateFlag(ctx context.Context, f *PromoFraudFlag) (bool, error)
+	// GetFlag returns nil, nil when there is no such flag.
+	GetFlag(ctx context.Context, id uuid.UUID) (*PromoFraudFlag, error)
+	// LatestFlag returns the user's newest flag on the code, nil, nil when
+	// there is none.
+	LatestFlag(ctx context.Context, promoCodeID, userID uuid.UUID) (*PromoFraudFlag, error)
+	// ListFlags returns the newest flags first, only those in status when
+	// given.
+	ListFlags(ctx context.Context, status string, limit int) ([]PromoFraudFlag, error)
+	// Review moves a pending flag to status and reports false when it was
+	// no longer pending.
+	Review(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error)
+}
+
+type promoFraudRepo struct {
+	db *DB
+}
+
+// NewPromoFraudRepo creates a PromoFraudRepository on the main pool.
+func NewPromoFraudRepo(db *sql.DB) PromoFraudRepository {
+	return &promoFraudRepo{db: WrapDB(db)}
+}
+
+func (r *promoFraudRepo) UsersSharingIP(ctx context.Context, promoCodeID, userID uuid.UUID, ip string, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(DISTINCT user_id) FROM promo_code_validations
+        WHERE promo_code_id = $1 AND user_id <> $2 AND ip_address = $3::inet
+          AND valid AND created_at >= $4
+    `, promoCodeID, userID, ip, since).Scan(&n)
+	return n, err
+}
+
+func (r *promoFraudRepo) UsersSharingDevice(ctx context.Context, promoCodeID, userID uuid.UUID, deviceID string, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(DISTINCT user_id) FROM promo_code_validations
+        WHERE promo_code_id = $1 AND user_id <> $2 AND device_id = $3
+          AND valid AND created_at >= $4
+    `, promoCodeID, userID, deviceID, since).Scan(&n)
+	return n, err
+}
+
+func (r *promoFraudRepo) DiscountedByOtherCode(ctx context.Context, promoCodeID, userID uuid.UUID) (bool, error) {
+	var discounted bool
+	err := r.db.QueryRowContext(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM user_subscriptions
+            WHERE user_id = $2 AND promo_code_id IS NOT NULL AND promo_code_id <> $1
+              AND status IN ('active', 'trialing', 'past_due')
+        ) OR EXISTS (
+            SELECT 1 FROM family_subscriptions fs
+            JOIN family_memberships fm ON fm.family_id = fs.family_id AND fm.is_active
+            WHERE fm.user_id = $2 AND fs.promo_code_id IS NOT NULL AND fs.promo_code_id <> $1
+              AND fs.status IN ('active', 'trialing', 'past_due')
+        )
+    `, promoCodeID, userID).Scan(&discounted)
+	return discounted, err
+}
+
+func (r *promoFraudRepo) CodesChecked(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(DISTINCT promo_code_id) FROM promo_code_validations
+        WHERE user_id = $1 AND valid AND created_at >= $2
+    `, userID, since).Scan(&n)
+	return n, err
+}
+
+func (r *promoFraudRepo) CreateFlag(ctx context.Context, f *PromoFraudFlag) (bool, error) {
+	signals, err := json.Marshal(f.Signals)
+	if err != nil {
+		return false, err
+	}
+	err = r.db.QueryRowContext(ctx, `
+        INSERT INTO promo_fraud_flags
+            (promo_code_id, validation_id, user_id, signals, ip_address, device_id, held)
+        VALUES ($1, $2, $3, $4, NULLIF($5, '')::inet, $6, $7)
+        ON CONFLICT (promo_code_id, user_id) WHERE status = 'pending' DO NOTHING
+        RETURNING id, status, created_at
+    `, f.PromoCodeID, f.ValidationID, f.UserID, signals, f.IPAddress, f.DeviceID, f.Held,
+	).Scan(&f.ID, &f.Status, &f.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+const promoFraudFlagCols = `f.id, f.promo_code_id, pc.code, f.validation_id, f.user_id,
+        COALESCE(u.email, ''), f.signals, COALESCE(host(f.ip_address), ''), f.device_id,
+        f.held, f.status, f.reviewed_by, f.reviewed_at, f.review_note, f.created_at`
+
+const promoFraudFlagFrom = `
+        FROM promo_fraud_flags f
+        JOIN promo_codes pc ON pc.id = f.promo_code_id
+        LEFT JOIN app_users u ON u.id = f.user_id`
+
+func scanPromoFraudFlag(row interface{ Scan(...any) error }, f *PromoFraudFlag) error {
+	var signals []byte
+	if err := row.Scan(&f.ID, &f.PromoCodeID, &f.Code, &f.ValidationID, &f.UserID,
+		&f.UserEmail, &signals, &f.IPAddress, &f.DeviceID,
+		&f.Held, &f.Status, &f.ReviewedBy, &f.ReviewedAt, &f.ReviewNote, &f.CreatedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal(signals, &f.Signals)
+}
+
+func (r *promoFraudRepo) getFlag(ctx context.Context, where string, args ...interface{}) (*PromoFraudFlag, error) {
+	var f PromoFraudFlag
+	err := scanPromoFraudFlag(r.db.QueryRowContext(ctx, `SELECT `+promoFraudFlagCols+promoFraudFlagFrom+` `+where, args...), &f)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *promoFraudRepo) GetFlag(ctx context.Context, id uuid.UUID) (*PromoFraudFlag, error) {
+	return r.getFlag(ctx, `WHERE f.id = $1`, id)
+}
+
+func (r *promoFraudRepo) LatestFlag(ctx context.Context, promoCodeID, userID uuid.UUID) (*PromoFraudFlag, error) {
+	return r.getFlag(ctx, `WHERE f.promo_code_id = $1 AND f.user_id = $2
+        ORDER BY f.created_at DESC LIMIT 1`, promoCodeID, userID)
+}
+
+func (r *promoFraudRepo) ListFlags(ctx context.Context, status string, limit int) ([]PromoFraudFlag, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+promoFraudFlagCols+promoFraudFlagFrom+`
+        WHERE ($1 = '' OR f.status = $1)
+        ORDER BY f.created_at DESC
+        LIMIT $2`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PromoFraudFlag
+	for rows.Next() {
+		var f PromoFraudFlag
+		if err := scanPromoFraudFlag(rows, &f); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (r *promoFraudRepo) Review(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE promo_fraud_flags
+        SET status = $2, reviewed_by = $3, reviewed_at = NOW(), review_note = $4
+        WHERE id = $1 AND status = 'pending'
+    `, id, status, by, note)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
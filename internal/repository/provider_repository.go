@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// ErrProviderGrantExists is returned when the child already has an open
+// invitation or grant for the address.
+var ErrProviderGrantExists = apperr.Conflict("this provider has already been invited for this child")
+
+// ProviderRepository stores external contributors: their branding, the
+// per-child grants families give them and the logs they entered.
+type ProviderRepository interface {
+	// GetProfile returns nil, nil when the user has no provider profile.
+	GetProfile(ctx context.Context, userID uuid.UUID) (*models.ProviderProfile, error)
+	UpsertProfile(ctx context.Context, p *models.ProviderProfile) error
+
+	// CreateGrant inserts a pending grant with the invitation's token
+	// hash, or returns ErrProviderGrantExists.
+	CreateGrant(ctx context.Context, g *models.ProviderGrant, tokenHash string) error
+	// GetGrant returns nil, nil when there is no such grant.
+	GetGrant(ctx context.Context, id uuid.UUID) (*models.ProviderGrant, error)
+	// GetGrantByToken returns the grant whose invitation token hashes to
+	// tokenHash, or nil, nil.
+	GetGrantByToken(ctx context.Context, tokenHash string) (*models.ProviderGrant, error)
+	// ListGrantsByChild returns the child's grants, newest first, with
+	// the providers' branding.
+	ListGrantsByChild(ctx context.Context, childID uuid.UUID) ([]models.ProviderGrant, error)
+	// ListActiveGrantsByProvider returns the grants a provider can record
+	// under, with the children's first names.
+	ListActiveGrantsByProvider(ctx context.Context, userID uuid.UUID) ([]models.ProviderGrant, error)
+	// AcceptGrant activates a pending grant for userID and spends the
+	// invitation token.
+	AcceptGrant(ctx context.Context, id, userID uuid.UUID, at time.Time) error
+	UpdateLogTypes(ctx context.Context, id uuid.UUID, logTypes []string) error
+	RevokeGrant(ctx context.Context, id, by uuid.UUID, at time.Time) error
+
+	// RecordEntry attributes a log to the grant it was entered under.
+	RecordEntry(ctx context.Context, e *models.ProviderLogEntry) error
+	// Attributions returns the provider attribution of each of the logs
+	// that has one.
+	Attributions(ctx context.Context, logType string, logIDs []uuid.UUID) (map[uuid.UUID]*models.ProviderAttribution, error)
+	// ListEntries returns the logs entered under a grant, newest first.
+	ListEntries(ctx context.Context, grantID uuid.UUID, limit int) ([]models.ProviderLogEntry, error)
+}
+
+type providerRepo struct {
+	db *DB
+}
+
+// NewProviderRepo creates a ProviderRepository on the main pool.
+func NewProviderRepo(db *sql.DB) ProviderRepository {
+	return &providerRepo{db: WrapDB(db)}
+}
+
+const providerProfileCols = `user_id, organization_name, provider_type, brand_color, logo_url, created_at, updated_at`
+
+func (r *providerRepo) GetProfile(ctx context.Context, userID uuid.UUID) (*models.ProviderProfile, error) {
+	var p models.ProviderProfile
+	err := r.db.QueryRowContext(ctx, `SELECT `+providerProfileCols+`
+        FROM provider_profiles WHERE user_id = $1`, userID,
+	).Scan(&p.UserID, &p.OrganizationName, &p.ProviderType, &p.BrandColor, &p.LogoURL, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *providerRepo) UpsertProfile(ctx context.Context, p *models.ProviderProfile) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO provider_profiles (user_id, organization_name, provider_type, brand_color, logo_url)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id) DO UPDATE SET
+            organization_name = EXCLUDED.organization_name,
+            provider_type = EXCLUDED.provider_type,
+            brand_color = EXCLUDED.brand_color,
+            logo_url = EXCLUDED.logo_url,
+            updated_at = NOW()
+        RETURNING created_at, updated_at
+    `, p.UserID, p.OrganizationName, p.ProviderType, p.BrandColor, p.LogoURL,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+}
+
+const providerGrantCols = `g.id, g.child_id, g.family_id, g.provider_user_id, g.invite_email, g.organization_name,
+        g.provider_type, g.log_types, g.status, g.invite_expires_at, g.invited_by, g.accepted_at,
+        g.revoked_at, g.revoked_by, g.created_at, g.updated_at`
+
+func scanProviderGrant(row interface{ Scan(...any) error }, g *models.ProviderGrant, extra ...any) error {
+	return row.Scan(append([]any{&g.ID, &g.ChildID, &g.FamilyID, &g.ProviderUserID, &g.InviteEmail, &g.OrganizationName,
+		&g.ProviderType, &g.LogTypes, &g.Status, &g.InviteExpiresAt, &g.InvitedBy, &g.AcceptedAt,
+		&g.RevokedAt, &g.RevokedBy, &g.CreatedAt, &g.UpdatedAt}, extra...)...)
+}
+
+func (r *providerRepo) CreateGrant(ctx context.Context, g *models.ProviderGrant, tokenHash string) error {
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO provider_grants (child_id, family_id, invite_email, organization_name, provider_type,
+            log_types, status, invite_token_hash, invite_expires_at, invited_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id, created_at, updated_at
+    `, g.ChildID, g.FamilyID, g.InviteEmail, g.OrganizationName, g.ProviderType,
+		g.LogTypes, g.Status, tokenHash, g.InviteExpiresAt, g.InvitedBy,
+	).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrProviderGrantExists
+	}
+	return err
+}
+
+func (r *providerRepo) getGrant(ctx context.Context, where string, arg any) (*models.ProviderGrant, error) {
+	var g models.ProviderGrant
+	err := scanProviderGrant(r.db.QueryRowContext(ctx, `SELECT `+providerGrantCols+`
+        FROM provider_grants g WHERE `+where, arg), &g)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *providerRepo) GetGrant(ctx context.Context, id uuid.UUID) (*models.ProviderGrant, error) {
+	return r.getGrant(ctx, "g.id = $1", id)
+}
+
+func (r *providerRepo) GetGrantByToken(ctx context.Context, tokenHash string) (*models.ProviderGrant, error) {
+	return r.getGrant(ctx, "g.invite_token_hash = $1", tokenHash)
+}
+
+// listGrants runs a grant query that also selects the child's first name
+// and the provider's branding columns.
+func (r *providerRepo) listGrants(ctx context.Context, query string, arg any) ([]models.ProviderGrant, error) {
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.ProviderGrant
+	for rows.Next() {
+		var g models.ProviderGrant
+		var p models.ProviderProfile
+		var orgName, providerType sql.NullString
+		var created, updated sql.NullTime
+		if err := scanProviderGrant(rows, &g, &g.ChildFirstName, &orgName, &providerType,
+			&p.BrandColor, &p.LogoURL, &created, &updated); err != nil {
+			return nil, err
+		}
+		if orgName.Valid && g.ProviderUserID != nil {
+			p.UserID = *g.ProviderUserID
+			p.OrganizationName, p.ProviderType = orgName.String, providerType.String
+			p.CreatedAt, p.UpdatedAt = created.Time, updated.Time
+			g.Branding = &p
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+const providerGrantListFrom = `, c.first_name, p.organization_name, p.provider_type, p.brand_color, p.logo_url,
+            p.created_at, p.updated_at
+        FROM provider_grants g
+        JOIN children c ON c.id = g.child_id
+        LEFT JOIN provider_profiles p ON p.user_id = g.provider_user_id`
+
+func (r *providerRepo) ListGrantsByChild(ctx context.Context, childID uuid.UUID) ([]models.ProviderGrant, error) {
+	return r.listGrants(ctx, `SELECT `+providerGrantCols+providerGrantListFrom+`
+        WHERE g.child_id = $1
+        ORDER BY g.created_at DESC`, childID)
+}
+
+func (r *providerRepo) ListActiveGrantsByProvider(ctx context.Context, userID uuid.UUID) ([]models.ProviderGrant, error) {
+	return r.listGrants(ctx, `SELECT `+providerGrantCols+providerGrantListFrom+`
+        WHERE g.provider_user_id = $1 AND g.status = 'active'
+        ORDER BY c.first_name, g.created_at`, userID)
+}
+
+func (r *providerRepo) AcceptGrant(ctx context.Context, id, userID uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE provider_grants
+        SET status = 'active', provider_user_id = $2, accepted_at = $3,
+            invite_token_hash = NULL, updated_at = NOW()
+        WHERE id = $1 AND status = 'pending'
+    `, id, userID, at)
+	return err
+}
+
+func (r *providerRepo) UpdateLogTypes(ctx context.Context, id uuid.UUID, logTypes []string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE provider_grants SET log_types = $2, updated_at = NOW() WHERE id = $1
+    `, id, pq.Array(logTypes))
+	return err
+}
+
+func (r *providerRepo) RevokeGrant(ctx context.Context, id, by uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE provider_grants
+        SET status = 'revoked', revoked_at = $3, revoked_by = $2,
+            invite_token_hash = NULL, updated_at = NOW()
+        WHERE id = $1 AND status <> 'revoked'
+    `, id, by, at)
+	return err
+}
+
+func (r *providerRepo) RecordEntry(ctx context.Context, e *models.ProviderLogEntry) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO provider_log_entries (log_type, log_id, grant_id, provider_user_id, organization_name)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING created_at
+    `, e.LogType, e.LogID, e.GrantID, e.ProviderUserID, e.OrganizationName,
+	).Scan(&e.CreatedAt)
+}
+
+func (r *providerRepo) Attributions(ctx context.Context, logType string, logIDs []uuid.UUID) (map[uuid.UUID]*models.ProviderAttribution, error) {
+	out := map[uuid.UUID]*models.ProviderAttribution{}
+	if len(logIDs) == 0 {
+		return out, nil
+	}
+	ids := make([]string, len(logIDs))
+	for i, id := range logIDs {
+		ids[i] = id.String()
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT e.log_id, e.grant_id, e.provider_user_id, e.organization_name, p.brand_color, p.logo_url
+        FROM provider_log_entries e
+        LEFT JOIN provider_profiles p ON p.user_id = e.provider_user_id
+        WHERE e.log_type = $1 AND e.log_id = ANY($2::uuid[])
+    `, logType, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var logID uuid.UUID
+		var a models.ProviderAttribution
+		if err := rows.Scan(&logID, &a.GrantID, &a.ProviderUserID, &a.OrganizationName, &a.BrandColor, &a.LogoURL); err != nil {
+			return nil, err
+		}
+		out[logID] = &a
+	}
+	return out, rows.Err()
+}
+
+func (r *providerRepo) ListEntries(ctx context.Context, grantID uuid.UUID, limit int) ([]models.ProviderLogEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT e.log_type, e.log_id, e.grant_id, e.provider_user_id, e.organization_name,
+            p.brand_color, p.logo_url, e.created_at
+        FROM provider_log_entries e
+        LEFT JOIN provider_profiles p ON p.user_id = e.provider_user_id
+        WHERE e.grant_id = $1
+        ORDER BY e.created_at DESC
+        LIMIT $2
+    `, grantID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.ProviderLogEntry
+	for rows.Next() {
+		var e models.ProviderLogEntry
+		if err := rows.Scan(&e.LogType, &e.LogID, &e.GrantID, &e.ProviderUserID, &e.OrganizationName,
+			&e.BrandColor, &e.LogoURL, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// QueryStatSample is one statement's pg_stat_statements counters at a
+// snapshot. The Delta fields are the change since the statement's previous
+// snapshot, filled in by the collector.
+type QueryStatSample struct {
+	QueryID        int64
+	Query          string
+	Method         string
+	Calls          int64
+	TotalExecMs    float64
+	Rows           int64
+	SharedBlksHit  int64
+	SharedBlksRead int64
+
+	DeltaCalls  int64
+	DeltaExecMs float64
+	DeltaRows   int64
+}
+
+// QueryStatTotal is one statement summed over a window of snapshots.
+type QueryStatTotal struct {
+	QueryID     int64   `json:"queryid,string"`
+	Query       string  `json:"query"`
+	Method      string  `json:"method,omitempty"`
+	Calls       int64   `json:"calls"`
+	TotalExecMs float64 `json:"total_exec_ms"`
+	MeanExecMs  float64 `json:"mean_exec_ms"`
+	Rows        int64   `json:"rows"`
+	// CacheHitPct is shared buffer hits over hits+reads as of the latest
+	// snapshot (cumulative, not windowed).
+	CacheHitPct float64 `json:"cache_hit_pct"`
+}
+
+// QueryStatPoint is one day of a statement's history.
+type QueryStatPoint struct {
+	Day         time.Time `json:"day"`
+	Calls       int64     `json:"calls"`
+	TotalExecMs float64   `json:"total_exec_ms"`
+	MeanExecMs  float64   `json:"mean_exec_ms"`
+}
+
+// Orderings accepted by QueryStatsRepository.Totals.
+const (
+	QueryStatsByTotal = "total"
+	QueryStatsByMean  = "mean"
+	QueryStatsByCalls = "calls"
+)
+
+// QueryStatsRepository reads pg_stat_statements and owns db_query_stats.
+type QueryStatsRepository interface {
+	// Available returns an error explaining why pg_stat_statements can't
+	// be read (extension missing, not preloaded, no permission).
+	Available(ctx context.Context) error
+	// ReadStatements returns the current database's top limit statements
+	// by total execution time, summed across roles.
+	ReadStatements(ctx context.Context, limit int) ([]QueryStatSample, error)
+	// LastSnapshots returns each statement's most recent stored snapshot.
+	LastSnapshots(ctx context.Context, queryIDs []int64) (map[int64]QueryStatSample, error)
+	// HasSnapshots reports whether the collector has stored anything yet.
+	HasSnapshots(ctx context.Context) (bool, error)
+	// SaveSnapshot stores samples captured at at.
+	SaveSnapshot(ctx context.Context, at time.Time, samples []QueryStatSample) error
+	// Totals sums the deltas captured in [from, to) per statement, ordered
+	// by one of the QueryStatsBy* constants.
+	Totals(ctx context.Context, from, to time.Time, orderBy string, limit int) ([]QueryStatTotal, error)
+	// TotalsByID is Totals for the given statements, keyed by queryid.
+	TotalsByID(ctx context.Context, from, to time.Time, queryIDs []int64) (map[int64]QueryStatTotal, error)
+	// Daily is one statement's per-day totals since from, oldest first.
+	Daily(ctx context.Context, queryID int64, from time.Time) ([]QueryStatPoint, error)
+	// Prune deletes snapshots captured before cutoff.
+	Prune(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type queryStatsRepo struct {
+	db *DB
+}
+
+// NewQueryStatsRepo creates a QueryStatsRepository on the main pool.
+func NewQueryStatsRepo(db *sql.DB) QueryStatsRepository {
+	return &queryStatsRepo{db: WrapDB(db)}
+}
+
+func (r *queryStatsRepo) Available(ctx context.Context) error {
+	var installed bool
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements')`,
+	).Scan(&installed); err != nil {
+		return err
+	}
+	if !installed {
+		return fmt.Errorf("pg_stat_statements extension is not installed (CREATE EXTENSION pg_stat_statements)")
+	}
+	// Fails with "must be loaded via shared_preload_libraries" when the
+	// extension exists but the parameter group doesn't preload it.
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM pg_stat_statements LIMIT 1`).Scan(&n)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func (r *queryStatsRepo) ReadStatements(ctx context.Context, limit int) ([]QueryStatSample, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT queryid, LEFT(MIN(query), 4000),
+               SUM(calls), SUM(total_exec_time), SUM(rows),
+               SUM(shared_blks_hit), SUM(shared_blks_read)
+        FROM pg_stat_statements
+        WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+          AND queryid IS NOT NULL
+        GROUP BY queryid
+        ORDER BY SUM(total_exec_time) DESC
+        LIMIT $1
+    `, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []QueryStatSample
+	for rows.Next() {
+		var s QueryStatSample
+		if err := rows.Scan(&s.QueryID, &s.Query, &s.Calls, &s.TotalExecMs, &s.Rows, &s.SharedBlksHit, &s.SharedBlksRead); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *queryStatsRepo) LastSnapshots(ctx context.Context, queryIDs []int64) (map[int64]QueryStatSample, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT DISTINCT ON (queryid) queryid, calls, total_exec_ms, rows
+        FROM db_query_stats
+        WHERE queryid = ANY($1)
+        ORDER BY queryid, captured_at DESC
+    `, pq.Array(queryIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]QueryStatSample{}
+	for rows.Next() {
+		var s QueryStatSample
+		if err := rows.Scan(&s.QueryID, &s.Calls, &s.TotalExecMs, &s.Rows); err != nil {
+			return nil, err
+		}
+		out[s.QueryID] = s
+	}
+	return out, rows.Err()
+}
+
+func (r *queryStatsRepo) HasSnapshots(ctx context.Context) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM db_query_stats)`).Scan(&ok)
+	return ok, err
+}
+
+func (r *queryStatsRepo) SaveSnapshot(ctx context.Context, at time.Time, samples []QueryStatSample) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO db_query_stats
+            (captured_at, queryid, query, method, calls, total_exec_ms, rows,
+             shared_blks_hit, shared_blks_read, delta_calls, delta_exec_ms, delta_rows)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, s := range samples {
+		if _, err := stmt.ExecContext(ctx, at, s.QueryID, s.Query, s.Method, s.Calls, s.TotalExecMs, s.Rows,
+			s.SharedBlksHit, s.SharedBlksRead, s.DeltaCalls, s.DeltaExecMs, s.DeltaRows); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// queryStatsTotalsSQL sums deltas per statement; text, method and cache
+// ratio come from the statement's newest snapshot in the window.
+const queryStatsTotalsSQL = `
+    SELECT queryid,
+           (ARRAY_AGG(query ORDER BY captured_at DESC))[1],
+           COALESCE((ARRAY_AGG(method ORDER BY captured_at DESC))[1], ''),
+           SUM(delta_calls), SUM(delta_exec_ms), SUM(delta_rows),
+           COALESCE((ARRAY_AGG(shared_blks_hit * 100.0 / NULLIF(shared_blks_hit + shared_blks_read, 0)
+                     ORDER BY captured_at DESC))[1], 0)
+    FROM db_query_stats
+    WHERE captured_at >= $1 AND captured_at < $2`
+
+func scanQueryStatTotals(rows *Rows) ([]QueryStatTotal, error) {
+	defer rows.Close()
+	var out []QueryStatTotal
+	for rows.Next() {
+		var t QueryStatTotal
+		if err := rows.Scan(&t.QueryID, &t.Query, &t.Method, &t.Calls, &t.TotalExecMs, &t.Rows, &t.CacheHitPct); err != nil {
+			return nil, err
+		}
+		if t.Calls > 0 {
+			t.MeanExecMs = t.TotalExecMs / float64(t.Calls)
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *queryStatsRepo) Totals(ctx context.Context, from, to time.Time, orderBy string, limit int) ([]QueryStatTotal, error) {
+	order := "SUM(delta_exec_ms) DESC"
+	switch orderBy {
+	case QueryStatsByMean:
+		order = "SUM(delta_exec_ms) / NULLIF(SUM(delta_calls), 0) DESC NULLS LAST"
+	case QueryStatsByCalls:
+		order = "SUM(delta_calls) DESC"
+	}
+	rows, err := r.db.QueryContext(ctx, queryStatsTotalsSQL+`
+    GROUP BY queryid
+    HAVING SUM(delta_calls) > 0
+    ORDER BY `+order+`
+    LIMIT $3`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanQueryStatTotals(rows)
+}
+
+func (r *queryStatsRepo) TotalsByID(ctx context.Context, from, to time.Time, queryIDs []int64) (map[int64]QueryStatTotal, error) {
+	rows, err := r.db.QueryContext(ctx, queryStatsTotalsSQL+`
+      AND queryid = ANY($3)
+    GROUP BY queryid`, from, to, pq.Array(queryIDs))
+	if err != nil {
+		return nil, err
+	}
+	totals, err := scanQueryStatTotals(rows)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64]QueryStatTotal, len(totals))
+	for _, t := range totals {
+		out[t.QueryID] = t
+	}
+	return out, nil
+}
+
+func (r *queryStatsRepo) Daily(ctx context.Context, queryID int64, from time.Time) ([]QueryStatPoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT date_trunc('day', captured_at), SUM(delta_calls), SUM(delta_exec_ms)
+        FROM db_query_stats
+        WHERE queryid = $1 AND captured_at >= $2
+        GROUP BY 1
+        ORDER BY 1
+    `, queryID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []QueryStatPoint
+	for rows.Next() {
+		var p QueryStatPoint
+		if err := rows.Scan(&p.Day, &p.Calls, &p.TotalExecMs); err != nil {
+			return nil, err
+		}
+		if p.Calls > 0 {
+			p.MeanExecMs = p.TotalExecMs / float64(p.Calls)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *queryStatsRepo) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM db_query_stats WHERE captured_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
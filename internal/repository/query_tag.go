@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// QueryTagOptions controls the SQL comments the repositories add to their
+// statements. Set once at startup with SetQueryTagOptions.
+type QueryTagOptions struct {
+	Enabled bool
+	// RequestID adds req=<chi request id> for statements run on behalf of
+	// an HTTP request. The SQL text then differs per request, so pgx's
+	// per-connection statement cache can't reuse a prepared statement
+	// across requests and every statement pays an extra parse round trip.
+	// Off by default; turn it on only while chasing a specific request.
+	RequestID bool
+}
+
+var queryTags = QueryTagOptions{Enabled: true}
+
+// SetQueryTagOptions replaces the tagging options. Not safe to call once
+// repositories are serving queries.
+func SetQueryTagOptions(opts QueryTagOptions) {
+	queryTags = opts
+}
+
+// DB is a *sql.DB whose ExecContext, QueryContext, QueryRowContext and
+// PrepareContext prefix the statement with
+//
+//	/* method=logRepo.GetBehaviorLogs */
+//
+// naming the repository method that ran it (plus req=<request id> when
+// QueryTagOptions.RequestID is on). The comment survives into
+// pg_stat_statements, the RDS slow query log and Performance Insights, so a
+// slow statement there can be traced back to its call site.
+type DB struct {
+	*sql.DB
+	// watch, when set, sees each statement before it runs.
+	watch func(ctx context.Context, query string, args []any)
+}
+
+// WrapDB returns db with query tagging, or nil for a nil db.
+func WrapDB(db *sql.DB) *DB {
+	if db == nil {
+		return nil
+	}
+	return &DB{DB: db}
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if db.watch != nil {
+		db.watch(ctx, query, args)
+	}
+	st := newStatement(ctx, query)
+	defer st.cancel()
+	if family, ok := FamilyScope(ctx); ok {
+		res, err := db.scopedExec(st.ctx, family, st.query, args)
+		return res, st.done(err)
+	}
+	res, err := db.DB.ExecContext(st.ctx, st.query, args...)
+	return res, st.done(err)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	if db.watch != nil {
+		db.watch(ctx, query, args)
+	}
+	st := newStatement(ctx, query)
+	var rows *Rows
+	var err error
+	if family, ok := FamilyScope(ctx); ok {
+		rows, err = db.scopedQuery(st.ctx, family, st.query, args)
+	} else {
+		var r *sql.Rows
+		if r, err = db.DB.QueryContext(st.ctx, st.query, args...); err == nil {
+			rows = &Rows{Rows: r}
+		}
+	}
+	if err != nil {
+		st.cancel()
+		return nil, st.done(err)
+	}
+	rows.cancel = st.cancel
+	return rows, nil
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	if db.watch != nil {
+		db.watch(ctx, query, args)
+	}
+	st := newStatement(ctx, query)
+	var row *Row
+	if family, ok := FamilyScope(ctx); ok {
+		row = db.scopedQueryRow(st.ctx, family, st.query, args)
+	} else {
+		row = &Row{Row: db.DB.QueryRowContext(st.ctx, st.query, args...)}
+	}
+	row.cancel = st.cancel
+	st.done(row.Err())
+	return row
+}
+
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if db.watch != nil {
+		db.watch(ctx, query, nil)
+	}
+	if _, ok := FamilyScope(ctx); ok {
+		return nil, errScopedPrepare
+	}
+	return db.DB.PrepareContext(ctx, tagQuery(ctx, query))
+}
+
+// BeginTx starts a transaction whose statements are tagged the same way.
+// Under a family scope the transaction is limited to the family.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if family, ok := FamilyScope(ctx); ok {
+		if err := scopeTx(ctx, tx, family); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return &Tx{Tx: tx, watch: db.watch}, nil
+}
+
+// Tx is the tagging counterpart of *sql.Tx.
+type Tx struct {
+	*sql.Tx
+	watch func(ctx context.Context, query string, args []any)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx.watch != nil {
+		tx.watch(ctx, query, args)
+	}
+	st := newStatement(ctx, query)
+	defer st.cancel()
+	res, err := tx.Tx.ExecContext(st.ctx, st.query, args...)
+	return res, st.done(err)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	if tx.watch != nil {
+		tx.watch(ctx, query, args)
+	}
+	st := newStatement(ctx, query)
+	rows, err := tx.Tx.QueryContext(st.ctx, st.query, args...)
+	if err != nil {
+		st.cancel()
+		return nil, st.done(err)
+	}
+	return &Rows{Rows: rows, cancel: st.cancel}, nil
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	if tx.watch != nil {
+		tx.watch(ctx, query, args)
+	}
+	st := newStatement(ctx, query)
+	row := &Row{Row: tx.Tx.QueryRowContext(st.ctx, st.query, args...), cancel: st.cancel}
+	st.done(row.Err())
+	return row
+}
+
+func (tx *Tx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if tx.watch != nil {
+		tx.watch(ctx, query, nil)
+	}
+	return tx.Tx.PrepareContext(ctx, tagQuery(ctx, query))
+}
+
+// tagQuery prefixes query with its method and request comment.
+func tagQuery(ctx context.Context, query string) string {
+	if !queryTags.Enabled {
+		return query
+	}
+	return tagWith(ctx, callerMethod(), query)
+}
+
+// tagWith is tagQuery for a method already looked up.
+func tagWith(ctx context.Context, method, query string) string {
+	opts := queryTags
+	if !opts.Enabled {
+		return query
+	}
+	var req string
+	if opts.RequestID {
+		req = sanitizeTag(chimiddleware.GetReqID(ctx))
+	}
+	if method == "" && req == "" {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + len(method) + len(req) + 24)
+	b.WriteString("/*")
+	if method != "" {
+		b.WriteString(" method=")
+		b.WriteString(method)
+	}
+	if req != "" {
+		b.WriteString(" req=")
+		b.WriteString(req)
+	}
+	b.WriteString(" */ ")
+	b.WriteString(query)
+	return b.String()
+}
+
+const repositoryPkg = "carecompanion/internal/repository."
+
+// callerMethod names the repository method running the statement, as
+// "logRepo.GetBehaviorLogs". Unexported helpers and closures are skipped
+// in favour of the exported method that called them, when there is one.
+func callerMethod() string {
+	var pcs [16]uintptr
+	// Skip runtime.Callers, callerMethod, tagQuery (or newStatement) and
+	// the DB/Tx method.
+	n := runtime.Callers(4, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	first := ""
+	for {
+		f, more := frames.Next()
+		name, ok := strings.CutPrefix(f.Function, repositoryPkg)
+		if !ok {
+			if first != "" {
+				break
+			}
+		} else if name = trimFuncName(name); name != "" {
+			if first == "" {
+				first = name
+			}
+			if exportedMethod(name) {
+				return name
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return first
+}
+
+// trimFuncName turns "(*logRepo).GetLogs.func1" into "logRepo.GetLogs".
+func trimFuncName(name string) string {
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		if n, ok := strings.CutPrefix(p, "func"); ok && i > 0 && strings.Trim(n, "0123456789") == "" {
+			parts = parts[:i]
+			break
+		}
+	}
+	return strings.TrimRight(strings.Join(parts, "."), ".")
+}
+
+func exportedMethod(name string) bool {
+	i := strings.LastIndexByte(name, '.')
+	return i >= 0 && i+1 < len(name) && name[i+1] >= 'A' && name[i+1] <= 'Z'
+}
+
+// sanitizeTag keeps a tag value from closing the comment. Request ids can
+// come from the client's X-Request-Id header.
+func sanitizeTag(s string) string {
+	if len(s) > 64 {
+		s = s[:64]
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '-', r == '_', r == '.', r == '/':
+			return r
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// tagProbe stands in for a repository; run plays the DB method frame
+// that tagQuery expects between it and the repository method.
+type tagProbe struct{}
+
+func run(ctx context.Context) string { return tagQuery(ctx, "SELECT 1") }
+
+func (tagProbe) GetThing(ctx context.Context) string { return run(ctx) }
+
+func (p tagProbe) ListThings(ctx context.Context) string { return p.scan(ctx) }
+
+func (tagProbe) scan(ctx context.Context) string { return run(ctx) }
+
+func (*tagProbe) CountThings(ctx context.Context) string {
+	var out string
+	func() { out = run(ctx) }()
+	return out
+}
+
+func TestTagQuery(t *testing.T) {
+	ctx := context.Background()
+	p := &tagProbe{}
+	for name, got := range map[string]string{
+		"method":  p.GetThing(ctx),
+		"helper":  p.ListThings(ctx),
+		"closure": p.CountThings(ctx),
+	} {
+		want := map[string]string{
+			"method":  "/* method=tagProbe.GetThing */ SELECT 1",
+			"helper":  "/* method=tagProbe.ListThings */ SELECT 1",
+			"closure": "/* method=tagProbe.CountThings */ SELECT 1",
+		}[name]
+		if got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	ctx = context.WithValue(ctx, chimiddleware.RequestIDKey, "host/abc-001*/ DROP TABLE users; /*")
+	if got, want := p.GetThing(ctx), "/* method=tagProbe.GetThing */ SELECT 1"; got != want {
+		t.Errorf("request ids off: got %q, want %q", got, want)
+	}
+
+	// Request ids can come from the client; nothing may close the comment.
+	defer SetQueryTagOptions(queryTags)
+	SetQueryTagOptions(QueryTagOptions{Enabled: true, RequestID: true})
+	if got, want := p.GetThing(ctx), "/* method=tagProbe.GetThing req=host/abc-001/DROPTABLEusers/ */ SELECT 1"; got != want {
+		t.Errorf("with request id: got %q, want %q", got, want)
+	}
+	SetQueryTagOptions(QueryTagOptions{})
+	if got := p.GetThing(ctx); got != "SELECT 1" {
+		t.Errorf("tagging off: got %q", got)
+	}
+}
@@ -34,8 +34,8 @@ type ReplicatingAdminRepo struct {
 	AdminRepository // embedded base — fall-through for non-admin-user methods
 
 	base     AdminRepository
-	localDB  *sql.DB
-	mirrorDB *sql.DB
+	localDB  *DB
+	mirrorDB *DB
 }
 
 // NewReplicatingAdminRepo wraps base for admin_users dual-write replication.
@@ -45,8 +45,8 @@ func NewReplicatingAdminRepo(base AdminRepository, localDB, mirrorDB *sql.DB) *R
 	return &ReplicatingAdminRepo{
 		AdminRepository: base,
 		base:            base,
-		localDB:         localDB,
-		mirrorDB:        mirrorDB,
+		localDB:         wrapAdminDB(localDB),
+		mirrorDB:        wrapAdminDB(mirrorDB),
 	}
 }
 
@@ -170,7 +170,7 @@ func (r *ReplicatingAdminRepo) SyncAdminUsers(ctx context.Context) (insertedToMi
 		updatedAt    time.Time
 	}
 
-	loadAll := func(db *sql.DB) (map[string]adminRow, error) {
+	loadAll := func(db *DB) (map[string]adminRow, error) {
 		rows, err := db.QueryContext(ctx, `
 			SELECT id, email, password_hash, first_name, last_name, system_role::text, status::text, updated_at
 			FROM admin_users
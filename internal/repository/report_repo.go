@@ -29,11 +29,11 @@ type ReportRepository interface {
 }
 
 type reportRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewReportRepo(db *sql.DB) ReportRepository {
-	return &reportRepo{db: db}
+	return &reportRepo{db: WrapDB(db)}
 }
 
 func (r *reportRepo) Create(ctx context.Context, report *models.Report) error {
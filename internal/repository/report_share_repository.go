@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// ReportShareRepository stores shared report links and their read
+// receipts.
+type ReportShareRepository interface {
+	// Create inserts the share; ID and CreatedAt are filled in.
+	Create(ctx context.Context, s *models.ReportShare) error
+	// GetByID returns the share with its view summary, or nil, nil.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ReportShare, error)
+	// ListByChild returns the child's shares, newest first. A non-nil
+	// reportID limits them to that report.
+	ListByChild(ctx context.Context, childID uuid.UUID, reportID *uuid.UUID) ([]models.ReportShare, error)
+	// ListViews returns the views of the given shares, oldest first.
+	ListViews(ctx context.Context, shareIDs []uuid.UUID) ([]models.ReportShareView, error)
+	// RecordView inserts a view; ID and ViewedAt are filled in.
+	RecordView(ctx context.Context, v *models.ReportShareView) error
+	// SetExpiry moves an unrevoked share's expiry.
+	SetExpiry(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	// Revoke marks the share revoked, if it isn't already.
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type reportShareRepo struct {
+	db *DB
+}
+
+// NewReportShareRepo creates a ReportShareRepository on the main pool.
+func NewReportShareRepo(db *sql.DB) ReportShareRepository {
+	return &reportShareRepo{db: WrapDB(db)}
+}
+
+const reportShareSelect = `
+        SELECT s.id, s.report_id, r.title, s.child_id, s.shared_by, s.recipient_name, s.recipient_email, s.note,
+               s.expires_at, s.revoked_at, s.created_at, v.n, v.first_at, v.last_at
+        FROM report_shares s
+        JOIN reports r ON r.id = s.report_id
+        CROSS JOIN LATERAL (
+            SELECT COUNT(*) AS n, MIN(viewed_at) AS first_at, MAX(viewed_at) AS last_at
+            FROM report_share_views WHERE share_id = s.id
+        ) v`
+
+func scanReportShare(row interface{ Scan(...any) error }, s *models.ReportShare) error {
+	var revokedAt, firstAt, lastAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.ReportID, &s.ReportTitle, &s.ChildID, &s.SharedBy, &s.RecipientName,
+		&s.RecipientEmail, &s.Note, &s.ExpiresAt, &revokedAt, &s.CreatedAt, &s.ViewCount, &firstAt, &lastAt); err != nil {
+		return err
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	if firstAt.Valid {
+		s.FirstViewedAt = &firstAt.Time
+	}
+	if lastAt.Valid {
+		s.LastViewedAt = &lastAt.Time
+	}
+	return nil
+}
+
+func (r *reportShareRepo) Create(ctx context.Context, s *models.ReportShare) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO report_shares (report_id, child_id, shared_by, recipient_name, recipient_email, note, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `, s.ReportID, s.ChildID, s.SharedBy, s.RecipientName, s.RecipientEmail, s.Note, s.ExpiresAt,
+	).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *reportShareRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.ReportShare, error) {
+	var s models.ReportShare
+	err := scanReportShare(r.db.QueryRowContext(ctx, reportShareSelect+` WHERE s.id = $1`, id), &s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *reportShareRepo) ListByChild(ctx context.Context, childID uuid.UUID, reportID *uuid.UUID) ([]models.ReportShare, error) {
+	rows, err := r.db.QueryContext(ctx, reportShareSelect+`
+        WHERE s.child_id = $1 AND ($2::uuid IS NULL OR s.report_id = $2)
+        ORDER BY s.created_at DESC
+    `, childID, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.ReportShare
+	for rows.Next() {
+		var s models.ReportShare
+		if err := scanReportShare(rows, &s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *reportShareRepo) ListViews(ctx context.Context, shareIDs []uuid.UUID) ([]models.ReportShareView, error) {
+	if len(shareIDs) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(shareIDs))
+	for i, id := range shareIDs {
+		ids[i] = id.String()
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, share_id, viewed_at, host(ip_address), user_agent
+        FROM report_share_views
+        WHERE share_id = ANY($1::uuid[])
+        ORDER BY viewed_at
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.ReportShareView
+	for rows.Next() {
+		var v models.ReportShareView
+		if err := rows.Scan(&v.ID, &v.ShareID, &v.ViewedAt, &v.IPAddress, &v.UserAgent); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+func (r *reportShareRepo) RecordView(ctx context.Context, v *models.ReportShareView) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO report_share_views (share_id, ip_address, user_agent)
+        VALUES ($1, NULLIF($2, '')::inet, $3)
+        RETURNING id, viewed_at
+    `, v.ShareID, v.IPAddress.String, v.UserAgent,
+	).Scan(&v.ID, &v.ViewedAt)
+}
+
+func (r *reportShareRepo) SetExpiry(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE report_shares SET expires_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, expiresAt)
+	return err
+}
+
+func (r *reportShareRepo) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE report_shares SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, at)
+	return err
+}
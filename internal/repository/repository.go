@@ -67,6 +67,8 @@ type ChildRepository interface {
 	Create(ctx context.Context, child *models.Child) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error)
 	GetByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Child, error)
+	// FamilyChangeStamp covers the rows GetByFamilyID returns.
+	FamilyChangeStamp(ctx context.Context, familyID uuid.UUID) (models.ChangeStamp, error)
 	Update(ctx context.Context, child *models.Child) error
 	Delete(ctx context.Context, id uuid.UUID) error
 
@@ -104,6 +106,8 @@ type MedicationRepository interface {
 	GetLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.MedicationLog, error)
 	GetLogsByMedication(ctx context.Context, medicationID uuid.UUID, startDate, endDate time.Time) ([]models.MedicationLog, error)
 	GetLogsByMedicationSince(ctx context.Context, medicationID uuid.UUID, since time.Time) ([]models.MedicationLog, error)
+	// UpdateLog updates the log only if it is still at log.Version, bumps
+	// the version, and returns ErrLogVersionConflict otherwise.
 	UpdateLog(ctx context.Context, log *models.MedicationLog) error
 	DeleteLog(ctx context.Context, id uuid.UUID) error
 
@@ -119,7 +123,9 @@ type MedicationRepository interface {
 	HardDeleteMedication(ctx context.Context, id uuid.UUID) error
 }
 
-// LogRepository handles all log types
+// LogRepository handles all log types. The Update*Log methods update the
+// log only if it is still at log.Version, bump the version, and return
+// ErrLogVersionConflict otherwise.
 type LogRepository interface {
 	// Behavior logs
 	CreateBehaviorLog(ctx context.Context, log *models.BehaviorLog) error
@@ -198,8 +204,16 @@ type LogRepository interface {
 	UpdateHealthEventLog(ctx context.Context, log *models.HealthEventLog) error
 	DeleteHealthEventLog(ctx context.Context, id uuid.UUID) error
 
+	// Sparse fieldsets: one log type's Get*Logs result with only fields
+	// selected. Returns ErrUnknownField for a field the type lacks.
+	ListLogFields(ctx context.Context, logType string, childID uuid.UUID, startDate, endDate time.Time, fields []string) (any, error)
+
 	// Daily log page
 	GetDailyLogs(ctx context.Context, childID uuid.UUID, date time.Time) (*models.DailyLogPage, error)
+	// DailyLogsChangeStamp covers GetDailyLogs / GetLogsForDateRange over
+	// the same dates: the child row, its medications (names are joined
+	// into medication logs) and the per-day log_change_stamps.
+	DailyLogsChangeStamp(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (models.ChangeStamp, error)
 	GetLogsForDateRange(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (*models.DailyLogPage, error)
 
 	// Date listing
@@ -382,6 +396,67 @@ type Repositories struct {
 	AccountDeletion  AccountDeletionRepository  // User-initiated account deletion (App Store Blocker 2)
 	ProQA            ProQARepository            // Admin-only Pro QA workspace (shared support DB)
 	Role             RoleRepository             // Custom admin roles (per-env, main DB)
+	TicketTaxonomy   TicketTaxonomyRepository   // Ticket categories + tags (shared support DB)
+	KnowledgeBase    KnowledgeBaseRepository    // Help center articles (per-env, main DB)
+	Feedback         FeedbackRepository         // In-app NPS + feedback (per-env, main DB)
+	Campaign         CampaignRepository         // Marketing campaigns + UTM attribution (per-env, main DB)
+	Testimonial      TestimonialRepository      // Marketing testimonials + case studies (per-env, main DB)
+	FileTransfer     FileTransferRepository     // Admin file drop + share links (per-env, main DB)
+	UploadSession    UploadSessionRepository    // Resumable chunked uploads (per-env, main DB)
+	UploadScan       UploadScanRepository       // Antivirus verdicts + quarantine queue (per-env, main DB)
+	Backup           BackupRepository           // RDS snapshots, config dumps, restore drills (per-env, main DB)
+	Cost             CostRepository             // AWS daily cost history (per-env, main DB)
+	LogRetention     LogRetentionRepository     // Request/error log rollups + pruning (per-env, main DB)
+	Performance      PerformanceRepository      // Per-route latency from the hourly rollups (per-env, main DB)
+	APIUsage         APIUsageRepository         // Hourly requests per client and route, flushed from Redis (per-env, main DB)
+	AbuseBlocks      AbuseBlockRepository       // Abuse detection blocks + review queue (per-env, main DB)
+	PHICanaries      PHICanaryRepository        // Canary rows planted in PHI tables (per-env, main DB)
+	QueryStats       QueryStatsRepository       // pg_stat_statements snapshots (per-env, main DB)
+	CSPReport        CSPReportRepository        // CSP violation reports into error_logs
+	Image            ImageRepository            // Responsive image variants (per-env, main DB)
+	EventOutbox      EventOutboxRepository      // Domain event outbox for the stream relay (per-env, main DB)
+	WarehouseExport  WarehouseExportRepository  // De-identified aggregates + export history (per-env, main DB)
+	ProductAnalytics ProductAnalyticsRepository // Retention, DAU/WAU/MAU, adoption, activation (per-env, main DB)
+	Engagement       EngagementRepository       // Per-family engagement scores + churn risk (per-env, main DB)
+	Segment          SegmentRepository          // Saved user segments for targeted messaging (per-env, main DB)
+	Onboarding       OnboardingRepository       // Guided setup steps + stalled-user nudges (per-env, main DB)
+	EmailVerification EmailVerificationRepository // Email verification links + grace periods (per-env, main DB)
+	Legal             LegalRepository             // Versioned Terms/Privacy + user acceptances (per-env, main DB)
+	ResearchConsent   ResearchConsentRepository   // Family research opt-in + consent artifacts (per-env, main DB)
+	Organization      OrganizationRepository      // Clinics above families: staff, seats, aggregate reports (per-env, main DB)
+	Invoice           InvoiceRepository           // Issued invoices for family payments + org seat billing (per-env, main DB)
+	Tax               TaxRepository               // Tax rate table + tax collected per jurisdiction (per-env, main DB)
+	Dispute           DisputeRepository           // Stripe disputes/chargebacks (per-env, main DB)
+	PlanChange        PlanChangeRepository        // Family plan upgrade/downgrade history (per-env, main DB)
+	ExitPackage       ExitPackageRepository       // Data exports built on cancellation (per-env, main DB)
+	LogUsage          LogUsageRepository          // Per-log-type usage aggregates (per-env, main DB)
+	AdminNotification AdminNotificationRepository // Notifications raised for admins by background checks (per-env, main DB)
+	MetricSeries      MetricSeriesRepository      // System metric series for anomaly detection (per-env, main DB)
+	Integrity         IntegrityRepository         // Data integrity checker runs + quarantine (per-env, main DB)
+	PendingActions    PendingActionRepository     // Destructive admin actions awaiting confirmation/approval (per-env, main DB)
+	PromoCodes        PromoCodeRepository         // Promo code validations + analytics aggregates (per-env, main DB)
+	PromoFraud        PromoFraudRepository        // Promo code abuse signals + fraud review queue (per-env, main DB)
+	SeizureEvents     SeizureEventRepository      // Live seizure timer events (per-env, main DB)
+	TherapyGoals      TherapyGoalRepository       // Therapy goals and the goals each therapy log worked on (per-env, main DB)
+	Providers         ProviderRepository          // School/ABA provider profiles, per-child grants and attributed entries (per-env, main DB)
+	Routines          RoutineRepository           // Daily routines, their steps and per-day completions (per-env, main DB)
+	Handoffs          HandoffRepository           // Respite handoff profiles: emergency contacts and care notes (per-env, main DB)
+	Annotations       AnnotationRepository        // Dated chart annotations and milestone markers per child (per-env, main DB)
+	Interventions     InterventionRepository      // Intervention experiments and the metrics they target (per-env, main DB)
+	Restrictions      RestrictionRepository       // Per-child allergy and dietary restriction registry (per-env, main DB)
+	SleepSync         SleepSyncRepository         // Device-imported sleep records and preferred sleep sources (per-env, main DB)
+	Rubrics           RubricRepository            // Versioned per-family scoring rubrics for behavior log levels (per-env, main DB)
+	FoodRecognitions  FoodRecognitionRepository   // Food suggestions made from meal photos for diet logs (per-env, main DB)
+	ReportShares      ReportShareRepository       // Report links shared with clinicians and their read receipts (per-env, main DB)
+	LogRevisions      LogRevisionRepository       // Amendment history of log entries: fields changed, editor and time (per-env, main DB)
+	LogDrafts         LogDraftRepository          // Autosaved log form drafts per user, child and log type, with expiry (per-env, main DB)
+	LogTemplates      LogTemplateRepository       // Family-defined pre-filled log entries ("school day breakfast") (per-env, main DB)
+	Gamification      GamificationRepository      // Caregivers' logging activity and streak/badge/digest opt-outs (per-env, main DB)
+	AdminInvitations  AdminInvitationRepository   // One-time password links for imported admins (per-env, main DB)
+	AccessReviews     AdminAccessReviewRepository // Quarterly admin access reviews and their immutable decisions (per-env, main DB)
+	Elevations        AdminElevationRepository    // Just-in-time section access requests and their approval windows (per-env, main DB)
+	ComplianceExports ComplianceExportRepository  // Audit log, settings history and PHI access exports for auditors (per-env, main DB)
+	TicketMerges      TicketMergeRepository       // Merged support tickets, their redirects and merge history (shared support DB)
 }
 
 // NewRepositories creates all repository implementations.
@@ -430,6 +505,67 @@ func NewRepositories(db, supportDB *sql.DB, sessionsProdDB *sql.DB, adminMirrorD
 		AccountDeletion:  NewAccountDeletionRepository(db),
 		ProQA:            NewProQARepo(supportDB),
 		Role:             NewRoleRepo(db),
+		TicketTaxonomy:   NewTicketTaxonomyRepo(supportDB),
+		KnowledgeBase:    NewKnowledgeBaseRepo(db),
+		Feedback:         NewFeedbackRepo(db),
+		Campaign:         NewCampaignRepo(db),
+		Testimonial:      NewTestimonialRepo(db),
+		FileTransfer:     NewFileTransferRepo(db),
+		UploadSession:    NewUploadSessionRepo(db),
+		UploadScan:       NewUploadScanRepo(db),
+		Backup:           NewBackupRepo(db),
+		Cost:             NewCostRepo(db),
+		LogRetention:     NewLogRetentionRepo(db),
+		Performance:      NewPerformanceRepo(db),
+		APIUsage:         NewAPIUsageRepo(db),
+		AbuseBlocks:      NewAbuseBlockRepo(db),
+		PHICanaries:      NewPHICanaryRepo(db),
+		QueryStats:       NewQueryStatsRepo(db),
+		CSPReport:        NewCSPReportRepo(db),
+		Image:            NewImageRepo(db),
+		EventOutbox:      NewEventOutboxRepo(db),
+		WarehouseExport:  NewWarehouseExportRepo(db),
+		ProductAnalytics: NewProductAnalyticsRepo(db),
+		Engagement:       NewEngagementRepo(db),
+		Segment:          NewSegmentRepo(db),
+		Onboarding:       NewOnboardingRepo(db),
+		EmailVerification: NewEmailVerificationRepo(db),
+		Legal:             NewLegalRepo(db),
+		ResearchConsent:   NewResearchConsentRepo(db),
+		Organization:      NewOrganizationRepo(db),
+		Invoice:           NewInvoiceRepo(db),
+		Tax:               NewTaxRepo(db),
+		Dispute:           NewDisputeRepo(db),
+		PlanChange:        NewPlanChangeRepo(db),
+		ExitPackage:       NewExitPackageRepo(db),
+		LogUsage:          NewLogUsageRepo(db),
+		AdminNotification: NewAdminNotificationRepo(db),
+		MetricSeries:      NewMetricSeriesRepo(db),
+		Integrity:         NewIntegrityRepo(db),
+		PendingActions:    NewPendingActionRepo(db),
+		PromoCodes:        NewPromoCodeRepo(db),
+		PromoFraud:        NewPromoFraudRepo(db),
+		SeizureEvents:     NewSeizureEventRepo(db),
+		TherapyGoals:      NewTherapyGoalRepo(db),
+		Providers:         NewProviderRepo(db),
+		Routines:          NewRoutineRepo(db),
+		Handoffs:          NewHandoffRepo(db),
+		Annotations:       NewAnnotationRepo(db),
+		Interventions:     NewInterventionRepo(db),
+		Restrictions:      NewRestrictionRepo(db),
+		SleepSync:         NewSleepSyncRepo(db),
+		Rubrics:           NewRubricRepo(db),
+		FoodRecognitions:  NewFoodRecognitionRepo(db),
+		ReportShares:      NewReportShareRepo(db),
+		LogRevisions:      NewLogRevisionRepo(db),
+		LogDrafts:         NewLogDraftRepo(db),
+		LogTemplates:      NewLogTemplateRepo(db),
+		Gamification:      NewGamificationRepo(db),
+		AdminInvitations:  NewAdminInvitationRepo(db),
+		AccessReviews:     NewAdminAccessReviewRepo(db),
+		Elevations:        NewAdminElevationRepo(db),
+		ComplianceExports: NewComplianceExportRepo(db),
+		TicketMerges:      NewTicketMergeRepo(supportDB),
 	}
 	if sessionsProdDB != nil {
 		repos.SessionProd = NewSessionRepo(sessionsProdDB)
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Research consent scopes. Each is one warehouse export dataset a family
+// can opt into; the warehouse queries only count families whose active
+// consent lists the dataset's scope.
+const (
+	ResearchScopeCareLogs     = "care_logs"
+	ResearchScopeFeatureUsage = "feature_usage"
+	ResearchScopeRetention    = "retention"
+)
+
+// ResearchScopes is every scope, in display order.
+var ResearchScopes = []string{ResearchScopeCareLogs, ResearchScopeFeatureUsage, ResearchScopeRetention}
+
+// researchOptedIn is a SQL condition true when the family in familyCol has
+// an active research consent covering scope.
+func researchOptedIn(familyCol, scope string) string {
+	return `EXISTS (SELECT 1 FROM research_consents rc
+                WHERE rc.family_id = ` + familyCol + ` AND rc.withdrawn_at IS NULL
+                  AND '` + scope + `' = ANY(rc.scopes))`
+}
+
+// ResearchConsent is one consent a family gave, and its withdrawal if any.
+type ResearchConsent struct {
+	ID                uuid.UUID  `json:"id"`
+	FamilyID          uuid.UUID  `json:"family_id"`
+	GrantedBy         *uuid.UUID `json:"granted_by,omitempty"`
+	Scopes            []string   `json:"scopes"`
+	DisclosureVersion int        `json:"disclosure_version"`
+	DisclosureSHA     string     `json:"disclosure_sha"`
+	// DisclosureText is the exact text signed. Only loaded by GetConsent.
+	DisclosureText   string     `json:"disclosure_text,omitempty"`
+	SignatureName    string     `json:"signature_name"`
+	IPAddress        string     `json:"ip_address,omitempty"`
+	UserAgent        string     `json:"user_agent,omitempty"`
+	GrantedAt        time.Time  `json:"granted_at"`
+	WithdrawnAt      *time.Time `json:"withdrawn_at,omitempty"`
+	WithdrawnBy      *uuid.UUID `json:"withdrawn_by,omitempty"`
+	WithdrawalReason string     `json:"withdrawal_reason,omitempty"`
+	// WithdrawnScopes are the scopes this withdrawal removed: all of them,
+	// or just those dropped when the family narrowed its consent.
+	WithdrawnScopes []string   `json:"withdrawn_scopes,omitempty"`
+	RebuiltAt       *time.Time `json:"rebuilt_at,omitempty"`
+}
+
+// ResearchConsentSummary is the admin overview of research participation.
+type ResearchConsentSummary struct {
+	ParticipatingFamilies int            `json:"participating_families"`
+	ByScope               map[string]int `json:"by_scope"`
+	Withdrawals30d        int            `json:"withdrawals_30d"`
+	// PendingRebuilds are withdrawals whose exports haven't been rebuilt.
+	PendingRebuilds int `json:"pending_rebuilds"`
+}
+
+// ResearchConsentRepository owns research_consents.
+type ResearchConsentRepository interface {
+	// Active returns the family's current consent, or nil.
+	Active(ctx context.Context, familyID uuid.UUID) (*ResearchConsent, error)
+	// GetConsent returns one consent with its disclosure text, or nil.
+	GetConsent(ctx context.Context, id uuid.UUID) (*ResearchConsent, error)
+	// History returns every consent the family gave, newest first.
+	History(ctx context.Context, familyID uuid.UUID) ([]ResearchConsent, error)
+	// Grant records c as the family's consent, withdrawing the previous one
+	// with the scopes c doesn't carry over as its withdrawn scopes.
+	Grant(ctx context.Context, c *ResearchConsent) error
+	// Withdraw ends the family's active consent; nil when there is none.
+	Withdraw(ctx context.Context, familyID, by uuid.UUID, reason string) (*ResearchConsent, error)
+	// PendingRebuilds returns withdrawals whose exports still include the
+	// family, oldest first.
+	PendingRebuilds(ctx context.Context) ([]ResearchConsent, error)
+	MarkRebuilt(ctx context.Context, ids []uuid.UUID) error
+	Summary(ctx context.Context) (*ResearchConsentSummary, error)
+	// Recent returns the latest consents across families, newest first.
+	Recent(ctx context.Context, limit int) ([]ResearchConsent, error)
+}
+
+type researchConsentRepo struct {
+	db *DB
+}
+
+// NewResearchConsentRepo creates a ResearchConsentRepository on the main pool.
+func NewResearchConsentRepo(db *sql.DB) ResearchConsentRepository {
+	return &researchConsentRepo{db: WrapDB(db)}
+}
+
+const researchConsentCols = `id, family_id, granted_by, scopes, disclosure_version, disclosure_sha,
+       signature_name, COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), granted_at,
+       withdrawn_at, withdrawn_by, COALESCE(withdrawal_reason, ''), withdrawn_scopes, rebuilt_at`
+
+func scanResearchConsent(row interface{ Scan(...any) error }, extra ...any) (*ResearchConsent, error) {
+	c := &ResearchConsent{}
+	dest := []any{&c.ID, &c.FamilyID, &c.GrantedBy, pq.Array(&c.Scopes), &c.DisclosureVersion, &c.DisclosureSHA,
+		&c.SignatureName, &c.IPAddress, &c.UserAgent, &c.GrantedAt,
+		&c.WithdrawnAt, &c.WithdrawnBy, &c.WithdrawalReason, pq.Array(&c.WithdrawnScopes), &c.RebuiltAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *researchConsentRepo) list(ctx context.Context, query string, args ...any) ([]ResearchConsent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []ResearchConsent{}
+	for rows.Next() {
+		c, err := scanResearchConsent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *researchConsentRepo) Active(ctx context.Context, familyID uuid.UUID) (*ResearchConsent, error) {
+	c, err := scanResearchConsent(r.db.QueryRowContext(ctx, `
+        SELECT `+researchConsentCols+`
+        FROM research_consents WHERE family_id = $1 AND withdrawn_at IS NULL`, familyID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *researchConsentRepo) GetConsent(ctx context.Context, id uuid.UUID) (*ResearchConsent, error) {
+	var text string
+	c, err := scanResearchConsent(r.db.QueryRowContext(ctx, `
+        SELECT `+researchConsentCols+`, disclosure_text
+        FROM research_consents WHERE id = $1`, id), &text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.DisclosureText = text
+	return c, nil
+}
+
+func (r *researchConsentRepo) History(ctx context.Context, familyID uuid.UUID) ([]ResearchConsent, error) {
+	return r.list(ctx, `
+        SELECT `+researchConsentCols+`
+        FROM research_consents WHERE family_id = $1
+        ORDER BY granted_at DESC`, familyID)
+}
+
+func (r *researchConsentRepo) Grant(ctx context.Context, c *ResearchConsent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The scopes the family drops are withdrawn; an unchanged or wider
+	// consent has nothing to rebuild, so it's stamped rebuilt at once.
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE research_consents
+        SET withdrawn_at = NOW(), withdrawn_by = $2, withdrawal_reason = 'replaced',
+            withdrawn_scopes = ARRAY(SELECT unnest(scopes) EXCEPT SELECT unnest($3::text[])),
+            rebuilt_at = CASE WHEN scopes <@ $3::text[] THEN NOW() END
+        WHERE family_id = $1 AND withdrawn_at IS NULL`,
+		c.FamilyID, c.GrantedBy, pq.Array(c.Scopes)); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO research_consents
+            (family_id, granted_by, scopes, disclosure_version, disclosure_sha, disclosure_text,
+             signature_name, ip_address, user_agent)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::inet, NULLIF($9, ''))
+        RETURNING id, granted_at`,
+		c.FamilyID, c.GrantedBy, pq.Array(c.Scopes), c.DisclosureVersion, c.DisclosureSHA, c.DisclosureText,
+		c.SignatureName, c.IPAddress, c.UserAgent,
+	).Scan(&c.ID, &c.GrantedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *researchConsentRepo) Withdraw(ctx context.Context, familyID, by uuid.UUID, reason string) (*ResearchConsent, error) {
+	c, err := scanResearchConsent(r.db.QueryRowContext(ctx, `
+        UPDATE research_consents
+        SET withdrawn_at = NOW(), withdrawn_by = $2, withdrawal_reason = NULLIF($3, ''),
+            withdrawn_scopes = scopes
+        WHERE family_id = $1 AND withdrawn_at IS NULL
+        RETURNING `+researchConsentCols, familyID, by, reason))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *researchConsentRepo) PendingRebuilds(ctx context.Context) ([]ResearchConsent, error) {
+	return r.list(ctx, `
+        SELECT `+researchConsentCols+`
+        FROM research_consents
+        WHERE withdrawn_at IS NOT NULL AND rebuilt_at IS NULL AND cardinality(withdrawn_scopes) > 0
+        ORDER BY withdrawn_at`)
+}
+
+func (r *researchConsentRepo) MarkRebuilt(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE research_consents SET rebuilt_at = NOW()
+        WHERE id = ANY($1::uuid[]) AND rebuilt_at IS NULL`, pq.Array(strs))
+	return err
+}
+
+func (r *researchConsentRepo) Summary(ctx context.Context) (*ResearchConsentSummary, error) {
+	s := &ResearchConsentSummary{ByScope: map[string]int{}}
+	if err := r.db.QueryRowContext(ctx, `
+        SELECT
+            COUNT(*) FILTER (WHERE rc.withdrawn_at IS NULL AND f.deleted_at IS NULL),
+            COUNT(*) FILTER (WHERE rc.withdrawn_at >= NOW() - INTERVAL '30 days'
+                               AND COALESCE(rc.withdrawal_reason, '') <> 'replaced'),
+            COUNT(*) FILTER (WHERE rc.withdrawn_at IS NOT NULL AND rc.rebuilt_at IS NULL
+                               AND cardinality(rc.withdrawn_scopes) > 0)
+        FROM research_consents rc
+        JOIN families f ON f.id = rc.family_id`,
+	).Scan(&s.ParticipatingFamilies, &s.Withdrawals30d, &s.PendingRebuilds); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT scope, COUNT(*)
+        FROM research_consents rc
+        JOIN families f ON f.id = rc.family_id AND f.deleted_at IS NULL
+        CROSS JOIN LATERAL unnest(rc.scopes) AS scope
+        WHERE rc.withdrawn_at IS NULL
+        GROUP BY scope`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var scope string
+		var n int
+		if err := rows.Scan(&scope, &n); err != nil {
+			return nil, err
+		}
+		s.ByScope[scope] = n
+	}
+	return s, rows.Err()
+}
+
+func (r *researchConsentRepo) Recent(ctx context.Context, limit int) ([]ResearchConsent, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return r.list(ctx, `
+        SELECT `+researchConsentCols+`
+        FROM research_consents
+        ORDER BY GREATEST(granted_at, COALESCE(withdrawn_at, granted_at)) DESC
+        LIMIT $1`, limit)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// ErrRestrictionExists is returned when the child already has the item
+// on their registry.
+var ErrRestrictionExists = apperr.Conflict("this item is already on the child's list")
+
+// RestrictionRepository stores each child's allergy and dietary
+// restriction registry.
+type RestrictionRepository interface {
+	// Create inserts the entry, or returns ErrRestrictionExists.
+	Create(ctx context.Context, r *models.ChildRestriction) error
+	// GetByID returns the entry, or nil, nil.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ChildRestriction, error)
+	// ListByChild returns the child's registry, by item.
+	ListByChild(ctx context.Context, childID uuid.UUID) ([]models.ChildRestriction, error)
+	// Update saves the entry, or returns ErrRestrictionExists.
+	Update(ctx context.Context, r *models.ChildRestriction) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type restrictionRepo struct {
+	db *DB
+}
+
+// NewRestrictionRepo creates a RestrictionRepository on the main pool.
+func NewRestrictionRepo(db *sql.DB) RestrictionRepository {
+	return &restrictionRepo{db: WrapDB(db)}
+}
+
+const restrictionCols = `id, child_id, item, kind, severity, aliases, notes, created_by, created_at, updated_at`
+
+func scanRestriction(row interface{ Scan(...any) error }, r *models.ChildRestriction) error {
+	return row.Scan(&r.ID, &r.ChildID, &r.Item, &r.Kind, &r.Severity, pq.Array(&r.Aliases), &r.Notes,
+		&r.CreatedBy, &r.CreatedAt, &r.UpdatedAt)
+}
+
+func (r *restrictionRepo) Create(ctx context.Context, cr *models.ChildRestriction) error {
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO child_restrictions (child_id, item, kind, severity, aliases, notes, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at
+    `, cr.ChildID, cr.Item, cr.Kind, cr.Severity, pq.Array(cr.Aliases), cr.Notes, cr.CreatedBy,
+	).Scan(&cr.ID, &cr.CreatedAt, &cr.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrRestrictionExists
+	}
+	return err
+}
+
+func (r *restrictionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.ChildRestriction, error) {
+	var cr models.ChildRestriction
+	err := scanRestriction(r.db.QueryRowContext(ctx, `SELECT `+restrictionCols+` FROM child_restrictions WHERE id = $1`, id), &cr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+func (r *restrictionRepo) ListByChild(ctx context.Context, childID uuid.UUID) ([]models.ChildRestriction, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+restrictionCols+`
+        FROM child_restrictions
+        WHERE child_id = $1
+        ORDER BY LOWER(item)`, childID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.ChildRestriction
+	for rows.Next() {
+		var cr models.ChildRestriction
+		if err := scanRestriction(rows, &cr); err != nil {
+			return nil, err
+		}
+		out = append(out, cr)
+	}
+	return out, rows.Err()
+}
+
+func (r *restrictionRepo) Update(ctx context.Context, cr *models.ChildRestriction) error {
+	err := r.db.QueryRowContext(ctx, `
+        UPDATE child_restrictions
+        SET item = $2, kind = $3, severity = $4, aliases = $5, notes = $6, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, cr.ID, cr.Item, cr.Kind, cr.Severity, pq.Array(cr.Aliases), cr.Notes).Scan(&cr.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrRestrictionExists
+	}
+	return err
+}
+
+func (r *restrictionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM child_restrictions WHERE id = $1`, id)
+	return err
+}
@@ -75,12 +75,12 @@ type RoadmapFollower struct {
 }
 
 type roadmapRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewRoadmapRepo creates a RoadmapRepository.
 func NewRoadmapRepo(db *sql.DB) RoadmapRepository {
-	return &roadmapRepo{db: db}
+	return &roadmapRepo{db: WrapDB(db)}
 }
 
 const roadmapSelectColumns = `
@@ -27,11 +27,11 @@ type RoleRepository interface {
 }
 
 type roleRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewRoleRepo(db *sql.DB) RoleRepository {
-	return &roleRepo{db: db}
+	return &roleRepo{db: WrapDB(db)}
 }
 
 func (r *roleRepo) List(ctx context.Context) ([]models.CustomRole, error) {
@@ -173,7 +173,7 @@ func (r *roleRepo) Update(ctx context.Context, role *models.CustomRole) error {
 	return tx.Commit()
 }
 
-func upsertPermissions(ctx context.Context, tx *sql.Tx, roleID uuid.UUID, perms map[string]string) error {
+func upsertPermissions(ctx context.Context, tx *Tx, roleID uuid.UUID, perms map[string]string) error {
 	for section, level := range perms {
 		if level == "" || level == "none" {
 			continue
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// RoutineRepository stores children's daily routines, their steps and the
+// steps ticked off each day.
+type RoutineRepository interface {
+	// Create inserts a routine with its steps; IDs and timestamps are
+	// filled in.
+	Create(ctx context.Context, rt *models.Routine) error
+	// GetByID returns the routine with its steps, or nil, nil.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Routine, error)
+	// ListByChild returns the child's routines with their steps, by start
+	// time.
+	ListByChild(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Routine, error)
+	// Update saves the routine and syncs its steps: steps with a known ID
+	// are updated, new ones inserted (IDs filled in) and the rest removed
+	// with their completions.
+	Update(ctx context.Context, rt *models.Routine) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// SetCompletion marks a step done on a day; marking it again keeps
+	// the first time.
+	SetCompletion(ctx context.Context, c *models.RoutineCompletion) error
+	ClearCompletion(ctx context.Context, stepID uuid.UUID, date time.Time) error
+	// Completions returns the child's completions with dates in
+	// [start, end].
+	Completions(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.RoutineCompletion, error)
+}
+
+type routineRepo struct {
+	db *DB
+}
+
+// NewRoutineRepo creates a RoutineRepository on the main pool.
+func NewRoutineRepo(db *sql.DB) RoutineRepository {
+	return &routineRepo{db: WrapDB(db)}
+}
+
+const routineCols = `id, child_id, name, icon, to_char(start_time, 'HH24:MI'), days_of_week, active,
+        created_by, created_at, updated_at`
+
+func scanRoutine(row interface{ Scan(...any) error }, rt *models.Routine) error {
+	var days []int64
+	if err := row.Scan(&rt.ID, &rt.ChildID, &rt.Name, &rt.Icon, &rt.StartTime, pq.Array(&days), &rt.Active,
+		&rt.CreatedBy, &rt.CreatedAt, &rt.UpdatedAt); err != nil {
+		return err
+	}
+	rt.DaysOfWeek = make([]int, len(days))
+	for i, d := range days {
+		rt.DaysOfWeek[i] = int(d)
+	}
+	return nil
+}
+
+func routineDays(days []int) interface{} {
+	out := make([]int64, len(days))
+	for i, d := range days {
+		out[i] = int64(d)
+	}
+	return pq.Array(out)
+}
+
+func routineStartTime(rt *models.Routine) interface{} {
+	if !rt.StartTime.Valid {
+		return nil
+	}
+	return rt.StartTime.String
+}
+
+func insertRoutineStep(ctx context.Context, tx *Tx, s *models.RoutineStep) error {
+	return tx.QueryRowContext(ctx, `
+        INSERT INTO routine_steps (routine_id, position, title, icon, duration_minutes)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `, s.RoutineID, s.Position, s.Title, s.Icon, s.DurationMinutes).Scan(&s.ID)
+}
+
+func (r *routineRepo) Create(ctx context.Context, rt *models.Routine) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO routines (child_id, name, icon, start_time, days_of_week, active, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at
+    `, rt.ChildID, rt.Name, rt.Icon, routineStartTime(rt), routineDays(rt.DaysOfWeek), rt.Active, rt.CreatedBy,
+	).Scan(&rt.ID, &rt.CreatedAt, &rt.UpdatedAt); err != nil {
+		return err
+	}
+	for i := range rt.Steps {
+		rt.Steps[i].RoutineID, rt.Steps[i].Position = rt.ID, i
+		if err := insertRoutineStep(ctx, tx, &rt.Steps[i]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *routineRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Routine, error) {
+	var rt models.Routine
+	err := scanRoutine(r.db.QueryRowContext(ctx, `SELECT `+routineCols+` FROM routines WHERE id = $1`, id), &rt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	routines := []models.Routine{rt}
+	if err := r.attachSteps(ctx, routines); err != nil {
+		return nil, err
+	}
+	return &routines[0], nil
+}
+
+func (r *routineRepo) ListByChild(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Routine, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+routineCols+`
+        FROM routines
+        WHERE child_id = $1 AND (active OR NOT $2)
+        ORDER BY start_time NULLS LAST, created_at`, childID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Routine
+	for rows.Next() {
+		var rt models.Routine
+		if err := scanRoutine(rows, &rt); err != nil {
+			return nil, err
+		}
+		out = append(out, rt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, r.attachSteps(ctx, out)
+}
+
+// attachSteps fills in each routine's Steps in order.
+func (r *routineRepo) attachSteps(ctx context.Context, routines []models.Routine) error {
+	if len(routines) == 0 {
+		return nil
+	}
+	ids := make([]string, len(routines))
+	index := map[uuid.UUID]int{}
+	for i, rt := range routines {
+		ids[i] = rt.ID.String()
+		index[rt.ID] = i
+		routines[i].Steps = []models.RoutineStep{}
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, routine_id, position, title, icon, duration_minutes
+        FROM routine_steps
+        WHERE routine_id = ANY($1::uuid[])
+        ORDER BY routine_id, position
+    `, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s models.RoutineStep
+		if err := rows.Scan(&s.ID, &s.RoutineID, &s.Position, &s.Title, &s.Icon, &s.DurationMinutes); err != nil {
+			return err
+		}
+		i := index[s.RoutineID]
+		routines[i].Steps = append(routines[i].Steps, s)
+	}
+	return rows.Err()
+}
+
+func (r *routineRepo) Update(ctx context.Context, rt *models.Routine) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.QueryRowContext(ctx, `
+        UPDATE routines
+        SET name = $2, icon = $3, start_time = $4, days_of_week = $5, active = $6, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, rt.ID, rt.Name, rt.Icon, routineStartTime(rt), routineDays(rt.DaysOfWeek), rt.Active,
+	).Scan(&rt.UpdatedAt); err != nil {
+		return err
+	}
+	keep := []string{}
+	for _, s := range rt.Steps {
+		if s.ID != uuid.Nil {
+			keep = append(keep, s.ID.String())
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+        DELETE FROM routine_steps WHERE routine_id = $1 AND NOT (id = ANY($2::uuid[]))
+    `, rt.ID, pq.Array(keep)); err != nil {
+		return err
+	}
+	for i := range rt.Steps {
+		s := &rt.Steps[i]
+		s.RoutineID, s.Position = rt.ID, i
+		if s.ID == uuid.Nil {
+			if err := insertRoutineStep(ctx, tx, s); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+            UPDATE routine_steps SET position = $3, title = $4, icon = $5, duration_minutes = $6
+            WHERE id = $1 AND routine_id = $2
+        `, s.ID, rt.ID, s.Position, s.Title, s.Icon, s.DurationMinutes); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *routineRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM routines WHERE id = $1`, id)
+	return err
+}
+
+func (r *routineRepo) SetCompletion(ctx context.Context, c *models.RoutineCompletion) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO routine_completions (step_id, completion_date, routine_id, child_id, completed_by)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (step_id, completion_date) DO UPDATE SET step_id = EXCLUDED.step_id
+        RETURNING completed_by, completed_at
+    `, c.StepID, c.CompletionDate, c.RoutineID, c.ChildID, c.CompletedBy,
+	).Scan(&c.CompletedBy, &c.CompletedAt)
+}
+
+func (r *routineRepo) ClearCompletion(ctx context.Context, stepID uuid.UUID, date time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        DELETE FROM routine_completions WHERE step_id = $1 AND completion_date = $2
+    `, stepID, date)
+	return err
+}
+
+func (r *routineRepo) Completions(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.RoutineCompletion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT step_id, routine_id, child_id, completion_date, completed_by, completed_at
+        FROM routine_completions
+        WHERE child_id = $1 AND completion_date BETWEEN $2 AND $3
+        ORDER BY completion_date
+    `, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.RoutineCompletion
+	for rows.Next() {
+		var c models.RoutineCompletion
+		if err := rows.Scan(&c.StepID, &c.RoutineID, &c.ChildID, &c.CompletionDate, &c.CompletedBy, &c.CompletedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
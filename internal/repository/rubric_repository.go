@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// RubricRepository stores the versions of each family's scoring rubric.
+type RubricRepository interface {
+	// Create saves the rubric as the family's next version and sets
+	// Version and CreatedAt.
+	Create(ctx context.Context, r *models.ScoringRubric) error
+	// Latest returns the family's newest version, or nil, nil when the
+	// family still uses the default.
+	Latest(ctx context.Context, familyID uuid.UUID) (*models.ScoringRubric, error)
+	// GetVersions returns the family's rubrics with the given versions;
+	// versions that don't exist are left out.
+	GetVersions(ctx context.Context, familyID uuid.UUID, versions []int) ([]models.ScoringRubric, error)
+}
+
+type rubricRepo struct {
+	db *DB
+}
+
+// NewRubricRepo creates a RubricRepository on the main pool.
+func NewRubricRepo(db *sql.DB) RubricRepository {
+	return &rubricRepo{db: WrapDB(db)}
+}
+
+const rubricCols = `family_id, version, metrics, created_by, created_at`
+
+func scanRubric(row interface{ Scan(...any) error }, r *models.ScoringRubric) error {
+	return row.Scan(&r.FamilyID, &r.Version, &r.Metrics, &r.CreatedBy, &r.CreatedAt)
+}
+
+// insertRubricSQL numbers the new version from the family's current
+// maximum.
+const insertRubricSQL = `
+        INSERT INTO scoring_rubrics (family_id, version, metrics, created_by)
+        SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3
+        FROM scoring_rubrics WHERE family_id = $1
+        RETURNING version, created_at`
+
+func (r *rubricRepo) Create(ctx context.Context, rb *models.ScoringRubric) error {
+	err := r.db.QueryRowContext(ctx, insertRubricSQL, rb.FamilyID, rb.Metrics, rb.CreatedBy).Scan(&rb.Version, &rb.CreatedAt)
+	if isUniqueViolation(err) {
+		// Two saves raced for the same version number; retry once against
+		// the new maximum.
+		err = r.db.QueryRowContext(ctx, insertRubricSQL, rb.FamilyID, rb.Metrics, rb.CreatedBy).Scan(&rb.Version, &rb.CreatedAt)
+	}
+	return err
+}
+
+func (r *rubricRepo) Latest(ctx context.Context, familyID uuid.UUID) (*models.ScoringRubric, error) {
+	var rb models.ScoringRubric
+	err := scanRubric(r.db.QueryRowContext(ctx, `SELECT `+rubricCols+`
+        FROM scoring_rubrics WHERE family_id = $1
+        ORDER BY version DESC LIMIT 1`, familyID), &rb)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rb, nil
+}
+
+func (r *rubricRepo) GetVersions(ctx context.Context, familyID uuid.UUID, versions []int) ([]models.ScoringRubric, error) {
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	v64 := make([]int64, len(versions))
+	for i, v := range versions {
+		v64[i] = int64(v)
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+rubricCols+`
+        FROM scoring_rubrics WHERE family_id = $1 AND version = ANY($2)
+        ORDER BY version`, familyID, pq.Array(v64))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.ScoringRubric
+	for rows.Next() {
+		var rb models.ScoringRubric
+		if err := scanRubric(rows, &rb); err != nil {
+			return nil, err
+		}
+		out = append(out, rb)
+	}
+	return out, rows.Err()
+}
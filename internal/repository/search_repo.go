@@ -17,12 +17,12 @@ type SearchRepository interface {
 }
 
 type searchRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewSearchRepo creates a new search repository
 func NewSearchRepo(db *sql.DB) SearchRepository {
-	return &searchRepo{db: db}
+	return &searchRepo{db: WrapDB(db)}
 }
 
 func (r *searchRepo) Search(ctx context.Context, familyID, userID uuid.UUID, query string) ([]models.SearchResult, error) {
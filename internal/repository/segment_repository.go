@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// SegmentFilters select app users. Unset fields don't filter; set fields
+// are ANDed. Only active, non-deleted accounts are ever members.
+type SegmentFilters struct {
+	// PlanIDs matches users in a family whose active or trialing
+	// subscription is on one of these plans.
+	PlanIDs []uuid.UUID `json:"plan_ids,omitempty"`
+	// TenureMinDays / TenureMaxDays bound days since signup.
+	TenureMinDays *int `json:"tenure_min_days,omitempty"`
+	TenureMaxDays *int `json:"tenure_max_days,omitempty"`
+	// ScoreMin / ScoreMax bound the latest nightly engagement score of the
+	// user's most engaged family. Users in no scored family don't match.
+	ScoreMin *int `json:"score_min,omitempty"`
+	ScoreMax *int `json:"score_max,omitempty"`
+	// PromoUsage is "used" (redeemed any promo code), "never", or empty.
+	PromoUsage string `json:"promo_usage,omitempty"`
+	// Locales matches app_users.language.
+	Locales []string `json:"locales,omitempty"`
+}
+
+// Segment is a saved, named SegmentFilters.
+type Segment struct {
+	ID          uuid.UUID       `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Filters     SegmentFilters  `json:"filters"`
+	CreatedBy   models.NullUUID `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// SegmentMember is what a sender needs to reach one member.
+type SegmentMember struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	Language  string    `json:"language"`
+}
+
+// SegmentOption is one choosable value in the segment builder, with how
+// many active users currently have it.
+type SegmentOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Users int    `json:"users"`
+}
+
+// SegmentOptions are the builder's plan and locale choices.
+type SegmentOptions struct {
+	Plans   []SegmentOption `json:"plans"`
+	Locales []SegmentOption `json:"locales"`
+}
+
+// SegmentRepository owns user_segments and evaluates segment filters.
+type SegmentRepository interface {
+	Create(ctx context.Context, s *Segment) error
+	Update(ctx context.Context, s *Segment) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetByID returns nil, nil when the segment doesn't exist.
+	GetByID(ctx context.Context, id uuid.UUID) (*Segment, error)
+	List(ctx context.Context) ([]Segment, error)
+
+	// Count returns how many users match f right now.
+	Count(ctx context.Context, f SegmentFilters) (int, error)
+	// Members returns the users matching f, oldest account first.
+	Members(ctx context.Context, f SegmentFilters) ([]SegmentMember, error)
+	// Contains reports whether userID matches f.
+	Contains(ctx context.Context, f SegmentFilters, userID uuid.UUID) (bool, error)
+	// Options lists the plans and locales the builder can filter on.
+	Options(ctx context.Context) (*SegmentOptions, error)
+}
+
+// ErrSegmentNameTaken is returned when another segment has the name.
+var ErrSegmentNameTaken = apperr.Conflict("segment name already used")
+
+type segmentRepo struct {
+	db *DB
+}
+
+// NewSegmentRepo creates a SegmentRepository on the main pool.
+func NewSegmentRepo(db *sql.DB) SegmentRepository {
+	return &segmentRepo{db: WrapDB(db)}
+}
+
+func (r *segmentRepo) Create(ctx context.Context, s *Segment) error {
+	filters, err := json.Marshal(s.Filters)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx, `
+        INSERT INTO user_segments (name, description, filters, created_by)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at, updated_at
+    `, s.Name, s.Description, filters, s.CreatedBy,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrSegmentNameTaken
+	}
+	return err
+}
+
+func (r *segmentRepo) Update(ctx context.Context, s *Segment) error {
+	filters, err := json.Marshal(s.Filters)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx, `
+        UPDATE user_segments SET name = $2, description = $3, filters = $4, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, s.ID, s.Name, s.Description, filters,
+	).Scan(&s.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrSegmentNameTaken
+	}
+	return err
+}
+
+func (r *segmentRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM user_segments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const segmentColumns = `id, name, description, filters, created_by, created_at, updated_at`
+
+func scanSegment(row rowScannerLike) (*Segment, error) {
+	var s Segment
+	var filters []byte
+	if err := row.Scan(&s.ID, &s.Name, &s.Description, &filters, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filters, &s.Filters); err != nil {
+		return nil, fmt.Errorf("segment %s: bad filters: %w", s.ID, err)
+	}
+	return &s, nil
+}
+
+func (r *segmentRepo) GetByID(ctx context.Context, id uuid.UUID) (*Segment, error) {
+	s, err := scanSegment(r.db.QueryRowContext(ctx,
+		`SELECT `+segmentColumns+` FROM user_segments WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (r *segmentRepo) List(ctx context.Context) ([]Segment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+segmentColumns+` FROM user_segments ORDER BY LOWER(name)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Segment
+	for rows.Next() {
+		s, err := scanSegment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// segmentWhere builds the WHERE clause for f over app_users u, with
+// placeholders numbered after the args already in args.
+func segmentWhere(f SegmentFilters, args []interface{}) (string, []interface{}) {
+	conds := []string{"u.deleted_at IS NULL", "u.status = 'active'"}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(f.PlanIDs) > 0 {
+		ids := make([]string, len(f.PlanIDs))
+		for i, id := range f.PlanIDs {
+			ids[i] = id.String()
+		}
+		conds = append(conds, `EXISTS (
+            SELECT 1 FROM family_memberships m
+            JOIN families fa ON fa.id = m.family_id AND fa.deleted_at IS NULL
+            JOIN family_subscriptions fs ON fs.family_id = m.family_id
+            WHERE m.user_id = u.id AND m.is_active
+              AND fs.status IN ('active', 'trialing')
+              AND fs.plan_id = ANY(`+arg(pq.Array(ids))+`::uuid[]))`)
+	}
+	if f.TenureMinDays != nil {
+		conds = append(conds, "u.created_at <= NOW() - make_interval(days => "+arg(*f.TenureMinDays)+"::int)")
+	}
+	if f.TenureMaxDays != nil {
+		conds = append(conds, "u.created_at > NOW() - make_interval(days => "+arg(*f.TenureMaxDays)+"::int)")
+	}
+	if f.ScoreMin != nil || f.ScoreMax != nil {
+		score := `(
+            SELECT MAX(s.score) FROM family_engagement_scores s
+            JOIN family_memberships m ON m.family_id = s.family_id AND m.is_active
+            WHERE m.user_id = u.id
+              AND s.score_date = (SELECT MAX(score_date) FROM family_engagement_scores))`
+		if f.ScoreMin != nil {
+			conds = append(conds, score+" >= "+arg(*f.ScoreMin))
+		}
+		if f.ScoreMax != nil {
+			conds = append(conds, score+" <= "+arg(*f.ScoreMax))
+		}
+	}
+	switch f.PromoUsage {
+	case "used":
+		conds = append(conds, "EXISTS (SELECT 1 FROM promo_code_usages p WHERE p.user_id = u.id)")
+	case "never":
+		conds = append(conds, "NOT EXISTS (SELECT 1 FROM promo_code_usages p WHERE p.user_id = u.id)")
+	}
+	if len(f.Locales) > 0 {
+		conds = append(conds, "u.language = ANY("+arg(pq.Array(f.Locales))+")")
+	}
+	return strings.Join(conds, "\n          AND "), args
+}
+
+func (r *segmentRepo) Count(ctx context.Context, f SegmentFilters) (int, error) {
+	where, args := segmentWhere(f, nil)
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM app_users u WHERE `+where, args...).Scan(&n)
+	return n, err
+}
+
+func (r *segmentRepo) Members(ctx context.Context, f SegmentFilters) ([]SegmentMember, error) {
+	where, args := segmentWhere(f, nil)
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT u.id, u.email, u.first_name, COALESCE(u.language, 'en')
+        FROM app_users u
+        WHERE `+where+`
+        ORDER BY u.created_at, u.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SegmentMember
+	for rows.Next() {
+		var m SegmentMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.FirstName, &m.Language); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (r *segmentRepo) Contains(ctx context.Context, f SegmentFilters, userID uuid.UUID) (bool, error) {
+	where, args := segmentWhere(f, []interface{}{userID})
+	var ok bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM app_users u WHERE u.id = $1 AND `+where+`)`, args...).Scan(&ok)
+	return ok, err
+}
+
+func (r *segmentRepo) Options(ctx context.Context) (*SegmentOptions, error) {
+	out := &SegmentOptions{Plans: []SegmentOption{}, Locales: []SegmentOption{}}
+	collect := func(dst *[]SegmentOption, query string) error {
+		rows, err := r.db.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var o SegmentOption
+			if err := rows.Scan(&o.Value, &o.Label, &o.Users); err != nil {
+				return err
+			}
+			*dst = append(*dst, o)
+		}
+		return rows.Err()
+	}
+	if err := collect(&out.Plans, `
+        SELECT sp.id::text, sp.name, COUNT(DISTINCT u.id)
+        FROM subscription_plans sp
+        LEFT JOIN family_subscriptions fs ON fs.plan_id = sp.id AND fs.status IN ('active', 'trialing')
+        LEFT JOIN family_memberships m ON m.family_id = fs.family_id AND m.is_active
+        LEFT JOIN app_users u ON u.id = m.user_id AND u.deleted_at IS NULL AND u.status = 'active'
+        WHERE sp.is_active
+        GROUP BY sp.id, sp.name, sp.price_cents
+        ORDER BY sp.price_cents, sp.name`); err != nil {
+		return nil, err
+	}
+	if err := collect(&out.Locales, `
+        SELECT COALESCE(language, 'en'), COALESCE(language, 'en'), COUNT(*)
+        FROM app_users
+        WHERE deleted_at IS NULL AND status = 'active'
+        GROUP BY 1
+        ORDER BY 3 DESC, 1`); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSeizureEventActive is returned by Create when the child already has
+// a live seizure event.
+var ErrSeizureEventActive = errors.New("child already has an active seizure event")
+
+// Seizure event statuses.
+const (
+	SeizureEventActive = "active"
+	SeizureEventEnded  = "ended"
+)
+
+// SeizureChecklistItem is one step of a live seizure's checklist: a
+// first-aid step, one of the child's rescue medications, or calling 911.
+// DueAfterSeconds, when set, is how far into the seizure the step is due.
+type SeizureChecklistItem struct {
+	Key             string     `json:"key"`
+	Kind            string     `json:"kind"`
+	Label           string     `json:"label"`
+	Detail          string     `json:"detail,omitempty"`
+	MedicationID    *uuid.UUID `json:"medication_id,omitempty"`
+	DueAfterSeconds int        `json:"due_after_seconds,omitempty"`
+	DoneAt          *time.Time `json:"done_at,omitempty"`
+	DoneBy          *uuid.UUID `json:"done_by,omitempty"`
+}
+
+// SeizureEvent is a seizure timed live from the app.
+type SeizureEvent struct {
+	ID           uuid.UUID              `json:"id"`
+	ChildID      uuid.UUID              `json:"child_id"`
+	Status       string                 `json:"status"`
+	StartedAt    time.Time              `json:"started_at"`
+	StartedBy    *uuid.UUID             `json:"started_by,omitempty"`
+	EndedAt      *time.Time             `json:"ended_at,omitempty"`
+	EndedBy      *uuid.UUID             `json:"ended_by,omitempty"`
+	Timezone     string                 `json:"timezone"`
+	Checklist    []SeizureChecklistItem `json:"checklist"`
+	SeizureLogID *uuid.UUID             `json:"seizure_log_id,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// SeizureEventRepository stores live seizure events.
+type SeizureEventRepository interface {
+	// Create inserts an active event; ID and CreatedAt are filled in.
+	Create(ctx context.Context, e *SeizureEvent) error
+	// Get returns nil, nil when there is no such event.
+	Get(ctx context.Context, id uuid.UUID) (*SeizureEvent, error)
+	// Active returns the child's live event, nil when there is none.
+	Active(ctx context.Context, childID uuid.UUID) (*SeizureEvent, error)
+	// ListByChild returns the child's events, newest first.
+	ListByChild(ctx context.Context, childID uuid.UUID, limit int) ([]SeizureEvent, error)
+	// UpdateChecklist replaces an active event's checklist and reports
+	// false when the event had already ended.
+	UpdateChecklist(ctx context.Context, id uuid.UUID, checklist []SeizureChecklistItem) (bool, error)
+	// End moves an active event to ended and reports false when it
+	// already had.
+	End(ctx context.Context, id, by uuid.UUID, endedAt time.Time) (bool, error)
+	// SetLog links an ended event to the seizure log written for it.
+	SetLog(ctx context.Context, id, seizureLogID uuid.UUID) error
+}
+
+type seizureEventRepo struct {
+	db *DB
+}
+
+// NewSeizureEventRepo creates a SeizureEventRepository on the main pool.
+func NewSeizureEventRepo(db *sql.DB) SeizureEventRepository {
+	return &seizureEventRepo{db: WrapDB(db)}
+}
+
+func (r *seizureEventRepo) Create(ctx context.Context, e *SeizureEvent) error {
+	checklist, err := json.Marshal(e.Checklist)
+	if err != nil {
+		return err
+	}
+	e.Status = SeizureEventActive
+	err = r.db.QueryRowContext(ctx, `
+        INSERT INTO seizure_events (child_id, status, started_at, started_by, timezone, checklist)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `, e.ChildID, e.Status, e.StartedAt, e.StartedBy, e.Timezone, checklist,
+	).Scan(&e.ID, &e.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrSeizureEventActive
+	}
+	return err
+}
+
+const seizureEventCols = `id, child_id, status, started_at, started_by, ended_at, ended_by,
+        timezone, checklist, seizure_log_id, created_at`
+
+func scanSeizureEvent(row interface{ Scan(...any) error }, e *SeizureEvent) error {
+	var checklist []byte
+	if err := row.Scan(&e.ID, &e.ChildID, &e.Status, &e.StartedAt, &e.StartedBy, &e.EndedAt, &e.EndedBy,
+		&e.Timezone, &checklist, &e.SeizureLogID, &e.CreatedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal(checklist, &e.Checklist)
+}
+
+func (r *seizureEventRepo) getOne(ctx context.Context, where string, arg interface{}) (*SeizureEvent, error) {
+	var e SeizureEvent
+	err := scanSeizureEvent(r.db.QueryRowContext(ctx, `SELECT `+seizureEventCols+`
+        FROM seizure_events WHERE `+where, arg), &e)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *seizureEventRepo) Get(ctx context.Context, id uuid.UUID) (*SeizureEvent, error) {
+	return r.getOne(ctx, `id = $1`, id)
+}
+
+func (r *seizureEventRepo) Active(ctx context.Context, childID uuid.UUID) (*SeizureEvent, error) {
+	return r.getOne(ctx, `child_id = $1 AND status = 'active'`, childID)
+}
+
+func (r *seizureEventRepo) ListByChild(ctx context.Context, childID uuid.UUID, limit int) ([]SeizureEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+seizureEventCols+`
+        FROM seizure_events WHERE child_id = $1
+        ORDER BY started_at DESC
+        LIMIT $2`, childID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SeizureEvent
+	for rows.Next() {
+		var e SeizureEvent
+		if err := scanSeizureEvent(rows, &e); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (r *seizureEventRepo) UpdateChecklist(ctx context.Context, id uuid.UUID, checklist []SeizureChecklistItem) (bool, error) {
+	raw, err := json.Marshal(checklist)
+	if err != nil {
+		return false, err
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE seizure_events SET checklist = $2 WHERE id = $1 AND status = 'active'
+    `, id, raw)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *seizureEventRepo) End(ctx context.Context, id, by uuid.UUID, endedAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE seizure_events SET status = 'ended', ended_at = $3, ended_by = $2
+        WHERE id = $1 AND status = 'active'
+    `, id, by, endedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *seizureEventRepo) SetLog(ctx context.Context, id, seizureLogID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE seizure_events SET seizure_log_id = $2 WHERE id = $1
+    `, id, seizureLogID)
+	return err
+}
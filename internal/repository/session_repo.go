@@ -17,12 +17,17 @@ type SessionRepository interface {
 	Revoke(ctx context.Context, id uuid.UUID) error
 	RevokeForUserKind(ctx context.Context, userID uuid.UUID, kind models.SessionKind) error
 	TouchLastSeen(ctx context.Context, id uuid.UUID) error
+	// MarkAuthenticated records a fresh password check (step-up re-auth).
+	MarkAuthenticated(ctx context.Context, id uuid.UUID) error
+	// ListActiveForUser returns the user's active sessions of kind, newest
+	// first.
+	ListActiveForUser(ctx context.Context, userID uuid.UUID, kind models.SessionKind) ([]models.Session, error)
 	ListActive(ctx context.Context, kind *models.SessionKind, limit int) ([]models.Session, error)
 }
 
-type sessionRepo struct{ db *sql.DB }
+type sessionRepo struct{ db *DB }
 
-func NewSessionRepo(db *sql.DB) SessionRepository { return &sessionRepo{db: db} }
+func NewSessionRepo(db *sql.DB) SessionRepository { return &sessionRepo{db: WrapDB(db)} }
 
 func (r *sessionRepo) Create(ctx context.Context, s *models.Session) error {
 	if s.ID == uuid.Nil {
@@ -33,6 +38,7 @@ func (r *sessionRepo) Create(ctx context.Context, s *models.Session) error {
 		s.CreatedAt = now
 	}
 	s.LastSeenAt = s.CreatedAt
+	s.LastAuthAt = s.CreatedAt
 	// Post-00032: sessions has admin_id + app_user_id (NOT user_id). Pick the
 	// right column based on Kind. The Session model still carries a single
 	// UserID field; the repo translates it to the right column.
@@ -48,12 +54,12 @@ func (r *sessionRepo) Create(ctx context.Context, s *models.Session) error {
 		INSERT INTO sessions
 			(id, admin_id, app_user_id, kind, system_role, family_id, ip_at_start, user_agent,
 			 user_email, user_first_name, user_last_name, family_name, env_name,
-			 created_at, last_seen_at, expires_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)`
+			 created_at, last_seen_at, expires_at, last_auth_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)`
 	_, err := r.db.ExecContext(ctx, q,
 		s.ID, adminID, appUserID, s.Kind, s.SystemRole, s.FamilyID, s.IPAtStart, s.UserAgent,
 		s.UserEmail, s.UserFirstName, s.UserLastName, s.FamilyName, s.EnvName,
-		s.CreatedAt, s.LastSeenAt, s.ExpiresAt)
+		s.CreatedAt, s.LastSeenAt, s.ExpiresAt, s.LastAuthAt)
 	return err
 }
 
@@ -62,13 +68,14 @@ func (r *sessionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Sessio
 	const q = `
 		SELECT id, COALESCE(admin_id, app_user_id) AS user_id, kind, system_role, family_id, ip_at_start::text,
 		       user_agent, created_at, last_seen_at, revoked_at, expires_at,
-		       user_email, user_first_name, user_last_name, family_name, env_name
+		       user_email, user_first_name, user_last_name, family_name, env_name,
+		       COALESCE(last_auth_at, created_at)
 		FROM sessions WHERE id = $1`
 	var s models.Session
 	err := r.db.QueryRowContext(ctx, q, id).Scan(
 		&s.ID, &s.UserID, &s.Kind, &s.SystemRole, &s.FamilyID, &s.IPAtStart,
 		&s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt, &s.ExpiresAt,
-		&s.UserEmail, &s.UserFirstName, &s.UserLastName, &s.FamilyName, &s.EnvName)
+		&s.UserEmail, &s.UserFirstName, &s.UserLastName, &s.FamilyName, &s.EnvName, &s.LastAuthAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -103,6 +110,42 @@ func (r *sessionRepo) TouchLastSeen(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+func (r *sessionRepo) MarkAuthenticated(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET last_auth_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	return err
+}
+
+func (r *sessionRepo) ListActiveForUser(ctx context.Context, userID uuid.UUID, kind models.SessionKind) ([]models.Session, error) {
+	col := "app_user_id"
+	if kind == models.SessionKindAdmin {
+		col = "admin_id"
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, COALESCE(admin_id, app_user_id) AS user_id, kind, system_role, family_id, ip_at_start::text,
+		       user_agent, created_at, last_seen_at, revoked_at, expires_at,
+		       user_email, user_first_name, user_last_name, family_name, env_name,
+		       COALESCE(last_auth_at, created_at)
+		FROM sessions
+		WHERE `+col+` = $1 AND kind = $2 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC`, userID, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Session
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Kind, &s.SystemRole, &s.FamilyID,
+			&s.IPAtStart, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt, &s.ExpiresAt,
+			&s.UserEmail, &s.UserFirstName, &s.UserLastName, &s.FamilyName, &s.EnvName, &s.LastAuthAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
 func (r *sessionRepo) ListActive(ctx context.Context, kind *models.SessionKind, limit int) ([]models.Session, error) {
 	if limit <= 0 {
 		limit = 200
@@ -110,7 +153,8 @@ func (r *sessionRepo) ListActive(ctx context.Context, kind *models.SessionKind,
 	q := `
 		SELECT id, COALESCE(admin_id, app_user_id) AS user_id, kind, system_role, family_id, ip_at_start::text,
 		       user_agent, created_at, last_seen_at, revoked_at, expires_at,
-		       user_email, user_first_name, user_last_name, family_name, env_name
+		       user_email, user_first_name, user_last_name, family_name, env_name,
+		       COALESCE(last_auth_at, created_at)
 		FROM sessions
 		WHERE revoked_at IS NULL AND expires_at > NOW()`
 	args := []any{}
@@ -133,7 +177,7 @@ func (r *sessionRepo) ListActive(ctx context.Context, kind *models.SessionKind,
 		var s models.Session
 		if err := rows.Scan(&s.ID, &s.UserID, &s.Kind, &s.SystemRole, &s.FamilyID,
 			&s.IPAtStart, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt, &s.ExpiresAt,
-			&s.UserEmail, &s.UserFirstName, &s.UserLastName, &s.FamilyName, &s.EnvName); err != nil {
+			&s.UserEmail, &s.UserFirstName, &s.UserLastName, &s.FamilyName, &s.EnvName, &s.LastAuthAt); err != nil {
 			return nil, err
 		}
 		out = append(out, s)
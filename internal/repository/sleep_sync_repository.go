@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// SleepSyncRepository stores device-imported sleep records and
+// each child's preferred sleep source.
+type SleepSyncRepository interface {
+	// UpsertDeviceRecord inserts the record, or replaces the one already
+	// imported with the same child, source and external ID. ID and
+	// timestamps are filled in.
+	UpsertDeviceRecord(ctx context.Context, rec *models.SleepDeviceRecord) error
+	// ListDeviceRecords returns the child's records for nights in
+	// [start, end], oldest first.
+	ListDeviceRecords(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.SleepDeviceRecord, error)
+	// ChildrenWithRecordsSince returns the children with a device record
+	// imported or replaced at or after since.
+	ChildrenWithRecordsSince(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	// GetPreference returns the child's preference, or nil, nil when none
+	// has been saved.
+	GetPreference(ctx context.Context, childID uuid.UUID) (*models.SleepSourcePreference, error)
+	SavePreference(ctx context.Context, p *models.SleepSourcePreference) error
+}
+
+type sleepSyncRepo struct {
+	db *DB
+}
+
+// NewSleepSyncRepo creates a SleepSyncRepository on the
+// main pool.
+func NewSleepSyncRepo(db *sql.DB) SleepSyncRepository {
+	return &sleepSyncRepo{db: WrapDB(db)}
+}
+
+const sleepDeviceRecordCols = `id, child_id, source, external_id, sleep_start, sleep_end, total_sleep_minutes,
+    night_wakings, night_date, timezone, imported_by, created_at, updated_at`
+
+func scanSleepDeviceRecord(row interface{ Scan(...any) error }, rec *models.SleepDeviceRecord) error {
+	return row.Scan(&rec.ID, &rec.ChildID, &rec.Source, &rec.ExternalID, &rec.SleepStart, &rec.SleepEnd,
+		&rec.TotalSleepMinutes, &rec.NightWakings, &rec.NightDate, &rec.Timezone, &rec.ImportedBy,
+		&rec.CreatedAt, &rec.UpdatedAt)
+}
+
+func (r *sleepSyncRepo) UpsertDeviceRecord(ctx context.Context, rec *models.SleepDeviceRecord) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO sleep_device_records (child_id, source, external_id, sleep_start, sleep_end,
+            total_sleep_minutes, night_wakings, night_date, timezone, imported_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        ON CONFLICT (child_id, source, external_id) DO UPDATE SET
+            sleep_start = EXCLUDED.sleep_start,
+            sleep_end = EXCLUDED.sleep_end,
+            total_sleep_minutes = EXCLUDED.total_sleep_minutes,
+            night_wakings = EXCLUDED.night_wakings,
+            night_date = EXCLUDED.night_date,
+            timezone = EXCLUDED.timezone,
+            imported_by = EXCLUDED.imported_by,
+            updated_at = NOW()
+        RETURNING id, created_at, updated_at
+    `, rec.ChildID, rec.Source, rec.ExternalID, rec.SleepStart, rec.SleepEnd, rec.TotalSleepMinutes,
+		rec.NightWakings, rec.NightDate, rec.Timezone, rec.ImportedBy,
+	).Scan(&rec.ID, &rec.CreatedAt, &rec.UpdatedAt)
+}
+
+func (r *sleepSyncRepo) ListDeviceRecords(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.SleepDeviceRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+sleepDeviceRecordCols+`
+        FROM sleep_device_records
+        WHERE child_id = $1 AND night_date BETWEEN $2 AND $3
+        ORDER BY night_date, sleep_start`, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.SleepDeviceRecord
+	for rows.Next() {
+		var rec models.SleepDeviceRecord
+		if err := scanSleepDeviceRecord(rows, &rec); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (r *sleepSyncRepo) ChildrenWithRecordsSince(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT DISTINCT child_id FROM sleep_device_records WHERE updated_at >= $1`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+func (r *sleepSyncRepo) GetPreference(ctx context.Context, childID uuid.UUID) (*models.SleepSourcePreference, error) {
+	var p models.SleepSourcePreference
+	err := r.db.QueryRowContext(ctx, `
+        SELECT child_id, preferred_source, conflict_threshold_minutes, updated_by, updated_at
+        FROM sleep_source_preferences WHERE child_id = $1`, childID,
+	).Scan(&p.ChildID, &p.PreferredSource, &p.ConflictThresholdMinutes, &p.UpdatedBy, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *sleepSyncRepo) SavePreference(ctx context.Context, p *models.SleepSourcePreference) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO sleep_source_preferences (child_id, preferred_source, conflict_threshold_minutes, updated_by)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (child_id) DO UPDATE SET
+            preferred_source = EXCLUDED.preferred_source,
+            conflict_threshold_minutes = EXCLUDED.conflict_threshold_minutes,
+            updated_by = EXCLUDED.updated_by,
+            updated_at = NOW()
+        RETURNING updated_at
+    `, p.ChildID, p.PreferredSource, p.ConflictThresholdMinutes, p.UpdatedBy,
+	).Scan(&p.UpdatedAt)
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaxRate is a row of the built-in rate table. Region "" is the
+// country-wide rate.
+type TaxRate struct {
+	ID               uuid.UUID `json:"id"`
+	Country          string    `json:"country"`
+	Region           string    `json:"region"`
+	Name             string    `json:"name"`
+	TaxType          string    `json:"tax_type"`
+	RateBps          int       `json:"rate_bps"`
+	InclusivePricing bool      `json:"inclusive_pricing"`
+	IsActive         bool      `json:"is_active"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PaymentTax is the tax collected for one jurisdiction on a paid Stripe
+// invoice.
+type PaymentTax struct {
+	ID              uuid.UUID  `json:"id"`
+	StripeInvoiceID string     `json:"stripe_invoice_id"`
+	PaymentID       *uuid.UUID `json:"payment_id,omitempty"`
+	OrganizationID  *uuid.UUID `json:"organization_id,omitempty"`
+	Country         string     `json:"country"`
+	Region          string     `json:"region"`
+	Jurisdiction    string     `json:"jurisdiction"`
+	TaxType         string     `json:"tax_type"`
+	RateBps         int        `json:"rate_bps"`
+	Inclusive       bool       `json:"inclusive"`
+	TaxableCents    int64      `json:"taxable_cents"`
+	TaxCents        int64      `json:"tax_cents"`
+	Currency        string     `json:"currency"`
+	Provider        string     `json:"provider"`
+	CollectedAt     time.Time  `json:"collected_at"`
+}
+
+// TaxReportRow is tax collected in one jurisdiction and currency over one
+// period.
+type TaxReportRow struct {
+	PeriodStart  time.Time `json:"period_start"`
+	Country      string    `json:"country"`
+	Region       string    `json:"region"`
+	Jurisdiction string    `json:"jurisdiction"`
+	TaxType      string    `json:"tax_type"`
+	Currency     string    `json:"currency"`
+	Invoices     int       `json:"invoices"`
+	TaxableCents int64     `json:"taxable_cents"`
+	TaxCents     int64     `json:"tax_cents"`
+}
+
+// TaxRepository stores the rate table and tax collected.
+type TaxRepository interface {
+	// RateFor returns the active rate for a region, falling back to the
+	// country-wide rate; nil if neither exists.
+	RateFor(ctx context.Context, country, region string) (*TaxRate, error)
+	ListRates(ctx context.Context) ([]TaxRate, error)
+
+	// RecordInvoiceTaxes replaces the tax recorded for a Stripe invoice
+	// (webhooks can be redelivered) and stamps the total on its payments
+	// row, if it has one.
+	RecordInvoiceTaxes(ctx context.Context, stripeInvoiceID string, taxes []PaymentTax) error
+	// Report sums tax collected in [from, to) by jurisdiction, currency and
+	// period; period is a date_trunc unit (month, quarter, year).
+	Report(ctx context.Context, from, to time.Time, period string) ([]TaxReportRow, error)
+}
+
+type taxRepo struct {
+	db *DB
+}
+
+// NewTaxRepo creates a TaxRepository on the main pool.
+func NewTaxRepo(db *sql.DB) TaxRepository {
+	return &taxRepo{db: WrapDB(db)}
+}
+
+const taxRateCols = `id, country, region, name, tax_type, rate_bps, inclusive_pricing, is_active, updated_at`
+
+func scanTaxRate(row interface{ Scan(...any) error }, t *TaxRate) error {
+	return row.Scan(&t.ID, &t.Country, &t.Region, &t.Name, &t.TaxType, &t.RateBps,
+		&t.InclusivePricing, &t.IsActive, &t.UpdatedAt)
+}
+
+func (r *taxRepo) RateFor(ctx context.Context, country, region string) (*TaxRate, error) {
+	var t TaxRate
+	err := scanTaxRate(r.db.QueryRowContext(ctx, `
+        SELECT `+taxRateCols+` FROM tax_rates
+        WHERE country = $1 AND region IN ('', $2) AND is_active
+        ORDER BY region DESC LIMIT 1`,
+		strings.ToUpper(country), strings.ToUpper(region)), &t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *taxRepo) ListRates(ctx context.Context) ([]TaxRate, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+taxRateCols+` FROM tax_rates ORDER BY country, region`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rates []TaxRate
+	for rows.Next() {
+		var t TaxRate
+		if err := scanTaxRate(rows, &t); err != nil {
+			return nil, err
+		}
+		rates = append(rates, t)
+	}
+	return rates, rows.Err()
+}
+
+func (r *taxRepo) RecordInvoiceTaxes(ctx context.Context, stripeInvoiceID string, taxes []PaymentTax) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payment_taxes WHERE stripe_invoice_id = $1`, stripeInvoiceID); err != nil {
+		return fmt.Errorf("clear invoice taxes: %w", err)
+	}
+	var total int64
+	inclusive := false
+	for _, t := range taxes {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO payment_taxes (
+                stripe_invoice_id, payment_id, organization_id, country, region, jurisdiction,
+                tax_type, rate_bps, inclusive, taxable_cents, tax_cents, currency, provider, collected_at
+            ) VALUES (
+                $1, (SELECT id FROM payments WHERE stripe_invoice_id = $1 ORDER BY created_at LIMIT 1),
+                $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+            )
+            ON CONFLICT (stripe_invoice_id, country, region, jurisdiction, tax_type) DO UPDATE SET
+                taxable_cents = payment_taxes.taxable_cents + EXCLUDED.taxable_cents,
+                tax_cents     = payment_taxes.tax_cents + EXCLUDED.tax_cents`,
+			stripeInvoiceID, t.OrganizationID, t.Country, t.Region, t.Jurisdiction,
+			t.TaxType, t.RateBps, t.Inclusive, t.TaxableCents, t.TaxCents, strings.ToUpper(t.Currency),
+			t.Provider, t.CollectedAt)
+		if err != nil {
+			return fmt.Errorf("insert invoice tax: %w", err)
+		}
+		total += t.TaxCents
+		inclusive = inclusive || t.Inclusive
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE payments SET tax_amount_cents = $2, tax_inclusive = $3
+        WHERE stripe_invoice_id = $1`, stripeInvoiceID, total, inclusive); err != nil {
+		return fmt.Errorf("stamp payment tax: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *taxRepo) Report(ctx context.Context, from, to time.Time, period string) ([]TaxReportRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT date_trunc($3, collected_at AT TIME ZONE 'UTC') AS period_start,
+               country, region, jurisdiction, tax_type, currency,
+               COUNT(DISTINCT stripe_invoice_id), SUM(taxable_cents), SUM(tax_cents)
+        FROM payment_taxes
+        WHERE collected_at >= $1 AND collected_at < $2
+        GROUP BY 1, country, region, jurisdiction, tax_type, currency
+        ORDER BY 1, country, region, jurisdiction, tax_type, currency`, from, to, period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TaxReportRow
+	for rows.Next() {
+		var row TaxReportRow
+		if err := rows.Scan(&row.PeriodStart, &row.Country, &row.Region, &row.Jurisdiction, &row.TaxType,
+			&row.Currency, &row.Invoices, &row.TaxableCents, &row.TaxCents); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
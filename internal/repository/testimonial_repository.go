@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// Testimonial is one quote or case study. Quote is always the short pull
+// quote; case studies add Title and Body.
+type Testimonial struct {
+	ID               uuid.UUID       `json:"id"`
+	Kind             string          `json:"kind"`
+	Quote            string          `json:"quote"`
+	Title            string          `json:"title"`
+	Body             string          `json:"body"`
+	AuthorName       string          `json:"author_name"`
+	AuthorDescriptor string          `json:"author_descriptor"`
+	Anonymous        bool            `json:"anonymous"`
+	AppUserID        models.NullUUID `json:"app_user_id,omitempty"`
+	Tags             []string        `json:"tags"`
+
+	ConsentStatus     string     `json:"consent_status"`
+	ConsentMethod     string     `json:"consent_method"`
+	ConsentChannels   []string   `json:"consent_channels"`
+	ConsentRecordedAt *time.Time `json:"consent_recorded_at,omitempty"`
+	ConsentExpiresOn  *time.Time `json:"consent_expires_on,omitempty"`
+	ConsentNote       string     `json:"consent_note"`
+
+	Status     string          `json:"status"`
+	ReviewNote string          `json:"review_note"`
+	ReviewedBy models.NullUUID `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time      `json:"reviewed_at,omitempty"`
+
+	IsFeatured   bool `json:"is_featured"`
+	FeaturedRank int  `json:"featured_rank"`
+
+	CreatedBy models.NullUUID `json:"created_by,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// TestimonialFilter narrows the admin list. Zero values mean "any".
+type TestimonialFilter struct {
+	Status        string
+	ConsentStatus string
+	Kind          string
+	Tag           string
+	FeaturedOnly  bool
+	Query         string
+}
+
+// TestimonialRepository owns the testimonials table.
+type TestimonialRepository interface {
+	Create(ctx context.Context, t *Testimonial) error
+	// Update writes every mutable column; the service owns transitions.
+	Update(ctx context.Context, t *Testimonial) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Testimonial, error)
+	List(ctx context.Context, f TestimonialFilter, limit, offset int) ([]Testimonial, int, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListPublished returns approved entries whose consent is granted,
+	// unexpired and covers channel. Featured entries come first by rank.
+	ListPublished(ctx context.Context, channel, tag string, featuredOnly bool, limit int) ([]Testimonial, error)
+}
+
+type testimonialRepo struct {
+	db *DB
+}
+
+// NewTestimonialRepo creates a TestimonialRepository on the main pool.
+func NewTestimonialRepo(db *sql.DB) TestimonialRepository {
+	return &testimonialRepo{db: WrapDB(db)}
+}
+
+const testimonialCols = `
+    id, kind, quote, title, body, author_name, author_descriptor, anonymous, app_user_id, tags,
+    consent_status, consent_method, consent_channels, consent_recorded_at, consent_expires_on, consent_note,
+    status, review_note, reviewed_by, reviewed_at, is_featured, featured_rank,
+    created_by, created_at, updated_at`
+
+func scanTestimonial(s rowScannerLike) (*Testimonial, error) {
+	t := &Testimonial{}
+	var recorded, expires, reviewed sql.NullTime
+	err := s.Scan(&t.ID, &t.Kind, &t.Quote, &t.Title, &t.Body, &t.AuthorName, &t.AuthorDescriptor,
+		&t.Anonymous, &t.AppUserID, pq.Array(&t.Tags),
+		&t.ConsentStatus, &t.ConsentMethod, pq.Array(&t.ConsentChannels), &recorded, &expires, &t.ConsentNote,
+		&t.Status, &t.ReviewNote, &t.ReviewedBy, &reviewed, &t.IsFeatured, &t.FeaturedRank,
+		&t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if recorded.Valid {
+		t.ConsentRecordedAt = &recorded.Time
+	}
+	if expires.Valid {
+		t.ConsentExpiresOn = &expires.Time
+	}
+	if reviewed.Valid {
+		t.ReviewedAt = &reviewed.Time
+	}
+	if t.Tags == nil {
+		t.Tags = []string{}
+	}
+	if t.ConsentChannels == nil {
+		t.ConsentChannels = []string{}
+	}
+	return t, nil
+}
+
+func (r *testimonialRepo) Create(ctx context.Context, t *Testimonial) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO testimonials
+            (kind, quote, title, body, author_name, author_descriptor, anonymous, app_user_id, tags,
+             consent_status, consent_method, consent_channels, consent_recorded_at, consent_expires_on,
+             consent_note, status, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+        RETURNING id, created_at, updated_at
+    `, t.Kind, t.Quote, t.Title, t.Body, t.AuthorName, t.AuthorDescriptor, t.Anonymous, t.AppUserID,
+		pq.Array(t.Tags), t.ConsentStatus, t.ConsentMethod, pq.Array(t.ConsentChannels),
+		t.ConsentRecordedAt, t.ConsentExpiresOn, t.ConsentNote, t.Status, t.CreatedBy,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+func (r *testimonialRepo) Update(ctx context.Context, t *Testimonial) error {
+	return r.db.QueryRowContext(ctx, `
+        UPDATE testimonials SET
+            kind = $2, quote = $3, title = $4, body = $5, author_name = $6,
+            author_descriptor = $7, anonymous = $8, app_user_id = $9, tags = $10,
+            consent_status = $11, consent_method = $12, consent_channels = $13,
+            consent_recorded_at = $14, consent_expires_on = $15, consent_note = $16,
+            status = $17, review_note = $18, reviewed_by = $19, reviewed_at = $20,
+            is_featured = $21, featured_rank = $22, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, t.ID, t.Kind, t.Quote, t.Title, t.Body, t.AuthorName, t.AuthorDescriptor, t.Anonymous,
+		t.AppUserID, pq.Array(t.Tags), t.ConsentStatus, t.ConsentMethod, pq.Array(t.ConsentChannels),
+		t.ConsentRecordedAt, t.ConsentExpiresOn, t.ConsentNote, t.Status, t.ReviewNote,
+		t.ReviewedBy, t.ReviewedAt, t.IsFeatured, t.FeaturedRank,
+	).Scan(&t.UpdatedAt)
+}
+
+func (r *testimonialRepo) GetByID(ctx context.Context, id uuid.UUID) (*Testimonial, error) {
+	t, err := scanTestimonial(r.db.QueryRowContext(ctx,
+		"SELECT "+testimonialCols+" FROM testimonials WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+func (r *testimonialRepo) List(ctx context.Context, f TestimonialFilter, limit, offset int) ([]Testimonial, int, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.ConsentStatus != "" {
+		add("consent_status = $%d", f.ConsentStatus)
+	}
+	if f.Kind != "" {
+		add("kind = $%d", f.Kind)
+	}
+	if f.Tag != "" {
+		add("$%d = ANY(tags)", f.Tag)
+	}
+	if f.FeaturedOnly {
+		where = append(where, "is_featured")
+	}
+	if q := strings.TrimSpace(f.Query); q != "" {
+		add("(quote ILIKE '%%' || $%[1]d || '%%' OR author_name ILIKE '%%' || $%[1]d || '%%' OR title ILIKE '%%' || $%[1]d || '%%')", q)
+	}
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM testimonials"+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, limit, offset)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+testimonialCols+" FROM testimonials"+whereSQL+
+		fmt.Sprintf(" ORDER BY updated_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var out []Testimonial
+	for rows.Next() {
+		t, err := scanTestimonial(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, *t)
+	}
+	return out, total, rows.Err()
+}
+
+func (r *testimonialRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM testimonials WHERE id = $1", id)
+	return err
+}
+
+func (r *testimonialRepo) ListPublished(ctx context.Context, channel, tag string, featuredOnly bool, limit int) ([]Testimonial, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+testimonialCols+` FROM testimonials
+        WHERE status = 'approved'
+          AND consent_status = 'granted'
+          AND $1 = ANY(consent_channels)
+          AND (consent_expires_on IS NULL OR consent_expires_on >= CURRENT_DATE)
+          AND ($2 = '' OR $2 = ANY(tags))
+          AND (NOT $3 OR is_featured)
+        ORDER BY is_featured DESC, featured_rank ASC, reviewed_at DESC NULLS LAST
+        LIMIT $4`, channel, tag, featuredOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Testimonial
+	for rows.Next() {
+		t, err := scanTestimonial(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *t)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// TherapyGoalRepository stores children's therapy goals and the goals
+// each therapy log worked on.
+type TherapyGoalRepository interface {
+	// Create inserts a goal; ID and timestamps are filled in.
+	Create(ctx context.Context, g *models.TherapyGoal) error
+	// GetByID returns nil, nil when there is no such goal.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.TherapyGoal, error)
+	// ListByChild returns the child's goals, active first; status ""
+	// lists every status.
+	ListByChild(ctx context.Context, childID uuid.UUID, status string) ([]models.TherapyGoal, error)
+	Update(ctx context.Context, g *models.TherapyGoal) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// SetLogGoals replaces the goals linked to a therapy log.
+	SetLogGoals(ctx context.Context, therapyLogID uuid.UUID, goals []models.TherapyLogGoal) error
+	// LogGoals returns the linked goals of each of the therapy logs, with
+	// their goal text.
+	LogGoals(ctx context.Context, therapyLogIDs []uuid.UUID) (map[uuid.UUID][]models.TherapyLogGoal, error)
+	// Points returns a goal's sessions with log dates in [start, end],
+	// oldest first.
+	Points(ctx context.Context, goalID uuid.UUID, start, end time.Time) ([]models.TherapyGoalPoint, error)
+}
+
+type therapyGoalRepo struct {
+	db *DB
+}
+
+// NewTherapyGoalRepo creates a TherapyGoalRepository on the main pool.
+func NewTherapyGoalRepo(db *sql.DB) TherapyGoalRepository {
+	return &therapyGoalRepo{db: WrapDB(db)}
+}
+
+const therapyGoalCols = `id, child_id, goal_text, therapy_type, target_metric, baseline, target_value,
+        target_date, status, met_at, created_by, created_at, updated_at`
+
+func scanTherapyGoal(row interface{ Scan(...any) error }, g *models.TherapyGoal) error {
+	return row.Scan(&g.ID, &g.ChildID, &g.GoalText, &g.TherapyType, &g.TargetMetric, &g.Baseline, &g.TargetValue,
+		&g.TargetDate, &g.Status, &g.MetAt, &g.CreatedBy, &g.CreatedAt, &g.UpdatedAt)
+}
+
+func (r *therapyGoalRepo) Create(ctx context.Context, g *models.TherapyGoal) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO therapy_goals (child_id, goal_text, therapy_type, target_metric, baseline, target_value,
+            target_date, status, met_at, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id, created_at, updated_at
+    `, g.ChildID, g.GoalText, g.TherapyType, g.TargetMetric, g.Baseline, g.TargetValue,
+		g.TargetDate, g.Status, g.MetAt, g.CreatedBy,
+	).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+}
+
+func (r *therapyGoalRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.TherapyGoal, error) {
+	var g models.TherapyGoal
+	err := scanTherapyGoal(r.db.QueryRowContext(ctx, `SELECT `+therapyGoalCols+`
+        FROM therapy_goals WHERE id = $1`, id), &g)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *therapyGoalRepo) ListByChild(ctx context.Context, childID uuid.UUID, status string) ([]models.TherapyGoal, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+therapyGoalCols+`
+        FROM therapy_goals
+        WHERE child_id = $1 AND ($2 = '' OR status = $2)
+        ORDER BY status = 'active' DESC, target_date NULLS LAST, created_at`, childID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.TherapyGoal
+	for rows.Next() {
+		var g models.TherapyGoal
+		if err := scanTherapyGoal(rows, &g); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+func (r *therapyGoalRepo) Update(ctx context.Context, g *models.TherapyGoal) error {
+	return r.db.QueryRowContext(ctx, `
+        UPDATE therapy_goals
+        SET goal_text = $2, therapy_type = $3, target_metric = $4, baseline = $5, target_value = $6,
+            target_date = $7, status = $8, met_at = $9, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at
+    `, g.ID, g.GoalText, g.TherapyType, g.TargetMetric, g.Baseline, g.TargetValue,
+		g.TargetDate, g.Status, g.MetAt,
+	).Scan(&g.UpdatedAt)
+}
+
+func (r *therapyGoalRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM therapy_goals WHERE id = $1`, id)
+	return err
+}
+
+func (r *therapyGoalRepo) SetLogGoals(ctx context.Context, therapyLogID uuid.UUID, goals []models.TherapyLogGoal) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM therapy_log_goals WHERE therapy_log_id = $1`, therapyLogID); err != nil {
+		return err
+	}
+	for _, g := range goals {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO therapy_log_goals (therapy_log_id, goal_id, progress_rating, measured_value, note)
+            VALUES ($1, $2, $3, $4, $5)
+        `, therapyLogID, g.GoalID, g.ProgressRating, g.MeasuredValue, g.Note); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *therapyGoalRepo) LogGoals(ctx context.Context, therapyLogIDs []uuid.UUID) (map[uuid.UUID][]models.TherapyLogGoal, error) {
+	out := map[uuid.UUID][]models.TherapyLogGoal{}
+	if len(therapyLogIDs) == 0 {
+		return out, nil
+	}
+	ids := make([]string, len(therapyLogIDs))
+	for i, id := range therapyLogIDs {
+		ids[i] = id.String()
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT lg.therapy_log_id, lg.goal_id, g.goal_text, lg.progress_rating, lg.measured_value, lg.note
+        FROM therapy_log_goals lg
+        JOIN therapy_goals g ON g.id = lg.goal_id
+        WHERE lg.therapy_log_id = ANY($1::uuid[])
+        ORDER BY g.created_at
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var logID uuid.UUID
+		var g models.TherapyLogGoal
+		if err := rows.Scan(&logID, &g.GoalID, &g.GoalText, &g.ProgressRating, &g.MeasuredValue, &g.Note); err != nil {
+			return nil, err
+		}
+		out[logID] = append(out[logID], g)
+	}
+	return out, rows.Err()
+}
+
+func (r *therapyGoalRepo) Points(ctx context.Context, goalID uuid.UUID, start, end time.Time) ([]models.TherapyGoalPoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT tl.id, tl.log_date, lg.progress_rating, lg.measured_value, lg.note
+        FROM therapy_log_goals lg
+        JOIN therapy_logs tl ON tl.id = lg.therapy_log_id
+        WHERE lg.goal_id = $1 AND tl.log_date BETWEEN $2 AND $3
+        ORDER BY tl.log_date, tl.created_at
+    `, goalID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.TherapyGoalPoint
+	for rows.Next() {
+		var p models.TherapyGoalPoint
+		if err := rows.Scan(&p.TherapyLogID, &p.Date, &p.ProgressRating, &p.MeasuredValue, &p.Note); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"carecompanion/internal/models"
 )
@@ -24,6 +25,21 @@ type TicketAttachment struct {
 	StorageDriver string          `json:"-"`
 	SizeBytes     int64           `json:"size_bytes"`
 	CreatedAt     time.Time       `json:"created_at"`
+	// MessageID is set once the upload is sent with a message; NULL means
+	// ticket-level (or still pending in the composer).
+	MessageID     models.NullUUID `json:"message_id,omitempty"`
+	ThumbnailPath string          `json:"-"`
+	HasThumbnail  bool            `json:"has_thumbnail"`
+	Width         int             `json:"width,omitempty"`
+	Height        int             `json:"height,omitempty"`
+	// Internal is true when the linked message is a staff-only note. Never
+	// serialized; used to hide the file from the ticket owner.
+	Internal bool `json:"-"`
+	// Short-lived signed URLs, minted by the service on read.
+	DownloadURL  string `json:"download_url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	// ScanStatus is the antivirus verdict, set on the upload response only.
+	ScanStatus string `json:"scan_status,omitempty"`
 }
 
 // TicketAttachmentRepository handles ticket_attachments rows. The actual file
@@ -37,6 +53,12 @@ type TicketAttachmentRepository interface {
 	// DeleteAllByTicket returns the rows that were deleted so the caller can
 	// also delete the underlying storage objects.
 	DeleteAllByTicket(ctx context.Context, ticketID uuid.UUID) ([]TicketAttachment, error)
+	// SetThumbnail records the generated thumbnail + source dimensions.
+	SetThumbnail(ctx context.Context, id uuid.UUID, path string, width, height int) error
+	// LinkToMessage attaches pending (message_id IS NULL) uploads on the
+	// ticket to messageID. Only rows uploaded by uploaderID are touched so
+	// nobody can claim someone else's file. Returns the number linked.
+	LinkToMessage(ctx context.Context, ticketID, uploaderID, messageID uuid.UUID, ids []uuid.UUID) (int64, error)
 }
 
 // ticketAttachmentRepo implements TicketAttachmentRepository.
@@ -48,8 +70,8 @@ type TicketAttachmentRepository interface {
 //              mode) or be a separate pool pointed at prod (shared mode set
 //              by SUPPORT_DB_DSN). All attachment SQL routes here.
 type ticketAttachmentRepo struct {
-	db        *sql.DB
-	supportDB *sql.DB
+	db        *DB
+	supportDB *DB
 }
 
 // NewTicketAttachmentRepo constructs the repo. supportDB falls back to db
@@ -58,22 +80,30 @@ func NewTicketAttachmentRepo(db, supportDB *sql.DB) TicketAttachmentRepository {
 	if supportDB == nil {
 		supportDB = db
 	}
-	return &ticketAttachmentRepo{db: db, supportDB: supportDB}
+	return &ticketAttachmentRepo{db: WrapDB(db), supportDB: WrapDB(supportDB)}
 }
 
+// attSelectCols / attFrom join the owning message so Internal can be
+// derived without a second round-trip.
 const attSelectCols = `
-    id, ticket_id, uploader_id, kind, content_type, original_name,
-    storage_path, storage_driver, size_bytes, created_at
+    a.id, a.ticket_id, a.uploader_id, a.kind, a.content_type, a.original_name,
+    a.storage_path, a.storage_driver, a.size_bytes, a.created_at,
+    a.message_id, COALESCE(a.thumbnail_path, ''), COALESCE(a.width, 0), COALESCE(a.height, 0),
+    COALESCE(m.is_internal, false)
 `
 
+const attFrom = ` FROM ticket_attachments a LEFT JOIN ticket_messages m ON m.id = a.message_id `
+
 func scanAttachment(s rowScannerLike) (*TicketAttachment, error) {
 	a := &TicketAttachment{}
 	if err := s.Scan(
 		&a.ID, &a.TicketID, &a.UploaderID, &a.Kind, &a.ContentType, &a.OriginalName,
 		&a.StoragePath, &a.StorageDriver, &a.SizeBytes, &a.CreatedAt,
+		&a.MessageID, &a.ThumbnailPath, &a.Width, &a.Height, &a.Internal,
 	); err != nil {
 		return nil, err
 	}
+	a.HasThumbnail = a.ThumbnailPath != ""
 	return a, nil
 }
 
@@ -125,7 +155,7 @@ func (r *ticketAttachmentRepo) Create(ctx context.Context, a *TicketAttachment)
 }
 
 func (r *ticketAttachmentRepo) GetByID(ctx context.Context, id uuid.UUID) (*TicketAttachment, error) {
-	row := r.supportDB.QueryRowContext(ctx, "SELECT "+attSelectCols+" FROM ticket_attachments WHERE id = $1", id)
+	row := r.supportDB.QueryRowContext(ctx, "SELECT "+attSelectCols+attFrom+"WHERE a.id = $1", id)
 	a, err := scanAttachment(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -135,7 +165,7 @@ func (r *ticketAttachmentRepo) GetByID(ctx context.Context, id uuid.UUID) (*Tick
 
 func (r *ticketAttachmentRepo) ListByTicket(ctx context.Context, ticketID uuid.UUID) ([]TicketAttachment, error) {
 	rows, err := r.supportDB.QueryContext(ctx,
-		"SELECT "+attSelectCols+" FROM ticket_attachments WHERE ticket_id = $1 ORDER BY created_at ASC",
+		"SELECT "+attSelectCols+attFrom+"WHERE a.ticket_id = $1 ORDER BY a.created_at ASC",
 		ticketID)
 	if err != nil {
 		return nil, err
@@ -175,3 +205,28 @@ func (r *ticketAttachmentRepo) DeleteAllByTicket(ctx context.Context, ticketID u
 	}
 	return atts, nil
 }
+
+func (r *ticketAttachmentRepo) SetThumbnail(ctx context.Context, id uuid.UUID, path string, width, height int) error {
+	_, err := r.supportDB.ExecContext(ctx,
+		"UPDATE ticket_attachments SET thumbnail_path = NULLIF($2, ''), width = $3, height = $4 WHERE id = $1",
+		id, path, width, height)
+	return err
+}
+
+func (r *ticketAttachmentRepo) LinkToMessage(ctx context.Context, ticketID, uploaderID, messageID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res, err := r.supportDB.ExecContext(ctx, `
+        UPDATE ticket_attachments
+           SET message_id = $3
+         WHERE ticket_id = $1
+           AND uploader_id = $2
+           AND message_id IS NULL
+           AND id = ANY($4)
+    `, ticketID, uploaderID, messageID, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
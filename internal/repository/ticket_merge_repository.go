@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// ErrTicketMergeConflict is returned by Merge when either ticket was merged
+// away between the caller's checks and the locked re-read.
+var ErrTicketMergeConflict = apperr.Conflict("one of the tickets was merged in the meantime")
+
+// TicketMerge is one row of ticket_merges: the history record (and the
+// redirect's reason) for a ticket merged into another.
+type TicketMerge struct {
+	ID               uuid.UUID       `json:"id"`
+	SourceTicketID   uuid.UUID       `json:"source_ticket_id"`
+	TargetTicketID   uuid.UUID       `json:"target_ticket_id"`
+	SourceNumber     int64           `json:"source_ticket_number"`
+	SourceSubject    string          `json:"source_subject"`
+	ReportedAt       time.Time       `json:"reported_at"`
+	MessagesMoved    int             `json:"messages_moved"`
+	AttachmentsMoved int             `json:"attachments_moved"`
+	MergedBy         models.NullUUID `json:"merged_by,omitempty"`
+	MergedByEmail    string          `json:"merged_by_email,omitempty"`
+	MergedAt         time.Time       `json:"merged_at"`
+}
+
+// TicketMergeInput is one merge. OpeningMessage carries the source's
+// subject and description into the target's thread, dated when the source
+// was opened; empty skips it. Notes, when set, gives the internal note for
+// support and the note for the user that Merge posts on the target from
+// the actor once the counts are known; an empty note is skipped.
+type TicketMergeInput struct {
+	SourceID       uuid.UUID
+	TargetID       uuid.UUID
+	OpeningMessage string
+	Notes          func(m *TicketMerge) (internal, user string)
+	ActorID        uuid.UUID
+	ActorEmail     string
+}
+
+// TicketMergeCandidate is a slim ticket row for duplicate suggestions.
+type TicketMergeCandidate struct {
+	ID        uuid.UUID `json:"id"`
+	Number    int64     `json:"ticket_number"`
+	Subject   string    `json:"subject"`
+	Status    string    `json:"status"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TicketMergeRepository owns ticket_merges and the merged_into_ticket_id
+// redirect on support_tickets. Everything lives next to support_tickets, so
+// every query routes to the support pool.
+type TicketMergeRepository interface {
+	// Merge moves the source's thread (messages, attachments, tags) onto
+	// the target, repoints duplicates and earlier merges (redirects and
+	// history) at the target, closes the source with the redirect set,
+	// posts the notes, and records the merge — in one tx. sql.ErrNoRows
+	// when either ticket is gone.
+	Merge(ctx context.Context, in TicketMergeInput) (*TicketMerge, error)
+	// ListForTarget returns the merges into targetID, oldest first.
+	ListForTarget(ctx context.Context, targetID uuid.UUID) ([]TicketMerge, error)
+	// ListCandidates returns the user's other tickets created in [from, to)
+	// that are still standalone: not merged away, not closed as duplicates.
+	ListCandidates(ctx context.Context, userID, excludeID uuid.UUID, from, to time.Time, limit int) ([]TicketMergeCandidate, error)
+}
+
+type ticketMergeRepo struct {
+	supportDB *DB
+}
+
+// NewTicketMergeRepo creates a TicketMergeRepository on the support pool.
+func NewTicketMergeRepo(supportDB *sql.DB) TicketMergeRepository {
+	return &ticketMergeRepo{supportDB: WrapDB(supportDB)}
+}
+
+// mergeTicketRow is what Merge needs of each ticket, read under lock.
+type mergeTicketRow struct {
+	id         uuid.UUID
+	number     int64
+	subject    string
+	userID     models.NullUUID
+	email      string
+	firstName  string
+	lastName   string
+	status     string
+	createdAt  time.Time
+	mergedInto models.NullUUID
+}
+
+func (r *ticketMergeRepo) Merge(ctx context.Context, in TicketMergeInput) (*TicketMerge, error) {
+	tx, err := r.supportDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // no-op after commit
+
+	// Lock both rows in id order so two admins merging the same pair in
+	// opposite directions can't deadlock.
+	rows, err := tx.QueryContext(ctx, `
+        SELECT id, ticket_number, subject, user_id,
+               COALESCE(user_email, ''), COALESCE(user_first_name, ''), COALESCE(user_last_name, ''),
+               status, created_at, merged_into_ticket_id
+        FROM support_tickets
+        WHERE id IN ($1, $2)
+        ORDER BY id
+        FOR UPDATE
+    `, in.SourceID, in.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	locked := map[uuid.UUID]*mergeTicketRow{}
+	for rows.Next() {
+		t := &mergeTicketRow{}
+		if err := rows.Scan(&t.id, &t.number, &t.subject, &t.userID,
+			&t.email, &t.firstName, &t.lastName,
+			&t.status, &t.createdAt, &t.mergedInto); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		locked[t.id] = t
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	src, tgt := locked[in.SourceID], locked[in.TargetID]
+	if src == nil || tgt == nil || src == tgt {
+		return nil, sql.ErrNoRows
+	}
+	if src.mergedInto.Valid || tgt.mergedInto.Valid {
+		return nil, ErrTicketMergeConflict
+	}
+
+	// The source may already have absorbed earlier tickets; carry the
+	// earliest report forward so the target's resolution time covers it.
+	var reportedAt time.Time
+	if err := tx.QueryRowContext(ctx, `
+        SELECT LEAST($2::timestamptz, MIN(reported_at)) FROM ticket_merges WHERE target_ticket_id = $1
+    `, src.id, src.createdAt).Scan(&reportedAt); err != nil {
+		return nil, err
+	}
+
+	moved := 0
+	if in.OpeningMessage != "" {
+		var sender interface{}
+		if src.userID.Valid {
+			sender = src.userID.UUID
+		}
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO ticket_messages (id, ticket_id, sender_id, message, is_internal, created_at, sender_email, sender_first_name, sender_last_name)
+            VALUES ($1, $2, $3, $4, false, $5, $6, $7, $8)
+        `, uuid.New(), tgt.id, sender, in.OpeningMessage, src.createdAt, src.email, src.firstName, src.lastName); err != nil {
+			return nil, err
+		}
+		moved++
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE ticket_messages SET ticket_id = $2 WHERE ticket_id = $1`, src.id, tgt.id)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := res.RowsAffected()
+	moved += int(n)
+
+	res, err = tx.ExecContext(ctx, `UPDATE ticket_attachments SET ticket_id = $2 WHERE ticket_id = $1`, src.id, tgt.id)
+	if err != nil {
+		return nil, err
+	}
+	attachments, _ := res.RowsAffected()
+
+	// Tags are unioned; the source keeps its own so its history reads right.
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO ticket_tags (ticket_id, tag, created_by, created_at)
+        SELECT $2, tag, created_by, created_at FROM ticket_tags WHERE ticket_id = $1
+        ON CONFLICT (ticket_id, tag) DO NOTHING
+    `, src.id, tgt.id); err != nil {
+		return nil, err
+	}
+
+	// Other users' duplicates of the source, and tickets merged into it
+	// earlier, now point at the target — redirects stay one hop.
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE support_tickets SET duplicate_of_ticket_id = $2, updated_at = NOW() WHERE duplicate_of_ticket_id = $1
+    `, src.id, tgt.id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE support_tickets SET merged_into_ticket_id = $2 WHERE merged_into_ticket_id = $1
+    `, src.id, tgt.id); err != nil {
+		return nil, err
+	}
+	// Their merge records follow, so the target's history lists them too.
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE ticket_merges SET target_ticket_id = $2 WHERE target_ticket_id = $1
+    `, src.id, tgt.id); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE support_tickets
+        SET merged_into_ticket_id = $2, merged_at = NOW(), status = 'closed', updated_at = NOW()
+        WHERE id = $1
+    `, src.id, tgt.id); err != nil {
+		return nil, err
+	}
+
+	// An open issue merged into a finished ticket reopens it; otherwise the
+	// user's open question would land on a closed thread.
+	srcOpen := src.status != "resolved" && src.status != "closed"
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE support_tickets
+        SET status      = CASE WHEN $2 AND status IN ('resolved', 'closed') THEN 'open' ELSE status END,
+            resolved_at = CASE WHEN $2 AND status IN ('resolved', 'closed') THEN NULL ELSE resolved_at END,
+            reopened_at = CASE WHEN $2 AND status IN ('resolved', 'closed') THEN NOW() ELSE reopened_at END,
+            updated_at  = NOW()
+        WHERE id = $1
+    `, tgt.id, srcOpen); err != nil {
+		return nil, err
+	}
+
+	var actor interface{}
+	if in.ActorID != uuid.Nil {
+		actor = in.ActorID
+	}
+	m := &TicketMerge{
+		SourceTicketID:   src.id,
+		TargetTicketID:   tgt.id,
+		SourceNumber:     src.number,
+		SourceSubject:    src.subject,
+		ReportedAt:       reportedAt,
+		MessagesMoved:    moved,
+		AttachmentsMoved: int(attachments),
+		MergedByEmail:    in.ActorEmail,
+	}
+	if in.ActorID != uuid.Nil {
+		m.MergedBy = models.NullUUID{UUID: in.ActorID, Valid: true}
+	}
+	if in.Notes != nil {
+		internal, user := in.Notes(m)
+		// clock_timestamp keeps the internal note ahead of the user's.
+		for _, note := range []struct {
+			text       string
+			isInternal bool
+		}{{internal, true}, {user, false}} {
+			if note.text == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+                INSERT INTO ticket_messages (id, ticket_id, sender_id, message, is_internal, created_at, sender_email)
+                VALUES ($1, $2, $3, $4, $5, clock_timestamp(), NULLIF($6, ''))
+            `, uuid.New(), tgt.id, actor, note.text, note.isInternal, in.ActorEmail); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO ticket_merges (source_ticket_id, target_ticket_id, source_number, source_subject, reported_at,
+                                   messages_moved, attachments_moved, merged_by, merged_by_email)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
+        RETURNING id, merged_at
+    `, m.SourceTicketID, m.TargetTicketID, m.SourceNumber, m.SourceSubject, m.ReportedAt,
+		m.MessagesMoved, m.AttachmentsMoved, actor, m.MergedByEmail).Scan(&m.ID, &m.MergedAt); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *ticketMergeRepo) ListForTarget(ctx context.Context, targetID uuid.UUID) ([]TicketMerge, error) {
+	rows, err := r.supportDB.QueryContext(ctx, `
+        SELECT id, source_ticket_id, target_ticket_id, source_number, source_subject, reported_at,
+               messages_moved, attachments_moved, merged_by, COALESCE(merged_by_email, ''), merged_at
+        FROM ticket_merges
+        WHERE target_ticket_id = $1
+        ORDER BY merged_at ASC
+    `, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []TicketMerge{}
+	for rows.Next() {
+		var m TicketMerge
+		if err := rows.Scan(&m.ID, &m.SourceTicketID, &m.TargetTicketID, &m.SourceNumber, &m.SourceSubject, &m.ReportedAt,
+			&m.MessagesMoved, &m.AttachmentsMoved, &m.MergedBy, &m.MergedByEmail, &m.MergedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (r *ticketMergeRepo) ListCandidates(ctx context.Context, userID, excludeID uuid.UUID, from, to time.Time, limit int) ([]TicketMergeCandidate, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := r.supportDB.QueryContext(ctx, `
+        SELECT id, ticket_number, subject, status, type, created_at
+        FROM support_tickets
+        WHERE user_id = $1 AND id <> $2
+          AND merged_into_ticket_id IS NULL
+          AND duplicate_of_ticket_id IS NULL AND duplicate_of_roadmap_id IS NULL
+          AND created_at >= $3 AND created_at < $4
+        ORDER BY created_at DESC
+        LIMIT $5
+    `, userID, excludeID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TicketMergeCandidate
+	for rows.Next() {
+		var c TicketMergeCandidate
+		if err := rows.Scan(&c.ID, &c.Number, &c.Subject, &c.Status, &c.Type, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+)
+
+// TicketCategory is one node of the support ticket taxonomy. ParentID is set
+// for sub-categories; the tree is intentionally shallow (one level).
+type TicketCategory struct {
+	ID          uuid.UUID       `json:"id"`
+	Slug        string          `json:"slug"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	ParentID    models.NullUUID `json:"parent_id,omitempty"`
+	SortOrder   int             `json:"sort_order"`
+	IsActive    bool            `json:"is_active"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	// Joined
+	TicketCount int `json:"ticket_count"`
+}
+
+// TicketCategoryBucket is one (period, category) cell of the
+// tickets-by-category-over-time report. Uncategorized tickets are reported
+// under an empty CategorySlug.
+type TicketCategoryBucket struct {
+	Period       time.Time `json:"period"`
+	CategorySlug string    `json:"category_slug"`
+	CategoryName string    `json:"category_name"`
+	TicketCount  int       `json:"ticket_count"`
+}
+
+// TicketCategoryResolution is the per-category resolution-time aggregate.
+// Only tickets with a resolved_at inside the window contribute. Tickets
+// merged into another count once, as the ticket they were merged into:
+// its resolution time runs from the earliest report folded into it, and
+// MergedCount says how many extra tickets that covered.
+type TicketCategoryResolution struct {
+	CategorySlug       string  `json:"category_slug"`
+	CategoryName       string  `json:"category_name"`
+	ResolvedCount      int     `json:"resolved_count"`
+	AvgResolutionHours float64 `json:"avg_resolution_hours"`
+	P50ResolutionHours float64 `json:"p50_resolution_hours"`
+	OpenCount          int     `json:"open_count"`
+	MergedCount        int     `json:"merged_count"`
+}
+
+// TicketTagCount is a tag and how many tickets carry it in the window.
+type TicketTagCount struct {
+	Tag         string `json:"tag"`
+	TicketCount int    `json:"ticket_count"`
+}
+
+// TicketTaxonomyRepository owns ticket_categories, ticket_tags and the
+// category_id column on support_tickets, plus the reporting aggregates over
+// them. All tables live next to support_tickets, so every query routes to
+// the support pool.
+type TicketTaxonomyRepository interface {
+	ListCategories(ctx context.Context, includeInactive bool) ([]TicketCategory, error)
+	GetCategory(ctx context.Context, id uuid.UUID) (*TicketCategory, error)
+	CreateCategory(ctx context.Context, c *TicketCategory) error
+	UpdateCategory(ctx context.Context, c *TicketCategory) error
+	DeleteCategory(ctx context.Context, id uuid.UUID) error
+
+	SetTicketCategory(ctx context.Context, ticketID uuid.UUID, categoryID *uuid.UUID) error
+	// ReplaceTicketTags swaps the full tag set for a ticket in one tx.
+	ReplaceTicketTags(ctx context.Context, ticketID uuid.UUID, tags []string, actorID uuid.UUID) error
+	ListTags(ctx context.Context, prefix string, limit int) ([]TicketTagCount, error)
+
+	// Reporting. interval is a date_trunc unit (day/week/month) — callers
+	// must validate it; it's interpolated into SQL. Tickets merged into
+	// another (migration 00112) are left out so one issue counts once.
+	CountByCategoryOverTime(ctx context.Context, from, to time.Time, interval string) ([]TicketCategoryBucket, error)
+	ResolutionByCategory(ctx context.Context, from, to time.Time) ([]TicketCategoryResolution, error)
+	TopTags(ctx context.Context, from, to time.Time, limit int) ([]TicketTagCount, error)
+}
+
+type ticketTaxonomyRepo struct {
+	supportDB *DB
+}
+
+// NewTicketTaxonomyRepo creates a TicketTaxonomyRepository on the support pool.
+func NewTicketTaxonomyRepo(supportDB *sql.DB) TicketTaxonomyRepository {
+	return &ticketTaxonomyRepo{supportDB: WrapDB(supportDB)}
+}
+
+const ticketCategoryCols = `
+    c.id, c.slug, c.name, c.description, c.parent_id, c.sort_order, c.is_active,
+    c.created_at, c.updated_at,
+    (SELECT COUNT(*) FROM support_tickets t WHERE t.category_id = c.id) AS ticket_count
+`
+
+func scanTicketCategory(s rowScannerLike) (*TicketCategory, error) {
+	c := &TicketCategory{}
+	if err := s.Scan(&c.ID, &c.Slug, &c.Name, &c.Description, &c.ParentID, &c.SortOrder, &c.IsActive,
+		&c.CreatedAt, &c.UpdatedAt, &c.TicketCount); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *ticketTaxonomyRepo) ListCategories(ctx context.Context, includeInactive bool) ([]TicketCategory, error) {
+	q := "SELECT " + ticketCategoryCols + " FROM ticket_categories c"
+	if !includeInactive {
+		q += " WHERE c.is_active = TRUE"
+	}
+	q += " ORDER BY c.sort_order ASC, c.name ASC"
+	rows, err := r.supportDB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TicketCategory
+	for rows.Next() {
+		c, err := scanTicketCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *ticketTaxonomyRepo) GetCategory(ctx context.Context, id uuid.UUID) (*TicketCategory, error) {
+	c, err := scanTicketCategory(r.supportDB.QueryRowContext(ctx,
+		"SELECT "+ticketCategoryCols+" FROM ticket_categories c WHERE c.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *ticketTaxonomyRepo) CreateCategory(ctx context.Context, c *TicketCategory) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	now := time.Now()
+	c.CreatedAt, c.UpdatedAt = now, now
+	_, err := r.supportDB.ExecContext(ctx, `
+        INSERT INTO ticket_categories (id, slug, name, description, parent_id, sort_order, is_active, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+    `, c.ID, c.Slug, c.Name, c.Description, c.ParentID, c.SortOrder, c.IsActive, now)
+	return err
+}
+
+func (r *ticketTaxonomyRepo) UpdateCategory(ctx context.Context, c *TicketCategory) error {
+	c.UpdatedAt = time.Now()
+	_, err := r.supportDB.ExecContext(ctx, `
+        UPDATE ticket_categories
+        SET name = $2, description = $3, parent_id = $4, sort_order = $5, is_active = $6, updated_at = $7
+        WHERE id = $1
+    `, c.ID, c.Name, c.Description, c.ParentID, c.SortOrder, c.IsActive, c.UpdatedAt)
+	return err
+}
+
+// DeleteCategory removes a category. Tickets pointing at it fall back to
+// uncategorized and children become top-level (both via ON DELETE SET NULL).
+func (r *ticketTaxonomyRepo) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	_, err := r.supportDB.ExecContext(ctx, "DELETE FROM ticket_categories WHERE id = $1", id)
+	return err
+}
+
+func (r *ticketTaxonomyRepo) SetTicketCategory(ctx context.Context, ticketID uuid.UUID, categoryID *uuid.UUID) error {
+	_, err := r.supportDB.ExecContext(ctx,
+		`UPDATE support_tickets SET category_id = $2, updated_at = NOW() WHERE id = $1`,
+		ticketID, categoryID)
+	return err
+}
+
+func (r *ticketTaxonomyRepo) ReplaceTicketTags(ctx context.Context, ticketID uuid.UUID, tags []string, actorID uuid.UUID) error {
+	tx, err := r.supportDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op after commit
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM ticket_tags WHERE ticket_id = $1 AND NOT (tag = ANY($2))`,
+		ticketID, pq.Array(tags)); err != nil {
+		return err
+	}
+	var actor interface{}
+	if actorID != uuid.Nil {
+		actor = actorID
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO ticket_tags (ticket_id, tag, created_by, created_at)
+            VALUES ($1, $2, $3, NOW())
+            ON CONFLICT (ticket_id, tag) DO NOTHING
+        `, ticketID, tag, actor); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE support_tickets SET updated_at = NOW() WHERE id = $1`, ticketID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListTags returns known tags (optionally prefix-matched) by popularity.
+// Drives the tag autocomplete in the ticket detail view.
+func (r *ticketTaxonomyRepo) ListTags(ctx context.Context, prefix string, limit int) ([]TicketTagCount, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 25
+	}
+	rows, err := r.supportDB.QueryContext(ctx, `
+        SELECT tag, COUNT(*) AS n
+        FROM ticket_tags
+        WHERE $1 = '' OR tag LIKE $1 || '%'
+        GROUP BY tag
+        ORDER BY n DESC, tag ASC
+        LIMIT $2
+    `, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTagCounts(rows)
+}
+
+func (r *ticketTaxonomyRepo) CountByCategoryOverTime(ctx context.Context, from, to time.Time, interval string) ([]TicketCategoryBucket, error) {
+	rows, err := r.supportDB.QueryContext(ctx, `
+        SELECT date_trunc('`+interval+`', t.created_at) AS period,
+               COALESCE(c.slug, '') AS slug,
+               COALESCE(c.name, 'Uncategorized') AS name,
+               COUNT(*) AS n
+        FROM support_tickets t
+        LEFT JOIN ticket_categories c ON t.category_id = c.id
+        WHERE t.created_at >= $1 AND t.created_at < $2
+          AND t.merged_into_ticket_id IS NULL
+        GROUP BY period, slug, name
+        ORDER BY period ASC, n DESC
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TicketCategoryBucket
+	for rows.Next() {
+		var b TicketCategoryBucket
+		if err := rows.Scan(&b.Period, &b.CategorySlug, &b.CategoryName, &b.TicketCount); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *ticketTaxonomyRepo) ResolutionByCategory(ctx context.Context, from, to time.Time) ([]TicketCategoryResolution, error) {
+	// g folds each ticket's merges in: the clock starts at the earliest
+	// report (LEAST ignores the NULL when nothing was merged).
+	rows, err := r.supportDB.QueryContext(ctx, `
+        SELECT COALESCE(c.slug, '') AS slug,
+               COALESCE(c.name, 'Uncategorized') AS name,
+               COUNT(*) FILTER (WHERE t.resolved_at IS NOT NULL) AS resolved,
+               COALESCE(AVG(EXTRACT(EPOCH FROM (t.resolved_at - LEAST(t.created_at, g.first_reported))) / 3600.0)
+                        FILTER (WHERE t.resolved_at IS NOT NULL), 0) AS avg_hours,
+               COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM (t.resolved_at - LEAST(t.created_at, g.first_reported))) / 3600.0)
+                        FILTER (WHERE t.resolved_at IS NOT NULL), 0) AS p50_hours,
+               COUNT(*) FILTER (WHERE t.status NOT IN ('resolved', 'closed')) AS open_count,
+               COALESCE(SUM(g.merged), 0)::int AS merged_count
+        FROM support_tickets t
+        LEFT JOIN ticket_categories c ON t.category_id = c.id
+        LEFT JOIN LATERAL (
+            SELECT COUNT(*) AS merged, MIN(m.reported_at) AS first_reported
+            FROM ticket_merges m
+            WHERE m.target_ticket_id = t.id
+        ) g ON true
+        WHERE t.created_at >= $1 AND t.created_at < $2
+          AND t.merged_into_ticket_id IS NULL
+        GROUP BY slug, name
+        ORDER BY resolved DESC, name ASC
+    `, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TicketCategoryResolution
+	for rows.Next() {
+		var c TicketCategoryResolution
+		if err := rows.Scan(&c.CategorySlug, &c.CategoryName, &c.ResolvedCount,
+			&c.AvgResolutionHours, &c.P50ResolutionHours, &c.OpenCount, &c.MergedCount); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *ticketTaxonomyRepo) TopTags(ctx context.Context, from, to time.Time, limit int) ([]TicketTagCount, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	rows, err := r.supportDB.QueryContext(ctx, `
+        SELECT tt.tag, COUNT(*) AS n
+        FROM ticket_tags tt
+        JOIN support_tickets t ON t.id = tt.ticket_id
+        WHERE t.created_at >= $1 AND t.created_at < $2
+          AND t.merged_into_ticket_id IS NULL
+        GROUP BY tt.tag
+        ORDER BY n DESC, tt.tag ASC
+        LIMIT $3
+    `, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTagCounts(rows)
+}
+
+func scanTagCounts(rows *Rows) ([]TicketTagCount, error) {
+	var out []TicketTagCount
+	for rows.Next() {
+		var tc TicketTagCount
+		if err := rows.Scan(&tc.Tag, &tc.TicketCount); err != nil {
+			return nil, err
+		}
+		out = append(out, tc)
+	}
+	return out, rows.Err()
+}
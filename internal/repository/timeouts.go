@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// TimeoutPolicy bounds how long statements and service operations may
+// run. Set once at startup with SetTimeoutPolicy.
+type TimeoutPolicy struct {
+	// Request caps each statement run on behalf of an HTTP request. Jobs
+	// and other background work aren't capped by it.
+	Request time.Duration
+	// Methods caps statements by repository method, as named in the query
+	// tags ("childRepo.GetDashboard"), or every method of a repository
+	// ("reportRepo.*"). They apply to background work too, and win over
+	// Request.
+	Methods map[string]time.Duration
+	// Operations are budgets for service operations spanning several
+	// statements ("dashboard"), applied with OperationContext.
+	Operations map[string]time.Duration
+}
+
+var timeouts TimeoutPolicy
+
+// SetTimeoutPolicy replaces the timeout policy. Not safe to call once
+// repositories are serving queries.
+func SetTimeoutPolicy(p TimeoutPolicy) {
+	timeouts = p
+}
+
+// TimeoutObserver hears about statements that ran out of time, whether
+// their own limit or their operation's budget, so timeouts show up as
+// infrastructure errors rather than only as slow requests.
+type TimeoutObserver interface {
+	StatementTimedOut(ctx context.Context, t StatementTimeout)
+}
+
+// StatementTimeout describes one timed-out statement.
+type StatementTimeout struct {
+	Method string
+	// Operation is the OperationContext name, if any.
+	Operation string
+	// Limit is the statement's own limit; zero when it had none and the
+	// deadline came from the caller.
+	Limit time.Duration
+}
+
+var timeoutObserver TimeoutObserver
+
+// SetTimeoutObserver installs the observer. Not safe to call once
+// repositories are serving queries.
+func SetTimeoutObserver(o TimeoutObserver) {
+	timeoutObserver = o
+}
+
+type operationKey struct{}
+
+// OperationContext gives a service operation its budget from the policy:
+// ctx is cancelled once the operation's statements have run that long in
+// total, however many there are. An operation with no budget only gets the
+// cancel. Call cancel when the operation returns.
+func OperationContext(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, operationKey{}, operation)
+	if d := timeouts.Operations[operation]; d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// forMethod is the statement limit for method run under ctx.
+func (p TimeoutPolicy) forMethod(ctx context.Context, method string) time.Duration {
+	if d, ok := p.Methods[method]; ok {
+		return d
+	}
+	if repo, _, ok := strings.Cut(method, "."); ok {
+		if d, ok := p.Methods[repo+".*"]; ok {
+			return d
+		}
+	}
+	if p.Request > 0 && chimiddleware.GetReqID(ctx) != "" {
+		return p.Request
+	}
+	return 0
+}
+
+// statement is one tagged, time-limited statement. newStatement has to be
+// called straight from a DB or Tx method, like tagQuery, for callerMethod
+// to find the repository method.
+type statement struct {
+	ctx    context.Context
+	parent context.Context
+	query  string
+	method string
+	limit  time.Duration
+	// cancel releases the statement's timer. Exec calls it on return;
+	// Query and QueryRow hand it to their Rows or Row, which call it once
+	// the rows are closed or the row scanned.
+	cancel context.CancelFunc
+}
+
+func newStatement(ctx context.Context, query string) *statement {
+	st := &statement{ctx: ctx, parent: ctx, query: query, cancel: func() {}}
+	if queryTags.Enabled || len(timeouts.Methods) > 0 || timeoutObserver != nil {
+		st.method = callerMethod()
+	}
+	st.query = tagWith(ctx, st.method, query)
+	if st.limit = timeouts.forMethod(ctx, st.method); st.limit > 0 {
+		st.ctx, st.cancel = context.WithTimeout(ctx, st.limit)
+	}
+	return st
+}
+
+// done reports a timeout to the observer and passes err through.
+func (st *statement) done(err error) error {
+	if err == nil || timeoutObserver == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	t := StatementTimeout{Method: st.method}
+	t.Operation, _ = st.parent.Value(operationKey{}).(string)
+	if st.parent.Err() == nil {
+		t.Limit = st.limit
+	}
+	timeoutObserver.StatementTimedOut(st.parent, t)
+	return err
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// timeoutProbe stands in for a repository, like tagProbe; stmt plays the
+// DB method frame.
+type timeoutProbe struct{}
+
+func stmt(ctx context.Context) *statement { return newStatement(ctx, "SELECT 1") }
+
+func (timeoutProbe) GetThing(ctx context.Context) *statement    { return stmt(ctx) }
+func (timeoutProbe) BuildReport(ctx context.Context) *statement { return stmt(ctx) }
+
+type timeoutRecorder []StatementTimeout
+
+func (r *timeoutRecorder) StatementTimedOut(_ context.Context, t StatementTimeout) {
+	*r = append(*r, t)
+}
+
+func setTimeouts(t *testing.T, p TimeoutPolicy, o TimeoutObserver) {
+	prevPolicy, prevObserver := timeouts, timeoutObserver
+	SetTimeoutPolicy(p)
+	SetTimeoutObserver(o)
+	t.Cleanup(func() {
+		SetTimeoutPolicy(prevPolicy)
+		SetTimeoutObserver(prevObserver)
+	})
+}
+
+func TestStatementLimits(t *testing.T) {
+	setTimeouts(t, TimeoutPolicy{
+		Request: 15 * time.Second,
+		Methods: map[string]time.Duration{
+			"timeoutProbe.*":           2 * time.Second,
+			"timeoutProbe.BuildReport": time.Minute,
+		},
+	}, nil)
+	var p timeoutProbe
+	bg := context.Background()
+	req := context.WithValue(bg, chimiddleware.RequestIDKey, "req-1")
+
+	for _, tc := range []struct {
+		name string
+		st   *statement
+		want time.Duration
+	}{
+		{"method", p.BuildReport(bg), time.Minute},
+		{"repository", p.GetThing(bg), 2 * time.Second},
+		{"request method", p.BuildReport(req), time.Minute},
+	} {
+		if tc.st.limit != tc.want {
+			t.Errorf("%s: limit %s, want %s", tc.name, tc.st.limit, tc.want)
+		}
+		if dl, ok := tc.st.ctx.Deadline(); !ok || time.Until(dl) > tc.want {
+			t.Errorf("%s: deadline %v, %v", tc.name, dl, ok)
+		}
+		tc.st.cancel()
+	}
+
+	SetTimeoutPolicy(TimeoutPolicy{Request: 15 * time.Second})
+	if st := p.GetThing(req); st.limit != 15*time.Second {
+		t.Errorf("request: limit %s", st.limit)
+	}
+	if st := p.GetThing(bg); st.limit != 0 || st.ctx != bg {
+		t.Errorf("background work got limit %s", st.limit)
+	}
+}
+
+func TestStatementTimeoutObserved(t *testing.T) {
+	var seen timeoutRecorder
+	setTimeouts(t, TimeoutPolicy{
+		Methods:    map[string]time.Duration{"timeoutProbe.GetThing": time.Millisecond},
+		Operations: map[string]time.Duration{"dashboard": time.Millisecond},
+	}, &seen)
+	var p timeoutProbe
+
+	st := p.GetThing(context.Background())
+	<-st.ctx.Done()
+	if err := st.done(st.ctx.Err()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("done = %v", err)
+	}
+	st.done(errors.New("syntax error"))
+
+	ctx, cancel := OperationContext(context.Background(), "dashboard")
+	defer cancel()
+	<-ctx.Done()
+	st = p.BuildReport(ctx)
+	st.done(ctx.Err())
+
+	want := timeoutRecorder{
+		{Method: "timeoutProbe.GetThing", Limit: time.Millisecond},
+		{Method: "timeoutProbe.BuildReport", Operation: "dashboard"},
+	}
+	if len(seen) != len(want) || seen[0] != want[0] || seen[1] != want[1] {
+		t.Errorf("observed %+v, want %+v", seen, want)
+	}
+}
+
+func TestOperationContextWithoutBudget(t *testing.T) {
+	setTimeouts(t, TimeoutPolicy{}, nil)
+	ctx, cancel := OperationContext(context.Background(), "search")
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("operation without a budget got a deadline")
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("cancel didn't cancel")
+	}
+}
+
+func TestRowReleasesTimer(t *testing.T) {
+	calls := 0
+	row := &Row{err: errors.New("no connection"), cancel: func() { calls++ }}
+	if err := row.Scan(); err == nil {
+		t.Error("Scan lost the error")
+	}
+	row.Scan()
+	if calls != 1 {
+		t.Errorf("cancel called %d times, want 1", calls)
+	}
+}
@@ -11,11 +11,11 @@ import (
 )
 
 type TransparencyRepository struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewTransparencyRepository(db *sql.DB) *TransparencyRepository {
-	return &TransparencyRepository{db: db}
+	return &TransparencyRepository{db: WrapDB(db)}
 }
 
 // ============================================================================
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// Antivirus verdicts.
+const (
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected"
+	ScanStatusError    = "error"
+	ScanStatusSkipped  = "skipped"
+)
+
+// Review outcomes for infected uploads.
+const (
+	ScanReviewReleased = "released"
+	ScanReviewDeleted  = "deleted"
+)
+
+// UploadScan is the recorded verdict for one uploaded file. Infected rows
+// with an empty ReviewStatus make up the quarantine review queue.
+type UploadScan struct {
+	ID               uuid.UUID       `json:"id"`
+	Source           string          `json:"source"`
+	Filename         string          `json:"filename"`
+	ContentType      string          `json:"content_type"`
+	SizeBytes        int64           `json:"size_bytes"`
+	SHA256           string          `json:"sha256,omitempty"`
+	Status           string          `json:"status"`
+	Signature        string          `json:"signature,omitempty"`
+	Engine           string          `json:"engine,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	UploadedBy       models.NullUUID `json:"uploaded_by,omitempty"`
+	QuarantineDriver string          `json:"-"`
+	QuarantinePath   string          `json:"-"`
+	HasQuarantined   bool            `json:"has_quarantined_file"`
+	ReviewStatus     string          `json:"review_status,omitempty"`
+	ReviewedBy       models.NullUUID `json:"reviewed_by,omitempty"`
+	ReviewedByEmail  string          `json:"reviewed_by_email,omitempty"`
+	ReviewedAt       *time.Time      `json:"reviewed_at,omitempty"`
+	ReviewNote       string          `json:"review_note,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// UploadScanFilter narrows List. Queue selects infected rows not yet
+// reviewed and ignores Status.
+type UploadScanFilter struct {
+	Queue  bool
+	Status string
+	Source string
+	Limit  int
+}
+
+// UploadScanRepository owns upload_scans. Quarantined bytes live in
+// BlobStorage; the service keeps the two in step.
+type UploadScanRepository interface {
+	Create(ctx context.Context, s *UploadScan) error
+	GetByID(ctx context.Context, id uuid.UUID) (*UploadScan, error)
+	List(ctx context.Context, f UploadScanFilter) ([]UploadScan, error)
+	// CountByStatus tallies verdicts recorded since the given time, plus
+	// the open queue under the "queue" key.
+	CountByStatus(ctx context.Context, since time.Time) (map[string]int, error)
+	// IsReleased reports whether an infected upload with this digest was
+	// marked a false positive.
+	IsReleased(ctx context.Context, sha256 string) (bool, error)
+	// Review closes an infected, unreviewed row with the given outcome and
+	// clears its quarantine path. It reports false if the row was already
+	// reviewed (or is not infected).
+	Review(ctx context.Context, id uuid.UUID, outcome string, by uuid.UUID, note string) (bool, error)
+}
+
+type uploadScanRepo struct {
+	db *DB
+}
+
+// NewUploadScanRepo creates an UploadScanRepository on the main pool.
+func NewUploadScanRepo(db *sql.DB) UploadScanRepository {
+	return &uploadScanRepo{db: WrapDB(db)}
+}
+
+const uploadScanCols = `
+    us.id, us.source, us.filename, us.content_type, us.size_bytes, COALESCE(us.sha256, ''), us.status,
+    us.signature, us.engine, us.error, us.uploaded_by, us.quarantine_driver, us.quarantine_path,
+    us.review_status, us.reviewed_by, COALESCE(au.email, ''), us.reviewed_at, us.review_note, us.created_at`
+
+const uploadScanFrom = `
+    FROM upload_scans us
+    LEFT JOIN admin_users au ON au.id = us.reviewed_by`
+
+func scanUploadScan(s rowScannerLike) (*UploadScan, error) {
+	u := &UploadScan{}
+	var reviewedAt sql.NullTime
+	err := s.Scan(&u.ID, &u.Source, &u.Filename, &u.ContentType, &u.SizeBytes, &u.SHA256, &u.Status,
+		&u.Signature, &u.Engine, &u.Error, &u.UploadedBy, &u.QuarantineDriver, &u.QuarantinePath,
+		&u.ReviewStatus, &u.ReviewedBy, &u.ReviewedByEmail, &reviewedAt, &u.ReviewNote, &u.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedAt.Valid {
+		u.ReviewedAt = &reviewedAt.Time
+	}
+	u.HasQuarantined = u.QuarantinePath != ""
+	return u, nil
+}
+
+func (r *uploadScanRepo) Create(ctx context.Context, s *UploadScan) error {
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO upload_scans
+            (source, filename, content_type, size_bytes, sha256, status, signature, engine, error,
+             uploaded_by, quarantine_driver, quarantine_path, review_status, review_note)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        RETURNING id, created_at
+    `, s.Source, s.Filename, s.ContentType, s.SizeBytes, s.SHA256, s.Status, s.Signature, s.Engine, s.Error,
+		s.UploadedBy, s.QuarantineDriver, s.QuarantinePath, s.ReviewStatus, s.ReviewNote,
+	).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *uploadScanRepo) GetByID(ctx context.Context, id uuid.UUID) (*UploadScan, error) {
+	s, err := scanUploadScan(r.db.QueryRowContext(ctx,
+		"SELECT "+uploadScanCols+uploadScanFrom+" WHERE us.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (r *uploadScanRepo) List(ctx context.Context, f UploadScanFilter) ([]UploadScan, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+	q := "SELECT " + uploadScanCols + uploadScanFrom + " WHERE 1=1"
+	args := []interface{}{}
+	if f.Queue {
+		q += " AND us.status = 'infected' AND us.review_status = ''"
+	} else if f.Status != "" {
+		args = append(args, f.Status)
+		q += " AND us.status = $1"
+	}
+	if f.Source != "" {
+		args = append(args, f.Source)
+		q += " AND us.source = $" + itoa(len(args))
+	}
+	args = append(args, limit)
+	q += " ORDER BY us.created_at DESC LIMIT $" + itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []UploadScan
+	for rows.Next() {
+		s, err := scanUploadScan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+func (r *uploadScanRepo) CountByStatus(ctx context.Context, since time.Time) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT status, COUNT(*) FROM upload_scans WHERE created_at >= $1 GROUP BY status
+        UNION ALL
+        SELECT 'queue', COUNT(*) FROM upload_scans WHERE status = 'infected' AND review_status = ''
+    `, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
+
+func (r *uploadScanRepo) IsReleased(ctx context.Context, sha256 string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `
+        SELECT EXISTS (SELECT 1 FROM upload_scans WHERE sha256 = $1 AND review_status = 'released')
+    `, sha256).Scan(&ok)
+	return ok, err
+}
+
+func (r *uploadScanRepo) Review(ctx context.Context, id uuid.UUID, outcome string, by uuid.UUID, note string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE upload_scans
+           SET review_status = $2, reviewed_by = $3, reviewed_at = NOW(), review_note = $4,
+               quarantine_driver = '', quarantine_path = ''
+         WHERE id = $1 AND status = 'infected' AND review_status = ''
+    `, id, outcome, by, note)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
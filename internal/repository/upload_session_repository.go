@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// Upload session statuses.
+const (
+	UploadStatusUploading  = "uploading"
+	UploadStatusAssembling = "assembling"
+	UploadStatusComplete   = "complete"
+	UploadStatusFailed     = "failed"
+)
+
+// UploadSession is one resumable upload. HashState is the marshaled
+// SHA-256 over the bytes received so far and never leaves the server.
+type UploadSession struct {
+	ID             uuid.UUID         `json:"id"`
+	Purpose        string            `json:"purpose"`
+	Filename       string            `json:"filename"`
+	ContentType    string            `json:"content_type"`
+	TotalBytes     int64             `json:"total_bytes"`
+	ReceivedBytes  int64             `json:"received_bytes"`
+	ExpectedSHA256 string            `json:"expected_sha256,omitempty"`
+	HashState      []byte            `json:"-"`
+	Metadata       map[string]string `json:"metadata"`
+	Status         string            `json:"status"`
+	ResultID       models.NullUUID   `json:"result_id,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	CreatedBy      models.NullUUID   `json:"created_by,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	ExpiresAt      time.Time         `json:"expires_at"`
+}
+
+// UploadChunk is one stored piece of an upload, keyed by its byte offset.
+type UploadChunk struct {
+	Offset      int64
+	SizeBytes   int64
+	StoragePath string
+}
+
+// UploadSessionRepository owns upload_sessions and upload_chunks. The
+// chunk bytes live in BlobStorage; the service keeps the two in step.
+type UploadSessionRepository interface {
+	Create(ctx context.Context, s *UploadSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*UploadSession, error)
+	// AppendChunk records a chunk and advances received_bytes, but only if
+	// the session is still uploading at exactly chunk.Offset. It reports
+	// false when another request got there first.
+	AppendChunk(ctx context.Context, id uuid.UUID, chunk UploadChunk, hashState []byte, expiresAt time.Time) (bool, error)
+	// SetStatus moves a session from one status to another, reporting false
+	// if it was no longer in `from`.
+	SetStatus(ctx context.Context, id uuid.UUID, from, to string, resultID uuid.UUID, errMsg string, expiresAt time.Time) (bool, error)
+	ListChunks(ctx context.Context, id uuid.UUID) ([]UploadChunk, error)
+	DeleteChunks(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]UploadSession, error)
+}
+
+type uploadSessionRepo struct {
+	db *DB
+}
+
+// NewUploadSessionRepo creates an UploadSessionRepository on the main pool.
+func NewUploadSessionRepo(db *sql.DB) UploadSessionRepository {
+	return &uploadSessionRepo{db: WrapDB(db)}
+}
+
+const uploadSessionCols = `
+    id, purpose, filename, content_type, total_bytes, received_bytes, COALESCE(expected_sha256, ''),
+    hash_state, metadata, status, result_id, error, created_by, created_at, updated_at, expires_at`
+
+func scanUploadSession(s rowScannerLike) (*UploadSession, error) {
+	u := &UploadSession{}
+	var meta []byte
+	err := s.Scan(&u.ID, &u.Purpose, &u.Filename, &u.ContentType, &u.TotalBytes, &u.ReceivedBytes, &u.ExpectedSHA256,
+		&u.HashState, &meta, &u.Status, &u.ResultID, &u.Error, &u.CreatedBy, &u.CreatedAt, &u.UpdatedAt, &u.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(meta, &u.Metadata); err != nil {
+		return nil, fmt.Errorf("upload session %s metadata: %w", u.ID, err)
+	}
+	return u, nil
+}
+
+func (r *uploadSessionRepo) Create(ctx context.Context, s *UploadSession) error {
+	meta, err := json.Marshal(s.Metadata)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO upload_sessions
+            (purpose, filename, content_type, total_bytes, expected_sha256, hash_state, metadata, created_by, expires_at)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+        RETURNING id, status, created_at, updated_at
+    `, s.Purpose, s.Filename, s.ContentType, s.TotalBytes, s.ExpectedSHA256, s.HashState, meta,
+		s.CreatedBy, s.ExpiresAt,
+	).Scan(&s.ID, &s.Status, &s.CreatedAt, &s.UpdatedAt)
+}
+
+func (r *uploadSessionRepo) GetByID(ctx context.Context, id uuid.UUID) (*UploadSession, error) {
+	s, err := scanUploadSession(r.db.QueryRowContext(ctx,
+		"SELECT "+uploadSessionCols+" FROM upload_sessions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (r *uploadSessionRepo) AppendChunk(ctx context.Context, id uuid.UUID, chunk UploadChunk, hashState []byte, expiresAt time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE upload_sessions
+           SET received_bytes = received_bytes + $3, hash_state = $4, expires_at = $5, updated_at = NOW()
+         WHERE id = $1 AND received_bytes = $2 AND status = 'uploading'
+    `, id, chunk.Offset, chunk.SizeBytes, hashState, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO upload_chunks (session_id, offset_bytes, size_bytes, storage_path)
+        VALUES ($1, $2, $3, $4)
+    `, id, chunk.Offset, chunk.SizeBytes, chunk.StoragePath); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *uploadSessionRepo) SetStatus(ctx context.Context, id uuid.UUID, from, to string, resultID uuid.UUID, errMsg string, expiresAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE upload_sessions
+           SET status = $3, result_id = $4, error = $5, expires_at = $6, updated_at = NOW()
+         WHERE id = $1 AND status = $2
+    `, id, from, to, models.NullUUID{UUID: resultID, Valid: resultID != uuid.Nil}, errMsg, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *uploadSessionRepo) ListChunks(ctx context.Context, id uuid.UUID) ([]UploadChunk, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT offset_bytes, size_bytes, storage_path
+          FROM upload_chunks WHERE session_id = $1 ORDER BY offset_bytes
+    `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []UploadChunk
+	for rows.Next() {
+		var c UploadChunk
+		if err := rows.Scan(&c.Offset, &c.SizeBytes, &c.StoragePath); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *uploadSessionRepo) DeleteChunks(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM upload_chunks WHERE session_id = $1", id)
+	return err
+}
+
+func (r *uploadSessionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM upload_sessions WHERE id = $1", id)
+	return err
+}
+
+func (r *uploadSessionRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]UploadSession, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+uploadSessionCols+
+		" FROM upload_sessions WHERE expires_at <= $1 ORDER BY expires_at LIMIT $2", now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []UploadSession
+	for rows.Next() {
+		s, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
@@ -22,11 +22,11 @@ import (
 // — the unified view's GetByEmail can return 2 rows once an email exists in
 // both tables, which is exactly the scenario this migration enables.
 type userRepo struct {
-	db *sql.DB
+	db *DB
 }
 
 func NewUserRepo(db *sql.DB) UserRepository {
-	return &userRepo{db: db}
+	return &userRepo{db: WrapDB(db)}
 }
 
 // Create inserts a new APP user (parent / caregiver / doctor / etc.).
@@ -17,7 +17,8 @@ type UserSupportRepository interface {
 	// CreateTicket creates a new ticket for the current user
 	CreateTicket(ctx context.Context, userID uuid.UUID, subject, description, priority, ticketType string) (*SupportTicket, error)
 
-	// GetTickets gets all tickets for a user
+	// GetTickets gets all tickets for a user, leaving out ones merged into
+	// another of theirs
 	GetTickets(ctx context.Context, userID uuid.UUID) ([]SupportTicket, error)
 
 	// GetTicketByID gets a specific ticket (validates ownership)
@@ -26,8 +27,8 @@ type UserSupportRepository interface {
 	// GetTicketMessages gets non-internal messages for a ticket
 	GetTicketMessages(ctx context.Context, ticketID, userID uuid.UUID) ([]TicketMessage, error)
 
-	// AddMessage adds a message from the user
-	AddMessage(ctx context.Context, ticketID, userID uuid.UUID, message string) error
+	// AddMessage adds a message from the user and returns its ID
+	AddMessage(ctx context.Context, ticketID, userID uuid.UUID, message string) (uuid.UUID, error)
 
 	// MarkTicketRead updates last_user_read_at timestamp
 	MarkTicketRead(ctx context.Context, ticketID, userID uuid.UUID) error
@@ -60,8 +61,8 @@ type UserSupportRepository interface {
 //              pointing at prod's RDS (shared-support-DB mode set by
 //              SUPPORT_DB_DSN). All ticket SQL routes through supportDB.
 type userSupportRepo struct {
-	db        *sql.DB
-	supportDB *sql.DB
+	db        *DB
+	supportDB *DB
 }
 
 // NewUserSupportRepo creates a new user support repository. When supportDB is
@@ -70,7 +71,7 @@ func NewUserSupportRepo(db, supportDB *sql.DB) UserSupportRepository {
 	if supportDB == nil {
 		supportDB = db
 	}
-	return &userSupportRepo{db: db, supportDB: supportDB}
+	return &userSupportRepo{db: WrapDB(db), supportDB: WrapDB(supportDB)}
 }
 
 // lookupUserDenorm fetches the actor's email + name from the LOCAL users
@@ -112,7 +113,8 @@ func (r *userSupportRepo) CreateTicket(ctx context.Context, userID uuid.UUID, su
 	return r.GetTicketByID(ctx, id, userID)
 }
 
-// GetTickets returns all tickets for a specific user
+// GetTickets returns all tickets for a specific user. Merged tickets are
+// left out — their thread lives on the ticket they were merged into.
 func (r *userSupportRepo) GetTickets(ctx context.Context, userID uuid.UUID) ([]SupportTicket, error) {
 	query := `
 		SELECT t.id, t.ticket_number, t.user_id, t.subject, t.description, t.status, t.priority, t.type,
@@ -123,7 +125,7 @@ func (r *userSupportRepo) GetTickets(ctx context.Context, userID uuid.UUID) ([]S
 		FROM support_tickets t
 		LEFT JOIN users u ON t.user_id = u.id
 		LEFT JOIN users a ON t.assigned_to = a.id
-		WHERE t.user_id = $1
+		WHERE t.user_id = $1 AND t.merged_into_ticket_id IS NULL
 		ORDER BY t.updated_at DESC
 	`
 	rows, err := r.supportDB.QueryContext(ctx, query, userID)
@@ -151,7 +153,7 @@ func (r *userSupportRepo) GetTicketByID(ctx context.Context, ticketID, userID uu
 	query := `
 		SELECT t.id, t.ticket_number, t.user_id, t.subject, t.description, t.status, t.priority, t.type,
 		       t.assigned_to, t.created_at, t.updated_at, t.resolved_at, t.resolved_by,
-		       t.duplicate_of_ticket_id, t.duplicate_of_roadmap_id,
+		       t.duplicate_of_ticket_id, t.duplicate_of_roadmap_id, t.merged_into_ticket_id,
 		       COALESCE(NULLIF(t.user_email, ''), u.email, '') as user_email,
 		       COALESCE(a.first_name || ' ' || a.last_name, '') as assignee_name
 		FROM support_tickets t
@@ -163,7 +165,7 @@ func (r *userSupportRepo) GetTicketByID(ctx context.Context, ticketID, userID uu
 	err := r.supportDB.QueryRowContext(ctx, query, ticketID, userID).Scan(
 		&t.ID, &t.Number, &t.UserID, &t.Subject, &t.Description, &t.Status, &t.Priority, &t.Type,
 		&t.AssignedTo, &t.CreatedAt, &t.UpdatedAt, &t.ResolvedAt, &t.ResolvedBy,
-		&t.DuplicateOfTicketID, &t.DuplicateOfRoadmapID,
+		&t.DuplicateOfTicketID, &t.DuplicateOfRoadmapID, &t.MergedIntoTicketID,
 		&t.UserEmail, &t.AssigneeName,
 	)
 	if err == sql.ErrNoRows {
@@ -220,15 +222,15 @@ func (r *userSupportRepo) GetTicketMessages(ctx context.Context, ticketID, userI
 }
 
 // AddMessage adds a message from the user to a ticket
-func (r *userSupportRepo) AddMessage(ctx context.Context, ticketID, userID uuid.UUID, message string) error {
+func (r *userSupportRepo) AddMessage(ctx context.Context, ticketID, userID uuid.UUID, message string) (uuid.UUID, error) {
 	// First verify ownership
 	var ownerID models.NullUUID
 	err := r.supportDB.QueryRowContext(ctx, "SELECT user_id FROM support_tickets WHERE id = $1", ticketID).Scan(&ownerID)
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 	if !ownerID.Valid || ownerID.UUID != userID {
-		return sql.ErrNoRows // Not the owner
+		return uuid.Nil, sql.ErrNoRows // Not the owner
 	}
 
 	id := uuid.New()
@@ -236,12 +238,12 @@ func (r *userSupportRepo) AddMessage(ctx context.Context, ticketID, userID uuid.
 	query := `INSERT INTO ticket_messages (id, ticket_id, sender_id, message, is_internal, created_at, sender_email, sender_first_name, sender_last_name) VALUES ($1, $2, $3, $4, false, NOW(), $5, $6, $7)`
 	_, err = r.supportDB.ExecContext(ctx, query, id, ticketID, userID, message, email, firstName, lastName)
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 
 	// Update ticket updated_at timestamp
 	_, err = r.supportDB.ExecContext(ctx, "UPDATE support_tickets SET updated_at = NOW() WHERE id = $1", ticketID)
-	return err
+	return id, err
 }
 
 // MarkTicketRead updates the last_user_read_at timestamp for a ticket
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// Warehouse export statuses.
+const (
+	WarehouseExportRunning   = "running"
+	WarehouseExportSucceeded = "succeeded"
+	WarehouseExportFailed    = "failed"
+)
+
+// WarehouseExport is one dataset file written by the nightly export.
+type WarehouseExport struct {
+	ID         uuid.UUID `json:"id"`
+	Dataset    string    `json:"dataset"`
+	PeriodDate time.Time `json:"period_date"`
+	Status     string    `json:"status"`
+	RowCount   int64     `json:"row_count"`
+	// SuppressedRows is how many aggregate rows fell under the minimum
+	// cell size and were left out.
+	SuppressedRows   int64           `json:"suppressed_rows"`
+	StorageDriver    string          `json:"storage_driver,omitempty"`
+	StoragePath      string          `json:"storage_path,omitempty"`
+	SizeBytes        int64           `json:"size_bytes"`
+	Error            string          `json:"error,omitempty"`
+	TriggeredBy      models.NullUUID `json:"triggered_by,omitempty"`
+	TriggeredByEmail string          `json:"triggered_by_email,omitempty"`
+	StartedAt        time.Time       `json:"started_at"`
+	FinishedAt       *time.Time      `json:"finished_at,omitempty"`
+}
+
+// EntryCountRow is log entries of one type on one day for children in one
+// age band.
+type EntryCountRow struct {
+	LogType  string
+	AgeBand  string
+	Entries  int64
+	Children int64
+}
+
+// FeatureUsageRow is one feature's use on one day.
+type FeatureUsageRow struct {
+	Feature  string
+	Families int64
+	Events   int64
+}
+
+// RetentionCohortRow is how many of the families that signed up in one
+// month were active (logged anything) some months later.
+type RetentionCohortRow struct {
+	CohortMonth       time.Time
+	MonthsSinceSignup int64
+	CohortSize        int64
+	ActiveFamilies    int64
+}
+
+// WarehouseExportRepository reads the aggregates behind the warehouse
+// export and owns warehouse_exports. The aggregate queries return counts
+// only, never row-level data, and only count families that aren't
+// soft-deleted and whose research consent covers the dataset's scope;
+// suppressing small cells is left to the service.
+type WarehouseExportRepository interface {
+	// EntryCounts counts log entries dated day by log type and the child's
+	// two-year age band on that day.
+	EntryCounts(ctx context.Context, day time.Time) ([]EntryCountRow, error)
+	// FeatureUsage counts families and events per feature on day.
+	FeatureUsage(ctx context.Context, day time.Time) ([]FeatureUsageRow, error)
+	// RetentionCohorts returns monthly signup cohorts from the months
+	// before asOf's month, with their activity in each month since, up to
+	// asOf's month.
+	RetentionCohorts(ctx context.Context, asOf time.Time, months int) ([]RetentionCohortRow, error)
+
+	// Find returns the export for dataset and day, or nil.
+	Find(ctx context.Context, dataset string, day time.Time) (*WarehouseExport, error)
+	// Start records (or restarts) the export for dataset and day as
+	// running. triggeredBy is uuid.Nil for the scheduled run.
+	Start(ctx context.Context, dataset string, day time.Time, triggeredBy uuid.UUID) (*WarehouseExport, error)
+	Succeed(ctx context.Context, id uuid.UUID, rows, suppressed int64, driver, path string, size int64) error
+	Fail(ctx context.Context, id uuid.UUID, msg string) error
+	// List returns the most recent exports, newest period first.
+	List(ctx context.Context, limit int) ([]WarehouseExport, error)
+	// ExportedDays returns every day with a published file for dataset,
+	// oldest first.
+	ExportedDays(ctx context.Context, dataset string) ([]time.Time, error)
+}
+
+type warehouseExportRepo struct {
+	db *DB
+}
+
+// NewWarehouseExportRepo creates a WarehouseExportRepository on the main pool.
+func NewWarehouseExportRepo(db *sql.DB) WarehouseExportRepository {
+	return &warehouseExportRepo{db: WrapDB(db)}
+}
+
+// warehouseLogTables maps the exported log_type to its table.
+var warehouseLogTables = []struct{ logType, table string }{
+	{"medication", "medication_logs"},
+	{"behavior", "behavior_logs"},
+	{"bowel", "bowel_logs"},
+	{"speech", "speech_logs"},
+	{"diet", "diet_logs"},
+	{"weight", "weight_logs"},
+	{"sleep", "sleep_logs"},
+	{"sensory", "sensory_logs"},
+	{"social", "social_logs"},
+	{"therapy", "therapy_logs"},
+	{"seizure", "seizure_logs"},
+	{"health_event", "health_event_logs"},
+}
+
+// warehouseLogEntries is a UNION of every log table's (log_type, child_id,
+// log_date) where log_date satisfies cond.
+func warehouseLogEntries(cond string) string {
+	parts := make([]string, len(warehouseLogTables))
+	for i, t := range warehouseLogTables {
+		parts[i] = "SELECT '" + t.logType + "' AS log_type, child_id, log_date FROM " + t.table + " WHERE " + cond
+	}
+	return strings.Join(parts, "\n        UNION ALL ")
+}
+
+func (r *warehouseExportRepo) EntryCounts(ctx context.Context, day time.Time) ([]EntryCountRow, error) {
+	// Age bands match service.AgeBand ("4-5y", "18+"), taken at the
+	// entry's date rather than today.
+	rows, err := r.db.QueryContext(ctx, `
+        WITH entries AS (
+        `+warehouseLogEntries("log_date = $1")+`
+        ), aged AS (
+            SELECT e.log_type, e.child_id,
+                   DATE_PART('year', AGE(e.log_date, c.date_of_birth))::int AS years
+            FROM entries e
+            JOIN children c ON c.id = e.child_id
+            JOIN families f ON f.id = c.family_id AND f.deleted_at IS NULL
+                 AND `+researchOptedIn("f.id", ResearchScopeCareLogs)+`
+        )
+        SELECT log_type,
+               CASE WHEN years >= 18 THEN '18+'
+                    ELSE (years / 2 * 2)::text || '-' || (years / 2 * 2 + 1)::text || 'y' END AS age_band,
+               COUNT(*), COUNT(DISTINCT child_id)
+        FROM aged
+        WHERE years >= 0
+        GROUP BY 1, 2
+        ORDER BY 1, 2`, day.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []EntryCountRow
+	for rows.Next() {
+		var e EntryCountRow
+		if err := rows.Scan(&e.LogType, &e.AgeBand, &e.Entries, &e.Children); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (r *warehouseExportRepo) FeatureUsage(ctx context.Context, day time.Time) ([]FeatureUsageRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        WITH usage AS (
+            SELECT 'logging' AS feature, c.family_id
+            FROM (`+warehouseLogEntries("log_date = $1")+`) e
+            JOIN children c ON c.id = e.child_id
+            UNION ALL
+            SELECT 'chat', t.family_id
+            FROM chat_messages m JOIN chat_threads t ON t.id = m.thread_id
+            WHERE m.created_at >= $1::date AND m.created_at < $1::date + 1
+            UNION ALL
+            SELECT 'reports', family_id FROM reports
+            WHERE created_at >= $1::date AND created_at < $1::date + 1
+            UNION ALL
+            SELECT 'correlations', c.family_id
+            FROM correlation_requests cr JOIN children c ON c.id = cr.child_id
+            WHERE cr.created_at >= $1::date AND cr.created_at < $1::date + 1
+            UNION ALL
+            SELECT 'alerts_reviewed', family_id FROM alerts
+            WHERE acknowledged_at >= $1::date AND acknowledged_at < $1::date + 1
+        )
+        SELECT u.feature, COUNT(DISTINCT u.family_id), COUNT(*)
+        FROM usage u
+        JOIN families f ON f.id = u.family_id AND f.deleted_at IS NULL
+             AND `+researchOptedIn("f.id", ResearchScopeFeatureUsage)+`
+        GROUP BY 1
+        ORDER BY 1`, day.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FeatureUsageRow
+	for rows.Next() {
+		var f FeatureUsageRow
+		if err := rows.Scan(&f.Feature, &f.Families, &f.Events); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (r *warehouseExportRepo) RetentionCohorts(ctx context.Context, asOf time.Time, months int) ([]RetentionCohortRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        WITH bounds AS (
+            SELECT DATE_TRUNC('month', $1::date)::date AS this_month
+        ), cohorts AS (
+            SELECT f.id AS family_id, DATE_TRUNC('month', f.created_at)::date AS cohort_month
+            FROM families f, bounds b
+            WHERE f.deleted_at IS NULL
+              AND `+researchOptedIn("f.id", ResearchScopeRetention)+`
+              AND f.created_at >= b.this_month - make_interval(months => $2::int)
+              AND f.created_at < b.this_month
+        ), sizes AS (
+            SELECT cohort_month, COUNT(*) AS cohort_size FROM cohorts GROUP BY 1
+        ), activity AS (
+            SELECT DISTINCT c.family_id, DATE_TRUNC('month', e.log_date)::date AS active_month
+            FROM (`+warehouseLogEntries("log_date >= (SELECT this_month FROM bounds) - make_interval(months => $2::int) AND log_date <= $1::date")+`) e
+            JOIN children c ON c.id = e.child_id
+        )
+        SELECT s.cohort_month, m.n, s.cohort_size, COUNT(a.family_id)
+        FROM sizes s
+        CROSS JOIN bounds b
+        CROSS JOIN LATERAL generate_series(0,
+            (EXTRACT(YEAR FROM AGE(b.this_month, s.cohort_month)) * 12
+             + EXTRACT(MONTH FROM AGE(b.this_month, s.cohort_month)))::int) AS m(n)
+        LEFT JOIN cohorts c ON c.cohort_month = s.cohort_month
+        LEFT JOIN activity a ON a.family_id = c.family_id
+             AND a.active_month = (s.cohort_month + make_interval(months => m.n))::date
+        GROUP BY s.cohort_month, m.n, s.cohort_size
+        ORDER BY s.cohort_month, m.n`, asOf.Format("2006-01-02"), months)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RetentionCohortRow
+	for rows.Next() {
+		var c RetentionCohortRow
+		if err := rows.Scan(&c.CohortMonth, &c.MonthsSinceSignup, &c.CohortSize, &c.ActiveFamilies); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+const warehouseExportCols = `
+    w.id, w.dataset, w.period_date, w.status, w.row_count, w.suppressed_rows,
+    COALESCE(w.storage_driver, ''), COALESCE(w.storage_path, ''), w.size_bytes, COALESCE(w.error, ''),
+    w.triggered_by, COALESCE(au.email, ''), w.started_at, w.finished_at`
+
+const warehouseExportFrom = `
+    FROM warehouse_exports w
+    LEFT JOIN admin_users au ON au.id = w.triggered_by`
+
+func scanWarehouseExport(s rowScannerLike) (*WarehouseExport, error) {
+	e := &WarehouseExport{}
+	var finished sql.NullTime
+	err := s.Scan(&e.ID, &e.Dataset, &e.PeriodDate, &e.Status, &e.RowCount, &e.SuppressedRows,
+		&e.StorageDriver, &e.StoragePath, &e.SizeBytes, &e.Error, &e.TriggeredBy, &e.TriggeredByEmail,
+		&e.StartedAt, &finished)
+	if err != nil {
+		return nil, err
+	}
+	if finished.Valid {
+		e.FinishedAt = &finished.Time
+	}
+	return e, nil
+}
+
+func (r *warehouseExportRepo) Find(ctx context.Context, dataset string, day time.Time) (*WarehouseExport, error) {
+	e, err := scanWarehouseExport(r.db.QueryRowContext(ctx, `
+        SELECT `+warehouseExportCols+warehouseExportFrom+`
+        WHERE w.dataset = $1 AND w.period_date = $2`, dataset, day.Format("2006-01-02")))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+func (r *warehouseExportRepo) Start(ctx context.Context, dataset string, day time.Time, triggeredBy uuid.UUID) (*WarehouseExport, error) {
+	var by models.NullUUID
+	if triggeredBy != uuid.Nil {
+		by = models.NullUUID{UUID: triggeredBy, Valid: true}
+	}
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO warehouse_exports (dataset, period_date, status, triggered_by)
+        VALUES ($1, $2, 'running', $3)
+        ON CONFLICT (dataset, period_date) DO UPDATE
+        SET status = 'running', row_count = 0, suppressed_rows = 0, storage_driver = NULL,
+            storage_path = NULL, size_bytes = 0, error = NULL, triggered_by = EXCLUDED.triggered_by,
+            started_at = NOW(), finished_at = NULL
+        RETURNING id`, dataset, day.Format("2006-01-02"), by).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return scanWarehouseExport(r.db.QueryRowContext(ctx, `
+        SELECT `+warehouseExportCols+warehouseExportFrom+`
+        WHERE w.id = $1`, id))
+}
+
+func (r *warehouseExportRepo) Succeed(ctx context.Context, id uuid.UUID, rows, suppressed int64, driver, path string, size int64) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE warehouse_exports
+        SET status = 'succeeded', row_count = $2, suppressed_rows = $3, storage_driver = $4,
+            storage_path = $5, size_bytes = $6, finished_at = NOW()
+        WHERE id = $1`, id, rows, suppressed, driver, path, size)
+	return err
+}
+
+func (r *warehouseExportRepo) Fail(ctx context.Context, id uuid.UUID, msg string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE warehouse_exports
+        SET status = 'failed', error = $2, finished_at = NOW()
+        WHERE id = $1`, id, msg)
+	return err
+}
+
+func (r *warehouseExportRepo) List(ctx context.Context, limit int) ([]WarehouseExport, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+warehouseExportCols+warehouseExportFrom+`
+        ORDER BY w.period_date DESC, w.dataset
+        LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WarehouseExport
+	for rows.Next() {
+		e, err := scanWarehouseExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+func (r *warehouseExportRepo) ExportedDays(ctx context.Context, dataset string) ([]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT period_date FROM warehouse_exports
+        WHERE dataset = $1 AND status = 'succeeded'
+        ORDER BY period_date`, dataset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		out = append(out, day)
+	}
+	return out, rows.Err()
+}
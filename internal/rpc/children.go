@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/rpc/internalv1"
+	"carecompanion/internal/service"
+)
+
+type childServer struct {
+	internalv1.UnimplementedChildServiceServer
+	children *service.ChildService
+}
+
+func (s *childServer) GetChild(ctx context.Context, req *internalv1.GetChildRequest) (*internalv1.Child, error) {
+	child, err := lookupChild(ctx, s.children, req.GetChildId())
+	if err != nil {
+		return nil, err
+	}
+	return childProto(child, child.Conditions), nil
+}
+
+func (s *childServer) ListFamilyChildren(ctx context.Context, req *internalv1.ListFamilyChildrenRequest) (*internalv1.ListFamilyChildrenResponse, error) {
+	familyID, err := parseID("family_id", req.GetFamilyId())
+	if err != nil {
+		return nil, err
+	}
+	children, err := s.children.GetByFamilyID(ctx, familyID)
+	if err != nil {
+		return nil, internalError("ListFamilyChildren", err)
+	}
+	resp := &internalv1.ListFamilyChildrenResponse{}
+	for i := range children {
+		conditions, err := s.children.GetConditions(ctx, children[i].ID)
+		if err != nil {
+			return nil, internalError("ListFamilyChildren", err)
+		}
+		resp.Children = append(resp.Children, childProto(&children[i], conditions))
+	}
+	return resp, nil
+}
+
+// lookupChild loads a child by its request ID, mapping a missing child to
+// NotFound.
+func lookupChild(ctx context.Context, children *service.ChildService, childID string) (*models.Child, error) {
+	id, err := parseID("child_id", childID)
+	if err != nil {
+		return nil, err
+	}
+	child, err := children.GetByID(ctx, id)
+	if errors.Is(err, service.ErrChildNotFound) {
+		return nil, status.Error(codes.NotFound, "child not found")
+	}
+	if err != nil {
+		return nil, internalError("lookupChild", err)
+	}
+	return child, nil
+}
+
+func childProto(c *models.Child, conditions []models.ChildCondition) *internalv1.Child {
+	out := &internalv1.Child{
+		Id:          c.ID.String(),
+		FamilyId:    c.FamilyID.String(),
+		DateOfBirth: c.DateOfBirth.Format("2006-01-02"),
+		Gender:      c.Gender.String,
+		IsActive:    c.IsActive,
+		CreatedAt:   timestamppb.New(c.CreatedAt),
+		UpdatedAt:   timestamppb.New(c.UpdatedAt),
+	}
+	for _, cond := range conditions {
+		out.Conditions = append(out.Conditions, cond.ConditionName)
+	}
+	return out
+}
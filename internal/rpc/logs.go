@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"carecompanion/internal/repository"
+	"carecompanion/internal/rpc/internalv1"
+	"carecompanion/internal/service"
+)
+
+type logServer struct {
+	internalv1.UnimplementedLogServiceServer
+	logs     *service.LogService
+	children *service.ChildService
+}
+
+func (s *logServer) ListLogs(ctx context.Context, req *internalv1.ListLogsRequest) (*internalv1.ListLogsResponse, error) {
+	child, err := lookupChild(ctx, s.children, req.GetChildId())
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := parseDateRange(req.GetStartDate(), req.GetEndDate())
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.logs.ListLogFields(ctx, req.GetLogType(), child.ID, start, end, req.GetFields())
+	switch {
+	case errors.Is(err, repository.ErrUnknownField), errors.Is(err, repository.ErrUnknownLogType):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, internalError("ListLogs", err)
+	}
+	entries, err := logEntries(req.GetLogType(), logs)
+	if err != nil {
+		return nil, internalError("ListLogs", err)
+	}
+	return &internalv1.ListLogsResponse{Entries: entries}, nil
+}
+
+// logEntries converts a typed log slice through its REST JSON form, so
+// the entry fields carry the same keys and value formats as the API.
+func logEntries(logType string, logs any) ([]*internalv1.LogEntry, error) {
+	raw, err := json.Marshal(logs)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+	entries := make([]*internalv1.LogEntry, 0, len(rows))
+	for _, row := range rows {
+		fields, err := structpb.NewStruct(row)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := row["id"].(string)
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, errors.New("log row without id")
+		}
+		entries = append(entries, &internalv1.LogEntry{Id: id, LogType: logType, Fields: fields})
+	}
+	return entries, nil
+}
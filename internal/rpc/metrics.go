@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"carecompanion/internal/rpc/internalv1"
+	"carecompanion/internal/service"
+)
+
+type metricsServer struct {
+	internalv1.UnimplementedMetricsServiceServer
+	correlation *service.CorrelationService
+	children    *service.ChildService
+}
+
+func (s *metricsServer) GetMetricSeries(ctx context.Context, req *internalv1.GetMetricSeriesRequest) (*internalv1.GetMetricSeriesResponse, error) {
+	child, err := lookupChild(ctx, s.children, req.GetChildId())
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := parseDateRange(req.GetStartDate(), req.GetEndDate())
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.correlation.GetMetricSeries(ctx, child.ID, start, end)
+	if err != nil {
+		return nil, internalError("GetMetricSeries", err)
+	}
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		if len(req.GetMetrics()) == 0 || slices.Contains(req.GetMetrics(), name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	resp := &internalv1.GetMetricSeriesResponse{}
+	for _, name := range names {
+		series := &internalv1.MetricSeries{Metric: name}
+		for _, dp := range data[name] {
+			series.Points = append(series.Points, &internalv1.DataPoint{Date: dp.Date.Format("2006-01-02"), Value: dp.Value})
+		}
+		resp.Series = append(resp.Series, series)
+	}
+	return resp, nil
+}
+
+func (s *metricsServer) ListBaselines(ctx context.Context, req *internalv1.ListBaselinesRequest) (*internalv1.ListBaselinesResponse, error) {
+	child, err := lookupChild(ctx, s.children, req.GetChildId())
+	if err != nil {
+		return nil, err
+	}
+	baselines, err := s.correlation.GetBaselines(ctx, child.ID)
+	if err != nil {
+		return nil, internalError("ListBaselines", err)
+	}
+	resp := &internalv1.ListBaselinesResponse{}
+	for _, b := range baselines {
+		out := &internalv1.Baseline{
+			Metric:       b.MetricName,
+			Value:        b.BaselineValue,
+			StdDeviation: b.StdDeviation,
+			SampleSize:   int32(b.SampleSize),
+			CalculatedAt: timestamppb.New(b.CalculatedAt),
+		}
+		if b.ValidUntil.Valid {
+			out.ValidUntil = timestamppb.New(b.ValidUntil.Time)
+		}
+		resp.Baselines = append(resp.Baselines, out)
+	}
+	return resp, nil
+}
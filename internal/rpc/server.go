@@ -0,0 +1,151 @@
+// Package rpc is the internal gRPC surface for service-to-service callers
+// (the analytics worker). It serves the protobuf services in
+// proto/internal/v1 on its own port with mutual TLS, backed by the same
+// service layer as the REST handlers.
+package rpc
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=carecompanion/internal/rpc --go-grpc_out=. --go-grpc_opt=module=carecompanion/internal/rpc internal/v1/children.proto internal/v1/logs.proto internal/v1/metrics.proto
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/rpc/internalv1"
+	"carecompanion/internal/service"
+)
+
+// NewServer creates the gRPC server with the internal services registered.
+// It refuses to start without the certificate, key and client CA: the
+// listener is never served without mTLS.
+func NewServer(cfg config.GRPCConfig, services *service.Services) (*grpc.Server, error) {
+	tlsConfig, err := TLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(recoverInterceptor, authorizeInterceptor(cfg.AllowedClients)),
+	)
+	Register(srv, services)
+	return srv, nil
+}
+
+// Register adds the internal services to srv.
+func Register(srv grpc.ServiceRegistrar, services *service.Services) {
+	internalv1.RegisterChildServiceServer(srv, &childServer{children: services.Child})
+	internalv1.RegisterLogServiceServer(srv, &logServer{logs: services.Log, children: services.Child})
+	internalv1.RegisterMetricsServiceServer(srv, &metricsServer{correlation: services.Correlation, children: services.Child})
+}
+
+// TLSConfig is the server side of the mTLS handshake: the server's own
+// certificate, and client certificates required and verified against the
+// client CA.
+func TLSConfig(cfg config.GRPCConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" || cfg.ClientCAFile == "" {
+		return nil, errors.New("GRPC_TLS_CERT, GRPC_TLS_KEY and GRPC_CLIENT_CA are required")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates in %s", cfg.ClientCAFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// clientName is the common name of the caller's verified certificate.
+func clientName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// authorizeInterceptor limits callers to the allowed certificate names and
+// logs each call. An empty list admits any certificate the CA signed.
+func authorizeInterceptor(allowed []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		client := clientName(ctx)
+		if client == "" || (len(allowed) > 0 && !slices.Contains(allowed, client)) {
+			log.Printf("[GRPC] denied %s for client %q", info.FullMethod, client)
+			return nil, status.Error(codes.PermissionDenied, "client not allowed")
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		log.Printf("[GRPC] %s client=%s code=%s %s", info.FullMethod, client, status.Code(err), time.Since(start).Round(time.Millisecond))
+		return resp, err
+	}
+}
+
+func recoverInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("[GRPC] panic in %s: %v\n%s", info.FullMethod, p, debug.Stack())
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// parseID parses a UUID request field.
+func parseID(name, v string) (uuid.UUID, error) {
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s", name)
+	}
+	return id, nil
+}
+
+// parseDateRange parses inclusive YYYY-MM-DD bounds.
+func parseDateRange(start, end string) (time.Time, time.Time, error) {
+	s, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "invalid start_date, use YYYY-MM-DD")
+	}
+	e, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "invalid end_date, use YYYY-MM-DD")
+	}
+	if e.Before(s) {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "end_date is before start_date")
+	}
+	return s, e, nil
+}
+
+// internalError logs err and returns an opaque Internal status.
+func internalError(method string, err error) error {
+	log.Printf("[GRPC] %s: %v", method, err)
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/rpc/internalv1"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for name, signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestServerRequiresAllowedClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	serverCert, serverKey := ca.issue(t, "carecompanion-api", x509.ExtKeyUsageServerAuth)
+	cfg := config.GRPCConfig{
+		CertFile:       write("server.crt", serverCert),
+		KeyFile:        write("server.key", serverKey),
+		ClientCAFile:   write("ca.crt", ca.pem),
+		AllowedClients: []string{"analytics-worker"},
+	}
+	if _, err := TLSConfig(config.GRPCConfig{}); err == nil {
+		t.Error("TLSConfig without files: want error")
+	}
+	tlsConfig, err := TLSConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(recoverInterceptor, authorizeInterceptor(cfg.AllowedClients)),
+	)
+	// No service behind it: requests with a bad child_id are rejected
+	// before the service layer is reached.
+	internalv1.RegisterChildServiceServer(srv, &childServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	call := func(clientName string) error {
+		tc := &tls.Config{RootCAs: roots, ServerName: "carecompanion-api"}
+		if clientName != "" {
+			certPEM, keyPEM := ca.issue(t, clientName, x509.ExtKeyUsageClientAuth)
+			pair, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.Certificates = []tls.Certificate{pair}
+		}
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(credentials.NewTLS(tc)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = internalv1.NewChildServiceClient(conn).GetChild(ctx, &internalv1.GetChildRequest{ChildId: "not-a-uuid"})
+		return err
+	}
+
+	if code := status.Code(call("analytics-worker")); code != codes.InvalidArgument {
+		t.Errorf("allowed client: code %s, want InvalidArgument", code)
+	}
+	if code := status.Code(call("someone-else")); code != codes.PermissionDenied {
+		t.Errorf("other client: code %s, want PermissionDenied", code)
+	}
+	if code := status.Code(call("")); code != codes.Unavailable {
+		t.Errorf("no client certificate: code %s, want Unavailable", code)
+	}
+}
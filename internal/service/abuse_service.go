@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrAbuseBlockNotFound = apperr.NotFound("abuse block not found")
+	// ErrAbuseReviewInvalid is returned for a review action other than
+	// "confirm" or "unblock".
+	ErrAbuseReviewInvalid = apperr.Validation("review action must be confirm or unblock")
+)
+
+// Abuse block reasons, one per heuristic.
+const (
+	AbuseReasonVelocity     = "velocity"
+	AbuseReasonEnumeration  = "enumeration"
+	AbuseReasonProbing      = "probing"
+	AbuseReasonScannerAgent = "scanner_agent"
+)
+
+const (
+	// abuseWindow is the window enumeration and probing are counted over.
+	abuseWindow = 10 * time.Minute
+	// abuseFlagTTL is how long an IP's errors are classified as scanner
+	// noise after it was flagged.
+	abuseFlagTTL = time.Hour
+	// abuseAllowTTL exempts an IP an admin unblocked from detection for a
+	// day, so the traffic that tripped it doesn't block it again.
+	abuseAllowTTL = 24 * time.Hour
+	// Repeat offenders: the block duration doubles for each block in the
+	// escalation window, up to abuseMaxBlock.
+	abuseEscalationWindow = 30 * 24 * time.Hour
+	abuseMaxBlock         = 7 * 24 * time.Hour
+)
+
+// scannerAgents are User-Agent fragments of vulnerability scanners and
+// content discovery tools. Matched case-insensitively.
+var scannerAgents = []string{
+	"sqlmap", "nikto", "nmap", "masscan", "zgrab", "nuclei", "gobuster",
+	"dirbuster", "wpscan", "ffuf", "feroxbuster", "acunetix", "netsparker",
+	"openvas", "nessus",
+}
+
+var abuseUUIDPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// scannerAgent reports whether ua belongs to a known scanner.
+func scannerAgent(ua string) bool {
+	ua = strings.ToLower(ua)
+	for _, s := range scannerAgents {
+		if strings.Contains(ua, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// AbuseObservation is one finished request as abuse detection sees it.
+// Route is the matched route pattern, "unmatched" when none was.
+type AbuseObservation struct {
+	IP        string
+	Method    string
+	Path      string
+	Route     string
+	Status    int
+	UserAgent string
+}
+
+// AbuseBlockList is the admin view of abuse blocks: the ones asked for
+// and how many are waiting for review.
+type AbuseBlockList struct {
+	Blocks []repository.AbuseBlock `json:"blocks"`
+	Queue  int                     `json:"queue"`
+}
+
+// AbuseService detects abusive and scraping traffic per client IP and
+// blocks it for a while (see config.AbuseConfig). Counters, flags and
+// blocks live in Redis, where AbuseGuard checks them on every request;
+// each block is also recorded in abuse_blocks for admins to confirm or
+// lift. Detection is best effort and fails open: without Redis nothing
+// is counted or blocked.
+type AbuseService struct {
+	r         *database.Redis
+	repo      repository.AbuseBlockRepository
+	cfg       config.AbuseConfig
+	allowlist []netip.Prefix
+	now       func() time.Time
+}
+
+func NewAbuseService(r *database.Redis, repo repository.AbuseBlockRepository, cfg config.AbuseConfig) *AbuseService {
+	s := &AbuseService{r: r, repo: repo, cfg: cfg, now: time.Now}
+	for _, entry := range cfg.Allowlist {
+		if p, err := netip.ParsePrefix(entry); err == nil {
+			s.allowlist = append(s.allowlist, p.Masked())
+		} else if a, err := netip.ParseAddr(entry); err == nil {
+			s.allowlist = append(s.allowlist, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+		} else {
+			log.Printf("[ABUSE] ignoring allowlist entry %q: not an IP or CIDR", entry)
+		}
+	}
+	return s
+}
+
+func (s *AbuseService) active() bool {
+	return s != nil && s.r != nil && s.cfg.Enabled
+}
+
+func (s *AbuseService) allowlisted(ip string) bool {
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		// Not something that can be blocked.
+		return true
+	}
+	a = a.Unmap()
+	for _, p := range s.allowlist {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+func abuseKey(kind, ip string) string {
+	return "abuse:" + kind + ":" + ip
+}
+
+func abuseSlotKey(kind, ip string, t time.Time, window time.Duration) string {
+	return abuseKey(kind, ip) + ":" + strconv.FormatInt(t.Truncate(window).Unix(), 10)
+}
+
+// Blocked reports whether ip is blocked, and for how much longer.
+func (s *AbuseService) Blocked(ctx context.Context, ip string) (time.Duration, bool) {
+	if !s.active() {
+		return 0, false
+	}
+	ttl, err := s.r.PTTL(ctx, abuseKey("block", ip)).Result()
+	if err != nil || ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// IsSuspicious reports whether a request from ip with user agent ua looks
+// like scanner traffic: a known scanner agent, or an IP flagged or
+// blocked in the last hour. The error tracker files such errors as
+// scanner noise.
+func (s *AbuseService) IsSuspicious(ctx context.Context, ip, ua string) bool {
+	if scannerAgent(ua) {
+		return true
+	}
+	if !s.active() || ip == "" {
+		return false
+	}
+	n, err := s.r.Exists(ctx, abuseKey("flag", ip), abuseKey("block", ip)).Result()
+	return err == nil && n > 0
+}
+
+// Observe counts a finished request against its IP and blocks the IP
+// when a heuristic trips:
+//   - velocity: more than VelocityPerMinute requests in a minute
+//   - enumeration: 403s and 404s on more than EnumerationIDs distinct
+//     UUIDs in ten minutes, walking IDs it doesn't own
+//   - probing: more than ProbePaths 404s on paths no route serves in ten
+//     minutes
+//   - scanner_agent: a known scanner's User-Agent
+//
+// A request without a User-Agent only flags the IP.
+func (s *AbuseService) Observe(ctx context.Context, o AbuseObservation) {
+	if !s.active() || s.allowlisted(o.IP) {
+		return
+	}
+	now := s.now()
+	if n, err := s.r.Exists(ctx, abuseKey("allow", o.IP), abuseKey("block", o.IP)).Result(); err != nil || n > 0 {
+		return
+	}
+
+	if scannerAgent(o.UserAgent) {
+		s.block(ctx, o.IP, AbuseReasonScannerAgent, map[string]any{"user_agent": o.UserAgent, "path": o.Path})
+		return
+	}
+	if strings.TrimSpace(o.UserAgent) == "" {
+		s.flag(ctx, o.IP)
+	}
+
+	rateKey := abuseSlotKey("rate", o.IP, now, time.Minute)
+	pipe := s.r.Pipeline()
+	rate := pipe.Incr(ctx, rateKey)
+	pipe.Expire(ctx, rateKey, 2*time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[ABUSE] counting request from %s: %v", o.IP, err)
+		return
+	}
+	if s.cfg.VelocityPerMinute > 0 && rate.Val() > int64(s.cfg.VelocityPerMinute) {
+		s.block(ctx, o.IP, AbuseReasonVelocity, map[string]any{"requests_per_minute": rate.Val(), "path": o.Path, "user_agent": o.UserAgent})
+		return
+	}
+
+	if o.Status == 403 || o.Status == 404 {
+		if ids := abuseUUIDPattern.FindAllString(o.Path, -1); len(ids) > 0 && s.cfg.EnumerationIDs > 0 {
+			key := abuseSlotKey("enum", o.IP, now, abuseWindow)
+			members := make([]any, len(ids))
+			for i, id := range ids {
+				members[i] = strings.ToLower(id)
+			}
+			pipe := s.r.Pipeline()
+			pipe.PFAdd(ctx, key, members...)
+			count := pipe.PFCount(ctx, key)
+			pipe.Expire(ctx, key, 2*abuseWindow)
+			if _, err := pipe.Exec(ctx); err == nil && count.Val() > int64(s.cfg.EnumerationIDs) {
+				s.block(ctx, o.IP, AbuseReasonEnumeration, map[string]any{"distinct_ids": count.Val(), "path": o.Path, "user_agent": o.UserAgent})
+				return
+			}
+		}
+	}
+
+	if o.Status == 404 && o.Route == "unmatched" && s.cfg.ProbePaths > 0 {
+		key := abuseSlotKey("probe", o.IP, now, abuseWindow)
+		pipe := s.r.Pipeline()
+		probes := pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, 2*abuseWindow)
+		if _, err := pipe.Exec(ctx); err == nil && probes.Val() > int64(s.cfg.ProbePaths) {
+			s.block(ctx, o.IP, AbuseReasonProbing, map[string]any{"unmatched_404s": probes.Val(), "path": o.Path, "user_agent": o.UserAgent})
+		}
+	}
+}
+
+func (s *AbuseService) flag(ctx context.Context, ip string) {
+	if err := s.r.Set(ctx, abuseKey("flag", ip), "1", abuseFlagTTL).Err(); err != nil {
+		log.Printf("[ABUSE] flagging %s: %v", ip, err)
+	}
+}
+
+// blockDuration is the configured block doubled for each of the IP's
+// blocks in the escalation window, capped at abuseMaxBlock.
+func (s *AbuseService) blockDuration(ctx context.Context, ip string) time.Duration {
+	d := s.cfg.BlockDuration
+	if d <= 0 {
+		d = time.Hour
+	}
+	n, err := s.repo.CountSince(ctx, ip, s.now().Add(-abuseEscalationWindow))
+	if err != nil {
+		log.Printf("[ABUSE] counting earlier blocks of %s: %v", ip, err)
+	}
+	for ; n > 0 && d < abuseMaxBlock; n-- {
+		d *= 2
+	}
+	return min(d, abuseMaxBlock)
+}
+
+// block blocks ip unless it already is, and records the block for
+// review.
+func (s *AbuseService) block(ctx context.Context, ip, reason string, details map[string]any) {
+	d := s.blockDuration(ctx, ip)
+	ok, err := s.r.SetNX(ctx, abuseKey("block", ip), reason, d).Result()
+	if err != nil || !ok {
+		return
+	}
+	s.flag(ctx, ip)
+	raw, _ := json.Marshal(details)
+	b := &repository.AbuseBlock{IPAddress: ip, Reason: reason, Details: raw, ExpiresAt: s.now().Add(d)}
+	if err := s.repo.Create(ctx, b); err != nil {
+		log.Printf("[ABUSE] recording %s block of %s: %v", reason, ip, err)
+		return
+	}
+	log.Printf("[ABUSE] blocked %s for %s (%s)", ip, d, reason)
+}
+
+// List returns blocks for the admin review queue.
+func (s *AbuseService) List(ctx context.Context, f repository.AbuseBlockFilter) (*AbuseBlockList, error) {
+	blocks, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if blocks == nil {
+		blocks = []repository.AbuseBlock{}
+	}
+	queue, err := s.repo.QueueSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &AbuseBlockList{Blocks: blocks, Queue: queue}, nil
+}
+
+// Review takes a block off the review queue. "confirm" leaves it to run
+// out; "unblock" lifts it now and exempts the IP from detection for a
+// day, for blocks that caught legitimate traffic.
+func (s *AbuseService) Review(ctx context.Context, id uuid.UUID, action string, adminID uuid.UUID, note string) (*repository.AbuseBlock, error) {
+	var status string
+	switch action {
+	case "confirm":
+	case "unblock":
+		status = repository.AbuseBlockLifted
+	default:
+		return nil, ErrAbuseReviewInvalid
+	}
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, ErrAbuseBlockNotFound
+	}
+	if status == repository.AbuseBlockLifted && s.r != nil {
+		pipe := s.r.Pipeline()
+		pipe.Del(ctx, abuseKey("block", b.IPAddress), abuseKey("flag", b.IPAddress))
+		pipe.Set(ctx, abuseKey("allow", b.IPAddress), "1", abuseAllowTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := s.repo.Review(ctx, id, status, adminID, strings.TrimSpace(note)); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+	"carecompanion/internal/repository"
+)
+
+type fakeAbuseBlocks struct {
+	blocks []*repository.AbuseBlock
+	prior  int
+}
+
+func (f *fakeAbuseBlocks) Create(ctx context.Context, b *repository.AbuseBlock) error {
+	b.ID = uuid.New()
+	b.Status = repository.AbuseBlockActive
+	f.blocks = append(f.blocks, b)
+	return nil
+}
+
+func (f *fakeAbuseBlocks) GetByID(ctx context.Context, id uuid.UUID) (*repository.AbuseBlock, error) {
+	for _, b := range f.blocks {
+		if b.ID == id {
+			c := *b
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeAbuseBlocks) List(ctx context.Context, flt repository.AbuseBlockFilter) ([]repository.AbuseBlock, error) {
+	var out []repository.AbuseBlock
+	for _, b := range f.blocks {
+		out = append(out, *b)
+	}
+	return out, nil
+}
+
+func (f *fakeAbuseBlocks) CountSince(ctx context.Context, ip string, since time.Time) (int, error) {
+	return f.prior, nil
+}
+
+func (f *fakeAbuseBlocks) QueueSize(ctx context.Context) (int, error) {
+	n := 0
+	for _, b := range f.blocks {
+		if b.ReviewedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeAbuseBlocks) Review(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error) {
+	for _, b := range f.blocks {
+		if b.ID == id {
+			now := time.Now()
+			if status != "" {
+				b.Status = status
+			}
+			b.ReviewedAt, b.ReviewNote = &now, note
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newTestAbuseService(t *testing.T, cfg config.AbuseConfig) (*AbuseService, *fakeAbuseBlocks) {
+	mr := miniredis.RunT(t)
+	rdb := &database.Redis{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	repo := &fakeAbuseBlocks{}
+	cfg.Enabled = true
+	if cfg.BlockDuration == 0 {
+		cfg.BlockDuration = time.Hour
+	}
+	s := NewAbuseService(rdb, repo, cfg)
+	now := time.Date(2026, 6, 1, 10, 0, 30, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, repo
+}
+
+func TestAbuseVelocityBlock(t *testing.T) {
+	s, repo := newTestAbuseService(t, config.AbuseConfig{VelocityPerMinute: 5})
+	ctx := context.Background()
+	o := AbuseObservation{IP: "203.0.113.7", Method: "GET", Path: "/api/children", Route: "/api/children", Status: 200, UserAgent: "CareCompanion/2.3.0"}
+	for i := 0; i < 5; i++ {
+		s.Observe(ctx, o)
+	}
+	if _, blocked := s.Blocked(ctx, o.IP); blocked {
+		t.Fatal("blocked at the limit")
+	}
+	s.Observe(ctx, o)
+	retry, blocked := s.Blocked(ctx, o.IP)
+	if !blocked || retry <= 59*time.Minute || retry > time.Hour {
+		t.Fatalf("Blocked = %v, %v; want about an hour", retry, blocked)
+	}
+	if len(repo.blocks) != 1 || repo.blocks[0].Reason != AbuseReasonVelocity {
+		t.Fatalf("blocks = %+v", repo.blocks)
+	}
+	if !s.IsSuspicious(ctx, o.IP, o.UserAgent) {
+		t.Error("blocked IP isn't suspicious")
+	}
+	if s.IsSuspicious(ctx, "203.0.113.8", o.UserAgent) {
+		t.Error("other IP is suspicious")
+	}
+}
+
+func TestAbuseEnumerationBlock(t *testing.T) {
+	s, repo := newTestAbuseService(t, config.AbuseConfig{EnumerationIDs: 3})
+	ctx := context.Background()
+	ip := "198.51.100.2"
+	walk := func(status, n int) {
+		for i := 0; i < n; i++ {
+			s.Observe(ctx, AbuseObservation{IP: ip, Method: "GET", Path: fmt.Sprintf("/api/children/%s/logs", uuid.New()), Route: "/api/children/{childID}/logs", Status: status, UserAgent: "python-requests/2.31"})
+		}
+	}
+	walk(200, 10)
+	walk(404, 3)
+	if _, blocked := s.Blocked(ctx, ip); blocked {
+		t.Fatal("blocked before walking more than 3 IDs it can't see")
+	}
+	walk(403, 1)
+	if _, blocked := s.Blocked(ctx, ip); !blocked {
+		t.Fatal("not blocked after walking 4 IDs")
+	}
+	if len(repo.blocks) != 1 || repo.blocks[0].Reason != AbuseReasonEnumeration {
+		t.Fatalf("blocks = %+v", repo.blocks)
+	}
+}
+
+func TestAbuseProbingAndScannerAgents(t *testing.T) {
+	s, repo := newTestAbuseService(t, config.AbuseConfig{ProbePaths: 2})
+	ctx := context.Background()
+	for _, path := range []string{"/wp-login.php", "/.env", "/.git/config"} {
+		s.Observe(ctx, AbuseObservation{IP: "192.0.2.1", Method: "GET", Path: path, Route: "unmatched", Status: 404, UserAgent: "Mozilla/5.0"})
+	}
+	s.Observe(ctx, AbuseObservation{IP: "192.0.2.2", Method: "GET", Path: "/", Route: "/", Status: 200, UserAgent: "Mozilla/5.0 (compatible; Nuclei - Open-source project)"})
+	s.Observe(ctx, AbuseObservation{IP: "192.0.2.3", Method: "GET", Path: "/", Route: "/", Status: 200})
+
+	if len(repo.blocks) != 2 || repo.blocks[0].Reason != AbuseReasonProbing || repo.blocks[1].Reason != AbuseReasonScannerAgent {
+		t.Fatalf("blocks = %+v", repo.blocks)
+	}
+	if _, blocked := s.Blocked(ctx, "192.0.2.3"); blocked {
+		t.Error("missing User-Agent blocked")
+	}
+	if !s.IsSuspicious(ctx, "192.0.2.3", "") {
+		t.Error("missing User-Agent not flagged")
+	}
+	if !s.IsSuspicious(ctx, "192.0.2.99", "sqlmap/1.7") {
+		t.Error("scanner agent not suspicious")
+	}
+}
+
+func TestAbuseAllowlist(t *testing.T) {
+	s, repo := newTestAbuseService(t, config.AbuseConfig{VelocityPerMinute: 1, Allowlist: []string{"10.0.0.0/8", "2001:db8::1", "bogus"}})
+	ctx := context.Background()
+	for _, ip := range []string{"10.1.2.3", "2001:db8::1", "not-an-ip"} {
+		for i := 0; i < 3; i++ {
+			s.Observe(ctx, AbuseObservation{IP: ip, Path: "/api/children", Route: "/api/children", Status: 200, UserAgent: "nikto"})
+		}
+	}
+	if len(repo.blocks) != 0 {
+		t.Fatalf("allowlisted IPs blocked: %+v", repo.blocks)
+	}
+}
+
+func TestAbuseBlockEscalation(t *testing.T) {
+	s, repo := newTestAbuseService(t, config.AbuseConfig{BlockDuration: time.Hour})
+	ctx := context.Background()
+	for _, tc := range []struct {
+		prior int
+		want  time.Duration
+	}{
+		{0, time.Hour},
+		{1, 2 * time.Hour},
+		{3, 8 * time.Hour},
+		{20, abuseMaxBlock},
+	} {
+		repo.prior = tc.prior
+		if got := s.blockDuration(ctx, "192.0.2.1"); got != tc.want {
+			t.Errorf("blockDuration after %d blocks = %v, want %v", tc.prior, got, tc.want)
+		}
+	}
+}
+
+func TestAbuseReview(t *testing.T) {
+	s, repo := newTestAbuseService(t, config.AbuseConfig{})
+	ctx := context.Background()
+	ip := "203.0.113.9"
+	s.Observe(ctx, AbuseObservation{IP: ip, Path: "/", Route: "/", Status: 200, UserAgent: "masscan/1.3"})
+	if len(repo.blocks) != 1 {
+		t.Fatalf("blocks = %+v", repo.blocks)
+	}
+	id := repo.blocks[0].ID
+	admin := uuid.New()
+
+	if _, err := s.Review(ctx, id, "ignore", admin, ""); !errors.Is(err, ErrAbuseReviewInvalid) {
+		t.Errorf("bad action: err = %v", err)
+	}
+	if _, err := s.Review(ctx, uuid.New(), "confirm", admin, ""); !errors.Is(err, ErrAbuseBlockNotFound) {
+		t.Errorf("unknown block: err = %v", err)
+	}
+
+	b, err := s.Review(ctx, id, "unblock", admin, " office VPN ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Status != repository.AbuseBlockLifted || b.ReviewNote != "office VPN" {
+		t.Errorf("reviewed block = %+v", b)
+	}
+	if _, blocked := s.Blocked(ctx, ip); blocked {
+		t.Error("still blocked after unblock")
+	}
+	if s.IsSuspicious(ctx, ip, "Mozilla/5.0") {
+		t.Error("still flagged after unblock")
+	}
+	// Unblocked IPs are left alone for a day.
+	s.Observe(ctx, AbuseObservation{IP: ip, Path: "/", Route: "/", Status: 200, UserAgent: "masscan/1.3"})
+	if len(repo.blocks) != 1 {
+		t.Errorf("blocked again after unblock: %+v", repo.blocks)
+	}
+
+	list, err := s.List(ctx, repository.AbuseBlockFilter{Queue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Queue != 0 || len(list.Blocks) != 1 {
+		t.Errorf("List = %+v", list)
+	}
+}
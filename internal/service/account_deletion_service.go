@@ -17,17 +17,18 @@ import (
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/config"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrDeletionAlreadyPending  = errors.New("a deletion request is already pending for this user")
-	ErrDeletionCodeInvalid     = errors.New("confirmation code is invalid or expired")
+	ErrDeletionAlreadyPending  = apperr.Conflict("a deletion request is already pending for this user")
+	ErrDeletionCodeInvalid     = apperr.Validation("confirmation code is invalid or expired")
 	ErrDeletionCodeMaxAttempts = errors.New("too many failed attempts; request a new code")
-	ErrDeletionRestoreInvalid  = errors.New("restore link is invalid or expired")
-	ErrDeletionNotFound        = errors.New("no active deletion request")
+	ErrDeletionRestoreInvalid  = apperr.Validation("restore link is invalid or expired")
+	ErrDeletionNotFound        = apperr.NotFound("no active deletion request")
 )
 
 // AccountDeletionService orchestrates the user-initiated account deletion
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/auth"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrAccessReviewNotFound        = apperr.NotFound("access review not found")
+	ErrAccessReviewItemNotFound    = apperr.NotFound("account not found in this review")
+	ErrAccessReviewDecided         = apperr.Conflict("this account has already been decided")
+	ErrAccessReviewCompleted       = apperr.Conflict("this access review is already complete")
+	ErrAccessReviewIncomplete      = apperr.Validation("every account needs a decision before the review can be completed")
+	ErrAccessReviewInvalidDecision = apperr.Validation("decision must be attest or revoke")
+	ErrAccessReviewSelf            = apperr.Forbidden("another super admin has to review your own access")
+	ErrAccessReviewPolicyInvalid   = apperr.Validation("invalid access review policy")
+)
+
+const (
+	// AccessReviewPolicyKey is the system_settings key holding
+	// AccessReviewPolicy.
+	AccessReviewPolicyKey = "access_review_policy"
+
+	// AdminNotificationKindAccessReview is the admin_notifications kind
+	// opened when a quarter's review opens or falls overdue.
+	AdminNotificationKindAccessReview = "access_review"
+	// AdminNotificationKindAdminDeactivated is opened when the inactivity
+	// sweep deactivates admins.
+	AdminNotificationKindAdminDeactivated = "admin_deactivated"
+)
+
+// AccessReviewPolicy tunes access reviews and the inactivity sweep.
+type AccessReviewPolicy struct {
+	// InactiveDays deactivates admins who haven't signed in for this many
+	// days (counting from account creation for those who never have).
+	// 0 turns the sweep off.
+	InactiveDays int `json:"inactive_days"`
+	// DueDays is how long a quarter's review has before it's overdue.
+	DueDays int `json:"due_days"`
+}
+
+// DefaultAccessReviewPolicy applies when the setting is missing or
+// unreadable.
+var DefaultAccessReviewPolicy = AccessReviewPolicy{InactiveDays: 90, DueDays: 14}
+
+func (p AccessReviewPolicy) Validate() error {
+	switch {
+	case p.InactiveDays != 0 && (p.InactiveDays < 14 || p.InactiveDays > 365):
+		return fmt.Errorf("%w: inactive_days must be 0 (off) or 14-365", ErrAccessReviewPolicyInvalid)
+	case p.DueDays < 1 || p.DueDays > 60:
+		return fmt.Errorf("%w: due_days must be 1-60", ErrAccessReviewPolicyInvalid)
+	}
+	return nil
+}
+
+// accessReviewAdmins is the part of AdminRepository access reviews use;
+// status changes go through it so they replicate like any other.
+type accessReviewAdmins interface {
+	UpdateUserStatus(ctx context.Context, id uuid.UUID, status models.UserStatus) error
+	LogAction(ctx context.Context, adminID uuid.UUID, action, targetType string, targetID uuid.UUID, details map[string]interface{}, ip, userAgent string) error
+}
+
+type adminSessionRevoker interface {
+	RevokeForUserKind(ctx context.Context, userID uuid.UUID, kind models.SessionKind) error
+}
+
+type accessReviewMailer interface {
+	IsEnabled() bool
+	SendAccessReviewEmail(to, firstName, period, accounts, dueOn, reviewURL string) error
+}
+
+// AdminAccessReviewService runs the quarterly admin access reviews and
+// deactivates admins who have stopped signing in.
+type AdminAccessReviewService struct {
+	repo      repository.AdminAccessReviewRepository
+	admins    accessReviewAdmins
+	sessions  adminSessionRevoker
+	notify    adminNotifier
+	settings  settingsStore
+	mailer    accessReviewMailer
+	reviewURL string
+	now       func() time.Time
+}
+
+// NewAdminAccessReviewService creates the service. email may be nil;
+// super admins then only get the portal notification.
+func NewAdminAccessReviewService(repo repository.AdminAccessReviewRepository, admins accessReviewAdmins, sessions adminSessionRevoker,
+	notify adminNotifier, settings settingsStore, email *EmailService, appURL string) *AdminAccessReviewService {
+	s := &AdminAccessReviewService{
+		repo: repo, admins: admins, sessions: sessions, notify: notify, settings: settings,
+		reviewURL: appURL + "/admin/access-reviews", now: time.Now,
+	}
+	if email != nil {
+		s.mailer = email
+	}
+	return s
+}
+
+// Policy returns the stored policy, the default where it's missing.
+func (s *AdminAccessReviewService) Policy(ctx context.Context) (AccessReviewPolicy, error) {
+	out := DefaultAccessReviewPolicy
+	val, err := s.settings.GetSetting(ctx, AccessReviewPolicyKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	stored := DefaultAccessReviewPolicy
+	if err := json.Unmarshal(raw, &stored); err != nil || stored.Validate() != nil {
+		log.Printf("[ACCESS-REVIEW] %s setting unreadable, using defaults: %v", AccessReviewPolicyKey, err)
+		return out, nil
+	}
+	return stored, nil
+}
+
+// UpdatePolicy validates and stores a new policy.
+func (s *AdminAccessReviewService) UpdatePolicy(ctx context.Context, p AccessReviewPolicy, by uuid.UUID) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	return s.settings.UpdateSetting(ctx, AccessReviewPolicyKey, p, by)
+}
+
+// accessReviewPeriod names the quarter t falls in, e.g. 2026-Q4.
+func accessReviewPeriod(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+}
+
+// rolePermissions is what role can reach: section -> level, sections it
+// can't see left out.
+func rolePermissions(role string) map[string]string {
+	out := map[string]string{}
+	for _, section := range auth.Sections {
+		if lvl := auth.Matrix(models.SystemRole(role), section); lvl != auth.LevelNone {
+			out[section] = string(lvl)
+		}
+	}
+	return out
+}
+
+// reviewItems snapshots the accounts that can sign in, or will once they
+// accept an invitation. Suspended and deactivated accounts have no access
+// to attest.
+func reviewItems(accounts []repository.AdminAccount) []repository.AdminAccessReviewItem {
+	var items []repository.AdminAccessReviewItem
+	for _, a := range accounts {
+		if a.Status != string(models.UserStatusActive) && a.Status != string(models.UserStatusPendingVerification) {
+			continue
+		}
+		items = append(items, repository.AdminAccessReviewItem{
+			AdminID:     a.ID,
+			Email:       a.Email,
+			Name:        strings.TrimSpace(a.FirstName + " " + a.LastName),
+			Role:        a.Role,
+			Status:      a.Status,
+			LastLoginAt: a.LastLoginAt,
+			Permissions: rolePermissions(a.Role),
+		})
+	}
+	return items
+}
+
+// OpenReview opens this quarter's review if it isn't open yet and asks
+// the super admins to work through it. It returns the review, nil when
+// the quarter already had one.
+func (s *AdminAccessReviewService) OpenReview(ctx context.Context) (*repository.AdminAccessReview, error) {
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.repo.ListAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list admins: %w", err)
+	}
+	now := s.now()
+	review := &repository.AdminAccessReview{
+		Period: accessReviewPeriod(now),
+		DueAt:  now.Add(time.Duration(policy.DueDays) * 24 * time.Hour),
+	}
+	items := reviewItems(accounts)
+	created, err := s.repo.Open(ctx, review, items)
+	if err != nil || !created {
+		return nil, err
+	}
+	log.Printf("[ACCESS-REVIEW] Opened %s with %d accounts", review.Period, len(items))
+
+	dueOn := review.DueAt.UTC().Format("January 2, 2006")
+	details, _ := json.Marshal(map[string]interface{}{"review_id": review.ID, "period": review.Period, "accounts": len(items)})
+	if _, err := s.notify.Open(ctx, &repository.AdminNotification{
+		Kind:      AdminNotificationKindAccessReview,
+		Severity:  "warning",
+		Title:     "Admin access review " + review.Period + " is open",
+		Message:   fmt.Sprintf("Attest or revoke each of the %d admin accounts by %s.", len(items), dueOn),
+		DedupeKey: AdminNotificationKindAccessReview + ":" + review.Period,
+		Details:   details,
+	}); err != nil {
+		log.Printf("[ACCESS-REVIEW] notify %s: %v", review.Period, err)
+	}
+	if s.mailer != nil && s.mailer.IsEnabled() {
+		for _, a := range accounts {
+			if a.Role != string(models.SystemRoleSuperAdmin) || a.Status != string(models.UserStatusActive) {
+				continue
+			}
+			if err := s.mailer.SendAccessReviewEmail(a.Email, a.FirstName, review.Period, strconv.Itoa(len(items)), dueOn, s.reviewURL); err != nil {
+				log.Printf("[ACCESS-REVIEW] email %s: %v", a.Email, err)
+			}
+		}
+	}
+	return review, nil
+}
+
+// remindOverdue flags the latest review once it's past due and still
+// open.
+func (s *AdminAccessReviewService) remindOverdue(ctx context.Context) error {
+	reviews, err := s.repo.List(ctx, 1)
+	if err != nil || len(reviews) == 0 {
+		return err
+	}
+	rv := reviews[0]
+	if rv.CompletedAt != nil || s.now().Before(rv.DueAt) {
+		return nil
+	}
+	details, _ := json.Marshal(map[string]interface{}{"review_id": rv.ID, "period": rv.Period, "pending": rv.Pending()})
+	_, err = s.notify.Open(ctx, &repository.AdminNotification{
+		Kind:      AdminNotificationKindAccessReview,
+		Severity:  "critical",
+		Title:     "Admin access review " + rv.Period + " is overdue",
+		Message:   fmt.Sprintf("%d of %d accounts still need a decision; the review was due %s.", rv.Pending(), rv.Items, rv.DueAt.UTC().Format("January 2")),
+		DedupeKey: AdminNotificationKindAccessReview + ":overdue:" + rv.Period,
+		Details:   details,
+	})
+	return err
+}
+
+// inactiveAdmins picks the active accounts nobody has signed in to since
+// cutoff. The last super admin who could still sign in is spared even
+// then, so the sweep can't lock everyone out of the portal.
+func inactiveAdmins(accounts []repository.AdminAccount, cutoff time.Time) []repository.AdminAccount {
+	lastSeen := func(a repository.AdminAccount) time.Time {
+		if a.LastLoginAt != nil {
+			return *a.LastLoginAt
+		}
+		return a.CreatedAt
+	}
+	var stale, supers []repository.AdminAccount
+	keptSuper := false
+	for _, a := range accounts {
+		if a.Status != string(models.UserStatusActive) {
+			continue
+		}
+		isSuper := a.Role == string(models.SystemRoleSuperAdmin)
+		if !lastSeen(a).Before(cutoff) {
+			keptSuper = keptSuper || isSuper
+			continue
+		}
+		if isSuper {
+			supers = append(supers, a)
+			continue
+		}
+		stale = append(stale, a)
+	}
+	if len(supers) > 0 && !keptSuper {
+		sort.SliceStable(supers, func(i, j int) bool { return lastSeen(supers[i]).After(lastSeen(supers[j])) })
+		supers = supers[1:]
+	}
+	return append(stale, supers...)
+}
+
+// DeactivateInactive sets admins who haven't signed in within the
+// policy's window to inactive and signs them out. A super admin can
+// reactivate them from the admin users page.
+func (s *AdminAccessReviewService) DeactivateInactive(ctx context.Context) ([]repository.AdminAccount, error) {
+	policy, err := s.Policy(ctx)
+	if err != nil || policy.InactiveDays == 0 {
+		return nil, err
+	}
+	accounts, err := s.repo.ListAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list admins: %w", err)
+	}
+	var done []repository.AdminAccount
+	for _, a := range inactiveAdmins(accounts, s.now().Add(-time.Duration(policy.InactiveDays)*24*time.Hour)) {
+		if err := s.admins.UpdateUserStatus(ctx, a.ID, models.UserStatusInactive); err != nil {
+			log.Printf("[ACCESS-REVIEW] deactivate %s: %v", a.Email, err)
+			continue
+		}
+		if err := s.sessions.RevokeForUserKind(ctx, a.ID, models.SessionKindAdmin); err != nil {
+			log.Printf("[ACCESS-REVIEW] revoke sessions of %s: %v", a.Email, err)
+		}
+		if err := s.admins.LogAction(ctx, uuid.Nil, "deactivate_inactive_admin", "user", a.ID, map[string]interface{}{
+			"actor": "scheduler", "email": a.Email, "role": a.Role,
+			"last_login_at": a.LastLoginAt, "inactive_days": policy.InactiveDays,
+		}, "", "scheduler"); err != nil {
+			log.Printf("[ACCESS-REVIEW] audit deactivation of %s: %v", a.Email, err)
+		}
+		done = append(done, a)
+	}
+	if len(done) == 0 {
+		return nil, nil
+	}
+	emails := make([]string, len(done))
+	for i, a := range done {
+		emails[i] = a.Email
+	}
+	log.Printf("[ACCESS-REVIEW] Deactivated %d admins inactive for %d days: %s", len(done), policy.InactiveDays, strings.Join(emails, ", "))
+	details, _ := json.Marshal(map[string]interface{}{"emails": emails, "inactive_days": policy.InactiveDays})
+	if _, err := s.notify.Open(ctx, &repository.AdminNotification{
+		Kind:      AdminNotificationKindAdminDeactivated,
+		Severity:  "info",
+		Title:     fmt.Sprintf("%d inactive admin account(s) deactivated", len(done)),
+		Message:   fmt.Sprintf("No sign-in for %d days: %s. Reactivate them from Admin Users if they still need access.", policy.InactiveDays, strings.Join(emails, ", ")),
+		DedupeKey: AdminNotificationKindAdminDeactivated + ":" + s.now().UTC().Format("2006-01-02"),
+		Details:   details,
+	}); err != nil {
+		log.Printf("[ACCESS-REVIEW] notify deactivations: %v", err)
+	}
+	return done, nil
+}
+
+// Run is the daily job: open the quarter's review, flag an overdue one
+// and sweep inactive admins.
+func (s *AdminAccessReviewService) Run(ctx context.Context) error {
+	var errs []string
+	if _, err := s.OpenReview(ctx); err != nil {
+		errs = append(errs, "open review: "+err.Error())
+	}
+	if err := s.remindOverdue(ctx); err != nil {
+		errs = append(errs, "overdue reminder: "+err.Error())
+	}
+	if _, err := s.DeactivateInactive(ctx); err != nil {
+		errs = append(errs, "inactivity sweep: "+err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// List returns reviews newest first.
+func (s *AdminAccessReviewService) List(ctx context.Context, limit int) ([]repository.AdminAccessReview, error) {
+	return s.repo.List(ctx, limit)
+}
+
+// Get returns a review with its checklist.
+func (s *AdminAccessReviewService) Get(ctx context.Context, id uuid.UUID) (*repository.AdminAccessReview, []repository.AdminAccessReviewItem, error) {
+	rv, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rv == nil {
+		return nil, nil, ErrAccessReviewNotFound
+	}
+	items, err := s.repo.Items(ctx, id)
+	return rv, items, err
+}
+
+// soleSuperAdmin reports whether adminID is the only active super admin
+// under review, who has no one else to attest them.
+func soleSuperAdmin(items []repository.AdminAccessReviewItem, adminID uuid.UUID) bool {
+	for _, it := range items {
+		if it.AdminID != adminID && it.Role == string(models.SystemRoleSuperAdmin) && it.Status == string(models.UserStatusActive) {
+			return false
+		}
+	}
+	return true
+}
+
+// Decide records by's decision on one account. Revoking suspends the
+// admin and signs them out straight away. Nobody reviews their own
+// access unless they're the only super admin.
+func (s *AdminAccessReviewService) Decide(ctx context.Context, reviewID, itemID uuid.UUID, decision string, by uuid.UUID, note string) (*repository.AdminAccessReviewItem, error) {
+	if decision != repository.AccessReviewAttest && decision != repository.AccessReviewRevoke {
+		return nil, ErrAccessReviewInvalidDecision
+	}
+	rv, items, err := s.Get(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if rv.CompletedAt != nil {
+		return nil, ErrAccessReviewCompleted
+	}
+	var item *repository.AdminAccessReviewItem
+	for i := range items {
+		if items[i].ID == itemID {
+			item = &items[i]
+		}
+	}
+	if item == nil {
+		return nil, ErrAccessReviewItemNotFound
+	}
+	if item.AdminID == by && !soleSuperAdmin(items, by) {
+		return nil, ErrAccessReviewSelf
+	}
+	ok, err := s.repo.Decide(ctx, itemID, decision, by, strings.TrimSpace(note))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAccessReviewDecided
+	}
+	if decision == repository.AccessReviewRevoke {
+		if err := s.admins.UpdateUserStatus(ctx, item.AdminID, models.UserStatusSuspended); err != nil {
+			return nil, fmt.Errorf("decision recorded but suspending %s failed: %w", item.Email, err)
+		}
+		if err := s.sessions.RevokeForUserKind(ctx, item.AdminID, models.SessionKindAdmin); err != nil {
+			log.Printf("[ACCESS-REVIEW] revoke sessions of %s: %v", item.Email, err)
+		}
+	}
+	return s.repo.GetItem(ctx, reviewID, itemID)
+}
+
+// Complete closes a review once every account has a decision. After that
+// the review can't change.
+func (s *AdminAccessReviewService) Complete(ctx context.Context, id, by uuid.UUID) (*repository.AdminAccessReview, error) {
+	rv, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case rv == nil:
+		return nil, ErrAccessReviewNotFound
+	case rv.CompletedAt != nil:
+		return nil, ErrAccessReviewCompleted
+	case rv.Pending() > 0:
+		return nil, ErrAccessReviewIncomplete
+	}
+	ok, err := s.repo.Complete(ctx, id, by)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAccessReviewCompleted
+	}
+	return s.repo.Get(ctx, id)
+}
+
+// AccessReviewScheduler runs the access review job once a day.
+type AccessReviewScheduler struct {
+	svc  *AdminAccessReviewService
+	jobs *JobLocker
+}
+
+func NewAccessReviewScheduler(svc *AdminAccessReviewService, jobs *JobLocker) *AccessReviewScheduler {
+	return &AccessReviewScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *AccessReviewScheduler) Start(ctx context.Context) {
+	log.Println("Access review scheduler started")
+	check := func() {
+		s.jobs.RunOnce(ctx, "access_review", TickSlot(time.Now(), 24*time.Hour), 24*time.Hour, func(ctx context.Context) {
+			if err := s.svc.Run(ctx); err != nil {
+				log.Printf("Access review: tick failed: %v", err)
+			}
+		})
+	}
+	check()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Access review scheduler stopped")
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
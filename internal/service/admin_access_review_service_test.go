@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+func TestAccessReviewPeriod(t *testing.T) {
+	for in, want := range map[string]string{
+		"2026-01-01T00:00:00Z": "2026-Q1",
+		"2026-03-31T23:59:59Z": "2026-Q1",
+		"2026-04-01T00:00:00Z": "2026-Q2",
+		"2026-10-17T12:00:00Z": "2026-Q4",
+	} {
+		ts, _ := time.Parse(time.RFC3339, in)
+		if got := accessReviewPeriod(ts); got != want {
+			t.Errorf("accessReviewPeriod(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestAccessReviewPolicyValidate(t *testing.T) {
+	for _, p := range []AccessReviewPolicy{DefaultAccessReviewPolicy, {InactiveDays: 0, DueDays: 7}} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+	for _, p := range []AccessReviewPolicy{{InactiveDays: 5, DueDays: 14}, {InactiveDays: 90, DueDays: 0}} {
+		if err := p.Validate(); !errors.Is(err, ErrAccessReviewPolicyInvalid) {
+			t.Errorf("%+v: err = %v", p, err)
+		}
+	}
+}
+
+func account(email, role, status string, lastLogin *time.Time, created time.Time) repository.AdminAccount {
+	return repository.AdminAccount{ID: uuid.New(), Email: email, Role: role, Status: status, LastLoginAt: lastLogin, CreatedAt: created}
+}
+
+func TestReviewItems(t *testing.T) {
+	now := time.Now()
+	items := reviewItems([]repository.AdminAccount{
+		account("boss@example.com", "super_admin", "active", &now, now),
+		account("help@example.com", "support", "pending_verification", nil, now),
+		account("gone@example.com", "support", "suspended", &now, now),
+	})
+	if len(items) != 2 {
+		t.Fatalf("items = %+v", items)
+	}
+	if items[0].Permissions["admin_users"] != "full" {
+		t.Errorf("super admin permissions = %v", items[0].Permissions)
+	}
+	if _, ok := items[1].Permissions["admin_users"]; ok {
+		t.Errorf("support permissions = %v", items[1].Permissions)
+	}
+}
+
+func TestInactiveAdmins(t *testing.T) {
+	now := time.Now()
+	cutoff := now.Add(-90 * 24 * time.Hour)
+	old, older, recent := now.Add(-100*24*time.Hour), now.Add(-200*24*time.Hour), now.Add(-time.Hour)
+
+	emails := func(as []repository.AdminAccount) map[string]bool {
+		out := map[string]bool{}
+		for _, a := range as {
+			out[a.Email] = true
+		}
+		return out
+	}
+
+	got := emails(inactiveAdmins([]repository.AdminAccount{
+		account("stale@example.com", "support", "active", &old, older),
+		account("never@example.com", "marketing", "active", nil, older),
+		account("new@example.com", "marketing", "active", nil, recent),
+		account("fresh@example.com", "support", "active", &recent, older),
+		account("suspended@example.com", "support", "suspended", &older, older),
+		account("boss@example.com", "super_admin", "active", &recent, older),
+		account("oldboss@example.com", "super_admin", "active", &old, older),
+	}, cutoff))
+	want := map[string]bool{"stale@example.com": true, "never@example.com": true, "oldboss@example.com": true}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for e := range want {
+		if !got[e] {
+			t.Errorf("%s not deactivated", e)
+		}
+	}
+
+	// With no super admin left active, the most recently seen one stays.
+	got = emails(inactiveAdmins([]repository.AdminAccount{
+		account("a@example.com", "super_admin", "active", &old, older),
+		account("b@example.com", "super_admin", "active", &older, older),
+	}, cutoff))
+	if got["a@example.com"] || !got["b@example.com"] {
+		t.Errorf("got %v, want only b@example.com", got)
+	}
+}
+
+// fakeAccessReviewRepo holds one review in memory.
+type fakeAccessReviewRepo struct {
+	repository.AdminAccessReviewRepository
+	review repository.AdminAccessReview
+	items  []repository.AdminAccessReviewItem
+}
+
+func (f *fakeAccessReviewRepo) Get(_ context.Context, id uuid.UUID) (*repository.AdminAccessReview, error) {
+	if id != f.review.ID {
+		return nil, nil
+	}
+	rv := f.review
+	return &rv, nil
+}
+
+func (f *fakeAccessReviewRepo) Items(context.Context, uuid.UUID) ([]repository.AdminAccessReviewItem, error) {
+	return append([]repository.AdminAccessReviewItem(nil), f.items...), nil
+}
+
+func (f *fakeAccessReviewRepo) GetItem(_ context.Context, _, itemID uuid.UUID) (*repository.AdminAccessReviewItem, error) {
+	for i := range f.items {
+		if f.items[i].ID == itemID {
+			it := f.items[i]
+			return &it, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeAccessReviewRepo) Decide(_ context.Context, itemID uuid.UUID, decision string, by uuid.UUID, note string) (bool, error) {
+	for i := range f.items {
+		if f.items[i].ID == itemID && f.items[i].Decision == "" {
+			f.items[i].Decision, f.items[i].DecidedBy, f.items[i].Note = decision, &by, note
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeReviewAdmins struct {
+	status  map[uuid.UUID]models.UserStatus
+	revoked []uuid.UUID
+}
+
+func (f *fakeReviewAdmins) UpdateUserStatus(_ context.Context, id uuid.UUID, status models.UserStatus) error {
+	f.status[id] = status
+	return nil
+}
+
+func (f *fakeReviewAdmins) LogAction(context.Context, uuid.UUID, string, string, uuid.UUID, map[string]interface{}, string, string) error {
+	return nil
+}
+
+func (f *fakeReviewAdmins) RevokeForUserKind(_ context.Context, id uuid.UUID, _ models.SessionKind) error {
+	f.revoked = append(f.revoked, id)
+	return nil
+}
+
+func TestAccessReviewDecide(t *testing.T) {
+	boss, other, helper := uuid.New(), uuid.New(), uuid.New()
+	repo := &fakeAccessReviewRepo{
+		review: repository.AdminAccessReview{ID: uuid.New(), Period: "2026-Q4", Items: 3},
+		items: []repository.AdminAccessReviewItem{
+			{ID: uuid.New(), AdminID: boss, Email: "boss@example.com", Role: "super_admin", Status: "active"},
+			{ID: uuid.New(), AdminID: other, Email: "other@example.com", Role: "super_admin", Status: "active"},
+			{ID: uuid.New(), AdminID: helper, Email: "help@example.com", Role: "support", Status: "active"},
+		},
+	}
+	admins := &fakeReviewAdmins{status: map[uuid.UUID]models.UserStatus{}}
+	s := &AdminAccessReviewService{repo: repo, admins: admins, sessions: admins, now: time.Now}
+	ctx := context.Background()
+	rid := repo.review.ID
+
+	if _, err := s.Decide(ctx, rid, repo.items[0].ID, "attest", boss, ""); !errors.Is(err, ErrAccessReviewSelf) {
+		t.Errorf("self attest: err = %v", err)
+	}
+	if _, err := s.Decide(ctx, rid, repo.items[0].ID, "maybe", other, ""); !errors.Is(err, ErrAccessReviewInvalidDecision) {
+		t.Errorf("bad decision: err = %v", err)
+	}
+	if _, err := s.Decide(ctx, rid, repo.items[0].ID, "attest", other, "still runs ops"); err != nil {
+		t.Errorf("attest: %v", err)
+	}
+	it, err := s.Decide(ctx, rid, repo.items[2].ID, "revoke", boss, "left the team")
+	if err != nil || it.Decision != "revoke" {
+		t.Fatalf("revoke: %+v, %v", it, err)
+	}
+	if admins.status[helper] != models.UserStatusSuspended || len(admins.revoked) != 1 || admins.revoked[0] != helper {
+		t.Errorf("revoke didn't suspend: %v %v", admins.status, admins.revoked)
+	}
+	if _, err := s.Decide(ctx, rid, repo.items[2].ID, "attest", boss, ""); !errors.Is(err, ErrAccessReviewDecided) {
+		t.Errorf("second decision: err = %v", err)
+	}
+
+	now := time.Now()
+	repo.review.CompletedAt = &now
+	if _, err := s.Decide(ctx, rid, repo.items[1].ID, "attest", boss, ""); !errors.Is(err, ErrAccessReviewCompleted) {
+		t.Errorf("completed review: err = %v", err)
+	}
+}
+
+func TestSoleSuperAdminMayReviewThemselves(t *testing.T) {
+	boss := uuid.New()
+	items := []repository.AdminAccessReviewItem{
+		{AdminID: boss, Role: "super_admin", Status: "active"},
+		{AdminID: uuid.New(), Role: "super_admin", Status: "pending_verification"},
+		{AdminID: uuid.New(), Role: "support", Status: "active"},
+	}
+	if !soleSuperAdmin(items, boss) {
+		t.Error("the only active super admin can't review themselves")
+	}
+	items[1].Status = "active"
+	if soleSuperAdmin(items, boss) {
+		t.Error("a second super admin should review")
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/auth"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrElevationNotFound       = apperr.NotFound("elevation not found")
+	ErrElevationInvalidSection = apperr.Validation("unknown section")
+	ErrElevationInvalidLevel   = apperr.Validation("level must be read, write or full")
+	ErrElevationInvalidTime    = apperr.Validation("duration must be 15 minutes to 8 hours")
+	ErrElevationReasonRequired = apperr.Validation("a reason is required")
+	ErrElevationNotNeeded      = apperr.Validation("your role already has that access")
+	ErrElevationOpen           = apperr.Conflict("you already have a pending or active elevation for this section")
+	ErrElevationNotPending     = apperr.Conflict("this elevation is no longer pending")
+	ErrElevationNotActive      = apperr.Conflict("this elevation isn't active")
+	ErrElevationForbidden      = apperr.Forbidden("only the requester or a super admin can do that")
+	ErrElevationReadOnly       = apperr.Forbidden("read-only roles can't be elevated")
+)
+
+const (
+	// ElevationMinDuration and ElevationMaxDuration bound how long an
+	// approved elevation lasts.
+	ElevationMinDuration = 15 * time.Minute
+	ElevationMaxDuration = 8 * time.Hour
+
+	// AdminNotificationKindElevationRequest is the admin_notifications
+	// kind opened for super admins when an elevation is requested.
+	AdminNotificationKindElevationRequest = "elevation_request"
+)
+
+// AdminElevationService handles just-in-time elevation: an admin asks for
+// a section at a higher level than their role grants for a while, a super
+// admin approves or denies it, and RequireSection honours it until it
+// expires or is revoked.
+type AdminElevationService struct {
+	repo   repository.AdminElevationRepository
+	notify adminNotifier
+}
+
+// NewAdminElevationService creates the service.
+func NewAdminElevationService(repo repository.AdminElevationRepository, notify adminNotifier) *AdminElevationService {
+	return &AdminElevationService{repo: repo, notify: notify}
+}
+
+// ElevationRequest is what an admin submits.
+type ElevationRequest struct {
+	Section         string `json:"section"`
+	Level           string `json:"level"`
+	DurationMinutes int    `json:"duration_minutes"`
+	Reason          string `json:"reason"`
+}
+
+func validSection(section string) bool {
+	for _, s := range auth.Sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks req for an admin with role.
+func (req ElevationRequest) Validate(role models.SystemRole) error {
+	level := auth.Level(req.Level)
+	d := time.Duration(req.DurationMinutes) * time.Minute
+	switch {
+	case auth.ReadOnly(role):
+		return ErrElevationReadOnly
+	case !validSection(req.Section):
+		return ErrElevationInvalidSection
+	case level != auth.LevelRead && level != auth.LevelWrite && level != auth.LevelFull:
+		return ErrElevationInvalidLevel
+	case d < ElevationMinDuration || d > ElevationMaxDuration:
+		return ErrElevationInvalidTime
+	case strings.TrimSpace(req.Reason) == "":
+		return ErrElevationReasonRequired
+	case role == models.SystemRoleSuperAdmin || auth.RankAtLeast(auth.Matrix(role, req.Section), level):
+		return ErrElevationNotNeeded
+	}
+	return nil
+}
+
+// Request files an elevation for adminID and notifies super admins.
+func (s *AdminElevationService) Request(ctx context.Context, adminID uuid.UUID, role models.SystemRole, req ElevationRequest) (*repository.AdminElevation, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := req.Validate(role); err != nil {
+		return nil, err
+	}
+	open, err := s.repo.Open(ctx, adminID, req.Section)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, ErrElevationOpen
+	}
+	e := &repository.AdminElevation{
+		AdminID: adminID, Section: req.Section, Level: req.Level,
+		DurationMinutes: req.DurationMinutes, Reason: req.Reason,
+	}
+	if err := s.repo.Create(ctx, e); err != nil {
+		return nil, err
+	}
+	if e, err = s.repo.Get(ctx, e.ID); err != nil || e == nil {
+		return e, err
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{"elevation_id": e.ID, "admin_id": adminID})
+	if _, err := s.notify.Open(ctx, &repository.AdminNotification{
+		Kind:      AdminNotificationKindElevationRequest,
+		Severity:  "warning",
+		Title:     fmt.Sprintf("%s asks for %s access to %s", e.AdminEmail, e.Level, sectionLabel(e.Section)),
+		Message:   fmt.Sprintf("For %s: %s", time.Duration(e.DurationMinutes)*time.Minute, e.Reason),
+		DedupeKey: AdminNotificationKindElevationRequest + ":" + e.ID.String(),
+		Details:   details,
+	}); err != nil {
+		log.Printf("[ELEVATION] notify %s: %v", e.ID, err)
+	}
+	return e, nil
+}
+
+func sectionLabel(section string) string {
+	if l, ok := auth.SectionLabels[section]; ok {
+		return l
+	}
+	return section
+}
+
+// Get returns an elevation.
+func (s *AdminElevationService) Get(ctx context.Context, id uuid.UUID) (*repository.AdminElevation, error) {
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrElevationNotFound
+	}
+	return e, nil
+}
+
+// List returns elevations newest first.
+func (s *AdminElevationService) List(ctx context.Context, f repository.AdminElevationFilter) ([]repository.AdminElevation, error) {
+	return s.repo.List(ctx, f)
+}
+
+// decide moves a pending elevation to status and returns it afterwards.
+func (s *AdminElevationService) decide(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (*repository.AdminElevation, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.Decide(ctx, id, status, by, strings.TrimSpace(note))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrElevationNotPending
+	}
+	return s.Get(ctx, id)
+}
+
+// Approve starts a pending elevation's window now. Callers gate this to
+// super admins.
+func (s *AdminElevationService) Approve(ctx context.Context, id, by uuid.UUID, note string) (*repository.AdminElevation, error) {
+	e, err := s.decide(ctx, id, repository.ElevationApproved, by, note)
+	if err == nil {
+		log.Printf("[ELEVATION] %s approved %s for %s until %s", by, e.Section, e.AdminEmail, e.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return e, err
+}
+
+// Deny turns a pending elevation down. Callers gate this to super admins.
+func (s *AdminElevationService) Deny(ctx context.Context, id, by uuid.UUID, note string) (*repository.AdminElevation, error) {
+	return s.decide(ctx, id, repository.ElevationDenied, by, note)
+}
+
+// Cancel withdraws the requester's own pending elevation.
+func (s *AdminElevationService) Cancel(ctx context.Context, id, by uuid.UUID) (*repository.AdminElevation, error) {
+	e, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.AdminID != by {
+		return nil, ErrElevationForbidden
+	}
+	return s.decide(ctx, id, repository.ElevationCancelled, by, "")
+}
+
+// Revoke ends an active elevation early. The requester may end their own;
+// anyone else has to be a super admin.
+func (s *AdminElevationService) Revoke(ctx context.Context, id, by uuid.UUID, superAdmin bool) (*repository.AdminElevation, error) {
+	e, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.AdminID != by && !superAdmin {
+		return nil, ErrElevationForbidden
+	}
+	ok, err := s.repo.Revoke(ctx, id, by)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrElevationNotActive
+	}
+	return s.Get(ctx, id)
+}
+
+// ActiveIDs returns the ids of the admin's active elevations, for tagging
+// their audit rows. Errors are logged and read as none.
+func (s *AdminElevationService) ActiveIDs(ctx context.Context, adminID uuid.UUID) []uuid.UUID {
+	active, err := s.repo.Active(ctx, adminID)
+	if err != nil {
+		log.Printf("[ELEVATION] active for %s: %v", adminID, err)
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(active))
+	for _, e := range active {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+// ElevatedLevel returns the highest level the admin's active elevations
+// grant on section and the id of the one granting it, or uuid.Nil when
+// none does. It satisfies middleware.ElevationResolver.
+func (s *AdminElevationService) ElevatedLevel(ctx context.Context, adminID uuid.UUID, section string) (auth.Level, uuid.UUID) {
+	active, err := s.repo.Active(ctx, adminID)
+	if err != nil {
+		log.Printf("[ELEVATION] active for %s: %v", adminID, err)
+		return auth.LevelNone, uuid.Nil
+	}
+	level, id := auth.LevelNone, uuid.Nil
+	for _, e := range active {
+		if e.Section == section && !auth.RankAtLeast(level, auth.Level(e.Level)) {
+			level, id = auth.Level(e.Level), e.ID
+		}
+	}
+	return level, id
+}
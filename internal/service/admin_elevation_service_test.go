@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/auth"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+func TestElevationRequestValidate(t *testing.T) {
+	ok := ElevationRequest{Section: "financials", Level: "write", DurationMinutes: 120, Reason: "refund for #1234"}
+	if err := ok.Validate(models.SystemRoleSupport); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+	for _, c := range []struct {
+		name string
+		mod  func(*ElevationRequest)
+		role models.SystemRole
+		want error
+	}{
+		{"section", func(r *ElevationRequest) { r.Section = "nope" }, models.SystemRoleSupport, ErrElevationInvalidSection},
+		{"level", func(r *ElevationRequest) { r.Level = "admin" }, models.SystemRoleSupport, ErrElevationInvalidLevel},
+		{"too short", func(r *ElevationRequest) { r.DurationMinutes = 5 }, models.SystemRoleSupport, ErrElevationInvalidTime},
+		{"too long", func(r *ElevationRequest) { r.DurationMinutes = 9 * 60 }, models.SystemRoleSupport, ErrElevationInvalidTime},
+		{"reason", func(r *ElevationRequest) { r.Reason = "  " }, models.SystemRoleSupport, ErrElevationReasonRequired},
+		{"super admin", func(r *ElevationRequest) {}, models.SystemRoleSuperAdmin, ErrElevationNotNeeded},
+		{"already granted", func(r *ElevationRequest) { r.Section, r.Level = "tickets", "read" }, models.SystemRolePartner, ErrElevationNotNeeded},
+	} {
+		req := ok
+		c.mod(&req)
+		if err := req.Validate(c.role); !errors.Is(err, c.want) {
+			t.Errorf("%s: err = %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+// fakeElevationRepo keeps elevations in memory.
+type fakeElevationRepo struct {
+	repository.AdminElevationRepository
+	byID map[uuid.UUID]*repository.AdminElevation
+}
+
+func (f *fakeElevationRepo) Create(_ context.Context, e *repository.AdminElevation) error {
+	e.ID, e.Status, e.RequestedAt = uuid.New(), repository.ElevationPending, time.Now()
+	cp := *e
+	f.byID[e.ID] = &cp
+	return nil
+}
+
+func (f *fakeElevationRepo) Get(_ context.Context, id uuid.UUID) (*repository.AdminElevation, error) {
+	e, ok := f.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *e
+	return &cp, nil
+}
+
+func (f *fakeElevationRepo) Active(_ context.Context, adminID uuid.UUID) ([]repository.AdminElevation, error) {
+	var out []repository.AdminElevation
+	for _, e := range f.byID {
+		if e.AdminID == adminID && e.CurrentStatus(time.Now()) == repository.ElevationApproved {
+			out = append(out, *e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeElevationRepo) Open(_ context.Context, adminID uuid.UUID, section string) (bool, error) {
+	for _, e := range f.byID {
+		st := e.CurrentStatus(time.Now())
+		if e.AdminID == adminID && e.Section == section && (st == repository.ElevationPending || st == repository.ElevationApproved) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeElevationRepo) Decide(_ context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error) {
+	e, ok := f.byID[id]
+	if !ok || e.Status != repository.ElevationPending {
+		return false, nil
+	}
+	e.Status, e.DecidedBy, e.DecisionNote = status, &by, note
+	if status == repository.ElevationApproved {
+		exp := time.Now().Add(time.Duration(e.DurationMinutes) * time.Minute)
+		e.ExpiresAt = &exp
+	}
+	return true, nil
+}
+
+func (f *fakeElevationRepo) Revoke(_ context.Context, id, by uuid.UUID) (bool, error) {
+	e, ok := f.byID[id]
+	if !ok || e.CurrentStatus(time.Now()) != repository.ElevationApproved {
+		return false, nil
+	}
+	e.Status, e.RevokedBy = repository.ElevationRevoked, &by
+	return true, nil
+}
+
+func TestElevationLifecycle(t *testing.T) {
+	repo := &fakeElevationRepo{byID: map[uuid.UUID]*repository.AdminElevation{}}
+	notify := &fakeNotifier{opened: map[string]repository.AdminNotification{}}
+	s := NewAdminElevationService(repo, notify)
+	ctx := context.Background()
+	helper, boss, other := uuid.New(), uuid.New(), uuid.New()
+
+	req := ElevationRequest{Section: "financials", Level: "write", DurationMinutes: 120, Reason: "refund"}
+	e, err := s.Request(ctx, helper, models.SystemRoleSupport, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := notify.opened[AdminNotificationKindElevationRequest+":"+e.ID.String()]; !ok || n.Title == "" {
+		t.Errorf("notifications = %+v", notify.opened)
+	}
+	if _, err := s.Request(ctx, helper, models.SystemRoleSupport, req); !errors.Is(err, ErrElevationOpen) {
+		t.Errorf("duplicate request: err = %v", err)
+	}
+	if lvl, id := s.ElevatedLevel(ctx, helper, "financials"); id != uuid.Nil || lvl != auth.LevelNone {
+		t.Errorf("pending elevation granted %s", lvl)
+	}
+
+	if _, err := s.Cancel(ctx, e.ID, other); !errors.Is(err, ErrElevationForbidden) {
+		t.Errorf("cancel by someone else: err = %v", err)
+	}
+	if _, err := s.Approve(ctx, e.ID, boss, "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Deny(ctx, e.ID, boss, ""); !errors.Is(err, ErrElevationNotPending) {
+		t.Errorf("deny after approve: err = %v", err)
+	}
+	if lvl, id := s.ElevatedLevel(ctx, helper, "financials"); id != e.ID || lvl != auth.LevelWrite {
+		t.Errorf("ElevatedLevel = %s, %v", lvl, id)
+	}
+	if lvl, _ := s.ElevatedLevel(ctx, helper, "subscriptions"); lvl != auth.LevelNone {
+		t.Errorf("other section elevated to %s", lvl)
+	}
+	if ids := s.ActiveIDs(ctx, helper); len(ids) != 1 || ids[0] != e.ID {
+		t.Errorf("ActiveIDs = %v", ids)
+	}
+
+	if _, err := s.Revoke(ctx, e.ID, other, false); !errors.Is(err, ErrElevationForbidden) {
+		t.Errorf("revoke by non-super admin: err = %v", err)
+	}
+	if _, err := s.Revoke(ctx, e.ID, helper, false); err != nil {
+		t.Fatalf("requester ends early: %v", err)
+	}
+	if _, id := s.ElevatedLevel(ctx, helper, "financials"); id != uuid.Nil {
+		t.Error("revoked elevation still grants access")
+	}
+
+	// Expiry needs no action.
+	e2, err := s.Request(ctx, helper, models.SystemRoleSupport, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, e2.ID, boss, ""); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Minute)
+	repo.byID[e2.ID].ExpiresAt = &past
+	if _, id := s.ElevatedLevel(ctx, helper, "financials"); id != uuid.Nil {
+		t.Error("expired elevation still grants access")
+	}
+	if _, err := s.Revoke(ctx, e2.ID, boss, true); !errors.Is(err, ErrElevationNotActive) {
+		t.Errorf("revoke expired: err = %v", err)
+	}
+}
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrAdminInviteInvalid  = apperr.NotFound("this invitation link isn't valid")
+	ErrAdminInviteUsed     = apperr.Conflict("this invitation has already been accepted")
+	ErrAdminInviteExpired  = apperr.Validation("this invitation has expired; ask a super admin for a new link")
+	ErrAdminInviteAccepted = apperr.Conflict("this admin has already accepted their invitation")
+	ErrAdminImportTooLarge = apperr.Validation(fmt.Sprintf("an import can have at most %d rows", maxAdminImportRows))
+)
+
+const (
+	// adminInviteTTL is how long an invitation link works.
+	adminInviteTTL = 7 * 24 * time.Hour
+	// maxAdminImportRows caps one CSV; onboarding a team is tens of rows.
+	maxAdminImportRows = 500
+	// minAdminPasswordLength matches adminctl and the app's password rules.
+	minAdminPasswordLength = 8
+)
+
+// AdminInviteService imports admins in bulk and onboards them through
+// one-time invitation links rather than passwords someone has to send
+// them. An imported admin is created pending_verification with a random
+// password nobody knows, so they can't sign in until they open their link
+// and choose one.
+type AdminInviteService struct {
+	admins  repository.AdminRepository
+	users   repository.UserRepository
+	invites repository.AdminInvitationRepository
+	email   *EmailService
+	appURL  string
+}
+
+// NewAdminInviteService wires the dependencies. admins should be the
+// replicating repository when admin mirroring is on, so imported admins
+// and their passwords reach the other env; email may be nil (adminctl),
+// in which case links are only returned.
+func NewAdminInviteService(admins repository.AdminRepository, users repository.UserRepository, invites repository.AdminInvitationRepository, email *EmailService, appURL string) *AdminInviteService {
+	return &AdminInviteService{
+		admins:  admins,
+		users:   users,
+		invites: invites,
+		email:   email,
+		appURL:  strings.TrimRight(appURL, "/"),
+	}
+}
+
+// AdminImportRow is one admin to create, from a CSV line.
+type AdminImportRow struct {
+	Line      int    `json:"line"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Role      string `json:"role"`
+}
+
+// AdminImportResult is what became of one row.
+type AdminImportResult struct {
+	Line  int    `json:"line"`
+	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
+	// Status is "invited", "valid" (dry run) or "error".
+	Status  string     `json:"status"`
+	Error   string     `json:"error,omitempty"`
+	AdminID *uuid.UUID `json:"admin_id,omitempty"`
+	// InviteURL is shown once, here; only its hash is stored.
+	InviteURL string     `json:"invite_url,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Emailed   bool       `json:"emailed,omitempty"`
+}
+
+// AdminImportReport summarizes an import.
+type AdminImportReport struct {
+	BatchID uuid.UUID           `json:"batch_id"`
+	DryRun  bool                `json:"dry_run"`
+	Invited int                 `json:"invited"`
+	Failed  int                 `json:"failed"`
+	Rows    []AdminImportResult `json:"rows"`
+}
+
+// AdminImportOptions control an import.
+type AdminImportOptions struct {
+	// InvitedBy is the importing admin; uuid.Nil from the CLI.
+	InvitedBy uuid.UUID
+	// DryRun validates every row, including against existing admins,
+	// without creating anything.
+	DryRun bool
+	// SendEmail emails each invitee their link as well.
+	SendEmail bool
+}
+
+// ParseAdminCSV reads an import file. The first line is a header naming
+// the columns, in any order: email, role, and either name or first_name
+// (plus optional last_name). Rows that can't be used come back as error
+// results alongside the good ones, so one typo doesn't sink the file; a
+// file without the required columns is an error.
+func ParseAdminCSV(r io.Reader) ([]AdminImportRow, []AdminImportResult, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, apperr.Validation("the file is empty")
+	}
+	if err != nil {
+		return nil, nil, apperr.Wrap(apperr.ErrValidation, "the file isn't valid CSV", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		name = strings.ReplaceAll(name, " ", "_")
+		cols[name] = i
+	}
+	_, hasName := cols["name"]
+	_, hasFirst := cols["first_name"]
+	if _, ok := cols["email"]; !ok || !(hasName || hasFirst) {
+		return nil, nil, apperr.Validation("the header must name an email column and a name (or first_name) column")
+	}
+	if _, ok := cols["role"]; !ok {
+		return nil, nil, apperr.Validation("the header must name a role column")
+	}
+
+	var rows []AdminImportRow
+	var bad []AdminImportResult
+	seen := map[string]int{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(rows)+len(bad) >= maxAdminImportRows {
+			return nil, nil, ErrAdminImportTooLarge
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return nil, nil, apperr.Wrap(apperr.ErrValidation, "the file couldn't be read", err)
+			}
+			bad = append(bad, AdminImportResult{Line: perr.Line, Status: "error", Error: "malformed CSV line"})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		row := AdminImportRow{
+			Line:      line,
+			Email:     field("email"),
+			FirstName: field("first_name"),
+			LastName:  field("last_name"),
+			Role:      strings.ToLower(field("role")),
+		}
+		if row.FirstName == "" {
+			row.FirstName, row.LastName, _ = strings.Cut(field("name"), " ")
+			row.LastName = strings.TrimSpace(row.LastName)
+		}
+		if problem := validateImportRow(row); problem != "" {
+			bad = append(bad, AdminImportResult{Line: line, Email: row.Email, Role: row.Role, Status: "error", Error: problem})
+			continue
+		}
+		key := strings.ToLower(row.Email)
+		if first, dup := seen[key]; dup {
+			bad = append(bad, AdminImportResult{Line: line, Email: row.Email, Role: row.Role, Status: "error",
+				Error: fmt.Sprintf("duplicate of line %d", first)})
+			continue
+		}
+		seen[key] = line
+		rows = append(rows, row)
+	}
+	return rows, bad, nil
+}
+
+// validateImportRow says what's wrong with row, if anything. Only the
+// built-in roles can be imported: admin_users stores the system_role enum.
+func validateImportRow(row AdminImportRow) string {
+	if row.Email == "" && row.FirstName == "" && row.Role == "" {
+		return "empty row"
+	}
+	addr, err := mail.ParseAddress(row.Email)
+	if err != nil || addr.Address != row.Email {
+		return "invalid email address"
+	}
+	if row.FirstName == "" {
+		return "name is required"
+	}
+	if len(row.FirstName) > 100 || len(row.LastName) > 100 {
+		return "name is too long"
+	}
+	if !models.IsValidSystemRole(row.Role) {
+		return fmt.Sprintf("invalid role %q; valid roles: super_admin, support, marketing, partner, auditor", row.Role)
+	}
+	return ""
+}
+
+// Import creates the parsed rows' admins and invitations. Rows fail one
+// at a time — an existing admin, a database error — and are reported as
+// such; the rest go ahead. bad are the parse failures from ParseAdminCSV,
+// merged into the report in line order.
+func (s *AdminInviteService) Import(ctx context.Context, rows []AdminImportRow, bad []AdminImportResult, opts AdminImportOptions) *AdminImportReport {
+	report := &AdminImportReport{BatchID: uuid.New(), DryRun: opts.DryRun}
+	results := make([]AdminImportResult, 0, len(rows)+len(bad))
+	results = append(results, bad...)
+	for _, row := range rows {
+		results = append(results, s.importRow(ctx, row, report.BatchID, opts))
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+	for _, res := range results {
+		if res.Status == "error" {
+			report.Failed++
+		} else if res.Status == "invited" {
+			report.Invited++
+		}
+	}
+	report.Rows = results
+	return report
+}
+
+func (s *AdminInviteService) importRow(ctx context.Context, row AdminImportRow, batchID uuid.UUID, opts AdminImportOptions) AdminImportResult {
+	res := AdminImportResult{Line: row.Line, Email: row.Email, Role: row.Role, Status: "error"}
+	existing, err := s.users.GetAdminByEmail(ctx, row.Email)
+	if err != nil {
+		log.Printf("[ADMIN-IMPORT] line %d: look up %s: %v", row.Line, row.Email, err)
+		res.Error = "couldn't check for an existing admin"
+		return res
+	}
+	if existing != nil {
+		res.Error = "an admin with this email already exists"
+		return res
+	}
+	if opts.DryRun {
+		res.Status = "valid"
+		return res
+	}
+
+	hash, err := unusablePasswordHash()
+	if err != nil {
+		res.Error = "couldn't create the admin"
+		return res
+	}
+	view, err := s.admins.CreateAdminUser(ctx, row.Email, hash, row.FirstName, row.LastName, models.SystemRole(row.Role))
+	if err != nil {
+		log.Printf("[ADMIN-IMPORT] line %d: create %s: %v", row.Line, row.Email, err)
+		res.Error = "couldn't create the admin"
+		if apperr.KindOf(err) == apperr.ErrConflict {
+			res.Error = "an admin with this email already exists"
+		}
+		return res
+	}
+	res.AdminID = &view.ID
+	// Admins are created active; hold the account until the invitation is
+	// accepted. If that fails, take the admin back out rather than leave an
+	// account with no way in.
+	if err := s.admins.UpdateUserStatus(ctx, view.ID, models.UserStatusPendingVerification); err != nil {
+		log.Printf("[ADMIN-IMPORT] line %d: hold %s pending: %v", row.Line, row.Email, err)
+		if rerr := s.admins.RemoveAdminRole(ctx, view.ID); rerr != nil {
+			log.Printf("[ADMIN-IMPORT] line %d: remove %s after failure: %v", row.Line, row.Email, rerr)
+		}
+		res.AdminID = nil
+		res.Error = "couldn't create the admin"
+		return res
+	}
+	if err := s.issue(ctx, &res, view.ID, row.FirstName, batchID, opts); err != nil {
+		log.Printf("[ADMIN-IMPORT] line %d: invitation for %s: %v", row.Line, row.Email, err)
+		res.Error = "admin created, but the invitation couldn't be issued; re-invite them"
+		return res
+	}
+	res.Status = "invited"
+	return res
+}
+
+// Reinvite issues a fresh link for an admin who hasn't accepted theirs,
+// e.g. after it expired. Earlier links stop working.
+func (s *AdminInviteService) Reinvite(ctx context.Context, adminID uuid.UUID, opts AdminImportOptions) (*AdminImportResult, error) {
+	admin, err := s.admins.GetUserByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil || !admin.SystemRole.Valid {
+		return nil, apperr.NotFound("admin not found")
+	}
+	if admin.Status != models.UserStatusPendingVerification {
+		return nil, ErrAdminInviteAccepted
+	}
+	res := &AdminImportResult{Email: admin.Email, Role: admin.SystemRole.String, AdminID: &admin.ID}
+	if err := s.issue(ctx, res, admin.ID, admin.FirstName, uuid.Nil, opts); err != nil {
+		return nil, err
+	}
+	res.Status = "invited"
+	return res, nil
+}
+
+// issue stores a new invitation for adminID and puts its link in res.
+func (s *AdminInviteService) issue(ctx context.Context, res *AdminImportResult, adminID uuid.UUID, firstName string, batchID uuid.UUID, opts AdminImportOptions) error {
+	token, tokenHash, err := newInviteToken()
+	if err != nil {
+		return err
+	}
+	inv := &repository.AdminInvitation{
+		AdminID:   adminID,
+		BatchID:   uuid.NullUUID{UUID: batchID, Valid: batchID != uuid.Nil},
+		InvitedBy: uuid.NullUUID{UUID: opts.InvitedBy, Valid: opts.InvitedBy != uuid.Nil},
+		ExpiresAt: time.Now().Add(adminInviteTTL),
+	}
+	if err := s.invites.Create(ctx, inv, tokenHash); err != nil {
+		return err
+	}
+	res.InviteURL = s.appURL + "/admin/invite/" + token
+	res.ExpiresAt = &inv.ExpiresAt
+	if opts.SendEmail && s.email != nil && s.email.IsEnabled() {
+		to, url := res.Email, res.InviteURL
+		expires := inv.ExpiresAt.Format("January 2")
+		go func() {
+			if err := s.email.SendAdminInvitationEmail(to, firstName, url, expires); err != nil {
+				log.Printf("[EMAIL] Failed to send admin invitation to %s: %v", to, err)
+			}
+		}()
+		res.Emailed = true
+	}
+	return nil
+}
+
+// Lookup returns the pending invitation for a link, for the accept page.
+func (s *AdminInviteService) Lookup(ctx context.Context, token string) (*repository.AdminInvitation, error) {
+	inv, err := s.invites.GetByTokenHash(ctx, hashInviteToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, ErrAdminInviteInvalid
+	}
+	switch inv.Status(time.Now()) {
+	case "accepted":
+		return nil, ErrAdminInviteUsed
+	case "expired", "revoked":
+		return nil, ErrAdminInviteExpired
+	}
+	return inv, nil
+}
+
+// Accept sets the invited admin's password and activates their account.
+// The invitation is claimed first, so a link works exactly once.
+func (s *AdminInviteService) Accept(ctx context.Context, token, password string) (*repository.AdminInvitation, error) {
+	if len(password) < minAdminPasswordLength {
+		return nil, apperr.Validation(fmt.Sprintf("password must be at least %d characters", minAdminPasswordLength))
+	}
+	inv, err := s.Lookup(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+	claimed, err := s.invites.MarkAccepted(ctx, inv.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrAdminInviteUsed
+	}
+	// From here a failure leaves the admin pending with a spent link; a
+	// super admin can re-invite them.
+	if err := s.admins.ResetUserPassword(ctx, inv.AdminID, string(hash)); err != nil {
+		return nil, fmt.Errorf("set password: %w", err)
+	}
+	if err := s.admins.UpdateUserStatus(ctx, inv.AdminID, models.UserStatusActive); err != nil {
+		return nil, fmt.Errorf("activate admin: %w", err)
+	}
+	log.Printf("[ADMIN-INVITE] %s accepted their invitation", inv.Email)
+	return inv, nil
+}
+
+// ListInvitations returns invitations newest first, for one import batch
+// when batchID is set.
+func (s *AdminInviteService) ListInvitations(ctx context.Context, batchID uuid.UUID, limit int) ([]repository.AdminInvitation, error) {
+	return s.invites.List(ctx, batchID, limit)
+}
+
+// newInviteToken returns a link token and the hash stored for it.
+func newInviteToken() (token, tokenHash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("generate token: %w", err)
+	}
+	token = hex.EncodeToString(b)
+	return token, hashInviteToken(token), nil
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// unusablePasswordHash is the bcrypt hash of a random password that is
+// thrown away, for admin_users.password_hash until the invitation is
+// accepted.
+func unusablePasswordHash() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(b)), bcrypt.DefaultCost)
+	return string(hash), err
+}
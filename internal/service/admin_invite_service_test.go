@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+func TestParseAdminCSV(t *testing.T) {
+	in := "\ufeffEmail, Name ,Role\n" +
+		"ana@example.com,Ana Lima,support\n" +
+		"bo@example.com,Bo,Super_Admin\n" +
+		"not-an-email,Cy,support\n" +
+		"dee@example.com,,marketing\n" +
+		"ed@example.com,Ed,owner\n" +
+		"ANA@example.com,Ana Again,support\n" +
+		"fay@example.com,\"Fay \"quoted\" Ray\",partner\n" +
+		"gil@example.com,Gil,partner\n"
+
+	rows, bad, err := ParseAdminCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseAdminCSV: %v", err)
+	}
+	want := []AdminImportRow{
+		{Line: 2, Email: "ana@example.com", FirstName: "Ana", LastName: "Lima", Role: "support"},
+		{Line: 3, Email: "bo@example.com", FirstName: "Bo", Role: "super_admin"},
+		{Line: 9, Email: "gil@example.com", FirstName: "Gil", Role: "partner"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	wantBad := map[int]string{
+		4: "invalid email",
+		5: "name is required",
+		6: "invalid role",
+		7: "duplicate of line 2",
+		8: "malformed",
+	}
+	if len(bad) != len(wantBad) {
+		t.Fatalf("bad = %+v", bad)
+	}
+	for _, b := range bad {
+		if b.Status != "error" || !strings.Contains(b.Error, wantBad[b.Line]) {
+			t.Errorf("line %d: %q, want %q", b.Line, b.Error, wantBad[b.Line])
+		}
+	}
+}
+
+func TestParseAdminCSVFirstLastColumns(t *testing.T) {
+	in := "role,last_name,first_name,email\nsupport,Lima,Ana,ana@example.com\n"
+	rows, bad, err := ParseAdminCSV(strings.NewReader(in))
+	if err != nil || len(bad) != 0 || len(rows) != 1 {
+		t.Fatalf("rows %+v bad %+v err %v", rows, bad, err)
+	}
+	if rows[0].FirstName != "Ana" || rows[0].LastName != "Lima" {
+		t.Errorf("row = %+v", rows[0])
+	}
+}
+
+func TestParseAdminCSVRejectsFile(t *testing.T) {
+	for name, in := range map[string]string{
+		"empty":      "",
+		"no email":   "name,role\nAna,support\n",
+		"no name":    "email,role\nana@example.com,support\n",
+		"no role":    "email,name\nana@example.com,Ana\n",
+		"too large":  "email,name,role\n" + strings.Repeat("x@example.com,X,support\n", maxAdminImportRows+1),
+		"bad header": "email,\"name\n",
+	} {
+		_, _, err := ParseAdminCSV(strings.NewReader(in))
+		if !errors.Is(err, apperr.ErrValidation) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestAdminInvitationStatus(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	for want, inv := range map[string]repository.AdminInvitation{
+		"pending":  {ExpiresAt: later},
+		"expired":  {ExpiresAt: earlier},
+		"accepted": {ExpiresAt: earlier, AcceptedAt: &earlier},
+		"revoked":  {ExpiresAt: later, RevokedAt: &earlier},
+	} {
+		if got := inv.Status(now); got != want {
+			t.Errorf("Status = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestHashInviteToken(t *testing.T) {
+	token, hash, err := newInviteToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hash) != 64 || hashInviteToken(token) != hash || strings.Contains(hash, token) {
+		t.Errorf("token %q hash %q", token, hash)
+	}
+}
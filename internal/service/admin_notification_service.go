@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var ErrAdminNotificationNotFound = apperr.NotFound("notification not found or already acknowledged")
+
+// AdminNotificationService lists and acknowledges the notifications
+// background checks open for admins (see MetricAnomalyService).
+type AdminNotificationService struct {
+	repo repository.AdminNotificationRepository
+}
+
+func NewAdminNotificationService(repo repository.AdminNotificationRepository) *AdminNotificationService {
+	return &AdminNotificationService{repo: repo}
+}
+
+// List returns up to limit notifications, newest first: open ones only
+// unless all.
+func (s *AdminNotificationService) List(ctx context.Context, all bool, limit int) ([]repository.AdminNotification, error) {
+	return s.repo.List(ctx, all, limit)
+}
+
+// Acknowledge closes an open notification on behalf of admin by.
+func (s *AdminNotificationService) Acknowledge(ctx context.Context, id, by uuid.UUID) (*repository.AdminNotification, error) {
+	n, err := s.repo.Acknowledge(ctx, id, by)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, ErrAdminNotificationNotFound
+	}
+	return n, nil
+}
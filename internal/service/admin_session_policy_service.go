@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+var (
+	ErrAdminSessionPolicyInvalid = apperr.Validation("invalid admin session policy")
+	// ErrSessionIdle is ValidateSession's answer for an admin session
+	// unused for longer than the policy's idle timeout.
+	ErrSessionIdle = errors.New("session idle")
+	// ErrStepUpRequired means the action needs the admin to re-enter their
+	// password: they last did longer ago than the policy's step-up window.
+	ErrStepUpRequired = apperr.Forbidden("re-enter your password to continue")
+)
+
+// AdminSessionPolicyKey is the system_settings key holding
+// AdminSessionPolicy.
+const AdminSessionPolicyKey = "admin_session_policy"
+
+// adminSessionPolicyTTL is how long Current serves a cached policy. Other
+// instances pick up a change within it.
+const adminSessionPolicyTTL = 30 * time.Second
+
+// AdminSessionPolicy bounds admin portal sessions. User (app) sessions
+// aren't affected.
+type AdminSessionPolicy struct {
+	// MaxSessionMinutes ends a session this long after sign-in, however
+	// busy. Refreshing the token doesn't extend it.
+	MaxSessionMinutes int `json:"max_session_minutes"`
+	// IdleTimeoutMinutes ends a session with no requests for this long.
+	// Badge polling doesn't count. 0 turns it off.
+	IdleTimeoutMinutes int `json:"idle_timeout_minutes"`
+	// MaxConcurrentSessions is how many sessions an admin may hold at
+	// once; signing in past it ends the oldest.
+	MaxConcurrentSessions int `json:"max_concurrent_sessions"`
+	// StepUpMinutes makes sensitive actions ask for the password again
+	// when it was last entered longer ago than this. 0 turns it off.
+	StepUpMinutes int `json:"step_up_minutes"`
+}
+
+// DefaultAdminSessionPolicy applies when the setting is missing or
+// unreadable. One session at a time matches the portal's behaviour before
+// the policy existed.
+var DefaultAdminSessionPolicy = AdminSessionPolicy{
+	MaxSessionMinutes:     480,
+	IdleTimeoutMinutes:    60,
+	MaxConcurrentSessions: 1,
+	StepUpMinutes:         15,
+}
+
+func (p AdminSessionPolicy) Validate() error {
+	switch {
+	case p.MaxSessionMinutes < 15 || p.MaxSessionMinutes > 1440:
+		return fmt.Errorf("%w: max_session_minutes must be 15-1440", ErrAdminSessionPolicyInvalid)
+	case p.IdleTimeoutMinutes != 0 && (p.IdleTimeoutMinutes < 5 || p.IdleTimeoutMinutes > p.MaxSessionMinutes):
+		return fmt.Errorf("%w: idle_timeout_minutes must be 0 (off) or 5 up to max_session_minutes", ErrAdminSessionPolicyInvalid)
+	case p.MaxConcurrentSessions < 1 || p.MaxConcurrentSessions > 10:
+		return fmt.Errorf("%w: max_concurrent_sessions must be 1-10", ErrAdminSessionPolicyInvalid)
+	case p.StepUpMinutes != 0 && (p.StepUpMinutes < 1 || p.StepUpMinutes > 240):
+		return fmt.Errorf("%w: step_up_minutes must be 0 (off) or 1-240", ErrAdminSessionPolicyInvalid)
+	}
+	return nil
+}
+
+// Check returns ErrSessionExpired for a session past the max duration and
+// ErrSessionIdle for one idle past the timeout, at now.
+func (p AdminSessionPolicy) Check(s *models.Session, now time.Time) error {
+	if now.Sub(s.CreatedAt) > time.Duration(p.MaxSessionMinutes)*time.Minute {
+		return ErrSessionExpired
+	}
+	if p.IdleTimeoutMinutes > 0 && now.Sub(s.LastSeenAt) > time.Duration(p.IdleTimeoutMinutes)*time.Minute {
+		return ErrSessionIdle
+	}
+	return nil
+}
+
+// RecentlyAuthenticated reports whether s's password check at LastAuthAt
+// still covers a sensitive action at now.
+func (p AdminSessionPolicy) RecentlyAuthenticated(s *models.Session, now time.Time) bool {
+	return p.StepUpMinutes == 0 || now.Sub(s.LastAuthAt) <= time.Duration(p.StepUpMinutes)*time.Minute
+}
+
+// AdminSessionPolicyService stores the admin session policy. AuthService
+// enforces it.
+type AdminSessionPolicyService struct {
+	settings settingsStore
+	now      func() time.Time
+
+	mu       sync.Mutex
+	cached   AdminSessionPolicy
+	cachedAt time.Time
+}
+
+func NewAdminSessionPolicyService(settings settingsStore) *AdminSessionPolicyService {
+	return &AdminSessionPolicyService{settings: settings, now: time.Now}
+}
+
+// Policy returns the stored policy, the default where it's missing.
+func (s *AdminSessionPolicyService) Policy(ctx context.Context) (AdminSessionPolicy, error) {
+	out := DefaultAdminSessionPolicy
+	val, err := s.settings.GetSetting(ctx, AdminSessionPolicyKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	stored := DefaultAdminSessionPolicy
+	if err := json.Unmarshal(raw, &stored); err != nil || stored.Validate() != nil {
+		log.Printf("[AUTH] %s setting unreadable, using defaults: %v", AdminSessionPolicyKey, err)
+		return out, nil
+	}
+	return stored, nil
+}
+
+// Current is Policy cached for adminSessionPolicyTTL, for the auth hot
+// path. A failed read keeps serving the last good policy.
+func (s *AdminSessionPolicyService) Current(ctx context.Context) AdminSessionPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cachedAt.IsZero() && s.now().Sub(s.cachedAt) < adminSessionPolicyTTL {
+		return s.cached
+	}
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		log.Printf("[AUTH] load %s: %v", AdminSessionPolicyKey, err)
+		if s.cachedAt.IsZero() {
+			policy = DefaultAdminSessionPolicy
+		} else {
+			policy = s.cached
+		}
+	}
+	s.cached, s.cachedAt = policy, s.now()
+	return s.cached
+}
+
+// UpdatePolicy validates and stores a new policy. This instance applies it
+// at once; others within adminSessionPolicyTTL.
+func (s *AdminSessionPolicyService) UpdatePolicy(ctx context.Context, p AdminSessionPolicy, by uuid.UUID) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if err := s.settings.UpdateSetting(ctx, AdminSessionPolicyKey, p, by); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cached, s.cachedAt = p, s.now()
+	s.mu.Unlock()
+	return nil
+}
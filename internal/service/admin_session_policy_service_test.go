@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+func TestAdminSessionPolicyValidate(t *testing.T) {
+	if err := DefaultAdminSessionPolicy.Validate(); err != nil {
+		t.Fatalf("default policy invalid: %v", err)
+	}
+	for name, p := range map[string]AdminSessionPolicy{
+		"short max":       {MaxSessionMinutes: 10, MaxConcurrentSessions: 1},
+		"idle over max":   {MaxSessionMinutes: 60, IdleTimeoutMinutes: 90, MaxConcurrentSessions: 1},
+		"tiny idle":       {MaxSessionMinutes: 60, IdleTimeoutMinutes: 2, MaxConcurrentSessions: 1},
+		"no sessions":     {MaxSessionMinutes: 60},
+		"too many":        {MaxSessionMinutes: 60, MaxConcurrentSessions: 11},
+		"negative stepup": {MaxSessionMinutes: 60, MaxConcurrentSessions: 1, StepUpMinutes: -1},
+	} {
+		if err := p.Validate(); !errors.Is(err, ErrAdminSessionPolicyInvalid) {
+			t.Errorf("%s: Validate = %v, want ErrAdminSessionPolicyInvalid", name, err)
+		}
+	}
+	off := AdminSessionPolicy{MaxSessionMinutes: 60, MaxConcurrentSessions: 3}
+	if err := off.Validate(); err != nil {
+		t.Errorf("idle timeout and step-up off: %v", err)
+	}
+}
+
+func TestAdminSessionPolicyCheck(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	p := AdminSessionPolicy{MaxSessionMinutes: 120, IdleTimeoutMinutes: 30, MaxConcurrentSessions: 1, StepUpMinutes: 10}
+	sess := func(age, idle, sinceAuth time.Duration) *models.Session {
+		return &models.Session{CreatedAt: now.Add(-age), LastSeenAt: now.Add(-idle), LastAuthAt: now.Add(-sinceAuth)}
+	}
+
+	if err := p.Check(sess(time.Hour, 5*time.Minute, 0), now); err != nil {
+		t.Errorf("active session: %v", err)
+	}
+	if err := p.Check(sess(3*time.Hour, time.Minute, 0), now); err != ErrSessionExpired {
+		t.Errorf("past max duration: %v, want ErrSessionExpired", err)
+	}
+	if err := p.Check(sess(time.Hour, 31*time.Minute, 0), now); err != ErrSessionIdle {
+		t.Errorf("idle: %v, want ErrSessionIdle", err)
+	}
+	p.IdleTimeoutMinutes = 0
+	if err := p.Check(sess(time.Hour, 90*time.Minute, 0), now); err != nil {
+		t.Errorf("idle timeout off: %v", err)
+	}
+
+	if !p.RecentlyAuthenticated(sess(time.Hour, 0, 10*time.Minute), now) {
+		t.Error("password 10 minutes ago should cover a 10 minute window")
+	}
+	if p.RecentlyAuthenticated(sess(time.Hour, 0, 11*time.Minute), now) {
+		t.Error("password 11 minutes ago shouldn't cover a 10 minute window")
+	}
+	p.StepUpMinutes = 0
+	if !p.RecentlyAuthenticated(sess(time.Hour, 0, time.Hour), now) {
+		t.Error("step-up off should never ask")
+	}
+}
+
+func TestAdminSessionPolicyStore(t *testing.T) {
+	settings := memSettings{}
+	svc := NewAdminSessionPolicyService(settings)
+	ctx := context.Background()
+
+	if got := svc.Current(ctx); got != DefaultAdminSessionPolicy {
+		t.Fatalf("missing setting = %+v, want defaults", got)
+	}
+
+	// Written badly through the generic settings endpoint: defaults.
+	settings[AdminSessionPolicyKey] = map[string]interface{}{"max_session_minutes": 5}
+	if got, err := svc.Policy(ctx); err != nil || got != DefaultAdminSessionPolicy {
+		t.Fatalf("invalid stored policy = %+v, %v; want defaults", got, err)
+	}
+
+	bad := DefaultAdminSessionPolicy
+	bad.MaxConcurrentSessions = 0
+	if err := svc.UpdatePolicy(ctx, bad, uuid.New()); !errors.Is(err, ErrAdminSessionPolicyInvalid) {
+		t.Fatalf("UpdatePolicy(invalid) = %v", err)
+	}
+
+	want := AdminSessionPolicy{MaxSessionMinutes: 240, IdleTimeoutMinutes: 20, MaxConcurrentSessions: 2, StepUpMinutes: 5}
+	if err := svc.UpdatePolicy(ctx, want, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	// Applied on this instance at once, cache or not.
+	if got := svc.Current(ctx); got != want {
+		t.Errorf("Current after update = %+v, want %+v", got, want)
+	}
+	if got, _ := svc.Policy(ctx); got != want {
+		t.Errorf("stored policy = %+v, want %+v", got, want)
+	}
+}
@@ -59,6 +59,14 @@ type AIInsightService struct {
 	// outbound prompts. Optional (nil-safe — nil means "always strip").
 	// Phase 3 of the internal-AI initiative; defaults to dormant.
 	narrativeConsent *AINarrativeConsentService
+
+	// annotations supplies caregiver chart annotations ("started
+	// melatonin") so Tier 3 can compare before and after. Optional.
+	annotations aiAnnotationSource
+}
+
+type aiAnnotationSource interface {
+	ListForRange(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.Annotation, error)
 }
 
 type rateLimiter struct {
@@ -125,6 +133,12 @@ func (s *AIInsightService) SetNarrativeConsent(svc *AINarrativeConsentService) {
 	s.narrativeConsent = svc
 }
 
+// SetAnnotations adds the child's chart annotations to the Tier 3 log
+// context.
+func (s *AIInsightService) SetAnnotations(src aiAnnotationSource) {
+	s.annotations = src
+}
+
 // aiInsightResult is the expected JSON structure from Claude's response
 type aiInsightResult struct {
 	Tier                int               `json:"tier"`
@@ -209,6 +223,13 @@ func (s *AIInsightService) AnalyzeChild(ctx context.Context, child models.Child)
 	includeNarrative := s.narrativeConsent != nil && s.narrativeConsent.AllowsNarrativeForFamily(ctx, child.FamilyID)
 	if s.limiter.allow() && logs != nil {
 		logCtx := s.buildLogContext(child, logs, includeNarrative)
+		if s.annotations != nil {
+			annotations, err := s.annotations.ListForRange(ctx, child.ID, lookbackStart, today)
+			if err != nil {
+				log.Printf("AI Insights: failed to get annotations for %s: %v", child.FirstName, err)
+			}
+			logCtx += buildAnnotationContext(annotations, includeNarrative)
+		}
 		results, err := s.callClaudeForTier3(ctx, profileCtx, logCtx, child.FirstName)
 		if err != nil {
 			if errors.Is(err, ErrClaudeUnavailable) {
@@ -569,6 +590,26 @@ func (s *AIInsightService) buildLogContext(_ models.Child, logs *models.DailyLog
 	return b.String()
 }
 
+// buildAnnotationContext lists the caregiver's chart annotations with
+// relative day labels. Titles are free text, so they're only included
+// under the narrative opt-in; otherwise just the category goes out.
+func buildAnnotationContext(annotations []models.Annotation, includeNarrative bool) string {
+	if len(annotations) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	now := time.Now()
+	b.WriteString("\nCaregiver Annotations (changes the caregiver marked on the charts):\n")
+	for _, a := range annotations {
+		line := fmt.Sprintf("  %s: %s", RelativeDayLabel(a.AnnotationDate, now), a.Category)
+		if includeNarrative {
+			line += fmt.Sprintf(" — %s", aiTruncate(a.Title, 100))
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
 const tier12SystemPrompt = `You are a medical knowledge assistant for MyCareCompanion, a family care tracking app for children with autism spectrum disorder and related conditions. Given a child's de-identified profile (age band, diagnosis categories, medication classes — NO real names), provide relevant insights.
 
 PRIVACY NOTE: the subject child is referred to as "[CHILD]" — this is a placeholder. The real first name will be substituted into your output client-side. ALWAYS use the literal string "[CHILD]" in your title and descriptions where you would normally refer to the child by name. Do not invent a name. Do not write "the child" — write "[CHILD]". Possessive form: "[CHILD]'s".
@@ -606,7 +647,7 @@ PRIVACY NOTE: ALWAYS use the literal string "[CHILD]" where you would refer to t
 Analyze the data for:
 1. Correlations between different log types (e.g., sleep quality vs next-day mood)
 2. Trends over time (improving, declining, stable)
-3. Notable events or outliers
+3. Notable events or outliers, and changes before vs after any caregiver annotation (e.g. a diet or medication change)
 4. Positive developments worth celebrating
 5. Concerning patterns that warrant attention
 
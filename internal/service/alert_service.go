@@ -2,18 +2,18 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrAlertNotFound = errors.New("alert not found")
+	ErrAlertNotFound = apperr.NotFound("alert not found")
 )
 
 type AlertService struct {
@@ -21,6 +21,7 @@ type AlertService struct {
 	childRepo   repository.ChildRepository
 	familyRepo  repository.FamilyRepository
 	pushService *PushService
+	drain       *Drain
 }
 
 func NewAlertService(alertRepo repository.AlertRepository, childRepo repository.ChildRepository) *AlertService {
@@ -36,6 +37,12 @@ func (s *AlertService) SetPushService(ps *PushService, familyRepo repository.Fam
 	s.familyRepo = familyRepo
 }
 
+// SetDrain tracks the push fan-out through shutdown, so a deploy doesn't
+// cut a family's alert notifications off halfway.
+func (s *AlertService) SetDrain(d *Drain) {
+	s.drain = d
+}
+
 func (s *AlertService) Create(ctx context.Context, alert *models.Alert) error {
 	if err := s.alertRepo.Create(ctx, alert); err != nil {
 		return err
@@ -43,7 +50,7 @@ func (s *AlertService) Create(ctx context.Context, alert *models.Alert) error {
 
 	// Send push notifications to family members
 	if s.pushService != nil && s.familyRepo != nil && alert.FamilyID != uuid.Nil {
-		go func() {
+		s.drain.Go("alert push", func() {
 			members, err := s.familyRepo.GetMembers(context.Background(), alert.FamilyID)
 			if err != nil {
 				log.Printf("Failed to get family members for alert push: %v", err)
@@ -70,7 +77,7 @@ func (s *AlertService) Create(ctx context.Context, alert *models.Alert) error {
 			for _, m := range members {
 				s.pushService.Send(context.Background(), m.UserID, msg)
 			}
-		}()
+		})
 	}
 
 	return nil
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrAnnotationNotFound = apperr.NotFound("annotation not found")
+	ErrAnnotationInvalid  = apperr.Validation("invalid annotation")
+)
+
+// maxAnnotationNotesLen caps an annotation's notes.
+const maxAnnotationNotesLen = 2000
+
+// AnnotationService manages the dated annotations and milestone markers
+// caregivers put on a child's charts. Reports, comparisons and the
+// insight engine read them back for a date range through ListForRange.
+type AnnotationService struct {
+	repo repository.AnnotationRepository
+	now  func() time.Time
+}
+
+func NewAnnotationService(repo repository.AnnotationRepository) *AnnotationService {
+	return &AnnotationService{repo: repo, now: time.Now}
+}
+
+func invalidAnnotation(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrAnnotationInvalid, fmt.Sprintf(format, args...))
+}
+
+// apply validates req onto a. Annotations can't be dated more than a day
+// ahead, allowing for the caller's timezone.
+func (s *AnnotationService) apply(a *models.Annotation, req *models.AnnotationRequest) error {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return invalidAnnotation("date must be YYYY-MM-DD")
+	}
+	if date.After(s.now().UTC().AddDate(0, 0, 1)) {
+		return invalidAnnotation("date can't be in the future")
+	}
+	category := req.Category
+	if category == "" {
+		category = models.AnnotationCategoryOther
+	}
+	if !slices.Contains(models.AnnotationCategories, category) {
+		return invalidAnnotation("category must be one of %s", strings.Join(models.AnnotationCategories, ", "))
+	}
+	title := strings.TrimSpace(req.Title)
+	notes := strings.TrimSpace(req.Notes)
+	switch {
+	case title == "":
+		return invalidAnnotation("title is required")
+	case len(title) > 200:
+		return invalidAnnotation("title must be 200 characters or fewer")
+	case len(notes) > maxAnnotationNotesLen:
+		return invalidAnnotation("notes must be %d characters or fewer", maxAnnotationNotesLen)
+	}
+	a.AnnotationDate, a.Category, a.Title = date, category, title
+	a.Notes.String, a.Notes.Valid = notes, notes != ""
+	return nil
+}
+
+// Create adds an annotation to the child's charts.
+func (s *AnnotationService) Create(ctx context.Context, childID, by uuid.UUID, req *models.AnnotationRequest) (*models.Annotation, error) {
+	a := &models.Annotation{ChildID: childID, CreatedBy: &by}
+	if err := s.apply(a, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get returns an annotation of the child, or ErrAnnotationNotFound.
+func (s *AnnotationService) Get(ctx context.Context, childID, id uuid.UUID) (*models.Annotation, error) {
+	a, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil || a.ChildID != childID {
+		return nil, ErrAnnotationNotFound
+	}
+	return a, nil
+}
+
+// ListForRange returns the child's annotations dated in [start, end],
+// oldest first.
+func (s *AnnotationService) ListForRange(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.Annotation, error) {
+	list, err := s.repo.ListByChild(ctx, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []models.Annotation{}
+	}
+	return list, nil
+}
+
+// Update replaces an annotation's date, category, title and notes.
+func (s *AnnotationService) Update(ctx context.Context, a *models.Annotation, req *models.AnnotationRequest) error {
+	if err := s.apply(a, req); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, a)
+}
+
+func (s *AnnotationService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeAnnotationRepo struct {
+	byID map[uuid.UUID]models.Annotation
+}
+
+func (f *fakeAnnotationRepo) Create(ctx context.Context, a *models.Annotation) error {
+	a.ID = uuid.New()
+	f.byID[a.ID] = *a
+	return nil
+}
+
+func (f *fakeAnnotationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Annotation, error) {
+	if a, ok := f.byID[id]; ok {
+		return &a, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeAnnotationRepo) ListByChild(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.Annotation, error) {
+	var out []models.Annotation
+	for _, a := range f.byID {
+		if a.ChildID == childID && !a.AnnotationDate.Before(start) && !a.AnnotationDate.After(end) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAnnotationRepo) Update(ctx context.Context, a *models.Annotation) error {
+	f.byID[a.ID] = *a
+	return nil
+}
+
+func (f *fakeAnnotationRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.byID, id)
+	return nil
+}
+
+func TestAnnotationService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	svc := NewAnnotationService(&fakeAnnotationRepo{byID: map[uuid.UUID]models.Annotation{}})
+	svc.now = func() time.Time { return now }
+	childID, other := uuid.New(), uuid.New()
+
+	for name, req := range map[string]models.AnnotationRequest{
+		"bad date":     {Date: "10/01/2026", Title: "Started melatonin"},
+		"future date":  {Date: "2026-10-20", Title: "Started melatonin"},
+		"bad category": {Date: "2026-10-01", Category: "weather", Title: "Storm"},
+		"no title":     {Date: "2026-10-01", Title: "  "},
+		"long notes":   {Date: "2026-10-01", Title: "Diet", Notes: strings.Repeat("x", maxAnnotationNotesLen+1)},
+	} {
+		if _, err := svc.Create(ctx, childID, uuid.New(), &req); !errors.Is(err, ErrAnnotationInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	a, err := svc.Create(ctx, childID, uuid.New(), &models.AnnotationRequest{Date: "2026-10-01", Title: " Started melatonin "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Category != models.AnnotationCategoryOther || a.Title != "Started melatonin" || a.Notes.Valid {
+		t.Errorf("created %+v", a)
+	}
+	if _, err := svc.Get(ctx, other, a.ID); !errors.Is(err, ErrAnnotationNotFound) {
+		t.Errorf("other child's annotation: %v", err)
+	}
+	if err := svc.Update(ctx, a, &models.AnnotationRequest{Date: "2026-10-02", Category: "medication", Title: "Started melatonin", Notes: "1mg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	if list, _ := svc.ListForRange(ctx, childID, day(2), day(3)); len(list) != 1 || list[0].Category != "medication" {
+		t.Errorf("in range: %+v", list)
+	}
+	if list, _ := svc.ListForRange(ctx, childID, day(3), day(10)); list == nil || len(list) != 0 {
+		t.Errorf("out of range: %+v", list)
+	}
+}
+
+func TestBuildAnnotationContext(t *testing.T) {
+	annotations := []models.Annotation{{AnnotationDate: time.Now().AddDate(0, 0, -3), Category: "diet", Title: "Started GFCF diet at Grandma's"}}
+	if got := buildAnnotationContext(annotations, false); !strings.Contains(got, "Day-3: diet") || strings.Contains(got, "Grandma") {
+		t.Errorf("stripped context %q", got)
+	}
+	if got := buildAnnotationContext(annotations, true); !strings.Contains(got, "GFCF") {
+		t.Errorf("narrative context %q", got)
+	}
+	if buildAnnotationContext(nil, true) != "" {
+		t.Error("empty annotations produced context")
+	}
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"carecompanion/internal/database"
+	"carecompanion/internal/repository"
+)
+
+const (
+	// apiUsageKeyTTL keeps an hour's counters in Redis long enough for a
+	// flusher that was down for a while to catch up.
+	apiUsageKeyTTL = 25 * time.Hour
+	// apiUsageRetention is how long hourly rows are kept in Postgres.
+	apiUsageRetention = 90 * 24 * time.Hour
+	// apiUsageSlowMs is the latency a request counts as slow from.
+	apiUsageSlowMs = 1000
+
+	// A client is flagged for its error rate once it has made
+	// apiUsageMinRequests requests and at least apiUsageErrorRate of them
+	// failed, and for hammering a route it calls more than
+	// apiUsageHammerPerHour times an hour on average.
+	apiUsageMinRequests   = 100
+	apiUsageErrorRate     = 0.2
+	apiUsageHammerPerHour = 3600
+)
+
+// apiUsageSep separates the parts of a counter field. Routes can hold
+// almost anything else.
+const apiUsageSep = "\x1f"
+
+// apiUsageVersions is the part of AppVersionService the report needs.
+type apiUsageVersions interface {
+	Check(ctx context.Context, platform, version string) AppVersionCheck
+}
+
+// APIRouteUsage is a client's requests to one route over the report
+// window.
+type APIRouteUsage struct {
+	Method       string  `json:"method"`
+	Route        string  `json:"route"`
+	Requests     int64   `json:"requests"`
+	ClientErrors int64   `json:"client_errors"`
+	ServerErrors int64   `json:"server_errors"`
+	AvgMs        float64 `json:"avg_ms"`
+	PerHour      float64 `json:"per_hour"`
+}
+
+// APIClientUsage is one client's requests over the report window, with
+// its busiest routes. Flags say why it looks like it is misbehaving:
+// "error_rate", "hammering" (a route more than apiUsageHammerPerHour
+// times an hour) and "unsupported_version" (a mobile build below the
+// platform's minimum).
+type APIClientUsage struct {
+	Platform      string          `json:"platform"`
+	ClientVersion string          `json:"client_version"`
+	Requests      int64           `json:"requests"`
+	ClientErrors  int64           `json:"client_errors"`
+	ServerErrors  int64           `json:"server_errors"`
+	ErrorRate     float64         `json:"error_rate"`
+	AvgMs         float64         `json:"avg_ms"`
+	Slow          int64           `json:"slow"`
+	TopRoutes     []APIRouteUsage `json:"top_routes"`
+	Flags         []string        `json:"flags"`
+}
+
+// APIUsageReport is API usage per client over a window ending now, the
+// busiest client first.
+type APIUsageReport struct {
+	Window  string           `json:"window"`
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Clients []APIClientUsage `json:"clients"`
+	Flagged int              `json:"flagged"`
+}
+
+// APIUsageService counts API requests per client (platform and app
+// version, see middleware.APIUsage) and route in Redis, one hash per
+// hour, and flushes the counts to Postgres for the admin report. Counting
+// is best effort: without Redis nothing is recorded.
+type APIUsageService struct {
+	r        *database.Redis
+	repo     repository.APIUsageRepository
+	versions apiUsageVersions
+	now      func() time.Time
+}
+
+func NewAPIUsageService(r *database.Redis, repo repository.APIUsageRepository, versions apiUsageVersions) *APIUsageService {
+	return &APIUsageService{r: r, repo: repo, versions: versions, now: time.Now}
+}
+
+func apiUsageKey(hour time.Time) string {
+	return "api_usage:" + strconv.FormatInt(hour.Unix(), 10)
+}
+
+// Record counts one request.
+func (s *APIUsageService) Record(ctx context.Context, platform, version, method, route string, status int, elapsed time.Duration) {
+	if s == nil || s.r == nil {
+		return
+	}
+	key := apiUsageKey(s.now().UTC().Truncate(time.Hour))
+	field := strings.Join([]string{platform, version, method, route}, apiUsageSep) + apiUsageSep
+	ms := elapsed.Milliseconds()
+
+	pipe := s.r.Pipeline()
+	pipe.HIncrBy(ctx, key, field+"n", 1)
+	pipe.HIncrBy(ctx, key, field+"ms", ms)
+	switch {
+	case status >= 500:
+		pipe.HIncrBy(ctx, key, field+"5xx", 1)
+	case status >= 400:
+		pipe.HIncrBy(ctx, key, field+"4xx", 1)
+	}
+	if ms >= apiUsageSlowMs {
+		pipe.HIncrBy(ctx, key, field+"slow", 1)
+	}
+	pipe.Expire(ctx, key, apiUsageKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[API_USAGE] record: %v", err)
+	}
+}
+
+// parseAPIUsage turns an hour's counter hash into rows.
+func parseAPIUsage(hour time.Time, counters map[string]string) []repository.APIUsage {
+	index := map[string]int{}
+	var out []repository.APIUsage
+	for field, v := range counters {
+		i := strings.LastIndex(field, apiUsageSep)
+		parts := strings.SplitN(field[:max(i, 0)], apiUsageSep, 4)
+		n, err := strconv.ParseInt(v, 10, 64)
+		if i < 0 || len(parts) != 4 || err != nil {
+			continue
+		}
+		id, ok := index[field[:i]]
+		if !ok {
+			id = len(out)
+			index[field[:i]] = id
+			out = append(out, repository.APIUsage{Hour: hour, Platform: parts[0], ClientVersion: parts[1], Method: parts[2], Route: parts[3]})
+		}
+		u := &out[id]
+		switch field[i+1:] {
+		case "n":
+			u.Requests = n
+		case "ms":
+			u.TotalMs = n
+		case "4xx":
+			u.ClientErrors = n
+		case "5xx":
+			u.ServerErrors = n
+		case "slow":
+			u.Slow = n
+		}
+	}
+	return out
+}
+
+// Flush saves the counters of the last day's hours to Postgres. Hours
+// that ended a few minutes ago are then removed from Redis, so a run
+// normally only saves the current and previous hour. Hours past the
+// retention are deleted.
+func (s *APIUsageService) Flush(ctx context.Context) error {
+	if s.r == nil {
+		return nil
+	}
+	now := s.now().UTC()
+	current := now.Truncate(time.Hour)
+	for hour := current.Add(-24 * time.Hour); !hour.After(current); hour = hour.Add(time.Hour) {
+		key := apiUsageKey(hour)
+		counters, err := s.r.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if len(counters) == 0 {
+			continue
+		}
+		if err := s.repo.Save(ctx, parseAPIUsage(hour, counters)); err != nil {
+			return err
+		}
+		// Late increments from requests that straddled the hour are in by
+		// now.
+		if now.Sub(hour.Add(time.Hour)) >= 5*time.Minute {
+			if err := s.r.Del(ctx, key).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	if n, err := s.repo.DeleteBefore(ctx, now.Add(-apiUsageRetention)); err != nil {
+		return err
+	} else if n > 0 {
+		log.Printf("[API_USAGE] deleted %d hourly row(s) past retention", n)
+	}
+	return nil
+}
+
+// Report summarizes API usage per client over the window ending now,
+// with up to topRoutes (1-50) routes each. The current hour counts up to
+// the last flush.
+func (s *APIUsageService) Report(ctx context.Context, window time.Duration, topRoutes int) (*APIUsageReport, error) {
+	topRoutes = min(max(topRoutes, 1), 50)
+	to := s.now().UTC()
+	from := to.Add(-window).Truncate(time.Hour)
+	usage, err := s.repo.Usage(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	rep := apiUsageReport(usage, to.Sub(from).Hours(), topRoutes)
+	rep.Window, rep.From, rep.To = window.String(), from, to
+	for i := range rep.Clients {
+		c := &rep.Clients[i]
+		if s.versions != nil && (c.Platform == AppPlatformIOS || c.Platform == AppPlatformAndroid) &&
+			!s.versions.Check(ctx, c.Platform, c.ClientVersion).Supported {
+			c.Flags = append(c.Flags, "unsupported_version")
+		}
+		if len(c.Flags) > 0 {
+			rep.Flagged++
+		}
+	}
+	return rep, nil
+}
+
+type apiClientKey struct {
+	platform, version string
+}
+
+// apiUsageReport rolls the window's rows, hours long, up per client.
+func apiUsageReport(usage []repository.APIUsage, hours float64, topRoutes int) *APIUsageReport {
+	rep := &APIUsageReport{Clients: []APIClientUsage{}}
+	index := map[apiClientKey]int{}
+	var totalMs []int64
+	for _, u := range usage {
+		k := apiClientKey{u.Platform, u.ClientVersion}
+		i, ok := index[k]
+		if !ok {
+			i = len(rep.Clients)
+			index[k] = i
+			rep.Clients = append(rep.Clients, APIClientUsage{Platform: u.Platform, ClientVersion: u.ClientVersion, Flags: []string{}})
+			totalMs = append(totalMs, 0)
+		}
+		c := &rep.Clients[i]
+		c.Requests += u.Requests
+		c.ClientErrors += u.ClientErrors
+		c.ServerErrors += u.ServerErrors
+		c.Slow += u.Slow
+		totalMs[i] += u.TotalMs
+		r := APIRouteUsage{Method: u.Method, Route: u.Route, Requests: u.Requests,
+			ClientErrors: u.ClientErrors, ServerErrors: u.ServerErrors}
+		if u.Requests > 0 {
+			r.AvgMs = float64(u.TotalMs) / float64(u.Requests)
+		}
+		if hours > 0 {
+			r.PerHour = float64(u.Requests) / hours
+		}
+		c.TopRoutes = append(c.TopRoutes, r)
+	}
+	for i := range rep.Clients {
+		c := &rep.Clients[i]
+		if c.Requests > 0 {
+			c.ErrorRate = float64(c.ClientErrors+c.ServerErrors) / float64(c.Requests)
+			c.AvgMs = float64(totalMs[i]) / float64(c.Requests)
+		}
+		if c.Requests >= apiUsageMinRequests && c.ErrorRate >= apiUsageErrorRate {
+			c.Flags = append(c.Flags, "error_rate")
+		}
+		sort.Slice(c.TopRoutes, func(a, b int) bool { return c.TopRoutes[a].Requests > c.TopRoutes[b].Requests })
+		if len(c.TopRoutes) > 0 && c.TopRoutes[0].PerHour > apiUsageHammerPerHour {
+			c.Flags = append(c.Flags, "hammering")
+		}
+		if len(c.TopRoutes) > topRoutes {
+			c.TopRoutes = c.TopRoutes[:topRoutes]
+		}
+	}
+	sort.SliceStable(rep.Clients, func(a, b int) bool { return rep.Clients[a].Requests > rep.Clients[b].Requests })
+	return rep
+}
+
+// APIUsageFlusher runs Flush every five minutes.
+type APIUsageFlusher struct {
+	svc  *APIUsageService
+	jobs *JobLocker
+}
+
+func NewAPIUsageFlusher(svc *APIUsageService, jobs *JobLocker) *APIUsageFlusher {
+	return &APIUsageFlusher{svc: svc, jobs: jobs}
+}
+
+func (f *APIUsageFlusher) Start(ctx context.Context) {
+	log.Println("API usage flusher started")
+	const interval = 5 * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("API usage flusher stopped")
+			return
+		case <-ticker.C:
+			f.jobs.RunOnce(ctx, "api_usage_flush", TickSlot(time.Now(), interval), interval, func(ctx context.Context) {
+				if err := f.svc.Flush(ctx); err != nil {
+					log.Printf("API usage flush failed: %v", err)
+				}
+			})
+		}
+	}
+}
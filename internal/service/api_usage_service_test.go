@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/database"
+	"carecompanion/internal/repository"
+)
+
+type fakeAPIUsage struct {
+	saved map[string]repository.APIUsage
+}
+
+func (f *fakeAPIUsage) Save(ctx context.Context, usage []repository.APIUsage) error {
+	for _, u := range usage {
+		f.saved[u.Hour.Format(time.RFC3339)+" "+u.Platform+" "+u.ClientVersion+" "+u.Method+" "+u.Route] = u
+	}
+	return nil
+}
+
+func (f *fakeAPIUsage) Usage(ctx context.Context, from, to time.Time) ([]repository.APIUsage, error) {
+	var out []repository.APIUsage
+	for _, u := range f.saved {
+		if !u.Hour.Before(from) && u.Hour.Before(to) {
+			u.Hour = time.Time{}
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAPIUsage) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestAPIUsageRecordAndFlush(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := &database.Redis{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	repo := &fakeAPIUsage{saved: map[string]repository.APIUsage{}}
+	s := NewAPIUsageService(rdb, repo, nil)
+	now := time.Date(2026, 6, 1, 10, 58, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	s.Record(ctx, "ios", "2.3.0", "GET", "/api/children/{id}", 200, 40*time.Millisecond)
+	s.Record(ctx, "ios", "2.3.0", "GET", "/api/children/{id}", 500, 1500*time.Millisecond)
+	s.Record(ctx, "ios", "2.3.0", "GET", "/api/children/{id}", 404, 10*time.Millisecond)
+	s.Record(ctx, "web", "", "POST", "/api/logs", 201, 20*time.Millisecond)
+	if err := s.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	got := repo.saved["2026-06-01T10:00:00Z ios 2.3.0 GET /api/children/{id}"]
+	want := repository.APIUsage{Hour: now.Truncate(time.Hour), Platform: "ios", ClientVersion: "2.3.0", Method: "GET", Route: "/api/children/{id}",
+		Requests: 3, ClientErrors: 1, ServerErrors: 1, TotalMs: 1550, Slow: 1}
+	if got != want {
+		t.Errorf("ios usage = %+v, want %+v", got, want)
+	}
+	if len(repo.saved) != 2 {
+		t.Errorf("saved %d rows, want 2", len(repo.saved))
+	}
+
+	// A flush after the hour is over saves it once more and clears it.
+	s.Record(ctx, "web", "", "POST", "/api/logs", 201, 20*time.Millisecond)
+	now = now.Add(10 * time.Minute)
+	if err := s.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.saved["2026-06-01T10:00:00Z web  POST /api/logs"].Requests; n != 2 {
+		t.Errorf("web requests = %d, want 2", n)
+	}
+	if mr.Exists(apiUsageKey(now.Add(-time.Hour).Truncate(time.Hour))) {
+		t.Error("finished hour still in Redis after the flush")
+	}
+}
+
+type fakeAppVersions map[string]bool
+
+func (f fakeAppVersions) Check(ctx context.Context, platform, version string) AppVersionCheck {
+	return AppVersionCheck{Platform: platform, Version: version, Supported: !f[platform+" "+version]}
+}
+
+func TestAPIUsageReport(t *testing.T) {
+	hour := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	repo := &fakeAPIUsage{saved: map[string]repository.APIUsage{}}
+	repo.Save(context.Background(), []repository.APIUsage{
+		// An old Android build polling one endpoint 5000 times an hour.
+		{Hour: hour, Platform: "android", ClientVersion: "1.0.0", Method: "GET", Route: "/api/alerts", Requests: 5000, TotalMs: 50000},
+		{Hour: hour, Platform: "android", ClientVersion: "1.0.0", Method: "GET", Route: "/api/children", Requests: 10, TotalMs: 100},
+		// A script whose token expired.
+		{Hour: hour, Platform: "other", ClientVersion: "python-requests", Method: "GET", Route: "/api/logs", Requests: 200, ClientErrors: 190, TotalMs: 400},
+		{Hour: hour, Platform: "web", Method: "GET", Route: "/api/logs", Requests: 300, ServerErrors: 3, TotalMs: 6000},
+	})
+	s := NewAPIUsageService(nil, repo, fakeAppVersions{"android 1.0.0": true})
+	s.now = func() time.Time { return hour.Add(time.Hour) }
+
+	rep, err := s.Report(context.Background(), time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Clients) != 3 || rep.Flagged != 2 {
+		t.Fatalf("clients = %+v, flagged %d", rep.Clients, rep.Flagged)
+	}
+	android, script, web := rep.Clients[0], rep.Clients[2], rep.Clients[1]
+	sort.Strings(android.Flags)
+	if android.Requests != 5010 || len(android.TopRoutes) != 1 || android.TopRoutes[0].Route != "/api/alerts" ||
+		len(android.Flags) != 2 || android.Flags[0] != "hammering" || android.Flags[1] != "unsupported_version" {
+		t.Errorf("android = %+v", android)
+	}
+	if script.ErrorRate != 0.95 || len(script.Flags) != 1 || script.Flags[0] != "error_rate" {
+		t.Errorf("script = %+v", script)
+	}
+	if web.AvgMs != 20 || len(web.Flags) != 0 {
+		t.Errorf("web = %+v", web)
+	}
+}
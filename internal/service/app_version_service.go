@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+)
+
+var ErrAppVersionPolicyInvalid = apperr.Validation("invalid app version policy")
+
+const (
+	// AppVersionSettingKey is the system_settings key holding
+	// AppVersionPolicy.
+	AppVersionSettingKey = "app_min_versions"
+	// appVersionPolicyTTL is how long each instance serves its cached
+	// policy to the gate; a change on another instance shows up within
+	// this.
+	appVersionPolicyTTL = time.Minute
+)
+
+// App platforms, as sent in X-App-Platform and stored on device_tokens.
+const (
+	AppPlatformIOS     = "ios"
+	AppPlatformAndroid = "android"
+)
+
+// AppPlatformVersions is the version policy for one platform.
+type AppPlatformVersions struct {
+	// MinVersion is the oldest build still allowed to call the API; older
+	// builds get 426. Empty allows every build.
+	MinVersion string `json:"min_version"`
+	// LatestVersion is the newest build in the store. Builds between
+	// MinVersion and this are told an update is available but keep working.
+	LatestVersion string `json:"latest_version"`
+	StoreURL      string `json:"store_url"`
+}
+
+// AppVersionPolicy is the minimum supported app version per platform, plus
+// the message old builds show on their upgrade screen.
+type AppVersionPolicy struct {
+	IOS     AppPlatformVersions `json:"ios"`
+	Android AppPlatformVersions `json:"android"`
+	Message string              `json:"message"`
+}
+
+// DefaultAppVersionPolicy applies until an admin saves a policy. 1.0.0 is
+// the first store release, so nothing is blocked.
+var DefaultAppVersionPolicy = AppVersionPolicy{
+	IOS:     AppPlatformVersions{MinVersion: "1.0.0"},
+	Android: AppPlatformVersions{MinVersion: "1.0.0"},
+	Message: "This version of CareCompanion is no longer supported. Please update to the latest version to continue.",
+}
+
+// Platform returns the policy for platform, and false for an unknown one.
+func (p AppVersionPolicy) Platform(platform string) (AppPlatformVersions, bool) {
+	switch platform {
+	case AppPlatformIOS:
+		return p.IOS, true
+	case AppPlatformAndroid:
+		return p.Android, true
+	}
+	return AppPlatformVersions{}, false
+}
+
+// Validate checks each platform's versions parse, that latest isn't below
+// minimum, and that store links are https.
+func (p AppVersionPolicy) Validate() error {
+	for _, pv := range []struct {
+		name string
+		v    AppPlatformVersions
+	}{{AppPlatformIOS, p.IOS}, {AppPlatformAndroid, p.Android}} {
+		var minV, latestV []int
+		var ok bool
+		if pv.v.MinVersion != "" {
+			if minV, ok = parseAppVersion(pv.v.MinVersion); !ok {
+				return fmt.Errorf("%w: %s min_version %q is not a version number", ErrAppVersionPolicyInvalid, pv.name, pv.v.MinVersion)
+			}
+		}
+		if pv.v.LatestVersion != "" {
+			if latestV, ok = parseAppVersion(pv.v.LatestVersion); !ok {
+				return fmt.Errorf("%w: %s latest_version %q is not a version number", ErrAppVersionPolicyInvalid, pv.name, pv.v.LatestVersion)
+			}
+			if minV != nil && compareVersionParts(latestV, minV) < 0 {
+				return fmt.Errorf("%w: %s latest_version is below min_version", ErrAppVersionPolicyInvalid, pv.name)
+			}
+		}
+		if pv.v.StoreURL != "" && !strings.HasPrefix(pv.v.StoreURL, "https://") {
+			return fmt.Errorf("%w: %s store_url must be an https link", ErrAppVersionPolicyInvalid, pv.name)
+		}
+	}
+	if len(p.Message) > 500 {
+		return fmt.Errorf("%w: message must be 500 characters or fewer", ErrAppVersionPolicyInvalid)
+	}
+	return nil
+}
+
+// AppVersionCheck is the verdict for one client build.
+type AppVersionCheck struct {
+	Platform        string `json:"platform"`
+	Version         string `json:"version"`
+	MinVersion      string `json:"min_version"`
+	LatestVersion   string `json:"latest_version,omitempty"`
+	Supported       bool   `json:"supported"`
+	UpdateAvailable bool   `json:"update_available"`
+	StoreURL        string `json:"store_url,omitempty"`
+	// Message is the upgrade text, set only when the build is unsupported.
+	Message string `json:"message,omitempty"`
+}
+
+// AppVersionService stores the minimum app version policy and serves it
+// cached to the per-request version gate.
+type AppVersionService struct {
+	settings settingsStore
+	now      func() time.Time
+
+	mu       sync.Mutex
+	cached   AppVersionPolicy
+	cachedAt time.Time
+}
+
+func NewAppVersionService(settings settingsStore) *AppVersionService {
+	return &AppVersionService{
+		settings: settings,
+		now:      time.Now,
+		cached:   DefaultAppVersionPolicy,
+	}
+}
+
+// Policy reads the stored policy, with defaults when none is stored or it
+// doesn't validate.
+func (s *AppVersionService) Policy(ctx context.Context) (AppVersionPolicy, error) {
+	out := DefaultAppVersionPolicy
+	val, err := s.settings.GetSetting(ctx, AppVersionSettingKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	var stored AppVersionPolicy
+	if err := json.Unmarshal(raw, &stored); err != nil || stored.Validate() != nil {
+		log.Printf("[APP VERSION] %s setting unreadable, using defaults: %v", AppVersionSettingKey, err)
+		return out, nil
+	}
+	return stored, nil
+}
+
+// Current is Policy cached for appVersionPolicyTTL, for the gate. A failed
+// read keeps serving the last good policy.
+func (s *AppVersionService) Current(ctx context.Context) AppVersionPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cachedAt.IsZero() && s.now().Sub(s.cachedAt) < appVersionPolicyTTL {
+		return s.cached
+	}
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		log.Printf("[APP VERSION] load policy: %v", err)
+		policy = s.cached
+	}
+	s.cached, s.cachedAt = policy, s.now()
+	return s.cached
+}
+
+// UpdatePolicy validates and stores a new policy. This instance applies it
+// at once; others within appVersionPolicyTTL.
+func (s *AppVersionService) UpdatePolicy(ctx context.Context, policy AppVersionPolicy, by uuid.UUID) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if err := s.settings.UpdateSetting(ctx, AppVersionSettingKey, policy, by); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cached, s.cachedAt = policy, s.now()
+	s.mu.Unlock()
+	return nil
+}
+
+// Check compares a client's build against the cached policy. It fails
+// open: an unknown platform or a version that doesn't parse is reported
+// as supported, since blocking on a malformed header would lock out the
+// very clients that most need to reach the API.
+func (s *AppVersionService) Check(ctx context.Context, platform, version string) AppVersionCheck {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	out := AppVersionCheck{Platform: platform, Version: version, Supported: true}
+	policy := s.Current(ctx)
+	pv, ok := policy.Platform(platform)
+	if !ok {
+		return out
+	}
+	out.MinVersion, out.LatestVersion, out.StoreURL = pv.MinVersion, pv.LatestVersion, pv.StoreURL
+	if version == "" {
+		return out
+	}
+	if pv.MinVersion != "" && CompareAppVersions(version, pv.MinVersion) < 0 {
+		out.Supported = false
+		out.UpdateAvailable = true
+		out.Message = policy.Message
+		return out
+	}
+	if pv.LatestVersion != "" && CompareAppVersions(version, pv.LatestVersion) < 0 {
+		out.UpdateAvailable = true
+	}
+	return out
+}
+
+// CompareAppVersions compares two dotted version strings numerically,
+// returning -1, 0 or 1. Missing components count as zero (1.2 == 1.2.0)
+// and anything after the numeric part is ignored ("1.4.0-beta (212)" is
+// 1.4.0). If either side doesn't start with a number they compare equal.
+func CompareAppVersions(a, b string) int {
+	av, okA := parseAppVersion(a)
+	bv, okB := parseAppVersion(b)
+	if !okA || !okB {
+		return 0
+	}
+	return compareVersionParts(av, bv)
+}
+
+func compareVersionParts(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseAppVersion reads the leading dotted numeric part of v, up to four
+// components, with an optional "v" prefix.
+func parseAppVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if end := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); end >= 0 {
+		v = v[:end]
+	}
+	v = strings.TrimRight(v, ".")
+	if v == "" {
+		return nil, false
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) > 4 {
+		return nil, false
+	}
+	out := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCompareAppVersions(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2", 0},
+		{"1.10.0", "1.9.9", 1},
+		{"1.4.0-beta (212)", "1.4.0", 0},
+		{"v2.0", "1.99.99", 1},
+		{"1.0.9", "1.1", -1},
+		{"garbage", "1.0.0", 0},
+	} {
+		if got := CompareAppVersions(c.a, c.b); got != c.want {
+			t.Errorf("CompareAppVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestAppVersionPolicyValidate(t *testing.T) {
+	if err := DefaultAppVersionPolicy.Validate(); err != nil {
+		t.Fatalf("defaults invalid: %v", err)
+	}
+	for _, p := range []AppVersionPolicy{
+		{IOS: AppPlatformVersions{MinVersion: "one"}},
+		{Android: AppPlatformVersions{MinVersion: "2.0", LatestVersion: "1.9"}},
+		{IOS: AppPlatformVersions{StoreURL: "http://apps.apple.com/app"}},
+	} {
+		if err := p.Validate(); !errors.Is(err, ErrAppVersionPolicyInvalid) {
+			t.Errorf("%+v: err = %v, want ErrAppVersionPolicyInvalid", p, err)
+		}
+	}
+}
+
+func TestAppVersionCheck(t *testing.T) {
+	svc := NewAppVersionService(memSettings{})
+	ctx := context.Background()
+	policy := AppVersionPolicy{
+		IOS:     AppPlatformVersions{MinVersion: "2.1", LatestVersion: "2.3.0", StoreURL: "https://apps.apple.com/app/id1"},
+		Android: AppPlatformVersions{},
+		Message: "Please update",
+	}
+	if err := svc.UpdatePolicy(ctx, policy, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := svc.Check(ctx, "iOS", "2.0.9"); c.Supported || !c.UpdateAvailable || c.Message != "Please update" || c.StoreURL == "" {
+		t.Errorf("below min: %+v", c)
+	}
+	if c := svc.Check(ctx, "ios", "2.2.0"); !c.Supported || !c.UpdateAvailable || c.Message != "" {
+		t.Errorf("below latest: %+v", c)
+	}
+	if c := svc.Check(ctx, "ios", "2.3"); !c.Supported || c.UpdateAvailable {
+		t.Errorf("current: %+v", c)
+	}
+	// No minimum, unknown platforms and unreadable versions all pass.
+	for _, c := range []AppVersionCheck{
+		svc.Check(ctx, "android", "0.1"),
+		svc.Check(ctx, "windows", "0.1"),
+		svc.Check(ctx, "ios", "dev-build"),
+	} {
+		if !c.Supported {
+			t.Errorf("should fail open: %+v", c)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	astypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+)
+
+// ============================================================================
+// ASG write operations — instance refresh, desired capacity and draining an
+// instance, so routine blue/green deploys don't need the AWS console.
+//
+// Every operation is two-step: called without a confirmation token it only
+// checks the guardrails and returns a plan plus a token; called again with
+// that token (same admin, same parameters, within asgConfirmTTL) it acts.
+// Tokens are HMAC-signed rather than stored so they work on whichever
+// instance behind the ALB gets the second request. Plans embed the state
+// they were made against (e.g. the current desired capacity), so a token
+// can't be replayed once that state has moved.
+// ============================================================================
+
+var (
+	ErrASGNotConfigured    = errors.New("auto scaling group not configured")
+	ErrASGGuardrail        = errors.New("refused by guardrail")
+	ErrASGConfirmInvalid   = apperr.Validation("confirmation token invalid or expired")
+	ErrASGInstanceNotFound = apperr.NotFound("instance is not in the auto scaling group")
+)
+
+// ASG operation names, also used as audit actions.
+const (
+	ASGOpInstanceRefresh = "asg_start_instance_refresh"
+	ASGOpSetDesired      = "asg_set_desired_capacity"
+	ASGOpDrainInstance   = "asg_drain_instance"
+)
+
+const (
+	// asgConfirmTTL is how long a plan's confirmation token stays valid.
+	asgConfirmTTL = 2 * time.Minute
+	// asgMaxDesiredStep caps how far one call can move desired capacity.
+	asgMaxDesiredStep = 2
+)
+
+// ASGPlan describes what an operation will do. ConfirmToken is empty once
+// the operation has executed.
+type ASGPlan struct {
+	Action       string            `json:"action"`
+	Params       map[string]string `json:"params"`
+	Summary      string            `json:"summary"`
+	ConfirmToken string            `json:"confirm_token,omitempty"`
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"`
+	Executed     bool              `json:"executed"`
+	Result       string            `json:"result,omitempty"`
+}
+
+// ASGInstanceRefresh is one instance refresh, newest first from
+// ListInstanceRefreshes.
+type ASGInstanceRefresh struct {
+	ID                 string     `json:"id"`
+	Status             string     `json:"status"`
+	StatusReason       string     `json:"status_reason,omitempty"`
+	PercentageComplete int        `json:"percentage_complete"`
+	InstancesToUpdate  int        `json:"instances_to_update"`
+	StartTime          *time.Time `json:"start_time,omitempty"`
+	EndTime            *time.Time `json:"end_time,omitempty"`
+}
+
+// SetConfirmSecret sets the key confirmation tokens are signed with. Write
+// operations are refused until it is set.
+func (s *CloudWatchService) SetConfirmSecret(secret string) {
+	s.confirmSecret = []byte(secret)
+}
+
+func (s *CloudWatchService) requireASG() error {
+	if s == nil || s.asgName == "" || len(s.confirmSecret) == 0 {
+		return ErrASGNotConfigured
+	}
+	return nil
+}
+
+// signASGPlan returns a token binding the action, its parameters and the
+// admin to an expiry.
+func signASGPlan(secret []byte, adminID uuid.UUID, action string, params map[string]string, expires time.Time) string {
+	v := url.Values{}
+	for k, p := range params {
+		v.Set(k, p)
+	}
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(adminID.String() + "\n" + action + "\n" + v.Encode() + "\n" + exp))
+	return exp + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func verifyASGPlan(secret []byte, adminID uuid.UUID, action string, params map[string]string, token string, now time.Time) bool {
+	exp, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.After(time.Unix(unix, 0)) {
+		return false
+	}
+	want := signASGPlan(secret, adminID, action, params, time.Unix(unix, 0))
+	return hmac.Equal([]byte(want), []byte(token))
+}
+
+// confirmOrPlan returns the plan with a fresh token when token is empty,
+// ErrASGConfirmInvalid when it doesn't match, or nil when the caller may
+// go ahead.
+func (s *CloudWatchService) confirmOrPlan(adminID uuid.UUID, plan *ASGPlan, token string) (*ASGPlan, error) {
+	now := time.Now()
+	if token == "" {
+		exp := now.Add(asgConfirmTTL)
+		plan.ConfirmToken = signASGPlan(s.confirmSecret, adminID, plan.Action, plan.Params, exp)
+		plan.ExpiresAt = &exp
+		return plan, nil
+	}
+	if !verifyASGPlan(s.confirmSecret, adminID, plan.Action, plan.Params, token, now) {
+		return nil, ErrASGConfirmInvalid
+	}
+	return nil, nil
+}
+
+// asgSnapshot is the group state the guardrails look at.
+type asgSnapshot struct {
+	min, max, desired int
+	inService         []string
+	lifecycle         map[string]string
+	refreshing        bool
+}
+
+func (s *CloudWatchService) describeASG(ctx context.Context) (*asgSnapshot, error) {
+	out, err := s.asgClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{s.asgName},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe ASG: %w", err)
+	}
+	if len(out.AutoScalingGroups) == 0 {
+		return nil, fmt.Errorf("%w: %s not found", ErrASGNotConfigured, s.asgName)
+	}
+	g := out.AutoScalingGroups[0]
+	snap := &asgSnapshot{
+		min:       int(aws.ToInt32(g.MinSize)),
+		max:       int(aws.ToInt32(g.MaxSize)),
+		desired:   int(aws.ToInt32(g.DesiredCapacity)),
+		lifecycle: map[string]string{},
+	}
+	for _, inst := range g.Instances {
+		id := aws.ToString(inst.InstanceId)
+		snap.lifecycle[id] = string(inst.LifecycleState)
+		if inst.LifecycleState == astypes.LifecycleStateInService {
+			snap.inService = append(snap.inService, id)
+		}
+	}
+	refreshes, err := s.ListInstanceRefreshes(ctx, 5)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range refreshes {
+		switch r.Status {
+		case "Pending", "InProgress", "Cancelling", "RollbackInProgress", "Baking":
+			snap.refreshing = true
+		}
+	}
+	return snap, nil
+}
+
+// checkDesiredCapacity applies the desired-capacity guardrails.
+func checkDesiredCapacity(g *asgSnapshot, desired int) error {
+	switch {
+	case g.refreshing:
+		return fmt.Errorf("%w: an instance refresh is in progress", ErrASGGuardrail)
+	case desired < 1:
+		return fmt.Errorf("%w: desired capacity must be at least 1", ErrASGGuardrail)
+	case desired < g.min || desired > g.max:
+		return fmt.Errorf("%w: desired capacity must be within the group's min %d / max %d", ErrASGGuardrail, g.min, g.max)
+	case desired == g.desired:
+		return fmt.Errorf("%w: desired capacity is already %d", ErrASGGuardrail, desired)
+	case desired-g.desired > asgMaxDesiredStep || g.desired-desired > asgMaxDesiredStep:
+		return fmt.Errorf("%w: change desired capacity by at most %d at a time (currently %d)", ErrASGGuardrail, asgMaxDesiredStep, g.desired)
+	}
+	return nil
+}
+
+// checkDrain applies the drain guardrails.
+func checkDrain(g *asgSnapshot, instanceID string) error {
+	state, ok := g.lifecycle[instanceID]
+	switch {
+	case !ok:
+		return ErrASGInstanceNotFound
+	case g.refreshing:
+		return fmt.Errorf("%w: an instance refresh is in progress", ErrASGGuardrail)
+	case state != string(astypes.LifecycleStateInService):
+		return fmt.Errorf("%w: instance is %s, not InService", ErrASGGuardrail, state)
+	case len(g.inService) < 2:
+		return fmt.Errorf("%w: it is the only InService instance", ErrASGGuardrail)
+	}
+	return nil
+}
+
+// ListInstanceRefreshes returns the group's most recent instance refreshes.
+func (s *CloudWatchService) ListInstanceRefreshes(ctx context.Context, limit int) ([]ASGInstanceRefresh, error) {
+	if s == nil || s.asgName == "" {
+		return nil, ErrASGNotConfigured
+	}
+	out, err := s.asgClient.DescribeInstanceRefreshes(ctx, &autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: aws.String(s.asgName),
+		MaxRecords:           aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe instance refreshes: %w", err)
+	}
+	refreshes := make([]ASGInstanceRefresh, 0, len(out.InstanceRefreshes))
+	for _, r := range out.InstanceRefreshes {
+		refreshes = append(refreshes, ASGInstanceRefresh{
+			ID:                 aws.ToString(r.InstanceRefreshId),
+			Status:             string(r.Status),
+			StatusReason:       aws.ToString(r.StatusReason),
+			PercentageComplete: int(aws.ToInt32(r.PercentageComplete)),
+			InstancesToUpdate:  int(aws.ToInt32(r.InstancesToUpdate)),
+			StartTime:          r.StartTime,
+			EndTime:            r.EndTime,
+		})
+	}
+	return refreshes, nil
+}
+
+// StartInstanceRefresh rolls every instance onto the group's current launch
+// template — the blue/green step after a new AMI or template version.
+// minHealthy (50-100) is the share of capacity kept InService while
+// instances are replaced; warmup is seconds before a new instance counts.
+func (s *CloudWatchService) StartInstanceRefresh(ctx context.Context, adminID uuid.UUID, minHealthy, warmup int, token string) (*ASGPlan, error) {
+	if err := s.requireASG(); err != nil {
+		return nil, err
+	}
+	if minHealthy < 50 || minHealthy > 100 {
+		return nil, fmt.Errorf("%w: min healthy percentage must be 50-100", ErrASGGuardrail)
+	}
+	if warmup < 0 || warmup > 3600 {
+		return nil, fmt.Errorf("%w: warmup must be 0-3600 seconds", ErrASGGuardrail)
+	}
+	g, err := s.describeASG(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if g.refreshing {
+		return nil, fmt.Errorf("%w: an instance refresh is already in progress", ErrASGGuardrail)
+	}
+	plan := &ASGPlan{
+		Action: ASGOpInstanceRefresh,
+		Params: map[string]string{
+			"asg":         s.asgName,
+			"min_healthy": strconv.Itoa(minHealthy),
+			"warmup":      strconv.Itoa(warmup),
+		},
+		Summary: fmt.Sprintf("Replace all %d instances of %s, keeping at least %d%% InService (warmup %ds)",
+			len(g.lifecycle), s.asgName, minHealthy, warmup),
+	}
+	if p, err := s.confirmOrPlan(adminID, plan, token); p != nil || err != nil {
+		return p, err
+	}
+	out, err := s.asgClient.StartInstanceRefresh(ctx, &autoscaling.StartInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(s.asgName),
+		Strategy:             astypes.RefreshStrategyRolling,
+		Preferences: &astypes.RefreshPreferences{
+			MinHealthyPercentage: aws.Int32(int32(minHealthy)),
+			InstanceWarmup:       aws.Int32(int32(warmup)),
+			SkipMatching:         aws.Bool(false),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start instance refresh: %w", err)
+	}
+	plan.Executed = true
+	plan.Result = "instance refresh " + aws.ToString(out.InstanceRefreshId) + " started"
+	return plan, nil
+}
+
+// SetDesiredCapacity moves the group's desired capacity, at most
+// asgMaxDesiredStep at a time and never outside min/max.
+func (s *CloudWatchService) SetDesiredCapacity(ctx context.Context, adminID uuid.UUID, desired int, token string) (*ASGPlan, error) {
+	if err := s.requireASG(); err != nil {
+		return nil, err
+	}
+	g, err := s.describeASG(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDesiredCapacity(g, desired); err != nil {
+		return nil, err
+	}
+	plan := &ASGPlan{
+		Action: ASGOpSetDesired,
+		Params: map[string]string{
+			"asg":  s.asgName,
+			"from": strconv.Itoa(g.desired),
+			"to":   strconv.Itoa(desired),
+		},
+		Summary: fmt.Sprintf("Change desired capacity of %s from %d to %d", s.asgName, g.desired, desired),
+	}
+	if p, err := s.confirmOrPlan(adminID, plan, token); p != nil || err != nil {
+		return p, err
+	}
+	_, err = s.asgClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+		AutoScalingGroupName: aws.String(s.asgName),
+		DesiredCapacity:      aws.Int32(int32(desired)),
+		HonorCooldown:        aws.Bool(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("set desired capacity: %w", err)
+	}
+	plan.Executed = true
+	plan.Result = fmt.Sprintf("desired capacity set to %d", desired)
+	return plan, nil
+}
+
+// DrainInstance takes one instance out of service; the ASG deregisters it
+// from the target group (waiting out connection draining) and launches a
+// replacement. With terminate false the instance is parked in Standby for
+// inspection and must be terminated from the console afterwards; with
+// terminate true it is terminated once drained.
+func (s *CloudWatchService) DrainInstance(ctx context.Context, adminID uuid.UUID, instanceID string, terminate bool, token string) (*ASGPlan, error) {
+	if err := s.requireASG(); err != nil {
+		return nil, err
+	}
+	g, err := s.describeASG(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDrain(g, instanceID); err != nil {
+		return nil, err
+	}
+	mode := "standby"
+	summary := fmt.Sprintf("Move %s to Standby (out of the load balancer) and launch a replacement", instanceID)
+	if terminate {
+		mode = "terminate"
+		summary = fmt.Sprintf("Drain and terminate %s, launching a replacement", instanceID)
+	}
+	plan := &ASGPlan{
+		Action:  ASGOpDrainInstance,
+		Params:  map[string]string{"asg": s.asgName, "instance_id": instanceID, "mode": mode},
+		Summary: summary,
+	}
+	if p, err := s.confirmOrPlan(adminID, plan, token); p != nil || err != nil {
+		return p, err
+	}
+	if terminate {
+		_, err = s.asgClient.TerminateInstanceInAutoScalingGroup(ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String(instanceID),
+			ShouldDecrementDesiredCapacity: aws.Bool(false),
+		})
+	} else {
+		// Not decrementing desired capacity is what makes the group
+		// launch the replacement.
+		_, err = s.asgClient.EnterStandby(ctx, &autoscaling.EnterStandbyInput{
+			AutoScalingGroupName:           aws.String(s.asgName),
+			InstanceIds:                    []string{instanceID},
+			ShouldDecrementDesiredCapacity: aws.Bool(false),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("drain %s: %w", instanceID, err)
+	}
+	plan.Executed = true
+	plan.Result = instanceID + " draining (" + mode + ")"
+	return plan, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestASGPlanToken(t *testing.T) {
+	secret := []byte("s3cret")
+	admin := uuid.New()
+	params := map[string]string{"asg": "cc-asg", "from": "2", "to": "3"}
+	now := time.Now()
+	tok := signASGPlan(secret, admin, ASGOpSetDesired, params, now.Add(asgConfirmTTL))
+
+	if !verifyASGPlan(secret, admin, ASGOpSetDesired, params, tok, now) {
+		t.Fatal("token should verify")
+	}
+	if verifyASGPlan(secret, uuid.New(), ASGOpSetDesired, params, tok, now) {
+		t.Error("token verified for another admin")
+	}
+	if verifyASGPlan(secret, admin, ASGOpDrainInstance, params, tok, now) {
+		t.Error("token verified for another action")
+	}
+	moved := map[string]string{"asg": "cc-asg", "from": "3", "to": "3"}
+	if verifyASGPlan(secret, admin, ASGOpSetDesired, moved, tok, now) {
+		t.Error("token verified after the group state moved")
+	}
+	if verifyASGPlan(secret, admin, ASGOpSetDesired, params, tok, now.Add(asgConfirmTTL+time.Second)) {
+		t.Error("expired token verified")
+	}
+	if verifyASGPlan(secret, admin, ASGOpSetDesired, params, "garbage", now) {
+		t.Error("malformed token verified")
+	}
+}
+
+func TestCheckDesiredCapacity(t *testing.T) {
+	g := &asgSnapshot{min: 1, max: 6, desired: 2}
+	cases := []struct {
+		desired int
+		ok      bool
+	}{
+		{3, true},
+		{4, true},
+		{1, true},
+		{5, false}, // step too large
+		{2, false}, // no change
+		{0, false}, // below 1
+		{7, false}, // above max
+	}
+	for _, c := range cases {
+		err := checkDesiredCapacity(g, c.desired)
+		if (err == nil) != c.ok {
+			t.Errorf("desired %d: err = %v, want ok=%v", c.desired, err, c.ok)
+		}
+		if err != nil && !errors.Is(err, ErrASGGuardrail) {
+			t.Errorf("desired %d: err = %v, want ErrASGGuardrail", c.desired, err)
+		}
+	}
+
+	g.refreshing = true
+	if err := checkDesiredCapacity(g, 3); !errors.Is(err, ErrASGGuardrail) {
+		t.Errorf("during refresh: err = %v, want ErrASGGuardrail", err)
+	}
+}
+
+func TestCheckDrain(t *testing.T) {
+	g := &asgSnapshot{
+		inService: []string{"i-a", "i-b"},
+		lifecycle: map[string]string{"i-a": "InService", "i-b": "InService", "i-c": "Pending"},
+	}
+	if err := checkDrain(g, "i-a"); err != nil {
+		t.Errorf("i-a: %v", err)
+	}
+	if err := checkDrain(g, "i-x"); !errors.Is(err, ErrASGInstanceNotFound) {
+		t.Errorf("unknown instance: err = %v", err)
+	}
+	if err := checkDrain(g, "i-c"); !errors.Is(err, ErrASGGuardrail) {
+		t.Errorf("pending instance: err = %v", err)
+	}
+
+	g.inService = []string{"i-a"}
+	if err := checkDrain(g, "i-a"); !errors.Is(err, ErrASGGuardrail) {
+		t.Errorf("last InService instance: err = %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrAssetSignature = errors.New("asset link expired or invalid")
+
+// StaticAssetURLTTL is how long a signed /static link handed to the admin
+// UI stays valid. Long enough to open a preview or share the link with a
+// colleague, short enough that a forwarded link goes stale the same day.
+const StaticAssetURLTTL = time.Hour
+
+// AssetSigner issues and checks signed, expiring URLs for files under
+// /static that shouldn't be public (generated marketing PDFs, attachments
+// written to disk). The signature is an HMAC over the URL path and expiry,
+// in the same form as the report and ticket attachment links.
+type AssetSigner struct {
+	secret    []byte
+	protected []string
+}
+
+// NewAssetSigner creates a signer. protected are URL path prefixes such as
+// "/static/marketing/"; only paths under them need a signature.
+func NewAssetSigner(secret string, protected ...string) *AssetSigner {
+	return &AssetSigner{secret: []byte(secret), protected: protected}
+}
+
+// Protected reports whether urlPath may only be served with a signature.
+// The path is cleaned first so "/static/x/../marketing/a.pdf" counts.
+func (s *AssetSigner) Protected(urlPath string) bool {
+	urlPath = path.Clean("/" + urlPath)
+	for _, p := range s.protected {
+		if strings.HasPrefix(urlPath+"/", p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AssetSigner) mac(urlPath string, expUnix int64) []byte {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte("static-asset|" + path.Clean("/"+urlPath) + "|" + strconv.FormatInt(expUnix, 10)))
+	return m.Sum(nil)
+}
+
+// SignedURL returns urlPath with exp and sig query parameters that Verify
+// accepts until exp.
+func (s *AssetSigner) SignedURL(urlPath string, ttl time.Duration) (signed string, exp time.Time) {
+	exp = time.Now().Add(ttl)
+	urlPath = path.Clean("/" + urlPath)
+	sig := hex.EncodeToString(s.mac(urlPath, exp.Unix()))
+	return fmt.Sprintf("%s?exp=%d&sig=%s", (&url.URL{Path: urlPath}).EscapedPath(), exp.Unix(), sig), exp
+}
+
+// Verify checks expiry and signature for urlPath.
+func (s *AssetSigner) Verify(urlPath string, expUnix int64, sig string) error {
+	if time.Now().Unix() > expUnix {
+		return ErrAssetSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(urlPath, expUnix)) {
+		return ErrAssetSignature
+	}
+	return nil
+}
+
+// SignedFileURL is SignedURL for a file on disk under the static directory
+// ("static/marketing/brochures/a.pdf"). It returns "" for files outside it.
+func (s *AssetSigner) SignedFileURL(filePath string, ttl time.Duration) (signed string, exp time.Time) {
+	rel := filepath.ToSlash(filepath.Clean(filePath))
+	if !strings.HasPrefix(rel, "static/") {
+		return "", time.Time{}
+	}
+	return s.SignedURL("/"+rel, ttl)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAssetSigner(t *testing.T) {
+	s := NewAssetSigner("test-secret", "/static/marketing/")
+
+	for path, want := range map[string]bool{
+		"/static/marketing/brochures/a.pdf":    true,
+		"/static/marketing":                    true,
+		"/static/images/../marketing/logo.png": true,
+		"/static/images/logo.png":              false,
+		"/static/marketingx/a.pdf":             false,
+	} {
+		if got := s.Protected(path); got != want {
+			t.Errorf("Protected(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	signed, exp := s.SignedFileURL("static/marketing/brochures/family guide.pdf", time.Minute)
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/static/marketing/brochures/family guide.pdf" || u.Query().Get("exp") != strconv.FormatInt(exp.Unix(), 10) {
+		t.Fatalf("signed URL = %q", signed)
+	}
+	sig := u.Query().Get("sig")
+	if err := s.Verify(u.Path, exp.Unix(), sig); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := s.Verify("/static/marketing/brochures/other.pdf", exp.Unix(), sig); err == nil {
+		t.Error("signature must be bound to the path")
+	}
+	if err := s.Verify(u.Path, exp.Unix()+1, sig); err == nil {
+		t.Error("tampered expiry accepted")
+	}
+	past := time.Now().Add(-time.Minute).Unix()
+	if err := s.Verify(u.Path, past, hex.EncodeToString(s.mac(u.Path, past))); err == nil {
+		t.Error("expired link accepted")
+	}
+	if got, _ := s.SignedFileURL("/etc/passwd", time.Minute); got != "" {
+		t.Errorf("file outside static signed: %q", got)
+	}
+}
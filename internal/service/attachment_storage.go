@@ -233,6 +233,8 @@ func extFromName(filename, contentType string) string {
 		return ".webm"
 	case "video/3gpp":
 		return ".3gp"
+	case "application/vnd.apache.parquet":
+		return ".parquet"
 	}
 	return ""
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -14,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/config"
 	"carecompanion/internal/database"
 	"carecompanion/internal/models"
@@ -38,10 +40,10 @@ func stripPort(addr string) string {
 
 var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrUserNotFound       = apperr.NotFound("user not found")
 	ErrUserInactive       = errors.New("user account is inactive")
 	ErrInvalidToken       = errors.New("invalid or expired token")
-	ErrEmailExists        = errors.New("email already registered")
+	ErrEmailExists        = apperr.Conflict("email already registered")
 )
 
 type AuthService struct {
@@ -54,7 +56,11 @@ type AuthService struct {
 	emailService *EmailService
 	appURL       string
 	appEnv       string
-	subSvc       *SubscriptionService // wired post-construction; nil-safe
+	subSvc       *SubscriptionService      // wired post-construction; nil-safe
+	campaignSvc  *CampaignService          // wired post-construction; nil-safe
+	verifySvc    *EmailVerificationService // wired post-construction; nil-safe
+	adminPolicy  *AdminSessionPolicyService // wired post-construction; nil-safe
+	tasks        *TaskQueue                 // wired post-construction; nil-safe
 }
 
 // SetSubscriptionService wires the subscription lifecycle service so
@@ -63,6 +69,76 @@ func (s *AuthService) SetSubscriptionService(sub *SubscriptionService) {
 	s.subSvc = sub
 }
 
+// SetCampaignService wires campaign attribution so Register can record the
+// UTM parameters a new user arrived with.
+func (s *AuthService) SetCampaignService(c *CampaignService) {
+	s.campaignSvc = c
+}
+
+// SetEmailVerificationService wires email verification so Register can
+// email the new user a verification link.
+func (s *AuthService) SetEmailVerificationService(v *EmailVerificationService) {
+	s.verifySvc = v
+}
+
+// SetAdminSessionPolicy wires the admin session policy: duration, idle
+// and concurrency limits on admin sessions, and the step-up window.
+func (s *AuthService) SetAdminSessionPolicy(p *AdminSessionPolicyService) {
+	s.adminPolicy = p
+}
+
+// TaskWelcomeEmail is the task type that sends the post-signup welcome
+// email, so a mail provider outage retries instead of dropping it.
+const TaskWelcomeEmail = "welcome_email"
+
+type welcomeEmailTask struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+}
+
+// SetTaskQueue registers the welcome email task type on q and sends
+// welcome emails through it from then on. Call before q.Start.
+func (s *AuthService) SetTaskQueue(q *TaskQueue) {
+	s.tasks = q
+	q.Register(TaskType{
+		Name:        TaskWelcomeEmail,
+		Concurrency: 2,
+		Timeout:     time.Minute,
+		Handler: func(ctx context.Context, payload json.RawMessage) error {
+			var p welcomeEmailTask
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return err
+			}
+			return s.emailService.SendWelcomeEmail(p.Email, p.FirstName, s.appURL)
+		},
+	})
+}
+
+// sendWelcomeEmail queues the welcome email, or sends it from a goroutine
+// when there's no queue (no Redis) so signup never waits on SMTP.
+func (s *AuthService) sendWelcomeEmail(ctx context.Context, user *models.User) {
+	if s.tasks != nil {
+		_, err := s.tasks.Enqueue(ctx, TaskWelcomeEmail, welcomeEmailTask{Email: user.Email, FirstName: user.FirstName})
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, ErrTaskQueueUnavailable) {
+			log.Printf("[EMAIL] Failed to queue welcome email for %s, sending inline: %v", user.Email, err)
+		}
+	}
+	email, firstName := user.Email, user.FirstName
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[AUTH] panic in welcome-email goroutine: %v\n%s", r, debug.Stack())
+			}
+		}()
+		if err := s.emailService.SendWelcomeEmail(email, firstName, s.appURL); err != nil {
+			log.Printf("[EMAIL] Failed to send welcome email to %s: %v", email, err)
+		}
+	}()
+}
+
 func NewAuthService(
 	userRepo repository.UserRepository,
 	familyRepo repository.FamilyRepository,
@@ -141,6 +217,8 @@ type RegisterRequest struct {
 	LastName   string `json:"last_name"`
 	Phone      string `json:"phone,omitempty"`
 	FamilyName string `json:"family_name,omitempty"`
+	// Attribution is the optional first-touch UTM data for campaign reporting.
+	Attribution *SignupAttributionInput `json:"attribution,omitempty"`
 }
 
 type LoginRequest struct {
@@ -186,6 +264,9 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*mode
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, nil, err
 	}
+	if s.campaignSvc != nil && req.Attribution != nil {
+		s.campaignSvc.RecordSignup(ctx, user.ID, *req.Attribution)
+	}
 
 	// Create family if name provided
 	var familyID uuid.UUID
@@ -246,16 +327,15 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*mode
 	}
 
 	// Send welcome email (async, don't block registration)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("[AUTH] panic in welcome-email goroutine: %v\n%s", r, debug.Stack())
-			}
-		}()
-		if err := s.emailService.SendWelcomeEmail(user.Email, user.FirstName, s.appURL); err != nil {
-			log.Printf("[EMAIL] Failed to send welcome email to %s: %v", user.Email, err)
+	s.sendWelcomeEmail(ctx, user)
+
+	// Start email verification. A failure here shouldn't fail signup; the
+	// user can resend the link from Settings.
+	if s.verifySvc != nil {
+		if err := s.verifySvc.Start(ctx, user.ID); err != nil {
+			log.Printf("[AUTH] Failed to start email verification for %s: %v", user.Email, err)
 		}
-	}()
+	}
 
 	// Determine the role for token generation
 	// If user created their own family, they're a parent; if they joined via invitation, use that role
@@ -320,8 +400,7 @@ func (s *AuthService) LoginWithContext(ctx context.Context, req *LoginRequest, l
 		lc.Kind = models.SessionKindUser
 	}
 
-	// At most one active session per (user_id, kind). Revoke any existing one.
-	_ = s.sessionRepo.RevokeForUserKind(ctx, user.ID, lc.Kind)
+	s.makeRoomForSession(ctx, user.ID, lc.Kind)
 
 	expires := time.Now().Add(s.jwtConfig.AccessExpiry)
 	sess := &models.Session{
@@ -364,6 +443,29 @@ func (s *AuthService) LoginWithContext(ctx context.Context, req *LoginRequest, l
 	return user, tokens, nil
 }
 
+// makeRoomForSession revokes sessions so the one about to be created fits:
+// at most one active session per (user_id, kind), except admins, who get
+// the admin session policy's concurrent limit and lose their oldest.
+func (s *AuthService) makeRoomForSession(ctx context.Context, userID uuid.UUID, kind models.SessionKind) {
+	if kind != models.SessionKindAdmin || s.adminPolicy == nil {
+		_ = s.sessionRepo.RevokeForUserKind(ctx, userID, kind)
+		return
+	}
+	limit := s.adminPolicy.Current(ctx).MaxConcurrentSessions
+	active, err := s.sessionRepo.ListActiveForUser(ctx, userID, kind)
+	if err != nil {
+		log.Printf("[AUTH] list sessions for %s: %v", userID, err)
+		_ = s.sessionRepo.RevokeForUserKind(ctx, userID, kind)
+		return
+	}
+	// Newest first: keep limit-1 of them.
+	for _, old := range active[min(limit-1, len(active)):] {
+		if err := s.RevokeSession(ctx, old.ID); err != nil {
+			log.Printf("[AUTH] revoke session %s past the concurrent limit: %v", old.ID, err)
+		}
+	}
+}
+
 func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
 	return s.sessionRepo.RevokeForUserKind(ctx, userID, models.SessionKindUser)
 }
@@ -382,7 +484,7 @@ func (s *AuthService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
 var (
 	ErrSessionRevoked  = errors.New("session revoked")
 	ErrSessionExpired  = errors.New("session expired")
-	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionNotFound = apperr.NotFound("session not found")
 )
 
 func (s *AuthService) RevokeSession(ctx context.Context, sid uuid.UUID) error {
@@ -414,10 +516,66 @@ func (s *AuthService) ValidateSession(ctx context.Context, sid uuid.UUID) error
 	if time.Now().After(row.ExpiresAt) {
 		return ErrSessionExpired
 	}
+	if row.Kind == models.SessionKindAdmin && s.adminPolicy != nil {
+		// Revoked so it leaves the live sessions list; this request still
+		// learns why.
+		if err := s.adminPolicy.Current(ctx).Check(row, time.Now()); err != nil {
+			_ = s.RevokeSession(ctx, sid)
+			return err
+		}
+	}
 	s.sessionCache.MarkValid(ctx, sid)
 	return nil
 }
 
+// CheckRecentAuth returns ErrStepUpRequired unless the admin behind sid
+// entered their password within the admin session policy's step-up window.
+func (s *AuthService) CheckRecentAuth(ctx context.Context, sid uuid.UUID) error {
+	if s.adminPolicy == nil {
+		return nil
+	}
+	policy := s.adminPolicy.Current(ctx)
+	if policy.StepUpMinutes == 0 {
+		return nil
+	}
+	if sid == uuid.Nil {
+		return ErrStepUpRequired
+	}
+	row, err := s.sessionRepo.GetByID(ctx, sid)
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return ErrSessionNotFound
+	}
+	if !policy.RecentlyAuthenticated(row, time.Now()) {
+		return ErrStepUpRequired
+	}
+	return nil
+}
+
+// Reauthenticate checks the admin's password again for step-up and, when
+// it matches, restarts the session's step-up window.
+func (s *AuthService) Reauthenticate(ctx context.Context, claims *AuthClaims, password string) error {
+	if claims.Sid == uuid.Nil {
+		return ErrInvalidToken
+	}
+	user, err := s.userRepo.GetAdminByEmail(ctx, claims.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.ID != claims.UserID {
+		return ErrInvalidCredentials
+	}
+	if user.Status != models.UserStatusActive {
+		return ErrUserInactive
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return s.sessionRepo.MarkAuthenticated(ctx, claims.Sid)
+}
+
 // TouchSession updates last_seen_at off the request hot path. Best-effort —
 // errors are swallowed.
 func (s *AuthService) TouchSession(sid uuid.UUID) {
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// awsJSONClient calls an AWS JSON 1.1 protocol API (Cost Explorer,
+// CloudWatch Logs, ...) with the SDK's SigV4 signer and default credential
+// chain, for services whose SDK module we don't pull in. Same approach as
+// RDSClient for the Query protocol.
+type awsJSONClient struct {
+	endpoint string
+	region   string
+	// signingName is the SigV4 service name, e.g. "ce" or "logs".
+	signingName string
+	// targetPrefix is the X-Amz-Target prefix, e.g. "Logs_20140328".
+	targetPrefix string
+	creds        aws.CredentialsProvider
+	signer       *v4.Signer
+	http         *http.Client
+}
+
+func newAWSJSONClient(endpoint, region, signingName, targetPrefix string, creds aws.CredentialsProvider) *awsJSONClient {
+	return &awsJSONClient{
+		endpoint:     endpoint,
+		region:       region,
+		signingName:  signingName,
+		targetPrefix: targetPrefix,
+		creds:        creds,
+		signer:       v4.NewSigner(),
+		http:         &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// loadAWSCredentials returns the default credential chain for region.
+func loadAWSCredentials(ctx context.Context, region string) (aws.CredentialsProvider, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+	return cfg.Credentials, nil
+}
+
+// AWSAPIError is an error response from a JSON protocol API.
+type AWSAPIError struct {
+	Service string
+	Code    string
+	Message string
+}
+
+func (e *AWSAPIError) Error() string { return e.Service + ": " + e.Code + ": " + e.Message }
+
+// call POSTs one signed operation and decodes the response into out.
+func (c *awsJSONClient) call(ctx context.Context, op string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", c.targetPrefix+"."+op)
+
+	creds, err := c.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("AWS credentials: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), c.signingName, c.region, time.Now()); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type     string `json:"__type"`
+			Message  string `json:"message"`
+			MessageU string `json:"Message"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Type != "" {
+			// __type may carry a namespace: "com.amazonaws...#ThrottlingException".
+			code := e.Type[strings.LastIndex(e.Type, "#")+1:]
+			msg := e.Message
+			if msg == "" {
+				msg = e.MessageU
+			}
+			return &AWSAPIError{Service: c.signingName, Code: code, Message: msg}
+		}
+		return fmt.Errorf("%s %s: HTTP %d", c.signingName, op, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
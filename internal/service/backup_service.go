@@ -0,0 +1,365 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrSnapshotsDisabled = errors.New("RDS snapshots are not configured (BACKUP_RDS_INSTANCE_ID unset)")
+	ErrNoSnapshot        = errors.New("no available snapshot to restore")
+)
+
+// ConfigDumpTables is the allowlist of tables the nightly logical dump may
+// include. Every table here is configuration or reference data with no
+// PHI and no user accounts; anything touching children, logs, families,
+// users, tickets or payments stays in the RDS snapshots only. Adding a
+// table here is a privacy review, not a config change.
+var ConfigDumpTables = []string{
+	"subscription_plans",
+	"promo_codes",
+	"system_settings",
+	"custom_roles",
+	"custom_role_permissions",
+	"kb_categories",
+	"kb_articles",
+	"ticket_categories",
+	"ticket_tags",
+	"brand_config",
+	"social_templates",
+	"medication_reference",
+	"marketing_campaigns",
+	"version_log",
+}
+
+// BackupOptions configures BackupService; NewServices fills it from
+// config.BackupConfig.
+type BackupOptions struct {
+	// InstanceID is the RDS instance to snapshot; empty disables snapshots.
+	InstanceID string
+	// DumpTables narrows ConfigDumpTables; names outside the allowlist are
+	// ignored. Empty means the whole allowlist.
+	DumpTables  []string
+	DumpHourUTC int
+}
+
+// BackupService triggers and tracks RDS snapshots and writes logical dumps
+// of the config tables to blob storage. Restore drills live in
+// restore_drill.go.
+type BackupService struct {
+	repo  repository.BackupRepository
+	rds   RDSAPI
+	store BlobStorage
+	db    *sql.DB
+	opts  BackupOptions
+	now   func() time.Time
+}
+
+// NewBackupService creates the service. rds may be nil when snapshots
+// aren't configured; dumps still work.
+func NewBackupService(repo repository.BackupRepository, rds RDSAPI, store BlobStorage, db *sql.DB, opts BackupOptions) *BackupService {
+	return &BackupService{repo: repo, rds: rds, store: store, db: db, opts: opts, now: time.Now}
+}
+
+// SnapshotsEnabled reports whether an RDS instance is configured.
+func (s *BackupService) SnapshotsEnabled() bool {
+	return s.rds != nil && s.opts.InstanceID != ""
+}
+
+// InstanceID is the RDS instance being backed up.
+func (s *BackupService) InstanceID() string { return s.opts.InstanceID }
+
+// DumpTables is the effective list of tables the config dump includes.
+func (s *BackupService) DumpTables() []string {
+	if len(s.opts.DumpTables) == 0 {
+		return ConfigDumpTables
+	}
+	want := make(map[string]bool, len(s.opts.DumpTables))
+	for _, t := range s.opts.DumpTables {
+		want[t] = true
+	}
+	var out []string
+	for _, t := range ConfigDumpTables {
+		if want[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// TriggerSnapshot starts a manual RDS snapshot and records it as creating;
+// SyncSnapshots picks up the final status.
+func (s *BackupService) TriggerSnapshot(ctx context.Context, by uuid.UUID) (*repository.DBBackup, error) {
+	if !s.SnapshotsEnabled() {
+		return nil, ErrSnapshotsDisabled
+	}
+	id := fmt.Sprintf("%s-manual-%s", s.opts.InstanceID, s.now().UTC().Format("20060102-150405"))
+	snap, err := s.rds.CreateSnapshot(ctx, s.opts.InstanceID, id)
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot: %w", err)
+	}
+	b := &repository.DBBackup{
+		Kind:         repository.BackupKindSnapshot,
+		Identifier:   id,
+		Source:       s.opts.InstanceID,
+		SnapshotType: "manual",
+		Status:       snapshotStatus(snap.Status),
+	}
+	if by != uuid.Nil {
+		b.TriggeredBy = models.NullUUID{UUID: by, Valid: true}
+	}
+	if err := s.repo.Create(ctx, b); err != nil {
+		return nil, fmt.Errorf("record snapshot %s: %w", id, err)
+	}
+	log.Printf("[BACKUP] snapshot %s started", id)
+	return b, nil
+}
+
+// SyncSnapshots mirrors the instance's snapshots, manual and automated,
+// into db_backups. Snapshots we recorded as creating that RDS no longer
+// lists are marked deleted. Returns the number of snapshots seen.
+func (s *BackupService) SyncSnapshots(ctx context.Context) (int, error) {
+	if !s.SnapshotsEnabled() {
+		return 0, ErrSnapshotsDisabled
+	}
+	snaps, err := s.rds.DescribeSnapshots(ctx, s.opts.InstanceID, "")
+	if err != nil {
+		return 0, fmt.Errorf("describe snapshots: %w", err)
+	}
+	seen := make(map[string]bool, len(snaps))
+	for _, snap := range snaps {
+		seen[snap.Identifier] = true
+		b := &repository.DBBackup{
+			Kind:         repository.BackupKindSnapshot,
+			Identifier:   snap.Identifier,
+			Source:       snap.InstanceIdentifier,
+			SnapshotType: snap.SnapshotType,
+			Status:       snapshotStatus(snap.Status),
+			SizeBytes:    snap.AllocatedStorageGB << 30,
+			StartedAt:    snap.CreatedAt,
+		}
+		if b.StartedAt.IsZero() {
+			b.StartedAt = s.now()
+		}
+		if b.Status == repository.BackupStatusFailed {
+			b.Error = "RDS status: " + snap.Status
+		}
+		if err := s.repo.UpsertSnapshot(ctx, b); err != nil {
+			return 0, fmt.Errorf("record snapshot %s: %w", snap.Identifier, err)
+		}
+	}
+	pending, err := s.repo.Pending(ctx, repository.BackupKindSnapshot)
+	if err != nil {
+		return len(snaps), err
+	}
+	for _, p := range pending {
+		if !seen[p.Identifier] {
+			p.Status, p.Error = repository.BackupStatusDeleted, "no longer listed by RDS"
+			if err := s.repo.Finish(ctx, &p); err != nil {
+				return len(snaps), err
+			}
+		}
+	}
+	return len(snaps), nil
+}
+
+// snapshotStatus folds RDS snapshot states into ours.
+func snapshotStatus(rdsStatus string) string {
+	switch {
+	case rdsStatus == "available":
+		return repository.BackupStatusAvailable
+	case rdsStatus == "failed", strings.HasPrefix(rdsStatus, "incompatible"):
+		return repository.BackupStatusFailed
+	case rdsStatus == "deleting" || rdsStatus == "deleted":
+		return repository.BackupStatusDeleted
+	default: // creating, copying, pending ...
+		return repository.BackupStatusCreating
+	}
+}
+
+// configDumpManifest is manifest.json inside a config dump archive.
+type configDumpManifest struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Format    string         `json:"format"`
+	Tables    map[string]int `json:"tables"`
+}
+
+// DumpConfigTables writes the allowlisted config tables to blob storage as
+// a tar.gz of one NDJSON file per table plus manifest.json. All tables are
+// read in one read-only repeatable-read transaction so the dump is
+// consistent. The archive is held in memory; these tables are small.
+func (s *BackupService) DumpConfigTables(ctx context.Context, by uuid.UUID) (*repository.DBBackup, error) {
+	tables := s.DumpTables()
+	started := s.now().UTC()
+	name := "config-" + started.Format("20060102-150405") + ".tar.gz"
+	b := &repository.DBBackup{
+		Kind:       repository.BackupKindConfigDump,
+		Identifier: name,
+		Source:     s.store.Driver(),
+		Status:     repository.BackupStatusCreating,
+		Tables:     tables,
+	}
+	if by != uuid.Nil {
+		b.TriggeredBy = models.NullUUID{UUID: by, Valid: true}
+	}
+	if err := s.repo.Create(ctx, b); err != nil {
+		return nil, fmt.Errorf("record dump: %w", err)
+	}
+
+	archive, err := s.writeConfigDump(ctx, tables, started)
+	if err == nil {
+		var path string
+		var size int64
+		path, size, err = s.store.Save(ctx, "config", name, "application/gzip", bytes.NewReader(archive))
+		if err == nil {
+			b.Identifier, b.SizeBytes, b.Status = path, size, repository.BackupStatusAvailable
+		}
+	}
+	if err != nil {
+		b.Status, b.Error = repository.BackupStatusFailed, err.Error()
+	}
+	if ferr := s.repo.Finish(ctx, b); ferr != nil {
+		return b, fmt.Errorf("record dump result: %w", ferr)
+	}
+	if err != nil {
+		return b, fmt.Errorf("config dump: %w", err)
+	}
+	log.Printf("[BACKUP] config dump %s: %d tables, %d bytes", b.Identifier, len(tables), b.SizeBytes)
+	return b, nil
+}
+
+func (s *BackupService) writeConfigDump(ctx context.Context, tables []string, at time.Time) ([]byte, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	manifest := configDumpManifest{CreatedAt: at, Format: "ndjson", Tables: map[string]int{}}
+
+	for _, table := range tables {
+		data, n, err := dumpTable(ctx, tx, table)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		manifest.Tables[table] = n
+		if err := writeTarFile(tw, table+".ndjson", data, at); err != nil {
+			return nil, err
+		}
+	}
+	mj, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarFile(tw, "manifest.json", mj, at); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// dumpTable returns every row of table as one JSON object per line.
+func dumpTable(ctx context.Context, tx *sql.Tx, table string) ([]byte, int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT row_to_json(t)::text FROM "+pq.QuoteIdentifier(table)+" t")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var buf bytes.Buffer
+	n := 0
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, 0, err
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		n++
+	}
+	return buf.Bytes(), n, rows.Err()
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, at time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: at}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func (s *BackupService) List(ctx context.Context, kind string, limit int) ([]repository.DBBackup, error) {
+	return s.repo.List(ctx, kind, limit)
+}
+
+func (s *BackupService) ListDrills(ctx context.Context, limit int) ([]repository.RestoreDrill, error) {
+	return s.repo.ListDrills(ctx, limit)
+}
+
+// BackupScheduler runs the config dump daily at DumpHourUTC and syncs
+// snapshot status every 15 minutes, so manual snapshots flip to available
+// on the dashboard without anyone refreshing RDS.
+type BackupScheduler struct {
+	svc  *BackupService
+	jobs *JobLocker
+}
+
+func NewBackupScheduler(svc *BackupService, jobs *JobLocker) *BackupScheduler {
+	return &BackupScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *BackupScheduler) Start(ctx context.Context) {
+	hour := s.svc.opts.DumpHourUTC
+	log.Printf("Backup scheduler started (config dump %02d:00 UTC daily, snapshots enabled=%t)", hour, s.svc.SnapshotsEnabled())
+	sync := func() {
+		if !s.svc.SnapshotsEnabled() {
+			return
+		}
+		s.jobs.RunOnce(ctx, "backup_snapshot_sync", TickSlot(time.Now(), 15*time.Minute), 15*time.Minute, func(ctx context.Context) {
+			if _, err := s.svc.SyncSnapshots(ctx); err != nil {
+				log.Printf("Backup: snapshot sync failed: %v", err)
+			}
+		})
+	}
+	sync()
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+	for {
+		next := nextUTCRunAt(time.Now().UTC(), hour, 0)
+		select {
+		case <-ctx.Done():
+			log.Println("Backup scheduler stopped")
+			return
+		case <-ticker.C:
+			sync()
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "backup_config_dump", next, 24*time.Hour, func(ctx context.Context) {
+				if _, err := s.svc.DumpConfigTables(ctx, uuid.Nil); err != nil {
+					log.Printf("Backup: config dump failed: %v", err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// backupRepo is an in-memory BackupRepository.
+type backupRepo struct {
+	backups []*repository.DBBackup
+	drills  []*repository.RestoreDrill
+}
+
+func (r *backupRepo) find(kind, identifier string) *repository.DBBackup {
+	for _, b := range r.backups {
+		if b.Kind == kind && b.Identifier == identifier {
+			return b
+		}
+	}
+	return nil
+}
+
+func (r *backupRepo) Create(ctx context.Context, b *repository.DBBackup) error {
+	b.ID, b.StartedAt = uuid.New(), time.Now()
+	cp := *b
+	r.backups = append(r.backups, &cp)
+	return nil
+}
+
+func (r *backupRepo) UpsertSnapshot(ctx context.Context, b *repository.DBBackup) error {
+	if have := r.find(repository.BackupKindSnapshot, b.Identifier); have != nil {
+		have.SnapshotType, have.Status, have.SizeBytes, have.Error = b.SnapshotType, b.Status, b.SizeBytes, b.Error
+		b.ID = have.ID
+		return nil
+	}
+	b.ID = uuid.New()
+	cp := *b
+	cp.Kind = repository.BackupKindSnapshot
+	r.backups = append(r.backups, &cp)
+	return nil
+}
+
+func (r *backupRepo) Finish(ctx context.Context, b *repository.DBBackup) error {
+	for _, have := range r.backups {
+		if have.ID == b.ID {
+			have.Status, have.Identifier, have.SizeBytes, have.Error = b.Status, b.Identifier, b.SizeBytes, b.Error
+		}
+	}
+	return nil
+}
+
+func (r *backupRepo) List(ctx context.Context, kind string, limit int) ([]repository.DBBackup, error) {
+	var out []repository.DBBackup
+	for _, b := range r.backups {
+		if kind == "" || b.Kind == kind {
+			out = append(out, *b)
+		}
+	}
+	return out, nil
+}
+
+func (r *backupRepo) Pending(ctx context.Context, kind string) ([]repository.DBBackup, error) {
+	var out []repository.DBBackup
+	for _, b := range r.backups {
+		if b.Kind == kind && b.Status == repository.BackupStatusCreating {
+			out = append(out, *b)
+		}
+	}
+	return out, nil
+}
+
+func (r *backupRepo) Latest(ctx context.Context, kind, status string) (*repository.DBBackup, error) {
+	var latest *repository.DBBackup
+	for _, b := range r.backups {
+		if b.Kind == kind && b.Status == status && (latest == nil || b.StartedAt.After(latest.StartedAt)) {
+			latest = b
+		}
+	}
+	return latest, nil
+}
+
+func (r *backupRepo) CreateDrill(ctx context.Context, d *repository.RestoreDrill) error {
+	d.ID, d.StartedAt = uuid.New(), time.Now()
+	r.drills = append(r.drills, d)
+	return nil
+}
+
+func (r *backupRepo) FinishDrill(ctx context.Context, d *repository.RestoreDrill) error { return nil }
+
+func (r *backupRepo) ListDrills(ctx context.Context, limit int) ([]repository.RestoreDrill, error) {
+	return nil, nil
+}
+
+// fakeRDS serves canned snapshots.
+type fakeRDS struct {
+	snapshots []RDSSnapshot
+	created   []string
+}
+
+func (f *fakeRDS) CreateSnapshot(ctx context.Context, instanceID, snapshotID string) (*RDSSnapshot, error) {
+	f.created = append(f.created, snapshotID)
+	return &RDSSnapshot{Identifier: snapshotID, InstanceIdentifier: instanceID, Status: "creating"}, nil
+}
+
+func (f *fakeRDS) DescribeSnapshots(ctx context.Context, instanceID, snapshotID string) ([]RDSSnapshot, error) {
+	return f.snapshots, nil
+}
+
+func (f *fakeRDS) RestoreInstance(ctx context.Context, snapshotID, instanceID string, opts RDSRestoreOptions) (*RDSInstance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeRDS) DescribeInstance(ctx context.Context, instanceID string) (*RDSInstance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeRDS) DeleteInstance(ctx context.Context, instanceID string) error { return nil }
+
+func TestBackupSyncSnapshots(t *testing.T) {
+	repo := &backupRepo{}
+	rds := &fakeRDS{}
+	svc := NewBackupService(repo, rds, nil, nil, BackupOptions{InstanceID: "cc-prod"})
+	svc.now = func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	manual, err := svc.TriggerSnapshot(ctx, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manual.Identifier != "cc-prod-manual-20261001-120000" || manual.Status != repository.BackupStatusCreating {
+		t.Fatalf("manual snapshot = %s %s", manual.Identifier, manual.Status)
+	}
+	// A second manual snapshot RDS never lists (e.g. deleted in the console).
+	gone := &repository.DBBackup{Kind: repository.BackupKindSnapshot, Identifier: "cc-prod-manual-gone", Status: repository.BackupStatusCreating}
+	repo.Create(ctx, gone)
+
+	taken := time.Date(2026, 10, 1, 4, 0, 0, 0, time.UTC)
+	rds.snapshots = []RDSSnapshot{
+		{Identifier: manual.Identifier, InstanceIdentifier: "cc-prod", Status: "available", SnapshotType: "manual", AllocatedStorageGB: 20, CreatedAt: taken},
+		{Identifier: "rds:cc-prod-2026-10-01-04-00", InstanceIdentifier: "cc-prod", Status: "available", SnapshotType: "automated", AllocatedStorageGB: 20, CreatedAt: taken},
+		{Identifier: "cc-prod-bad", InstanceIdentifier: "cc-prod", Status: "incompatible-restore", SnapshotType: "manual"},
+	}
+	n, err := svc.SyncSnapshots(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("SyncSnapshots = %d, %v", n, err)
+	}
+
+	if b := repo.find(repository.BackupKindSnapshot, manual.Identifier); b.Status != repository.BackupStatusAvailable || b.SizeBytes != 20<<30 {
+		t.Errorf("manual snapshot after sync = %s, %d bytes", b.Status, b.SizeBytes)
+	}
+	if b := repo.find(repository.BackupKindSnapshot, "rds:cc-prod-2026-10-01-04-00"); b == nil || b.SnapshotType != "automated" {
+		t.Errorf("automated snapshot not imported: %+v", b)
+	}
+	if b := repo.find(repository.BackupKindSnapshot, "cc-prod-bad"); b.Status != repository.BackupStatusFailed || b.Error == "" {
+		t.Errorf("incompatible snapshot = %s %q", b.Status, b.Error)
+	}
+	if b := repo.find(repository.BackupKindSnapshot, "cc-prod-manual-gone"); b.Status != repository.BackupStatusDeleted {
+		t.Errorf("unlisted pending snapshot = %s, want deleted", b.Status)
+	}
+}
+
+func TestBackupSnapshotsDisabled(t *testing.T) {
+	svc := NewBackupService(&backupRepo{}, nil, nil, nil, BackupOptions{})
+	if _, err := svc.TriggerSnapshot(context.Background(), uuid.Nil); !errors.Is(err, ErrSnapshotsDisabled) {
+		t.Fatalf("TriggerSnapshot err = %v", err)
+	}
+	if _, err := svc.RunRestoreDrill(context.Background(), DrillOptions{}); !errors.Is(err, ErrSnapshotsDisabled) {
+		t.Fatalf("RunRestoreDrill err = %v", err)
+	}
+}
+
+func TestBackupDumpTablesOnlyNarrows(t *testing.T) {
+	svc := NewBackupService(&backupRepo{}, nil, nil, nil, BackupOptions{
+		DumpTables: []string{"kb_articles", "children", "promo_codes"},
+	})
+	got := strings.Join(svc.DumpTables(), ",")
+	if got != "promo_codes,kb_articles" {
+		t.Fatalf("DumpTables = %s; PHI tables must never be added", got)
+	}
+}
+
+func TestRDSClientSignsAndParses(t *testing.T) {
+	var calls []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-west-2/rds/aws4_request") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		calls = append(calls, form)
+		if form.Get("Marker") == "" {
+			io.WriteString(w, `<DescribeDBSnapshotsResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+  <DescribeDBSnapshotsResult>
+    <Marker>page2</Marker>
+    <DBSnapshots>
+      <DBSnapshot>
+        <DBSnapshotIdentifier>rds:cc-prod-2026-10-01-04-00</DBSnapshotIdentifier>
+        <DBInstanceIdentifier>cc-prod</DBInstanceIdentifier>
+        <SnapshotCreateTime>2026-10-01T04:00:12.345Z</SnapshotCreateTime>
+        <Status>available</Status>
+        <SnapshotType>automated</SnapshotType>
+        <AllocatedStorage>20</AllocatedStorage>
+      </DBSnapshot>
+    </DBSnapshots>
+  </DescribeDBSnapshotsResult>
+</DescribeDBSnapshotsResponse>`)
+			return
+		}
+		io.WriteString(w, `<DescribeDBSnapshotsResponse><DescribeDBSnapshotsResult><DBSnapshots>
+  <DBSnapshot><DBSnapshotIdentifier>cc-prod-manual-1</DBSnapshotIdentifier><Status>creating</Status></DBSnapshot>
+</DBSnapshots></DescribeDBSnapshotsResult></DescribeDBSnapshotsResponse>`)
+	}))
+	defer srv.Close()
+
+	c := newRDSClient(srv.URL, "us-west-2", aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")))
+	snaps, err := c.DescribeSnapshots(context.Background(), "cc-prod", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 || len(calls) != 2 {
+		t.Fatalf("got %d snapshots in %d calls", len(snaps), len(calls))
+	}
+	if calls[0].Get("Action") != "DescribeDBSnapshots" || calls[0].Get("DBInstanceIdentifier") != "cc-prod" || calls[1].Get("Marker") != "page2" {
+		t.Errorf("request params = %v / %v", calls[0], calls[1])
+	}
+	s := snaps[0]
+	if s.AllocatedStorageGB != 20 || s.SnapshotType != "automated" || s.CreatedAt.Hour() != 4 {
+		t.Errorf("parsed snapshot = %+v", s)
+	}
+}
+
+func TestRDSClientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `<ErrorResponse><Error><Type>Sender</Type><Code>DBSnapshotAlreadyExists</Code><Message>exists</Message></Error></ErrorResponse>`)
+	}))
+	defer srv.Close()
+
+	c := newRDSClient(srv.URL, "us-east-1", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""))
+	_, err := c.CreateSnapshot(context.Background(), "cc-prod", "dup")
+	var rerr *RDSError
+	if !errors.As(err, &rerr) || rerr.Code != "DBSnapshotAlreadyExists" {
+		t.Fatalf("err = %v", err)
+	}
+}
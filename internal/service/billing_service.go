@@ -29,9 +29,20 @@ func (s *BillingService) GetFamilyBillingInfo(ctx context.Context, familyID uuid
 	return s.billingRepo.GetFamilyBillingInfo(ctx, familyID)
 }
 
-// GetAvailablePlans returns all active subscription plans
+// GetAvailablePlans returns the active plans a family can subscribe to.
+// Seat-based plans are sold to organizations (see OrganizationBillingService).
 func (s *BillingService) GetAvailablePlans(ctx context.Context) ([]models.SubscriptionPlan, error) {
-	return s.billingRepo.GetActivePlans(ctx)
+	plans, err := s.billingRepo.GetActivePlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	family := plans[:0]
+	for _, p := range plans {
+		if !p.SeatBased {
+			family = append(family, p)
+		}
+	}
+	return family, nil
 }
 
 // CanAddChild checks if a family can add more children based on their plan limits
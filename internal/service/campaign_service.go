@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrCampaignInvalid     = apperr.Validation("invalid campaign")
+	ErrCampaignNotFound    = apperr.NotFound("campaign not found")
+	ErrCampaignReportRange = errors.New("invalid campaign report range")
+)
+
+const (
+	maxUTMValueLen       = 100
+	maxAttributionURLLen = 500
+	// maxCampaignReportSpan matches the NPS report: two years.
+	maxCampaignReportSpan = 2 * 366 * 24 * time.Hour
+)
+
+// utmKeyPattern is what a campaign's utm_campaign must look like after
+// normalization — the same shape qrSlug produces for generated QR links.
+var utmKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.\-]*$`)
+
+// CampaignService manages marketing campaigns, records signup attribution
+// and builds the per-campaign ROI report.
+type CampaignService struct {
+	repo repository.CampaignRepository
+}
+
+// NewCampaignService creates a new campaign service
+func NewCampaignService(repo repository.CampaignRepository) *CampaignService {
+	return &CampaignService{repo: repo}
+}
+
+// normalizeUTM lower-cases and trims a UTM value so "Spring_Promo " and
+// "spring_promo" attribute to the same campaign.
+func normalizeUTM(v string) string {
+	return truncateRunes(strings.ToLower(strings.TrimSpace(v)), maxUTMValueLen)
+}
+
+// SignupAttributionInput is the optional UTM block clients send with
+// registration. Web clients read it from the landing URL; the mobile apps
+// forward whatever deep-link parameters they were opened with.
+type SignupAttributionInput struct {
+	UTMSource   string `json:"utm_source"`
+	UTMMedium   string `json:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign"`
+	UTMContent  string `json:"utm_content"`
+	UTMTerm     string `json:"utm_term"`
+	Referrer    string `json:"referrer"`
+	LandingPath string `json:"landing_path"`
+}
+
+func (in SignupAttributionInput) empty() bool {
+	return in.UTMSource == "" && in.UTMMedium == "" && in.UTMCampaign == "" &&
+		in.UTMContent == "" && in.UTMTerm == "" && in.Referrer == ""
+}
+
+// RecordSignup stores first-touch attribution for a new user. It is
+// best-effort by design — a failure is logged, never surfaced, so
+// attribution can't block registration.
+func (s *CampaignService) RecordSignup(ctx context.Context, userID uuid.UUID, in SignupAttributionInput) {
+	if in.empty() {
+		return
+	}
+	a := &repository.SignupAttribution{
+		UserID:      userID,
+		UTMSource:   normalizeUTM(in.UTMSource),
+		UTMMedium:   normalizeUTM(in.UTMMedium),
+		UTMCampaign: normalizeUTM(in.UTMCampaign),
+		UTMContent:  normalizeUTM(in.UTMContent),
+		UTMTerm:     normalizeUTM(in.UTMTerm),
+		Referrer:    truncateRunes(strings.TrimSpace(in.Referrer), maxAttributionURLLen),
+		LandingPath: truncateRunes(strings.TrimSpace(in.LandingPath), maxAttributionURLLen),
+	}
+	if err := s.repo.RecordSignup(ctx, a); err != nil {
+		log.Printf("[CAMPAIGN] failed to record signup attribution for %s: %v", userID, err)
+	}
+}
+
+// CampaignInput is the admin create/update payload. Dates are YYYY-MM-DD.
+type CampaignInput struct {
+	Name        string `json:"name"`
+	UTMCampaign string `json:"utm_campaign"`
+	Channel     string `json:"channel"`
+	StartsOn    string `json:"starts_on"`
+	EndsOn      string `json:"ends_on"`
+	Notes       string `json:"notes"`
+	IsArchived  bool   `json:"is_archived"`
+}
+
+func parseOptionalDate(v, field string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be YYYY-MM-DD", ErrCampaignInvalid, field)
+	}
+	return &t, nil
+}
+
+// apply validates in and copies it onto c.
+func (in CampaignInput) apply(c *repository.Campaign) error {
+	name := strings.TrimSpace(in.Name)
+	if name == "" || len(name) > 100 {
+		return fmt.Errorf("%w: name is required (max 100 characters)", ErrCampaignInvalid)
+	}
+	key := normalizeUTM(in.UTMCampaign)
+	if !utmKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: utm_campaign may only contain letters, digits, '_', '-' and '.'", ErrCampaignInvalid)
+	}
+	starts, err := parseOptionalDate(in.StartsOn, "starts_on")
+	if err != nil {
+		return err
+	}
+	ends, err := parseOptionalDate(in.EndsOn, "ends_on")
+	if err != nil {
+		return err
+	}
+	if starts != nil && ends != nil && ends.Before(*starts) {
+		return fmt.Errorf("%w: ends_on is before starts_on", ErrCampaignInvalid)
+	}
+	c.Name = name
+	c.UTMCampaign = key
+	c.Channel = truncateRunes(strings.ToLower(strings.TrimSpace(in.Channel)), 50)
+	c.StartsOn, c.EndsOn = starts, ends
+	c.Notes = strings.TrimSpace(in.Notes)
+	c.IsArchived = in.IsArchived
+	return nil
+}
+
+// CreateCampaign validates and stores a new campaign.
+func (s *CampaignService) CreateCampaign(ctx context.Context, in CampaignInput, createdBy uuid.UUID) (*repository.Campaign, error) {
+	c := &repository.Campaign{CreatedBy: models.NullUUID{UUID: createdBy, Valid: createdBy != uuid.Nil}}
+	if err := in.apply(c); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// UpdateCampaign replaces a campaign's editable fields. Changing
+// utm_campaign re-points attribution retroactively, since signups are
+// joined by key rather than stamped with a campaign ID.
+func (s *CampaignService) UpdateCampaign(ctx context.Context, id uuid.UUID, in CampaignInput) (*repository.Campaign, error) {
+	c, err := s.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.apply(c); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCampaign returns one campaign or ErrCampaignNotFound.
+func (s *CampaignService) GetCampaign(ctx context.Context, id uuid.UUID) (*repository.Campaign, error) {
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCampaignNotFound
+	}
+	return c, nil
+}
+
+// ListCampaigns returns campaigns, newest first.
+func (s *CampaignService) ListCampaigns(ctx context.Context, includeArchived bool) ([]repository.Campaign, error) {
+	list, err := s.repo.List(ctx, includeArchived)
+	if list == nil {
+		list = []repository.Campaign{}
+	}
+	return list, err
+}
+
+// SpendInput is one spend entry. Amount is in cents.
+type SpendInput struct {
+	SpentOn     string `json:"spent_on"`
+	AmountCents int64  `json:"amount_cents"`
+	Note        string `json:"note"`
+}
+
+// AddSpend records spend against a campaign.
+func (s *CampaignService) AddSpend(ctx context.Context, campaignID uuid.UUID, in SpendInput, recordedBy uuid.UUID) (*repository.CampaignSpend, error) {
+	if _, err := s.GetCampaign(ctx, campaignID); err != nil {
+		return nil, err
+	}
+	if in.AmountCents <= 0 {
+		return nil, fmt.Errorf("%w: amount_cents must be positive", ErrCampaignInvalid)
+	}
+	on, err := parseOptionalDate(in.SpentOn, "spent_on")
+	if err != nil {
+		return nil, err
+	}
+	if on == nil {
+		return nil, fmt.Errorf("%w: spent_on is required", ErrCampaignInvalid)
+	}
+	sp := &repository.CampaignSpend{
+		CampaignID:  campaignID,
+		SpentOn:     *on,
+		AmountCents: in.AmountCents,
+		Note:        strings.TrimSpace(in.Note),
+		RecordedBy:  models.NullUUID{UUID: recordedBy, Valid: recordedBy != uuid.Nil},
+	}
+	if err := s.repo.AddSpend(ctx, sp); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// ListSpend returns a campaign's spend entries.
+func (s *CampaignService) ListSpend(ctx context.Context, campaignID uuid.UUID) ([]repository.CampaignSpend, error) {
+	list, err := s.repo.ListSpend(ctx, campaignID)
+	if list == nil {
+		list = []repository.CampaignSpend{}
+	}
+	return list, err
+}
+
+// DeleteSpend removes one spend entry from a campaign.
+func (s *CampaignService) DeleteSpend(ctx context.Context, campaignID, spendID uuid.UUID) error {
+	err := s.repo.DeleteSpend(ctx, campaignID, spendID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCampaignNotFound
+	}
+	return err
+}
+
+// CampaignROI is one row of the ROI report.
+type CampaignROI struct {
+	repository.CampaignTally
+	Name        string `json:"name"`
+	UTMCampaign string `json:"utm_campaign"`
+	Channel     string `json:"channel"`
+	Signups     int    `json:"signups"`
+	// ConversionRate is conversions / signups, as a percentage.
+	ConversionRate float64 `json:"conversion_rate"`
+	// CACCents is spend per paying customer; nil with no conversions.
+	CACCents *int64 `json:"cac_cents"`
+	// ROI is (revenue - spend) / spend as a percentage; nil with no spend.
+	ROI *float64 `json:"roi"`
+}
+
+// CampaignROIReport is the body of GET /campaigns/report.
+type CampaignROIReport struct {
+	From      time.Time                     `json:"from"`
+	To        time.Time                     `json:"to"`
+	Campaigns []CampaignROI                 `json:"campaigns"`
+	Totals    CampaignROI                   `json:"totals"`
+	Untracked []repository.UTMCampaignCount `json:"untracked_utm_campaigns"`
+}
+
+// deriveROI fills the computed fields from the raw tally.
+func deriveROI(r *CampaignROI) {
+	r.Signups = r.UTMSignups + r.PromoSignups
+	r.ConversionRate, r.CACCents, r.ROI = 0, nil, nil
+	if r.Signups > 0 {
+		r.ConversionRate = math.Round(float64(r.Conversions)*1000/float64(r.Signups)) / 10
+	}
+	if r.Conversions > 0 && r.SpendCents > 0 {
+		cac := int64(math.Round(float64(r.SpendCents) / float64(r.Conversions)))
+		r.CACCents = &cac
+	}
+	if r.SpendCents > 0 {
+		roi := math.Round(float64(r.RevenueCents-r.SpendCents)*1000/float64(r.SpendCents)) / 10
+		r.ROI = &roi
+	}
+}
+
+// ROIReport attributes users acquired in [from, to) to campaigns and
+// reports spend, revenue to date and conversions for each. Archived
+// campaigns are included when they have activity in the window.
+func (s *CampaignService) ROIReport(ctx context.Context, from, to time.Time) (*CampaignROIReport, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: 'to' must be after 'from'", ErrCampaignReportRange)
+	}
+	if to.Sub(from) > maxCampaignReportSpan {
+		return nil, fmt.Errorf("%w: window is limited to two years", ErrCampaignReportRange)
+	}
+	campaigns, err := s.repo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	tallies, err := s.repo.Tallies(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := s.repo.UntrackedUTMCampaigns(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if untracked == nil {
+		untracked = []repository.UTMCampaignCount{}
+	}
+	return buildROIReport(from, to, campaigns, tallies, untracked), nil
+}
+
+func buildROIReport(from, to time.Time, campaigns []repository.Campaign, tallies []repository.CampaignTally, untracked []repository.UTMCampaignCount) *CampaignROIReport {
+	byID := make(map[uuid.UUID]repository.CampaignTally, len(tallies))
+	for _, t := range tallies {
+		byID[t.CampaignID] = t
+	}
+	report := &CampaignROIReport{From: from, To: to, Campaigns: []CampaignROI{}, Untracked: untracked}
+	var totals repository.CampaignTally
+	for _, c := range campaigns {
+		t := byID[c.ID]
+		t.CampaignID = c.ID
+		row := CampaignROI{CampaignTally: t, Name: c.Name, UTMCampaign: c.UTMCampaign, Channel: c.Channel}
+		deriveROI(&row)
+		if c.IsArchived && row.Signups == 0 && row.SpendCents == 0 && row.PromoRedemptions == 0 {
+			continue
+		}
+		report.Campaigns = append(report.Campaigns, row)
+		totals.UTMSignups += t.UTMSignups
+		totals.PromoSignups += t.PromoSignups
+		totals.PromoRedemptions += t.PromoRedemptions
+		totals.Conversions += t.Conversions
+		totals.RevenueCents += t.RevenueCents
+		totals.SpendCents += t.SpendCents
+	}
+	sort.SliceStable(report.Campaigns, func(i, j int) bool {
+		return report.Campaigns[i].RevenueCents > report.Campaigns[j].RevenueCents
+	})
+	report.Totals = CampaignROI{CampaignTally: totals, Name: "All campaigns"}
+	deriveROI(&report.Totals)
+	return report
+}
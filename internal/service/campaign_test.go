@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+func TestCampaignInputApply(t *testing.T) {
+	var c repository.Campaign
+	err := CampaignInput{Name: " Spring Push ", UTMCampaign: " Spring_2026 ", Channel: "Paid_Social", StartsOn: "2026-03-01", EndsOn: "2026-04-30"}.apply(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Spring Push" || c.UTMCampaign != "spring_2026" || c.Channel != "paid_social" {
+		t.Errorf("normalized = %q/%q/%q", c.Name, c.UTMCampaign, c.Channel)
+	}
+	if c.StartsOn == nil || c.EndsOn == nil {
+		t.Fatal("dates not parsed")
+	}
+
+	bad := []CampaignInput{
+		{UTMCampaign: "x"},
+		{Name: "n", UTMCampaign: ""},
+		{Name: "n", UTMCampaign: "has space"},
+		{Name: "n", UTMCampaign: "x", StartsOn: "03/01/2026"},
+		{Name: "n", UTMCampaign: "x", StartsOn: "2026-05-01", EndsOn: "2026-04-01"},
+	}
+	for i, in := range bad {
+		if err := in.apply(&repository.Campaign{}); !errors.Is(err, ErrCampaignInvalid) {
+			t.Errorf("case %d: err = %v, want ErrCampaignInvalid", i, err)
+		}
+	}
+}
+
+func TestDeriveROI(t *testing.T) {
+	r := CampaignROI{CampaignTally: repository.CampaignTally{
+		UTMSignups: 30, PromoSignups: 10, Conversions: 8,
+		RevenueCents: 60000, SpendCents: 40000,
+	}}
+	deriveROI(&r)
+	if r.Signups != 40 {
+		t.Errorf("signups = %d, want 40", r.Signups)
+	}
+	if r.ConversionRate != 20 {
+		t.Errorf("conversion rate = %v, want 20", r.ConversionRate)
+	}
+	if r.CACCents == nil || *r.CACCents != 5000 {
+		t.Errorf("cac = %v, want 5000", r.CACCents)
+	}
+	if r.ROI == nil || *r.ROI != 50 {
+		t.Errorf("roi = %v, want 50", r.ROI)
+	}
+
+	// No spend: ROI and CAC are undefined, not zero or infinite.
+	free := CampaignROI{CampaignTally: repository.CampaignTally{UTMSignups: 5, Conversions: 1, RevenueCents: 999}}
+	deriveROI(&free)
+	if free.ROI != nil || free.CACCents != nil {
+		t.Errorf("zero-spend roi/cac = %v/%v, want nil", free.ROI, free.CACCents)
+	}
+}
+
+func TestBuildROIReport(t *testing.T) {
+	a, b, archived := uuid.New(), uuid.New(), uuid.New()
+	campaigns := []repository.Campaign{
+		{ID: a, Name: "A", UTMCampaign: "a"},
+		{ID: b, Name: "B", UTMCampaign: "b"},
+		{ID: archived, Name: "Old", UTMCampaign: "old", IsArchived: true},
+	}
+	tallies := []repository.CampaignTally{
+		{CampaignID: a, UTMSignups: 10, Conversions: 2, RevenueCents: 2000, SpendCents: 1000},
+		{CampaignID: b, PromoSignups: 4, PromoRedemptions: 6, Conversions: 1, RevenueCents: 9000},
+	}
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rep := buildROIReport(from, from.AddDate(0, 3, 0), campaigns, tallies, nil)
+
+	if len(rep.Campaigns) != 2 {
+		t.Fatalf("got %d rows, want 2 (idle archived campaign hidden)", len(rep.Campaigns))
+	}
+	if rep.Campaigns[0].CampaignID != b {
+		t.Errorf("rows should be sorted by revenue, got %s first", rep.Campaigns[0].Name)
+	}
+	if rep.Totals.Signups != 14 || rep.Totals.RevenueCents != 11000 || rep.Totals.SpendCents != 1000 {
+		t.Errorf("totals = %+v", rep.Totals)
+	}
+	if rep.Totals.ROI == nil || *rep.Totals.ROI != 1000 {
+		t.Errorf("total roi = %v, want 1000", rep.Totals.ROI)
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+)
+
+var (
+	// ErrCaptchaRequired is returned when an endpoint that asks for a
+	// CAPTCHA was called without a token.
+	ErrCaptchaRequired = apperr.Validation("captcha required")
+	// ErrCaptchaFailed is returned when the provider rejected the token.
+	ErrCaptchaFailed = errors.New("captcha verification failed")
+)
+
+// CaptchaSettingKey is the system_settings key holding CaptchaSettings.
+const CaptchaSettingKey = "captcha"
+
+// Endpoints a CAPTCHA can be asked for on.
+const (
+	CaptchaActionRegister        = "register"
+	CaptchaActionPasswordReset   = "password_reset"
+	CaptchaActionPromoValidation = "promo_validation"
+)
+
+// CaptchaActions lists the actions in display order.
+var CaptchaActions = []string{CaptchaActionRegister, CaptchaActionPasswordReset, CaptchaActionPromoValidation}
+
+// Verification outcomes counted per action and day.
+const (
+	captchaPassed  = "passed"
+	captchaFailed  = "failed"
+	captchaMissing = "missing"
+	captchaError   = "error"
+)
+
+// captchaMetricsTTL keeps a day's counters for the dashboard's longest
+// window.
+const captchaMetricsTTL = 31 * 24 * time.Hour
+
+// captchaVerifyURLs are the providers' siteverify endpoints. Both take
+// the same form post and answer alike.
+var captchaVerifyURLs = map[string]string{
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// CaptchaSettings say which endpoints ask for a CAPTCHA. Enabled is the
+// environment's master switch; the rest pick endpoints while it's on.
+type CaptchaSettings struct {
+	Enabled         bool `json:"enabled"`
+	Register        bool `json:"register"`
+	PasswordReset   bool `json:"password_reset"`
+	PromoValidation bool `json:"promo_validation"`
+}
+
+// DefaultCaptchaSettings applies until an admin saves settings: off, with
+// every endpoint selected for when it is switched on.
+var DefaultCaptchaSettings = CaptchaSettings{Register: true, PasswordReset: true, PromoValidation: true}
+
+func (s CaptchaSettings) action(action string) bool {
+	switch action {
+	case CaptchaActionRegister:
+		return s.Register
+	case CaptchaActionPasswordReset:
+		return s.PasswordReset
+	case CaptchaActionPromoValidation:
+		return s.PromoValidation
+	}
+	return false
+}
+
+// ClientCaptcha tells clients which widget to show and where.
+type ClientCaptcha struct {
+	Provider string   `json:"provider"`
+	SiteKey  string   `json:"site_key"`
+	Actions  []string `json:"actions"`
+}
+
+// CaptchaOutcomes counts verifications by outcome. Missing is a request
+// without a token; Errors are provider outages, let through.
+type CaptchaOutcomes struct {
+	Passed  int64 `json:"passed"`
+	Failed  int64 `json:"failed"`
+	Missing int64 `json:"missing"`
+	Errors  int64 `json:"errors"`
+}
+
+func (o *CaptchaOutcomes) add(outcome string, n int64) {
+	switch outcome {
+	case captchaPassed:
+		o.Passed += n
+	case captchaFailed:
+		o.Failed += n
+	case captchaMissing:
+		o.Missing += n
+	case captchaError:
+		o.Errors += n
+	}
+}
+
+// CaptchaDay is one day's outcomes over all actions.
+type CaptchaDay struct {
+	Date string `json:"date"`
+	CaptchaOutcomes
+}
+
+// CaptchaMetrics are verification outcomes over the last Days days, per
+// action and per day (oldest first).
+type CaptchaMetrics struct {
+	Days    int                        `json:"days"`
+	Actions map[string]CaptchaOutcomes `json:"actions"`
+	Daily   []CaptchaDay               `json:"daily"`
+}
+
+// captchaVerifier checks a token with the provider.
+type captchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// siteVerifier posts to a provider's siteverify endpoint.
+type siteVerifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (v *siteVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("siteverify: HTTP %d", resp.StatusCode)
+	}
+	var out struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("siteverify: %w", err)
+	}
+	for _, code := range out.ErrorCodes {
+		// Our side is misconfigured, not the user's answer wrong.
+		if strings.Contains(code, "secret") || code == "internal-error" {
+			return false, fmt.Errorf("siteverify: %s", strings.Join(out.ErrorCodes, ", "))
+		}
+	}
+	return out.Success, nil
+}
+
+// CaptchaService verifies hCaptcha or Turnstile tokens on the endpoints
+// the "captcha" system setting selects, and counts outcomes per action
+// and day in Redis for the settings page. A provider outage lets the
+// request through rather than locking everyone out of signup; it is
+// counted as an error.
+type CaptchaService struct {
+	provider string
+	siteKey  string
+	verifier captchaVerifier
+	settings settingsStore
+	r        *database.Redis
+	now      func() time.Time
+
+	mu       sync.Mutex
+	cached   CaptchaSettings
+	cachedAt time.Time
+}
+
+func NewCaptchaService(cfg config.CaptchaConfig, settings settingsStore, r *database.Redis) *CaptchaService {
+	s := &CaptchaService{settings: settings, r: r, now: time.Now, cached: DefaultCaptchaSettings}
+	if u, ok := captchaVerifyURLs[cfg.Provider]; ok && cfg.SecretKey != "" {
+		s.provider, s.siteKey = cfg.Provider, cfg.SiteKey
+		s.verifier = &siteVerifier{url: u, secret: cfg.SecretKey, client: &http.Client{Timeout: cfg.Timeout}}
+	} else if cfg.Provider != "off" && cfg.Provider != "" {
+		log.Printf("[CAPTCHA] provider %q not usable (unknown, or CAPTCHA_SECRET_KEY unset); CAPTCHA is off", cfg.Provider)
+	}
+	return s
+}
+
+// Provider is the configured provider, "" when none is.
+func (s *CaptchaService) Provider() string {
+	return s.provider
+}
+
+// Settings reads the stored settings, with defaults when none are stored.
+func (s *CaptchaService) Settings(ctx context.Context) (CaptchaSettings, error) {
+	out := DefaultCaptchaSettings
+	val, err := s.settings.GetSetting(ctx, CaptchaSettingKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		log.Printf("[CAPTCHA] %s setting unreadable, using defaults: %v", CaptchaSettingKey, err)
+		return DefaultCaptchaSettings, nil
+	}
+	return out, nil
+}
+
+// Current is Settings cached for a minute, for the per-request checks. A
+// failed read keeps serving the last good settings.
+func (s *CaptchaService) Current(ctx context.Context) CaptchaSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cachedAt.IsZero() && s.now().Sub(s.cachedAt) < securitySettingsTTL {
+		return s.cached
+	}
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		log.Printf("[CAPTCHA] load settings: %v", err)
+		settings = s.cached
+	}
+	s.cached, s.cachedAt = settings, s.now()
+	return s.cached
+}
+
+// UpdateSettings stores new settings. This instance applies them at once;
+// others within a minute.
+func (s *CaptchaService) UpdateSettings(ctx context.Context, settings CaptchaSettings, by uuid.UUID) error {
+	if err := s.settings.UpdateSetting(ctx, CaptchaSettingKey, settings, by); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cached, s.cachedAt = settings, s.now()
+	s.mu.Unlock()
+	return nil
+}
+
+// Required reports whether action asks for a CAPTCHA right now.
+func (s *CaptchaService) Required(ctx context.Context, action string) bool {
+	if s == nil || s.verifier == nil {
+		return false
+	}
+	settings := s.Current(ctx)
+	return settings.Enabled && settings.action(action)
+}
+
+// Client is what clients need to show the widget, nil while no endpoint
+// asks for one.
+func (s *CaptchaService) Client(ctx context.Context) *ClientCaptcha {
+	if s == nil || s.verifier == nil {
+		return nil
+	}
+	settings := s.Current(ctx)
+	if !settings.Enabled {
+		return nil
+	}
+	c := &ClientCaptcha{Provider: s.provider, SiteKey: s.siteKey, Actions: []string{}}
+	for _, a := range CaptchaActions {
+		if settings.action(a) {
+			c.Actions = append(c.Actions, a)
+		}
+	}
+	if len(c.Actions) == 0 {
+		return nil
+	}
+	return c
+}
+
+// Verify checks token for action when the action asks for a CAPTCHA:
+// ErrCaptchaRequired without one, ErrCaptchaFailed when the provider
+// rejects it.
+func (s *CaptchaService) Verify(ctx context.Context, action, token, remoteIP string) error {
+	if !s.Required(ctx, action) {
+		return nil
+	}
+	if strings.TrimSpace(token) == "" {
+		s.record(ctx, action, captchaMissing)
+		return ErrCaptchaRequired
+	}
+	ok, err := s.verifier.Verify(ctx, token, remoteIP)
+	switch {
+	case err != nil:
+		log.Printf("[CAPTCHA] %s verification unavailable, letting %s through: %v", s.provider, action, err)
+		s.record(ctx, action, captchaError)
+		return nil
+	case !ok:
+		s.record(ctx, action, captchaFailed)
+		return ErrCaptchaFailed
+	}
+	s.record(ctx, action, captchaPassed)
+	return nil
+}
+
+func captchaMetricsKey(day time.Time) string {
+	return "captcha:" + day.Format("2006-01-02")
+}
+
+func (s *CaptchaService) record(ctx context.Context, action, outcome string) {
+	if s.r == nil {
+		return
+	}
+	key := captchaMetricsKey(s.now().UTC())
+	pipe := s.r.Pipeline()
+	pipe.HIncrBy(ctx, key, action+":"+outcome, 1)
+	pipe.Expire(ctx, key, captchaMetricsTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[CAPTCHA] counting %s %s: %v", action, outcome, err)
+	}
+}
+
+// Metrics totals outcomes over the last days days (1-31), today
+// included.
+func (s *CaptchaService) Metrics(ctx context.Context, days int) (*CaptchaMetrics, error) {
+	days = max(1, min(days, 31))
+	m := &CaptchaMetrics{Days: days, Actions: map[string]CaptchaOutcomes{}, Daily: []CaptchaDay{}}
+	for _, a := range CaptchaActions {
+		m.Actions[a] = CaptchaOutcomes{}
+	}
+	if s.r == nil {
+		return m, nil
+	}
+	today := s.now().UTC()
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		counts, err := s.r.HGetAll(ctx, captchaMetricsKey(day)).Result()
+		if err != nil {
+			return nil, err
+		}
+		d := CaptchaDay{Date: day.Format("2006-01-02")}
+		for field, v := range counts {
+			action, outcome, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				continue
+			}
+			d.add(outcome, n)
+			totals := m.Actions[action]
+			totals.add(outcome, n)
+			m.Actions[action] = totals
+		}
+		m.Daily = append(m.Daily, d)
+	}
+	return m, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+)
+
+// newTestCaptcha points a hCaptcha CaptchaService at a siteverify stub
+// that accepts the token "good", fails "bad" and errors on "outage".
+func newTestCaptcha(t *testing.T, settings memSettings) *CaptchaService {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "shh" {
+			t.Errorf("secret = %q", r.FormValue("secret"))
+		}
+		switch r.FormValue("response") {
+		case "outage":
+			w.WriteHeader(http.StatusBadGateway)
+		case "good":
+			json.NewEncoder(w).Encode(map[string]any{"success": true})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"success": false, "error-codes": []string{"invalid-input-response"}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	mr := miniredis.RunT(t)
+	rdb := &database.Redis{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	s := NewCaptchaService(config.CaptchaConfig{Provider: "hcaptcha", SiteKey: "site", SecretKey: "shh", Timeout: time.Second}, settings, rdb)
+	s.verifier.(*siteVerifier).url = srv.URL
+	return s
+}
+
+func TestCaptchaVerify(t *testing.T) {
+	settings := memSettings{}
+	s := newTestCaptcha(t, settings)
+	ctx := context.Background()
+
+	// Off until switched on.
+	if err := s.Verify(ctx, CaptchaActionRegister, "", "203.0.113.1"); err != nil {
+		t.Fatalf("disabled: %v", err)
+	}
+	if s.Client(ctx) != nil {
+		t.Error("Client while disabled")
+	}
+
+	if err := s.UpdateSettings(ctx, CaptchaSettings{Enabled: true, Register: true, PasswordReset: true}, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		action, token string
+		want          error
+	}{
+		{CaptchaActionRegister, "", ErrCaptchaRequired},
+		{CaptchaActionRegister, "bad", ErrCaptchaFailed},
+		{CaptchaActionRegister, "good", nil},
+		{CaptchaActionRegister, "good", nil},
+		{CaptchaActionPasswordReset, "outage", nil},
+		{CaptchaActionPromoValidation, "", nil},
+	} {
+		if err := s.Verify(ctx, tc.action, tc.token, "203.0.113.1"); !errors.Is(err, tc.want) {
+			t.Errorf("Verify(%s, %q) = %v, want %v", tc.action, tc.token, err, tc.want)
+		}
+	}
+
+	c := s.Client(ctx)
+	if c == nil || c.Provider != "hcaptcha" || c.SiteKey != "site" || len(c.Actions) != 2 {
+		t.Errorf("Client = %+v", c)
+	}
+
+	m, err := s.Metrics(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Actions[CaptchaActionRegister]; got != (CaptchaOutcomes{Passed: 2, Failed: 1, Missing: 1}) {
+		t.Errorf("register outcomes = %+v", got)
+	}
+	if got := m.Actions[CaptchaActionPasswordReset]; got != (CaptchaOutcomes{Errors: 1}) {
+		t.Errorf("password reset outcomes = %+v", got)
+	}
+	if len(m.Daily) != 7 || m.Daily[6].Passed != 2 {
+		t.Errorf("daily = %+v", m.Daily)
+	}
+}
+
+func TestCaptchaUnconfigured(t *testing.T) {
+	settings := memSettings{CaptchaSettingKey: map[string]interface{}{"enabled": true, "register": true}}
+	for _, cfg := range []config.CaptchaConfig{
+		{Provider: "off"},
+		{Provider: "hcaptcha"},
+		{Provider: "recaptcha", SecretKey: "shh"},
+	} {
+		s := NewCaptchaService(cfg, settings, nil)
+		if s.Required(context.Background(), CaptchaActionRegister) {
+			t.Errorf("%+v: CAPTCHA required without a usable provider", cfg)
+		}
+	}
+}
@@ -24,6 +24,9 @@ import (
 type ChatHub struct {
 	mu   sync.RWMutex
 	subs map[uuid.UUID]map[*ChatSubscriber]struct{} // threadID -> set
+
+	closing   chan struct{}
+	closeOnce sync.Once
 }
 
 // ChatSubscriber holds the channel a single SSE connection drains.
@@ -37,10 +40,24 @@ type ChatSubscriber struct {
 
 func NewChatHub() *ChatHub {
 	return &ChatHub{
-		subs: make(map[uuid.UUID]map[*ChatSubscriber]struct{}),
+		subs:    make(map[uuid.UUID]map[*ChatSubscriber]struct{}),
+		closing: make(chan struct{}),
 	}
 }
 
+// Close tells every SSE connection to end so the server can shut down;
+// the browser's EventSource reconnects, landing on a healthy instance.
+// Registered with http.Server.RegisterOnShutdown — without it Shutdown
+// would wait out its whole deadline on streams that never finish.
+func (h *ChatHub) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// Closing is closed once Close has been called.
+func (h *ChatHub) Closing() <-chan struct{} {
+	return h.closing
+}
+
 // Subscribe registers a new subscriber for the given thread and returns
 // the subscriber and an unsubscribe func. Callers must call the
 // unsubscribe func when their connection ends.
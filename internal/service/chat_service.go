@@ -6,16 +6,17 @@ import (
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrThreadNotFound    = errors.New("thread not found")
-	ErrNotParticipant    = errors.New("user is not a participant in this thread")
-	ErrMessageNotFound   = errors.New("message not found")
-	ErrNotMessageOwner   = errors.New("user is not the owner of this message")
-	ErrEmptyMessage      = errors.New("message text cannot be empty")
+	ErrThreadNotFound    = apperr.NotFound("thread not found")
+	ErrNotParticipant    = apperr.Forbidden("user is not a participant in this thread")
+	ErrMessageNotFound   = apperr.NotFound("message not found")
+	ErrNotMessageOwner   = apperr.Forbidden("user is not the owner of this message")
+	ErrEmptyMessage      = apperr.Validation("message text cannot be empty")
 )
 
 type ChatService struct {
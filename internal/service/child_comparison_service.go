@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+var (
+	// ErrComparisonAccess covers every child the caller can't compare —
+	// unknown, archived or in another family — without saying which.
+	ErrComparisonAccess  = errors.New("access denied")
+	ErrComparisonInvalid = apperr.Validation("invalid comparison")
+)
+
+const (
+	// maxComparedChildren caps a comparison; more lines than this on one
+	// chart can't be read.
+	maxComparedChildren = 6
+	// maxComparisonDays caps the date range.
+	maxComparisonDays = 366
+)
+
+// comparisonMetric is a metric that can be compared across children, or
+// across an intervention's periods: the correlation series it comes from
+// and how a day's points combine.
+type comparisonMetric struct {
+	label  string
+	unit   string
+	series string
+	// sum adds up a day's points (counts); otherwise they're averaged.
+	sum   bool
+	scale float64
+	// lowerIsBetter marks metrics where a drop is an improvement.
+	lowerIsBetter bool
+}
+
+// comparisonMetrics are the metrics a comparison can ask for, by key.
+var comparisonMetrics = map[string]comparisonMetric{
+	"sleep":              {label: "Sleep", unit: "hours", series: "sleep_minutes", scale: 1.0 / 60},
+	"night_wakings":      {label: "Night wakings", unit: "count", series: "night_wakings", sum: true, scale: 1, lowerIsBetter: true},
+	"meltdowns":          {label: "Meltdowns", unit: "count", series: "meltdowns", sum: true, scale: 1, lowerIsBetter: true},
+	"adherence":          {label: "Medication adherence", unit: "percent", series: "medication_adherence", scale: 100},
+	"mood":               {label: "Mood", unit: "rating", series: "mood", scale: 1},
+	"anxiety":            {label: "Anxiety", unit: "rating", series: "anxiety", scale: 1, lowerIsBetter: true},
+	"routine_completion": {label: "Routine completion", unit: "percent", series: "routine_completion", scale: 100},
+}
+
+// DefaultComparisonMetrics are compared when none are asked for.
+var DefaultComparisonMetrics = []string{"sleep", "meltdowns", "adherence"}
+
+type comparisonChildSource interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error)
+	GetByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Child, error)
+}
+
+type comparisonMembershipSource interface {
+	GetMembership(ctx context.Context, familyID, userID uuid.UUID) (*models.FamilyMembership, error)
+}
+
+type comparisonSeriesSource interface {
+	GetMetricSeries(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (map[string][]models.DataPoint, error)
+}
+
+type comparisonAnnotationSource interface {
+	ListForRange(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.Annotation, error)
+}
+
+// ChildComparisonService lines up metric series across a family's
+// children, from the same daily series the correlation engine uses.
+// Every child compared must be an active child of the caller's family.
+type ChildComparisonService struct {
+	children comparisonChildSource
+	members  comparisonMembershipSource
+	series   comparisonSeriesSource
+	// annotations, when set, adds each child's chart annotations.
+	annotations comparisonAnnotationSource
+}
+
+func NewChildComparisonService(children comparisonChildSource, members comparisonMembershipSource, series comparisonSeriesSource) *ChildComparisonService {
+	return &ChildComparisonService{children: children, members: members, series: series}
+}
+
+// SetAnnotations includes each compared child's chart annotations.
+func (s *ChildComparisonService) SetAnnotations(annotations comparisonAnnotationSource) {
+	s.annotations = annotations
+}
+
+// Compare returns the metrics' daily series for childIDs over [start,
+// end] (dates, UTC midnight). With no childIDs every active child in the
+// family is compared; with no metrics, DefaultComparisonMetrics.
+func (s *ChildComparisonService) Compare(ctx context.Context, familyID, userID uuid.UUID, childIDs []uuid.UUID, metrics []string, start, end time.Time) (*models.ChildComparison, error) {
+	if end.Before(start) || end.Sub(start) >= maxComparisonDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: end_date must be after start_date and within %d days of it", ErrComparisonInvalid, maxComparisonDays)
+	}
+	if len(metrics) == 0 {
+		metrics = DefaultComparisonMetrics
+	}
+	seen := map[string]bool{}
+	for _, m := range metrics {
+		if _, ok := comparisonMetrics[m]; !ok {
+			return nil, fmt.Errorf("%w: unknown metric %q", ErrComparisonInvalid, m)
+		}
+		if seen[m] {
+			return nil, fmt.Errorf("%w: metric %q given twice", ErrComparisonInvalid, m)
+		}
+		seen[m] = true
+	}
+
+	children, err := s.familyChildren(ctx, familyID, userID, childIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &models.ChildComparison{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Dates:     []string{},
+		Children:  make([]models.ComparedChild, len(children)),
+		Metrics:   make([]models.ComparisonMetric, len(metrics)),
+	}
+	index := map[string]int{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		index[day.Format("2006-01-02")] = len(out.Dates)
+		out.Dates = append(out.Dates, day.Format("2006-01-02"))
+	}
+	for i, key := range metrics {
+		m := comparisonMetrics[key]
+		out.Metrics[i] = models.ComparisonMetric{Key: key, Label: m.label, Unit: m.unit, Series: []models.ComparisonSeries{}}
+	}
+	for ci, child := range children {
+		out.Children[ci] = models.ComparedChild{ID: child.ID, FirstName: child.FirstName, Annotations: []models.Annotation{}}
+		if s.annotations != nil {
+			if out.Children[ci].Annotations, err = s.annotations.ListForRange(ctx, child.ID, start, end); err != nil {
+				return nil, err
+			}
+		}
+		data, err := s.series.GetMetricSeries(ctx, child.ID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range metrics {
+			out.Metrics[i].Series = append(out.Metrics[i].Series,
+				alignComparisonSeries(child.ID, data[comparisonMetrics[key].series], comparisonMetrics[key], index, len(out.Dates)))
+		}
+	}
+	return out, nil
+}
+
+// familyChildren loads the children to compare, checking the caller is an
+// active member of familyID and each child is an active child of it.
+func (s *ChildComparisonService) familyChildren(ctx context.Context, familyID, userID uuid.UUID, childIDs []uuid.UUID) ([]models.Child, error) {
+	membership, err := s.members.GetMembership(ctx, familyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil || !membership.IsActive {
+		return nil, ErrComparisonAccess
+	}
+
+	var children []models.Child
+	if len(childIDs) == 0 {
+		all, err := s.children.GetByFamilyID(ctx, familyID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range all {
+			if c.IsActive && c.FamilyID == familyID {
+				children = append(children, c)
+			}
+		}
+	} else {
+		seen := map[uuid.UUID]bool{}
+		for _, id := range childIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			c, err := s.children.GetByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if c == nil || c.FamilyID != familyID || !c.IsActive {
+				return nil, ErrComparisonAccess
+			}
+			children = append(children, *c)
+		}
+	}
+	switch {
+	case len(children) < 2:
+		return nil, fmt.Errorf("%w: a comparison needs at least two children", ErrComparisonInvalid)
+	case len(children) > maxComparedChildren:
+		return nil, fmt.Errorf("%w: at most %d children can be compared at once", ErrComparisonInvalid, maxComparedChildren)
+	}
+	return children, nil
+}
+
+// alignComparisonSeries combines points into one value per day and places
+// them on the comparison's dates.
+func alignComparisonSeries(childID uuid.UUID, points []models.DataPoint, m comparisonMetric, index map[string]int, days int) models.ComparisonSeries {
+	sums := make([]float64, days)
+	counts := make([]int, days)
+	for _, p := range points {
+		i, ok := index[p.Date.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		sums[i] += p.Value
+		counts[i]++
+	}
+	series := models.ComparisonSeries{ChildID: childID, Values: make([]*float64, days)}
+	var total float64
+	for i := range sums {
+		if counts[i] == 0 {
+			continue
+		}
+		v := sums[i]
+		if !m.sum {
+			v /= float64(counts[i])
+		}
+		v = math.Round(v*m.scale*100) / 100
+		series.Values[i] = &v
+		series.DaysLogged++
+		total += v
+	}
+	if series.DaysLogged > 0 {
+		avg := math.Round(total/float64(series.DaysLogged)*100) / 100
+		series.Average = &avg
+	}
+	return series
+}
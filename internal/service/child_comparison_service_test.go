@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeComparisonSources struct {
+	children    map[uuid.UUID]*models.Child
+	memberships map[uuid.UUID]*models.FamilyMembership // by user
+	series      map[uuid.UUID]map[string][]models.DataPoint
+}
+
+func (f *fakeComparisonSources) GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error) {
+	return f.children[id], nil
+}
+
+func (f *fakeComparisonSources) GetByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Child, error) {
+	var out []models.Child
+	for _, c := range f.children {
+		if c.FamilyID == familyID {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeComparisonSources) GetMembership(ctx context.Context, familyID, userID uuid.UUID) (*models.FamilyMembership, error) {
+	if m := f.memberships[userID]; m != nil && m.FamilyID == familyID {
+		return m, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeComparisonSources) GetMetricSeries(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (map[string][]models.DataPoint, error) {
+	return f.series[childID], nil
+}
+
+func TestChildComparison(t *testing.T) {
+	ctx := context.Background()
+	family, otherFamily := uuid.New(), uuid.New()
+	parent, former := uuid.New(), uuid.New()
+	ava := &models.Child{ID: uuid.New(), FamilyID: family, FirstName: "Ava", IsActive: true}
+	ben := &models.Child{ID: uuid.New(), FamilyID: family, FirstName: "Ben", IsActive: true}
+	archived := &models.Child{ID: uuid.New(), FamilyID: family, FirstName: "Cal", IsActive: false}
+	stranger := &models.Child{ID: uuid.New(), FamilyID: otherFamily, FirstName: "Dee", IsActive: true}
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return start.AddDate(0, 0, n) }
+	src := &fakeComparisonSources{
+		children: map[uuid.UUID]*models.Child{ava.ID: ava, ben.ID: ben, archived.ID: archived, stranger.ID: stranger},
+		memberships: map[uuid.UUID]*models.FamilyMembership{
+			parent: {FamilyID: family, UserID: parent, IsActive: true},
+			former: {FamilyID: family, UserID: former, IsActive: false},
+		},
+		series: map[uuid.UUID]map[string][]models.DataPoint{
+			ava.ID: {
+				"sleep_minutes":        {{Date: day(0), Value: 540}, {Date: day(2), Value: 480}},
+				"meltdowns":            {{Date: day(0), Value: 1}, {Date: day(0), Value: 2}, {Date: day(9), Value: 4}},
+				"medication_adherence": {{Date: day(1), Value: 1}, {Date: day(1), Value: 0}},
+			},
+			ben.ID: {
+				"meltdowns": {{Date: day(2), Value: 0}},
+			},
+		},
+	}
+	svc := NewChildComparisonService(src, src, src)
+	end := day(2)
+
+	for name, tc := range map[string]struct {
+		user    uuid.UUID
+		ids     []uuid.UUID
+		metrics []string
+		want    error
+	}{
+		"other family's child": {parent, []uuid.UUID{ava.ID, stranger.ID}, nil, ErrComparisonAccess},
+		"archived child":       {parent, []uuid.UUID{ava.ID, archived.ID}, nil, ErrComparisonAccess},
+		"unknown child":        {parent, []uuid.UUID{ava.ID, uuid.New()}, nil, ErrComparisonAccess},
+		"inactive membership":  {former, nil, nil, ErrComparisonAccess},
+		"not a member":         {uuid.New(), nil, nil, ErrComparisonAccess},
+		"one child":            {parent, []uuid.UUID{ava.ID, ava.ID}, nil, ErrComparisonInvalid},
+		"unknown metric":       {parent, nil, []string{"weight"}, ErrComparisonInvalid},
+	} {
+		if _, err := svc.Compare(ctx, family, tc.user, tc.ids, tc.metrics, start, end); !errors.Is(err, tc.want) {
+			t.Errorf("%s: %v, want %v", name, err, tc.want)
+		}
+	}
+	if _, err := svc.Compare(ctx, family, parent, nil, nil, start, start.AddDate(1, 0, 1)); !errors.Is(err, ErrComparisonInvalid) {
+		t.Errorf("long range: %v", err)
+	}
+
+	cmp, err := svc.Compare(ctx, family, parent, []uuid.UUID{ben.ID, ava.ID}, nil, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmp.Dates) != 3 || len(cmp.Children) != 2 || cmp.Children[0].FirstName != "Ben" || len(cmp.Metrics) != 3 {
+		t.Fatalf("comparison %+v", cmp)
+	}
+	val := func(p *float64) float64 {
+		if p == nil {
+			return -1
+		}
+		return *p
+	}
+	sleep, melt, adh := cmp.Metrics[0].Series[1], cmp.Metrics[1].Series[1], cmp.Metrics[2].Series[1]
+	if val(sleep.Values[0]) != 9 || sleep.Values[1] != nil || val(sleep.Values[2]) != 8 || val(sleep.Average) != 8.5 {
+		t.Errorf("sleep %+v", sleep)
+	}
+	// Points on the same day add up; days outside the range are dropped.
+	if val(melt.Values[0]) != 3 || melt.DaysLogged != 1 {
+		t.Errorf("meltdowns %+v", melt)
+	}
+	if val(adh.Values[1]) != 50 {
+		t.Errorf("adherence %+v", adh)
+	}
+	if b := cmp.Metrics[1].Series[0]; b.ChildID != ben.ID || val(b.Values[2]) != 0 || b.DaysLogged != 1 {
+		t.Errorf("ben meltdowns %+v", b)
+	}
+
+	// No child IDs compares the family's active children.
+	cmp, err = svc.Compare(ctx, family, parent, nil, []string{"meltdowns"}, start, end)
+	if err != nil || len(cmp.Children) != 2 || len(cmp.Metrics) != 1 {
+		t.Errorf("defaults: %+v, %v", cmp, err)
+	}
+}
@@ -2,18 +2,18 @@ package service
 
 import (
 	"context"
-	"errors"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrChildNotFound = errors.New("child not found")
+	ErrChildNotFound = apperr.NotFound("child not found")
 )
 
 type ChildService struct {
@@ -103,6 +103,11 @@ func (s *ChildService) GetByFamilyID(ctx context.Context, familyID uuid.UUID) ([
 	return s.childRepo.GetByFamilyID(ctx, familyID)
 }
 
+// FamilyChangeStamp says when the family's children list last changed.
+func (s *ChildService) FamilyChangeStamp(ctx context.Context, familyID uuid.UUID) (models.ChangeStamp, error) {
+	return s.childRepo.FamilyChangeStamp(ctx, familyID)
+}
+
 func (s *ChildService) Update(ctx context.Context, childID uuid.UUID, req *models.UpdateChildRequest) (*models.Child, error) {
 	child, err := s.childRepo.GetByID(ctx, childID)
 	if err != nil {
@@ -166,10 +171,14 @@ func (s *ChildService) RemoveCondition(ctx context.Context, conditionID uuid.UUI
 }
 
 func (s *ChildService) GetDashboard(ctx context.Context, childID uuid.UUID) (*models.ChildDashboard, error) {
-	return s.childRepo.GetDashboard(ctx, childID, time.Now())
+	return s.GetDashboardForDate(ctx, childID, time.Now())
 }
 
+// GetDashboardForDate runs the dashboard's queries under the "dashboard"
+// operation budget.
 func (s *ChildService) GetDashboardForDate(ctx context.Context, childID uuid.UUID, date time.Time) (*models.ChildDashboard, error) {
+	ctx, cancel := repository.OperationContext(ctx, "dashboard")
+	defer cancel()
 	return s.childRepo.GetDashboard(ctx, childID, date)
 }
 
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkBytes is the INSTREAM chunk size. clamd's own default
+// StreamMaxLength (25MB) is the real ceiling on a single scan.
+const clamdChunkBytes = 64 * 1024
+
+// VirusVerdict is what a scanner concluded about one stream.
+type VirusVerdict struct {
+	Infected  bool
+	Signature string
+}
+
+// VirusScanner checks a byte stream for malware. Scan returns an error
+// only when no verdict could be reached.
+type VirusScanner interface {
+	Engine() string
+	Scan(ctx context.Context, body io.Reader) (VirusVerdict, error)
+	Version(ctx context.Context) (string, error)
+}
+
+// ClamdScanner talks to a clamd sidecar over its socket protocol. Addr is
+// host:port for TCP or an absolute path for a unix socket.
+type ClamdScanner struct {
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner for the clamd at addr. timeout bounds
+// one whole scan, including streaming the body.
+func NewClamdScanner(addr string, timeout time.Duration) *ClamdScanner {
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	return &ClamdScanner{addr: addr, timeout: timeout}
+}
+
+// Engine names the scanner in recorded verdicts.
+func (c *ClamdScanner) Engine() string { return "clamav" }
+
+func (c *ClamdScanner) dial(ctx context.Context) (net.Conn, error) {
+	network := "tcp"
+	if strings.HasPrefix(c.addr, "/") {
+		network = "unix"
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("clamd dial: %w", err)
+	}
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	_ = conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+// readReply reads one NUL-terminated clamd reply.
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", fmt.Errorf("clamd reply: %w", err)
+	}
+	return strings.TrimSpace(strings.TrimRight(reply, "\x00")), nil
+}
+
+// Version asks clamd for its engine and signature database version; it
+// doubles as the health check.
+func (c *ClamdScanner) Version(ctx context.Context) (string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("zVERSION\x00")); err != nil {
+		return "", fmt.Errorf("clamd write: %w", err)
+	}
+	return readReply(conn)
+}
+
+// Scan streams body to clamd with INSTREAM: length-prefixed chunks ended
+// by a zero-length one.
+func (c *ClamdScanner) Scan(ctx context.Context, body io.Reader) (VirusVerdict, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return VirusVerdict{}, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := writeInstream(conn, body); err != nil {
+		// clamd hangs up once StreamMaxLength is exceeded; its reply says so.
+		if reply, rerr := readReply(conn); rerr == nil && reply != "" {
+			return parseClamdReply(reply)
+		}
+		return VirusVerdict{}, err
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		return VirusVerdict{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+func writeInstream(conn net.Conn, body io.Reader) error {
+	w := bufio.NewWriterSize(conn, clamdChunkBytes+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return err
+	}
+	buf := make([]byte, clamdChunkBytes)
+	var size [4]byte
+	for {
+		n, rerr := body.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return fmt.Errorf("read upload: %w", rerr)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// parseClamdReply turns "stream: OK", "stream: <sig> FOUND" or
+// "<message> ERROR" into a verdict.
+func parseClamdReply(reply string) (VirusVerdict, error) {
+	msg := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case msg == "OK":
+		return VirusVerdict{}, nil
+	case strings.HasSuffix(msg, " FOUND"):
+		return VirusVerdict{Infected: true, Signature: strings.TrimSuffix(msg, " FOUND")}, nil
+	default:
+		return VirusVerdict{}, fmt.Errorf("clamd: %s", msg)
+	}
+}
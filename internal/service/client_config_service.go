@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// clientConfigTTL is how long each instance serves its cached client
+// config; settings changes (maintenance, registration, versions) reach
+// clients within this plus their own max-age.
+const clientConfigTTL = time.Minute
+
+// defaultSupportEmail is used when the brand config has none.
+const defaultSupportEmail = "support@mycarecompanion.net"
+
+// ClientLimits are the server-side limits clients should check before
+// sending, so they can fail fast with a useful message.
+type ClientLimits struct {
+	MaxFileBytes            int64 `json:"max_file_bytes"`
+	MaxImageBytes           int64 `json:"max_image_bytes"`
+	MaxAttachmentBytes      int64 `json:"max_attachment_bytes"`
+	MaxAttachmentsPerTicket int   `json:"max_attachments_per_ticket"`
+	// The rest are enforced by the API handlers, which fill them in.
+	MaxBatchRequests        int `json:"max_batch_requests"`
+	MaxTicketDescriptionLen int `json:"max_ticket_description_length"`
+	MaxTicketMessageLen     int `json:"max_ticket_message_length"`
+}
+
+// ClientSupport is how users reach support, from the brand config.
+type ClientSupport struct {
+	Email         string `json:"email"`
+	Phone         string `json:"phone,omitempty"`
+	WebsiteURL    string `json:"website_url,omitempty"`
+	HelpCenterURL string `json:"help_center_url"`
+}
+
+// ClientMaintenance is the maintenance_mode setting as clients see it.
+type ClientMaintenance struct {
+	Active  bool   `json:"active"`
+	Message string `json:"message,omitempty"`
+}
+
+// ClientConfig is what GET /api/client-config returns: the settings the
+// apps used to hardcode, for the environment they're talking to.
+type ClientConfig struct {
+	Environment string            `json:"environment"`
+	Features    map[string]bool   `json:"features"`
+	Limits      ClientLimits      `json:"limits"`
+	Support     ClientSupport     `json:"support"`
+	Maintenance ClientMaintenance `json:"maintenance"`
+	AppVersions struct {
+		IOS     AppPlatformVersions `json:"ios"`
+		Android AppPlatformVersions `json:"android"`
+	} `json:"app_versions"`
+	// Captcha is the widget to show on the endpoints that ask for one;
+	// absent while none do.
+	Captcha *ClientCaptcha `json:"captcha,omitempty"`
+	// Vocabularies maps each reference list the apps cache to a hash of its
+	// contents; a client refetches a list when its hash changes.
+	Vocabularies map[string]string `json:"vocabularies"`
+	GeneratedAt  time.Time         `json:"generated_at"`
+}
+
+// ClientConfigOptions are the config-file parts of ClientConfig.
+type ClientConfigOptions struct {
+	// Environment is config.AppConfig.Env.
+	Environment string
+	AppURL      string
+	// Features are the flags fixed by config (AI, billing); flags backed
+	// by system settings are added per snapshot.
+	Features map[string]bool
+	Limits   ClientLimits
+}
+
+// brandSource is the slice of MarketingRepository the support contact uses.
+type brandSource interface {
+	GetBrandConfig(ctx context.Context) (*models.BrandConfig, error)
+}
+
+// helpCategorySource lists the public help center categories.
+type helpCategorySource interface {
+	ListCategories(ctx context.Context, publicView bool) ([]repository.KBCategory, error)
+}
+
+// ClientConfigService assembles ClientConfig from config, system settings
+// and reference data, cached per instance for clientConfigTTL.
+type ClientConfigService struct {
+	opts     ClientConfigOptions
+	settings settingsStore
+	versions *AppVersionService
+	brand    brandSource
+	help     helpCategorySource
+	captcha  *CaptchaService
+	now      func() time.Time
+
+	mu       sync.Mutex
+	cached   *ClientConfig
+	cachedAt time.Time
+}
+
+func NewClientConfigService(opts ClientConfigOptions, settings settingsStore, versions *AppVersionService, brand brandSource, help helpCategorySource) *ClientConfigService {
+	return &ClientConfigService{
+		opts:     opts,
+		settings: settings,
+		versions: versions,
+		brand:    brand,
+		help:     help,
+		now:      time.Now,
+	}
+}
+
+// SetCaptcha adds the CAPTCHA widget settings to the config.
+func (s *ClientConfigService) SetCaptcha(c *CaptchaService) {
+	s.captcha = c
+}
+
+// Current returns the cached snapshot, rebuilding it once it's older than
+// clientConfigTTL. The snapshot is shared; callers must not modify it.
+func (s *ClientConfigService) Current(ctx context.Context) *ClientConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != nil && s.now().Sub(s.cachedAt) < clientConfigTTL {
+		return s.cached
+	}
+	s.cached, s.cachedAt = s.build(ctx), s.now()
+	return s.cached
+}
+
+// build assembles a fresh snapshot. Each source that fails to load keeps
+// its default rather than failing the whole config; an app that can't
+// read its config at launch is worse off than one with a stale hash.
+func (s *ClientConfigService) build(ctx context.Context) *ClientConfig {
+	cfg := &ClientConfig{
+		Environment:  s.opts.Environment,
+		Features:     map[string]bool{},
+		Limits:       s.opts.Limits,
+		Vocabularies: map[string]string{},
+		GeneratedAt:  s.now().UTC(),
+	}
+	for k, v := range s.opts.Features {
+		cfg.Features[k] = v
+	}
+	cfg.Features["registration"] = settingEnabled(ctx, s.settings, "registration_enabled", s.opts.Environment == "production")
+
+	if val, err := s.settings.GetSetting(ctx, "maintenance_mode"); err != nil {
+		log.Printf("[CLIENT CONFIG] maintenance_mode: %v", err)
+	} else {
+		cfg.Maintenance = parseMaintenance(val)
+	}
+
+	if s.versions != nil {
+		policy := s.versions.Current(ctx)
+		cfg.AppVersions.IOS, cfg.AppVersions.Android = policy.IOS, policy.Android
+	}
+
+	cfg.Captcha = s.captcha.Client(ctx)
+
+	cfg.Support = ClientSupport{Email: defaultSupportEmail, HelpCenterURL: s.opts.AppURL + "/help"}
+	if s.brand != nil {
+		if b, err := s.brand.GetBrandConfig(ctx); err != nil {
+			log.Printf("[CLIENT CONFIG] brand config: %v", err)
+		} else if b != nil {
+			if b.SupportEmail != "" {
+				cfg.Support.Email = b.SupportEmail
+			}
+			cfg.Support.Phone, cfg.Support.WebsiteURL = b.ContactPhone, b.WebsiteURL
+		}
+	}
+
+	cfg.Vocabularies["alert_types"] = vocabularyHash(models.AlertTypes)
+	if s.help != nil {
+		if cats, err := s.help.ListCategories(ctx, true); err != nil {
+			log.Printf("[CLIENT CONFIG] help categories: %v", err)
+		} else {
+			// Article counts move with every publish; only the list
+			// itself is the vocabulary.
+			type helpCategory struct {
+				Slug, Name, Description string
+				SortOrder               int
+			}
+			list := make([]helpCategory, len(cats))
+			for i, c := range cats {
+				list[i] = helpCategory{c.Slug, c.Name, c.Description, c.SortOrder}
+			}
+			cfg.Vocabularies["help_categories"] = vocabularyHash(list)
+		}
+	}
+	return cfg
+}
+
+// settingEnabled reads a {"enabled": bool} (or bare bool) system setting,
+// falling back to def when it's unset or unreadable.
+func settingEnabled(ctx context.Context, settings settingsStore, key string, def bool) bool {
+	val, err := settings.GetSetting(ctx, key)
+	if err != nil || val == nil {
+		return def
+	}
+	if m, ok := val.(map[string]interface{}); ok {
+		if e, ok := m["enabled"].(bool); ok {
+			return e
+		}
+	}
+	if b, ok := val.(bool); ok {
+		return b
+	}
+	return def
+}
+
+// parseMaintenance reads maintenance_mode as the admin toggle writes it,
+// {"enabled": bool, "message": string}.
+func parseMaintenance(val interface{}) ClientMaintenance {
+	var out ClientMaintenance
+	switch v := val.(type) {
+	case map[string]interface{}:
+		out.Active, _ = v["enabled"].(bool)
+		if out.Active {
+			out.Message, _ = v["message"].(string)
+		}
+	case bool:
+		out.Active = v
+	}
+	return out
+}
+
+// vocabularyHash is a short content hash of v's JSON encoding.
+func vocabularyHash(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
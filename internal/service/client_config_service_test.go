@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type stubBrand struct {
+	cfg *models.BrandConfig
+	err error
+}
+
+func (b stubBrand) GetBrandConfig(ctx context.Context) (*models.BrandConfig, error) {
+	return b.cfg, b.err
+}
+
+type stubHelpCategories struct{ cats []repository.KBCategory }
+
+func (h *stubHelpCategories) ListCategories(ctx context.Context, publicView bool) ([]repository.KBCategory, error) {
+	return h.cats, nil
+}
+
+func TestClientConfigSnapshot(t *testing.T) {
+	settings := memSettings{
+		"maintenance_mode": map[string]interface{}{"enabled": true, "message": "Back at 9pm"},
+	}
+	help := &stubHelpCategories{cats: []repository.KBCategory{{Slug: "billing", Name: "Billing", ArticleCount: 3}}}
+	svc := NewClientConfigService(ClientConfigOptions{
+		Environment: "staging",
+		AppURL:      "https://staging.example.com",
+		Features:    map[string]bool{"billing": true},
+		Limits:      ClientLimits{MaxImageBytes: 1 << 20},
+	}, settings, NewAppVersionService(settings), stubBrand{err: errors.New("no brand row")}, help)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	cfg := svc.Current(ctx)
+	if cfg.Environment != "staging" || !cfg.Features["billing"] || cfg.Limits.MaxImageBytes != 1<<20 {
+		t.Errorf("config parts: %+v", cfg)
+	}
+	if cfg.Features["registration"] {
+		t.Error("registration should default closed outside production")
+	}
+	if !cfg.Maintenance.Active || cfg.Maintenance.Message != "Back at 9pm" {
+		t.Errorf("maintenance = %+v", cfg.Maintenance)
+	}
+	if cfg.Support.Email != defaultSupportEmail || cfg.Support.HelpCenterURL != "https://staging.example.com/help" {
+		t.Errorf("support without brand config = %+v", cfg.Support)
+	}
+	if cfg.AppVersions.IOS.MinVersion != DefaultAppVersionPolicy.IOS.MinVersion {
+		t.Errorf("app versions = %+v", cfg.AppVersions)
+	}
+	helpHash := cfg.Vocabularies["help_categories"]
+	if helpHash == "" || cfg.Vocabularies["alert_types"] == "" {
+		t.Fatalf("vocabularies = %v", cfg.Vocabularies)
+	}
+
+	// Changes show up once the cache expires; an article count alone
+	// doesn't change the vocabulary hash, a renamed category does.
+	settings["registration_enabled"] = map[string]interface{}{"enabled": true}
+	help.cats[0].ArticleCount = 4
+	if svc.Current(ctx).Features["registration"] {
+		t.Error("within TTL should serve the cached snapshot")
+	}
+	now = now.Add(clientConfigTTL)
+	cfg = svc.Current(ctx)
+	if !cfg.Features["registration"] || cfg.Vocabularies["help_categories"] != helpHash {
+		t.Errorf("after TTL: registration %v, help hash %s (was %s)", cfg.Features["registration"], cfg.Vocabularies["help_categories"], helpHash)
+	}
+	help.cats[0].Name = "Billing & plans"
+	now = now.Add(clientConfigTTL)
+	if svc.Current(ctx).Vocabularies["help_categories"] == helpHash {
+		t.Error("renamed category should change the help_categories hash")
+	}
+}
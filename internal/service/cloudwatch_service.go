@@ -24,6 +24,9 @@ type CloudWatchService struct {
 	albARN            string
 	targetGroupARN    string
 	region            string
+	// confirmSecret signs confirmation tokens for the write operations in
+	// asg_ops.go.
+	confirmSecret     []byte
 }
 
 // CloudWatchMetrics contains all metrics fetched from CloudWatch
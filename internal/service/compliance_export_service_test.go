@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+func TestComplianceExportStart(t *testing.T) {
+	at := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	s := &ComplianceExportService{now: func() time.Time { return at }}
+
+	day := 24 * time.Hour
+	if _, err := s.Start(ComplianceExportAuditLog, "a@example.com", repository.ComplianceExportFilter{From: at, To: at}); !errors.Is(err, ErrComplianceExportRange) {
+		t.Errorf("empty range: err = %v", err)
+	}
+	e, err := s.Start(ComplianceExportAuditLog, "a@example.com", repository.ComplianceExportFilter{From: at.Add(-day), To: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := e.Watermark()
+	for _, want := range []string{"CONFIDENTIAL", "audit_log", e.ID.String(), "a@example.com", "2026-10-17T09:30:00Z"} {
+		if !strings.Contains(w, want) {
+			t.Errorf("watermark %q lacks %q", w, want)
+		}
+	}
+}
@@ -9,13 +9,14 @@ import (
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrCorrelationNotFound     = errors.New("correlation request not found")
-	ErrPatternNotFound         = errors.New("pattern not found")
+	ErrCorrelationNotFound     = apperr.NotFound("correlation request not found")
+	ErrPatternNotFound         = apperr.NotFound("pattern not found")
 	ErrInsufficientData        = errors.New("insufficient data for correlation analysis")
 	MinimumDataPointsRequired  = 14 // At least 2 weeks of data
 	SignificantCorrelation     = 0.5
@@ -57,6 +58,12 @@ func (s *CorrelationService) UpdateBaseline(ctx context.Context, baseline *model
 	return s.correlationRepo.UpdateBaseline(ctx, baseline)
 }
 
+// GetMetricSeries returns the daily series the correlation engine works
+// from, keyed by metric name.
+func (s *CorrelationService) GetMetricSeries(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (map[string][]models.DataPoint, error) {
+	return s.correlationRepo.GetCorrelationData(ctx, childID, startDate, endDate)
+}
+
 // Calculate baselines from historical data
 func (s *CorrelationService) CalculateBaselines(ctx context.Context, childID uuid.UUID) ([]models.ChildBaseline, error) {
 	endDate := time.Now()
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ServiceCost is one day's unblended cost for one AWS service.
+type ServiceCost struct {
+	Day       time.Time
+	Service   string
+	Amount    float64
+	Unit      string
+	Estimated bool
+}
+
+// CostExplorerAPI is the slice of the Cost Explorer API the cost panel uses.
+type CostExplorerAPI interface {
+	// DailyCostByService returns unblended cost per service per day for
+	// [from, to), both truncated to UTC days.
+	DailyCostByService(ctx context.Context, from, to time.Time) ([]ServiceCost, error)
+}
+
+// CostExplorerClient talks to Cost Explorer. The API is global and served
+// from us-east-1 only; every request is billed ($0.01), which is why the
+// sync runs daily rather than on page load.
+type CostExplorerClient struct {
+	api *awsJSONClient
+}
+
+// NewCostExplorerClient creates a client using the default AWS config.
+func NewCostExplorerClient(ctx context.Context) (*CostExplorerClient, error) {
+	creds, err := loadAWSCredentials(ctx, "us-east-1")
+	if err != nil {
+		return nil, err
+	}
+	return &CostExplorerClient{
+		api: newAWSJSONClient("https://ce.us-east-1.amazonaws.com/", "us-east-1", "ce", "AWSInsightsIndexService", creds),
+	}, nil
+}
+
+type ceMetric struct {
+	Amount string `json:"Amount"`
+	Unit   string `json:"Unit"`
+}
+
+type ceGetCostAndUsageOutput struct {
+	NextPageToken string `json:"NextPageToken"`
+	ResultsByTime []struct {
+		TimePeriod struct {
+			Start string `json:"Start"`
+		} `json:"TimePeriod"`
+		Estimated bool `json:"Estimated"`
+		Groups    []struct {
+			Keys    []string            `json:"Keys"`
+			Metrics map[string]ceMetric `json:"Metrics"`
+		} `json:"Groups"`
+	} `json:"ResultsByTime"`
+}
+
+func (c *CostExplorerClient) DailyCostByService(ctx context.Context, from, to time.Time) ([]ServiceCost, error) {
+	in := map[string]interface{}{
+		"TimePeriod": map[string]string{
+			"Start": from.UTC().Format("2006-01-02"),
+			"End":   to.UTC().Format("2006-01-02"),
+		},
+		"Granularity": "DAILY",
+		"Metrics":     []string{"UnblendedCost"},
+		"GroupBy":     []map[string]string{{"Type": "DIMENSION", "Key": "SERVICE"}},
+	}
+	var costs []ServiceCost
+	for {
+		var out ceGetCostAndUsageOutput
+		if err := c.api.call(ctx, "GetCostAndUsage", in, &out); err != nil {
+			return nil, err
+		}
+		for _, r := range out.ResultsByTime {
+			day, err := time.Parse("2006-01-02", r.TimePeriod.Start)
+			if err != nil {
+				return nil, fmt.Errorf("cost explorer: bad period start %q", r.TimePeriod.Start)
+			}
+			for _, g := range r.Groups {
+				if len(g.Keys) == 0 {
+					continue
+				}
+				m := g.Metrics["UnblendedCost"]
+				amount, err := strconv.ParseFloat(m.Amount, 64)
+				if err != nil {
+					return nil, fmt.Errorf("cost explorer: bad amount %q for %s", m.Amount, g.Keys[0])
+				}
+				costs = append(costs, ServiceCost{
+					Day:       day,
+					Service:   g.Keys[0],
+					Amount:    amount,
+					Unit:      m.Unit,
+					Estimated: r.Estimated,
+				})
+			}
+		}
+		if out.NextPageToken == "" {
+			return costs, nil
+		}
+		in["NextPageToken"] = out.NextPageToken
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var ErrCostExplorerDisabled = errors.New("cost explorer is not configured (COST_EXPLORER_ENABLED unset)")
+
+const (
+	// costRefetchDays is how many recent days each sync re-reads; AWS
+	// keeps adjusting them (credits, tax, late usage) for a few days.
+	costRefetchDays = 3
+)
+
+// CostOptions configures CostService; NewServices fills it from
+// config.CostConfig.
+type CostOptions struct {
+	// MonthlyBudgetUSD of zero disables the budget alerts.
+	MonthlyBudgetUSD float64
+	// AlertThresholds are percentages of the budget, e.g. 50, 80, 100.
+	AlertThresholds []int
+	SyncHourUTC     int
+	// BackfillDays is how far back the first sync reads.
+	BackfillDays int
+}
+
+// CostSummary is the cost panel on the infrastructure page.
+type CostSummary struct {
+	Enabled       bool       `json:"enabled"`
+	LastSyncedDay *time.Time `json:"last_synced_day,omitempty"`
+	Currency      string     `json:"currency"`
+
+	BudgetUSD         float64 `json:"budget_usd"`
+	MonthToDateUSD    float64 `json:"month_to_date_usd"`
+	ForecastUSD       float64 `json:"forecast_usd"`
+	BudgetUsedPct     float64 `json:"budget_used_pct"`
+	ForecastPct       float64 `json:"forecast_pct"`
+	DaysElapsed       int     `json:"days_elapsed"`
+	DaysInMonth       int     `json:"days_in_month"`
+	ActiveUsers30d    int     `json:"active_users_30d"`
+	CostPerActiveUser float64 `json:"cost_per_active_user_usd"`
+
+	ByService []repository.ServiceCostTotal `json:"by_service"`
+	Daily     []repository.DailyCostTotal   `json:"daily"`
+	Months    []MonthlyCostPerUser          `json:"months"`
+	Alerts    []models.InfrastructureAlert  `json:"alerts"`
+}
+
+// MonthlyCostPerUser is one month's cost and cost per 30-day active user.
+type MonthlyCostPerUser struct {
+	repository.MonthlyCost
+	CostPerActiveUser float64 `json:"cost_per_active_user_usd"`
+}
+
+// CostService pulls daily AWS cost by service from Cost Explorer, keeps
+// the history in aws_daily_costs and turns it into the cost panel: month
+// to date against the budget, a straight-line forecast and cost per
+// active user.
+type CostService struct {
+	repo repository.CostRepository
+	ce   CostExplorerAPI
+	opts CostOptions
+	now  func() time.Time
+}
+
+// NewCostService creates the service. ce may be nil when Cost Explorer
+// isn't configured; the panel then shows stored history only.
+func NewCostService(repo repository.CostRepository, ce CostExplorerAPI, opts CostOptions) *CostService {
+	if opts.BackfillDays <= 0 {
+		opts.BackfillDays = 90
+	}
+	return &CostService{repo: repo, ce: ce, opts: opts, now: time.Now}
+}
+
+// Enabled reports whether Cost Explorer is configured.
+func (s *CostService) Enabled() bool { return s != nil && s.ce != nil }
+
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Sync fetches costs from the last stored day (less costRefetchDays) or
+// BackfillDays ago through today, and records today's active user count.
+// Returns the number of service-day rows stored.
+func (s *CostService) Sync(ctx context.Context) (int, error) {
+	if !s.Enabled() {
+		return 0, ErrCostExplorerDisabled
+	}
+	today := utcDay(s.now())
+	from := today.AddDate(0, 0, -s.opts.BackfillDays)
+	latest, err := s.repo.LatestDay(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("latest cost day: %w", err)
+	}
+	if latest != nil {
+		if f := utcDay(*latest).AddDate(0, 0, -costRefetchDays); f.After(from) {
+			from = f
+		}
+	}
+	costs, err := s.ce.DailyCostByService(ctx, from, today.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, fmt.Errorf("cost explorer: %w", err)
+	}
+	rows := make([]repository.AWSDailyCost, 0, len(costs))
+	for _, c := range costs {
+		rows = append(rows, repository.AWSDailyCost{
+			Day:       c.Day,
+			Service:   c.Service,
+			AmountUSD: c.Amount,
+			Estimated: c.Estimated,
+		})
+	}
+	if err := s.repo.UpsertDaily(ctx, rows); err != nil {
+		return 0, fmt.Errorf("store costs: %w", err)
+	}
+	if _, err := s.repo.RecordActiveUsers(ctx, today); err != nil {
+		return len(rows), fmt.Errorf("record active users: %w", err)
+	}
+	log.Printf("[COST] synced %d service-day rows from %s", len(rows), from.Format("2006-01-02"))
+	return len(rows), nil
+}
+
+// Summary builds the cost panel from stored history.
+func (s *CostService) Summary(ctx context.Context) (*CostSummary, error) {
+	now := s.now().UTC()
+	today := utcDay(now)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	nextMonth := monthStart.AddDate(0, 1, 0)
+
+	sum := &CostSummary{
+		Enabled:     s.Enabled(),
+		Currency:    "USD",
+		BudgetUSD:   s.opts.MonthlyBudgetUSD,
+		DaysElapsed: today.Day() - 1,
+		DaysInMonth: nextMonth.AddDate(0, 0, -1).Day(),
+	}
+	var err error
+	if sum.LastSyncedDay, err = s.repo.LatestDay(ctx); err != nil {
+		return nil, err
+	}
+	if sum.Daily, err = s.repo.DailyTotals(ctx, today.AddDate(0, 0, -30), today.AddDate(0, 0, 1)); err != nil {
+		return nil, err
+	}
+	if sum.ByService, err = s.repo.ServiceTotals(ctx, monthStart, nextMonth); err != nil {
+		return nil, err
+	}
+	months, err := s.repo.MonthlyTotals(ctx, 6)
+	if err != nil {
+		return nil, err
+	}
+
+	// Today is partial; the forecast extrapolates from complete days only.
+	var completeDays float64
+	for _, d := range sum.Daily {
+		if d.Day.Before(monthStart) {
+			continue
+		}
+		sum.MonthToDateUSD += d.AmountUSD
+		if d.Day.Before(today) {
+			completeDays += d.AmountUSD
+		}
+	}
+	if sum.DaysElapsed > 0 {
+		sum.ForecastUSD = completeDays / float64(sum.DaysElapsed) * float64(sum.DaysInMonth)
+	}
+	if sum.BudgetUSD > 0 {
+		sum.BudgetUsedPct = sum.MonthToDateUSD / sum.BudgetUSD * 100
+		sum.ForecastPct = sum.ForecastUSD / sum.BudgetUSD * 100
+	}
+
+	for _, m := range months {
+		mc := MonthlyCostPerUser{MonthlyCost: m}
+		if m.ActiveUsers > 0 {
+			mc.CostPerActiveUser = m.AmountUSD / float64(m.ActiveUsers)
+		}
+		sum.Months = append(sum.Months, mc)
+	}
+	// Cost per active user for the current month uses the forecast (or
+	// MTD on the 1st) so it's comparable with completed months.
+	if n := len(months); n > 0 && months[n-1].ActiveUsers > 0 {
+		sum.ActiveUsers30d = months[n-1].ActiveUsers
+		monthCost := sum.ForecastUSD
+		if monthCost == 0 {
+			monthCost = sum.MonthToDateUSD
+		}
+		sum.CostPerActiveUser = monthCost / float64(sum.ActiveUsers30d)
+	}
+
+	sum.Alerts = costBudgetAlerts(sum, s.opts.AlertThresholds, now)
+	if sum.ByService == nil {
+		sum.ByService = []repository.ServiceCostTotal{}
+	}
+	if sum.Daily == nil {
+		sum.Daily = []repository.DailyCostTotal{}
+	}
+	if sum.Months == nil {
+		sum.Months = []MonthlyCostPerUser{}
+	}
+	return sum, nil
+}
+
+// costBudgetAlerts raises one alert for the highest budget threshold the
+// month to date has crossed — critical at 100% or more, a warning below —
+// and a warning when the forecast will overrun a budget not yet spent.
+func costBudgetAlerts(sum *CostSummary, thresholds []int, now time.Time) []models.InfrastructureAlert {
+	alerts := []models.InfrastructureAlert{}
+	if sum.BudgetUSD <= 0 {
+		return alerts
+	}
+	sorted := append([]int(nil), thresholds...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	for _, t := range sorted {
+		if t <= 0 || sum.BudgetUsedPct < float64(t) {
+			continue
+		}
+		severity := models.HealthStatusDegraded
+		title := fmt.Sprintf("AWS spend passed %d%% of monthly budget", t)
+		if t >= 100 {
+			severity = models.HealthStatusCritical
+			title = "AWS spend is over the monthly budget"
+		}
+		alerts = append(alerts, models.InfrastructureAlert{
+			ID:           fmt.Sprintf("cost-budget-%d", t),
+			Severity:     severity,
+			Component:    "cost",
+			Title:        title,
+			Description:  fmt.Sprintf("Month-to-date AWS cost is $%.2f of a $%.2f budget.", sum.MonthToDateUSD, sum.BudgetUSD),
+			CurrentValue: fmt.Sprintf("%.0f%%", sum.BudgetUsedPct),
+			Threshold:    fmt.Sprintf("%d%%", t),
+			Recommendation: "1. Check the cost-by-service breakdown for the service driving the increase\n" +
+				"2. Compare ASG desired capacity and instance types against traffic\n" +
+				"3. Look for unattached volumes, old snapshots and idle restore-drill instances",
+			DetectedAt: now,
+		})
+		break
+	}
+	if sum.BudgetUsedPct < 100 && sum.ForecastPct >= 100 {
+		alerts = append(alerts, models.InfrastructureAlert{
+			ID:           "cost-forecast-over-budget",
+			Severity:     models.HealthStatusDegraded,
+			Component:    "cost",
+			Title:        "AWS spend forecast to exceed monthly budget",
+			Description:  fmt.Sprintf("At the current daily rate the month will cost about $%.2f against a $%.2f budget.", sum.ForecastUSD, sum.BudgetUSD),
+			CurrentValue: fmt.Sprintf("%.0f%%", sum.ForecastPct),
+			Threshold:    "100%",
+			Recommendation: "1. Review which services grew in the last few days\n" +
+				"2. Scale down non-production resources if any are running",
+			DetectedAt: now,
+		})
+	}
+	return alerts
+}
+
+// CostScheduler syncs Cost Explorer once a day at SyncHourUTC, and at
+// startup when the stored history is more than a day behind.
+type CostScheduler struct {
+	svc  *CostService
+	jobs *JobLocker
+}
+
+func NewCostScheduler(svc *CostService, jobs *JobLocker) *CostScheduler {
+	return &CostScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *CostScheduler) Start(ctx context.Context) {
+	if !s.svc.Enabled() {
+		log.Println("Cost scheduler not started (Cost Explorer disabled)")
+		return
+	}
+	hour := s.svc.opts.SyncHourUTC
+	log.Printf("Cost scheduler started (Cost Explorer sync %02d:00 UTC daily)", hour)
+	if latest, err := s.svc.repo.LatestDay(ctx); err == nil &&
+		(latest == nil || utcDay(*latest).Before(utcDay(time.Now()).AddDate(0, 0, -1))) {
+		s.jobs.RunOnce(ctx, "cost_sync_boot", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			if _, err := s.svc.Sync(ctx); err != nil {
+				log.Printf("Cost: startup sync failed: %v", err)
+			}
+		})
+	}
+	for {
+		next := nextUTCRunAt(time.Now().UTC(), hour, 0)
+		select {
+		case <-ctx.Done():
+			log.Println("Cost scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "cost_sync", next, 24*time.Hour, func(ctx context.Context) {
+				if _, err := s.svc.Sync(ctx); err != nil {
+					log.Printf("Cost: sync failed: %v", err)
+				}
+			})
+		}
+	}
+}
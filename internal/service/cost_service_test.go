@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// costRepo is an in-memory CostRepository.
+type costRepo struct {
+	costs  map[string]repository.AWSDailyCost // day|service
+	active map[time.Time]int
+}
+
+func newCostRepo() *costRepo {
+	return &costRepo{costs: map[string]repository.AWSDailyCost{}, active: map[time.Time]int{}}
+}
+
+func (r *costRepo) UpsertDaily(ctx context.Context, costs []repository.AWSDailyCost) error {
+	for _, c := range costs {
+		r.costs[c.Day.Format("2006-01-02")+"|"+c.Service] = c
+	}
+	return nil
+}
+
+func (r *costRepo) LatestDay(ctx context.Context) (*time.Time, error) {
+	var latest *time.Time
+	for _, c := range r.costs {
+		if latest == nil || c.Day.After(*latest) {
+			d := c.Day
+			latest = &d
+		}
+	}
+	return latest, nil
+}
+
+func (r *costRepo) DailyTotals(ctx context.Context, from, to time.Time) ([]repository.DailyCostTotal, error) {
+	byDay := map[time.Time]float64{}
+	for _, c := range r.costs {
+		if !c.Day.Before(from) && c.Day.Before(to) {
+			byDay[c.Day] += c.AmountUSD
+		}
+	}
+	var out []repository.DailyCostTotal
+	for d, a := range byDay {
+		out = append(out, repository.DailyCostTotal{Day: d, AmountUSD: a})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func (r *costRepo) ServiceTotals(ctx context.Context, from, to time.Time) ([]repository.ServiceCostTotal, error) {
+	bySvc := map[string]float64{}
+	for _, c := range r.costs {
+		if !c.Day.Before(from) && c.Day.Before(to) {
+			bySvc[c.Service] += c.AmountUSD
+		}
+	}
+	var out []repository.ServiceCostTotal
+	for s, a := range bySvc {
+		out = append(out, repository.ServiceCostTotal{Service: s, AmountUSD: a})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AmountUSD > out[j].AmountUSD })
+	return out, nil
+}
+
+func (r *costRepo) RecordActiveUsers(ctx context.Context, day time.Time) (int, error) {
+	r.active[day] = 40
+	return 40, nil
+}
+
+func (r *costRepo) MonthlyTotals(ctx context.Context, months int) ([]repository.MonthlyCost, error) {
+	byMonth := map[time.Time]*repository.MonthlyCost{}
+	for _, c := range r.costs {
+		m := time.Date(c.Day.Year(), c.Day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if byMonth[m] == nil {
+			byMonth[m] = &repository.MonthlyCost{Month: m}
+		}
+		byMonth[m].AmountUSD += c.AmountUSD
+	}
+	for d, n := range r.active {
+		m := time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if byMonth[m] != nil {
+			byMonth[m].ActiveUsers = n
+		}
+	}
+	var out []repository.MonthlyCost
+	for _, m := range byMonth {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Month.Before(out[j].Month) })
+	return out, nil
+}
+
+// fakeCostExplorer returns $10/day for EC2 and $2/day for RDS, and
+// records the windows it was asked for.
+type fakeCostExplorer struct {
+	calls [][2]time.Time
+}
+
+func (f *fakeCostExplorer) DailyCostByService(ctx context.Context, from, to time.Time) ([]ServiceCost, error) {
+	f.calls = append(f.calls, [2]time.Time{from, to})
+	var out []ServiceCost
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		out = append(out,
+			ServiceCost{Day: d, Service: "Amazon Elastic Compute Cloud - Compute", Amount: 10, Unit: "USD"},
+			ServiceCost{Day: d, Service: "Amazon Relational Database Service", Amount: 2, Unit: "USD"},
+		)
+	}
+	return out, nil
+}
+
+func TestCostSyncWindow(t *testing.T) {
+	repo := newCostRepo()
+	ce := &fakeCostExplorer{}
+	svc := NewCostService(repo, ce, CostOptions{BackfillDays: 30})
+	now := time.Date(2026, 6, 16, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// First sync backfills, through today inclusive.
+	if got := ce.calls[0]; !got[0].Equal(time.Date(2026, 5, 17, 0, 0, 0, 0, time.UTC)) || !got[1].Equal(time.Date(2026, 6, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("first window = %v", got)
+	}
+
+	now = now.AddDate(0, 0, 1)
+	if _, err := svc.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Later syncs re-read the last few stored days only.
+	if got := ce.calls[1][0]; !got.Equal(time.Date(2026, 6, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("second window starts %v", got)
+	}
+}
+
+func TestCostSyncDisabled(t *testing.T) {
+	svc := NewCostService(newCostRepo(), nil, CostOptions{})
+	if _, err := svc.Sync(context.Background()); err != ErrCostExplorerDisabled {
+		t.Errorf("err = %v, want ErrCostExplorerDisabled", err)
+	}
+}
+
+func TestCostSummary(t *testing.T) {
+	repo := newCostRepo()
+	// $12/day, budget $300: by the 16th, 16 days = $192 MTD (64%), the
+	// 15 complete days forecast $12 * 30 = $360 (120%).
+	svc := NewCostService(repo, &fakeCostExplorer{}, CostOptions{
+		MonthlyBudgetUSD: 300,
+		AlertThresholds:  []int{50, 80, 100},
+		BackfillDays:     20,
+	})
+	svc.now = func() time.Time { return time.Date(2026, 6, 16, 12, 0, 0, 0, time.UTC) }
+	if _, err := svc.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := svc.Summary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.MonthToDateUSD != 192 {
+		t.Errorf("MTD = %v, want 192", sum.MonthToDateUSD)
+	}
+	if sum.DaysElapsed != 15 || sum.DaysInMonth != 30 {
+		t.Errorf("days = %d/%d", sum.DaysElapsed, sum.DaysInMonth)
+	}
+	if sum.ForecastUSD != 360 {
+		t.Errorf("forecast = %v, want 360", sum.ForecastUSD)
+	}
+	if sum.ActiveUsers30d != 40 || sum.CostPerActiveUser != 9 {
+		t.Errorf("per user = %v over %d users, want 9 over 40", sum.CostPerActiveUser, sum.ActiveUsers30d)
+	}
+	if len(sum.ByService) != 2 || sum.ByService[0].AmountUSD != 160 {
+		t.Errorf("by service = %+v", sum.ByService)
+	}
+
+	ids := map[string]models.HealthStatus{}
+	for _, a := range sum.Alerts {
+		ids[a.ID] = a.Severity
+	}
+	if len(ids) != 2 || ids["cost-budget-50"] != models.HealthStatusDegraded || ids["cost-forecast-over-budget"] != models.HealthStatusDegraded {
+		t.Errorf("alerts = %v, want the 50%% threshold and the forecast warning", ids)
+	}
+}
+
+func TestCostBudgetAlerts(t *testing.T) {
+	now := time.Now()
+	over := costBudgetAlerts(&CostSummary{BudgetUSD: 100, MonthToDateUSD: 110, BudgetUsedPct: 110, ForecastPct: 200}, []int{50, 80, 100}, now)
+	if len(over) != 1 || over[0].ID != "cost-budget-100" || over[0].Severity != models.HealthStatusCritical {
+		t.Errorf("over budget alerts = %+v", over)
+	}
+	if got := costBudgetAlerts(&CostSummary{BudgetUsedPct: 500}, []int{50}, now); len(got) != 0 {
+		t.Errorf("no budget should mean no alerts, got %+v", got)
+	}
+	if got := costBudgetAlerts(&CostSummary{BudgetUSD: 100, BudgetUsedPct: 40, ForecastPct: 90}, []int{50, 80, 100}, now); len(got) != 0 {
+		t.Errorf("under every threshold, got %+v", got)
+	}
+}
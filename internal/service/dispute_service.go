@@ -0,0 +1,310 @@
+package service
+
+// dispute_service.go — payment disputes (chargebacks).
+//
+// Stripe's charge.dispute.* webhooks are mirrored into payment_disputes.
+// Evidence is submitted in the Stripe Dashboard; here we make sure finance
+// admins hear about a dispute when it opens, are reminded before the
+// evidence deadline, and learn the outcome — and the financial overview
+// nets closed disputes out of revenue.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	stripe "github.com/stripe/stripe-go/v76"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/auth"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var ErrDisputeNotFound = apperr.NotFound("dispute not found")
+
+// disputeReminderDays are the days before the evidence deadline at which
+// finance admins are reminded, nearest first.
+var disputeReminderDays = []int{1, 3, 7}
+
+type disputeMailer interface {
+	IsEnabled() bool
+	SendDisputeAlertEmail(to, firstName, subject, heading, message, detailsURL string) error
+}
+
+// DisputeService records disputes and keeps finance admins informed.
+type DisputeService struct {
+	repo       repository.DisputeRepository
+	mailer     disputeMailer
+	detailsURL string
+	now        func() time.Time
+}
+
+// NewDisputeService creates the dispute service. email may be nil; alerts
+// are then only logged.
+func NewDisputeService(repo repository.DisputeRepository, email *EmailService, appURL string) *DisputeService {
+	s := &DisputeService{repo: repo, detailsURL: appURL + "/admin/financials#disputes", now: time.Now}
+	if email != nil {
+		s.mailer = email
+	}
+	return s
+}
+
+// disputeClosed reports whether a Stripe dispute status is final.
+func disputeClosed(status string) bool {
+	return status == "won" || status == "lost" || status == "warning_closed"
+}
+
+// disputeFromStripe maps a webhook's dispute onto our row. Stripe doesn't
+// timestamp evidence submission or closure, so `now` stands in for both.
+func disputeFromStripe(dp *stripe.Dispute, now time.Time) *repository.Dispute {
+	d := &repository.Dispute{
+		StripeDisputeID: dp.ID,
+		AmountCents:     dp.Amount,
+		Currency:        strings.ToUpper(string(dp.Currency)),
+		Reason:          string(dp.Reason),
+		Status:          string(dp.Status),
+		OpenedAt:        time.Unix(dp.Created, 0),
+	}
+	if dp.Charge != nil {
+		d.StripeChargeID = dp.Charge.ID
+		if dp.Charge.Invoice != nil {
+			d.StripeInvoiceID = dp.Charge.Invoice.ID
+			d.OrganizationID = organizationIDFromInvoice(dp.Charge.Invoice)
+		}
+	}
+	if dp.PaymentIntent != nil {
+		d.StripePaymentIntentID = dp.PaymentIntent.ID
+	}
+	for _, bt := range dp.BalanceTransactions {
+		if bt != nil {
+			d.BalanceNetCents += bt.Net
+		}
+	}
+	if e := dp.EvidenceDetails; e != nil {
+		if e.DueBy != 0 {
+			due := time.Unix(e.DueBy, 0)
+			d.EvidenceDueBy = &due
+		}
+		if e.SubmissionCount > 0 {
+			d.EvidenceSubmittedAt = &now
+		}
+	}
+	if disputeClosed(d.Status) {
+		d.ClosedAt = &now
+	}
+	return d
+}
+
+// Apply records a dispute from a webhook and alerts finance admins when
+// it opens, escalates from an inquiry to a chargeback, or closes.
+func (s *DisputeService) Apply(ctx context.Context, d *repository.Dispute) error {
+	prev, err := s.repo.Upsert(ctx, d)
+	if err != nil {
+		return fmt.Errorf("record dispute %s: %w", d.StripeDisputeID, err)
+	}
+	log.Printf("[DISPUTE] %s status %q -> %q amount=%d %s", d.StripeDisputeID, prev, d.Status, d.AmountCents, d.Currency)
+	amount := formatInvoiceMoney(d.AmountCents, d.Currency)
+	switch {
+	case prev == "":
+		kind := "Payment disputed"
+		if strings.HasPrefix(d.Status, "warning_") {
+			kind = "Payment inquiry opened"
+		}
+		s.notify(ctx, fmt.Sprintf("%s (%s)", kind, amount), kind,
+			fmt.Sprintf("A %s payment was disputed (%s, reason: %s).%s", amount, d.StripeDisputeID,
+				disputeReasonText(d.Reason), disputeDeadlineText(d)))
+		// The alert already gives the deadline; skip reminders it covers.
+		if d.EvidenceDueBy != nil {
+			if step := disputeReminderStep(d.EvidenceDueBy.Sub(s.now())); step > 0 {
+				if err := s.repo.MarkReminded(ctx, d.ID, step); err != nil {
+					log.Printf("[DISPUTE] %s: mark reminded: %v", d.StripeDisputeID, err)
+				}
+			}
+		}
+	case prev == d.Status:
+	case disputeClosed(d.Status):
+		outcome := map[string]string{"won": "won", "lost": "lost", "warning_closed": "closed without a chargeback"}[d.Status]
+		s.notify(ctx, fmt.Sprintf("Dispute %s (%s)", outcome, amount), "Dispute "+outcome,
+			fmt.Sprintf("The dispute on a %s payment (%s) was %s.", amount, d.StripeDisputeID, outcome))
+	case strings.HasPrefix(prev, "warning_") && !strings.HasPrefix(d.Status, "warning_"):
+		s.notify(ctx, fmt.Sprintf("Inquiry escalated to a chargeback (%s)", amount), "Inquiry escalated",
+			fmt.Sprintf("The inquiry on a %s payment (%s) is now a chargeback.%s", amount, d.StripeDisputeID, disputeDeadlineText(d)))
+	}
+	return nil
+}
+
+func disputeReasonText(reason string) string {
+	if reason == "" {
+		return "not given"
+	}
+	return strings.ReplaceAll(reason, "_", " ")
+}
+
+func disputeDeadlineText(d *repository.Dispute) string {
+	if d.EvidenceDueBy == nil {
+		return " No response is possible for this dispute."
+	}
+	return fmt.Sprintf(" Evidence is due by %s; submit it in the Stripe Dashboard.",
+		d.EvidenceDueBy.UTC().Format("Mon Jan 2, 2006 15:04 MST"))
+}
+
+// SendReminders reminds finance admins about disputes still awaiting
+// evidence 7, 3 and 1 days before the deadline, once per step. Returns
+// the number of reminders sent.
+func (s *DisputeService) SendReminders(ctx context.Context) (int, error) {
+	now := s.now()
+	furthest := disputeReminderDays[len(disputeReminderDays)-1]
+	due, err := s.repo.AwaitingEvidence(ctx, now.Add(time.Duration(furthest)*24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for i := range due {
+		d := &due[i]
+		step := disputeReminderStep(d.EvidenceDueBy.Sub(now))
+		if step == 0 || (d.LastReminderDays != nil && *d.LastReminderDays <= step) {
+			continue
+		}
+		left := "is overdue"
+		if hours := int(d.EvidenceDueBy.Sub(now).Hours()); hours >= 0 {
+			left = fmt.Sprintf("is due in %d hours", hours)
+			if hours >= 48 {
+				left = fmt.Sprintf("is due in %d days", hours/24)
+			}
+		}
+		amount := formatInvoiceMoney(d.AmountCents, d.Currency)
+		s.notify(ctx, fmt.Sprintf("Dispute evidence %s (%s)", left, amount), "Dispute evidence "+left,
+			fmt.Sprintf("No evidence has been submitted for the %s dispute %s%s.%s", amount, d.StripeDisputeID,
+				disputeCustomerText(d), disputeDeadlineText(d)))
+		if err := s.repo.MarkReminded(ctx, d.ID, step); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// disputeReminderStep is the reminder due with `left` before the
+// deadline: the nearest step not yet passed, or 0 if none is due.
+func disputeReminderStep(left time.Duration) int {
+	for _, days := range disputeReminderDays {
+		if left <= time.Duration(days)*24*time.Hour {
+			return days
+		}
+	}
+	return 0
+}
+
+func disputeCustomerText(d *repository.Dispute) string {
+	if d.Customer == "" {
+		return ""
+	}
+	return " (" + d.Customer + ")"
+}
+
+// notify emails every active admin whose role can see Financials.
+// Failures are logged; a missed alert never fails the webhook.
+func (s *DisputeService) notify(ctx context.Context, subject, heading, message string) {
+	if s.mailer == nil || !s.mailer.IsEnabled() {
+		log.Printf("[DISPUTE] email disabled, not sent: %s", subject)
+		return
+	}
+	admins, err := s.repo.ActiveAdmins(ctx)
+	if err != nil {
+		log.Printf("[DISPUTE] list admins for %q: %v", subject, err)
+		return
+	}
+	for _, a := range admins {
+		if auth.Matrix(models.SystemRole(a.SystemRole), "financials") == auth.LevelNone {
+			continue
+		}
+		if err := s.mailer.SendDisputeAlertEmail(a.Email, a.FirstName, subject, heading, message, s.detailsURL); err != nil {
+			log.Printf("[DISPUTE] alert %q to %s: %v", subject, a.Email, err)
+		}
+	}
+}
+
+// List returns disputes for the admin view.
+func (s *DisputeService) List(ctx context.Context, f repository.DisputeFilter) ([]repository.Dispute, int, error) {
+	return s.repo.List(ctx, f)
+}
+
+// Get returns one dispute.
+func (s *DisputeService) Get(ctx context.Context, id uuid.UUID) (*repository.Dispute, error) {
+	d, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDisputeNotFound
+	}
+	return d, nil
+}
+
+// MarkEvidenceSubmitted records (or clears) that evidence went in via the
+// Stripe Dashboard, which stops reminders. Stripe's next dispute update
+// also sets it.
+func (s *DisputeService) MarkEvidenceSubmitted(ctx context.Context, id uuid.UUID, submitted bool) (*repository.Dispute, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	var at *time.Time
+	if submitted {
+		now := s.now()
+		at = &now
+	}
+	if err := s.repo.SetEvidenceSubmitted(ctx, id, at); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// SetNotes replaces the finance team's notes on a dispute.
+func (s *DisputeService) SetNotes(ctx context.Context, id uuid.UUID, notes string) (*repository.Dispute, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetNotes(ctx, id, strings.TrimSpace(notes)); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// DisputeReminderScheduler runs SendReminders hourly.
+type DisputeReminderScheduler struct {
+	svc  *DisputeService
+	jobs *JobLocker
+}
+
+func NewDisputeReminderScheduler(svc *DisputeService, jobs *JobLocker) *DisputeReminderScheduler {
+	return &DisputeReminderScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *DisputeReminderScheduler) Start(ctx context.Context) {
+	log.Println("Dispute reminder scheduler started")
+	check := func() {
+		s.jobs.RunOnce(ctx, "dispute_reminders", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			if n, err := s.svc.SendReminders(ctx); err != nil {
+				log.Printf("Dispute reminders: tick failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Dispute reminders: sent %d", n)
+			}
+		})
+	}
+	check()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Dispute reminder scheduler stopped")
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	stripe "github.com/stripe/stripe-go/v76"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeDisputeRepo struct {
+	repository.DisputeRepository
+	disputes map[string]*repository.Dispute
+	admins   []repository.AdminRecipient
+}
+
+func (f *fakeDisputeRepo) Upsert(ctx context.Context, d *repository.Dispute) (string, error) {
+	prev, ok := f.disputes[d.StripeDisputeID]
+	if !ok {
+		d.ID = uuid.New()
+		cp := *d
+		f.disputes[d.StripeDisputeID] = &cp
+		return "", nil
+	}
+	status := prev.Status
+	d.ID = prev.ID
+	prev.Status, prev.ClosedAt, prev.EvidenceDueBy = d.Status, d.ClosedAt, d.EvidenceDueBy
+	return status, nil
+}
+
+func (f *fakeDisputeRepo) AwaitingEvidence(ctx context.Context, before time.Time) ([]repository.Dispute, error) {
+	var out []repository.Dispute
+	for _, d := range f.disputes {
+		if d.ClosedAt == nil && d.EvidenceSubmittedAt == nil && d.EvidenceDueBy != nil && d.EvidenceDueBy.Before(before) {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDisputeRepo) MarkReminded(ctx context.Context, id uuid.UUID, days int) error {
+	for _, d := range f.disputes {
+		if d.ID == id {
+			d.LastReminderDays = &days
+		}
+	}
+	return nil
+}
+
+func (f *fakeDisputeRepo) ActiveAdmins(ctx context.Context) ([]repository.AdminRecipient, error) {
+	return f.admins, nil
+}
+
+type fakeDisputeMailer struct {
+	sent []string // "to: subject"
+}
+
+func (f *fakeDisputeMailer) IsEnabled() bool { return true }
+
+func (f *fakeDisputeMailer) SendDisputeAlertEmail(to, firstName, subject, heading, message, detailsURL string) error {
+	f.sent = append(f.sent, to+": "+subject)
+	return nil
+}
+
+func newDisputeTest(now time.Time) (*DisputeService, *fakeDisputeRepo, *fakeDisputeMailer) {
+	repo := &fakeDisputeRepo{
+		disputes: map[string]*repository.Dispute{},
+		admins: []repository.AdminRecipient{
+			{Email: "owner@example.com", SystemRole: "super_admin"},
+			{Email: "partner@example.com", SystemRole: "partner"},
+			{Email: "support@example.com", SystemRole: "support"},
+		},
+	}
+	mailer := &fakeDisputeMailer{}
+	svc := NewDisputeService(repo, nil, "https://app.example.com")
+	svc.mailer = mailer
+	svc.now = func() time.Time { return now }
+	return svc, repo, mailer
+}
+
+func TestDisputeFromStripe(t *testing.T) {
+	var dp stripe.Dispute
+	err := json.Unmarshal([]byte(`{
+		"id": "du_1", "amount": 999, "currency": "usd", "reason": "fraudulent", "status": "needs_response",
+		"created": 1790000000, "charge": "ch_1", "payment_intent": "pi_1",
+		"balance_transactions": [{"id": "txn_1", "net": -2499}],
+		"evidence_details": {"due_by": 1791000000, "submission_count": 0}
+	}`), &dp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	d := disputeFromStripe(&dp, now)
+	if d.StripeChargeID != "ch_1" || d.StripePaymentIntentID != "pi_1" || d.Currency != "USD" || d.BalanceNetCents != -2499 {
+		t.Errorf("mapped %+v", d)
+	}
+	if d.EvidenceDueBy == nil || d.EvidenceDueBy.Unix() != 1791000000 || d.EvidenceSubmittedAt != nil || d.ClosedAt != nil {
+		t.Errorf("evidence due %v submitted %v closed %v", d.EvidenceDueBy, d.EvidenceSubmittedAt, d.ClosedAt)
+	}
+
+	dp.Status = stripe.DisputeStatusLost
+	dp.EvidenceDetails.SubmissionCount = 1
+	d = disputeFromStripe(&dp, now)
+	if d.ClosedAt == nil || d.EvidenceSubmittedAt == nil {
+		t.Errorf("lost dispute: closed %v submitted %v", d.ClosedAt, d.EvidenceSubmittedAt)
+	}
+}
+
+func TestApplyDisputeNotifiesFinanceAdmins(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	svc, repo, mailer := newDisputeTest(now)
+	ctx := context.Background()
+	due := now.Add(10 * 24 * time.Hour)
+	d := &repository.Dispute{StripeDisputeID: "du_1", AmountCents: 999, Currency: "USD", Status: "needs_response", EvidenceDueBy: &due}
+
+	if err := svc.Apply(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.sent) != 2 || !strings.HasPrefix(mailer.sent[0], "owner@example.com: Payment disputed") {
+		t.Fatalf("opened alerts = %v", mailer.sent)
+	}
+	for _, s := range mailer.sent {
+		if strings.HasPrefix(s, "support@") {
+			t.Errorf("support admin without Financials access was alerted: %v", s)
+		}
+	}
+
+	redelivered := *d
+	if err := svc.Apply(ctx, &redelivered); err != nil || len(mailer.sent) != 2 {
+		t.Errorf("redelivery sent %d alerts (err %v)", len(mailer.sent)-2, err)
+	}
+
+	won := *d
+	won.Status = "won"
+	won.ClosedAt = &now
+	if err := svc.Apply(ctx, &won); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.sent) != 4 || !strings.Contains(mailer.sent[2], "Dispute won") {
+		t.Errorf("closed alerts = %v", mailer.sent[2:])
+	}
+	if repo.disputes["du_1"].LastReminderDays != nil {
+		t.Error("reminder step marked for a deadline 10 days out")
+	}
+}
+
+func TestDisputeReminders(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	svc, repo, mailer := newDisputeTest(now)
+	ctx := context.Background()
+	due := now.Add(6 * 24 * time.Hour)
+	repo.disputes["du_1"] = &repository.Dispute{ID: uuid.New(), StripeDisputeID: "du_1", AmountCents: 500, Currency: "USD", Status: "needs_response", EvidenceDueBy: &due}
+	submittedDue := now.Add(2 * 24 * time.Hour)
+	repo.disputes["du_2"] = &repository.Dispute{ID: uuid.New(), StripeDisputeID: "du_2", Status: "needs_response", EvidenceDueBy: &submittedDue, EvidenceSubmittedAt: &now}
+
+	steps := []struct {
+		at   time.Time
+		sent int
+		step int
+	}{
+		{now, 1, 7},
+		{now.Add(time.Hour), 0, 7},
+		{now.Add(3*24*time.Hour + time.Hour), 1, 3},
+		{now.Add(5*24*time.Hour + time.Hour), 1, 1},
+		{now.Add(7 * 24 * time.Hour), 0, 1},
+	}
+	for _, s := range steps {
+		svc.now = func() time.Time { return s.at }
+		before := len(mailer.sent)
+		n, err := svc.SendReminders(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != s.sent || len(mailer.sent)-before != 2*s.sent {
+			t.Errorf("at %v: %d reminders, %d emails", s.at, n, len(mailer.sent)-before)
+		}
+		if got := repo.disputes["du_1"].LastReminderDays; got == nil || *got != s.step {
+			t.Errorf("at %v: last reminder step %v, want %d", s.at, got, s.step)
+		}
+	}
+}
+
+func TestDisputeReminderStep(t *testing.T) {
+	cases := []struct {
+		left time.Duration
+		want int
+	}{
+		{8 * 24 * time.Hour, 0},
+		{7 * 24 * time.Hour, 7},
+		{4 * 24 * time.Hour, 7},
+		{3 * 24 * time.Hour, 3},
+		{20 * time.Hour, 1},
+		{-time.Hour, 1},
+	}
+	for _, c := range cases {
+		if got := disputeReminderStep(c.left); got != c.want {
+			t.Errorf("disputeReminderStep(%v) = %d, want %d", c.left, got, c.want)
+		}
+	}
+}
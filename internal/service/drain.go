@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Drain coordinates background work through a graceful shutdown.
+//
+// Shutdown has two phases. Begin marks the process as draining: schedulers
+// stop starting new ticks, task workers stop taking tasks, long loops
+// checkpoint at their next item boundary. Work already running keeps its
+// context until the drain deadline; only then is the hard context
+// cancelled, so anything still going stops and persists what it can
+// (tasks go back on the queue) instead of being killed with the process.
+//
+// A nil *Drain is valid and never drains: work contexts are the caller's.
+type Drain struct {
+	stopping chan struct{}
+	begin    sync.Once
+
+	hard       context.Context
+	cancelHard context.CancelFunc
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// NewDrain creates a Drain.
+func NewDrain() *Drain {
+	hard, cancel := context.WithCancel(context.Background())
+	return &Drain{
+		stopping:   make(chan struct{}),
+		hard:       hard,
+		cancelHard: cancel,
+		running:    map[string]int{},
+	}
+}
+
+// Go runs fn in a tracked goroutine; Wait waits for it. Panics are logged
+// and swallowed like the other fire-and-forget goroutines in the app.
+func (d *Drain) Go(name string, fn func()) {
+	if d == nil {
+		go fn()
+		return
+	}
+	d.wg.Add(1)
+	d.mu.Lock()
+	d.running[name]++
+	d.mu.Unlock()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[DRAIN] panic in %s: %v\n%s", name, r, debug.Stack())
+			}
+			d.mu.Lock()
+			if d.running[name]--; d.running[name] == 0 {
+				delete(d.running, name)
+			}
+			d.mu.Unlock()
+			d.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// Stopping is closed once the drain has begun.
+func (d *Drain) Stopping() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.stopping
+}
+
+// Draining reports whether the drain has begun; loops over many items
+// check it between items and leave the rest for another instance.
+func (d *Drain) Draining() bool {
+	if d == nil {
+		return false
+	}
+	select {
+	case <-d.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// WorkContext derives the context for one unit of work from ctx. It keeps
+// ctx's values but not its cancellation — the scheduler context is
+// cancelled as soon as shutdown starts — and is cancelled instead at the
+// drain deadline.
+func (d *Drain) WorkContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d == nil {
+		return context.WithCancel(ctx)
+	}
+	wctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(d.hard, cancel)
+	return wctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// HardStopped reports whether the drain deadline has passed, i.e. a work
+// context was cancelled by the drain rather than by its own timeout.
+func (d *Drain) HardStopped() bool {
+	return d != nil && d.hard.Err() != nil
+}
+
+// Begin starts the drain. Safe to call more than once.
+func (d *Drain) Begin() {
+	if d == nil {
+		return
+	}
+	d.begin.Do(func() { close(d.stopping) })
+}
+
+// Wait begins the drain and waits up to timeout for tracked goroutines.
+// Past the timeout it cancels every work context and allows grace for the
+// work to checkpoint. Returns the names still running at the end.
+func (d *Drain) Wait(timeout, grace time.Duration) []string {
+	if d == nil {
+		return nil
+	}
+	d.Begin()
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancelHard()
+		return nil
+	case <-time.After(timeout):
+	}
+	log.Printf("[DRAIN] Deadline reached; stopping %v", d.Running())
+	d.cancelHard()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(grace):
+		return d.Running()
+	}
+}
+
+// Running lists the tracked goroutines still running.
+func (d *Drain) Running() []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	names := make([]string, 0, len(d.running))
+	for n := range d.running {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDrainLetsWorkFinish(t *testing.T) {
+	d := NewDrain()
+	parent, cancelParent := context.WithCancel(context.Background())
+	finished := make(chan error, 1)
+	d.Go("export", func() {
+		ctx, cancel := d.WorkContext(parent)
+		defer cancel()
+		<-d.Stopping()
+		// The scheduler context is gone, the work context is not.
+		cancelParent()
+		select {
+		case <-ctx.Done():
+			finished <- ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			finished <- nil
+		}
+	})
+	if left := d.Wait(time.Second, time.Second); len(left) != 0 {
+		t.Fatalf("still running: %v", left)
+	}
+	if err := <-finished; err != nil {
+		t.Errorf("work context cancelled early: %v", err)
+	}
+	if !d.Draining() {
+		t.Error("Wait should begin the drain")
+	}
+}
+
+func TestDrainDeadlineCancelsWork(t *testing.T) {
+	d := NewDrain()
+	d.Go("stuck", func() {
+		ctx, cancel := d.WorkContext(context.Background())
+		defer cancel()
+		<-ctx.Done()
+	})
+	start := time.Now()
+	if left := d.Wait(10*time.Millisecond, time.Second); len(left) != 0 {
+		t.Fatalf("still running after grace: %v", left)
+	}
+	if !d.HardStopped() || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("hard stop = %v after %s", d.HardStopped(), time.Since(start))
+	}
+}
+
+func TestJobLockerSkipsWhileDraining(t *testing.T) {
+	d := NewDrain()
+	l := NewJobLocker(nil, d)
+	d.Begin()
+	if l.RunOnce(context.Background(), "sweep", time.Now(), time.Minute, func(context.Context) {}) {
+		t.Error("job started after drain began")
+	}
+}
+
+func TestTaskQueueRequeuesOnHardStop(t *testing.T) {
+	q := newTestTaskQueue(t)
+	d := NewDrain()
+	q.drain = d
+	ctx := context.Background()
+	q.Register(TaskType{Name: "export", MaxAttempts: 1, Handler: func(ctx context.Context, payload json.RawMessage) error {
+		d.Begin()
+		d.cancelHard()
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	_ = q.ensureGroup(ctx, "export")
+	if _, err := q.Enqueue(ctx, "export", nil); err != nil {
+		t.Fatal(err)
+	}
+	deliverOne(t, q, "export")
+	// Not a failure: back on the queue with its attempt unspent, even
+	// though MaxAttempts is 1.
+	if s := queueStats(t, q); s.Ready != 1 || s.InFlight != 0 || s.Dead != 0 || s.Retried != 0 {
+		t.Errorf("after hard stop: %+v", s)
+	}
+}
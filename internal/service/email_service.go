@@ -3,12 +3,16 @@ package service
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"html/template"
 	"log"
+	"mime"
+	"mime/multipart"
 	"net"
 	"net/smtp"
+	"net/textproto"
 	"strings"
 	"time"
 
@@ -65,8 +69,21 @@ func sanitizeHeader(value string) string {
 	return value
 }
 
+// EmailAttachment is a file attached to an outgoing email.
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
 // SendEmail sends an email with the given parameters
 func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
+	return s.SendEmailWithAttachments(to, subject, htmlBody)
+}
+
+// SendEmailWithAttachments sends an HTML email with files attached as a
+// multipart/mixed message. With no attachments it's the same as SendEmail.
+func (s *EmailService) SendEmailWithAttachments(to, subject, htmlBody string, attachments ...EmailAttachment) error {
 	if !s.cfg.Enabled {
 		log.Printf("[EMAIL] Skipping email to %s (SMTP disabled): %s", to, subject)
 		return nil
@@ -85,9 +102,13 @@ func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
 	msg.WriteString(fmt.Sprintf("To: %s\r\n", to))
 	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
 	msg.WriteString("MIME-Version: 1.0\r\n")
-	msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
-	msg.WriteString("\r\n")
-	msg.WriteString(htmlBody)
+	if len(attachments) == 0 {
+		msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
+		msg.WriteString("\r\n")
+		msg.WriteString(htmlBody)
+	} else if err := writeMultipartBody(&msg, htmlBody, attachments); err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
 
 	addr := fmt.Sprintf("%s:%s", s.cfg.Host, s.cfg.Port)
 
@@ -151,6 +172,45 @@ func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
 	return nil
 }
 
+// writeMultipartBody writes the Content-Type header and a multipart/mixed
+// body: the HTML part, then each attachment base64-encoded.
+func writeMultipartBody(msg *bytes.Buffer, htmlBody string, attachments []EmailAttachment) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\n", mw.Boundary()))
+	msg.WriteString("\r\n")
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {`text/html; charset="UTF-8"`}})
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write([]byte(htmlBody)); err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": sanitizeHeader(a.Filename)})},
+		})
+		if err != nil {
+			return err
+		}
+		// RFC 2045 caps encoded lines at 76 characters.
+		enc := base64.StdEncoding.EncodeToString(a.Data)
+		for len(enc) > 76 {
+			part.Write([]byte(enc[:76] + "\r\n"))
+			enc = enc[76:]
+		}
+		part.Write([]byte(enc + "\r\n"))
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	msg.Write(body.Bytes())
+	return nil
+}
+
 // --- Email template methods ---
 
 // SendWelcomeEmail sends a welcome email to a newly registered user
@@ -194,6 +254,20 @@ func (s *EmailService) SendPasswordResetEmail(to, firstName, resetURL string) er
 	return s.SendEmail(to, subject, body)
 }
 
+// SendEmailVerificationEmail sends the link that confirms a new or changed
+// address.
+func (s *EmailService) SendEmailVerificationEmail(to, firstName, verifyURL string) error {
+	subject := "MyCareCompanion - Confirm Your Email"
+	body, err := renderTemplate(emailVerificationTemplate, map[string]string{
+		"FirstName": firstName,
+		"VerifyURL": verifyURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render email verification email: %w", err)
+	}
+	return s.SendEmail(to, subject, body)
+}
+
 // SendAccountDeletionCodeEmail sends the 6-digit OTP that begins the
 // in-app account-deletion flow. ttlMinutes is the validity window we
 // want to communicate to the user (15 by default).
@@ -271,6 +345,137 @@ func (s *EmailService) SendFamilyMemberAddedEmail(to, firstName, familyName, rol
 	return s.SendEmail(to, subject, body)
 }
 
+// SendInvoiceEmail sends an invoice with its PDF attached.
+func (s *EmailService) SendInvoiceEmail(to, name, number, total string, pdf EmailAttachment) error {
+	subject := fmt.Sprintf("MyCareCompanion - Invoice %s", number)
+	if name == "" {
+		name = "there"
+	}
+	body, err := renderTemplate(invoiceTemplate, map[string]string{
+		"Name":   name,
+		"Number": number,
+		"Total":  total,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render invoice email: %w", err)
+	}
+	return s.SendEmailWithAttachments(to, subject, body, pdf)
+}
+
+// SendDisputeAlertEmail notifies a finance admin about a payment dispute:
+// opened, closed, or an evidence deadline coming up.
+func (s *EmailService) SendDisputeAlertEmail(to, firstName, subject, heading, message, detailsURL string) error {
+	if firstName == "" {
+		firstName = "there"
+	}
+	body, err := renderTemplate(disputeAlertTemplate, map[string]string{
+		"FirstName":  firstName,
+		"Heading":    heading,
+		"Message":    message,
+		"DetailsURL": detailsURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render dispute alert email: %w", err)
+	}
+	return s.SendEmail(to, "MyCareCompanion - "+subject, body)
+}
+
+// SendExitPackageEmail sends a parent the download link for the data
+// export built when their family's subscription was cancelled.
+func (s *EmailService) SendExitPackageEmail(to, firstName, familyName, downloadURL, expiresOn string) error {
+	if firstName == "" {
+		firstName = "there"
+	}
+	body, err := renderTemplate(exitPackageTemplate, map[string]string{
+		"FirstName":   firstName,
+		"FamilyName":  familyName,
+		"DownloadURL": downloadURL,
+		"ExpiresOn":   expiresOn,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render exit package email: %w", err)
+	}
+	return s.SendEmail(to, "MyCareCompanion - Your family's data export is ready", body)
+}
+
+// SendProviderInvitationEmail invites a school or ABA provider to record
+// logs for a child.
+func (s *EmailService) SendProviderInvitationEmail(to, organizationName, childFirstName, inviterName, acceptURL string) error {
+	body, err := renderTemplate(providerInvitationTemplate, map[string]string{
+		"OrganizationName": organizationName,
+		"ChildFirstName":   childFirstName,
+		"InviterName":      inviterName,
+		"AcceptURL":        acceptURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render provider invitation email: %w", err)
+	}
+	return s.SendEmail(to, fmt.Sprintf("MyCareCompanion - %s invited %s to share updates", inviterName, organizationName), body)
+}
+
+// SendAdminInvitationEmail sends an imported admin the link to choose
+// their password and sign in to the admin portal.
+func (s *EmailService) SendAdminInvitationEmail(to, firstName, inviteURL, expiresOn string) error {
+	body, err := renderTemplate(adminInvitationTemplate, map[string]string{
+		"FirstName": firstName,
+		"InviteURL": inviteURL,
+		"ExpiresOn": expiresOn,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render admin invitation email: %w", err)
+	}
+	return s.SendEmail(to, "MyCareCompanion - Your admin portal invitation", body)
+}
+
+// SendAccessReviewEmail asks a super admin to work through a quarter's
+// admin access review.
+func (s *EmailService) SendAccessReviewEmail(to, firstName, period, accounts, dueOn, reviewURL string) error {
+	body, err := renderTemplate(accessReviewTemplate, map[string]string{
+		"FirstName": firstName,
+		"Period":    period,
+		"Accounts":  accounts,
+		"DueOn":     dueOn,
+		"ReviewURL": reviewURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render access review email: %w", err)
+	}
+	return s.SendEmail(to, "MyCareCompanion - Admin access review for "+period, body)
+}
+
+// SendReportShareEmail sends a clinician or other recipient the link to
+// a report a caregiver shared with them.
+func (s *EmailService) SendReportShareEmail(to, recipientName, senderName, reportTitle, viewURL, expiresOn string) error {
+	body, err := renderTemplate(reportShareTemplate, map[string]string{
+		"RecipientName": recipientName,
+		"SenderName":    senderName,
+		"ReportTitle":   reportTitle,
+		"ViewURL":       viewURL,
+		"ExpiresOn":     expiresOn,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render report share email: %w", err)
+	}
+	return s.SendEmail(to, fmt.Sprintf("MyCareCompanion - %s shared a report with you", senderName), body)
+}
+
+// SendWeeklyDigestEmail sends a caregiver their week's logging digest.
+// streak and newBadges are left out when empty.
+func (s *EmailService) SendWeeklyDigestEmail(to, firstName, entries, days, streak, newBadges, settingsURL string) error {
+	body, err := renderTemplate(weeklyDigestTemplate, map[string]string{
+		"FirstName":   firstName,
+		"Entries":     entries,
+		"Days":        days,
+		"Streak":      streak,
+		"NewBadges":   newBadges,
+		"SettingsURL": settingsURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render weekly digest email: %w", err)
+	}
+	return s.SendEmail(to, "MyCareCompanion - Your week in review", body)
+}
+
 func renderTemplate(tmpl string, data map[string]string) (string, error) {
 	t, err := template.New("email").Parse(tmpl)
 	if err != nil {
@@ -369,6 +574,15 @@ var passwordResetTemplate = fmt.Sprintf(emailWrapper, `
     <p>If you didn't request this, you can safely ignore this email. Your password won't be changed.</p>
 `)
 
+var emailVerificationTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>Confirm Your Email</h2>
+    <p>Hi {{.FirstName}},</p>
+    <p>Please confirm this is your email address so care alerts, reports and family invitations reach you:</p>
+    <p><a href="{{.VerifyURL}}" class="btn" style="color: #ffffff;">Confirm Email</a></p>
+    <p>This link will expire in 48 hours. You can request a new one from Settings in the app.</p>
+    <p>If you didn't create a MyCareCompanion account or change your email, you can safely ignore this email.</p>
+`)
+
 var memberAddedTemplate = fmt.Sprintf(emailWrapper, `
     <h2>You've Been Added to a Family</h2>
     <p>Hi {{.FirstName}},</p>
@@ -377,6 +591,75 @@ var memberAddedTemplate = fmt.Sprintf(emailWrapper, `
     <p><a href="{{.AppURL}}" class="btn" style="color: #ffffff;">Open MyCareCompanion</a></p>
 `)
 
+var invoiceTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>Your Invoice</h2>
+    <p>Hi {{.Name}},</p>
+    <p>Thanks for your payment of <strong>{{.Total}}</strong>. Invoice <strong>{{.Number}}</strong> is attached as a PDF.</p>
+    <p>Keep it for your records or submit it with reimbursement and FSA/HSA claims. You can download your invoices any time from Billing in the app.</p>
+`)
+
+var disputeAlertTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>{{.Heading}}</h2>
+    <p>Hi {{.FirstName}},</p>
+    <p>{{.Message}}</p>
+    <p><a href="{{.DetailsURL}}" class="btn" style="color: #ffffff;">View Disputes</a></p>
+    <p style="font-size:0.85rem; color:#78716c;">You're receiving this because your admin role has access to Financials.</p>
+`)
+
+var exitPackageTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>Your Data Export Is Ready</h2>
+    <p>Hi {{.FirstName}},</p>
+    <p>Your MyCareCompanion subscription for {{.FamilyName}} has ended. Your family's care history belongs to you, so we've put together a complete copy: every log as a spreadsheet (CSV) and as JSON, plus the PDF of every report you generated.</p>
+    <p><a href="{{.DownloadURL}}" class="btn" style="color: #ffffff;">Download Your Data</a></p>
+    <p>The link works until <strong>{{.ExpiresOn}}</strong>. Any parent in your family can also download it from the app's billing page until then.</p>
+    <p style="font-size:0.85rem; color:#78716c;">Once your data leaves our system, you're responsible for storing it securely. Don't share it casually — it contains protected health information about your child.</p>
+`)
+
+var providerInvitationTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>You're Invited to Share Updates</h2>
+    <p>Hi {{.OrganizationName}} team,</p>
+    <p><strong>{{.InviterName}}</strong> has invited you to record updates about <strong>{{.ChildFirstName}}</strong> on MyCareCompanion, so the family sees how the day went at school or in therapy.</p>
+    <p>Sign in or create an account with this email address, then accept the invitation:</p>
+    <p><a href="{{.AcceptURL}}" class="btn" style="color: #ffffff;">Accept Invitation</a></p>
+    <p>You'll only be able to add the kinds of entries the family chose, for {{.ChildFirstName}} only, and the family can end your access at any time.</p>
+    <p><small>This invitation expires in 7 days.</small></p>
+`)
+
+var adminInvitationTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>You're Invited to the Admin Portal</h2>
+    <p>Hi {{.FirstName}},</p>
+    <p>An admin account has been created for you on the MyCareCompanion admin portal. Choose your password to finish setting it up:</p>
+    <p><a href="{{.InviteURL}}" class="btn" style="color: #ffffff;">Set Up Your Account</a></p>
+    <p>The link works once, until <strong>{{.ExpiresOn}}</strong>. If it has expired, ask whoever invited you for a new one.</p>
+    <p style="font-size:0.85rem; color:#78716c;">If you weren't expecting this, you can ignore this email; the account stays locked until the link is used.</p>
+`)
+
+var accessReviewTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>Admin Access Review: {{.Period}}</h2>
+    <p>Hi {{.FirstName}},</p>
+    <p>This quarter's admin access review is open. Please check each of the <strong>{{.Accounts}}</strong> admin accounts, with their role, permissions and last sign-in, and attest that it still needs its access or revoke it.</p>
+    <p><a href="{{.ReviewURL}}" class="btn" style="color: #ffffff;">Open the Review</a></p>
+    <p>The review is due by <strong>{{.DueOn}}</strong>. Any super admin can complete it; decisions are recorded permanently.</p>
+`)
+
+var reportShareTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>A Report Has Been Shared With You</h2>
+    <p>Hi {{.RecipientName}},</p>
+    <p><strong>{{.SenderName}}</strong> has shared a care report with you on MyCareCompanion: <strong>{{.ReportTitle}}</strong>.</p>
+    <p><a href="{{.ViewURL}}" class="btn" style="color: #ffffff;">View Report</a></p>
+    <p>The link works until <strong>{{.ExpiresOn}}</strong>. The family can see when it has been opened.</p>
+    <p style="font-size:0.85rem; color:#78716c;">This report contains protected health information. Please don't forward the link.</p>
+`)
+
+var weeklyDigestTemplate = fmt.Sprintf(emailWrapper, `
+    <h2>Your Week in Review</h2>
+    <p>Hi {{.FirstName}},</p>
+    <p>This week you logged <strong>{{.Entries}}</strong> entries on <strong>{{.Days}}</strong> of the last 7 days. Every entry helps build a clearer picture of your child's care.</p>
+    {{if .Streak}}<p>You're on a streak: <strong>{{.Streak}}</strong>.</p>{{end}}
+    {{if .NewBadges}}<p>New this week: <strong>{{.NewBadges}}</strong>.</p>{{end}}
+    <p style="font-size:0.85rem; color:#78716c;">You can turn off streaks and badges, or this email, in <a href="{{.SettingsURL}}">Settings</a>.</p>
+`)
+
 // --- Account deletion templates ---
 
 var accountDeletionCodeTemplate = fmt.Sprintf(emailWrapper, `
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrEmailVerifyTokenInvalid = apperr.Validation("invalid verification link")
+	ErrEmailVerifyTokenExpired = errors.New("verification link has expired")
+	ErrEmailVerifyTokenUsed    = errors.New("verification link has already been used")
+	ErrEmailAlreadyVerified    = apperr.Conflict("email address is already verified")
+	// ErrEmailVerifyResendLimited is returned when a user asks for links
+	// faster than the resend limits allow.
+	ErrEmailVerifyResendLimited = errors.New("too many verification emails requested")
+)
+
+const (
+	// Unverified users keep every feature for this long after signing up
+	// or changing their address.
+	emailVerifyGrace = 7 * 24 * time.Hour
+	emailVerifyTTL   = 48 * time.Hour
+	// Resend limits, counted from the links already sent.
+	emailVerifyCooldown   = time.Minute
+	emailVerifyDailyLimit = 5
+)
+
+// EmailVerificationStatus is what the app shows about a user's address.
+// Restricted is true once the grace period has passed unverified.
+type EmailVerificationStatus struct {
+	Email      string     `json:"email"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	Restricted bool       `json:"restricted"`
+}
+
+// emailVerificationMailer is the slice of EmailService verification needs.
+type emailVerificationMailer interface {
+	SendEmailVerificationEmail(to, firstName, verifyURL string) error
+}
+
+// EmailVerificationService sends verification links on signup and email
+// change, verifies them, and tracks the grace period before unverified
+// accounts are restricted (see middleware.RequireVerifiedEmail).
+type EmailVerificationService struct {
+	repo   repository.EmailVerificationRepository
+	mailer emailVerificationMailer
+	appURL string
+	now    func() time.Time
+}
+
+func NewEmailVerificationService(repo repository.EmailVerificationRepository, mailer emailVerificationMailer, appURL string) *EmailVerificationService {
+	return &EmailVerificationService{repo: repo, mailer: mailer, appURL: appURL, now: time.Now}
+}
+
+func hashEmailVerifyToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func emailVerificationStatus(st *repository.EmailVerificationState, now time.Time) *EmailVerificationStatus {
+	return &EmailVerificationStatus{
+		Email:      st.Email,
+		Verified:   st.VerifiedAt != nil,
+		VerifiedAt: st.VerifiedAt,
+		DueAt:      st.DueAt,
+		Restricted: st.VerifiedAt == nil && st.DueAt != nil && !now.Before(*st.DueAt),
+	}
+}
+
+// Start requires userID to verify their current address: a new grace
+// period begins, older links stop working, and a fresh link is emailed.
+// Called after signup and after the address changes.
+func (s *EmailVerificationService) Start(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.Require(ctx, userID, s.now().Add(emailVerifyGrace)); err != nil {
+		return err
+	}
+	st, err := s.repo.State(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		return ErrUserNotFound
+	}
+	return s.send(ctx, userID, st)
+}
+
+// send stores a new link for the user's current address and emails it.
+func (s *EmailVerificationService) send(ctx context.Context, userID uuid.UUID, st *repository.EmailVerificationState) error {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+	if err := s.repo.CreateToken(ctx, userID, st.Email, hashEmailVerifyToken(token), s.now().Add(emailVerifyTTL)); err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", s.appURL, token)
+	go func() {
+		if err := s.mailer.SendEmailVerificationEmail(st.Email, st.FirstName, verifyURL); err != nil {
+			log.Printf("[EMAIL] Failed to send verification email to %s: %v", st.Email, err)
+		}
+	}()
+	return nil
+}
+
+// Status returns userID's verification state.
+func (s *EmailVerificationService) Status(ctx context.Context, userID uuid.UUID) (*EmailVerificationStatus, error) {
+	st, err := s.repo.State(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, ErrUserNotFound
+	}
+	return emailVerificationStatus(st, s.now()), nil
+}
+
+// Resend emails a new link, at most once a minute and five times a day.
+// The grace period isn't extended.
+func (s *EmailVerificationService) Resend(ctx context.Context, userID uuid.UUID) (*EmailVerificationStatus, error) {
+	st, err := s.repo.State(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, ErrUserNotFound
+	}
+	if st.VerifiedAt != nil {
+		return nil, ErrEmailAlreadyVerified
+	}
+	now := s.now()
+	sent, last, err := s.repo.SendsSince(ctx, userID, now.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if sent >= emailVerifyDailyLimit {
+		return nil, fmt.Errorf("%w: limit is %d per day", ErrEmailVerifyResendLimited, emailVerifyDailyLimit)
+	}
+	if last != nil && now.Sub(*last) < emailVerifyCooldown {
+		return nil, fmt.Errorf("%w: wait a minute before asking again", ErrEmailVerifyResendLimited)
+	}
+	if err := s.send(ctx, userID, st); err != nil {
+		return nil, err
+	}
+	return emailVerificationStatus(st, now), nil
+}
+
+// Verify confirms the address a link was sent to. A link for an address
+// the user has since changed is invalid.
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) error {
+	t, err := s.repo.GetToken(ctx, hashEmailVerifyToken(token))
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrEmailVerifyTokenInvalid
+	}
+	if t.UsedAt != nil {
+		return ErrEmailVerifyTokenUsed
+	}
+	if s.now().After(t.ExpiresAt) {
+		return ErrEmailVerifyTokenExpired
+	}
+	ok, err := s.repo.MarkVerified(ctx, t)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrEmailVerifyTokenInvalid
+	}
+	log.Printf("[EMAIL-VERIFY] Verified email for user %s", t.UserID)
+	return nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeEmailVerificationRepo struct {
+	repository.EmailVerificationRepository
+	state  *repository.EmailVerificationState
+	tokens map[string]*repository.EmailVerificationToken
+	sends  []time.Time
+}
+
+func (f *fakeEmailVerificationRepo) State(ctx context.Context, userID uuid.UUID) (*repository.EmailVerificationState, error) {
+	return f.state, nil
+}
+
+func (f *fakeEmailVerificationRepo) Require(ctx context.Context, userID uuid.UUID, dueAt time.Time) error {
+	f.state.VerifiedAt, f.state.DueAt = nil, &dueAt
+	for _, t := range f.tokens {
+		if t.UsedAt == nil {
+			t.UsedAt = &dueAt
+		}
+	}
+	return nil
+}
+
+func (f *fakeEmailVerificationRepo) CreateToken(ctx context.Context, userID uuid.UUID, email, tokenHash string, expiresAt time.Time) error {
+	f.tokens[tokenHash] = &repository.EmailVerificationToken{ID: uuid.New(), UserID: userID, Email: email, ExpiresAt: expiresAt}
+	return nil
+}
+
+func (f *fakeEmailVerificationRepo) SendsSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, *time.Time, error) {
+	var n int
+	var last *time.Time
+	for i, at := range f.sends {
+		if !at.Before(since) {
+			n++
+			last = &f.sends[i]
+		}
+	}
+	return n, last, nil
+}
+
+func (f *fakeEmailVerificationRepo) GetToken(ctx context.Context, tokenHash string) (*repository.EmailVerificationToken, error) {
+	return f.tokens[tokenHash], nil
+}
+
+func (f *fakeEmailVerificationRepo) MarkVerified(ctx context.Context, t *repository.EmailVerificationToken) (bool, error) {
+	if t.UsedAt != nil || !strings.EqualFold(t.Email, f.state.Email) {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt, f.state.VerifiedAt, f.state.DueAt = &now, &now, nil
+	return true, nil
+}
+
+type fakeVerifyMailer struct {
+	urls chan string
+}
+
+func (f *fakeVerifyMailer) SendEmailVerificationEmail(to, firstName, verifyURL string) error {
+	f.urls <- verifyURL
+	return nil
+}
+
+func newTestEmailVerification(now time.Time) (*EmailVerificationService, *fakeEmailVerificationRepo, *fakeVerifyMailer) {
+	repo := &fakeEmailVerificationRepo{
+		state:  &repository.EmailVerificationState{Email: "pat@example.com", FirstName: "Pat"},
+		tokens: map[string]*repository.EmailVerificationToken{},
+	}
+	mailer := &fakeVerifyMailer{urls: make(chan string, 4)}
+	svc := NewEmailVerificationService(repo, mailer, "https://app.test")
+	svc.now = func() time.Time { return now }
+	return svc, repo, mailer
+}
+
+func sentToken(t *testing.T, m *fakeVerifyMailer) string {
+	t.Helper()
+	select {
+	case raw := <-m.urls:
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query().Get("token")
+	case <-time.After(time.Second):
+		t.Fatal("no verification email sent")
+		return ""
+	}
+}
+
+func TestEmailVerificationStartAndVerify(t *testing.T) {
+	now := time.Now()
+	svc, repo, mailer := newTestEmailVerification(now)
+	ctx := context.Background()
+
+	if err := svc.Start(ctx, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	token := sentToken(t, mailer)
+	st, _ := svc.Status(ctx, uuid.Nil)
+	if st.Verified || st.Restricted || st.DueAt == nil || !st.DueAt.Equal(now.Add(emailVerifyGrace)) {
+		t.Errorf("after start: %+v", st)
+	}
+
+	if err := svc.Verify(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := svc.Status(ctx, uuid.Nil); !st.Verified || st.DueAt != nil {
+		t.Errorf("after verify: %+v", st)
+	}
+	if err := svc.Verify(ctx, token); !errors.Is(err, ErrEmailVerifyTokenUsed) {
+		t.Errorf("reuse: err = %v, want ErrEmailVerifyTokenUsed", err)
+	}
+	if err := svc.Verify(ctx, "nope"); !errors.Is(err, ErrEmailVerifyTokenInvalid) {
+		t.Errorf("unknown: err = %v, want ErrEmailVerifyTokenInvalid", err)
+	}
+	if _, err := svc.Resend(ctx, uuid.Nil); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("resend when verified: err = %v", err)
+	}
+
+	// Changing the address voids the old link and restarts the grace period.
+	if err := svc.Start(ctx, uuid.Nil); err != nil {
+		t.Fatal(err)
+	}
+	stale := sentToken(t, mailer)
+	repo.state.Email = "pat@new.example.com"
+	if err := svc.Verify(ctx, stale); !errors.Is(err, ErrEmailVerifyTokenInvalid) {
+		t.Errorf("link for old address: err = %v, want ErrEmailVerifyTokenInvalid", err)
+	}
+}
+
+func TestEmailVerificationExpiredLink(t *testing.T) {
+	now := time.Now()
+	svc, _, mailer := newTestEmailVerification(now)
+	if err := svc.Start(context.Background(), uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	token := sentToken(t, mailer)
+	svc.now = func() time.Time { return now.Add(emailVerifyTTL + time.Minute) }
+	if err := svc.Verify(context.Background(), token); !errors.Is(err, ErrEmailVerifyTokenExpired) {
+		t.Errorf("err = %v, want ErrEmailVerifyTokenExpired", err)
+	}
+}
+
+func TestEmailVerificationStatusRestricted(t *testing.T) {
+	now := time.Now()
+	due := now.Add(-time.Hour)
+	st := emailVerificationStatus(&repository.EmailVerificationState{Email: "a@b.c", DueAt: &due}, now)
+	if !st.Restricted {
+		t.Error("past the grace period should be restricted")
+	}
+	// Accounts with no grace period recorded are never restricted.
+	if st := emailVerificationStatus(&repository.EmailVerificationState{Email: "a@b.c"}, now); st.Restricted {
+		t.Error("no due date should not be restricted")
+	}
+}
+
+func TestEmailVerificationResendLimits(t *testing.T) {
+	now := time.Now()
+	svc, repo, _ := newTestEmailVerification(now)
+	ctx := context.Background()
+
+	repo.sends = []time.Time{now.Add(-30 * time.Second)}
+	if _, err := svc.Resend(ctx, uuid.New()); !errors.Is(err, ErrEmailVerifyResendLimited) {
+		t.Errorf("within cooldown: err = %v", err)
+	}
+
+	repo.sends = nil
+	for i := 0; i < emailVerifyDailyLimit; i++ {
+		repo.sends = append(repo.sends, now.Add(-time.Duration(i+1)*time.Hour))
+	}
+	if _, err := svc.Resend(ctx, uuid.New()); !errors.Is(err, ErrEmailVerifyResendLimited) {
+		t.Errorf("over daily limit: err = %v", err)
+	}
+
+	repo.sends = repo.sends[1:]
+	if _, err := svc.Resend(ctx, uuid.New()); err != nil {
+		t.Errorf("under limits: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+// Engagement scoring. The score is 0-100, built from four parts:
+//
+//	frequency  40  logging days in the last 14 (10+ days scores full)
+//	trend      20  last 14 days' entries against the 14 before (flat or up scores full)
+//	breadth    20  log types + chat/reports/correlations used in 30 days (5+ scores full)
+//	recency    20  days since the last activity (today/yesterday full, 0 after 30)
+const (
+	engagementHourUTC       = 3
+	engagementRetentionDays = 120
+)
+
+// Churn-risk list defaults.
+const (
+	churnCompareDays = 14 // score drop is measured against this many days earlier
+	churnRenewalDays = 14 // renewal within this many days
+	churnMinDrop     = 15 // points
+	churnLowScore    = 25 // families this disengaged are listed without a drop
+	churnLimit       = 500
+)
+
+// engagementScore scores one family's signals as of day.
+func engagementScore(s repository.EngagementSignals, day time.Time) int {
+	frequency := 40 * math.Min(float64(s.ActiveDays14d), 10) / 10
+
+	var trend float64
+	switch {
+	case s.Entries14d == 0:
+	case s.EntriesPrev14d == 0 || s.Entries14d >= s.EntriesPrev14d:
+		trend = 20
+	default:
+		trend = 20 * float64(s.Entries14d) / float64(s.EntriesPrev14d)
+	}
+
+	breadth := 20 * math.Min(float64(s.Features30d), 5) / 5
+
+	var recency float64
+	if s.LastActiveOn != nil {
+		since := utcDay(day).Sub(utcDay(*s.LastActiveOn)).Hours() / 24
+		switch {
+		case since <= 1:
+			recency = 20
+		case since < 30:
+			recency = 20 * (30 - since) / 29
+		}
+	}
+
+	return int(math.Round(frequency + trend + breadth + recency))
+}
+
+// ChurnRiskList is the churn-risk segment as of the latest scored day.
+type ChurnRiskList struct {
+	ScoreDate *time.Time                   `json:"score_date"`
+	Filter    ChurnRiskParams              `json:"filter"`
+	Families  []repository.ChurnRiskFamily `json:"families"`
+}
+
+// ChurnRiskParams are the tunable churn-risk thresholds; zero values take
+// the defaults.
+type ChurnRiskParams struct {
+	RenewalDays int `json:"renewal_days"`
+	MinDrop     int `json:"min_drop"`
+	LowScore    int `json:"low_score"`
+	CompareDays int `json:"compare_days"`
+}
+
+// EngagementService scores families nightly and builds the churn-risk list.
+type EngagementService struct {
+	repo repository.EngagementRepository
+	now  func() time.Time
+}
+
+func NewEngagementService(repo repository.EngagementRepository) *EngagementService {
+	return &EngagementService{repo: repo, now: time.Now}
+}
+
+// Score computes and stores every family's score for day, then prunes
+// scores past the retention window. Returns how many families were scored.
+func (s *EngagementService) Score(ctx context.Context, day time.Time) (int, error) {
+	day = utcDay(day)
+	signals, err := s.repo.Signals(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+	scores := make([]repository.EngagementScore, len(signals))
+	for i, sig := range signals {
+		scores[i] = repository.EngagementScore{EngagementSignals: sig, Score: engagementScore(sig, day)}
+	}
+	if err := s.repo.SaveScores(ctx, day, scores); err != nil {
+		return 0, err
+	}
+	if n, err := s.repo.Prune(ctx, day.AddDate(0, 0, -engagementRetentionDays)); err != nil {
+		log.Printf("Engagement: prune failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Engagement: pruned %d old scores", n)
+	}
+	return len(scores), nil
+}
+
+// ChurnRisk returns families whose score is dropping (or already low) with
+// a paid renewal coming up, as of the latest scored day.
+func (s *EngagementService) ChurnRisk(ctx context.Context, p ChurnRiskParams) (*ChurnRiskList, error) {
+	if p.RenewalDays <= 0 {
+		p.RenewalDays = churnRenewalDays
+	}
+	if p.MinDrop <= 0 {
+		p.MinDrop = churnMinDrop
+	}
+	if p.LowScore <= 0 {
+		p.LowScore = churnLowScore
+	}
+	if p.CompareDays <= 0 {
+		p.CompareDays = churnCompareDays
+	}
+	out := &ChurnRiskList{Filter: p, Families: []repository.ChurnRiskFamily{}}
+
+	day, err := s.repo.LatestScoreDate(ctx)
+	if err != nil || day == nil {
+		return out, err
+	}
+	out.ScoreDate = day
+	families, err := s.repo.ChurnRisk(ctx, repository.ChurnRiskFilter{
+		Day:         *day,
+		CompareDays: p.CompareDays,
+		RenewalDays: p.RenewalDays,
+		MinDrop:     p.MinDrop,
+		LowScore:    p.LowScore,
+		Limit:       churnLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if families != nil {
+		out.Families = families
+	}
+	return out, nil
+}
+
+// EngagementScheduler scores the previous UTC day once a day.
+type EngagementScheduler struct {
+	svc  *EngagementService
+	jobs *JobLocker
+}
+
+func NewEngagementScheduler(svc *EngagementService, jobs *JobLocker) *EngagementScheduler {
+	return &EngagementScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *EngagementScheduler) Start(ctx context.Context) {
+	log.Printf("Engagement scheduler started (%02d:00 UTC daily)", engagementHourUTC)
+	yesterday := utcDay(time.Now()).AddDate(0, 0, -1)
+	if latest, err := s.svc.repo.LatestScoreDate(ctx); err == nil && (latest == nil || utcDay(*latest).Before(yesterday)) {
+		s.jobs.RunOnce(ctx, "engagement_scores_boot", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			if _, err := s.svc.Score(ctx, yesterday); err != nil {
+				log.Printf("Engagement: startup scoring failed: %v", err)
+			}
+		})
+	}
+	for {
+		next := nextUTCRunAt(time.Now().UTC(), engagementHourUTC, 0)
+		select {
+		case <-ctx.Done():
+			log.Println("Engagement scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "engagement_scores", next, 24*time.Hour, func(ctx context.Context) {
+				day := next.AddDate(0, 0, -1)
+				n, err := s.svc.Score(ctx, day)
+				if err != nil {
+					log.Printf("Engagement: scoring %s failed: %v", day.Format("2006-01-02"), err)
+					return
+				}
+				log.Printf("Engagement: scored %d families for %s", n, day.Format("2006-01-02"))
+			})
+		}
+	}
+}
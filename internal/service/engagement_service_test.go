@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+func TestEngagementScore(t *testing.T) {
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) *time.Time {
+		d := day.AddDate(0, 0, -days)
+		return &d
+	}
+	tests := []struct {
+		name string
+		sig  repository.EngagementSignals
+		want int
+	}{
+		{"inactive", repository.EngagementSignals{}, 0},
+		{"daily, growing, broad", repository.EngagementSignals{
+			ActiveDays14d: 14, Entries14d: 60, EntriesPrev14d: 40, Features30d: 7, LastActiveOn: ago(0),
+		}, 100},
+		{"halved and slipping", repository.EngagementSignals{
+			ActiveDays14d: 5, Entries14d: 10, EntriesPrev14d: 20, Features30d: 2, LastActiveOn: ago(4),
+		}, 20 + 10 + 8 + 18},
+		{"only used chat a month ago", repository.EngagementSignals{
+			Features30d: 1, LastActiveOn: ago(29),
+		}, 4 + 1},
+	}
+	for _, tt := range tests {
+		if got := engagementScore(tt.sig, day); got != tt.want {
+			t.Errorf("%s: score = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+type fakeEngagementRepo struct {
+	signals []repository.EngagementSignals
+	saved   []repository.EngagementScore
+	latest  *time.Time
+	filter  repository.ChurnRiskFilter
+}
+
+func (f *fakeEngagementRepo) Signals(ctx context.Context, day time.Time) ([]repository.EngagementSignals, error) {
+	return f.signals, nil
+}
+
+func (f *fakeEngagementRepo) SaveScores(ctx context.Context, day time.Time, scores []repository.EngagementScore) error {
+	f.saved = scores
+	f.latest = &day
+	return nil
+}
+
+func (f *fakeEngagementRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeEngagementRepo) LatestScoreDate(ctx context.Context) (*time.Time, error) {
+	return f.latest, nil
+}
+
+func (f *fakeEngagementRepo) ChurnRisk(ctx context.Context, filter repository.ChurnRiskFilter) ([]repository.ChurnRiskFamily, error) {
+	f.filter = filter
+	return nil, nil
+}
+
+func TestEngagementChurnRisk(t *testing.T) {
+	repo := &fakeEngagementRepo{}
+	svc := NewEngagementService(repo)
+
+	list, err := svc.ChurnRisk(context.Background(), ChurnRiskParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.ScoreDate != nil || len(list.Families) != 0 {
+		t.Errorf("before any scoring: %+v", list)
+	}
+
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	repo.signals = []repository.EngagementSignals{{FamilyID: uuid.New(), ActiveDays14d: 10}}
+	if n, err := svc.Score(context.Background(), day.Add(15*time.Hour)); err != nil || n != 1 {
+		t.Fatalf("Score = %d, %v", n, err)
+	}
+	if repo.saved[0].Score != 40 {
+		t.Errorf("saved score = %d, want 40", repo.saved[0].Score)
+	}
+
+	list, err = svc.ChurnRisk(context.Background(), ChurnRiskParams{MinDrop: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.ScoreDate == nil || !list.ScoreDate.Equal(day) {
+		t.Errorf("score date = %v, want %s", list.ScoreDate, day)
+	}
+	f := repo.filter
+	if f.MinDrop != 30 || f.RenewalDays != churnRenewalDays || f.CompareDays != churnCompareDays || f.LowScore != churnLowScore {
+		t.Errorf("filter = %+v", f)
+	}
+	if list.Families == nil {
+		t.Error("families should encode as [] not null")
+	}
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// Event is the wire envelope for a domain event. Data is the event's
+// payload as written by the outbox trigger; its shape is fixed per
+// (Type, SchemaVersion) and documented in docs/DOMAIN-EVENTS.md. Delivery is
+// at-least-once, so consumers dedupe on EventID.
+type Event struct {
+	EventID       uuid.UUID       `json:"event_id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Aggregate     EventAggregate  `json:"aggregate"`
+	FamilyID      *uuid.UUID      `json:"family_id,omitempty"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// EventAggregate identifies the entity an event is about.
+type EventAggregate struct {
+	Type string    `json:"type"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// PartitionKey keeps one aggregate's events on one shard/partition so
+// they are read in order.
+func (e *Event) PartitionKey() string { return e.Aggregate.ID.String() }
+
+func eventFromOutbox(o repository.OutboxEvent) Event {
+	e := Event{
+		EventID:       o.EventID,
+		Type:          o.EventType,
+		SchemaVersion: o.SchemaVersion,
+		OccurredAt:    o.OccurredAt.UTC(),
+		Aggregate:     EventAggregate{Type: o.AggregateType, ID: o.AggregateID},
+		Data:          o.Payload,
+	}
+	if o.FamilyID.Valid {
+		id := o.FamilyID.UUID
+		e.FamilyID = &id
+	}
+	return e
+}
+
+// EventSink publishes events to a stream. Publish returns an error for the
+// batch as a whole (nothing known to be written), or one entry per event,
+// nil where the record was accepted.
+type EventSink interface {
+	Publish(ctx context.Context, events []Event) ([]error, error)
+	// MaxBatch is the most records one Publish call accepts.
+	MaxBatch() int
+}
+
+// KinesisSink writes to a Kinesis data stream with PutRecords.
+type KinesisSink struct {
+	api    *awsJSONClient
+	stream string
+}
+
+// NewKinesisSink creates a sink for stream in region using the default AWS
+// config.
+func NewKinesisSink(ctx context.Context, region, stream string) (*KinesisSink, error) {
+	if stream == "" {
+		return nil, errors.New("kinesis stream name is required")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	creds, err := loadAWSCredentials(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return &KinesisSink{
+		api:    newAWSJSONClient("https://kinesis."+region+".amazonaws.com/", region, "kinesis", "Kinesis_20131202", creds),
+		stream: stream,
+	}, nil
+}
+
+// MaxBatch is the PutRecords limit.
+func (k *KinesisSink) MaxBatch() int { return 500 }
+
+func (k *KinesisSink) Publish(ctx context.Context, events []Event) ([]error, error) {
+	type record struct {
+		Data         []byte `json:"Data"` // base64 on the wire
+		PartitionKey string `json:"PartitionKey"`
+	}
+	in := struct {
+		StreamName string   `json:"StreamName"`
+		Records    []record `json:"Records"`
+	}{StreamName: k.stream}
+	for i := range events {
+		data, err := json.Marshal(&events[i])
+		if err != nil {
+			return nil, err
+		}
+		in.Records = append(in.Records, record{Data: data, PartitionKey: events[i].PartitionKey()})
+	}
+
+	var out struct {
+		FailedRecordCount int `json:"FailedRecordCount"`
+		Records           []struct {
+			ErrorCode    string `json:"ErrorCode"`
+			ErrorMessage string `json:"ErrorMessage"`
+		} `json:"Records"`
+	}
+	if err := k.api.call(ctx, "PutRecords", in, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Records) != len(events) {
+		return nil, fmt.Errorf("kinesis PutRecords: %d results for %d records", len(out.Records), len(events))
+	}
+	errs := make([]error, len(events))
+	for i, r := range out.Records {
+		if r.ErrorCode != "" {
+			errs[i] = &AWSAPIError{Service: "kinesis", Code: r.ErrorCode, Message: r.ErrorMessage}
+		}
+	}
+	return errs, nil
+}
+
+// KafkaRESTSink produces to a Kafka topic through a Confluent-compatible
+// REST Proxy (v2 API), which avoids a native Kafka client. Credentials for
+// the proxy, if any, go in the URL's userinfo (HTTP basic auth).
+type KafkaRESTSink struct {
+	endpoint string
+	http     *http.Client
+}
+
+// NewKafkaRESTSink creates a sink producing to topic via the proxy at
+// baseURL.
+func NewKafkaRESTSink(baseURL, topic string) (*KafkaRESTSink, error) {
+	if baseURL == "" || topic == "" {
+		return nil, errors.New("kafka REST proxy URL and topic are required")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("kafka REST proxy URL: %w", err)
+	}
+	return &KafkaRESTSink{
+		endpoint: strings.TrimRight(baseURL, "/") + "/topics/" + url.PathEscape(topic),
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// MaxBatch keeps requests well under the proxy's default 1 MB limit.
+func (k *KafkaRESTSink) MaxBatch() int { return 200 }
+
+func (k *KafkaRESTSink) Publish(ctx context.Context, events []Event) ([]error, error) {
+	type record struct {
+		Key   string `json:"key"`
+		Value *Event `json:"value"`
+	}
+	in := struct {
+		Records []record `json:"records"`
+	}{}
+	for i := range events {
+		in.Records = append(in.Records, record{Key: events[i].PartitionKey(), Value: &events[i]})
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Message != "" {
+			return nil, fmt.Errorf("kafka REST proxy: HTTP %d: %s", resp.StatusCode, e.Message)
+		}
+		return nil, fmt.Errorf("kafka REST proxy: HTTP %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("kafka REST proxy: decode response: %w", err)
+	}
+	if len(out.Offsets) != len(events) {
+		return nil, fmt.Errorf("kafka REST proxy: %d offsets for %d records", len(out.Offsets), len(events))
+	}
+	errs := make([]error, len(events))
+	for i, o := range out.Offsets {
+		if o.ErrorCode != nil {
+			errs[i] = fmt.Errorf("kafka error %d: %s", *o.ErrorCode, o.Error)
+		}
+	}
+	return errs, nil
+}
+
+// EventRelayOptions configures EventRelay; NewServices fills it from
+// config.EventsConfig.
+type EventRelayOptions struct {
+	BatchSize int
+	// Retention is how long published rows stay in the outbox (for replay
+	// by hand) before pruning.
+	Retention time.Duration
+	// PendingMaxAge drops events that still haven't been published after
+	// this long, e.g. while no sink is configured.
+	PendingMaxAge time.Duration
+}
+
+// relayMaxBatches bounds one Relay run so a large backlog drains over
+// several ticks rather than holding the job lock indefinitely.
+const relayMaxBatches = 20
+
+// EventRelay publishes the transactional outbox (event_outbox) to the
+// configured stream.
+type EventRelay struct {
+	repo repository.EventOutboxRepository
+	sink EventSink
+	opts EventRelayOptions
+	now  func() time.Time
+}
+
+// NewEventRelay creates a relay. sink may be nil, in which case Relay is a
+// no-op and the outbox is only pruned.
+func NewEventRelay(repo repository.EventOutboxRepository, sink EventSink, opts EventRelayOptions) *EventRelay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if sink != nil && opts.BatchSize > sink.MaxBatch() {
+		opts.BatchSize = sink.MaxBatch()
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	if opts.PendingMaxAge <= 0 {
+		opts.PendingMaxAge = 14 * 24 * time.Hour
+	}
+	return &EventRelay{repo: repo, sink: sink, opts: opts, now: time.Now}
+}
+
+// Enabled reports whether a sink is configured.
+func (r *EventRelay) Enabled() bool { return r.sink != nil }
+
+// Relay publishes pending events in batches, oldest first, and returns how
+// many were published. Rejected records are marked failed and retried on
+// the next run; the run stops at the first batch with a failure so a
+// broken stream isn't hammered.
+func (r *EventRelay) Relay(ctx context.Context) (int, error) {
+	if r.sink == nil {
+		return 0, nil
+	}
+	published := 0
+	for i := 0; i < relayMaxBatches; i++ {
+		pending, err := r.repo.Pending(ctx, r.opts.BatchSize)
+		if err != nil {
+			return published, fmt.Errorf("load pending events: %w", err)
+		}
+		if len(pending) == 0 {
+			return published, nil
+		}
+
+		events := make([]Event, len(pending))
+		ids := make([]int64, len(pending))
+		for j, o := range pending {
+			events[j] = eventFromOutbox(o)
+			ids[j] = o.ID
+		}
+		errs, err := r.sink.Publish(ctx, events)
+		if err != nil {
+			if markErr := r.repo.MarkFailed(ctx, ids, err.Error()); markErr != nil {
+				log.Printf("Event relay: mark failed: %v", markErr)
+			}
+			return published, fmt.Errorf("publish: %w", err)
+		}
+
+		var ok []int64
+		failed := map[string][]int64{}
+		for j, e := range errs {
+			if e == nil {
+				ok = append(ok, ids[j])
+			} else {
+				failed[e.Error()] = append(failed[e.Error()], ids[j])
+			}
+		}
+		if err := r.repo.MarkPublished(ctx, ok); err != nil {
+			// The records are in the stream; they'll be sent again next
+			// run, which consumers absorb by deduping on event_id.
+			return published, fmt.Errorf("mark published: %w", err)
+		}
+		published += len(ok)
+		for reason, ids := range failed {
+			if err := r.repo.MarkFailed(ctx, ids, reason); err != nil {
+				return published, fmt.Errorf("mark failed: %w", err)
+			}
+		}
+		if len(failed) > 0 {
+			return published, fmt.Errorf("%d of %d events rejected", len(pending)-len(ok), len(pending))
+		}
+		if len(pending) < r.opts.BatchSize {
+			return published, nil
+		}
+	}
+	return published, nil
+}
+
+// Prune deletes published events past retention and pending events past
+// PendingMaxAge, logging the latter since they were never delivered.
+func (r *EventRelay) Prune(ctx context.Context) error {
+	now := r.now()
+	stats, err := r.repo.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if r.sink != nil && stats.Pending > 0 && stats.OldestPending.Valid && now.Sub(stats.OldestPending.Time) > time.Hour {
+		log.Printf("Event relay: %d events pending (%d failing), oldest from %s",
+			stats.Pending, stats.Failing, stats.OldestPending.Time.UTC().Format(time.RFC3339))
+	}
+	n, err := r.repo.Prune(ctx, now.Add(-r.opts.Retention), now.Add(-r.opts.PendingMaxAge))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Event relay: pruned %d outbox rows", n)
+	}
+	return nil
+}
+
+// EventRelayScheduler relays the outbox every interval and prunes it
+// hourly. It runs with or without a sink so the outbox can't grow without
+// bound.
+type EventRelayScheduler struct {
+	relay    *EventRelay
+	jobs     *JobLocker
+	interval time.Duration
+}
+
+func NewEventRelayScheduler(relay *EventRelay, jobs *JobLocker, interval time.Duration) *EventRelayScheduler {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &EventRelayScheduler{relay: relay, jobs: jobs, interval: interval}
+}
+
+func (s *EventRelayScheduler) Start(ctx context.Context) {
+	if s.relay.Enabled() {
+		log.Printf("Event relay started (every %s)", s.interval)
+	} else {
+		log.Println("Event relay: no sink configured (EVENTS_SINK); pruning the outbox only")
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Event relay stopped")
+			return
+		case now := <-ticker.C:
+			s.jobs.RunOnce(ctx, "event_relay", TickSlot(now, s.interval), s.interval, func(ctx context.Context) {
+				if _, err := s.relay.Relay(ctx); err != nil {
+					log.Printf("Event relay: %v", err)
+				}
+			})
+			s.jobs.RunOnce(ctx, "event_outbox_prune", TickSlot(now, time.Hour), time.Hour, func(ctx context.Context) {
+				if err := s.relay.Prune(ctx); err != nil {
+					log.Printf("Event relay: prune failed: %v", err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// fakeOutboxRepo keeps outbox rows in memory.
+type fakeOutboxRepo struct {
+	rows      []repository.OutboxEvent
+	published map[int64]bool
+	failures  map[int64]string
+}
+
+func newFakeOutboxRepo(n int) *fakeOutboxRepo {
+	r := &fakeOutboxRepo{published: map[int64]bool{}, failures: map[int64]string{}}
+	for i := 1; i <= n; i++ {
+		r.rows = append(r.rows, repository.OutboxEvent{
+			ID:            int64(i),
+			EventID:       uuid.New(),
+			EventType:     "log.created",
+			SchemaVersion: 1,
+			AggregateType: "log",
+			AggregateID:   uuid.New(),
+			Payload:       json.RawMessage(`{"log_type":"sleep"}`),
+			OccurredAt:    time.Date(2026, 3, 1, 12, 0, i, 0, time.UTC),
+		})
+	}
+	return r
+}
+
+func (r *fakeOutboxRepo) Pending(ctx context.Context, limit int) ([]repository.OutboxEvent, error) {
+	var out []repository.OutboxEvent
+	for _, e := range r.rows {
+		if !r.published[e.ID] && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeOutboxRepo) MarkPublished(ctx context.Context, ids []int64) error {
+	for _, id := range ids {
+		r.published[id] = true
+	}
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkFailed(ctx context.Context, ids []int64, reason string) error {
+	for _, id := range ids {
+		r.failures[id] = reason
+	}
+	return nil
+}
+
+func (r *fakeOutboxRepo) Prune(ctx context.Context, publishedBefore, pendingBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeOutboxRepo) Stats(ctx context.Context) (*repository.OutboxStats, error) {
+	return &repository.OutboxStats{}, nil
+}
+
+// fakeSink records published events and rejects those whose event ID is in
+// reject.
+type fakeSink struct {
+	sent   []Event
+	reject map[uuid.UUID]bool
+	err    error
+}
+
+func (s *fakeSink) MaxBatch() int { return 500 }
+
+func (s *fakeSink) Publish(ctx context.Context, events []Event) ([]error, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	errs := make([]error, len(events))
+	for i, e := range events {
+		if s.reject[e.EventID] {
+			errs[i] = errors.New("ProvisionedThroughputExceededException")
+			continue
+		}
+		s.sent = append(s.sent, e)
+	}
+	return errs, nil
+}
+
+func TestEventRelayPublishesInBatches(t *testing.T) {
+	repo := newFakeOutboxRepo(7)
+	sink := &fakeSink{}
+	relay := NewEventRelay(repo, sink, EventRelayOptions{BatchSize: 3})
+
+	n, err := relay.Relay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 || len(sink.sent) != 7 {
+		t.Fatalf("published %d, sent %d; want 7", n, len(sink.sent))
+	}
+	for i, e := range sink.sent {
+		if e.EventID != repo.rows[i].EventID {
+			t.Errorf("event %d out of order", i)
+		}
+	}
+	if n, _ := relay.Relay(context.Background()); n != 0 {
+		t.Errorf("second run published %d, want 0", n)
+	}
+}
+
+func TestEventRelayPartialFailure(t *testing.T) {
+	repo := newFakeOutboxRepo(4)
+	sink := &fakeSink{reject: map[uuid.UUID]bool{repo.rows[1].EventID: true}}
+	relay := NewEventRelay(repo, sink, EventRelayOptions{BatchSize: 10})
+
+	n, err := relay.Relay(context.Background())
+	if err == nil {
+		t.Fatal("want error for rejected record")
+	}
+	if n != 3 {
+		t.Errorf("published %d, want 3", n)
+	}
+	if repo.published[2] || repo.failures[2] == "" {
+		t.Errorf("rejected row: published=%v failure=%q", repo.published[2], repo.failures[2])
+	}
+
+	// Retried on the next run once the stream accepts it.
+	sink.reject = nil
+	if n, err := relay.Relay(context.Background()); err != nil || n != 1 {
+		t.Errorf("retry published %d, %v; want 1", n, err)
+	}
+}
+
+func TestEventRelayBatchErrorMarksAllFailed(t *testing.T) {
+	repo := newFakeOutboxRepo(2)
+	relay := NewEventRelay(repo, &fakeSink{err: errors.New("stream not found")}, EventRelayOptions{})
+	if _, err := relay.Relay(context.Background()); err == nil {
+		t.Fatal("want error")
+	}
+	if len(repo.failures) != 2 || len(repo.published) != 0 {
+		t.Errorf("failures %v published %v", repo.failures, repo.published)
+	}
+}
+
+func TestEventEnvelope(t *testing.T) {
+	family := uuid.New()
+	o := newFakeOutboxRepo(1).rows[0]
+	o.FamilyID = uuid.NullUUID{UUID: family, Valid: true}
+	raw, err := json.Marshal(eventFromOutbox(o))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	json.Unmarshal(raw, &got)
+	for _, key := range []string{"event_id", "type", "schema_version", "occurred_at", "aggregate", "family_id", "data"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("envelope missing %q: %s", key, raw)
+		}
+	}
+	if got["family_id"] != family.String() || got["data"].(map[string]any)["log_type"] != "sleep" {
+		t.Errorf("envelope = %s", raw)
+	}
+}
+
+func TestKafkaRESTSink(t *testing.T) {
+	var body struct {
+		Records []struct {
+			Key   string `json:"key"`
+			Value Event  `json:"value"`
+		} `json:"records"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/cc.events" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			t.Errorf("request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":10},{"error_code":50002,"error":"broker unavailable"}]}`))
+	}))
+	defer srv.Close()
+
+	sink, err := NewKafkaRESTSink(srv.URL+"/", "cc.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newFakeOutboxRepo(2)
+	events := []Event{eventFromOutbox(repo.rows[0]), eventFromOutbox(repo.rows[1])}
+	errs, err := sink.Publish(context.Background(), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || errs[1] == nil {
+		t.Errorf("errs = %v", errs)
+	}
+	if len(body.Records) != 2 || body.Records[0].Key != events[0].Aggregate.ID.String() || body.Records[0].Value.EventID != events[0].EventID {
+		t.Errorf("records = %+v", body.Records)
+	}
+}
@@ -0,0 +1,583 @@
+package service
+
+// exit_package_service.go — the "exit package" a family gets when they
+// cancel.
+//
+// Cancelling schedules a package for when the family's access ends. The
+// builder then zips every child's logs (one CSV per log type plus the
+// full JSON) and the PDF of every report, stores the ZIP, and emails the
+// family's parents a signed download link. The link and the in-app
+// download work for 90 days; after that the file is deleted. If the
+// cancellation is withdrawn first (resumed, or resubscribed), nothing is
+// built.
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrExitPackageNotFound    = apperr.NotFound("exit package not found")
+	ErrExitPackageUnavailable = errors.New("exit package is not available for download")
+)
+
+const (
+	// exitPackageTTL is how long a built package can be downloaded.
+	exitPackageTTL         = 90 * 24 * time.Hour
+	exitPackageMaxAttempts = 5
+	exitPackageBatch       = 10
+)
+
+// exitLogsFrom predates any log, so the export covers the whole history.
+var exitLogsFrom = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type exitChildSource interface {
+	GetByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Child, error)
+}
+
+type exitLogSource interface {
+	GetLogsForDateRange(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (*models.DailyLogPage, error)
+}
+
+// exitReportSource is the part of ReportService exports need.
+type exitReportSource interface {
+	ListAllReports(ctx context.Context, childID uuid.UUID) ([]models.Report, error)
+	OpenPDF(ctx context.Context, report *models.Report) (io.ReadCloser, error)
+}
+
+type exitPackageMailer interface {
+	IsEnabled() bool
+	SendExitPackageEmail(to, firstName, familyName, downloadURL, expiresOn string) error
+}
+
+// ExitPackageService schedules, builds and serves exit packages.
+type ExitPackageService struct {
+	repo          repository.ExitPackageRepository
+	children      exitChildSource
+	logs          exitLogSource
+	reports       exitReportSource
+	storage       BlobStorage
+	mailer        exitPackageMailer
+	appURL        string
+	signingSecret []byte
+	now           func() time.Time
+}
+
+// NewExitPackageService creates the exit package service. email may be
+// nil; packages are then built but not emailed. signingSecret signs the
+// emailed download links.
+func NewExitPackageService(repo repository.ExitPackageRepository, children exitChildSource, logs exitLogSource,
+	reports exitReportSource, storage BlobStorage, email *EmailService, appURL, signingSecret string) *ExitPackageService {
+	s := &ExitPackageService{repo: repo, children: children, logs: logs, reports: reports, storage: storage,
+		appURL: appURL, signingSecret: []byte(signingSecret), now: time.Now}
+	if email != nil {
+		s.mailer = email
+	}
+	return s
+}
+
+// ScheduleForStripeSubscription schedules the package for a family whose
+// Stripe subscription was cancelled. accessEnds is when it stops (now, or
+// the period end for a cancel-at-period-end). Errors are logged: a missed
+// schedule mustn't fail the webhook.
+func (s *ExitPackageService) ScheduleForStripeSubscription(ctx context.Context, stripeSubscriptionID string, accessEnds time.Time) {
+	p, err := s.repo.ScheduleForStripeSubscription(ctx, stripeSubscriptionID, "stripe", accessEnds)
+	s.logScheduled(p, err, "stripe subscription "+stripeSubscriptionID)
+}
+
+// ScheduleForFamily schedules the package for a family whose subscription
+// an admin cancelled.
+func (s *ExitPackageService) ScheduleForFamily(ctx context.Context, familyID uuid.UUID, accessEnds time.Time) {
+	p, err := s.repo.ScheduleForFamily(ctx, familyID, "admin", accessEnds)
+	s.logScheduled(p, err, "family "+familyID.String())
+}
+
+func (s *ExitPackageService) logScheduled(p *repository.ExitPackage, err error, what string) {
+	switch {
+	case err != nil:
+		log.Printf("[EXIT] schedule package for %s: %v", what, err)
+	case p != nil:
+		log.Printf("[EXIT] package %s for %s scheduled after %s", p.ID, what, p.BuildAfter.Format(time.RFC3339))
+	}
+}
+
+// ProcessDue builds and emails packages whose family's access has ended,
+// withdraws those whose cancellation no longer stands, and deletes the
+// files of packages past their download window. Returns the number built.
+func (s *ExitPackageService) ProcessDue(ctx context.Context) (int, error) {
+	now := s.now()
+	due, err := s.repo.Due(ctx, now, exitPackageBatch)
+	if err != nil {
+		return 0, err
+	}
+	built := 0
+	for i := range due {
+		p := &due[i]
+		if !p.Current {
+			log.Printf("[EXIT] package %s withdrawn: cancellation no longer stands", p.ID)
+			if err := s.repo.SetStatus(ctx, p.ID, "withdrawn"); err != nil {
+				return built, err
+			}
+			continue
+		}
+		if err := s.build(ctx, p); err != nil {
+			final := p.Attempts+1 >= exitPackageMaxAttempts
+			log.Printf("[EXIT] package %s: build attempt %d failed (final=%v): %v", p.ID, p.Attempts+1, final, err)
+			if err := s.repo.MarkAttemptFailed(ctx, p.ID, err.Error(), final); err != nil {
+				return built, err
+			}
+			continue
+		}
+		built++
+		s.send(ctx, p)
+	}
+	return built, s.expire(ctx, now)
+}
+
+func (s *ExitPackageService) expire(ctx context.Context, now time.Time) error {
+	expiring, err := s.repo.Expiring(ctx, now, exitPackageBatch)
+	if err != nil {
+		return err
+	}
+	for _, p := range expiring {
+		// A file already gone is fine; anything else is retried next tick.
+		if err := s.storage.Delete(ctx, p.StoragePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[EXIT] package %s: delete expired file: %v", p.ID, err)
+			continue
+		}
+		if err := s.repo.SetStatus(ctx, p.ID, "expired"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build writes the ZIP to a temp file, stores it and marks it ready.
+func (s *ExitPackageService) build(ctx context.Context, p *repository.ExitPackage) error {
+	f, err := os.CreateTemp("", "exit-package-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := s.writeArchive(ctx, f, p); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	path, size, err := s.storage.Save(ctx, "exit_packages", p.ID.String()+".zip", "application/zip", f)
+	if err != nil {
+		return fmt.Errorf("store package: %w", err)
+	}
+	builtAt := s.now()
+	expiresAt := builtAt.Add(exitPackageTTL)
+	if err := s.repo.MarkReady(ctx, p.ID, path, size, builtAt, expiresAt); err != nil {
+		_ = s.storage.Delete(ctx, path)
+		return err
+	}
+	p.Status, p.StoragePath, p.SizeBytes, p.BuiltAt, p.ExpiresAt = "ready", path, &size, &builtAt, &expiresAt
+	log.Printf("[EXIT] package %s built for family %s (%d bytes)", p.ID, p.FamilyID, size)
+	return nil
+}
+
+// exitManifest is family.json at the root of the package.
+type exitManifest struct {
+	Family      string              `json:"family"`
+	CancelledAt time.Time           `json:"cancelled_at"`
+	ExportedAt  time.Time           `json:"exported_at"`
+	Children    []exitManifestChild `json:"children"`
+}
+
+type exitManifestChild struct {
+	Child   models.Child   `json:"child"`
+	Folder  string         `json:"folder"`
+	Logs    map[string]int `json:"logs"`
+	Reports int            `json:"reports"`
+}
+
+// exitLogSet is one log type's rows, written as <name>.csv.
+type exitLogSet struct {
+	name  string
+	count int
+	rows  any
+}
+
+func exitLogSets(p *models.DailyLogPage) []exitLogSet {
+	return []exitLogSet{
+		{"medication", len(p.MedicationLogs), p.MedicationLogs},
+		{"behavior", len(p.BehaviorLogs), p.BehaviorLogs},
+		{"bowel", len(p.BowelLogs), p.BowelLogs},
+		{"speech", len(p.SpeechLogs), p.SpeechLogs},
+		{"diet", len(p.DietLogs), p.DietLogs},
+		{"weight", len(p.WeightLogs), p.WeightLogs},
+		{"sleep", len(p.SleepLogs), p.SleepLogs},
+		{"sensory", len(p.SensoryLogs), p.SensoryLogs},
+		{"social", len(p.SocialLogs), p.SocialLogs},
+		{"therapy", len(p.TherapyLogs), p.TherapyLogs},
+		{"seizure", len(p.SeizureLogs), p.SeizureLogs},
+		{"health_event", len(p.HealthEventLogs), p.HealthEventLogs},
+	}
+}
+
+func (s *ExitPackageService) writeArchive(ctx context.Context, w io.Writer, p *repository.ExitPackage) error {
+	zw := zip.NewWriter(w)
+	now := s.now()
+	children, err := s.children.GetByFamilyID(ctx, p.FamilyID)
+	if err != nil {
+		return fmt.Errorf("list children: %w", err)
+	}
+	manifest := exitManifest{Family: p.FamilyName, CancelledAt: p.CancelledAt, ExportedAt: now}
+	missing := 0
+	for i := range children {
+		c := &children[i]
+		dir := exitChildFolder(c) + "/"
+		page, err := s.logs.GetLogsForDateRange(ctx, c.ID, exitLogsFrom, now)
+		if err != nil {
+			return fmt.Errorf("logs for child %s: %w", c.ID, err)
+		}
+		page.MedicationsDue = nil
+		if err := zipJSON(zw, dir+"logs.json", page); err != nil {
+			return err
+		}
+		entry := exitManifestChild{Child: *c, Folder: dir, Logs: map[string]int{}}
+		for _, set := range exitLogSets(page) {
+			if set.count == 0 {
+				continue
+			}
+			entry.Logs[set.name] = set.count
+			data, err := exitLogCSV(set.rows)
+			if err != nil {
+				return fmt.Errorf("%s logs for child %s: %w", set.name, c.ID, err)
+			}
+			if err := zipFile(zw, dir+set.name+".csv", data); err != nil {
+				return err
+			}
+		}
+		reports, err := s.reports.ListAllReports(ctx, c.ID)
+		if err != nil {
+			return fmt.Errorf("reports for child %s: %w", c.ID, err)
+		}
+		names := map[string]int{}
+		for j := range reports {
+			r := &reports[j]
+			if r.Status != "completed" || !r.StoragePath.Valid {
+				continue
+			}
+			// A report whose file is gone is noted in the README rather
+			// than failing the whole package.
+			if err := s.copyReport(ctx, zw, dir+"reports/"+exitReportName(r, names), r); err != nil {
+				log.Printf("[EXIT] package %s: report %s: %v", p.ID, r.ID, err)
+				missing++
+				continue
+			}
+			entry.Reports++
+		}
+		manifest.Children = append(manifest.Children, entry)
+	}
+	if err := zipJSON(zw, "family.json", manifest); err != nil {
+		return err
+	}
+	if err := zipFile(zw, "README.txt", exitReadme(&manifest, missing)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (s *ExitPackageService) copyReport(ctx context.Context, zw *zip.Writer, name string, r *models.Report) error {
+	rc, err := s.reports.OpenPDF(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+func zipFile(zw *zip.Writer, name string, data []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+func zipJSON(zw *zip.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return zipFile(zw, name, data)
+}
+
+// exitChildFolder names a child's folder; the short ID keeps two children
+// with the same name apart.
+func exitChildFolder(c *models.Child) string {
+	name := exitSafeName(strings.TrimSpace(c.FirstName + " " + c.LastName.String))
+	if name == "" {
+		name = "Child"
+	}
+	return name + " (" + c.ID.String()[:8] + ")"
+}
+
+// exitReportName is "<created date> <title>.pdf", numbered on collision.
+func exitReportName(r *models.Report, seen map[string]int) string {
+	base := r.CreatedAt.Format("2006-01-02") + " " + exitSafeName(r.Title)
+	seen[base]++
+	if n := seen[base]; n > 1 {
+		base += " (" + strconv.Itoa(n) + ")"
+	}
+	return strings.TrimSpace(base) + ".pdf"
+}
+
+// exitSafeName drops characters that aren't safe in file names.
+func exitSafeName(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`/\:*?"<>|`, r):
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// exitLogCSV flattens log rows to CSV through their JSON form, so every
+// field the API returns becomes a column. Columns are sorted by name;
+// nested values are written as JSON.
+func exitLogCSV(rows any) ([]byte, error) {
+	raw, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var records []map[string]any
+	if err := dec.Decode(&records); err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{}
+	for _, rec := range records {
+		for k := range rec {
+			keys[k] = true
+		}
+	}
+	cols := make([]string, 0, len(keys))
+	for k := range keys {
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write(cols); err != nil {
+		return nil, err
+	}
+	row := make([]string, len(cols))
+	for _, rec := range records {
+		for i, k := range cols {
+			row[i] = exitCSVValue(rec[k])
+		}
+		if err := cw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
+
+func exitCSVValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func exitReadme(m *exitManifest, missingReports int) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "MyCareCompanion data export for %s\n", m.Family)
+	fmt.Fprintf(&b, "Exported %s\n\n", m.ExportedAt.UTC().Format("January 2, 2006 15:04 MST"))
+	b.WriteString("This archive holds everything your family recorded in MyCareCompanion.\n\n")
+	b.WriteString("  family.json           The family, each child, and what was exported for them.\n")
+	b.WriteString("  <child>/logs.json     Every log for the child, exactly as the app stores it.\n")
+	b.WriteString("  <child>/<type>.csv    One spreadsheet per log type (medication, sleep, ...).\n")
+	b.WriteString("  <child>/reports/      The PDF of every report generated for the child.\n\n")
+	b.WriteString("Logs with an amended_at date were corrected after they were first logged.\n\n")
+	if len(m.Children) == 0 {
+		b.WriteString("No children were recorded for this family.\n\n")
+	}
+	for _, c := range m.Children {
+		total := 0
+		for _, n := range c.Logs {
+			total += n
+		}
+		fmt.Fprintf(&b, "%s: %d logs, %d reports\n", c.Folder, total, c.Reports)
+	}
+	if missingReports > 0 {
+		fmt.Fprintf(&b, "\n%d report PDF(s) could not be retrieved and are not included. Contact support@mycarecompanion.net if you need them.\n", missingReports)
+	}
+	b.WriteString("\nThis data contains protected health information about your child. Store it securely.\n")
+	return []byte(b.String())
+}
+
+// send emails each parent the signed download link. Failures are logged;
+// the package stays downloadable in the app.
+func (s *ExitPackageService) send(ctx context.Context, p *repository.ExitPackage) {
+	if s.mailer == nil || !s.mailer.IsEnabled() {
+		log.Printf("[EXIT] email disabled, package %s not sent", p.ID)
+		return
+	}
+	recipients, err := s.repo.Recipients(ctx, p.FamilyID)
+	if err != nil {
+		log.Printf("[EXIT] package %s: list recipients: %v", p.ID, err)
+		return
+	}
+	url := s.appURL + s.SignedDownloadURL(p)
+	expiresOn := p.ExpiresAt.UTC().Format("January 2, 2006")
+	sent := false
+	for _, rc := range recipients {
+		if err := s.mailer.SendExitPackageEmail(rc.Email, rc.FirstName, p.FamilyName, url, expiresOn); err != nil {
+			log.Printf("[EXIT] package %s to %s: %v", p.ID, rc.Email, err)
+			continue
+		}
+		sent = true
+	}
+	if sent {
+		if err := s.repo.MarkEmailed(ctx, p.ID); err != nil {
+			log.Printf("[EXIT] package %s: mark emailed: %v", p.ID, err)
+		}
+	}
+}
+
+// ListForFamily returns a family's exit packages, newest first.
+func (s *ExitPackageService) ListForFamily(ctx context.Context, familyID uuid.UUID) ([]repository.ExitPackage, error) {
+	return s.repo.ListForFamily(ctx, familyID)
+}
+
+// Get returns a package, or ErrExitPackageNotFound.
+func (s *ExitPackageService) Get(ctx context.Context, id uuid.UUID) (*repository.ExitPackage, error) {
+	p, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrExitPackageNotFound
+	}
+	return p, nil
+}
+
+// Open returns a reader for a ready package within its download window.
+// Caller must close the reader.
+func (s *ExitPackageService) Open(ctx context.Context, p *repository.ExitPackage) (io.ReadCloser, error) {
+	if p.Status != "ready" || p.ExpiresAt == nil || !s.now().Before(*p.ExpiresAt) {
+		return nil, ErrExitPackageUnavailable
+	}
+	return s.storage.Open(ctx, p.StoragePath)
+}
+
+// ExitPackageFilename is the download name for a package.
+func ExitPackageFilename(p *repository.ExitPackage) string {
+	name := exitSafeName(p.FamilyName)
+	if name == "" {
+		name = "Family"
+	}
+	return "MyCareCompanion export - " + name + ".zip"
+}
+
+// SignedDownloadURL returns the path of the emailed download link, valid
+// until the package expires.
+func (s *ExitPackageService) SignedDownloadURL(p *repository.ExitPackage) string {
+	expUnix := p.ExpiresAt.Unix()
+	return fmt.Sprintf("/exit/signed/%s?exp=%d&sig=%s", p.ID, expUnix, s.sign(p.ID, expUnix))
+}
+
+// VerifySignedDownload checks a signed download URL's expiry and signature.
+func (s *ExitPackageService) VerifySignedDownload(id uuid.UUID, expUnix int64, sig string) error {
+	if s.now().Unix() > expUnix {
+		return fmt.Errorf("signed url expired")
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("bad signature encoding")
+	}
+	want, _ := hex.DecodeString(s.sign(id, expUnix))
+	if !hmac.Equal(got, want) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (s *ExitPackageService) sign(id uuid.UUID, expUnix int64) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte("exit_package|" + id.String() + "|" + strconv.FormatInt(expUnix, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ExitPackageScheduler runs ProcessDue hourly.
+type ExitPackageScheduler struct {
+	svc  *ExitPackageService
+	jobs *JobLocker
+}
+
+func NewExitPackageScheduler(svc *ExitPackageService, jobs *JobLocker) *ExitPackageScheduler {
+	return &ExitPackageScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *ExitPackageScheduler) Start(ctx context.Context) {
+	log.Println("Exit package scheduler started")
+	check := func() {
+		s.jobs.RunOnce(ctx, "exit_packages", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			if n, err := s.svc.ProcessDue(ctx); err != nil {
+				log.Printf("Exit packages: tick failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Exit packages: built %d", n)
+			}
+		})
+	}
+	check()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Exit package scheduler stopped")
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakeExitPackageRepo struct {
+	repository.ExitPackageRepository
+	packages map[uuid.UUID]*repository.ExitPackage
+	emailed  []uuid.UUID
+}
+
+func (f *fakeExitPackageRepo) Due(ctx context.Context, now time.Time, limit int) ([]repository.ExitPackage, error) {
+	var out []repository.ExitPackage
+	for _, p := range f.packages {
+		if p.Status == "pending" && !p.BuildAfter.After(now) {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeExitPackageRepo) Expiring(ctx context.Context, now time.Time, limit int) ([]repository.ExitPackage, error) {
+	var out []repository.ExitPackage
+	for _, p := range f.packages {
+		if p.Status == "ready" && !p.ExpiresAt.After(now) {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeExitPackageRepo) MarkReady(ctx context.Context, id uuid.UUID, path string, size int64, builtAt, expiresAt time.Time) error {
+	p := f.packages[id]
+	p.Status, p.StoragePath, p.SizeBytes, p.BuiltAt, p.ExpiresAt = "ready", path, &size, &builtAt, &expiresAt
+	p.Attempts++
+	return nil
+}
+
+func (f *fakeExitPackageRepo) MarkAttemptFailed(ctx context.Context, id uuid.UUID, msg string, final bool) error {
+	p := f.packages[id]
+	p.Attempts++
+	p.LastError = msg
+	if final {
+		p.Status = "failed"
+	}
+	return nil
+}
+
+func (f *fakeExitPackageRepo) MarkEmailed(ctx context.Context, id uuid.UUID) error {
+	f.emailed = append(f.emailed, id)
+	return nil
+}
+
+func (f *fakeExitPackageRepo) SetStatus(ctx context.Context, id uuid.UUID, status string) error {
+	f.packages[id].Status = status
+	return nil
+}
+
+func (f *fakeExitPackageRepo) Recipients(ctx context.Context, familyID uuid.UUID) ([]repository.ExitPackageRecipient, error) {
+	return []repository.ExitPackageRecipient{{Email: "parent@example.com", FirstName: "Pat"}}, nil
+}
+
+type fakeExitSources struct {
+	children []models.Child
+	logs     map[uuid.UUID]*models.DailyLogPage
+	reports  map[uuid.UUID][]models.Report
+	pdfs     map[string][]byte
+	logErr   error
+}
+
+func (f *fakeExitSources) GetByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Child, error) {
+	return f.children, nil
+}
+
+func (f *fakeExitSources) GetLogsForDateRange(ctx context.Context, childID uuid.UUID, start, end time.Time) (*models.DailyLogPage, error) {
+	if f.logErr != nil {
+		return nil, f.logErr
+	}
+	page := *f.logs[childID]
+	return &page, nil
+}
+
+func (f *fakeExitSources) ListAllReports(ctx context.Context, childID uuid.UUID) ([]models.Report, error) {
+	return f.reports[childID], nil
+}
+
+func (f *fakeExitSources) OpenPDF(ctx context.Context, r *models.Report) (io.ReadCloser, error) {
+	pdf, ok := f.pdfs[r.StoragePath.String]
+	if !ok {
+		return nil, errors.New("no such file")
+	}
+	return io.NopCloser(bytes.NewReader(pdf)), nil
+}
+
+type fakeExitMailer struct {
+	urls []string
+}
+
+func (f *fakeExitMailer) IsEnabled() bool { return true }
+
+func (f *fakeExitMailer) SendExitPackageEmail(to, firstName, familyName, downloadURL, expiresOn string) error {
+	f.urls = append(f.urls, downloadURL)
+	return nil
+}
+
+func nullString(s string) models.NullString {
+	return models.NullString{NullString: sql.NullString{String: s, Valid: true}}
+}
+
+func newExitPackageTest() (*ExitPackageService, *fakeExitPackageRepo, *fakeExitSources, *memBlobStorage, *fakeExitMailer) {
+	child := models.Child{ID: uuid.New(), FirstName: "Ava", LastName: nullString("Smith")}
+	mood := 4
+	sources := &fakeExitSources{
+		children: []models.Child{child},
+		logs: map[uuid.UUID]*models.DailyLogPage{child.ID: {
+			Child: child,
+			BehaviorLogs: []models.BehaviorLog{
+				{ID: uuid.New(), ChildID: child.ID, LogDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), MoodLevel: &mood, Notes: nullString("calm, \"good\" day")},
+				{ID: uuid.New(), ChildID: child.ID, LogDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		}},
+		reports: map[uuid.UUID][]models.Report{child.ID: {
+			{ID: uuid.New(), Title: "Monthly: March", Status: "completed", StoragePath: nullString("reports/a.pdf"), CreatedAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: uuid.New(), Title: "Lost", Status: "completed", StoragePath: nullString("reports/gone.pdf"), CreatedAt: time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC)},
+			{ID: uuid.New(), Title: "Failed", Status: "failed"},
+		}},
+		pdfs: map[string][]byte{"reports/a.pdf": []byte("%PDF-1.4 march")},
+	}
+	repo := &fakeExitPackageRepo{packages: map[uuid.UUID]*repository.ExitPackage{}}
+	storage := &memBlobStorage{blobs: map[string][]byte{}}
+	svc := NewExitPackageService(repo, sources, sources, sources, storage, nil, "https://app.example.com", "test-secret")
+	mailer := &fakeExitMailer{}
+	svc.mailer = mailer
+	return svc, repo, sources, storage, mailer
+}
+
+func (f *fakeExitPackageRepo) add(buildAfter time.Time, current bool) *repository.ExitPackage {
+	p := &repository.ExitPackage{ID: uuid.New(), FamilyID: uuid.New(), Status: "pending", BuildAfter: buildAfter,
+		FamilyName: "Smith Family", Current: current}
+	f.packages[p.ID] = p
+	return p
+}
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	return files
+}
+
+func TestExitPackageBuildAndEmail(t *testing.T) {
+	svc, repo, sources, storage, mailer := newExitPackageTest()
+	ctx := context.Background()
+	p := repo.add(time.Now().Add(-time.Minute), true)
+	later := repo.add(time.Now().Add(time.Hour), true)
+
+	n, err := svc.ProcessDue(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("ProcessDue = %d, %v", n, err)
+	}
+	if p.Status != "ready" || later.Status != "pending" {
+		t.Fatalf("statuses %s / %s", p.Status, later.Status)
+	}
+	if want := p.BuiltAt.Add(90 * 24 * time.Hour); !p.ExpiresAt.Equal(want) {
+		t.Errorf("expires %s, want %s", p.ExpiresAt, want)
+	}
+
+	files := readZip(t, storage.blobs[p.StoragePath])
+	dir := "Ava Smith (" + sources.children[0].ID.String()[:8] + ")/"
+	for _, name := range []string{"README.txt", "family.json", dir + "logs.json", dir + "behavior.csv", dir + "reports/2026-04-01 Monthly March.pdf"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s; have %v", name, keys(files))
+		}
+	}
+	if _, ok := files[dir+"sleep.csv"]; ok {
+		t.Error("empty log types get no CSV")
+	}
+	if !strings.Contains(string(files["README.txt"]), "1 report PDF(s) could not be retrieved") {
+		t.Errorf("README doesn't note the missing report:\n%s", files["README.txt"])
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(files[dir+"behavior.csv"])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("behavior.csv has %d rows", len(rows))
+	}
+	col := map[string]int{}
+	for i, c := range rows[0] {
+		col[c] = i
+	}
+	if rows[1][col["mood_level"]] != "4" || rows[1][col["notes"]] != `calm, "good" day` || rows[2][col["mood_level"]] != "" {
+		t.Errorf("behavior rows %v", rows)
+	}
+
+	if len(mailer.urls) != 1 || len(repo.emailed) != 1 {
+		t.Fatalf("emails %v, marked %v", mailer.urls, repo.emailed)
+	}
+	u, err := url.Parse(mailer.urls[0])
+	if err != nil || u.Host != "app.example.com" || u.Path != "/exit/signed/"+p.ID.String() {
+		t.Fatalf("download url %q", mailer.urls[0])
+	}
+	exp, _ := strconv.ParseInt(u.Query().Get("exp"), 10, 64)
+	if exp != p.ExpiresAt.Unix() {
+		t.Errorf("link expires %d, package %d", exp, p.ExpiresAt.Unix())
+	}
+	if err := svc.VerifySignedDownload(p.ID, exp, u.Query().Get("sig")); err != nil {
+		t.Errorf("emailed link doesn't verify: %v", err)
+	}
+	if err := svc.VerifySignedDownload(later.ID, exp, u.Query().Get("sig")); err == nil {
+		t.Error("signature verified for another package")
+	}
+}
+
+func TestExitPackageWithdrawnAndRetried(t *testing.T) {
+	svc, repo, sources, _, mailer := newExitPackageTest()
+	ctx := context.Background()
+	withdrawn := repo.add(time.Now().Add(-time.Minute), false)
+	failing := repo.add(time.Now().Add(-time.Minute), true)
+	sources.logErr = errors.New("db down")
+
+	for i := 1; i <= exitPackageMaxAttempts; i++ {
+		if n, err := svc.ProcessDue(ctx); err != nil || n != 0 {
+			t.Fatalf("attempt %d: ProcessDue = %d, %v", i, n, err)
+		}
+		want := "pending"
+		if i == exitPackageMaxAttempts {
+			want = "failed"
+		}
+		if failing.Status != want || failing.Attempts != i {
+			t.Fatalf("attempt %d: status %s after %d attempts", i, failing.Status, failing.Attempts)
+		}
+	}
+	if withdrawn.Status != "withdrawn" {
+		t.Errorf("withdrawn cancellation left %s", withdrawn.Status)
+	}
+	if len(mailer.urls) != 0 {
+		t.Error("nothing built, nothing emailed")
+	}
+}
+
+func TestExitPackageExpiry(t *testing.T) {
+	svc, repo, _, storage, _ := newExitPackageTest()
+	ctx := context.Background()
+	p := repo.add(time.Now().Add(-time.Minute), true)
+	if _, err := svc.ProcessDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Open(ctx, p); err != nil {
+		t.Fatalf("open ready package: %v", err)
+	}
+
+	svc.now = func() time.Time { return p.ExpiresAt.Add(time.Minute) }
+	if _, err := svc.Open(ctx, p); !errors.Is(err, ErrExitPackageUnavailable) {
+		t.Errorf("open after the window: %v", err)
+	}
+	if _, err := svc.ProcessDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != "expired" {
+		t.Errorf("status %s after the window", p.Status)
+	}
+	if _, ok := storage.blobs[p.StoragePath]; ok {
+		t.Error("expired package file not deleted")
+	}
+}
+
+func keys(m map[string][]byte) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
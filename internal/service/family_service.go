@@ -7,18 +7,19 @@ import (
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrFamilyNotFound        = errors.New("family not found")
-	ErrNotFamilyMember       = errors.New("not a member of this family")
-	ErrInsufficientRole      = errors.New("insufficient role for this action")
+	ErrFamilyNotFound        = apperr.NotFound("family not found")
+	ErrNotFamilyMember       = apperr.Forbidden("not a member of this family")
+	ErrInsufficientRole      = apperr.Forbidden("insufficient role for this action")
 	ErrCannotRemoveCreator   = errors.New("cannot remove family creator")
 	ErrCannotChangeCreator   = errors.New("cannot change family creator's role")
-	ErrMemberNotFound        = errors.New("member not found")
-	ErrAlreadyMember         = errors.New("user is already a member of this family")
+	ErrMemberNotFound        = apperr.NotFound("member not found")
+	ErrAlreadyMember         = apperr.Conflict("user is already a member of this family")
 )
 
 type FamilyService struct {
@@ -161,6 +162,9 @@ type FamilyDashboard struct {
 }
 
 func (s *FamilyService) GetDashboard(ctx context.Context, familyID uuid.UUID) (*FamilyDashboard, error) {
+	ctx, cancel := repository.OperationContext(ctx, "dashboard")
+	defer cancel()
+
 	family, err := s.familyRepo.GetByID(ctx, familyID)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrFeedbackInvalid     = apperr.Validation("invalid feedback")
+	ErrNPSThrottled        = errors.New("nps already collected recently")
+	ErrFeedbackRateLimited = errors.New("too much feedback submitted today")
+	ErrFeedbackReportRange = errors.New("invalid feedback report range")
+)
+
+const (
+	// NPSPromptInterval is the minimum gap between NPS asks for one user.
+	// Answering and dismissing both reset the clock.
+	NPSPromptInterval = 90 * 24 * time.Hour
+
+	maxFeedbackCommentLen = 5000
+	maxFeedbackContextLen = 100
+	// maxFeedbackPerDay bounds free-text submissions; there's no prompt
+	// to throttle, so this just keeps a stuck client from flooding the table.
+	maxFeedbackPerDay = 10
+	// maxNPSReportSpan allows two years so quarter buckets are useful.
+	maxNPSReportSpan = 2 * 366 * 24 * time.Hour
+)
+
+var validNPSIntervals = map[string]bool{"week": true, "month": true, "quarter": true}
+
+// FeedbackService collects in-app NPS scores and free-text feedback and
+// builds the admin trend report.
+type FeedbackService struct {
+	repo repository.FeedbackRepository
+	now  func() time.Time
+}
+
+// NewFeedbackService creates a new feedback service
+func NewFeedbackService(repo repository.FeedbackRepository) *FeedbackService {
+	return &FeedbackService{repo: repo, now: time.Now}
+}
+
+// NPSPromptStatus tells the client whether to show the NPS prompt.
+type NPSPromptStatus struct {
+	Eligible       bool       `json:"eligible"`
+	LastPromptedAt *time.Time `json:"last_prompted_at,omitempty"`
+	NextEligibleAt *time.Time `json:"next_eligible_at,omitempty"`
+}
+
+// npsEligibility is the pure throttle decision used by PromptStatus and
+// SubmitNPS.
+func npsEligibility(last *time.Time, now time.Time) NPSPromptStatus {
+	if last == nil {
+		return NPSPromptStatus{Eligible: true}
+	}
+	next := last.Add(NPSPromptInterval)
+	st := NPSPromptStatus{LastPromptedAt: last, Eligible: !now.Before(next)}
+	if !st.Eligible {
+		st.NextEligibleAt = &next
+	}
+	return st
+}
+
+// PromptStatus reports whether the user may be asked for NPS now.
+func (s *FeedbackService) PromptStatus(ctx context.Context, userID uuid.UUID) (*NPSPromptStatus, error) {
+	last, err := s.repo.LastNPSPrompt(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	st := npsEligibility(last, s.now())
+	return &st, nil
+}
+
+// FeedbackInput is the client payload for NPS and free-text feedback.
+// Score is required for NPS and ignored for free text.
+type FeedbackInput struct {
+	Score      *int   `json:"score"`
+	Comment    string `json:"comment"`
+	Context    string `json:"context"`
+	Platform   string `json:"platform"`
+	AppVersion string `json:"app_version"`
+}
+
+func (in FeedbackInput) toRow(userID, familyID uuid.UUID, kind string) *repository.UserFeedback {
+	f := &repository.UserFeedback{
+		UserID:     userID,
+		Kind:       kind,
+		Comment:    strings.TrimSpace(in.Comment),
+		Context:    truncateRunes(strings.TrimSpace(in.Context), maxFeedbackContextLen),
+		Platform:   truncateRunes(strings.ToLower(strings.TrimSpace(in.Platform)), 20),
+		AppVersion: truncateRunes(strings.TrimSpace(in.AppVersion), 40),
+	}
+	if familyID != uuid.Nil {
+		f.FamilyID = models.NullUUID{UUID: familyID, Valid: true}
+	}
+	return f
+}
+
+// SubmitNPS records a 0–10 score. Rejected with ErrNPSThrottled if the user
+// already answered or dismissed within NPSPromptInterval.
+func (s *FeedbackService) SubmitNPS(ctx context.Context, userID, familyID uuid.UUID, in FeedbackInput) (*repository.UserFeedback, error) {
+	if in.Score == nil || *in.Score < 0 || *in.Score > 10 {
+		return nil, fmt.Errorf("%w: score must be 0-10", ErrFeedbackInvalid)
+	}
+	if len(in.Comment) > maxFeedbackCommentLen {
+		return nil, fmt.Errorf("%w: comment is too long", ErrFeedbackInvalid)
+	}
+	if err := s.checkThrottle(ctx, userID); err != nil {
+		return nil, err
+	}
+	f := in.toRow(userID, familyID, repository.FeedbackKindNPS)
+	f.Score = sql.NullInt32{Int32: int32(*in.Score), Valid: true}
+	f.ScoreValue = in.Score
+	if err := s.repo.Create(ctx, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// DismissNPS records that the user closed the prompt without answering so
+// they aren't asked again until the next quarter.
+func (s *FeedbackService) DismissNPS(ctx context.Context, userID, familyID uuid.UUID, in FeedbackInput) error {
+	if err := s.checkThrottle(ctx, userID); err != nil {
+		return err
+	}
+	f := in.toRow(userID, familyID, repository.FeedbackKindNPSDismissed)
+	f.Comment = ""
+	return s.repo.Create(ctx, f)
+}
+
+func (s *FeedbackService) checkThrottle(ctx context.Context, userID uuid.UUID) error {
+	st, err := s.PromptStatus(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !st.Eligible {
+		return ErrNPSThrottled
+	}
+	return nil
+}
+
+// SubmitFeedback records free-text feedback. Not tied to the NPS throttle;
+// users can always reach the feedback form themselves.
+func (s *FeedbackService) SubmitFeedback(ctx context.Context, userID, familyID uuid.UUID, in FeedbackInput) (*repository.UserFeedback, error) {
+	if strings.TrimSpace(in.Comment) == "" {
+		return nil, fmt.Errorf("%w: comment is required", ErrFeedbackInvalid)
+	}
+	if len(in.Comment) > maxFeedbackCommentLen {
+		return nil, fmt.Errorf("%w: comment is too long", ErrFeedbackInvalid)
+	}
+	n, err := s.repo.CountTextSince(ctx, userID, s.now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxFeedbackPerDay {
+		return nil, ErrFeedbackRateLimited
+	}
+	f := in.toRow(userID, familyID, repository.FeedbackKindText)
+	if err := s.repo.Create(ctx, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ListFeedback is the admin list of raw responses.
+func (s *FeedbackService) ListFeedback(ctx context.Context, f repository.FeedbackFilter, page, limit int) ([]repository.UserFeedback, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 25
+	}
+	return s.repo.List(ctx, f, limit, (page-1)*limit)
+}
+
+// npsScore is the standard Net Promoter Score: % promoters minus %
+// detractors, rounded to one decimal. Zero responses score 0.
+func npsScore(promoters, detractors, responses int) float64 {
+	if responses == 0 {
+		return 0
+	}
+	v := float64(promoters-detractors) * 100 / float64(responses)
+	return math.Round(v*10) / 10
+}
+
+func fillNPS(buckets []repository.NPSBucket) []repository.NPSBucket {
+	if buckets == nil {
+		return []repository.NPSBucket{}
+	}
+	for i := range buckets {
+		b := &buckets[i]
+		b.NPS = npsScore(b.Promoters, b.Detractors, b.Responses)
+		b.AvgScore = math.Round(b.AvgScore*100) / 100
+	}
+	return buckets
+}
+
+// NPSReport is the body of GET /feedback/nps/report.
+type NPSReport struct {
+	From     time.Time              `json:"from"`
+	To       time.Time              `json:"to"`
+	Interval string                 `json:"interval"`
+	Overall  repository.NPSBucket   `json:"overall"`
+	Trend    []repository.NPSBucket `json:"trend"`
+	ByPlan   []repository.NPSBucket `json:"by_plan"`
+	ByTenure []repository.NPSBucket `json:"by_tenure"`
+}
+
+// NPSReport builds the score trend for [from, to) plus plan and tenure
+// segmentation over the same window.
+func (s *FeedbackService) NPSReport(ctx context.Context, from, to time.Time, interval string) (*NPSReport, error) {
+	if interval == "" {
+		interval = "month"
+	}
+	if !validNPSIntervals[interval] {
+		return nil, fmt.Errorf("%w: interval must be week, month, or quarter", ErrFeedbackReportRange)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: 'to' must be after 'from'", ErrFeedbackReportRange)
+	}
+	if to.Sub(from) > maxNPSReportSpan {
+		return nil, fmt.Errorf("%w: window is limited to two years", ErrFeedbackReportRange)
+	}
+
+	trend, err := s.repo.NPSTrend(ctx, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	byPlan, err := s.repo.NPSByPlan(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byTenure, err := s.repo.NPSByTenure(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var overall repository.NPSBucket
+	var scoreSum float64
+	for _, b := range trend {
+		overall.Responses += b.Responses
+		overall.Promoters += b.Promoters
+		overall.Passives += b.Passives
+		overall.Detractors += b.Detractors
+		scoreSum += b.AvgScore * float64(b.Responses)
+	}
+	if overall.Responses > 0 {
+		overall.AvgScore = scoreSum / float64(overall.Responses)
+	}
+	overall = fillNPS([]repository.NPSBucket{overall})[0]
+
+	return &NPSReport{
+		From: from, To: to, Interval: interval,
+		Overall:  overall,
+		Trend:    fillNPS(trend),
+		ByPlan:   fillNPS(byPlan),
+		ByTenure: fillNPS(byTenure),
+	}, nil
+}
+
+// truncateRunes cuts s to at most n runes without splitting a character.
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
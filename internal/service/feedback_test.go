@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+func TestNPSScore(t *testing.T) {
+	cases := []struct {
+		name                             string
+		promoters, detractors, responses int
+		want                             float64
+	}{
+		{"no responses", 0, 0, 0, 0},
+		{"all promoters", 10, 0, 10, 100},
+		{"all detractors", 0, 4, 4, -100},
+		{"mixed", 5, 2, 10, 30},
+		{"rounds to one decimal", 1, 0, 3, 33.3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := npsScore(c.promoters, c.detractors, c.responses); got != c.want {
+				t.Errorf("npsScore = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNPSEligibility(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-30 * 24 * time.Hour)
+	old := now.Add(-NPSPromptInterval)
+
+	if st := npsEligibility(nil, now); !st.Eligible || st.NextEligibleAt != nil {
+		t.Errorf("never asked: got %+v, want eligible", st)
+	}
+	st := npsEligibility(&recent, now)
+	if st.Eligible {
+		t.Fatalf("asked 30 days ago: got eligible")
+	}
+	if want := recent.Add(NPSPromptInterval); st.NextEligibleAt == nil || !st.NextEligibleAt.Equal(want) {
+		t.Errorf("next eligible = %v, want %v", st.NextEligibleAt, want)
+	}
+	if st := npsEligibility(&old, now); !st.Eligible {
+		t.Errorf("asked exactly one interval ago: got ineligible")
+	}
+}
+
+// fakeFeedbackRepo records inserts and serves a fixed last-prompt time.
+type fakeFeedbackRepo struct {
+	repository.FeedbackRepository
+	last    *time.Time
+	created []*repository.UserFeedback
+}
+
+func (f *fakeFeedbackRepo) LastNPSPrompt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	return f.last, nil
+}
+
+func (f *fakeFeedbackRepo) Create(ctx context.Context, fb *repository.UserFeedback) error {
+	f.created = append(f.created, fb)
+	return nil
+}
+
+func TestSubmitNPS(t *testing.T) {
+	ctx := context.Background()
+	user := uuid.New()
+	score := func(v int) *int { return &v }
+
+	repo := &fakeFeedbackRepo{}
+	svc := NewFeedbackService(repo)
+
+	if _, err := svc.SubmitNPS(ctx, user, uuid.Nil, FeedbackInput{}); !errors.Is(err, ErrFeedbackInvalid) {
+		t.Errorf("missing score: err = %v, want ErrFeedbackInvalid", err)
+	}
+	if _, err := svc.SubmitNPS(ctx, user, uuid.Nil, FeedbackInput{Score: score(11)}); !errors.Is(err, ErrFeedbackInvalid) {
+		t.Errorf("score 11: err = %v, want ErrFeedbackInvalid", err)
+	}
+
+	fb, err := svc.SubmitNPS(ctx, user, uuid.Nil, FeedbackInput{Score: score(9), Platform: " iOS "})
+	if err != nil {
+		t.Fatalf("valid submit: %v", err)
+	}
+	if fb.Kind != repository.FeedbackKindNPS || !fb.Score.Valid || fb.Score.Int32 != 9 || fb.Platform != "ios" {
+		t.Errorf("stored row = %+v", fb)
+	}
+	if fb.FamilyID.Valid {
+		t.Errorf("family id should be null when no family context")
+	}
+
+	recent := time.Now().Add(-time.Hour)
+	repo.last = &recent
+	if _, err := svc.SubmitNPS(ctx, user, uuid.Nil, FeedbackInput{Score: score(7)}); !errors.Is(err, ErrNPSThrottled) {
+		t.Errorf("second submit: err = %v, want ErrNPSThrottled", err)
+	}
+	if err := svc.DismissNPS(ctx, user, uuid.Nil, FeedbackInput{}); !errors.Is(err, ErrNPSThrottled) {
+		t.Errorf("dismiss after answer: err = %v, want ErrNPSThrottled", err)
+	}
+	if len(repo.created) != 1 {
+		t.Errorf("created %d rows, want 1", len(repo.created))
+	}
+}
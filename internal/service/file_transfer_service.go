@@ -0,0 +1,523 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrTransferInvalid          = apperr.Validation("invalid file transfer request")
+	ErrTransferNotFound         = apperr.NotFound("file not found")
+	ErrTransferTooLarge         = apperr.Validation("file exceeds the per-file size limit")
+	ErrTransferQuotaExceeded    = errors.New("file transfer storage quota exceeded")
+	ErrTransferExpired          = errors.New("file or link has expired")
+	ErrTransferPasswordRequired = apperr.Validation("password required")
+	ErrTransferPasswordWrong    = errors.New("incorrect password")
+)
+
+const (
+	transferNamespace      = "file_transfers"
+	minTransferTTL         = 5 * time.Minute
+	minSharePasswordLen    = 8
+	maxTransferPreviewSize = 1 << 20
+	transferSweepBatch     = 100
+)
+
+// FileTransferLimits bounds the file drop; NewServices fills it from the
+// Transfer* fields of config.StorageConfig.
+type FileTransferLimits struct {
+	MaxFileBytes int64
+	QuotaBytes   int64
+	DefaultTTL   time.Duration
+	MaxTTL       time.Duration
+}
+
+// FileTransferService is the admin file drop that replaced the old
+// unauthenticated /filextfer handlers: expiring files, a total storage
+// quota, checksums, and optional password-protected share links.
+type FileTransferService struct {
+	repo    repository.FileTransferRepository
+	storage BlobStorage
+	limits  FileTransferLimits
+	scan    *UploadScanService
+	now     func() time.Time
+
+	// quotaMu serializes uploads so two concurrent ones can't both pass
+	// the quota check. Uploads are rare admin actions, so this is fine.
+	quotaMu sync.Mutex
+}
+
+// NewFileTransferService creates a new file transfer service
+func NewFileTransferService(repo repository.FileTransferRepository, storage BlobStorage, limits FileTransferLimits) *FileTransferService {
+	return &FileTransferService{repo: repo, storage: storage, limits: limits, now: time.Now}
+}
+
+// SetScanService routes uploads through the antivirus stage. Without it
+// files are stored unscanned.
+func (s *FileTransferService) SetScanService(scan *UploadScanService) {
+	s.scan = scan
+}
+
+// Limits returns the configured size, quota and expiry bounds.
+func (s *FileTransferService) Limits() FileTransferLimits {
+	return s.limits
+}
+
+// resolveTTL applies the default and bounds a requested lifetime.
+func (s *FileTransferService) resolveTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl == 0 {
+		return s.limits.DefaultTTL, nil
+	}
+	if ttl < minTransferTTL || ttl > s.limits.MaxTTL {
+		return 0, fmt.Errorf("%w: expiry must be between %s and %s", ErrTransferInvalid, minTransferTTL, s.limits.MaxTTL)
+	}
+	return ttl, nil
+}
+
+// cleanTransferName keeps only the base name so uploads can't smuggle
+// paths; the stored object key is a UUID regardless.
+func cleanTransferName(name string) (string, error) {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" || name == ".." || len(name) > 255 || !utf8.ValidString(name) {
+		return "", fmt.Errorf("%w: a valid filename is required", ErrTransferInvalid)
+	}
+	return name, nil
+}
+
+// TransferUploadInput is one new file.
+type TransferUploadInput struct {
+	Filename    string
+	ContentType string
+	Note        string
+	TTL         time.Duration
+	Body        io.Reader
+	UploadedBy  uuid.UUID
+}
+
+// Upload streams a file into storage, hashing it on the way, and records
+// it. The per-file limit is the smaller of the configured maximum and the
+// quota still free.
+func (s *FileTransferService) Upload(ctx context.Context, in TransferUploadInput) (*repository.FileTransfer, error) {
+	name, err := cleanTransferName(in.Filename)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := s.resolveTTL(in.TTL)
+	if err != nil {
+		return nil, err
+	}
+	ctype := strings.TrimSpace(in.ContentType)
+	if ctype == "" || len(ctype) > 100 {
+		ctype = "application/octet-stream"
+	}
+
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+
+	used, err := s.repo.TotalBytes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limit, limitErr := s.limits.MaxFileBytes, ErrTransferTooLarge
+	if free := s.limits.QuotaBytes - used; free < limit {
+		limit, limitErr = free, ErrTransferQuotaExceeded
+	}
+	if limit <= 0 {
+		return nil, ErrTransferQuotaExceeded
+	}
+
+	hash := sha256.New()
+	body := io.TeeReader(io.LimitReader(in.Body, limit+1), hash)
+	path, size, err := s.storage.Save(ctx, transferNamespace, name, ctype, body)
+	if err != nil {
+		return nil, fmt.Errorf("store file: %w", err)
+	}
+	if size > limit {
+		s.deleteBlob(ctx, path)
+		return nil, limitErr
+	}
+	scanStatus, err := s.scan.Check(ctx, ScanTarget{
+		Source:      ScanSourceFileTransfer,
+		Filename:    name,
+		ContentType: ctype,
+		Size:        size,
+		UploadedBy:  in.UploadedBy,
+		Open:        blobOpener(s.storage, path),
+	})
+	if err != nil {
+		s.deleteBlob(ctx, path)
+		return nil, err
+	}
+
+	f := &repository.FileTransfer{
+		Filename:      name,
+		ContentType:   ctype,
+		SizeBytes:     size,
+		SHA256:        hex.EncodeToString(hash.Sum(nil)),
+		StorageDriver: s.storage.Driver(),
+		StoragePath:   path,
+		Note:          truncateRunes(strings.TrimSpace(in.Note), 500),
+		UploadedBy:    models.NullUUID{UUID: in.UploadedBy, Valid: in.UploadedBy != uuid.Nil},
+		ExpiresAt:     s.now().Add(ttl),
+		ScanStatus:    scanStatus,
+	}
+	if err := s.repo.Create(ctx, f); err != nil {
+		s.deleteBlob(ctx, path)
+		return nil, err
+	}
+	return f, nil
+}
+
+// SaveText stores pasted text as a file.
+func (s *FileTransferService) SaveText(ctx context.Context, filename, content, note string, ttl time.Duration, by uuid.UUID) (*repository.FileTransfer, error) {
+	return s.Upload(ctx, TransferUploadInput{
+		Filename:    filename,
+		ContentType: "text/plain; charset=utf-8",
+		Note:        note,
+		TTL:         ttl,
+		Body:        strings.NewReader(content),
+		UploadedBy:  by,
+	})
+}
+
+// UploadPurposeFileTransfer routes resumable uploads into the file drop.
+const UploadPurposeFileTransfer = "file_transfer"
+
+// uploadTTL reads the optional ttl_hours upload metadata; 0 means default.
+func uploadTTL(meta map[string]string) (time.Duration, error) {
+	v := strings.TrimSpace(meta["ttl_hours"])
+	if v == "" {
+		return 0, nil
+	}
+	h, err := strconv.Atoi(v)
+	if err != nil || h < 0 {
+		return 0, fmt.Errorf("%w: ttl_hours must be a whole number of hours", ErrTransferInvalid)
+	}
+	return time.Duration(h) * time.Hour, nil
+}
+
+// CheckUpload vets a resumable upload against the name, expiry, size and
+// quota rules before any bytes are sent. The quota is checked again when
+// the file lands, since other uploads may have finished in between.
+func (s *FileTransferService) CheckUpload(ctx context.Context, u *repository.UploadSession) error {
+	if _, err := cleanTransferName(u.Filename); err != nil {
+		return err
+	}
+	ttl, err := uploadTTL(u.Metadata)
+	if err != nil {
+		return err
+	}
+	if _, err := s.resolveTTL(ttl); err != nil {
+		return err
+	}
+	if u.TotalBytes > s.limits.MaxFileBytes {
+		return ErrTransferTooLarge
+	}
+	used, err := s.repo.TotalBytes(ctx)
+	if err != nil {
+		return err
+	}
+	if used+u.TotalBytes > s.limits.QuotaBytes {
+		return ErrTransferQuotaExceeded
+	}
+	return nil
+}
+
+// FinishUpload stores an assembled resumable upload as a file.
+func (s *FileTransferService) FinishUpload(ctx context.Context, u *repository.UploadSession, body io.Reader) (uuid.UUID, error) {
+	ttl, err := uploadTTL(u.Metadata)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	f, err := s.Upload(ctx, TransferUploadInput{
+		Filename:    u.Filename,
+		ContentType: u.ContentType,
+		Note:        u.Metadata["note"],
+		TTL:         ttl,
+		Body:        body,
+		UploadedBy:  u.CreatedBy.UUID,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return f.ID, nil
+}
+
+func (s *FileTransferService) deleteBlob(ctx context.Context, path string) {
+	if err := s.storage.Delete(ctx, path); err != nil {
+		log.Printf("[FILEXFER] failed to delete blob %s: %v", path, err)
+	}
+}
+
+// FileTransferUsage is the quota summary shown above the file list.
+type FileTransferUsage struct {
+	UsedBytes    int64 `json:"used_bytes"`
+	QuotaBytes   int64 `json:"quota_bytes"`
+	MaxFileBytes int64 `json:"max_file_bytes"`
+	DefaultTTLH  int   `json:"default_ttl_hours"`
+	MaxTTLH      int   `json:"max_ttl_hours"`
+}
+
+// List returns every stored file, newest first, with quota usage.
+func (s *FileTransferService) List(ctx context.Context) ([]repository.FileTransfer, *FileTransferUsage, error) {
+	files, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if files == nil {
+		files = []repository.FileTransfer{}
+	}
+	usage := &FileTransferUsage{
+		QuotaBytes:   s.limits.QuotaBytes,
+		MaxFileBytes: s.limits.MaxFileBytes,
+		DefaultTTLH:  int(s.limits.DefaultTTL / time.Hour),
+		MaxTTLH:      int(s.limits.MaxTTL / time.Hour),
+	}
+	for _, f := range files {
+		usage.UsedBytes += f.SizeBytes
+	}
+	return files, usage, nil
+}
+
+// Get returns a file that has not expired.
+func (s *FileTransferService) Get(ctx context.Context, id uuid.UUID) (*repository.FileTransfer, error) {
+	f, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil || !s.now().Before(f.ExpiresAt) {
+		return nil, ErrTransferNotFound
+	}
+	return f, nil
+}
+
+// Open returns the file and its content, counting the download.
+func (s *FileTransferService) Open(ctx context.Context, id uuid.UUID) (*repository.FileTransfer, io.ReadCloser, error) {
+	f, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, f)
+}
+
+func (s *FileTransferService) open(ctx context.Context, f *repository.FileTransfer) (*repository.FileTransfer, io.ReadCloser, error) {
+	rc, err := s.storage.Open(ctx, f.StoragePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open file: %w", err)
+	}
+	if err := s.repo.RecordDownload(ctx, f.ID); err != nil {
+		log.Printf("[FILEXFER] download count for %s not recorded: %v", f.ID, err)
+	}
+	return f, rc, nil
+}
+
+// Preview returns a small text file's content for in-browser viewing.
+func (s *FileTransferService) Preview(ctx context.Context, id uuid.UUID) (*repository.FileTransfer, string, error) {
+	f, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if f.SizeBytes > maxTransferPreviewSize {
+		return nil, "", fmt.Errorf("%w: file is too large to preview", ErrTransferInvalid)
+	}
+	rc, err := s.storage.Open(ctx, f.StoragePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("open file: %w", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxTransferPreviewSize))
+	if err != nil {
+		return nil, "", err
+	}
+	if !utf8.Valid(data) {
+		return nil, "", fmt.Errorf("%w: file is not text", ErrTransferInvalid)
+	}
+	return f, string(data), nil
+}
+
+// Delete removes a file and its stored bytes.
+func (s *FileTransferService) Delete(ctx context.Context, id uuid.UUID) (*repository.FileTransfer, error) {
+	f, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrTransferNotFound
+	}
+	if err := s.storage.Delete(ctx, f.StoragePath); err != nil {
+		return nil, fmt.Errorf("delete file: %w", err)
+	}
+	return f, s.repo.Delete(ctx, id)
+}
+
+// ShareLink is a freshly created share link. The token is only returned
+// here; the list view shows it too so admins can copy it again.
+type ShareLink struct {
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	HasPassword bool      `json:"has_password"`
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateShare issues (or replaces) the file's share link. The link never
+// outlives the file.
+func (s *FileTransferService) CreateShare(ctx context.Context, id uuid.UUID, password string, ttl time.Duration, by uuid.UUID) (*ShareLink, error) {
+	f, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ttl, err = s.resolveTTL(ttl); err != nil {
+		return nil, err
+	}
+	expires := s.now().Add(ttl)
+	if f.ExpiresAt.Before(expires) {
+		expires = f.ExpiresAt
+	}
+	var hash string
+	if password != "" {
+		if len(password) < minSharePasswordLen {
+			return nil, fmt.Errorf("%w: share password must be at least %d characters", ErrTransferInvalid, minSharePasswordLen)
+		}
+		h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		hash = string(h)
+	}
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetShare(ctx, id, token, hash, expires, by); err != nil {
+		return nil, err
+	}
+	return &ShareLink{Token: token, ExpiresAt: expires, HasPassword: hash != ""}, nil
+}
+
+// RevokeShare disables the file's share link.
+func (s *FileTransferService) RevokeShare(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.ClearShare(ctx, id)
+}
+
+// LookupShare resolves a share token to its file without checking the
+// password, for the landing page.
+func (s *FileTransferService) LookupShare(ctx context.Context, token string) (*repository.FileTransfer, error) {
+	if token == "" {
+		return nil, ErrTransferNotFound
+	}
+	f, err := s.repo.GetByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrTransferNotFound
+	}
+	now := s.now()
+	if !now.Before(f.ExpiresAt) || f.ShareExpiresAt == nil || !now.Before(*f.ShareExpiresAt) {
+		return nil, ErrTransferExpired
+	}
+	return f, nil
+}
+
+// OpenShared checks the link password and opens the file.
+func (s *FileTransferService) OpenShared(ctx context.Context, token, password string) (*repository.FileTransfer, io.ReadCloser, error) {
+	f, err := s.LookupShare(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if f.SharePasswordHash != "" {
+		if password == "" {
+			return nil, nil, ErrTransferPasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(f.SharePasswordHash), []byte(password)) != nil {
+			return nil, nil, ErrTransferPasswordWrong
+		}
+	}
+	return s.open(ctx, f)
+}
+
+// SweepExpired deletes files past their expiry, bytes first so a failed
+// blob delete leaves the row for the next sweep.
+func (s *FileTransferService) SweepExpired(ctx context.Context) (int, error) {
+	expired, err := s.repo.ListExpired(ctx, s.now(), transferSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, f := range expired {
+		if err := s.storage.Delete(ctx, f.StoragePath); err != nil {
+			log.Printf("[FILEXFER] sweep: blob %s not deleted: %v", f.StoragePath, err)
+			continue
+		}
+		if err := s.repo.Delete(ctx, f.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// FileTransferSweeper runs SweepExpired periodically.
+type FileTransferSweeper struct {
+	svc  *FileTransferService
+	jobs *JobLocker
+}
+
+func NewFileTransferSweeper(svc *FileTransferService, jobs *JobLocker) *FileTransferSweeper {
+	return &FileTransferSweeper{svc: svc, jobs: jobs}
+}
+
+func (s *FileTransferSweeper) Start(ctx context.Context) {
+	log.Println("File transfer expiry sweeper started")
+	sweep := func() {
+		s.jobs.RunOnce(ctx, "file_transfer_sweep", TickSlot(time.Now(), 15*time.Minute), 15*time.Minute, func(ctx context.Context) {
+			if n, err := s.svc.SweepExpired(ctx); err != nil {
+				log.Printf("File transfer sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("File transfer sweep: deleted %d expired file(s)", n)
+			}
+		})
+	}
+	sweep()
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("File transfer expiry sweeper stopped")
+			return
+		case <-ticker.C:
+			sweep()
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// fileTransferRepo is an in-memory FileTransferRepository.
+type fileTransferRepo struct {
+	repository.FileTransferRepository
+	files map[uuid.UUID]*repository.FileTransfer
+}
+
+func (r *fileTransferRepo) Create(ctx context.Context, f *repository.FileTransfer) error {
+	f.ID = uuid.New()
+	cp := *f
+	r.files[f.ID] = &cp
+	return nil
+}
+
+func (r *fileTransferRepo) GetByID(ctx context.Context, id uuid.UUID) (*repository.FileTransfer, error) {
+	if f, ok := r.files[id]; ok {
+		cp := *f
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (r *fileTransferRepo) GetByShareToken(ctx context.Context, token string) (*repository.FileTransfer, error) {
+	for _, f := range r.files {
+		if f.ShareToken == token {
+			cp := *f
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fileTransferRepo) TotalBytes(ctx context.Context) (int64, error) {
+	var n int64
+	for _, f := range r.files {
+		n += f.SizeBytes
+	}
+	return n, nil
+}
+
+func (r *fileTransferRepo) SetShare(ctx context.Context, id uuid.UUID, token, hash string, exp time.Time, by uuid.UUID) error {
+	f := r.files[id]
+	f.ShareToken, f.SharePasswordHash, f.ShareExpiresAt = token, hash, &exp
+	f.HasSharePassword = hash != ""
+	return nil
+}
+
+func (r *fileTransferRepo) RecordDownload(ctx context.Context, id uuid.UUID) error {
+	r.files[id].DownloadCount++
+	return nil
+}
+
+func (r *fileTransferRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.files, id)
+	return nil
+}
+
+func (r *fileTransferRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]repository.FileTransfer, error) {
+	var out []repository.FileTransfer
+	for _, f := range r.files {
+		if !now.Before(f.ExpiresAt) {
+			out = append(out, *f)
+		}
+	}
+	return out, nil
+}
+
+func newTestFileTransferService() (*FileTransferService, *fileTransferRepo, *memBlobStorage) {
+	repo := &fileTransferRepo{files: map[uuid.UUID]*repository.FileTransfer{}}
+	store := &memBlobStorage{blobs: map[string][]byte{}}
+	svc := NewFileTransferService(repo, store, FileTransferLimits{
+		MaxFileBytes: 10, QuotaBytes: 25, DefaultTTL: 24 * time.Hour, MaxTTL: 72 * time.Hour,
+	})
+	return svc, repo, store
+}
+
+func TestFileTransferUploadLimits(t *testing.T) {
+	svc, _, store := newTestFileTransferService()
+	ctx := context.Background()
+
+	f, err := svc.SaveText(ctx, "../../etc/notes.txt", "0123456789", "", 0, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("0123456789"))
+	if f.Filename != "notes.txt" || f.SHA256 != hex.EncodeToString(sum[:]) || f.SizeBytes != 10 {
+		t.Errorf("file = %+v", f)
+	}
+
+	if _, err := svc.SaveText(ctx, "big.txt", strings.Repeat("x", 11), "", 0, uuid.Nil); !errors.Is(err, ErrTransferTooLarge) {
+		t.Errorf("over per-file limit: err = %v", err)
+	}
+	if _, err := svc.SaveText(ctx, "b.txt", "0123456789", "", 0, uuid.Nil); err != nil {
+		t.Fatal(err)
+	}
+	// 20 of 25 bytes used: a 6-byte file fits the per-file limit but not the quota.
+	if _, err := svc.SaveText(ctx, "c.txt", "012345", "", 0, uuid.Nil); !errors.Is(err, ErrTransferQuotaExceeded) {
+		t.Errorf("over quota: err = %v", err)
+	}
+	if len(store.blobs) != 2 {
+		t.Errorf("rejected uploads left %d blobs, want 2", len(store.blobs))
+	}
+
+	for _, ttl := range []time.Duration{time.Minute, 73 * time.Hour} {
+		if _, err := svc.SaveText(ctx, "d.txt", "x", "", ttl, uuid.Nil); !errors.Is(err, ErrTransferInvalid) {
+			t.Errorf("ttl %s: err = %v", ttl, err)
+		}
+	}
+	for _, name := range []string{"", "  ", "..", "/"} {
+		if _, err := cleanTransferName(name); !errors.Is(err, ErrTransferInvalid) {
+			t.Errorf("cleanTransferName(%q) err = %v", name, err)
+		}
+	}
+}
+
+func TestFileTransferShareLinks(t *testing.T) {
+	svc, repo, _ := newTestFileTransferService()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	f, err := svc.SaveText(ctx, "a.txt", "hello", "", 2*time.Hour, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CreateShare(ctx, f.ID, "short", 0, uuid.Nil); !errors.Is(err, ErrTransferInvalid) {
+		t.Errorf("short password: err = %v", err)
+	}
+	link, err := svc.CreateShare(ctx, f.ID, "correct horse", 48*time.Hour, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !link.ExpiresAt.Equal(f.ExpiresAt) {
+		t.Errorf("link expires %s, want capped at file expiry %s", link.ExpiresAt, f.ExpiresAt)
+	}
+
+	if _, _, err := svc.OpenShared(ctx, link.Token, ""); !errors.Is(err, ErrTransferPasswordRequired) {
+		t.Errorf("no password: err = %v", err)
+	}
+	if _, _, err := svc.OpenShared(ctx, link.Token, "wrong password"); !errors.Is(err, ErrTransferPasswordWrong) {
+		t.Errorf("wrong password: err = %v", err)
+	}
+	_, rc, err := svc.OpenShared(ctx, link.Token, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "hello" || repo.files[f.ID].DownloadCount != 1 {
+		t.Errorf("body = %q, downloads = %d", body, repo.files[f.ID].DownloadCount)
+	}
+
+	now = now.Add(3 * time.Hour)
+	if _, err := svc.LookupShare(ctx, link.Token); !errors.Is(err, ErrTransferExpired) {
+		t.Errorf("after expiry: err = %v", err)
+	}
+	if n, err := svc.SweepExpired(ctx); err != nil || n != 1 || len(repo.files) != 0 {
+		t.Errorf("sweep: n=%d err=%v remaining=%d", n, err, len(repo.files))
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	// ErrFoodVisionUnavailable is returned when no vision provider is
+	// configured or the provider couldn't be reached.
+	ErrFoodVisionUnavailable = errors.New("meal photo recognition is unavailable")
+	// ErrFoodPhotoInvalid is returned for an upload that isn't a usable
+	// photo; the message says why.
+	ErrFoodPhotoInvalid = apperr.Validation("invalid meal photo")
+	// ErrFoodRecognitionNotFound is returned when a diet log names a
+	// recognition that doesn't exist or belongs to another child.
+	ErrFoodRecognitionNotFound = apperr.NotFound("meal photo recognition not found")
+)
+
+// FoodVisionProvider suggests the foods in a meal photo. Implementations:
+// BedrockFoodVisionProvider.
+type FoodVisionProvider interface {
+	Name() string
+	Model() string
+	SuggestFoods(ctx context.Context, image []byte, mediaType string) ([]models.FoodSuggestion, error)
+}
+
+const (
+	maxFoodSuggestions = 12
+	// Suggestions below this are noise more often than not.
+	minFoodSuggestionConfidence = 0.15
+	maxFoodSuggestionLen        = 100
+)
+
+// foodPhotoMediaTypes are the formats vision providers accept. HEIC
+// photos are converted by the apps before upload.
+var foodPhotoMediaTypes = []string{"image/jpeg", "image/png", "image/webp", "image/gif"}
+
+// FoodPhotoService turns a meal photo into confidence-ranked foods_eaten
+// suggestions for the caregiver to confirm, and records on diet logs
+// which foods came from a photo. Photos are sent to the provider and
+// hashed, never stored.
+type FoodPhotoService struct {
+	repo     repository.FoodRecognitionRepository
+	provider FoodVisionProvider
+	maxBytes int
+}
+
+// NewFoodPhotoService creates the service. provider may be nil, which
+// leaves recognition off; provenance for existing recognitions still
+// works.
+func NewFoodPhotoService(repo repository.FoodRecognitionRepository, provider FoodVisionProvider, maxBytes int) *FoodPhotoService {
+	return &FoodPhotoService{repo: repo, provider: provider, maxBytes: maxBytes}
+}
+
+// Enabled reports whether a vision provider is configured.
+func (s *FoodPhotoService) Enabled() bool {
+	return s.provider != nil
+}
+
+// MaxBytes is the largest photo accepted.
+func (s *FoodPhotoService) MaxBytes() int {
+	return s.maxBytes
+}
+
+// Recognize sends the photo to the vision provider and stores its
+// suggestions against the child.
+func (s *FoodPhotoService) Recognize(ctx context.Context, childID, userID uuid.UUID, photo io.Reader) (*models.FoodRecognition, error) {
+	if s.provider == nil {
+		return nil, ErrFoodVisionUnavailable
+	}
+	data, err := io.ReadAll(io.LimitReader(photo, int64(s.maxBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: the photo is empty", ErrFoodPhotoInvalid)
+	}
+	if len(data) > s.maxBytes {
+		return nil, fmt.Errorf("%w: photos can be at most %d MB", ErrFoodPhotoInvalid, s.maxBytes/(1024*1024))
+	}
+	mediaType := http.DetectContentType(data)
+	if !slices.Contains(foodPhotoMediaTypes, mediaType) {
+		return nil, fmt.Errorf("%w: use a JPEG, PNG, WebP or GIF photo", ErrFoodPhotoInvalid)
+	}
+
+	suggestions, err := s.provider.SuggestFoods(ctx, data, mediaType)
+	if err != nil {
+		log.Printf("Food photo: %s provider failed: %v", s.provider.Name(), err)
+		return nil, fmt.Errorf("%w: %v", ErrFoodVisionUnavailable, err)
+	}
+	sum := sha256.Sum256(data)
+	fr := &models.FoodRecognition{
+		ChildID:     childID,
+		Provider:    s.provider.Name(),
+		ImageSHA256: hex.EncodeToString(sum[:]),
+		Suggestions: rankFoodSuggestions(suggestions),
+		CreatedBy:   &userID,
+	}
+	fr.Model.String = s.provider.Model()
+	fr.Model.Valid = fr.Model.String != ""
+	if err := s.repo.Create(ctx, fr); err != nil {
+		return nil, err
+	}
+	return fr, nil
+}
+
+// rankFoodSuggestions cleans up what the provider returned: names
+// trimmed and lower-cased, duplicates merged keeping the higher
+// confidence, low-confidence guesses dropped, most confident first.
+func rankFoodSuggestions(in []models.FoodSuggestion) models.FoodSuggestions {
+	byKey := map[string]models.FoodSuggestion{}
+	for _, sg := range in {
+		sg.Name = strings.ToLower(strings.Join(strings.Fields(sg.Name), " "))
+		sg.Portion = strings.TrimSpace(sg.Portion)
+		if sg.Name == "" || len(sg.Name) > maxFoodSuggestionLen {
+			continue
+		}
+		sg.Confidence = min(max(sg.Confidence, 0), 1)
+		if sg.Confidence < minFoodSuggestionConfidence {
+			continue
+		}
+		key := strings.Join(foodWords(sg.Name), " ")
+		if prev, ok := byKey[key]; !ok || sg.Confidence > prev.Confidence {
+			byKey[key] = sg
+		}
+	}
+	out := make(models.FoodSuggestions, 0, len(byKey))
+	for _, sg := range byKey {
+		out = append(out, sg)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Confidence != out[j].Confidence {
+			return out[i].Confidence > out[j].Confidence
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > maxFoodSuggestions {
+		out = out[:maxFoodSuggestions]
+	}
+	return out
+}
+
+// Provenance fills the diet log's FoodProvenance from the recognition it
+// names. A log without a recognition is left alone.
+func (s *FoodPhotoService) Provenance(ctx context.Context, l *models.DietLog) error {
+	if l.PhotoRecognitionID == nil {
+		l.FoodProvenance = nil
+		return nil
+	}
+	fr, err := s.repo.GetByID(ctx, *l.PhotoRecognitionID)
+	if err != nil {
+		return err
+	}
+	if fr == nil || fr.ChildID != l.ChildID {
+		return ErrFoodRecognitionNotFound
+	}
+	l.FoodProvenance = foodProvenance(l.FoodsEaten, fr.Suggestions)
+	return nil
+}
+
+// LinkDietLog records the saved log on its recognition. The log already
+// carries the link, so a failure is only logged.
+func (s *FoodPhotoService) LinkDietLog(ctx context.Context, l *models.DietLog) {
+	if l.PhotoRecognitionID == nil {
+		return
+	}
+	if err := s.repo.SetDietLog(ctx, *l.PhotoRecognitionID, l.ID); err != nil {
+		log.Printf("Food photo: link recognition %s to diet log %s: %v", *l.PhotoRecognitionID, l.ID, err)
+	}
+}
+
+// foodProvenance says where each food came from: a suggestion confirmed
+// as is, one the caregiver reworded ("toast" for "buttered toast"), or
+// typed in.
+func foodProvenance(foods []string, suggestions models.FoodSuggestions) models.FoodProvenanceList {
+	out := make(models.FoodProvenanceList, 0, len(foods))
+	for _, food := range foods {
+		p := models.FoodProvenance{Food: food, Source: models.FoodSourceManual}
+		words := foodWords(food)
+		for _, sg := range suggestions {
+			sw := foodWords(sg.Name)
+			conf := sg.Confidence
+			switch {
+			case slices.Equal(words, sw):
+				p.Source, p.Confidence = models.FoodSourcePhoto, &conf
+			case p.Source == models.FoodSourceManual && (termMatches(sw, words) || termMatches(words, sw)):
+				p.Source, p.Confidence = models.FoodSourcePhotoEdited, &conf
+			default:
+				continue
+			}
+			if p.Source == models.FoodSourcePhoto {
+				break
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeFoodRecognitionRepo struct {
+	byID map[uuid.UUID]*models.FoodRecognition
+}
+
+func (f *fakeFoodRecognitionRepo) Create(ctx context.Context, fr *models.FoodRecognition) error {
+	fr.ID = uuid.New()
+	f.byID[fr.ID] = fr
+	return nil
+}
+
+func (f *fakeFoodRecognitionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.FoodRecognition, error) {
+	return f.byID[id], nil
+}
+
+func (f *fakeFoodRecognitionRepo) SetDietLog(ctx context.Context, id, dietLogID uuid.UUID) error {
+	f.byID[id].DietLogID = &dietLogID
+	return nil
+}
+
+type fakeFoodVision struct {
+	suggestions []models.FoodSuggestion
+	err         error
+	mediaType   string
+}
+
+func (f *fakeFoodVision) Name() string  { return "fake" }
+func (f *fakeFoodVision) Model() string { return "" }
+
+func (f *fakeFoodVision) SuggestFoods(ctx context.Context, image []byte, mediaType string) ([]models.FoodSuggestion, error) {
+	f.mediaType = mediaType
+	return f.suggestions, f.err
+}
+
+// pngHeader is enough for http.DetectContentType to call it a PNG.
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+func TestRankFoodSuggestions(t *testing.T) {
+	got := rankFoodSuggestions([]models.FoodSuggestion{
+		{Name: "Apple  Slices", Confidence: 0.6},
+		{Name: "apple slice", Confidence: 0.8, Portion: " 4 pieces "},
+		{Name: "Milk", Confidence: 1.4},
+		{Name: "napkin", Confidence: 0.05},
+		{Name: "  ", Confidence: 0.9},
+	})
+	if len(got) != 2 {
+		t.Fatalf("got %+v", got)
+	}
+	if got[0].Name != "milk" || got[0].Confidence != 1 {
+		t.Errorf("first = %+v", got[0])
+	}
+	if got[1].Name != "apple slice" || got[1].Confidence != 0.8 || got[1].Portion != "4 pieces" {
+		t.Errorf("second = %+v", got[1])
+	}
+}
+
+func TestFoodProvenance(t *testing.T) {
+	suggestions := models.FoodSuggestions{
+		{Name: "buttered toast", Confidence: 0.9},
+		{Name: "orange juice", Confidence: 0.7},
+	}
+	got := foodProvenance([]string{"Orange juice", "toast", "yogurt"}, suggestions)
+	want := []string{models.FoodSourcePhoto, models.FoodSourcePhotoEdited, models.FoodSourceManual}
+	for i, p := range got {
+		if p.Source != want[i] {
+			t.Errorf("%q source = %s, want %s", p.Food, p.Source, want[i])
+		}
+	}
+	if got[0].Confidence == nil || *got[0].Confidence != 0.7 || got[2].Confidence != nil {
+		t.Errorf("confidences = %+v", got)
+	}
+}
+
+func TestFoodPhotoRecognizeAndProvenance(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeFoodRecognitionRepo{byID: map[uuid.UUID]*models.FoodRecognition{}}
+	childID := uuid.New()
+
+	if _, err := NewFoodPhotoService(repo, nil, 1024).Recognize(ctx, childID, uuid.New(), bytes.NewReader(pngHeader)); !errors.Is(err, ErrFoodVisionUnavailable) {
+		t.Errorf("no provider: %v", err)
+	}
+
+	vision := &fakeFoodVision{suggestions: []models.FoodSuggestion{{Name: "Rice", Confidence: 0.5}, {Name: "Peas", Confidence: 0.9}}}
+	svc := NewFoodPhotoService(repo, vision, 1024)
+	for name, body := range map[string][]byte{
+		"empty":        nil,
+		"too big":      append(append([]byte{}, pngHeader...), make([]byte, 1024)...),
+		"not an image": []byte("hello, world"),
+	} {
+		if _, err := svc.Recognize(ctx, childID, uuid.New(), bytes.NewReader(body)); !errors.Is(err, ErrFoodPhotoInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	fr, err := svc.Recognize(ctx, childID, uuid.New(), bytes.NewReader(pngHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vision.mediaType != "image/png" || fr.Provider != "fake" || fr.Model.Valid || len(fr.ImageSHA256) != 64 {
+		t.Errorf("recognition = %+v (media type %s)", fr, vision.mediaType)
+	}
+	if len(fr.Suggestions) != 2 || fr.Suggestions[0].Name != "peas" {
+		t.Errorf("suggestions = %+v", fr.Suggestions)
+	}
+
+	l := &models.DietLog{ID: uuid.New(), ChildID: childID, PhotoRecognitionID: &fr.ID, FoodsEaten: models.StringArray{"peas", "chicken"}}
+	if err := svc.Provenance(ctx, l); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.FoodProvenance) != 2 || l.FoodProvenance[0].Source != models.FoodSourcePhoto || l.FoodProvenance[1].Source != models.FoodSourceManual {
+		t.Errorf("provenance = %+v", l.FoodProvenance)
+	}
+	svc.LinkDietLog(ctx, l)
+	if fr.DietLogID == nil || *fr.DietLogID != l.ID {
+		t.Errorf("recognition not linked: %v", fr.DietLogID)
+	}
+
+	other := &models.DietLog{ChildID: uuid.New(), PhotoRecognitionID: &fr.ID}
+	if err := svc.Provenance(ctx, other); !errors.Is(err, ErrFoodRecognitionNotFound) {
+		t.Errorf("other child's recognition: %v", err)
+	}
+
+	vision.err = errors.New("throttled")
+	if _, err := svc.Recognize(ctx, childID, uuid.New(), bytes.NewReader(pngHeader)); !errors.Is(err, ErrFoodVisionUnavailable) {
+		t.Errorf("provider failure: %v", err)
+	}
+}
+
+func TestParseFoodSuggestions(t *testing.T) {
+	got, err := parseFoodSuggestions("Here you go:\n```json\n[{\"name\":\"pasta\",\"confidence\":0.8,\"portion\":\"1 bowl\"}]\n```")
+	if err != nil || len(got) != 1 || got[0].Name != "pasta" || got[0].Portion != "1 bowl" {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	if got, err := parseFoodSuggestions("[]"); err != nil || len(got) != 0 {
+		t.Errorf("empty: %+v, %v", got, err)
+	}
+	if _, err := parseFoodSuggestions("I can't tell."); err == nil {
+		t.Error("expected an error for a reply without JSON")
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+
+	"carecompanion/internal/models"
+)
+
+const (
+	foodVisionTimeout   = 30 * time.Second
+	foodVisionMaxTokens = 1024
+)
+
+const foodVisionSystemPrompt = `You identify foods in photos of a child's meal for a caregiver's diet log.
+List each distinct food or drink you can see, using short everyday names a parent would write ("chicken nuggets", "apple slices", "milk").
+Do not guess at foods you cannot see, and ignore plates, cutlery and packaging unless the packaging names the food.
+Respond with only a JSON array of objects with these fields:
+  "name": the food's name
+  "confidence": how sure you are it is that food, from 0 to 1
+  "portion": optional rough amount ("half a cup", "2 pieces")
+Return [] if the photo does not show food.`
+
+// BedrockFoodVisionProvider suggests foods with Claude on AWS Bedrock, under
+// the same BAA as AI insights. Only the photo is sent: no child details.
+type BedrockFoodVisionProvider struct {
+	client *bedrockruntime.Client
+	model  string
+}
+
+// NewBedrockFoodVisionProvider loads AWS credentials from the default
+// chain, like NewAIInsightService.
+func NewBedrockFoodVisionProvider(model string) (*BedrockFoodVisionProvider, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+	return &BedrockFoodVisionProvider{client: bedrockruntime.NewFromConfig(awsCfg), model: model}, nil
+}
+
+func (p *BedrockFoodVisionProvider) Name() string  { return "bedrock" }
+func (p *BedrockFoodVisionProvider) Model() string { return p.model }
+
+// foodVisionRequest is claudeRequest with content blocks, which images
+// need.
+type foodVisionRequest struct {
+	AnthropicVersion string              `json:"anthropic_version"`
+	MaxTokens        int                 `json:"max_tokens"`
+	System           string              `json:"system"`
+	Messages         []foodVisionMessage `json:"messages"`
+}
+
+type foodVisionMessage struct {
+	Role    string              `json:"role"`
+	Content []foodVisionContent `json:"content"`
+}
+
+type foodVisionContent struct {
+	Type   string            `json:"type"`
+	Text   string            `json:"text,omitempty"`
+	Source *foodVisionSource `json:"source,omitempty"`
+}
+
+type foodVisionSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+func (p *BedrockFoodVisionProvider) SuggestFoods(ctx context.Context, image []byte, mediaType string) ([]models.FoodSuggestion, error) {
+	ctx, cancel := context.WithTimeout(ctx, foodVisionTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(foodVisionRequest{
+		AnthropicVersion: bedrockAnthropicVersion,
+		MaxTokens:        foodVisionMaxTokens,
+		System:           foodVisionSystemPrompt,
+		Messages: []foodVisionMessage{{
+			Role: "user",
+			Content: []foodVisionContent{
+				{Type: "image", Source: &foodVisionSource{Type: "base64", MediaType: mediaType, Data: base64.StdEncoding.EncodeToString(image)}},
+				{Type: "text", Text: "What foods are in this meal?"},
+			},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	contentType, accept := "application/json", "application/json"
+	out, err := p.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		ModelId:     &p.model,
+		Body:        body,
+		ContentType: &contentType,
+		Accept:      &accept,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bedrock InvokeModel: %w", err)
+	}
+	var resp claudeResponse
+	if err := json.Unmarshal(out.Body, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("API error: %s — %s", resp.Error.Type, resp.Error.Message)
+	}
+	if len(resp.Content) == 0 {
+		return nil, errors.New("empty response content")
+	}
+	return parseFoodSuggestions(resp.Content[0].Text)
+}
+
+// parseFoodSuggestions pulls the JSON array out of the model's reply,
+// which may be wrapped in prose or code fences. An empty array means the
+// photo showed no food.
+func parseFoodSuggestions(text string) ([]models.FoodSuggestion, error) {
+	arr := extractJSONArray(text)
+	if arr == "" {
+		if strings.Contains(text, "[]") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("no JSON array in response: %s", truncateLog(text, 200))
+	}
+	var out []models.FoodSuggestion
+	if err := json.Unmarshal([]byte(arr), &out); err != nil {
+		return nil, fmt.Errorf("parse response JSON: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// loggingBadges are the milestones, in display order: a number of
+// entries, or a streak of days.
+var loggingBadges = []struct {
+	key, name       string
+	entries, streak int
+}{
+	{"first_entry", "First entry", 1, 0},
+	{"entries_100", "100 entries", 100, 0},
+	{"entries_500", "500 entries", 500, 0},
+	{"streak_7", "7-day streak", 0, 7},
+	{"streak_30", "30-day streak", 0, 30},
+}
+
+// gamificationTimezones is the part of UserService the service needs: the
+// caregiver's timezone, which decides where their days start.
+type gamificationTimezones interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error)
+}
+
+type gamificationMailer interface {
+	IsEnabled() bool
+	SendWeeklyDigestEmail(to, firstName, entries, days, streak, newBadges, settingsURL string) error
+}
+
+// GamificationService keeps caregivers' logging streaks, milestone badges
+// and progress stats, and sends the weekly digest. It only ever counts
+// entries, never reads them, and it is gentle: a missed day ends a streak
+// quietly and badges are never taken away. Caregivers who'd rather not
+// see any of it on their child's medical data can turn it off, in the app
+// and the digest alike.
+type GamificationService struct {
+	repo      repository.GamificationRepository
+	timezones gamificationTimezones
+	mailer    gamificationMailer
+	appURL    string
+	now       func() time.Time
+}
+
+// NewGamificationService creates the service. email may be nil; no
+// digests are sent then.
+func NewGamificationService(repo repository.GamificationRepository, timezones gamificationTimezones, email *EmailService, appURL string) *GamificationService {
+	s := &GamificationService{repo: repo, timezones: timezones, appURL: appURL, now: time.Now}
+	if email != nil {
+		s.mailer = email
+	}
+	return s
+}
+
+// Status returns the user's settings and, unless they opted out, their
+// progress.
+func (s *GamificationService) Status(ctx context.Context, userID uuid.UUID) (*models.GamificationStatus, error) {
+	settings, err := s.repo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	st := &models.GamificationStatus{GamificationSettings: *settings}
+	if !settings.Enabled {
+		return st, nil
+	}
+	tz := ""
+	if prefs, err := s.timezones.GetPreferences(ctx, userID); err == nil {
+		tz = prefs.Timezone
+	}
+	if st.Progress, _, err = s.progress(ctx, userID, tz); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// Update changes the user's settings and returns their status.
+func (s *GamificationService) Update(ctx context.Context, userID uuid.UUID, req *models.UpdateGamificationRequest) (*models.GamificationStatus, error) {
+	settings, err := s.repo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.WeeklyDigest != nil {
+		settings.WeeklyDigest = *req.WeeklyDigest
+	}
+	if err := s.repo.SaveSettings(ctx, userID, settings); err != nil {
+		return nil, err
+	}
+	return s.Status(ctx, userID)
+}
+
+// progress counts the user's days in tz, UTC when it is empty or unknown,
+// and returns it with the date it is as of.
+func (s *GamificationService) progress(ctx context.Context, userID uuid.UUID, tz string) (*models.LoggingProgress, time.Time, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "" {
+		loc, tz = time.UTC, "UTC"
+	}
+	days, err := s.repo.LoggingDays(ctx, userID, tz)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := s.now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return loggingProgress(days, today), today, nil
+}
+
+// loggingProgress works out the stats from days (oldest first) as of
+// today, both dates at UTC midnight.
+func loggingProgress(days []repository.LoggingDay, today time.Time) *models.LoggingProgress {
+	p := &models.LoggingProgress{Badges: make([]models.LoggingBadge, len(loggingBadges))}
+	earned := make([]*time.Time, len(loggingBadges))
+	weekStart, lastWeekStart := today.AddDate(0, 0, -6), today.AddDate(0, 0, -13)
+
+	run := 0
+	var prev time.Time
+	for _, d := range days {
+		if run > 0 && d.Date.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = d.Date
+		p.TotalEntries += d.Entries
+		p.DaysLogged++
+		p.LongestStreak = max(p.LongestStreak, run)
+		switch {
+		case !d.Date.Before(weekStart):
+			p.EntriesThisWeek += d.Entries
+			p.DaysThisWeek++
+		case !d.Date.Before(lastWeekStart):
+			p.EntriesLastWeek += d.Entries
+		}
+		for i, b := range loggingBadges {
+			if earned[i] == nil && ((b.entries > 0 && p.TotalEntries >= b.entries) || (b.streak > 0 && run >= b.streak)) {
+				date := d.Date
+				earned[i] = &date
+			}
+		}
+	}
+	if run > 0 && !prev.Before(today.AddDate(0, 0, -1)) {
+		p.CurrentStreak = run
+	}
+
+	for i, b := range loggingBadges {
+		badge := models.LoggingBadge{Key: b.key, Name: b.name, EarnedOn: earned[i]}
+		if b.entries > 0 {
+			badge.Target, badge.Progress = b.entries, min(p.TotalEntries, b.entries)
+		} else {
+			badge.Target, badge.Progress = b.streak, min(p.CurrentStreak, b.streak)
+		}
+		if badge.EarnedOn != nil {
+			badge.Progress = badge.Target
+		}
+		p.Badges[i] = badge
+	}
+	return p
+}
+
+// SendWeeklyDigests emails the week's digest to every caregiver who
+// logged this week and hasn't turned it off: what they logged, with their
+// streak and new badges for those who haven't opted out of gamification.
+// Returns how many were sent.
+func (s *GamificationService) SendWeeklyDigests(ctx context.Context) (int, error) {
+	if s.mailer == nil || !s.mailer.IsEnabled() {
+		return 0, nil
+	}
+	recipients, err := s.repo.DigestRecipients(ctx, s.now().AddDate(0, 0, -7))
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, r := range recipients {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		p, today, err := s.progress(ctx, r.UserID, r.Timezone)
+		if err != nil {
+			log.Printf("[GAMIFICATION] digest for user %s: %v", r.UserID, err)
+			continue
+		}
+		if p.EntriesThisWeek == 0 {
+			continue
+		}
+		streak, badges := "", ""
+		if r.Gamification {
+			streak, badges = digestStreak(p), digestNewBadges(p, today)
+		}
+		if err := s.mailer.SendWeeklyDigestEmail(r.Email, r.FirstName, strconv.Itoa(p.EntriesThisWeek), strconv.Itoa(p.DaysThisWeek),
+			streak, badges, s.appURL+"/settings"); err != nil {
+			log.Printf("[GAMIFICATION] digest for user %s: %v", r.UserID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// digestStreak describes the current streak, or "" for none worth
+// mentioning.
+func digestStreak(p *models.LoggingProgress) string {
+	if p.CurrentStreak < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%d days in a row", p.CurrentStreak)
+}
+
+// digestNewBadges lists the badges earned in the week to today, or "".
+func digestNewBadges(p *models.LoggingProgress, today time.Time) string {
+	var names []string
+	for _, b := range p.Badges {
+		if b.EarnedOn != nil && !b.EarnedOn.Before(today.AddDate(0, 0, -6)) {
+			names = append(names, b.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// WeeklyDigestScheduler sends the weekly digest on Sunday evenings, 18:00
+// UTC.
+type WeeklyDigestScheduler struct {
+	svc  *GamificationService
+	jobs *JobLocker
+}
+
+func NewWeeklyDigestScheduler(svc *GamificationService, jobs *JobLocker) *WeeklyDigestScheduler {
+	return &WeeklyDigestScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *WeeklyDigestScheduler) Start(ctx context.Context) {
+	log.Println("Weekly digest scheduler started")
+	for {
+		next := nextUTCRunAt(time.Now().UTC(), 18, 0)
+		for next.Weekday() != time.Sunday {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			log.Println("Weekly digest scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "weekly_digest", next, 7*24*time.Hour, func(ctx context.Context) {
+				if n, err := s.svc.SendWeeklyDigests(ctx); err != nil {
+					log.Printf("Weekly digest failed after %d sent: %v", n, err)
+				} else {
+					log.Printf("Weekly digest: sent %d", n)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakeGamification struct {
+	settings   models.GamificationSettings
+	days       []repository.LoggingDay
+	tz         string
+	recipients []repository.DigestRecipient
+}
+
+func (f *fakeGamification) GetSettings(ctx context.Context, userID uuid.UUID) (*models.GamificationSettings, error) {
+	s := f.settings
+	return &s, nil
+}
+
+func (f *fakeGamification) SaveSettings(ctx context.Context, userID uuid.UUID, s *models.GamificationSettings) error {
+	f.settings = *s
+	return nil
+}
+
+func (f *fakeGamification) LoggingDays(ctx context.Context, userID uuid.UUID, tz string) ([]repository.LoggingDay, error) {
+	f.tz = tz
+	return f.days, nil
+}
+
+func (f *fakeGamification) DigestRecipients(ctx context.Context, since time.Time) ([]repository.DigestRecipient, error) {
+	return f.recipients, nil
+}
+
+type fakeTimezones string
+
+func (f fakeTimezones) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error) {
+	return &models.UserPreferences{Timezone: string(f)}, nil
+}
+
+type digestEmail struct {
+	to, entries, days, streak, newBadges string
+}
+
+type fakeDigestMailer struct {
+	sent []digestEmail
+}
+
+func (f *fakeDigestMailer) IsEnabled() bool { return true }
+
+func (f *fakeDigestMailer) SendWeeklyDigestEmail(to, firstName, entries, days, streak, newBadges, settingsURL string) error {
+	f.sent = append(f.sent, digestEmail{to, entries, days, streak, newBadges})
+	return nil
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// loggedDays logs entries a day on n consecutive days ending on last.
+func loggedDays(last time.Time, n, entries int) []repository.LoggingDay {
+	days := make([]repository.LoggingDay, n)
+	for i := range days {
+		days[i] = repository.LoggingDay{Date: last.AddDate(0, 0, i-n+1), Entries: entries}
+	}
+	return days
+}
+
+func badge(p *models.LoggingProgress, key string) models.LoggingBadge {
+	for _, b := range p.Badges {
+		if b.Key == key {
+			return b
+		}
+	}
+	return models.LoggingBadge{}
+}
+
+func TestLoggingProgress(t *testing.T) {
+	today := date(2026, 6, 30)
+
+	// 35 days up to June 10 earn the 30-day streak; the gap ends it, and
+	// the 3 days up to yesterday are the current streak.
+	days := append(loggedDays(date(2026, 6, 10), 35, 3), loggedDays(date(2026, 6, 29), 3, 1)...)
+	p := loggingProgress(days, today)
+	if p.TotalEntries != 108 || p.DaysLogged != 38 {
+		t.Errorf("totals = %d entries, %d days, want 108, 38", p.TotalEntries, p.DaysLogged)
+	}
+	if p.LongestStreak != 35 || p.CurrentStreak != 3 {
+		t.Errorf("streaks = %d longest, %d current, want 35, 3", p.LongestStreak, p.CurrentStreak)
+	}
+	if p.EntriesThisWeek != 3 || p.DaysThisWeek != 3 || p.EntriesLastWeek != 0 {
+		t.Errorf("weeks = %d/%d this, %d last, want 3/3, 0", p.EntriesThisWeek, p.DaysThisWeek, p.EntriesLastWeek)
+	}
+	if b := badge(p, "streak_30"); b.EarnedOn == nil || !b.EarnedOn.Equal(date(2026, 6, 5)) || b.Progress != 30 {
+		t.Errorf("streak_30 = %+v, want earned on June 5", b)
+	}
+	// Cumulative entries reach 100 on the 34th day, June 9.
+	if b := badge(p, "entries_100"); b.EarnedOn == nil || !b.EarnedOn.Equal(date(2026, 6, 9)) {
+		t.Errorf("entries_100 = %+v, want earned on June 9", b)
+	}
+	if b := badge(p, "entries_500"); b.EarnedOn != nil || b.Progress != 108 || b.Target != 500 {
+		t.Errorf("entries_500 = %+v, want 108/500", b)
+	}
+
+	// Two days without an entry end the streak.
+	if p := loggingProgress(loggedDays(date(2026, 6, 28), 5, 1), today); p.CurrentStreak != 0 || p.LongestStreak != 5 {
+		t.Errorf("lapsed streak = %d current, %d longest, want 0, 5", p.CurrentStreak, p.LongestStreak)
+	}
+	// A streak not yet at 7 days counts towards the badge.
+	if p := loggingProgress(loggedDays(today, 4, 1), today); badge(p, "streak_7").Progress != 4 {
+		t.Errorf("streak_7 progress = %d, want 4", badge(p, "streak_7").Progress)
+	}
+
+	p = loggingProgress(nil, today)
+	if p.TotalEntries != 0 || p.CurrentStreak != 0 || len(p.Badges) != len(loggingBadges) || badge(p, "first_entry").EarnedOn != nil {
+		t.Errorf("no logs = %+v", p)
+	}
+}
+
+func TestGamificationStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeGamification{
+		settings: models.GamificationSettings{Enabled: true, WeeklyDigest: true},
+		days:     loggedDays(date(2026, 6, 30), 2, 1),
+	}
+	s := NewGamificationService(repo, fakeTimezones("America/Los_Angeles"), nil, "")
+	// Still June 30 in Los Angeles.
+	s.now = func() time.Time { return time.Date(2026, 7, 1, 3, 0, 0, 0, time.UTC) }
+	user := uuid.New()
+
+	st, err := s.Status(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.tz != "America/Los_Angeles" || st.Progress == nil || st.Progress.CurrentStreak != 2 {
+		t.Errorf("status in %s = %+v", repo.tz, st.Progress)
+	}
+
+	off := false
+	st, err = s.Update(ctx, user, &models.UpdateGamificationRequest{Enabled: &off})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Enabled || !st.WeeklyDigest || st.Progress != nil {
+		t.Errorf("opted out = %+v, want no progress", st)
+	}
+}
+
+func TestSendWeeklyDigests(t *testing.T) {
+	repo := &fakeGamification{
+		days: loggedDays(date(2026, 6, 28), 7, 2),
+		recipients: []repository.DigestRecipient{
+			{UserID: uuid.New(), Email: "a@example.com", Gamification: true},
+			{UserID: uuid.New(), Email: "b@example.com", Timezone: "Not/AZone"},
+		},
+	}
+	mailer := &fakeDigestMailer{}
+	s := NewGamificationService(repo, nil, nil, "https://app.example.com")
+	s.mailer = mailer
+	s.now = func() time.Time { return time.Date(2026, 6, 28, 18, 0, 0, 0, time.UTC) }
+
+	n, err := s.SendWeeklyDigests(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("sent %d, %v, want 2", n, err)
+	}
+	want := []digestEmail{
+		{"a@example.com", "14", "7", "7 days in a row", "First entry, 7-day streak"},
+		// Opted out of gamification: just what was logged.
+		{"b@example.com", "14", "7", "", ""},
+	}
+	for i, w := range want {
+		if mailer.sent[i] != w {
+			t.Errorf("email %d = %+v, want %+v", i, mailer.sent[i], w)
+		}
+	}
+	if repo.tz != "UTC" {
+		t.Errorf("unknown timezone counted in %q, want UTC", repo.tz)
+	}
+}
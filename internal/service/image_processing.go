@@ -0,0 +1,270 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// imageEncodeTimeout bounds one external encoder run. AVIF at speed 6 on
+// a 1920px image takes a second or two.
+const imageEncodeTimeout = 30 * time.Second
+
+// imageEncoder produces one output format. The stdlib only encodes JPEG
+// and PNG, so WebP and AVIF go through the cwebp and avifenc binaries when
+// they are installed.
+type imageEncoder interface {
+	Format() string
+	ContentType() string
+	Encode(ctx context.Context, img *image.RGBA) ([]byte, error)
+}
+
+type jpegEncoder struct{}
+
+func (jpegEncoder) Format() string      { return "jpeg" }
+func (jpegEncoder) ContentType() string { return "image/jpeg" }
+
+// Encode flattens transparency onto white; JPEG has no alpha channel and
+// would otherwise turn transparent pixels black.
+func (jpegEncoder) Encode(ctx context.Context, img *image.RGBA) ([]byte, error) {
+	var src image.Image = img
+	if !img.Opaque() {
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		src = flat
+	}
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, src, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// execEncoder runs an external encoder on a PNG written to a temp dir.
+// The PNG carries no metadata, so neither does the output.
+type execEncoder struct {
+	format      string
+	contentType string
+	bin         string
+	args        func(in, out string) []string
+}
+
+func (e *execEncoder) Format() string      { return e.format }
+func (e *execEncoder) ContentType() string { return e.contentType }
+
+func (e *execEncoder) Encode(ctx context.Context, img *image.RGBA) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "img-"+e.format+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "in.png"), filepath.Join(dir, "out."+e.format)
+
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.BestSpeed}).Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(in, buf.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, imageEncodeTimeout)
+	defer cancel()
+	if output, err := exec.CommandContext(ctx, e.bin, e.args(in, out)...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", filepath.Base(e.bin), err, bytes.TrimSpace(output))
+	}
+	return os.ReadFile(out)
+}
+
+// newImageEncoders returns the encoders to run for every variant, best
+// compression first. A binary that isn't on PATH just drops its format;
+// JPEG is always produced so every client has something to show.
+func newImageEncoders(cwebp, avifenc string) []imageEncoder {
+	var encoders []imageEncoder
+	if bin := lookEncoder(avifenc); bin != "" {
+		encoders = append(encoders, &execEncoder{format: "avif", contentType: "image/avif", bin: bin,
+			args: func(in, out string) []string { return []string{"-s", "6", "-q", "60", in, out} }})
+	}
+	if bin := lookEncoder(cwebp); bin != "" {
+		encoders = append(encoders, &execEncoder{format: "webp", contentType: "image/webp", bin: bin,
+			args: func(in, out string) []string {
+				return []string{"-quiet", "-q", "80", "-metadata", "none", in, "-o", out}
+			}})
+	}
+	return append(encoders, jpegEncoder{})
+}
+
+func lookEncoder(name string) string {
+	if name == "" || name == "off" {
+		return ""
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return ""
+	}
+	return bin
+}
+
+// decodeUpright decodes an upload and returns it the right way up, scaled
+// so its displayed width is at most maxWidth. Decoding to pixels and
+// re-encoding is what strips EXIF: nothing from the source file other
+// than the orientation tag survives.
+func decodeUpright(data []byte, maxWidth int) (*image.RGBA, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > thumbMaxPixels {
+		return nil, errThumbnailTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	orientation := jpegOrientation(data)
+	w, h := cfg.Width, cfg.Height
+	dispW := w
+	if orientation >= 5 {
+		dispW = h
+	}
+	// Scale before rotating so the pixel shuffle runs on the small image.
+	if dispW > maxWidth {
+		w, h = max(w*maxWidth/dispW, 1), max(h*maxWidth/dispW, 1)
+	}
+	return orient(scaleRGBA(src, w, h), orientation), nil
+}
+
+// scaleRGBA resizes src to w x h.
+func scaleRGBA(src image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if src.Bounds().Dx() == w && src.Bounds().Dy() == h {
+		draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	}
+	return dst
+}
+
+// orient applies an EXIF orientation (1-8) so the pixels display upright
+// without the tag.
+func orient(src *image.RGBA, o int) *image.RGBA {
+	if o < 2 || o > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs 90 counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			si, di := src.PixOffset(sx, sy), dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag from a JPEG's APP1
+// segment. Anything else (PNG, no EXIF, malformed) reads as 1, upright.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // image data starts; no EXIF seen
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return exifOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in IFD0 of a TIFF-structured block.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < n; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[off:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[off+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// variantWidths are the responsive widths generated for a source of width
+// srcW: each breakpoint narrower than the source, then the source itself
+// (already capped at the largest breakpoint by decodeUpright).
+func variantWidths(srcW int) []int {
+	var out []int
+	for _, w := range imageWidths {
+		if w < srcW {
+			out = append(out, w)
+		}
+	}
+	return append(out, srcW)
+}
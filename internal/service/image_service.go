@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrImageInvalid   = apperr.Validation("invalid image")
+	ErrImageNotFound  = apperr.NotFound("image not found")
+	ErrImageSignature = errors.New("image link expired or invalid")
+)
+
+// Image owners. Each owner has at most one live image set.
+const (
+	ImageOwnerChildPhoto          = "child_photo"
+	ImageOwnerTestimonialHeadshot = "testimonial_headshot"
+	ImageOwnerMarketingAsset      = "marketing_asset"
+)
+
+// ImageURLTTL is how long signed variant URLs stay valid. They're minted
+// on every read, so clients just refetch the set when a link lapses.
+const ImageURLTTL = time.Hour
+
+// imageWidths are the responsive breakpoints, in CSS pixels at 1x: list
+// thumbnails, phone-width cards, tablet/desktop, and full-width hero.
+var imageWidths = []int{320, 640, 1280, 1920}
+
+// ImageOptions configures the pipeline (config.StorageConfig).
+type ImageOptions struct {
+	MaxBytes int64
+	// CWebP and AVIFEnc are the encoder binaries, looked up on PATH.
+	// Empty or "off" skips that format.
+	CWebP   string
+	AVIFEnc string
+}
+
+// ImageService turns uploaded photos into responsive variants: every
+// breakpoint width up to the source's, in each available format (AVIF,
+// WebP, JPEG). Variants are re-encoded from decoded pixels, so EXIF
+// metadata — camera serials, capture time, GPS position — is dropped, and
+// the original upload is never stored.
+type ImageService struct {
+	repo          repository.ImageRepository
+	storage       BlobStorage
+	encoders      []imageEncoder
+	maxBytes      int64
+	signingSecret []byte
+	scan          *UploadScanService
+}
+
+// NewImageService builds the service. signingSecret HMAC-signs variant
+// URLs, same scheme as ticket attachments.
+func NewImageService(repo repository.ImageRepository, storage BlobStorage, signingSecret string, opts ImageOptions) *ImageService {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 20 << 20
+	}
+	s := &ImageService{
+		repo:          repo,
+		storage:       storage,
+		encoders:      newImageEncoders(opts.CWebP, opts.AVIFEnc),
+		maxBytes:      opts.MaxBytes,
+		signingSecret: []byte(signingSecret),
+	}
+	formats := make([]string, len(s.encoders))
+	for i, e := range s.encoders {
+		formats[i] = e.Format()
+	}
+	log.Printf("[IMAGES] variant formats: %v", formats)
+	return s
+}
+
+// SetScanService routes uploads through the antivirus stage.
+func (s *ImageService) SetScanService(scan *UploadScanService) {
+	s.scan = scan
+}
+
+// MaxBytes is the largest source image accepted.
+func (s *ImageService) MaxBytes() int64 { return s.maxBytes }
+
+// ImageUpload is one source image for an owner.
+type ImageUpload struct {
+	OwnerType  string
+	OwnerID    uuid.UUID
+	UploadedBy uuid.UUID
+	Filename   string
+	Body       io.Reader
+	// Scanned skips the antivirus stage for callers that already ran the
+	// upload through it.
+	Scanned bool
+}
+
+// Process decodes the upload, generates and stores its variants, records
+// them, and replaces the owner's previous set. The returned set carries
+// signed URLs.
+func (s *ImageService) Process(ctx context.Context, in ImageUpload) (*models.ImageSet, error) {
+	data, err := io.ReadAll(io.LimitReader(in.Body, s.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("%w: file exceeds %d MB", ErrImageInvalid, s.maxBytes>>20)
+	}
+	base, err := decodeUpright(data, imageWidths[len(imageWidths)-1])
+	if errors.Is(err, errThumbnailTooLarge) {
+		return nil, fmt.Errorf("%w: image dimensions are too large", ErrImageInvalid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: file must be a JPEG, PNG, GIF or WebP image", ErrImageInvalid)
+	}
+	if !in.Scanned {
+		if _, err := s.scan.Check(ctx, ScanTarget{
+			Source:   ScanSourceImage,
+			Filename: in.Filename,
+			Size:     int64(len(data)),
+			Open:     bytesOpener(data),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	set := &models.ImageSet{
+		OwnerType:    in.OwnerType,
+		OwnerID:      in.OwnerID,
+		SourceWidth:  base.Bounds().Dx(),
+		SourceHeight: base.Bounds().Dy(),
+		CreatedBy:    models.NullUUID{UUID: in.UploadedBy, Valid: in.UploadedBy != uuid.Nil},
+	}
+	namespace := in.OwnerType + "/" + in.OwnerID.String()
+	for _, w := range variantWidths(set.SourceWidth) {
+		h := max(set.SourceHeight*w/set.SourceWidth, 1)
+		img := base
+		if w != set.SourceWidth {
+			img = scaleRGBA(base, w, h)
+		}
+		for _, enc := range s.encoders {
+			out, err := enc.Encode(ctx, img)
+			if err != nil {
+				if _, ok := enc.(jpegEncoder); ok {
+					s.deleteVariants(ctx, set.Variants)
+					return nil, err
+				}
+				log.Printf("[IMAGES] %s encode failed for %s %s at %dpx: %v", enc.Format(), in.OwnerType, in.OwnerID, w, err)
+				continue
+			}
+			name := fmt.Sprintf("w%d.%s", w, enc.Format())
+			path, size, err := s.storage.Save(ctx, namespace, name, enc.ContentType(), bytes.NewReader(out))
+			if err != nil {
+				s.deleteVariants(ctx, set.Variants)
+				return nil, err
+			}
+			set.Variants = append(set.Variants, models.ImageVariant{
+				Format: enc.Format(), Width: w, Height: h,
+				ContentType: enc.ContentType(), StoragePath: path, SizeBytes: size,
+			})
+		}
+	}
+	if err := s.repo.CreateSet(ctx, set); err != nil {
+		s.deleteVariants(ctx, set.Variants)
+		return nil, err
+	}
+	s.deleteOwner(ctx, in.OwnerType, in.OwnerID, set.ID)
+	s.sign(set)
+	return set, nil
+}
+
+// Latest returns the owner's current set with signed URLs.
+func (s *ImageService) Latest(ctx context.Context, ownerType string, ownerID uuid.UUID) (*models.ImageSet, error) {
+	set, err := s.repo.LatestSet(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, ErrImageNotFound
+	}
+	s.sign(set)
+	return set, nil
+}
+
+// DeleteOwner removes every set the owner has, bytes included.
+func (s *ImageService) DeleteOwner(ctx context.Context, ownerType string, ownerID uuid.UUID) error {
+	return s.deleteOwner(ctx, ownerType, ownerID, uuid.Nil)
+}
+
+// deleteOwner removes the owner's sets other than keep. Blob failures are
+// logged and the rows removed anyway; an orphaned file is harmless, a row
+// pointing at a missing file is not.
+func (s *ImageService) deleteOwner(ctx context.Context, ownerType string, ownerID, keep uuid.UUID) error {
+	sets, err := s.repo.ListSets(ctx, ownerType, ownerID)
+	if err != nil {
+		log.Printf("[IMAGES] list sets for %s %s: %v", ownerType, ownerID, err)
+		return err
+	}
+	for _, set := range sets {
+		if set.ID == keep {
+			continue
+		}
+		s.deleteVariants(ctx, set.Variants)
+		if err := s.repo.DeleteSet(ctx, set.ID); err != nil {
+			log.Printf("[IMAGES] delete set %s: %v", set.ID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ImageService) deleteVariants(ctx context.Context, variants []models.ImageVariant) {
+	for _, v := range variants {
+		if err := s.storage.Delete(ctx, v.StoragePath); err != nil {
+			log.Printf("[IMAGES] delete %s: %v", v.StoragePath, err)
+		}
+	}
+}
+
+func (s *ImageService) sign(set *models.ImageSet) {
+	for i := range set.Variants {
+		set.Variants[i].URL, _ = s.SignedURL(set.Variants[i].ID, ImageURLTTL)
+	}
+}
+
+func (s *ImageService) mac(variantID uuid.UUID, expUnix int64) []byte {
+	m := hmac.New(sha256.New, s.signingSecret)
+	m.Write([]byte("image-variant|" + variantID.String() + "|" + strconv.FormatInt(expUnix, 10)))
+	return m.Sum(nil)
+}
+
+// SignedURL returns a path the public image route accepts until exp.
+func (s *ImageService) SignedURL(variantID uuid.UUID, ttl time.Duration) (path string, exp time.Time) {
+	exp = time.Now().Add(ttl)
+	sig := hex.EncodeToString(s.mac(variantID, exp.Unix()))
+	return fmt.Sprintf("/i/signed/%s?exp=%d&sig=%s", variantID, exp.Unix(), sig), exp
+}
+
+// VerifySignedURL checks expiry and signature.
+func (s *ImageService) VerifySignedURL(variantID uuid.UUID, expUnix int64, sig string) error {
+	if time.Now().Unix() > expUnix {
+		return ErrImageSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(variantID, expUnix)) {
+		return ErrImageSignature
+	}
+	return nil
+}
+
+// OpenVariant opens a variant after the caller has verified the signature.
+func (s *ImageService) OpenVariant(ctx context.Context, variantID uuid.UUID) (io.ReadCloser, *models.ImageVariant, error) {
+	v, err := s.repo.GetVariant(ctx, variantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v == nil {
+		return nil, nil, ErrImageNotFound
+	}
+	body, err := s.storage.Open(ctx, v.StoragePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, v, nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// memImageRepo keeps image sets in memory, newest last.
+type memImageRepo struct{ sets []models.ImageSet }
+
+func (m *memImageRepo) CreateSet(ctx context.Context, set *models.ImageSet) error {
+	set.ID = uuid.New()
+	for i := range set.Variants {
+		set.Variants[i].ID = uuid.New()
+		set.Variants[i].ImageSetID = set.ID
+	}
+	m.sets = append(m.sets, *set)
+	return nil
+}
+
+func (m *memImageRepo) LatestSet(ctx context.Context, ownerType string, ownerID uuid.UUID) (*models.ImageSet, error) {
+	sets, _ := m.ListSets(ctx, ownerType, ownerID)
+	if len(sets) == 0 {
+		return nil, nil
+	}
+	return &sets[0], nil
+}
+
+func (m *memImageRepo) ListSets(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]models.ImageSet, error) {
+	var out []models.ImageSet
+	for i := len(m.sets) - 1; i >= 0; i-- {
+		if m.sets[i].OwnerType == ownerType && m.sets[i].OwnerID == ownerID {
+			s := m.sets[i]
+			s.Variants = append([]models.ImageVariant(nil), s.Variants...)
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *memImageRepo) GetVariant(ctx context.Context, id uuid.UUID) (*models.ImageVariant, error) {
+	for _, s := range m.sets {
+		for _, v := range s.Variants {
+			if v.ID == id {
+				return &v, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (m *memImageRepo) DeleteSet(ctx context.Context, id uuid.UUID) error {
+	for i, s := range m.sets {
+		if s.ID == id {
+			m.sets = append(m.sets[:i], m.sets[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// exifJPEG returns a w x h JPEG whose left half is red, with an EXIF
+// block carrying orientation and a GPS IFD pointer.
+func exifJPEG(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{0, 0, 255, 255}
+			if x < w/2 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var enc bytes.Buffer
+	if err := jpeg.Encode(&enc, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatal(err)
+	}
+
+	be := binary.BigEndian
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = be.AppendUint16(tiff, 2)
+	tiff = be.AppendUint16(tiff, 0x0112) // Orientation, SHORT, 1
+	tiff = be.AppendUint16(tiff, 3)
+	tiff = be.AppendUint32(tiff, 1)
+	tiff = be.AppendUint16(tiff, uint16(orientation))
+	tiff = be.AppendUint16(tiff, 0)
+	tiff = be.AppendUint16(tiff, 0x8825) // GPS IFD pointer, LONG, 1
+	tiff = be.AppendUint16(tiff, 4)
+	tiff = be.AppendUint32(tiff, 1)
+	tiff = be.AppendUint32(tiff, 0)
+	tiff = be.AppendUint32(tiff, 0)
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	out = be.AppendUint16(out, uint16(len(seg)+2))
+	out = append(out, seg...)
+	return append(out, enc.Bytes()[2:]...)
+}
+
+func TestVariantWidths(t *testing.T) {
+	for _, tc := range []struct {
+		src  int
+		want []int
+	}{
+		{200, []int{200}},
+		{320, []int{320}},
+		{1000, []int{320, 640, 1000}},
+		{1920, []int{320, 640, 1280, 1920}},
+	} {
+		got := variantWidths(tc.src)
+		if len(got) != len(tc.want) {
+			t.Errorf("variantWidths(%d) = %v, want %v", tc.src, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("variantWidths(%d) = %v, want %v", tc.src, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestDecodeUprightAppliesOrientation(t *testing.T) {
+	data := exifJPEG(t, 80, 40, 6)
+	if o := jpegOrientation(data); o != 6 {
+		t.Fatalf("orientation = %d, want 6", o)
+	}
+	img, err := decodeUpright(data, 1920)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 80 {
+		t.Fatalf("upright size = %v, want 40x80", b.Size())
+	}
+	// Rotated 90 clockwise, the red left half ends up on top.
+	if r, _, b, _ := img.At(20, 5).RGBA(); r < b {
+		t.Errorf("top of rotated image is not red")
+	}
+	if r, _, b, _ := img.At(20, 75).RGBA(); r > b {
+		t.Errorf("bottom of rotated image is not blue")
+	}
+
+	// Wide sources are scaled to the displayed width cap.
+	img, err = decodeUpright(exifJPEG(t, 400, 100, 1), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 50 {
+		t.Fatalf("scaled size = %v, want 200x50", b.Size())
+	}
+}
+
+func TestImageServiceProcess(t *testing.T) {
+	ctx := context.Background()
+	repo := &memImageRepo{}
+	store := &memBlobStorage{blobs: map[string][]byte{}}
+	svc := NewImageService(repo, store, "secret", ImageOptions{CWebP: "off", AVIFEnc: "off"})
+	owner := uuid.New()
+
+	first, err := svc.Process(ctx, ImageUpload{OwnerType: ImageOwnerChildPhoto, OwnerID: owner, Body: bytes.NewReader(exifJPEG(t, 800, 600, 1))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Variants) != 3 {
+		t.Fatalf("variants = %d, want 3 (320, 640, 800)", len(first.Variants))
+	}
+	for _, v := range first.Variants {
+		if v.Format != "jpeg" || v.Height != 600*v.Width/800 || !strings.HasPrefix(v.URL, "/i/signed/"+v.ID.String()) {
+			t.Errorf("unexpected variant %+v", v)
+		}
+		if bytes.Contains(store.blobs[v.StoragePath], []byte("Exif")) {
+			t.Errorf("variant %dpx still carries EXIF", v.Width)
+		}
+	}
+
+	second, err := svc.Process(ctx, ImageUpload{OwnerType: ImageOwnerChildPhoto, OwnerID: owner, Body: bytes.NewReader(exifJPEG(t, 200, 100, 1))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.sets) != 1 || len(store.blobs) != len(second.Variants) {
+		t.Fatalf("previous set not replaced: %d sets, %d blobs", len(repo.sets), len(store.blobs))
+	}
+
+	v := second.Variants[0]
+	_, exp := svc.SignedURL(v.ID, time.Minute)
+	sig := hex.EncodeToString(svc.mac(v.ID, exp.Unix()))
+	if err := svc.VerifySignedURL(v.ID, exp.Unix(), sig); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := svc.VerifySignedURL(uuid.New(), exp.Unix(), sig); !errors.Is(err, ErrImageSignature) {
+		t.Errorf("signature for another variant accepted")
+	}
+	if err := svc.VerifySignedURL(v.ID, time.Now().Add(-time.Minute).Unix(), sig); !errors.Is(err, ErrImageSignature) {
+		t.Errorf("expired link accepted")
+	}
+	body, got, err := svc.OpenVariant(ctx, v.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	if got.ContentType != "image/jpeg" || len(data) == 0 {
+		t.Errorf("OpenVariant = %q, %d bytes", got.ContentType, len(data))
+	}
+
+	if _, err := svc.Process(ctx, ImageUpload{OwnerType: ImageOwnerChildPhoto, OwnerID: owner, Body: strings.NewReader("not an image")}); !errors.Is(err, ErrImageInvalid) {
+		t.Errorf("garbage upload: err = %v, want ErrImageInvalid", err)
+	}
+	if err := svc.DeleteOwner(ctx, ImageOwnerChildPhoto, owner); err != nil || len(repo.sets) != 0 || len(store.blobs) != 0 {
+		t.Errorf("DeleteOwner left %d sets, %d blobs (err %v)", len(repo.sets), len(store.blobs), err)
+	}
+}
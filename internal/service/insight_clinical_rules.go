@@ -427,6 +427,8 @@ func friendlyMetricName(metric string) string {
 		return "bowel pattern"
 	case "bowel_count":
 		return "bowel frequency"
+	case "routine_completion":
+		return "daily routine completion"
 	}
 	return strings.ReplaceAll(metric, "_", " ")
 }
@@ -29,6 +29,8 @@ type InsightGenerator struct {
 	perMetricScanner *PerMetricScanner
 	clinicalScanner  *ClinicalRuleScanner
 	lastInternalRun  time.Time
+
+	jobs *JobLocker
 }
 
 // NewInsightGenerator creates a new insight generator
@@ -58,21 +60,29 @@ func NewInsightGenerator(
 	}
 }
 
+// SetJobLocker makes the hourly and daily runs once-per-tick across
+// instances. Without it every instance runs them.
+func (g *InsightGenerator) SetJobLocker(jobs *JobLocker) {
+	g.jobs = jobs
+}
+
 // Start begins the insight generation loop
 func (g *InsightGenerator) Start(ctx context.Context) {
 	log.Println("Insight generator started")
 	// Run immediately on startup
-	g.generateAllInsights(ctx)
-
-	// Also run AI analysis immediately on first startup if enabled
-	if g.aiService != nil {
-		log.Println("AI Insight generator enabled — running initial analysis")
-		g.runAIAnalysis(ctx)
-	}
-
-	// Internal-AI scanners (auto-correlation, per-metric, clinical rules)
-	// also run once at startup so dev gets immediate feedback.
-	g.runInternalScans(ctx)
+	g.jobs.RunOnce(ctx, "insight_generation", TickSlot(time.Now(), time.Hour), time.Hour, g.generateAllInsights)
+
+	// AI analysis and the internal-AI scanners (auto-correlation,
+	// per-metric, clinical rules) also run once at startup so dev gets
+	// immediate feedback — once per hour across instances, so a rolling
+	// deploy doesn't repeat the Bedrock calls per instance.
+	g.jobs.RunOnce(ctx, "insight_ai_boot", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+		if g.aiService != nil {
+			log.Println("AI Insight generator enabled — running initial analysis")
+			g.runAIAnalysis(ctx)
+		}
+		g.runInternalScans(ctx)
+	})
 
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
@@ -83,12 +93,14 @@ func (g *InsightGenerator) Start(ctx context.Context) {
 			log.Println("Insight generator stopped")
 			return
 		case <-ticker.C:
-			g.generateAllInsights(ctx)
+			g.jobs.RunOnce(ctx, "insight_generation", TickSlot(time.Now(), time.Hour), time.Hour, g.generateAllInsights)
 
 			// Run AI analysis + internal-AI scanners daily at configured hour
 			if g.shouldRunAI() {
-				g.runAIAnalysis(ctx)
-				g.runInternalScans(ctx)
+				g.jobs.RunOnce(ctx, "insight_ai_daily", TickSlot(time.Now(), 24*time.Hour), 24*time.Hour, func(ctx context.Context) {
+					g.runAIAnalysis(ctx)
+					g.runInternalScans(ctx)
+				})
 			}
 		}
 	}
@@ -258,6 +258,7 @@ func humanizeFactor(factor string) string {
 		"bristol_scale":         "bowel regularity",
 		"bowel_count":           "bowel frequency",
 		"water_intake":          "water intake",
+		"routine_completion":    "routine completion",
 		"aggression_incidents":  "aggressive behavior",
 	}
 	if name, ok := names[factor]; ok {
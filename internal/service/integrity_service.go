@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrIntegrityRunNotFound   = apperr.NotFound("integrity check run not found")
+	ErrIntegrityUnknownCheck  = errors.New("unknown integrity check")
+	ErrIntegrityInvalidSample = apperr.Validation("samples must be between 0 and 50")
+)
+
+const (
+	integrityDefaultSamples = 5
+	integrityMaxSamples     = 50
+)
+
+// IntegrityOptions controls one integrity run.
+type IntegrityOptions struct {
+	// Quarantine moves the orphans of quarantinable checks into
+	// integrity_quarantine after counting them.
+	Quarantine bool
+	// Samples is how many row IDs to keep per check; 0 means the default.
+	Samples int
+	// Checks limits the run to these check names; empty runs them all.
+	Checks []string
+	// RunBy is recorded on the run: an admin's email or user@host.
+	RunBy string
+}
+
+// IntegrityService scans for rows whose references don't resolve and
+// optionally quarantines them. It's run by cmd/integritycheck and from the
+// admin Integrity page.
+type IntegrityService struct {
+	repo repository.IntegrityRepository
+}
+
+func NewIntegrityService(repo repository.IntegrityRepository) *IntegrityService {
+	return &IntegrityService{repo: repo}
+}
+
+// Checks lists every check a run can perform.
+func (s *IntegrityService) Checks() []repository.IntegrityCheck {
+	return repository.IntegrityChecks
+}
+
+// Run performs the checks and records the run. A check that fails is
+// noted on its result and the run carries on; the run is then failed.
+// The error return is only for a run that couldn't be recorded at all.
+func (s *IntegrityService) Run(ctx context.Context, opts IntegrityOptions) (*repository.IntegrityRun, error) {
+	checks, err := selectIntegrityChecks(opts.Checks)
+	if err != nil {
+		return nil, err
+	}
+	samples := opts.Samples
+	if samples == 0 {
+		samples = integrityDefaultSamples
+	}
+	if samples < 0 || samples > integrityMaxSamples {
+		return nil, ErrIntegrityInvalidSample
+	}
+
+	run := &repository.IntegrityRun{Quarantine: opts.Quarantine, RunBy: opts.RunBy}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("record run: %w", err)
+	}
+
+	var failed, problems int
+	for _, c := range checks {
+		res := repository.IntegrityCheckResult{
+			Name: c.Name, Description: c.Description, Table: c.Table, Quarantinable: c.Quarantinable,
+		}
+		res.Count, res.SampleIDs, err = s.repo.Count(ctx, c, samples)
+		if err != nil {
+			res.Error = err.Error()
+			failed++
+			run.Results = append(run.Results, res)
+			continue
+		}
+		if res.Count > 0 {
+			problems++
+			if opts.Quarantine && c.Quarantinable {
+				if res.Quarantined, err = s.repo.Quarantine(ctx, run.ID, c); err != nil {
+					res.Error = "quarantine: " + err.Error()
+					failed++
+				} else {
+					log.Printf("[INTEGRITY] run %s quarantined %d row(s) from %s (%s)", run.ID, res.Quarantined, c.Table, c.Name)
+				}
+			}
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	switch {
+	case failed > 0:
+		run.Status = repository.IntegrityStatusFailed
+		run.Error = fmt.Sprintf("%d check(s) failed", failed)
+	case problems > 0:
+		run.Status = repository.IntegrityStatusProblems
+	default:
+		run.Status = repository.IntegrityStatusClean
+	}
+	// The checks are done; record them even if the caller has gone.
+	if err := s.repo.FinishRun(context.WithoutCancel(ctx), run); err != nil {
+		return run, fmt.Errorf("record results: %w", err)
+	}
+	return run, nil
+}
+
+func selectIntegrityChecks(names []string) ([]repository.IntegrityCheck, error) {
+	if len(names) == 0 {
+		return repository.IntegrityChecks, nil
+	}
+	byName := make(map[string]repository.IntegrityCheck, len(repository.IntegrityChecks))
+	for _, c := range repository.IntegrityChecks {
+		byName[c.Name] = c
+	}
+	out := make([]repository.IntegrityCheck, 0, len(names))
+	for _, n := range names {
+		c, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrIntegrityUnknownCheck, n)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (s *IntegrityService) ListRuns(ctx context.Context, limit int) ([]repository.IntegrityRun, error) {
+	return s.repo.ListRuns(ctx, limit)
+}
+
+func (s *IntegrityService) GetRun(ctx context.Context, id uuid.UUID) (*repository.IntegrityRun, error) {
+	run, err := s.repo.GetRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, ErrIntegrityRunNotFound
+	}
+	return run, nil
+}
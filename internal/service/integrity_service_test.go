@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeIntegrityRepo struct {
+	repository.IntegrityRepository
+	counts      map[string]int64
+	failing     map[string]bool
+	quarantined []string
+	finished    *repository.IntegrityRun
+}
+
+func (f *fakeIntegrityRepo) Count(ctx context.Context, check repository.IntegrityCheck, samples int) (int64, []string, error) {
+	if f.failing[check.Name] {
+		return 0, nil, errors.New("relation does not exist")
+	}
+	n := f.counts[check.Name]
+	var ids []string
+	for i := int64(0); i < n && i < int64(samples); i++ {
+		ids = append(ids, uuid.NewString())
+	}
+	return n, ids, nil
+}
+
+func (f *fakeIntegrityRepo) Quarantine(ctx context.Context, runID uuid.UUID, check repository.IntegrityCheck) (int64, error) {
+	f.quarantined = append(f.quarantined, check.Name)
+	return f.counts[check.Name], nil
+}
+
+func (f *fakeIntegrityRepo) CreateRun(ctx context.Context, run *repository.IntegrityRun) error {
+	run.ID = uuid.New()
+	return nil
+}
+
+func (f *fakeIntegrityRepo) FinishRun(ctx context.Context, run *repository.IntegrityRun) error {
+	f.finished = run
+	return nil
+}
+
+func TestIntegrityRun(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &fakeIntegrityRepo{}
+	run, err := NewIntegrityService(repo).Run(ctx, IntegrityOptions{RunBy: "ops@host"})
+	if err != nil || run.Status != repository.IntegrityStatusClean || repo.finished != run ||
+		len(run.Results) != len(repository.IntegrityChecks) {
+		t.Fatalf("clean run: %+v, %v", run, err)
+	}
+
+	repo = &fakeIntegrityRepo{counts: map[string]int64{
+		"behavior_logs.missing_child":     12,
+		"behavior_logs.deactivated_child": 3,
+		"payments.missing_subscription":   2,
+	}}
+	run, err = NewIntegrityService(repo).Run(ctx, IntegrityOptions{Quarantine: true, Samples: 4})
+	if err != nil || run.Status != repository.IntegrityStatusProblems {
+		t.Fatalf("problems run: %+v, %v", run, err)
+	}
+	// Report-only checks are never quarantined, nor are clean ones.
+	if len(repo.quarantined) != 1 || repo.quarantined[0] != "behavior_logs.missing_child" {
+		t.Errorf("quarantined %v", repo.quarantined)
+	}
+	for _, res := range run.Results {
+		if res.Name == "behavior_logs.missing_child" && (res.Count != 12 || res.Quarantined != 12 || len(res.SampleIDs) != 4) {
+			t.Errorf("missing_child result %+v", res)
+		}
+	}
+
+	// A failing check is recorded and the rest still run.
+	repo = &fakeIntegrityRepo{failing: map[string]bool{"family_memberships.missing_user": true}}
+	run, err = NewIntegrityService(repo).Run(ctx, IntegrityOptions{})
+	if err != nil || run.Status != repository.IntegrityStatusFailed || len(run.Results) != len(repository.IntegrityChecks) {
+		t.Fatalf("failed run: %+v, %v", run, err)
+	}
+
+	svc := NewIntegrityService(&fakeIntegrityRepo{})
+	if _, err := svc.Run(ctx, IntegrityOptions{Checks: []string{"users.missing_everything"}}); !errors.Is(err, ErrIntegrityUnknownCheck) {
+		t.Errorf("unknown check: %v", err)
+	}
+	if _, err := svc.Run(ctx, IntegrityOptions{Samples: 500}); !errors.Is(err, ErrIntegrityInvalidSample) {
+		t.Errorf("too many samples: %v", err)
+	}
+	run, _ = svc.Run(ctx, IntegrityOptions{Checks: []string{"payments.missing_subscription"}})
+	if len(run.Results) != 1 {
+		t.Errorf("filtered run has %d results", len(run.Results))
+	}
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrInterventionNotFound = apperr.NotFound("intervention not found")
+	ErrInterventionInvalid  = apperr.Validation("invalid intervention")
+)
+
+const (
+	// interventionMinDays is how many logged days the before and during
+	// periods each need before a metric gets a direction.
+	interventionMinDays = 4
+	// interventionChangeThreshold is the relative change below which a
+	// metric counts as unchanged; day-to-day noise is larger than this.
+	interventionChangeThreshold = 0.10
+)
+
+// InterventionService manages intervention experiments and evaluates them:
+// each target metric's daily average during the intervention against the
+// same number of days before it and, once it has ended, after it. Metrics
+// and their series are the ones the multi-child comparison uses.
+type InterventionService struct {
+	repo   repository.InterventionRepository
+	series comparisonSeriesSource
+	now    func() time.Time
+}
+
+func NewInterventionService(repo repository.InterventionRepository, series comparisonSeriesSource) *InterventionService {
+	return &InterventionService{repo: repo, series: series, now: time.Now}
+}
+
+func invalidIntervention(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInterventionInvalid, fmt.Sprintf(format, args...))
+}
+
+// apply validates req onto i. With no target metrics the comparison
+// defaults are used.
+func (s *InterventionService) apply(i *models.Intervention, req *models.InterventionRequest) error {
+	name := strings.TrimSpace(req.Name)
+	description := strings.TrimSpace(req.Description)
+	switch {
+	case name == "":
+		return invalidIntervention("name is required")
+	case len(name) > 200:
+		return invalidIntervention("name must be 200 characters or fewer")
+	case len(description) > 2000:
+		return invalidIntervention("description must be 2000 characters or fewer")
+	case req.StartDate.Time.IsZero():
+		return invalidIntervention("start_date is required")
+	case req.StartDate.Time.After(s.now().UTC().AddDate(0, 0, 1)):
+		return invalidIntervention("start_date can't be in the future")
+	case !req.EndDate.Time.IsZero() && req.EndDate.Time.Before(req.StartDate.Time):
+		return invalidIntervention("end_date must be on or after start_date")
+	}
+	metrics := req.TargetMetrics
+	if len(metrics) == 0 {
+		metrics = DefaultComparisonMetrics
+	}
+	seen := map[string]bool{}
+	for _, m := range metrics {
+		if _, ok := comparisonMetrics[m]; !ok {
+			return invalidIntervention("unknown metric %q", m)
+		}
+		if seen[m] {
+			return invalidIntervention("metric %q given twice", m)
+		}
+		seen[m] = true
+	}
+
+	i.Name = name
+	i.Description.String, i.Description.Valid = description, description != ""
+	i.StartDate = dateOnly(req.StartDate.Time)
+	i.EndDate = nil
+	if !req.EndDate.Time.IsZero() {
+		end := dateOnly(req.EndDate.Time)
+		i.EndDate = &end
+	}
+	i.TargetMetrics = append([]string(nil), metrics...)
+	return nil
+}
+
+// dateOnly drops t's time of day, keeping its calendar date.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Create records an intervention for the child.
+func (s *InterventionService) Create(ctx context.Context, childID, by uuid.UUID, req *models.InterventionRequest) (*models.Intervention, error) {
+	i := &models.Intervention{ChildID: childID, CreatedBy: &by}
+	if err := s.apply(i, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Get returns an intervention of the child, or ErrInterventionNotFound.
+func (s *InterventionService) Get(ctx context.Context, childID, id uuid.UUID) (*models.Intervention, error) {
+	i, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if i == nil || i.ChildID != childID {
+		return nil, ErrInterventionNotFound
+	}
+	return i, nil
+}
+
+func (s *InterventionService) List(ctx context.Context, childID uuid.UUID) ([]models.Intervention, error) {
+	list, err := s.repo.ListByChild(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []models.Intervention{}
+	}
+	return list, nil
+}
+
+// Update replaces an intervention's fields.
+func (s *InterventionService) Update(ctx context.Context, i *models.Intervention, req *models.InterventionRequest) error {
+	if err := s.apply(i, req); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, i)
+}
+
+func (s *InterventionService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Evaluate compares the intervention's target metrics before, during and
+// after it, as of today (the caller's date). The during period runs from
+// the start to the end date, or to today while it's ongoing; before and
+// after are the same number of days either side, after being cut short
+// at today.
+func (s *InterventionService) Evaluate(ctx context.Context, i *models.Intervention, today time.Time) (*models.InterventionEvaluation, error) {
+	today = dateOnly(today)
+	ended := i.EndDate != nil && i.EndDate.Before(today)
+	duringEnd := today
+	if ended {
+		duringEnd = *i.EndDate
+	}
+	if duringEnd.Before(i.StartDate) {
+		duringEnd = i.StartDate
+	}
+	days := int(duringEnd.Sub(i.StartDate).Hours()/24) + 1
+	beforeStart := i.StartDate.AddDate(0, 0, -days)
+	afterEnd := duringEnd
+	if ended {
+		afterEnd = duringEnd.AddDate(0, 0, days)
+		if afterEnd.After(today) {
+			afterEnd = today
+		}
+	}
+
+	data, err := s.series.GetMetricSeries(ctx, i.ChildID, beforeStart, afterEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	eval := &models.InterventionEvaluation{Intervention: *i, Metrics: []models.InterventionMetricResult{}}
+	counts := map[string]int{}
+	for _, key := range i.TargetMetrics {
+		m, ok := comparisonMetrics[key]
+		if !ok {
+			continue
+		}
+		points := data[m.series]
+		r := models.InterventionMetricResult{
+			Key:    key,
+			Label:  m.label,
+			Unit:   m.unit,
+			Before: interventionPeriod(points, m, beforeStart, i.StartDate.AddDate(0, 0, -1)),
+			During: interventionPeriod(points, m, i.StartDate, duringEnd),
+		}
+		if ended {
+			after := interventionPeriod(points, m, duringEnd.AddDate(0, 0, 1), afterEnd)
+			r.After = &after
+		}
+		interventionEffect(&r, m)
+		r.Summary = interventionMetricSummary(r, i.Name)
+		counts[r.Direction]++
+		eval.Metrics = append(eval.Metrics, r)
+	}
+	eval.Summary = interventionSummary(i, days, ended, counts, len(eval.Metrics))
+	return eval, nil
+}
+
+// interventionPeriod averages a metric's points over [from, to].
+func interventionPeriod(points []models.DataPoint, m comparisonMetric, from, to time.Time) models.InterventionPeriod {
+	index := map[string]int{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		index[day.Format("2006-01-02")] = len(index)
+	}
+	series := alignComparisonSeries(uuid.Nil, points, m, index, len(index))
+	return models.InterventionPeriod{
+		StartDate:  from.Format("2006-01-02"),
+		EndDate:    to.Format("2006-01-02"),
+		Average:    series.Average,
+		DaysLogged: series.DaysLogged,
+	}
+}
+
+// interventionEffect sets r's change and direction from its before and
+// during averages.
+func interventionEffect(r *models.InterventionMetricResult, m comparisonMetric) {
+	if r.Before.DaysLogged < interventionMinDays || r.During.DaysLogged < interventionMinDays {
+		r.Direction = models.InterventionInsufficientData
+		return
+	}
+	before, during := *r.Before.Average, *r.During.Average
+	diff := during - before
+	if before != 0 {
+		pct := math.Round(diff / math.Abs(before) * 100)
+		r.ChangePercent = &pct
+	}
+	switch {
+	case math.Abs(diff) <= interventionChangeThreshold*math.Max(math.Abs(before), math.Abs(during)):
+		r.Direction = models.InterventionNoChange
+	case (diff < 0) == m.lowerIsBetter:
+		r.Direction = models.InterventionImproved
+	default:
+		r.Direction = models.InterventionWorsened
+	}
+}
+
+// formatMetricValue renders v in the metric's unit for a summary.
+func formatMetricValue(v float64, unit string) string {
+	switch unit {
+	case "hours":
+		return fmt.Sprintf("%.1f hours", v)
+	case "count":
+		return fmt.Sprintf("%.1f a day", v)
+	case "percent":
+		return fmt.Sprintf("%.0f%%", v)
+	default:
+		return fmt.Sprintf("%.1f", v)
+	}
+}
+
+// interventionMetricSummary is a one-sentence, plain-language reading of
+// r, plus how the metric did after the intervention when known.
+func interventionMetricSummary(r models.InterventionMetricResult, name string) string {
+	if r.Direction == models.InterventionInsufficientData {
+		return fmt.Sprintf("Not enough %s logged to compare yet: %d days before and %d during; at least %d of each are needed.",
+			strings.ToLower(r.Label), r.Before.DaysLogged, r.During.DaysLogged, interventionMinDays)
+	}
+	before := formatMetricValue(*r.Before.Average, r.Unit)
+	during := formatMetricValue(*r.During.Average, r.Unit)
+	var out string
+	switch r.Direction {
+	case models.InterventionNoChange:
+		out = fmt.Sprintf("%s held about steady during %s: %s, against %s before.", r.Label, name, during, before)
+	default:
+		way := "up"
+		if *r.During.Average < *r.Before.Average {
+			way = "down"
+		}
+		change := ""
+		if r.ChangePercent != nil {
+			change = fmt.Sprintf(" (%+.0f%%)", *r.ChangePercent)
+		}
+		verdict := "an improvement"
+		if r.Direction == models.InterventionWorsened {
+			verdict = "a change for the worse"
+		}
+		out = fmt.Sprintf("%s averaged %s during %s, %s from %s before%s — %s.", r.Label, during, name, way, before, change, verdict)
+	}
+	if r.After != nil && r.After.Average != nil {
+		out += fmt.Sprintf(" After it ended: %s over %d logged days.", formatMetricValue(*r.After.Average, r.Unit), r.After.DaysLogged)
+	}
+	return out
+}
+
+// joinList joins parts as "a, b and c".
+func joinList(parts []string) string {
+	if len(parts) < 2 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// interventionSummary is the plain-language headline of an evaluation.
+func interventionSummary(i *models.Intervention, days int, ended bool, counts map[string]int, total int) string {
+	var b strings.Builder
+	if ended {
+		fmt.Fprintf(&b, "%s ran for %d days. ", i.Name, days)
+	} else {
+		fmt.Fprintf(&b, "%s has been running for %d days. ", i.Name, days)
+	}
+	compared := total - counts[models.InterventionInsufficientData]
+	if compared == 0 {
+		b.WriteString("There isn't enough logged data yet to compare before and during it; keep logging and check back.")
+		return b.String()
+	}
+	var parts []string
+	for _, d := range []struct{ key, label string }{
+		{models.InterventionImproved, "improved"},
+		{models.InterventionWorsened, "got worse"},
+		{models.InterventionNoChange, "showed no clear change"},
+	} {
+		if n := counts[d.key]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, d.label))
+		}
+	}
+	fmt.Fprintf(&b, "Of %d tracked %s with enough data, %s. ", compared, plural(compared, "metric", "metrics"), joinList(parts))
+	b.WriteString("These compare averages of logged days, not cause and effect — other changes at the same time can play a part.")
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeInterventionRepo struct {
+	byID map[uuid.UUID]models.Intervention
+}
+
+func (f *fakeInterventionRepo) Create(ctx context.Context, i *models.Intervention) error {
+	i.ID = uuid.New()
+	f.byID[i.ID] = *i
+	return nil
+}
+
+func (f *fakeInterventionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Intervention, error) {
+	if i, ok := f.byID[id]; ok {
+		return &i, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeInterventionRepo) ListByChild(ctx context.Context, childID uuid.UUID) ([]models.Intervention, error) {
+	var out []models.Intervention
+	for _, i := range f.byID {
+		if i.ChildID == childID {
+			out = append(out, i)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeInterventionRepo) Update(ctx context.Context, i *models.Intervention) error {
+	f.byID[i.ID] = *i
+	return nil
+}
+
+func (f *fakeInterventionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.byID, id)
+	return nil
+}
+
+func TestInterventionValidation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	svc := NewInterventionService(&fakeInterventionRepo{byID: map[uuid.UUID]models.Intervention{}}, &fakeComparisonSources{})
+	svc.now = func() time.Time { return now }
+	date := func(s string) models.FlexDate { t, _ := time.Parse("2006-01-02", s); return models.FlexDate{Time: t} }
+
+	for name, req := range map[string]models.InterventionRequest{
+		"no name":        {StartDate: date("2026-10-01")},
+		"no start":       {Name: "Melatonin"},
+		"future start":   {Name: "Melatonin", StartDate: date("2026-11-01")},
+		"end before":     {Name: "Melatonin", StartDate: date("2026-10-05"), EndDate: date("2026-10-01")},
+		"unknown metric": {Name: "Melatonin", StartDate: date("2026-10-01"), TargetMetrics: []string{"weight"}},
+		"metric twice":   {Name: "Melatonin", StartDate: date("2026-10-01"), TargetMetrics: []string{"sleep", "sleep"}},
+	} {
+		if _, err := svc.Create(ctx, uuid.New(), uuid.New(), &req); !errors.Is(err, ErrInterventionInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	i, err := svc.Create(ctx, uuid.New(), uuid.New(), &models.InterventionRequest{Name: " Melatonin ", StartDate: date("2026-10-01")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Name != "Melatonin" || i.EndDate != nil || strings.Join(i.TargetMetrics, ",") != strings.Join(DefaultComparisonMetrics, ",") {
+		t.Errorf("created %+v", i)
+	}
+	if _, err := svc.Get(ctx, uuid.New(), i.ID); !errors.Is(err, ErrInterventionNotFound) {
+		t.Errorf("other child's intervention: %v", err)
+	}
+}
+
+func TestInterventionEvaluate(t *testing.T) {
+	childID := uuid.New()
+	day := func(n int) time.Time { return time.Date(2026, 9, n, 0, 0, 0, 0, time.UTC) }
+	var meltdowns, sleep, anxiety []models.DataPoint
+	for d := 3; d <= 9; d++ { // before
+		meltdowns = append(meltdowns, models.DataPoint{Date: day(d), Value: 3})
+		sleep = append(sleep, models.DataPoint{Date: day(d), Value: 480})
+		anxiety = append(anxiety, models.DataPoint{Date: day(d), Value: 4})
+	}
+	for d := 10; d <= 16; d++ { // during
+		meltdowns = append(meltdowns, models.DataPoint{Date: day(d), Value: 1})
+		sleep = append(sleep, models.DataPoint{Date: day(d), Value: 490})
+	}
+	anxiety = append(anxiety, models.DataPoint{Date: day(12), Value: 2})
+	for d := 17; d <= 19; d++ { // after
+		meltdowns = append(meltdowns, models.DataPoint{Date: day(d), Value: 2})
+	}
+	src := &fakeComparisonSources{series: map[uuid.UUID]map[string][]models.DataPoint{childID: {
+		"meltdowns": meltdowns, "sleep_minutes": sleep, "anxiety": anxiety,
+	}}}
+	svc := NewInterventionService(&fakeInterventionRepo{}, src)
+	end := day(16)
+	i := &models.Intervention{ChildID: childID, Name: "Melatonin", StartDate: day(10), EndDate: &end,
+		TargetMetrics: []string{"meltdowns", "sleep", "anxiety"}}
+
+	eval, err := svc.Evaluate(context.Background(), i, day(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	melt, sl, anx := eval.Metrics[0], eval.Metrics[1], eval.Metrics[2]
+	if melt.Before.StartDate != "2026-09-03" || melt.Direction != models.InterventionImproved ||
+		melt.ChangePercent == nil || *melt.ChangePercent != -67 {
+		t.Errorf("meltdowns %+v", melt)
+	}
+	if melt.After == nil || melt.After.EndDate != "2026-09-23" || melt.After.DaysLogged != 3 || !strings.Contains(melt.Summary, "After it ended: 2.0 a day") {
+		t.Errorf("meltdowns after %+v", melt.After)
+	}
+	if sl.Direction != models.InterventionNoChange || anx.Direction != models.InterventionInsufficientData {
+		t.Errorf("sleep %s, anxiety %s", sl.Direction, anx.Direction)
+	}
+	if !strings.Contains(eval.Summary, "ran for 7 days") || !strings.Contains(eval.Summary, "Of 2 tracked metrics with enough data, 1 improved and 1 showed no clear change") {
+		t.Errorf("summary %q", eval.Summary)
+	}
+
+	// Ongoing: during runs to today and there's no after period yet.
+	i.EndDate = nil
+	eval, err = svc.Evaluate(context.Background(), i, day(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eval.Metrics[0].After != nil || eval.Metrics[0].During.EndDate != "2026-09-16" || !strings.Contains(eval.Summary, "has been running for 7 days") {
+		t.Errorf("ongoing %+v", eval)
+	}
+}
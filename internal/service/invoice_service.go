@@ -0,0 +1,555 @@
+package service
+
+// invoice_service.go — invoices for reimbursement and FSA/HSA claims.
+//
+// Every successful family payment and every paid organization seat
+// invoice gets an invoice with its own number. The bill-to details and
+// line items are snapshotted at issue; the PDF is rendered with fpdf into
+// blob storage and emailed to the payer. Families and org admins download
+// them from their billing history; support can regenerate (re-snapshotting
+// the bill-to) and resend from the admin portal.
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrInvoiceNotFound      = apperr.NotFound("invoice not found")
+	ErrInvoiceNotPaid       = errors.New("payment hasn't succeeded, so there's nothing to invoice")
+	ErrInvoiceNoRecipient   = errors.New("no email address to send the invoice to")
+	ErrInvoiceEmailDisabled = errors.New("email sending is disabled")
+)
+
+// BrandSource supplies the brand name, colors and contacts printed on
+// invoices. MarketingRepository implements it.
+type BrandSource interface {
+	GetBrandConfig(ctx context.Context) (*models.BrandConfig, error)
+}
+
+// invoiceMailer is the part of EmailService invoices need.
+type invoiceMailer interface {
+	IsEnabled() bool
+	SendInvoiceEmail(to, name, number, total string, pdf EmailAttachment) error
+}
+
+// OrganizationInvoiceInput is a paid Stripe invoice for an organization's
+// seat subscription.
+type OrganizationInvoiceInput struct {
+	OrganizationID  uuid.UUID
+	StripeInvoiceID string
+	Lines           []repository.InvoiceLine
+	SubtotalCents   int64
+	DiscountCents   int64
+	TaxCents        int64
+	TaxInclusive    bool
+	TotalCents      int64
+	Currency        string
+	PeriodStart     *time.Time
+	PeriodEnd       *time.Time
+	PaidAt          time.Time
+}
+
+// InvoiceService issues, renders and sends invoices.
+type InvoiceService struct {
+	repo          repository.InvoiceRepository
+	brand         BrandSource
+	storage       BlobStorage
+	mailer        invoiceMailer
+	signingSecret []byte
+}
+
+// NewInvoiceService creates the invoice service. signingSecret signs the
+// short-lived download URLs handed to system browsers, as for reports.
+func NewInvoiceService(repo repository.InvoiceRepository, brand BrandSource, storage BlobStorage, email *EmailService, signingSecret string) *InvoiceService {
+	s := &InvoiceService{repo: repo, brand: brand, storage: storage, signingSecret: []byte(signingSecret)}
+	if email != nil {
+		s.mailer = email
+	}
+	return s
+}
+
+// IssueForPayment issues the invoice for a successful payment and renders
+// its PDF. Idempotent: the existing invoice is returned if there is one.
+func (s *InvoiceService) IssueForPayment(ctx context.Context, paymentID uuid.UUID) (*repository.Invoice, error) {
+	if inv, err := s.repo.GetByPayment(ctx, paymentID); inv != nil || err != nil {
+		return inv, err
+	}
+	p, err := s.repo.PaymentSource(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrInvoiceNotFound
+	}
+	switch p.Status {
+	case "succeeded", "refunded", "partially_refunded":
+	default:
+		return nil, ErrInvoiceNotPaid
+	}
+
+	description := "MyCareCompanion subscription"
+	if p.PlanName != "" {
+		description = fmt.Sprintf("MyCareCompanion %s plan", p.PlanName)
+		if p.BillingInterval != "" && p.BillingInterval != string(models.BillingIntervalLifetime) {
+			description += " (" + p.BillingInterval + ")"
+		}
+	}
+	currency := strings.ToUpper(p.Currency)
+	if currency == "" {
+		currency = "USD"
+	}
+	// The payment is what was charged: net less discount, plus tax unless
+	// the tax was already inside the price.
+	lineCents := p.AmountCents + p.DiscountCents
+	if !p.TaxInclusive {
+		lineCents -= p.TaxCents
+	}
+	inv := &repository.Invoice{
+		PaymentID:       &p.PaymentID,
+		StripeInvoiceID: p.StripeInvoiceID,
+		FamilyID:        p.FamilyID,
+		UserID:          p.UserID,
+		BillToName:      p.PayerName,
+		BillToEmail:     p.PayerEmail,
+		Lines: []repository.InvoiceLine{
+			{Description: description, Quantity: 1, AmountCents: lineCents},
+		},
+		SubtotalCents: lineCents,
+		DiscountCents: p.DiscountCents,
+		TaxCents:      p.TaxCents,
+		TaxInclusive:  p.TaxInclusive,
+		TotalCents:    p.AmountCents,
+		Currency:      currency,
+		PaidAt:        p.PaidAt,
+	}
+	return s.create(ctx, inv, func() (*repository.Invoice, error) { return s.repo.GetByPayment(ctx, paymentID) })
+}
+
+// IssueForStripeInvoice issues the invoice for the family payment recorded
+// against a paid Stripe invoice. Returns nil, nil when no payment was
+// recorded (an untracked subscription, or a family with no parent).
+func (s *InvoiceService) IssueForStripeInvoice(ctx context.Context, stripeInvoiceID string) (*repository.Invoice, error) {
+	paymentID, err := s.repo.PaymentForStripeInvoice(ctx, stripeInvoiceID)
+	if err != nil || paymentID == nil {
+		return nil, err
+	}
+	return s.IssueForPayment(ctx, *paymentID)
+}
+
+// IssueForOrganization issues the invoice for a paid organization seat
+// invoice. Idempotent on the Stripe invoice ID.
+func (s *InvoiceService) IssueForOrganization(ctx context.Context, in OrganizationInvoiceInput) (*repository.Invoice, error) {
+	if inv, err := s.repo.GetByStripeInvoice(ctx, in.StripeInvoiceID); inv != nil || err != nil {
+		return inv, err
+	}
+	name, email, err := s.repo.CurrentBillTo(ctx, nil, &in.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	orgID := in.OrganizationID
+	currency := strings.ToUpper(in.Currency)
+	if currency == "" {
+		currency = "USD"
+	}
+	inv := &repository.Invoice{
+		StripeInvoiceID: in.StripeInvoiceID,
+		OrganizationID:  &orgID,
+		BillToName:      name,
+		BillToEmail:     email,
+		Lines:           in.Lines,
+		SubtotalCents:   in.SubtotalCents,
+		DiscountCents:   in.DiscountCents,
+		TaxCents:        in.TaxCents,
+		TaxInclusive:    in.TaxInclusive,
+		TotalCents:      in.TotalCents,
+		Currency:        currency,
+		PeriodStart:     in.PeriodStart,
+		PeriodEnd:       in.PeriodEnd,
+		PaidAt:          in.PaidAt,
+	}
+	return s.create(ctx, inv, func() (*repository.Invoice, error) { return s.repo.GetByStripeInvoice(ctx, in.StripeInvoiceID) })
+}
+
+// create inserts the invoice (or, if a concurrent webhook beat us to it,
+// loads that one) and renders the PDF. A render failure is logged, not
+// returned: the invoice exists and the PDF is rendered on first download.
+func (s *InvoiceService) create(ctx context.Context, inv *repository.Invoice, existing func() (*repository.Invoice, error)) (*repository.Invoice, error) {
+	if err := s.repo.Create(ctx, inv); err != nil {
+		if errors.Is(err, repository.ErrInvoiceExists) {
+			return existing()
+		}
+		return nil, fmt.Errorf("create invoice: %w", err)
+	}
+	if _, err := s.render(ctx, inv); err != nil {
+		log.Printf("[INVOICE] %s issued but PDF render failed: %v", inv.Number, err)
+	}
+	return inv, nil
+}
+
+// FamilyHistory returns the family's invoices, newest first. Payments
+// that were never invoiced (made before invoicing existed, or whose
+// webhook invoicing failed) are invoiced first.
+func (s *InvoiceService) FamilyHistory(ctx context.Context, familyID uuid.UUID) ([]repository.Invoice, error) {
+	missing, err := s.repo.UninvoicedPayments(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range missing {
+		if _, err := s.IssueForPayment(ctx, id); err != nil {
+			log.Printf("[INVOICE] backfill for payment %s failed: %v", id, err)
+		}
+	}
+	return s.repo.ListForFamily(ctx, familyID)
+}
+
+// OrganizationHistory returns the organization's invoices, newest first.
+func (s *InvoiceService) OrganizationHistory(ctx context.Context, orgID uuid.UUID) ([]repository.Invoice, error) {
+	return s.repo.ListForOrganization(ctx, orgID)
+}
+
+// Get returns an invoice or ErrInvoiceNotFound.
+func (s *InvoiceService) Get(ctx context.Context, id uuid.UUID) (*repository.Invoice, error) {
+	inv, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, ErrInvoiceNotFound
+	}
+	return inv, nil
+}
+
+// List returns invoices for the admin portal.
+func (s *InvoiceService) List(ctx context.Context, f repository.InvoiceFilter) ([]repository.Invoice, int, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	return s.repo.List(ctx, f)
+}
+
+// OpenPDF returns the invoice PDF, rendering it first if it never was.
+func (s *InvoiceService) OpenPDF(ctx context.Context, inv *repository.Invoice) (io.ReadCloser, error) {
+	if inv.PDFPath == "" {
+		pdf, err := s.render(ctx, inv)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(pdf)), nil
+	}
+	return s.storage.Open(ctx, inv.PDFPath)
+}
+
+// Regenerate refreshes the bill-to details from the account as it is now
+// (a corrected name or address for a claim) and renders a new PDF. The
+// number, amounts and lines don't change.
+func (s *InvoiceService) Regenerate(ctx context.Context, id uuid.UUID) (*repository.Invoice, error) {
+	inv, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	name, email, err := s.repo.CurrentBillTo(ctx, inv.UserID, inv.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" || email != "" {
+		if err := s.repo.SetBillTo(ctx, id, name, email); err != nil {
+			return nil, err
+		}
+		inv.BillToName, inv.BillToEmail = name, email
+	}
+	if _, err := s.render(ctx, inv); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Send emails the invoice PDF to `to`, or to the bill-to email when empty.
+func (s *InvoiceService) Send(ctx context.Context, id uuid.UUID, to string) (*repository.Invoice, error) {
+	if s.mailer == nil || !s.mailer.IsEnabled() {
+		return nil, ErrInvoiceEmailDisabled
+	}
+	inv, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(ctx, inv, to); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *InvoiceService) send(ctx context.Context, inv *repository.Invoice, to string) error {
+	to = strings.TrimSpace(to)
+	if to == "" {
+		to = inv.BillToEmail
+	}
+	if to == "" {
+		return ErrInvoiceNoRecipient
+	}
+	rc, err := s.OpenPDF(ctx, inv)
+	if err != nil {
+		return fmt.Errorf("open invoice pdf: %w", err)
+	}
+	pdf, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("read invoice pdf: %w", err)
+	}
+	err = s.mailer.SendInvoiceEmail(to, inv.BillToName, inv.Number, formatInvoiceMoney(inv.TotalCents, inv.Currency),
+		EmailAttachment{Filename: InvoiceFilename(inv), ContentType: "application/pdf", Data: pdf})
+	if err != nil {
+		return fmt.Errorf("send invoice: %w", err)
+	}
+	return s.repo.MarkSent(ctx, inv.ID, to)
+}
+
+// SendNew emails a just-issued invoice to its bill-to address. Failures
+// are logged; the payer can still download it from billing history.
+func (s *InvoiceService) SendNew(ctx context.Context, inv *repository.Invoice) {
+	if inv == nil || inv.SentCount > 0 || s.mailer == nil || !s.mailer.IsEnabled() {
+		return
+	}
+	if err := s.send(ctx, inv, ""); err != nil {
+		log.Printf("[INVOICE] emailing %s failed: %v", inv.Number, err)
+	}
+}
+
+// SignedPDFURL returns a short-lived HMAC-signed path for the invoice PDF
+// that system browsers can open without the app's JWT.
+func (s *InvoiceService) SignedPDFURL(id uuid.UUID, ttl time.Duration) (path string, exp time.Time) {
+	exp = time.Now().Add(ttl)
+	expUnix := exp.Unix()
+	path = fmt.Sprintf("/inv/signed/%s?exp=%d&sig=%s", id, expUnix, s.sign(id, expUnix))
+	return path, exp
+}
+
+// VerifySignedPDF checks a signed invoice URL's expiry and signature.
+func (s *InvoiceService) VerifySignedPDF(id uuid.UUID, expUnix int64, sig string) error {
+	if time.Now().Unix() > expUnix {
+		return fmt.Errorf("signed url expired")
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("bad signature encoding")
+	}
+	want, _ := hex.DecodeString(s.sign(id, expUnix))
+	if !hmac.Equal(got, want) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (s *InvoiceService) sign(id uuid.UUID, expUnix int64) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte("invoice|" + id.String() + "|" + strconv.FormatInt(expUnix, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// InvoiceFilename is the download name for an invoice PDF.
+func InvoiceFilename(inv *repository.Invoice) string {
+	return "Invoice " + inv.Number + ".pdf"
+}
+
+// render draws the PDF, stores it and records it on the invoice.
+func (s *InvoiceService) render(ctx context.Context, inv *repository.Invoice) ([]byte, error) {
+	brand := defaultInvoiceBrand()
+	if s.brand != nil {
+		if b, err := s.brand.GetBrandConfig(ctx); err == nil && b != nil && b.AppName != "" {
+			brand = b
+		}
+	}
+	pdf, err := renderInvoicePDF(inv, brand)
+	if err != nil {
+		return nil, fmt.Errorf("render invoice: %w", err)
+	}
+	path, size, err := s.storage.Save(ctx, "invoices", inv.Number+".pdf", "application/pdf", bytes.NewReader(pdf))
+	if err != nil {
+		return nil, fmt.Errorf("store invoice pdf: %w", err)
+	}
+	version, err := s.repo.SetPDF(ctx, inv.ID, path, size)
+	if err != nil {
+		_ = s.storage.Delete(ctx, path)
+		return nil, err
+	}
+	if inv.PDFPath != "" && inv.PDFPath != path {
+		if err := s.storage.Delete(ctx, inv.PDFPath); err != nil {
+			log.Printf("[INVOICE] removing superseded PDF for %s: %v", inv.Number, err)
+		}
+	}
+	inv.PDFPath, inv.PDFSize, inv.PDFVersion = path, size, version
+	return pdf, nil
+}
+
+func defaultInvoiceBrand() *models.BrandConfig {
+	return &models.BrandConfig{
+		AppName:      "MyCareCompanion",
+		PrimaryColor: "#4F46E5",
+		WebsiteURL:   "https://www.mycarecompanion.net",
+		SupportEmail: "support@mycarecompanion.net",
+	}
+}
+
+// formatInvoiceMoney formats cents as "$12.34" for USD and "12.34 EUR"
+// otherwise; credits get a leading minus.
+func formatInvoiceMoney(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	amount := fmt.Sprintf("%d.%02d", cents/100, cents%100)
+	if currency == "" || strings.EqualFold(currency, "USD") {
+		return sign + "$" + amount
+	}
+	return sign + amount + " " + strings.ToUpper(currency)
+}
+
+// renderInvoicePDF lays out a one-page US Letter invoice: brand header,
+// seller and bill-to blocks, line items, totals and a paid stamp.
+func renderInvoicePDF(inv *repository.Invoice, brand *models.BrandConfig) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(18, 18, 18)
+	pdf.SetAutoPageBreak(true, 25)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	r, g, b := hexToRGB(brand.PrimaryColor)
+	pageW, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := pageW - left - right
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-18)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 113, 108)
+		pdf.CellFormat(0, 4, tr(fmt.Sprintf("Questions about this invoice? %s", brand.SupportEmail)), "", 1, "C", false, 0, "")
+		pdf.CellFormat(0, 4, "Keep this invoice for your records, reimbursement or FSA/HSA claims.", "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// Header: brand on the left, INVOICE and number on the right.
+	pdf.SetFont("Helvetica", "B", 22)
+	pdf.SetTextColor(int(r), int(g), int(b))
+	pdf.CellFormat(width/2, 10, tr(brand.AppName), "", 0, "L", false, 0, "")
+	pdf.SetTextColor(55, 65, 81)
+	pdf.CellFormat(width/2, 10, "INVOICE", "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(107, 114, 128)
+	seller := []string{brand.WebsiteURL, brand.SupportEmail, brand.ContactPhone}
+	meta := []string{
+		"Invoice " + inv.Number,
+		"Issued " + inv.IssuedAt.Format("January 2, 2006"),
+		"Paid " + inv.PaidAt.Format("January 2, 2006"),
+	}
+	for i := range meta {
+		pdf.CellFormat(width/2, 5, tr(seller[i]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(width/2, 5, meta[i], "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+	pdf.SetDrawColor(int(r), int(g), int(b))
+	pdf.SetLineWidth(0.6)
+	pdf.Line(left, pdf.GetY(), left+width, pdf.GetY())
+	pdf.Ln(8)
+
+	// Bill to and service period.
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetTextColor(107, 114, 128)
+	pdf.CellFormat(width/2, 5, "BILL TO", "", 0, "L", false, 0, "")
+	if inv.PeriodStart != nil && inv.PeriodEnd != nil {
+		pdf.CellFormat(width/2, 5, "SERVICE PERIOD", "", 0, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(31, 41, 55)
+	billTo := inv.BillToName
+	if billTo == "" {
+		billTo = inv.BillToEmail
+	}
+	pdf.CellFormat(width/2, 6, tr(billTo), "", 0, "L", false, 0, "")
+	if inv.PeriodStart != nil && inv.PeriodEnd != nil {
+		pdf.CellFormat(width/2, 6, fmt.Sprintf("%s - %s",
+			inv.PeriodStart.Format("Jan 2, 2006"), inv.PeriodEnd.Format("Jan 2, 2006")), "", 0, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+	if inv.BillToName != "" && inv.BillToEmail != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.CellFormat(width/2, 5, tr(inv.BillToEmail), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(8)
+
+	// Line items.
+	descW, qtyW := width-60, 20.0
+	amtW := width - descW - qtyW
+	pdf.SetFillColor(243, 244, 246)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetTextColor(75, 85, 99)
+	pdf.CellFormat(descW, 8, "  DESCRIPTION", "", 0, "L", true, 0, "")
+	pdf.CellFormat(qtyW, 8, "QTY", "", 0, "C", true, 0, "")
+	pdf.CellFormat(amtW, 8, "AMOUNT  ", "", 1, "R", true, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(31, 41, 55)
+	pdf.SetDrawColor(229, 231, 235)
+	pdf.SetLineWidth(0.2)
+	for _, l := range inv.Lines {
+		qty := ""
+		if l.Quantity > 0 {
+			qty = strconv.Itoa(l.Quantity)
+		}
+		pdf.CellFormat(descW, 9, "  "+tr(l.Description), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(qtyW, 9, qty, "B", 0, "C", false, 0, "")
+		pdf.CellFormat(amtW, 9, formatInvoiceMoney(l.AmountCents, inv.Currency)+"  ", "B", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Totals.
+	labelW := 40.0
+	totalRow := func(label, value string, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(width-labelW-amtW, 7, "", "", 0, "L", false, 0, "")
+		pdf.CellFormat(labelW, 7, label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(amtW, 7, value+"  ", "", 1, "R", false, 0, "")
+	}
+	totalRow("Subtotal", formatInvoiceMoney(inv.SubtotalCents, inv.Currency), false)
+	if inv.DiscountCents != 0 {
+		totalRow("Discount", formatInvoiceMoney(-inv.DiscountCents, inv.Currency), false)
+	}
+	if inv.TaxCents != 0 && !inv.TaxInclusive {
+		totalRow("Tax", formatInvoiceMoney(inv.TaxCents, inv.Currency), false)
+	}
+	totalRow("Total", formatInvoiceMoney(inv.TotalCents, inv.Currency), true)
+	if inv.TaxCents != 0 && inv.TaxInclusive {
+		totalRow("Includes tax", formatInvoiceMoney(inv.TaxCents, inv.Currency), false)
+	}
+	totalRow("Amount paid", formatInvoiceMoney(inv.TotalCents, inv.Currency), false)
+	totalRow("Balance due", formatInvoiceMoney(0, inv.Currency), true)
+
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetTextColor(22, 163, 74)
+	pdf.CellFormat(0, 8, "PAID", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(107, 114, 128)
+	pdf.CellFormat(0, 5, "Paid in full on "+inv.PaidAt.Format("January 2, 2006")+".", "", 1, "L", false, 0, "")
+
+	return outputPDF(pdf)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeInvoiceRepo struct {
+	repository.InvoiceRepository
+	invoices []*repository.Invoice
+	payments map[uuid.UUID]*repository.InvoicePaymentSource
+	billTo   [2]string
+	sent     []string
+}
+
+func (f *fakeInvoiceRepo) Create(ctx context.Context, inv *repository.Invoice) error {
+	for _, existing := range f.invoices {
+		if inv.PaymentID != nil && existing.PaymentID != nil && *existing.PaymentID == *inv.PaymentID {
+			return repository.ErrInvoiceExists
+		}
+	}
+	inv.ID = uuid.New()
+	inv.Number = fmt.Sprintf("MCC-2026-%06d", 1001+len(f.invoices))
+	inv.IssuedAt = time.Now()
+	cp := *inv
+	f.invoices = append(f.invoices, &cp)
+	return nil
+}
+
+func (f *fakeInvoiceRepo) find(match func(*repository.Invoice) bool) *repository.Invoice {
+	for _, inv := range f.invoices {
+		if match(inv) {
+			cp := *inv
+			return &cp
+		}
+	}
+	return nil
+}
+
+func (f *fakeInvoiceRepo) Get(ctx context.Context, id uuid.UUID) (*repository.Invoice, error) {
+	return f.find(func(inv *repository.Invoice) bool { return inv.ID == id }), nil
+}
+
+func (f *fakeInvoiceRepo) GetByPayment(ctx context.Context, paymentID uuid.UUID) (*repository.Invoice, error) {
+	return f.find(func(inv *repository.Invoice) bool { return inv.PaymentID != nil && *inv.PaymentID == paymentID }), nil
+}
+
+func (f *fakeInvoiceRepo) GetByStripeInvoice(ctx context.Context, id string) (*repository.Invoice, error) {
+	return f.find(func(inv *repository.Invoice) bool { return inv.StripeInvoiceID == id }), nil
+}
+
+func (f *fakeInvoiceRepo) PaymentSource(ctx context.Context, paymentID uuid.UUID) (*repository.InvoicePaymentSource, error) {
+	return f.payments[paymentID], nil
+}
+
+func (f *fakeInvoiceRepo) CurrentBillTo(ctx context.Context, userID, orgID *uuid.UUID) (string, string, error) {
+	return f.billTo[0], f.billTo[1], nil
+}
+
+func (f *fakeInvoiceRepo) SetBillTo(ctx context.Context, id uuid.UUID, name, email string) error {
+	for _, inv := range f.invoices {
+		if inv.ID == id {
+			inv.BillToName, inv.BillToEmail = name, email
+		}
+	}
+	return nil
+}
+
+func (f *fakeInvoiceRepo) SetPDF(ctx context.Context, id uuid.UUID, path string, size int64) (int, error) {
+	for _, inv := range f.invoices {
+		if inv.ID == id {
+			inv.PDFPath, inv.PDFSize = path, size
+			inv.PDFVersion++
+			return inv.PDFVersion, nil
+		}
+	}
+	return 0, errors.New("no such invoice")
+}
+
+func (f *fakeInvoiceRepo) MarkSent(ctx context.Context, id uuid.UUID, to string) error {
+	f.sent = append(f.sent, to)
+	for _, inv := range f.invoices {
+		if inv.ID == id {
+			inv.SentCount++
+			inv.LastSentTo = to
+		}
+	}
+	return nil
+}
+
+type fakeInvoiceMailer struct {
+	to  []string
+	pdf EmailAttachment
+}
+
+func (f *fakeInvoiceMailer) IsEnabled() bool { return true }
+
+func (f *fakeInvoiceMailer) SendInvoiceEmail(to, name, number, total string, pdf EmailAttachment) error {
+	f.to = append(f.to, to)
+	f.pdf = pdf
+	return nil
+}
+
+func newInvoiceTest() (*InvoiceService, *fakeInvoiceRepo, *memBlobStorage, *fakeInvoiceMailer) {
+	repo := &fakeInvoiceRepo{payments: map[uuid.UUID]*repository.InvoicePaymentSource{}}
+	storage := &memBlobStorage{blobs: map[string][]byte{}}
+	mailer := &fakeInvoiceMailer{}
+	svc := NewInvoiceService(repo, nil, storage, nil, "test-secret")
+	svc.mailer = mailer
+	return svc, repo, storage, mailer
+}
+
+func TestIssueInvoiceForPayment(t *testing.T) {
+	svc, repo, storage, _ := newInvoiceTest()
+	ctx := context.Background()
+	familyID := uuid.New()
+	paymentID := uuid.New()
+	repo.payments[paymentID] = &repository.InvoicePaymentSource{
+		PaymentID: paymentID, FamilyID: &familyID, Status: "succeeded",
+		AmountCents: 799, DiscountCents: 200, Currency: "usd",
+		PaidAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), PayerName: "Sam Rivera",
+		PayerEmail: "sam@example.com", PlanName: "Family", BillingInterval: "monthly",
+	}
+
+	inv, err := svc.IssueForPayment(ctx, paymentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.SubtotalCents != 999 || inv.DiscountCents != 200 || inv.TotalCents != 799 || inv.Currency != "USD" {
+		t.Errorf("amounts = %d - %d = %d %s", inv.SubtotalCents, inv.DiscountCents, inv.TotalCents, inv.Currency)
+	}
+	if len(inv.Lines) != 1 || inv.Lines[0].Description != "MyCareCompanion Family plan (monthly)" {
+		t.Errorf("lines = %+v", inv.Lines)
+	}
+	if inv.PDFPath == "" || inv.PDFVersion != 1 {
+		t.Fatalf("pdf not rendered: path %q version %d", inv.PDFPath, inv.PDFVersion)
+	}
+	if pdf := storage.blobs[inv.PDFPath]; !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Errorf("stored blob isn't a PDF (%d bytes)", len(pdf))
+	}
+
+	again, err := svc.IssueForPayment(ctx, paymentID)
+	if err != nil || again.ID != inv.ID || len(repo.invoices) != 1 {
+		t.Errorf("second issue: id %v (want %v), %d invoices, err %v", again.ID, inv.ID, len(repo.invoices), err)
+	}
+
+	failed := uuid.New()
+	repo.payments[failed] = &repository.InvoicePaymentSource{PaymentID: failed, Status: "failed"}
+	if _, err := svc.IssueForPayment(ctx, failed); !errors.Is(err, ErrInvoiceNotPaid) {
+		t.Errorf("failed payment: err = %v", err)
+	}
+}
+
+func TestRegenerateAndResendInvoice(t *testing.T) {
+	svc, repo, storage, mailer := newInvoiceTest()
+	ctx := context.Background()
+	orgID := uuid.New()
+	repo.billTo = [2]string{"Northside Clinic", "billing@northside.example"}
+
+	inv, err := svc.IssueForOrganization(ctx, OrganizationInvoiceInput{
+		OrganizationID: orgID, StripeInvoiceID: "in_1",
+		Lines: []repository.InvoiceLine{
+			{Description: "10 × Clinic (at $8.00 / month)", Quantity: 10, AmountCents: 8000},
+			{Description: "Remaining time on 2 × Clinic", AmountCents: 960},
+		},
+		SubtotalCents: 8960, TotalCents: 8960, PaidAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.SendNew(ctx, inv)
+	if len(mailer.to) != 1 || mailer.to[0] != "billing@northside.example" || mailer.pdf.Filename != "Invoice "+inv.Number+".pdf" {
+		t.Errorf("sent to %v as %q", mailer.to, mailer.pdf.Filename)
+	}
+
+	oldPath := inv.PDFPath
+	repo.billTo = [2]string{"Northside Pediatric Clinic", "ap@northside.example"}
+	regen, err := svc.Regenerate(ctx, inv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regen.BillToName != "Northside Pediatric Clinic" || regen.PDFVersion != 2 || regen.Number != inv.Number {
+		t.Errorf("regenerated: %q version %d number %q", regen.BillToName, regen.PDFVersion, regen.Number)
+	}
+	if _, ok := storage.blobs[oldPath]; ok {
+		t.Error("superseded PDF left in storage")
+	}
+
+	sent, err := svc.Send(ctx, inv.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.LastSentTo != "ap@northside.example" || sent.SentCount != 2 {
+		t.Errorf("resend went to %q (count %d)", sent.LastSentTo, sent.SentCount)
+	}
+	if _, err := svc.Send(ctx, inv.ID, "accounts@other.example"); err != nil || repo.sent[len(repo.sent)-1] != "accounts@other.example" {
+		t.Errorf("resend to override: sent %v err %v", repo.sent, err)
+	}
+}
+
+func TestInvoiceSignedURL(t *testing.T) {
+	svc, _, _, _ := newInvoiceTest()
+	id := uuid.New()
+	path, exp := svc.SignedPDFURL(id, time.Minute)
+	u, err := url.Parse(path)
+	if err != nil || u.Path != "/inv/signed/"+id.String() {
+		t.Fatalf("signed path = %q", path)
+	}
+	expUnix, _ := strconv.ParseInt(u.Query().Get("exp"), 10, 64)
+	sig := u.Query().Get("sig")
+	if expUnix != exp.Unix() {
+		t.Errorf("exp = %d, want %d", expUnix, exp.Unix())
+	}
+	if err := svc.VerifySignedPDF(id, expUnix, sig); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := svc.VerifySignedPDF(uuid.New(), expUnix, sig); err == nil {
+		t.Error("signature accepted for another invoice")
+	}
+	if err := svc.VerifySignedPDF(id, time.Now().Add(-time.Minute).Unix(), sig); err == nil {
+		t.Error("expired signature accepted")
+	}
+}
+
+func TestFormatInvoiceMoney(t *testing.T) {
+	cases := []struct {
+		cents    int64
+		currency string
+		want     string
+	}{
+		{799, "USD", "$7.99"},
+		{-200, "usd", "-$2.00"},
+		{12000, "EUR", "120.00 EUR"},
+	}
+	for _, c := range cases {
+		if got := formatInvoiceMoney(c.cents, c.currency); got != c.want {
+			t.Errorf("formatInvoiceMoney(%d, %q) = %q, want %q", c.cents, c.currency, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/database"
+)
+
+// jobLease is how long a run lock survives its holder dying; the holder
+// renews it every jobLease/3 while the job runs.
+const jobLease = 60 * time.Second
+
+// Compare-and-delete / compare-and-extend, so an instance that lost its
+// lease (paused past the TTL) can't release or extend another's lock.
+var (
+	jobUnlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+	jobRenewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// JobLocker makes scheduled jobs run once per tick across every instance
+// in the ASG. Each run claims its tick slot (jobs:<name>:<slot>) with
+// SET NX, so the first instance to reach a tick runs it and the others
+// skip, and holds a renewed run lock (jobs:<name>:running) so a run that
+// overruns into the next tick isn't started a second time elsewhere.
+//
+// A nil *JobLocker, or one without Redis, runs every job locally — the
+// single-instance behaviour.
+//
+// Once drain has begun no new runs start, and runs already going get a
+// context that outlives the scheduler's until the drain deadline.
+type JobLocker struct {
+	r     *database.Redis
+	owner string
+	drain *Drain
+}
+
+// NewJobLocker creates a locker on r. drain may be nil.
+func NewJobLocker(r *database.Redis, drain *Drain) *JobLocker {
+	host, _ := os.Hostname()
+	return &JobLocker{r: r, owner: fmt.Sprintf("%s-%d", host, os.Getpid()), drain: drain}
+}
+
+// Draining reports whether shutdown has begun. Jobs that loop over many
+// items check it between items and leave the rest for the next run.
+func (l *JobLocker) Draining() bool {
+	return l != nil && l.drain.Draining()
+}
+
+// TickSlot is the slot for an interval job ticking at now: instances whose
+// tickers fire at different offsets within the same interval share a slot.
+func TickSlot(now time.Time, interval time.Duration) time.Time {
+	return now.UTC().Truncate(interval)
+}
+
+// RunOnce runs fn unless another instance has already run (or is running)
+// job name for slot. window is how long the slot stays claimed — the job's
+// interval, so late tickers on other instances still see the claim.
+// Reports whether fn ran here. Redis errors fail open: running a job twice
+// is better than never running it.
+func (l *JobLocker) RunOnce(ctx context.Context, name string, slot time.Time, window time.Duration, fn func(context.Context)) bool {
+	if l == nil {
+		fn(ctx)
+		return true
+	}
+	if l.drain.Draining() {
+		return false
+	}
+	ctx, cancel := l.drain.WorkContext(ctx)
+	defer cancel()
+	if l.r == nil {
+		fn(ctx)
+		return true
+	}
+
+	lockKey := "jobs:" + name + ":running"
+	token := l.owner + "/" + uuid.New().String()
+	ok, err := l.r.SetNX(ctx, lockKey, token, jobLease).Result()
+	if err != nil {
+		log.Printf("[JOBS] %s: lock unavailable, running locally: %v", name, err)
+		fn(ctx)
+		return true
+	}
+	if !ok {
+		return false
+	}
+	defer func() {
+		if err := jobUnlockScript.Run(context.WithoutCancel(ctx), l.r, []string{lockKey}, token).Err(); err != nil {
+			log.Printf("[JOBS] %s: release lock: %v", name, err)
+		}
+	}()
+
+	slotKey := "jobs:" + name + ":" + strconv.FormatInt(slot.Unix(), 10)
+	claimed, err := l.r.SetNX(ctx, slotKey, l.owner, window+time.Minute).Result()
+	if err != nil {
+		log.Printf("[JOBS] %s: slot claim failed, running anyway: %v", name, err)
+	} else if !claimed {
+		return false
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go l.renew(runCtx, name, lockKey, token)
+	fn(ctx)
+	return true
+}
+
+// renew extends the run lock until ctx is cancelled.
+func (l *JobLocker) renew(ctx context.Context, name, key, token string) {
+	ticker := time.NewTicker(jobLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := jobRenewScript.Run(ctx, l.r, []string{key}, token, jobLease.Milliseconds()).Int()
+			if err == nil && n == 0 {
+				log.Printf("[JOBS] %s: lost run lock (lease expired)", name)
+				return
+			}
+		}
+	}
+}
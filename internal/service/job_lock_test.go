@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/database"
+)
+
+func TestJobLockerOncePerSlot(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := &database.Redis{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	a, b := NewJobLocker(rdb, nil), NewJobLocker(rdb, nil)
+	ctx := context.Background()
+
+	runs := 0
+	job := func(context.Context) { runs++ }
+	// Two instances whose hourly tickers fire at :07 and :37 share a slot.
+	slot := TickSlot(time.Date(2026, 6, 1, 10, 7, 0, 0, time.UTC), time.Hour)
+	if !a.RunOnce(ctx, "sweep", slot, time.Hour, job) {
+		t.Fatal("first instance should run")
+	}
+	if b.RunOnce(ctx, "sweep", TickSlot(time.Date(2026, 6, 1, 10, 37, 0, 0, time.UTC), time.Hour), time.Hour, job) {
+		t.Fatal("second instance ran the same slot")
+	}
+	if !b.RunOnce(ctx, "sweep", slot.Add(time.Hour), time.Hour, job) {
+		t.Fatal("next slot should run")
+	}
+	if !b.RunOnce(ctx, "other", slot, time.Hour, job) {
+		t.Fatal("a different job has its own slots")
+	}
+	if runs != 3 {
+		t.Errorf("runs = %d, want 3", runs)
+	}
+}
+
+func TestJobLockerNoOverlap(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := &database.Redis{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	a, b := NewJobLocker(rdb, nil), NewJobLocker(rdb, nil)
+	ctx := context.Background()
+	slot := TickSlot(time.Now(), time.Minute)
+
+	// While a's run overruns into the next tick, b must not start one.
+	a.RunOnce(ctx, "reports", slot, time.Minute, func(context.Context) {
+		if b.RunOnce(ctx, "reports", slot.Add(time.Minute), time.Minute, func(context.Context) {}) {
+			t.Error("overlapping run started")
+		}
+	})
+	if mr.Exists("jobs:reports:running") {
+		t.Error("run lock not released")
+	}
+	if !b.RunOnce(ctx, "reports", slot.Add(time.Minute), time.Minute, func(context.Context) {}) {
+		t.Error("next slot should run once the lock is free")
+	}
+}
+
+func TestJobLockerWithoutRedis(t *testing.T) {
+	var nilLocker *JobLocker
+	ran := false
+	if !nilLocker.RunOnce(context.Background(), "x", time.Now(), time.Hour, func(context.Context) { ran = true }) || !ran {
+		t.Error("nil locker should run locally")
+	}
+}
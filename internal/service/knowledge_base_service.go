@@ -0,0 +1,418 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrKBArticleNotFound  = apperr.NotFound("article not found")
+	ErrKBCategoryNotFound = apperr.NotFound("help center category not found")
+	ErrKBInvalid          = apperr.Validation("invalid help center input")
+)
+
+// KB article lifecycle. Only published articles are visible publicly.
+const (
+	KBStatusDraft     = "draft"
+	KBStatusPublished = "published"
+	KBStatusArchived  = "archived"
+)
+
+const (
+	maxKBTitleLen    = 200
+	maxKBSummaryLen  = 500
+	maxKBBodyLen     = 200_000
+	maxKBKeywords    = 20
+	maxKBSuggestions = 5
+	// maxKBSuggestTerms bounds the OR-query built from ticket text so a
+	// pasted log dump doesn't turn into a 500-term tsquery.
+	maxKBSuggestTerms = 12
+)
+
+var (
+	kbSlugAllowed  = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	kbSlugStripper = regexp.MustCompile(`[^a-z0-9]+`)
+
+	validKBStatuses = map[string]bool{KBStatusDraft: true, KBStatusPublished: true, KBStatusArchived: true}
+
+	// kbStopwords are dropped from ticket text before matching. Postgres'
+	// english config drops its own stopwords too, but these also cover
+	// support-ticket filler that would otherwise match every article.
+	kbStopwords = map[string]bool{
+		"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
+		"you": true, "all": true, "any": true, "can": true, "had": true, "her": true,
+		"was": true, "one": true, "our": true, "out": true, "has": true, "him": true,
+		"his": true, "how": true, "its": true, "may": true, "new": true, "now": true,
+		"see": true, "two": true, "way": true, "who": true, "did": true, "get": true,
+		"got": true, "let": true, "she": true, "too": true, "use": true, "this": true,
+		"that": true, "with": true, "have": true, "from": true, "they": true, "will": true,
+		"what": true, "when": true, "your": true, "just": true, "been": true, "into": true,
+		"than": true, "then": true, "them": true, "some": true, "does": true, "dont": true,
+		"cant": true, "there": true, "their": true, "which": true, "would": true,
+		"could": true, "should": true, "about": true, "after": true, "again": true,
+		"please": true, "help": true, "thanks": true, "thank": true, "hello": true,
+		"issue": true, "problem": true, "app": true, "working": true, "work": true,
+	}
+)
+
+// ExtractKBTerms turns free text (a ticket subject + description) into a
+// short, deduplicated list of lowercase [a-z0-9] terms suitable for an OR
+// full-text query. Order follows first appearance so the subject wins when
+// the list is truncated.
+func ExtractKBTerms(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+	seen := make(map[string]bool, len(fields))
+	out := make([]string, 0, maxKBSuggestTerms)
+	for _, f := range fields {
+		if len(f) < 3 || len(f) > 40 || kbStopwords[f] || seen[f] {
+			continue
+		}
+		// Pure numbers (versions, counts) match nothing useful.
+		if strings.IndexFunc(f, func(r rune) bool { return r >= 'a' && r <= 'z' }) < 0 {
+			continue
+		}
+		seen[f] = true
+		out = append(out, f)
+		if len(out) == maxKBSuggestTerms {
+			break
+		}
+	}
+	return out
+}
+
+// slugifyKB derives a URL slug from a title.
+func slugifyKB(title string) string {
+	s := kbSlugStripper.ReplaceAllString(strings.ToLower(title), "-")
+	s = strings.Trim(s, "-")
+	if len(s) > 100 {
+		s = strings.TrimRight(s[:100], "-")
+	}
+	return s
+}
+
+// KnowledgeBaseService owns the help center: admin authoring, public
+// reads/search, and ticket-time article suggestions.
+type KnowledgeBaseService struct {
+	repo repository.KnowledgeBaseRepository
+	md   goldmark.Markdown
+}
+
+// NewKnowledgeBaseService constructs the service. Markdown is rendered with
+// GFM (tables, task lists, autolinks); raw HTML in the source is escaped.
+func NewKnowledgeBaseService(repo repository.KnowledgeBaseRepository) *KnowledgeBaseService {
+	return &KnowledgeBaseService{
+		repo: repo,
+		md:   goldmark.New(goldmark.WithExtensions(extension.GFM)),
+	}
+}
+
+func (s *KnowledgeBaseService) render(a *repository.KBArticle) {
+	var buf bytes.Buffer
+	if err := s.md.Convert([]byte(a.BodyMD), &buf); err != nil {
+		log.Printf("[KB] render %s failed: %v", a.Slug, err)
+		return
+	}
+	a.BodyHTML = buf.String()
+}
+
+// ============================================================================
+// Categories
+// ============================================================================
+
+// KBCategoryInput is the admin create/update payload.
+type KBCategoryInput struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+func (in KBCategoryInput) apply(c *repository.KBCategory) error {
+	c.Name = strings.TrimSpace(in.Name)
+	if c.Name == "" || len(c.Name) > 120 {
+		return fmt.Errorf("%w: category name is required (max 120 chars)", ErrKBInvalid)
+	}
+	c.Slug = strings.TrimSpace(in.Slug)
+	if c.Slug == "" {
+		c.Slug = slugifyKB(c.Name)
+	}
+	if !kbSlugAllowed.MatchString(c.Slug) || len(c.Slug) > 80 {
+		return fmt.Errorf("%w: slug must be lowercase words separated by '-'", ErrKBInvalid)
+	}
+	c.Description = strings.TrimSpace(in.Description)
+	c.SortOrder = in.SortOrder
+	return nil
+}
+
+// ListCategories returns all categories with article counts. publicView
+// counts only published articles.
+func (s *KnowledgeBaseService) ListCategories(ctx context.Context, publicView bool) ([]repository.KBCategory, error) {
+	return s.repo.ListCategories(ctx, publicView)
+}
+
+// CategoriesChangeStamp says when the category list last changed.
+func (s *KnowledgeBaseService) CategoriesChangeStamp(ctx context.Context) (models.ChangeStamp, error) {
+	return s.repo.CategoriesChangeStamp(ctx)
+}
+
+func (s *KnowledgeBaseService) CreateCategory(ctx context.Context, in KBCategoryInput) (*repository.KBCategory, error) {
+	c := &repository.KBCategory{}
+	if err := in.apply(c); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *KnowledgeBaseService) UpdateCategory(ctx context.Context, id uuid.UUID, in KBCategoryInput) (*repository.KBCategory, error) {
+	c, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrKBCategoryNotFound
+	}
+	if err := in.apply(c); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteCategory removes a category; its articles become uncategorized.
+func (s *KnowledgeBaseService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteCategory(ctx, id)
+}
+
+// ============================================================================
+// Articles (admin)
+// ============================================================================
+
+// KBArticleInput is the admin create/update payload. Status defaults to
+// draft on create; Slug defaults to a slugified Title.
+type KBArticleInput struct {
+	Slug       string   `json:"slug"`
+	Title      string   `json:"title"`
+	Summary    string   `json:"summary"`
+	BodyMD     string   `json:"body_md"`
+	CategoryID string   `json:"category_id"`
+	Status     string   `json:"status"`
+	Keywords   []string `json:"keywords"`
+}
+
+func (s *KnowledgeBaseService) applyArticleInput(ctx context.Context, a *repository.KBArticle, in KBArticleInput) error {
+	a.Title = strings.TrimSpace(in.Title)
+	if a.Title == "" || len(a.Title) > maxKBTitleLen {
+		return fmt.Errorf("%w: title is required (max %d chars)", ErrKBInvalid, maxKBTitleLen)
+	}
+	a.Slug = strings.TrimSpace(in.Slug)
+	if a.Slug == "" {
+		a.Slug = slugifyKB(a.Title)
+	}
+	if !kbSlugAllowed.MatchString(a.Slug) || len(a.Slug) > 120 {
+		return fmt.Errorf("%w: slug must be lowercase words separated by '-'", ErrKBInvalid)
+	}
+	a.Summary = strings.TrimSpace(in.Summary)
+	if len(a.Summary) > maxKBSummaryLen {
+		return fmt.Errorf("%w: summary is limited to %d chars", ErrKBInvalid, maxKBSummaryLen)
+	}
+	if len(in.BodyMD) > maxKBBodyLen {
+		return fmt.Errorf("%w: body is too long", ErrKBInvalid)
+	}
+	a.BodyMD = in.BodyMD
+
+	status := in.Status
+	if status == "" {
+		status = a.Status
+	}
+	if status == "" {
+		status = KBStatusDraft
+	}
+	if !validKBStatuses[status] {
+		return fmt.Errorf("%w: status must be draft, published, or archived", ErrKBInvalid)
+	}
+	// First publish stamps published_at; re-publishing after archive keeps
+	// the original date so "what's new" ordering isn't gamed by toggling.
+	if status == KBStatusPublished && !a.PublishedAt.Valid {
+		a.PublishedAt = models.NullTime{NullTime: sql.NullTime{Time: time.Now(), Valid: true}}
+	}
+	a.Status = status
+
+	a.CategoryID = models.NullUUID{}
+	if in.CategoryID != "" {
+		cid, err := uuid.Parse(in.CategoryID)
+		if err != nil {
+			return fmt.Errorf("%w: bad category_id", ErrKBInvalid)
+		}
+		c, err := s.repo.GetCategory(ctx, cid)
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			return ErrKBCategoryNotFound
+		}
+		a.CategoryID = models.NullUUID{UUID: cid, Valid: true}
+	}
+
+	kw, err := normalizeKBKeywords(in.Keywords)
+	if err != nil {
+		return err
+	}
+	a.Keywords = kw
+	return nil
+}
+
+// normalizeKBKeywords lowercases keywords and splits multi-word entries so
+// each stored keyword can match a single extracted ticket term.
+func normalizeKBKeywords(raw []string) ([]string, error) {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, k := range raw {
+		for _, w := range kbSlugStripper.Split(strings.ToLower(k), -1) {
+			if w == "" || seen[w] {
+				continue
+			}
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	if len(out) > maxKBKeywords {
+		return nil, fmt.Errorf("%w: at most %d keywords", ErrKBInvalid, maxKBKeywords)
+	}
+	return out, nil
+}
+
+// ListArticles is the admin list (all statuses unless filtered).
+func (s *KnowledgeBaseService) ListArticles(ctx context.Context, f repository.KBArticleFilter, page, limit int) ([]repository.KBArticle, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 25
+	}
+	if f.Status != "" && !validKBStatuses[f.Status] {
+		return nil, 0, fmt.Errorf("%w: unknown status %q", ErrKBInvalid, f.Status)
+	}
+	return s.repo.ListArticles(ctx, f, limit, (page-1)*limit)
+}
+
+// GetArticle returns an article by ID with rendered HTML (admin preview).
+func (s *KnowledgeBaseService) GetArticle(ctx context.Context, id uuid.UUID) (*repository.KBArticle, error) {
+	a, err := s.repo.GetArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrKBArticleNotFound
+	}
+	s.render(a)
+	return a, nil
+}
+
+func (s *KnowledgeBaseService) CreateArticle(ctx context.Context, in KBArticleInput, actorID uuid.UUID) (*repository.KBArticle, error) {
+	a := &repository.KBArticle{CreatedBy: nullUUID(actorID)}
+	if err := s.applyArticleInput(ctx, a, in); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateArticle(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *KnowledgeBaseService) UpdateArticle(ctx context.Context, id uuid.UUID, in KBArticleInput, actorID uuid.UUID) (*repository.KBArticle, error) {
+	a, err := s.repo.GetArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrKBArticleNotFound
+	}
+	if err := s.applyArticleInput(ctx, a, in); err != nil {
+		return nil, err
+	}
+	a.UpdatedBy = nullUUID(actorID)
+	if err := s.repo.UpdateArticle(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *KnowledgeBaseService) DeleteArticle(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteArticle(ctx, id)
+}
+
+// ============================================================================
+// Public reads
+// ============================================================================
+
+// SearchPublished lists/searches published articles. An empty query lists
+// newest first; a non-empty one is ranked by relevance.
+func (s *KnowledgeBaseService) SearchPublished(ctx context.Context, query, categorySlug string, page, limit int) ([]repository.KBArticle, int, error) {
+	if len(query) > 200 {
+		query = query[:200]
+	}
+	return s.ListArticles(ctx, repository.KBArticleFilter{
+		Status:       KBStatusPublished,
+		CategorySlug: categorySlug,
+		Query:        query,
+	}, page, limit)
+}
+
+// GetPublishedBySlug returns a published article with rendered HTML and
+// bumps its view counter. Drafts/archived read as not found.
+func (s *KnowledgeBaseService) GetPublishedBySlug(ctx context.Context, slug string) (*repository.KBArticle, error) {
+	a, err := s.repo.GetArticleBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil || a.Status != KBStatusPublished {
+		return nil, ErrKBArticleNotFound
+	}
+	if err := s.repo.IncrementViews(ctx, a.ID); err != nil {
+		// Counter is advisory; never fail the read over it.
+		log.Printf("[KB] view increment for %s failed: %v", a.ID, err)
+	} else {
+		a.ViewCount++
+	}
+	s.render(a)
+	return a, nil
+}
+
+// SuggestForText recommends published articles matching free text, e.g.
+// a ticket's subject + description. Returns an empty slice (not an error)
+// when nothing useful can be extracted.
+func (s *KnowledgeBaseService) SuggestForText(ctx context.Context, text string) ([]repository.KBArticle, error) {
+	terms := ExtractKBTerms(text)
+	if len(terms) == 0 {
+		return []repository.KBArticle{}, nil
+	}
+	out, err := s.repo.SuggestArticles(ctx, terms, maxKBSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = []repository.KBArticle{}
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractKBTerms(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"stopwords and short words dropped", "Please help, the app is not syncing", []string{"syncing"}},
+		{"lowercased and deduped", "Medication reminder MEDICATION Reminder", []string{"medication", "reminder"}},
+		{"punctuation splits", "push-notifications/iOS v17.2", []string{"push", "notifications", "ios", "v17"}},
+		{"pure numbers skipped", "error 500 after 2024 update", []string{"error", "update"}},
+		{"alphanumeric kept", "crash on ios17 build", []string{"crash", "ios17", "build"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := ExtractKBTerms(c.in)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("ExtractKBTerms(%q) = %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestExtractKBTermsCapped(t *testing.T) {
+	words := make([]string, 0, 30)
+	for i := 0; i < 30; i++ {
+		words = append(words, "term"+string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+	got := ExtractKBTerms(strings.Join(words, " "))
+	if len(got) != maxKBSuggestTerms {
+		t.Fatalf("got %d terms, want %d", len(got), maxKBSuggestTerms)
+	}
+	if got[0] != "termaa" {
+		t.Errorf("first term = %q, want first-appearance order", got[0])
+	}
+}
+
+func TestSlugifyKB(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"How do I add a medication?", "how-do-i-add-a-medication"},
+		{"  Sync & Backup  ", "sync-backup"},
+		{"Ünïcode—Title", "n-code-title"},
+		{"---", ""},
+		{strings.Repeat("ab-", 60), strings.TrimRight(strings.Repeat("ab-", 34)[:100], "-")},
+	}
+	for _, c := range cases {
+		if got := slugifyKB(c.in); got != c.want {
+			t.Errorf("slugifyKB(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeKBKeywords(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr error
+	}{
+		{"nil ok", nil, []string{}, nil},
+		{"lowercased", []string{"Billing"}, []string{"billing"}, nil},
+		{"multi-word split", []string{"push notifications"}, []string{"push", "notifications"}, nil},
+		{"deduped across entries", []string{"sync", "Sync", "sync issues"}, []string{"sync", "issues"}, nil},
+		{"blanks dropped", []string{"", "  ", "x"}, []string{"x"}, nil},
+		{"too many rejected", strings.Fields("a b c d e f g h i j k l m n o p q r s t u"), nil, ErrKBInvalid},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := normalizeKBKeywords(c.in)
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Fatalf("err = %v, want %v", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
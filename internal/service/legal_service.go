@@ -0,0 +1,345 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrLegalDocumentNotFound  = apperr.NotFound("legal document not found")
+	ErrLegalDocumentPublished = errors.New("published legal documents can't be changed")
+	ErrLegalInvalid           = apperr.Validation("invalid legal document input")
+	// ErrLegalNotCurrent is returned when a user tries to accept a version
+	// that isn't the one in effect for its kind.
+	ErrLegalNotCurrent = errors.New("legal document is not the version in effect")
+)
+
+// Legal document kinds.
+const (
+	LegalKindTerms   = "terms"
+	LegalKindPrivacy = "privacy"
+)
+
+const (
+	maxLegalVersionLen = 20
+	maxLegalTitleLen   = 200
+	maxLegalSummaryLen = 2000
+	maxLegalBodyLen    = 500_000
+	maxLegalUALen      = 500
+)
+
+var validLegalKinds = map[string]bool{LegalKindTerms: true, LegalKindPrivacy: true}
+
+// LegalDocumentInput is the admin create/update payload for a draft.
+type LegalDocumentInput struct {
+	Kind               string `json:"kind"`
+	Version            string `json:"version"`
+	Title              string `json:"title"`
+	Summary            string `json:"summary"`
+	BodyMD             string `json:"body_md"`
+	RequiresAcceptance bool   `json:"requires_acceptance"`
+}
+
+func (in LegalDocumentInput) apply(d *repository.LegalDocument) error {
+	d.Version = strings.TrimSpace(in.Version)
+	if d.Version == "" || len(d.Version) > maxLegalVersionLen {
+		return fmt.Errorf("%w: version is required (max %d chars)", ErrLegalInvalid, maxLegalVersionLen)
+	}
+	d.Title = strings.TrimSpace(in.Title)
+	if d.Title == "" || len(d.Title) > maxLegalTitleLen {
+		return fmt.Errorf("%w: title is required (max %d chars)", ErrLegalInvalid, maxLegalTitleLen)
+	}
+	d.Summary = strings.TrimSpace(in.Summary)
+	if len(d.Summary) > maxLegalSummaryLen {
+		return fmt.Errorf("%w: summary must be %d characters or fewer", ErrLegalInvalid, maxLegalSummaryLen)
+	}
+	d.BodyMD = in.BodyMD
+	if strings.TrimSpace(d.BodyMD) == "" || len(d.BodyMD) > maxLegalBodyLen {
+		return fmt.Errorf("%w: body is required", ErrLegalInvalid)
+	}
+	d.RequiresAcceptance = in.RequiresAcceptance
+	return nil
+}
+
+// LegalStatus is where one user stands on the documents in effect.
+type LegalStatus struct {
+	// Pending are versions in effect the user must accept before going on.
+	Pending []repository.LegalDocument `json:"pending"`
+	// Upcoming are published versions whose effective date hasn't come,
+	// for a "these terms change on ..." notice.
+	Upcoming []repository.LegalDocument   `json:"upcoming"`
+	Accepted []repository.LegalAcceptance `json:"accepted"`
+}
+
+// LegalService manages versioned Terms of Service / Privacy Policy
+// documents and users' acceptance of them.
+type LegalService struct {
+	repo repository.LegalRepository
+	md   goldmark.Markdown
+	now  func() time.Time
+}
+
+func NewLegalService(repo repository.LegalRepository) *LegalService {
+	return &LegalService{
+		repo: repo,
+		md:   goldmark.New(goldmark.WithExtensions(extension.GFM)),
+		now:  time.Now,
+	}
+}
+
+func (s *LegalService) render(d *repository.LegalDocument) {
+	if d.BodyMD == "" {
+		return
+	}
+	var buf bytes.Buffer
+	if err := s.md.Convert([]byte(d.BodyMD), &buf); err != nil {
+		log.Printf("[LEGAL] render %s %s failed: %v", d.Kind, d.Version, err)
+		return
+	}
+	d.BodyHTML = buf.String()
+}
+
+// ============================================================================
+// Admin
+// ============================================================================
+
+// List returns every version of kind ("" for all), without bodies.
+func (s *LegalService) List(ctx context.Context, kind string) ([]repository.LegalDocument, error) {
+	if kind != "" && !validLegalKinds[kind] {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrLegalInvalid, kind)
+	}
+	return s.repo.ListDocuments(ctx, kind)
+}
+
+// Get returns one version with its body rendered.
+func (s *LegalService) Get(ctx context.Context, id uuid.UUID) (*repository.LegalDocument, error) {
+	d, err := s.repo.GetDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrLegalDocumentNotFound
+	}
+	s.render(d)
+	return d, nil
+}
+
+// CreateDraft saves a new, unpublished version.
+func (s *LegalService) CreateDraft(ctx context.Context, in LegalDocumentInput, by uuid.UUID) (*repository.LegalDocument, error) {
+	if !validLegalKinds[in.Kind] {
+		return nil, fmt.Errorf("%w: kind must be terms or privacy", ErrLegalInvalid)
+	}
+	d := &repository.LegalDocument{Kind: in.Kind}
+	if err := in.apply(d); err != nil {
+		return nil, err
+	}
+	if by != uuid.Nil {
+		d.CreatedBy = &by
+	}
+	if err := s.repo.CreateDocument(ctx, d); err != nil {
+		if errors.Is(err, repository.ErrLegalVersionTaken) {
+			return nil, fmt.Errorf("%w: %s version %s already exists", ErrLegalInvalid, d.Kind, d.Version)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// UpdateDraft edits a draft. The kind can't change.
+func (s *LegalService) UpdateDraft(ctx context.Context, id uuid.UUID, in LegalDocumentInput) (*repository.LegalDocument, error) {
+	d, err := s.draft(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.apply(d); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.UpdateDraft(ctx, d)
+	switch {
+	case errors.Is(err, repository.ErrLegalVersionTaken):
+		return nil, fmt.Errorf("%w: %s version %s already exists", ErrLegalInvalid, d.Kind, d.Version)
+	case err != nil:
+		return nil, err
+	case !ok:
+		// Published between the read and the write.
+		return nil, ErrLegalDocumentPublished
+	}
+	return d, nil
+}
+
+// DeleteDraft removes a draft. Published versions are kept forever since
+// acceptances point at them.
+func (s *LegalService) DeleteDraft(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.draft(ctx, id); err != nil {
+		return err
+	}
+	ok, err := s.repo.DeleteDraft(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLegalDocumentPublished
+	}
+	return nil
+}
+
+// Publish freezes a draft and schedules it to take effect at effectiveAt
+// (now when zero). The date can't be in the past: users would be held to
+// terms that were never announced.
+func (s *LegalService) Publish(ctx context.Context, id uuid.UUID, effectiveAt time.Time) (*repository.LegalDocument, error) {
+	if _, err := s.draft(ctx, id); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if effectiveAt.IsZero() {
+		effectiveAt = now
+	} else if effectiveAt.Before(now.Add(-time.Minute)) {
+		return nil, fmt.Errorf("%w: effective date can't be in the past", ErrLegalInvalid)
+	}
+	ok, err := s.repo.Publish(ctx, id, effectiveAt)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLegalDocumentPublished
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *LegalService) draft(ctx context.Context, id uuid.UUID) (*repository.LegalDocument, error) {
+	d, err := s.repo.GetDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrLegalDocumentNotFound
+	}
+	if d.PublishedAt != nil {
+		return nil, ErrLegalDocumentPublished
+	}
+	return d, nil
+}
+
+// Coverage reports, per published version, how many active users have
+// accepted it.
+func (s *LegalService) Coverage(ctx context.Context) ([]repository.LegalCoverage, error) {
+	return s.repo.Coverage(ctx, s.now())
+}
+
+// Acceptances lists who accepted a published version, for export.
+func (s *LegalService) Acceptances(ctx context.Context, id uuid.UUID) (*repository.LegalDocument, []repository.LegalAcceptanceRow, error) {
+	d, err := s.repo.GetDocument(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if d == nil {
+		return nil, nil, ErrLegalDocumentNotFound
+	}
+	rows, err := s.repo.ListAcceptances(ctx, id)
+	return d, rows, err
+}
+
+// ============================================================================
+// Users
+// ============================================================================
+
+// Current returns the version in effect for each kind, rendered, keyed by
+// kind. A kind with an empty body is served from the static page.
+func (s *LegalService) Current(ctx context.Context) (map[string]*repository.LegalDocument, error) {
+	docs, err := s.repo.InEffect(ctx, s.now())
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*repository.LegalDocument, len(docs))
+	for i := range docs {
+		s.render(&docs[i])
+		out[docs[i].Kind] = &docs[i]
+	}
+	return out, nil
+}
+
+// Pending returns the in-effect versions userID still has to accept.
+func (s *LegalService) Pending(ctx context.Context, userID uuid.UUID) ([]repository.LegalDocument, error) {
+	return s.repo.Pending(ctx, userID, s.now())
+}
+
+// Status returns what userID has accepted, what they still must, and any
+// announced changes.
+func (s *LegalService) Status(ctx context.Context, userID uuid.UUID) (*LegalStatus, error) {
+	now := s.now()
+	pending, err := s.repo.Pending(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	upcoming, err := s.repo.Upcoming(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := s.repo.AcceptancesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &LegalStatus{Pending: pending, Upcoming: upcoming, Accepted: accepted}, nil
+}
+
+// Accept records userID's acceptance of documentIDs, each of which must
+// be the version currently in effect for its kind. ip and userAgent are
+// stored as evidence; an unparseable ip is dropped rather than failing.
+func (s *LegalService) Accept(ctx context.Context, userID uuid.UUID, documentIDs []uuid.UUID, ip, userAgent string) error {
+	if len(documentIDs) == 0 {
+		return fmt.Errorf("%w: no documents to accept", ErrLegalInvalid)
+	}
+	current, err := s.repo.InEffect(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	inEffect := make(map[uuid.UUID]bool, len(current))
+	for _, d := range current {
+		inEffect[d.ID] = true
+	}
+	for _, id := range documentIDs {
+		if !inEffect[id] {
+			return ErrLegalNotCurrent
+		}
+	}
+	return s.repo.Accept(ctx, userID, documentIDs, legalIP(ip), truncateRunes(userAgent, maxLegalUALen))
+}
+
+// AcceptCurrent records acceptance of every version in effect, for signup,
+// where agreeing to the terms is part of creating the account.
+func (s *LegalService) AcceptCurrent(ctx context.Context, userID uuid.UUID, ip, userAgent string) error {
+	current, err := s.repo.InEffect(ctx, s.now())
+	if err != nil || len(current) == 0 {
+		return err
+	}
+	ids := make([]uuid.UUID, len(current))
+	for i, d := range current {
+		ids[i] = d.ID
+	}
+	return s.repo.Accept(ctx, userID, ids, legalIP(ip), truncateRunes(userAgent, maxLegalUALen))
+}
+
+// legalIP returns ip without any port, or "" if it isn't an address.
+func legalIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// fakeLegalRepo keeps documents in memory; InEffect mirrors the SQL rule
+// (latest published version per kind whose effective date has passed).
+type fakeLegalRepo struct {
+	repository.LegalRepository
+	docs     map[uuid.UUID]*repository.LegalDocument
+	accepted map[uuid.UUID][]uuid.UUID
+	lastIP   string
+}
+
+func newFakeLegalRepo() *fakeLegalRepo {
+	return &fakeLegalRepo{docs: map[uuid.UUID]*repository.LegalDocument{}, accepted: map[uuid.UUID][]uuid.UUID{}}
+}
+
+func (f *fakeLegalRepo) GetDocument(ctx context.Context, id uuid.UUID) (*repository.LegalDocument, error) {
+	d, ok := f.docs[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (f *fakeLegalRepo) CreateDocument(ctx context.Context, d *repository.LegalDocument) error {
+	d.ID = uuid.New()
+	cp := *d
+	f.docs[d.ID] = &cp
+	return nil
+}
+
+func (f *fakeLegalRepo) Publish(ctx context.Context, id uuid.UUID, effectiveAt time.Time) (bool, error) {
+	d := f.docs[id]
+	if d == nil || d.PublishedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	d.PublishedAt, d.EffectiveAt = &now, &effectiveAt
+	return true, nil
+}
+
+func (f *fakeLegalRepo) InEffect(ctx context.Context, now time.Time) ([]repository.LegalDocument, error) {
+	latest := map[string]*repository.LegalDocument{}
+	for _, d := range f.docs {
+		if d.EffectiveAt == nil || d.EffectiveAt.After(now) {
+			continue
+		}
+		if cur := latest[d.Kind]; cur == nil || d.EffectiveAt.After(*cur.EffectiveAt) {
+			latest[d.Kind] = d
+		}
+	}
+	var out []repository.LegalDocument
+	for _, d := range latest {
+		out = append(out, *d)
+	}
+	return out, nil
+}
+
+func (f *fakeLegalRepo) Accept(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, ip, ua string) error {
+	f.accepted[userID] = append(f.accepted[userID], ids...)
+	f.lastIP = ip
+	return nil
+}
+
+func TestLegalPublishAndAccept(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeLegalRepo()
+	svc := NewLegalService(repo)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	in := LegalDocumentInput{Kind: LegalKindTerms, Version: "2.0", Title: "Terms of Service", BodyMD: "## Changes\n\nNew terms.", RequiresAcceptance: true}
+	draft, err := svc.CreateDraft(ctx, in, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Publish(ctx, draft.ID, now.Add(-time.Hour)); !errors.Is(err, ErrLegalInvalid) {
+		t.Errorf("backdated publish: err = %v, want ErrLegalInvalid", err)
+	}
+	effective := now.Add(30 * 24 * time.Hour)
+	pub, err := svc.Publish(ctx, draft.ID, effective)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(pub.BodyHTML, "<h2>Changes</h2>") {
+		t.Errorf("body not rendered: %q", pub.BodyHTML)
+	}
+	if _, err := svc.UpdateDraft(ctx, draft.ID, in); !errors.Is(err, ErrLegalDocumentPublished) {
+		t.Errorf("editing a published version: err = %v", err)
+	}
+
+	user := uuid.New()
+	// Not in effect until its date, so it can't be accepted yet.
+	if err := svc.Accept(ctx, user, []uuid.UUID{draft.ID}, "203.0.113.9", "test"); !errors.Is(err, ErrLegalNotCurrent) {
+		t.Errorf("accepting an upcoming version: err = %v", err)
+	}
+	now = effective.Add(time.Minute)
+	if err := svc.Accept(ctx, user, []uuid.UUID{draft.ID}, "203.0.113.9:5123", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.accepted[user]; len(got) != 1 || got[0] != draft.ID {
+		t.Errorf("accepted = %v", got)
+	}
+	if repo.lastIP != "203.0.113.9" {
+		t.Errorf("ip = %q, want the port stripped", repo.lastIP)
+	}
+}
+
+func TestLegalAcceptCurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeLegalRepo()
+	svc := NewLegalService(repo)
+	for _, kind := range []string{LegalKindTerms, LegalKindPrivacy} {
+		d, err := svc.CreateDraft(ctx, LegalDocumentInput{Kind: kind, Version: "1.1", Title: kind, BodyMD: "text"}, uuid.Nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := svc.Publish(ctx, d.ID, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	user := uuid.New()
+	if err := svc.AcceptCurrent(ctx, user, "not an ip", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.accepted[user]) != 2 {
+		t.Errorf("signup should accept both documents, got %v", repo.accepted[user])
+	}
+	if repo.lastIP != "" {
+		t.Errorf("an unparseable ip should be dropped, got %q", repo.lastIP)
+	}
+}
+
+func TestLegalDraftValidation(t *testing.T) {
+	svc := NewLegalService(newFakeLegalRepo())
+	cases := []LegalDocumentInput{
+		{Kind: "cookies", Version: "1", Title: "x", BodyMD: "x"},
+		{Kind: LegalKindTerms, Version: "", Title: "x", BodyMD: "x"},
+		{Kind: LegalKindTerms, Version: "1", Title: "x", BodyMD: "   "},
+	}
+	for _, in := range cases {
+		if _, err := svc.CreateDraft(context.Background(), in, uuid.Nil); !errors.Is(err, ErrLegalInvalid) {
+			t.Errorf("%+v: err = %v, want ErrLegalInvalid", in, err)
+		}
+	}
+}
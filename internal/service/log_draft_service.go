@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	// ErrLogDraftInvalid is returned for a draft that can't be saved; the
+	// message says why.
+	ErrLogDraftInvalid = apperr.Validation("invalid log draft")
+	// ErrNoPreviousLog is returned when there is no entry on the day a
+	// draft was to be copied from.
+	ErrNoPreviousLog = errors.New("no entry of that type on that day")
+)
+
+// draftLogSource is the part of LogRepository FromPrevious needs: a
+// day's logs of one type.
+type draftLogSource interface {
+	ListLogFields(ctx context.Context, logType string, childID uuid.UUID, startDate, endDate time.Time, fields []string) (any, error)
+}
+
+// LogDraftService autosaves log forms in progress, so a long entry
+// survives the app being backgrounded and can be picked up on another
+// device. Each user has at most one draft per child and log type; saving
+// replaces it and pushes its expiry out by the TTL. Drafts hold whatever
+// the form had and aren't validated until the log itself is saved.
+type LogDraftService struct {
+	repo     repository.LogDraftRepository
+	logs     draftLogSource
+	ttl      time.Duration
+	maxBytes int
+	now      func() time.Time
+}
+
+func NewLogDraftService(repo repository.LogDraftRepository, logs draftLogSource, ttl time.Duration, maxBytes int) *LogDraftService {
+	return &LogDraftService{repo: repo, logs: logs, ttl: ttl, maxBytes: maxBytes, now: time.Now}
+}
+
+func invalidLogDraft(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrLogDraftInvalid, fmt.Sprintf(format, args...))
+}
+
+func validLogDraftType(logType string) error {
+	if !slices.Contains(repository.LogUsageTypes, logType) {
+		return invalidLogDraft("unknown log type %q", logType)
+	}
+	return nil
+}
+
+// Save replaces the user's draft for the child and log type.
+func (s *LogDraftService) Save(ctx context.Context, userID, childID uuid.UUID, logType string, req *models.SaveLogDraftRequest) (*models.LogDraft, error) {
+	if err := validLogDraftType(logType); err != nil {
+		return nil, err
+	}
+	data := bytes.TrimSpace(req.Data)
+	if len(data) == 0 || data[0] != '{' || !json.Valid(data) {
+		return nil, invalidLogDraft("data must be a JSON object")
+	}
+	if len(data) > s.maxBytes {
+		return nil, invalidLogDraft("drafts can be at most %d KB", s.maxBytes/1024)
+	}
+	d := &models.LogDraft{
+		UserID:    userID,
+		ChildID:   childID,
+		LogType:   logType,
+		LogID:     req.LogID,
+		Data:      data,
+		ExpiresAt: s.now().Add(s.ttl),
+	}
+	if err := s.repo.Save(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// FromPrevious replaces the user's draft for the log type with a copy of
+// the child's latest entry of that type on day ("same as yesterday"):
+// what was entered, without the date, so it is logged for the day it is
+// saved. ErrNoPreviousLog when there was no such entry.
+func (s *LogDraftService) FromPrevious(ctx context.Context, userID, childID uuid.UUID, logType string, day time.Time) (*models.LogDraft, error) {
+	if !slices.Contains(logTemplateTypes, logType) {
+		return nil, invalidLogDraft("%q entries can't be copied", logType)
+	}
+	logs, err := s.logs.ListLogFields(ctx, logType, childID, day, day, nil)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(logs)
+	if err != nil {
+		return nil, err
+	}
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoPreviousLog
+	}
+	// Newest first.
+	data, err := logEntryFormData(entries[0])
+	if err != nil {
+		return nil, err
+	}
+	return s.Save(ctx, userID, childID, logType, &models.SaveLogDraftRequest{Data: data})
+}
+
+// Get returns the user's draft for the child and log type, or nil.
+func (s *LogDraftService) Get(ctx context.Context, userID, childID uuid.UUID, logType string) (*models.LogDraft, error) {
+	if err := validLogDraftType(logType); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID, childID, logType)
+}
+
+// List returns the user's drafts for the child, most recent first.
+func (s *LogDraftService) List(ctx context.Context, userID, childID uuid.UUID) ([]models.LogDraft, error) {
+	drafts, err := s.repo.ListForChild(ctx, userID, childID)
+	if err != nil {
+		return nil, err
+	}
+	if drafts == nil {
+		drafts = []models.LogDraft{}
+	}
+	return drafts, nil
+}
+
+// Delete discards the user's draft for the child and log type; clients
+// call it once the log is saved or the form abandoned.
+func (s *LogDraftService) Delete(ctx context.Context, userID, childID uuid.UUID, logType string) error {
+	if err := validLogDraftType(logType); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userID, childID, logType)
+}
+
+// SweepExpired deletes drafts past their expiry.
+func (s *LogDraftService) SweepExpired(ctx context.Context) (int, error) {
+	return s.repo.DeleteExpired(ctx, s.now())
+}
+
+// LogDraftSweeper runs SweepExpired hourly.
+type LogDraftSweeper struct {
+	svc  *LogDraftService
+	jobs *JobLocker
+}
+
+func NewLogDraftSweeper(svc *LogDraftService, jobs *JobLocker) *LogDraftSweeper {
+	return &LogDraftSweeper{svc: svc, jobs: jobs}
+}
+
+func (s *LogDraftSweeper) Start(ctx context.Context) {
+	log.Println("Log draft sweeper started")
+	sweep := func() {
+		s.jobs.RunOnce(ctx, "log_draft_sweep", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			if n, err := s.svc.SweepExpired(ctx); err != nil {
+				log.Printf("Log draft sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Log draft sweep: deleted %d expired draft(s)", n)
+			}
+		})
+	}
+	sweep()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Log draft sweeper stopped")
+			return
+		case <-ticker.C:
+			sweep()
+		}
+	}
+}
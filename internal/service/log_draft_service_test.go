@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeLogDrafts struct {
+	saved []models.LogDraft
+}
+
+func (f *fakeLogDrafts) Save(ctx context.Context, d *models.LogDraft) error {
+	d.UpdatedAt = d.ExpiresAt.Add(-time.Hour)
+	f.saved = append(f.saved, *d)
+	return nil
+}
+
+func (f *fakeLogDrafts) Get(ctx context.Context, userID, childID uuid.UUID, logType string) (*models.LogDraft, error) {
+	return nil, nil
+}
+
+func (f *fakeLogDrafts) ListForChild(ctx context.Context, userID, childID uuid.UUID) ([]models.LogDraft, error) {
+	return nil, nil
+}
+
+func (f *fakeLogDrafts) Delete(ctx context.Context, userID, childID uuid.UUID, logType string) error {
+	return nil
+}
+
+func (f *fakeLogDrafts) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestLogDraftSave(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeLogDrafts{}
+	s := NewLogDraftService(repo, nil, 48*time.Hour, 1024)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	user, child := uuid.New(), uuid.New()
+
+	d, err := s.Save(ctx, user, child, "therapy", &models.SaveLogDraftRequest{Data: json.RawMessage(` {"progress_notes": "worked on"} `)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.ExpiresAt.Equal(now.Add(48*time.Hour)) || string(d.Data) != `{"progress_notes": "worked on"}` || len(repo.saved) != 1 {
+		t.Errorf("draft = %+v", d)
+	}
+
+	for name, req := range map[string]struct {
+		logType string
+		data    string
+	}{
+		"unknown type": {"mood", `{}`},
+		"not object":   {"behavior", `["notes"]`},
+		"bad json":     {"behavior", `{"notes": `},
+		"empty":        {"behavior", ``},
+		"too big":      {"behavior", `{"notes": "` + strings.Repeat("x", 1024) + `"}`},
+	} {
+		if _, err := s.Save(ctx, user, child, req.logType, &models.SaveLogDraftRequest{Data: json.RawMessage(req.data)}); !errors.Is(err, ErrLogDraftInvalid) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if len(repo.saved) != 1 {
+		t.Errorf("invalid drafts saved: %d", len(repo.saved))
+	}
+
+	drafts, err := s.List(ctx, user, child)
+	if err != nil || drafts == nil {
+		t.Errorf("List = %v, %v; want empty slice", drafts, err)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// fakeLogFieldsRepo answers ListLogFields with logs and records the fields
+// it was asked for. Like the column projection, it rejects "provider".
+type fakeLogFieldsRepo struct {
+	repository.LogRepository
+	logs   any
+	fields []string
+}
+
+func (f *fakeLogFieldsRepo) ListLogFields(ctx context.Context, logType string, childID uuid.UUID, startDate, endDate time.Time, fields []string) (any, error) {
+	f.fields = fields
+	for _, field := range fields {
+		if field == logProviderField {
+			return nil, repository.ErrUnknownField
+		}
+	}
+	return f.logs, nil
+}
+
+type fakeLogAttributor map[uuid.UUID]*models.ProviderAttribution
+
+func (f fakeLogAttributor) Attributions(ctx context.Context, logType string, logIDs []uuid.UUID) (map[uuid.UUID]*models.ProviderAttribution, error) {
+	return f, nil
+}
+
+func TestListLogFieldsAttachesProvider(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	attr := &models.ProviderAttribution{GrantID: uuid.New(), OrganizationName: "Bright Steps ABA"}
+
+	cases := []struct {
+		logType    string
+		fields     []string
+		wantFields []string
+		attached   bool
+	}{
+		{"behavior", []string{"mood_level", "provider"}, []string{"mood_level"}, true},
+		{"behavior", []string{"provider"}, []string{"id"}, true},
+		{"behavior", []string{"mood_level"}, []string{"mood_level"}, false},
+		{"behavior", nil, nil, true},
+		{"therapy", []string{"provider", "therapy_type"}, []string{"therapy_type"}, true},
+	}
+	for _, c := range cases {
+		repo := &fakeLogFieldsRepo{}
+		if c.logType == "behavior" {
+			repo.logs = []models.BehaviorLog{{ID: id}}
+		} else {
+			repo.logs = []models.TherapyLog{{ID: id}}
+		}
+		s := &LogService{logRepo: repo, providers: fakeLogAttributor{id: attr}}
+		logs, err := s.ListLogFields(ctx, c.logType, uuid.New(), time.Now(), time.Now(), c.fields)
+		if err != nil {
+			t.Fatalf("%s %v: %v", c.logType, c.fields, err)
+		}
+		if !reflect.DeepEqual(repo.fields, c.wantFields) {
+			t.Errorf("%s %v: repository asked for %v, want %v", c.logType, c.fields, repo.fields, c.wantFields)
+		}
+		var got *models.ProviderAttribution
+		switch l := logs.(type) {
+		case []models.BehaviorLog:
+			got = l[0].Provider
+		case []models.TherapyLog:
+			got = l[0].Provider
+		}
+		if (got != nil) != c.attached {
+			t.Errorf("%s %v: provider attached = %v, want %v", c.logType, c.fields, got != nil, c.attached)
+		}
+	}
+
+	// Other log types have no provider attribution, so it's an unknown field.
+	s := &LogService{logRepo: &fakeLogFieldsRepo{logs: []models.SleepLog{}}}
+	if _, err := s.ListLogFields(ctx, "sleep", uuid.New(), time.Now(), time.Now(), []string{"provider"}); !errors.Is(err, repository.ErrUnknownField) {
+		t.Errorf("sleep provider = %v, want ErrUnknownField", err)
+	}
+}
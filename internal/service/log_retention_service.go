@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrLogRetentionInvalid = apperr.Validation("invalid log retention settings")
+	ErrLogRetentionRunning = errors.New("log retention is already running")
+)
+
+const (
+	// LogRetentionSettingKey is the system_settings key holding LogRetention.
+	LogRetentionSettingKey = "log_retention"
+	// logPruneBatch is how many raw rows each DELETE removes, so pruning a
+	// large backlog doesn't hold one long lock on the table.
+	logPruneBatch = 5000
+)
+
+// LogRetention is how long request and error logs are kept. Raw rows feed
+// the error log views and per-request drill-downs; the hourly rollups feed
+// the dashboard and long-range charts, so they are kept much longer.
+type LogRetention struct {
+	ResponseTimeDays int `json:"response_time_days"`
+	ErrorLogDays     int `json:"error_log_days"`
+	RollupDays       int `json:"rollup_days"`
+}
+
+// DefaultLogRetention applies when the setting is missing or a field is
+// out of range (e.g. written through the generic settings endpoint).
+var DefaultLogRetention = LogRetention{ResponseTimeDays: 3, ErrorLogDays: 90, RollupDays: 395}
+
+// Validate checks each window against its allowed range.
+func (l LogRetention) Validate() error {
+	switch {
+	case l.ResponseTimeDays < 1 || l.ResponseTimeDays > 90:
+		return fmt.Errorf("%w: response_time_days must be 1-90", ErrLogRetentionInvalid)
+	case l.ErrorLogDays < 7 || l.ErrorLogDays > 730:
+		return fmt.Errorf("%w: error_log_days must be 7-730", ErrLogRetentionInvalid)
+	case l.RollupDays < 30 || l.RollupDays > 1825:
+		return fmt.Errorf("%w: rollup_days must be 30-1825", ErrLogRetentionInvalid)
+	}
+	return nil
+}
+
+// settingsStore is the slice of AdminRepository the retention settings use.
+type settingsStore interface {
+	GetSetting(ctx context.Context, key string) (interface{}, error)
+	UpdateSetting(ctx context.Context, key string, value interface{}, updatedBy uuid.UUID) error
+}
+
+// LogRetentionRun reports what one retention run did.
+type LogRetentionRun struct {
+	Settings            LogRetention `json:"settings"`
+	RollupThrough       time.Time    `json:"rollup_through"`
+	ResponseRollupRows  int64        `json:"response_rollup_rows"`
+	ErrorRollupRows     int64        `json:"error_rollup_rows"`
+	PrunedResponseTimes int64        `json:"pruned_response_times"`
+	PrunedErrorLogs     int64        `json:"pruned_error_logs"`
+	PrunedRollupRows    int64        `json:"pruned_rollup_rows"`
+}
+
+// LogRetentionStatus is the retention panel on the settings page.
+type LogRetentionStatus struct {
+	Settings         LogRetention               `json:"settings"`
+	ResponseRollupTo *time.Time                 `json:"response_rollup_to,omitempty"`
+	ErrorRollupTo    *time.Time                 `json:"error_rollup_to,omitempty"`
+	Tables           []repository.LogTableStats `json:"tables"`
+}
+
+// LogRetentionService rolls response_time_logs and error_logs up into
+// hourly tables and prunes raw rows and rollups past their windows.
+//
+// Each completed hour is rolled up before its raw rows can be pruned, and
+// the hour before the newest rollup is rebuilt on every run to pick up
+// rows inserted late by the async request logger.
+type LogRetentionService struct {
+	repo     repository.LogRetentionRepository
+	settings settingsStore
+	jobs     *JobLocker
+	now      func() time.Time
+}
+
+// NewLogRetentionService creates the service. jobs may be nil (single
+// instance); settings is normally the AdminRepository.
+func NewLogRetentionService(repo repository.LogRetentionRepository, settings settingsStore, jobs *JobLocker) *LogRetentionService {
+	return &LogRetentionService{repo: repo, settings: settings, jobs: jobs, now: time.Now}
+}
+
+// Settings returns the stored windows, with defaults for any field that is
+// missing or out of range.
+func (s *LogRetentionService) Settings(ctx context.Context) (LogRetention, error) {
+	out := DefaultLogRetention
+	val, err := s.settings.GetSetting(ctx, LogRetentionSettingKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	var stored LogRetention
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		log.Printf("[RETENTION] %s setting unreadable, using defaults: %v", LogRetentionSettingKey, err)
+		return out, nil
+	}
+	return stored.withDefaults(), nil
+}
+
+// withDefaults replaces each out-of-range window with its default.
+func (l LogRetention) withDefaults() LogRetention {
+	d := DefaultLogRetention
+	if (LogRetention{l.ResponseTimeDays, d.ErrorLogDays, d.RollupDays}).Validate() != nil {
+		l.ResponseTimeDays = d.ResponseTimeDays
+	}
+	if (LogRetention{d.ResponseTimeDays, l.ErrorLogDays, d.RollupDays}).Validate() != nil {
+		l.ErrorLogDays = d.ErrorLogDays
+	}
+	if (LogRetention{d.ResponseTimeDays, d.ErrorLogDays, l.RollupDays}).Validate() != nil {
+		l.RollupDays = d.RollupDays
+	}
+	return l
+}
+
+// UpdateSettings validates and stores new windows. They take effect on the
+// next run.
+func (s *LogRetentionService) UpdateSettings(ctx context.Context, l LogRetention, by uuid.UUID) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	return s.settings.UpdateSetting(ctx, LogRetentionSettingKey, l, by)
+}
+
+// Status returns the settings, rollup progress and table sizes.
+func (s *LogRetentionService) Status(ctx context.Context) (*LogRetentionStatus, error) {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st := &LogRetentionStatus{Settings: settings}
+	if st.ResponseRollupTo, err = s.repo.ResponseRollupEnd(ctx); err != nil {
+		return nil, err
+	}
+	if st.ErrorRollupTo, err = s.repo.ErrorRollupEnd(ctx); err != nil {
+		return nil, err
+	}
+	if st.Tables, err = s.repo.TableStats(ctx); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// RunNow runs retention immediately from the admin UI, under the same job
+// lock as the hourly run so the two never overlap.
+func (s *LogRetentionService) RunNow(ctx context.Context) (*LogRetentionRun, error) {
+	var (
+		run    *LogRetentionRun
+		runErr error
+	)
+	ran := s.jobs.RunOnce(ctx, "log_retention", s.now(), time.Minute, func(ctx context.Context) {
+		run, runErr = s.Run(ctx)
+	})
+	if !ran {
+		return nil, ErrLogRetentionRunning
+	}
+	return run, runErr
+}
+
+// Run rolls up every completed hour not yet rolled up, then prunes.
+// Nothing is pruned when a rollup fails, so no raw row is deleted before
+// it has been counted.
+func (s *LogRetentionService) Run(ctx context.Context) (*LogRetentionRun, error) {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	end := now.Truncate(time.Hour)
+	run := &LogRetentionRun{Settings: settings, RollupThrough: end}
+
+	respFrom, err := s.rollupFrom(ctx, s.repo.ResponseRollupEnd, end, settings.ResponseTimeDays)
+	if err == nil {
+		run.ResponseRollupRows, err = s.repo.RollupResponseTimes(ctx, respFrom, end)
+	}
+	if err != nil {
+		return run, fmt.Errorf("roll up response times: %w", err)
+	}
+	errFrom, err := s.rollupFrom(ctx, s.repo.ErrorRollupEnd, end, settings.ErrorLogDays)
+	if err == nil {
+		run.ErrorRollupRows, err = s.repo.RollupErrorLogs(ctx, errFrom, end)
+	}
+	if err != nil {
+		return run, fmt.Errorf("roll up error logs: %w", err)
+	}
+
+	if run.PrunedResponseTimes, err = s.prune(ctx, s.repo.PruneResponseTimes, now.AddDate(0, 0, -settings.ResponseTimeDays)); err != nil {
+		return run, fmt.Errorf("prune response times: %w", err)
+	}
+	if run.PrunedErrorLogs, err = s.prune(ctx, s.repo.PruneErrorLogs, now.AddDate(0, 0, -settings.ErrorLogDays)); err != nil {
+		return run, fmt.Errorf("prune error logs: %w", err)
+	}
+	if run.PrunedRollupRows, err = s.repo.PruneRollups(ctx, end.AddDate(0, 0, -settings.RollupDays)); err != nil {
+		return run, fmt.Errorf("prune rollups: %w", err)
+	}
+	return run, nil
+}
+
+// rollupFrom is where a rollup up to end starts: the hour before the
+// newest rollup (rebuilt for late rows), or on the first run as far back
+// as raw rows are kept.
+func (s *LogRetentionService) rollupFrom(ctx context.Context, rollupEnd func(context.Context) (*time.Time, error), end time.Time, rawDays int) (time.Time, error) {
+	last, err := rollupEnd(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last == nil {
+		return end.AddDate(0, 0, -rawDays).Truncate(time.Hour), nil
+	}
+	return last.UTC().Add(-time.Hour), nil
+}
+
+// prune deletes in batches until a short batch, leaving the remainder for
+// the next run once shutdown starts.
+func (s *LogRetentionService) prune(ctx context.Context, del func(context.Context, time.Time, int) (int64, error), cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		n, err := del(ctx, cutoff, logPruneBatch)
+		total += n
+		if err != nil || n < logPruneBatch || s.jobs.Draining() {
+			return total, err
+		}
+	}
+}
+
+// LogRetentionScheduler runs retention a few minutes past every hour, once
+// the previous hour's async request logs have landed.
+type LogRetentionScheduler struct {
+	svc  *LogRetentionService
+	jobs *JobLocker
+}
+
+func NewLogRetentionScheduler(svc *LogRetentionService, jobs *JobLocker) *LogRetentionScheduler {
+	return &LogRetentionScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *LogRetentionScheduler) Start(ctx context.Context) {
+	log.Println("Log retention scheduler started (hourly at :05)")
+	for {
+		next := TickSlot(time.Now(), time.Hour).Add(time.Hour + 5*time.Minute)
+		if time.Until(next) > time.Hour {
+			next = next.Add(-time.Hour)
+		}
+		select {
+		case <-ctx.Done():
+			log.Println("Log retention scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "log_retention", next, time.Hour, func(ctx context.Context) {
+				run, err := s.svc.Run(ctx)
+				if err != nil {
+					log.Printf("Log retention failed: %v", err)
+					return
+				}
+				if run.PrunedResponseTimes+run.PrunedErrorLogs+run.PrunedRollupRows > 0 {
+					log.Printf("Log retention: pruned %d response time row(s), %d error log(s), %d rollup row(s)",
+						run.PrunedResponseTimes, run.PrunedErrorLogs, run.PrunedRollupRows)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// retentionRepo is an in-memory LogRetentionRepository holding raw row
+// timestamps and the rolled-up hours.
+type retentionRepo struct {
+	responses, errs         []time.Time
+	responseHours, errHours map[time.Time]int
+	rollupCalls             [][2]time.Time
+	failRollup              bool
+}
+
+func newRetentionRepo() *retentionRepo {
+	return &retentionRepo{responseHours: map[time.Time]int{}, errHours: map[time.Time]int{}}
+}
+
+func hoursEnd(hours map[time.Time]int) *time.Time {
+	var end *time.Time
+	for h := range hours {
+		if e := h.Add(time.Hour); end == nil || e.After(*end) {
+			end = &e
+		}
+	}
+	return end
+}
+
+// rollup upserts the hours in [from, to) that have raw rows, like the
+// INSERT ... ON CONFLICT DO UPDATE in the real repository.
+func rollup(rows []time.Time, hours map[time.Time]int, from, to time.Time) int64 {
+	counts := map[time.Time]int{}
+	for _, t := range rows {
+		if !t.Before(from) && t.Before(to) {
+			counts[t.Truncate(time.Hour)]++
+		}
+	}
+	for h, n := range counts {
+		hours[h] = n
+	}
+	return int64(len(counts))
+}
+
+func prune(rows *[]time.Time, cutoff time.Time, limit int) int64 {
+	var kept []time.Time
+	var n int64
+	for _, t := range *rows {
+		if t.Before(cutoff) && n < int64(limit) {
+			n++
+			continue
+		}
+		kept = append(kept, t)
+	}
+	*rows = kept
+	return n
+}
+
+func (r *retentionRepo) ResponseRollupEnd(ctx context.Context) (*time.Time, error) {
+	return hoursEnd(r.responseHours), nil
+}
+
+func (r *retentionRepo) ErrorRollupEnd(ctx context.Context) (*time.Time, error) {
+	return hoursEnd(r.errHours), nil
+}
+
+func (r *retentionRepo) RollupResponseTimes(ctx context.Context, from, to time.Time) (int64, error) {
+	if r.failRollup {
+		return 0, errors.New("rollup failed")
+	}
+	r.rollupCalls = append(r.rollupCalls, [2]time.Time{from, to})
+	return rollup(r.responses, r.responseHours, from, to), nil
+}
+
+func (r *retentionRepo) RollupErrorLogs(ctx context.Context, from, to time.Time) (int64, error) {
+	return rollup(r.errs, r.errHours, from, to), nil
+}
+
+func (r *retentionRepo) PruneResponseTimes(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return prune(&r.responses, cutoff, limit), nil
+}
+
+func (r *retentionRepo) PruneErrorLogs(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return prune(&r.errs, cutoff, limit), nil
+}
+
+func (r *retentionRepo) PruneRollups(ctx context.Context, cutoff time.Time) (int64, error) {
+	var n int64
+	for _, hours := range []map[time.Time]int{r.responseHours, r.errHours} {
+		for h := range hours {
+			if h.Before(cutoff) {
+				delete(hours, h)
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (r *retentionRepo) TableStats(ctx context.Context) ([]repository.LogTableStats, error) {
+	return nil, nil
+}
+
+// memSettings is an in-memory settingsStore.
+type memSettings map[string]interface{}
+
+func (m memSettings) GetSetting(ctx context.Context, key string) (interface{}, error) {
+	return m[key], nil
+}
+
+func (m memSettings) UpdateSetting(ctx context.Context, key string, value interface{}, updatedBy uuid.UUID) error {
+	m[key] = value
+	return nil
+}
+
+func TestLogRetentionSettingsDefaults(t *testing.T) {
+	settings := memSettings{}
+	svc := NewLogRetentionService(newRetentionRepo(), settings, nil)
+	ctx := context.Background()
+
+	got, err := svc.Settings(ctx)
+	if err != nil || got != DefaultLogRetention {
+		t.Fatalf("missing setting = %+v, %v; want defaults", got, err)
+	}
+
+	// Written through the generic settings endpoint: one bad field falls
+	// back to its default, the rest are kept.
+	settings[LogRetentionSettingKey] = map[string]interface{}{
+		"response_time_days": 7.0, "error_log_days": 1.0, "rollup_days": 60.0,
+	}
+	got, _ = svc.Settings(ctx)
+	want := LogRetention{ResponseTimeDays: 7, ErrorLogDays: DefaultLogRetention.ErrorLogDays, RollupDays: 60}
+	if got != want {
+		t.Fatalf("Settings = %+v, want %+v", got, want)
+	}
+
+	err = svc.UpdateSettings(ctx, LogRetention{ResponseTimeDays: 0, ErrorLogDays: 30, RollupDays: 90}, uuid.Nil)
+	if !errors.Is(err, ErrLogRetentionInvalid) {
+		t.Fatalf("UpdateSettings(invalid) = %v, want ErrLogRetentionInvalid", err)
+	}
+	if err := svc.UpdateSettings(ctx, LogRetention{ResponseTimeDays: 2, ErrorLogDays: 30, RollupDays: 90}, uuid.Nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := svc.Settings(ctx); got.ErrorLogDays != 30 {
+		t.Fatalf("after update = %+v", got)
+	}
+}
+
+func TestLogRetentionRun(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 5, 0, 0, time.UTC)
+	repo := newRetentionRepo()
+	for _, age := range []time.Duration{10 * time.Minute, 90 * time.Minute, 30 * time.Hour, 5 * 24 * time.Hour} {
+		repo.responses = append(repo.responses, now.Add(-age))
+		repo.errs = append(repo.errs, now.Add(-age))
+	}
+	repo.errs = append(repo.errs, now.AddDate(0, 0, -100))
+	repo.responseHours[now.AddDate(-2, 0, 0).Truncate(time.Hour)] = 1
+
+	svc := NewLogRetentionService(repo, memSettings{}, nil)
+	svc.now = func() time.Time { return now }
+
+	run, err := svc.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Defaults: 3 days of raw response times, 90 of error logs, 395 of rollups.
+	if run.PrunedResponseTimes != 1 || run.PrunedErrorLogs != 1 || run.PrunedRollupRows != 1 {
+		t.Fatalf("pruned = %d/%d/%d, want 1/1/1", run.PrunedResponseTimes, run.PrunedErrorLogs, run.PrunedRollupRows)
+	}
+	if len(repo.responses) != 3 {
+		t.Fatalf("%d raw response rows left, want 3", len(repo.responses))
+	}
+	// The current hour isn't complete, so the 10-minute-old row isn't
+	// rolled up yet; everything before it is.
+	end := now.Truncate(time.Hour)
+	if got := hoursEnd(repo.responseHours); got == nil || !got.Equal(end) {
+		t.Fatalf("response rollup end = %v, want %v", got, end)
+	}
+	if n := repo.responseHours[end.Add(-time.Hour)]; n != 1 {
+		t.Fatalf("last complete hour rolled up %d row(s), want 1", n)
+	}
+
+	// A late row for the last rolled-up hour is picked up next run, which
+	// starts from the hour before the newest rollup.
+	repo.responses = append(repo.responses, end.Add(-time.Minute))
+	now = now.Add(time.Hour)
+	if _, err := svc.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if call := repo.rollupCalls[len(repo.rollupCalls)-1]; !call[0].Equal(end.Add(-time.Hour)) {
+		t.Fatalf("second rollup from %v, want %v", call[0], end.Add(-time.Hour))
+	}
+	if n := repo.responseHours[end.Add(-time.Hour)]; n != 2 {
+		t.Fatalf("late row not rolled up: hour has %d row(s), want 2", n)
+	}
+}
+
+func TestLogRetentionRunSkipsPruneWhenRollupFails(t *testing.T) {
+	now := time.Now()
+	repo := newRetentionRepo()
+	repo.responses = []time.Time{now.AddDate(0, 0, -10)}
+	repo.failRollup = true
+	svc := NewLogRetentionService(repo, memSettings{}, nil)
+
+	if _, err := svc.Run(context.Background()); err == nil {
+		t.Fatal("Run succeeded with a failing rollup")
+	}
+	if len(repo.responses) != 1 {
+		t.Fatal("raw rows pruned although their rollup failed")
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// ErrLogNotFound is returned for a revision history request or update
+// naming a log that doesn't exist.
+var ErrLogNotFound = apperr.NotFound("log not found")
+
+// logRevisionIgnored are the log fields an edit doesn't set: bookkeeping,
+// and values derived from the ones the caregiver entered.
+var logRevisionIgnored = map[string]bool{
+	"id":                    true,
+	"child_id":              true,
+	"logged_by":             true,
+	"created_at":            true,
+	"updated_at":            true,
+	"amended_at":            true,
+	"version":               true,
+	"provider":              true,
+	"rubric_version":        true,
+	"restriction_matches":   true,
+	"restriction_warnings":  true,
+	"food_provenance":       true,
+	"reconciliation_status": true,
+	"reconciliation_note":   true,
+}
+
+// SetRevisions keeps a revision history of log edits, and stamps edited
+// logs with amended_at.
+func (s *LogService) SetRevisions(revisions repository.LogRevisionRepository) {
+	s.revisions = revisions
+}
+
+// storedForRevision reads the log as it is before an edit, when revisions
+// are kept.
+func storedForRevision[T any](ctx context.Context, revisions repository.LogRevisionRepository, get func(context.Context, uuid.UUID) (*T, error), id uuid.UUID) (*T, error) {
+	if revisions == nil {
+		return nil, nil
+	}
+	return get(ctx, id)
+}
+
+// recordRevision stores what a saved edit changed, and the version it
+// produced, and returns when, or the log's previous amended_at if it
+// changed nothing. The edit is
+// already saved, so a failure to record it is only logged.
+func recordRevision[T any](ctx context.Context, revisions repository.LogRevisionRepository, logType string, childID, logID uuid.UUID, version int, editedBy uuid.UUID, before, after *T, prevAmended *time.Time) *time.Time {
+	if revisions == nil || before == nil {
+		return prevAmended
+	}
+	changes, err := logChanges(before, after)
+	if err != nil {
+		log.Printf("[LOGS] %s log %s: diff revision: %v", logType, logID, err)
+		return prevAmended
+	}
+	if len(changes) == 0 {
+		return prevAmended
+	}
+	rev := &models.LogRevision{LogType: logType, LogID: logID, ChildID: childID, Version: version, Changes: changes}
+	if editedBy != uuid.Nil {
+		rev.EditedBy = &editedBy
+	}
+	if err := revisions.Record(ctx, rev); err != nil {
+		log.Printf("[LOGS] %s log %s: record revision: %v", logType, logID, err)
+		return prevAmended
+	}
+	return &rev.EditedAt
+}
+
+// logChanges compares two versions of a log by their JSON fields. Times
+// and clock times compare by value, so "14:30" and "14:30:00" are the
+// same.
+func logChanges(before, after any) (models.LogFieldChanges, error) {
+	from, err := logFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := logFields(after)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(from)+len(to))
+	for k := range from {
+		names[k] = true
+	}
+	for k := range to {
+		names[k] = true
+	}
+	var changes models.LogFieldChanges
+	for name := range names {
+		if logRevisionIgnored[name] {
+			continue
+		}
+		a, b := logFieldValue(from[name]), logFieldValue(to[name])
+		if sameLogValue(a, b) {
+			continue
+		}
+		changes = append(changes, models.LogFieldChange{Field: name, From: a, To: b})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func logFields(v any) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	return fields, json.Unmarshal(raw, &fields)
+}
+
+// logFieldValue treats a missing field, null, "" and [] alike: the
+// models omit empty values inconsistently.
+func logFieldValue(v json.RawMessage) json.RawMessage {
+	switch string(v) {
+	case "", `""`, "[]":
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+func sameLogValue(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var sa, sb string
+	if json.Unmarshal(a, &sa) != nil || json.Unmarshal(b, &sb) != nil {
+		return false
+	}
+	if ta, err := time.Parse(time.RFC3339Nano, sa); err == nil {
+		tb, err := time.Parse(time.RFC3339Nano, sb)
+		return err == nil && ta.Equal(tb)
+	}
+	ca, okA := parseClockTime(sa)
+	cb, okB := parseClockTime(sb)
+	return okA && okB && ca == cb
+}
+
+func parseClockTime(s string) (time.Time, bool) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// History returns the log's revision history. ErrLogNotFound when there
+// is no such log (a deleted log's history isn't served).
+func (s *LogService) History(ctx context.Context, logType string, logID uuid.UUID) (*models.LogHistory, error) {
+	h, err := s.logHistoryHeader(ctx, logType, logID)
+	if err != nil {
+		return nil, err
+	}
+	if s.revisions != nil {
+		revs, err := s.revisions.ListForLog(ctx, logType, logID)
+		if err != nil {
+			return nil, err
+		}
+		h.Revisions = revs
+	}
+	if h.Revisions == nil {
+		h.Revisions = []models.LogRevision{}
+	}
+	return h, nil
+}
+
+// logHistoryHeader loads the log to say whose it is and when it was
+// first logged.
+func (s *LogService) logHistoryHeader(ctx context.Context, logType string, id uuid.UUID) (*models.LogHistory, error) {
+	var (
+		h   *models.LogHistory
+		err error
+	)
+	switch logType {
+	case "behavior":
+		var l *models.BehaviorLog
+		if l, err = s.logRepo.GetBehaviorLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "bowel":
+		var l *models.BowelLog
+		if l, err = s.logRepo.GetBowelLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "speech":
+		var l *models.SpeechLog
+		if l, err = s.logRepo.GetSpeechLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "diet":
+		var l *models.DietLog
+		if l, err = s.logRepo.GetDietLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "weight":
+		var l *models.WeightLog
+		if l, err = s.logRepo.GetWeightLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "sleep":
+		var l *models.SleepLog
+		if l, err = s.logRepo.GetSleepLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "sensory":
+		var l *models.SensoryLog
+		if l, err = s.logRepo.GetSensoryLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "social":
+		var l *models.SocialLog
+		if l, err = s.logRepo.GetSocialLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "therapy":
+		var l *models.TherapyLog
+		if l, err = s.logRepo.GetTherapyLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "seizure":
+		var l *models.SeizureLog
+		if l, err = s.logRepo.GetSeizureLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	case "health_event":
+		var l *models.HealthEventLog
+		if l, err = s.logRepo.GetHealthEventLogByID(ctx, id); l != nil {
+			h = &models.LogHistory{ChildID: l.ChildID, LoggedBy: l.LoggedBy, CreatedAt: l.CreatedAt, AmendedAt: l.AmendedAt}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, ErrLogNotFound
+	}
+	h.LogType, h.LogID = logType, id
+	return h, nil
+}
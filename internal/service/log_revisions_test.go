@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeLogRevisions struct {
+	revs []models.LogRevision
+}
+
+func (f *fakeLogRevisions) Record(ctx context.Context, rev *models.LogRevision) error {
+	rev.ID = uuid.New()
+	rev.EditedAt = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	f.revs = append(f.revs, *rev)
+	return nil
+}
+
+func (f *fakeLogRevisions) ListForLog(ctx context.Context, logType string, logID uuid.UUID) ([]models.LogRevision, error) {
+	return f.revs, nil
+}
+
+func TestLogChanges(t *testing.T) {
+	three, four := 3, 4
+	before := &models.BehaviorLog{
+		ID: uuid.New(), LogDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), MoodLevel: &three,
+		LogTime: nullString("14:30:00"), Notes: nullString("calm"), RubricVersion: 1,
+	}
+	after := *before
+	after.LogDate = time.Date(2026, 3, 1, 0, 0, 0, 0, time.FixedZone("", 0))
+	after.LogTime = nullString("14:30")
+	after.MoodLevel = &four
+	after.Notes = nullString("")
+	after.Triggers = models.StringArray{}
+	after.RubricVersion = 2
+	after.CreatedAt = time.Now()
+
+	changes, err := logChanges(before, &after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Field+":"+string(c.From)+">"+string(c.To))
+	}
+	if want := `mood_level:3>4,notes:"calm">null`; strings.Join(got, ",") != want {
+		t.Errorf("changes = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestRecordRevision(t *testing.T) {
+	ctx := context.Background()
+	revs := &fakeLogRevisions{}
+	s := &LogService{revisions: revs}
+	editor := uuid.New()
+	before := &models.BowelLog{ID: uuid.New(), ChildID: uuid.New(), Notes: nullString("ok")}
+	same := *before
+
+	if at := recordRevision(ctx, s.revisions, "bowel", before.ChildID, before.ID, 2, editor, before, &same, nil); at != nil || len(revs.revs) != 0 {
+		t.Errorf("unchanged log recorded: %v, %+v", at, revs.revs)
+	}
+	edited := *before
+	edited.Notes = nullString("hard stool")
+	at := recordRevision(ctx, s.revisions, "bowel", before.ChildID, before.ID, 2, editor, before, &edited, nil)
+	if at == nil || len(revs.revs) != 1 {
+		t.Fatalf("edit not recorded: %v, %+v", at, revs.revs)
+	}
+	rev := revs.revs[0]
+	if rev.LogType != "bowel" || rev.LogID != before.ID || rev.Version != 2 || rev.EditedBy == nil || *rev.EditedBy != editor ||
+		len(rev.Changes) != 1 || rev.Changes[0].Field != "notes" {
+		t.Errorf("revision = %+v", rev)
+	}
+
+	// System edits have no editor.
+	recordRevision(ctx, s.revisions, "bowel", before.ChildID, before.ID, 3, uuid.Nil, &edited, before, at)
+	if len(revs.revs) != 2 || revs.revs[1].EditedBy != nil {
+		t.Errorf("system edit = %+v", revs.revs)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"carecompanion/internal/apperr"
+)
+
+var (
+	ErrLogSearchDisabled = errors.New("log search is not configured (LOGS_INSIGHTS_LOG_GROUP unset)")
+	ErrLogSearchInvalid  = apperr.Validation("invalid log search")
+)
+
+const (
+	// logSearchMaxWindow bounds how much log a single search scans;
+	// Logs Insights bills per GB scanned.
+	logSearchMaxWindow = 7 * 24 * time.Hour
+	logSearchMaxLimit  = 500
+	// logSearchWait is how long a search waits for Logs Insights before
+	// stopping the query and returning what it has.
+	logSearchWait = 20 * time.Second
+)
+
+// The filters are spliced into a Logs Insights query string, so each is
+// held to the characters it can legitimately contain. Request IDs are
+// chi's "host/prefix-000001" form or a client-supplied X-Request-ID.
+var (
+	logSearchRequestIDRe = regexp.MustCompile(`^[A-Za-z0-9._:/\-]{1,128}$`)
+	logSearchUserHashRe  = regexp.MustCompile(`^[0-9a-f]{16}$`)
+	logSearchPathRe      = regexp.MustCompile(`^/[A-Za-z0-9._~/\-%]{0,511}$`)
+)
+
+// LogSearchParams are the filters for one search; at least one of
+// RequestID, UserHash and Path is required, and they combine with AND.
+type LogSearchParams struct {
+	RequestID string
+	// UserHash is middleware.LogUserHash of the user, as logged in uid=.
+	UserHash string
+	Path     string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// LogEntry is one matching log line.
+type LogEntry struct {
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+	Stream    string `json:"stream,omitempty"`
+}
+
+// LogSearchResult is a finished (or timed-out) search.
+type LogSearchResult struct {
+	LogGroup       string     `json:"log_group"`
+	Query          string     `json:"query"`
+	From           time.Time  `json:"from"`
+	To             time.Time  `json:"to"`
+	Status         string     `json:"status"`
+	Entries        []LogEntry `json:"entries"`
+	RecordsMatched float64    `json:"records_matched"`
+	RecordsScanned float64    `json:"records_scanned"`
+	BytesScanned   float64    `json:"bytes_scanned"`
+}
+
+// LogSearchService runs parameterized Logs Insights queries against the
+// application log group, so admins can pull the request log around an
+// error without shell access to the instances.
+type LogSearchService struct {
+	api          LogsInsightsAPI
+	logGroup     string
+	pollInterval time.Duration
+	wait         time.Duration
+}
+
+// NewLogSearchService creates the service. api may be nil when no log
+// group is configured; searches then return ErrLogSearchDisabled.
+func NewLogSearchService(api LogsInsightsAPI, logGroup string) *LogSearchService {
+	return &LogSearchService{api: api, logGroup: logGroup, pollInterval: 500 * time.Millisecond, wait: logSearchWait}
+}
+
+// Enabled reports whether a log group is configured.
+func (s *LogSearchService) Enabled() bool {
+	return s != nil && s.api != nil && s.logGroup != ""
+}
+
+// quoteLogsLiteral quotes v as a Logs Insights string literal.
+func quoteLogsLiteral(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
+
+// buildLogsQuery validates p and renders the query string.
+func buildLogsQuery(p LogSearchParams) (string, error) {
+	if p.RequestID == "" && p.UserHash == "" && p.Path == "" {
+		return "", fmt.Errorf("%w: give a request_id, user_hash or path", ErrLogSearchInvalid)
+	}
+	if !p.From.Before(p.To) {
+		return "", fmt.Errorf("%w: from must be before to", ErrLogSearchInvalid)
+	}
+	if p.To.Sub(p.From) > logSearchMaxWindow {
+		return "", fmt.Errorf("%w: time window is limited to %s", ErrLogSearchInvalid, logSearchMaxWindow)
+	}
+	q := []string{"fields @timestamp, @message, @logStream"}
+	if p.RequestID != "" {
+		if !logSearchRequestIDRe.MatchString(p.RequestID) {
+			return "", fmt.Errorf("%w: malformed request_id", ErrLogSearchInvalid)
+		}
+		q = append(q, "filter @message like "+quoteLogsLiteral("rid="+p.RequestID))
+	}
+	if p.UserHash != "" {
+		if !logSearchUserHashRe.MatchString(p.UserHash) {
+			return "", fmt.Errorf("%w: user_hash must be 16 hex characters", ErrLogSearchInvalid)
+		}
+		q = append(q, "filter @message like "+quoteLogsLiteral("uid="+p.UserHash))
+	}
+	if p.Path != "" {
+		if !logSearchPathRe.MatchString(p.Path) {
+			return "", fmt.Errorf("%w: malformed path", ErrLogSearchInvalid)
+		}
+		q = append(q, "filter @message like "+quoteLogsLiteral(" "+p.Path))
+	}
+	q = append(q, "sort @timestamp asc", "limit "+strconv.Itoa(p.Limit))
+	return strings.Join(q, "\n| "), nil
+}
+
+// Search runs one query and waits for it to finish. A query still running
+// after the wait is stopped and returned with the rows found so far and
+// its status as "Timeout".
+func (s *LogSearchService) Search(ctx context.Context, p LogSearchParams) (*LogSearchResult, error) {
+	if !s.Enabled() {
+		return nil, ErrLogSearchDisabled
+	}
+	if p.Limit <= 0 || p.Limit > logSearchMaxLimit {
+		p.Limit = 100
+	}
+	query, err := buildLogsQuery(p)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.api.StartQuery(ctx, s.logGroup, query, p.From, p.To, p.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("start query: %w", err)
+	}
+
+	deadline := time.Now().Add(s.wait)
+	var res *LogsQueryResult
+	for {
+		if res, err = s.api.GetQueryResults(ctx, id); err != nil {
+			return nil, fmt.Errorf("query results: %w", err)
+		}
+		if res.Status != "Scheduled" && res.Status != "Running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			// Stopping is best effort; Logs Insights times queries out on
+			// its own after 60 minutes.
+			_ = s.api.StopQuery(context.WithoutCancel(ctx), id)
+			res.Status = "Timeout"
+			break
+		}
+		select {
+		case <-ctx.Done():
+			_ = s.api.StopQuery(context.WithoutCancel(ctx), id)
+			return nil, ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+	}
+
+	out := &LogSearchResult{
+		LogGroup:       s.logGroup,
+		Query:          query,
+		From:           p.From,
+		To:             p.To,
+		Status:         res.Status,
+		Entries:        make([]LogEntry, 0, len(res.Rows)),
+		RecordsMatched: res.RecordsMatched,
+		RecordsScanned: res.RecordsScanned,
+		BytesScanned:   res.BytesScanned,
+	}
+	for _, row := range res.Rows {
+		out.Entries = append(out.Entries, LogEntry{
+			Timestamp: row["@timestamp"],
+			Message:   row["@message"],
+			Stream:    row["@logStream"],
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeLogsInsights finishes a query after a set number of polls.
+type fakeLogsInsights struct {
+	query   string
+	polls   int
+	doneAt  int
+	stopped bool
+}
+
+func (f *fakeLogsInsights) StartQuery(ctx context.Context, logGroup, query string, from, to time.Time, limit int) (string, error) {
+	f.query = query
+	return "q-1", nil
+}
+
+func (f *fakeLogsInsights) GetQueryResults(ctx context.Context, queryID string) (*LogsQueryResult, error) {
+	f.polls++
+	if f.polls < f.doneAt {
+		return &LogsQueryResult{Status: "Running"}, nil
+	}
+	return &LogsQueryResult{
+		Status:         "Complete",
+		Rows:           []map[string]string{{"@timestamp": "2026-06-01 12:00:00.000", "@message": "GET /api/x 500 rid=a/b-000001"}},
+		RecordsMatched: 1,
+	}, nil
+}
+
+func (f *fakeLogsInsights) StopQuery(ctx context.Context, queryID string) error {
+	f.stopped = true
+	return nil
+}
+
+func TestBuildLogsQuery(t *testing.T) {
+	to := time.Now()
+	from := to.Add(-time.Hour)
+
+	q, err := buildLogsQuery(LogSearchParams{RequestID: "host/abc-000042", UserHash: "0123456789abcdef", Path: "/api/children", From: from, To: to, Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"rid=host/abc-000042"`, `"uid=0123456789abcdef"`, `" /api/children"`, "limit 50"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query missing %s:\n%s", want, q)
+		}
+	}
+
+	bad := []LogSearchParams{
+		{From: from, To: to},
+		{RequestID: `x" | display @message`, From: from, To: to},
+		{UserHash: "not-a-hash", From: from, To: to},
+		{Path: "api/no-slash", From: from, To: to},
+		{RequestID: "r", From: to, To: from},
+		{RequestID: "r", From: to.Add(-8 * 24 * time.Hour), To: to},
+	}
+	for _, p := range bad {
+		if _, err := buildLogsQuery(p); !errors.Is(err, ErrLogSearchInvalid) {
+			t.Errorf("%+v: err = %v, want ErrLogSearchInvalid", p, err)
+		}
+	}
+}
+
+func TestLogSearch(t *testing.T) {
+	api := &fakeLogsInsights{doneAt: 3}
+	svc := NewLogSearchService(api, "/carecompanion/app")
+	svc.pollInterval = time.Millisecond
+	res, err := svc.Search(context.Background(), LogSearchParams{RequestID: "a/b-000001", From: time.Now().Add(-time.Hour), To: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "Complete" || len(res.Entries) != 1 || api.polls != 3 || api.stopped {
+		t.Errorf("result = %+v after %d polls (stopped %v)", res, api.polls, api.stopped)
+	}
+}
+
+func TestLogSearchTimeout(t *testing.T) {
+	api := &fakeLogsInsights{doneAt: 1 << 30}
+	svc := NewLogSearchService(api, "/carecompanion/app")
+	svc.pollInterval, svc.wait = time.Millisecond, 5*time.Millisecond
+	res, err := svc.Search(context.Background(), LogSearchParams{Path: "/api/x", From: time.Now().Add(-time.Hour), To: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "Timeout" || !api.stopped {
+		t.Errorf("status = %s, stopped = %v; want Timeout and a StopQuery", res.Status, api.stopped)
+	}
+}
+
+func TestLogSearchDisabled(t *testing.T) {
+	svc := NewLogSearchService(nil, "")
+	if _, err := svc.Search(context.Background(), LogSearchParams{Path: "/x"}); err != ErrLogSearchDisabled {
+		t.Errorf("err = %v, want ErrLogSearchDisabled", err)
+	}
+}
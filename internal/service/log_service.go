@@ -10,8 +10,39 @@ import (
 	"carecompanion/internal/repository"
 )
 
+// logAttributor tells which logs a school or ABA provider entered.
+type logAttributor interface {
+	Attributions(ctx context.Context, logType string, logIDs []uuid.UUID) (map[uuid.UUID]*models.ProviderAttribution, error)
+}
+
+// dietScreener checks diet logs against the child's allergy and
+// restriction registry.
+type dietScreener interface {
+	Screen(ctx context.Context, l *models.DietLog) error
+	ReportIncident(ctx context.Context, l *models.DietLog)
+}
+
+// dietPhotoProvenance records which diet log foods were confirmed from a
+// meal photo's suggestions.
+type dietPhotoProvenance interface {
+	Provenance(ctx context.Context, l *models.DietLog) error
+	LinkDietLog(ctx context.Context, l *models.DietLog)
+}
+
+// rubricVersioner gives the scoring rubric version behavior log levels
+// are entered under.
+type rubricVersioner interface {
+	CurrentVersionForChild(ctx context.Context, childID uuid.UUID) (int, error)
+}
+
 type LogService struct {
-	logRepo repository.LogRepository
+	logRepo      repository.LogRepository
+	goals        *TherapyGoalService
+	providers    logAttributor
+	restrictions dietScreener
+	rubrics      rubricVersioner
+	foodPhotos   dietPhotoProvenance
+	revisions    repository.LogRevisionRepository
 }
 
 func NewLogService(logRepo repository.LogRepository) *LogService {
@@ -20,6 +51,71 @@ func NewLogService(logRepo repository.LogRepository) *LogService {
 	}
 }
 
+// SetTherapyGoals links therapy logs to the child's therapy goals.
+func (s *LogService) SetTherapyGoals(goals *TherapyGoalService) {
+	s.goals = goals
+}
+
+// SetRestrictionScreening flags diet logs whose foods match the child's
+// allergy and restriction registry, and alerts on allergy incidents.
+func (s *LogService) SetRestrictionScreening(restrictions dietScreener) {
+	s.restrictions = restrictions
+}
+
+// SetRubrics stamps behavior logs with the family's scoring rubric
+// version, so reports can label their levels the way they were meant.
+func (s *LogService) SetRubrics(rubrics rubricVersioner) {
+	s.rubrics = rubrics
+}
+
+// SetFoodPhotos records on diet logs saved from a meal photo which foods
+// came from its suggestions.
+func (s *LogService) SetFoodPhotos(foodPhotos dietPhotoProvenance) {
+	s.foodPhotos = foodPhotos
+}
+
+// SetProviderAttribution marks behavior and therapy logs entered by a
+// provider with who entered them.
+func (s *LogService) SetProviderAttribution(providers logAttributor) {
+	s.providers = providers
+}
+
+func (s *LogService) attachBehaviorProviders(ctx context.Context, logs []models.BehaviorLog) error {
+	if s.providers == nil || len(logs) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(logs))
+	for i, l := range logs {
+		ids[i] = l.ID
+	}
+	byLog, err := s.providers.Attributions(ctx, "behavior", ids)
+	if err != nil {
+		return err
+	}
+	for i := range logs {
+		logs[i].Provider = byLog[logs[i].ID]
+	}
+	return nil
+}
+
+func (s *LogService) attachTherapyProviders(ctx context.Context, logs []models.TherapyLog) error {
+	if s.providers == nil || len(logs) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(logs))
+	for i, l := range logs {
+		ids[i] = l.ID
+	}
+	byLog, err := s.providers.Attributions(ctx, "therapy", ids)
+	if err != nil {
+		return err
+	}
+	for i := range logs {
+		logs[i].Provider = byLog[logs[i].ID]
+	}
+	return nil
+}
+
 // Behavior Logs
 func (s *LogService) CreateBehaviorLog(ctx context.Context, childID, loggedBy uuid.UUID, req *models.CreateBehaviorLogRequest) (*models.BehaviorLog, error) {
 	logDate := req.LogDate.Time
@@ -48,6 +144,13 @@ func (s *LogService) CreateBehaviorLog(ctx context.Context, childID, loggedBy uu
 	log.LocationOther.Valid = req.LocationOther != ""
 	log.Notes.String = req.Notes
 	log.Notes.Valid = req.Notes != ""
+	if s.rubrics != nil {
+		v, err := s.rubrics.CurrentVersionForChild(ctx, childID)
+		if err != nil {
+			return nil, err
+		}
+		log.RubricVersion = v
+	}
 
 	if err := s.logRepo.CreateBehaviorLog(ctx, log); err != nil {
 		return nil, err
@@ -56,15 +159,58 @@ func (s *LogService) CreateBehaviorLog(ctx context.Context, childID, loggedBy uu
 }
 
 func (s *LogService) GetBehaviorLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.BehaviorLog, error) {
-	return s.logRepo.GetBehaviorLogs(ctx, childID, startDate, endDate)
+	logs, err := s.logRepo.GetBehaviorLogs(ctx, childID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return logs, s.attachBehaviorProviders(ctx, logs)
 }
 
 func (s *LogService) GetBehaviorLogByID(ctx context.Context, id uuid.UUID) (*models.BehaviorLog, error) {
-	return s.logRepo.GetBehaviorLogByID(ctx, id)
+	log, err := s.logRepo.GetBehaviorLogByID(ctx, id)
+	if err != nil || log == nil {
+		return log, err
+	}
+	logs := []models.BehaviorLog{*log}
+	if err := s.attachBehaviorProviders(ctx, logs); err != nil {
+		return nil, err
+	}
+	return &logs[0], nil
+}
+
+// UpdateBehaviorLog saves an edited behavior log. When the levels changed
+// they were re-entered under the current rubric, so the log takes its
+// version; otherwise it keeps the one it was first entered under.
+func (s *LogService) UpdateBehaviorLog(ctx context.Context, log *models.BehaviorLog, editedBy uuid.UUID) error {
+	var stored *models.BehaviorLog
+	if s.rubrics != nil || s.revisions != nil {
+		var err error
+		if stored, err = s.logRepo.GetBehaviorLogByID(ctx, log.ID); err != nil {
+			return err
+		}
+	}
+	if s.rubrics != nil {
+		if stored == nil || !sameLevel(stored.MoodLevel, log.MoodLevel) ||
+			!sameLevel(stored.EnergyLevel, log.EnergyLevel) || !sameLevel(stored.AnxietyLevel, log.AnxietyLevel) {
+			v, err := s.rubrics.CurrentVersionForChild(ctx, log.ChildID)
+			if err != nil {
+				return err
+			}
+			log.RubricVersion = v
+		}
+	}
+	if err := s.logRepo.UpdateBehaviorLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "behavior", log, log.ID, log.Version, s.logRepo.GetBehaviorLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "behavior", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
-func (s *LogService) UpdateBehaviorLog(ctx context.Context, log *models.BehaviorLog) error {
-	return s.logRepo.UpdateBehaviorLog(ctx, log)
+func sameLevel(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 func (s *LogService) DeleteBehaviorLog(ctx context.Context, id uuid.UUID) error {
@@ -107,8 +253,16 @@ func (s *LogService) GetBowelLogByID(ctx context.Context, id uuid.UUID) (*models
 	return s.logRepo.GetBowelLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateBowelLog(ctx context.Context, log *models.BowelLog) error {
-	return s.logRepo.UpdateBowelLog(ctx, log)
+func (s *LogService) UpdateBowelLog(ctx context.Context, log *models.BowelLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetBowelLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateBowelLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "bowel", log, log.ID, log.Version, s.logRepo.GetBowelLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "bowel", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteBowelLog(ctx context.Context, id uuid.UUID) error {
@@ -152,8 +306,16 @@ func (s *LogService) GetSpeechLogByID(ctx context.Context, id uuid.UUID) (*model
 	return s.logRepo.GetSpeechLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateSpeechLog(ctx context.Context, log *models.SpeechLog) error {
-	return s.logRepo.UpdateSpeechLog(ctx, log)
+func (s *LogService) UpdateSpeechLog(ctx context.Context, log *models.SpeechLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetSpeechLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateSpeechLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "speech", log, log.ID, log.Version, s.logRepo.GetSpeechLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "speech", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteSpeechLog(ctx context.Context, id uuid.UUID) error {
@@ -193,9 +355,26 @@ func (s *LogService) CreateDietLog(ctx context.Context, childID, loggedBy uuid.U
 	log.Notes.String = req.Notes
 	log.Notes.Valid = req.Notes != ""
 
+	if s.foodPhotos != nil && req.PhotoRecognitionID != nil {
+		log.PhotoRecognitionID = req.PhotoRecognitionID
+		if err := s.foodPhotos.Provenance(ctx, log); err != nil {
+			return nil, err
+		}
+	}
+	if s.restrictions != nil {
+		if err := s.restrictions.Screen(ctx, log); err != nil {
+			return nil, err
+		}
+	}
 	if err := s.logRepo.CreateDietLog(ctx, log); err != nil {
 		return nil, err
 	}
+	if s.foodPhotos != nil {
+		s.foodPhotos.LinkDietLog(ctx, log)
+	}
+	if s.restrictions != nil {
+		s.restrictions.ReportIncident(ctx, log)
+	}
 	return log, nil
 }
 
@@ -207,8 +386,29 @@ func (s *LogService) GetDietLogByID(ctx context.Context, id uuid.UUID) (*models.
 	return s.logRepo.GetDietLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateDietLog(ctx context.Context, log *models.DietLog) error {
-	return s.logRepo.UpdateDietLog(ctx, log)
+// UpdateDietLog saves the log, screening its foods again and working out
+// their photo provenance afresh. Incidents are only alerted on when a log
+// is created.
+func (s *LogService) UpdateDietLog(ctx context.Context, log *models.DietLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetDietLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if s.foodPhotos != nil {
+		if err := s.foodPhotos.Provenance(ctx, log); err != nil {
+			return err
+		}
+	}
+	if s.restrictions != nil {
+		if err := s.restrictions.Screen(ctx, log); err != nil {
+			return err
+		}
+	}
+	if err := s.logRepo.UpdateDietLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "diet", log, log.ID, log.Version, s.logRepo.GetDietLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "diet", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteDietLog(ctx context.Context, id uuid.UUID) error {
@@ -247,8 +447,16 @@ func (s *LogService) GetWeightLogByID(ctx context.Context, id uuid.UUID) (*model
 	return s.logRepo.GetWeightLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateWeightLog(ctx context.Context, log *models.WeightLog) error {
-	return s.logRepo.UpdateWeightLog(ctx, log)
+func (s *LogService) UpdateWeightLog(ctx context.Context, log *models.WeightLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetWeightLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateWeightLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "weight", log, log.ID, log.Version, s.logRepo.GetWeightLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "weight", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteWeightLog(ctx context.Context, id uuid.UUID) error {
@@ -283,6 +491,7 @@ func (s *LogService) CreateSleepLog(ctx context.Context, childID, loggedBy uuid.
 	log.SleepAidName.Valid = req.SleepAidName != ""
 	log.Notes.String = req.Notes
 	log.Notes.Valid = req.Notes != ""
+	fillSleepMinutes(log)
 
 	if err := s.logRepo.CreateSleepLog(ctx, log); err != nil {
 		return nil, err
@@ -298,8 +507,17 @@ func (s *LogService) GetSleepLogByID(ctx context.Context, id uuid.UUID) (*models
 	return s.logRepo.GetSleepLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateSleepLog(ctx context.Context, log *models.SleepLog) error {
-	return s.logRepo.UpdateSleepLog(ctx, log)
+func (s *LogService) UpdateSleepLog(ctx context.Context, log *models.SleepLog, editedBy uuid.UUID) error {
+	fillSleepMinutes(log)
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetSleepLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateSleepLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "sleep", log, log.ID, log.Version, s.logRepo.GetSleepLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "sleep", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteSleepLog(ctx context.Context, id uuid.UUID) error {
@@ -311,6 +529,12 @@ func (s *LogService) GetDailyLogs(ctx context.Context, childID uuid.UUID, date t
 	return s.logRepo.GetDailyLogs(ctx, childID, date)
 }
 
+// DailyLogsChangeStamp says when the logs GetDailyLogs returns for the
+// dates last changed.
+func (s *LogService) DailyLogsChangeStamp(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) (models.ChangeStamp, error) {
+	return s.logRepo.DailyLogsChangeStamp(ctx, childID, startDate, endDate)
+}
+
 func (s *LogService) GetTodaysLogs(ctx context.Context, childID uuid.UUID) (*models.DailyLogPage, error) {
 	return s.logRepo.GetDailyLogs(ctx, childID, time.Now())
 }
@@ -404,8 +628,16 @@ func (s *LogService) GetSensoryLogByID(ctx context.Context, id uuid.UUID) (*mode
 	return s.logRepo.GetSensoryLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateSensoryLog(ctx context.Context, log *models.SensoryLog) error {
-	return s.logRepo.UpdateSensoryLog(ctx, log)
+func (s *LogService) UpdateSensoryLog(ctx context.Context, log *models.SensoryLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetSensoryLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateSensoryLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "sensory", log, log.ID, log.Version, s.logRepo.GetSensoryLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "sensory", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteSensoryLog(ctx context.Context, id uuid.UUID) error {
@@ -458,8 +690,16 @@ func (s *LogService) GetSocialLogByID(ctx context.Context, id uuid.UUID) (*model
 	return s.logRepo.GetSocialLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateSocialLog(ctx context.Context, log *models.SocialLog) error {
-	return s.logRepo.UpdateSocialLog(ctx, log)
+func (s *LogService) UpdateSocialLog(ctx context.Context, log *models.SocialLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetSocialLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateSocialLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "social", log, log.ID, log.Version, s.logRepo.GetSocialLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "social", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteSocialLog(ctx context.Context, id uuid.UUID) error {
@@ -491,23 +731,100 @@ func (s *LogService) CreateTherapyLog(ctx context.Context, childID, loggedBy uui
 	log.HomeworkAssigned.Valid = req.HomeworkAssigned != ""
 	log.ParentNotes.String = req.ParentNotes
 	log.ParentNotes.Valid = req.ParentNotes != ""
+	log.Goals = req.Goals
 
+	if err := s.validateTherapyGoals(ctx, log); err != nil {
+		return nil, err
+	}
 	if err := s.logRepo.CreateTherapyLog(ctx, log); err != nil {
 		return nil, err
 	}
+	if err := s.saveTherapyGoals(ctx, log); err != nil {
+		return nil, err
+	}
 	return log, nil
 }
 
 func (s *LogService) GetTherapyLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.TherapyLog, error) {
-	return s.logRepo.GetTherapyLogs(ctx, childID, startDate, endDate)
+	logs, err := s.logRepo.GetTherapyLogs(ctx, childID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if s.goals != nil {
+		if err := s.goals.AttachLogGoals(ctx, logs); err != nil {
+			return nil, err
+		}
+	}
+	return logs, s.attachTherapyProviders(ctx, logs)
 }
 
 func (s *LogService) GetTherapyLogByID(ctx context.Context, id uuid.UUID) (*models.TherapyLog, error) {
-	return s.logRepo.GetTherapyLogByID(ctx, id)
+	log, err := s.logRepo.GetTherapyLogByID(ctx, id)
+	if err != nil || log == nil {
+		return log, err
+	}
+	logs := []models.TherapyLog{*log}
+	if s.goals != nil {
+		if err := s.goals.AttachLogGoals(ctx, logs); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.attachTherapyProviders(ctx, logs); err != nil {
+		return nil, err
+	}
+	return &logs[0], nil
 }
 
-func (s *LogService) UpdateTherapyLog(ctx context.Context, log *models.TherapyLog) error {
-	return s.logRepo.UpdateTherapyLog(ctx, log)
+// UpdateTherapyLog saves log; a non-nil Goals replaces the goals it
+// worked on.
+func (s *LogService) UpdateTherapyLog(ctx context.Context, log *models.TherapyLog, editedBy uuid.UUID) error {
+	if err := s.validateTherapyGoals(ctx, log); err != nil {
+		return err
+	}
+	stored, err := storedForRevision(ctx, s.revisions, s.GetTherapyLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateTherapyLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "therapy", log, log.ID, log.Version, s.GetTherapyLogByID, err)
+	}
+	if err := s.saveTherapyGoals(ctx, log); err != nil {
+		return err
+	}
+	// Goals left nil were kept as they were.
+	after := *log
+	if after.Goals == nil && stored != nil {
+		after.Goals = stored.Goals
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "therapy", log.ChildID, log.ID, log.Version, editedBy, stored, &after, log.AmendedAt)
+	return nil
+}
+
+func (s *LogService) validateTherapyGoals(ctx context.Context, log *models.TherapyLog) error {
+	if log.Goals == nil {
+		return nil
+	}
+	if s.goals == nil {
+		return ErrTherapyGoalInvalid
+	}
+	return s.goals.ValidateLogGoals(ctx, log.ChildID, log.Goals)
+}
+
+// saveTherapyGoals links log to its Goals and reads them back with their
+// goal text.
+func (s *LogService) saveTherapyGoals(ctx context.Context, log *models.TherapyLog) error {
+	if log.Goals == nil || s.goals == nil {
+		return nil
+	}
+	if err := s.goals.SetLogGoals(ctx, log.ID, log.Goals); err != nil {
+		return err
+	}
+	logs := []models.TherapyLog{*log}
+	if err := s.goals.AttachLogGoals(ctx, logs); err != nil {
+		return err
+	}
+	log.Goals = logs[0].Goals
+	return nil
 }
 
 func (s *LogService) DeleteTherapyLog(ctx context.Context, id uuid.UUID) error {
@@ -555,8 +872,16 @@ func (s *LogService) GetSeizureLogByID(ctx context.Context, id uuid.UUID) (*mode
 	return s.logRepo.GetSeizureLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateSeizureLog(ctx context.Context, log *models.SeizureLog) error {
-	return s.logRepo.UpdateSeizureLog(ctx, log)
+func (s *LogService) UpdateSeizureLog(ctx context.Context, log *models.SeizureLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetSeizureLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateSeizureLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "seizure", log, log.ID, log.Version, s.logRepo.GetSeizureLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "seizure", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteSeizureLog(ctx context.Context, id uuid.UUID) error {
@@ -609,14 +934,70 @@ func (s *LogService) GetHealthEventLogByID(ctx context.Context, id uuid.UUID) (*
 	return s.logRepo.GetHealthEventLogByID(ctx, id)
 }
 
-func (s *LogService) UpdateHealthEventLog(ctx context.Context, log *models.HealthEventLog) error {
-	return s.logRepo.UpdateHealthEventLog(ctx, log)
+func (s *LogService) UpdateHealthEventLog(ctx context.Context, log *models.HealthEventLog, editedBy uuid.UUID) error {
+	stored, err := storedForRevision(ctx, s.revisions, s.logRepo.GetHealthEventLogByID, log.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.logRepo.UpdateHealthEventLog(ctx, log); err != nil {
+		return versionConflict(ctx, s.revisions, "health_event", log, log.ID, log.Version, s.logRepo.GetHealthEventLogByID, err)
+	}
+	log.AmendedAt = recordRevision(ctx, s.revisions, "health_event", log.ChildID, log.ID, log.Version, editedBy, stored, log, log.AmendedAt)
+	return nil
 }
 
 func (s *LogService) DeleteHealthEventLog(ctx context.Context, id uuid.UUID) error {
 	return s.logRepo.DeleteHealthEventLog(ctx, id)
 }
 
+// logProviderField is the provider attribution on behavior and therapy
+// logs. It isn't a column, so ListLogFields attaches it after the query
+// instead of selecting it.
+const logProviderField = "provider"
+
+// ListLogFields is the sparse-fieldset variant of the Get*Logs lists.
+// Behavior and therapy logs carry their provider attribution like the full
+// lists do when fields is empty or asks for "provider".
+func (s *LogService) ListLogFields(ctx context.Context, logType string, childID uuid.UUID, startDate, endDate time.Time, fields []string) (any, error) {
+	withProvider := len(fields) == 0
+	if logType == "behavior" || logType == "therapy" {
+		fields, withProvider = splitProviderField(fields)
+	}
+	logs, err := s.logRepo.ListLogFields(ctx, logType, childID, startDate, endDate, fields)
+	if err != nil || !withProvider {
+		return logs, err
+	}
+	switch l := logs.(type) {
+	case []models.BehaviorLog:
+		return l, s.attachBehaviorProviders(ctx, l)
+	case []models.TherapyLog:
+		return l, s.attachTherapyProviders(ctx, l)
+	}
+	return logs, nil
+}
+
+// splitProviderField drops logProviderField from fields and reports whether
+// the provider should be attached. Asking for the provider alone still
+// selects "id", which the attribution is looked up by.
+func splitProviderField(fields []string) ([]string, bool) {
+	if len(fields) == 0 {
+		return fields, true
+	}
+	cols := make([]string, 0, len(fields))
+	withProvider := false
+	for _, f := range fields {
+		if f == logProviderField {
+			withProvider = true
+			continue
+		}
+		cols = append(cols, f)
+	}
+	if len(cols) == 0 {
+		cols = append(cols, "id")
+	}
+	return cols, withProvider
+}
+
 // GetDatesWithLogs returns dates that have log entries for a child
 func (s *LogService) GetDatesWithLogs(ctx context.Context, childID uuid.UUID, limit int) ([]models.DateWithEntryCount, error) {
 	return s.logRepo.GetDatesWithLogs(ctx, childID, limit)
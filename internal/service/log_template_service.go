@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	// ErrLogTemplateInvalid is returned for a template that can't be
+	// saved or used; the message says why.
+	ErrLogTemplateInvalid  = apperr.Validation("invalid log template")
+	ErrLogTemplateNotFound = apperr.NotFound("log template not found")
+)
+
+const (
+	maxLogTemplates       = 50
+	maxLogTemplateNameLen = 100
+	maxLogTemplateBytes   = 16 * 1024
+)
+
+// logTemplateTypes are the log types with a create endpoint templates can
+// be logged through. Medication logs are made from a medication's
+// schedule instead.
+var logTemplateTypes = []string{
+	"behavior", "diet", "sleep", "bowel", "speech", "weight",
+	"sensory", "social", "therapy", "seizure", "health_event",
+}
+
+// logEntryOnlyFields are log fields a new entry doesn't copy from an
+// earlier one or a template: its date, and what is derived when it is
+// saved.
+var logEntryOnlyFields = []string{"log_date", "photo_recognition_id", "source"}
+
+// logEntryFormData reduces a log, or a template's fields, to what a
+// caregiver fills in for a new entry of the same kind.
+func logEntryFormData(fields map[string]json.RawMessage) ([]byte, error) {
+	for name := range fields {
+		if logRevisionIgnored[name] || slices.Contains(logEntryOnlyFields, name) || string(fields[name]) == "null" {
+			delete(fields, name)
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// jsonObject parses data as a JSON object.
+func jsonObject(data []byte) (map[string]json.RawMessage, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return nil, false
+	}
+	return fields, true
+}
+
+// LogTemplateService manages families' log entry templates ("school day
+// breakfast") and fills in entries from them.
+type LogTemplateService struct {
+	repo repository.LogTemplateRepository
+}
+
+func NewLogTemplateService(repo repository.LogTemplateRepository) *LogTemplateService {
+	return &LogTemplateService{repo: repo}
+}
+
+func invalidLogTemplate(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrLogTemplateInvalid, fmt.Sprintf(format, args...))
+}
+
+// List returns the family's templates, only of logType when it isn't
+// empty.
+func (s *LogTemplateService) List(ctx context.Context, familyID uuid.UUID, logType string) ([]models.LogTemplate, error) {
+	if logType != "" && !slices.Contains(logTemplateTypes, logType) {
+		return nil, invalidLogTemplate("unknown log type %q", logType)
+	}
+	templates, err := s.repo.ListForFamily(ctx, familyID, logType)
+	if err != nil {
+		return nil, err
+	}
+	if templates == nil {
+		templates = []models.LogTemplate{}
+	}
+	return templates, nil
+}
+
+// Get returns the family's template. ErrLogTemplateNotFound when it
+// doesn't exist or belongs to another family.
+func (s *LogTemplateService) Get(ctx context.Context, familyID, id uuid.UUID) (*models.LogTemplate, error) {
+	t, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.FamilyID != familyID {
+		return nil, ErrLogTemplateNotFound
+	}
+	return t, nil
+}
+
+func (s *LogTemplateService) Create(ctx context.Context, familyID, userID uuid.UUID, req *models.LogTemplateRequest) (*models.LogTemplate, error) {
+	t := &models.LogTemplate{FamilyID: familyID, CreatedBy: &userID}
+	if err := applyLogTemplateRequest(t, req); err != nil {
+		return nil, err
+	}
+	n, err := s.repo.CountForFamily(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxLogTemplates {
+		return nil, invalidLogTemplate("a family can have at most %d templates", maxLogTemplates)
+	}
+	if err := s.repo.Create(ctx, t); err != nil {
+		if errors.Is(err, repository.ErrLogTemplateNameTaken) {
+			return nil, invalidLogTemplate("there is already a %s template called %q", t.LogType, t.Name)
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *LogTemplateService) Update(ctx context.Context, familyID, id uuid.UUID, req *models.LogTemplateRequest) (*models.LogTemplate, error) {
+	t, err := s.Get(ctx, familyID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyLogTemplateRequest(t, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, t); err != nil {
+		if errors.Is(err, repository.ErrLogTemplateNameTaken) {
+			return nil, invalidLogTemplate("there is already a %s template called %q", t.LogType, t.Name)
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *LogTemplateService) Delete(ctx context.Context, familyID, id uuid.UUID) error {
+	if _, err := s.Get(ctx, familyID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func applyLogTemplateRequest(t *models.LogTemplate, req *models.LogTemplateRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxLogTemplateNameLen {
+		return invalidLogTemplate("name must be 1-%d characters", maxLogTemplateNameLen)
+	}
+	if !slices.Contains(logTemplateTypes, req.LogType) {
+		return invalidLogTemplate("unknown log type %q", req.LogType)
+	}
+	fields, ok := jsonObject(req.Data)
+	if !ok {
+		return invalidLogTemplate("data must be a JSON object")
+	}
+	data, err := logEntryFormData(fields)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxLogTemplateBytes {
+		return invalidLogTemplate("data can be at most %d KB", maxLogTemplateBytes/1024)
+	}
+	t.Name, t.LogType, t.Data = name, req.LogType, data
+	return nil
+}
+
+// EntryData is the create request for a log from the template: its data
+// with overrides, a JSON object such as {"log_date": ..., "notes": ...},
+// laid over it.
+func (s *LogTemplateService) EntryData(t *models.LogTemplate, overrides []byte) ([]byte, error) {
+	fields, ok := jsonObject(t.Data)
+	if !ok {
+		return nil, invalidLogTemplate("template data is not a JSON object")
+	}
+	if len(bytes.TrimSpace(overrides)) > 0 {
+		extra, ok := jsonObject(overrides)
+		if !ok {
+			return nil, invalidLogTemplate("overrides must be a JSON object")
+		}
+		for k, v := range extra {
+			fields[k] = v
+		}
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+func TestLogTemplateRequest(t *testing.T) {
+	var tmpl models.LogTemplate
+	err := applyLogTemplateRequest(&tmpl, &models.LogTemplateRequest{
+		LogType: "diet",
+		Name:    "  School day breakfast ",
+		Data:    json.RawMessage(`{"meal_type": "breakfast", "foods_eaten": ["toast"], "log_date": "2026-05-01", "id": "x", "notes": null}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Name != "School day breakfast" || string(tmpl.Data) != `{"foods_eaten":["toast"],"meal_type":"breakfast"}` {
+		t.Errorf("template = %q %s", tmpl.Name, tmpl.Data)
+	}
+
+	for name, req := range map[string]models.LogTemplateRequest{
+		"no name":    {LogType: "diet", Name: " ", Data: json.RawMessage(`{}`)},
+		"medication": {LogType: "medication", Name: "AM", Data: json.RawMessage(`{}`)},
+		"array":      {LogType: "diet", Name: "AM", Data: json.RawMessage(`[]`)},
+	} {
+		if err := applyLogTemplateRequest(&tmpl, &req); !errors.Is(err, ErrLogTemplateInvalid) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestLogTemplateEntryData(t *testing.T) {
+	s := &LogTemplateService{}
+	tmpl := &models.LogTemplate{Data: json.RawMessage(`{"meal_type":"breakfast","notes":"usual"}`)}
+	data, err := s.EntryData(tmpl, []byte(`{"notes": "ate half", "log_date": "2026-05-02"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"log_date":"2026-05-02","meal_type":"breakfast","notes":"ate half"}` {
+		t.Errorf("data = %s", data)
+	}
+	if data, err := s.EntryData(tmpl, nil); err != nil || string(data) != `{"meal_type":"breakfast","notes":"usual"}` {
+		t.Errorf("no overrides = %s, %v", data, err)
+	}
+	if _, err := s.EntryData(tmpl, []byte(`"notes"`)); !errors.Is(err, ErrLogTemplateInvalid) {
+		t.Errorf("bad overrides = %v", err)
+	}
+}
+
+type fakeDraftLogSource struct {
+	logs any
+}
+
+func (f fakeDraftLogSource) ListLogFields(ctx context.Context, logType string, childID uuid.UUID, startDate, endDate time.Time, fields []string) (any, error) {
+	return f.logs, nil
+}
+
+func TestLogDraftFromPrevious(t *testing.T) {
+	ctx := context.Background()
+	five := 5
+	yesterday := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	logs := []models.BehaviorLog{
+		{ID: uuid.New(), LogDate: yesterday, MoodLevel: &five, Notes: nullString("good day"), Version: 3, RubricVersion: 2},
+		{ID: uuid.New(), LogDate: yesterday, Notes: nullString("earlier")},
+	}
+	repo := &fakeLogDrafts{}
+	s := NewLogDraftService(repo, fakeDraftLogSource{logs: logs}, time.Hour, 4096)
+
+	d, err := s.FromPrevious(ctx, uuid.New(), uuid.New(), "behavior", yesterday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(d.Data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if string(fields["mood_level"]) != "5" || string(fields["notes"]) != `"good day"` {
+		t.Errorf("draft data = %s", d.Data)
+	}
+	for _, name := range []string{"id", "log_date", "version", "rubric_version", "created_at", "logged_by"} {
+		if _, ok := fields[name]; ok {
+			t.Errorf("draft copied %s: %s", name, d.Data)
+		}
+	}
+
+	s.logs = fakeDraftLogSource{logs: []models.BehaviorLog{}}
+	if _, err := s.FromPrevious(ctx, uuid.New(), uuid.New(), "behavior", yesterday); !errors.Is(err, ErrNoPreviousLog) {
+		t.Errorf("empty day = %v", err)
+	}
+	if _, err := s.FromPrevious(ctx, uuid.New(), uuid.New(), "medication", yesterday); !errors.Is(err, ErrLogDraftInvalid) {
+		t.Errorf("medication = %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+// Platforms entries are counted under. Requests without an app platform
+// come from the web app.
+const (
+	LogPlatformWeb   = "web"
+	LogPlatformOther = "other"
+)
+
+// LogUsageService records which client each log entry came from and
+// reports per-log-type usage for product decisions. Aggregates only.
+type LogUsageService struct {
+	repo repository.LogUsageRepository
+	now  func() time.Time
+}
+
+func NewLogUsageService(repo repository.LogUsageRepository) *LogUsageService {
+	return &LogUsageService{repo: repo, now: time.Now}
+}
+
+// LogTypeUsage is one log type's usage over a report's range.
+type LogTypeUsage struct {
+	LogType string `json:"log_type"`
+	Entries int    `json:"entries"`
+	// SharePercent is this type's share of all entries in the range.
+	SharePercent float64 `json:"share_percent"`
+	// AvgDailyFamilies is the mean number of families logging this type on
+	// a recorded day.
+	AvgDailyFamilies float64 `json:"avg_daily_families"`
+	// Platforms counts entries by client platform. Counting began with
+	// log_entry_platforms, so older ranges split only part of Entries.
+	Platforms  map[string]int     `json:"platforms"`
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty"`
+	Daily      []LogUsageDayPoint `json:"daily"`
+}
+
+// LogUsageDayPoint is one day of a log type's series. Entries is null on
+// days with no metrics refresh, so charts show a gap rather than a zero.
+type LogUsageDayPoint struct {
+	Date    string `json:"date"`
+	Entries *int   `json:"entries"`
+}
+
+// LogUsageReport is GET /metrics/log-usage: every log type, most used
+// first, so the barely used ones sit at the bottom.
+type LogUsageReport struct {
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	TotalEntries int            `json:"total_entries"`
+	Platforms    map[string]int `json:"platforms"`
+	Types        []LogTypeUsage `json:"types"`
+}
+
+// RecordEntry counts one created entry of logType from platform (as
+// reported by middleware.AppClient). Best effort: a failure is logged and
+// never fails the request that created the entry.
+func (s *LogUsageService) RecordEntry(ctx context.Context, logType, platform string) {
+	switch platform {
+	case "":
+		platform = LogPlatformWeb
+	case AppPlatformIOS, AppPlatformAndroid:
+	default:
+		platform = LogPlatformOther
+	}
+	if err := s.repo.IncrementPlatform(ctx, s.now(), logType, platform); err != nil {
+		log.Printf("[log-usage] record %s entry from %s: %v", logType, platform, err)
+	}
+}
+
+// Report summarises usage per log type over the dates from..to (UTC,
+// inclusive).
+func (s *LogUsageService) Report(ctx context.Context, from, to time.Time) (*LogUsageReport, error) {
+	daily, err := s.repo.Daily(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	platforms, err := s.repo.Platforms(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	lastUsed, err := s.repo.LastUsed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LogUsageReport{
+		From:      from.Format("2006-01-02"),
+		To:        to.Format("2006-01-02"),
+		Platforms: map[string]int{},
+	}
+	byType := make(map[string]*LogTypeUsage, len(repository.LogUsageTypes))
+	for _, t := range repository.LogUsageTypes {
+		byType[t] = &LogTypeUsage{LogType: t, Platforms: map[string]int{}}
+	}
+
+	entriesByDay := map[string]map[string]int{}
+	familyDays := map[string]int{}
+	recordedDays := map[string]bool{}
+	for _, d := range daily {
+		u, ok := byType[d.LogType]
+		if !ok {
+			continue
+		}
+		day := d.Date.Format("2006-01-02")
+		if entriesByDay[d.LogType] == nil {
+			entriesByDay[d.LogType] = map[string]int{}
+		}
+		entriesByDay[d.LogType][day] = d.Entries
+		recordedDays[day] = true
+		familyDays[d.LogType] += d.Families
+		u.Entries += d.Entries
+		report.TotalEntries += d.Entries
+	}
+	for _, p := range platforms {
+		if u, ok := byType[p.LogType]; ok {
+			u.Platforms[p.Platform] += p.Entries
+			report.Platforms[p.Platform] += p.Entries
+		}
+	}
+
+	for _, t := range repository.LogUsageTypes {
+		u := byType[t]
+		if report.TotalEntries > 0 {
+			u.SharePercent = float64(u.Entries) / float64(report.TotalEntries) * 100
+		}
+		if len(recordedDays) > 0 {
+			u.AvgDailyFamilies = float64(familyDays[t]) / float64(len(recordedDays))
+		}
+		if last, ok := lastUsed[t]; ok {
+			u.LastUsedAt = &last
+		}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			day := d.Format("2006-01-02")
+			p := LogUsageDayPoint{Date: day}
+			if recordedDays[day] {
+				n := entriesByDay[t][day]
+				p.Entries = &n
+			}
+			u.Daily = append(u.Daily, p)
+		}
+		report.Types = append(report.Types, *u)
+	}
+	sort.SliceStable(report.Types, func(i, j int) bool {
+		return report.Types[i].Entries > report.Types[j].Entries
+	})
+	return report, nil
+}
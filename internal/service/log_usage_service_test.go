@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeLogUsageRepo struct {
+	repository.LogUsageRepository
+	daily     []repository.LogUsageDay
+	platforms []repository.LogPlatformDay
+	lastUsed  map[string]time.Time
+	recorded  []string
+}
+
+func (f *fakeLogUsageRepo) IncrementPlatform(ctx context.Context, at time.Time, logType, platform string) error {
+	f.recorded = append(f.recorded, logType+"/"+platform)
+	return nil
+}
+
+func (f *fakeLogUsageRepo) Daily(ctx context.Context, from, to time.Time) ([]repository.LogUsageDay, error) {
+	return f.daily, nil
+}
+
+func (f *fakeLogUsageRepo) Platforms(ctx context.Context, from, to time.Time) ([]repository.LogPlatformDay, error) {
+	return f.platforms, nil
+}
+
+func (f *fakeLogUsageRepo) LastUsed(ctx context.Context) (map[string]time.Time, error) {
+	return f.lastUsed, nil
+}
+
+func TestLogUsageRecordEntryPlatforms(t *testing.T) {
+	repo := &fakeLogUsageRepo{}
+	svc := NewLogUsageService(repo)
+	ctx := context.Background()
+	for _, p := range []string{"", "ios", "android", "windows"} {
+		svc.RecordEntry(ctx, "sleep", p)
+	}
+	want := []string{"sleep/web", "sleep/ios", "sleep/android", "sleep/other"}
+	if len(repo.recorded) != len(want) {
+		t.Fatalf("recorded %v", repo.recorded)
+	}
+	for i := range want {
+		if repo.recorded[i] != want[i] {
+			t.Errorf("recorded[%d] = %s, want %s", i, repo.recorded[i], want[i])
+		}
+	}
+}
+
+func TestLogUsageReport(t *testing.T) {
+	day1 := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	day3 := day1.AddDate(0, 0, 2)
+	last := time.Date(2025, 12, 24, 9, 0, 0, 0, time.UTC)
+	repo := &fakeLogUsageRepo{
+		daily: []repository.LogUsageDay{
+			{Date: day1, LogType: "behavior", Entries: 30, Families: 6},
+			{Date: day1, LogType: "sensory", Entries: 0},
+			{Date: day3, LogType: "behavior", Entries: 9, Families: 2},
+			{Date: day3, LogType: "sensory", Entries: 1, Families: 1},
+		},
+		platforms: []repository.LogPlatformDay{
+			{Date: day3, LogType: "behavior", Platform: "ios", Entries: 7},
+			{Date: day3, LogType: "behavior", Platform: "web", Entries: 2},
+			{Date: day3, LogType: "sensory", Platform: "android", Entries: 1},
+		},
+		lastUsed: map[string]time.Time{"sensory": last},
+	}
+	report, err := NewLogUsageService(repo).Report(context.Background(), day1, day3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.TotalEntries != 40 || len(report.Types) != len(repository.LogUsageTypes) {
+		t.Fatalf("total %d over %d types", report.TotalEntries, len(report.Types))
+	}
+	if report.Platforms["ios"] != 7 || report.Platforms["android"] != 1 {
+		t.Errorf("platforms %v", report.Platforms)
+	}
+
+	behavior, sensory := report.Types[0], report.Types[1]
+	if behavior.LogType != "behavior" || sensory.LogType != "sensory" {
+		t.Fatalf("order %s, %s", behavior.LogType, sensory.LogType)
+	}
+	if behavior.SharePercent != 97.5 || sensory.SharePercent != 2.5 {
+		t.Errorf("shares %v / %v", behavior.SharePercent, sensory.SharePercent)
+	}
+	if behavior.AvgDailyFamilies != 4 {
+		t.Errorf("behavior avg daily families %v", behavior.AvgDailyFamilies)
+	}
+	if behavior.Platforms["web"] != 2 || sensory.Platforms["android"] != 1 {
+		t.Errorf("per-type platforms %v / %v", behavior.Platforms, sensory.Platforms)
+	}
+	if sensory.LastUsedAt == nil || !sensory.LastUsedAt.Equal(last) || behavior.LastUsedAt != nil {
+		t.Errorf("last used %v / %v", sensory.LastUsedAt, behavior.LastUsedAt)
+	}
+
+	if len(sensory.Daily) != 3 {
+		t.Fatalf("sensory daily %v", sensory.Daily)
+	}
+	if sensory.Daily[0].Entries == nil || *sensory.Daily[0].Entries != 0 {
+		t.Error("a recorded day with no entries is zero")
+	}
+	if sensory.Daily[1].Entries != nil {
+		t.Error("a day without a refresh is null")
+	}
+	unused := report.Types[len(report.Types)-1]
+	if unused.Entries != 0 || unused.Daily[2].Entries == nil || *unused.Daily[2].Entries != 0 {
+		t.Errorf("unused type %+v", unused)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// LogConflictError is returned by the Update*Log methods for an edit made
+// against a version of the log that is no longer current. Conflict says
+// what changed since and how the edit merges onto it. It matches
+// repository.ErrLogVersionConflict.
+type LogConflictError struct {
+	Conflict *models.LogUpdateConflict
+}
+
+func (e *LogConflictError) Error() string {
+	return fmt.Sprintf("%s log %s was changed since version %d", e.Conflict.LogType, e.Conflict.LogID, e.Conflict.YourVersion)
+}
+
+func (e *LogConflictError) Unwrap() error {
+	return repository.ErrLogVersionConflict
+}
+
+// versionConflict turns an Update*Log failure into a *LogConflictError
+// when the log was at another version, or ErrLogNotFound when it was
+// deleted. Other errors are returned as they are. revisions may be nil.
+func versionConflict[T any](ctx context.Context, revisions repository.LogRevisionRepository, logType string, mine *T, id uuid.UUID, version int, get func(context.Context, uuid.UUID) (*T, error), err error) error {
+	if !errors.Is(err, repository.ErrLogVersionConflict) {
+		return err
+	}
+	current, err := get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return ErrLogNotFound
+	}
+	c, err := logConflict(ctx, revisions, logType, id, version, mine, current)
+	if err != nil {
+		return err
+	}
+	return &LogConflictError{Conflict: c}
+}
+
+// logConflict works out how an edit made against version lines up with
+// the current log. The revisions since version say which fields someone
+// else changed and what they were before: the edit's changes to other
+// fields merge cleanly, and a field both sides changed to different
+// values is a conflict. Without revision history every field the edit
+// would change is a conflict; that's the case when revisions is nil.
+func logConflict(ctx context.Context, revisions repository.LogRevisionRepository, logType string, id uuid.UUID, version int, mine, current any) (*models.LogUpdateConflict, error) {
+	c := &models.LogUpdateConflict{
+		LogType:      logType,
+		LogID:        id,
+		YourVersion:  version,
+		Current:      current,
+		TheirChanges: []models.LogRevision{},
+		Conflicts:    []models.LogFieldConflict{},
+	}
+	mineFields, err := logFields(mine)
+	if err != nil {
+		return nil, err
+	}
+	currentFields, err := logFields(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(currentFields["version"], &c.CurrentVersion); err != nil {
+		return nil, err
+	}
+
+	// base holds what the edit saw for each field changed since.
+	var base map[string]json.RawMessage
+	if revisions != nil {
+		revs, err := revisions.ListForLog(ctx, logType, id)
+		if err != nil {
+			return nil, err
+		}
+		base = map[string]json.RawMessage{}
+		for _, rev := range revs {
+			if rev.Version <= version {
+				continue
+			}
+			c.TheirChanges = append(c.TheirChanges, rev)
+			for _, ch := range rev.Changes {
+				if _, ok := base[ch.Field]; !ok {
+					base[ch.Field] = ch.From
+				}
+			}
+		}
+		c.Merged = make(map[string]json.RawMessage, len(currentFields))
+		for k, v := range currentFields {
+			c.Merged[k] = v
+		}
+	}
+
+	names := make([]string, 0, len(mineFields))
+	for name := range mineFields {
+		names = append(names, name)
+	}
+	for name := range currentFields {
+		if _, ok := mineFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if logRevisionIgnored[name] {
+			continue
+		}
+		m, cur := logFieldValue(mineFields[name]), logFieldValue(currentFields[name])
+		if sameLogValue(m, cur) {
+			continue
+		}
+		if base != nil {
+			was, theirs := base[name]
+			if !theirs {
+				c.Merged[name] = m
+				continue
+			}
+			if sameLogValue(m, logFieldValue(was)) {
+				// The edit left it as it was; their change stands.
+				continue
+			}
+			c.Conflicts = append(c.Conflicts, models.LogFieldConflict{Field: name, Base: logFieldValue(was), Mine: m, Theirs: cur})
+			continue
+		}
+		c.Conflicts = append(c.Conflicts, models.LogFieldConflict{Field: name, Mine: m, Theirs: cur})
+	}
+	return c, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+func TestLogConflictMerges(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	current := &models.BowelLog{ID: id, BristolScale: intPtr(4), PainLevel: intPtr(2), Notes: nullString("b"), Version: 4}
+	revs := &fakeLogRevisions{revs: []models.LogRevision{
+		{Version: 2, Changes: models.LogFieldChanges{{Field: "bristol_scale", From: json.RawMessage("3"), To: json.RawMessage("4")}}},
+		{Version: 3, Changes: models.LogFieldChanges{{Field: "notes", From: json.RawMessage(`"a"`), To: json.RawMessage(`"b"`)}}},
+		{Version: 4, Changes: models.LogFieldChanges{{Field: "pain_level", From: json.RawMessage("1"), To: json.RawMessage("2")}}},
+	}}
+
+	// Made against version 2: notes and bristol_scale edited, pain_level
+	// left as the editor saw it.
+	mine := &models.BowelLog{ID: id, BristolScale: intPtr(6), PainLevel: intPtr(1), Notes: nullString("c"), Version: 2}
+	c, err := logConflict(ctx, revs, "bowel", id, 2, mine, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CurrentVersion != 4 || len(c.TheirChanges) != 2 {
+		t.Errorf("current version %d, their changes %d", c.CurrentVersion, len(c.TheirChanges))
+	}
+	if len(c.Conflicts) != 1 || c.Conflicts[0].Field != "notes" || string(c.Conflicts[0].Base) != `"a"` ||
+		string(c.Conflicts[0].Mine) != `"c"` || string(c.Conflicts[0].Theirs) != `"b"` {
+		t.Errorf("conflicts = %+v", c.Conflicts)
+	}
+	for field, want := range map[string]string{"bristol_scale": "6", "pain_level": "2", "notes": `"b"`, "version": "4"} {
+		if got := string(c.Merged[field]); got != want {
+			t.Errorf("merged %s = %s, want %s", field, got, want)
+		}
+	}
+}
+
+func TestLogConflictWithoutHistory(t *testing.T) {
+	id := uuid.New()
+	current := &models.BowelLog{ID: id, BristolScale: intPtr(4), Notes: nullString("b"), Version: 3}
+	mine := &models.BowelLog{ID: id, BristolScale: intPtr(4), Notes: nullString("c"), HadAccident: true, Version: 1}
+	c, err := logConflict(context.Background(), nil, "bowel", id, 1, mine, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Merged != nil || len(c.Conflicts) != 2 || c.Conflicts[0].Field != "had_accident" || c.Conflicts[1].Field != "notes" ||
+		c.Conflicts[1].Base != nil {
+		t.Errorf("conflict = %+v", c)
+	}
+}
+
+func TestVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	mine := &models.BowelLog{ID: id, Version: 1}
+	get := func(context.Context, uuid.UUID) (*models.BowelLog, error) {
+		return &models.BowelLog{ID: id, Version: 2}, nil
+	}
+
+	other := errors.New("connection reset")
+	if err := versionConflict(ctx, nil, "bowel", mine, id, 1, get, other); err != other {
+		t.Errorf("other error = %v", err)
+	}
+	err := versionConflict(ctx, nil, "bowel", mine, id, 1, get, repository.ErrLogVersionConflict)
+	var conflict *LogConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, repository.ErrLogVersionConflict) || conflict.Conflict.CurrentVersion != 2 {
+		t.Errorf("conflict = %v", err)
+	}
+	gone := func(context.Context, uuid.UUID) (*models.BowelLog, error) { return nil, nil }
+	if err := versionConflict(ctx, nil, "bowel", mine, id, 1, gone, repository.ErrLogVersionConflict); !errors.Is(err, ErrLogNotFound) {
+		t.Errorf("deleted log = %v", err)
+	}
+}
+
+func TestMedicationLogVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	current := &models.MedicationLog{ID: id, Status: "taken", Notes: nullString("with food"), Version: 3}
+	repo := &fakeMedicationLogRepo{log: current}
+	s := &MedicationService{medRepo: repo}
+
+	mine := &models.MedicationLog{ID: id, Status: "skipped", Notes: nullString("with food"), Version: 2}
+	err := s.UpdateLogWithTracking(ctx, &models.MedicationLog{ID: id, Version: 2}, mine, uuid.New(), nil)
+	var conflict *LogConflictError
+	if !errors.As(err, &conflict) || conflict.Conflict.LogType != "medication" || conflict.Conflict.CurrentVersion != 3 {
+		t.Fatalf("conflict = %v", err)
+	}
+	if c := conflict.Conflict.Conflicts; len(c) != 1 || c[0].Field != "status" {
+		t.Errorf("conflicts = %+v", c)
+	}
+}
+
+func TestMedicationLogConflictMerges(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	seen := models.MedicationLog{ID: id, Status: "taken", Notes: nullString("with food"), Version: 3}
+	repo := &fakeMedicationLogRepo{log: &seen}
+	s := &MedicationService{medRepo: repo}
+	s.SetRevisions(&fakeLogRevisions{})
+
+	theirs := seen
+	theirs.Notes = nullString("after lunch")
+	if err := s.UpdateLogWithTracking(ctx, &seen, &theirs, uuid.New(), nil); err != nil {
+		t.Fatal(err)
+	}
+	repo.log = &theirs
+
+	// Made against version 3 too, changing only the status.
+	mine := seen
+	mine.Status = "skipped"
+	err := s.UpdateLogWithTracking(ctx, &seen, &mine, uuid.New(), nil)
+	var conflict *LogConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("conflict = %v", err)
+	}
+	c := conflict.Conflict
+	if len(c.TheirChanges) != 1 || c.TheirChanges[0].Version != 4 || len(c.Conflicts) != 0 {
+		t.Errorf("their changes %+v, conflicts %+v", c.TheirChanges, c.Conflicts)
+	}
+	for field, want := range map[string]string{"status": `"skipped"`, "notes": `"after lunch"`} {
+		if got := string(c.Merged[field]); got != want {
+			t.Errorf("merged %s = %s, want %s", field, got, want)
+		}
+	}
+}
+
+// fakeMedicationLogRepo holds one medication log; UpdateLog only succeeds
+// at its version.
+type fakeMedicationLogRepo struct {
+	repository.MedicationRepository
+	log *models.MedicationLog
+}
+
+func (f *fakeMedicationLogRepo) GetLogByID(ctx context.Context, id uuid.UUID) (*models.MedicationLog, error) {
+	return f.log, nil
+}
+
+func (f *fakeMedicationLogRepo) UpdateLog(ctx context.Context, log *models.MedicationLog) error {
+	if log.Version != f.log.Version {
+		return repository.ErrLogVersionConflict
+	}
+	log.Version++
+	return nil
+}
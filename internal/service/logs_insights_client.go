@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// LogsQueryResult is the state of one Logs Insights query. Rows map field
+// names (@timestamp, @message, ...) to values.
+type LogsQueryResult struct {
+	Status         string
+	Rows           []map[string]string
+	RecordsMatched float64
+	RecordsScanned float64
+	BytesScanned   float64
+}
+
+// LogsInsightsAPI is the slice of the CloudWatch Logs API the log search
+// uses.
+type LogsInsightsAPI interface {
+	StartQuery(ctx context.Context, logGroup, query string, from, to time.Time, limit int) (string, error)
+	GetQueryResults(ctx context.Context, queryID string) (*LogsQueryResult, error)
+	StopQuery(ctx context.Context, queryID string) error
+}
+
+// LogsInsightsClient talks to CloudWatch Logs.
+type LogsInsightsClient struct {
+	api *awsJSONClient
+}
+
+// NewLogsInsightsClient creates a client for region using the default AWS
+// config.
+func NewLogsInsightsClient(ctx context.Context, region string) (*LogsInsightsClient, error) {
+	if region == "" {
+		region = "us-east-1"
+	}
+	creds, err := loadAWSCredentials(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return &LogsInsightsClient{
+		api: newAWSJSONClient("https://logs."+region+".amazonaws.com/", region, "logs", "Logs_20140328", creds),
+	}, nil
+}
+
+func (c *LogsInsightsClient) StartQuery(ctx context.Context, logGroup, query string, from, to time.Time, limit int) (string, error) {
+	var out struct {
+		QueryID string `json:"queryId"`
+	}
+	err := c.api.call(ctx, "StartQuery", map[string]interface{}{
+		"logGroupName": logGroup,
+		"queryString":  query,
+		"startTime":    from.Unix(),
+		"endTime":      to.Unix(),
+		"limit":        limit,
+	}, &out)
+	return out.QueryID, err
+}
+
+func (c *LogsInsightsClient) GetQueryResults(ctx context.Context, queryID string) (*LogsQueryResult, error) {
+	var out struct {
+		Status  string `json:"status"`
+		Results [][]struct {
+			Field string `json:"field"`
+			Value string `json:"value"`
+		} `json:"results"`
+		Statistics struct {
+			RecordsMatched float64 `json:"recordsMatched"`
+			RecordsScanned float64 `json:"recordsScanned"`
+			BytesScanned   float64 `json:"bytesScanned"`
+		} `json:"statistics"`
+	}
+	if err := c.api.call(ctx, "GetQueryResults", map[string]string{"queryId": queryID}, &out); err != nil {
+		return nil, err
+	}
+	res := &LogsQueryResult{
+		Status:         out.Status,
+		Rows:           make([]map[string]string, 0, len(out.Results)),
+		RecordsMatched: out.Statistics.RecordsMatched,
+		RecordsScanned: out.Statistics.RecordsScanned,
+		BytesScanned:   out.Statistics.BytesScanned,
+	}
+	for _, fields := range out.Results {
+		row := make(map[string]string, len(fields))
+		for _, f := range fields {
+			row[f.Field] = f.Value
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	return res, nil
+}
+
+func (c *LogsInsightsClient) StopQuery(ctx context.Context, queryID string) error {
+	return c.api.call(ctx, "StopQuery", map[string]string{"queryId": queryID}, nil)
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+	"golang.org/x/image/font/opentype"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+var (
+	ErrFontInvalid            = apperr.Validation("invalid font file")
+	ErrFontNotFound           = apperr.NotFound("brand font not found")
+	ErrFontStorageUnavailable = errors.New("font storage not configured")
+)
+
+const (
+	maxFontBytes = 10 << 20
+	// Fallbacks used when the brand family hasn't been uploaded.
+	fallbackPDFFont   = "Helvetica"
+	fallbackPNGFont   = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+	fallbackPNGBold   = "/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf"
+	pdfHeadingFontKey = "BrandHeading"
+	pdfBodyFontKey    = "BrandBody"
+)
+
+// fpdfStyles maps stored styles to fpdf style strings.
+var fpdfStyles = map[string]string{
+	models.FontStyleRegular:    "",
+	models.FontStyleBold:       "B",
+	models.FontStyleItalic:     "I",
+	models.FontStyleBoldItalic: "BI",
+}
+
+// SetFontStorage wires the blob store for uploaded brand fonts. Without it
+// uploads fail and generators use the fallback fonts.
+func (s *MarketingService) SetFontStorage(storage BlobStorage) {
+	s.fontStorage = storage
+}
+
+// SetScanService routes font and screenshot uploads through the antivirus
+// stage. Without it they are stored unscanned.
+func (s *MarketingService) SetScanService(scan *UploadScanService) {
+	s.scan = scan
+}
+
+// validateFontFile checks that data is a font both renderers can embed and
+// returns its format. Both fpdf's UTF-8 font support and gg need TrueType
+// outlines, so CFF-flavored OpenType ("OTTO") is rejected up front rather
+// than failing later at generation time.
+func validateFontFile(data []byte) (format string, err error) {
+	if len(data) < 12 {
+		return "", fmt.Errorf("%w: file too small", ErrFontInvalid)
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true":
+		format = "ttf"
+	case "OTTO":
+		return "", fmt.Errorf("%w: OpenType fonts with CFF outlines can't be embedded; upload the TrueType (TTF) version", ErrFontInvalid)
+	default:
+		return "", fmt.Errorf("%w: not a TrueType/OpenType font", ErrFontInvalid)
+	}
+	if _, err := opentype.Parse(data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFontInvalid, err)
+	}
+
+	// fpdf's subsetter panics on some malformed tables instead of erroring.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: unreadable by PDF renderer", ErrFontInvalid)
+		}
+	}()
+	probe := fpdf.New("P", "in", "Letter", "")
+	probe.AddUTF8FontFromBytes("probe", "", data)
+	if probe.Err() {
+		return "", fmt.Errorf("%w: %v", ErrFontInvalid, probe.Error())
+	}
+	return format, nil
+}
+
+// UploadBrandFont validates and stores a font file for family/style,
+// replacing any earlier upload for the same pair.
+func (s *MarketingService) UploadBrandFont(ctx context.Context, family, style, licenseNote, filename string, body io.Reader, uploadedBy uuid.UUID) (*models.BrandFont, error) {
+	if s.fontStorage == nil {
+		return nil, ErrFontStorageUnavailable
+	}
+	family = strings.TrimSpace(family)
+	if family == "" || len(family) > 100 {
+		return nil, fmt.Errorf("%w: family is required (max 100 characters)", ErrFontInvalid)
+	}
+	if style == "" {
+		style = models.FontStyleRegular
+	}
+	if _, ok := fpdfStyles[style]; !ok {
+		return nil, fmt.Errorf("%w: style must be regular, bold, italic, or bold_italic", ErrFontInvalid)
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext != ".ttf" && ext != ".otf" {
+		return nil, fmt.Errorf("%w: file must be .ttf or .otf", ErrFontInvalid)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxFontBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFontBytes {
+		return nil, fmt.Errorf("%w: file exceeds %d MB", ErrFontInvalid, maxFontBytes>>20)
+	}
+	format, err := validateFontFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".otf" {
+		// TrueType-outline OTF: keep the uploader's extension for clarity.
+		format = "otf"
+	}
+
+	scanStatus, err := s.scan.Check(ctx, ScanTarget{
+		Source:      ScanSourceBrandFont,
+		Filename:    filepath.Base(filename),
+		ContentType: "font/" + format,
+		Size:        int64(len(data)),
+		UploadedBy:  uploadedBy,
+		Open:        bytesOpener(data),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	path, size, err := s.fontStorage.Save(ctx, "brand_fonts", filename, "font/"+format, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("store font: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	font := &models.BrandFont{
+		Family:           family,
+		Style:            style,
+		Format:           format,
+		OriginalFilename: filepath.Base(filename),
+		StorageDriver:    s.fontStorage.Driver(),
+		StoragePath:      path,
+		SizeBytes:        size,
+		SHA256:           hex.EncodeToString(sum[:]),
+		LicenseNote:      strings.TrimSpace(licenseNote),
+		UploadedBy:       &uploadedBy,
+		ScanStatus:       scanStatus,
+	}
+	replaced, err := s.repo.UpsertBrandFont(ctx, font)
+	if err != nil {
+		_ = s.fontStorage.Delete(ctx, path)
+		return nil, err
+	}
+	if replaced != nil {
+		s.dropCachedFont(replaced.ID)
+		if err := s.fontStorage.Delete(ctx, replaced.StoragePath); err != nil {
+			log.Printf("[MARKETING] orphaned font blob %s: %v", replaced.StoragePath, err)
+		}
+	}
+	return font, nil
+}
+
+// ListBrandFonts returns all uploaded fonts.
+func (s *MarketingService) ListBrandFonts(ctx context.Context) ([]models.BrandFont, error) {
+	return s.repo.ListBrandFonts(ctx)
+}
+
+// DeleteBrandFont removes an uploaded font and its stored file.
+func (s *MarketingService) DeleteBrandFont(ctx context.Context, id uuid.UUID) error {
+	font, err := s.repo.GetBrandFont(ctx, id)
+	if err != nil {
+		return err
+	}
+	if font == nil {
+		return ErrFontNotFound
+	}
+	if err := s.repo.DeleteBrandFont(ctx, id); err != nil {
+		return err
+	}
+	s.dropCachedFont(id)
+	if s.fontStorage != nil {
+		if err := s.fontStorage.Delete(ctx, font.StoragePath); err != nil {
+			log.Printf("[MARKETING] orphaned font blob %s: %v", font.StoragePath, err)
+		}
+	}
+	return nil
+}
+
+func (s *MarketingService) dropCachedFont(id uuid.UUID) {
+	s.fontMu.Lock()
+	delete(s.fontCache, id)
+	s.fontMu.Unlock()
+}
+
+// fontBytes returns a font file, from cache or storage. Font files are
+// immutable per ID (re-uploads get a new row), so cache entries never go
+// stale.
+func (s *MarketingService) fontBytes(ctx context.Context, f models.BrandFont) ([]byte, error) {
+	s.fontMu.Lock()
+	data, ok := s.fontCache[f.ID]
+	s.fontMu.Unlock()
+	if ok {
+		return data, nil
+	}
+	if s.fontStorage == nil {
+		return nil, ErrFontStorageUnavailable
+	}
+	rc, err := s.fontStorage.Open(ctx, f.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err = io.ReadAll(io.LimitReader(rc, maxFontBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	s.fontMu.Lock()
+	s.fontCache[f.ID] = data
+	s.fontMu.Unlock()
+	return data, nil
+}
+
+// brandFonts holds the uploaded files for the configured heading and body
+// families, keyed by style. Missing entries mean "use the fallback".
+type brandFonts struct {
+	heading map[string][]byte
+	body    map[string][]byte
+}
+
+// loadBrandFonts resolves BrandConfig.HeadingFont/BodyFont to uploaded
+// files. It never fails: any problem is logged and the fallback is used,
+// so a broken upload can't take down asset generation.
+func (s *MarketingService) loadBrandFonts(ctx context.Context, config *models.BrandConfig) *brandFonts {
+	set := &brandFonts{heading: map[string][]byte{}, body: map[string][]byte{}}
+	if s.fontStorage == nil || config == nil {
+		return set
+	}
+	fonts, err := s.repo.ListBrandFonts(ctx)
+	if err != nil {
+		log.Printf("[MARKETING] brand fonts unavailable, using fallback: %v", err)
+		return set
+	}
+	for _, f := range fonts {
+		isHeading := strings.EqualFold(f.Family, config.HeadingFont)
+		isBody := strings.EqualFold(f.Family, config.BodyFont)
+		if !isHeading && !isBody {
+			continue
+		}
+		data, err := s.fontBytes(ctx, f)
+		if err != nil {
+			log.Printf("[MARKETING] font %s %s unreadable, using fallback: %v", f.Family, f.Style, err)
+			continue
+		}
+		if isHeading {
+			set.heading[f.Style] = data
+		}
+		if isBody {
+			set.body[f.Style] = data
+		}
+	}
+	return set
+}
+
+// pickFontStyle returns the file for style, falling back to the closest uploaded
+// style (bold_italic → bold → regular) so a family with only a regular
+// weight still renders everywhere.
+func pickFontStyle(styles map[string][]byte, style string) []byte {
+	order := map[string][]string{
+		models.FontStyleRegular:    {models.FontStyleRegular, models.FontStyleBold},
+		models.FontStyleBold:       {models.FontStyleBold, models.FontStyleRegular},
+		models.FontStyleItalic:     {models.FontStyleItalic, models.FontStyleRegular, models.FontStyleBold},
+		models.FontStyleBoldItalic: {models.FontStyleBoldItalic, models.FontStyleBold, models.FontStyleRegular},
+	}[style]
+	for _, st := range order {
+		if data, ok := styles[st]; ok {
+			return data
+		}
+	}
+	return nil
+}
+
+// applyPDF registers the brand families on pdf and returns the family names
+// to pass to SetFont for headings and body text.
+func (b *brandFonts) applyPDF(pdf *fpdf.Fpdf) (heading, body string) {
+	register := func(key string, styles map[string][]byte) string {
+		if len(styles) == 0 {
+			return fallbackPDFFont
+		}
+		for style, fpdfStyle := range fpdfStyles {
+			pdf.AddUTF8FontFromBytes(key, fpdfStyle, pickFontStyle(styles, style))
+		}
+		return key
+	}
+	return register(pdfHeadingFontKey, b.heading), register(pdfBodyFontKey, b.body)
+}
+
+// setPNGFont sets the gg font for a heading (bold) or body (regular) run.
+func (b *brandFonts) setPNGFont(dc *gg.Context, heading bool, size float64) error {
+	styles, style, fallback := b.body, models.FontStyleRegular, fallbackPNGFont
+	if heading {
+		styles, style, fallback = b.heading, models.FontStyleBold, fallbackPNGBold
+	}
+	if data := pickFontStyle(styles, style); data != nil {
+		f, err := opentype.Parse(data)
+		if err == nil {
+			// DPI 72 so size is in points, matching gg.LoadFontFace.
+			face, ferr := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72})
+			if ferr == nil {
+				dc.SetFontFace(face)
+				return nil
+			}
+			err = ferr
+		}
+		log.Printf("[MARKETING] brand font failed to load, using fallback: %v", err)
+	}
+	return dc.LoadFontFace(fallback, size)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"carecompanion/internal/models"
+)
+
+type fontRepo struct {
+	brandConfigRepo
+	fonts []models.BrandFont
+}
+
+func (f *fontRepo) ListBrandFonts(ctx context.Context) ([]models.BrandFont, error) {
+	return f.fonts, nil
+}
+
+func (f *fontRepo) UpsertBrandFont(ctx context.Context, font *models.BrandFont) (*models.BrandFont, error) {
+	font.ID = uuid.New()
+	f.fonts = append(f.fonts, *font)
+	return nil, nil
+}
+
+// memBlobStorage keeps blobs in a map.
+type memBlobStorage struct{ blobs map[string][]byte }
+
+func (m *memBlobStorage) Driver() string { return "mem" }
+
+func (m *memBlobStorage) Save(ctx context.Context, namespace, filename, contentType string, body io.Reader) (string, int64, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", 0, err
+	}
+	path := namespace + "/" + uuid.New().String()
+	m.blobs[path] = data
+	return path, int64(len(data)), nil
+}
+
+func (m *memBlobStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.blobs[path])), nil
+}
+
+func (m *memBlobStorage) Delete(ctx context.Context, path string) error {
+	delete(m.blobs, path)
+	return nil
+}
+
+func TestValidateFontFile(t *testing.T) {
+	if format, err := validateFontFile(goregular.TTF); err != nil || format != "ttf" {
+		t.Fatalf("goregular: format=%q err=%v", format, err)
+	}
+	cff := append([]byte("OTTO"), make([]byte, 64)...)
+	for name, data := range map[string][]byte{
+		"cff otf": cff,
+		"garbage": []byte("definitely not a font file"),
+		"empty":   nil,
+	} {
+		if _, err := validateFontFile(data); !errors.Is(err, ErrFontInvalid) {
+			t.Errorf("%s: err = %v, want ErrFontInvalid", name, err)
+		}
+	}
+}
+
+func TestPickFontStyleFallback(t *testing.T) {
+	regular, bold := []byte("r"), []byte("b")
+	styles := map[string][]byte{models.FontStyleRegular: regular}
+	if got := pickFontStyle(styles, models.FontStyleBoldItalic); !bytes.Equal(got, regular) {
+		t.Errorf("bold_italic with only regular = %q", got)
+	}
+	styles[models.FontStyleBold] = bold
+	if got := pickFontStyle(styles, models.FontStyleBoldItalic); !bytes.Equal(got, bold) {
+		t.Errorf("bold_italic with bold = %q", got)
+	}
+	if got := pickFontStyle(map[string][]byte{}, models.FontStyleRegular); got != nil {
+		t.Errorf("empty set = %q, want nil", got)
+	}
+}
+
+func TestUploadedFontEmbeddedInPDF(t *testing.T) {
+	repo := &fontRepo{brandConfigRepo: brandConfigRepo{cfg: &models.BrandConfig{
+		AppName: "CareCompanion", PrimaryColor: "#4F46E5", HeadingFont: "Go", BodyFont: "Go",
+	}}}
+	svc := NewMarketingService(repo, t.TempDir())
+
+	// Without storage configured generation still works on core fonts.
+	pdf, err := svc.GenerateLetterheadPDF(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(pdf, []byte("/FontFile2")) {
+		t.Error("letterhead embeds a font file before any upload")
+	}
+
+	svc.SetFontStorage(&memBlobStorage{blobs: map[string][]byte{}})
+	ctx := context.Background()
+	uploader := uuid.New()
+	if _, err := svc.UploadBrandFont(ctx, "go", "", "", "Go-Regular.ttf", bytes.NewReader(goregular.TTF), uploader); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UploadBrandFont(ctx, "Go", models.FontStyleBold, "BSD", "Go-Bold.ttf", bytes.NewReader(gobold.TTF), uploader); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UploadBrandFont(ctx, "Go", "", "", "font.woff", bytes.NewReader(goregular.TTF), uploader); !errors.Is(err, ErrFontInvalid) {
+		t.Errorf("woff upload: err = %v, want ErrFontInvalid", err)
+	}
+
+	pdf, err = svc.GenerateLetterheadPDF(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(pdf, []byte("/FontFile2")) {
+		t.Error("letterhead did not embed the uploaded brand font")
+	}
+
+	if _, err := svc.GenerateLogoPNG(ctx, "primary", 128); err != nil {
+		t.Errorf("logo with brand font: %v", err)
+	}
+}
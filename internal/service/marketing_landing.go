@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	// publicStatsTTL bounds how often the public widget endpoint hits the
+	// database, however many marketing-site visitors poll it.
+	publicStatsTTL = 10 * time.Minute
+	// widgetRefreshMs is how often an embedded widget re-fetches stats.
+	widgetRefreshMs = 5 * 60 * 1000
+)
+
+// PublicStats are the aggregate numbers safe to show on the public site.
+// Counts are floored to coarse buckets so the widget never reveals exact
+// figures (or tiny ones) and doesn't tick visibly with each signup.
+type PublicStats struct {
+	Families      int       `json:"families"`
+	Entries       int       `json:"entries"`
+	EntriesPerDay int       `json:"entries_per_day"`
+	Insights      int       `json:"insights"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type publicStatsCache struct {
+	mu      sync.Mutex
+	stats   *PublicStats
+	fetched time.Time
+}
+
+// floorBucket rounds n down to a "nice" granularity for its magnitude:
+// tens under 1k, hundreds under 10k, thousands above. Values under 10
+// collapse to 0 so the widget can hide them.
+func floorBucket(n int) int {
+	switch {
+	case n < 10:
+		return 0
+	case n < 1000:
+		return n / 10 * 10
+	case n < 10000:
+		return n / 100 * 100
+	default:
+		return n / 1000 * 1000
+	}
+}
+
+// GetPublicStats returns bucketed marketing stats, cached for publicStatsTTL.
+func (s *MarketingService) GetPublicStats(ctx context.Context) (*PublicStats, error) {
+	c := s.publicStats
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats != nil && time.Since(c.fetched) < publicStatsTTL {
+		return c.stats, nil
+	}
+
+	raw, err := s.repo.GetMarketingStats(ctx)
+	if err != nil {
+		if c.stats != nil {
+			// Serve stale rather than break the marketing site.
+			return c.stats, nil
+		}
+		return nil, err
+	}
+	c.stats = &PublicStats{
+		Families:      floorBucket(raw.TotalFamilies),
+		Entries:       floorBucket(raw.TotalEntries),
+		EntriesPerDay: floorBucket(int(raw.AverageEntriesPerDay)),
+		Insights:      floorBucket(raw.InsightsGenerated),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	c.fetched = time.Now()
+	return c.stats, nil
+}
+
+// LandingSnippet is paste-ready markup for the public marketing site. The
+// widget script fills every <div data-cc-stats-widget> on the page; the
+// hero HTML already contains one.
+type LandingSnippet struct {
+	HTML      string `json:"html"`
+	CSS       string `json:"css"`
+	Widget    string `json:"widget"`     // script tag that loads the stats widget
+	StatsURL  string `json:"stats_url"`  // JSON the widget polls
+	ScriptURL string `json:"script_url"` // widget script
+}
+
+var landingHeroHTML = template.Must(template.New("hero").Parse(`<section class="cc-hero">
+  <div class="cc-hero__inner">
+    <h1 class="cc-hero__title">{{.AppName}}</h1>
+    <p class="cc-hero__tagline">{{.Tagline}}</p>
+    {{if .Mission}}<p class="cc-hero__body">{{.Mission}}</p>{{end}}
+    {{if .CTAURL}}<a class="cc-hero__cta" href="{{.CTAURL}}">Get started</a>{{end}}
+    <div data-cc-stats-widget></div>
+  </div>
+</section>
+`))
+
+// landingHeroCSS is scoped under .cc-hero so it can't leak into the host
+// page's styles. Values are checked by safeCSS before they reach it.
+var landingHeroCSS = texttemplate.Must(texttemplate.New("css").Parse(`.cc-hero{background:linear-gradient(135deg,{{.Primary}} 0%,{{.PrimaryDark}} 100%);color:#fff;padding:72px 24px;font-family:{{.BodyFont}},system-ui,sans-serif}
+.cc-hero__inner{max-width:960px;margin:0 auto;text-align:center}
+.cc-hero__title{font-family:{{.HeadingFont}},system-ui,sans-serif;font-size:clamp(2rem,5vw,3.5rem);font-weight:800;margin:0 0 12px}
+.cc-hero__tagline{font-size:clamp(1.1rem,2.5vw,1.5rem);opacity:.9;margin:0 0 20px}
+.cc-hero__body{max-width:640px;margin:0 auto 28px;line-height:1.6;opacity:.85}
+.cc-hero__cta{display:inline-block;background:{{.Accent}};color:#fff;font-weight:700;padding:14px 32px;border-radius:999px;text-decoration:none}
+.cc-hero__cta:hover{filter:brightness(1.08)}
+.cc-hero [data-cc-stats-widget]{margin-top:40px}
+`))
+
+// statsWidgetJS renders into every [data-cc-stats-widget] element. Text is
+// set via textContent only; the stats JSON is never treated as markup.
+const statsWidgetJS = `(function () {
+  var cfg = %s;
+  var self = document.currentScript;
+  if (!self || !self.src) return;
+  // Stats live next to this script, wherever it was loaded from.
+  cfg.url = new URL("stats.json", self.src).toString();
+  function fmt(n) { return n.toLocaleString() + "+"; }
+  function render(el, s) {
+    var tiles = [["families", "families"], ["entries", "entries logged"], ["insights", "insights generated"]];
+    el.textContent = "";
+    el.style.cssText = "display:flex;flex-wrap:wrap;gap:16px;justify-content:center;font-family:system-ui,sans-serif";
+    tiles.forEach(function (t) {
+      if (!s[t[0]]) return;
+      var tile = document.createElement("div");
+      tile.style.cssText = "min-width:140px;padding:16px 20px;border-radius:12px;background:#fff;color:" + cfg.dark + ";box-shadow:0 1px 3px rgba(0,0,0,.12);text-align:center";
+      var num = document.createElement("div");
+      num.style.cssText = "font-size:28px;font-weight:800;color:" + cfg.primary;
+      num.textContent = fmt(s[t[0]]);
+      var label = document.createElement("div");
+      label.style.cssText = "font-size:13px;opacity:.75";
+      label.textContent = t[1];
+      tile.appendChild(num);
+      tile.appendChild(label);
+      el.appendChild(tile);
+    });
+  }
+  function refresh() {
+    fetch(cfg.url, { credentials: "omit" }).then(function (r) { return r.json(); }).then(function (s) {
+      document.querySelectorAll("[data-cc-stats-widget]").forEach(function (el) { render(el, s); });
+    }).catch(function () {});
+  }
+  refresh();
+  setInterval(refresh, cfg.refreshMs);
+})();
+`
+
+// publicBase strips any trailing slash so paths can be appended.
+func publicBase(baseURL string) string {
+	return strings.TrimRight(baseURL, "/")
+}
+
+// GenerateLandingSnippet builds the hero section and widget embed code.
+// baseURL is the public origin of this server (where the widget endpoints
+// are served), not the marketing site.
+func (s *MarketingService) GenerateLandingSnippet(ctx context.Context, baseURL string) (*LandingSnippet, error) {
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	view := struct {
+		AppName, Tagline, Mission string
+		CTAURL                    template.URL
+	}{AppName: config.AppName, Tagline: config.Tagline, Mission: config.MissionStatement}
+	if target, err := qrTargetFor(config, "website", "landing", "hero"); err == nil {
+		view.CTAURL = template.URL(target)
+	}
+	var html bytes.Buffer
+	if err := landingHeroHTML.Execute(&html, view); err != nil {
+		return nil, err
+	}
+
+	var css bytes.Buffer
+	err = landingHeroCSS.Execute(&css, map[string]template.CSS{
+		"Primary":     safeCSS(config.PrimaryColor, cssHexColor, "#4F46E5"),
+		"PrimaryDark": safeCSS(config.PrimaryDark, cssHexColor, "#3730A3"),
+		"Accent":      safeCSS(config.AccentColor, cssHexColor, "#F59E0B"),
+		"HeadingFont": safeCSS(config.HeadingFont, cssFontName, "Inter"),
+		"BodyFont":    safeCSS(config.BodyFont, cssFontName, "Inter"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	base := publicBase(baseURL)
+	script := base + "/widget/stats.js"
+	return &LandingSnippet{
+		HTML:      html.String(),
+		CSS:       css.String(),
+		Widget:    fmt.Sprintf("<script src=%q async></script>", script),
+		StatsURL:  base + "/widget/stats.json",
+		ScriptURL: script,
+	}, nil
+}
+
+// StatsWidgetScript returns the widget JS with brand colors baked in. The
+// script finds stats.json relative to its own src, so no host is embedded
+// and a cached copy can't be poisoned via the Host header.
+func (s *MarketingService) StatsWidgetScript(ctx context.Context) ([]byte, error) {
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := json.Marshal(map[string]interface{}{
+		"primary":   string(safeCSS(config.PrimaryColor, cssHexColor, "#4F46E5")),
+		"dark":      string(safeCSS(config.PrimaryDark, cssHexColor, "#3730A3")),
+		"refreshMs": widgetRefreshMs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf(statsWidgetJS, cfg)), nil
+}
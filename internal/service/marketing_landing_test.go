@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"carecompanion/internal/models"
+)
+
+type statsRepo struct {
+	brandConfigRepo
+	calls int
+}
+
+func (f *statsRepo) GetMarketingStats(ctx context.Context) (*models.MarketingStats, error) {
+	f.calls++
+	return &models.MarketingStats{TotalFamilies: 1234, TotalEntries: 98765, AverageEntriesPerDay: 7.9, InsightsGenerated: 42}, nil
+}
+
+func TestFloorBucket(t *testing.T) {
+	cases := map[int]int{0: 0, 9: 0, 10: 10, 57: 50, 999: 990, 1234: 1200, 98765: 98000}
+	for in, want := range cases {
+		if got := floorBucket(in); got != want {
+			t.Errorf("floorBucket(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestGetPublicStatsBucketsAndCaches(t *testing.T) {
+	repo := &statsRepo{}
+	svc := NewMarketingService(repo, t.TempDir())
+	for i := 0; i < 3; i++ {
+		stats, err := svc.GetPublicStats(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Families != 1200 || stats.Entries != 98000 || stats.EntriesPerDay != 0 || stats.Insights != 40 {
+			t.Fatalf("stats = %+v", stats)
+		}
+	}
+	if repo.calls != 1 {
+		t.Errorf("repo hit %d times, want 1 (cached)", repo.calls)
+	}
+}
+
+func TestGenerateLandingSnippet(t *testing.T) {
+	svc := NewMarketingService(&brandConfigRepo{cfg: &models.BrandConfig{
+		AppName:      "Care<script>",
+		Tagline:      "Track. Discover.",
+		PrimaryColor: "#4F46E5",
+		AccentColor:  "red}body{display:none",
+		WebsiteURL:   "https://carecompanion.app",
+	}}, t.TempDir())
+
+	snip, err := svc.GenerateLandingSnippet(context.Background(), "https://api.carecompanion.app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(snip.HTML, "<script>") {
+		t.Error("app name not escaped in hero HTML")
+	}
+	if !strings.Contains(snip.HTML, "utm_medium=landing") {
+		t.Error("hero CTA missing UTM tagging")
+	}
+	if strings.Contains(snip.CSS, "display:none") {
+		t.Error("unsafe accent color reached the CSS")
+	}
+	if snip.StatsURL != "https://api.carecompanion.app/widget/stats.json" {
+		t.Errorf("stats url = %q", snip.StatsURL)
+	}
+	if !strings.Contains(snip.Widget, `src="https://api.carecompanion.app/widget/stats.js"`) {
+		t.Errorf("widget = %q", snip.Widget)
+	}
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// ErrNewsletterInvalid is returned for bad newsletter generator input.
+var ErrNewsletterInvalid = apperr.Validation("invalid newsletter request")
+
+// Newsletter template kinds.
+const (
+	NewsletterAnnouncement  = "announcement"
+	NewsletterFeatureLaunch = "feature_launch"
+	NewsletterTestimonial   = "testimonial"
+)
+
+// Newsletter export dialects. Merge fields are written as [[name]] in
+// copy and rewritten into the target platform's syntax on export.
+const (
+	NewsletterExportRaw       = "raw"
+	NewsletterExportMailchimp = "mailchimp"
+	NewsletterExportSES       = "ses"
+)
+
+// NewsletterMergeField describes one supported placeholder.
+type NewsletterMergeField struct {
+	Name        string `json:"name"`
+	Placeholder string `json:"placeholder"`
+	Mailchimp   string `json:"mailchimp"`
+	SES         string `json:"ses"`
+	Description string `json:"description"`
+}
+
+// newsletterMergeFields is the closed set of placeholders. Mailchimp tags
+// follow its default audience fields; SES uses Handlebars variables that
+// must be supplied per destination in SendBulkTemplatedEmail.
+var newsletterMergeFields = []NewsletterMergeField{
+	{Name: "first_name", Mailchimp: "*|FNAME|*", SES: "{{first_name}}", Description: "Recipient first name"},
+	{Name: "last_name", Mailchimp: "*|LNAME|*", SES: "{{last_name}}", Description: "Recipient last name"},
+	{Name: "email", Mailchimp: "*|EMAIL|*", SES: "{{email}}", Description: "Recipient email address"},
+	{Name: "unsubscribe_url", Mailchimp: "*|UNSUB|*", SES: "{{unsubscribe_url}}", Description: "One-click unsubscribe link (required for bulk sends)"},
+}
+
+var mergeFieldPattern = regexp.MustCompile(`\[\[\s*([a-z_]+)\s*\]\]`)
+
+func init() {
+	for i := range newsletterMergeFields {
+		newsletterMergeFields[i].Placeholder = "[[" + newsletterMergeFields[i].Name + "]]"
+	}
+}
+
+// NewsletterMergeFields returns the supported placeholders.
+func NewsletterMergeFields() []NewsletterMergeField {
+	return append([]NewsletterMergeField(nil), newsletterMergeFields...)
+}
+
+// NewsletterRequest is the copy for one generated email. Paragraphs in
+// Body are separated by blank lines. Any text field may contain merge
+// fields like [[first_name]].
+type NewsletterRequest struct {
+	Kind      string   `json:"kind"`
+	Subject   string   `json:"subject"`
+	Preheader string   `json:"preheader"`
+	Headline  string   `json:"headline"`
+	Body      string   `json:"body"`
+	CTAText   string   `json:"cta_text"`
+	CTAURL    string   `json:"cta_url"`
+	Features  []string `json:"features"`     // feature_launch bullets
+	Quote     string   `json:"quote"`        // testimonial
+	QuoteBy   string   `json:"quote_author"` // testimonial
+	Export    string   `json:"export"`       // raw | mailchimp | ses
+	Name      string   `json:"name"`         // template name for export payloads
+}
+
+// NewsletterTemplate is a rendered email in one export dialect.
+type NewsletterTemplate struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	Export      string   `json:"export"`
+	Subject     string   `json:"subject"`
+	HTML        string   `json:"html"`
+	Text        string   `json:"text"`
+	MergeFields []string `json:"merge_fields"`
+	// Payload is the platform import body: for SES, the CreateTemplate
+	// request; for Mailchimp, the POST /templates body. Nil for raw.
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// validateNewsletter checks required copy per kind and that every merge
+// field used is known. Returns the distinct fields used.
+func validateNewsletter(req *NewsletterRequest) ([]string, error) {
+	switch req.Kind {
+	case NewsletterAnnouncement, NewsletterFeatureLaunch, NewsletterTestimonial:
+	default:
+		return nil, fmt.Errorf("%w: kind must be announcement, feature_launch, or testimonial", ErrNewsletterInvalid)
+	}
+	switch req.Export {
+	case "":
+		req.Export = NewsletterExportRaw
+	case NewsletterExportRaw, NewsletterExportMailchimp, NewsletterExportSES:
+	default:
+		return nil, fmt.Errorf("%w: export must be raw, mailchimp, or ses", ErrNewsletterInvalid)
+	}
+	if strings.TrimSpace(req.Subject) == "" || strings.TrimSpace(req.Headline) == "" {
+		return nil, fmt.Errorf("%w: subject and headline are required", ErrNewsletterInvalid)
+	}
+	if req.Kind == NewsletterTestimonial && strings.TrimSpace(req.Quote) == "" {
+		return nil, fmt.Errorf("%w: testimonial requires a quote", ErrNewsletterInvalid)
+	}
+	if req.Kind == NewsletterFeatureLaunch && len(req.Features) == 0 {
+		return nil, fmt.Errorf("%w: feature_launch requires at least one feature", ErrNewsletterInvalid)
+	}
+	if req.CTAURL != "" && !strings.HasPrefix(req.CTAURL, "https://") && !strings.HasPrefix(req.CTAURL, "http://") {
+		return nil, fmt.Errorf("%w: cta_url must be an absolute http(s) URL", ErrNewsletterInvalid)
+	}
+
+	known := make(map[string]bool, len(newsletterMergeFields))
+	for _, f := range newsletterMergeFields {
+		known[f.Name] = true
+	}
+	seen := map[string]bool{}
+	var used []string
+	all := strings.Join(append([]string{req.Subject, req.Preheader, req.Headline, req.Body, req.CTAText, req.Quote, req.QuoteBy}, req.Features...), "\n")
+	for _, m := range mergeFieldPattern.FindAllStringSubmatch(all, -1) {
+		name := m[1]
+		if !known[name] {
+			return nil, fmt.Errorf("%w: unknown merge field [[%s]]", ErrNewsletterInvalid, name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			used = append(used, name)
+		}
+	}
+	return used, nil
+}
+
+// applyMergeDialect rewrites [[field]] placeholders for the export target.
+// Unknown names were rejected during validation, so they can't reach here.
+func applyMergeDialect(s, export string) string {
+	if export == NewsletterExportRaw {
+		return s
+	}
+	return mergeFieldPattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := mergeFieldPattern.FindStringSubmatch(m)[1]
+		for _, f := range newsletterMergeFields {
+			if f.Name == name {
+				if export == NewsletterExportMailchimp {
+					return f.Mailchimp
+				}
+				return f.SES
+			}
+		}
+		return m
+	})
+}
+
+// newsletterParagraphs splits body copy on blank lines.
+func newsletterParagraphs(body string) []string {
+	var out []string
+	for _, p := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// newsletterHTML is a single-column, table-based layout with inline styles
+// — the lowest common denominator that renders in Outlook, Gmail and Apple
+// Mail. The 600px container collapses to full width on small screens via
+// the one media query most clients honor.
+var newsletterHTML = template.Must(template.New("newsletter").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Subject}}</title>
+<style>
+  @media only screen and (max-width: 620px) {
+    .container { width: 100% !important; }
+    .px { padding-left: 20px !important; padding-right: 20px !important; }
+  }
+</style>
+</head>
+<body style="margin:0;padding:0;background:#f3f4f6;">
+<div style="display:none;max-height:0;overflow:hidden;">{{.Preheader}}</div>
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="background:#f3f4f6;">
+<tr><td align="center" style="padding:24px 0;">
+<table role="presentation" class="container" width="600" cellpadding="0" cellspacing="0" style="width:600px;background:#ffffff;border-radius:8px;overflow:hidden;font-family:{{.BodyFont}},Arial,sans-serif;color:#374151;">
+  <tr><td class="px" style="background:{{.Primary}};padding:24px 40px;color:#ffffff;font-family:{{.HeadingFont}},Arial,sans-serif;font-size:22px;font-weight:bold;">{{.AppName}}</td></tr>
+  <tr><td class="px" style="padding:32px 40px 8px 40px;">
+    <h1 style="margin:0 0 16px 0;font-family:{{.HeadingFont}},Arial,sans-serif;font-size:26px;line-height:1.3;color:{{.PrimaryDark}};">{{.Headline}}</h1>
+    {{range .Paragraphs}}<p style="margin:0 0 16px 0;font-size:16px;line-height:1.6;">{{.}}</p>
+    {{end}}
+  </td></tr>
+  {{if .Features}}<tr><td class="px" style="padding:0 40px 8px 40px;">
+    <table role="presentation" width="100%" cellpadding="0" cellspacing="0">
+    {{range .Features}}<tr>
+      <td width="24" valign="top" style="padding:6px 0;color:{{$.Secondary}};font-size:18px;">&#10003;</td>
+      <td style="padding:6px 0;font-size:16px;line-height:1.5;">{{.}}</td>
+    </tr>{{end}}
+    </table>
+  </td></tr>{{end}}
+  {{if .Quote}}<tr><td class="px" style="padding:8px 40px 16px 40px;">
+    <table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="border-left:4px solid {{.Accent}};background:#f9fafb;">
+    <tr><td style="padding:16px 20px;font-size:18px;line-height:1.5;font-style:italic;">&ldquo;{{.Quote}}&rdquo;{{if .QuoteBy}}<br><span style="font-size:14px;font-style:normal;color:#6b7280;">&mdash; {{.QuoteBy}}</span>{{end}}</td></tr>
+    </table>
+  </td></tr>{{end}}
+  {{if .CTAURL}}<tr><td class="px" align="left" style="padding:8px 40px 32px 40px;">
+    <table role="presentation" cellpadding="0" cellspacing="0"><tr>
+      <td style="background:{{.Accent}};border-radius:6px;">
+        <a href="{{.CTAURL}}" style="display:inline-block;padding:14px 28px;font-size:16px;font-weight:bold;color:#ffffff;text-decoration:none;">{{.CTAText}}</a>
+      </td>
+    </tr></table>
+  </td></tr>{{end}}
+  <tr><td class="px" style="padding:24px 40px;background:#f9fafb;font-size:12px;line-height:1.5;color:#6b7280;">
+    {{.Copyright}}<br>
+    {{if .Website}}<a href="{{.Website}}" style="color:#6b7280;">{{.Website}}</a> &middot; {{end}}<a href="{{.UnsubscribeURL}}" style="color:#6b7280;">Unsubscribe</a>
+  </td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+type newsletterView struct {
+	Subject, Preheader, Headline    string
+	Paragraphs, Features            []string
+	Quote, QuoteBy                  string
+	CTAText                         string
+	CTAURL                          template.URL
+	AppName, Copyright              string
+	Website                         template.URL
+	UnsubscribeURL                  template.URL
+	Primary, PrimaryDark, Secondary template.CSS
+	Accent                          template.CSS
+	HeadingFont, BodyFont           template.CSS
+}
+
+var (
+	cssHexColor = regexp.MustCompile(`^#[0-9a-fA-F]{3,8}$`)
+	cssFontName = regexp.MustCompile(`^[A-Za-z0-9 \-]{1,60}$`)
+)
+
+// safeCSS only lets through values matching pattern; brand config is
+// admin-edited, but it still ends up inside style attributes.
+func safeCSS(v string, pattern *regexp.Regexp, fallback string) template.CSS {
+	if pattern.MatchString(v) {
+		return template.CSS(v)
+	}
+	return template.CSS(fallback)
+}
+
+// GenerateNewsletter renders a branded HTML + plain-text email from the
+// brand config and the given copy, in the requested export dialect.
+func (s *MarketingService) GenerateNewsletter(ctx context.Context, req NewsletterRequest) (*NewsletterTemplate, error) {
+	used, err := validateNewsletter(&req)
+	if err != nil {
+		return nil, err
+	}
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctaText := req.CTAText
+	if req.CTAURL != "" && ctaText == "" {
+		ctaText = "Learn more"
+	}
+	view := newsletterView{
+		Subject:     req.Subject,
+		Preheader:   req.Preheader,
+		Headline:    req.Headline,
+		Paragraphs:  newsletterParagraphs(req.Body),
+		Features:    req.Features,
+		Quote:       req.Quote,
+		QuoteBy:     req.QuoteBy,
+		CTAText:     ctaText,
+		CTAURL:      template.URL(req.CTAURL),
+		AppName:     config.AppName,
+		Copyright:   config.CopyrightText,
+		Website:     template.URL(config.WebsiteURL),
+		Primary:     safeCSS(config.PrimaryColor, cssHexColor, "#4F46E5"),
+		PrimaryDark: safeCSS(config.PrimaryDark, cssHexColor, "#3730A3"),
+		Secondary:   safeCSS(config.SecondaryColor, cssHexColor, "#10B981"),
+		Accent:      safeCSS(config.AccentColor, cssHexColor, "#F59E0B"),
+		HeadingFont: safeCSS(config.HeadingFont, cssFontName, "Helvetica"),
+		BodyFont:    safeCSS(config.BodyFont, cssFontName, "Helvetica"),
+		// Always present so every export has a working unsubscribe link.
+		UnsubscribeURL: "[[unsubscribe_url]]",
+	}
+	if !strings.HasPrefix(config.WebsiteURL, "http") {
+		view.Website = ""
+	}
+
+	var buf bytes.Buffer
+	if err := newsletterHTML.Execute(&buf, view); err != nil {
+		return nil, err
+	}
+	if !containsString(used, "unsubscribe_url") {
+		used = append(used, "unsubscribe_url")
+	}
+
+	text := newsletterText(req, config, ctaText)
+	// Name ends up in SES TemplateName and download filenames.
+	name := qrSlug(req.Name)
+	if name == "" {
+		name = fmt.Sprintf("%s_%s", req.Kind, time.Now().Format("20060102"))
+	}
+	out := &NewsletterTemplate{
+		Name:        name,
+		Kind:        req.Kind,
+		Export:      req.Export,
+		Subject:     applyMergeDialect(req.Subject, req.Export),
+		HTML:        applyMergeDialect(buf.String(), req.Export),
+		Text:        applyMergeDialect(text, req.Export),
+		MergeFields: used,
+	}
+	switch req.Export {
+	case NewsletterExportSES:
+		// Body for SES CreateTemplate / `aws sesv2 create-email-template`.
+		out.Payload = map[string]interface{}{
+			"TemplateName": name,
+			"TemplateContent": map[string]string{
+				"Subject": out.Subject,
+				"Html":    out.HTML,
+				"Text":    out.Text,
+			},
+		}
+	case NewsletterExportMailchimp:
+		// Body for Mailchimp Marketing API POST /templates.
+		out.Payload = map[string]string{"name": name, "html": out.HTML}
+	}
+	return out, nil
+}
+
+// newsletterText is the plain-text alternative part.
+func newsletterText(req NewsletterRequest, config *models.BrandConfig, ctaText string) string {
+	var b strings.Builder
+	b.WriteString(req.Headline + "\n\n")
+	for _, p := range newsletterParagraphs(req.Body) {
+		b.WriteString(p + "\n\n")
+	}
+	for _, f := range req.Features {
+		b.WriteString("* " + f + "\n")
+	}
+	if len(req.Features) > 0 {
+		b.WriteString("\n")
+	}
+	if req.Quote != "" {
+		b.WriteString("\"" + req.Quote + "\"")
+		if req.QuoteBy != "" {
+			b.WriteString(" - " + req.QuoteBy)
+		}
+		b.WriteString("\n\n")
+	}
+	if req.CTAURL != "" {
+		b.WriteString(ctaText + ": " + req.CTAURL + "\n\n")
+	}
+	b.WriteString("--\n" + config.AppName + "\n")
+	if config.CopyrightText != "" {
+		b.WriteString(config.CopyrightText + "\n")
+	}
+	b.WriteString("Unsubscribe: [[unsubscribe_url]]\n")
+	return b.String()
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type brandConfigRepo struct {
+	repository.MarketingRepository
+	cfg *models.BrandConfig
+}
+
+func (f *brandConfigRepo) GetBrandConfig(ctx context.Context) (*models.BrandConfig, error) {
+	return f.cfg, nil
+}
+
+func newsletterTestService(t *testing.T) *MarketingService {
+	return NewMarketingService(&brandConfigRepo{cfg: &models.BrandConfig{
+		AppName:       "CareCompanion",
+		PrimaryColor:  "#4F46E5",
+		AccentColor:   "red;background:url(x)",
+		HeadingFont:   "Inter",
+		WebsiteURL:    "https://carecompanion.app",
+		CopyrightText: "© CareCompanion",
+	}}, t.TempDir())
+}
+
+func TestGenerateNewsletterExports(t *testing.T) {
+	svc := newsletterTestService(t)
+	base := NewsletterRequest{
+		Kind:     NewsletterFeatureLaunch,
+		Name:     "Spring Launch!",
+		Subject:  "New for you, [[first_name]]",
+		Headline: "Meet medication reminders",
+		Body:     "Hi [[first_name]],\n\nWe shipped <b>reminders</b>.",
+		Features: []string{"Custom schedules", "Refill alerts"},
+		CTAURL:   "https://carecompanion.app/new",
+	}
+
+	cases := []struct {
+		export  string
+		subject string
+		unsub   string
+	}{
+		{NewsletterExportRaw, "New for you, [[first_name]]", "[[unsubscribe_url]]"},
+		{NewsletterExportMailchimp, "New for you, *|FNAME|*", "*|UNSUB|*"},
+		{NewsletterExportSES, "New for you, {{first_name}}", "{{unsubscribe_url}}"},
+	}
+	for _, c := range cases {
+		t.Run(c.export, func(t *testing.T) {
+			req := base
+			req.Export = c.export
+			got, err := svc.GenerateNewsletter(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Subject != c.subject {
+				t.Errorf("subject = %q, want %q", got.Subject, c.subject)
+			}
+			if !strings.Contains(got.HTML, c.unsub) || !strings.Contains(got.Text, c.unsub) {
+				t.Errorf("unsubscribe placeholder %q missing", c.unsub)
+			}
+			if strings.Contains(got.HTML, "<b>reminders</b>") {
+				t.Error("body copy was not HTML-escaped")
+			}
+			if strings.Contains(got.HTML, "url(x)") {
+				t.Error("unsafe brand color reached the style attribute")
+			}
+			if got.Name != "spring_launch" {
+				t.Errorf("name = %q, want spring_launch", got.Name)
+			}
+			if (c.export == NewsletterExportRaw) != (got.Payload == nil) {
+				t.Errorf("payload = %v for export %s", got.Payload, c.export)
+			}
+		})
+	}
+}
+
+func TestGenerateNewsletterValidation(t *testing.T) {
+	svc := newsletterTestService(t)
+	cases := []struct {
+		name string
+		req  NewsletterRequest
+	}{
+		{"bad kind", NewsletterRequest{Kind: "promo", Subject: "s", Headline: "h"}},
+		{"bad export", NewsletterRequest{Kind: NewsletterAnnouncement, Subject: "s", Headline: "h", Export: "sendgrid"}},
+		{"missing headline", NewsletterRequest{Kind: NewsletterAnnouncement, Subject: "s"}},
+		{"testimonial without quote", NewsletterRequest{Kind: NewsletterTestimonial, Subject: "s", Headline: "h"}},
+		{"unknown merge field", NewsletterRequest{Kind: NewsletterAnnouncement, Subject: "Hi [[nickname]]", Headline: "h"}},
+		{"relative cta", NewsletterRequest{Kind: NewsletterAnnouncement, Subject: "s", Headline: "h", CTAURL: "/x"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := svc.GenerateNewsletter(context.Background(), c.req); !errors.Is(err, ErrNewsletterInvalid) {
+				t.Errorf("err = %v, want ErrNewsletterInvalid", err)
+			}
+		})
+	}
+}
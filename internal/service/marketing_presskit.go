@@ -0,0 +1,264 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"carecompanion/internal/models"
+)
+
+// pressKitLogoVariants and pressKitLogoSizes are the logo renders shipped in
+// the press kit: every variant as SVG plus PNG at web and print sizes.
+var (
+	pressKitLogoVariants = []string{"primary", "white", "dark"}
+	pressKitLogoSizes    = []int{256, 1024}
+)
+
+// brandSwatch is one named brand color.
+type brandSwatch struct {
+	Name string `json:"name"`
+	Hex  string `json:"hex"`
+	RGB  [3]int `json:"rgb"`
+}
+
+// brandSwatches lists the configured brand colors in style-guide order,
+// skipping any left blank.
+func brandSwatches(config *models.BrandConfig) []brandSwatch {
+	named := []struct{ name, hex string }{
+		{"Primary", config.PrimaryColor},
+		{"Primary Light", config.PrimaryLight},
+		{"Primary Dark", config.PrimaryDark},
+		{"Secondary", config.SecondaryColor},
+		{"Secondary Dark", config.SecondaryDark},
+		{"Accent", config.AccentColor},
+		{"Accent Dark", config.AccentDark},
+	}
+	var out []brandSwatch
+	for _, c := range named {
+		hex := strings.ToUpper(strings.TrimSpace(c.hex))
+		if hex == "" {
+			continue
+		}
+		if !strings.HasPrefix(hex, "#") {
+			hex = "#" + hex
+		}
+		r, g, b := hexToRGB(hex)
+		out = append(out, brandSwatch{Name: c.name, Hex: hex, RGB: [3]int{int(r), int(g), int(b)}})
+	}
+	return out
+}
+
+// encodeASE writes swatches as an Adobe Swatch Exchange file (v1.0) with
+// one group named after the app, so the palette imports into Illustrator,
+// InDesign and Photoshop as a single named set.
+func encodeASE(group string, swatches []brandSwatch) []byte {
+	var buf bytes.Buffer
+	be := binary.BigEndian
+	aseString := func(s string) []byte {
+		units := utf16.Encode([]rune(s + "\x00"))
+		b := make([]byte, 2+2*len(units))
+		be.PutUint16(b, uint16(len(units)))
+		for i, u := range units {
+			be.PutUint16(b[2+2*i:], u)
+		}
+		return b
+	}
+	block := func(kind uint16, body []byte) {
+		binary.Write(&buf, be, kind)
+		binary.Write(&buf, be, uint32(len(body)))
+		buf.Write(body)
+	}
+
+	buf.WriteString("ASEF")
+	binary.Write(&buf, be, uint16(1))
+	binary.Write(&buf, be, uint16(0))
+	binary.Write(&buf, be, uint32(len(swatches)+2))
+
+	block(0xC001, aseString(group))
+	for _, sw := range swatches {
+		body := aseString(sw.Name)
+		body = append(body, "RGB "...)
+		for _, v := range sw.RGB {
+			body = be.AppendUint32(body, math.Float32bits(float32(v)/255))
+		}
+		body = be.AppendUint16(body, 2) // normal (non-global, non-spot)
+		block(0x0001, body)
+	}
+	block(0xC002, nil)
+	return buf.Bytes()
+}
+
+// pressBoilerplate is the "about" copy journalists paste into articles,
+// built from the brand config in short, medium and long lengths.
+func pressBoilerplate(config *models.BrandConfig, generated time.Time) string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+	short := config.AppName
+	if config.Tagline != "" {
+		short += " — " + config.Tagline
+	}
+
+	line("%s PRESS BOILERPLATE", strings.ToUpper(config.AppName))
+	line("Generated %s from the current brand configuration.", generated.Format("January 2, 2006"))
+	line("")
+	line("SHORT (one line)")
+	line("%s", short)
+	line("")
+	line("MEDIUM (one paragraph)")
+	medium := short + "."
+	if config.MissionStatement != "" {
+		medium += " " + config.MissionStatement
+	}
+	line("%s", medium)
+	line("")
+	line("LONG")
+	line("%s", medium)
+	line("")
+	for _, f := range models.GetDefaultFeatures() {
+		line("- %s: %s", f.Title, f.Description)
+	}
+	line("")
+	line("MEDIA CONTACT")
+	if config.WebsiteURL != "" {
+		line("Website: %s", config.WebsiteURL)
+	}
+	if config.SupportEmail != "" {
+		line("Email:   %s", config.SupportEmail)
+	}
+	if config.ContactPhone != "" {
+		line("Phone:   %s", config.ContactPhone)
+	}
+	for _, s := range []struct{ label, url string }{
+		{"Facebook", config.FacebookURL}, {"X/Twitter", config.TwitterURL},
+		{"Instagram", config.InstagramURL}, {"LinkedIn", config.LinkedInURL},
+	} {
+		if s.url != "" {
+			line("%-9s %s", s.label+":", s.url)
+		}
+	}
+	if config.CopyrightText != "" {
+		line("")
+		line("%s", config.CopyrightText)
+	}
+	return b.String()
+}
+
+// GeneratePressKit assembles the press kit ZIP on demand from the current
+// brand config: logos, style guide, boilerplate copy, color swatches and
+// any active screenshot assets. Nothing is cached, so the kit always
+// matches the live brand.
+func (s *MarketingService) GeneratePressKit(ctx context.Context) ([]byte, error) {
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	var contents []string
+	add := func(name string, data []byte) error {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		contents = append(contents, name)
+		return nil
+	}
+
+	for _, variant := range pressKitLogoVariants {
+		svg, err := s.GenerateLogoSVG(ctx, variant, 512)
+		if err != nil {
+			return nil, fmt.Errorf("logo %s svg: %w", variant, err)
+		}
+		if err := add(fmt.Sprintf("logos/logo_%s.svg", variant), svg); err != nil {
+			return nil, err
+		}
+		for _, size := range pressKitLogoSizes {
+			png, err := s.GenerateLogoPNG(ctx, variant, size)
+			if err != nil {
+				return nil, fmt.Errorf("logo %s %dpx: %w", variant, size, err)
+			}
+			if err := add(fmt.Sprintf("logos/logo_%s_%d.png", variant, size), png); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sheet, err := s.GenerateLogoSheetPDF(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("logo sheet: %w", err)
+	}
+	if err := add("logos/logo_sheet.pdf", sheet); err != nil {
+		return nil, err
+	}
+
+	guide, err := s.GenerateStyleGuidePDF(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("style guide: %w", err)
+	}
+	if err := add("style_guide.pdf", guide); err != nil {
+		return nil, err
+	}
+
+	if err := add("boilerplate.txt", []byte(pressBoilerplate(config, now))); err != nil {
+		return nil, err
+	}
+
+	swatches := brandSwatches(config)
+	if err := add("colors/brand_colors.ase", encodeASE(config.AppName, swatches)); err != nil {
+		return nil, err
+	}
+	swatchJSON, err := json.MarshalIndent(map[string]interface{}{"brand": config.AppName, "colors": swatches}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := add("colors/brand_colors.json", swatchJSON); err != nil {
+		return nil, err
+	}
+
+	// Screenshots are uploaded assets rather than generated ones; a file
+	// missing from disk is logged and left out instead of failing the kit.
+	shots, err := s.repo.ListMarketingAssets(ctx, models.AssetTypeScreenshot)
+	if err != nil {
+		return nil, fmt.Errorf("list screenshots: %w", err)
+	}
+	for _, a := range shots {
+		if !a.IsActive {
+			continue
+		}
+		data, err := os.ReadFile(a.FilePath)
+		if err != nil {
+			log.Printf("[MARKETING] press kit: skipping screenshot %s: %v", a.Name, err)
+			continue
+		}
+		if err := add("screenshots/"+filepath.Base(a.FilePath), data); err != nil {
+			return nil, err
+		}
+	}
+
+	readme := fmt.Sprintf("%s press kit\nGenerated %s\n\nContents:\n  %s\n\nQuestions: %s\n",
+		config.AppName, now.UTC().Format(time.RFC1123), strings.Join(contents, "\n  "), config.SupportEmail)
+	if err := add("README.txt", []byte(readme)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"carecompanion/internal/models"
+)
+
+type screenshotRepo struct {
+	brandConfigRepo
+	assets []models.MarketingAsset
+}
+
+func (f *screenshotRepo) ListMarketingAssets(ctx context.Context, assetType string) ([]models.MarketingAsset, error) {
+	return f.assets, nil
+}
+
+func TestEncodeASE(t *testing.T) {
+	cfg := &models.BrandConfig{PrimaryColor: "#4f46e5", AccentColor: "F59E0B"}
+	swatches := brandSwatches(cfg)
+	if len(swatches) != 2 || swatches[0].Hex != "#4F46E5" || swatches[1].Hex != "#F59E0B" {
+		t.Fatalf("swatches = %+v", swatches)
+	}
+	if swatches[0].RGB != [3]int{0x4F, 0x46, 0xE5} {
+		t.Errorf("rgb = %v", swatches[0].RGB)
+	}
+
+	ase := encodeASE("Brand", swatches)
+	if string(ase[:4]) != "ASEF" {
+		t.Fatalf("signature = %q", ase[:4])
+	}
+	if n := binary.BigEndian.Uint32(ase[8:12]); n != 4 {
+		t.Errorf("block count = %d, want 4 (group start, 2 colors, group end)", n)
+	}
+	// Walk the blocks to make sure every declared length is consistent.
+	off, blocks := 12, 0
+	for off < len(ase) {
+		if off+6 > len(ase) {
+			t.Fatalf("truncated block header at %d", off)
+		}
+		off += 6 + int(binary.BigEndian.Uint32(ase[off+2:off+6]))
+		blocks++
+	}
+	if off != len(ase) || blocks != 4 {
+		t.Errorf("walked %d blocks ending at %d of %d bytes", blocks, off, len(ase))
+	}
+}
+
+func TestGeneratePressKit(t *testing.T) {
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "home.png")
+	if err := os.WriteFile(shot, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo := &screenshotRepo{
+		brandConfigRepo: brandConfigRepo{cfg: &models.BrandConfig{
+			AppName:          "CareCompanion",
+			Tagline:          "Care, connected",
+			MissionStatement: "We help families see the whole picture.",
+			PrimaryColor:     "#4F46E5",
+			PrimaryDark:      "#3730A3",
+			SecondaryColor:   "#10B981",
+			HeadingFont:      "Inter",
+			BodyFont:         "Inter",
+			SupportEmail:     "press@carecompanion.app",
+		}},
+		assets: []models.MarketingAsset{
+			{Name: "home", FilePath: shot, IsActive: true},
+			{Name: "gone", FilePath: filepath.Join(dir, "missing.png"), IsActive: true},
+			{Name: "old", FilePath: shot, IsActive: false},
+		},
+	}
+	svc := NewMarketingService(repo, dir)
+
+	data, err := svc.GeneratePressKit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	for _, want := range []string{
+		"logos/logo_primary.svg", "logos/logo_dark_1024.png", "logos/logo_sheet.pdf",
+		"style_guide.pdf", "boilerplate.txt", "colors/brand_colors.ase",
+		"colors/brand_colors.json", "screenshots/home.png", "README.txt",
+	} {
+		if files[want] == nil {
+			t.Errorf("press kit missing %s", want)
+		}
+	}
+	if n := len(zr.File); n != 3*3+1+1+1+2+1+1 {
+		t.Errorf("press kit has %d files", n)
+	}
+
+	rc, err := files["boilerplate.txt"].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	var b bytes.Buffer
+	b.ReadFrom(rc)
+	if !strings.Contains(b.String(), "CareCompanion — Care, connected. We help families") {
+		t.Errorf("boilerplate missing medium copy:\n%s", b.String())
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+var (
+	ErrPrintInvalid   = apperr.Validation("invalid print collateral request")
+	ErrNoPricingPlans = errors.New("no active subscription plans to price")
+)
+
+const (
+	// printBleed is how far full-bleed backgrounds extend past the trim
+	// line on every side; 1/8" is what most print shops require.
+	printBleed = 0.125
+	// printSafe is the inset from trim that text and logos stay inside, so
+	// cutter drift never clips them.
+	printSafe = 0.125
+	// maxInkCoverage is the total area coverage (C+M+Y+K, percent) we allow.
+	// Coated stock tolerates ~300%; 280% keeps uncoated runs from smearing.
+	maxInkCoverage = 280
+	// minInkTint drops separations lighter than this (percent): dots that
+	// small don't hold on press and just add noise.
+	minInkTint = 3
+	// maxPricingPlans is how many plan cards fit on the sheet (3 rows of 2).
+	maxPricingPlans = 6
+)
+
+// cmyk is an ink mix, each channel 0-100.
+type cmyk struct{ C, M, Y, K float64 }
+
+// rgbToCMYK is the device-independent naive conversion; good enough to
+// bound ink usage, and the print shop's RIP applies the real profile.
+func rgbToCMYK(r, g, b uint8) cmyk {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	k := 1 - math.Max(rf, math.Max(gf, bf))
+	if k >= 1 {
+		return cmyk{K: 100}
+	}
+	return cmyk{
+		C: (1 - rf - k) / (1 - k) * 100,
+		M: (1 - gf - k) / (1 - k) * 100,
+		Y: (1 - bf - k) / (1 - k) * 100,
+		K: k * 100,
+	}
+}
+
+func (c cmyk) rgb() (int, int, int) {
+	conv := func(v float64) int {
+		return int(math.Round(255 * (1 - v/100) * (1 - c.K/100)))
+	}
+	return conv(c.C), conv(c.M), conv(c.Y)
+}
+
+// printSafeCMYK returns the ink mix for a brand color with total coverage
+// capped and unprintable tints removed. Pure black stays K-only so body
+// text prints on a single plate.
+func printSafeCMYK(hex string) cmyk {
+	c := rgbToCMYK(hexToRGB(hex))
+	for _, v := range []*float64{&c.C, &c.M, &c.Y, &c.K} {
+		if *v < minInkTint {
+			*v = 0
+		}
+	}
+	if total := c.C + c.M + c.Y + c.K; total > maxInkCoverage {
+		// Pull colored inks down proportionally; K carries the darkness.
+		scale := (maxInkCoverage - c.K) / (c.C + c.M + c.Y)
+		c.C, c.M, c.Y = c.C*scale, c.M*scale, c.Y*scale
+	}
+	return c
+}
+
+// printRGB is printSafeCMYK as drawable RGB.
+func printRGB(hex string) (int, int, int) {
+	return printSafeCMYK(hex).rgb()
+}
+
+// newPrintPDF returns a PDF whose media box is the trim size plus bleed,
+// with TrimBox and BleedBox set so it can go to press as-is. Content is
+// drawn in media coordinates: the trim edge is at printBleed.
+func newPrintPDF(trimW, trimH float64) *fpdf.Fpdf {
+	w, h := trimW+2*printBleed, trimH+2*printBleed
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "in",
+		Size:           fpdf.SizeType{Wd: w, Ht: h},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetPageBox("bleed", 0, 0, w, h)
+	pdf.SetPageBox("trim", printBleed, printBleed, trimW, trimH)
+	return pdf
+}
+
+func outputPDF(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// BusinessCardInfo is the person printed on a business card.
+type BusinessCardInfo struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// GenerateBusinessCardPDF creates a two-sided 3.5" x 2" business card.
+// Email and phone default to the brand support contacts.
+func (s *MarketingService) GenerateBusinessCardPDF(ctx context.Context, info BusinessCardInfo) ([]byte, error) {
+	info.Name = strings.TrimSpace(info.Name)
+	if info.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrPrintInvalid)
+	}
+	if len(info.Name) > 40 || len(info.Title) > 50 || len(info.Email) > 60 || len(info.Phone) > 30 {
+		return nil, fmt.Errorf("%w: card text too long to fit", ErrPrintInvalid)
+	}
+
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.Email == "" {
+		info.Email = config.SupportEmail
+	}
+	if info.Phone == "" {
+		info.Phone = config.ContactPhone
+	}
+
+	const trimW, trimH = 3.5, 2.0
+	pdf := newPrintPDF(trimW, trimH)
+	hf, bf := s.loadBrandFonts(ctx, config).applyPDF(pdf)
+	pr, pg, pb := printRGB(config.PrimaryColor)
+	ar, ag, ab := printRGB(config.AccentColor)
+	left := printBleed + printSafe + 0.1
+
+	// Front: accent strip bleeding off the left edge, person details.
+	pdf.AddPage()
+	pdf.SetFillColor(ar, ag, ab)
+	pdf.Rect(0, 0, printBleed+0.12, trimH+2*printBleed, "F")
+
+	pdf.SetFont(hf, "B", 9)
+	pdf.SetTextColor(pr, pg, pb)
+	pdf.SetXY(left, printBleed+printSafe)
+	pdf.CellFormat(trimW-2*printSafe-0.1, 0.2, config.AppName, "", 0, "R", false, 0, "")
+
+	pdf.SetFont(hf, "B", 13)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(left, printBleed+0.6)
+	pdf.Cell(3, 0.25, info.Name)
+	if info.Title != "" {
+		pdf.SetFont(bf, "", 8)
+		pdf.SetTextColor(75, 85, 99)
+		pdf.SetXY(left, printBleed+0.85)
+		pdf.Cell(3, 0.18, info.Title)
+	}
+
+	pdf.SetFont(bf, "", 7.5)
+	pdf.SetTextColor(55, 65, 81)
+	y := printBleed + trimH - printSafe - 0.48
+	for _, line := range []string{info.Email, info.Phone, config.WebsiteURL} {
+		if line == "" {
+			continue
+		}
+		pdf.SetXY(left, y)
+		pdf.Cell(3, 0.16, line)
+		y += 0.16
+	}
+
+	// Back: full-bleed primary with name, tagline and QR.
+	pdf.AddPage()
+	pdf.SetFillColor(pr, pg, pb)
+	pdf.Rect(0, 0, trimW+2*printBleed, trimH+2*printBleed, "F")
+	pdf.SetFont(hf, "B", 16)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(left, printBleed+0.7)
+	pdf.Cell(2.2, 0.3, config.AppName)
+	pdf.SetFont(bf, "", 7.5)
+	pdf.SetXY(left, printBleed+1.0)
+	pdf.MultiCell(2.0, 0.14, config.Tagline, "", "L", false)
+	if err := placeQRInPDF(pdf, config, "business_card", "business_card", printBleed+trimW-printSafe-0.85, printBleed+(trimH-0.85)/2, 0.85); err != nil {
+		log.Printf("[MARKETING] business card QR skipped: %v", err)
+	}
+
+	return outputPDF(pdf)
+}
+
+// GenerateLetterheadPDF creates a US Letter letterhead with a full-bleed
+// brand header and a contact footer; the body is left blank.
+func (s *MarketingService) GenerateLetterheadPDF(ctx context.Context) ([]byte, error) {
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	const trimW, trimH = 8.5, 11.0
+	pdf := newPrintPDF(trimW, trimH)
+	hf, bf := s.loadBrandFonts(ctx, config).applyPDF(pdf)
+	pdf.AddPage()
+	pr, pg, pb := printRGB(config.PrimaryColor)
+	ar, ag, ab := printRGB(config.AccentColor)
+	fullW := trimW + 2*printBleed
+	left := printBleed + 0.75
+
+	// Header band bleeds off the top and sides.
+	pdf.SetFillColor(pr, pg, pb)
+	pdf.Rect(0, 0, fullW, printBleed+0.9, "F")
+	pdf.SetFillColor(ar, ag, ab)
+	pdf.Rect(0, printBleed+0.9, fullW, 0.06, "F")
+
+	pdf.SetFont(hf, "B", 20)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(left, printBleed+0.25)
+	pdf.Cell(5, 0.35, config.AppName)
+	pdf.SetFont(bf, "", 9)
+	pdf.SetXY(left, printBleed+0.58)
+	pdf.Cell(5, 0.2, config.Tagline)
+
+	// Footer: contact line above the trim, inside the safe zone.
+	var contact []string
+	for _, v := range []string{config.WebsiteURL, config.SupportEmail, config.ContactPhone} {
+		if v != "" {
+			contact = append(contact, v)
+		}
+	}
+	pdf.SetDrawColor(pr, pg, pb)
+	pdf.SetLineWidth(0.01)
+	pdf.Line(left, printBleed+trimH-0.85, printBleed+trimW-0.75, printBleed+trimH-0.85)
+	pdf.SetFont(bf, "", 8)
+	pdf.SetTextColor(75, 85, 99)
+	pdf.SetXY(left, printBleed+trimH-0.75)
+	pdf.CellFormat(trimW-1.5, 0.2, strings.Join(contact, "  |  "), "", 0, "C", false, 0, "")
+	if config.CopyrightText != "" {
+		pdf.SetFont(bf, "", 7)
+		pdf.SetXY(left, printBleed+trimH-0.55)
+		pdf.CellFormat(trimW-1.5, 0.2, config.CopyrightText, "", 0, "C", false, 0, "")
+	}
+
+	return outputPDF(pdf)
+}
+
+// formatPlanPrice renders a plan price like "$9.99 / month".
+func formatPlanPrice(p models.PricingPlan) string {
+	if p.PriceCents == 0 {
+		return "Free"
+	}
+	price := fmt.Sprintf("$%d.%02d", p.PriceCents/100, p.PriceCents%100)
+	switch p.BillingInterval {
+	case string(models.BillingIntervalMonthly):
+		return price + " / month"
+	case string(models.BillingIntervalYearly):
+		return price + " / year"
+	case string(models.BillingIntervalLifetime):
+		return price + " one-time"
+	}
+	return price
+}
+
+// GeneratePricingSheetPDF creates a one-page pricing sheet from the live
+// subscription_plans rows, so printed prices never drift from checkout.
+func (s *MarketingService) GeneratePricingSheetPDF(ctx context.Context) ([]byte, error) {
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.repo.ListPricingPlans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list plans: %w", err)
+	}
+	if len(plans) == 0 {
+		return nil, ErrNoPricingPlans
+	}
+	if len(plans) > maxPricingPlans {
+		log.Printf("[MARKETING] pricing sheet shows %d of %d active plans", maxPricingPlans, len(plans))
+		plans = plans[:maxPricingPlans]
+	}
+
+	const trimW, trimH = 8.5, 11.0
+	pdf := newPrintPDF(trimW, trimH)
+	hf, bf := s.loadBrandFonts(ctx, config).applyPDF(pdf)
+	pdf.AddPage()
+	pr, pg, pb := printRGB(config.PrimaryColor)
+	sr, sg, sb := printRGB(config.SecondaryColor)
+	fullW := trimW + 2*printBleed
+	left := printBleed + 0.6
+
+	pdf.SetFillColor(pr, pg, pb)
+	pdf.Rect(0, 0, fullW, printBleed+1.3, "F")
+	pdf.SetFont(hf, "B", 26)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(left, printBleed+0.35)
+	pdf.Cell(7, 0.45, config.AppName+" Plans & Pricing")
+	pdf.SetFont(bf, "", 11)
+	pdf.SetXY(left, printBleed+0.85)
+	pdf.Cell(7, 0.25, config.Tagline)
+
+	const cardW, cardH, gap = 3.5, 2.5, 0.3
+	top := printBleed + 1.7
+	for i, p := range plans {
+		x := left + float64(i%2)*(cardW+gap)
+		y := top + float64(i/2)*(cardH+gap)
+
+		pdf.SetDrawColor(209, 213, 219)
+		pdf.SetLineWidth(0.01)
+		pdf.Rect(x, y, cardW, cardH, "D")
+		pdf.SetFillColor(sr, sg, sb)
+		pdf.Rect(x, y, cardW, 0.08, "F")
+
+		pdf.SetFont(hf, "B", 14)
+		pdf.SetTextColor(pr, pg, pb)
+		pdf.SetXY(x+0.2, y+0.2)
+		pdf.Cell(cardW-0.4, 0.3, p.Name)
+
+		pdf.SetFont(hf, "B", 18)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetXY(x+0.2, y+0.5)
+		pdf.Cell(cardW-0.4, 0.35, formatPlanPrice(p))
+
+		pdf.SetFont(bf, "", 9)
+		pdf.SetTextColor(75, 85, 99)
+		pdf.SetXY(x+0.2, y+0.9)
+		pdf.Cell(cardW-0.4, 0.2, p.Description)
+
+		pdf.SetFont(bf, "", 9)
+		pdf.SetTextColor(55, 65, 81)
+		fy := y + 1.2
+		for j, f := range p.Features {
+			if j == 5 {
+				break
+			}
+			pdf.SetXY(x+0.2, fy)
+			pdf.Cell(cardW-0.4, 0.2, "- "+f)
+			fy += 0.22
+		}
+	}
+
+	pdf.SetFont(bf, "", 8)
+	pdf.SetTextColor(107, 114, 128)
+	pdf.SetXY(left, printBleed+trimH-0.7)
+	pdf.Cell(7.3, 0.2, fmt.Sprintf("Prices in USD as of %s. Subscribe at %s", time.Now().Format("January 2, 2006"), config.WebsiteURL))
+	if config.CopyrightText != "" {
+		pdf.SetXY(left, printBleed+trimH-0.5)
+		pdf.Cell(7.3, 0.2, config.CopyrightText)
+	}
+
+	return outputPDF(pdf)
+}
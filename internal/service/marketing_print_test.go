@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"carecompanion/internal/models"
+)
+
+type pricingRepo struct {
+	brandConfigRepo
+	plans []models.PricingPlan
+}
+
+func (f *pricingRepo) ListPricingPlans(ctx context.Context) ([]models.PricingPlan, error) {
+	return f.plans, nil
+}
+
+func TestPrintSafeCMYK(t *testing.T) {
+	cases := []struct {
+		hex  string
+		want cmyk
+	}{
+		{"#000000", cmyk{K: 100}},
+		{"#FFFFFF", cmyk{}},
+		{"#FF0000", cmyk{M: 100, Y: 100}},
+		// 2% cyan tint is below what holds on press
+		{"#FAFFFF", cmyk{}},
+	}
+	for _, c := range cases {
+		if got := printSafeCMYK(c.hex); got != c.want {
+			t.Errorf("printSafeCMYK(%s) = %+v, want %+v", c.hex, got, c.want)
+		}
+	}
+
+	// Near-black navy would need ~300% ink naively.
+	got := printSafeCMYK("#00000A")
+	if total := got.C + got.M + got.Y + got.K; total > maxInkCoverage+0.001 {
+		t.Errorf("total ink %.1f exceeds %d", total, maxInkCoverage)
+	}
+}
+
+func TestFormatPlanPrice(t *testing.T) {
+	cases := []struct {
+		plan models.PricingPlan
+		want string
+	}{
+		{models.PricingPlan{PriceCents: 0, BillingInterval: "monthly"}, "Free"},
+		{models.PricingPlan{PriceCents: 999, BillingInterval: "monthly"}, "$9.99 / month"},
+		{models.PricingPlan{PriceCents: 19990, BillingInterval: "yearly"}, "$199.90 / year"},
+		{models.PricingPlan{PriceCents: 4900, BillingInterval: "lifetime"}, "$49.00 one-time"},
+	}
+	for _, c := range cases {
+		if got := formatPlanPrice(c.plan); got != c.want {
+			t.Errorf("formatPlanPrice(%d %s) = %q, want %q", c.plan.PriceCents, c.plan.BillingInterval, got, c.want)
+		}
+	}
+}
+
+func TestGeneratePricingSheetPDF(t *testing.T) {
+	cfg := &models.BrandConfig{AppName: "CareCompanion", PrimaryColor: "#4F46E5", SecondaryColor: "#10B981"}
+	svc := NewMarketingService(&pricingRepo{brandConfigRepo: brandConfigRepo{cfg: cfg}}, t.TempDir())
+	if _, err := svc.GeneratePricingSheetPDF(context.Background()); !errors.Is(err, ErrNoPricingPlans) {
+		t.Fatalf("no plans: err = %v, want ErrNoPricingPlans", err)
+	}
+
+	svc = NewMarketingService(&pricingRepo{
+		brandConfigRepo: brandConfigRepo{cfg: cfg},
+		plans: []models.PricingPlan{
+			{Name: "Basic", PriceCents: 999, BillingInterval: "monthly", Features: []string{"Unlimited logging"}},
+		},
+	}, t.TempDir())
+	pdf, err := svc.GeneratePricingSheetPDF(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, box := range []string{"/TrimBox", "/BleedBox"} {
+		if !bytes.Contains(pdf, []byte(box)) {
+			t.Errorf("pricing sheet missing %s", box)
+		}
+	}
+}
+
+func TestGenerateBusinessCardRequiresName(t *testing.T) {
+	svc := NewMarketingService(&brandConfigRepo{cfg: &models.BrandConfig{}}, t.TempDir())
+	if _, err := svc.GenerateBusinessCardPDF(context.Background(), BusinessCardInfo{Name: "  "}); !errors.Is(err, ErrPrintInvalid) {
+		t.Errorf("err = %v, want ErrPrintInvalid", err)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// ErrQRInvalid is returned for unusable QR targets or options.
+var ErrQRInvalid = apperr.Validation("invalid QR code request")
+
+const (
+	defaultQRCampaign = "brand_materials"
+	minQRSizePx       = 64
+	maxQRSizePx       = 2048
+	// minQRContrast is the foreground/background contrast ratio below which
+	// we fall back to near-black. Phone scanners are much less forgiving of
+	// low contrast than people are.
+	minQRContrast = 4.5
+)
+
+// UTMParams are the analytics tags appended to QR target URLs. Empty
+// fields are left off.
+type UTMParams struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Content  string
+}
+
+// BuildQRTargetURL validates base (absolute http/https) and sets the
+// non-empty UTM parameters on it, replacing any existing values.
+func BuildQRTargetURL(base string, utm UTMParams) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(base))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: target must be an absolute http(s) URL", ErrQRInvalid)
+	}
+	q := u.Query()
+	for k, v := range map[string]string{
+		"utm_source":   utm.Source,
+		"utm_medium":   utm.Medium,
+		"utm_campaign": utm.Campaign,
+		"utm_content":  utm.Content,
+	} {
+		if v = strings.TrimSpace(v); v != "" {
+			q.Set(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// relativeLuminance is the WCAG relative luminance of an sRGB color.
+func relativeLuminance(c color.RGBA) float64 {
+	lin := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(c.R) + 0.7152*lin(c.G) + 0.0722*lin(c.B)
+}
+
+// contrastRatio is the WCAG contrast ratio between two colors (1–21).
+func contrastRatio(a, b color.RGBA) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// qrForeground picks the module color for a QR on bg: the preferred brand
+// color if it scans reliably, otherwise near-black.
+func qrForeground(preferred string, bg color.RGBA) color.RGBA {
+	if preferred != "" {
+		fg := hexToColor(preferred)
+		if contrastRatio(fg, bg) >= minQRContrast {
+			return fg
+		}
+	}
+	return color.RGBA{17, 24, 39, 255}
+}
+
+// qrTargetFor resolves the configured landing URL (QR target, else the
+// website) and tags it for one placement.
+func qrTargetFor(config *models.BrandConfig, source, medium, content string) (string, error) {
+	base := config.QRTargetURL
+	if base == "" {
+		base = config.WebsiteURL
+	}
+	campaign := config.QRUTMCampaign
+	if campaign == "" {
+		campaign = defaultQRCampaign
+	}
+	return BuildQRTargetURL(base, UTMParams{Source: source, Medium: medium, Campaign: campaign, Content: content})
+}
+
+// renderQR encodes content as a size×size QR image.
+func renderQR(content string, size int, fg, bg color.Color) (image.Image, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQRInvalid, err)
+	}
+	q.ForegroundColor = fg
+	q.BackgroundColor = bg
+	return q.Image(size), nil
+}
+
+// QRRequest is the input to GenerateQRCode. TargetURL overrides the brand
+// config's QR target; zero UTM fields fall back to brand defaults.
+type QRRequest struct {
+	TargetURL  string
+	UTM        UTMParams
+	SizePx     int
+	Foreground string // hex; empty = brand PrimaryDark
+	Background string // hex; empty = white
+}
+
+// GenerateQRCode renders a standalone brand-colored QR code as PNG and
+// returns the exact URL it encodes.
+func (s *MarketingService) GenerateQRCode(ctx context.Context, req QRRequest) ([]byte, string, error) {
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	size := req.SizePx
+	if size == 0 {
+		size = 512
+	}
+	if size < minQRSizePx || size > maxQRSizePx {
+		return nil, "", fmt.Errorf("%w: size must be %d-%d", ErrQRInvalid, minQRSizePx, maxQRSizePx)
+	}
+
+	base := req.TargetURL
+	if base == "" {
+		base = config.QRTargetURL
+	}
+	if base == "" {
+		base = config.WebsiteURL
+	}
+	utm := req.UTM
+	if utm.Campaign == "" {
+		utm.Campaign = config.QRUTMCampaign
+		if utm.Campaign == "" {
+			utm.Campaign = defaultQRCampaign
+		}
+	}
+	if utm.Source == "" {
+		utm.Source = "qr"
+	}
+	target, err := BuildQRTargetURL(base, utm)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bg := color.RGBA{255, 255, 255, 255}
+	if req.Background != "" {
+		bg = hexToColor(req.Background)
+	}
+	preferred := req.Foreground
+	if preferred == "" {
+		preferred = config.PrimaryDark
+	}
+	img, err := renderQR(target, size, qrForeground(preferred, bg), bg)
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), target, nil
+}
+
+// placeQRInPDF renders a QR for one placement and draws it at (x, y) with
+// side length size (in the document's unit) on a white quiet zone.
+// Failures are returned so callers can decide whether to skip the code.
+func placeQRInPDF(pdf *fpdf.Fpdf, config *models.BrandConfig, name, source string, x, y, size float64) error {
+	target, err := qrTargetFor(config, source, "print", name)
+	if err != nil {
+		return err
+	}
+	white := color.RGBA{255, 255, 255, 255}
+	img, err := renderQR(target, 600, qrForeground(config.PrimaryDark, white), white)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("qr_"+name, opts, &buf)
+	pdf.SetFillColor(255, 255, 255)
+	pdf.Rect(x-0.05, y-0.05, size+0.1, size+0.1, "F")
+	pdf.ImageOptions("qr_"+name, x, y, size, size, false, opts, 0, "")
+	return pdf.Error()
+}
+
+// drawQROnContext draws a QR for a social placement with its top-left at
+// (x, y), on a white rounded backing so it reads on any background.
+func drawQROnContext(dc *gg.Context, config *models.BrandConfig, platform, content string, x, y, size int) error {
+	target, err := qrTargetFor(config, platform, "social", content)
+	if err != nil {
+		return err
+	}
+	white := color.RGBA{255, 255, 255, 255}
+	img, err := renderQR(target, size, qrForeground(config.PrimaryDark, white), white)
+	if err != nil {
+		return err
+	}
+	pad := float64(size) * 0.06
+	dc.SetColor(white)
+	dc.DrawRoundedRectangle(float64(x)-pad, float64(y)-pad, float64(size)+2*pad, float64(size)+2*pad, pad)
+	dc.Fill()
+	dc.DrawImage(img, x, y)
+	return nil
+}
+
+// qrSlug turns an asset name into a utm_content value.
+func qrSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+	return strings.Join(words, "_")
+}
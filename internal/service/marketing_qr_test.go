@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"image/color"
+	"net/url"
+	"testing"
+)
+
+func TestBuildQRTargetURL(t *testing.T) {
+	cases := []struct {
+		name    string
+		base    string
+		utm     UTMParams
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "all params",
+			base: "https://carecompanion.app/start",
+			utm:  UTMParams{Source: "brochure", Medium: "print", Campaign: "spring", Content: "tri_fold"},
+			want: map[string]string{"utm_source": "brochure", "utm_medium": "print", "utm_campaign": "spring", "utm_content": "tri_fold"},
+		},
+		{
+			name: "existing query kept, utm overridden",
+			base: "https://carecompanion.app/?ref=x&utm_source=old",
+			utm:  UTMParams{Source: "qr"},
+			want: map[string]string{"ref": "x", "utm_source": "qr"},
+		},
+		{
+			name: "empty params omitted",
+			base: "http://example.com",
+			utm:  UTMParams{Medium: "social"},
+			want: map[string]string{"utm_medium": "social"},
+		},
+		{name: "relative rejected", base: "/start", wantErr: true},
+		{name: "non-http rejected", base: "javascript:alert(1)", wantErr: true},
+		{name: "empty rejected", base: "", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := BuildQRTargetURL(c.base, c.utm)
+			if c.wantErr {
+				if !errors.Is(err, ErrQRInvalid) {
+					t.Fatalf("err = %v, want ErrQRInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			u, _ := url.Parse(got)
+			q := u.Query()
+			if len(q) != len(c.want) {
+				t.Errorf("query %v has %d params, want %d", q, len(q), len(c.want))
+			}
+			for k, v := range c.want {
+				if q.Get(k) != v {
+					t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+				}
+			}
+		})
+	}
+}
+
+func TestQRForeground(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	near := color.RGBA{17, 24, 39, 255}
+
+	if got := qrForeground("#1E3A8A", white); got != hexToColor("#1E3A8A") {
+		t.Errorf("dark brand color should be kept, got %v", got)
+	}
+	if got := qrForeground("#FDE68A", white); got != near {
+		t.Errorf("light brand color should fall back, got %v", got)
+	}
+	if got := qrForeground("", white); got != near {
+		t.Errorf("empty color should fall back, got %v", got)
+	}
+}
+
+func TestRenderQRSize(t *testing.T) {
+	img, err := renderQR("https://carecompanion.app/?utm_source=qr", 300, color.Black, color.White)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Errorf("bounds = %v, want 300x300", b)
+	}
+}
+
+func TestQRSlug(t *testing.T) {
+	if got := qrSlug("Instagram Post (Square) Default"); got != "instagram_post_square_default" {
+		t.Errorf("qrSlug = %q", got)
+	}
+}
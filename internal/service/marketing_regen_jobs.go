@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+// Asset regeneration job states.
+const (
+	AssetJobQueued    = "queued"
+	AssetJobRunning   = "running"
+	AssetJobCompleted = "completed"
+	// AssetJobFailed means at least one asset failed; the others were
+	// still generated and saved.
+	AssetJobFailed = "failed"
+)
+
+const (
+	// assetRegenConcurrency bounds parallel generators. PDF/PNG rendering is
+	// CPU-bound, so a handful of workers is enough to overlap the DB/disk
+	// writes without starving request handling.
+	assetRegenConcurrency = 4
+	// assetRegenTimeout caps a whole background run.
+	assetRegenTimeout = 10 * time.Minute
+	// maxTrackedAssetJobs is how many finished jobs stay queryable.
+	maxTrackedAssetJobs = 20
+)
+
+var (
+	ErrAssetJobNotFound     = apperr.NotFound("asset regeneration job not found")
+	ErrAssetRegenInProgress = errors.New("asset regeneration already in progress")
+)
+
+// AssetRegenResult is the outcome for one generated asset.
+type AssetRegenResult struct {
+	Name       string    `json:"name"`
+	AssetType  string    `json:"asset_type"`
+	Status     string    `json:"status"` // queued | running | completed | failed
+	Error      string    `json:"error,omitempty"`
+	AssetID    uuid.UUID `json:"asset_id,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// AssetRegenJob tracks one "regenerate all assets" run.
+type AssetRegenJob struct {
+	ID          uuid.UUID          `json:"id"`
+	Status      string             `json:"status"`
+	RequestedBy uuid.UUID          `json:"requested_by"`
+	Total       int                `json:"total"`
+	Done        int                `json:"done"`
+	Failed      int                `json:"failed"`
+	Percent     int                `json:"percent"`
+	FailedStep  string             `json:"failed_step,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Assets      []AssetRegenResult `json:"assets"`
+	CreatedAt   time.Time          `json:"created_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	FinishedAt  *time.Time         `json:"finished_at,omitempty"`
+}
+
+func (j *AssetRegenJob) clone() *AssetRegenJob {
+	c := *j
+	c.Assets = append([]AssetRegenResult(nil), j.Assets...)
+	return &c
+}
+
+// assetJobTracker holds regeneration jobs in memory. Jobs are admin-only
+// and short-lived, so losing history on restart is acceptable; the saved
+// assets themselves are durable.
+type assetJobTracker struct {
+	mu     sync.Mutex
+	jobs   map[uuid.UUID]*AssetRegenJob
+	order  []uuid.UUID
+	active uuid.UUID
+}
+
+func newAssetJobTracker() *assetJobTracker {
+	return &assetJobTracker{jobs: make(map[uuid.UUID]*AssetRegenJob)}
+}
+
+// regenTask is one asset to generate and save.
+type regenTask struct {
+	name          string
+	assetType     string
+	format        string
+	width, height int
+	generate      func(ctx context.Context) ([]byte, error)
+}
+
+// regenTasks lists every asset RegenerateAllAssets produces.
+func (s *MarketingService) regenTasks(ctx context.Context) ([]regenTask, error) {
+	tasks := []regenTask{
+		{"Single Page Brochure", models.AssetTypeBrochure, models.FormatPDF, 612, 792, s.GenerateSinglePageBrochure},
+		{"Tri-Fold Brochure", models.AssetTypeBrochure, models.FormatPDF, 792, 612, s.GenerateTriFoldBrochure},
+		{"Brand Style Guide", models.AssetTypeStyleGuide, models.FormatPDF, 612, 792, s.GenerateStyleGuidePDF},
+		{"Logo Sheet", models.AssetTypeLogo, models.FormatPDF, 612, 792, s.GenerateLogoSheetPDF},
+		{"Letterhead", models.AssetTypePrintCollateral, models.FormatPDF, 630, 810, s.GenerateLetterheadPDF},
+		{"Pricing Sheet", models.AssetTypePrintCollateral, models.FormatPDF, 630, 810, s.GeneratePricingSheetPDF},
+	}
+
+	for _, variant := range []string{"primary", "white", "dark"} {
+		variant := variant
+		for _, size := range []int{64, 128, 256, 512} {
+			size := size
+			tasks = append(tasks, regenTask{
+				name:      fmt.Sprintf("Logo %s %dx%d", strings.Title(variant), size, size),
+				assetType: models.AssetTypeLogo, format: models.FormatPNG, width: size, height: size,
+				generate: func(ctx context.Context) ([]byte, error) { return s.GenerateLogoPNG(ctx, variant, size) },
+			})
+		}
+		// SVG (scalable, just one size reference)
+		tasks = append(tasks, regenTask{
+			name:      fmt.Sprintf("Logo %s SVG", strings.Title(variant)),
+			assetType: models.AssetTypeLogo, format: models.FormatSVG, width: 512, height: 512,
+			generate: func(ctx context.Context) ([]byte, error) { return s.GenerateLogoSVG(ctx, variant, 512) },
+		})
+	}
+
+	templates, err := s.repo.ListSocialTemplates(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list social templates: %w", err)
+	}
+	tagline := ""
+	if config, err := s.repo.GetBrandConfig(ctx); err == nil && config != nil {
+		tagline = config.Tagline
+	}
+	for _, tmpl := range templates {
+		tmpl := tmpl
+		tasks = append(tasks, regenTask{
+			name:      tmpl.Name + " Default",
+			assetType: models.AssetTypeSocialGraphic, format: models.FormatPNG, width: tmpl.WidthPx, height: tmpl.HeightPx,
+			generate: func(ctx context.Context) ([]byte, error) {
+				return s.GenerateSocialGraphic(ctx, tmpl, tagline, "Track. Discover. Coordinate.")
+			},
+		})
+	}
+	return tasks, nil
+}
+
+// runRegenTasks generates and saves tasks with bounded concurrency,
+// calling onStart/onDone around each. It never stops early: one broken
+// template shouldn't block the logos.
+func (s *MarketingService) runRegenTasks(ctx context.Context, tasks []regenTask, onStart func(i int), onDone func(i int, asset *models.MarketingAsset, dur time.Duration, err error)) {
+	sem := make(chan struct{}, assetRegenConcurrency)
+	var wg sync.WaitGroup
+	for i := range tasks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			t := tasks[i]
+			onStart(i)
+			start := time.Now()
+			var asset *models.MarketingAsset
+			err := ctx.Err()
+			if err == nil {
+				var content []byte
+				content, err = t.generate(ctx)
+				if err == nil {
+					asset, err = s.SaveAsset(ctx, t.name, t.assetType, t.format, content, t.width, t.height)
+				}
+			}
+			onDone(i, asset, time.Since(start), err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// RegenerateAllAssets regenerates all marketing assets synchronously and
+// returns every per-asset failure joined. Prefer StartRegenerateAll from
+// request handlers.
+func (s *MarketingService) RegenerateAllAssets(ctx context.Context) error {
+	tasks, err := s.regenTasks(ctx)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	var errs []error
+	s.runRegenTasks(ctx, tasks, func(int) {}, func(i int, _ *models.MarketingAsset, _ time.Duration, err error) {
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("%s: %w", tasks[i].name, err))
+			mu.Unlock()
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// StartRegenerateAll queues a background regeneration and returns the job
+// immediately. Only one run may be active; a second call returns the
+// running job with ErrAssetRegenInProgress.
+func (s *MarketingService) StartRegenerateAll(requestedBy uuid.UUID) (*AssetRegenJob, error) {
+	t := s.jobs
+	t.mu.Lock()
+	if active, ok := t.jobs[t.active]; ok && (active.Status == AssetJobQueued || active.Status == AssetJobRunning) {
+		snap := active.clone()
+		t.mu.Unlock()
+		return snap, ErrAssetRegenInProgress
+	}
+	job := &AssetRegenJob{
+		ID:          uuid.New(),
+		Status:      AssetJobQueued,
+		RequestedBy: requestedBy,
+		Assets:      []AssetRegenResult{},
+		CreatedAt:   time.Now(),
+	}
+	t.jobs[job.ID] = job
+	t.order = append(t.order, job.ID)
+	t.active = job.ID
+	for len(t.order) > maxTrackedAssetJobs {
+		delete(t.jobs, t.order[0])
+		t.order = t.order[1:]
+	}
+	snap := job.clone()
+	t.mu.Unlock()
+
+	go s.runRegenJob(job)
+	return snap, nil
+}
+
+func (s *MarketingService) runRegenJob(job *AssetRegenJob) {
+	// Detached from the request: the admin's HTTP call has already returned.
+	ctx, cancel := context.WithTimeout(context.Background(), assetRegenTimeout)
+	defer cancel()
+	t := s.jobs
+
+	finish := func(status, errMsg string) {
+		now := time.Now()
+		t.mu.Lock()
+		job.Status = status
+		job.Error = errMsg
+		job.FinishedAt = &now
+		log.Printf("[MARKETING] asset regeneration %s %s: %d/%d done, %d failed", job.ID, status, job.Done, job.Total, job.Failed)
+		t.mu.Unlock()
+	}
+
+	tasks, err := s.regenTasks(ctx)
+	if err != nil {
+		t.mu.Lock()
+		job.FailedStep = "plan"
+		t.mu.Unlock()
+		finish(AssetJobFailed, err.Error())
+		return
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	job.Status = AssetJobRunning
+	job.StartedAt = &now
+	job.Total = len(tasks)
+	for _, task := range tasks {
+		job.Assets = append(job.Assets, AssetRegenResult{Name: task.name, AssetType: task.assetType, Status: AssetJobQueued})
+	}
+	t.mu.Unlock()
+
+	s.runRegenTasks(ctx, tasks,
+		func(i int) {
+			t.mu.Lock()
+			job.Assets[i].Status = AssetJobRunning
+			t.mu.Unlock()
+		},
+		func(i int, asset *models.MarketingAsset, dur time.Duration, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			res := &job.Assets[i]
+			res.DurationMs = dur.Milliseconds()
+			job.Done++
+			if err != nil {
+				res.Status = AssetJobFailed
+				res.Error = err.Error()
+				job.Failed++
+				if job.FailedStep == "" {
+					job.FailedStep = res.Name
+				}
+			} else {
+				res.Status = AssetJobCompleted
+				res.AssetID = asset.ID
+			}
+			job.Percent = job.Done * 100 / job.Total
+		})
+
+	if job.Failed > 0 {
+		finish(AssetJobFailed, fmt.Sprintf("%d of %d assets failed", job.Failed, job.Total))
+		return
+	}
+	finish(AssetJobCompleted, "")
+}
+
+// GetRegenJob returns a snapshot of a regeneration job.
+func (s *MarketingService) GetRegenJob(id uuid.UUID) (*AssetRegenJob, error) {
+	s.jobs.mu.Lock()
+	defer s.jobs.mu.Unlock()
+	job, ok := s.jobs.jobs[id]
+	if !ok {
+		return nil, ErrAssetJobNotFound
+	}
+	return job.clone(), nil
+}
+
+// LatestRegenJob returns the most recently started job, if any, so the
+// materials page can resume progress after a reload.
+func (s *MarketingService) LatestRegenJob() (*AssetRegenJob, error) {
+	s.jobs.mu.Lock()
+	defer s.jobs.mu.Unlock()
+	job, ok := s.jobs.jobs[s.jobs.active]
+	if !ok {
+		return nil, ErrAssetJobNotFound
+	}
+	return job.clone(), nil
+}
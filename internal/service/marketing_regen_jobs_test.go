@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakeMarketingRepo struct {
+	repository.MarketingRepository
+	mu    sync.Mutex
+	saved []string
+}
+
+func (f *fakeMarketingRepo) GetMarketingAssetByName(ctx context.Context, name string) (*models.MarketingAsset, error) {
+	return nil, nil
+}
+
+func (f *fakeMarketingRepo) CreateMarketingAsset(ctx context.Context, a *models.MarketingAsset) error {
+	f.mu.Lock()
+	f.saved = append(f.saved, a.Name)
+	f.mu.Unlock()
+	return nil
+}
+
+func TestRunRegenTasksContinuesPastFailures(t *testing.T) {
+	repo := &fakeMarketingRepo{}
+	svc := NewMarketingService(repo, t.TempDir())
+
+	var inFlight, peak int32
+	gen := func(fail bool) func(context.Context) ([]byte, error) {
+		return func(context.Context) ([]byte, error) {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			if fail {
+				return nil, errors.New("boom")
+			}
+			return []byte("x"), nil
+		}
+	}
+
+	var tasks []regenTask
+	for i := 0; i < 10; i++ {
+		tasks = append(tasks, regenTask{
+			name: "Asset " + string(rune('A'+i)), assetType: models.AssetTypeLogo, format: models.FormatPNG,
+			width: 1, height: 1, generate: gen(i == 3),
+		})
+	}
+
+	var mu sync.Mutex
+	var failed []string
+	started := 0
+	svc.runRegenTasks(context.Background(), tasks,
+		func(int) { mu.Lock(); started++; mu.Unlock() },
+		func(i int, _ *models.MarketingAsset, _ time.Duration, err error) {
+			if err != nil {
+				mu.Lock()
+				failed = append(failed, tasks[i].name)
+				mu.Unlock()
+			}
+		})
+
+	if started != len(tasks) {
+		t.Errorf("started %d tasks, want %d", started, len(tasks))
+	}
+	if len(failed) != 1 || failed[0] != "Asset D" {
+		t.Errorf("failed = %v, want [Asset D]", failed)
+	}
+	if len(repo.saved) != len(tasks)-1 {
+		t.Errorf("saved %d assets, want %d", len(repo.saved), len(tasks)-1)
+	}
+	if peak > assetRegenConcurrency {
+		t.Errorf("peak concurrency %d exceeds limit %d", peak, assetRegenConcurrency)
+	}
+}
+
+func TestGetRegenJobNotFound(t *testing.T) {
+	svc := NewMarketingService(&fakeMarketingRepo{}, t.TempDir())
+	if _, err := svc.LatestRegenJob(); !errors.Is(err, ErrAssetJobNotFound) {
+		t.Errorf("LatestRegenJob on fresh service: err = %v, want ErrAssetJobNotFound", err)
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // raw screenshots may be uploaded as JPEG
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+)
+
+var (
+	ErrScreenshotInvalid  = apperr.Validation("invalid screenshot")
+	ErrScreenshotNotFound = apperr.NotFound("screenshot not found")
+)
+
+const (
+	maxScreenshotBytes      = 15 << 20
+	minScreenshotEdge       = 320
+	maxListingShots         = 10
+	maxListingCaptionRunes  = 80
+	listingCaptionBandRatio = 0.2
+)
+
+// StoreListingTarget is one required screenshot size for a store listing.
+type StoreListingTarget struct {
+	ID     string `json:"id"`
+	Store  string `json:"store"`
+	Label  string `json:"label"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Tablet bool   `json:"tablet"`
+}
+
+// storeListingTargets are the portrait sizes App Store Connect and Google
+// Play accept; one set per target covers every required device class.
+var storeListingTargets = []StoreListingTarget{
+	{ID: "ios_6_9", Store: "app_store", Label: `iPhone 6.9"`, Width: 1320, Height: 2868},
+	{ID: "ios_6_5", Store: "app_store", Label: `iPhone 6.5"`, Width: 1284, Height: 2778},
+	{ID: "ipad_13", Store: "app_store", Label: `iPad 13"`, Width: 2064, Height: 2752, Tablet: true},
+	{ID: "play_phone", Store: "play_store", Label: "Android phone", Width: 1080, Height: 1920},
+	{ID: "play_tablet_10", Store: "play_store", Label: `Android 10" tablet`, Width: 1600, Height: 2560, Tablet: true},
+}
+
+// StoreListingTargets lists the sizes the listing generator can produce.
+func StoreListingTargets() []StoreListingTarget {
+	return append([]StoreListingTarget(nil), storeListingTargets...)
+}
+
+// UploadScreenshot stores a raw app screenshot for framing. The image is
+// re-encoded as PNG, which also drops any EXIF metadata from device
+// captures. Uploading under an existing label replaces that screenshot.
+func (s *MarketingService) UploadScreenshot(ctx context.Context, label string, body io.Reader) (*models.MarketingAsset, error) {
+	label = strings.TrimSpace(label)
+	if label == "" || len(label) > 60 || qrSlug(label) == "" {
+		return nil, fmt.Errorf("%w: label is required (max 60 characters, letters or digits)", ErrScreenshotInvalid)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxScreenshotBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxScreenshotBytes {
+		return nil, fmt.Errorf("%w: file exceeds %d MB", ErrScreenshotInvalid, maxScreenshotBytes>>20)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg") {
+		return nil, fmt.Errorf("%w: file must be a PNG or JPEG image", ErrScreenshotInvalid)
+	}
+	// Scan the upload as received; what gets stored is a re-encode.
+	scanStatus, err := s.scan.Check(ctx, ScanTarget{
+		Source:      ScanSourceMarketingScreenshot,
+		Filename:    label + "." + format,
+		ContentType: "image/" + format,
+		Size:        int64(len(data)),
+		Open:        bytesOpener(data),
+	})
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if b.Dx() < minScreenshotEdge || b.Dy() < minScreenshotEdge {
+		return nil, fmt.Errorf("%w: image must be at least %dpx on each side", ErrScreenshotInvalid, minScreenshotEdge)
+	}
+	if b.Dy() <= b.Dx() {
+		return nil, fmt.Errorf("%w: screenshots must be portrait", ErrScreenshotInvalid)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	asset, err := s.saveAsset(ctx, "Screenshot "+label, models.AssetTypeScreenshot, models.FormatPNG, buf.Bytes(), b.Dx(), b.Dy(), false)
+	if err != nil {
+		return nil, err
+	}
+	asset.ScanStatus = scanStatus
+	if s.images != nil {
+		set, err := s.images.Process(ctx, ImageUpload{
+			OwnerType: ImageOwnerMarketingAsset,
+			OwnerID:   asset.ID,
+			Filename:  label + "." + format,
+			Body:      bytes.NewReader(data),
+			Scanned:   true,
+		})
+		if err != nil {
+			// The PNG is what listings are framed from; variants only
+			// serve the website, so a failure here doesn't fail the upload.
+			log.Printf("[MARKETING] screenshot %s variants: %v", asset.ID, err)
+		} else {
+			asset.Variants = set.Variants
+		}
+	}
+	return asset, nil
+}
+
+// ListScreenshots returns the active raw screenshots.
+func (s *MarketingService) ListScreenshots(ctx context.Context) ([]models.MarketingAsset, error) {
+	shots, err := s.repo.ListMarketingAssets(ctx, models.AssetTypeScreenshot)
+	if err != nil || s.images == nil {
+		return shots, err
+	}
+	for i := range shots {
+		if set, err := s.images.Latest(ctx, ImageOwnerMarketingAsset, shots[i].ID); err == nil {
+			shots[i].Variants = set.Variants
+		}
+	}
+	return shots, nil
+}
+
+// screenshotAsset loads an active screenshot asset by ID.
+func (s *MarketingService) screenshotAsset(ctx context.Context, id uuid.UUID) (*models.MarketingAsset, error) {
+	a, err := s.repo.GetMarketingAsset(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScreenshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.AssetType != models.AssetTypeScreenshot || !a.IsActive {
+		return nil, ErrScreenshotNotFound
+	}
+	return a, nil
+}
+
+// DeleteScreenshot deactivates a raw screenshot so it leaves the listing
+// picker and the press kit.
+func (s *MarketingService) DeleteScreenshot(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.screenshotAsset(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteMarketingAsset(ctx, id); err != nil {
+		return err
+	}
+	if s.images != nil {
+		if err := s.images.DeleteOwner(ctx, ImageOwnerMarketingAsset, id); err != nil {
+			log.Printf("[MARKETING] screenshot %s variants: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// ListingShot is one screenshot in a listing set, in display order.
+type ListingShot struct {
+	ScreenshotID uuid.UUID `json:"screenshotId"`
+	Caption      string    `json:"caption"`
+}
+
+// StoreListingRequest selects screenshots and target sizes. Empty Targets
+// means every size.
+type StoreListingRequest struct {
+	Shots   []ListingShot `json:"shots"`
+	Targets []string      `json:"targets"`
+}
+
+func (req StoreListingRequest) targets() ([]StoreListingTarget, error) {
+	if len(req.Targets) == 0 {
+		return storeListingTargets, nil
+	}
+	var out []StoreListingTarget
+	for _, id := range req.Targets {
+		found := false
+		for _, t := range storeListingTargets {
+			if t.ID == id {
+				out = append(out, t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unknown target %q", ErrScreenshotInvalid, id)
+		}
+	}
+	return out, nil
+}
+
+// GenerateStoreListingSet frames every selected screenshot at every target
+// size and returns the set as a ZIP laid out store/target/NN_caption.png,
+// ready to drag into App Store Connect or the Play Console.
+func (s *MarketingService) GenerateStoreListingSet(ctx context.Context, req StoreListingRequest) ([]byte, error) {
+	if len(req.Shots) == 0 || len(req.Shots) > maxListingShots {
+		return nil, fmt.Errorf("%w: choose 1 to %d screenshots", ErrScreenshotInvalid, maxListingShots)
+	}
+	targets, err := req.targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, shot := range req.Shots {
+		if len([]rune(strings.TrimSpace(shot.Caption))) > maxListingCaptionRunes {
+			return nil, fmt.Errorf("%w: captions are limited to %d characters", ErrScreenshotInvalid, maxListingCaptionRunes)
+		}
+	}
+
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fonts := s.loadBrandFonts(ctx, config)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	now := time.Now()
+	for i, shot := range req.Shots {
+		a, err := s.screenshotAsset(ctx, shot.ScreenshotID)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := os.ReadFile(a.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("read screenshot %s: %w", a.Name, err)
+		}
+		img, _, err := image.Decode(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("decode screenshot %s: %w", a.Name, err)
+		}
+		caption := strings.TrimSpace(shot.Caption)
+		name := fmt.Sprintf("%02d_%s.png", i+1, qrSlug(strings.TrimPrefix(a.Name, "Screenshot ")))
+		for _, t := range targets {
+			framed, err := renderListingScreenshot(config, fonts, img, caption, t)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", t.ID, a.Name, err)
+			}
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: t.Store + "/" + t.ID + "/" + name, Method: zip.Store, Modified: now})
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(framed); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderListingScreenshot composites one screenshot into a device frame on
+// the brand gradient with its caption above. The canvas is fully opaque so
+// the PNG encoder writes it without an alpha channel, which App Store
+// Connect rejects.
+func renderListingScreenshot(config *models.BrandConfig, fonts *brandFonts, shot image.Image, caption string, t StoreListingTarget) ([]byte, error) {
+	W, H := float64(t.Width), float64(t.Height)
+	dc := gg.NewContext(t.Width, t.Height)
+
+	top, bottom := hexToColor(config.PrimaryColor), hexToColor(config.PrimaryDark)
+	grad := gg.NewLinearGradient(0, 0, 0, H)
+	grad.AddColorStop(0, top)
+	grad.AddColorStop(1, bottom)
+	dc.SetFillStyle(grad)
+	dc.DrawRectangle(0, 0, W, H)
+	dc.Fill()
+
+	margin := W * 0.06
+	band := H * listingCaptionBandRatio
+	if caption != "" {
+		dc.SetColor(color.White)
+		if err := fonts.setPNGFont(dc, true, W*0.065); err == nil {
+			dc.DrawStringWrapped(caption, W/2, band*0.55, 0.5, 0.5, W-2*margin, 1.2, gg.AlignCenter)
+		}
+	} else {
+		band = margin
+	}
+
+	// Device body sized to the screenshot's aspect ratio, as large as fits
+	// below the caption band.
+	bezelRatio, cornerRatio := 0.035, 0.13
+	if t.Tablet {
+		bezelRatio, cornerRatio = 0.03, 0.05
+	}
+	sb := shot.Bounds()
+	aspect := float64(sb.Dy()) / float64(sb.Dx())
+	maxW, maxH := W-2*margin, H-band-margin
+	devW := maxW
+	bezel := devW * bezelRatio
+	if devH := (devW-2*bezel)*aspect + 2*bezel; devH > maxH {
+		// Solve devW from devH = (devW - 2*bezelRatio*devW)*aspect + 2*bezelRatio*devW.
+		devW = maxH / ((1-2*bezelRatio)*aspect + 2*bezelRatio)
+		bezel = devW * bezelRatio
+	}
+	devH := (devW-2*bezel)*aspect + 2*bezel
+	devX, devY := (W-devW)/2, band+(maxH-devH)/2
+	radius := devW * cornerRatio
+
+	dc.SetRGBA(0, 0, 0, 0.25)
+	dc.DrawRoundedRectangle(devX+W*0.01, devY+W*0.015, devW, devH, radius)
+	dc.Fill()
+	dc.SetHexColor("#111827")
+	dc.DrawRoundedRectangle(devX, devY, devW, devH, radius)
+	dc.Fill()
+
+	screenX, screenY := devX+bezel, devY+bezel
+	screenW := devW - 2*bezel
+	scale := screenW / float64(sb.Dx())
+	dc.Push()
+	dc.DrawRoundedRectangle(screenX, screenY, screenW, devH-2*bezel, math.Max(radius-bezel, 0))
+	dc.Clip()
+	dc.Translate(screenX, screenY)
+	dc.Scale(scale, scale)
+	dc.DrawImage(shot, -sb.Min.X, -sb.Min.Y)
+	dc.Pop()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dc.Image()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
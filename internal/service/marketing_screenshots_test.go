@@ -0,0 +1,163 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type assetLibraryRepo struct {
+	brandConfigRepo
+	assets map[uuid.UUID]*models.MarketingAsset
+}
+
+func (f *assetLibraryRepo) GetMarketingAssetByName(ctx context.Context, name string) (*models.MarketingAsset, error) {
+	for _, a := range f.assets {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *assetLibraryRepo) GetMarketingAsset(ctx context.Context, id uuid.UUID) (*models.MarketingAsset, error) {
+	if a, ok := f.assets[id]; ok {
+		return a, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *assetLibraryRepo) CreateMarketingAsset(ctx context.Context, a *models.MarketingAsset) error {
+	f.assets[a.ID] = a
+	return nil
+}
+
+func (f *assetLibraryRepo) UpdateMarketingAsset(ctx context.Context, a *models.MarketingAsset) error {
+	f.assets[a.ID] = a
+	return nil
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{200, 220, 255, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadScreenshotValidation(t *testing.T) {
+	repo := &assetLibraryRepo{assets: map[uuid.UUID]*models.MarketingAsset{}}
+	svc := NewMarketingService(repo, t.TempDir())
+	ctx := context.Background()
+
+	bad := []struct {
+		label string
+		data  []byte
+	}{
+		{"", testPNG(t, 400, 800)},
+		{"home", []byte("not an image")},
+		{"home", testPNG(t, 800, 400)},
+		{"home", testPNG(t, 200, 400)},
+	}
+	for i, c := range bad {
+		if _, err := svc.UploadScreenshot(ctx, c.label, bytes.NewReader(c.data)); !errors.Is(err, ErrScreenshotInvalid) {
+			t.Errorf("case %d: err = %v, want ErrScreenshotInvalid", i, err)
+		}
+	}
+
+	a, err := svc.UploadScreenshot(ctx, "Home", bytes.NewReader(testPNG(t, 400, 800)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.AssetType != models.AssetTypeScreenshot || a.IsAutoGenerated || a.WidthPx != 400 {
+		t.Errorf("asset = %+v", a)
+	}
+}
+
+func TestGenerateStoreListingSet(t *testing.T) {
+	repo := &assetLibraryRepo{
+		brandConfigRepo: brandConfigRepo{cfg: &models.BrandConfig{
+			AppName: "CareCompanion", PrimaryColor: "#4F46E5", PrimaryDark: "#3730A3",
+		}},
+		assets: map[uuid.UUID]*models.MarketingAsset{},
+	}
+	svc := NewMarketingService(repo, t.TempDir())
+	ctx := context.Background()
+	shot, err := svc.UploadScreenshot(ctx, "Daily Log", bytes.NewReader(testPNG(t, 390, 844)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.GenerateStoreListingSet(ctx, StoreListingRequest{
+		Shots: []ListingShot{{ScreenshotID: shot.ID}}, Targets: []string{"watch"},
+	}); !errors.Is(err, ErrScreenshotInvalid) {
+		t.Errorf("unknown target: err = %v", err)
+	}
+	if _, err := svc.GenerateStoreListingSet(ctx, StoreListingRequest{
+		Shots: []ListingShot{{ScreenshotID: uuid.New()}},
+	}); !errors.Is(err, ErrScreenshotNotFound) {
+		t.Errorf("unknown screenshot: err = %v", err)
+	}
+
+	data, err := svc.GenerateStoreListingSet(ctx, StoreListingRequest{
+		Shots:   []ListingShot{{ScreenshotID: shot.ID, Caption: "Log a day in seconds"}},
+		Targets: []string{"play_phone", "ipad_13"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int{
+		"play_store/play_phone/01_daily_log.png": {1080, 1920},
+		"app_store/ipad_13/01_daily_log.png":     {2064, 2752},
+	}
+	if len(zr.File) != len(want) {
+		t.Fatalf("zip has %d files, want %d", len(zr.File), len(want))
+	}
+	for _, f := range zr.File {
+		size, ok := want[f.Name]
+		if !ok {
+			t.Errorf("unexpected file %s", f.Name)
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw bytes.Buffer
+		raw.ReadFrom(rc)
+		rc.Close()
+		cfg, err := png.DecodeConfig(bytes.NewReader(raw.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Width != size[0] || cfg.Height != size[1] {
+			t.Errorf("%s is %dx%d, want %dx%d", f.Name, cfg.Width, cfg.Height, size[0], size[1])
+		}
+		// App Store Connect rejects screenshots with an alpha channel; IHDR
+		// color type 2 is truecolor without alpha.
+		if ct := raw.Bytes()[25]; ct != 2 {
+			t.Errorf("%s has PNG color type %d, want 2 (RGB)", f.Name, ct)
+		}
+	}
+}
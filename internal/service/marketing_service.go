@@ -8,9 +8,12 @@ import (
 	"image/color"
 	"image/png"
 	"io"
+	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fogleman/gg"
@@ -25,6 +28,17 @@ import (
 type MarketingService struct {
 	repo      repository.MarketingRepository
 	assetsDir string
+	jobs      *assetJobTracker
+
+	fontStorage BlobStorage
+	fontMu      sync.Mutex
+	fontCache   map[uuid.UUID][]byte
+
+	publicStats  *publicStatsCache
+	testimonials TestimonialSource
+	scan         *UploadScanService
+	signer       *AssetSigner
+	images       *ImageService
 }
 
 // NewMarketingService creates a new marketing service
@@ -32,6 +46,10 @@ func NewMarketingService(repo repository.MarketingRepository, assetsDir string)
 	return &MarketingService{
 		repo:      repo,
 		assetsDir: assetsDir,
+		jobs:      newAssetJobTracker(),
+		fontCache: make(map[uuid.UUID][]byte),
+
+		publicStats: &publicStatsCache{},
 	}
 }
 
@@ -78,6 +96,12 @@ func (s *MarketingService) GetMarketingMaterialsData(ctx context.Context) (*mode
 		stats = &models.MarketingStats{}
 	}
 
+	for _, assets := range [][]models.MarketingAsset{logos, brochures, socialGraphics} {
+		for i := range assets {
+			s.signAsset(&assets[i])
+		}
+	}
+
 	return &models.MarketingMaterialsData{
 		BrandConfig:     config,
 		Logos:           logos,
@@ -118,6 +142,7 @@ func (s *MarketingService) GenerateSinglePageBrochure(ctx context.Context) ([]by
 
 	// Create PDF (Letter size: 8.5" x 11")
 	pdf := fpdf.New("P", "in", "Letter", "")
+	hf, bf := s.loadBrandFonts(ctx, config).applyPDF(pdf)
 	pdf.SetMargins(0.5, 0.5, 0.5)
 	pdf.AddPage()
 
@@ -131,12 +156,12 @@ func (s *MarketingService) GenerateSinglePageBrochure(ctx context.Context) ([]by
 	pdf.Rect(0, 0, 8.5, 1.5, "F")
 
 	// App name and tagline in header
-	pdf.SetFont("Helvetica", "B", 32)
+	pdf.SetFont(hf, "B", 32)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(0.5, 0.4)
 	pdf.Cell(5, 0.5, config.AppName)
 
-	pdf.SetFont("Helvetica", "", 14)
+	pdf.SetFont(bf, "", 14)
 	pdf.SetXY(0.5, 0.9)
 	pdf.Cell(5, 0.3, config.Tagline)
 
@@ -144,12 +169,12 @@ func (s *MarketingService) GenerateSinglePageBrochure(ctx context.Context) ([]by
 	pdf.SetTextColor(31, 41, 55) // Dark gray
 
 	// The Challenge section
-	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetFont(hf, "B", 18)
 	pdf.SetXY(0.5, 1.8)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.Cell(4, 0.4, "The Challenge")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.5, 2.3)
 
@@ -165,18 +190,18 @@ func (s *MarketingService) GenerateSinglePageBrochure(ctx context.Context) ([]by
 	pdf.MultiCell(7.5, 0.25, challengeText, "", "", false)
 
 	// Our Solution section
-	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetFont(hf, "B", 18)
 	pdf.SetXY(0.5, 3.3)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.Cell(4, 0.4, "Our Solution")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.5, 3.8)
 	pdf.MultiCell(7.5, 0.25, config.MissionStatement, "", "", false)
 
 	// Features section (3 columns)
-	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetFont(hf, "B", 18)
 	pdf.SetXY(0.5, 5.0)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.Cell(4, 0.4, "Key Features")
@@ -197,12 +222,12 @@ func (s *MarketingService) GenerateSinglePageBrochure(ctx context.Context) ([]by
 		pdf.SetFillColor(int(sr), int(sg), int(sb))
 		pdf.Rect(x, y, 0.3, 0.3, "F")
 
-		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFont(hf, "B", 10)
 		pdf.SetTextColor(31, 41, 55)
 		pdf.SetXY(x+0.4, y)
 		pdf.Cell(1.8, 0.25, feature.Title)
 
-		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetFont(bf, "", 8)
 		pdf.SetTextColor(107, 114, 128)
 		pdf.SetXY(x+0.4, y+0.25)
 		// Truncate description if too long
@@ -213,21 +238,35 @@ func (s *MarketingService) GenerateSinglePageBrochure(ctx context.Context) ([]by
 		pdf.MultiCell(1.8, 0.15, desc, "", "", false)
 	}
 
+	// Featured testimonial between the features and the CTA
+	if q := s.featuredQuote(ctx, TestimonialChannelPrint); q != nil {
+		pdf.SetDrawColor(int(ar), int(ag), int(ab))
+		pdf.SetLineWidth(0.04)
+		pdf.Line(0.5, 7.65, 0.5, 8.3)
+		pdf.SetTextColor(55, 65, 81)
+		drawPDFQuote(pdf, bf, q, 0.65, 7.6, 7.35, 10)
+	}
+
 	// Call to Action section
 	pdf.SetFillColor(int(ar), int(ag), int(ab))
 	pdf.Rect(0.5, 8.5, 7.5, 1.2, "F")
 
-	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetFont(hf, "B", 16)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(0.7, 8.7)
 	pdf.Cell(3, 0.4, "Start Your Journey Today")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetXY(0.7, 9.2)
 	pdf.Cell(4, 0.3, fmt.Sprintf("Visit %s to learn more", config.WebsiteURL))
 
+	// QR code at the right edge of the call-to-action box
+	if err := placeQRInPDF(pdf, config, "single_page_brochure", "brochure", 6.85, 8.6, 1.0); err != nil {
+		log.Printf("[MARKETING] single page brochure QR skipped: %v", err)
+	}
+
 	// Footer
-	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetFont(bf, "", 9)
 	pdf.SetTextColor(107, 114, 128)
 	pdf.SetXY(0.5, 10.3)
 	pdf.Cell(7.5, 0.3, fmt.Sprintf("%s | %s", config.CopyrightText, config.SupportEmail))
@@ -251,6 +290,7 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 
 	// Create PDF (Letter size landscape for tri-fold: 11" x 8.5")
 	pdf := fpdf.New("L", "in", "Letter", "")
+	hf, bf := s.loadBrandFonts(ctx, config).applyPDF(pdf)
 	pdf.SetMargins(0.25, 0.25, 0.25)
 
 	// Get brand colors
@@ -264,16 +304,16 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 	// Panel 1 (Back) | Panel 2 (Front Cover) | Panel 3 (Inside Flap)
 	pdf.AddPage()
 
-	// Panel 1: Back panel (Contact info, QR code placeholder)
+	// Panel 1: Back panel (Contact info, QR code)
 	pdf.SetFillColor(245, 245, 245)
 	pdf.Rect(0, 0, panelWidth, 8.5, "F")
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.25, 2)
 	pdf.Cell(3, 0.4, "Contact Us")
 
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(bf, "", 10)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.25, 2.6)
 	pdf.Cell(3, 0.3, config.WebsiteURL)
@@ -284,11 +324,16 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 		pdf.Cell(3, 0.3, config.ContactPhone)
 	}
 
+	// QR code between contact details and social links
+	if err := placeQRInPDF(pdf, config, "tri_fold_brochure", "brochure", (panelWidth-1.0)/2, 3.85, 1.0); err != nil {
+		log.Printf("[MARKETING] tri-fold brochure QR skipped: %v", err)
+	}
+
 	// Social links
-	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetFont(hf, "B", 11)
 	pdf.SetXY(0.25, 5)
 	pdf.Cell(3, 0.3, "Follow Us")
-	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetFont(bf, "", 9)
 	socialY := 5.5
 	if config.FacebookURL != "" {
 		pdf.SetXY(0.25, socialY)
@@ -311,7 +356,7 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 	}
 
 	// Copyright at bottom
-	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetFont(bf, "", 8)
 	pdf.SetTextColor(107, 114, 128)
 	pdf.SetXY(0.25, 7.8)
 	pdf.Cell(3, 0.3, config.CopyrightText)
@@ -320,12 +365,12 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 	pdf.SetFillColor(int(pr), int(pg), int(pb))
 	pdf.Rect(panelWidth, 0, panelWidth, 8.5, "F")
 
-	pdf.SetFont("Helvetica", "B", 28)
+	pdf.SetFont(hf, "B", 28)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(panelWidth+0.25, 3)
 	pdf.Cell(3, 0.6, config.AppName)
 
-	pdf.SetFont("Helvetica", "", 14)
+	pdf.SetFont(bf, "", 14)
 	pdf.SetXY(panelWidth+0.25, 4)
 	pdf.MultiCell(3, 0.35, config.Tagline, "", "", false)
 
@@ -333,7 +378,7 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 	pdf.SetFillColor(255, 255, 255)
 	pdf.Rect(panelWidth*2, 0, panelWidth, 8.5, "F")
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(panelWidth*2+0.25, 1)
 	pdf.Cell(3, 0.4, "Did You Know?")
@@ -356,12 +401,12 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 		pdf.SetFillColor(int(sr), int(sg), int(sb))
 		pdf.Rect(panelWidth*2+0.25, statsY, 3, 1, "F")
 
-		pdf.SetFont("Helvetica", "B", 20)
+		pdf.SetFont(hf, "B", 20)
 		pdf.SetTextColor(255, 255, 255)
 		pdf.SetXY(panelWidth*2+0.35, statsY+0.2)
 		pdf.Cell(2.8, 0.4, stat.value)
 
-		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetFont(bf, "", 9)
 		pdf.SetXY(panelWidth*2+0.35, statsY+0.6)
 		pdf.Cell(2.8, 0.3, stat.desc)
 
@@ -374,12 +419,12 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 
 	// Panel 4: The Challenge
 	pdf.SetFillColor(255, 255, 255)
-	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetFont(hf, "B", 16)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.25, 0.5)
 	pdf.Cell(3, 0.4, "The Challenge")
 
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(bf, "", 10)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.25, 1.1)
 
@@ -401,12 +446,12 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 	}
 
 	// Panel 5: Our Solution
-	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetFont(hf, "B", 16)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(panelWidth+0.25, 0.5)
 	pdf.Cell(3, 0.4, "Our Solution")
 
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(bf, "", 10)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(panelWidth+0.25, 1.1)
 	pdf.MultiCell(3, 0.25, config.MissionStatement, "", "", false)
@@ -420,12 +465,12 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 		pdf.SetFillColor(int(sr), int(sg), int(sb))
 		pdf.Rect(panelWidth+0.25, featureY, 0.2, 0.2, "F")
 
-		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFont(hf, "B", 10)
 		pdf.SetTextColor(31, 41, 55)
 		pdf.SetXY(panelWidth+0.55, featureY)
 		pdf.Cell(2.5, 0.25, feature.Title)
 
-		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetFont(bf, "", 8)
 		pdf.SetTextColor(107, 114, 128)
 		pdf.SetXY(panelWidth+0.55, featureY+0.25)
 		desc := feature.Description
@@ -440,7 +485,7 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 	pdf.SetFillColor(int(pr), int(pg), int(pb))
 	pdf.Rect(panelWidth*2, 0, panelWidth+0.5, 8.5, "F")
 
-	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetFont(hf, "B", 16)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(panelWidth*2+0.25, 0.5)
 	pdf.Cell(3, 0.4, "How It Works")
@@ -460,28 +505,34 @@ func (s *MarketingService) GenerateTriFoldBrochure(ctx context.Context) ([]byte,
 		pdf.SetFillColor(255, 255, 255)
 		pdf.Circle(panelWidth*2+0.5, stepY+0.15, 0.2, "F")
 
-		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetFont(hf, "B", 12)
 		pdf.SetTextColor(int(pr), int(pg), int(pb))
 		pdf.SetXY(panelWidth*2+0.35, stepY)
 		pdf.Cell(0.3, 0.3, step.num)
 
-		pdf.SetFont("Helvetica", "", 11)
+		pdf.SetFont(bf, "", 11)
 		pdf.SetTextColor(255, 255, 255)
 		pdf.SetXY(panelWidth*2+0.8, stepY)
 		pdf.Cell(2.5, 0.3, step.text)
 		stepY += 0.7
 	}
 
+	// Featured testimonial between the steps and the CTA
+	if q := s.featuredQuote(ctx, TestimonialChannelPrint); q != nil {
+		pdf.SetTextColor(255, 255, 255)
+		drawPDFQuote(pdf, bf, q, panelWidth*2+0.25, stepY+0.4, 3, 10)
+	}
+
 	// CTA at bottom
 	pdf.SetFillColor(int(ar), int(ag), int(ab))
 	pdf.Rect(panelWidth*2+0.25, 6.5, 3, 1.2, "F")
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(panelWidth*2+0.4, 6.7)
 	pdf.Cell(2.7, 0.4, "Get Started Today!")
 
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(bf, "", 10)
 	pdf.SetXY(panelWidth*2+0.4, 7.2)
 	pdf.Cell(2.7, 0.3, config.WebsiteURL)
 
@@ -503,6 +554,7 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 	}
 
 	pdf := fpdf.New("P", "in", "Letter", "")
+	hf, bf := s.loadBrandFonts(ctx, config).applyPDF(pdf)
 	pdf.SetMargins(0.75, 0.75, 0.75)
 
 	pr, pg, pb := hexToRGB(config.PrimaryColor)
@@ -514,55 +566,55 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 	pdf.SetFillColor(int(pr), int(pg), int(pb))
 	pdf.Rect(0, 0, 8.5, 11, "F")
 
-	pdf.SetFont("Helvetica", "B", 48)
+	pdf.SetFont(hf, "B", 48)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(0.75, 4)
 	pdf.Cell(7, 1, config.AppName)
 
-	pdf.SetFont("Helvetica", "", 24)
+	pdf.SetFont(bf, "", 24)
 	pdf.SetXY(0.75, 5.2)
 	pdf.Cell(7, 0.5, "Brand Style Guide")
 
-	pdf.SetFont("Helvetica", "", 14)
+	pdf.SetFont(bf, "", 14)
 	pdf.SetXY(0.75, 9)
 	pdf.Cell(7, 0.4, time.Now().Format("January 2006"))
 
 	// Page 2: Brand Overview
 	pdf.AddPage()
-	pdf.SetFont("Helvetica", "B", 28)
+	pdf.SetFont(hf, "B", 28)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.75, 0.75)
 	pdf.Cell(7, 0.6, "Brand Overview")
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 1.8)
 	pdf.Cell(7, 0.4, "Mission Statement")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.75, 2.3)
 	pdf.MultiCell(7, 0.25, config.MissionStatement, "", "", false)
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 4)
 	pdf.Cell(7, 0.4, "Tagline")
 
-	pdf.SetFont("Helvetica", "I", 16)
+	pdf.SetFont(bf, "I", 16)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.75, 4.5)
 	pdf.Cell(7, 0.4, fmt.Sprintf("\"%s\"", config.Tagline))
 
 	// Page 3: Color Palette
 	pdf.AddPage()
-	pdf.SetFont("Helvetica", "B", 28)
+	pdf.SetFont(hf, "B", 28)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.75, 0.75)
 	pdf.Cell(7, 0.6, "Color Palette")
 
 	// Primary colors
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 1.8)
 	pdf.Cell(7, 0.4, "Primary Colors")
@@ -582,19 +634,19 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 		pdf.SetFillColor(int(r), int(g), int(b))
 		pdf.Rect(x, 2.3, 2, 1.2, "F")
 
-		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFont(hf, "B", 10)
 		pdf.SetTextColor(31, 41, 55)
 		pdf.SetXY(x, 3.6)
 		pdf.Cell(2, 0.25, c.name)
 
-		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetFont(bf, "", 10)
 		pdf.SetXY(x, 3.9)
 		pdf.Cell(2, 0.25, c.hex)
 		x += 2.2
 	}
 
 	// Secondary colors
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 4.5)
 	pdf.Cell(7, 0.4, "Secondary & Accent Colors")
@@ -615,12 +667,12 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 		pdf.SetFillColor(int(r), int(g), int(b))
 		pdf.Rect(x, 5, 1.6, 1, "F")
 
-		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFont(hf, "B", 9)
 		pdf.SetTextColor(31, 41, 55)
 		pdf.SetXY(x, 6.1)
 		pdf.Cell(1.6, 0.2, c.name)
 
-		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetFont(bf, "", 9)
 		pdf.SetXY(x, 6.35)
 		pdf.Cell(1.6, 0.2, c.hex)
 		x += 1.75
@@ -628,37 +680,37 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 
 	// Page 4: Typography
 	pdf.AddPage()
-	pdf.SetFont("Helvetica", "B", 28)
+	pdf.SetFont(hf, "B", 28)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.75, 0.75)
 	pdf.Cell(7, 0.6, "Typography")
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 1.8)
 	pdf.Cell(7, 0.4, fmt.Sprintf("Heading Font: %s", config.HeadingFont))
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.75, 2.3)
 	pdf.Cell(7, 0.3, "Use for headlines, titles, and prominent text")
 
-	pdf.SetFont("Helvetica", "B", 36)
+	pdf.SetFont(hf, "B", 36)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 2.8)
 	pdf.Cell(7, 0.7, "Aa Bb Cc 123")
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 4)
 	pdf.Cell(7, 0.4, fmt.Sprintf("Body Font: %s", config.BodyFont))
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.75, 4.5)
 	pdf.Cell(7, 0.3, "Use for body copy, descriptions, and general text")
 
-	pdf.SetFont("Helvetica", "", 14)
+	pdf.SetFont(bf, "", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 5)
 	pdf.Cell(7, 0.3, "Aa Bb Cc Dd Ee Ff Gg Hh Ii Jj Kk Ll Mm")
@@ -669,39 +721,39 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 
 	// Page 5: Voice & Tone
 	pdf.AddPage()
-	pdf.SetFont("Helvetica", "B", 28)
+	pdf.SetFont(hf, "B", 28)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.75, 0.75)
 	pdf.Cell(7, 0.6, "Voice & Tone")
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 1.8)
 	pdf.Cell(7, 0.4, "Brand Voice")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.75, 2.3)
 	pdf.MultiCell(7, 0.25, config.BrandVoice, "", "", false)
 
-	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetFont(hf, "B", 14)
 	pdf.SetTextColor(31, 41, 55)
 	pdf.SetXY(0.75, 5)
 	pdf.Cell(7, 0.4, "Writing Guidelines")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	pdf.SetXY(0.75, 5.5)
 	pdf.MultiCell(7, 0.25, config.WritingGuidelines, "", "", false)
 
 	// Page 6: Contact Information
 	pdf.AddPage()
-	pdf.SetFont("Helvetica", "B", 28)
+	pdf.SetFont(hf, "B", 28)
 	pdf.SetTextColor(int(pr), int(pg), int(pb))
 	pdf.SetXY(0.75, 0.75)
 	pdf.Cell(7, 0.6, "Contact Information")
 
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(bf, "", 11)
 	pdf.SetTextColor(55, 65, 81)
 	y := 1.8
 
@@ -720,11 +772,11 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 
 	for _, c := range contacts {
 		if c.value != "" {
-			pdf.SetFont("Helvetica", "B", 11)
+			pdf.SetFont(hf, "B", 11)
 			pdf.SetXY(0.75, y)
 			pdf.Cell(2, 0.3, c.label+":")
 
-			pdf.SetFont("Helvetica", "", 11)
+			pdf.SetFont(bf, "", 11)
 			pdf.SetXY(2.75, y)
 			pdf.Cell(5, 0.3, c.value)
 			y += 0.4
@@ -734,7 +786,7 @@ func (s *MarketingService) GenerateStyleGuidePDF(ctx context.Context) ([]byte, e
 	// Footer
 	pdf.SetFillColor(int(sr), int(sg), int(sb))
 	pdf.Rect(0, 9.5, 8.5, 1.5, "F")
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(bf, "", 10)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(0.75, 10)
 	pdf.Cell(7, 0.3, config.CopyrightText)
@@ -786,7 +838,7 @@ func (s *MarketingService) GenerateLogoPNG(ctx context.Context, variant string,
 	fontSize := float64(size) * 0.5
 
 	// Try to load a font, fall back to basic drawing if not available
-	if err := dc.LoadFontFace("/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf", fontSize); err != nil {
+	if err := s.loadBrandFonts(ctx, config).setPNGFont(dc, true, fontSize); err != nil {
 		// Fallback: draw a simple "C" using shapes
 		dc.SetLineWidth(float64(size) * 0.08)
 		cx := float64(size) / 2
@@ -846,6 +898,83 @@ func (s *MarketingService) GenerateLogoSVG(ctx context.Context, variant string,
 	return []byte(svg), nil
 }
 
+// GenerateLogoSheetPDF creates a one-page logo sheet: every logo variant
+// at print and screen sizes, plus a QR code to the brand landing page.
+func (s *MarketingService) GenerateLogoSheetPDF(ctx context.Context) ([]byte, error) {
+	config, err := s.repo.GetBrandConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "in", "Letter", "")
+	hf, bf := s.loadBrandFonts(ctx, config).applyPDF(pdf)
+	pdf.SetMargins(0.75, 0.75, 0.75)
+	pdf.AddPage()
+
+	pr, pg, pb := hexToRGB(config.PrimaryColor)
+
+	// Header
+	pdf.SetFillColor(int(pr), int(pg), int(pb))
+	pdf.Rect(0, 0, 8.5, 1.2, "F")
+	pdf.SetFont(hf, "B", 24)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(0.75, 0.4)
+	pdf.Cell(7, 0.5, config.AppName+" Logo Sheet")
+
+	// One row per variant, three sizes per row
+	variants := []string{"primary", "white", "dark"}
+	sizes := []float64{1.5, 1.0, 0.5}
+	y := 1.7
+	for _, variant := range variants {
+		img, err := s.GenerateLogoPNG(ctx, variant, 512)
+		if err != nil {
+			return nil, fmt.Errorf("logo %s: %w", variant, err)
+		}
+		name := "logo_" + variant
+		opts := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(img))
+
+		pdf.SetFont(hf, "B", 12)
+		pdf.SetTextColor(int(pr), int(pg), int(pb))
+		pdf.SetXY(0.75, y)
+		pdf.Cell(3, 0.3, strings.Title(variant))
+
+		x := 0.75
+		for _, sz := range sizes {
+			if variant == "white" {
+				// Outline so the white variant is visible on paper
+				pdf.SetDrawColor(209, 213, 219)
+				pdf.Rect(x, y+0.4, sz, sz, "D")
+			}
+			pdf.ImageOptions(name, x, y+0.4, sz, sz, false, opts, 0, "")
+			x += sz + 0.5
+		}
+		y += 2.2
+	}
+
+	// QR code + target, bottom right
+	if err := placeQRInPDF(pdf, config, "logo_sheet", "logo_sheet", 6.0, 8.4, 1.5); err != nil {
+		log.Printf("[MARKETING] logo sheet QR skipped: %v", err)
+	} else {
+		pdf.SetFont(bf, "", 9)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.SetXY(0.75, 9.6)
+		pdf.Cell(5, 0.3, "Scan to visit "+config.WebsiteURL)
+	}
+
+	// Footer
+	pdf.SetFont(bf, "", 8)
+	pdf.SetTextColor(107, 114, 128)
+	pdf.SetXY(0.75, 10.3)
+	pdf.Cell(7, 0.3, config.CopyrightText)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // GenerateSocialGraphic generates a social media graphic
 func (s *MarketingService) GenerateSocialGraphic(ctx context.Context, template models.SocialTemplate, headline, body string) ([]byte, error) {
 	config, err := s.repo.GetBrandConfig(ctx)
@@ -854,6 +983,7 @@ func (s *MarketingService) GenerateSocialGraphic(ctx context.Context, template m
 	}
 
 	dc := gg.NewContext(template.WidthPx, template.HeightPx)
+	fonts := s.loadBrandFonts(ctx, config)
 
 	// Gradient background (simplified - solid primary color)
 	bgColor := hexToColor(config.PrimaryColor)
@@ -882,7 +1012,7 @@ func (s *MarketingService) GenerateSocialGraphic(ctx context.Context, template m
 	// Headline
 	dc.SetColor(hexToColor(config.PrimaryDark))
 	headlineFontSize := float64(template.WidthPx) * 0.045
-	if err := dc.LoadFontFace("/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf", headlineFontSize); err == nil {
+	if err := fonts.setPNGFont(dc, true, headlineFontSize); err == nil {
 		dc.DrawStringWrapped(headline, float64(template.WidthPx)/2, float64(template.HeightPx)*0.4, 0.5, 0.5, float64(template.WidthPx)-4*margin, 1.2, gg.AlignCenter)
 	}
 
@@ -890,7 +1020,7 @@ func (s *MarketingService) GenerateSocialGraphic(ctx context.Context, template m
 	if body != "" {
 		dc.SetColor(color.RGBA{75, 85, 99, 255})
 		bodyFontSize := float64(template.WidthPx) * 0.025
-		if err := dc.LoadFontFace("/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf", bodyFontSize); err == nil {
+		if err := fonts.setPNGFont(dc, false, bodyFontSize); err == nil {
 			dc.DrawStringWrapped(body, float64(template.WidthPx)/2, float64(template.HeightPx)*0.55, 0.5, 0.5, float64(template.WidthPx)-4*margin, 1.4, gg.AlignCenter)
 		}
 	}
@@ -898,13 +1028,20 @@ func (s *MarketingService) GenerateSocialGraphic(ctx context.Context, template m
 	// App name at bottom
 	dc.SetColor(color.RGBA{255, 255, 255, 255})
 	brandFontSize := float64(template.WidthPx) * 0.03
-	if err := dc.LoadFontFace("/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf", brandFontSize); err == nil {
+	if err := fonts.setPNGFont(dc, true, brandFontSize); err == nil {
 		dc.DrawString(config.AppName, margin, float64(template.HeightPx)-margin)
 	}
 
+	// QR code in the top-right of the header band, above the content card
+	qrSize := int(math.Min(float64(template.WidthPx), float64(template.HeightPx)) * 0.14)
+	qrY := (template.HeightPx/4 - qrSize) / 2
+	if err := drawQROnContext(dc, config, template.Platform, qrSlug(template.Name), template.WidthPx-int(margin)-qrSize, qrY, qrSize); err != nil {
+		log.Printf("[MARKETING] social graphic %s QR skipped: %v", template.Name, err)
+	}
+
 	// Website URL
 	urlFontSize := float64(template.WidthPx) * 0.02
-	if err := dc.LoadFontFace("/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf", urlFontSize); err == nil {
+	if err := fonts.setPNGFont(dc, false, urlFontSize); err == nil {
 		dc.DrawStringAnchored(config.WebsiteURL, float64(template.WidthPx)-margin, float64(template.HeightPx)-margin, 1, 0)
 	}
 
@@ -919,6 +1056,12 @@ func (s *MarketingService) GenerateSocialGraphic(ctx context.Context, template m
 
 // SaveAsset saves generated content to file and database
 func (s *MarketingService) SaveAsset(ctx context.Context, name, assetType, format string, content []byte, width, height int) (*models.MarketingAsset, error) {
+	return s.saveAsset(ctx, name, assetType, format, content, width, height, true)
+}
+
+// saveAsset writes content and upserts its asset row by name. Saving over a
+// deactivated asset brings it back.
+func (s *MarketingService) saveAsset(ctx context.Context, name, assetType, format string, content []byte, width, height int, autoGenerated bool) (*models.MarketingAsset, error) {
 	// Ensure directory exists
 	dir := filepath.Join(s.assetsDir, assetType+"s")
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -945,6 +1088,8 @@ func (s *MarketingService) SaveAsset(ctx context.Context, name, assetType, forma
 		existing.WidthPx = width
 		existing.HeightPx = height
 		existing.LastGeneratedAt = &now
+		existing.IsAutoGenerated = autoGenerated
+		existing.IsActive = true
 		existing.UpdatedAt = now
 
 		if err := s.repo.UpdateMarketingAsset(ctx, existing); err != nil {
@@ -963,7 +1108,7 @@ func (s *MarketingService) SaveAsset(ctx context.Context, name, assetType, forma
 		HeightPx:        height,
 		FilePath:        filePath,
 		FileSizeBytes:   int64(len(content)),
-		IsAutoGenerated: true,
+		IsAutoGenerated: autoGenerated,
 		LastGeneratedAt: &now,
 		IsActive:        true,
 		CreatedAt:       now,
@@ -977,95 +1122,55 @@ func (s *MarketingService) SaveAsset(ctx context.Context, name, assetType, forma
 	return asset, nil
 }
 
-// RegenerateAllAssets regenerates all marketing assets
-func (s *MarketingService) RegenerateAllAssets(ctx context.Context) error {
-	// Generate brochures
-	singlePage, err := s.GenerateSinglePageBrochure(ctx)
-	if err != nil {
-		return fmt.Errorf("single page brochure: %w", err)
-	}
-	if _, err := s.SaveAsset(ctx, "Single Page Brochure", models.AssetTypeBrochure, models.FormatPDF, singlePage, 612, 792); err != nil {
-		return err
-	}
-
-	triFold, err := s.GenerateTriFoldBrochure(ctx)
+// GetAssetFile reads an asset file from disk
+func (s *MarketingService) GetAssetFile(ctx context.Context, id uuid.UUID) (io.ReadCloser, string, error) {
+	asset, err := s.repo.GetMarketingAsset(ctx, id)
 	if err != nil {
-		return fmt.Errorf("tri-fold brochure: %w", err)
-	}
-	if _, err := s.SaveAsset(ctx, "Tri-Fold Brochure", models.AssetTypeBrochure, models.FormatPDF, triFold, 792, 612); err != nil {
-		return err
+		return nil, "", err
 	}
 
-	// Generate style guide
-	styleGuide, err := s.GenerateStyleGuidePDF(ctx)
+	file, err := os.Open(asset.FilePath)
 	if err != nil {
-		return fmt.Errorf("style guide: %w", err)
-	}
-	if _, err := s.SaveAsset(ctx, "Brand Style Guide", models.AssetTypeStyleGuide, models.FormatPDF, styleGuide, 612, 792); err != nil {
-		return err
+		return nil, "", err
 	}
 
-	// Generate logos
-	logoSizes := []int{64, 128, 256, 512}
-	variants := []string{"primary", "white", "dark"}
-
-	for _, variant := range variants {
-		for _, size := range logoSizes {
-			// PNG
-			png, err := s.GenerateLogoPNG(ctx, variant, size)
-			if err != nil {
-				return fmt.Errorf("logo PNG %s %d: %w", variant, size, err)
-			}
-			name := fmt.Sprintf("Logo %s %dx%d", strings.Title(variant), size, size)
-			if _, err := s.SaveAsset(ctx, name, models.AssetTypeLogo, models.FormatPNG, png, size, size); err != nil {
-				return err
-			}
-		}
+	return file, asset.Name + "." + asset.Format, nil
+}
 
-		// SVG (scalable, just one size reference)
-		svg, err := s.GenerateLogoSVG(ctx, variant, 512)
-		if err != nil {
-			return fmt.Errorf("logo SVG %s: %w", variant, err)
-		}
-		name := fmt.Sprintf("Logo %s SVG", strings.Title(variant))
-		if _, err := s.SaveAsset(ctx, name, models.AssetTypeLogo, models.FormatSVG, svg, 512, 512); err != nil {
-			return err
-		}
-	}
+// SetAssetSigner makes asset listings carry signed /static links. Without
+// it assets are only reachable through the authenticated download route.
+func (s *MarketingService) SetAssetSigner(signer *AssetSigner) {
+	s.signer = signer
+}
 
-	// Generate social media graphics
-	templates, err := s.repo.ListSocialTemplates(ctx, "")
-	if err != nil {
-		return fmt.Errorf("list social templates: %w", err)
-	}
+// SetImageService makes uploaded screenshots also get responsive
+// WebP/AVIF variants for the website.
+func (s *MarketingService) SetImageService(images *ImageService) {
+	s.images = images
+}
 
-	config, _ := s.repo.GetBrandConfig(ctx)
-	for _, tmpl := range templates {
-		graphic, err := s.GenerateSocialGraphic(ctx, tmpl, config.Tagline, "Track. Discover. Coordinate.")
-		if err != nil {
-			return fmt.Errorf("social graphic %s: %w", tmpl.Name, err)
-		}
-		if _, err := s.SaveAsset(ctx, tmpl.Name+" Default", models.AssetTypeSocialGraphic, models.FormatPNG, graphic, tmpl.WidthPx, tmpl.HeightPx); err != nil {
-			return err
-		}
+// signAsset sets a.URL when the file is served from /static.
+func (s *MarketingService) signAsset(a *models.MarketingAsset) {
+	if s.signer != nil && a.FilePath != "" {
+		a.URL, _ = s.signer.SignedFileURL(a.FilePath, StaticAssetURLTTL)
 	}
-
-	return nil
 }
 
-// GetAssetFile reads an asset file from disk
-func (s *MarketingService) GetAssetFile(ctx context.Context, id uuid.UUID) (io.ReadCloser, string, error) {
+// SignedAssetURL returns a fresh signed /static link for asset id, or
+// ErrAssetSignature when the file isn't served from /static.
+func (s *MarketingService) SignedAssetURL(ctx context.Context, id uuid.UUID) (string, time.Time, error) {
+	if s.signer == nil {
+		return "", time.Time{}, ErrAssetSignature
+	}
 	asset, err := s.repo.GetMarketingAsset(ctx, id)
 	if err != nil {
-		return nil, "", err
+		return "", time.Time{}, err
 	}
-
-	file, err := os.Open(asset.FilePath)
-	if err != nil {
-		return nil, "", err
+	url, exp := s.signer.SignedFileURL(asset.FilePath, StaticAssetURLTTL)
+	if url == "" {
+		return "", time.Time{}, ErrAssetSignature
 	}
-
-	return file, asset.Name + "." + asset.Format, nil
+	return url, exp, nil
 }
 
 // LoadMascotImage loads the Matty mascot image if available
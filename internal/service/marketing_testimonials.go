@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+)
+
+// maxPrintQuoteRunes keeps a brochure quote inside its fixed box.
+const maxPrintQuoteRunes = 220
+
+// TestimonialSource supplies consented quotes to the generators.
+// Implemented by TestimonialService.
+type TestimonialSource interface {
+	FeaturedQuote(ctx context.Context, channel string) (*FeaturedQuote, error)
+	QuoteForChannel(ctx context.Context, id uuid.UUID, channel string) (*FeaturedQuote, error)
+}
+
+// SetTestimonialSource wires featured testimonials into brochure and
+// social generation. Without it the generators omit the quote.
+func (s *MarketingService) SetTestimonialSource(src TestimonialSource) {
+	s.testimonials = src
+}
+
+// featuredQuote returns the top featured quote for channel, or nil. Like
+// brand fonts, a lookup failure is logged and the asset renders without it.
+func (s *MarketingService) featuredQuote(ctx context.Context, channel string) *FeaturedQuote {
+	if s.testimonials == nil {
+		return nil
+	}
+	q, err := s.testimonials.FeaturedQuote(ctx, channel)
+	if err != nil {
+		log.Printf("[MARKETING] featured testimonial unavailable for %s: %v", channel, err)
+		return nil
+	}
+	return q
+}
+
+// SocialQuoteText formats a testimonial for the body of a social graphic.
+// It fails with ErrTestimonialNotPublishable unless the entry is approved
+// with social consent.
+func (s *MarketingService) SocialQuoteText(ctx context.Context, id uuid.UUID) (string, error) {
+	if s.testimonials == nil {
+		return "", ErrTestimonialNotFound
+	}
+	q, err := s.testimonials.QuoteForChannel(ctx, id, TestimonialChannelSocial)
+	if err != nil {
+		return "", err
+	}
+	return "“" + q.Quote + "”\n— " + q.Attribution, nil
+}
+
+// drawPDFQuote renders q as an italic pull quote with its byline in a
+// box of width w starting at (x, y). Core PDF fonts are cp1252, so text
+// is transcoded when family is the Helvetica fallback; uploaded brand
+// fonts take UTF-8 as-is.
+func drawPDFQuote(pdf *fpdf.Fpdf, family string, q *FeaturedQuote, x, y, w, size float64) {
+	text := "\"" + truncateRunes(q.Quote, maxPrintQuoteRunes) + "\""
+	if len([]rune(q.Quote)) > maxPrintQuoteRunes {
+		text = "\"" + truncateRunes(q.Quote, maxPrintQuoteRunes-3) + "...\""
+	}
+	byline := "- " + q.Attribution
+	if family == fallbackPDFFont {
+		tr := pdf.UnicodeTranslatorFromDescriptor("")
+		text, byline = tr(text), tr(byline)
+	}
+	lineH := size / 72 * 1.35
+	pdf.SetFont(family, "I", size)
+	pdf.SetXY(x, y)
+	pdf.MultiCell(w, lineH, text, "", "L", false)
+	pdf.SetFont(family, "B", size-1)
+	pdf.SetX(x)
+	pdf.MultiCell(w, lineH, byline, "", "R", false)
+}
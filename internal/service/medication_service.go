@@ -2,23 +2,24 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrMedicationNotFound = errors.New("medication not found")
+	ErrMedicationNotFound = apperr.NotFound("medication not found")
 )
 
 type MedicationService struct {
 	medRepo          repository.MedicationRepository
 	transparencyRepo *repository.TransparencyRepository
+	revisions        repository.LogRevisionRepository
 }
 
 func NewMedicationService(medRepo repository.MedicationRepository, transparencyRepo *repository.TransparencyRepository) *MedicationService {
@@ -28,6 +29,12 @@ func NewMedicationService(medRepo repository.MedicationRepository, transparencyR
 	}
 }
 
+// SetRevisions keeps a revision history of medication log edits, which
+// lets a conflicting edit merge the fields nobody else changed.
+func (s *MedicationService) SetRevisions(revisions repository.LogRevisionRepository) {
+	s.revisions = revisions
+}
+
 func (s *MedicationService) Create(ctx context.Context, childID uuid.UUID, req *models.CreateMedicationRequest) (*models.Medication, error) {
 	med := &models.Medication{
 		ChildID:    childID,
@@ -391,8 +398,9 @@ func (s *MedicationService) UpdateLogWithTracking(ctx context.Context, oldLog *m
 	}
 	// Update the log
 	if err := s.medRepo.UpdateLog(ctx, newLog); err != nil {
-		return err
+		return versionConflict(ctx, s.revisions, "medication", newLog, newLog.ID, newLog.Version, s.medRepo.GetLogByID, err)
 	}
+	recordRevision(ctx, s.revisions, "medication", newLog.ChildID, newLog.ID, newLog.Version, userID, oldLog, newLog, nil)
 
 	// Create treatment change record for audit
 	if s.transparencyRepo != nil {
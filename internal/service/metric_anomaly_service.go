@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var ErrAnomalySettingsInvalid = apperr.Validation("invalid anomaly detection settings")
+
+// AnomalySettingKey is the system_settings key holding per-metric
+// AnomalyMetricConfig overrides.
+const AnomalySettingKey = "metric_anomaly_detection"
+
+// Metrics the anomaly detector watches.
+const (
+	AnomalyMetricSignups      = "signups"
+	AnomalyMetricRevenue      = "revenue"
+	AnomalyMetricServerErrors = "server_errors"
+	AnomalyMetricResponseTime = "response_time"
+)
+
+// Detection methods.
+const (
+	// AnomalyMethodZScore compares the point with the mean and standard
+	// deviation of the previous Window points.
+	AnomalyMethodZScore = "zscore"
+	// AnomalyMethodEWMA compares it with an exponentially weighted mean and
+	// variance over the previous Window points, so recent points count
+	// more and a level shift stops alerting sooner.
+	AnomalyMethodEWMA = "ewma"
+)
+
+// AdminNotificationKindMetricAnomaly is the admin_notifications kind the
+// detector opens.
+const AdminNotificationKindMetricAnomaly = "metric_anomaly"
+
+// AnomalyMetricConfig is the detector's sensitivity for one metric.
+type AnomalyMetricConfig struct {
+	Enabled bool   `json:"enabled"`
+	Method  string `json:"method"`
+	// Threshold is how many standard deviations from expected a point must
+	// be to count. Twice this is critical.
+	Threshold float64 `json:"threshold"`
+	// Window is how many previous buckets (days or hours, by metric) the
+	// expectation is built from; MinPoints of them must have data.
+	Window    int `json:"window"`
+	MinPoints int `json:"min_points"`
+	// Alpha is the EWMA smoothing factor, 0 < alpha <= 1.
+	Alpha float64 `json:"alpha,omitempty"`
+	// Direction is "up", "down" or "both": which deviations are reported.
+	Direction string `json:"direction"`
+}
+
+// anomalyMetric describes where a metric's series comes from.
+type anomalyMetric struct {
+	label string
+	unit  string
+	step  time.Duration
+	// sparse metrics have no value in a bucket with no activity (a mean
+	// response time with no requests) or not yet recorded (a revenue
+	// snapshot still to be written); others read a missing bucket as 0.
+	sparse bool
+	series func(repository.MetricSeriesRepository) func(ctx context.Context, from, to time.Time) ([]repository.MetricSample, error)
+	// lag is how far behind now the newest complete bucket ends: a day for
+	// daily metrics, and for hourly ones the hour the log retention job
+	// needs to roll it up.
+	lag time.Duration
+}
+
+var anomalyMetrics = map[string]anomalyMetric{
+	AnomalyMetricSignups: {
+		label: "Signups", unit: "signups", step: 24 * time.Hour,
+		series: func(r repository.MetricSeriesRepository) func(context.Context, time.Time, time.Time) ([]repository.MetricSample, error) {
+			return r.DailySignups
+		},
+	},
+	AnomalyMetricRevenue: {
+		label: "Revenue", unit: "cents", step: 24 * time.Hour, sparse: true,
+		series: func(r repository.MetricSeriesRepository) func(context.Context, time.Time, time.Time) ([]repository.MetricSample, error) {
+			return r.DailyRevenue
+		},
+	},
+	AnomalyMetricServerErrors: {
+		label: "Server errors", unit: "errors", step: time.Hour, lag: time.Hour,
+		series: func(r repository.MetricSeriesRepository) func(context.Context, time.Time, time.Time) ([]repository.MetricSample, error) {
+			return r.HourlyServerErrors
+		},
+	},
+	AnomalyMetricResponseTime: {
+		label: "Mean response time", unit: "ms", step: time.Hour, lag: time.Hour, sparse: true,
+		series: func(r repository.MetricSeriesRepository) func(context.Context, time.Time, time.Time) ([]repository.MetricSample, error) {
+			return r.HourlyResponseTimes
+		},
+	},
+}
+
+// DefaultAnomalySettings applies to each metric until an admin saves a
+// config for it. Errors and latency only alert upwards; a quiet hour is
+// good news.
+var DefaultAnomalySettings = map[string]AnomalyMetricConfig{
+	AnomalyMetricSignups:      {Enabled: true, Method: AnomalyMethodZScore, Threshold: 3, Window: 28, MinPoints: 14, Direction: "both"},
+	AnomalyMetricRevenue:      {Enabled: true, Method: AnomalyMethodEWMA, Threshold: 3, Window: 56, MinPoints: 14, Alpha: 0.1, Direction: "both"},
+	AnomalyMetricServerErrors: {Enabled: true, Method: AnomalyMethodEWMA, Threshold: 4, Window: 168, MinPoints: 48, Alpha: 0.05, Direction: "up"},
+	AnomalyMetricResponseTime: {Enabled: true, Method: AnomalyMethodZScore, Threshold: 4, Window: 168, MinPoints: 48, Direction: "up"},
+}
+
+// Validate checks one metric's config.
+func (c AnomalyMetricConfig) Validate() error {
+	switch c.Method {
+	case AnomalyMethodZScore:
+	case AnomalyMethodEWMA:
+		if c.Alpha <= 0 || c.Alpha > 1 {
+			return fmt.Errorf("%w: alpha must be in (0, 1]", ErrAnomalySettingsInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: method must be %q or %q", ErrAnomalySettingsInvalid, AnomalyMethodZScore, AnomalyMethodEWMA)
+	}
+	if c.Threshold < 1 || c.Threshold > 20 {
+		return fmt.Errorf("%w: threshold must be between 1 and 20", ErrAnomalySettingsInvalid)
+	}
+	if c.Window < 3 || c.Window > 1000 {
+		return fmt.Errorf("%w: window must be between 3 and 1000", ErrAnomalySettingsInvalid)
+	}
+	if c.MinPoints < 2 || c.MinPoints > c.Window {
+		return fmt.Errorf("%w: min_points must be between 2 and window", ErrAnomalySettingsInvalid)
+	}
+	switch c.Direction {
+	case "up", "down", "both":
+	default:
+		return fmt.Errorf("%w: direction must be up, down or both", ErrAnomalySettingsInvalid)
+	}
+	return nil
+}
+
+// MetricAnomaly is a point that deviated from its expected value.
+type MetricAnomaly struct {
+	Metric   string    `json:"metric"`
+	Bucket   time.Time `json:"bucket"`
+	Value    float64   `json:"value"`
+	Expected float64   `json:"expected"`
+	// Spread is the standard deviation the score is measured in.
+	Spread   float64 `json:"spread"`
+	Score    float64 `json:"score"`
+	Severity string  `json:"severity"`
+	// Notified is false when the bucket had already been notified.
+	Notified bool `json:"notified"`
+}
+
+// DetectAnomaly scores value against history (oldest first, missing points
+// as NaN) using cfg. It returns the expected value, the spread and the
+// score, and ok=false when there's too little history to judge.
+//
+// The spread is floored at 5% of the expected value, and at 1, so a
+// near-constant history doesn't turn every small wobble into a huge score.
+func DetectAnomaly(cfg AnomalyMetricConfig, history []float64, value float64) (expected, spread, score float64, ok bool) {
+	var points []float64
+	for _, v := range history {
+		if !math.IsNaN(v) {
+			points = append(points, v)
+		}
+	}
+	if len(points) < cfg.MinPoints || len(points) < 2 {
+		return 0, 0, 0, false
+	}
+	var variance float64
+	switch cfg.Method {
+	case AnomalyMethodEWMA:
+		expected = points[0]
+		for _, v := range points[1:] {
+			diff := v - expected
+			expected += cfg.Alpha * diff
+			variance = (1 - cfg.Alpha) * (variance + cfg.Alpha*diff*diff)
+		}
+	default:
+		for _, v := range points {
+			expected += v
+		}
+		expected /= float64(len(points))
+		for _, v := range points {
+			variance += (v - expected) * (v - expected)
+		}
+		variance /= float64(len(points))
+	}
+	spread = math.Max(math.Sqrt(variance), math.Max(0.05*math.Abs(expected), 1))
+	return expected, spread, (value - expected) / spread, true
+}
+
+// isAnomalous reports whether score crosses cfg's threshold in a watched
+// direction.
+func (c AnomalyMetricConfig) isAnomalous(score float64) bool {
+	switch c.Direction {
+	case "up":
+		return score >= c.Threshold
+	case "down":
+		return score <= -c.Threshold
+	}
+	return math.Abs(score) >= c.Threshold
+}
+
+type adminNotifier interface {
+	Open(ctx context.Context, n *repository.AdminNotification) (bool, error)
+}
+
+// MetricAnomalyService watches system metric series and opens an admin
+// notification when the latest complete bucket of one deviates sharply
+// from its recent history.
+type MetricAnomalyService struct {
+	series   repository.MetricSeriesRepository
+	notify   adminNotifier
+	settings settingsStore
+	now      func() time.Time
+}
+
+func NewMetricAnomalyService(series repository.MetricSeriesRepository, notify adminNotifier, settings settingsStore) *MetricAnomalyService {
+	return &MetricAnomalyService{series: series, notify: notify, settings: settings, now: time.Now}
+}
+
+// Settings returns every metric's config: the stored one where saved,
+// the default otherwise.
+func (s *MetricAnomalyService) Settings(ctx context.Context) (map[string]AnomalyMetricConfig, error) {
+	out := make(map[string]AnomalyMetricConfig, len(DefaultAnomalySettings))
+	for k, v := range DefaultAnomalySettings {
+		out[k] = v
+	}
+	val, err := s.settings.GetSetting(ctx, AnomalySettingKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	var stored map[string]AnomalyMetricConfig
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		log.Printf("[ANOMALY] %s setting unreadable, using defaults: %v", AnomalySettingKey, err)
+		return out, nil
+	}
+	for k, v := range stored {
+		if _, known := anomalyMetrics[k]; known && v.Validate() == nil {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// UpdateSettings validates and stores configs for the metrics in update;
+// metrics not in it keep their current config.
+func (s *MetricAnomalyService) UpdateSettings(ctx context.Context, update map[string]AnomalyMetricConfig, by uuid.UUID) (map[string]AnomalyMetricConfig, error) {
+	current, err := s.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range update {
+		if _, known := anomalyMetrics[k]; !known {
+			return nil, fmt.Errorf("%w: unknown metric %q", ErrAnomalySettingsInvalid, k)
+		}
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		current[k] = v
+	}
+	if err := s.settings.UpdateSetting(ctx, AnomalySettingKey, current, by); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// Scan checks the latest complete bucket of every enabled metric and
+// opens a notification for each anomaly not already notified. A metric
+// whose series can't be read is logged and skipped.
+func (s *MetricAnomalyService) Scan(ctx context.Context) ([]MetricAnomaly, error) {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(anomalyMetrics))
+	for name := range anomalyMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	anomalies := []MetricAnomaly{}
+	for _, name := range names {
+		cfg := settings[name]
+		if !cfg.Enabled {
+			continue
+		}
+		a, err := s.check(ctx, name, cfg)
+		if err != nil {
+			log.Printf("[ANOMALY] %s: %v", name, err)
+			continue
+		}
+		if a == nil {
+			continue
+		}
+		if a.Notified, err = s.open(ctx, a); err != nil {
+			log.Printf("[ANOMALY] notify %s: %v", name, err)
+		}
+		anomalies = append(anomalies, *a)
+	}
+	return anomalies, nil
+}
+
+// check scores the metric's latest complete bucket, returning nil when it
+// isn't anomalous or can't be judged.
+func (s *MetricAnomalyService) check(ctx context.Context, name string, cfg AnomalyMetricConfig) (*MetricAnomaly, error) {
+	m := anomalyMetrics[name]
+	latest := s.now().UTC().Add(-m.lag).Truncate(m.step).Add(-m.step)
+	from := latest.Add(-time.Duration(cfg.Window) * m.step)
+	samples, err := m.series(s.series)(ctx, from, latest.Add(m.step))
+	if err != nil {
+		return nil, err
+	}
+	byBucket := make(map[time.Time]float64, len(samples))
+	for _, sm := range samples {
+		byBucket[sm.Bucket] = sm.Value
+	}
+	lookup := func(b time.Time) float64 {
+		if v, ok := byBucket[b]; ok {
+			return v
+		}
+		if m.sparse {
+			return math.NaN()
+		}
+		return 0
+	}
+
+	value := lookup(latest)
+	if math.IsNaN(value) {
+		return nil, nil
+	}
+	history := make([]float64, 0, cfg.Window)
+	for b := from; b.Before(latest); b = b.Add(m.step) {
+		history = append(history, lookup(b))
+	}
+	expected, spread, score, ok := DetectAnomaly(cfg, history, value)
+	if !ok || !cfg.isAnomalous(score) {
+		return nil, nil
+	}
+	severity := "warning"
+	if math.Abs(score) >= 2*cfg.Threshold {
+		severity = "critical"
+	}
+	return &MetricAnomaly{Metric: name, Bucket: latest, Value: value, Expected: expected,
+		Spread: spread, Score: score, Severity: severity}, nil
+}
+
+func (s *MetricAnomalyService) open(ctx context.Context, a *MetricAnomaly) (bool, error) {
+	m := anomalyMetrics[a.Metric]
+	period := a.Bucket.Format("2006-01-02")
+	if m.step < 24*time.Hour {
+		period = a.Bucket.Format("2006-01-02 15:04") + " UTC"
+	}
+	level, direction := "high", "above"
+	if a.Score < 0 {
+		level, direction = "low", "below"
+	}
+	details, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	return s.notify.Open(ctx, &repository.AdminNotification{
+		Kind:     AdminNotificationKindMetricAnomaly,
+		Severity: a.Severity,
+		Title:    fmt.Sprintf("%s unusually %s", m.label, level),
+		Message: fmt.Sprintf("%s for %s was %.1f %s, %s the expected %.1f (%.1f standard deviations).",
+			m.label, period, a.Value, m.unit, direction, a.Expected, math.Abs(a.Score)),
+		DedupeKey: AdminNotificationKindMetricAnomaly + ":" + a.Metric + ":" + a.Bucket.Format(time.RFC3339),
+		Details:   details,
+	})
+}
+
+// MetricAnomalyScheduler runs the detector hourly.
+type MetricAnomalyScheduler struct {
+	anomalies *MetricAnomalyService
+	jobs      *JobLocker
+}
+
+func NewMetricAnomalyScheduler(anomalies *MetricAnomalyService, jobs *JobLocker) *MetricAnomalyScheduler {
+	return &MetricAnomalyScheduler{anomalies: anomalies, jobs: jobs}
+}
+
+func (s *MetricAnomalyScheduler) Start(ctx context.Context) {
+	log.Println("Metric anomaly scheduler started")
+	check := func() {
+		s.jobs.RunOnce(ctx, "metric_anomalies", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			found, err := s.anomalies.Scan(ctx)
+			if err != nil {
+				log.Printf("Metric anomalies: tick failed: %v", err)
+				return
+			}
+			for _, a := range found {
+				if a.Notified {
+					log.Printf("Metric anomalies: %s at %s is %.1f (expected %.1f)", a.Metric, a.Bucket.Format(time.RFC3339), a.Value, a.Expected)
+				}
+			}
+		})
+	}
+	check()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Metric anomaly scheduler stopped")
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
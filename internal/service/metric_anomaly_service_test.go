@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeMetricSeries struct {
+	repository.MetricSeriesRepository
+	signups []repository.MetricSample
+	latency []repository.MetricSample
+}
+
+func (f *fakeMetricSeries) DailySignups(ctx context.Context, from, to time.Time) ([]repository.MetricSample, error) {
+	return inRange(f.signups, from, to), nil
+}
+
+func (f *fakeMetricSeries) DailyRevenue(ctx context.Context, from, to time.Time) ([]repository.MetricSample, error) {
+	return nil, nil
+}
+
+func (f *fakeMetricSeries) HourlyServerErrors(ctx context.Context, from, to time.Time) ([]repository.MetricSample, error) {
+	return nil, errors.New("rollup table missing")
+}
+
+func (f *fakeMetricSeries) HourlyResponseTimes(ctx context.Context, from, to time.Time) ([]repository.MetricSample, error) {
+	return inRange(f.latency, from, to), nil
+}
+
+func inRange(samples []repository.MetricSample, from, to time.Time) []repository.MetricSample {
+	var out []repository.MetricSample
+	for _, s := range samples {
+		if !s.Bucket.Before(from) && s.Bucket.Before(to) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+type fakeNotifier struct {
+	opened map[string]repository.AdminNotification
+}
+
+func (f *fakeNotifier) Open(ctx context.Context, n *repository.AdminNotification) (bool, error) {
+	if _, ok := f.opened[n.DedupeKey]; ok {
+		return false, nil
+	}
+	f.opened[n.DedupeKey] = *n
+	return true, nil
+}
+
+func TestDetectAnomaly(t *testing.T) {
+	zscore := AnomalyMetricConfig{Method: AnomalyMethodZScore, Threshold: 3, Window: 10, MinPoints: 5, Direction: "both"}
+	history := []float64{10, 12, 8, 10, 12, 8, 10, 12, 8, 10}
+
+	expected, spread, score, ok := DetectAnomaly(zscore, history, 30)
+	if !ok || math.Abs(expected-10) > 1e-9 || score < 3 {
+		t.Fatalf("spike: expected %v spread %v score %v ok %v", expected, spread, score, ok)
+	}
+	if _, _, score, _ := DetectAnomaly(zscore, history, 11); zscore.isAnomalous(score) {
+		t.Errorf("11 against ~10 scored %v", score)
+	}
+
+	// Gaps don't count towards MinPoints.
+	sparse := []float64{10, math.NaN(), math.NaN(), 12, math.NaN(), 8, math.NaN(), 10}
+	if _, _, _, ok := DetectAnomaly(zscore, sparse, 30); ok {
+		t.Error("judged with fewer than MinPoints points")
+	}
+
+	// A flat history floors the spread instead of dividing by zero.
+	flat := []float64{0, 0, 0, 0, 0, 0}
+	if _, spread, score, ok := DetectAnomaly(zscore, flat, 2); !ok || spread != 1 || score != 2 {
+		t.Errorf("flat history: spread %v score %v", spread, score)
+	}
+
+	// EWMA follows a level shift, so the new level stops looking anomalous.
+	ewma := AnomalyMetricConfig{Method: AnomalyMethodEWMA, Threshold: 3, Window: 40, MinPoints: 5, Alpha: 0.3, Direction: "both"}
+	var shifted []float64
+	for i := 0; i < 20; i++ {
+		shifted = append(shifted, 100)
+	}
+	if _, _, score, _ := DetectAnomaly(ewma, shifted, 200); !ewma.isAnomalous(score) {
+		t.Errorf("jump to 200 scored %v", score)
+	}
+	for i := 0; i < 20; i++ {
+		shifted = append(shifted, 200)
+	}
+	if expected, _, score, _ := DetectAnomaly(ewma, shifted, 200); ewma.isAnomalous(score) || expected < 199 {
+		t.Errorf("settled at 200: expected %v score %v", expected, score)
+	}
+
+	up := zscore
+	up.Direction = "up"
+	if _, _, score, _ := DetectAnomaly(up, history, -20); up.isAnomalous(score) {
+		t.Error("a drop alerted on an up-only metric")
+	}
+}
+
+func TestMetricAnomalyScan(t *testing.T) {
+	now := time.Date(2026, 6, 10, 14, 30, 0, 0, time.UTC)
+	yesterday := time.Date(2026, 6, 9, 0, 0, 0, 0, time.UTC)
+	series := &fakeMetricSeries{}
+	for d := 1; d <= 28; d++ {
+		day := yesterday.AddDate(0, 0, -d)
+		series.signups = append(series.signups, repository.MetricSample{Bucket: day, Value: float64(20 + d%3)})
+	}
+	series.signups = append(series.signups, repository.MetricSample{Bucket: yesterday, Value: 2})
+	// Latency: hourly history, nothing for the hour being judged.
+	for h := 3; h < 100; h++ {
+		series.latency = append(series.latency, repository.MetricSample{Bucket: now.Truncate(time.Hour).Add(-time.Duration(h) * time.Hour), Value: 120})
+	}
+
+	notifier := &fakeNotifier{opened: map[string]repository.AdminNotification{}}
+	svc := NewMetricAnomalyService(series, notifier, memSettings{})
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	found, err := svc.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Metric != AnomalyMetricSignups || !found[0].Bucket.Equal(yesterday) || !found[0].Notified {
+		t.Fatalf("found %+v", found)
+	}
+	n, ok := notifier.opened["metric_anomaly:signups:2026-06-09T00:00:00Z"]
+	if !ok {
+		t.Fatalf("opened %v", notifier.opened)
+	}
+	if n.Kind != AdminNotificationKindMetricAnomaly || n.Severity != "critical" || n.Title != "Signups unusually low" ||
+		!strings.Contains(n.Message, "2026-06-09 was 2.0 signups, below the expected") {
+		t.Errorf("notification %+v", n)
+	}
+
+	// The next hourly run finds the same day again but doesn't re-notify.
+	found, err = svc.Scan(ctx)
+	if err != nil || len(found) != 1 || found[0].Notified || len(notifier.opened) != 1 {
+		t.Fatalf("rescan: %+v, %v, %d opened", found, err, len(notifier.opened))
+	}
+
+	// Disabling the metric silences it.
+	off := DefaultAnomalySettings[AnomalyMetricSignups]
+	off.Enabled = false
+	if _, err := svc.UpdateSettings(ctx, map[string]AnomalyMetricConfig{AnomalyMetricSignups: off}, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := svc.Scan(ctx); len(found) != 0 {
+		t.Errorf("disabled metric still found %+v", found)
+	}
+}
+
+func TestMetricAnomalySettings(t *testing.T) {
+	settings := memSettings{}
+	svc := NewMetricAnomalyService(&fakeMetricSeries{}, &fakeNotifier{}, settings)
+	ctx := context.Background()
+
+	got, err := svc.Settings(ctx)
+	if err != nil || got[AnomalyMetricRevenue] != DefaultAnomalySettings[AnomalyMetricRevenue] {
+		t.Fatalf("defaults = %+v, %v", got, err)
+	}
+
+	tight := DefaultAnomalySettings[AnomalyMetricRevenue]
+	tight.Threshold = 2
+	if _, err := svc.UpdateSettings(ctx, map[string]AnomalyMetricConfig{AnomalyMetricRevenue: tight}, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = svc.Settings(ctx)
+	if got[AnomalyMetricRevenue].Threshold != 2 || got[AnomalyMetricSignups] != DefaultAnomalySettings[AnomalyMetricSignups] {
+		t.Errorf("after update %+v", got)
+	}
+
+	for name, bad := range map[string]map[string]AnomalyMetricConfig{
+		"unknown metric": {"page_views": DefaultAnomalySettings[AnomalyMetricSignups]},
+		"bad method":     {AnomalyMetricSignups: {Enabled: true, Method: "median", Threshold: 3, Window: 28, MinPoints: 14, Direction: "both"}},
+		"ewma no alpha":  {AnomalyMetricSignups: {Enabled: true, Method: AnomalyMethodEWMA, Threshold: 3, Window: 28, MinPoints: 14, Direction: "both"}},
+		"min > window":   {AnomalyMetricSignups: {Enabled: true, Method: AnomalyMethodZScore, Threshold: 3, Window: 7, MinPoints: 14, Direction: "both"}},
+		"bad direction":  {AnomalyMetricSignups: {Enabled: true, Method: AnomalyMethodZScore, Threshold: 3, Window: 28, MinPoints: 14, Direction: "sideways"}},
+	} {
+		if _, err := svc.UpdateSettings(ctx, bad, uuid.New()); !errors.Is(err, ErrAnomalySettingsInvalid) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// metricsRefresher is the part of AdminRepository the scheduler needs.
+type metricsRefresher interface {
+	RefreshMetrics(ctx context.Context) error
+}
+
+// MetricsRefreshScheduler refreshes the admin dashboard metrics hourly.
+// Each refresh also records the day's point in system_metrics_history, so
+// the trend charts get a point every day whether or not anyone presses
+// Refresh.
+type MetricsRefreshScheduler struct {
+	metrics metricsRefresher
+	jobs    *JobLocker
+}
+
+func NewMetricsRefreshScheduler(metrics metricsRefresher, jobs *JobLocker) *MetricsRefreshScheduler {
+	return &MetricsRefreshScheduler{metrics: metrics, jobs: jobs}
+}
+
+func (s *MetricsRefreshScheduler) Start(ctx context.Context) {
+	log.Println("Metrics refresh scheduler started")
+	check := func() {
+		s.jobs.RunOnce(ctx, "metrics_refresh", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			if err := s.metrics.RefreshMetrics(ctx); err != nil {
+				log.Printf("Metrics refresh: tick failed: %v", err)
+			}
+		})
+	}
+	check()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Metrics refresh scheduler stopped")
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrOnboardingStepUnknown = errors.New("unknown onboarding step")
+	// ErrOnboardingStepNotDone is returned when a client marks a step
+	// complete but the server can't see it in the user's data yet.
+	ErrOnboardingStepNotDone  = errors.New("onboarding step not completed yet")
+	ErrOnboardingStepRequired = apperr.Validation("onboarding step can't be skipped")
+)
+
+// Onboarding step statuses.
+const (
+	OnboardingStatusDone    = "done"
+	OnboardingStatusSkipped = "skipped"
+	OnboardingStatusPending = "pending"
+)
+
+const (
+	// onboardingReconcileWindow is how far back the hourly job re-checks
+	// signups, and the longest funnel the admin report covers.
+	onboardingReconcileWindow = 90 * 24 * time.Hour
+	// Stalled users are nudged once per step after this long without
+	// progress, and only during their first two weeks.
+	onboardingNudgeIdle   = 48 * time.Hour
+	onboardingNudgeWindow = 14 * 24 * time.Hour
+	onboardingNudgeBatch  = 500
+	// Nudges go out between these local hours.
+	onboardingNudgeFromHour = 9
+	onboardingNudgeToHour   = 20
+)
+
+// onboardingOptional are the steps a user may skip — not every child
+// takes medication.
+var onboardingOptional = map[string]bool{
+	models.OnboardingStepAddMedication: true,
+}
+
+// onboardingNudges is the push each stalled step sends.
+var onboardingNudges = map[string]PushMessage{
+	models.OnboardingStepVerifyEmail: {
+		Title: "Confirm your email",
+		Body:  "Confirm your email address so care alerts and reports reach you.",
+	},
+	models.OnboardingStepCreateFamily: {
+		Title: "Set up your family",
+		Body:  "Create your family to start tracking care in one place.",
+	},
+	models.OnboardingStepAddChild: {
+		Title: "Add your child",
+		Body:  "Add your child's profile so you can start logging.",
+	},
+	models.OnboardingStepAddMedication: {
+		Title: "Add medications",
+		Body:  "Add your child's medications to get dose reminders and change tracking.",
+	},
+	models.OnboardingStepFirstLog: {
+		Title: "Log your first entry",
+		Body:  "A quick behavior, sleep or diet entry is all it takes to get started.",
+	},
+}
+
+// OnboardingStepStatus is one step of a user's guided setup.
+type OnboardingStepStatus struct {
+	Step        string     `json:"step"`
+	Status      string     `json:"status"`
+	Optional    bool       `json:"optional"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// OnboardingProgress is a user's guided setup state. NextStep is the first
+// pending step, empty once every step is resolved.
+type OnboardingProgress struct {
+	Steps    []OnboardingStepStatus `json:"steps"`
+	NextStep string                 `json:"next_step,omitempty"`
+	Complete bool                   `json:"complete"`
+}
+
+// OnboardingFunnel is the admin report: how far the users who signed up in
+// [From, To) got through guided setup.
+type OnboardingFunnel struct {
+	From     time.Time                         `json:"from"`
+	To       time.Time                         `json:"to"`
+	SignedUp int                               `json:"signed_up"`
+	Steps    []repository.OnboardingFunnelStep `json:"steps"`
+}
+
+// onboardingPusher is the slice of PushService nudging needs.
+type onboardingPusher interface {
+	Send(ctx context.Context, userID uuid.UUID, msg PushMessage) error
+}
+
+// OnboardingService tracks each new user's guided setup, nudges users who
+// stall, and reports the setup funnel.
+type OnboardingService struct {
+	repo repository.OnboardingRepository
+	push onboardingPusher
+	now  func() time.Time
+}
+
+func NewOnboardingService(repo repository.OnboardingRepository, push onboardingPusher) *OnboardingService {
+	return &OnboardingService{repo: repo, push: push, now: time.Now}
+}
+
+func knownOnboardingStep(step string) bool {
+	for _, s := range models.OnboardingSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// onboardingProgress lays resolved records over the ordered step list.
+func onboardingProgress(records []models.OnboardingStepRecord) *OnboardingProgress {
+	byStep := make(map[string]models.OnboardingStepRecord, len(records))
+	for _, r := range records {
+		byStep[r.Step] = r
+	}
+	p := &OnboardingProgress{Steps: make([]OnboardingStepStatus, len(models.OnboardingSteps))}
+	for i, step := range models.OnboardingSteps {
+		st := OnboardingStepStatus{Step: step, Status: OnboardingStatusPending, Optional: onboardingOptional[step]}
+		if r, ok := byStep[step]; ok {
+			at := r.CompletedAt
+			st.CompletedAt = &at
+			st.Status = OnboardingStatusDone
+			if r.Skipped {
+				st.Status = OnboardingStatusSkipped
+			}
+		} else if p.NextStep == "" {
+			p.NextStep = step
+		}
+		p.Steps[i] = st
+	}
+	p.Complete = p.NextStep == ""
+	return p
+}
+
+// Progress re-checks the user's data and returns their setup state.
+func (s *OnboardingService) Progress(ctx context.Context, userID uuid.UUID) (*OnboardingProgress, error) {
+	if err := s.repo.ReconcileUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	records, err := s.repo.Steps(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return onboardingProgress(records), nil
+}
+
+// CompleteStep is called by clients right after the user finishes a step,
+// so the checklist updates without waiting for the hourly job. Completion
+// is still verified against the user's data; a step that can't be seen
+// yet returns ErrOnboardingStepNotDone.
+func (s *OnboardingService) CompleteStep(ctx context.Context, userID uuid.UUID, step string) (*OnboardingProgress, error) {
+	if !knownOnboardingStep(step) {
+		return nil, ErrOnboardingStepUnknown
+	}
+	p, err := s.Progress(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range p.Steps {
+		if st.Step == step && st.Status == OnboardingStatusPending {
+			return nil, ErrOnboardingStepNotDone
+		}
+	}
+	return p, nil
+}
+
+// SkipStep marks an optional step skipped. Doing it later still flips it
+// to done.
+func (s *OnboardingService) SkipStep(ctx context.Context, userID uuid.UUID, step string) (*OnboardingProgress, error) {
+	if !knownOnboardingStep(step) {
+		return nil, ErrOnboardingStepUnknown
+	}
+	if !onboardingOptional[step] {
+		return nil, ErrOnboardingStepRequired
+	}
+	if err := s.repo.Skip(ctx, userID, step); err != nil {
+		return nil, err
+	}
+	return s.Progress(ctx, userID)
+}
+
+// nextNudgeStep returns the step a stalled user should be reminded about:
+// their first unresolved step, unless they've already been nudged about
+// it. Email verification doesn't block the family → child → medication →
+// first log chain, so an already-nudged verify_email falls through to it.
+func nextNudgeStep(c repository.OnboardingCandidate) string {
+	has := func(list []string, step string) bool {
+		for _, s := range list {
+			if s == step {
+				return true
+			}
+		}
+		return false
+	}
+	for _, step := range models.OnboardingSteps {
+		if has(c.Resolved, step) {
+			continue
+		}
+		if !has(c.Nudged, step) {
+			return step
+		}
+		if step != models.OnboardingStepVerifyEmail {
+			return ""
+		}
+	}
+	return ""
+}
+
+// nudgeHour reports whether it's daytime for the user, falling back to
+// UTC for an unknown zone.
+func nudgeHour(now time.Time, tz string) bool {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	h := now.In(loc).Hour()
+	return h >= onboardingNudgeFromHour && h < onboardingNudgeToHour
+}
+
+// Run reconciles recent signups' steps, then pushes one reminder to each
+// stalled user whose local time is daytime. Returns how many were nudged.
+func (s *OnboardingService) Run(ctx context.Context) (int, error) {
+	now := s.now()
+	if n, err := s.repo.ReconcileSince(ctx, now.Add(-onboardingReconcileWindow)); err != nil {
+		return 0, err
+	} else if n > 0 {
+		log.Printf("Onboarding: recorded %d completed steps", n)
+	}
+	if s.push == nil {
+		return 0, nil
+	}
+
+	stalled, err := s.repo.Stalled(ctx, now.Add(-onboardingNudgeWindow), now.Add(-onboardingNudgeIdle), onboardingNudgeBatch)
+	if err != nil {
+		return 0, err
+	}
+	nudged := 0
+	for _, c := range stalled {
+		step := nextNudgeStep(c)
+		if step == "" || !nudgeHour(now, c.Timezone) {
+			continue
+		}
+		msg := onboardingNudges[step]
+		msg.Priority = PushPriorityNormal
+		msg.Data = map[string]string{"type": "onboarding_nudge", "step": step}
+		if err := s.push.Send(ctx, c.UserID, msg); err != nil {
+			log.Printf("Onboarding: nudge to %s failed: %v", c.UserID, err)
+			continue
+		}
+		if err := s.repo.RecordNudge(ctx, c.UserID, step); err != nil {
+			return nudged, err
+		}
+		nudged++
+	}
+	return nudged, nil
+}
+
+// Funnel reports guided setup for users who signed up in the last days
+// days (capped at the reconcile window).
+func (s *OnboardingService) Funnel(ctx context.Context, days int) (*OnboardingFunnel, error) {
+	maxDays := int(onboardingReconcileWindow / (24 * time.Hour))
+	if days <= 0 || days > maxDays {
+		days = maxDays
+	}
+	to := s.now().UTC()
+	from := utcDay(to).AddDate(0, 0, -days+1)
+	signedUp, steps, err := s.repo.Funnel(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &OnboardingFunnel{From: from, To: to, SignedUp: signedUp, Steps: steps}, nil
+}
+
+// OnboardingScheduler reconciles setup steps and sends nudges hourly.
+type OnboardingScheduler struct {
+	svc  *OnboardingService
+	jobs *JobLocker
+}
+
+func NewOnboardingScheduler(svc *OnboardingService, jobs *JobLocker) *OnboardingScheduler {
+	return &OnboardingScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *OnboardingScheduler) Start(ctx context.Context) {
+	log.Println("Onboarding scheduler started (hourly)")
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Onboarding scheduler stopped")
+			return
+		case now := <-ticker.C:
+			s.jobs.RunOnce(ctx, "onboarding_nudges", TickSlot(now, time.Hour), time.Hour, func(ctx context.Context) {
+				n, err := s.svc.Run(ctx)
+				if err != nil {
+					log.Printf("Onboarding: run failed: %v", err)
+					return
+				}
+				if n > 0 {
+					log.Printf("Onboarding: nudged %d stalled users", n)
+				}
+			})
+		}
+	}
+}
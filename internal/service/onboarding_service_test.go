@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+func TestOnboardingProgress(t *testing.T) {
+	at := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	p := onboardingProgress([]models.OnboardingStepRecord{
+		{Step: models.OnboardingStepCreateFamily, CompletedAt: at},
+		{Step: models.OnboardingStepAddMedication, CompletedAt: at, Skipped: true},
+	})
+	if p.Complete || p.NextStep != models.OnboardingStepVerifyEmail {
+		t.Errorf("next = %q, complete = %v", p.NextStep, p.Complete)
+	}
+	want := []string{OnboardingStatusPending, OnboardingStatusDone, OnboardingStatusPending, OnboardingStatusSkipped, OnboardingStatusPending}
+	for i, st := range p.Steps {
+		if st.Status != want[i] {
+			t.Errorf("%s: status = %s, want %s", st.Step, st.Status, want[i])
+		}
+	}
+	if !p.Steps[3].Optional || p.Steps[2].Optional {
+		t.Error("only add_medication should be optional")
+	}
+
+	var all []models.OnboardingStepRecord
+	for _, s := range models.OnboardingSteps {
+		all = append(all, models.OnboardingStepRecord{Step: s, CompletedAt: at})
+	}
+	if p := onboardingProgress(all); !p.Complete || p.NextStep != "" {
+		t.Errorf("all resolved: %+v", p)
+	}
+}
+
+func TestNextNudgeStep(t *testing.T) {
+	tests := []struct {
+		name             string
+		resolved, nudged []string
+		want             string
+	}{
+		{"fresh signup", nil, nil, models.OnboardingStepVerifyEmail},
+		{"nudged to verify, falls through to family", nil, []string{"verify_email"}, models.OnboardingStepCreateFamily},
+		{"already nudged about the family", nil, []string{"verify_email", "create_family"}, ""},
+		{"has a child", []string{"verify_email", "create_family", "add_child"}, nil, models.OnboardingStepAddMedication},
+		{"skipped medication", []string{"verify_email", "create_family", "add_child", "add_medication"}, []string{"add_child"}, models.OnboardingStepFirstLog},
+	}
+	for _, tt := range tests {
+		got := nextNudgeStep(repository.OnboardingCandidate{Resolved: tt.resolved, Nudged: tt.nudged})
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNudgeHour(t *testing.T) {
+	now := time.Date(2026, 3, 20, 15, 0, 0, 0, time.UTC) // 10:00 in Chicago, 00:00 in Tokyo
+	if !nudgeHour(now, "America/Chicago") {
+		t.Error("10:00 in Chicago should be nudge time")
+	}
+	if nudgeHour(now, "Asia/Tokyo") {
+		t.Error("midnight in Tokyo should not be nudge time")
+	}
+	if !nudgeHour(now, "Not/AZone") {
+		t.Error("unknown zone should fall back to UTC")
+	}
+}
+
+type fakeOnboardingRepo struct {
+	repository.OnboardingRepository
+	stalled []repository.OnboardingCandidate
+	nudged  map[uuid.UUID]string
+}
+
+func (f *fakeOnboardingRepo) ReconcileSince(ctx context.Context, since time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeOnboardingRepo) Stalled(ctx context.Context, signedUpSince, idleBefore time.Time, limit int) ([]repository.OnboardingCandidate, error) {
+	return f.stalled, nil
+}
+
+func (f *fakeOnboardingRepo) RecordNudge(ctx context.Context, userID uuid.UUID, step string) error {
+	f.nudged[userID] = step
+	return nil
+}
+
+type fakePusher struct {
+	sent map[uuid.UUID]PushMessage
+	fail uuid.UUID
+}
+
+func (f *fakePusher) Send(ctx context.Context, userID uuid.UUID, msg PushMessage) error {
+	if userID == f.fail {
+		return errors.New("fcm down")
+	}
+	f.sent[userID] = msg
+	return nil
+}
+
+func TestOnboardingRun(t *testing.T) {
+	chicago, tokyo, done, failing := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	repo := &fakeOnboardingRepo{
+		nudged: map[uuid.UUID]string{},
+		stalled: []repository.OnboardingCandidate{
+			{UserID: chicago, Timezone: "America/Chicago", Resolved: []string{"verify_email", "create_family"}},
+			{UserID: tokyo, Timezone: "Asia/Tokyo"},
+			{UserID: done, Resolved: []string{"verify_email"}, Nudged: []string{"create_family"}},
+			{UserID: failing},
+		},
+	}
+	push := &fakePusher{sent: map[uuid.UUID]PushMessage{}, fail: failing}
+	svc := NewOnboardingService(repo, push)
+	svc.now = func() time.Time { return time.Date(2026, 3, 20, 15, 0, 0, 0, time.UTC) }
+
+	n, err := svc.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || repo.nudged[chicago] != models.OnboardingStepAddChild {
+		t.Errorf("nudged %d: %v", n, repo.nudged)
+	}
+	if msg := push.sent[chicago]; msg.Data["step"] != models.OnboardingStepAddChild || msg.Title == "" {
+		t.Errorf("push = %+v", msg)
+	}
+	if _, ok := repo.nudged[failing]; ok {
+		t.Error("a failed push should not be recorded as a nudge")
+	}
+}
+
+func TestOnboardingSkipRequiredStep(t *testing.T) {
+	svc := NewOnboardingService(&fakeOnboardingRepo{}, nil)
+	if _, err := svc.SkipStep(context.Background(), uuid.New(), models.OnboardingStepAddChild); !errors.Is(err, ErrOnboardingStepRequired) {
+		t.Errorf("err = %v, want ErrOnboardingStepRequired", err)
+	}
+	if _, err := svc.CompleteStep(context.Background(), uuid.New(), "tour"); !errors.Is(err, ErrOnboardingStepUnknown) {
+		t.Errorf("err = %v, want ErrOnboardingStepUnknown", err)
+	}
+}
@@ -0,0 +1,428 @@
+package service
+
+// organization_billing_service.go — seat-based licensing for organizations.
+//
+// An organization subscribes to a seat-based plan (PriceCents is per seat)
+// through Stripe Checkout with quantity = seats. After that, org admins
+// allocate and release seats in the app and support can set them in the
+// admin portal; each change updates the subscription item's quantity and
+// Stripe invoices the prorated difference straight away. Quantity changes
+// made in the Stripe dashboard come back through the webhook.
+//
+// Organizations support sets up without Stripe (pilots, invoiced by hand)
+// change seats locally with no proration.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// orgSeatChangesShown is how much seat history the summary carries.
+const orgSeatChangesShown = 20
+
+var (
+	ErrOrgSeatCount           = errors.New("seat count must be a positive number")
+	ErrOrgSeatsInUse          = repository.ErrOrgSeatsInUse
+	ErrOrgSeatBillingDisabled = errors.New("seat billing isn't available right now")
+	ErrOrgSeatPlanInvalid     = apperr.Validation("not an available organization plan")
+	ErrOrgAlreadySubscribed   = apperr.Conflict("organization already has a subscription")
+	ErrOrgSeatBillingFailed   = errors.New("couldn't update the subscription with the payment provider")
+)
+
+// SeatBiller changes organization subscriptions at the payment provider.
+// StripeService implements it.
+type SeatBiller interface {
+	// CreateOrganizationCheckout starts a subscription for seats of the
+	// plan and returns the URL to send the org admin to.
+	CreateOrganizationCheckout(ctx context.Context, p OrganizationCheckoutParams) (string, error)
+	// UpdateSeatQuantity sets the subscription item's quantity, invoicing
+	// the proration immediately, and returns the prorated amount in cents
+	// (negative for a credit).
+	UpdateSeatQuantity(ctx context.Context, subscriptionID, itemID string, seats int) (int64, error)
+}
+
+// OrganizationCheckoutParams is what the biller needs to start an
+// organization's subscription.
+type OrganizationCheckoutParams struct {
+	OrganizationID   uuid.UUID
+	PlanID           uuid.UUID
+	StripePriceID    string
+	Seats            int
+	StripeCustomerID string // empty for first-time customers
+	CustomerEmail    string
+}
+
+// OrgSeatSummary is an organization's seats and how they're billed.
+type OrgSeatSummary struct {
+	SeatLimit        int                        `json:"seat_limit"`
+	SeatsUsed        int                        `json:"seats_used"`
+	SeatsAvailable   int                        `json:"seats_available"`
+	Plan             *models.SubscriptionPlan   `json:"plan,omitempty"`
+	BillingStatus    string                     `json:"billing_status,omitempty"`
+	CurrentPeriodEnd *time.Time                 `json:"current_period_end,omitempty"`
+	BilledByStripe   bool                       `json:"billed_by_stripe"`
+	MonthlyCents     int64                      `json:"monthly_cents"`
+	Changes          []repository.OrgSeatChange `json:"changes"`
+	// Plans are the seat-based plans on offer, when not yet subscribed.
+	Plans []models.SubscriptionPlan `json:"plans,omitempty"`
+}
+
+// OrgSeatTotals sums seat utilization across organizations.
+type OrgSeatTotals struct {
+	Organizations int   `json:"organizations"`
+	SeatsLicensed int   `json:"seats_licensed"`
+	SeatsUsed     int   `json:"seats_used"`
+	MRRCents      int64 `json:"mrr_cents"`
+}
+
+// OrganizationBillingService sells and adjusts organization seats.
+type OrganizationBillingService struct {
+	repo   repository.OrganizationRepository
+	plans  repository.BillingRepository
+	biller SeatBiller // nil when Stripe is disabled
+}
+
+func NewOrganizationBillingService(repo repository.OrganizationRepository, plans repository.BillingRepository) *OrganizationBillingService {
+	return &OrganizationBillingService{repo: repo, plans: plans}
+}
+
+// SetSeatBiller attaches the payment provider. Without one, organizations
+// billed through Stripe can't change seats (the quantity would drift).
+func (s *OrganizationBillingService) SetSeatBiller(b SeatBiller) {
+	s.biller = b
+}
+
+// seatPlans returns the active seat-based plans.
+func (s *OrganizationBillingService) seatPlans(ctx context.Context) ([]models.SubscriptionPlan, error) {
+	all, err := s.plans.GetActivePlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []models.SubscriptionPlan
+	for _, p := range all {
+		if p.SeatBased {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// Seats returns the organization's seat summary.
+func (s *OrganizationBillingService) Seats(ctx context.Context, orgID uuid.UUID) (*OrgSeatSummary, error) {
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrgNotFound
+	}
+	billing, err := s.repo.GetBilling(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.seatPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sum := &OrgSeatSummary{
+		SeatLimit:      org.SeatLimit,
+		SeatsUsed:      org.SeatsUsed,
+		SeatsAvailable: max(org.SeatLimit-org.SeatsUsed, 0),
+	}
+	if billing != nil {
+		sum.BillingStatus = billing.Status
+		sum.CurrentPeriodEnd = billing.CurrentPeriodEnd
+		sum.BilledByStripe = billing.StripeSubscriptionItemID != ""
+		if billing.PlanID != nil {
+			for i := range plans {
+				if plans[i].ID == *billing.PlanID {
+					sum.Plan = &plans[i]
+				}
+			}
+		}
+	}
+	if sum.Plan != nil {
+		sum.MonthlyCents = int64(sum.Plan.PriceCents) * int64(org.SeatLimit)
+		if sum.Plan.BillingInterval == models.BillingIntervalYearly {
+			sum.MonthlyCents /= 12
+		}
+	}
+	if !orgSubscriptionLive(sum.BillingStatus) {
+		sum.Plans = plans
+	}
+	if sum.Changes, err = s.repo.ListSeatChanges(ctx, orgID, orgSeatChangesShown); err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// Allocate adds n seats.
+func (s *OrganizationBillingService) Allocate(ctx context.Context, orgID uuid.UUID, n int, by uuid.UUID, source string) (*OrgSeatSummary, error) {
+	if n <= 0 {
+		return nil, ErrOrgSeatCount
+	}
+	return s.adjust(ctx, orgID, func(limit int) int { return limit + n }, by, source)
+}
+
+// Release gives back n seats. Seats taken by linked families can't be
+// released; remove the families first.
+func (s *OrganizationBillingService) Release(ctx context.Context, orgID uuid.UUID, n int, by uuid.UUID, source string) (*OrgSeatSummary, error) {
+	if n <= 0 {
+		return nil, ErrOrgSeatCount
+	}
+	return s.adjust(ctx, orgID, func(limit int) int { return limit - n }, by, source)
+}
+
+// SetSeats sets the seat limit outright (admin portal).
+func (s *OrganizationBillingService) SetSeats(ctx context.Context, orgID uuid.UUID, seats int, by uuid.UUID, source string) (*OrgSeatSummary, error) {
+	if seats < 0 {
+		return nil, ErrOrgSeatCount
+	}
+	return s.adjust(ctx, orgID, func(int) int { return seats }, by, source)
+}
+
+func (s *OrganizationBillingService) adjust(ctx context.Context, orgID uuid.UUID, to func(limit int) int, by uuid.UUID, source string) (*OrgSeatSummary, error) {
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrgNotFound
+	}
+	seats := to(org.SeatLimit)
+	if seats == org.SeatLimit {
+		return s.Seats(ctx, orgID)
+	}
+	if seats < org.SeatsUsed {
+		return nil, ErrOrgSeatsInUse
+	}
+	billing, err := s.repo.GetBilling(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	stripeBilled := billing != nil && billing.StripeSubscriptionItemID != "" && orgSubscriptionLive(billing.Status)
+	if stripeBilled && seats < 1 {
+		// Stripe won't keep a subscription item at quantity 0; cancel the
+		// subscription instead.
+		return nil, ErrOrgSeatCount
+	}
+
+	change := &repository.OrgSeatChange{OrganizationID: orgID, ToSeats: seats, Source: source}
+	if by != uuid.Nil {
+		change.ChangedBy = &by
+	}
+	if stripeBilled {
+		if s.biller == nil {
+			return nil, ErrOrgSeatBillingDisabled
+		}
+		change.ProrationCents, err = s.biller.UpdateSeatQuantity(ctx, billing.StripeSubscriptionID, billing.StripeSubscriptionItemID, seats)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrOrgSeatBillingFailed, err)
+		}
+	}
+	if err := s.repo.ChangeSeats(ctx, change); err != nil {
+		if stripeBilled {
+			// A family took a seat between our check and the lock. Put
+			// Stripe back; the proration nets out on the next invoice.
+			if _, rerr := s.biller.UpdateSeatQuantity(ctx, billing.StripeSubscriptionID, billing.StripeSubscriptionItemID, org.SeatLimit); rerr != nil {
+				log.Printf("[ORG_BILLING] org %s: seat change failed (%v) and reverting Stripe quantity to %d failed: %v",
+					orgID, err, org.SeatLimit, rerr)
+			}
+		}
+		return nil, err
+	}
+	return s.Seats(ctx, orgID)
+}
+
+// Checkout starts a seat-based subscription for the organization and
+// returns the Stripe Checkout URL.
+func (s *OrganizationBillingService) Checkout(ctx context.Context, orgID, planID uuid.UUID, seats int, email string) (string, error) {
+	if s.biller == nil {
+		return "", ErrOrgSeatBillingDisabled
+	}
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+	if org == nil {
+		return "", ErrOrgNotFound
+	}
+	billing, err := s.repo.GetBilling(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+	if billing != nil && orgSubscriptionLive(billing.Status) {
+		return "", ErrOrgAlreadySubscribed
+	}
+	if seats < 1 || seats < org.SeatsUsed {
+		return "", fmt.Errorf("%w: at least %d needed for the families already linked", ErrOrgSeatCount, max(org.SeatsUsed, 1))
+	}
+	plans, err := s.seatPlans(ctx)
+	if err != nil {
+		return "", err
+	}
+	var plan *models.SubscriptionPlan
+	for i := range plans {
+		if plans[i].ID == planID {
+			plan = &plans[i]
+		}
+	}
+	if plan == nil || !plan.StripePriceID.Valid || plan.StripePriceID.String == "" {
+		return "", ErrOrgSeatPlanInvalid
+	}
+	p := OrganizationCheckoutParams{
+		OrganizationID: orgID,
+		PlanID:         planID,
+		StripePriceID:  plan.StripePriceID.String,
+		Seats:          seats,
+		CustomerEmail:  email,
+	}
+	if billing != nil {
+		p.StripeCustomerID = billing.StripeCustomerID
+	}
+	if p.CustomerEmail == "" {
+		p.CustomerEmail = org.ContactEmail
+	}
+	return s.biller.CreateOrganizationCheckout(ctx, p)
+}
+
+// ApplyCheckoutCompleted records the subscription a completed checkout
+// created. The item ID and quantity arrive with the subscription event.
+func (s *OrganizationBillingService) ApplyCheckoutCompleted(ctx context.Context, orgID, planID uuid.UUID, customerID, subscriptionID, status string, periodEnd time.Time) error {
+	billing, err := s.repo.GetBilling(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if billing == nil {
+		log.Printf("[ORG_BILLING] checkout completed for unknown organization %s (sub=%s)", orgID, subscriptionID)
+		return nil
+	}
+	if billing.StripeSubscriptionID != subscriptionID {
+		billing.StripeSubscriptionItemID = ""
+	}
+	billing.PlanID = &planID
+	billing.Status = status
+	billing.CurrentPeriodEnd = &periodEnd
+	billing.StripeCustomerID = customerID
+	billing.StripeSubscriptionID = subscriptionID
+	return s.repo.SaveBilling(ctx, billing)
+}
+
+// OrgSubscriptionUpdate is the state of an organization's Stripe
+// subscription from a customer.subscription.* event.
+type OrgSubscriptionUpdate struct {
+	OrganizationID uuid.UUID // from subscription metadata; Nil if absent
+	SubscriptionID string
+	CustomerID     string
+	ItemID         string
+	Seats          int
+	Status         string
+	PeriodEnd      time.Time
+	Deleted        bool
+}
+
+// ApplySubscriptionUpdated syncs the organization with its Stripe
+// subscription. A quantity changed outside the app becomes the seat
+// limit; a cancelled subscription suspends the organization.
+func (s *OrganizationBillingService) ApplySubscriptionUpdated(ctx context.Context, u OrgSubscriptionUpdate) error {
+	billing, err := s.repo.GetBillingByStripeSubscription(ctx, u.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if billing == nil && u.OrganizationID != uuid.Nil {
+		// The subscription event can beat checkout.session.completed.
+		billing, err = s.repo.GetBilling(ctx, u.OrganizationID)
+		if err != nil {
+			return err
+		}
+	}
+	if billing == nil {
+		log.Printf("[ORG_BILLING] subscription %s not linked to an organization — ignoring", u.SubscriptionID)
+		return nil
+	}
+	billing.Status = u.Status
+	if u.Deleted {
+		billing.Status = "canceled"
+	}
+	billing.CurrentPeriodEnd = &u.PeriodEnd
+	billing.StripeSubscriptionID = u.SubscriptionID
+	if u.CustomerID != "" {
+		billing.StripeCustomerID = u.CustomerID
+	}
+	if u.ItemID != "" {
+		billing.StripeSubscriptionItemID = u.ItemID
+	}
+	if err := s.repo.SaveBilling(ctx, billing); err != nil {
+		return err
+	}
+
+	org, err := s.repo.Get(ctx, billing.OrganizationID)
+	if err != nil || org == nil {
+		return err
+	}
+	if !u.Deleted && u.Seats > 0 && u.Seats != org.SeatLimit {
+		if err := s.repo.ChangeSeats(ctx, &repository.OrgSeatChange{
+			OrganizationID: org.ID,
+			ToSeats:        u.Seats,
+			Source:         repository.OrgSeatSourceStripe,
+		}); err != nil {
+			return err
+		}
+	}
+	if u.Deleted && org.Status == repository.OrgStatusActive {
+		org.Status = repository.OrgStatusSuspended
+		if _, err := s.repo.Update(ctx, org); err != nil {
+			return err
+		}
+		log.Printf("[ORG_BILLING] org %s suspended: subscription %s cancelled", org.ID, u.SubscriptionID)
+	}
+	return nil
+}
+
+// Utilization returns every organization's seats with totals, for the
+// admin financials view. MRR counts organizations with a live
+// subscription only.
+func (s *OrganizationBillingService) Utilization(ctx context.Context) (*OrgSeatTotals, []repository.OrgSeatUsage, error) {
+	rows, err := s.repo.SeatUsage(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := &OrgSeatTotals{Organizations: len(rows)}
+	for _, u := range rows {
+		t.SeatsLicensed += u.SeatLimit
+		t.SeatsUsed += u.SeatsUsed
+		if orgSubscriptionLive(u.BillingStatus) {
+			t.MRRCents += int64(u.PricePerSeatCents) * int64(u.SeatLimit)
+		}
+	}
+	return t, rows, nil
+}
+
+// orgSubscriptionLive reports whether a Stripe subscription status still
+// bills (and so still holds the organization's seats).
+func orgSubscriptionLive(status string) bool {
+	switch status {
+	case "active", "trialing", "past_due":
+		return true
+	}
+	return false
+}
+
+// orgBillingLapsed reports whether an organization that subscribed has
+// lost its subscription, so no more families should join.
+func orgBillingLapsed(status string) bool {
+	switch status {
+	case "canceled", "unpaid", "incomplete_expired":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakeSeatRepo struct {
+	fakeOrganizationRepo
+	billing *repository.OrganizationBilling
+	changes []repository.OrgSeatChange
+}
+
+func (f *fakeSeatRepo) GetBilling(ctx context.Context, orgID uuid.UUID) (*repository.OrganizationBilling, error) {
+	if f.billing == nil {
+		return &repository.OrganizationBilling{OrganizationID: orgID}, nil
+	}
+	cp := *f.billing
+	return &cp, nil
+}
+
+func (f *fakeSeatRepo) GetBillingByStripeSubscription(ctx context.Context, id string) (*repository.OrganizationBilling, error) {
+	if f.billing == nil || f.billing.StripeSubscriptionID != id {
+		return nil, nil
+	}
+	cp := *f.billing
+	return &cp, nil
+}
+
+func (f *fakeSeatRepo) SaveBilling(ctx context.Context, b *repository.OrganizationBilling) error {
+	cp := *b
+	f.billing = &cp
+	return nil
+}
+
+func (f *fakeSeatRepo) ChangeSeats(ctx context.Context, c *repository.OrgSeatChange) error {
+	if c.Source != repository.OrgSeatSourceStripe && c.ToSeats < f.org.SeatsUsed {
+		return repository.ErrOrgSeatsInUse
+	}
+	c.FromSeats = f.org.SeatLimit
+	f.org.SeatLimit = c.ToSeats
+	f.changes = append([]repository.OrgSeatChange{*c}, f.changes...)
+	return nil
+}
+
+func (f *fakeSeatRepo) ListSeatChanges(ctx context.Context, orgID uuid.UUID, limit int) ([]repository.OrgSeatChange, error) {
+	return f.changes, nil
+}
+
+func (f *fakeSeatRepo) Update(ctx context.Context, o *repository.Organization) (bool, error) {
+	f.org.Status = o.Status
+	return true, nil
+}
+
+type fakeSeatPlans struct {
+	repository.BillingRepository
+	plans []models.SubscriptionPlan
+}
+
+func (f *fakeSeatPlans) GetActivePlans(ctx context.Context) ([]models.SubscriptionPlan, error) {
+	return f.plans, nil
+}
+
+type fakeSeatBiller struct {
+	quantities []int
+	proration  int64
+	checkout   *OrganizationCheckoutParams
+}
+
+func (f *fakeSeatBiller) CreateOrganizationCheckout(ctx context.Context, p OrganizationCheckoutParams) (string, error) {
+	f.checkout = &p
+	return "https://checkout.example/session", nil
+}
+
+func (f *fakeSeatBiller) UpdateSeatQuantity(ctx context.Context, subscriptionID, itemID string, seats int) (int64, error) {
+	f.quantities = append(f.quantities, seats)
+	return f.proration, nil
+}
+
+func newSeatTest(seatLimit, seatsUsed int) (*OrganizationBillingService, *fakeSeatRepo, *fakeSeatBiller, models.SubscriptionPlan) {
+	plan := models.SubscriptionPlan{
+		ID: uuid.New(), Name: "Clinic", PriceCents: 800, BillingInterval: models.BillingIntervalMonthly,
+		SeatBased: true, StripePriceID: models.NullString{NullString: sql.NullString{String: "price_clinic", Valid: true}},
+	}
+	repo := &fakeSeatRepo{fakeOrganizationRepo: fakeOrganizationRepo{org: &repository.Organization{
+		ID: uuid.New(), Status: repository.OrgStatusActive, SeatLimit: seatLimit, SeatsUsed: seatsUsed,
+	}}}
+	biller := &fakeSeatBiller{}
+	svc := NewOrganizationBillingService(repo, &fakeSeatPlans{plans: []models.SubscriptionPlan{
+		{ID: uuid.New(), Name: "Family", PriceCents: 999},
+		plan,
+	}})
+	svc.SetSeatBiller(biller)
+	return svc, repo, biller, plan
+}
+
+func TestOrgSeatsWithoutStripeChangeLocally(t *testing.T) {
+	svc, repo, biller, _ := newSeatTest(5, 3)
+	ctx := context.Background()
+	orgID := repo.org.ID
+
+	sum, err := svc.Allocate(ctx, orgID, 2, uuid.New(), repository.OrgSeatSourceOrgAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.SeatLimit != 7 || sum.SeatsAvailable != 4 {
+		t.Errorf("after allocating 2: limit %d available %d", sum.SeatLimit, sum.SeatsAvailable)
+	}
+	if len(biller.quantities) != 0 {
+		t.Error("an organization not billed through Stripe must not touch Stripe")
+	}
+	if _, err := svc.Release(ctx, orgID, 5, uuid.New(), repository.OrgSeatSourceOrgAdmin); !errors.Is(err, ErrOrgSeatsInUse) {
+		t.Errorf("releasing seats families hold: err = %v", err)
+	}
+	if _, err := svc.Allocate(ctx, orgID, 0, uuid.New(), repository.OrgSeatSourceOrgAdmin); !errors.Is(err, ErrOrgSeatCount) {
+		t.Errorf("allocating 0: err = %v", err)
+	}
+	if len(sum.Plans) != 1 || !sum.Plans[0].SeatBased {
+		t.Errorf("unsubscribed organization should be offered the seat plan only, got %+v", sum.Plans)
+	}
+}
+
+func TestOrgSeatsProrateThroughStripe(t *testing.T) {
+	svc, repo, biller, plan := newSeatTest(10, 4)
+	repo.billing = &repository.OrganizationBilling{
+		OrganizationID: repo.org.ID, PlanID: &plan.ID, Status: "active",
+		StripeSubscriptionID: "sub_1", StripeSubscriptionItemID: "si_1",
+	}
+	biller.proration = 1240
+	ctx := context.Background()
+
+	sum, err := svc.SetSeats(ctx, repo.org.ID, 15, uuid.New(), repository.OrgSeatSourceAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(biller.quantities) != 1 || biller.quantities[0] != 15 {
+		t.Errorf("stripe quantities = %v, want [15]", biller.quantities)
+	}
+	if len(sum.Changes) != 1 || sum.Changes[0].ProrationCents != 1240 || sum.Changes[0].FromSeats != 10 {
+		t.Errorf("changes = %+v", sum.Changes)
+	}
+	if sum.MonthlyCents != 15*800 || sum.Plan == nil || !sum.BilledByStripe {
+		t.Errorf("monthly %d plan %v billed %v", sum.MonthlyCents, sum.Plan, sum.BilledByStripe)
+	}
+	if sum.Plans != nil {
+		t.Error("a subscribed organization shouldn't be offered plans")
+	}
+
+	svc.SetSeatBiller(nil)
+	if _, err := svc.Allocate(ctx, repo.org.ID, 1, uuid.New(), repository.OrgSeatSourceOrgAdmin); !errors.Is(err, ErrOrgSeatBillingDisabled) {
+		t.Errorf("without a biller: err = %v", err)
+	}
+	if repo.org.SeatLimit != 15 {
+		t.Errorf("seat limit changed to %d without Stripe", repo.org.SeatLimit)
+	}
+}
+
+func TestOrgSeatCheckout(t *testing.T) {
+	svc, repo, biller, plan := newSeatTest(0, 3)
+	repo.org.ContactEmail = "billing@clinic.example"
+	ctx := context.Background()
+
+	if _, err := svc.Checkout(ctx, repo.org.ID, plan.ID, 2, ""); !errors.Is(err, ErrOrgSeatCount) {
+		t.Errorf("fewer seats than families: err = %v", err)
+	}
+	if _, err := svc.Checkout(ctx, repo.org.ID, uuid.New(), 5, ""); !errors.Is(err, ErrOrgSeatPlanInvalid) {
+		t.Errorf("unknown plan: err = %v", err)
+	}
+	url, err := svc.Checkout(ctx, repo.org.ID, plan.ID, 5, "")
+	if err != nil || url == "" {
+		t.Fatalf("checkout: %q, %v", url, err)
+	}
+	if biller.checkout.Seats != 5 || biller.checkout.StripePriceID != "price_clinic" || biller.checkout.CustomerEmail != "billing@clinic.example" {
+		t.Errorf("checkout params = %+v", biller.checkout)
+	}
+
+	repo.billing = &repository.OrganizationBilling{OrganizationID: repo.org.ID, Status: "active", StripeSubscriptionID: "sub_1"}
+	if _, err := svc.Checkout(ctx, repo.org.ID, plan.ID, 5, ""); !errors.Is(err, ErrOrgAlreadySubscribed) {
+		t.Errorf("second checkout: err = %v", err)
+	}
+}
+
+func TestOrgSubscriptionWebhookSync(t *testing.T) {
+	svc, repo, _, plan := newSeatTest(5, 5)
+	ctx := context.Background()
+	end := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := svc.ApplyCheckoutCompleted(ctx, repo.org.ID, plan.ID, "cus_1", "sub_1", "active", end); err != nil {
+		t.Fatal(err)
+	}
+	// Quantity lowered in the Stripe dashboard below seats in use: Stripe
+	// is the source of truth for what's billed, so it still applies.
+	err := svc.ApplySubscriptionUpdated(ctx, OrgSubscriptionUpdate{
+		SubscriptionID: "sub_1", ItemID: "si_1", Seats: 4, Status: "active", PeriodEnd: end,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.org.SeatLimit != 4 || repo.billing.StripeSubscriptionItemID != "si_1" {
+		t.Errorf("limit %d item %q", repo.org.SeatLimit, repo.billing.StripeSubscriptionItemID)
+	}
+	if len(repo.changes) != 1 || repo.changes[0].Source != repository.OrgSeatSourceStripe {
+		t.Errorf("changes = %+v", repo.changes)
+	}
+
+	err = svc.ApplySubscriptionUpdated(ctx, OrgSubscriptionUpdate{
+		SubscriptionID: "sub_1", Seats: 4, Status: "canceled", PeriodEnd: end, Deleted: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.org.Status != repository.OrgStatusSuspended || repo.billing.Status != "canceled" {
+		t.Errorf("after cancel: org %q billing %q", repo.org.Status, repo.billing.Status)
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+// organization_service.go — clinics and practices above families.
+//
+// An organization's subscription covers every client family linked to it
+// (see computeEntitlement in middleware/require_subscription.go); each
+// linked family takes one seat, up to the seat limit support sets in the
+// admin portal. Staff are app users with an org role: admins manage staff,
+// seats and the join code, clinicians can see the client list and report.
+//
+// Org staff never get access to a family's logs through the organization.
+// They see the client families' names and counts, and a report aggregated
+// across all of them with small cells suppressed. Anything more needs a
+// family membership the family grants as usual.
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+// OrgReportMinCell is the fewest children a log-type row of the
+// organization report may describe; smaller rows are suppressed.
+const OrgReportMinCell = 5
+
+var (
+	ErrOrgNotFound        = apperr.NotFound("organization not found")
+	ErrOrgForbidden       = apperr.Forbidden("not allowed for your organization role")
+	ErrOrgInvalid         = apperr.Validation("invalid organization")
+	ErrOrgJoinCodeInvalid = apperr.Validation("that code doesn't match an active organization")
+	ErrOrgUserNotFound    = apperr.NotFound("no user with that email")
+	ErrOrgLastAdmin       = errors.New("an organization needs at least one admin")
+	ErrOrgFamilyNotLinked = errors.New("family isn't linked to this organization")
+	ErrOrgSeatsFull       = repository.ErrOrgSeatsFull
+	ErrFamilyHasOrg       = repository.ErrFamilyHasOrganization
+)
+
+// OrganizationInput is an organization as entered in the admin portal.
+type OrganizationInput struct {
+	Name         string `json:"name"`
+	ContactEmail string `json:"contact_email"`
+	Status       string `json:"status"`
+	SeatLimit    int    `json:"seat_limit"`
+}
+
+// OrganizationService manages organizations, their staff and the families
+// linked to them.
+type OrganizationService struct {
+	repo repository.OrganizationRepository
+	now  func() time.Time
+}
+
+func NewOrganizationService(repo repository.OrganizationRepository) *OrganizationService {
+	return &OrganizationService{repo: repo, now: time.Now}
+}
+
+// List returns every organization with seat usage.
+func (s *OrganizationService) List(ctx context.Context) ([]repository.Organization, error) {
+	return s.repo.List(ctx)
+}
+
+// Get returns one organization.
+func (s *OrganizationService) Get(ctx context.Context, id uuid.UUID) (*repository.Organization, error) {
+	o, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, ErrOrgNotFound
+	}
+	return o, nil
+}
+
+// Create adds an organization with a fresh join code.
+func (s *OrganizationService) Create(ctx context.Context, in OrganizationInput, by uuid.UUID) (*repository.Organization, error) {
+	o := &repository.Organization{}
+	if err := applyOrganizationInput(o, in); err != nil {
+		return nil, err
+	}
+	if by != uuid.Nil {
+		o.CreatedBy = &by
+	}
+	// A collision in a 31^8 space is unlikely; retry a few times anyway.
+	for attempt := 0; ; attempt++ {
+		code, err := generateOrgJoinCode()
+		if err != nil {
+			return nil, err
+		}
+		o.JoinCode = code
+		err = s.repo.Create(ctx, o)
+		if errors.Is(err, repository.ErrOrgJoinCodeTaken) && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create organization: %w", err)
+		}
+		return o, nil
+	}
+}
+
+// Update saves name, contact and status. The seat limit is ignored here:
+// seats change through OrganizationBillingService so the subscription
+// quantity follows.
+func (s *OrganizationService) Update(ctx context.Context, id uuid.UUID, in OrganizationInput) (*repository.Organization, error) {
+	o := &repository.Organization{ID: id}
+	if err := applyOrganizationInput(o, in); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.Update(ctx, o)
+	if err != nil {
+		return nil, fmt.Errorf("update organization: %w", err)
+	}
+	if !ok {
+		return nil, ErrOrgNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+// RotateJoinCode replaces the join code, so the old one stops working.
+// Families already linked stay linked.
+func (s *OrganizationService) RotateJoinCode(ctx context.Context, id uuid.UUID) (string, error) {
+	for attempt := 0; ; attempt++ {
+		code, err := generateOrgJoinCode()
+		if err != nil {
+			return "", err
+		}
+		err = s.repo.SetJoinCode(ctx, id, code)
+		if errors.Is(err, repository.ErrOrgJoinCodeTaken) && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return code, nil
+	}
+}
+
+// ForUser returns the organizations the user is on the staff of. The join
+// code is only included where they're an admin.
+func (s *OrganizationService) ForUser(ctx context.Context, userID uuid.UUID) ([]repository.OrganizationMembership, error) {
+	orgs, err := s.repo.ForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orgs {
+		if orgs[i].Role != repository.OrgRoleAdmin {
+			orgs[i].JoinCode = ""
+		}
+	}
+	return orgs, nil
+}
+
+// Authorize returns the user's role in the organization. It fails with
+// ErrOrgNotFound when they aren't on its staff (so the organization's
+// existence isn't revealed), and with ErrOrgForbidden when adminOnly is
+// set and they're not an admin.
+func (s *OrganizationService) Authorize(ctx context.Context, orgID, userID uuid.UUID, adminOnly bool) (string, error) {
+	role, err := s.repo.MemberRole(ctx, orgID, userID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return "", ErrOrgNotFound
+	}
+	if adminOnly && role != repository.OrgRoleAdmin {
+		return "", ErrOrgForbidden
+	}
+	return role, nil
+}
+
+// Members lists the organization's staff.
+func (s *OrganizationService) Members(ctx context.Context, orgID uuid.UUID) ([]repository.OrganizationMember, error) {
+	return s.repo.ListMembers(ctx, orgID)
+}
+
+// AddMember puts the app user with email on the staff with role, or
+// changes their role. Demoting the last admin fails with ErrOrgLastAdmin.
+func (s *OrganizationService) AddMember(ctx context.Context, orgID uuid.UUID, email, role string, by uuid.UUID) (*repository.OrganizationMember, error) {
+	email = strings.TrimSpace(email)
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, fmt.Errorf("%w: email is not valid", ErrOrgInvalid)
+	}
+	if role != repository.OrgRoleAdmin && role != repository.OrgRoleClinician {
+		return nil, fmt.Errorf("%w: role must be admin or clinician", ErrOrgInvalid)
+	}
+	if _, err := s.Get(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if role != repository.OrgRoleAdmin {
+		members, err := s.repo.ListMembers(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if isOnlyOrgAdmin(members, func(m repository.OrganizationMember) bool { return strings.EqualFold(m.Email, email) }) {
+			return nil, ErrOrgLastAdmin
+		}
+	}
+	m, err := s.repo.AddMember(ctx, orgID, email, role, by)
+	if err != nil {
+		return nil, fmt.Errorf("add organization member: %w", err)
+	}
+	if m == nil {
+		return nil, ErrOrgUserNotFound
+	}
+	return m, nil
+}
+
+// RemoveMember takes the user off the staff. The last admin can't be
+// removed.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	members, err := s.repo.ListMembers(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if isOnlyOrgAdmin(members, func(m repository.OrganizationMember) bool { return m.UserID == userID }) {
+		return ErrOrgLastAdmin
+	}
+	ok, err := s.repo.RemoveMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOrgUserNotFound
+	}
+	return nil
+}
+
+// isOnlyOrgAdmin reports whether the member matching is the only admin.
+func isOnlyOrgAdmin(members []repository.OrganizationMember, match func(repository.OrganizationMember) bool) bool {
+	admins, matched := 0, false
+	for _, m := range members {
+		if m.Role != repository.OrgRoleAdmin {
+			continue
+		}
+		admins++
+		if match(m) {
+			matched = true
+		}
+	}
+	return matched && admins == 1
+}
+
+// Families lists the organization's client families.
+func (s *OrganizationService) Families(ctx context.Context, orgID uuid.UUID) ([]repository.OrganizationFamily, error) {
+	return s.repo.ListFamilies(ctx, orgID)
+}
+
+// RemoveFamily ends a family's link and frees its seat.
+func (s *OrganizationService) RemoveFamily(ctx context.Context, orgID, familyID uuid.UUID) error {
+	ok, err := s.repo.UnlinkFamily(ctx, orgID, familyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOrgFamilyNotLinked
+	}
+	return nil
+}
+
+// OrganizationReport is the organization's aggregated activity, with
+// log-type rows describing fewer than OrgReportMinCell children left out.
+type OrganizationReport struct {
+	repository.OrganizationReport
+	Since       time.Time `json:"since"`
+	Suppressed  int       `json:"suppressed"`
+	MinCellSize int       `json:"min_cell_size"`
+}
+
+// Report aggregates the last days of activity across the organization's
+// families.
+func (s *OrganizationService) Report(ctx context.Context, orgID uuid.UUID, days int) (*OrganizationReport, error) {
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+	since := utcDay(s.now()).AddDate(0, 0, -days+1)
+	rep, err := s.repo.Report(ctx, orgID, since)
+	if err != nil {
+		return nil, err
+	}
+	out := &OrganizationReport{OrganizationReport: *rep, Since: since, MinCellSize: OrgReportMinCell}
+	kept := out.Entries[:0]
+	for _, e := range rep.Entries {
+		if e.Children < OrgReportMinCell {
+			out.Suppressed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	out.Entries = kept
+	return out, nil
+}
+
+// FamilyOrganization returns the organization the family is linked to,
+// without its join code, or nil.
+func (s *OrganizationService) FamilyOrganization(ctx context.Context, familyID uuid.UUID) (*repository.Organization, error) {
+	o, err := s.repo.FamilyOrganization(ctx, familyID)
+	if err != nil || o == nil {
+		return nil, err
+	}
+	o.JoinCode = ""
+	return o, nil
+}
+
+// JoinByCode links the family to the active organization with code,
+// taking one of its seats.
+func (s *OrganizationService) JoinByCode(ctx context.Context, familyID, userID uuid.UUID, code string) (*repository.Organization, error) {
+	code = normalizeOrgJoinCode(code)
+	if code == "" {
+		return nil, ErrOrgJoinCodeInvalid
+	}
+	o, err := s.repo.GetByJoinCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil || o.Status != repository.OrgStatusActive || orgBillingLapsed(o.BillingStatus) {
+		return nil, ErrOrgJoinCodeInvalid
+	}
+	if err := s.repo.LinkFamily(ctx, o.ID, familyID, userID); err != nil {
+		return nil, err
+	}
+	return s.FamilyOrganization(ctx, familyID)
+}
+
+// Leave ends the family's link to its organization.
+func (s *OrganizationService) Leave(ctx context.Context, familyID uuid.UUID) error {
+	o, err := s.repo.FamilyOrganization(ctx, familyID)
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		return ErrOrgFamilyNotLinked
+	}
+	return s.RemoveFamily(ctx, o.ID, familyID)
+}
+
+func applyOrganizationInput(o *repository.Organization, in OrganizationInput) error {
+	o.Name = strings.TrimSpace(in.Name)
+	if o.Name == "" || len([]rune(o.Name)) > 200 {
+		return fmt.Errorf("%w: name is required (200 characters max)", ErrOrgInvalid)
+	}
+	o.ContactEmail = strings.TrimSpace(in.ContactEmail)
+	if o.ContactEmail != "" {
+		if _, err := mail.ParseAddress(o.ContactEmail); err != nil {
+			return fmt.Errorf("%w: contact email is not valid", ErrOrgInvalid)
+		}
+	}
+	o.Status = in.Status
+	if o.Status == "" {
+		o.Status = repository.OrgStatusActive
+	}
+	if o.Status != repository.OrgStatusActive && o.Status != repository.OrgStatusSuspended {
+		return fmt.Errorf("%w: status must be active or suspended", ErrOrgInvalid)
+	}
+	if in.SeatLimit < 0 {
+		return fmt.Errorf("%w: seat limit can't be negative", ErrOrgInvalid)
+	}
+	o.SeatLimit = in.SeatLimit
+	return nil
+}
+
+// orgJoinCodeAlphabet leaves out 0/O, 1/I/L so codes read back cleanly.
+const orgJoinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// generateOrgJoinCode returns an 8-character join code.
+func generateOrgJoinCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(orgJoinCodeAlphabet)))
+	for i := 0; i < 8; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(orgJoinCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeOrgJoinCode upper-cases code and drops the spaces and dashes
+// people type when reading it out.
+func normalizeOrgJoinCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeOrganizationRepo struct {
+	repository.OrganizationRepository
+	org     *repository.Organization
+	members []repository.OrganizationMember
+	linked  map[uuid.UUID]bool
+	report  *repository.OrganizationReport
+	since   time.Time
+}
+
+func (f *fakeOrganizationRepo) Get(ctx context.Context, id uuid.UUID) (*repository.Organization, error) {
+	if f.org == nil || f.org.ID != id {
+		return nil, nil
+	}
+	cp := *f.org
+	return &cp, nil
+}
+
+func (f *fakeOrganizationRepo) GetByJoinCode(ctx context.Context, code string) (*repository.Organization, error) {
+	if f.org == nil || f.org.JoinCode != code {
+		return nil, nil
+	}
+	cp := *f.org
+	return &cp, nil
+}
+
+func (f *fakeOrganizationRepo) Create(ctx context.Context, o *repository.Organization) error {
+	o.ID = uuid.New()
+	f.org = o
+	return nil
+}
+
+func (f *fakeOrganizationRepo) ListMembers(ctx context.Context, orgID uuid.UUID) ([]repository.OrganizationMember, error) {
+	return f.members, nil
+}
+
+func (f *fakeOrganizationRepo) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	for i, m := range f.members {
+		if m.UserID == userID {
+			f.members = append(f.members[:i], f.members[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeOrganizationRepo) LinkFamily(ctx context.Context, orgID, familyID, addedBy uuid.UUID) error {
+	if f.linked[familyID] {
+		return repository.ErrFamilyHasOrganization
+	}
+	if len(f.linked) >= f.org.SeatLimit {
+		return repository.ErrOrgSeatsFull
+	}
+	f.linked[familyID] = true
+	return nil
+}
+
+func (f *fakeOrganizationRepo) FamilyOrganization(ctx context.Context, familyID uuid.UUID) (*repository.Organization, error) {
+	if !f.linked[familyID] {
+		return nil, nil
+	}
+	cp := *f.org
+	return &cp, nil
+}
+
+func (f *fakeOrganizationRepo) Report(ctx context.Context, orgID uuid.UUID, since time.Time) (*repository.OrganizationReport, error) {
+	f.since = since
+	cp := *f.report
+	cp.Entries = append([]repository.OrgEntryCount(nil), f.report.Entries...)
+	return &cp, nil
+}
+
+func TestOrganizationCreateValidates(t *testing.T) {
+	repo := &fakeOrganizationRepo{}
+	svc := NewOrganizationService(repo)
+	ctx := context.Background()
+
+	bad := []OrganizationInput{
+		{Name: " "},
+		{Name: "Clinic", ContactEmail: "not-an-email"},
+		{Name: "Clinic", Status: "closed"},
+		{Name: "Clinic", SeatLimit: -1},
+	}
+	for _, in := range bad {
+		if _, err := svc.Create(ctx, in, uuid.Nil); !errors.Is(err, ErrOrgInvalid) {
+			t.Errorf("%+v: err = %v, want ErrOrgInvalid", in, err)
+		}
+	}
+
+	o, err := svc.Create(ctx, OrganizationInput{Name: " Bright Path ", SeatLimit: 3}, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Name != "Bright Path" || o.Status != repository.OrgStatusActive {
+		t.Errorf("name %q status %q", o.Name, o.Status)
+	}
+	if len(o.JoinCode) != 8 {
+		t.Errorf("join code %q, want 8 characters", o.JoinCode)
+	}
+}
+
+func TestOrganizationJoinByCode(t *testing.T) {
+	org := &repository.Organization{ID: uuid.New(), Status: repository.OrgStatusActive, SeatLimit: 1, JoinCode: "ABCD2345"}
+	repo := &fakeOrganizationRepo{org: org, linked: map[uuid.UUID]bool{}}
+	svc := NewOrganizationService(repo)
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	if _, err := svc.JoinByCode(ctx, first, uuid.New(), "WRONG"); !errors.Is(err, ErrOrgJoinCodeInvalid) {
+		t.Errorf("wrong code: err = %v", err)
+	}
+	got, err := svc.JoinByCode(ctx, first, uuid.New(), " abcd-2345 ")
+	if err != nil {
+		t.Fatalf("code as typed should normalize: %v", err)
+	}
+	if got.JoinCode != "" {
+		t.Error("families must not see the join code")
+	}
+	if _, err := svc.JoinByCode(ctx, first, uuid.New(), "ABCD2345"); !errors.Is(err, ErrFamilyHasOrg) {
+		t.Errorf("joining twice: err = %v", err)
+	}
+	if _, err := svc.JoinByCode(ctx, second, uuid.New(), "ABCD2345"); !errors.Is(err, ErrOrgSeatsFull) {
+		t.Errorf("over the seat limit: err = %v", err)
+	}
+
+	org.BillingStatus = "canceled"
+	if _, err := svc.JoinByCode(ctx, second, uuid.New(), "ABCD2345"); !errors.Is(err, ErrOrgJoinCodeInvalid) {
+		t.Errorf("lapsed seat subscription: err = %v", err)
+	}
+	org.BillingStatus = ""
+
+	org.Status = repository.OrgStatusSuspended
+	if _, err := svc.JoinByCode(ctx, second, uuid.New(), "ABCD2345"); !errors.Is(err, ErrOrgJoinCodeInvalid) {
+		t.Errorf("suspended organization: err = %v", err)
+	}
+}
+
+func TestOrganizationKeepsLastAdmin(t *testing.T) {
+	orgID, admin, clinician := uuid.New(), uuid.New(), uuid.New()
+	repo := &fakeOrganizationRepo{
+		org: &repository.Organization{ID: orgID},
+		members: []repository.OrganizationMember{
+			{UserID: admin, Email: "lead@clinic.example", Role: repository.OrgRoleAdmin},
+			{UserID: clinician, Email: "dr@clinic.example", Role: repository.OrgRoleClinician},
+		},
+	}
+	svc := NewOrganizationService(repo)
+	ctx := context.Background()
+
+	if err := svc.RemoveMember(ctx, orgID, admin); !errors.Is(err, ErrOrgLastAdmin) {
+		t.Errorf("removing the last admin: err = %v", err)
+	}
+	if _, err := svc.AddMember(ctx, orgID, "Lead@Clinic.example", repository.OrgRoleClinician, uuid.Nil); !errors.Is(err, ErrOrgLastAdmin) {
+		t.Errorf("demoting the last admin: err = %v", err)
+	}
+	if err := svc.RemoveMember(ctx, orgID, clinician); err != nil {
+		t.Errorf("removing a clinician: %v", err)
+	}
+}
+
+func TestOrganizationReportSuppressesSmallCells(t *testing.T) {
+	repo := &fakeOrganizationRepo{report: &repository.OrganizationReport{
+		Families: 12, Children: 15, ActiveFamilies: 9,
+		Entries: []repository.OrgEntryCount{
+			{LogType: "sleep", Entries: 400, Children: 11},
+			{LogType: "seizure", Entries: 6, Children: 2},
+			{LogType: "diet", Entries: 90, Children: OrgReportMinCell},
+		},
+	}}
+	svc := NewOrganizationService(repo)
+	svc.now = func() time.Time { return time.Date(2026, 5, 30, 15, 0, 0, 0, time.UTC) }
+
+	rep, err := svc.Report(context.Background(), uuid.New(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 5, 24, 0, 0, 0, 0, time.UTC); !repo.since.Equal(want) {
+		t.Errorf("since = %v, want %v", repo.since, want)
+	}
+	if len(rep.Entries) != 2 || rep.Suppressed != 1 {
+		t.Fatalf("entries = %+v, suppressed %d", rep.Entries, rep.Suppressed)
+	}
+	for _, e := range rep.Entries {
+		if e.LogType == "seizure" {
+			t.Error("a row describing 2 children should be suppressed")
+		}
+	}
+}
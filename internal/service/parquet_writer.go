@@ -0,0 +1,301 @@
+package service
+
+// parquet_writer.go — a minimal Parquet encoder for the warehouse export.
+// There is no Parquet module in our dependency set and the exports are
+// small aggregate tables, so this writes the simplest file every reader
+// (Athena, Spark, pyarrow, DuckDB) accepts: one row group, one GZIP data
+// page per column, PLAIN encoding, all columns REQUIRED (so no definition
+// or repetition levels). Metadata is Thrift compact protocol per
+// https://github.com/apache/parquet-format.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// parquetKind is a column's logical type.
+type parquetKind int
+
+const (
+	parquetString parquetKind = iota // BYTE_ARRAY, UTF8
+	parquetInt64                     // INT64
+	parquetDouble                    // DOUBLE
+	parquetDate                      // INT32, DATE (days since epoch)
+)
+
+type parquetColumn struct {
+	Name string
+	Kind parquetKind
+}
+
+// Parquet enum values used below.
+const (
+	pqTypeInt32     = 1
+	pqTypeInt64     = 2
+	pqTypeDouble    = 5
+	pqTypeByteArray = 6
+
+	pqConvertedUTF8 = 0
+	pqConvertedDate = 6
+
+	pqRequired       = 0
+	pqEncodingPlain  = 0
+	pqEncodingRLE    = 3
+	pqCodecGzip      = 2
+	pqPageTypeData   = 0
+	pqFormatVersion  = 1
+	parquetCreatedBy = "carecompanion warehouse export"
+)
+
+var parquetMagic = []byte("PAR1")
+
+func (k parquetKind) physical() int32 {
+	switch k {
+	case parquetInt64:
+		return pqTypeInt64
+	case parquetDouble:
+		return pqTypeDouble
+	case parquetDate:
+		return pqTypeInt32
+	}
+	return pqTypeByteArray
+}
+
+// writeParquet writes rows as a Parquet file. Each row has one value per
+// column: string for parquetString, int64 (or int) for parquetInt64,
+// float64 for parquetDouble and time.Time for parquetDate.
+func writeParquet(w io.Writer, cols []parquetColumn, rows [][]any) error {
+	var file bytes.Buffer
+	file.Write(parquetMagic)
+
+	type chunk struct {
+		offset            int64
+		compressed, plain int64
+	}
+	chunks := make([]chunk, len(cols))
+	for c, col := range cols {
+		var plain bytes.Buffer
+		for r, row := range rows {
+			if len(row) != len(cols) {
+				return fmt.Errorf("parquet: row %d has %d values, want %d", r, len(row), len(cols))
+			}
+			if err := encodePlain(&plain, col.Kind, row[c]); err != nil {
+				return fmt.Errorf("parquet: row %d column %s: %w", r, col.Name, err)
+			}
+		}
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		zw.Write(plain.Bytes())
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		var header thriftWriter
+		header.i32(1, pqPageTypeData)
+		header.i32(2, int32(plain.Len()))
+		header.i32(3, int32(compressed.Len()))
+		header.structBegin(5) // DataPageHeader
+		header.i32(1, int32(len(rows)))
+		header.i32(2, pqEncodingPlain)
+		header.i32(3, pqEncodingRLE)
+		header.i32(4, pqEncodingRLE)
+		header.structEnd()
+		header.stop()
+
+		chunks[c] = chunk{
+			offset:     int64(file.Len()),
+			compressed: int64(header.buf.Len() + compressed.Len()),
+			plain:      int64(header.buf.Len() + plain.Len()),
+		}
+		file.Write(header.buf.Bytes())
+		file.Write(compressed.Bytes())
+	}
+
+	// FileMetaData
+	var meta thriftWriter
+	meta.i32(1, pqFormatVersion)
+	meta.listBegin(2, thriftStruct, len(cols)+1)
+	meta.elemBegin()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(cols)))
+	meta.elemEnd()
+	for _, col := range cols {
+		meta.elemBegin()
+		meta.i32(1, col.Kind.physical())
+		meta.i32(3, pqRequired)
+		meta.binary(4, col.Name)
+		switch col.Kind {
+		case parquetString:
+			meta.i32(6, pqConvertedUTF8)
+		case parquetDate:
+			meta.i32(6, pqConvertedDate)
+		}
+		meta.elemEnd()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.listBegin(4, thriftStruct, 1)
+	meta.elemBegin() // RowGroup
+	meta.listBegin(1, thriftStruct, len(cols))
+	var total int64
+	for c, col := range cols {
+		ch := chunks[c]
+		total += ch.plain
+		meta.elemBegin() // ColumnChunk
+		meta.i64(2, ch.offset)
+		meta.structBegin(3) // ColumnMetaData
+		meta.i32(1, col.Kind.physical())
+		meta.listBegin(2, thriftI32, 2)
+		meta.listI32(pqEncodingPlain)
+		meta.listI32(pqEncodingRLE)
+		meta.listBegin(3, thriftBinary, 1)
+		meta.listBinary(col.Name)
+		meta.i32(4, pqCodecGzip)
+		meta.i64(5, int64(len(rows)))
+		meta.i64(6, ch.plain)
+		meta.i64(7, ch.compressed)
+		meta.i64(9, ch.offset)
+		meta.structEnd()
+		meta.elemEnd()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(len(rows)))
+	meta.elemEnd()
+	meta.binary(6, parquetCreatedBy)
+	meta.stop()
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.Write(parquetMagic)
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+func encodePlain(buf *bytes.Buffer, kind parquetKind, v any) error {
+	switch kind {
+	case parquetString:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("want string, got %T", v)
+		}
+		binary.Write(buf, binary.LittleEndian, uint32(len(s)))
+		buf.WriteString(s)
+	case parquetInt64:
+		switch n := v.(type) {
+		case int64:
+			binary.Write(buf, binary.LittleEndian, n)
+		case int:
+			binary.Write(buf, binary.LittleEndian, int64(n))
+		default:
+			return fmt.Errorf("want int64, got %T", v)
+		}
+	case parquetDouble:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("want float64, got %T", v)
+		}
+		binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+	case parquetDate:
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("want time.Time, got %T", v)
+		}
+		y, m, d := t.Date()
+		days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+		binary.Write(buf, binary.LittleEndian, int32(days))
+	}
+	return nil
+}
+
+// Thrift compact protocol element types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes Thrift compact protocol structs. Field IDs are
+// delta-encoded against the previous field in the same struct, so nested
+// structs (and struct list elements) push a new "last field" onto a stack.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16
+	cur  int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.cur; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(uint64(zigzag(int64(id))))
+	}
+	t.cur = id
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func zigzag(v int64) uint64 { return uint64((v << 1) ^ (v >> 63)) }
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+func (t *thriftWriter) structEnd() { t.elemEnd() }
+
+// elemBegin starts a struct that is a list element (no field header).
+func (t *thriftWriter) elemBegin() {
+	t.last = append(t.last, t.cur)
+	t.cur = 0
+}
+
+func (t *thriftWriter) elemEnd() {
+	t.stop()
+	t.cur = t.last[len(t.last)-1]
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) stop() { t.buf.WriteByte(0) }
+
+func (t *thriftWriter) listBegin(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+	} else {
+		t.buf.WriteByte(0xF0 | elem)
+		t.varint(uint64(n))
+	}
+}
+
+func (t *thriftWriter) listI32(v int32) { t.varint(zigzag(int64(v))) }
+
+func (t *thriftWriter) listBinary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes Thrift compact structs generically: a struct is a
+// map of field ID to value, a list is []any.
+type thriftReader struct {
+	r *bytes.Reader
+}
+
+func (t *thriftReader) varint() uint64 {
+	v, err := binary.ReadUvarint(t.r)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func unzigzag(v uint64) int64 { return int64(v>>1) ^ -int64(v&1) }
+
+func (t *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return unzigzag(t.varint())
+	case 7:
+		var f float64
+		binary.Read(t.r, binary.LittleEndian, &f)
+		return f
+	case thriftBinary:
+		b := make([]byte, t.varint())
+		io.ReadFull(t.r, b)
+		return string(b)
+	case thriftList:
+		h, _ := t.r.ReadByte()
+		n, elem := int(h>>4), h&0x0F
+		if n == 15 {
+			n = int(t.varint())
+		}
+		out := make([]any, n)
+		for i := range out {
+			out[i] = t.value(elem)
+		}
+		return out
+	case thriftStruct:
+		return t.structure()
+	}
+	panic("unsupported thrift type")
+}
+
+func (t *thriftReader) structure() map[int16]any {
+	out := map[int16]any{}
+	var id int16
+	for {
+		h, _ := t.r.ReadByte()
+		if h == 0 {
+			return out
+		}
+		typ := h & 0x0F
+		if delta := int16(h >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(unzigzag(t.varint()))
+		}
+		out[id] = t.value(typ)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	cols := []parquetColumn{
+		{"day", parquetDate}, {"log_type", parquetString}, {"entries", parquetInt64}, {"rate", parquetDouble},
+	}
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var rows [][]any
+	for i := 0; i < 20; i++ {
+		rows = append(rows, []any{day, "sleep", int64(i * 1000), float64(i) / 4})
+	}
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, cols, rows); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, parquetMagic) || !bytes.HasSuffix(data, parquetMagic) {
+		t.Fatal("missing PAR1 magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := (&thriftReader{bytes.NewReader(data[len(data)-8-footerLen : len(data)-8])}).structure()
+
+	if meta[3].(int64) != 20 {
+		t.Errorf("num_rows = %v", meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(cols)+1 || schema[0].(map[int16]any)[5].(int64) != int64(len(cols)) {
+		t.Fatalf("schema = %v", schema)
+	}
+	for i, col := range cols {
+		el := schema[i+1].(map[int16]any)
+		if el[4] != col.Name || el[1].(int64) != int64(col.Kind.physical()) {
+			t.Errorf("schema[%d] = %v", i+1, el)
+		}
+	}
+
+	chunks := meta[4].([]any)[0].(map[int16]any)[1].([]any)
+	for c, col := range cols {
+		cm := chunks[c].(map[int16]any)[3].(map[int16]any)
+		r := bytes.NewReader(data[cm[9].(int64):])
+		page := (&thriftReader{r}).structure()
+		if page[1].(int64) != pqPageTypeData || page[5].(map[int16]any)[1].(int64) != 20 {
+			t.Fatalf("%s page header = %v", col.Name, page)
+		}
+		compressed := make([]byte, page[3].(int64))
+		io.ReadFull(r, compressed)
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, _ := io.ReadAll(zr)
+		if int64(len(plain)) != page[2].(int64) {
+			t.Errorf("%s: uncompressed size %d, header says %v", col.Name, len(plain), page[2])
+		}
+
+		// Spot-check the last value.
+		switch col.Kind {
+		case parquetDate:
+			if days := int32(binary.LittleEndian.Uint32(plain[len(plain)-4:])); days != int32(day.Unix()/86400) {
+				t.Errorf("day = %d", days)
+			}
+		case parquetString:
+			if !bytes.HasSuffix(plain, []byte("\x05\x00\x00\x00sleep")) {
+				t.Errorf("log_type tail = %q", plain[len(plain)-9:])
+			}
+		case parquetInt64:
+			if v := int64(binary.LittleEndian.Uint64(plain[len(plain)-8:])); v != 19000 {
+				t.Errorf("entries = %d", v)
+			}
+		case parquetDouble:
+			if v := math.Float64frombits(binary.LittleEndian.Uint64(plain[len(plain)-8:])); v != 4.75 {
+				t.Errorf("rate = %v", v)
+			}
+		}
+	}
+}
+
+func TestWriteParquetRejectsWrongType(t *testing.T) {
+	err := writeParquet(io.Discard, []parquetColumn{{"n", parquetInt64}}, [][]any{{"x"}})
+	if err == nil {
+		t.Fatal("want error for string in int64 column")
+	}
+}
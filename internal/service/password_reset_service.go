@@ -14,13 +14,14 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/repository"
 )
 
 var (
 	ErrResetTokenExpired = errors.New("reset token has expired")
 	ErrResetTokenUsed    = errors.New("reset token has already been used")
-	ErrResetTokenInvalid = errors.New("invalid reset token")
+	ErrResetTokenInvalid = apperr.Validation("invalid reset token")
 )
 
 type PasswordResetService struct {
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrPendingActionNotFound        = apperr.NotFound("pending action not found")
+	ErrPendingActionUnknown         = errors.New("unknown pending action type")
+	ErrPendingActionState           = errors.New("pending action is no longer open for that step")
+	ErrPendingActionConfirmMismatch = errors.New("confirmation text does not match")
+	ErrPendingActionForbidden       = apperr.Forbidden("not allowed to act on this pending action")
+	ErrPendingActionFailed          = errors.New("pending action failed")
+)
+
+// PendingActionApprovalKey is the admin setting holding, per action, whether
+// a second admin must approve it after confirmation.
+const PendingActionApprovalKey = "pending_action_approval"
+
+// AdminNotificationKindPendingApproval is the admin_notifications kind
+// opened when an action is waiting for a second admin.
+const AdminNotificationKindPendingApproval = "pending_action_approval"
+
+const (
+	// pendingConfirmTTL is how long the requester has to type the
+	// confirmation; pendingApprovalTTL how long a second admin has after.
+	pendingConfirmTTL  = 15 * time.Minute
+	pendingApprovalTTL = 24 * time.Hour
+)
+
+// PendingActionSpec registers an action type. Execute performs the action
+// once it is confirmed (and approved); its result is stored on the action.
+type PendingActionSpec struct {
+	Action string
+	// RequiresApproval is the default; the approval setting overrides it.
+	RequiresApproval bool
+	Execute          func(ctx context.Context, a *repository.PendingAdminAction) (map[string]interface{}, error)
+}
+
+// PendingActionRequest describes an action to hold for confirmation.
+// ConfirmPhrase is what the requester must type back, e.g. the email of
+// the user being deactivated.
+type PendingActionRequest struct {
+	Action        string
+	TargetType    string
+	TargetID      string
+	Params        map[string]interface{}
+	Summary       string
+	ConfirmPhrase string
+	RequestedBy   uuid.UUID
+}
+
+// PendingActionService holds destructive admin actions until the requester
+// has confirmed them by typing a phrase and, where the policy says so, a
+// second admin has approved them. Each action runs at most once.
+type PendingActionService struct {
+	repo     repository.PendingActionRepository
+	notify   adminNotifier
+	settings settingsStore
+	specs    map[string]PendingActionSpec
+	now      func() time.Time
+}
+
+func NewPendingActionService(repo repository.PendingActionRepository, notify adminNotifier, settings settingsStore) *PendingActionService {
+	return &PendingActionService{
+		repo: repo, notify: notify, settings: settings,
+		specs: make(map[string]PendingActionSpec),
+		now:   time.Now,
+	}
+}
+
+// Register adds an action type. Call it during setup, before serving.
+func (s *PendingActionService) Register(spec PendingActionSpec) {
+	s.specs[spec.Action] = spec
+}
+
+// ApprovalPolicy reports, per registered action, whether it needs a
+// second admin's approval.
+func (s *PendingActionService) ApprovalPolicy(ctx context.Context) (map[string]bool, error) {
+	out := make(map[string]bool, len(s.specs))
+	for name, spec := range s.specs {
+		out[name] = spec.RequiresApproval
+	}
+	val, err := s.settings.GetSetting(ctx, PendingActionApprovalKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	var stored map[string]bool
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		log.Printf("[PENDING-ACTION] %s setting unreadable, using defaults: %v", PendingActionApprovalKey, err)
+		return out, nil
+	}
+	for k, v := range stored {
+		if _, known := s.specs[k]; known {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// UpdateApprovalPolicy stores the policy for the actions in update;
+// others keep their current policy.
+func (s *PendingActionService) UpdateApprovalPolicy(ctx context.Context, update map[string]bool, by uuid.UUID) (map[string]bool, error) {
+	current, err := s.ApprovalPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range update {
+		if _, known := s.specs[k]; !known {
+			return nil, fmt.Errorf("%w: %q", ErrPendingActionUnknown, k)
+		}
+		current[k] = v
+	}
+	if err := s.settings.UpdateSetting(ctx, PendingActionApprovalKey, current, by); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// Request records an action awaiting the requester's confirmation.
+func (s *PendingActionService) Request(ctx context.Context, req PendingActionRequest) (*repository.PendingAdminAction, error) {
+	if _, ok := s.specs[req.Action]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrPendingActionUnknown, req.Action)
+	}
+	policy, err := s.ApprovalPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Params == nil {
+		req.Params = map[string]interface{}{}
+	}
+	a := &repository.PendingAdminAction{
+		Action:           req.Action,
+		TargetType:       req.TargetType,
+		TargetID:         req.TargetID,
+		Params:           req.Params,
+		Summary:          req.Summary,
+		ConfirmPhrase:    req.ConfirmPhrase,
+		RequiresApproval: policy[req.Action],
+		RequestedBy:      req.RequestedBy,
+		ExpiresAt:        s.now().Add(pendingConfirmTTL),
+	}
+	if err := s.repo.Create(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Confirm checks the requester's typed confirmation. The action then runs,
+// or waits for approval when it requires it. A mismatch leaves it open.
+func (s *PendingActionService) Confirm(ctx context.Context, id, adminID uuid.UUID, typed string) (*repository.PendingAdminAction, error) {
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.RequestedBy != adminID {
+		return nil, fmt.Errorf("%w: only the requesting admin can confirm", ErrPendingActionForbidden)
+	}
+	if a.Status != repository.PendingActionAwaitingConfirmation {
+		return nil, ErrPendingActionState
+	}
+	if strings.TrimSpace(typed) != a.ConfirmPhrase {
+		return nil, ErrPendingActionConfirmMismatch
+	}
+
+	now := s.now()
+	next, expires := repository.PendingActionExecuting, a.ExpiresAt
+	if a.RequiresApproval {
+		next, expires = repository.PendingActionAwaitingApproval, now.Add(pendingApprovalTTL)
+	}
+	ok, err := s.repo.Confirm(ctx, id, next, expires, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPendingActionState
+	}
+	a.Status, a.ConfirmedAt, a.ExpiresAt = next, &now, expires
+	if a.RequiresApproval {
+		s.notifyApprovers(ctx, a)
+		return a, nil
+	}
+	return s.execute(ctx, a)
+}
+
+// Approve runs an action awaiting approval. The approver must be another
+// admin than the requester.
+func (s *PendingActionService) Approve(ctx context.Context, id, adminID uuid.UUID, note string) (*repository.PendingAdminAction, error) {
+	a, err := s.decide(ctx, id, adminID, note, repository.PendingActionExecuting, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(ctx, a)
+}
+
+// Reject closes an action awaiting approval without running it.
+func (s *PendingActionService) Reject(ctx context.Context, id, adminID uuid.UUID, note string) (*repository.PendingAdminAction, error) {
+	return s.decide(ctx, id, adminID, note, repository.PendingActionRejected, false)
+}
+
+// Cancel lets the requester withdraw an open action.
+func (s *PendingActionService) Cancel(ctx context.Context, id, adminID uuid.UUID) (*repository.PendingAdminAction, error) {
+	return s.decide(ctx, id, adminID, "", repository.PendingActionCancelled, true)
+}
+
+func (s *PendingActionService) decide(ctx context.Context, id, adminID uuid.UUID, note, next string, byRequester bool) (*repository.PendingAdminAction, error) {
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	from := []string{repository.PendingActionAwaitingApproval}
+	if byRequester {
+		if a.RequestedBy != adminID {
+			return nil, fmt.Errorf("%w: only the requesting admin can cancel", ErrPendingActionForbidden)
+		}
+		from = append(from, repository.PendingActionAwaitingConfirmation)
+	} else if a.RequestedBy == adminID {
+		return nil, fmt.Errorf("%w: a second admin must decide", ErrPendingActionForbidden)
+	}
+
+	now := s.now()
+	ok, err := s.repo.Decide(ctx, id, from, next, adminID, note, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPendingActionState
+	}
+	a.Status, a.DecidedBy, a.DecidedAt, a.DecisionNote = next, &adminID, &now, note
+	return a, nil
+}
+
+// execute runs an action the caller has moved to executing and records
+// the outcome.
+func (s *PendingActionService) execute(ctx context.Context, a *repository.PendingAdminAction) (*repository.PendingAdminAction, error) {
+	spec, ok := s.specs[a.Action]
+	var execErr error
+	if !ok {
+		execErr = fmt.Errorf("%w: %q", ErrPendingActionUnknown, a.Action)
+	} else {
+		a.Result, execErr = spec.Execute(ctx, a)
+	}
+	a.Status = repository.PendingActionExecuted
+	if execErr != nil {
+		a.Status, a.Error = repository.PendingActionFailed, execErr.Error()
+	}
+	now := s.now()
+	a.ExecutedAt = &now
+	// The action has run; record that even if the request has gone.
+	if err := s.repo.Finish(context.WithoutCancel(ctx), a.ID, a.Status, a.Result, a.Error); err != nil {
+		log.Printf("[PENDING-ACTION] record outcome of %s %s: %v", a.Action, a.ID, err)
+	}
+	if execErr != nil {
+		return a, fmt.Errorf("%w: %v", ErrPendingActionFailed, execErr)
+	}
+	return a, nil
+}
+
+// notifyApprovers raises an admin notification for an action waiting on a
+// second admin. Best effort: the action is listed either way.
+func (s *PendingActionService) notifyApprovers(ctx context.Context, a *repository.PendingAdminAction) {
+	if s.notify == nil {
+		return
+	}
+	details, _ := json.Marshal(map[string]interface{}{
+		"pending_action_id": a.ID, "action": a.Action, "requested_by": a.RequestedBy,
+		"expires_at": a.ExpiresAt,
+	})
+	if _, err := s.notify.Open(ctx, &repository.AdminNotification{
+		Kind:      AdminNotificationKindPendingApproval,
+		Severity:  "warning",
+		Title:     "Approval needed: " + a.Summary,
+		Message:   fmt.Sprintf("A destructive action is waiting for a second admin's approval until %s.", a.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")),
+		DedupeKey: AdminNotificationKindPendingApproval + ":" + a.ID.String(),
+		Details:   details,
+	}); err != nil {
+		log.Printf("[PENDING-ACTION] notify approvers of %s: %v", a.ID, err)
+	}
+}
+
+// Get returns an action; one past its expiry reads as expired.
+func (s *PendingActionService) Get(ctx context.Context, id uuid.UUID) (*repository.PendingAdminAction, error) {
+	a, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrPendingActionNotFound
+	}
+	if isOpenPendingAction(a.Status) && !a.ExpiresAt.After(s.now()) {
+		a.Status = repository.PendingActionExpired
+	}
+	return a, nil
+}
+
+// List expires lapsed actions and returns the newest first, open ones
+// only unless all.
+func (s *PendingActionService) List(ctx context.Context, all bool, limit int) ([]repository.PendingAdminAction, error) {
+	if _, err := s.repo.Expire(ctx, s.now()); err != nil {
+		return nil, err
+	}
+	var statuses []string
+	if !all {
+		statuses = []string{repository.PendingActionAwaitingConfirmation, repository.PendingActionAwaitingApproval}
+	}
+	return s.repo.List(ctx, statuses, limit)
+}
+
+func isOpenPendingAction(status string) bool {
+	return status == repository.PendingActionAwaitingConfirmation || status == repository.PendingActionAwaitingApproval
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// memPendingActions is an in-memory PendingActionRepository.
+type memPendingActions struct {
+	actions map[uuid.UUID]*repository.PendingAdminAction
+}
+
+func (m *memPendingActions) Create(ctx context.Context, a *repository.PendingAdminAction) error {
+	a.ID, a.Status = uuid.New(), repository.PendingActionAwaitingConfirmation
+	c := *a
+	m.actions[a.ID] = &c
+	return nil
+}
+
+func (m *memPendingActions) Get(ctx context.Context, id uuid.UUID) (*repository.PendingAdminAction, error) {
+	a, ok := m.actions[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *a
+	return &c, nil
+}
+
+func (m *memPendingActions) List(ctx context.Context, statuses []string, limit int) ([]repository.PendingAdminAction, error) {
+	var out []repository.PendingAdminAction
+	for _, a := range m.actions {
+		if len(statuses) == 0 || slices.Contains(statuses, a.Status) {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (m *memPendingActions) Confirm(ctx context.Context, id uuid.UUID, status string, expiresAt, now time.Time) (bool, error) {
+	a := m.actions[id]
+	if a.Status != repository.PendingActionAwaitingConfirmation || !a.ExpiresAt.After(now) {
+		return false, nil
+	}
+	a.Status, a.ExpiresAt, a.ConfirmedAt = status, expiresAt, &now
+	return true, nil
+}
+
+func (m *memPendingActions) Decide(ctx context.Context, id uuid.UUID, from []string, status string, by uuid.UUID, note string, now time.Time) (bool, error) {
+	a := m.actions[id]
+	if !slices.Contains(from, a.Status) || !a.ExpiresAt.After(now) {
+		return false, nil
+	}
+	a.Status, a.DecidedBy, a.DecisionNote = status, &by, note
+	return true, nil
+}
+
+func (m *memPendingActions) Finish(ctx context.Context, id uuid.UUID, status string, result map[string]interface{}, errMsg string) error {
+	a := m.actions[id]
+	a.Status, a.Result, a.Error = status, result, errMsg
+	return nil
+}
+
+func (m *memPendingActions) Expire(ctx context.Context, now time.Time) (int64, error) {
+	var n int64
+	for _, a := range m.actions {
+		if isOpenPendingAction(a.Status) && !a.ExpiresAt.After(now) {
+			a.Status = repository.PendingActionExpired
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestPendingActionWorkflow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := &memPendingActions{actions: map[uuid.UUID]*repository.PendingAdminAction{}}
+	notifier := &fakeNotifier{opened: map[string]repository.AdminNotification{}}
+	svc := NewPendingActionService(repo, notifier, memSettings{})
+	svc.now = func() time.Time { return now }
+
+	var ran []string
+	execute := func(ctx context.Context, a *repository.PendingAdminAction) (map[string]interface{}, error) {
+		ran = append(ran, a.TargetID)
+		if a.TargetID == "broken" {
+			return nil, errors.New("boom")
+		}
+		return map[string]interface{}{"ok": true}, nil
+	}
+	svc.Register(PendingActionSpec{Action: "deactivate_user", Execute: execute})
+	svc.Register(PendingActionSpec{Action: "deactivate_promo_code", RequiresApproval: true, Execute: execute})
+
+	requester, approver := uuid.New(), uuid.New()
+	request := func(action, target string) *repository.PendingAdminAction {
+		a, err := svc.Request(ctx, PendingActionRequest{
+			Action: action, TargetType: "x", TargetID: target, Summary: "do " + target,
+			ConfirmPhrase: target + "@example.com", RequestedBy: requester,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	// Confirmation only: a wrong phrase or the wrong admin leaves it open.
+	a := request("deactivate_user", "u1")
+	if _, err := svc.Confirm(ctx, a.ID, requester, "u1"); !errors.Is(err, ErrPendingActionConfirmMismatch) {
+		t.Errorf("wrong phrase: %v", err)
+	}
+	if _, err := svc.Confirm(ctx, a.ID, approver, "u1@example.com"); !errors.Is(err, ErrPendingActionForbidden) {
+		t.Errorf("other admin confirming: %v", err)
+	}
+	done, err := svc.Confirm(ctx, a.ID, requester, " u1@example.com ")
+	if err != nil || done.Status != repository.PendingActionExecuted || len(ran) != 1 {
+		t.Fatalf("confirm: %+v, %v, ran %v", done, err, ran)
+	}
+	if _, err := svc.Confirm(ctx, a.ID, requester, "u1@example.com"); !errors.Is(err, ErrPendingActionState) || len(ran) != 1 {
+		t.Errorf("second confirm: %v, ran %v", err, ran)
+	}
+
+	// Approval required: confirming only moves it on and notifies.
+	p := request("deactivate_promo_code", "p1")
+	if !p.RequiresApproval {
+		t.Fatal("promo deactivation should need approval by default")
+	}
+	p, err = svc.Confirm(ctx, p.ID, requester, "p1@example.com")
+	if err != nil || p.Status != repository.PendingActionAwaitingApproval || len(ran) != 1 {
+		t.Fatalf("confirm with approval: %+v, %v", p, err)
+	}
+	if _, ok := notifier.opened[AdminNotificationKindPendingApproval+":"+p.ID.String()]; !ok {
+		t.Errorf("no approval notification: %v", notifier.opened)
+	}
+	if _, err := svc.Approve(ctx, p.ID, requester, ""); !errors.Is(err, ErrPendingActionForbidden) {
+		t.Errorf("self approval: %v", err)
+	}
+	p, err = svc.Approve(ctx, p.ID, approver, "checked with billing")
+	if err != nil || p.Status != repository.PendingActionExecuted || *p.DecidedBy != approver || len(ran) != 2 {
+		t.Fatalf("approve: %+v, %v", p, err)
+	}
+
+	// Rejected and expired actions never run.
+	r := request("deactivate_promo_code", "p2")
+	svc.Confirm(ctx, r.ID, requester, "p2@example.com")
+	if r, err = svc.Reject(ctx, r.ID, approver, "no"); err != nil || r.Status != repository.PendingActionRejected {
+		t.Fatalf("reject: %+v, %v", r, err)
+	}
+	e := request("deactivate_user", "u2")
+	now = now.Add(pendingConfirmTTL)
+	if _, err := svc.Confirm(ctx, e.ID, requester, "u2@example.com"); !errors.Is(err, ErrPendingActionState) {
+		t.Errorf("expired confirm: %v", err)
+	}
+	if open, _ := svc.List(ctx, false, 10); len(open) != 0 {
+		t.Errorf("open after expiry: %+v", open)
+	}
+	if len(ran) != 2 {
+		t.Errorf("ran %v", ran)
+	}
+
+	// A failing action is recorded as failed.
+	f := request("deactivate_user", "broken")
+	f, err = svc.Confirm(ctx, f.ID, requester, "broken@example.com")
+	if !errors.Is(err, ErrPendingActionFailed) || f.Status != repository.PendingActionFailed || repo.actions[f.ID].Error != "boom" {
+		t.Errorf("failed action: %+v, %v", f, err)
+	}
+
+	// The policy can turn approval on for an action.
+	if _, err := svc.UpdateApprovalPolicy(ctx, map[string]bool{"deactivate_user": true}, approver); err != nil {
+		t.Fatal(err)
+	}
+	if a := request("deactivate_user", "u3"); !a.RequiresApproval {
+		t.Error("policy not applied")
+	}
+	if _, err := svc.UpdateApprovalPolicy(ctx, map[string]bool{"drop_database": true}, approver); !errors.Is(err, ErrPendingActionUnknown) {
+		t.Errorf("unknown action policy: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+var ErrPerformanceWindow = errors.New("window must be between 1h and 30d")
+
+const (
+	// regressionMinRequests keeps rarely hit routes, whose percentiles are
+	// noise, out of the regression list.
+	regressionMinRequests = 50
+	// A route regressed when its p95 grew by at least regressionPct percent
+	// and regressionMinMs milliseconds against the same window a week ago.
+	regressionPct   = 25.0
+	regressionMinMs = 25.0
+)
+
+// RouteLatencyStats is one route's latency over the report window, with
+// the same window a week earlier for comparison.
+type RouteLatencyStats struct {
+	Route        string           `json:"route"`
+	Method       string           `json:"method"`
+	Requests     int64            `json:"requests"`
+	ServerErrors int64            `json:"server_errors"`
+	StatusCounts map[string]int64 `json:"status_counts"`
+	AvgMs        float64          `json:"avg_ms"`
+	P50Ms        float64          `json:"p50_ms"`
+	P95Ms        float64          `json:"p95_ms"`
+	P99Ms        float64          `json:"p99_ms"`
+	MaxMs        float64          `json:"max_ms"`
+
+	PrevRequests int64   `json:"prev_requests"`
+	PrevP50Ms    float64 `json:"prev_p50_ms"`
+	PrevP95Ms    float64 `json:"prev_p95_ms"`
+	PrevP99Ms    float64 `json:"prev_p99_ms"`
+	// P95ChangePct is against last week's p95; zero without enough
+	// requests in both windows to compare.
+	P95ChangePct float64 `json:"p95_change_pct"`
+	Regressed    bool    `json:"regressed"`
+}
+
+// RouteLatencyReport is the slow-route report. Windows end at the start of
+// the current hour; each hour is filled in by the log retention rollup a
+// few minutes after it ends.
+type RouteLatencyReport struct {
+	Window      string              `json:"window"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	CompareFrom time.Time           `json:"compare_from"`
+	CompareTo   time.Time           `json:"compare_to"`
+	Routes      []RouteLatencyStats `json:"routes"`
+	Regressions []RouteLatencyStats `json:"regressions"`
+}
+
+// PerformanceService turns the hourly latency histograms into per-route
+// percentiles and week-over-week regressions.
+type PerformanceService struct {
+	repo repository.PerformanceRepository
+	now  func() time.Time
+}
+
+func NewPerformanceService(repo repository.PerformanceRepository) *PerformanceService {
+	return &PerformanceService{repo: repo, now: time.Now}
+}
+
+// ParsePerformanceWindow parses "6h", "24h", "7d" and the like.
+func ParsePerformanceWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return 24 * time.Hour, nil
+	}
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, ErrPerformanceWindow
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, ErrPerformanceWindow
+		}
+	}
+	if d < time.Hour || d > 30*24*time.Hour {
+		return 0, ErrPerformanceWindow
+	}
+	return d.Truncate(time.Hour), nil
+}
+
+// RouteLatency reports the slowest limit routes by p95 over window, plus
+// every route whose p95 regressed against the same window last week.
+func (s *PerformanceService) RouteLatency(ctx context.Context, window time.Duration, limit int) (*RouteLatencyReport, error) {
+	to := s.now().UTC().Truncate(time.Hour)
+	rep := &RouteLatencyReport{
+		Window:      formatPerformanceWindow(window),
+		From:        to.Add(-window),
+		To:          to,
+		CompareFrom: to.Add(-window).AddDate(0, 0, -7),
+		CompareTo:   to.AddDate(0, 0, -7),
+	}
+	cur, err := s.repo.RouteLatency(ctx, rep.From, rep.To)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.repo.RouteLatency(ctx, rep.CompareFrom, rep.CompareTo)
+	if err != nil {
+		return nil, err
+	}
+
+	prevByRoute := mergeRouteLatency(prev)
+	for key, m := range mergeRouteLatency(cur) {
+		st := RouteLatencyStats{
+			Route:        key.route,
+			Method:       key.method,
+			Requests:     m.requests,
+			ServerErrors: m.errors,
+			StatusCounts: m.classes,
+			MaxMs:        m.hist.maxMs,
+			P50Ms:        m.hist.percentile(0.50),
+			P95Ms:        m.hist.percentile(0.95),
+			P99Ms:        m.hist.percentile(0.99),
+		}
+		if m.requests > 0 {
+			st.AvgMs = m.totalMs / float64(m.requests)
+		}
+		if p, ok := prevByRoute[key]; ok {
+			st.PrevRequests = p.requests
+			st.PrevP50Ms = p.hist.percentile(0.50)
+			st.PrevP95Ms = p.hist.percentile(0.95)
+			st.PrevP99Ms = p.hist.percentile(0.99)
+		}
+		if st.Requests >= regressionMinRequests && st.PrevRequests >= regressionMinRequests && st.PrevP95Ms > 0 {
+			st.P95ChangePct = (st.P95Ms - st.PrevP95Ms) / st.PrevP95Ms * 100
+			st.Regressed = st.P95ChangePct >= regressionPct && st.P95Ms-st.PrevP95Ms >= regressionMinMs
+		}
+		rep.Routes = append(rep.Routes, st)
+		if st.Regressed {
+			rep.Regressions = append(rep.Regressions, st)
+		}
+	}
+
+	sort.Slice(rep.Routes, func(i, j int) bool {
+		if rep.Routes[i].P95Ms != rep.Routes[j].P95Ms {
+			return rep.Routes[i].P95Ms > rep.Routes[j].P95Ms
+		}
+		return rep.Routes[i].Route < rep.Routes[j].Route
+	})
+	sort.Slice(rep.Regressions, func(i, j int) bool {
+		return rep.Regressions[i].P95ChangePct > rep.Regressions[j].P95ChangePct
+	})
+	if limit > 0 && len(rep.Routes) > limit {
+		rep.Routes = rep.Routes[:limit]
+	}
+	return rep, nil
+}
+
+func formatPerformanceWindow(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return fmt.Sprintf("%dh", d/time.Hour)
+}
+
+type routeMethod struct{ route, method string }
+
+// mergedRoute is one route's status classes summed together.
+type mergedRoute struct {
+	requests, errors int64
+	totalMs          float64
+	classes          map[string]int64
+	hist             latencyHistogram
+}
+
+func mergeRouteLatency(rows []repository.RouteLatency) map[routeMethod]*mergedRoute {
+	out := map[routeMethod]*mergedRoute{}
+	for _, r := range rows {
+		key := routeMethod{r.Route, r.Method}
+		m, ok := out[key]
+		if !ok {
+			m = &mergedRoute{classes: map[string]int64{}, hist: latencyHistogram{buckets: map[int]int64{}}}
+			out[key] = m
+		}
+		m.requests += r.Requests
+		m.errors += r.Errors
+		m.totalMs += r.TotalMs
+		if r.MaxMs > m.hist.maxMs {
+			m.hist.maxMs = r.MaxMs
+		}
+		class := "unknown"
+		if r.StatusClass > 0 {
+			class = strconv.Itoa(r.StatusClass) + "xx"
+		}
+		m.classes[class] += r.Requests
+		for b, n := range r.Buckets {
+			m.hist.buckets[b] += n
+			m.hist.count += n
+		}
+	}
+	return out
+}
+
+// latencyHistogram is a merged response_time_hourly histogram.
+type latencyHistogram struct {
+	buckets map[int]int64
+	count   int64
+	maxMs   float64
+}
+
+// percentile estimates the q-th quantile, interpolating linearly inside
+// the bucket it falls in. The open-ended last bucket is capped at the
+// slowest request seen.
+func (h latencyHistogram) percentile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	bounds := repository.LatencyBucketBoundsMs
+	rank := q * float64(h.count)
+	var seen int64
+	for i := 1; i <= len(bounds); i++ {
+		n := h.buckets[i]
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		lower := bounds[i-1]
+		upper := h.maxMs
+		if i < len(bounds) && bounds[i] < upper {
+			upper = bounds[i]
+		}
+		if upper < lower {
+			upper = lower
+		}
+		return lower + (upper-lower)*(rank-float64(seen))/float64(n)
+	}
+	return h.maxMs
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+// perfRepo returns canned rollups keyed by the window start.
+type perfRepo map[time.Time][]repository.RouteLatency
+
+func (r perfRepo) RouteLatency(ctx context.Context, from, to time.Time) ([]repository.RouteLatency, error) {
+	return r[from], nil
+}
+
+// bucketFor is the width_bucket index for ms.
+func bucketFor(ms float64) int {
+	b := 0
+	for i, lower := range repository.LatencyBucketBoundsMs {
+		if ms >= lower {
+			b = i + 1
+		}
+	}
+	return b
+}
+
+// latencyRow builds one rollup row from individual request latencies.
+func latencyRow(route string, class int, latencies ...float64) repository.RouteLatency {
+	row := repository.RouteLatency{Route: route, Method: "GET", StatusClass: class, Buckets: map[int]int64{}}
+	for _, ms := range latencies {
+		row.Requests++
+		row.TotalMs += ms
+		row.MaxMs = math.Max(row.MaxMs, ms)
+		row.Buckets[bucketFor(ms)]++
+		if class == 5 {
+			row.Errors++
+		}
+	}
+	return row
+}
+
+func repeat(ms float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = ms
+	}
+	return out
+}
+
+func TestLatencyHistogramPercentile(t *testing.T) {
+	// 90 requests at 12ms, 10 at 400ms: p50 falls in the 10-15 bucket,
+	// p95 and p99 in the 300-500 bucket.
+	row := latencyRow("/r", 2, append(repeat(12, 90), repeat(400, 10)...)...)
+	m := mergeRouteLatency([]repository.RouteLatency{row})[routeMethod{"/r", "GET"}]
+
+	if p := m.hist.percentile(0.50); p < 10 || p > 15 {
+		t.Errorf("p50 = %.1f, want within [10, 15]", p)
+	}
+	if p := m.hist.percentile(0.95); p < 300 || p > 400 {
+		t.Errorf("p95 = %.1f, want within [300, 400] (capped at max)", p)
+	}
+	if p := m.hist.percentile(0.99); p > m.hist.maxMs {
+		t.Errorf("p99 = %.1f above max %.1f", p, m.hist.maxMs)
+	}
+
+	// The open-ended top bucket is capped at the slowest request.
+	slow := latencyRow("/slow", 2, 45000)
+	h := mergeRouteLatency([]repository.RouteLatency{slow})[routeMethod{"/slow", "GET"}].hist
+	if p := h.percentile(0.99); p < 30000 || p > 45000 {
+		t.Errorf("top bucket p99 = %.1f, want within [30000, 45000]", p)
+	}
+}
+
+func TestRouteLatencyRegressions(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	to := now.Truncate(time.Hour)
+	from := to.Add(-24 * time.Hour)
+	prevFrom := from.AddDate(0, 0, -7)
+
+	repo := perfRepo{
+		from: {
+			latencyRow("/api/children/{id}", 2, repeat(180, 100)...),
+			latencyRow("/api/children/{id}", 5, 250),
+			latencyRow("/api/logs", 2, repeat(22, 100)...),
+			latencyRow("/api/new", 2, repeat(900, 3)...),
+		},
+		prevFrom: {
+			latencyRow("/api/children/{id}", 2, repeat(40, 100)...),
+			latencyRow("/api/logs", 2, repeat(20, 100)...),
+		},
+	}
+	svc := NewPerformanceService(repo)
+	svc.now = func() time.Time { return now }
+
+	rep, err := svc.RouteLatency(context.Background(), 24*time.Hour, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Window != "1d" || !rep.To.Equal(to) || !rep.CompareFrom.Equal(prevFrom) {
+		t.Fatalf("report window = %s %v..%v, compare from %v", rep.Window, rep.From, rep.To, rep.CompareFrom)
+	}
+	if len(rep.Routes) != 3 || rep.Routes[0].Route != "/api/new" {
+		t.Fatalf("routes not sorted by p95: %+v", rep.Routes)
+	}
+	if len(rep.Regressions) != 1 || rep.Regressions[0].Route != "/api/children/{id}" {
+		t.Fatalf("regressions = %+v, want only /api/children/{id}", rep.Regressions)
+	}
+	children := rep.Regressions[0]
+	if children.Requests != 101 || children.ServerErrors != 1 || children.StatusCounts["5xx"] != 1 {
+		t.Errorf("status classes not merged: %+v", children)
+	}
+	for _, r := range rep.Routes {
+		switch r.Route {
+		case "/api/logs":
+			if r.Regressed {
+				t.Error("a 2ms p95 change counted as a regression")
+			}
+		case "/api/new":
+			if r.Regressed || r.PrevRequests != 0 {
+				t.Error("a route with no history counted as a regression")
+			}
+		}
+	}
+
+	if rep, _ := svc.RouteLatency(context.Background(), 24*time.Hour, 1); len(rep.Routes) != 1 || len(rep.Regressions) != 1 {
+		t.Errorf("limit should cap routes but not regressions: %d routes, %d regressions", len(rep.Routes), len(rep.Regressions))
+	}
+}
+
+func TestParsePerformanceWindow(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"": 24 * time.Hour, "1h": time.Hour, "6h": 6 * time.Hour, "7d": 7 * 24 * time.Hour,
+	} {
+		if got, err := ParsePerformanceWindow(in); err != nil || got != want {
+			t.Errorf("ParsePerformanceWindow(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"30m", "31d", "xd", "soon"} {
+		if _, err := ParsePerformanceWindow(in); err == nil {
+			t.Errorf("ParsePerformanceWindow(%q) accepted", in)
+		}
+	}
+}
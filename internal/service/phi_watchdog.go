@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// AdminNotificationKindPHIAccess marks notifications about admin-context
+// code reaching PHI.
+const AdminNotificationKindPHIAccess = "phi_access"
+
+// phiCanaryReload is how often the watchdog rereads phi_canaries.
+const phiCanaryReload = 10 * time.Minute
+
+// phiCheckedLimit caps the per-statement verdict cache; admin SQL is a
+// fixed set of statements plus filter variants, well under it.
+const phiCheckedLimit = 4096
+
+// PHIWatchdog enforces the admin side of PHI isolation at runtime. It
+// sees every statement the admin repositories run (as their
+// AdminQueryWatcher) and every admin response, and opens a critical admin
+// notification when a statement breaks the PHIViolations rule, names a
+// canary row's id or token, or a response carries a canary token. The
+// statement still runs: the watchdog reports, it doesn't block.
+type PHIWatchdog struct {
+	canaries repository.PHICanaryRepository
+	notify   adminNotifier
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	byID    map[uuid.UUID]repository.PHICanary
+	byToken map[string]repository.PHICanary
+
+	checkedMu sync.Mutex
+	checked   map[string][]repository.PHIViolation
+	alerted   map[string]bool
+}
+
+func NewPHIWatchdog(canaries repository.PHICanaryRepository, notify adminNotifier) *PHIWatchdog {
+	return &PHIWatchdog{canaries: canaries, notify: notify, now: time.Now, checked: map[string][]repository.PHIViolation{}, alerted: map[string]bool{}}
+}
+
+// Load rereads the canaries. Only rows in PHI tables count; the canary
+// family and user are ordinary admin-visible metadata.
+func (w *PHIWatchdog) Load(ctx context.Context) error {
+	list, err := w.canaries.List(ctx)
+	if err != nil {
+		return err
+	}
+	byID := map[uuid.UUID]repository.PHICanary{}
+	byToken := map[string]repository.PHICanary{}
+	for _, c := range list {
+		if !slices.Contains(repository.PHITables, c.Table) {
+			continue
+		}
+		byID[c.RowID] = c
+		if c.Token != "" {
+			byToken[c.Token] = c
+		}
+	}
+	w.mu.Lock()
+	w.byID, w.byToken = byID, byToken
+	w.mu.Unlock()
+	return nil
+}
+
+// Tokens are the strings that must never appear in an admin response: the
+// canaries' tokens and row ids.
+func (w *PHIWatchdog) Tokens() []string {
+	if w == nil {
+		return nil
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	out := make([]string, 0, len(w.byToken)+len(w.byID))
+	for t := range w.byToken {
+		out = append(out, t)
+	}
+	for id := range w.byID {
+		out = append(out, id.String())
+	}
+	return out
+}
+
+// canaryIn finds a canary named in s, by token or row id.
+func (w *PHIWatchdog) canaryIn(s string) (repository.PHICanary, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for t, c := range w.byToken {
+		if strings.Contains(s, t) {
+			return c, true
+		}
+	}
+	for id, c := range w.byID {
+		if strings.Contains(s, id.String()) {
+			return c, true
+		}
+	}
+	return repository.PHICanary{}, false
+}
+
+// canaryArg finds a canary among a statement's arguments.
+func (w *PHIWatchdog) canaryArg(arg any) (repository.PHICanary, bool) {
+	switch v := arg.(type) {
+	case uuid.UUID:
+		w.mu.RLock()
+		c, ok := w.byID[v]
+		w.mu.RUnlock()
+		return c, ok
+	case *uuid.UUID:
+		if v != nil {
+			return w.canaryArg(*v)
+		}
+	case string:
+		return w.canaryIn(v)
+	case []byte:
+		return w.canaryIn(string(v))
+	case fmt.Stringer:
+		return w.canaryIn(v.String())
+	}
+	return repository.PHICanary{}, false
+}
+
+// violations is PHIViolations, remembered per statement.
+func (w *PHIWatchdog) violations(query string) []repository.PHIViolation {
+	w.checkedMu.Lock()
+	v, ok := w.checked[query]
+	w.checkedMu.Unlock()
+	if ok {
+		return v
+	}
+	v = repository.PHIViolations(query)
+	w.checkedMu.Lock()
+	if len(w.checked) < phiCheckedLimit {
+		w.checked[query] = v
+	}
+	w.checkedMu.Unlock()
+	return v
+}
+
+// WatchAdminQuery implements repository.AdminQueryWatcher.
+func (w *PHIWatchdog) WatchAdminQuery(ctx context.Context, q repository.AdminQuery) {
+	if v := w.violations(q.SQL); len(v) > 0 {
+		described := make([]string, len(v))
+		for i, x := range v {
+			described[i] = x.String()
+		}
+		w.alert("query:"+q.Method, "Admin query reads PHI",
+			fmt.Sprintf("%s ran a statement that %s.", methodOrUnknown(q.Method), strings.Join(described, ", ")),
+			map[string]any{"method": q.Method, "violations": described, "sql": truncateSQL(q.SQL)})
+	}
+	c, ok := w.canaryIn(q.SQL)
+	for _, arg := range q.Args {
+		if ok {
+			break
+		}
+		c, ok = w.canaryArg(arg)
+	}
+	if ok {
+		w.alert("canary:"+q.Method, "Admin query touched a PHI canary",
+			fmt.Sprintf("%s ran a statement naming the %s canary row. Admin code has reached PHI.", methodOrUnknown(q.Method), c.Table),
+			map[string]any{"method": q.Method, "canary_table": c.Table, "canary_row": c.RowID, "sql": truncateSQL(q.SQL)})
+	}
+}
+
+// ReportResponse records that an admin response carried a canary token
+// or row id.
+func (w *PHIWatchdog) ReportResponse(method, route string, adminID uuid.UUID, token string) {
+	c, _ := w.canaryIn(token)
+	details := map[string]any{"method": method, "route": route, "canary_table": c.Table, "canary_row": c.RowID}
+	if adminID != uuid.Nil {
+		details["admin_id"] = adminID
+	}
+	w.alert("response:"+method+" "+route, "Admin response contained PHI",
+		fmt.Sprintf("%s %s returned the %s canary row to an admin. Admin code has reached PHI.", method, route, c.Table),
+		details)
+}
+
+// alert logs and opens a critical notification, once per source a day.
+// The notification is written in the background; the caller may be a
+// request in flight.
+func (w *PHIWatchdog) alert(source, title, message string, details map[string]any) {
+	key := AdminNotificationKindPHIAccess + ":" + source + ":" + w.now().UTC().Format("2006-01-02")
+	w.checkedMu.Lock()
+	seen := w.alerted[key]
+	if !seen && len(w.alerted) < phiCheckedLimit {
+		w.alerted[key] = true
+	}
+	w.checkedMu.Unlock()
+	if seen {
+		return
+	}
+	log.Printf("[PHI-WATCHDOG] CRITICAL %s: %s", title, message)
+	if w.notify == nil {
+		return
+	}
+	raw, err := json.Marshal(details)
+	if err != nil {
+		raw = nil
+	}
+	n := &repository.AdminNotification{
+		Kind:      AdminNotificationKindPHIAccess,
+		Severity:  "critical",
+		Title:     title,
+		Message:   message,
+		DedupeKey: key,
+		Details:   raw,
+	}
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("[PHI-WATCHDOG] notify goroutine panic: %v", rec)
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := w.notify.Open(ctx, n); err != nil {
+			log.Printf("[PHI-WATCHDOG] notify: %v", err)
+		}
+	}()
+}
+
+func methodOrUnknown(m string) string {
+	if m == "" {
+		return "An admin repository method"
+	}
+	return m
+}
+
+// truncateSQL keeps a statement short enough for a notification. SQL
+// text holds no row values; arguments are never recorded.
+func truncateSQL(q string) string {
+	q = strings.Join(strings.Fields(q), " ")
+	if len(q) > 500 {
+		q = q[:500] + "…"
+	}
+	return q
+}
+
+// Start loads the canaries and rereads them every phiCanaryReload until
+// ctx ends. Every instance runs it: each watches its own statements.
+func (w *PHIWatchdog) Start(ctx context.Context) {
+	log.Println("PHI watchdog started")
+	load := func() {
+		if err := w.Load(ctx); err != nil {
+			log.Printf("[PHI-WATCHDOG] load canaries: %v", err)
+		}
+	}
+	load()
+	ticker := time.NewTicker(phiCanaryReload)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("PHI watchdog stopped")
+			return
+		case <-ticker.C:
+			load()
+		}
+	}
+}
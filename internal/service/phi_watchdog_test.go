@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakePHICanaries []repository.PHICanary
+
+func (f fakePHICanaries) List(ctx context.Context) ([]repository.PHICanary, error) {
+	return f, nil
+}
+
+// chanNotifier hands each notification to the test; the watchdog opens
+// them in the background.
+type chanNotifier chan repository.AdminNotification
+
+func (c chanNotifier) Open(ctx context.Context, n *repository.AdminNotification) (bool, error) {
+	c <- *n
+	return true, nil
+}
+
+func (c chanNotifier) next(t *testing.T) repository.AdminNotification {
+	t.Helper()
+	select {
+	case n := <-c:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("no notification")
+	}
+	return repository.AdminNotification{}
+}
+
+func (c chanNotifier) none(t *testing.T) {
+	t.Helper()
+	select {
+	case n := <-c:
+		t.Fatalf("unexpected notification %+v", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPHIWatchdog(t *testing.T) {
+	child, family := uuid.New(), uuid.New()
+	notes := make(chanNotifier, 4)
+	w := NewPHIWatchdog(fakePHICanaries{
+		{Table: "children", RowID: child, Token: "phi-canary-abc"},
+		{Table: "families", RowID: family},
+	}, notes)
+	if err := w.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Counting and the canary family are fine.
+	w.WatchAdminQuery(ctx, repository.AdminQuery{Method: "adminRepo.GetFamilyByID",
+		SQL: "SELECT f.name, (SELECT COUNT(*) FROM children WHERE family_id = f.id) FROM families f WHERE f.id = $1", Args: []any{family}})
+	notes.none(t)
+
+	w.WatchAdminQuery(ctx, repository.AdminQuery{Method: "adminRepo.GetChild", SQL: "SELECT COUNT(*) FROM app_users WHERE id = $1", Args: []any{child}})
+	n := notes.next(t)
+	if n.Kind != AdminNotificationKindPHIAccess || n.Severity != "critical" || n.Title != "Admin query touched a PHI canary" {
+		t.Errorf("canary notification = %+v", n)
+	}
+
+	w.WatchAdminQuery(ctx, repository.AdminQuery{Method: "adminRepo.Search", SQL: "SELECT id FROM app_users WHERE first_name = $1", Args: []any{"x phi-canary-abc"}})
+	if n := notes.next(t); n.DedupeKey == "" || n.Title != "Admin query touched a PHI canary" {
+		t.Errorf("token notification = %+v", n)
+	}
+
+	bad := repository.AdminQuery{Method: "adminRepo.ListChildren", SQL: "SELECT first_name FROM children"}
+	w.WatchAdminQuery(ctx, bad)
+	if n := notes.next(t); n.Title != "Admin query reads PHI" || n.Severity != "critical" {
+		t.Errorf("violation notification = %+v", n)
+	}
+	// Once a day per method.
+	w.WatchAdminQuery(ctx, bad)
+	notes.none(t)
+
+	w.ReportResponse("GET", "/api/admin/families/{id}", uuid.New(), child.String())
+	if n := notes.next(t); n.Title != "Admin response contained PHI" {
+		t.Errorf("response notification = %+v", n)
+	}
+
+	tokens := w.Tokens()
+	if len(tokens) != 2 {
+		t.Errorf("Tokens = %q, want the child's token and id only", tokens)
+	}
+}
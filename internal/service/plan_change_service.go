@@ -0,0 +1,328 @@
+package service
+
+// plan_change_service.go — mid-cycle plan switches for families.
+//
+// A parent previews the switch (Stripe prices the proration for the rest
+// of the period), then confirms it; Stripe invoices the difference straight
+// away. Every switch — in the app or in the Stripe Dashboard — is recorded
+// in subscription_plan_changes, which feeds the daily revenue snapshot's
+// upgrade/downgrade counters. A plan the family has outgrown (too many
+// children or members) can't be switched to.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// planChangeProrationWindow is how old a previewed proration date may be
+// when the change is confirmed; older previews are re-priced at now.
+const planChangeProrationWindow = time.Hour
+
+var (
+	ErrPlanChangeNoSubscription = errors.New("no subscription found for this family")
+	ErrPlanChangePlanInvalid    = apperr.Validation("not an available plan")
+	ErrPlanChangeSamePlan       = errors.New("the family is already on this plan")
+	ErrPlanChangeNotAllowed     = errors.New("this subscription can't change plans")
+	ErrPlanChangeEntitlements   = errors.New("the family uses more than this plan allows")
+	ErrPlanChangeBillingOff     = errors.New("plan changes aren't available right now")
+	ErrPlanChangeBillingFailed  = errors.New("couldn't update the subscription with the payment provider")
+)
+
+// PlanBiller switches family subscriptions at the payment provider.
+// StripeService implements it.
+type PlanBiller interface {
+	// PreviewPlanChange prices moving the subscription to the price as of
+	// prorationDate (unix seconds), with the proration invoiced at once.
+	PreviewPlanChange(ctx context.Context, subscriptionID, priceID string, prorationDate int64) (*PlanProration, error)
+	// ChangeSubscriptionPrice moves the subscription to the price, invoicing
+	// the proration as of prorationDate immediately, and returns the
+	// prorated amount in cents (negative for a credit).
+	ChangeSubscriptionPrice(ctx context.Context, subscriptionID, priceID string, prorationDate int64) (int64, error)
+}
+
+// PlanProration is what a switch costs now. ProrationCents is the net of
+// the unused-time credit and the new plan's charge for the rest of the
+// period (negative for a credit); AmountDueCents is what the immediate
+// invoice collects, after tax and any balance.
+type PlanProration struct {
+	ProrationCents int64  `json:"proration_cents"`
+	AmountDueCents int64  `json:"amount_due_cents"`
+	Currency       string `json:"currency"`
+}
+
+// PlanChangePreview is what switching to a plan would do.
+type PlanChangePreview struct {
+	FromPlan      models.SubscriptionPlan `json:"from_plan"`
+	ToPlan        models.SubscriptionPlan `json:"to_plan"`
+	Direction     string                  `json:"direction"`
+	Proration     *PlanProration          `json:"proration,omitempty"` // nil when not billed through Stripe
+	ProrationDate int64                   `json:"proration_date"`
+	Allowed       bool                    `json:"allowed"`
+	Blockers      []string                `json:"blockers"`
+	Usage         repository.FamilyUsage  `json:"usage"`
+}
+
+// PlanChangeService previews, applies and records family plan switches.
+type PlanChangeService struct {
+	billing repository.BillingRepository
+	repo    repository.PlanChangeRepository
+	biller  PlanBiller // nil when Stripe is disabled
+	now     func() time.Time
+}
+
+func NewPlanChangeService(billing repository.BillingRepository, repo repository.PlanChangeRepository) *PlanChangeService {
+	return &PlanChangeService{billing: billing, repo: repo, now: time.Now}
+}
+
+// SetPlanBiller attaches the payment provider. Without one, only families
+// not yet billed through Stripe (trials) can switch.
+func (s *PlanChangeService) SetPlanBiller(b PlanBiller) {
+	s.biller = b
+}
+
+// monthlyEquivalentCents puts plans of different intervals on one scale.
+func monthlyEquivalentCents(p *models.SubscriptionPlan) int64 {
+	if p.BillingInterval == models.BillingIntervalYearly {
+		return int64(p.PriceCents) / 12
+	}
+	return int64(p.PriceCents)
+}
+
+// planChangeDirection is "upgrade" unless the target costs less per month.
+func planChangeDirection(from, to *models.SubscriptionPlan) string {
+	if monthlyEquivalentCents(to) < monthlyEquivalentCents(from) {
+		return "downgrade"
+	}
+	return "upgrade"
+}
+
+// planBlockers lists what the family uses beyond the plan's limits.
+// Negative limits are unlimited.
+func planBlockers(plan *models.SubscriptionPlan, u repository.FamilyUsage) []string {
+	out := []string{}
+	if plan.MaxChildren >= 0 && u.Children > plan.MaxChildren {
+		out = append(out, fmt.Sprintf("%s allows %d %s; the family has %d. Remove %d before switching.",
+			plan.Name, plan.MaxChildren, plural(plan.MaxChildren, "child", "children"), u.Children, u.Children-plan.MaxChildren))
+	}
+	if plan.MaxFamilyMembers >= 0 && u.Members > plan.MaxFamilyMembers {
+		out = append(out, fmt.Sprintf("%s allows %d family %s; the family has %d. Remove %d before switching.",
+			plan.Name, plan.MaxFamilyMembers, plural(plan.MaxFamilyMembers, "member", "members"), u.Members, u.Members-plan.MaxFamilyMembers))
+	}
+	return out
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// planChange is a validated switch: the subscription and both plans.
+type planChange struct {
+	sub      *models.FamilySubscription
+	from, to *models.SubscriptionPlan
+	usage    repository.FamilyUsage
+	stripe   bool
+}
+
+func (s *PlanChangeService) prepare(ctx context.Context, familyID, planID uuid.UUID) (*planChange, error) {
+	sub, err := s.billing.GetFamilySubscription(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrPlanChangeNoSubscription
+	}
+	switch sub.Status {
+	case models.SubscriptionStatusActive, models.SubscriptionStatusTrialing:
+	case models.SubscriptionStatusComped:
+		return nil, fmt.Errorf("%w: complimentary subscriptions are managed by support", ErrPlanChangeNotAllowed)
+	case models.SubscriptionStatusPastDue:
+		return nil, fmt.Errorf("%w: update the payment method first", ErrPlanChangeNotAllowed)
+	default:
+		return nil, fmt.Errorf("%w: subscribe to a plan instead", ErrPlanChangeNotAllowed)
+	}
+	if sub.PlanID == planID {
+		return nil, ErrPlanChangeSamePlan
+	}
+	plans, err := s.billing.GetActivePlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &planChange{sub: sub, stripe: sub.StripeSubscriptionID.Valid && sub.StripeSubscriptionID.String != ""}
+	for i := range plans {
+		switch plans[i].ID {
+		case sub.PlanID:
+			c.from = &plans[i]
+		case planID:
+			c.to = &plans[i]
+		}
+	}
+	if c.to == nil || c.to.SeatBased || c.to.BillingInterval == models.BillingIntervalLifetime {
+		return nil, ErrPlanChangePlanInvalid
+	}
+	if c.from == nil {
+		return nil, fmt.Errorf("%w: the current plan is no longer offered; contact support", ErrPlanChangeNotAllowed)
+	}
+	if c.from.BillingInterval == models.BillingIntervalLifetime {
+		return nil, fmt.Errorf("%w: lifetime plans don't renew, so there's nothing to prorate", ErrPlanChangeNotAllowed)
+	}
+	if c.stripe && (!c.to.StripePriceID.Valid || c.to.StripePriceID.String == "") {
+		return nil, ErrPlanChangePlanInvalid
+	}
+	if !c.stripe && sub.Status != models.SubscriptionStatusTrialing {
+		// Active but not billed through Stripe: switching would skip payment.
+		return nil, fmt.Errorf("%w: subscribe to a plan instead", ErrPlanChangeNotAllowed)
+	}
+	usage, err := s.repo.FamilyUsage(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	c.usage = *usage
+	return c, nil
+}
+
+// Preview prices switching the family to the plan and reports anything
+// that blocks the switch. Pass its ProrationDate back to Change to be
+// charged what was shown.
+func (s *PlanChangeService) Preview(ctx context.Context, familyID, planID uuid.UUID) (*PlanChangePreview, error) {
+	c, err := s.prepare(ctx, familyID, planID)
+	if err != nil {
+		return nil, err
+	}
+	p := &PlanChangePreview{
+		FromPlan:      *c.from,
+		ToPlan:        *c.to,
+		Direction:     planChangeDirection(c.from, c.to),
+		ProrationDate: s.now().Unix(),
+		Blockers:      planBlockers(c.to, c.usage),
+		Usage:         c.usage,
+	}
+	p.Allowed = len(p.Blockers) == 0
+	if c.stripe && p.Allowed {
+		if s.biller == nil {
+			return nil, ErrPlanChangeBillingOff
+		}
+		p.Proration, err = s.biller.PreviewPlanChange(ctx, c.sub.StripeSubscriptionID.String, c.to.StripePriceID.String, p.ProrationDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPlanChangeBillingFailed, err)
+		}
+	}
+	return p, nil
+}
+
+// Change switches the family to the plan, charging or crediting the
+// proration as of prorationDate (0, or a stale preview, means now).
+func (s *PlanChangeService) Change(ctx context.Context, familyID, planID, by uuid.UUID, prorationDate int64) (*repository.PlanChange, error) {
+	c, err := s.prepare(ctx, familyID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if blockers := planBlockers(c.to, c.usage); len(blockers) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPlanChangeEntitlements, blockers[0])
+	}
+	now := s.now().Unix()
+	if prorationDate > now || prorationDate < now-int64(planChangeProrationWindow/time.Second) {
+		prorationDate = now
+	}
+	change := &repository.PlanChange{
+		FamilySubscriptionID: c.sub.ID,
+		FamilyID:             familyID,
+		FromPlanID:           c.from.ID,
+		ToPlanID:             c.to.ID,
+		Direction:            planChangeDirection(c.from, c.to),
+		Source:               "app",
+	}
+	if by != uuid.Nil {
+		change.ChangedBy = &by
+	}
+	if c.stripe {
+		if s.biller == nil {
+			return nil, ErrPlanChangeBillingOff
+		}
+		change.ProrationCents, err = s.biller.ChangeSubscriptionPrice(ctx,
+			c.sub.StripeSubscriptionID.String, c.to.StripePriceID.String, prorationDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPlanChangeBillingFailed, err)
+		}
+	}
+	if err := s.repo.Apply(ctx, change); err != nil {
+		if errors.Is(err, repository.ErrPlanChangeConflict) {
+			// Stripe's subscription.updated webhook can land first and
+			// record the switch itself; that's the same change.
+			if sub, gerr := s.billing.GetFamilySubscription(ctx, familyID); gerr == nil && sub != nil && sub.PlanID == c.to.ID {
+				return change, nil
+			}
+		}
+		return nil, err
+	}
+	log.Printf("[PLAN_CHANGE] family %s %s %s -> %s proration=%d", familyID, change.Direction, c.from.Name, c.to.Name, change.ProrationCents)
+	return change, nil
+}
+
+// History returns the family's recent plan switches.
+func (s *PlanChangeService) History(ctx context.Context, familyID uuid.UUID) ([]repository.PlanChange, error) {
+	out, err := s.repo.ListForFamily(ctx, familyID, 20)
+	if out == nil {
+		out = []repository.PlanChange{}
+	}
+	return out, err
+}
+
+// SyncFromStripe records a switch made outside the app (Stripe Dashboard,
+// customer portal) when a subscription.updated webhook carries a price
+// other than the family's plan. Switches made here are already applied
+// and fall through as no-ops.
+func (s *PlanChangeService) SyncFromStripe(ctx context.Context, stripeSubscriptionID, priceID string) error {
+	if priceID == "" {
+		return nil
+	}
+	sub, err := s.repo.SubscriptionByStripeID(ctx, stripeSubscriptionID)
+	if err != nil || sub == nil {
+		return err
+	}
+	plans, err := s.billing.GetActivePlans(ctx)
+	if err != nil {
+		return err
+	}
+	var from, to *models.SubscriptionPlan
+	for i := range plans {
+		if plans[i].ID == sub.PlanID {
+			from = &plans[i]
+		}
+		if plans[i].StripePriceID.Valid && plans[i].StripePriceID.String == priceID {
+			to = &plans[i]
+		}
+	}
+	if to == nil || to.ID == sub.PlanID {
+		return nil
+	}
+	if from == nil {
+		// The old plan was retired, so there's no price to compare;
+		// count moving off it as an upgrade.
+		from = &models.SubscriptionPlan{ID: sub.PlanID}
+	}
+	change := &repository.PlanChange{
+		FamilySubscriptionID: sub.ID,
+		FamilyID:             sub.FamilyID,
+		FromPlanID:           sub.PlanID,
+		ToPlanID:             to.ID,
+		Direction:            planChangeDirection(from, to),
+		Source:               "stripe",
+	}
+	if err := s.repo.Apply(ctx, change); err != nil && !errors.Is(err, repository.ErrPlanChangeConflict) {
+		return fmt.Errorf("record plan change for %s: %w", stripeSubscriptionID, err)
+	}
+	log.Printf("[PLAN_CHANGE] family %s %s to %s via Stripe", sub.FamilyID, change.Direction, to.Name)
+	return nil
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakePlanBilling struct {
+	repository.BillingRepository
+	sub   *models.FamilySubscription
+	plans []models.SubscriptionPlan
+}
+
+func (f *fakePlanBilling) GetFamilySubscription(ctx context.Context, familyID uuid.UUID) (*models.FamilySubscription, error) {
+	if f.sub == nil || f.sub.FamilyID != familyID {
+		return nil, nil
+	}
+	cp := *f.sub
+	return &cp, nil
+}
+
+func (f *fakePlanBilling) GetActivePlans(ctx context.Context) ([]models.SubscriptionPlan, error) {
+	return f.plans, nil
+}
+
+type fakePlanChangeRepo struct {
+	billing *fakePlanBilling
+	usage   repository.FamilyUsage
+	changes []repository.PlanChange
+}
+
+func (f *fakePlanChangeRepo) Apply(ctx context.Context, c *repository.PlanChange) error {
+	if f.billing.sub.PlanID != c.FromPlanID {
+		return repository.ErrPlanChangeConflict
+	}
+	f.billing.sub.PlanID = c.ToPlanID
+	f.changes = append(f.changes, *c)
+	return nil
+}
+
+func (f *fakePlanChangeRepo) ListForFamily(ctx context.Context, familyID uuid.UUID, limit int) ([]repository.PlanChange, error) {
+	return f.changes, nil
+}
+
+func (f *fakePlanChangeRepo) FamilyUsage(ctx context.Context, familyID uuid.UUID) (*repository.FamilyUsage, error) {
+	u := f.usage
+	return &u, nil
+}
+
+func (f *fakePlanChangeRepo) SubscriptionByStripeID(ctx context.Context, id string) (*models.FamilySubscription, error) {
+	if s := f.billing.sub; s.StripeSubscriptionID.String == id {
+		cp := *s
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+type fakePlanBiller struct {
+	previews  int
+	changes   []string
+	proration int64
+	dates     []int64
+}
+
+func (f *fakePlanBiller) PreviewPlanChange(ctx context.Context, subscriptionID, priceID string, prorationDate int64) (*PlanProration, error) {
+	f.previews++
+	return &PlanProration{ProrationCents: f.proration, AmountDueCents: f.proration, Currency: "USD"}, nil
+}
+
+func (f *fakePlanBiller) ChangeSubscriptionPrice(ctx context.Context, subscriptionID, priceID string, prorationDate int64) (int64, error) {
+	f.changes = append(f.changes, priceID)
+	f.dates = append(f.dates, prorationDate)
+	return f.proration, nil
+}
+
+func stripeID(s string) models.NullString {
+	return models.NullString{NullString: sql.NullString{String: s, Valid: s != ""}}
+}
+
+var (
+	testSingleChild = models.SubscriptionPlan{ID: uuid.New(), Name: "Single Child", PriceCents: 1000,
+		BillingInterval: models.BillingIntervalMonthly, MaxChildren: 1, MaxFamilyMembers: 10, StripePriceID: stripeID("price_single")}
+	testFamily = models.SubscriptionPlan{ID: uuid.New(), Name: "Family", PriceCents: 1500,
+		BillingInterval: models.BillingIntervalMonthly, MaxChildren: -1, MaxFamilyMembers: 10, StripePriceID: stripeID("price_family")}
+	testFamilyYearly = models.SubscriptionPlan{ID: uuid.New(), Name: "Family (yearly)", PriceCents: 15000,
+		BillingInterval: models.BillingIntervalYearly, MaxChildren: -1, MaxFamilyMembers: 10, StripePriceID: stripeID("price_family_yr")}
+	testClinic = models.SubscriptionPlan{ID: uuid.New(), Name: "Clinic", PriceCents: 800,
+		BillingInterval: models.BillingIntervalMonthly, SeatBased: true, StripePriceID: stripeID("price_clinic")}
+)
+
+func newPlanChangeTest(plan models.SubscriptionPlan, status models.SubscriptionStatus, stripeSub string) (*PlanChangeService, *fakePlanChangeRepo, *fakePlanBiller) {
+	billing := &fakePlanBilling{
+		sub: &models.FamilySubscription{ID: uuid.New(), FamilyID: uuid.New(), PlanID: plan.ID, Status: status,
+			StripeSubscriptionID: stripeID(stripeSub)},
+		plans: []models.SubscriptionPlan{testSingleChild, testFamily, testFamilyYearly, testClinic},
+	}
+	repo := &fakePlanChangeRepo{billing: billing, usage: repository.FamilyUsage{Children: 1, Members: 2}}
+	biller := &fakePlanBiller{proration: 250}
+	svc := NewPlanChangeService(billing, repo)
+	svc.SetPlanBiller(biller)
+	svc.now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+	return svc, repo, biller
+}
+
+func TestPlanChangeDirection(t *testing.T) {
+	cases := []struct {
+		from, to models.SubscriptionPlan
+		want     string
+	}{
+		{testSingleChild, testFamily, "upgrade"},
+		{testFamily, testSingleChild, "downgrade"},
+		{testFamily, testFamilyYearly, "downgrade"}, // $150/yr is $12.50/mo
+		{testSingleChild, testFamilyYearly, "upgrade"},
+	}
+	for _, c := range cases {
+		if got := planChangeDirection(&c.from, &c.to); got != c.want {
+			t.Errorf("%s -> %s: got %s, want %s", c.from.Name, c.to.Name, got, c.want)
+		}
+	}
+}
+
+func TestPlanChangeUpgradeThroughStripe(t *testing.T) {
+	svc, repo, biller := newPlanChangeTest(testSingleChild, models.SubscriptionStatusActive, "sub_1")
+	ctx := context.Background()
+	familyID := repo.billing.sub.FamilyID
+
+	preview, err := svc.Preview(ctx, familyID, testFamily.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !preview.Allowed || preview.Direction != "upgrade" || preview.Proration == nil || preview.Proration.ProrationCents != 250 {
+		t.Errorf("preview = %+v", preview)
+	}
+	by := uuid.New()
+	change, err := svc.Change(ctx, familyID, testFamily.ID, by, preview.ProrationDate-60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(biller.changes) != 1 || biller.changes[0] != "price_family" || biller.dates[0] != preview.ProrationDate-60 {
+		t.Errorf("stripe changes %v at %v", biller.changes, biller.dates)
+	}
+	if change.Direction != "upgrade" || change.ProrationCents != 250 || change.Source != "app" || *change.ChangedBy != by {
+		t.Errorf("change = %+v", change)
+	}
+	if repo.billing.sub.PlanID != testFamily.ID || len(repo.changes) != 1 {
+		t.Errorf("plan %s, %d changes recorded", repo.billing.sub.PlanID, len(repo.changes))
+	}
+
+	// A stale or future proration date is re-priced at now.
+	svc.Change(ctx, familyID, testSingleChild.ID, by, preview.ProrationDate-7200)
+	if got := biller.dates[len(biller.dates)-1]; got != preview.ProrationDate {
+		t.Errorf("stale proration date used %d, want now %d", got, preview.ProrationDate)
+	}
+}
+
+func TestPlanChangeDowngradeBlockedByEntitlements(t *testing.T) {
+	svc, repo, biller := newPlanChangeTest(testFamily, models.SubscriptionStatusActive, "sub_1")
+	repo.usage = repository.FamilyUsage{Children: 3, Members: 4}
+	ctx := context.Background()
+	familyID := repo.billing.sub.FamilyID
+
+	preview, err := svc.Preview(ctx, familyID, testSingleChild.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Allowed || len(preview.Blockers) != 1 || preview.Direction != "downgrade" || preview.Proration != nil {
+		t.Errorf("preview = %+v", preview)
+	}
+	if biller.previews != 0 {
+		t.Error("a blocked switch must not be priced")
+	}
+	if _, err := svc.Change(ctx, familyID, testSingleChild.ID, uuid.Nil, 0); !errors.Is(err, ErrPlanChangeEntitlements) {
+		t.Errorf("downgrade with 3 children: got %v", err)
+	}
+	if len(biller.changes) != 0 || len(repo.changes) != 0 {
+		t.Error("a blocked downgrade must not touch Stripe or the plan")
+	}
+
+	repo.usage.Children = 1
+	if _, err := svc.Change(ctx, familyID, testSingleChild.ID, uuid.Nil, 0); err != nil {
+		t.Fatalf("downgrade with 1 child: %v", err)
+	}
+	if repo.changes[0].Direction != "downgrade" {
+		t.Errorf("recorded %s", repo.changes[0].Direction)
+	}
+}
+
+func TestPlanChangeRejects(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name   string
+		status models.SubscriptionStatus
+		stripe string
+		to     uuid.UUID
+		want   error
+	}{
+		{"same plan", models.SubscriptionStatusActive, "sub_1", testSingleChild.ID, ErrPlanChangeSamePlan},
+		{"comped", models.SubscriptionStatusComped, "", testFamily.ID, ErrPlanChangeNotAllowed},
+		{"past due", models.SubscriptionStatusPastDue, "sub_1", testFamily.ID, ErrPlanChangeNotAllowed},
+		{"cancelled", models.SubscriptionStatusCancelled, "sub_1", testFamily.ID, ErrPlanChangeNotAllowed},
+		{"active without Stripe", models.SubscriptionStatusActive, "", testFamily.ID, ErrPlanChangeNotAllowed},
+		{"unknown plan", models.SubscriptionStatusActive, "sub_1", uuid.New(), ErrPlanChangePlanInvalid},
+		{"organization plan", models.SubscriptionStatusActive, "sub_1", testClinic.ID, ErrPlanChangePlanInvalid},
+	}
+	for _, c := range cases {
+		svc, repo, _ := newPlanChangeTest(testSingleChild, c.status, c.stripe)
+		if _, err := svc.Change(ctx, repo.billing.sub.FamilyID, c.to, uuid.Nil, 0); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+func TestPlanChangeTrialWithoutStripe(t *testing.T) {
+	svc, repo, biller := newPlanChangeTest(testSingleChild, models.SubscriptionStatusTrialing, "")
+	change, err := svc.Change(context.Background(), repo.billing.sub.FamilyID, testFamily.ID, uuid.Nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.ProrationCents != 0 || len(biller.changes) != 0 {
+		t.Error("a trial not billed through Stripe switches locally")
+	}
+}
+
+func TestPlanChangeSyncFromStripe(t *testing.T) {
+	svc, repo, _ := newPlanChangeTest(testFamily, models.SubscriptionStatusActive, "sub_1")
+	ctx := context.Background()
+
+	if err := svc.SyncFromStripe(ctx, "sub_1", "price_family"); err != nil || len(repo.changes) != 0 {
+		t.Fatalf("unchanged price: err %v, %d changes", err, len(repo.changes))
+	}
+	if err := svc.SyncFromStripe(ctx, "sub_1", "price_single"); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.changes) != 1 || repo.changes[0].Direction != "downgrade" || repo.changes[0].Source != "stripe" {
+		t.Errorf("changes = %+v", repo.changes)
+	}
+	// Redelivery is a no-op; so are unknown prices and subscriptions.
+	svc.SyncFromStripe(ctx, "sub_1", "price_single")
+	svc.SyncFromStripe(ctx, "sub_1", "price_unknown")
+	svc.SyncFromStripe(ctx, "sub_other", "price_family")
+	if len(repo.changes) != 1 {
+		t.Errorf("%d changes after no-op syncs", len(repo.changes))
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+// Product analytics windows. Everything is as of the last complete UTC day.
+const (
+	productAnalyticsTTL = time.Hour
+	retentionWeeks      = 12 // weekly signup cohorts shown
+	activeUsersDays     = 90 // days of DAU/WAU/MAU history
+	adoptionWindowDays  = 30 // feature adoption looks at entries this recent
+	funnelWeeks         = 12 // weekly signup cohorts in the activation funnel
+	// activationWeekDays is how many of the 7 days starting with a
+	// parent's first entry they must log on to count as activated.
+	activationWeekDays = 4
+)
+
+// RetentionCohort is one weekly signup cohort. Active[n] is how many of
+// its parents were active n weeks after signing up and Retention[n] is
+// that as a fraction of Size; the last entry is the current, partial week.
+type RetentionCohort struct {
+	CohortWeek time.Time `json:"cohort_week"`
+	Size       int       `json:"cohort_size"`
+	Active     []int     `json:"active_users"`
+	Retention  []float64 `json:"retention"`
+}
+
+// ProductAnalytics is the cached analytics snapshot behind the admin
+// dashboard and /api/admin/analytics.
+type ProductAnalytics struct {
+	ComputedAt         time.Time                        `json:"computed_at"`
+	AsOf               time.Time                        `json:"as_of"`
+	Retention          []RetentionCohort                `json:"retention"`
+	ActiveUsers        []repository.ActiveUsersRow      `json:"active_users"`
+	Adoption           *repository.FeatureAdoption      `json:"feature_adoption"`
+	AdoptionWindowDays int                              `json:"adoption_window_days"`
+	Funnel             []repository.ActivationFunnelRow `json:"activation_funnel"`
+	ActivationWeekDays int                              `json:"activation_week_days"`
+}
+
+// Latest returns the most recent DAU/WAU/MAU row, or nil.
+func (p *ProductAnalytics) Latest() *repository.ActiveUsersRow {
+	if len(p.ActiveUsers) == 0 {
+		return nil
+	}
+	return &p.ActiveUsers[len(p.ActiveUsers)-1]
+}
+
+// ProductAnalyticsService computes the product analytics snapshot and
+// caches it in system_metrics_cache so dashboard loads don't rerun the
+// queries. The scheduler refreshes it hourly; Get recomputes on a miss.
+type ProductAnalyticsService struct {
+	repo repository.ProductAnalyticsRepository
+	mu   sync.Mutex // one refresh at a time
+	now  func() time.Time
+}
+
+func NewProductAnalyticsService(repo repository.ProductAnalyticsRepository) *ProductAnalyticsService {
+	return &ProductAnalyticsService{repo: repo, now: time.Now}
+}
+
+// Get returns the cached snapshot, recomputing it when it is missing or
+// older than an hour. A failed recompute serves the stale snapshot if
+// there is one.
+func (s *ProductAnalyticsService) Get(ctx context.Context) (*ProductAnalytics, error) {
+	cached, err := s.cached(ctx)
+	if err != nil {
+		log.Printf("Product analytics: read cache: %v", err)
+	}
+	if cached != nil && s.now().Sub(cached.ComputedAt) < productAnalyticsTTL {
+		return cached, nil
+	}
+	fresh, err := s.Refresh(ctx)
+	if err != nil {
+		if cached != nil {
+			log.Printf("Product analytics: refresh failed, serving snapshot from %s: %v", cached.ComputedAt.Format(time.RFC3339), err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	return fresh, nil
+}
+
+func (s *ProductAnalyticsService) cached(ctx context.Context) (*ProductAnalytics, error) {
+	raw, _, err := s.repo.CachedSnapshot(ctx)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var p ProductAnalytics
+	if err := json.Unmarshal(raw, &p); err != nil {
+		// An older snapshot shape is just a cache miss.
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// Refresh recomputes the snapshot and stores it.
+func (s *ProductAnalyticsService) Refresh(ctx context.Context) (*ProductAnalytics, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	asOf := utcDay(now).AddDate(0, 0, -1)
+	p := &ProductAnalytics{
+		ComputedAt:         now,
+		AsOf:               asOf,
+		AdoptionWindowDays: adoptionWindowDays,
+		ActivationWeekDays: activationWeekDays,
+	}
+
+	rows, err := s.repo.WeeklyRetention(ctx, asOf, retentionWeeks)
+	if err != nil {
+		return nil, err
+	}
+	p.Retention = groupRetention(rows)
+
+	if p.ActiveUsers, err = s.repo.ActiveUsers(ctx, asOf.AddDate(0, 0, -(activeUsersDays-1)), asOf); err != nil {
+		return nil, err
+	}
+	if p.Adoption, err = s.repo.FeatureAdoption(ctx, asOf.AddDate(0, 0, -(adoptionWindowDays-1))); err != nil {
+		return nil, err
+	}
+	if p.Funnel, err = s.repo.ActivationFunnel(ctx, asOf.AddDate(0, 0, -7*(funnelWeeks-1)), asOf, activationWeekDays); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveSnapshot(ctx, raw, now); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// groupRetention folds the per-(cohort, week) rows, which arrive ordered
+// by cohort then week, into one RetentionCohort per signup week.
+func groupRetention(rows []repository.WeeklyRetentionRow) []RetentionCohort {
+	out := []RetentionCohort{}
+	for _, r := range rows {
+		if n := len(out); n == 0 || !out[n-1].CohortWeek.Equal(r.CohortWeek) {
+			out = append(out, RetentionCohort{CohortWeek: r.CohortWeek, Size: r.CohortSize})
+		}
+		c := &out[len(out)-1]
+		rate := 0.0
+		if r.CohortSize > 0 {
+			rate = float64(r.ActiveUsers) / float64(r.CohortSize)
+		}
+		c.Active = append(c.Active, r.ActiveUsers)
+		c.Retention = append(c.Retention, rate)
+	}
+	return out
+}
+
+// ProductAnalyticsScheduler refreshes the snapshot hourly so dashboard
+// loads hit the cache.
+type ProductAnalyticsScheduler struct {
+	svc  *ProductAnalyticsService
+	jobs *JobLocker
+}
+
+func NewProductAnalyticsScheduler(svc *ProductAnalyticsService, jobs *JobLocker) *ProductAnalyticsScheduler {
+	return &ProductAnalyticsScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *ProductAnalyticsScheduler) Start(ctx context.Context) {
+	log.Println("Product analytics scheduler started (hourly)")
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Product analytics scheduler stopped")
+			return
+		case now := <-ticker.C:
+			s.jobs.RunOnce(ctx, "product_analytics", TickSlot(now, time.Hour), time.Hour, func(ctx context.Context) {
+				if _, err := s.svc.Refresh(ctx); err != nil {
+					log.Printf("Product analytics: refresh failed: %v", err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeAnalyticsRepo struct {
+	retention []repository.WeeklyRetentionRow
+	failWith  error
+	queries   int
+	snapshot  []byte
+	savedAt   time.Time
+}
+
+func (f *fakeAnalyticsRepo) WeeklyRetention(ctx context.Context, asOf time.Time, weeks int) ([]repository.WeeklyRetentionRow, error) {
+	f.queries++
+	return f.retention, f.failWith
+}
+
+func (f *fakeAnalyticsRepo) ActiveUsers(ctx context.Context, from, to time.Time) ([]repository.ActiveUsersRow, error) {
+	return []repository.ActiveUsersRow{{Day: to, DAU: 3, WAU: 10, MAU: 30}}, nil
+}
+
+func (f *fakeAnalyticsRepo) FeatureAdoption(ctx context.Context, since time.Time) (*repository.FeatureAdoption, error) {
+	return &repository.FeatureAdoption{ActiveFamilies: 5}, nil
+}
+
+func (f *fakeAnalyticsRepo) ActivationFunnel(ctx context.Context, from, asOf time.Time, weekDays int) ([]repository.ActivationFunnelRow, error) {
+	return nil, nil
+}
+
+func (f *fakeAnalyticsRepo) CachedSnapshot(ctx context.Context) ([]byte, time.Time, error) {
+	return f.snapshot, f.savedAt, nil
+}
+
+func (f *fakeAnalyticsRepo) SaveSnapshot(ctx context.Context, snapshot []byte, computedAt time.Time) error {
+	f.snapshot, f.savedAt = snapshot, computedAt
+	return nil
+}
+
+func TestGroupRetention(t *testing.T) {
+	w1 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	w2 := w1.AddDate(0, 0, 7)
+	got := groupRetention([]repository.WeeklyRetentionRow{
+		{CohortWeek: w1, WeeksSinceSignup: 0, CohortSize: 10, ActiveUsers: 8},
+		{CohortWeek: w1, WeeksSinceSignup: 1, CohortSize: 10, ActiveUsers: 5},
+		{CohortWeek: w2, WeeksSinceSignup: 0, CohortSize: 4, ActiveUsers: 4},
+	})
+	if len(got) != 2 {
+		t.Fatalf("got %d cohorts, want 2", len(got))
+	}
+	if got[0].Size != 10 || len(got[0].Active) != 2 || got[0].Retention[1] != 0.5 {
+		t.Errorf("first cohort = %+v", got[0])
+	}
+	if got[1].Size != 4 || got[1].Retention[0] != 1 {
+		t.Errorf("second cohort = %+v", got[1])
+	}
+}
+
+func TestProductAnalyticsGetUsesCache(t *testing.T) {
+	repo := &fakeAnalyticsRepo{}
+	svc := NewProductAnalyticsService(repo)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	a, err := svc.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.AsOf.Equal(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("as_of = %s, want the last complete day", a.AsOf)
+	}
+	if a.Latest() == nil || a.Latest().MAU != 30 {
+		t.Errorf("latest = %+v", a.Latest())
+	}
+
+	now = now.Add(30 * time.Minute)
+	if _, err := svc.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.queries != 1 {
+		t.Errorf("recomputed within the TTL (%d queries)", repo.queries)
+	}
+
+	// Stale, and the recompute fails: serve the old snapshot.
+	now = now.Add(2 * time.Hour)
+	repo.failWith = errors.New("db down")
+	a, err = svc.Get(context.Background())
+	if err != nil || a == nil || a.Adoption.ActiveFamilies != 5 {
+		t.Fatalf("stale fallback = %+v, %v", a, err)
+	}
+	if repo.queries != 2 {
+		t.Errorf("queries = %d, want a refresh attempt", repo.queries)
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var ErrPromoCodeNotFound = apperr.NotFound("promo code not found")
+
+// Reasons a promo code check fails, as recorded in promo_code_validations.
+const (
+	PromoInvalidNotFound        = "not_found"
+	PromoInvalidInactive        = "inactive"
+	PromoInvalidNotStarted      = "not_started"
+	PromoInvalidExpired         = "expired"
+	PromoInvalidExhausted       = "exhausted"
+	PromoInvalidAlreadyUsed     = "already_used"
+	PromoInvalidPlanNotEligible = "plan_not_eligible"
+	PromoInvalidUserNotEligible = "user_not_eligible"
+	PromoInvalidFraudRejected   = "fraud_rejected"
+)
+
+// promoCodeLookup is the part of the admin repository promo checks need.
+type promoCodeLookup interface {
+	GetPromoCodeByID(ctx context.Context, id uuid.UUID) (*models.PromoCode, error)
+	GetPromoCodeByCode(ctx context.Context, code string) (*models.PromoCode, error)
+}
+
+// PromoCheck is the answer to a user checking a promo code.
+type PromoCheck struct {
+	Valid          bool                     `json:"valid"`
+	Reason         string                   `json:"reason,omitempty"`
+	Code           string                   `json:"code"`
+	Name           string                   `json:"name,omitempty"`
+	DiscountType   models.PromoDiscountType `json:"discount_type,omitempty"`
+	DiscountValue  float64                  `json:"discount_value,omitempty"`
+	DurationMonths *int                     `json:"duration_months,omitempty"`
+	// HeldForReview means the code applies but the check was flagged and
+	// the discount is held until an admin approves it.
+	HeldForReview bool `json:"held_for_review,omitempty"`
+}
+
+// PromoCheckRequest is a user checking a promo code. IPAddress and
+// DeviceID feed the fraud checks and may be empty.
+type PromoCheckRequest struct {
+	UserID    uuid.UUID
+	Email     string
+	Code      string
+	PlanID    *uuid.UUID
+	IPAddress string
+	DeviceID  string
+}
+
+// PromoAnalytics is the admin report on one promo code over a date range.
+type PromoAnalytics struct {
+	PromoCodeID uuid.UUID `json:"promo_code_id"`
+	Code        string    `json:"code"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+
+	Redemptions int                       `json:"redemptions"`
+	Curve       []PromoRedemptionCurveDay `json:"curve"`
+
+	Funnel repository.PromoFunnel `json:"funnel"`
+	// ConversionRate is redeemed users / users who validated the code, as
+	// a percentage; nil with no validations.
+	ConversionRate *float64 `json:"conversion_rate"`
+
+	Revenue repository.PromoRevenue `json:"revenue"`
+	// DiscountRatio is discount given per cent of revenue; nil with no
+	// revenue.
+	DiscountRatio *float64 `json:"discount_ratio"`
+	NetCents      int64    `json:"net_cents"`
+
+	Plans     []repository.PromoPlanBreakdown       `json:"plans"`
+	Campaigns []repository.PromoCampaignRedemptions `json:"campaigns"`
+}
+
+// PromoRedemptionCurveDay is one day of a code's redemption curve.
+type PromoRedemptionCurveDay struct {
+	Date          string `json:"date"`
+	Redemptions   int    `json:"redemptions"`
+	Cumulative    int    `json:"cumulative"`
+	DiscountCents int64  `json:"discount_cents"`
+}
+
+// PromoCodeService checks promo codes for users, recording every check,
+// and reports on how codes perform.
+type PromoCodeService struct {
+	repo  repository.PromoCodeRepository
+	codes promoCodeLookup
+	fraud *PromoFraudService
+	now   func() time.Time
+}
+
+func NewPromoCodeService(repo repository.PromoCodeRepository, codes promoCodeLookup) *PromoCodeService {
+	return &PromoCodeService{repo: repo, codes: codes, now: time.Now}
+}
+
+// SetFraudScreening turns on fraud checks for successful promo code
+// checks.
+func (s *PromoCodeService) SetFraudScreening(fraud *PromoFraudService) {
+	s.fraud = fraud
+}
+
+// Check tells a user whether a code applies to them (and to the plan, when
+// given) and records the check. It doesn't redeem the code. With fraud
+// screening on, a successful check may be flagged for review and its
+// discount held.
+func (s *PromoCodeService) Check(ctx context.Context, req PromoCheckRequest) (*PromoCheck, error) {
+	code := strings.TrimSpace(req.Code)
+	promo, err := s.codes.GetPromoCodeByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	check := &PromoCheck{Code: strings.ToUpper(code)}
+	v := &repository.PromoValidation{Code: check.Code, UserID: req.UserID, PlanID: req.PlanID, DeviceID: req.DeviceID}
+	if ip := net.ParseIP(req.IPAddress); ip != nil {
+		v.IPAddress = ip.String()
+	}
+	var standing *repository.PromoFraudFlag
+	if promo == nil {
+		check.Reason = PromoInvalidNotFound
+	} else {
+		v.PromoCodeID = &promo.ID
+		check.Code = promo.Code
+		if check.Reason, err = s.ineligible(ctx, promo, req.UserID, req.Email, req.PlanID); err != nil {
+			return nil, err
+		}
+		if check.Reason == "" && s.fraud != nil {
+			if standing, err = s.fraud.Standing(ctx, promo.ID, req.UserID); err != nil {
+				return nil, err
+			}
+			if standing != nil && standing.Status == repository.PromoFraudRejected {
+				check.Reason = PromoInvalidFraudRejected
+			}
+		}
+	}
+	check.Valid = check.Reason == ""
+	if check.Valid {
+		check.Name, check.DiscountType, check.DiscountValue = promo.Name, promo.DiscountType, promo.DiscountValue
+		check.DurationMonths = promo.DurationMonths
+	}
+	v.Valid, v.Reason = check.Valid, check.Reason
+	if err := s.repo.RecordValidation(ctx, v); err != nil {
+		return nil, err
+	}
+
+	if check.Valid && s.fraud != nil {
+		// An approved flag clears the user for this code.
+		switch {
+		case standing == nil:
+			flag, err := s.fraud.Screen(ctx, promo, v, req.Email)
+			if err != nil {
+				return nil, err
+			}
+			check.HeldForReview = flag != nil && flag.Held
+		case standing.Status == repository.PromoFraudPending:
+			check.HeldForReview = standing.Held
+		}
+	}
+	return check, nil
+}
+
+// ineligible returns why promo doesn't apply, or "" when it does.
+func (s *PromoCodeService) ineligible(ctx context.Context, promo *models.PromoCode, userID uuid.UUID, email string, planID *uuid.UUID) (string, error) {
+	now := s.now()
+	switch {
+	case !promo.IsActive:
+		return PromoInvalidInactive, nil
+	case now.Before(promo.StartsAt):
+		return PromoInvalidNotStarted, nil
+	case promo.ExpiresAt.Valid && !now.Before(promo.ExpiresAt.Time):
+		return PromoInvalidExpired, nil
+	case promo.MaxTotalUses != nil && promo.CurrentTotalUses >= *promo.MaxTotalUses:
+		return PromoInvalidExhausted, nil
+	}
+
+	if len(promo.SpecificUserIDs) > 0 && !containsUUID(promo.SpecificUserIDs, userID) {
+		return PromoInvalidUserNotEligible, nil
+	}
+	if len(promo.SpecificEmailDomains) > 0 {
+		domain := emailDomain(email)
+		ok := false
+		for _, d := range promo.SpecificEmailDomains {
+			ok = ok || strings.ToLower(strings.TrimPrefix(d, "@")) == domain
+		}
+		if !ok {
+			return PromoInvalidUserNotEligible, nil
+		}
+	}
+	if promo.NewUsersOnly || promo.ExistingUsersOnly {
+		paid, err := s.repo.UserHasPaid(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		if (promo.NewUsersOnly && paid) || (promo.ExistingUsersOnly && !paid) {
+			return PromoInvalidUserNotEligible, nil
+		}
+	}
+	if promo.MaxUsesPerUser > 0 {
+		used, err := s.repo.UserRedemptions(ctx, promo.ID, userID)
+		if err != nil {
+			return "", err
+		}
+		if used >= promo.MaxUsesPerUser {
+			return PromoInvalidAlreadyUsed, nil
+		}
+	}
+
+	if planID != nil {
+		if len(promo.AppliesToPlans) > 0 && !containsUUID(promo.AppliesToPlans, *planID) {
+			return PromoInvalidPlanNotEligible, nil
+		}
+		if len(promo.AppliesToBillingIntervals) > 0 {
+			interval, err := s.repo.PlanInterval(ctx, *planID)
+			if err != nil {
+				return "", err
+			}
+			ok := false
+			for _, i := range promo.AppliesToBillingIntervals {
+				ok = ok || i == interval
+			}
+			if !ok {
+				return PromoInvalidPlanNotEligible, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Analytics reports on a promo code over the dates from..to (UTC,
+// inclusive).
+func (s *PromoCodeService) Analytics(ctx context.Context, id uuid.UUID, from, to time.Time) (*PromoAnalytics, error) {
+	promo, err := s.codes.GetPromoCodeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if promo == nil {
+		return nil, ErrPromoCodeNotFound
+	}
+	end := to.AddDate(0, 0, 1)
+
+	daily, err := s.repo.DailyRedemptions(ctx, id, from, end)
+	if err != nil {
+		return nil, err
+	}
+	a := &PromoAnalytics{
+		PromoCodeID: id,
+		Code:        promo.Code,
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+	}
+	if a.Funnel, err = s.repo.Funnel(ctx, id, from, end); err != nil {
+		return nil, err
+	}
+	if a.Revenue, err = s.repo.Revenue(ctx, id, from, end); err != nil {
+		return nil, err
+	}
+	if a.Plans, err = s.repo.PlanBreakdown(ctx, id, from, end); err != nil {
+		return nil, err
+	}
+	if a.Campaigns, err = s.repo.TopCampaigns(ctx, id, from, end, 10); err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]repository.PromoDailyRedemptions, len(daily))
+	for _, d := range daily {
+		byDay[d.Day.Format("2006-01-02")] = d
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := d.Format("2006-01-02")
+		p := PromoRedemptionCurveDay{Date: day, Redemptions: byDay[day].Redemptions, DiscountCents: byDay[day].DiscountCents}
+		a.Redemptions += p.Redemptions
+		p.Cumulative = a.Redemptions
+		a.Curve = append(a.Curve, p)
+	}
+
+	if a.Funnel.ValidatedUsers > 0 {
+		rate := math.Round(float64(a.Funnel.RedeemedUsers)*1000/float64(a.Funnel.ValidatedUsers)) / 10
+		a.ConversionRate = &rate
+	}
+	if a.Revenue.RevenueCents > 0 {
+		ratio := math.Round(float64(a.Revenue.DiscountCents)*1000/float64(a.Revenue.RevenueCents)) / 1000
+		a.DiscountRatio = &ratio
+	}
+	a.NetCents = a.Revenue.RevenueCents - a.Revenue.DiscountCents
+	if a.Plans == nil {
+		a.Plans = []repository.PromoPlanBreakdown{}
+	}
+	if a.Campaigns == nil {
+		a.Campaigns = []repository.PromoCampaignRedemptions{}
+	}
+	return a, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakePromoLookup map[string]*models.PromoCode
+
+func (f fakePromoLookup) GetPromoCodeByID(ctx context.Context, id uuid.UUID) (*models.PromoCode, error) {
+	for _, p := range f {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f fakePromoLookup) GetPromoCodeByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	return f[strings.ToUpper(code)], nil
+}
+
+type fakePromoRepo struct {
+	repository.PromoCodeRepository
+	validations []repository.PromoValidation
+	redeemed    map[uuid.UUID]int
+	paid        map[uuid.UUID]bool
+	intervals   map[uuid.UUID]string
+	daily       []repository.PromoDailyRedemptions
+}
+
+func (f *fakePromoRepo) RecordValidation(ctx context.Context, v *repository.PromoValidation) error {
+	f.validations = append(f.validations, *v)
+	return nil
+}
+
+func (f *fakePromoRepo) UserRedemptions(ctx context.Context, promoCodeID, userID uuid.UUID) (int, error) {
+	return f.redeemed[userID], nil
+}
+
+func (f *fakePromoRepo) UserHasPaid(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return f.paid[userID], nil
+}
+
+func (f *fakePromoRepo) PlanInterval(ctx context.Context, planID uuid.UUID) (string, error) {
+	return f.intervals[planID], nil
+}
+
+func (f *fakePromoRepo) DailyRedemptions(ctx context.Context, id uuid.UUID, from, to time.Time) ([]repository.PromoDailyRedemptions, error) {
+	return f.daily, nil
+}
+
+func (f *fakePromoRepo) Funnel(ctx context.Context, id uuid.UUID, from, to time.Time) (repository.PromoFunnel, error) {
+	return repository.PromoFunnel{Validations: 12, ValidValidations: 9, ValidatedUsers: 8, RedeemedUsers: 3}, nil
+}
+
+func (f *fakePromoRepo) Revenue(ctx context.Context, id uuid.UUID, from, to time.Time) (repository.PromoRevenue, error) {
+	return repository.PromoRevenue{Payments: 3, RevenueCents: 30000, DiscountCents: 4500}, nil
+}
+
+func (f *fakePromoRepo) PlanBreakdown(ctx context.Context, id uuid.UUID, from, to time.Time) ([]repository.PromoPlanBreakdown, error) {
+	return nil, nil
+}
+
+func (f *fakePromoRepo) TopCampaigns(ctx context.Context, id uuid.UUID, from, to time.Time, limit int) ([]repository.PromoCampaignRedemptions, error) {
+	return nil, nil
+}
+
+func TestPromoCodeCheck(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	monthly, annual := uuid.New(), uuid.New()
+	limit := 5
+	codes := fakePromoLookup{
+		"SPRING": {ID: uuid.New(), Code: "SPRING", Name: "Spring", IsActive: true, StartsAt: now.AddDate(0, -1, 0),
+			DiscountType: models.PromoDiscountPercentage, DiscountValue: 20, MaxUsesPerUser: 1,
+			AppliesToBillingIntervals: models.StringArray{"year"}},
+		"OLD":     {ID: uuid.New(), Code: "OLD", IsActive: true, StartsAt: now.AddDate(-1, 0, 0), ExpiresAt: models.NullTime{NullTime: sql.NullTime{Time: now.AddDate(0, 0, -1), Valid: true}}},
+		"GONE":    {ID: uuid.New(), Code: "GONE", IsActive: true, StartsAt: now.AddDate(-1, 0, 0), MaxTotalUses: &limit, CurrentTotalUses: 5},
+		"SCHOOL":  {ID: uuid.New(), Code: "SCHOOL", IsActive: true, StartsAt: now.AddDate(-1, 0, 0), SpecificEmailDomains: models.StringArray{"@district.org"}},
+		"WELCOME": {ID: uuid.New(), Code: "WELCOME", IsActive: true, StartsAt: now.AddDate(-1, 0, 0), NewUsersOnly: true},
+	}
+	user, repeat, payer := uuid.New(), uuid.New(), uuid.New()
+	repo := &fakePromoRepo{
+		redeemed:  map[uuid.UUID]int{repeat: 1},
+		paid:      map[uuid.UUID]bool{payer: true},
+		intervals: map[uuid.UUID]string{monthly: "month", annual: "year"},
+	}
+	svc := NewPromoCodeService(repo, codes)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		user   uuid.UUID
+		email  string
+		code   string
+		plan   *uuid.UUID
+		reason string
+	}{
+		{user, "a@example.com", " spring ", &annual, ""},
+		{user, "a@example.com", "spring", &monthly, PromoInvalidPlanNotEligible},
+		{user, "a@example.com", "spring", nil, ""},
+		{repeat, "b@example.com", "SPRING", &annual, PromoInvalidAlreadyUsed},
+		{user, "a@example.com", "NOPE", nil, PromoInvalidNotFound},
+		{user, "a@example.com", "OLD", nil, PromoInvalidExpired},
+		{user, "a@example.com", "GONE", nil, PromoInvalidExhausted},
+		{user, "a@gmail.com", "SCHOOL", nil, PromoInvalidUserNotEligible},
+		{user, "Teacher@District.org", "SCHOOL", nil, ""},
+		{payer, "c@example.com", "WELCOME", nil, PromoInvalidUserNotEligible},
+	} {
+		check, err := svc.Check(ctx, PromoCheckRequest{UserID: tc.user, Email: tc.email, Code: tc.code, PlanID: tc.plan})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check.Reason != tc.reason || check.Valid != (tc.reason == "") {
+			t.Errorf("%s for %s: %+v, want reason %q", tc.code, tc.email, check, tc.reason)
+		}
+	}
+	if len(repo.validations) != 10 {
+		t.Fatalf("recorded %d validations", len(repo.validations))
+	}
+	if v := repo.validations[0]; !v.Valid || v.Code != "SPRING" || v.PromoCodeID == nil {
+		t.Errorf("first validation %+v", v)
+	}
+	if v := repo.validations[4]; v.Valid || v.Code != "NOPE" || v.PromoCodeID != nil {
+		t.Errorf("unknown code validation %+v", v)
+	}
+}
+
+func TestPromoCodeAnalytics(t *testing.T) {
+	promo := &models.PromoCode{ID: uuid.New(), Code: "SPRING"}
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 4)
+	repo := &fakePromoRepo{daily: []repository.PromoDailyRedemptions{
+		{Day: from.AddDate(0, 0, 1), Redemptions: 2, DiscountCents: 3000},
+		{Day: from.AddDate(0, 0, 3), Redemptions: 1, DiscountCents: 1500},
+	}}
+	svc := NewPromoCodeService(repo, fakePromoLookup{"SPRING": promo})
+
+	a, err := svc.Analytics(context.Background(), promo.ID, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Curve) != 5 || a.Redemptions != 3 || a.Curve[2].Redemptions != 0 || a.Curve[2].Cumulative != 2 || a.Curve[4].Cumulative != 3 {
+		t.Errorf("curve %+v", a.Curve)
+	}
+	if a.ConversionRate == nil || *a.ConversionRate != 37.5 {
+		t.Errorf("conversion %v", a.ConversionRate)
+	}
+	if a.DiscountRatio == nil || *a.DiscountRatio != 0.15 || a.NetCents != 25500 {
+		t.Errorf("discount ratio %v net %d", a.DiscountRatio, a.NetCents)
+	}
+
+	if _, err := svc.Analytics(context.Background(), uuid.New(), from, to); err != ErrPromoCodeNotFound {
+		t.Errorf("unknown code: %v", err)
+	}
+}
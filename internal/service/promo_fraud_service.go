@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrPromoFraudFlagNotFound  = apperr.NotFound("promo fraud flag not found")
+	ErrPromoFraudFlagReviewed  = errors.New("promo fraud flag has already been reviewed")
+	ErrPromoFraudInvalidStatus = apperr.Validation("invalid promo fraud flag status")
+	ErrPromoFraudPolicyInvalid = apperr.Validation("invalid promo fraud policy")
+)
+
+const (
+	// PromoFraudPolicyKey is the system_settings key holding
+	// PromoFraudPolicy.
+	PromoFraudPolicyKey = "promo_fraud_policy"
+
+	// AdminNotificationKindPromoFraud is the admin_notifications kind
+	// opened when a promo code check is flagged.
+	AdminNotificationKindPromoFraud = "promo_fraud"
+)
+
+// Promo fraud signals.
+const (
+	PromoFraudSharedIP        = "shared_ip"
+	PromoFraudSharedDevice    = "shared_device"
+	PromoFraudDisposableEmail = "disposable_email"
+	PromoFraudStacking        = "stacking"
+	PromoFraudCodeCycling     = "code_cycling"
+)
+
+// PromoFraudPolicy tunes the abuse checks run on successful promo code
+// checks. The account limits count the checking user; a check is flagged
+// when more accounts than allowed used the code from one IP or device
+// within the window.
+type PromoFraudPolicy struct {
+	// HoldDiscounts withholds the discount on flagged checks until an
+	// admin approves the flag. Off, flags are only queued for review.
+	HoldDiscounts        bool `json:"hold_discounts"`
+	WindowHours          int  `json:"window_hours"`
+	MaxAccountsPerIP     int  `json:"max_accounts_per_ip"`
+	MaxAccountsPerDevice int  `json:"max_accounts_per_device"`
+	// MaxCodesPerUser is how many distinct codes one user may check
+	// successfully within the window.
+	MaxCodesPerUser int `json:"max_codes_per_user"`
+	// DisposableDomains adds to the built-in disposable email domains.
+	DisposableDomains []string `json:"disposable_domains"`
+}
+
+// DefaultPromoFraudPolicy applies when the setting is missing or
+// unreadable. Several accounts per IP is normal behind school and office
+// NAT; two accounts on one device is not.
+var DefaultPromoFraudPolicy = PromoFraudPolicy{
+	WindowHours:          72,
+	MaxAccountsPerIP:     3,
+	MaxAccountsPerDevice: 1,
+	MaxCodesPerUser:      3,
+}
+
+func (p PromoFraudPolicy) Validate() error {
+	switch {
+	case p.WindowHours < 1 || p.WindowHours > 720:
+		return fmt.Errorf("%w: window_hours must be 1-720", ErrPromoFraudPolicyInvalid)
+	case p.MaxAccountsPerIP < 1, p.MaxAccountsPerDevice < 1, p.MaxCodesPerUser < 1:
+		return fmt.Errorf("%w: account and code limits must be at least 1", ErrPromoFraudPolicyInvalid)
+	}
+	return nil
+}
+
+// disposableEmailDomains are throwaway inbox providers. Subdomains match
+// too.
+var disposableEmailDomains = map[string]bool{
+	"mailinator.com": true, "guerrillamail.com": true, "guerrillamail.net": true,
+	"sharklasers.com": true, "grr.la": true, "10minutemail.com": true,
+	"temp-mail.org": true, "tempmail.com": true, "tempmailo.com": true,
+	"throwawaymail.com": true, "yopmail.com": true, "trashmail.com": true,
+	"getnada.com": true, "maildrop.cc": true, "dispostable.com": true,
+	"fakeinbox.com": true, "mintemail.com": true, "mohmal.com": true,
+	"emailondeck.com": true, "burnermail.io": true,
+}
+
+// PromoFraudService screens successful promo code checks for abuse and
+// keeps the review queue of flagged ones.
+type PromoFraudService struct {
+	repo     repository.PromoFraudRepository
+	notify   adminNotifier
+	settings settingsStore
+	now      func() time.Time
+}
+
+func NewPromoFraudService(repo repository.PromoFraudRepository, notify adminNotifier, settings settingsStore) *PromoFraudService {
+	return &PromoFraudService{repo: repo, notify: notify, settings: settings, now: time.Now}
+}
+
+// Policy returns the stored policy, the default where it's missing.
+func (s *PromoFraudService) Policy(ctx context.Context) (PromoFraudPolicy, error) {
+	out := DefaultPromoFraudPolicy
+	val, err := s.settings.GetSetting(ctx, PromoFraudPolicyKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	stored := DefaultPromoFraudPolicy
+	if err := json.Unmarshal(raw, &stored); err != nil || stored.Validate() != nil {
+		log.Printf("[PROMO-FRAUD] %s setting unreadable, using defaults: %v", PromoFraudPolicyKey, err)
+		return out, nil
+	}
+	return stored, nil
+}
+
+// UpdatePolicy validates and stores a new policy.
+func (s *PromoFraudService) UpdatePolicy(ctx context.Context, p PromoFraudPolicy, by uuid.UUID) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	for i, d := range p.DisposableDomains {
+		p.DisposableDomains[i] = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+	}
+	return s.settings.UpdateSetting(ctx, PromoFraudPolicyKey, p, by)
+}
+
+// Standing returns the user's newest flag on a code, nil when there is
+// none. A rejected flag refuses the code, a pending one keeps any held
+// discount held, and an approved one clears the user for that code.
+func (s *PromoFraudService) Standing(ctx context.Context, promoCodeID, userID uuid.UUID) (*repository.PromoFraudFlag, error) {
+	return s.repo.LatestFlag(ctx, promoCodeID, userID)
+}
+
+// Screen runs the abuse checks on a recorded, successful check of promo
+// and flags it when any trip. It returns the flag, nil when the check
+// looks fine.
+func (s *PromoFraudService) Screen(ctx context.Context, promo *models.PromoCode, v *repository.PromoValidation, email string) (*repository.PromoFraudFlag, error) {
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	since := s.now().Add(-time.Duration(policy.WindowHours) * time.Hour)
+	var signals []repository.PromoFraudSignal
+
+	if v.IPAddress != "" {
+		others, err := s.repo.UsersSharingIP(ctx, promo.ID, v.UserID, v.IPAddress, since)
+		if err != nil {
+			return nil, err
+		}
+		if others+1 > policy.MaxAccountsPerIP {
+			signals = append(signals, repository.PromoFraudSignal{Signal: PromoFraudSharedIP,
+				Detail: fmt.Sprintf("%d other accounts used the code from %s in %dh", others, v.IPAddress, policy.WindowHours)})
+		}
+	}
+	if v.DeviceID != "" {
+		others, err := s.repo.UsersSharingDevice(ctx, promo.ID, v.UserID, v.DeviceID, since)
+		if err != nil {
+			return nil, err
+		}
+		if others+1 > policy.MaxAccountsPerDevice {
+			signals = append(signals, repository.PromoFraudSignal{Signal: PromoFraudSharedDevice,
+				Detail: fmt.Sprintf("%d other accounts used the code from this device in %dh", others, policy.WindowHours)})
+		}
+	}
+	if domain := emailDomain(email); isDisposableDomain(domain, policy.DisposableDomains) {
+		signals = append(signals, repository.PromoFraudSignal{Signal: PromoFraudDisposableEmail,
+			Detail: "disposable email domain " + domain})
+	}
+	stacked, err := s.repo.DiscountedByOtherCode(ctx, promo.ID, v.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if stacked {
+		signals = append(signals, repository.PromoFraudSignal{Signal: PromoFraudStacking,
+			Detail: "subscription is already discounted by another code"})
+	}
+	codes, err := s.repo.CodesChecked(ctx, v.UserID, since)
+	if err != nil {
+		return nil, err
+	}
+	if codes > policy.MaxCodesPerUser {
+		signals = append(signals, repository.PromoFraudSignal{Signal: PromoFraudCodeCycling,
+			Detail: fmt.Sprintf("%d different codes checked in %dh", codes, policy.WindowHours)})
+	}
+	if len(signals) == 0 {
+		return nil, nil
+	}
+
+	flag := &repository.PromoFraudFlag{
+		PromoCodeID:  promo.ID,
+		Code:         promo.Code,
+		ValidationID: &v.ID,
+		UserID:       v.UserID,
+		Signals:      signals,
+		IPAddress:    v.IPAddress,
+		DeviceID:     v.DeviceID,
+		Held:         policy.HoldDiscounts,
+	}
+	created, err := s.repo.CreateFlag(ctx, flag)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Raced with another check by the same user; that flag stands.
+		return s.repo.LatestFlag(ctx, promo.ID, v.UserID)
+	}
+	s.notifyFlagged(ctx, flag)
+	return flag, nil
+}
+
+// notifyFlagged opens an admin notification for a new flag. Failing to is
+// logged; the flag is in the review queue either way.
+func (s *PromoFraudService) notifyFlagged(ctx context.Context, f *repository.PromoFraudFlag) {
+	names := make([]string, len(f.Signals))
+	for i, sig := range f.Signals {
+		names[i] = sig.Signal
+	}
+	message := "A promo code check was flagged (" + strings.Join(names, ", ") + ") and is waiting for review."
+	if f.Held {
+		message += " The discount is held until it is approved."
+	}
+	details, _ := json.Marshal(map[string]interface{}{
+		"flag_id": f.ID, "promo_code_id": f.PromoCodeID, "user_id": f.UserID, "signals": f.Signals,
+	})
+	if _, err := s.notify.Open(ctx, &repository.AdminNotification{
+		Kind:      AdminNotificationKindPromoFraud,
+		Severity:  "warning",
+		Title:     "Promo code flagged: " + f.Code,
+		Message:   message,
+		DedupeKey: AdminNotificationKindPromoFraud + ":" + f.ID.String(),
+		Details:   details,
+	}); err != nil {
+		log.Printf("[PROMO-FRAUD] notify admins of flag %s: %v", f.ID, err)
+	}
+}
+
+// List returns the review queue, newest first: flags in status, or all
+// flags when status is "".
+func (s *PromoFraudService) List(ctx context.Context, status string, limit int) ([]repository.PromoFraudFlag, error) {
+	switch status {
+	case "", repository.PromoFraudPending, repository.PromoFraudApproved, repository.PromoFraudRejected:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrPromoFraudInvalidStatus, status)
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.ListFlags(ctx, status, limit)
+}
+
+func (s *PromoFraudService) Get(ctx context.Context, id uuid.UUID) (*repository.PromoFraudFlag, error) {
+	f, err := s.repo.GetFlag(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrPromoFraudFlagNotFound
+	}
+	return f, nil
+}
+
+// Review approves or rejects a pending flag. Approving releases a held
+// discount and clears the user for that code; rejecting refuses the code
+// to them.
+func (s *PromoFraudService) Review(ctx context.Context, id uuid.UUID, approve bool, by uuid.UUID, note string) (*repository.PromoFraudFlag, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	status := repository.PromoFraudRejected
+	if approve {
+		status = repository.PromoFraudApproved
+	}
+	ok, err := s.repo.Review(ctx, id, status, by, strings.TrimSpace(note))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPromoFraudFlagReviewed
+	}
+	return s.Get(ctx, id)
+}
+
+func emailDomain(email string) string {
+	return strings.ToLower(strings.TrimSpace(email[strings.LastIndex(email, "@")+1:]))
+}
+
+// isDisposableDomain reports whether domain, or a domain it is under, is
+// a built-in or extra disposable domain.
+func isDisposableDomain(domain string, extra []string) bool {
+	for d := domain; d != ""; {
+		if disposableEmailDomains[d] {
+			return true
+		}
+		for _, e := range extra {
+			if strings.EqualFold(e, d) {
+				return true
+			}
+		}
+		dot := strings.Index(d, ".")
+		if dot < 0 {
+			break
+		}
+		d = d[dot+1:]
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// fakePromoFraudRepo answers the abuse signals from fixed maps and keeps
+// flags in memory.
+type fakePromoFraudRepo struct {
+	ipUsers     map[string]int
+	deviceUsers map[string]int
+	stacked     map[uuid.UUID]bool
+	codes       map[uuid.UUID]int
+	flags       []*repository.PromoFraudFlag
+}
+
+func (f *fakePromoFraudRepo) UsersSharingIP(ctx context.Context, promoCodeID, userID uuid.UUID, ip string, since time.Time) (int, error) {
+	return f.ipUsers[ip], nil
+}
+
+func (f *fakePromoFraudRepo) UsersSharingDevice(ctx context.Context, promoCodeID, userID uuid.UUID, deviceID string, since time.Time) (int, error) {
+	return f.deviceUsers[deviceID], nil
+}
+
+func (f *fakePromoFraudRepo) DiscountedByOtherCode(ctx context.Context, promoCodeID, userID uuid.UUID) (bool, error) {
+	return f.stacked[userID], nil
+}
+
+func (f *fakePromoFraudRepo) CodesChecked(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	return f.codes[userID], nil
+}
+
+func (f *fakePromoFraudRepo) CreateFlag(ctx context.Context, flag *repository.PromoFraudFlag) (bool, error) {
+	for _, x := range f.flags {
+		if x.PromoCodeID == flag.PromoCodeID && x.UserID == flag.UserID && x.Status == repository.PromoFraudPending {
+			return false, nil
+		}
+	}
+	flag.ID, flag.Status = uuid.New(), repository.PromoFraudPending
+	c := *flag
+	f.flags = append(f.flags, &c)
+	return true, nil
+}
+
+func (f *fakePromoFraudRepo) GetFlag(ctx context.Context, id uuid.UUID) (*repository.PromoFraudFlag, error) {
+	for _, x := range f.flags {
+		if x.ID == id {
+			c := *x
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakePromoFraudRepo) LatestFlag(ctx context.Context, promoCodeID, userID uuid.UUID) (*repository.PromoFraudFlag, error) {
+	for i := len(f.flags) - 1; i >= 0; i-- {
+		if x := f.flags[i]; x.PromoCodeID == promoCodeID && x.UserID == userID {
+			c := *x
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakePromoFraudRepo) ListFlags(ctx context.Context, status string, limit int) ([]repository.PromoFraudFlag, error) {
+	var out []repository.PromoFraudFlag
+	for _, x := range f.flags {
+		if status == "" || x.Status == status {
+			out = append(out, *x)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakePromoFraudRepo) Review(ctx context.Context, id uuid.UUID, status string, by uuid.UUID, note string) (bool, error) {
+	for _, x := range f.flags {
+		if x.ID == id && x.Status == repository.PromoFraudPending {
+			x.Status, x.ReviewedBy, x.ReviewNote = status, &by, note
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestPromoFraudScreening(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	promo := &models.PromoCode{ID: uuid.New(), Code: "SPRING", IsActive: true, StartsAt: now.AddDate(0, -1, 0)}
+	honest, burner, farmer, stacker, cycler := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	fraudRepo := &fakePromoFraudRepo{
+		ipUsers:     map[string]int{"203.0.113.9": 2, "198.51.100.7": 3},
+		deviceUsers: map[string]int{"dev-farm": 4},
+		stacked:     map[uuid.UUID]bool{stacker: true},
+		codes:       map[uuid.UUID]int{cycler: 5},
+	}
+	notifier := &fakeNotifier{opened: map[string]repository.AdminNotification{}}
+	settings := memSettings{}
+	fraud := NewPromoFraudService(fraudRepo, notifier, settings)
+	fraud.now = func() time.Time { return now }
+	svc := NewPromoCodeService(&fakePromoRepo{}, fakePromoLookup{"SPRING": promo})
+	svc.now = func() time.Time { return now }
+	svc.SetFraudScreening(fraud)
+
+	check := func(user uuid.UUID, email, ip, device string) *PromoCheck {
+		t.Helper()
+		c, err := svc.Check(ctx, PromoCheckRequest{UserID: user, Email: email, Code: "spring", IPAddress: ip, DeviceID: device})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// Two others on one IP is within the default of three accounts.
+	if c := check(honest, "a@example.com", "203.0.113.9", "dev-a"); !c.Valid || c.HeldForReview || len(fraudRepo.flags) != 0 {
+		t.Fatalf("honest check: %+v, flags %d", c, len(fraudRepo.flags))
+	}
+	// Flagged but not held by default.
+	if c := check(burner, "x@mail.Mailinator.com", "", ""); !c.Valid || c.HeldForReview {
+		t.Errorf("disposable check: %+v", c)
+	}
+	for user, signal := range map[uuid.UUID]string{stacker: PromoFraudStacking, cycler: PromoFraudCodeCycling} {
+		check(user, "s@example.com", "", "")
+		if f, _ := fraudRepo.LatestFlag(ctx, promo.ID, user); f == nil || f.Signals[0].Signal != signal {
+			t.Errorf("want %s flag, got %+v", signal, f)
+		}
+	}
+
+	// With holding on, a device farm's discount is held, and stays held on
+	// later checks without a second flag.
+	if err := fraud.UpdatePolicy(ctx, PromoFraudPolicy{HoldDiscounts: true, WindowHours: 24, MaxAccountsPerIP: 3, MaxAccountsPerDevice: 1, MaxCodesPerUser: 3}, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if c := check(farmer, "f@example.com", "198.51.100.7", "dev-farm"); !c.Valid || !c.HeldForReview {
+		t.Errorf("farm check: %+v", c)
+	}
+	flag, _ := fraudRepo.LatestFlag(ctx, promo.ID, farmer)
+	if flag == nil || len(flag.Signals) != 2 || flag.Signals[0].Signal != PromoFraudSharedIP || flag.Signals[1].Signal != PromoFraudSharedDevice {
+		t.Fatalf("farm flag %+v", flag)
+	}
+	if _, ok := notifier.opened[AdminNotificationKindPromoFraud+":"+flag.ID.String()]; !ok {
+		t.Errorf("no notification for %s", flag.ID)
+	}
+	if c := check(farmer, "f@example.com", "198.51.100.7", "dev-farm"); !c.HeldForReview || len(fraudRepo.flags) != 4 {
+		t.Errorf("recheck: %+v, flags %d", c, len(fraudRepo.flags))
+	}
+
+	// Rejecting refuses the code; approving clears the user.
+	admin := uuid.New()
+	if _, err := fraud.Review(ctx, flag.ID, false, admin, "device farm"); err != nil {
+		t.Fatal(err)
+	}
+	if c := check(farmer, "f@example.com", "198.51.100.7", "dev-farm"); c.Valid || c.Reason != PromoInvalidFraudRejected {
+		t.Errorf("after reject: %+v", c)
+	}
+	if _, err := fraud.Review(ctx, flag.ID, true, admin, ""); !errors.Is(err, ErrPromoFraudFlagReviewed) {
+		t.Errorf("second review: %v", err)
+	}
+	burnerFlag, _ := fraudRepo.LatestFlag(ctx, promo.ID, burner)
+	if _, err := fraud.Review(ctx, burnerFlag.ID, true, admin, "real school account"); err != nil {
+		t.Fatal(err)
+	}
+	if c := check(burner, "x@mail.mailinator.com", "", ""); !c.Valid || c.HeldForReview || len(fraudRepo.flags) != 4 {
+		t.Errorf("after approve: %+v, flags %d", c, len(fraudRepo.flags))
+	}
+
+	if pending, _ := fraud.List(ctx, repository.PromoFraudPending, 0); len(pending) != 2 {
+		t.Errorf("pending queue %+v", pending)
+	}
+	if _, err := fraud.List(ctx, "bogus", 0); !errors.Is(err, ErrPromoFraudInvalidStatus) {
+		t.Errorf("bad status: %v", err)
+	}
+	if err := fraud.UpdatePolicy(ctx, PromoFraudPolicy{WindowHours: 0}, admin); !errors.Is(err, ErrPromoFraudPolicyInvalid) {
+		t.Errorf("invalid policy: %v", err)
+	}
+}
@@ -0,0 +1,390 @@
+package service
+
+// provider_access_service.go — schools and ABA providers as external
+// contributors.
+//
+// A provider is an ordinary app user holding grants, not a family member:
+// each grant covers one child and the log types the family chose
+// (behavior, therapy). Family endpoints check family membership, so a
+// grant opens nothing outside the provider endpoints, which check the
+// grant on every request — revoking it takes effect immediately. Entries
+// are written as regular logs of the child, logged by the provider, and
+// attributed to the grant so the family sees which organization wrote
+// them.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// providerInviteTTL is how long a provider invitation link works.
+const providerInviteTTL = 7 * 24 * time.Hour
+
+// providerEntriesLimit caps entry lists.
+const providerEntriesLimit = 200
+
+// ProviderLogTypes are the log types a provider can be granted.
+var ProviderLogTypes = []string{"behavior", "therapy"}
+
+var (
+	ErrProviderGrantNotFound  = apperr.NotFound("provider access not found")
+	ErrProviderInvalid        = apperr.Validation("invalid provider access")
+	ErrProviderGrantExists    = repository.ErrProviderGrantExists
+	ErrProviderInviteInvalid  = apperr.Validation("this invitation is no longer valid")
+	ErrProviderInviteExpired  = errors.New("this invitation has expired; ask the family to send a new one")
+	ErrProviderInviteEmail    = errors.New("this invitation was sent to a different email address")
+	ErrProviderLogTypeDenied  = errors.New("the family hasn't shared this log type with you")
+	ErrProviderProfileMissing = apperr.Validation("set up your organization profile first")
+)
+
+var brandColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type providerInviteMailer interface {
+	SendProviderInvitationEmail(to, organizationName, childFirstName, inviterName, acceptURL string) error
+}
+
+type providerLogWriter interface {
+	CreateBehaviorLog(ctx context.Context, childID, loggedBy uuid.UUID, req *models.CreateBehaviorLogRequest) (*models.BehaviorLog, error)
+	CreateTherapyLog(ctx context.Context, childID, loggedBy uuid.UUID, req *models.CreateTherapyLogRequest) (*models.TherapyLog, error)
+}
+
+// ProviderAccessService manages provider invitations and grants for
+// families, and the provider side: branding, granted children and the
+// entries providers make.
+type ProviderAccessService struct {
+	repo   repository.ProviderRepository
+	logs   providerLogWriter
+	mailer providerInviteMailer
+	appURL string
+	now    func() time.Time
+}
+
+func NewProviderAccessService(repo repository.ProviderRepository, logs providerLogWriter, mailer providerInviteMailer, appURL string) *ProviderAccessService {
+	return &ProviderAccessService{repo: repo, logs: logs, mailer: mailer, appURL: appURL, now: time.Now}
+}
+
+func invalidProvider(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrProviderInvalid, fmt.Sprintf(format, args...))
+}
+
+func hashProviderInviteToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func validProviderType(t string) bool {
+	switch t {
+	case models.ProviderTypeSchool, models.ProviderTypeABA, models.ProviderTypeOther:
+		return true
+	}
+	return false
+}
+
+// normalizeLogTypes checks the granted log types and removes duplicates.
+func normalizeLogTypes(types []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		allowed := false
+		for _, lt := range ProviderLogTypes {
+			allowed = allowed || t == lt
+		}
+		if !allowed {
+			return nil, invalidProvider("log_types may only include %s", strings.Join(ProviderLogTypes, ", "))
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		return nil, invalidProvider("choose at least one log type")
+	}
+	return out, nil
+}
+
+// Invite creates a pending grant for child and emails the provider a link
+// to accept it. inviterName is shown in the email.
+func (s *ProviderAccessService) Invite(ctx context.Context, child *models.Child, by uuid.UUID, inviterName string, req *models.ProviderInviteRequest) (*models.ProviderGrant, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, invalidProvider("a valid email is required")
+	}
+	name := strings.TrimSpace(req.OrganizationName)
+	switch {
+	case name == "":
+		return nil, invalidProvider("organization_name is required")
+	case len(name) > 200:
+		return nil, invalidProvider("organization_name must be 200 characters or fewer")
+	}
+	if req.ProviderType == "" {
+		req.ProviderType = models.ProviderTypeSchool
+	}
+	if !validProviderType(req.ProviderType) {
+		return nil, invalidProvider("provider_type must be school, aba or other")
+	}
+	logTypes, err := normalizeLogTypes(req.LogTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+	expires := s.now().Add(providerInviteTTL)
+	g := &models.ProviderGrant{
+		ChildID:          child.ID,
+		FamilyID:         child.FamilyID,
+		InviteEmail:      strings.ToLower(addr.Address),
+		OrganizationName: name,
+		ProviderType:     req.ProviderType,
+		LogTypes:         models.StringArray(logTypes),
+		Status:           models.ProviderGrantPending,
+		InviteExpiresAt:  &expires,
+		InvitedBy:        &by,
+	}
+	if err := s.repo.CreateGrant(ctx, g, hashProviderInviteToken(token)); err != nil {
+		return nil, err
+	}
+	if s.mailer != nil {
+		acceptURL := strings.TrimRight(s.appURL, "/") + "/provider/accept?token=" + url.QueryEscape(token)
+		if err := s.mailer.SendProviderInvitationEmail(g.InviteEmail, name, child.FirstName, inviterName, acceptURL); err != nil {
+			log.Printf("[PROVIDER] Failed to send invitation for grant %s: %v", g.ID, err)
+		}
+	}
+	return g, nil
+}
+
+// ListForChild returns every grant of the child, revoked ones included.
+func (s *ProviderAccessService) ListForChild(ctx context.Context, childID uuid.UUID) ([]models.ProviderGrant, error) {
+	return s.repo.ListGrantsByChild(ctx, childID)
+}
+
+// GetForChild returns one of the child's grants, or
+// ErrProviderGrantNotFound.
+func (s *ProviderAccessService) GetForChild(ctx context.Context, childID, id uuid.UUID) (*models.ProviderGrant, error) {
+	g, err := s.repo.GetGrant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil || g.ChildID != childID {
+		return nil, ErrProviderGrantNotFound
+	}
+	return g, nil
+}
+
+// UpdateLogTypes changes what a pending or active grant covers.
+func (s *ProviderAccessService) UpdateLogTypes(ctx context.Context, g *models.ProviderGrant, types []string) error {
+	if g.Status == models.ProviderGrantRevoked {
+		return invalidProvider("access has been revoked; invite the provider again")
+	}
+	logTypes, err := normalizeLogTypes(types)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateLogTypes(ctx, g.ID, logTypes); err != nil {
+		return err
+	}
+	g.LogTypes = models.StringArray(logTypes)
+	return nil
+}
+
+// Revoke ends a grant or withdraws an invitation. The provider's past
+// entries stay with the child, still attributed to them.
+func (s *ProviderAccessService) Revoke(ctx context.Context, g *models.ProviderGrant, by uuid.UUID) error {
+	if g.Status == models.ProviderGrantRevoked {
+		return nil
+	}
+	at := s.now().UTC()
+	if err := s.repo.RevokeGrant(ctx, g.ID, by, at); err != nil {
+		return err
+	}
+	g.Status, g.RevokedAt, g.RevokedBy = models.ProviderGrantRevoked, &at, &by
+	return nil
+}
+
+// Accept activates the invitation behind token for the signed-in user,
+// whose email must be the invited address. A provider without a profile
+// gets one from the name the family gave.
+func (s *ProviderAccessService) Accept(ctx context.Context, userID uuid.UUID, email, token string) (*models.ProviderGrant, error) {
+	g, err := s.repo.GetGrantByToken(ctx, hashProviderInviteToken(strings.TrimSpace(token)))
+	if err != nil {
+		return nil, err
+	}
+	if g == nil || g.Status != models.ProviderGrantPending {
+		return nil, ErrProviderInviteInvalid
+	}
+	if g.InviteExpiresAt != nil && s.now().After(*g.InviteExpiresAt) {
+		return nil, ErrProviderInviteExpired
+	}
+	if !strings.EqualFold(strings.TrimSpace(email), g.InviteEmail) {
+		return nil, ErrProviderInviteEmail
+	}
+	profile, err := s.repo.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &models.ProviderProfile{UserID: userID, OrganizationName: g.OrganizationName, ProviderType: g.ProviderType}
+		if err := s.repo.UpsertProfile(ctx, profile); err != nil {
+			return nil, err
+		}
+	}
+	at := s.now().UTC()
+	if err := s.repo.AcceptGrant(ctx, g.ID, userID, at); err != nil {
+		return nil, err
+	}
+	g.Status, g.ProviderUserID, g.AcceptedAt, g.Branding = models.ProviderGrantActive, &userID, &at, profile
+	return g, nil
+}
+
+// Profile returns the provider's branding, or ErrProviderProfileMissing.
+func (s *ProviderAccessService) Profile(ctx context.Context, userID uuid.UUID) (*models.ProviderProfile, error) {
+	p, err := s.repo.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrProviderProfileMissing
+	}
+	return p, nil
+}
+
+// SetProfile saves the provider's branding.
+func (s *ProviderAccessService) SetProfile(ctx context.Context, userID uuid.UUID, req *models.ProviderProfileRequest) (*models.ProviderProfile, error) {
+	name := strings.TrimSpace(req.OrganizationName)
+	switch {
+	case name == "":
+		return nil, invalidProvider("organization_name is required")
+	case len(name) > 200:
+		return nil, invalidProvider("organization_name must be 200 characters or fewer")
+	case !validProviderType(req.ProviderType):
+		return nil, invalidProvider("provider_type must be school, aba or other")
+	case req.BrandColor != "" && !brandColorPattern.MatchString(req.BrandColor):
+		return nil, invalidProvider("brand_color must be a hex colour like #1D4ED8")
+	}
+	if req.LogoURL != "" {
+		u, err := url.Parse(req.LogoURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || len(req.LogoURL) > 2000 {
+			return nil, invalidProvider("logo_url must be an https URL")
+		}
+	}
+	p := &models.ProviderProfile{UserID: userID, OrganizationName: name, ProviderType: req.ProviderType}
+	p.BrandColor.String, p.BrandColor.Valid = req.BrandColor, req.BrandColor != ""
+	p.LogoURL.String, p.LogoURL.Valid = req.LogoURL, req.LogoURL != ""
+	if err := s.repo.UpsertProfile(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Grants returns the children a provider can currently record for.
+func (s *ProviderAccessService) Grants(ctx context.Context, userID uuid.UUID) ([]models.ProviderGrant, error) {
+	return s.repo.ListActiveGrantsByProvider(ctx, userID)
+}
+
+// Grant returns the provider's active grant, or ErrProviderGrantNotFound
+// when it isn't theirs or has been revoked.
+func (s *ProviderAccessService) Grant(ctx context.Context, userID, grantID uuid.UUID) (*models.ProviderGrant, error) {
+	g, err := s.repo.GetGrant(ctx, grantID)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil || g.Status != models.ProviderGrantActive || g.ProviderUserID == nil || *g.ProviderUserID != userID {
+		return nil, ErrProviderGrantNotFound
+	}
+	return g, nil
+}
+
+// writableGrant returns the provider's grant if it covers logType, and
+// the organization name to attribute the entry to.
+func (s *ProviderAccessService) writableGrant(ctx context.Context, userID, grantID uuid.UUID, logType string) (*models.ProviderGrant, string, error) {
+	g, err := s.Grant(ctx, userID, grantID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !g.AllowsLogType(logType) {
+		return nil, "", ErrProviderLogTypeDenied
+	}
+	name := g.OrganizationName
+	if p, err := s.repo.GetProfile(ctx, userID); err != nil {
+		return nil, "", err
+	} else if p != nil {
+		name = p.OrganizationName
+	}
+	return g, name, nil
+}
+
+func (s *ProviderAccessService) attribute(ctx context.Context, g *models.ProviderGrant, userID uuid.UUID, name, logType string, logID uuid.UUID) (*models.ProviderAttribution, error) {
+	e := &models.ProviderLogEntry{LogType: logType, LogID: logID}
+	e.GrantID, e.ProviderUserID, e.OrganizationName = g.ID, &userID, name
+	if err := s.repo.RecordEntry(ctx, e); err != nil {
+		return nil, err
+	}
+	return &e.ProviderAttribution, nil
+}
+
+// CreateBehaviorLog records a behavior log for the grant's child on the
+// provider's behalf.
+func (s *ProviderAccessService) CreateBehaviorLog(ctx context.Context, userID, grantID uuid.UUID, req *models.CreateBehaviorLogRequest) (*models.BehaviorLog, *models.ProviderGrant, error) {
+	g, name, err := s.writableGrant(ctx, userID, grantID, "behavior")
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := s.logs.CreateBehaviorLog(ctx, g.ChildID, userID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l.Provider, err = s.attribute(ctx, g, userID, name, "behavior", l.ID); err != nil {
+		return nil, nil, err
+	}
+	return l, g, nil
+}
+
+// CreateTherapyLog records a therapy log for the grant's child on the
+// provider's behalf. Providers don't see the child's therapy goals, so
+// the log isn't linked to any.
+func (s *ProviderAccessService) CreateTherapyLog(ctx context.Context, userID, grantID uuid.UUID, req *models.CreateTherapyLogRequest) (*models.TherapyLog, *models.ProviderGrant, error) {
+	g, name, err := s.writableGrant(ctx, userID, grantID, "therapy")
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Goals = nil
+	l, err := s.logs.CreateTherapyLog(ctx, g.ChildID, userID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l.Provider, err = s.attribute(ctx, g, userID, name, "therapy", l.ID); err != nil {
+		return nil, nil, err
+	}
+	return l, g, nil
+}
+
+// Entries returns the logs entered under a grant, newest first.
+func (s *ProviderAccessService) Entries(ctx context.Context, grantID uuid.UUID) ([]models.ProviderLogEntry, error) {
+	return s.repo.ListEntries(ctx, grantID, providerEntriesLimit)
+}
+
+// Attributions returns which of the logs a provider entered, by log ID.
+func (s *ProviderAccessService) Attributions(ctx context.Context, logType string, logIDs []uuid.UUID) (map[uuid.UUID]*models.ProviderAttribution, error) {
+	return s.repo.Attributions(ctx, logType, logIDs)
+}
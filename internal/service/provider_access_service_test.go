@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// fakeProviderRepo keeps profiles, grants and entries in memory.
+type fakeProviderRepo struct {
+	profiles map[uuid.UUID]*models.ProviderProfile
+	grants   map[uuid.UUID]*models.ProviderGrant
+	tokens   map[string]uuid.UUID
+	entries  []models.ProviderLogEntry
+}
+
+func newFakeProviderRepo() *fakeProviderRepo {
+	return &fakeProviderRepo{
+		profiles: map[uuid.UUID]*models.ProviderProfile{},
+		grants:   map[uuid.UUID]*models.ProviderGrant{},
+		tokens:   map[string]uuid.UUID{},
+	}
+}
+
+func (f *fakeProviderRepo) GetProfile(ctx context.Context, userID uuid.UUID) (*models.ProviderProfile, error) {
+	if p, ok := f.profiles[userID]; ok {
+		c := *p
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeProviderRepo) UpsertProfile(ctx context.Context, p *models.ProviderProfile) error {
+	c := *p
+	f.profiles[p.UserID] = &c
+	return nil
+}
+
+func (f *fakeProviderRepo) CreateGrant(ctx context.Context, g *models.ProviderGrant, tokenHash string) error {
+	for _, o := range f.grants {
+		if o.ChildID == g.ChildID && o.InviteEmail == g.InviteEmail && o.Status != models.ProviderGrantRevoked {
+			return ErrProviderGrantExists
+		}
+	}
+	g.ID = uuid.New()
+	c := *g
+	f.grants[g.ID] = &c
+	f.tokens[tokenHash] = g.ID
+	return nil
+}
+
+func (f *fakeProviderRepo) GetGrant(ctx context.Context, id uuid.UUID) (*models.ProviderGrant, error) {
+	if g, ok := f.grants[id]; ok {
+		c := *g
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeProviderRepo) GetGrantByToken(ctx context.Context, tokenHash string) (*models.ProviderGrant, error) {
+	if id, ok := f.tokens[tokenHash]; ok {
+		return f.GetGrant(ctx, id)
+	}
+	return nil, nil
+}
+
+func (f *fakeProviderRepo) ListGrantsByChild(ctx context.Context, childID uuid.UUID) ([]models.ProviderGrant, error) {
+	var out []models.ProviderGrant
+	for _, g := range f.grants {
+		if g.ChildID == childID {
+			out = append(out, *g)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeProviderRepo) ListActiveGrantsByProvider(ctx context.Context, userID uuid.UUID) ([]models.ProviderGrant, error) {
+	var out []models.ProviderGrant
+	for _, g := range f.grants {
+		if g.Status == models.ProviderGrantActive && g.ProviderUserID != nil && *g.ProviderUserID == userID {
+			out = append(out, *g)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeProviderRepo) AcceptGrant(ctx context.Context, id, userID uuid.UUID, at time.Time) error {
+	g := f.grants[id]
+	g.Status, g.ProviderUserID, g.AcceptedAt = models.ProviderGrantActive, &userID, &at
+	for h, gid := range f.tokens {
+		if gid == id {
+			delete(f.tokens, h)
+		}
+	}
+	return nil
+}
+
+func (f *fakeProviderRepo) UpdateLogTypes(ctx context.Context, id uuid.UUID, logTypes []string) error {
+	f.grants[id].LogTypes = logTypes
+	return nil
+}
+
+func (f *fakeProviderRepo) RevokeGrant(ctx context.Context, id, by uuid.UUID, at time.Time) error {
+	g := f.grants[id]
+	g.Status, g.RevokedBy, g.RevokedAt = models.ProviderGrantRevoked, &by, &at
+	return nil
+}
+
+func (f *fakeProviderRepo) RecordEntry(ctx context.Context, e *models.ProviderLogEntry) error {
+	f.entries = append(f.entries, *e)
+	return nil
+}
+
+func (f *fakeProviderRepo) Attributions(ctx context.Context, logType string, logIDs []uuid.UUID) (map[uuid.UUID]*models.ProviderAttribution, error) {
+	out := map[uuid.UUID]*models.ProviderAttribution{}
+	for _, e := range f.entries {
+		for _, id := range logIDs {
+			if e.LogType == logType && e.LogID == id {
+				a := e.ProviderAttribution
+				out[id] = &a
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeProviderRepo) ListEntries(ctx context.Context, grantID uuid.UUID, limit int) ([]models.ProviderLogEntry, error) {
+	var out []models.ProviderLogEntry
+	for _, e := range f.entries {
+		if e.GrantID == grantID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+type fakeProviderLogWriter struct {
+	behaviorFor uuid.UUID
+}
+
+func (f *fakeProviderLogWriter) CreateBehaviorLog(ctx context.Context, childID, loggedBy uuid.UUID, req *models.CreateBehaviorLogRequest) (*models.BehaviorLog, error) {
+	f.behaviorFor = childID
+	return &models.BehaviorLog{ID: uuid.New(), ChildID: childID, LoggedBy: loggedBy}, nil
+}
+
+func (f *fakeProviderLogWriter) CreateTherapyLog(ctx context.Context, childID, loggedBy uuid.UUID, req *models.CreateTherapyLogRequest) (*models.TherapyLog, error) {
+	return &models.TherapyLog{ID: uuid.New(), ChildID: childID, LoggedBy: loggedBy, Goals: req.Goals}, nil
+}
+
+type fakeProviderMailer struct {
+	to, acceptURL string
+}
+
+func (f *fakeProviderMailer) SendProviderInvitationEmail(to, organizationName, childFirstName, inviterName, acceptURL string) error {
+	f.to, f.acceptURL = to, acceptURL
+	return nil
+}
+
+func TestProviderAccessLifecycle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeProviderRepo()
+	logs := &fakeProviderLogWriter{}
+	mailer := &fakeProviderMailer{}
+	svc := NewProviderAccessService(repo, logs, mailer, "https://app.example.com")
+	svc.now = func() time.Time { return now }
+	child := &models.Child{ID: uuid.New(), FamilyID: uuid.New(), FirstName: "Ava"}
+	parent, teacher, stranger := uuid.New(), uuid.New(), uuid.New()
+
+	for name, req := range map[string]models.ProviderInviteRequest{
+		"email":     {Email: "not an email", OrganizationName: "Oak Elementary", LogTypes: []string{"behavior"}},
+		"name":      {Email: "t@oak.edu", LogTypes: []string{"behavior"}},
+		"log type":  {Email: "t@oak.edu", OrganizationName: "Oak Elementary", LogTypes: []string{"medication"}},
+		"no types":  {Email: "t@oak.edu", OrganizationName: "Oak Elementary"},
+		"prov type": {Email: "t@oak.edu", OrganizationName: "Oak Elementary", ProviderType: "clinic", LogTypes: []string{"behavior"}},
+	} {
+		if _, err := svc.Invite(ctx, child, parent, "Sam", &req); !errors.Is(err, ErrProviderInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	g, err := svc.Invite(ctx, child, parent, "Sam", &models.ProviderInviteRequest{
+		Email: "Teacher@Oak.edu", OrganizationName: "Oak Elementary", LogTypes: []string{"behavior", "behavior"},
+	})
+	if err != nil || g.Status != models.ProviderGrantPending || len(g.LogTypes) != 1 || g.ProviderType != models.ProviderTypeSchool {
+		t.Fatalf("invite: %+v, %v", g, err)
+	}
+	if _, err := svc.Invite(ctx, child, parent, "Sam", &models.ProviderInviteRequest{
+		Email: "teacher@oak.edu", OrganizationName: "Oak", LogTypes: []string{"therapy"},
+	}); !errors.Is(err, ErrProviderGrantExists) {
+		t.Errorf("second invite: %v", err)
+	}
+	u, _ := url.Parse(mailer.acceptURL)
+	token := u.Query().Get("token")
+	if mailer.to != "teacher@oak.edu" || !strings.HasPrefix(mailer.acceptURL, "https://app.example.com/provider/accept?") || token == "" {
+		t.Fatalf("invitation email to %s: %s", mailer.to, mailer.acceptURL)
+	}
+
+	if _, err := svc.Accept(ctx, stranger, "someone@else.org", token); !errors.Is(err, ErrProviderInviteEmail) {
+		t.Errorf("wrong address: %v", err)
+	}
+	svc.now = func() time.Time { return now.Add(8 * 24 * time.Hour) }
+	if _, err := svc.Accept(ctx, teacher, "teacher@oak.edu", token); !errors.Is(err, ErrProviderInviteExpired) {
+		t.Errorf("expired: %v", err)
+	}
+	svc.now = func() time.Time { return now.Add(time.Hour) }
+	g, err = svc.Accept(ctx, teacher, "TEACHER@oak.edu", token)
+	if err != nil || g.Status != models.ProviderGrantActive || g.Branding == nil || g.Branding.OrganizationName != "Oak Elementary" {
+		t.Fatalf("accept: %+v, %v", g, err)
+	}
+	if _, err := svc.Accept(ctx, teacher, "teacher@oak.edu", token); !errors.Is(err, ErrProviderInviteInvalid) {
+		t.Errorf("token reused: %v", err)
+	}
+
+	// Only the provider, only the shared log types.
+	if _, _, err := svc.CreateBehaviorLog(ctx, stranger, g.ID, &models.CreateBehaviorLogRequest{}); !errors.Is(err, ErrProviderGrantNotFound) {
+		t.Errorf("stranger: %v", err)
+	}
+	if _, _, err := svc.CreateTherapyLog(ctx, teacher, g.ID, &models.CreateTherapyLogRequest{}); !errors.Is(err, ErrProviderLogTypeDenied) {
+		t.Errorf("therapy not shared: %v", err)
+	}
+	if _, err := svc.SetProfile(ctx, teacher, &models.ProviderProfileRequest{OrganizationName: "Oak Elementary School", ProviderType: "school", BrandColor: "#1D4ED8", LogoURL: "http://oak.edu/logo.png"}); !errors.Is(err, ErrProviderInvalid) {
+		t.Errorf("http logo: %v", err)
+	}
+	if _, err := svc.SetProfile(ctx, teacher, &models.ProviderProfileRequest{OrganizationName: "Oak Elementary School", ProviderType: "school", BrandColor: "#1D4ED8"}); err != nil {
+		t.Fatal(err)
+	}
+	l, _, err := svc.CreateBehaviorLog(ctx, teacher, g.ID, &models.CreateBehaviorLogRequest{})
+	if err != nil || logs.behaviorFor != child.ID || l.LoggedBy != teacher || l.Provider == nil || l.Provider.OrganizationName != "Oak Elementary School" {
+		t.Fatalf("provider behavior log: %+v, %v", l, err)
+	}
+
+	// Sharing therapy later; goals aren't the provider's to link.
+	grant, _ := svc.GetForChild(ctx, child.ID, g.ID)
+	if err := svc.UpdateLogTypes(ctx, grant, []string{"behavior", "therapy"}); err != nil {
+		t.Fatal(err)
+	}
+	rating := 4
+	tl, _, err := svc.CreateTherapyLog(ctx, teacher, g.ID, &models.CreateTherapyLogRequest{Goals: []models.TherapyLogGoal{{GoalID: uuid.New(), ProgressRating: &rating}}})
+	if err != nil || tl.Goals != nil {
+		t.Errorf("provider therapy log: %+v, %v", tl, err)
+	}
+	if entries, _ := svc.Entries(ctx, g.ID); len(entries) != 2 {
+		t.Errorf("entries: %+v", entries)
+	}
+
+	// Revoking ends access at once; past entries stay attributed.
+	if err := svc.Revoke(ctx, grant, parent); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.CreateBehaviorLog(ctx, teacher, g.ID, &models.CreateBehaviorLogRequest{}); !errors.Is(err, ErrProviderGrantNotFound) {
+		t.Errorf("after revoke: %v", err)
+	}
+	if grants, _ := svc.Grants(ctx, teacher); len(grants) != 0 {
+		t.Errorf("grants after revoke: %+v", grants)
+	}
+	if err := svc.UpdateLogTypes(ctx, grant, []string{"behavior"}); !errors.Is(err, ErrProviderInvalid) {
+		t.Errorf("update revoked: %v", err)
+	}
+	byLog, _ := svc.Attributions(ctx, "behavior", []uuid.UUID{l.ID, uuid.New()})
+	if len(byLog) != 1 || byLog[l.ID].GrantID != g.ID {
+		t.Errorf("attributions: %+v", byLog)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"carecompanion/internal/config"
+)
+
+const (
+	apnsProductionHost = "https://api.push.apple.com"
+	apnsSandboxHost    = "https://api.sandbox.push.apple.com"
+	// Apple rejects provider tokens older than an hour and throttles
+	// refreshing more often than every 20 minutes.
+	apnsTokenRefresh = 50 * time.Minute
+)
+
+// apnsSender delivers straight to APNs over HTTP/2 with token-based (.p8)
+// auth.
+type apnsSender struct {
+	host     string
+	keyID    string
+	teamID   string
+	bundleID string
+	key      *ecdsa.PrivateKey
+	client   *http.Client
+	now      func() time.Time
+
+	mu        sync.Mutex
+	jwt       string
+	jwtIssued time.Time
+}
+
+func newAPNsSender(cfg config.APNsConfig) (*apnsSender, error) {
+	pem, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read APNs key: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("parse APNs key: %w", err)
+	}
+	host := apnsSandboxHost
+	if cfg.Production {
+		host = apnsProductionHost
+	}
+	return &apnsSender{
+		host:     host,
+		keyID:    cfg.KeyID,
+		teamID:   cfg.TeamID,
+		bundleID: cfg.BundleID,
+		key:      key,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// providerToken returns the cached provider JWT, re-signing it when it's
+// due for refresh.
+func (a *apnsSender) providerToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	if a.jwt != "" && now.Sub(a.jwtIssued) < apnsTokenRefresh {
+		return a.jwt, nil
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.teamID,
+		"iat": now.Unix(),
+	})
+	tok.Header["kid"] = a.keyID
+	signed, err := tok.SignedString(a.key)
+	if err != nil {
+		return "", fmt.Errorf("sign APNs token: %w", err)
+	}
+	a.jwt, a.jwtIssued = signed, now
+	return signed, nil
+}
+
+// apnsPayload builds the notification body: the alert under "aps" and the
+// message data as top-level custom keys, where the app reads them.
+func apnsPayload(msg PushMessage) ([]byte, error) {
+	aps := map[string]interface{}{
+		"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+		"sound": "default",
+	}
+	if msg.Badge > 0 {
+		aps["badge"] = msg.Badge
+	}
+	body := map[string]interface{}{}
+	for k, v := range msg.Data {
+		body[k] = v
+	}
+	body["aps"] = aps
+	return json.Marshal(body)
+}
+
+func (a *apnsSender) send(ctx context.Context, token string, msg PushMessage) error {
+	payload, err := apnsPayload(msg)
+	if err != nil {
+		return err
+	}
+	bearer, err := a.providerToken()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.host+"/3/device/"+token, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	priority := "5"
+	if msg.Priority == PushPriorityHigh {
+		priority = "10"
+	}
+	req.Header.Set("authorization", "bearer "+bearer)
+	req.Header.Set("apns-topic", a.bundleID)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", priority)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("APNs send failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var apnsErr struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apnsErr)
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		apnsErr.Reason == "BadDeviceToken",
+		apnsErr.Reason == "DeviceTokenNotForTopic",
+		apnsErr.Reason == "Unregistered":
+		return fmt.Errorf("%w: APNs %d %s", errPushTokenInvalid, resp.StatusCode, apnsErr.Reason)
+	case apnsErr.Reason == "ExpiredProviderToken":
+		// Force a re-sign on the next send.
+		a.mu.Lock()
+		a.jwt = ""
+		a.mu.Unlock()
+	}
+	return fmt.Errorf("APNs send failed: %d %s", resp.StatusCode, apnsErr.Reason)
+}
@@ -2,15 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
 	"github.com/google/uuid"
 	"google.golang.org/api/option"
 
+	"carecompanion/internal/config"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
@@ -32,10 +36,32 @@ type PushMessage struct {
 	Badge    int               `json:"badge,omitempty"`
 }
 
-// PushService handles sending push notifications via Firebase Cloud Messaging
+// errPushTokenInvalid is wrapped by senders when the provider reports the
+// device token dead (app uninstalled, token rotated); the device is then
+// deactivated rather than counted as a failed send.
+var errPushTokenInvalid = errors.New("push token is no longer valid")
+
+// pushSender delivers one message to one device token through a single
+// provider (FCM or APNs).
+type pushSender interface {
+	send(ctx context.Context, token string, msg PushMessage) error
+}
+
+const (
+	// Devices not seen (no registration from the app) in this long are
+	// deactivated — FCM treats tokens this old as expired too.
+	devicePruneStale = 270 * 24 * time.Hour
+	// Deactivated devices are deleted after this long.
+	devicePruneDelete  = 30 * 24 * time.Hour
+	devicePruneHourUTC = 4
+)
+
+// PushService handles sending push notifications. Each user's active
+// devices are fanned out to in parallel, each through the sender for the
+// provider that issued its token.
 type PushService struct {
 	deviceTokenRepo repository.DeviceTokenRepository
-	fcmClient       *messaging.Client
+	senders         map[string]pushSender
 	enabled         bool
 }
 
@@ -43,6 +69,7 @@ type PushService struct {
 func NewPushService(deviceTokenRepo repository.DeviceTokenRepository, fcmServerKey string) *PushService {
 	return &PushService{
 		deviceTokenRepo: deviceTokenRepo,
+		senders:         map[string]pushSender{},
 		enabled:         false,
 	}
 }
@@ -68,11 +95,27 @@ func (s *PushService) InitFirebase(serviceAccountKeyFile string) {
 		return
 	}
 
-	s.fcmClient = client
+	s.senders[models.PushProviderFCM] = &fcmSender{client: client}
 	s.enabled = true
 	log.Println("Push notifications enabled (Firebase Admin SDK)")
 }
 
+// InitAPNs enables direct APNs delivery for devices that registered raw
+// APNs tokens. A no-op unless the APNs key is fully configured.
+func (s *PushService) InitAPNs(cfg config.APNsConfig) {
+	if cfg.KeyFile == "" || cfg.KeyID == "" || cfg.TeamID == "" || cfg.BundleID == "" {
+		return
+	}
+	sender, err := newAPNsSender(cfg)
+	if err != nil {
+		log.Printf("APNs push disabled: %v", err)
+		return
+	}
+	s.senders[models.PushProviderAPNs] = sender
+	s.enabled = true
+	log.Printf("APNs push enabled (topic %s, production=%v)", cfg.BundleID, cfg.Production)
+}
+
 // IsEnabled returns whether push notifications are configured
 func (s *PushService) IsEnabled() bool {
 	return s.enabled
@@ -88,6 +131,13 @@ func (s *PushService) UnregisterDevice(ctx context.Context, userID uuid.UUID, to
 	return s.deviceTokenRepo.Deactivate(ctx, userID, token)
 }
 
+// deviceResult is one device's outcome within a Send fan-out.
+type deviceResult struct {
+	device  models.DeviceToken
+	err     error
+	skipped bool // no sender configured for the device's provider
+}
+
 // Send sends a push notification to all active devices for a user
 func (s *PushService) Send(ctx context.Context, userID uuid.UUID, msg PushMessage) error {
 	if !s.enabled {
@@ -104,30 +154,49 @@ func (s *PushService) Send(ctx context.Context, userID uuid.UUID, msg PushMessag
 		return nil
 	}
 
+	results := make([]deviceResult, len(tokens))
+	var wg sync.WaitGroup
+	for i, dt := range tokens {
+		results[i].device = dt
+		sender, ok := s.senders[dt.Provider]
+		if !ok {
+			results[i].skipped = true
+			continue
+		}
+		wg.Add(1)
+		go func(i int, sender pushSender, token string) {
+			defer wg.Done()
+			results[i].err = sender.send(ctx, token, msg)
+		}(i, sender, dt.Token)
+	}
+	wg.Wait()
+
 	// Track per-device outcomes so the caller can distinguish "all sends
 	// failed" from "one device failed but the user got the message on
-	// another." Previously the function returned lastErr — which depended
-	// on iteration order and could mask a per-device failure if a later
-	// device happened to succeed (or vice-versa).
+	// another."
 	var sendErrs []string
 	succeeded := 0
-	for _, dt := range tokens {
-		if err := s.sendToDevice(ctx, dt.Token, msg); err != nil {
-			if isTokenInvalid(err) {
-				log.Printf("Deactivating invalid device token for user %s: %v", userID, err)
-				if deactErr := s.deviceTokenRepo.DeactivateByToken(ctx, dt.Token); deactErr != nil {
-					log.Printf("Failed to deactivate token: %v", deactErr)
-				}
-				// Don't count invalid-token errors as send failures — the
-				// token is dead and we've cleaned it up; nothing the caller
-				// can do about it.
-				continue
+	for _, res := range results {
+		dt := res.device
+		switch {
+		case res.skipped:
+			log.Printf("Push skipped for device %s: no %s sender configured", dt.ID, dt.Provider)
+		case errors.Is(res.err, errPushTokenInvalid):
+			// Don't count invalid-token errors as send failures — the
+			// token is dead and we clean it up; nothing the caller can do
+			// about it.
+			log.Printf("Deactivating invalid device token for user %s: %v", userID, res.err)
+			if deactErr := s.deviceTokenRepo.DeactivateByToken(ctx, dt.Token, models.DeviceDeactivatedInvalid); deactErr != nil {
+				log.Printf("Failed to deactivate token: %v", deactErr)
 			}
-			log.Printf("Failed to send push to device %s: %v", dt.ID, err)
-			sendErrs = append(sendErrs, fmt.Sprintf("device %s: %v", dt.ID, err))
-			continue
+		case res.err != nil:
+			log.Printf("Failed to send push to device %s: %v", dt.ID, res.err)
+			sendErrs = append(sendErrs, fmt.Sprintf("device %s: %v", dt.ID, res.err))
+			s.recordPush(ctx, dt.ID, false)
+		default:
+			succeeded++
+			s.recordPush(ctx, dt.ID, true)
 		}
-		succeeded++
 	}
 
 	// If at least one device got the message, treat the send as successful
@@ -145,6 +214,12 @@ func (s *PushService) Send(ctx context.Context, userID uuid.UUID, msg PushMessag
 	return nil
 }
 
+func (s *PushService) recordPush(ctx context.Context, deviceID uuid.UUID, ok bool) {
+	if err := s.deviceTokenRepo.RecordPush(ctx, deviceID, ok); err != nil {
+		log.Printf("Failed to record push outcome for device %s: %v", deviceID, err)
+	}
+}
+
 // SendToUsers sends a push notification to multiple users
 func (s *PushService) SendToUsers(ctx context.Context, userIDs []uuid.UUID, msg PushMessage) {
 	for _, userID := range userIDs {
@@ -154,7 +229,23 @@ func (s *PushService) SendToUsers(ctx context.Context, userIDs []uuid.UUID, msg
 	}
 }
 
-func (s *PushService) sendToDevice(ctx context.Context, token string, msg PushMessage) error {
+// PruneDevices deactivates devices that haven't registered in the stale
+// horizon and deletes long-deactivated ones.
+func (s *PushService) PruneDevices(ctx context.Context, now time.Time) (deactivated, deleted int64, err error) {
+	return s.deviceTokenRepo.Prune(ctx, now.Add(-devicePruneStale), now.Add(-devicePruneDelete))
+}
+
+// DeviceStats returns active devices by platform and app version.
+func (s *PushService) DeviceStats(ctx context.Context) (*repository.DeviceStats, error) {
+	return s.deviceTokenRepo.Stats(ctx, time.Now())
+}
+
+// fcmSender delivers through Firebase Cloud Messaging.
+type fcmSender struct {
+	client *messaging.Client
+}
+
+func (f *fcmSender) send(ctx context.Context, token string, msg PushMessage) error {
 	priority := "normal"
 	if msg.Priority == PushPriorityHigh {
 		priority = "high"
@@ -187,8 +278,11 @@ func (s *PushService) sendToDevice(ctx context.Context, token string, msg PushMe
 		},
 	}
 
-	_, err := s.fcmClient.Send(ctx, fcmMsg)
+	_, err := f.client.Send(ctx, fcmMsg)
 	if err != nil {
+		if messaging.IsUnregistered(err) || isTokenInvalid(err) {
+			return fmt.Errorf("%w: %v", errPushTokenInvalid, err)
+		}
 		return fmt.Errorf("FCM send failed: %w", err)
 	}
 
@@ -206,3 +300,34 @@ func isTokenInvalid(err error) bool {
 		strings.Contains(msg, "NotRegistered") ||
 		strings.Contains(msg, "InvalidRegistration")
 }
+
+// DevicePruneScheduler runs PruneDevices nightly.
+type DevicePruneScheduler struct {
+	push *PushService
+	jobs *JobLocker
+}
+
+func NewDevicePruneScheduler(push *PushService, jobs *JobLocker) *DevicePruneScheduler {
+	return &DevicePruneScheduler{push: push, jobs: jobs}
+}
+
+func (s *DevicePruneScheduler) Start(ctx context.Context) {
+	log.Printf("Device prune scheduler started (%02d:00 UTC daily)", devicePruneHourUTC)
+	for {
+		next := nextUTCRunAt(time.Now().UTC(), devicePruneHourUTC, 0)
+		select {
+		case <-ctx.Done():
+			log.Println("Device prune scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "device_prune", next, 24*time.Hour, func(ctx context.Context) {
+				deactivated, deleted, err := s.push.PruneDevices(ctx, next)
+				if err != nil {
+					log.Printf("Devices: prune failed: %v", err)
+					return
+				}
+				log.Printf("Devices: deactivated %d stale, deleted %d inactive", deactivated, deleted)
+			})
+		}
+	}
+}
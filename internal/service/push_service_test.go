@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakeDeviceRepo struct {
+	repository.DeviceTokenRepository
+	devices     []models.DeviceToken
+	deactivated map[string]string
+	pushed      map[uuid.UUID]bool
+}
+
+func (f *fakeDeviceRepo) GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.DeviceToken, error) {
+	return f.devices, nil
+}
+
+func (f *fakeDeviceRepo) DeactivateByToken(ctx context.Context, token, reason string) error {
+	f.deactivated[token] = reason
+	return nil
+}
+
+func (f *fakeDeviceRepo) RecordPush(ctx context.Context, id uuid.UUID, ok bool) error {
+	f.pushed[id] = ok
+	return nil
+}
+
+// fakeSender fails tokens listed in errs and records the rest.
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []string
+	errs map[string]error
+}
+
+func (f *fakeSender) send(ctx context.Context, token string, msg PushMessage) error {
+	if err := f.errs[token]; err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.sent = append(f.sent, token)
+	f.mu.Unlock()
+	return nil
+}
+
+func TestPushSendFansOutPerDevice(t *testing.T) {
+	phone := models.DeviceToken{ID: uuid.New(), Token: "fcm-phone", Provider: models.PushProviderFCM}
+	tablet := models.DeviceToken{ID: uuid.New(), Token: "apns-tablet", Provider: models.PushProviderAPNs}
+	dead := models.DeviceToken{ID: uuid.New(), Token: "fcm-dead", Provider: models.PushProviderFCM}
+	flaky := models.DeviceToken{ID: uuid.New(), Token: "fcm-flaky", Provider: models.PushProviderFCM}
+	repo := &fakeDeviceRepo{
+		devices:     []models.DeviceToken{phone, tablet, dead, flaky},
+		deactivated: map[string]string{},
+		pushed:      map[uuid.UUID]bool{},
+	}
+	fcm := &fakeSender{errs: map[string]error{
+		"fcm-dead":  fmt.Errorf("%w: not registered", errPushTokenInvalid),
+		"fcm-flaky": errors.New("unavailable"),
+	}}
+	apns := &fakeSender{}
+	svc := NewPushService(repo, "")
+	svc.senders = map[string]pushSender{models.PushProviderFCM: fcm, models.PushProviderAPNs: apns}
+	svc.enabled = true
+
+	if err := svc.Send(context.Background(), uuid.New(), PushMessage{Title: "hi"}); err != nil {
+		t.Fatalf("one device failing shouldn't fail the send: %v", err)
+	}
+	if len(fcm.sent) != 1 || fcm.sent[0] != "fcm-phone" || len(apns.sent) != 1 || apns.sent[0] != "apns-tablet" {
+		t.Errorf("fcm sent %v, apns sent %v", fcm.sent, apns.sent)
+	}
+	if repo.deactivated["fcm-dead"] != models.DeviceDeactivatedInvalid || len(repo.deactivated) != 1 {
+		t.Errorf("deactivated = %v", repo.deactivated)
+	}
+	if !repo.pushed[phone.ID] || !repo.pushed[tablet.ID] || repo.pushed[flaky.ID] {
+		t.Errorf("recorded = %v", repo.pushed)
+	}
+	if _, ok := repo.pushed[dead.ID]; ok {
+		t.Error("a dead token shouldn't be recorded as a delivery attempt")
+	}
+
+	// Devices whose provider isn't configured are skipped, not failed.
+	delete(svc.senders, models.PushProviderAPNs)
+	repo.devices = []models.DeviceToken{tablet, flaky}
+	if err := svc.Send(context.Background(), uuid.New(), PushMessage{}); err == nil || !strings.Contains(err.Error(), flaky.ID.String()) {
+		t.Errorf("all eligible devices failed: err = %v", err)
+	}
+}
+
+func newTestAPNs(t *testing.T, handler http.HandlerFunc) *apnsSender {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+	return &apnsSender{
+		host: srv.URL, keyID: "KEY123", teamID: "TEAM123", bundleID: "net.mycarecompanion.app",
+		key: key, client: srv.Client(), now: time.Now,
+	}
+}
+
+func TestAPNsSend(t *testing.T) {
+	var got struct {
+		path, auth, topic, priority string
+		body                        map[string]interface{}
+	}
+	a := newTestAPNs(t, func(w http.ResponseWriter, r *http.Request) {
+		got.path, got.auth = r.URL.Path, r.Header.Get("authorization")
+		got.topic, got.priority = r.Header.Get("apns-topic"), r.Header.Get("apns-priority")
+		_ = json.NewDecoder(r.Body).Decode(&got.body)
+		if strings.HasSuffix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusGone)
+			_, _ = w.Write([]byte(`{"reason":"Unregistered"}`))
+		}
+	})
+	ctx := context.Background()
+
+	msg := PushMessage{Title: "Dose due", Body: "Evening meds", Priority: PushPriorityHigh, Data: map[string]string{"type": "medication_reminder"}}
+	if err := a.send(ctx, "abc123", msg); err != nil {
+		t.Fatal(err)
+	}
+	if got.path != "/3/device/abc123" || !strings.HasPrefix(got.auth, "bearer ") || got.topic != "net.mycarecompanion.app" || got.priority != "10" {
+		t.Errorf("request = %+v", got)
+	}
+	aps, _ := got.body["aps"].(map[string]interface{})
+	if alert, _ := aps["alert"].(map[string]interface{}); alert["title"] != "Dose due" || got.body["type"] != "medication_reminder" {
+		t.Errorf("payload = %v", got.body)
+	}
+
+	firstJWT := got.auth
+	if err := a.send(ctx, "abc123", msg); err != nil {
+		t.Fatal(err)
+	}
+	if got.auth != firstJWT {
+		t.Error("provider token should be reused until it's due for refresh")
+	}
+
+	if err := a.send(ctx, "gone", msg); !errors.Is(err, errPushTokenInvalid) {
+		t.Errorf("410: err = %v, want errPushTokenInvalid", err)
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+var ErrQueryStatsUnavailable = errors.New("pg_stat_statements is unavailable")
+
+// queryMethodRe finds the repository method in a statement's
+// /* method=Name ... */ comment. pg_stat_statements keeps comments in the
+// stored text, so tagged queries can be traced back to their call site.
+var queryMethodRe = regexp.MustCompile(`/\*[^*]*?\bmethod=([A-Za-z0-9_.]+)`)
+
+// QueryMethod extracts the repository method tag from SQL text, or "".
+func QueryMethod(query string) string {
+	if m := queryMethodRe.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// QueryStatsOptions configures QueryStatsService; NewServices fills it
+// from config.QueryStatsConfig.
+type QueryStatsOptions struct {
+	TopN          int
+	RetentionDays int
+}
+
+// SlowQuery is one statement over the report window, with its mean time
+// over the window before for the trend.
+type SlowQuery struct {
+	repository.QueryStatTotal
+	PrevCalls      int64   `json:"prev_calls"`
+	PrevMeanExecMs float64 `json:"prev_mean_exec_ms"`
+	// MeanChangePct is against the previous window; zero when the
+	// statement didn't run then.
+	MeanChangePct float64 `json:"mean_change_pct"`
+	// PctOfTotal is this statement's share of database time across the
+	// statements listed.
+	PctOfTotal float64 `json:"pct_of_total"`
+}
+
+// SlowQueryReport is the slow query panel.
+type SlowQueryReport struct {
+	Window  string      `json:"window"`
+	Sort    string      `json:"sort"`
+	From    time.Time   `json:"from"`
+	To      time.Time   `json:"to"`
+	Queries []SlowQuery `json:"queries"`
+}
+
+// QueryStatsService snapshots pg_stat_statements into db_query_stats and
+// reports the statements costing the database the most time.
+type QueryStatsService struct {
+	repo repository.QueryStatsRepository
+	opts QueryStatsOptions
+	now  func() time.Time
+}
+
+func NewQueryStatsService(repo repository.QueryStatsRepository, opts QueryStatsOptions) *QueryStatsService {
+	if opts.TopN <= 0 {
+		opts.TopN = 100
+	}
+	if opts.RetentionDays <= 0 {
+		opts.RetentionDays = 90
+	}
+	return &QueryStatsService{repo: repo, opts: opts, now: time.Now}
+}
+
+// Collect takes one snapshot of the top statements by total time. Deltas
+// are against each statement's previous snapshot; a counter that went
+// backwards means the stats were reset, so the new value is the delta. A
+// statement with no earlier snapshot (new, or newly in the top N) counts
+// its whole total, except on the very first snapshot, which only sets the
+// baseline.
+func (s *QueryStatsService) Collect(ctx context.Context) (int, error) {
+	if err := s.repo.Available(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrQueryStatsUnavailable, err)
+	}
+	samples, err := s.repo.ReadStatements(ctx, s.opts.TopN)
+	if err != nil {
+		return 0, err
+	}
+	collectedBefore, err := s.repo.HasSnapshots(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]int64, len(samples))
+	for i, smp := range samples {
+		ids[i] = smp.QueryID
+	}
+	prev, err := s.repo.LastSnapshots(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range samples {
+		smp := &samples[i]
+		smp.Method = QueryMethod(smp.Query)
+		p, seen := prev[smp.QueryID]
+		switch {
+		case seen && smp.Calls >= p.Calls:
+			smp.DeltaCalls = smp.Calls - p.Calls
+			smp.DeltaExecMs = smp.TotalExecMs - p.TotalExecMs
+			smp.DeltaRows = smp.Rows - p.Rows
+		case seen || collectedBefore:
+			smp.DeltaCalls, smp.DeltaExecMs, smp.DeltaRows = smp.Calls, smp.TotalExecMs, smp.Rows
+		}
+	}
+	now := s.now().UTC()
+	if err := s.repo.SaveSnapshot(ctx, now, samples); err != nil {
+		return 0, err
+	}
+	if _, err := s.repo.Prune(ctx, now.AddDate(0, 0, -s.opts.RetentionDays)); err != nil {
+		log.Printf("[QUERY_STATS] prune failed: %v", err)
+	}
+	return len(samples), nil
+}
+
+// Worst lists the limit statements with the most time (or the highest
+// mean, or the most calls) over window.
+func (s *QueryStatsService) Worst(ctx context.Context, window time.Duration, sort string, limit int) (*SlowQueryReport, error) {
+	switch sort {
+	case repository.QueryStatsByTotal, repository.QueryStatsByMean, repository.QueryStatsByCalls:
+	default:
+		sort = repository.QueryStatsByTotal
+	}
+	to := s.now().UTC()
+	rep := &SlowQueryReport{Window: formatPerformanceWindow(window), Sort: sort, From: to.Add(-window), To: to}
+	totals, err := s.repo.Totals(ctx, rep.From, rep.To, sort, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(totals))
+	var sum float64
+	for i, t := range totals {
+		ids[i] = t.QueryID
+		sum += t.TotalExecMs
+	}
+	prev, err := s.repo.TotalsByID(ctx, rep.From.Add(-window), rep.From, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rep.Queries = make([]SlowQuery, 0, len(totals))
+	for _, t := range totals {
+		q := SlowQuery{QueryStatTotal: t}
+		if p, ok := prev[t.QueryID]; ok && p.Calls > 0 {
+			q.PrevCalls = p.Calls
+			q.PrevMeanExecMs = p.MeanExecMs
+			if p.MeanExecMs > 0 {
+				q.MeanChangePct = (t.MeanExecMs - p.MeanExecMs) / p.MeanExecMs * 100
+			}
+		}
+		if sum > 0 {
+			q.PctOfTotal = t.TotalExecMs / sum * 100
+		}
+		rep.Queries = append(rep.Queries, q)
+	}
+	return rep, nil
+}
+
+// Trend is one statement's daily calls and time over the last days days.
+func (s *QueryStatsService) Trend(ctx context.Context, queryID int64, days int) ([]repository.QueryStatPoint, error) {
+	if days <= 0 || days > s.opts.RetentionDays {
+		days = s.opts.RetentionDays
+	}
+	return s.repo.Daily(ctx, queryID, s.now().UTC().AddDate(0, 0, -days))
+}
+
+// QueryStatsScheduler snapshots pg_stat_statements every interval.
+type QueryStatsScheduler struct {
+	svc      *QueryStatsService
+	jobs     *JobLocker
+	interval time.Duration
+}
+
+func NewQueryStatsScheduler(svc *QueryStatsService, jobs *JobLocker, interval time.Duration) *QueryStatsScheduler {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	return &QueryStatsScheduler{svc: svc, jobs: jobs, interval: interval}
+}
+
+func (s *QueryStatsScheduler) Start(ctx context.Context) {
+	log.Printf("Query stats collector started (every %s)", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	warned := false
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Query stats collector stopped")
+			return
+		case <-ticker.C:
+			s.jobs.RunOnce(ctx, "query_stats", TickSlot(time.Now(), s.interval), s.interval, func(ctx context.Context) {
+				_, err := s.svc.Collect(ctx)
+				switch {
+				case err == nil:
+					warned = false
+				case errors.Is(err, ErrQueryStatsUnavailable):
+					// Logged once per outage rather than every tick.
+					if !warned {
+						log.Printf("Query stats: %v", err)
+						warned = true
+					}
+				default:
+					log.Printf("Query stats: collect failed: %v", err)
+				}
+			})
+		}
+	}
+}
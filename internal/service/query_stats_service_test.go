@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+// fakeQueryStatsRepo serves canned pg_stat_statements rows and keeps saved
+// snapshots in memory.
+type fakeQueryStatsRepo struct {
+	unavailable error
+	current     []repository.QueryStatSample
+	saved       []repository.QueryStatSample
+	totals      map[time.Time][]repository.QueryStatTotal
+}
+
+func (r *fakeQueryStatsRepo) Available(ctx context.Context) error { return r.unavailable }
+
+func (r *fakeQueryStatsRepo) ReadStatements(ctx context.Context, limit int) ([]repository.QueryStatSample, error) {
+	out := append([]repository.QueryStatSample(nil), r.current...)
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *fakeQueryStatsRepo) LastSnapshots(ctx context.Context, ids []int64) (map[int64]repository.QueryStatSample, error) {
+	out := map[int64]repository.QueryStatSample{}
+	for _, s := range r.saved {
+		for _, id := range ids {
+			if s.QueryID == id {
+				out[id] = s
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeQueryStatsRepo) HasSnapshots(ctx context.Context) (bool, error) {
+	return len(r.saved) > 0, nil
+}
+
+func (r *fakeQueryStatsRepo) SaveSnapshot(ctx context.Context, at time.Time, samples []repository.QueryStatSample) error {
+	r.saved = append(r.saved, samples...)
+	return nil
+}
+
+func (r *fakeQueryStatsRepo) Totals(ctx context.Context, from, to time.Time, orderBy string, limit int) ([]repository.QueryStatTotal, error) {
+	return r.totals[from], nil
+}
+
+func (r *fakeQueryStatsRepo) TotalsByID(ctx context.Context, from, to time.Time, ids []int64) (map[int64]repository.QueryStatTotal, error) {
+	out := map[int64]repository.QueryStatTotal{}
+	for _, t := range r.totals[from] {
+		out[t.QueryID] = t
+	}
+	return out, nil
+}
+
+func (r *fakeQueryStatsRepo) Daily(ctx context.Context, id int64, from time.Time) ([]repository.QueryStatPoint, error) {
+	return nil, nil
+}
+
+func (r *fakeQueryStatsRepo) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func stmt(id, calls int64, ms float64) repository.QueryStatSample {
+	return repository.QueryStatSample{QueryID: id, Query: "/* method=Repo.Get */ SELECT 1", Calls: calls, TotalExecMs: ms}
+}
+
+func TestQueryStatsCollectDeltas(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeQueryStatsRepo{current: []repository.QueryStatSample{stmt(1, 100, 500)}}
+	svc := NewQueryStatsService(repo, QueryStatsOptions{})
+
+	// The first snapshot only sets the baseline.
+	if _, err := svc.Collect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := repo.saved[0]; s.DeltaCalls != 0 || s.DeltaExecMs != 0 || s.Method != "Repo.Get" {
+		t.Fatalf("baseline snapshot = %+v", s)
+	}
+
+	// Statement 1 grows; statement 2 appears after collection started.
+	repo.current = []repository.QueryStatSample{stmt(1, 150, 800), stmt(2, 7, 70)}
+	if _, err := svc.Collect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := repo.saved[1]; s.DeltaCalls != 50 || s.DeltaExecMs != 300 {
+		t.Errorf("delta = %d calls / %.0fms, want 50 / 300", s.DeltaCalls, s.DeltaExecMs)
+	}
+	if s := repo.saved[2]; s.DeltaCalls != 7 || s.DeltaExecMs != 70 {
+		t.Errorf("new statement delta = %d calls / %.0fms, want its whole total", s.DeltaCalls, s.DeltaExecMs)
+	}
+
+	// A stats reset drops the counters below the last snapshot.
+	repo.current = []repository.QueryStatSample{stmt(1, 20, 90)}
+	if _, err := svc.Collect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := repo.saved[3]; s.DeltaCalls != 20 || s.DeltaExecMs != 90 {
+		t.Errorf("delta after reset = %d calls / %.0fms, want 20 / 90", s.DeltaCalls, s.DeltaExecMs)
+	}
+}
+
+func TestQueryStatsCollectUnavailable(t *testing.T) {
+	repo := &fakeQueryStatsRepo{unavailable: errors.New("not preloaded")}
+	svc := NewQueryStatsService(repo, QueryStatsOptions{})
+	if _, err := svc.Collect(context.Background()); !errors.Is(err, ErrQueryStatsUnavailable) {
+		t.Fatalf("err = %v, want ErrQueryStatsUnavailable", err)
+	}
+}
+
+func TestQueryStatsWorst(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	from := now.Add(-time.Hour)
+	repo := &fakeQueryStatsRepo{totals: map[time.Time][]repository.QueryStatTotal{
+		from: {
+			{QueryID: 1, Calls: 10, TotalExecMs: 300, MeanExecMs: 30},
+			{QueryID: 2, Calls: 100, TotalExecMs: 100, MeanExecMs: 1},
+		},
+		from.Add(-time.Hour): {
+			{QueryID: 1, Calls: 10, TotalExecMs: 200, MeanExecMs: 20},
+		},
+	}}
+	svc := NewQueryStatsService(repo, QueryStatsOptions{})
+	svc.now = func() time.Time { return now }
+
+	rep, err := svc.Worst(context.Background(), time.Hour, "bogus", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Sort != repository.QueryStatsByTotal || rep.Window != "1h" || len(rep.Queries) != 2 {
+		t.Fatalf("report = %+v", rep)
+	}
+	q1, q2 := rep.Queries[0], rep.Queries[1]
+	if q1.PctOfTotal != 75 || q2.PctOfTotal != 25 {
+		t.Errorf("share of total = %.1f / %.1f, want 75 / 25", q1.PctOfTotal, q2.PctOfTotal)
+	}
+	if q1.PrevCalls != 10 || math.Abs(q1.MeanChangePct-50) > 1e-9 {
+		t.Errorf("mean change = %.1f%% (prev calls %d), want +50%%", q1.MeanChangePct, q1.PrevCalls)
+	}
+	if q2.PrevCalls != 0 || q2.MeanChangePct != 0 {
+		t.Errorf("statement without history compared: %+v", q2)
+	}
+}
+
+func TestQueryMethod(t *testing.T) {
+	for query, want := range map[string]string{
+		"/* method=LogRepo.GetDailyLogs req=abc */ SELECT * FROM logs": "LogRepo.GetDailyLogs",
+		"SELECT 1 /* method=GetUser */":                                "GetUser",
+		"/* plain comment */ SELECT 1":                                 "",
+		"SELECT 'method=Fake'":                                         "",
+	} {
+		if got := QueryMethod(query); got != want {
+			t.Errorf("QueryMethod(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
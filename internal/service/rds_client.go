@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// RDSSnapshot is the part of an RDS DBSnapshot the backup module uses.
+type RDSSnapshot struct {
+	Identifier         string    `xml:"DBSnapshotIdentifier"`
+	InstanceIdentifier string    `xml:"DBInstanceIdentifier"`
+	Status             string    `xml:"Status"`
+	SnapshotType       string    `xml:"SnapshotType"`
+	AllocatedStorageGB int64     `xml:"AllocatedStorage"`
+	PercentProgress    int       `xml:"PercentProgress"`
+	CreatedAt          time.Time `xml:"SnapshotCreateTime"`
+}
+
+// RDSInstance is the part of an RDS DBInstance the restore drill uses.
+type RDSInstance struct {
+	Identifier string `xml:"DBInstanceIdentifier"`
+	Status     string `xml:"DBInstanceStatus"`
+	Address    string `xml:"Endpoint>Address"`
+	Port       int    `xml:"Endpoint>Port"`
+}
+
+// RDSRestoreOptions places a scratch instance restored from a snapshot.
+type RDSRestoreOptions struct {
+	InstanceClass    string
+	SubnetGroup      string
+	SecurityGroupIDs []string
+	Tags             map[string]string
+}
+
+// RDSAPI is the slice of the RDS API used for backups and restore drills.
+type RDSAPI interface {
+	CreateSnapshot(ctx context.Context, instanceID, snapshotID string) (*RDSSnapshot, error)
+	// DescribeSnapshots lists an instance's snapshots (manual and
+	// automated), or the single snapshot when snapshotID is set.
+	DescribeSnapshots(ctx context.Context, instanceID, snapshotID string) ([]RDSSnapshot, error)
+	RestoreInstance(ctx context.Context, snapshotID, instanceID string, opts RDSRestoreOptions) (*RDSInstance, error)
+	DescribeInstance(ctx context.Context, instanceID string) (*RDSInstance, error)
+	// DeleteInstance deletes without a final snapshot; only for scratch
+	// instances.
+	DeleteInstance(ctx context.Context, instanceID string) error
+}
+
+// rdsAPIVersion is the RDS Query API version.
+const rdsAPIVersion = "2014-10-31"
+
+// RDSClient talks to the RDS Query API, signing requests with the SDK's
+// SigV4 signer and default credential chain (instance role on EC2).
+type RDSClient struct {
+	endpoint string
+	region   string
+	creds    aws.CredentialsProvider
+	signer   *v4.Signer
+	http     *http.Client
+}
+
+// NewRDSClient creates a client for region using the default AWS config.
+func NewRDSClient(ctx context.Context, region string) (*RDSClient, error) {
+	if region == "" {
+		region = "us-east-1"
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+	return newRDSClient("https://rds."+region+".amazonaws.com/", region, cfg.Credentials), nil
+}
+
+func newRDSClient(endpoint, region string, creds aws.CredentialsProvider) *RDSClient {
+	return &RDSClient{
+		endpoint: endpoint,
+		region:   region,
+		creds:    creds,
+		signer:   v4.NewSigner(),
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// RDSError is an error response from the RDS API.
+type RDSError struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *RDSError) Error() string { return "rds: " + e.Code + ": " + e.Message }
+
+// call POSTs one signed Query API action and decodes the response into out.
+func (c *RDSClient) call(ctx context.Context, action string, params map[string]string, out interface{}) error {
+	form := url.Values{"Action": {action}, "Version": {rdsAPIVersion}}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		form.Set(k, params[k])
+	}
+	body := form.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds, err := c.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("AWS credentials: %w", err)
+	}
+	sum := sha256.Sum256([]byte(body))
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "rds", c.region, time.Now()); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error RDSError `xml:"Error"`
+		}
+		if xml.Unmarshal(data, &e) == nil && e.Error.Code != "" {
+			return &e.Error
+		}
+		return fmt.Errorf("rds %s: HTTP %d", action, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, out)
+}
+
+func (c *RDSClient) CreateSnapshot(ctx context.Context, instanceID, snapshotID string) (*RDSSnapshot, error) {
+	var out struct {
+		Snapshot RDSSnapshot `xml:"CreateDBSnapshotResult>DBSnapshot"`
+	}
+	err := c.call(ctx, "CreateDBSnapshot", map[string]string{
+		"DBInstanceIdentifier": instanceID,
+		"DBSnapshotIdentifier": snapshotID,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return &out.Snapshot, nil
+}
+
+func (c *RDSClient) DescribeSnapshots(ctx context.Context, instanceID, snapshotID string) ([]RDSSnapshot, error) {
+	var all []RDSSnapshot
+	marker := ""
+	for {
+		params := map[string]string{"MaxRecords": "100"}
+		if snapshotID != "" {
+			params["DBSnapshotIdentifier"] = snapshotID
+		} else {
+			params["DBInstanceIdentifier"] = instanceID
+		}
+		if marker != "" {
+			params["Marker"] = marker
+		}
+		var out struct {
+			Snapshots []RDSSnapshot `xml:"DescribeDBSnapshotsResult>DBSnapshots>DBSnapshot"`
+			Marker    string        `xml:"DescribeDBSnapshotsResult>Marker"`
+		}
+		if err := c.call(ctx, "DescribeDBSnapshots", params, &out); err != nil {
+			return nil, err
+		}
+		all = append(all, out.Snapshots...)
+		if out.Marker == "" {
+			return all, nil
+		}
+		marker = out.Marker
+	}
+}
+
+func (c *RDSClient) RestoreInstance(ctx context.Context, snapshotID, instanceID string, opts RDSRestoreOptions) (*RDSInstance, error) {
+	params := map[string]string{
+		"DBSnapshotIdentifier": snapshotID,
+		"DBInstanceIdentifier": instanceID,
+		"PubliclyAccessible":   "false",
+		"MultiAZ":              "false",
+		"DeletionProtection":   "false",
+	}
+	if opts.InstanceClass != "" {
+		params["DBInstanceClass"] = opts.InstanceClass
+	}
+	if opts.SubnetGroup != "" {
+		params["DBSubnetGroupName"] = opts.SubnetGroup
+	}
+	for i, sg := range opts.SecurityGroupIDs {
+		params["VpcSecurityGroupIds.VpcSecurityGroupId."+strconv.Itoa(i+1)] = sg
+	}
+	tagKeys := make([]string, 0, len(opts.Tags))
+	for k := range opts.Tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	for i, k := range tagKeys {
+		n := strconv.Itoa(i + 1)
+		params["Tags.Tag."+n+".Key"] = k
+		params["Tags.Tag."+n+".Value"] = opts.Tags[k]
+	}
+	var out struct {
+		Instance RDSInstance `xml:"RestoreDBInstanceFromDBSnapshotResult>DBInstance"`
+	}
+	if err := c.call(ctx, "RestoreDBInstanceFromDBSnapshot", params, &out); err != nil {
+		return nil, err
+	}
+	return &out.Instance, nil
+}
+
+func (c *RDSClient) DescribeInstance(ctx context.Context, instanceID string) (*RDSInstance, error) {
+	var out struct {
+		Instances []RDSInstance `xml:"DescribeDBInstancesResult>DBInstances>DBInstance"`
+	}
+	if err := c.call(ctx, "DescribeDBInstances", map[string]string{"DBInstanceIdentifier": instanceID}, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Instances) == 0 {
+		return nil, fmt.Errorf("rds: instance %s not found", instanceID)
+	}
+	return &out.Instances[0], nil
+}
+
+func (c *RDSClient) DeleteInstance(ctx context.Context, instanceID string) error {
+	return c.call(ctx, "DeleteDBInstance", map[string]string{
+		"DBInstanceIdentifier":   instanceID,
+		"SkipFinalSnapshot":      "true",
+		"DeleteAutomatedBackups": "true",
+	}, nil)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// ReportTypeGoalProgress is the report_type of therapy goal progress
+// reports.
+const ReportTypeGoalProgress = "goal_progress"
+
+// GenerateGoalProgressReport writes the IEP-style progress report for a
+// child's therapy goals over [start, end] and stores it as a report, so
+// it is listed, downloaded and shared like the child's other reports.
+// label names the period in the title, e.g. "Q2 2026".
+func (s *ReportService) GenerateGoalProgressReport(ctx context.Context, child *models.Child, userID uuid.UUID, label string, start, end time.Time, trends []models.TherapyGoalTrend) (*models.Report, error) {
+	report := &models.Report{
+		ChildID:     child.ID,
+		FamilyID:    child.FamilyID,
+		CreatedBy:   userID,
+		Title:       fmt.Sprintf("%s - Therapy Goal Progress (%s)", child.FirstName, label),
+		ReportType:  ReportTypeGoalProgress,
+		PeriodType:  "custom",
+		StartDate:   start,
+		EndDate:     end,
+		DataFilters: models.StringArray{"therapy"},
+	}
+	if err := s.reportRepo.Create(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to create report record: %w", err)
+	}
+
+	pdf := goalProgressPDF(child, label, start, end, trends)
+	driver, storagePath, size, err := s.storePDF(ctx, report.ID, pdf)
+	if err != nil {
+		s.reportRepo.UpdateError(ctx, report.ID, err.Error())
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+	if err := s.reportRepo.UpdateStatus(ctx, report.ID, "completed", driver, storagePath, size); err != nil {
+		return nil, err
+	}
+	report.Status = "completed"
+	report.StorageDriver = models.NullString{NullString: sql.NullString{String: driver, Valid: true}}
+	report.StoragePath = models.NullString{NullString: sql.NullString{String: storagePath, Valid: true}}
+	report.FileSize = &size
+	return report, nil
+}
+
+// goalProgressPDF lays out the progress report: a cover with a summary
+// table of every goal, then a page per goal with its rating chart and
+// session notes.
+func goalProgressPDF(child *models.Child, label string, start, end time.Time, trends []models.TherapyGoalTrend) *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(true, 20)
+	setDisclaimerFooter(pdf)
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetTextColor(79, 70, 229)
+	pdf.CellFormat(0, 12, "Therapy Goal Progress Report", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(55, 65, 81)
+	pdf.CellFormat(0, 7, fmt.Sprintf("%s - %s (%s to %s)", child.FullName(), label,
+		start.Format("January 2, 2006"), end.Format("January 2, 2006")), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(107, 114, 128)
+	pdf.CellFormat(0, 7, fmt.Sprintf("Generated: %s", time.Now().Format("January 2, 2006 3:04 PM")), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	if len(trends) == 0 {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.MultiCell(0, 6, "No therapy goals were recorded for this period.", "", "L", false)
+		return pdf
+	}
+	var rows [][]string
+	for _, t := range trends {
+		rows = append(rows, []string{
+			truncate(t.Goal.GoalText, 40), goalStatusLabel(t.Goal.Status), fmt.Sprintf("%d", t.Sessions),
+			formatRating(t.AverageRating), goalDirectionLabel(t.Direction),
+		})
+	}
+	addTable(pdf, []string{"Goal", "Status", "Sessions", "Avg rating", "Progress"}, rows)
+
+	for i, t := range trends {
+		g := t.Goal
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.SetTextColor(79, 70, 229)
+		pdf.MultiCell(0, 7, fmt.Sprintf("Goal %d: %s", i+1, g.GoalText), "", "L", false)
+		pdf.SetDrawColor(79, 70, 229)
+		pdf.Line(10, pdf.GetY(), 200, pdf.GetY())
+		pdf.Ln(3)
+
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(55, 65, 81)
+		line := func(k, v string) {
+			if v == "" {
+				return
+			}
+			pdf.SetFont("Helvetica", "B", 10)
+			pdf.CellFormat(45, 6, k, "", 0, "L", false, 0, "")
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.MultiCell(0, 6, v, "", "L", false)
+		}
+		line("Therapy", g.TherapyType.String)
+		line("Measured by", g.TargetMetric)
+		line("Baseline", formatGoalValue(g.Baseline))
+		line("Target", formatGoalValue(g.TargetValue))
+		if g.TargetDate != nil {
+			line("Target date", g.TargetDate.Format("January 2, 2006"))
+		}
+		line("Status", goalStatusLabel(g.Status))
+		line("Sessions this period", fmt.Sprintf("%d", t.Sessions))
+		line("Average rating", formatRating(t.AverageRating))
+		line("Latest measurement", formatGoalValue(t.LatestValue))
+		if t.PercentToTarget != nil {
+			line("Toward target", fmt.Sprintf("%.0f%%", *t.PercentToTarget))
+		}
+		line("Progress", goalDirectionLabel(t.Direction))
+		pdf.Ln(3)
+
+		var series []models.ChartDataPoint
+		for _, p := range t.Points {
+			if p.ProgressRating != nil {
+				series = append(series, models.ChartDataPoint{Date: p.Date.Format("2006-01-02"), Value: float64(*p.ProgressRating)})
+			}
+		}
+		if len(series) > 1 {
+			if chartPNG, err := renderChartImage(series, "Progress rating (1-5) by session", 700, 260, nil); err == nil {
+				name := fmt.Sprintf("goal_chart_%d", i)
+				pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(chartPNG))
+				pdf.ImageOptions(name, 10, pdf.GetY(), 190, 0, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+				pdf.Ln(74)
+			}
+		}
+
+		if len(t.Points) > 0 {
+			var rows [][]string
+			for _, p := range t.Points {
+				rating := "--"
+				if p.ProgressRating != nil {
+					rating = fmt.Sprintf("%d/5", *p.ProgressRating)
+				}
+				value := formatGoalValue(p.MeasuredValue)
+				if value == "" {
+					value = "--"
+				}
+				rows = append(rows, []string{p.Date.Format("01/02"), rating, value, truncate(p.Note, 40)})
+			}
+			addTable(pdf, []string{"Date", "Rating", "Measured", "Note"}, rows)
+		}
+	}
+	return pdf
+}
+
+func formatGoalValue(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", *v), "0"), ".")
+}
+
+func formatRating(v *float64) string {
+	if v == nil {
+		return "--"
+	}
+	return fmt.Sprintf("%.1f/5", *v)
+}
+
+func goalStatusLabel(status string) string {
+	switch status {
+	case models.TherapyGoalMet:
+		return "Met"
+	case models.TherapyGoalDiscontinued:
+		return "Discontinued"
+	default:
+		return "In progress"
+	}
+}
+
+func goalDirectionLabel(direction string) string {
+	switch direction {
+	case GoalTrendImproving:
+		return "Improving"
+	case GoalTrendDeclining:
+		return "Declining"
+	case GoalTrendSteady:
+		return "Steady"
+	default:
+		return "Not enough sessions to tell"
+	}
+}
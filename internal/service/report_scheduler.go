@@ -14,11 +14,12 @@ import (
 // ReportScheduler runs scheduled reports on a timer
 type ReportScheduler struct {
 	reportService *ReportService
+	jobs          *JobLocker
 }
 
 // NewReportScheduler creates a new report scheduler
-func NewReportScheduler(reportService *ReportService) *ReportScheduler {
-	return &ReportScheduler{reportService: reportService}
+func NewReportScheduler(reportService *ReportService, jobs *JobLocker) *ReportScheduler {
+	return &ReportScheduler{reportService: reportService, jobs: jobs}
 }
 
 // Start begins the scheduler loop, checking for due reports every minute
@@ -33,7 +34,9 @@ func (s *ReportScheduler) Start(ctx context.Context) {
 			log.Println("Report scheduler stopped")
 			return
 		case <-ticker.C:
-			s.checkDueReports(ctx)
+			// One instance per minute, so a due report isn't generated
+			// and shared N times.
+			s.jobs.RunOnce(ctx, "scheduled_reports", TickSlot(time.Now(), time.Minute), time.Minute, s.checkDueReports)
 		}
 	}
 }
@@ -45,7 +48,13 @@ func (s *ReportScheduler) checkDueReports(ctx context.Context) {
 		return
 	}
 
-	for _, sr := range dueReports {
+	for i, sr := range dueReports {
+		// Reports not reached keep their next_run and are picked up by
+		// the next tick on another instance.
+		if s.jobs.Draining() {
+			log.Printf("Report scheduler: shutting down, leaving %d due report(s)", len(dueReports)-i)
+			return
+		}
 		s.runScheduledReport(ctx, sr)
 	}
 }
@@ -35,6 +35,21 @@ type ReportService struct {
 	chatRepo      repository.ChatRepository
 	storage       BlobStorage
 	signingSecret []byte
+	annotations   reportAnnotationSource
+	restrictions  reportRestrictionSource
+	rubrics       reportRubricSource
+}
+
+type reportRubricSource interface {
+	VersionsForChild(ctx context.Context, childID uuid.UUID, versions []int) (map[int]*models.ScoringRubric, error)
+}
+
+type reportRestrictionSource interface {
+	List(ctx context.Context, childID uuid.UUID) ([]models.ChildRestriction, error)
+}
+
+type reportAnnotationSource interface {
+	ListForRange(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.Annotation, error)
 }
 
 // NewReportService creates a new report service. signingSecret is used to
@@ -52,6 +67,84 @@ func NewReportService(reportRepo repository.ReportRepository, logRepo repository
 	}
 }
 
+// SetAnnotations adds the child's chart annotations to the view data and
+// marks their dates on the PDF's charts.
+func (s *ReportService) SetAnnotations(annotations reportAnnotationSource) {
+	s.annotations = annotations
+}
+
+// SetRestrictions adds the child's allergy and restriction registry, and
+// the allergy incidents in the period, to reports.
+func (s *ReportService) SetRestrictions(restrictions reportRestrictionSource) {
+	s.restrictions = restrictions
+}
+
+// SetRubrics labels behavior log levels in reports with the family's
+// scoring rubric instead of bare numbers.
+func (s *ReportService) SetRubrics(rubrics reportRubricSource) {
+	s.rubrics = rubrics
+}
+
+// behaviorRubrics returns the rubric versions the behavior logs were
+// entered under. Without them levels are shown as bare numbers, so a
+// failure is logged rather than failing the report.
+func (s *ReportService) behaviorRubrics(ctx context.Context, childID uuid.UUID, logs []models.BehaviorLog) map[int]*models.ScoringRubric {
+	if s.rubrics == nil || len(logs) == 0 {
+		return nil
+	}
+	var versions []int
+	for _, l := range logs {
+		versions = append(versions, l.RubricVersion)
+	}
+	rubrics, err := s.rubrics.VersionsForChild(ctx, childID, versions)
+	if err != nil {
+		log.Printf("report: rubrics for child %s: %v", childID, err)
+		return nil
+	}
+	return rubrics
+}
+
+// childRestrictions returns the child's registry, logging rather than
+// failing the report when it can't be read.
+func (s *ReportService) childRestrictions(ctx context.Context, childID uuid.UUID) []models.ChildRestriction {
+	if s.restrictions == nil {
+		return nil
+	}
+	list, err := s.restrictions.List(ctx, childID)
+	if err != nil {
+		log.Printf("report: restrictions for child %s: %v", childID, err)
+		return nil
+	}
+	return list
+}
+
+// allergyIncidents are the diet logs that matched the registry or
+// recorded an allergic reaction.
+func allergyIncidents(diet []models.DietLog) []models.DietLog {
+	var out []models.DietLog
+	for _, l := range diet {
+		if len(l.RestrictionMatches) > 0 || l.AllergicReaction {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// chartAnnotations returns the child's annotations in [start, end]. They
+// only decorate the charts, so a failure is logged rather than failing
+// the report.
+func (s *ReportService) chartAnnotations(ctx context.Context, childID uuid.UUID, start, end time.Time) []models.Annotation {
+	if s.annotations == nil {
+		return nil
+	}
+	list, err := s.annotations.ListForRange(ctx, childID, start, end)
+	if err != nil {
+		log.Printf("report: annotations for child %s: %v", childID, err)
+		return nil
+	}
+	return list
+}
+
 // SignedPDFURL returns a path with HMAC signature + expiry that ServeSignedPDF
 // will accept without auth. TTL is short by design — the URL is meant to be
 // minted just before opening in a system browser.
@@ -165,14 +258,30 @@ func (s *ReportService) GetViewData(ctx context.Context, report *models.Report)
 	}
 
 	chartData := s.aggregateChartData(logs, []string(report.DataFilters), report.StartDate, report.EndDate)
+	annotations := s.chartAnnotations(ctx, report.ChildID, report.StartDate, report.EndDate)
+	if annotations == nil {
+		annotations = []models.Annotation{}
+	}
+	restrictions := s.childRestrictions(ctx, report.ChildID)
+	if restrictions == nil {
+		restrictions = []models.ChildRestriction{}
+	}
+	rubrics := []models.ScoringRubric{}
+	for _, r := range s.behaviorRubrics(ctx, report.ChildID, logs.BehaviorLogs) {
+		rubrics = append(rubrics, *r)
+	}
+	sort.Slice(rubrics, func(i, j int) bool { return rubrics[i].Version < rubrics[j].Version })
 
 	return &models.ReportChartData{
-		ReportID:  report.ID,
-		ChildName: childName,
-		StartDate: report.StartDate.Format("2006-01-02"),
-		EndDate:   report.EndDate.Format("2006-01-02"),
-		Charts:    chartData,
-		Logs:      logs,
+		ReportID:     report.ID,
+		ChildName:    childName,
+		StartDate:    report.StartDate.Format("2006-01-02"),
+		EndDate:      report.EndDate.Format("2006-01-02"),
+		Charts:       chartData,
+		Logs:         logs,
+		Annotations:  annotations,
+		Restrictions: restrictions,
+		Rubrics:      rubrics,
 	}, nil
 }
 
@@ -190,6 +299,11 @@ func (s *ReportService) ListReports(ctx context.Context, childID uuid.UUID) ([]m
 	return s.reportRepo.GetByChildID(ctx, childID, 50)
 }
 
+// ListAllReports returns every report for a child, for data exports.
+func (s *ReportService) ListAllReports(ctx context.Context, childID uuid.UUID) ([]models.Report, error) {
+	return s.reportRepo.GetByChildID(ctx, childID, math.MaxInt32)
+}
+
 // GetByID returns a report by ID
 func (s *ReportService) GetByID(ctx context.Context, id uuid.UUID) (*models.Report, error) {
 	return s.reportRepo.GetByID(ctx, id)
@@ -461,8 +575,9 @@ func computeDateRange(req *models.GenerateReportRequest) (time.Time, time.Time,
 	}
 }
 
-// renderChartImage creates a PNG chart image using fogleman/gg
-func renderChartImage(series []models.ChartDataPoint, title string, width, height int) ([]byte, error) {
+// renderChartImage creates a PNG chart image using fogleman/gg. Bars on a
+// date in markers (YYYY-MM-DD) get a dashed marker line, for annotations.
+func renderChartImage(series []models.ChartDataPoint, title string, width, height int, markers map[string]bool) ([]byte, error) {
 	dc := gg.NewContext(width, height)
 
 	// Background
@@ -537,6 +652,14 @@ func renderChartImage(series []models.ChartDataPoint, title string, width, heigh
 		dc.DrawRectangle(x, y, bw, barH)
 		dc.Fill()
 
+		if markers[p.Date] {
+			dc.SetColor(color.RGBA{217, 119, 6, 255})
+			dc.SetDash(4, 3)
+			dc.DrawLine(x+bw/2, marginTop, x+bw/2, marginTop+chartH)
+			dc.Stroke()
+			dc.SetDash()
+		}
+
 		// X-axis label (show every Nth label to avoid overlap)
 		labelInterval := 1
 		if n > 14 {
@@ -561,18 +684,7 @@ func renderChartImage(series []models.ChartDataPoint, title string, width, heigh
 func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, child *models.Child, startDate, endDate time.Time, filters []string, chartData map[string][]models.ChartDataPoint, logs *models.DailyLogPage) (driver string, storagePath string, size int64, err error) {
 	pdf := fpdf.New("P", "mm", "A4", "")
 	pdf.SetAutoPageBreak(true, 20)
-
-	// Medical disclaimer footer on every page (App Store guideline 1.4.1).
-	pdf.SetFooterFunc(func() {
-		pdf.SetY(-15)
-		pdf.SetFont("Helvetica", "I", 7)
-		pdf.SetTextColor(120, 113, 108)
-		pdf.MultiCell(0, 3.2,
-			"MyCareCompanion is a tracking and journaling tool, not a medical device. "+
-				"The data and patterns in this report are observations of your logged entries, not medical advice. "+
-				"Consult your child's healthcare provider for clinical decisions. In an emergency, call 911.",
-			"", "C", false)
-	})
+	setDisclaimerFooter(pdf)
 
 	// Cover page
 	pdf.AddPage()
@@ -594,6 +706,20 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 	pdf.Ln(5)
 	pdf.CellFormat(0, 8, fmt.Sprintf("Generated: %s", time.Now().Format("January 2, 2006 3:04 PM")), "", 1, "C", false, 0, "")
 
+	annotations := s.chartAnnotations(ctx, child.ID, startDate, endDate)
+	markers := make(map[string]bool, len(annotations))
+	for _, a := range annotations {
+		markers[a.AnnotationDate.Format("2006-01-02")] = true
+	}
+
+	// Allergies come first: whoever reads the report should see them
+	// before anything else.
+	restrictions := s.childRestrictions(ctx, child.ID)
+	incidents := allergyIncidents(logs.DietLogs)
+	if len(restrictions) > 0 || len(incidents) > 0 {
+		addAllergyPage(pdf, restrictions, incidents)
+	}
+
 	// Data sections - sorted for consistent ordering
 	sortedCharts := make([]string, 0, len(chartData))
 	for k := range chartData {
@@ -615,7 +741,7 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 		pdf.Ln(5)
 
 		// Render and embed chart image
-		chartPNG, err := renderChartImage(series, chartTitle, 700, 300)
+		chartPNG, err := renderChartImage(series, chartTitle, 700, 300, markers)
 		if err == nil && len(chartPNG) > 0 {
 			reader := bytes.NewReader(chartPNG)
 			imgName := fmt.Sprintf("chart_%s", chartTitle)
@@ -630,17 +756,32 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 	for _, f := range filters {
 		filterSet[f] = true
 	}
+	// Entries corrected after they were logged are starred.
+	anyAmended := false
+	logDate := func(d time.Time, amendedAt *time.Time) string {
+		if amendedAt == nil {
+			return d.Format("01/02")
+		}
+		anyAmended = true
+		return d.Format("01/02") + " *"
+	}
 
 	if filterSet["behavior"] && len(logs.BehaviorLogs) > 0 {
-		addDetailPage(pdf, "Behavior Log Details", []string{"Date", "Mood", "Energy", "Meltdowns", "Notes"}, func() [][]string {
+		rubrics := s.behaviorRubrics(ctx, child.ID, logs.BehaviorLogs)
+		addDetailPage(pdf, "Behavior Log Details", []string{"Date", "Mood", "Energy", "Anxiety", "Meltdowns", "Notes"}, func() [][]string {
 			var rows [][]string
 			for _, l := range logs.BehaviorLogs {
-				mood, energy := "--", "--"
-				if l.MoodLevel != nil { mood = fmt.Sprintf("%d/10", *l.MoodLevel) }
-				if l.EnergyLevel != nil { energy = fmt.Sprintf("%d/10", *l.EnergyLevel) }
+				rubric := rubrics[l.RubricVersion]
+				level := func(metric string, v *int) string {
+					if v == nil {
+						return "--"
+					}
+					return describeLevel(rubric, metric, *v)
+				}
 				rows = append(rows, []string{
-					l.LogDate.Format("01/02"), mood, energy,
-					fmt.Sprintf("%d", l.Meltdowns), truncate(l.Notes.String, 40),
+					logDate(l.LogDate, l.AmendedAt), level(models.RubricMetricMood, l.MoodLevel),
+					level(models.RubricMetricEnergy, l.EnergyLevel), level(models.RubricMetricAnxiety, l.AnxietyLevel),
+					fmt.Sprintf("%d", l.Meltdowns), truncate(l.Notes.String, 30),
 				})
 			}
 			return rows
@@ -653,7 +794,7 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 			for _, l := range logs.SleepLogs {
 				mins := 0; if l.TotalSleepMinutes != nil { mins = *l.TotalSleepMinutes }
 				rows = append(rows, []string{
-					l.LogDate.Format("01/02"), l.Bedtime.String, l.WakeTime.String,
+					logDate(l.LogDate, l.AmendedAt), l.Bedtime.String, l.WakeTime.String,
 					fmt.Sprintf("%.1f", float64(mins)/60), l.SleepQuality.String,
 				})
 			}
@@ -667,7 +808,7 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 			for _, l := range logs.DietLogs {
 				foods := strings.Join([]string(l.FoodsEaten), ", ")
 				rows = append(rows, []string{
-					l.LogDate.Format("01/02"), l.MealType.String,
+					logDate(l.LogDate, l.AmendedAt), l.MealType.String,
 					truncate(foods, 30), l.AppetiteLevel.String, truncate(l.Notes.String, 25),
 				})
 			}
@@ -694,7 +835,7 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 			for _, l := range logs.BowelLogs {
 				bristol := "--"; if l.BristolScale != nil { bristol = fmt.Sprintf("%d", *l.BristolScale) }
 				rows = append(rows, []string{
-					l.LogDate.Format("01/02"), bristol, truncate(l.Notes.String, 50),
+					logDate(l.LogDate, l.AmendedAt), bristol, truncate(l.Notes.String, 50),
 				})
 			}
 			return rows
@@ -708,7 +849,7 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 				reg := "--"; if l.OverallRegulation != nil { reg = fmt.Sprintf("%d/5", *l.OverallRegulation) }
 				triggers := strings.Join([]string(l.OverloadTriggers), ", ")
 				rows = append(rows, []string{
-					l.LogDate.Format("01/02"), reg, truncate(triggers, 30), truncate(l.Notes.String, 30),
+					logDate(l.LogDate, l.AmendedAt), reg, truncate(triggers, 30), truncate(l.Notes.String, 30),
 				})
 			}
 			return rows
@@ -721,7 +862,7 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 			for _, l := range logs.TherapyLogs {
 				dur := "--"; if l.DurationMinutes != nil { dur = fmt.Sprintf("%d min", *l.DurationMinutes) }
 				rows = append(rows, []string{
-					l.LogDate.Format("01/02"), l.TherapyType.String, dur, truncate(l.ProgressNotes.String, 35),
+					logDate(l.LogDate, l.AmendedAt), l.TherapyType.String, dur, truncate(l.ProgressNotes.String, 35),
 				})
 			}
 			return rows
@@ -734,13 +875,88 @@ func (s *ReportService) generatePDF(ctx context.Context, reportID uuid.UUID, chi
 			for _, l := range logs.SeizureLogs {
 				dur := "--"; if l.DurationSeconds != nil { dur = fmt.Sprintf("%d sec", *l.DurationSeconds) }
 				rows = append(rows, []string{
-					l.LogDate.Format("01/02"), l.SeizureType.String, dur, truncate(l.Notes.String, 40),
+					logDate(l.LogDate, l.AmendedAt), l.SeizureType.String, dur, truncate(l.Notes.String, 40),
 				})
 			}
 			return rows
 		})
 	}
 
+	if anyAmended {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.MultiCell(0, 4, "* Amended: the entry was corrected after it was first logged. "+
+			"Each change, with who made it and when, is in the entry's history in the app.", "", "L", false)
+	}
+
+	if len(annotations) > 0 {
+		addDetailPage(pdf, "Annotations", []string{"Date", "Category", "Annotation"}, func() [][]string {
+			var rows [][]string
+			for _, a := range annotations {
+				rows = append(rows, []string{
+					a.AnnotationDate.Format("01/02"), strings.Title(a.Category), truncate(a.Title, 40),
+				})
+			}
+			return rows
+		})
+	}
+
+	return s.storePDF(ctx, reportID, pdf)
+}
+
+// addAllergyPage lists the child's allergy and restriction registry and
+// the period's allergy incidents.
+func addAllergyPage(pdf *fpdf.Fpdf, restrictions []models.ChildRestriction, incidents []models.DietLog) {
+	addDetailPage(pdf, "Allergies & Dietary Restrictions", []string{"Item", "Type", "Severity", "Notes"}, func() [][]string {
+		var rows [][]string
+		for _, r := range restrictions {
+			severity := "--"
+			if r.Severity.Valid {
+				severity = strings.Title(r.Severity.String)
+			}
+			rows = append(rows, []string{truncate(r.Item, 30), strings.Title(r.Kind), severity, truncate(r.Notes.String, 30)})
+		}
+		return rows
+	})
+	if len(incidents) == 0 {
+		return
+	}
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetTextColor(55, 65, 81)
+	pdf.CellFormat(0, 8, "Allergy Incidents", "", 1, "L", false, 0, "")
+	var rows [][]string
+	for _, l := range incidents {
+		reaction := "No"
+		if l.AllergicReaction {
+			reaction = "Yes"
+		}
+		rows = append(rows, []string{
+			l.LogDate.Format("01/02"), truncate(strings.Join(l.FoodsEaten, ", "), 30),
+			truncate(strings.Join(l.RestrictionMatches, ", "), 30), reaction,
+		})
+	}
+	addTable(pdf, []string{"Date", "Foods", "Matched", "Reaction"}, rows)
+}
+
+// setDisclaimerFooter puts the medical disclaimer in the footer of every
+// page (App Store guideline 1.4.1).
+func setDisclaimerFooter(pdf *fpdf.Fpdf) {
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.SetTextColor(120, 113, 108)
+		pdf.MultiCell(0, 3.2,
+			"MyCareCompanion is a tracking and journaling tool, not a medical device. "+
+				"The data and patterns in this report are observations of your logged entries, not medical advice. "+
+				"Consult your child's healthcare provider for clinical decisions. In an emergency, call 911.",
+			"", "C", false)
+	})
+}
+
+// storePDF renders pdf and saves it to BlobStorage under reportID.
+func (s *ReportService) storePDF(ctx context.Context, reportID uuid.UUID, pdf *fpdf.Fpdf) (driver string, storagePath string, size int64, err error) {
 	// Render to a temp file, then hand the bytes to BlobStorage. Temp is
 	// removed on the way out — never persisted on the EC2 instance.
 	tmp, err := os.CreateTemp("", "report-*.pdf")
@@ -789,7 +1005,12 @@ func addDetailPage(pdf *fpdf.Fpdf, title string, headers []string, getRows func(
 	pdf.SetTextColor(55, 65, 81)
 	pdf.CellFormat(0, 10, title, "", 1, "L", false, 0, "")
 	pdf.Ln(3)
+	addTable(pdf, headers, getRows())
+}
 
+// addTable draws a bordered table at the current position, repeating the
+// header row after each page break.
+func addTable(pdf *fpdf.Fpdf, headers []string, rows [][]string) {
 	// Table header
 	pdf.SetFont("Helvetica", "B", 9)
 	pdf.SetFillColor(243, 244, 246)
@@ -803,7 +1024,6 @@ func addDetailPage(pdf *fpdf.Fpdf, title string, headers []string, getRows func(
 	// Table rows
 	pdf.SetFont("Helvetica", "", 8)
 	pdf.SetTextColor(75, 85, 99)
-	rows := getRows()
 	for _, row := range rows {
 		if pdf.GetY() > 270 {
 			pdf.AddPage()
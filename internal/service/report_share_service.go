@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrReportShareInvalid  = apperr.Validation("invalid report share")
+	ErrReportShareNotFound = apperr.NotFound("report share not found")
+	// ErrReportShareUnavailable is returned for a link that has expired
+	// or been revoked.
+	ErrReportShareUnavailable = errors.New("report share has expired or been revoked")
+)
+
+const (
+	ReportShareDefaultDays = 14
+	// ReportShareMaxDays caps how far ahead a share can expire, counted
+	// from now, both when created and when extended.
+	ReportShareMaxDays     = 90
+	maxReportShareNoteLen  = 1000
+	maxReportShareNameLen  = 200
+	maxReportShareAgentLen = 500
+)
+
+// reportShareReportSource is the part of ReportService shares need.
+type reportShareReportSource interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Report, error)
+	OpenPDF(ctx context.Context, report *models.Report) (io.ReadCloser, error)
+}
+
+type reportShareUserSource interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+type reportShareMailer interface {
+	IsEnabled() bool
+	SendReportShareEmail(to, recipientName, senderName, reportTitle, viewURL, expiresOn string) error
+}
+
+// ReportShareService shares generated reports with clinicians and other
+// people outside the family. Each share is a signed link for one
+// recipient; opening it records a read receipt. The link carries no
+// expiry — that lives on the share, so a caregiver can extend or revoke
+// it after the link has been sent.
+type ReportShareService struct {
+	repo          repository.ReportShareRepository
+	reports       reportShareReportSource
+	users         reportShareUserSource
+	mailer        reportShareMailer
+	appURL        string
+	signingSecret []byte
+	now           func() time.Time
+}
+
+// NewReportShareService creates the service. email may be nil; links are
+// then only returned to the caregiver, not emailed.
+func NewReportShareService(repo repository.ReportShareRepository, reports reportShareReportSource, users reportShareUserSource,
+	email *EmailService, appURL, signingSecret string) *ReportShareService {
+	s := &ReportShareService{repo: repo, reports: reports, users: users, appURL: appURL,
+		signingSecret: []byte(signingSecret), now: time.Now}
+	if email != nil {
+		s.mailer = email
+	}
+	return s
+}
+
+func invalidReportShare(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrReportShareInvalid, fmt.Sprintf(format, args...))
+}
+
+// Share creates a share of a completed report and, when the request has
+// an email address, sends the recipient the link.
+func (s *ReportShareService) Share(ctx context.Context, report *models.Report, userID uuid.UUID, req *models.CreateReportShareRequest) (*models.ReportShare, error) {
+	if report.Status != "completed" || !report.StoragePath.Valid {
+		return nil, invalidReportShare("only completed reports can be shared")
+	}
+	name := strings.TrimSpace(req.RecipientName)
+	if name == "" {
+		return nil, invalidReportShare("recipient_name is required")
+	}
+	if len(name) > maxReportShareNameLen {
+		return nil, invalidReportShare("recipient_name can be at most %d characters", maxReportShareNameLen)
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxReportShareNoteLen {
+		return nil, invalidReportShare("note can be at most %d characters", maxReportShareNoteLen)
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = ReportShareDefaultDays
+	}
+	if days < 1 || days > ReportShareMaxDays {
+		return nil, invalidReportShare("expires_in_days must be between 1 and %d", ReportShareMaxDays)
+	}
+
+	share := &models.ReportShare{
+		ReportID:      report.ID,
+		ReportTitle:   report.Title,
+		ChildID:       report.ChildID,
+		SharedBy:      &userID,
+		RecipientName: name,
+		ExpiresAt:     s.now().AddDate(0, 0, days),
+	}
+	if email := strings.TrimSpace(req.RecipientEmail); email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil {
+			return nil, invalidReportShare("recipient_email is not a valid email address")
+		}
+		share.RecipientEmail.String, share.RecipientEmail.Valid = addr.Address, true
+	}
+	share.Note.String, share.Note.Valid = note, note != ""
+	if err := s.repo.Create(ctx, share); err != nil {
+		return nil, err
+	}
+	share.URL = s.ShareURL(share.ID)
+	s.send(ctx, share, userID)
+	return share, nil
+}
+
+// send emails the recipient their link. Failures are logged; the
+// caregiver has the link to pass on themselves.
+func (s *ReportShareService) send(ctx context.Context, share *models.ReportShare, userID uuid.UUID) {
+	if !share.RecipientEmail.Valid || s.mailer == nil || !s.mailer.IsEnabled() {
+		return
+	}
+	sender := "A MyCareCompanion family"
+	if u, err := s.users.GetByID(ctx, userID); err == nil && u != nil && u.FullName() != "" {
+		sender = u.FullName()
+	}
+	expiresOn := share.ExpiresAt.UTC().Format("January 2, 2006")
+	if err := s.mailer.SendReportShareEmail(share.RecipientEmail.String, share.RecipientName, sender,
+		share.ReportTitle, share.URL, expiresOn); err != nil {
+		log.Printf("[REPORT] share %s: email to recipient: %v", share.ID, err)
+	}
+}
+
+// List returns the child's shares with their read receipts, newest
+// first. A non-nil reportID limits them to that report.
+func (s *ReportShareService) List(ctx context.Context, childID uuid.UUID, reportID *uuid.UUID) ([]models.ReportShare, error) {
+	shares, err := s.repo.ListByChild(ctx, childID, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if shares == nil {
+		return []models.ReportShare{}, nil
+	}
+	ids := make([]uuid.UUID, len(shares))
+	for i := range shares {
+		ids[i] = shares[i].ID
+	}
+	views, err := s.repo.ListViews(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byShare := make(map[uuid.UUID][]models.ReportShareView, len(shares))
+	for _, v := range views {
+		byShare[v.ShareID] = append(byShare[v.ShareID], v)
+	}
+	now := s.now()
+	for i := range shares {
+		shares[i].Views = byShare[shares[i].ID]
+		if reportShareActive(&shares[i], now) {
+			shares[i].URL = s.ShareURL(shares[i].ID)
+		}
+	}
+	return shares, nil
+}
+
+// get returns the child's share, or ErrReportShareNotFound.
+func (s *ReportShareService) get(ctx context.Context, childID, shareID uuid.UUID) (*models.ReportShare, error) {
+	share, err := s.repo.GetByID(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share == nil || share.ChildID != childID {
+		return nil, ErrReportShareNotFound
+	}
+	return share, nil
+}
+
+// Extend pushes the share's expiry back by days, from now if it has
+// already expired. The result can't be more than ReportShareMaxDays out.
+// Revoked shares can't be extended.
+func (s *ReportShareService) Extend(ctx context.Context, childID, shareID uuid.UUID, days int) (*models.ReportShare, error) {
+	if days < 1 || days > ReportShareMaxDays {
+		return nil, invalidReportShare("days must be between 1 and %d", ReportShareMaxDays)
+	}
+	share, err := s.get(ctx, childID, shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.RevokedAt != nil {
+		return nil, invalidReportShare("a revoked share can't be extended")
+	}
+	now := s.now()
+	from := share.ExpiresAt
+	if from.Before(now) {
+		from = now
+	}
+	expires := from.AddDate(0, 0, days)
+	if limit := now.AddDate(0, 0, ReportShareMaxDays); expires.After(limit) {
+		expires = limit
+	}
+	if err := s.repo.SetExpiry(ctx, share.ID, expires); err != nil {
+		return nil, err
+	}
+	share.ExpiresAt = expires
+	share.URL = s.ShareURL(share.ID)
+	return share, nil
+}
+
+// Revoke turns the share's link off for good.
+func (s *ReportShareService) Revoke(ctx context.Context, childID, shareID uuid.UUID) error {
+	share, err := s.get(ctx, childID, shareID)
+	if err != nil {
+		return err
+	}
+	if share.RevokedAt != nil {
+		return nil
+	}
+	return s.repo.Revoke(ctx, share.ID, s.now())
+}
+
+// Open checks a shared link, records the view and returns the report and
+// its PDF. Caller must close the reader.
+func (s *ReportShareService) Open(ctx context.Context, shareID uuid.UUID, sig, ip, userAgent string) (*models.Report, io.ReadCloser, error) {
+	if err := s.verify(shareID, sig); err != nil {
+		return nil, nil, err
+	}
+	share, err := s.repo.GetByID(ctx, shareID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if share == nil {
+		return nil, nil, ErrReportShareNotFound
+	}
+	if !reportShareActive(share, s.now()) {
+		return nil, nil, ErrReportShareUnavailable
+	}
+	report, err := s.reports.GetByID(ctx, share.ReportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report == nil {
+		return nil, nil, ErrReportShareNotFound
+	}
+	rc, err := s.reports.OpenPDF(ctx, report)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	view := &models.ReportShareView{ShareID: share.ID}
+	if net.ParseIP(ip) != nil {
+		view.IPAddress.String, view.IPAddress.Valid = ip, true
+	}
+	if len(userAgent) > maxReportShareAgentLen {
+		userAgent = userAgent[:maxReportShareAgentLen]
+	}
+	view.UserAgent.String, view.UserAgent.Valid = userAgent, userAgent != ""
+	if err := s.repo.RecordView(ctx, view); err != nil {
+		log.Printf("[REPORT] share %s: record view: %v", share.ID, err)
+	}
+	return report, rc, nil
+}
+
+func reportShareActive(share *models.ReportShare, now time.Time) bool {
+	return share.RevokedAt == nil && now.Before(share.ExpiresAt)
+}
+
+// ShareURL returns the share's link.
+func (s *ReportShareService) ShareURL(shareID uuid.UUID) string {
+	return fmt.Sprintf("%s/r/share/%s?sig=%s", s.appURL, shareID, s.sign(shareID))
+}
+
+func (s *ReportShareService) verify(shareID uuid.UUID, sig string) error {
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrReportShareNotFound
+	}
+	want, _ := hex.DecodeString(s.sign(shareID))
+	if !hmac.Equal(got, want) {
+		return ErrReportShareNotFound
+	}
+	return nil
+}
+
+func (s *ReportShareService) sign(shareID uuid.UUID) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte("report_share|" + shareID.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeReportShareRepo struct {
+	byID  map[uuid.UUID]*models.ReportShare
+	views []models.ReportShareView
+}
+
+func (f *fakeReportShareRepo) Create(ctx context.Context, s *models.ReportShare) error {
+	s.ID = uuid.New()
+	cp := *s
+	f.byID[s.ID] = &cp
+	return nil
+}
+
+func (f *fakeReportShareRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.ReportShare, error) {
+	s, ok := f.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (f *fakeReportShareRepo) ListByChild(ctx context.Context, childID uuid.UUID, reportID *uuid.UUID) ([]models.ReportShare, error) {
+	var out []models.ReportShare
+	for _, s := range f.byID {
+		if s.ChildID == childID && (reportID == nil || s.ReportID == *reportID) {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeReportShareRepo) ListViews(ctx context.Context, shareIDs []uuid.UUID) ([]models.ReportShareView, error) {
+	return f.views, nil
+}
+
+func (f *fakeReportShareRepo) RecordView(ctx context.Context, v *models.ReportShareView) error {
+	v.ID = uuid.New()
+	f.views = append(f.views, *v)
+	f.byID[v.ShareID].ViewCount++
+	return nil
+}
+
+func (f *fakeReportShareRepo) SetExpiry(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	f.byID[id].ExpiresAt = expiresAt
+	return nil
+}
+
+func (f *fakeReportShareRepo) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	f.byID[id].RevokedAt = &at
+	return nil
+}
+
+type fakeShareReports struct {
+	report *models.Report
+}
+
+func (f *fakeShareReports) GetByID(ctx context.Context, id uuid.UUID) (*models.Report, error) {
+	if f.report.ID != id {
+		return nil, nil
+	}
+	return f.report, nil
+}
+
+func (f *fakeShareReports) OpenPDF(ctx context.Context, report *models.Report) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("%PDF")), nil
+}
+
+// shareSig pulls the sig and share ID out of a share link.
+func shareSig(t *testing.T, link string) (uuid.UUID, string) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := uuid.Parse(path.Base(u.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, u.Query().Get("sig")
+}
+
+func TestReportShareLifecycle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	report := &models.Report{ID: uuid.New(), ChildID: uuid.New(), Title: "March", Status: "completed", StoragePath: nullString("reports/march.pdf")}
+	repo := &fakeReportShareRepo{byID: map[uuid.UUID]*models.ReportShare{}}
+	svc := NewReportShareService(repo, &fakeShareReports{report: report}, nil, nil, "https://app.example", "secret")
+	svc.now = func() time.Time { return now }
+
+	for name, req := range map[string]models.CreateReportShareRequest{
+		"no name":   {RecipientName: " "},
+		"bad email": {RecipientName: "Dr. Lee", RecipientEmail: "lee@"},
+		"too long":  {RecipientName: "Dr. Lee", ExpiresInDays: ReportShareMaxDays + 1},
+	} {
+		if _, err := svc.Share(ctx, report, uuid.New(), &req); !errors.Is(err, ErrReportShareInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	pending := &models.Report{ID: uuid.New(), ChildID: report.ChildID, Status: "generating"}
+	if _, err := svc.Share(ctx, pending, uuid.New(), &models.CreateReportShareRequest{RecipientName: "Dr. Lee"}); !errors.Is(err, ErrReportShareInvalid) {
+		t.Errorf("pending report: %v", err)
+	}
+
+	share, err := svc.Share(ctx, report, uuid.New(), &models.CreateReportShareRequest{RecipientName: " Dr. Lee ", RecipientEmail: "Dr Lee <lee@clinic.example>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share.RecipientName != "Dr. Lee" || share.RecipientEmail.String != "lee@clinic.example" {
+		t.Errorf("share = %+v", share)
+	}
+	if want := now.AddDate(0, 0, ReportShareDefaultDays); !share.ExpiresAt.Equal(want) {
+		t.Errorf("expires %v, want %v", share.ExpiresAt, want)
+	}
+	id, sig := shareSig(t, share.URL)
+	if id != share.ID || !strings.HasPrefix(share.URL, "https://app.example/r/share/") {
+		t.Errorf("url = %s", share.URL)
+	}
+
+	if _, _, err := svc.Open(ctx, id, strings.Repeat("0", len(sig)), "10.0.0.1", "Safari"); !errors.Is(err, ErrReportShareNotFound) {
+		t.Errorf("bad signature: %v", err)
+	}
+	got, rc, err := svc.Open(ctx, id, sig, "10.0.0.1", "Safari")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	if got.ID != report.ID || len(repo.views) != 1 || repo.views[0].IPAddress.String != "10.0.0.1" {
+		t.Errorf("open: report %v, views %+v", got.ID, repo.views)
+	}
+	if _, rc, err := svc.Open(ctx, id, sig, "not-an-ip", ""); err != nil || repo.views[1].IPAddress.Valid {
+		t.Errorf("open with bad IP: %v, %+v", err, repo.views[1])
+	} else {
+		rc.Close()
+	}
+
+	shares, err := svc.List(ctx, report.ChildID, nil)
+	if err != nil || len(shares) != 1 || len(shares[0].Views) != 2 || shares[0].URL == "" {
+		t.Fatalf("list = %+v, %v", shares, err)
+	}
+
+	// Expired links stop working but can be extended, from now.
+	now = now.AddDate(0, 0, 20)
+	if _, _, err := svc.Open(ctx, id, sig, "", ""); !errors.Is(err, ErrReportShareUnavailable) {
+		t.Errorf("expired: %v", err)
+	}
+	if shares, _ := svc.List(ctx, report.ChildID, &report.ID); len(shares) != 1 || shares[0].URL != "" {
+		t.Errorf("expired share should have no URL: %+v", shares)
+	}
+	if _, err := svc.Extend(ctx, uuid.New(), id, 7); !errors.Is(err, ErrReportShareNotFound) {
+		t.Errorf("other child: %v", err)
+	}
+	extended, err := svc.Extend(ctx, report.ChildID, id, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.AddDate(0, 0, 7); !extended.ExpiresAt.Equal(want) {
+		t.Errorf("extended to %v, want %v", extended.ExpiresAt, want)
+	}
+	extended, _ = svc.Extend(ctx, report.ChildID, id, ReportShareMaxDays)
+	if want := now.AddDate(0, 0, ReportShareMaxDays); !extended.ExpiresAt.Equal(want) {
+		t.Errorf("extension not capped: %v, want %v", extended.ExpiresAt, want)
+	}
+	if _, rc, err := svc.Open(ctx, id, sig, "", ""); err != nil {
+		t.Errorf("extended link: %v", err)
+	} else {
+		rc.Close()
+	}
+
+	if err := svc.Revoke(ctx, report.ChildID, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Open(ctx, id, sig, "", ""); !errors.Is(err, ErrReportShareUnavailable) {
+		t.Errorf("revoked: %v", err)
+	}
+	if _, err := svc.Extend(ctx, report.ChildID, id, 7); !errors.Is(err, ErrReportShareInvalid) {
+		t.Errorf("extend revoked: %v", err)
+	}
+}
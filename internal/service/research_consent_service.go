@@ -0,0 +1,213 @@
+package service
+
+// research_consent_service.go — families opting into contributing
+// de-identified data to autism research.
+//
+// A parent picks one or more scopes (care logs, feature usage, retention),
+// reads the disclosure and types their name. The row written is the
+// consent artifact: disclosure text, version and SHA-256, signature, IP and
+// user agent. Each scope is one warehouse export dataset, and the export
+// queries only count families whose active consent covers the dataset, so
+// nobody is in a research file without having opted in.
+//
+// Withdrawing (or narrowing the scopes) is retroactive. The withdrawn
+// scopes are recorded on the consent row, and
+// WarehouseExportService.RebuildWithdrawn rewrites every published file of
+// those datasets without the family before the next nightly export.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+// CurrentResearchDisclosureVersion is the version of the research
+// disclosure. Bump it when the text changes materially; existing consents
+// stay valid (they were given against the text stored with them), but new
+// consents are signed against the new text.
+const CurrentResearchDisclosureVersion = 1
+
+// CurrentResearchDisclosureText is shown in Settings → Research before a
+// parent opts in, and stored verbatim with every consent.
+const CurrentResearchDisclosureText = `By opting in, you allow MyCareCompanion to include your family in de-identified statistics shared with autism researchers, for the scopes you select below.
+
+What researchers receive:
+  • Counts only — for example "40 sleep entries for 12 children aged 4-5 on March 1". Never names, birthdates, notes, photos, messages, or any ID that points back to your family or child.
+  • Ages as two-year bands and signup dates as months.
+  • No number that describes fewer than 10 children or families; smaller groups are left out entirely.
+
+What stays the same:
+  • Your logs, reports and AI insights work the same whether or not you take part.
+  • Your data is never sold and is never shared at the individual level.
+
+You can withdraw at any time in Settings. When you do, we rebuild every research file already published so your family is removed from past data as well as future data.`
+
+// CurrentResearchDisclosureSHA returns the SHA-256 of the current
+// disclosure text; the client echoes it back when granting consent.
+func CurrentResearchDisclosureSHA() string {
+	h := sha256.Sum256([]byte(CurrentResearchDisclosureText))
+	return hex.EncodeToString(h[:])
+}
+
+var (
+	ErrResearchScopeInvalid      = apperr.Validation("choose at least one research scope")
+	ErrResearchDisclosureChanged = errors.New("the research disclosure has changed; please review it again")
+	ErrResearchSignatureRequired = apperr.Validation("type your full name to sign")
+	ErrResearchConsentNotFound   = apperr.NotFound("no active research consent")
+)
+
+// ResearchScopeInfo describes a scope for the consent screen.
+type ResearchScopeInfo struct {
+	Scope       string `json:"scope"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+}
+
+// ResearchScopeInfos is every scope with its description, in display order.
+var ResearchScopeInfos = []ResearchScopeInfo{
+	{repository.ResearchScopeCareLogs, "Care log counts", "How many entries of each log type (sleep, behavior, medication…) were recorded per day, by age band."},
+	{repository.ResearchScopeFeatureUsage, "App usage", "Which app features your family used on a given day."},
+	{repository.ResearchScopeRetention, "Long-term use", "Whether your family was still logging in each month after signing up."},
+}
+
+// ResearchConsentInput is a parent's consent as submitted.
+type ResearchConsentInput struct {
+	Scopes          []string `json:"scopes"`
+	AcknowledgedSHA string   `json:"acknowledged_sha"`
+	SignatureName   string   `json:"signature_name"`
+}
+
+// ResearchConsentStatus is what the consent screen shows.
+type ResearchConsentStatus struct {
+	DisclosureVersion int                          `json:"disclosure_version"`
+	DisclosureSHA     string                       `json:"disclosure_sha"`
+	DisclosureText    string                       `json:"disclosure_text"`
+	Scopes            []ResearchScopeInfo          `json:"scopes"`
+	Active            *repository.ResearchConsent  `json:"active,omitempty"`
+	History           []repository.ResearchConsent `json:"history"`
+}
+
+// ResearchConsentService records families' research consents.
+type ResearchConsentService struct {
+	repo repository.ResearchConsentRepository
+}
+
+func NewResearchConsentService(repo repository.ResearchConsentRepository) *ResearchConsentService {
+	return &ResearchConsentService{repo: repo}
+}
+
+// Status returns the disclosure, the family's active consent and its
+// history.
+func (s *ResearchConsentService) Status(ctx context.Context, familyID uuid.UUID) (*ResearchConsentStatus, error) {
+	active, err := s.repo.Active(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repo.History(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	return &ResearchConsentStatus{
+		DisclosureVersion: CurrentResearchDisclosureVersion,
+		DisclosureSHA:     CurrentResearchDisclosureSHA(),
+		DisclosureText:    CurrentResearchDisclosureText,
+		Scopes:            ResearchScopeInfos,
+		Active:            active,
+		History:           history,
+	}, nil
+}
+
+// Grant records a parent's consent for the family, replacing any active
+// one. Scopes left out of a replacement are withdrawn.
+func (s *ResearchConsentService) Grant(ctx context.Context, familyID, userID uuid.UUID, in ResearchConsentInput, ip, userAgent string) (*repository.ResearchConsent, error) {
+	scopes, err := normalizeResearchScopes(in.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if in.AcknowledgedSHA != CurrentResearchDisclosureSHA() {
+		return nil, ErrResearchDisclosureChanged
+	}
+	name := strings.TrimSpace(in.SignatureName)
+	if name == "" {
+		return nil, ErrResearchSignatureRequired
+	}
+	c := &repository.ResearchConsent{
+		FamilyID:          familyID,
+		GrantedBy:         &userID,
+		Scopes:            scopes,
+		DisclosureVersion: CurrentResearchDisclosureVersion,
+		DisclosureSHA:     CurrentResearchDisclosureSHA(),
+		DisclosureText:    CurrentResearchDisclosureText,
+		SignatureName:     truncateRunes(name, 200),
+		IPAddress:         legalIP(ip),
+		UserAgent:         userAgent,
+	}
+	if err := s.repo.Grant(ctx, c); err != nil {
+		return nil, fmt.Errorf("grant research consent: %w", err)
+	}
+	c.DisclosureText = ""
+	return c, nil
+}
+
+// Withdraw ends the family's participation. The family drops out of future
+// exports at once and out of published files at the next rebuild.
+func (s *ResearchConsentService) Withdraw(ctx context.Context, familyID, userID uuid.UUID, reason string) (*repository.ResearchConsent, error) {
+	c, err := s.repo.Withdraw(ctx, familyID, userID, truncateRunes(strings.TrimSpace(reason), 500))
+	if err != nil {
+		return nil, fmt.Errorf("withdraw research consent: %w", err)
+	}
+	if c == nil {
+		return nil, ErrResearchConsentNotFound
+	}
+	return c, nil
+}
+
+// Get returns one consent artifact, including the text signed.
+func (s *ResearchConsentService) Get(ctx context.Context, id uuid.UUID) (*repository.ResearchConsent, error) {
+	c, err := s.repo.GetConsent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrResearchConsentNotFound
+	}
+	return c, nil
+}
+
+// Summary is participation across all families.
+func (s *ResearchConsentService) Summary(ctx context.Context) (*repository.ResearchConsentSummary, error) {
+	return s.repo.Summary(ctx)
+}
+
+// Recent returns the latest consents and withdrawals.
+func (s *ResearchConsentService) Recent(ctx context.Context, limit int) ([]repository.ResearchConsent, error) {
+	return s.repo.Recent(ctx, limit)
+}
+
+// normalizeResearchScopes checks scopes against ResearchScopes and returns
+// them de-duplicated in display order.
+func normalizeResearchScopes(scopes []string) ([]string, error) {
+	want := map[string]bool{}
+	for _, sc := range scopes {
+		want[strings.TrimSpace(sc)] = true
+	}
+	var out []string
+	for _, sc := range repository.ResearchScopes {
+		if want[sc] {
+			out = append(out, sc)
+			delete(want, sc)
+		}
+	}
+	if len(out) == 0 || len(want) > 0 {
+		return nil, ErrResearchScopeInvalid
+	}
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeResearchConsentRepo struct {
+	repository.ResearchConsentRepository
+	granted []*repository.ResearchConsent
+}
+
+func (f *fakeResearchConsentRepo) Grant(ctx context.Context, c *repository.ResearchConsent) error {
+	c.ID = uuid.New()
+	cp := *c
+	f.granted = append(f.granted, &cp)
+	return nil
+}
+
+func (f *fakeResearchConsentRepo) Withdraw(ctx context.Context, familyID, by uuid.UUID, reason string) (*repository.ResearchConsent, error) {
+	return nil, nil
+}
+
+func TestResearchConsentGrant(t *testing.T) {
+	repo := &fakeResearchConsentRepo{}
+	svc := NewResearchConsentService(repo)
+	ctx := context.Background()
+	family, user := uuid.New(), uuid.New()
+	sha := CurrentResearchDisclosureSHA()
+
+	bad := []struct {
+		in   ResearchConsentInput
+		want error
+	}{
+		{ResearchConsentInput{Scopes: nil, AcknowledgedSHA: sha, SignatureName: "Sam Lee"}, ErrResearchScopeInvalid},
+		{ResearchConsentInput{Scopes: []string{"photos"}, AcknowledgedSHA: sha, SignatureName: "Sam Lee"}, ErrResearchScopeInvalid},
+		{ResearchConsentInput{Scopes: []string{"retention"}, AcknowledgedSHA: "stale", SignatureName: "Sam Lee"}, ErrResearchDisclosureChanged},
+		{ResearchConsentInput{Scopes: []string{"retention"}, AcknowledgedSHA: sha, SignatureName: "  "}, ErrResearchSignatureRequired},
+	}
+	for _, c := range bad {
+		if _, err := svc.Grant(ctx, family, user, c.in, "", ""); !errors.Is(err, c.want) {
+			t.Errorf("%+v: err = %v, want %v", c.in, err, c.want)
+		}
+	}
+	if len(repo.granted) != 0 {
+		t.Fatalf("invalid consents were stored: %d", len(repo.granted))
+	}
+
+	in := ResearchConsentInput{
+		Scopes:          []string{"retention", "care_logs", "retention"},
+		AcknowledgedSHA: sha,
+		SignatureName:   " Sam Lee ",
+	}
+	if _, err := svc.Grant(ctx, family, user, in, "198.51.100.4:443", "test"); err != nil {
+		t.Fatal(err)
+	}
+	got := repo.granted[0]
+	if len(got.Scopes) != 2 || got.Scopes[0] != "care_logs" || got.Scopes[1] != "retention" {
+		t.Errorf("scopes = %v, want de-duplicated in display order", got.Scopes)
+	}
+	if got.DisclosureText != CurrentResearchDisclosureText || got.DisclosureSHA != sha {
+		t.Error("the artifact should store the disclosure signed")
+	}
+	if got.SignatureName != "Sam Lee" || got.IPAddress != "198.51.100.4" {
+		t.Errorf("signature %q, ip %q", got.SignatureName, got.IPAddress)
+	}
+
+	if _, err := svc.Withdraw(ctx, family, user, ""); !errors.Is(err, ErrResearchConsentNotFound) {
+		t.Errorf("withdraw without consent: err = %v", err)
+	}
+}
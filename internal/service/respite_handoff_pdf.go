@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"carecompanion/internal/models"
+)
+
+// RespiteHandoffPDF lays out the handoff document on portrait Letter:
+// emergency contacts first, where a sitter looks in a hurry, then
+// medications, allergies, calming, communication and anything else the
+// family wants them to know.
+func RespiteHandoffPDF(h *models.RespiteHandoff, timezone string) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetAutoPageBreak(true, 20)
+	setDisclaimerFooter(pdf)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, _ := pdf.GetPageSize()
+	lm, _, rm, _ := pdf.GetMargins()
+	width := pageW - lm - rm
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetTextColor(79, 70, 229)
+	pdf.CellFormat(0, 12, tr("Caring for "+h.ChildName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(55, 65, 81)
+	sub := fmt.Sprintf("Age %d", h.AgeYears)
+	if len(h.Conditions) > 0 {
+		sub += " - " + strings.Join(h.Conditions, ", ")
+	}
+	pdf.MultiCell(0, 6, tr(sub), "", "L", false)
+	pdf.SetTextColor(107, 114, 128)
+	generated := h.GeneratedAt
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		generated = generated.In(loc)
+	}
+	pdf.CellFormat(0, 6, "Prepared "+generated.Format("January 2, 2006 3:04 PM MST"), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	section := func(title string) {
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.SetTextColor(79, 70, 229)
+		pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+		pdf.Ln(2)
+		pdf.SetTextColor(31, 41, 55)
+	}
+	text := func(s string) {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.MultiCell(0, 5.5, tr(s), "", "L", false)
+	}
+	muted := func(s string) {
+		pdf.SetFont("Helvetica", "I", 10)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.MultiCell(0, 5.5, tr(s), "", "L", false)
+		pdf.SetTextColor(31, 41, 55)
+	}
+	bullet := func(bold, rest string) {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(5, 5.5, "-", "", 0, "L", false, 0, "")
+		line := bold
+		if rest != "" {
+			line += "  " + rest
+		}
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.MultiCell(width-5, 5.5, tr(line), "", "L", false)
+		pdf.Ln(1)
+	}
+
+	section("Emergency contacts")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetTextColor(185, 28, 28)
+	pdf.CellFormat(0, 6, "In an emergency, call 911 first.", "", 1, "L", false, 0, "")
+	pdf.SetTextColor(31, 41, 55)
+	if len(h.EmergencyContacts) == 0 {
+		muted("No emergency contacts added yet.")
+	}
+	for _, c := range h.EmergencyContacts {
+		name := c.Name
+		if c.Relationship != "" {
+			name += " (" + c.Relationship + ")"
+		}
+		rest := c.Phone
+		if c.Notes != "" {
+			rest += " - " + c.Notes
+		}
+		bullet(name, rest)
+	}
+
+	section("Medications")
+	if len(h.Medications) == 0 {
+		muted("No current medications.")
+	}
+	for _, m := range h.Medications {
+		when := "As needed"
+		if !m.AsNeeded {
+			when = strings.Join(m.Times, ", ")
+		}
+		rest := m.Dose + " - " + when
+		if m.Instructions != "" {
+			rest += ". " + m.Instructions
+		}
+		bullet(m.Name, rest)
+	}
+
+	section("Allergies")
+	if len(h.Allergies) == 0 {
+		muted("No known allergies recorded.")
+	}
+	for _, a := range h.Allergies {
+		rest := ""
+		if a.LastReaction != "" {
+			rest = "(last reaction " + a.LastReaction + ")"
+		}
+		bullet(a.Description, rest)
+	}
+
+	section("Calming strategies")
+	if h.CalmingNotes != "" {
+		text(h.CalmingNotes)
+		pdf.Ln(2)
+	}
+	if len(h.CalmingStrategies) > 0 {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.CellFormat(0, 6, "Used most often lately:", "", 1, "L", false, 0, "")
+		pdf.SetTextColor(31, 41, 55)
+		for _, st := range h.CalmingStrategies {
+			bullet(st.Strategy, "")
+		}
+	}
+	if h.CalmingNotes == "" && len(h.CalmingStrategies) == 0 {
+		muted("Nothing recorded yet.")
+	}
+
+	section("Communication")
+	if h.CommunicationNotes != "" {
+		text(h.CommunicationNotes)
+	} else {
+		muted("Nothing recorded yet.")
+	}
+
+	if h.CareNotes != "" {
+		section("Other notes")
+		text(h.CareNotes)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrHandoffInvalid  = apperr.Validation("invalid handoff profile")
+	ErrHandoffNotFound = apperr.NotFound("handoff not found")
+	ErrHandoffLinkTTL  = errors.New("link lifetime must be between 1 hour and 14 days")
+)
+
+const (
+	// handoffCalmingDays is how far back the logs are read for calming
+	// strategies in use.
+	handoffCalmingDays = 90
+	// handoffReactionDays is how far back logged allergic reactions are
+	// listed; a reaction stays worth knowing about much longer than a
+	// calming strategy stays current.
+	handoffReactionDays = 365
+	// handoffMaxCalmingStrategies caps the strategies listed.
+	handoffMaxCalmingStrategies = 8
+	maxEmergencyContacts        = 10
+	maxHandoffNoteLen           = 4000
+
+	// HandoffLinkDefaultTTL is how long a shared handoff link works when
+	// no lifetime is asked for: a weekend away.
+	HandoffLinkDefaultTTL = 72 * time.Hour
+	// HandoffLinkMaxTTL is the longest a shared handoff link can work.
+	HandoffLinkMaxTTL = 14 * 24 * time.Hour
+)
+
+type handoffChildSource interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error)
+}
+
+type handoffMedicationSource interface {
+	GetByChildID(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Medication, error)
+}
+
+type handoffLogSource interface {
+	GetDietLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.DietLog, error)
+	GetSensoryLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SensoryLog, error)
+}
+
+// RespiteHandoffService assembles the handoff document a family gives a
+// babysitter or respite carer — medications and times, allergies, calming
+// strategies, communication notes and emergency contacts — and the
+// expiring link it is shared by.
+type RespiteHandoffService struct {
+	repo          repository.HandoffRepository
+	children      handoffChildSource
+	meds          handoffMedicationSource
+	logs          handoffLogSource
+	appURL        string
+	signingSecret []byte
+	now           func() time.Time
+	// restrictions, when set, puts the child's allergy and restriction
+	// registry at the top of the allergies.
+	restrictions handoffRestrictionSource
+}
+
+type handoffRestrictionSource interface {
+	List(ctx context.Context, childID uuid.UUID) ([]models.ChildRestriction, error)
+}
+
+// NewRespiteHandoffService creates the handoff service. signingSecret
+// signs shared handoff links.
+func NewRespiteHandoffService(repo repository.HandoffRepository, children handoffChildSource, meds handoffMedicationSource,
+	logs handoffLogSource, appURL, signingSecret string) *RespiteHandoffService {
+	return &RespiteHandoffService{repo: repo, children: children, meds: meds, logs: logs,
+		appURL: appURL, signingSecret: []byte(signingSecret), now: time.Now}
+}
+
+// SetRestrictions lists the child's allergy and restriction registry in
+// the handoff.
+func (s *RespiteHandoffService) SetRestrictions(restrictions handoffRestrictionSource) {
+	s.restrictions = restrictions
+}
+
+func invalidHandoff(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrHandoffInvalid, fmt.Sprintf(format, args...))
+}
+
+func handoffNote(s string) models.NullString {
+	s = strings.TrimSpace(s)
+	return models.NullString{NullString: sql.NullString{String: s, Valid: s != ""}}
+}
+
+// Profile returns the child's handoff profile, empty when the family
+// hasn't filled it in.
+func (s *RespiteHandoffService) Profile(ctx context.Context, childID uuid.UUID) (*models.HandoffProfile, error) {
+	p, err := s.repo.GetProfile(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = &models.HandoffProfile{ChildID: childID}
+	}
+	if p.EmergencyContacts == nil {
+		p.EmergencyContacts = models.EmergencyContacts{}
+	}
+	return p, nil
+}
+
+// SaveProfile validates and replaces the child's handoff profile.
+func (s *RespiteHandoffService) SaveProfile(ctx context.Context, childID, by uuid.UUID, req *models.HandoffProfileRequest) (*models.HandoffProfile, error) {
+	if len(req.EmergencyContacts) > maxEmergencyContacts {
+		return nil, invalidHandoff("at most %d emergency contacts", maxEmergencyContacts)
+	}
+	contacts := models.EmergencyContacts{}
+	for i, c := range req.EmergencyContacts {
+		c.Name, c.Phone = strings.TrimSpace(c.Name), strings.TrimSpace(c.Phone)
+		c.Relationship, c.Notes = strings.TrimSpace(c.Relationship), strings.TrimSpace(c.Notes)
+		switch {
+		case c.Name == "" || c.Phone == "":
+			return nil, invalidHandoff("emergency contact %d needs a name and phone number", i+1)
+		case len(c.Name) > 100 || len(c.Relationship) > 100 || len(c.Phone) > 40:
+			return nil, invalidHandoff("emergency contact %d has a field that is too long", i+1)
+		case len(c.Notes) > 500:
+			return nil, invalidHandoff("emergency contact notes must be 500 characters or fewer")
+		}
+		contacts = append(contacts, c)
+	}
+	for name, note := range map[string]string{
+		"communication_notes": req.CommunicationNotes, "calming_notes": req.CalmingNotes,
+		"allergy_notes": req.AllergyNotes, "care_notes": req.CareNotes,
+	} {
+		if len(note) > maxHandoffNoteLen {
+			return nil, invalidHandoff("%s must be %d characters or fewer", name, maxHandoffNoteLen)
+		}
+	}
+	p := &models.HandoffProfile{
+		ChildID:            childID,
+		EmergencyContacts:  contacts,
+		CommunicationNotes: handoffNote(req.CommunicationNotes),
+		CalmingNotes:       handoffNote(req.CalmingNotes),
+		AllergyNotes:       handoffNote(req.AllergyNotes),
+		CareNotes:          handoffNote(req.CareNotes),
+		UpdatedBy:          models.NullUUID{UUID: by, Valid: true},
+	}
+	if err := s.repo.SaveProfile(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Build assembles the handoff document for child from its handoff
+// profile, current medications and recent logs.
+func (s *RespiteHandoffService) Build(ctx context.Context, child *models.Child) (*models.RespiteHandoff, error) {
+	profile, err := s.Profile(ctx, child.ID)
+	if err != nil {
+		return nil, err
+	}
+	meds, err := s.meds.GetByChildID(ctx, child.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	diet, err := s.logs.GetDietLogs(ctx, child.ID, today.AddDate(0, 0, -handoffReactionDays), today)
+	if err != nil {
+		return nil, err
+	}
+	sensory, err := s.logs.GetSensoryLogs(ctx, child.ID, today.AddDate(0, 0, -handoffCalmingDays), today)
+	if err != nil {
+		return nil, err
+	}
+	var registry []models.ChildRestriction
+	if s.restrictions != nil {
+		if registry, err = s.restrictions.List(ctx, child.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	h := &models.RespiteHandoff{
+		ChildID:            child.ID,
+		ChildName:          child.FullName(),
+		AgeYears:           child.Age(),
+		Conditions:         []string{},
+		Medications:        handoffMedications(meds, today),
+		Allergies:          append(handoffRegistryAllergies(registry), handoffAllergies(profile.AllergyNotes.String, diet)...),
+		CalmingStrategies:  handoffCalmingStrategies(sensory),
+		CalmingNotes:       profile.CalmingNotes.String,
+		CommunicationNotes: profile.CommunicationNotes.String,
+		CareNotes:          profile.CareNotes.String,
+		EmergencyContacts:  profile.EmergencyContacts,
+		GeneratedAt:        now,
+	}
+	for _, c := range child.Conditions {
+		if c.IsActive {
+			h.Conditions = append(h.Conditions, c.ConditionName)
+		}
+	}
+	return h, nil
+}
+
+// handoffMedications lists the medications being taken on today, each
+// with its times, scheduled ones first by their earliest time.
+func handoffMedications(meds []models.Medication, today time.Time) []models.HandoffMedication {
+	type sortable struct {
+		med   models.HandoffMedication
+		first string
+	}
+	var list []sortable
+	for _, m := range meds {
+		if m.EndDate.Valid && m.EndDate.Time.Before(today) {
+			continue
+		}
+		hm := models.HandoffMedication{
+			Name:         m.Name,
+			Dose:         strings.TrimSpace(m.Dosage + " " + m.DosageUnit),
+			Instructions: m.Instructions.String,
+			Times:        []string{},
+		}
+		first := "~"
+		for _, sch := range m.Schedules {
+			if !sch.IsActive {
+				continue
+			}
+			label, key := handoffScheduleTime(sch)
+			hm.Times = append(hm.Times, label)
+			if key < first {
+				first = key
+			}
+		}
+		hm.AsNeeded = len(hm.Times) == 0
+		list = append(list, sortable{hm, first})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].first < list[j].first })
+	out := make([]models.HandoffMedication, len(list))
+	for i, l := range list {
+		out[i] = l.med
+	}
+	return out
+}
+
+// handoffTimesOfDay labels schedules without a clock time and places them
+// in the day.
+var handoffTimesOfDay = map[models.MedicationTimeOfDay]struct{ label, key string }{
+	models.MedicationTimeOfDayMorning:       {"Morning", "08:00"},
+	models.MedicationTimeOfDayWithBreakfast: {"With breakfast", "08:00"},
+	models.MedicationTimeOfDayWithLunch:     {"With lunch", "12:00"},
+	models.MedicationTimeOfDayAfternoon:     {"Afternoon", "14:00"},
+	models.MedicationTimeOfDayWithDinner:    {"With dinner", "18:00"},
+	models.MedicationTimeOfDayEvening:       {"Evening", "18:00"},
+	models.MedicationTimeOfDayBedtime:       {"Bedtime", "20:00"},
+	models.MedicationTimeOfDayNight:         {"Night", "21:00"},
+}
+
+// handoffScheduleTime describes when a schedule is given, e.g. "8:00 AM"
+// or "With breakfast (Mon, Wed)", and a "HH:MM" key to sort it by.
+func handoffScheduleTime(sch models.MedicationSchedule) (label, key string) {
+	tod, ok := handoffTimesOfDay[sch.TimeOfDay]
+	if !ok {
+		tod.label, tod.key = string(sch.TimeOfDay), "12:00"
+	}
+	label, key = tod.label, tod.key
+	if sch.ScheduledTime.Valid {
+		if t, err := time.Parse("15:04:05", sch.ScheduledTime.String); err == nil {
+			label, key = t.Format("3:04 PM"), t.Format("15:04")
+		}
+	}
+	if len(sch.DaysOfWeek) > 0 && len(sch.DaysOfWeek) < 7 {
+		label += " (" + routineDaysLabel(sch.DaysOfWeek) + ")"
+	}
+	return label, key
+}
+
+// handoffRegistryAllergies lists the registry's entries, severe ones
+// first.
+func handoffRegistryAllergies(registry []models.ChildRestriction) []models.HandoffAllergy {
+	out := []models.HandoffAllergy{}
+	for _, severe := range []bool{true, false} {
+		for _, r := range registry {
+			if (r.Severity.String == models.RestrictionSevere) != severe {
+				continue
+			}
+			desc := restrictionLabel(r.Item, r.Kind, r.Severity.String)
+			if r.Notes.Valid {
+				desc += " — " + r.Notes.String
+			}
+			out = append(out, models.HandoffAllergy{Description: desc})
+		}
+	}
+	return out
+}
+
+// handoffAllergies lists the family's allergy notes, a line each, then
+// the distinct reactions in the diet log, latest first.
+func handoffAllergies(notes string, diet []models.DietLog) []models.HandoffAllergy {
+	out := []models.HandoffAllergy{}
+	for _, line := range strings.Split(notes, "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(line, "-*• ")); line != "" {
+			out = append(out, models.HandoffAllergy{Description: line})
+		}
+	}
+	latest := map[string]models.HandoffAllergy{}
+	for _, l := range diet {
+		if !l.AllergicReaction {
+			continue
+		}
+		desc := strings.TrimSpace(l.ReactionDetails.String)
+		if desc == "" && len(l.FoodsEaten) > 0 {
+			desc = "Reaction after eating " + strings.Join(l.FoodsEaten, ", ")
+		}
+		if desc == "" {
+			desc = "Allergic reaction (no details logged)"
+		}
+		key := strings.ToLower(desc)
+		date := l.LogDate.Format("2006-01-02")
+		if prev, ok := latest[key]; !ok || date > prev.LastReaction {
+			latest[key] = models.HandoffAllergy{Description: desc, LastReaction: date}
+		}
+	}
+	var reactions []models.HandoffAllergy
+	for _, a := range latest {
+		reactions = append(reactions, a)
+	}
+	sort.Slice(reactions, func(i, j int) bool {
+		if reactions[i].LastReaction != reactions[j].LastReaction {
+			return reactions[i].LastReaction > reactions[j].LastReaction
+		}
+		return reactions[i].Description < reactions[j].Description
+	})
+	return append(out, reactions...)
+}
+
+// handoffCalmingStrategies ranks the calming strategies in the sensory
+// logs by how often they were used.
+func handoffCalmingStrategies(sensory []models.SensoryLog) []models.HandoffCalmingStrategy {
+	counts := map[string]*models.HandoffCalmingStrategy{}
+	var list []*models.HandoffCalmingStrategy
+	for _, l := range sensory {
+		for _, st := range l.CalmingStrategiesUsed {
+			st = strings.TrimSpace(st)
+			if st == "" {
+				continue
+			}
+			key := strings.ToLower(st)
+			if counts[key] == nil {
+				counts[key] = &models.HandoffCalmingStrategy{Strategy: st}
+				list = append(list, counts[key])
+			}
+			counts[key].TimesUsed++
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].TimesUsed > list[j].TimesUsed })
+	out := []models.HandoffCalmingStrategy{}
+	for i := 0; i < len(list) && i < handoffMaxCalmingStrategies; i++ {
+		out = append(out, *list[i])
+	}
+	return out
+}
+
+// ShareURL returns a link to the child's handoff PDF that works without
+// signing in for ttl (HandoffLinkDefaultTTL when zero). The document is
+// built when the link is opened, so it shows the latest medications and
+// notes.
+func (s *RespiteHandoffService) ShareURL(childID uuid.UUID, ttl time.Duration) (url string, exp time.Time, err error) {
+	if ttl == 0 {
+		ttl = HandoffLinkDefaultTTL
+	}
+	if ttl < time.Hour || ttl > HandoffLinkMaxTTL {
+		return "", time.Time{}, ErrHandoffLinkTTL
+	}
+	exp = s.now().Add(ttl)
+	expUnix := exp.Unix()
+	path := fmt.Sprintf("/handoff/signed/%s?exp=%d&sig=%s", childID, expUnix, s.sign(childID, expUnix))
+	return s.appURL + path, exp, nil
+}
+
+// VerifySignedHandoff checks a shared handoff link's expiry and
+// signature.
+func (s *RespiteHandoffService) VerifySignedHandoff(childID uuid.UUID, expUnix int64, sig string) error {
+	if s.now().Unix() > expUnix {
+		return fmt.Errorf("signed url expired")
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("bad signature encoding")
+	}
+	want, _ := hex.DecodeString(s.sign(childID, expUnix))
+	if !hmac.Equal(got, want) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// SignedHandoff loads the child behind a verified shared link and builds
+// the handoff.
+func (s *RespiteHandoffService) SignedHandoff(ctx context.Context, childID uuid.UUID) (*models.RespiteHandoff, error) {
+	child, err := s.children.GetByID(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	if child == nil || !child.IsActive {
+		return nil, ErrHandoffNotFound
+	}
+	return s.Build(ctx, child)
+}
+
+func (s *RespiteHandoffService) sign(childID uuid.UUID, expUnix int64) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte("respite_handoff|" + childID.String() + "|" + strconv.FormatInt(expUnix, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeHandoffRepo struct {
+	profiles map[uuid.UUID]models.HandoffProfile
+}
+
+func (f *fakeHandoffRepo) GetProfile(ctx context.Context, childID uuid.UUID) (*models.HandoffProfile, error) {
+	if p, ok := f.profiles[childID]; ok {
+		return &p, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeHandoffRepo) SaveProfile(ctx context.Context, p *models.HandoffProfile) error {
+	now := time.Now()
+	p.UpdatedAt = &now
+	f.profiles[p.ChildID] = *p
+	return nil
+}
+
+type fakeHandoffSources struct {
+	child   *models.Child
+	meds    []models.Medication
+	diet    []models.DietLog
+	sensory []models.SensoryLog
+}
+
+func (f *fakeHandoffSources) GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error) {
+	if f.child != nil && f.child.ID == id {
+		return f.child, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeHandoffSources) GetByChildID(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Medication, error) {
+	return f.meds, nil
+}
+
+func (f *fakeHandoffSources) GetDietLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.DietLog, error) {
+	return f.diet, nil
+}
+
+func (f *fakeHandoffSources) GetSensoryLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SensoryLog, error) {
+	return f.sensory, nil
+}
+
+func nullStr(s string) models.NullString {
+	return models.NullString{NullString: sql.NullString{String: s, Valid: s != ""}}
+}
+
+func TestRespiteHandoffBuild(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	child := &models.Child{ID: uuid.New(), FirstName: "Sam", DateOfBirth: now.AddDate(-8, 0, 0), IsActive: true,
+		Conditions: []models.ChildCondition{{ConditionName: "Autism", IsActive: true}, {ConditionName: "Asthma"}}}
+	src := &fakeHandoffSources{
+		child: child,
+		meds: []models.Medication{
+			{Name: "Melatonin", Dosage: "3", DosageUnit: "mg", Schedules: []models.MedicationSchedule{
+				{TimeOfDay: models.MedicationTimeOfDayBedtime, IsActive: true}}},
+			{Name: "Albuterol", Dosage: "2", DosageUnit: "puffs", Instructions: nullStr("Before outdoor play if wheezy")},
+			{Name: "Guanfacine", Dosage: "1", DosageUnit: "mg", Schedules: []models.MedicationSchedule{
+				{TimeOfDay: models.MedicationTimeOfDayMorning, ScheduledTime: nullStr("07:30:00"), IsActive: true},
+				{TimeOfDay: models.MedicationTimeOfDayEvening, IsActive: true, DaysOfWeek: []int{6, 0}}}},
+			{Name: "Amoxicillin", Dosage: "250", DosageUnit: "mg",
+				EndDate: models.NullTime{NullTime: sql.NullTime{Time: now.AddDate(0, 0, -3), Valid: true}}},
+		},
+		diet: []models.DietLog{
+			{LogDate: now.AddDate(0, -4, 0), AllergicReaction: true, ReactionDetails: nullStr("Hives after cashews")},
+			{LogDate: now.AddDate(0, -1, 0), AllergicReaction: true, ReactionDetails: nullStr("hives after cashews")},
+			{LogDate: now.AddDate(0, -2, 0), AllergicReaction: true, FoodsEaten: models.StringArray{"kiwi"}},
+			{LogDate: now.AddDate(0, 0, -1), FoodsEaten: models.StringArray{"pasta"}},
+		},
+		sensory: []models.SensoryLog{
+			{CalmingStrategiesUsed: models.StringArray{"Weighted blanket", "Headphones"}},
+			{CalmingStrategiesUsed: models.StringArray{"headphones", "Swing"}},
+			{CalmingStrategiesUsed: models.StringArray{"Headphones "}},
+		},
+	}
+	svc := NewRespiteHandoffService(&fakeHandoffRepo{profiles: map[uuid.UUID]models.HandoffProfile{}}, src, src, src, "https://app.example", "secret")
+	svc.now = func() time.Time { return now }
+
+	for name, req := range map[string]models.HandoffProfileRequest{
+		"no phone":  {EmergencyContacts: []models.EmergencyContact{{Name: "Grandma"}}},
+		"long note": {CareNotes: strings.Repeat("x", maxHandoffNoteLen+1)},
+	} {
+		if _, err := svc.SaveProfile(ctx, child.ID, uuid.New(), &req); !errors.Is(err, ErrHandoffInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := svc.SaveProfile(ctx, child.ID, uuid.New(), &models.HandoffProfileRequest{
+		EmergencyContacts:  []models.EmergencyContact{{Name: " Grandma ", Relationship: "Grandmother", Phone: "555-0100"}},
+		CommunicationNotes: "Uses an AAC tablet; give him time to answer.",
+		AllergyNotes:       "- Peanuts (EpiPen in the red bag)\n\n- Latex",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := svc.Build(ctx, child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Conditions) != 1 || h.EmergencyContacts[0].Name != "Grandma" || h.CommunicationNotes == "" {
+		t.Errorf("profile: %+v", h)
+	}
+	var names []string
+	for _, m := range h.Medications {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "Guanfacine,Melatonin,Albuterol" {
+		t.Fatalf("medications %v", names)
+	}
+	if g := h.Medications[0]; strings.Join(g.Times, "|") != "7:30 AM|Evening (Sun, Sat)" || g.Dose != "1 mg" {
+		t.Errorf("guanfacine %+v", g)
+	}
+	if a := h.Medications[2]; !a.AsNeeded || a.Instructions == "" {
+		t.Errorf("albuterol %+v", a)
+	}
+	var allergies []string
+	for _, a := range h.Allergies {
+		allergies = append(allergies, a.Description+"@"+a.LastReaction)
+	}
+	want := "Peanuts (EpiPen in the red bag)@|Latex@|hives after cashews@2026-09-17|Reaction after eating kiwi@2026-08-17"
+	if strings.Join(allergies, "|") != want {
+		t.Errorf("allergies %v", allergies)
+	}
+	if len(h.CalmingStrategies) != 3 || h.CalmingStrategies[0].Strategy != "Headphones" || h.CalmingStrategies[0].TimesUsed != 3 {
+		t.Errorf("calming %+v", h.CalmingStrategies)
+	}
+
+	pdf, err := RespiteHandoffPDF(h, "America/Chicago")
+	if err != nil || !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Fatalf("pdf: %v", err)
+	}
+}
+
+func TestRespiteHandoffShareLink(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	child := &models.Child{ID: uuid.New(), FirstName: "Sam", IsActive: true}
+	src := &fakeHandoffSources{child: child}
+	svc := NewRespiteHandoffService(&fakeHandoffRepo{profiles: map[uuid.UUID]models.HandoffProfile{}}, src, src, src, "https://app.example", "secret")
+	svc.now = func() time.Time { return now }
+
+	for _, ttl := range []time.Duration{30 * time.Minute, HandoffLinkMaxTTL + time.Hour} {
+		if _, _, err := svc.ShareURL(child.ID, ttl); !errors.Is(err, ErrHandoffLinkTTL) {
+			t.Errorf("ttl %v: %v", ttl, err)
+		}
+	}
+	link, exp, err := svc.ShareURL(child.ID, 0)
+	if err != nil || !exp.Equal(now.Add(HandoffLinkDefaultTTL)) {
+		t.Fatalf("share: %v %v", exp, err)
+	}
+	u, _ := url.Parse(link)
+	if u.Path != "/handoff/signed/"+child.ID.String() {
+		t.Errorf("path %s", u.Path)
+	}
+	expUnix, _ := strconv.ParseInt(u.Query().Get("exp"), 10, 64)
+	sig := u.Query().Get("sig")
+	if err := svc.VerifySignedHandoff(child.ID, expUnix, sig); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	if svc.VerifySignedHandoff(uuid.New(), expUnix, sig) == nil || svc.VerifySignedHandoff(child.ID, expUnix+3600, sig) == nil {
+		t.Error("tampered link verified")
+	}
+	svc.now = func() time.Time { return exp.Add(time.Second) }
+	if svc.VerifySignedHandoff(child.ID, expUnix, sig) == nil {
+		t.Error("expired link verified")
+	}
+	if _, err := svc.SignedHandoff(context.Background(), uuid.New()); !errors.Is(err, ErrHandoffNotFound) {
+		t.Errorf("unknown child: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"carecompanion/internal/repository"
+)
+
+// drillKeyTables are row-counted in the restored copy and compared with
+// live. They are the tables a real restore would be judged on.
+var drillKeyTables = []string{"admin_users", "app_users", "families", "children", "family_subscriptions"}
+
+// drillMinRowRatio is how much of live's row count a key table must hold in
+// the restored copy. The snapshot is up to a day old, so a little growth
+// since is expected; a restore missing more than that is not.
+const drillMinRowRatio = 0.9
+
+// drillMaxAuditLag bounds how far the newest admin_audit_log row in the
+// restored copy may trail the snapshot time. A larger gap means the
+// snapshot holds stale data even though RDS reported it available.
+const drillMaxAuditLag = 7 * 24 * time.Hour
+
+// DrillOptions controls one restore drill.
+type DrillOptions struct {
+	// ScratchInstance is the instance to restore into; it must not exist.
+	ScratchInstance string
+	Restore         RDSRestoreOptions
+	// Timeout bounds the wait for the scratch instance to come up.
+	Timeout time.Duration
+	// PollInterval defaults to 30s.
+	PollInterval time.Duration
+	// Keep leaves the scratch instance running for manual inspection.
+	Keep  bool
+	RunBy string
+	// Connect opens the restored database at host:port with the live
+	// credentials (a snapshot restore keeps the master user).
+	Connect func(ctx context.Context, host string, port int) (*sql.DB, error)
+	// Logf reports progress; defaults to log.Printf.
+	Logf func(format string, args ...interface{})
+}
+
+// RunRestoreDrill restores the newest available snapshot into a scratch
+// instance, runs sanity queries against it, records the result in
+// restore_drills and deletes the scratch instance. The returned drill is
+// recorded even when err is non-nil; err is only for failures to run the
+// drill at all, failed checks just set Status to failed.
+func (s *BackupService) RunRestoreDrill(ctx context.Context, opts DrillOptions) (*repository.RestoreDrill, error) {
+	if !s.SnapshotsEnabled() {
+		return nil, ErrSnapshotsDisabled
+	}
+	logf := opts.Logf
+	if logf == nil {
+		logf = log.Printf
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 30 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 90 * time.Minute
+	}
+
+	if _, err := s.SyncSnapshots(ctx); err != nil {
+		return nil, err
+	}
+	snap, err := s.repo.Latest(ctx, repository.BackupKindSnapshot, repository.BackupStatusAvailable)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, ErrNoSnapshot
+	}
+	snapAt := snap.StartedAt
+	drill := &repository.RestoreDrill{
+		SnapshotIdentifier: snap.Identifier,
+		SnapshotCreatedAt:  &snapAt,
+		ScratchInstance:    opts.ScratchInstance,
+		RunBy:              opts.RunBy,
+	}
+	if err := s.repo.CreateDrill(ctx, drill); err != nil {
+		return nil, fmt.Errorf("record drill: %w", err)
+	}
+	logf("Restoring snapshot %s (taken %s) into %s", snap.Identifier, snapAt.UTC().Format(time.RFC3339), opts.ScratchInstance)
+
+	runErr := s.runDrill(ctx, drill, opts, logf)
+	drill.Status = repository.DrillStatusPassed
+	if runErr != nil {
+		drill.Error = runErr.Error()
+	}
+	for _, c := range drill.Checks {
+		if !c.OK {
+			drill.Status = repository.DrillStatusFailed
+		}
+	}
+	if runErr != nil || len(drill.Checks) == 0 {
+		drill.Status = repository.DrillStatusFailed
+	}
+	// Record with a fresh context so an interrupted drill still lands.
+	recCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.repo.FinishDrill(recCtx, drill); err != nil {
+		return drill, fmt.Errorf("record drill result: %w", err)
+	}
+	return drill, nil
+}
+
+func (s *BackupService) runDrill(ctx context.Context, drill *repository.RestoreDrill, opts DrillOptions, logf func(string, ...interface{})) error {
+	started := s.now()
+	if opts.Restore.Tags == nil {
+		opts.Restore.Tags = map[string]string{}
+	}
+	opts.Restore.Tags["purpose"] = "restore-drill"
+	if _, err := s.rds.RestoreInstance(ctx, drill.SnapshotIdentifier, opts.ScratchInstance, opts.Restore); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	if !opts.Keep {
+		defer func() {
+			delCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := s.rds.DeleteInstance(delCtx, opts.ScratchInstance); err != nil {
+				logf("WARNING: could not delete scratch instance %s: %v", opts.ScratchInstance, err)
+			} else {
+				logf("Scratch instance %s deleting", opts.ScratchInstance)
+			}
+		}()
+	}
+
+	inst, err := s.waitAvailable(ctx, opts, logf)
+	if err != nil {
+		return err
+	}
+	drill.RestoreSeconds = int(s.now().Sub(started).Seconds())
+	logf("Scratch instance available at %s:%d after %ds", inst.Address, inst.Port, drill.RestoreSeconds)
+
+	restored, err := opts.Connect(ctx, inst.Address, inst.Port)
+	if err == nil {
+		err = restored.PingContext(ctx)
+		defer restored.Close()
+	}
+	if err != nil {
+		drill.Checks = append(drill.Checks, repository.DrillCheck{Name: "connect", Detail: err.Error()})
+		return nil
+	}
+	drill.Checks = append(drill.Checks, repository.DrillCheck{Name: "connect", OK: true, Detail: "ok"})
+	drill.Checks = append(drill.Checks, s.drillChecks(ctx, restored, *drill.SnapshotCreatedAt)...)
+	for _, c := range drill.Checks {
+		logf("  %-28s ok=%-5t %s", c.Name, c.OK, c.Detail)
+	}
+	return nil
+}
+
+func (s *BackupService) waitAvailable(ctx context.Context, opts DrillOptions, logf func(string, ...interface{})) (*RDSInstance, error) {
+	deadline := s.now().Add(opts.Timeout)
+	last := ""
+	for {
+		inst, err := s.rds.DescribeInstance(ctx, opts.ScratchInstance)
+		if err != nil {
+			return nil, fmt.Errorf("describe scratch instance: %w", err)
+		}
+		if inst.Status != last {
+			logf("Scratch instance status: %s", inst.Status)
+			last = inst.Status
+		}
+		switch inst.Status {
+		case "available":
+			return inst, nil
+		case "failed", "incompatible-restore", "incompatible-parameters", "incompatible-network":
+			return nil, fmt.Errorf("scratch instance entered status %s", inst.Status)
+		}
+		if s.now().After(deadline) {
+			return nil, fmt.Errorf("scratch instance not available after %s (status %s)", opts.Timeout, inst.Status)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.PollInterval):
+		}
+	}
+}
+
+// drillChecks compares the restored copy with live. Details carry counts
+// only, never row data.
+func (s *BackupService) drillChecks(ctx context.Context, restored *sql.DB, snapAt time.Time) []repository.DrillCheck {
+	var checks []repository.DrillCheck
+	const tableCountSQL = `SELECT COUNT(*) FROM information_schema.tables
+        WHERE table_schema = 'public' AND table_type = 'BASE TABLE'`
+
+	var liveTables, restoredTables int
+	c := repository.DrillCheck{Name: "schema"}
+	if err := s.db.QueryRowContext(ctx, tableCountSQL).Scan(&liveTables); err != nil {
+		c.Detail = "live: " + err.Error()
+	} else if err := restored.QueryRowContext(ctx, tableCountSQL).Scan(&restoredTables); err != nil {
+		c.Detail = err.Error()
+	} else {
+		// Migrations run after the snapshot add tables, so only fewer
+		// than live is suspicious if it's more than a handful.
+		c.OK = restoredTables > 0 && restoredTables >= liveTables-3
+		c.Detail = fmt.Sprintf("%d tables (live %d)", restoredTables, liveTables)
+	}
+	checks = append(checks, c)
+
+	for _, table := range drillKeyTables {
+		q := "SELECT COUNT(*) FROM " + table
+		c := repository.DrillCheck{Name: "rows:" + table}
+		var live, got int64
+		if err := s.db.QueryRowContext(ctx, q).Scan(&live); err != nil {
+			c.Detail = "live: " + err.Error()
+		} else if err := restored.QueryRowContext(ctx, q).Scan(&got); err != nil {
+			c.Detail = err.Error()
+		} else {
+			c.OK = float64(got) >= drillMinRowRatio*float64(live) && (live == 0 || got > 0)
+			c.Detail = fmt.Sprintf("%d rows (live %d)", got, live)
+		}
+		checks = append(checks, c)
+	}
+
+	c = repository.DrillCheck{Name: "freshness"}
+	var newest sql.NullTime
+	if err := restored.QueryRowContext(ctx, `SELECT MAX(created_at) FROM admin_audit_log`).Scan(&newest); err != nil {
+		c.Detail = err.Error()
+	} else if !newest.Valid {
+		c.Detail = "admin_audit_log is empty"
+	} else {
+		lag := snapAt.Sub(newest.Time)
+		c.OK = lag <= drillMaxAuditLag
+		c.Detail = fmt.Sprintf("newest audit entry %s before snapshot", lag.Round(time.Minute))
+	}
+	return append(checks, c)
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrRestrictionNotFound = apperr.NotFound("restriction not found")
+	ErrRestrictionInvalid  = apperr.Validation("invalid restriction")
+	ErrRestrictionExists   = repository.ErrRestrictionExists
+)
+
+const maxRestrictionAliases = 20
+
+// restrictionFamilies are foods the common allergens usually come in,
+// matched as well as the item itself and its aliases. Diet logs name what
+// was eaten ("mac and cheese"), not what's in it.
+var restrictionFamilies = map[string][]string{
+	"peanut":    {"peanut butter", "pb", "groundnut"},
+	"tree nut":  {"almond", "cashew", "walnut", "pecan", "pistachio", "hazelnut", "macadamia", "nutella"},
+	"milk":      {"dairy", "cheese", "yogurt", "cream", "ice cream", "whey", "casein"},
+	"dairy":     {"milk", "cheese", "yogurt", "cream", "ice cream", "whey", "casein"},
+	"egg":       {"mayonnaise", "mayo", "omelet", "omelette", "meringue"},
+	"wheat":     {"bread", "pasta", "flour", "cracker", "noodle", "bagel", "tortilla"},
+	"gluten":    {"wheat", "bread", "pasta", "flour", "cracker", "noodle", "bagel", "barley", "rye"},
+	"soy":       {"tofu", "edamame", "soy sauce", "miso"},
+	"fish":      {"salmon", "tuna", "cod", "tilapia"},
+	"shellfish": {"shrimp", "crab", "lobster", "prawn", "clam", "mussel", "oyster", "scallop"},
+	"sesame":    {"tahini", "hummus"},
+}
+
+type restrictionChildSource interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error)
+}
+
+type restrictionAlertSink interface {
+	Create(ctx context.Context, alert *models.Alert) error
+}
+
+// RestrictionService manages each child's allergy and dietary restriction
+// registry and screens diet logs against it: matching foods are flagged
+// on the log, returned as warnings, and raised as allergy incident alerts.
+type RestrictionService struct {
+	repo     repository.RestrictionRepository
+	children restrictionChildSource
+	alerts   restrictionAlertSink
+}
+
+func NewRestrictionService(repo repository.RestrictionRepository, children restrictionChildSource, alerts restrictionAlertSink) *RestrictionService {
+	return &RestrictionService{repo: repo, children: children, alerts: alerts}
+}
+
+func invalidRestriction(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrRestrictionInvalid, fmt.Sprintf(format, args...))
+}
+
+// apply validates req onto r.
+func (s *RestrictionService) apply(r *models.ChildRestriction, req *models.ChildRestrictionRequest) error {
+	item := strings.TrimSpace(req.Item)
+	notes := strings.TrimSpace(req.Notes)
+	kind := req.Kind
+	if kind == "" {
+		kind = models.RestrictionAllergy
+	}
+	switch {
+	case item == "":
+		return invalidRestriction("item is required")
+	case len(item) > 100:
+		return invalidRestriction("item must be 100 characters or fewer")
+	case kind != models.RestrictionAllergy && kind != models.RestrictionIntolerance && kind != models.RestrictionDietary:
+		return invalidRestriction("kind must be allergy, intolerance or dietary")
+	case req.Severity != "" && req.Severity != models.RestrictionMild && req.Severity != models.RestrictionModerate && req.Severity != models.RestrictionSevere:
+		return invalidRestriction("severity must be mild, moderate or severe")
+	case len(req.Aliases) > maxRestrictionAliases:
+		return invalidRestriction("at most %d aliases", maxRestrictionAliases)
+	case len(notes) > 2000:
+		return invalidRestriction("notes must be 2000 characters or fewer")
+	}
+	aliases := []string{}
+	seen := map[string]bool{strings.ToLower(item): true}
+	for _, a := range req.Aliases {
+		a = strings.TrimSpace(a)
+		if len(a) > 100 {
+			return invalidRestriction("aliases must be 100 characters or fewer")
+		}
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		seen[strings.ToLower(a)] = true
+		aliases = append(aliases, a)
+	}
+	r.Item, r.Kind, r.Aliases = item, kind, aliases
+	r.Severity.String, r.Severity.Valid = req.Severity, req.Severity != ""
+	r.Notes.String, r.Notes.Valid = notes, notes != ""
+	return nil
+}
+
+// Create adds an entry to the child's registry.
+func (s *RestrictionService) Create(ctx context.Context, childID, by uuid.UUID, req *models.ChildRestrictionRequest) (*models.ChildRestriction, error) {
+	r := &models.ChildRestriction{ChildID: childID, CreatedBy: &by}
+	if err := s.apply(r, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get returns an entry on the child's registry, or ErrRestrictionNotFound.
+func (s *RestrictionService) Get(ctx context.Context, childID, id uuid.UUID) (*models.ChildRestriction, error) {
+	r, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || r.ChildID != childID {
+		return nil, ErrRestrictionNotFound
+	}
+	return r, nil
+}
+
+// List returns the child's registry.
+func (s *RestrictionService) List(ctx context.Context, childID uuid.UUID) ([]models.ChildRestriction, error) {
+	list, err := s.repo.ListByChild(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []models.ChildRestriction{}
+	}
+	return list, nil
+}
+
+// Update replaces an entry's fields.
+func (s *RestrictionService) Update(ctx context.Context, r *models.ChildRestriction, req *models.ChildRestrictionRequest) error {
+	if err := s.apply(r, req); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, r)
+}
+
+func (s *RestrictionService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Screen checks a diet log's foods against the child's registry, setting
+// its RestrictionMatches and RestrictionWarnings. Call it before the log
+// is saved.
+func (s *RestrictionService) Screen(ctx context.Context, l *models.DietLog) error {
+	registry, err := s.repo.ListByChild(ctx, l.ChildID)
+	if err != nil {
+		return err
+	}
+	foods := append([]string{}, l.FoodsEaten...)
+	if l.NewFoodTried.Valid {
+		foods = append(foods, l.NewFoodTried.String)
+	}
+	l.RestrictionWarnings = MatchRestrictions(registry, foods)
+	l.RestrictionMatches = nil
+	seen := map[string]bool{}
+	for _, m := range l.RestrictionWarnings {
+		if !seen[m.Item] {
+			seen[m.Item] = true
+			l.RestrictionMatches = append(l.RestrictionMatches, m.Item)
+		}
+	}
+	return nil
+}
+
+// ReportIncident raises an allergy incident alert for a saved diet log
+// that matched the registry or records an allergic reaction. Failures are
+// logged; the diet log is already saved.
+func (s *RestrictionService) ReportIncident(ctx context.Context, l *models.DietLog) {
+	if len(l.RestrictionMatches) == 0 && !l.AllergicReaction {
+		return
+	}
+	child, err := s.children.GetByID(ctx, l.ChildID)
+	if err != nil || child == nil {
+		log.Printf("restrictions: incident alert for diet log %s: child %s not loaded: %v", l.ID, l.ChildID, err)
+		return
+	}
+	if err := s.alerts.Create(ctx, allergyIncidentAlert(child, l)); err != nil {
+		log.Printf("restrictions: incident alert for diet log %s: %v", l.ID, err)
+	}
+}
+
+// allergyIncidentAlert builds the alert for a flagged diet log. A logged
+// reaction to a registered allergy, or any match on a severe allergy, is
+// critical.
+func allergyIncidentAlert(child *models.Child, l *models.DietLog) *models.Alert {
+	severity := models.AlertSeverityWarning
+	var matched []string
+	for _, m := range l.RestrictionWarnings {
+		if m.Kind == models.RestrictionAllergy && (l.AllergicReaction || m.Severity == models.RestrictionSevere) {
+			severity = models.AlertSeverityCritical
+		}
+		matched = append(matched, fmt.Sprintf("%s (%s)", m.Food, restrictionLabel(m.Item, m.Kind, m.Severity)))
+	}
+
+	title := "Restricted food logged"
+	var desc string
+	switch {
+	case l.AllergicReaction && len(matched) > 0:
+		title = "Allergic reaction logged"
+		desc = fmt.Sprintf("%s had an allergic reaction after eating %s.", child.FirstName, joinList(matched))
+	case l.AllergicReaction:
+		title = "Allergic reaction logged"
+		desc = fmt.Sprintf("An allergic reaction was logged for %s.", child.FirstName)
+		if l.ReactionDetails.Valid {
+			desc += " " + truncate(l.ReactionDetails.String, 200)
+		}
+	default:
+		desc = fmt.Sprintf("A diet log for %s includes %s, on the allergy and restriction list.", child.FirstName, joinList(matched))
+	}
+	return &models.Alert{
+		ChildID:     child.ID,
+		FamilyID:    child.FamilyID,
+		AlertType:   models.AlertTypeAllergyIncident,
+		Severity:    severity,
+		Title:       title,
+		Description: desc,
+		Data: models.JSONB{
+			"diet_log_id":         l.ID,
+			"log_date":            l.LogDate.Format("2006-01-02"),
+			"restriction_matches": []string(l.RestrictionMatches),
+			"allergic_reaction":   l.AllergicReaction,
+		},
+	}
+}
+
+// restrictionLabel describes an entry as "peanut, severe allergy".
+func restrictionLabel(item, kind, severity string) string {
+	what := kind
+	if kind == models.RestrictionDietary {
+		what = "dietary restriction"
+	}
+	if severity != "" {
+		what = severity + " " + what
+	}
+	return item + ", " + what
+}
+
+// MatchRestrictions returns each food that matches an entry on the
+// registry, by the entry's item, aliases or the foods its allergen family
+// usually comes in. Matching tolerates case, plurals, punctuation and
+// one-letter typos.
+func MatchRestrictions(registry []models.ChildRestriction, foods []string) []models.RestrictionMatch {
+	var out []models.RestrictionMatch
+	for _, r := range registry {
+		terms := append([]string{r.Item}, r.Aliases...)
+		terms = append(terms, restrictionFamilies[strings.Join(foodWords(r.Item), " ")]...)
+		for _, food := range foods {
+			if strings.TrimSpace(food) == "" {
+				continue
+			}
+			words := foodWords(food)
+			for _, term := range terms {
+				if termMatches(foodWords(term), words) {
+					out = append(out, models.RestrictionMatch{
+						RestrictionID: r.ID, Item: r.Item, Kind: r.Kind, Severity: r.Severity.String,
+						Food: strings.TrimSpace(food),
+					})
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// foodWords lowercases s, splits it into words on anything but letters
+// and digits, and singularizes each.
+func foodWords(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = singularFood(w)
+	}
+	return words
+}
+
+func singularFood(w string) string {
+	switch {
+	case len(w) <= 3:
+		return w
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "es"):
+		stem := w[:len(w)-2]
+		for _, suf := range []string{"sh", "ch", "x", "o"} {
+			if strings.HasSuffix(stem, suf) {
+				return stem
+			}
+		}
+	}
+	if strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// termMatches reports whether the term's words appear in order in the
+// food's words. A word of four letters or more also matches the start of
+// a food word ("milk" in "milkshake"), and one of five or more a food
+// word a letter off ("peanutt").
+func termMatches(term, food []string) bool {
+	if len(term) == 0 {
+		return false
+	}
+	for start := 0; start+len(term) <= len(food); start++ {
+		ok := true
+		for i, t := range term {
+			if !foodWordMatches(t, food[start+i]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func foodWordMatches(term, word string) bool {
+	switch {
+	case term == word:
+		return true
+	case len(term) >= 4 && strings.HasPrefix(word, term):
+		return true
+	case len(term) >= 5:
+		return withinOneEdit(term, word)
+	}
+	return false
+}
+
+// withinOneEdit reports whether a and b differ by at most one inserted,
+// deleted or substituted letter.
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i, j, edits := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(a) == len(b) {
+			i++
+		}
+		j++
+	}
+	return edits+(len(b)-j)+(len(a)-i) <= 1
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeRestrictionRepo struct {
+	byID map[uuid.UUID]models.ChildRestriction
+}
+
+func (f *fakeRestrictionRepo) Create(ctx context.Context, r *models.ChildRestriction) error {
+	for _, e := range f.byID {
+		if e.ChildID == r.ChildID && strings.EqualFold(e.Item, r.Item) {
+			return ErrRestrictionExists
+		}
+	}
+	r.ID = uuid.New()
+	f.byID[r.ID] = *r
+	return nil
+}
+
+func (f *fakeRestrictionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.ChildRestriction, error) {
+	if r, ok := f.byID[id]; ok {
+		return &r, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeRestrictionRepo) ListByChild(ctx context.Context, childID uuid.UUID) ([]models.ChildRestriction, error) {
+	var out []models.ChildRestriction
+	for _, r := range f.byID {
+		if r.ChildID == childID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRestrictionRepo) Update(ctx context.Context, r *models.ChildRestriction) error {
+	f.byID[r.ID] = *r
+	return nil
+}
+
+func (f *fakeRestrictionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.byID, id)
+	return nil
+}
+
+type fakeRestrictionDeps struct {
+	child  *models.Child
+	alerts []*models.Alert
+}
+
+func (f *fakeRestrictionDeps) GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error) {
+	return f.child, nil
+}
+
+func (f *fakeRestrictionDeps) Create(ctx context.Context, a *models.Alert) error {
+	f.alerts = append(f.alerts, a)
+	return nil
+}
+
+func TestMatchRestrictions(t *testing.T) {
+	registry := []models.ChildRestriction{
+		{Item: "Peanuts", Kind: models.RestrictionAllergy},
+		{Item: "Tree nuts", Kind: models.RestrictionAllergy},
+		{Item: "Gluten", Kind: models.RestrictionDietary, Aliases: []string{"Goldfish crackers"}},
+		{Item: "Egg", Kind: models.RestrictionAllergy},
+	}
+	for food, want := range map[string]string{
+		"PB&J sandwich":     "Peanuts",
+		"peanutt cookies":   "Peanuts",
+		"Cashews":           "Tree nuts",
+		"spaghetti (pasta)": "Gluten",
+		"goldfish cracker":  "Gluten",
+		"scrambled eggs":    "Egg",
+		"eggplant":          "",
+		"apple slices":      "",
+		"pea soup":          "",
+	} {
+		var got []string
+		for _, m := range MatchRestrictions(registry, []string{food}) {
+			got = append(got, m.Item)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("%q matched %v, want %q", food, got, want)
+		}
+	}
+}
+
+func TestRestrictionScreenAndIncident(t *testing.T) {
+	ctx := context.Background()
+	child := &models.Child{ID: uuid.New(), FamilyID: uuid.New(), FirstName: "Sam"}
+	deps := &fakeRestrictionDeps{child: child}
+	svc := NewRestrictionService(&fakeRestrictionRepo{byID: map[uuid.UUID]models.ChildRestriction{}}, deps, deps)
+
+	for name, req := range map[string]models.ChildRestrictionRequest{
+		"no item":      {Item: " "},
+		"bad kind":     {Item: "Milk", Kind: "preference"},
+		"bad severity": {Item: "Milk", Severity: "extreme"},
+	} {
+		if _, err := svc.Create(ctx, child.ID, uuid.New(), &req); !errors.Is(err, ErrRestrictionInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	peanut, err := svc.Create(ctx, child.ID, uuid.New(), &models.ChildRestrictionRequest{Item: "Peanut", Severity: "severe", Aliases: []string{"PB", " ", "peanut"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peanut.Kind != models.RestrictionAllergy || len(peanut.Aliases) != 1 {
+		t.Errorf("created %+v", peanut)
+	}
+	if _, err := svc.Create(ctx, child.ID, uuid.New(), &models.ChildRestrictionRequest{Item: "peanut"}); !errors.Is(err, ErrRestrictionExists) {
+		t.Errorf("duplicate: %v", err)
+	}
+	if _, err := svc.Create(ctx, child.ID, uuid.New(), &models.ChildRestrictionRequest{Item: "Dairy", Kind: "intolerance", Severity: "mild"}); err != nil {
+		t.Fatal(err)
+	}
+
+	l := &models.DietLog{ChildID: child.ID, FoodsEaten: models.StringArray{"Peanut butter toast", "Grilled cheese", "apple"}}
+	if err := svc.Screen(ctx, l); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.RestrictionWarnings) != 2 || len(l.RestrictionMatches) != 2 {
+		t.Fatalf("screened %+v", l)
+	}
+	svc.ReportIncident(ctx, l)
+	if len(deps.alerts) != 1 || deps.alerts[0].Severity != models.AlertSeverityCritical || deps.alerts[0].AlertType != models.AlertTypeAllergyIncident {
+		t.Fatalf("alerts %+v", deps.alerts)
+	}
+
+	clean := &models.DietLog{ChildID: child.ID, FoodsEaten: models.StringArray{"rice"}}
+	_ = svc.Screen(ctx, clean)
+	svc.ReportIncident(ctx, clean)
+	mild := &models.DietLog{ChildID: child.ID, FoodsEaten: models.StringArray{"yogurt"}}
+	_ = svc.Screen(ctx, mild)
+	svc.ReportIncident(ctx, mild)
+	if len(deps.alerts) != 2 || deps.alerts[1].Severity != models.AlertSeverityWarning {
+		t.Errorf("alerts after clean and mild logs: %d", len(deps.alerts))
+	}
+}
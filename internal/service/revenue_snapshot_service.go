@@ -33,11 +33,10 @@ func (s *RevenueSnapshotService) SnapshotYesterday(ctx context.Context) error {
 }
 
 // SnapshotDate computes the daily snapshot for a specific UTC date. Pulls
-// from payments + family_subscriptions + promo_codes_usages. Counts upgrade
-// + downgrade events from looking at plan_id transitions inside
-// family_subscriptions.updated_at — but since we don't have a history
-// table, those two columns stay 0 for now (Phase 7 follow-up if Bryan
-// wants exact transition counts).
+// from payments + family_subscriptions + promo_codes_usages. Upgrades and
+// downgrades are counted from subscription_plan_changes, which records
+// every plan switch (in-app and from the Stripe Dashboard) since 00077;
+// earlier days stay 0.
 func (s *RevenueSnapshotService) SnapshotDate(ctx context.Context, day time.Time) error {
 	dayStr := day.Format("2006-01-02")
 
@@ -47,6 +46,8 @@ func (s *RevenueSnapshotService) SnapshotDate(ctx context.Context, day time.Time
 		promoDiscCents   int64
 		newSubs          int
 		cancelledSubs    int
+		upgrades         int
+		downgrades       int
 	)
 
 	// total revenue + refunds from payments rows that landed yesterday
@@ -80,26 +81,39 @@ func (s *RevenueSnapshotService) SnapshotDate(ctx context.Context, day time.Time
 		return fmt.Errorf("cancelled subs count: %w", err)
 	}
 
+	err = s.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FILTER (WHERE direction = 'upgrade'),
+               COUNT(*) FILTER (WHERE direction = 'downgrade')
+        FROM subscription_plan_changes
+        WHERE created_at::date = $1`, dayStr,
+	).Scan(&upgrades, &downgrades)
+	if err != nil {
+		return fmt.Errorf("plan changes count: %w", err)
+	}
+
 	_, err = s.db.ExecContext(ctx, `
         INSERT INTO daily_revenue_snapshots (
             snapshot_date, total_revenue_cents, refunds_cents,
             promo_discounts_cents, new_subscriptions, cancelled_subscriptions,
-            calculated_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+            upgrades, downgrades, calculated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
         ON CONFLICT (snapshot_date) DO UPDATE SET
             total_revenue_cents     = EXCLUDED.total_revenue_cents,
             refunds_cents           = EXCLUDED.refunds_cents,
             promo_discounts_cents   = EXCLUDED.promo_discounts_cents,
             new_subscriptions       = EXCLUDED.new_subscriptions,
             cancelled_subscriptions = EXCLUDED.cancelled_subscriptions,
+            upgrades                = EXCLUDED.upgrades,
+            downgrades              = EXCLUDED.downgrades,
             calculated_at           = NOW()`,
 		dayStr, revenueCents, refundsCents, promoDiscCents, newSubs, cancelledSubs,
+		upgrades, downgrades,
 	)
 	if err != nil {
 		return fmt.Errorf("upsert snapshot: %w", err)
 	}
-	log.Printf("[REVENUE] snapshot %s: revenue=%d¢ refunds=%d¢ new=%d cancelled=%d",
-		dayStr, revenueCents, refundsCents, newSubs, cancelledSubs)
+	log.Printf("[REVENUE] snapshot %s: revenue=%d¢ refunds=%d¢ new=%d cancelled=%d upgrades=%d downgrades=%d",
+		dayStr, revenueCents, refundsCents, newSubs, cancelledSubs, upgrades, downgrades)
 	return nil
 }
 
@@ -211,25 +225,28 @@ func monthStep(billingInterval string) int {
 // dashboard's "yesterday" view and gives Stripe webhook retries up to
 // an hour to land before we aggregate.
 type RevenueSnapshotScheduler struct {
-	svc *RevenueSnapshotService
+	svc  *RevenueSnapshotService
+	jobs *JobLocker
 }
 
-func NewRevenueSnapshotScheduler(svc *RevenueSnapshotService) *RevenueSnapshotScheduler {
-	return &RevenueSnapshotScheduler{svc: svc}
+func NewRevenueSnapshotScheduler(svc *RevenueSnapshotService, jobs *JobLocker) *RevenueSnapshotScheduler {
+	return &RevenueSnapshotScheduler{svc: svc, jobs: jobs}
 }
 
 func (s *RevenueSnapshotScheduler) Start(ctx context.Context) {
 	log.Println("Revenue snapshot scheduler started (target: 01:00 UTC daily)")
 	// Run once at boot — gives the admin dashboard recent data on a fresh
 	// deploy without waiting for the next 01:00.
-	go func() {
+	// A rolling deploy boots several instances within minutes; the boot
+	// run is claimed per hour so it only happens once.
+	go s.jobs.RunOnce(ctx, "revenue_snapshot_boot", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
 		if err := s.svc.SnapshotYesterday(ctx); err != nil {
 			log.Printf("Revenue snapshot: initial run failed: %v", err)
 		}
 		if err := s.svc.RebuildExpectedRevenue(ctx); err != nil {
 			log.Printf("Revenue projection: initial run failed: %v", err)
 		}
-	}()
+	})
 	for {
 		next := nextUTCRunAt(time.Now().UTC(), 1, 0)
 		select {
@@ -237,12 +254,14 @@ func (s *RevenueSnapshotScheduler) Start(ctx context.Context) {
 			log.Println("Revenue snapshot scheduler stopped")
 			return
 		case <-time.After(time.Until(next)):
-			if err := s.svc.SnapshotYesterday(ctx); err != nil {
-				log.Printf("Revenue snapshot: tick failed: %v", err)
-			}
-			if err := s.svc.RebuildExpectedRevenue(ctx); err != nil {
-				log.Printf("Revenue projection: tick failed: %v", err)
-			}
+			s.jobs.RunOnce(ctx, "revenue_snapshot", next, 24*time.Hour, func(ctx context.Context) {
+				if err := s.svc.SnapshotYesterday(ctx); err != nil {
+					log.Printf("Revenue snapshot: tick failed: %v", err)
+				}
+				if err := s.svc.RebuildExpectedRevenue(ctx); err != nil {
+					log.Printf("Revenue projection: tick failed: %v", err)
+				}
+			})
 		}
 	}
 }
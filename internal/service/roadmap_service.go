@@ -11,21 +11,22 @@ import (
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrRoadmapTitleRequired = errors.New("title is required")
-	ErrRoadmapInvalidStatus = errors.New("invalid status")
-	ErrRoadmapInvalidPrio   = errors.New("invalid priority")
-	ErrRoadmapInvalidSource = errors.New("invalid source")
-	ErrRoadmapTicketAlready = errors.New("ticket has already been promoted to the roadmap")
+	ErrRoadmapTitleRequired = apperr.Validation("title is required")
+	ErrRoadmapInvalidStatus = apperr.Validation("invalid status")
+	ErrRoadmapInvalidPrio   = apperr.Validation("invalid priority")
+	ErrRoadmapInvalidSource = apperr.Validation("invalid source")
+	ErrRoadmapTicketAlready = apperr.Conflict("ticket has already been promoted to the roadmap")
 	ErrRoadmapTicketWrongType = errors.New("only feature_request tickets can be promoted")
-	ErrDuplicateSelf         = errors.New("a ticket cannot be a duplicate of itself")
-	ErrDuplicateAlreadySet   = errors.New("ticket is already marked as a duplicate")
-	ErrDuplicateTargetMissing = errors.New("duplicate target not found")
-	ErrDuplicateTargetIsDup  = errors.New("the chosen target is itself a duplicate; pick the canonical one")
+	ErrDuplicateSelf         = apperr.Validation("a ticket cannot be a duplicate of itself")
+	ErrDuplicateAlreadySet   = apperr.Conflict("ticket is already marked as a duplicate")
+	ErrDuplicateTargetMissing = apperr.NotFound("duplicate target not found")
+	ErrDuplicateTargetIsDup  = apperr.Conflict("the chosen target is itself a duplicate; pick the canonical one")
 )
 
 // validRoadmapStatuses, validRoadmapPriorities, validRoadmapSources mirror the
@@ -37,6 +37,7 @@ var builtinNames = map[string]bool{
 	"support":     true,
 	"marketing":   true,
 	"partner":     true,
+	"auditor":     true,
 }
 
 func (s *RoleService) List(ctx context.Context) ([]models.CustomRole, error) {
@@ -130,6 +131,9 @@ func (s *RoleService) validate(role *models.CustomRole) error {
 	return nil
 }
 
+// customRoleLookupTimeout bounds LookupCustomRole's query.
+const customRoleLookupTimeout = 2 * time.Second
+
 // LookupCustomRole implements auth.PermResolver. Called by Matrix() for
 // any role name that didn't hit the built-in matrix.
 func (s *RoleService) LookupCustomRole(roleName, section string) auth.Level {
@@ -139,7 +143,11 @@ func (s *RoleService) LookupCustomRole(roleName, section string) auth.Level {
 	if lvl, ok := s.cache.get(roleName, section); ok {
 		return lvl
 	}
-	level, found, err := s.repo.GetLevel(context.Background(), roleName, section)
+	// auth.PermResolver has no context to pass on, so bound the lookup
+	// ourselves rather than let a stuck query hang the permission check.
+	ctx, cancel := context.WithTimeout(context.Background(), customRoleLookupTimeout)
+	defer cancel()
+	level, found, err := s.repo.GetLevel(ctx, roleName, section)
 	if err != nil {
 		// Fail closed on transient DB errors. Don't cache.
 		return auth.LevelNone
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"carecompanion/internal/models"
+)
+
+// routineCardColors are the card fills of the printed schedule, cycled
+// step by step so neighbouring steps are easy to tell apart.
+var routineCardColors = [][3]int{
+	{219, 234, 254}, {220, 252, 231}, {254, 243, 199}, {252, 231, 243}, {237, 233, 254}, {204, 251, 241},
+}
+
+var weekdayShort = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// routineIconLabel turns an icon key into the label printed on its card.
+func routineIconLabel(icon string) string {
+	if icon == "" || icon == "other" {
+		return ""
+	}
+	label := strings.ReplaceAll(icon, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// routineDaysLabel describes the days a routine runs.
+func routineDaysLabel(days []int) string {
+	if len(days) == 0 {
+		return "Every day"
+	}
+	var names []string
+	for d := 0; d < 7; d++ {
+		for _, rd := range days {
+			if rd == d {
+				names = append(names, weekdayShort[d])
+			}
+		}
+	}
+	if strings.Join(names, ",") == "Mon,Tue,Wed,Thu,Fri" {
+		return "School days"
+	}
+	return strings.Join(names, ", ")
+}
+
+// RoutineSchedulePDF lays out printable visual schedules, a landscape page
+// per routine: its steps as large numbered cards in order, each with its
+// icon label and a box to tick, for the fridge or the classroom wall.
+func RoutineSchedulePDF(child *models.Child, routines []models.Routine) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "Letter", "")
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	const (
+		margin  = 12.0
+		cols    = 4
+		gap     = 6.0
+		cardH   = 52.0
+		headerH = 30.0
+	)
+	pageW, pageH := pdf.GetPageSize()
+	cardW := (pageW - 2*margin - float64(cols-1)*gap) / cols
+	rowsPerPage := int((pageH - margin - headerH - margin + gap) / (cardH + gap))
+
+	header := func(rt *models.Routine, cont bool) {
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 24)
+		pdf.SetTextColor(79, 70, 229)
+		title := fmt.Sprintf("%s's %s", child.FirstName, rt.Name)
+		if cont {
+			title += " (continued)"
+		}
+		pdf.SetXY(margin, margin)
+		pdf.CellFormat(pageW-2*margin, 12, tr(title), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 12)
+		pdf.SetTextColor(107, 114, 128)
+		sub := routineDaysLabel(rt.DaysOfWeek)
+		if rt.StartTime.Valid {
+			sub += " - starts at " + rt.StartTime.String
+		}
+		pdf.SetX(margin)
+		pdf.CellFormat(pageW-2*margin, 7, tr(sub), "", 1, "L", false, 0, "")
+	}
+
+	for i := range routines {
+		rt := &routines[i]
+		header(rt, false)
+		for n, step := range rt.Steps {
+			slot := n % (cols * rowsPerPage)
+			if n > 0 && slot == 0 {
+				header(rt, true)
+			}
+			x := margin + float64(slot%cols)*(cardW+gap)
+			y := margin + headerH + float64(slot/cols)*(cardH+gap)
+			c := routineCardColors[n%len(routineCardColors)]
+
+			pdf.SetFillColor(c[0], c[1], c[2])
+			pdf.SetDrawColor(156, 163, 175)
+			pdf.SetLineWidth(0.4)
+			pdf.RoundedRect(x, y, cardW, cardH, 4, "1234", "FD")
+
+			// Step number
+			pdf.SetFillColor(79, 70, 229)
+			pdf.Circle(x+9, y+9, 6, "F")
+			pdf.SetFont("Helvetica", "B", 14)
+			pdf.SetTextColor(255, 255, 255)
+			pdf.SetXY(x+3, y+5)
+			pdf.CellFormat(12, 8, fmt.Sprintf("%d", n+1), "", 0, "C", false, 0, "")
+
+			// Tick box
+			pdf.SetFillColor(255, 255, 255)
+			pdf.Rect(x+cardW-15, y+4, 10, 10, "FD")
+
+			if label := routineIconLabel(step.Icon); label != "" {
+				pdf.SetFont("Helvetica", "B", 9)
+				pdf.SetTextColor(107, 114, 128)
+				pdf.SetXY(x+4, y+17)
+				pdf.CellFormat(cardW-8, 5, tr(strings.ToUpper(label)), "", 0, "C", false, 0, "")
+			}
+			pdf.SetFont("Helvetica", "B", 15)
+			pdf.SetTextColor(31, 41, 55)
+			pdf.SetXY(x+4, y+24)
+			pdf.MultiCell(cardW-8, 7, tr(step.Title), "", "C", false)
+			if step.DurationMinutes != nil {
+				pdf.SetFont("Helvetica", "", 10)
+				pdf.SetTextColor(107, 114, 128)
+				pdf.SetXY(x+4, y+cardH-9)
+				pdf.CellFormat(cardW-8, 5, fmt.Sprintf("%d min", *step.DurationMinutes), "", 0, "C", false, 0, "")
+			}
+		}
+	}
+	if len(routines) == 0 {
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "", 14)
+		pdf.CellFormat(0, 10, "No routines to print yet.", "", 1, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrRoutineNotFound     = apperr.NotFound("routine not found")
+	ErrRoutineInvalid      = apperr.Validation("invalid routine")
+	ErrRoutineStepNotFound = apperr.NotFound("step isn't part of this routine")
+	ErrRoutineFutureDate   = errors.New("can't tick off steps for a future day")
+)
+
+// maxRoutineSteps caps a routine's length; a visual schedule longer than
+// this stops being readable.
+const maxRoutineSteps = 30
+
+// RoutineIcons are the icon keys a routine or step can use. Clients draw
+// their own picture for each; the printed schedule labels it.
+var RoutineIcons = []string{
+	"wake_up", "toilet", "wash", "brush_teeth", "get_dressed", "hair", "breakfast", "lunch",
+	"dinner", "snack", "drink", "medication", "shoes", "coat", "backpack", "bus", "car",
+	"school", "therapy", "homework", "play", "outside", "screen_time", "music", "reading",
+	"clean_up", "bath", "pajamas", "story", "bed", "calm_down", "hug", "star", "other",
+}
+
+var routineTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// RoutineService manages children's daily routines: building them,
+// ticking steps off each day, and the completion stats that also feed
+// the correlation engine as routine_completion.
+type RoutineService struct {
+	repo repository.RoutineRepository
+}
+
+func NewRoutineService(repo repository.RoutineRepository) *RoutineService {
+	return &RoutineService{repo: repo}
+}
+
+func invalidRoutine(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrRoutineInvalid, fmt.Sprintf(format, args...))
+}
+
+func validRoutineIcon(icon string) bool {
+	if icon == "" {
+		return true
+	}
+	for _, i := range RoutineIcons {
+		if i == icon {
+			return true
+		}
+	}
+	return false
+}
+
+// apply validates req onto rt. Step IDs must be steps rt already has.
+func (s *RoutineService) apply(rt *models.Routine, req *models.RoutineRequest) error {
+	name := strings.TrimSpace(req.Name)
+	switch {
+	case name == "":
+		return invalidRoutine("name is required")
+	case len(name) > 100:
+		return invalidRoutine("name must be 100 characters or fewer")
+	case !validRoutineIcon(req.Icon):
+		return invalidRoutine("unknown icon %q", req.Icon)
+	case req.StartTime != "" && !routineTimePattern.MatchString(req.StartTime):
+		return invalidRoutine("start_time must be HH:MM")
+	case len(req.Steps) == 0:
+		return invalidRoutine("a routine needs at least one step")
+	case len(req.Steps) > maxRoutineSteps:
+		return invalidRoutine("a routine can have at most %d steps", maxRoutineSteps)
+	}
+	days := []int{}
+	seenDay := map[int]bool{}
+	for _, d := range req.DaysOfWeek {
+		if d < 0 || d > 6 {
+			return invalidRoutine("days_of_week must be 0 (Sunday) to 6")
+		}
+		if !seenDay[d] {
+			seenDay[d] = true
+			days = append(days, d)
+		}
+	}
+	if len(days) == 7 {
+		days = []int{}
+	}
+
+	existing := map[uuid.UUID]bool{}
+	for _, st := range rt.Steps {
+		existing[st.ID] = true
+	}
+	steps := make([]models.RoutineStep, 0, len(req.Steps))
+	seenStep := map[uuid.UUID]bool{}
+	for i, sr := range req.Steps {
+		title := strings.TrimSpace(sr.Title)
+		switch {
+		case title == "":
+			return invalidRoutine("step %d needs a title", i+1)
+		case len(title) > 100:
+			return invalidRoutine("step titles must be 100 characters or fewer")
+		case !validRoutineIcon(sr.Icon):
+			return invalidRoutine("unknown icon %q", sr.Icon)
+		case sr.DurationMinutes != nil && (*sr.DurationMinutes < 1 || *sr.DurationMinutes > 240):
+			return invalidRoutine("duration_minutes must be between 1 and 240")
+		}
+		step := models.RoutineStep{Title: title, Icon: sr.Icon, DurationMinutes: sr.DurationMinutes}
+		if sr.ID != nil {
+			if !existing[*sr.ID] || seenStep[*sr.ID] {
+				return invalidRoutine("step %s isn't part of this routine", *sr.ID)
+			}
+			seenStep[*sr.ID] = true
+			step.ID = *sr.ID
+		}
+		steps = append(steps, step)
+	}
+
+	rt.Name, rt.Icon, rt.DaysOfWeek, rt.Steps = name, req.Icon, days, steps
+	rt.StartTime.String, rt.StartTime.Valid = req.StartTime, req.StartTime != ""
+	if req.Active != nil {
+		rt.Active = *req.Active
+	}
+	return nil
+}
+
+// Create adds a routine for the child.
+func (s *RoutineService) Create(ctx context.Context, childID, by uuid.UUID, req *models.RoutineRequest) (*models.Routine, error) {
+	rt := &models.Routine{ChildID: childID, Active: true, CreatedBy: &by}
+	if err := s.apply(rt, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, rt); err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// Get returns a routine of the child, or ErrRoutineNotFound.
+func (s *RoutineService) Get(ctx context.Context, childID, id uuid.UUID) (*models.Routine, error) {
+	rt, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rt == nil || rt.ChildID != childID {
+		return nil, ErrRoutineNotFound
+	}
+	return rt, nil
+}
+
+// List returns the child's routines, paused ones too unless activeOnly.
+func (s *RoutineService) List(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Routine, error) {
+	return s.repo.ListByChild(ctx, childID, activeOnly)
+}
+
+// Update replaces a routine and its steps. Steps kept by ID keep their
+// completion history.
+func (s *RoutineService) Update(ctx context.Context, rt *models.Routine, req *models.RoutineRequest) error {
+	if err := s.apply(rt, req); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, rt)
+}
+
+// Delete removes a routine with its completion history. Pausing it
+// (active: false) keeps the history.
+func (s *RoutineService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Day returns the active routines scheduled on date with the steps done.
+func (s *RoutineService) Day(ctx context.Context, childID uuid.UUID, date time.Time) ([]models.RoutineDay, error) {
+	routines, err := s.repo.ListByChild(ctx, childID, true)
+	if err != nil {
+		return nil, err
+	}
+	completions, err := s.repo.Completions(ctx, childID, date, date)
+	if err != nil {
+		return nil, err
+	}
+	done := map[uuid.UUID]time.Time{}
+	for _, c := range completions {
+		done[c.StepID] = c.CompletedAt
+	}
+	out := []models.RoutineDay{}
+	for _, rt := range routines {
+		if !rt.RunsOn(date) {
+			continue
+		}
+		day := models.RoutineDay{RoutineID: rt.ID, Name: rt.Name, Icon: rt.Icon, StartTime: rt.StartTime, StepsTotal: len(rt.Steps)}
+		for _, st := range rt.Steps {
+			ds := models.RoutineDayStep{RoutineStep: st}
+			if at, ok := done[st.ID]; ok {
+				ds.Completed, ds.CompletedAt = true, &at
+				day.StepsDone++
+			}
+			day.Steps = append(day.Steps, ds)
+		}
+		out = append(out, day)
+	}
+	return out, nil
+}
+
+// SetStepDone ticks a step of rt off on date, or unticks it. today is the
+// current date where the family is.
+func (s *RoutineService) SetStepDone(ctx context.Context, rt *models.Routine, stepID uuid.UUID, date, today time.Time, by uuid.UUID, done bool) error {
+	if date.After(today) {
+		return ErrRoutineFutureDate
+	}
+	found := false
+	for _, st := range rt.Steps {
+		found = found || st.ID == stepID
+	}
+	if !found {
+		return ErrRoutineStepNotFound
+	}
+	if !done {
+		return s.repo.ClearCompletion(ctx, stepID, date)
+	}
+	return s.repo.SetCompletion(ctx, &models.RoutineCompletion{
+		StepID: stepID, RoutineID: rt.ID, ChildID: rt.ChildID, CompletionDate: date, CompletedBy: &by,
+	})
+}
+
+// Stats rolls up completion over [start, end] (dates, UTC midnight): the
+// share of scheduled steps done, per day and per routine, with each
+// routine's current streak of fully completed days up to end. A day
+// still in progress doesn't break a streak.
+func (s *RoutineService) Stats(ctx context.Context, childID uuid.UUID, start, end time.Time) (*models.RoutineCompletionSummary, error) {
+	routines, err := s.repo.ListByChild(ctx, childID, false)
+	if err != nil {
+		return nil, err
+	}
+	completions, err := s.repo.Completions(ctx, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return routineStats(routines, completions, start, end), nil
+}
+
+type routineDayKey struct {
+	routine uuid.UUID
+	date    string
+}
+
+func routineStats(routines []models.Routine, completions []models.RoutineCompletion, start, end time.Time) *models.RoutineCompletionSummary {
+	sum := &models.RoutineCompletionSummary{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Daily:     []models.ChartDataPoint{},
+		Routines:  []models.RoutineStats{},
+	}
+	stepOf := map[uuid.UUID]bool{}
+	for _, rt := range routines {
+		for _, st := range rt.Steps {
+			stepOf[st.ID] = true
+		}
+	}
+	doneOn := map[routineDayKey]int{}
+	for _, c := range completions {
+		if stepOf[c.StepID] {
+			doneOn[routineDayKey{c.RoutineID, c.CompletionDate.Format("2006-01-02")}]++
+		}
+	}
+
+	// A routine counts on the days it existed and ran. A paused one only
+	// counts on days something was done, so pausing a routine doesn't
+	// turn the days since into misses.
+	scheduled := func(rt *models.Routine, day time.Time) bool {
+		created := time.Date(rt.CreatedAt.Year(), rt.CreatedAt.Month(), rt.CreatedAt.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Before(created) || !rt.RunsOn(day) || len(rt.Steps) == 0 {
+			return false
+		}
+		return rt.Active || doneOn[routineDayKey{rt.ID, day.Format("2006-01-02")}] > 0
+	}
+
+	var totalDone, totalScheduled int
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		var dayDone, dayScheduled int
+		for i := range routines {
+			if scheduled(&routines[i], day) {
+				dayScheduled += len(routines[i].Steps)
+				dayDone += doneOn[routineDayKey{routines[i].ID, day.Format("2006-01-02")}]
+			}
+		}
+		if dayScheduled > 0 {
+			sum.Daily = append(sum.Daily, models.ChartDataPoint{Date: day.Format("2006-01-02"), Value: float64(dayDone) / float64(dayScheduled)})
+			totalDone += dayDone
+			totalScheduled += dayScheduled
+		}
+	}
+	if totalScheduled > 0 {
+		rate := float64(totalDone) / float64(totalScheduled)
+		sum.CompletionRate = &rate
+	}
+
+	for i := range routines {
+		rt := &routines[i]
+		st := models.RoutineStats{RoutineID: rt.ID, Name: rt.Name}
+		var done, sched int
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if !scheduled(rt, day) {
+				continue
+			}
+			n := doneOn[routineDayKey{rt.ID, day.Format("2006-01-02")}]
+			st.DaysScheduled++
+			sched += len(rt.Steps)
+			done += n
+			if n >= len(rt.Steps) {
+				st.DaysCompleted++
+			}
+		}
+		if sched > 0 {
+			st.CompletionRate = float64(done) / float64(sched)
+		}
+		for day, first := end, true; !day.Before(start); day, first = day.AddDate(0, 0, -1), false {
+			if !scheduled(rt, day) {
+				continue
+			}
+			if doneOn[routineDayKey{rt.ID, day.Format("2006-01-02")}] >= len(rt.Steps) {
+				st.CurrentStreak++
+			} else if !first {
+				break
+			}
+		}
+		if st.DaysScheduled > 0 || rt.Active {
+			sum.Routines = append(sum.Routines, st)
+		}
+	}
+	return sum
+}
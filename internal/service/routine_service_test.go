@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// fakeRoutineRepo keeps routines and completions in memory.
+type fakeRoutineRepo struct {
+	routines    map[uuid.UUID]*models.Routine
+	completions []models.RoutineCompletion
+}
+
+func (f *fakeRoutineRepo) Create(ctx context.Context, rt *models.Routine) error {
+	rt.ID = uuid.New()
+	for i := range rt.Steps {
+		rt.Steps[i].ID, rt.Steps[i].RoutineID, rt.Steps[i].Position = uuid.New(), rt.ID, i
+	}
+	c := *rt
+	f.routines[rt.ID] = &c
+	return nil
+}
+
+func (f *fakeRoutineRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Routine, error) {
+	if rt, ok := f.routines[id]; ok {
+		c := *rt
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeRoutineRepo) ListByChild(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Routine, error) {
+	var out []models.Routine
+	for _, rt := range f.routines {
+		if rt.ChildID == childID && (rt.Active || !activeOnly) {
+			out = append(out, *rt)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRoutineRepo) Update(ctx context.Context, rt *models.Routine) error {
+	for i := range rt.Steps {
+		if rt.Steps[i].ID == uuid.Nil {
+			rt.Steps[i].ID = uuid.New()
+		}
+	}
+	c := *rt
+	f.routines[rt.ID] = &c
+	return nil
+}
+
+func (f *fakeRoutineRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.routines, id)
+	return nil
+}
+
+func (f *fakeRoutineRepo) SetCompletion(ctx context.Context, c *models.RoutineCompletion) error {
+	f.completions = append(f.completions, *c)
+	return nil
+}
+
+func (f *fakeRoutineRepo) ClearCompletion(ctx context.Context, stepID uuid.UUID, date time.Time) error {
+	kept := f.completions[:0]
+	for _, c := range f.completions {
+		if c.StepID != stepID || !c.CompletionDate.Equal(date) {
+			kept = append(kept, c)
+		}
+	}
+	f.completions = kept
+	return nil
+}
+
+func (f *fakeRoutineRepo) Completions(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.RoutineCompletion, error) {
+	var out []models.RoutineCompletion
+	for _, c := range f.completions {
+		if c.ChildID == childID && !c.CompletionDate.Before(start) && !c.CompletionDate.After(end) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func TestRoutineBuildAndTick(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRoutineRepo{routines: map[uuid.UUID]*models.Routine{}}
+	svc := NewRoutineService(repo)
+	child, parent := uuid.New(), uuid.New()
+
+	for name, req := range map[string]models.RoutineRequest{
+		"no name":  {Steps: []models.RoutineStepRequest{{Title: "Brush teeth"}}},
+		"no steps": {Name: "Bedtime"},
+		"icon":     {Name: "Bedtime", Steps: []models.RoutineStepRequest{{Title: "Bath", Icon: "rocket"}}},
+		"time":     {Name: "Bedtime", StartTime: "7pm", Steps: []models.RoutineStepRequest{{Title: "Bath"}}},
+		"day":      {Name: "Bedtime", DaysOfWeek: []int{7}, Steps: []models.RoutineStepRequest{{Title: "Bath"}}},
+		"step id":  {Name: "Bedtime", Steps: []models.RoutineStepRequest{{ID: &parent, Title: "Bath"}}},
+	} {
+		if _, err := svc.Create(ctx, child, parent, &req); !errors.Is(err, ErrRoutineInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	rt, err := svc.Create(ctx, child, parent, &models.RoutineRequest{
+		Name: "Morning", Icon: "wake_up", StartTime: "07:00", DaysOfWeek: []int{1, 2, 3, 4, 5, 5},
+		Steps: []models.RoutineStepRequest{{Title: "Get dressed", Icon: "get_dressed"}, {Title: "Breakfast", Icon: "breakfast"}},
+	})
+	if err != nil || !rt.Active || len(rt.DaysOfWeek) != 5 || len(rt.Steps) != 2 {
+		t.Fatalf("create: %+v, %v", rt, err)
+	}
+
+	// Reordering keeps step IDs; a new step is added.
+	dressed := rt.Steps[0].ID
+	if err := svc.Update(ctx, rt, &models.RoutineRequest{Name: "Morning", DaysOfWeek: []int{0, 1, 2, 3, 4, 5, 6}, Steps: []models.RoutineStepRequest{
+		{Title: "Shoes on", Icon: "shoes"}, {ID: &dressed, Title: "Get dressed"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.DaysOfWeek) != 0 || rt.Steps[1].ID != dressed || rt.Steps[0].ID == uuid.Nil || len(rt.Steps) != 2 {
+		t.Errorf("update: %+v", rt)
+	}
+
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	if err := svc.SetStepDone(ctx, rt, dressed, monday.AddDate(0, 0, 1), monday, parent, true); !errors.Is(err, ErrRoutineFutureDate) {
+		t.Errorf("future: %v", err)
+	}
+	if err := svc.SetStepDone(ctx, rt, uuid.New(), monday, monday, parent, true); !errors.Is(err, ErrRoutineStepNotFound) {
+		t.Errorf("unknown step: %v", err)
+	}
+	if err := svc.SetStepDone(ctx, rt, dressed, monday, monday, parent, true); err != nil {
+		t.Fatal(err)
+	}
+	days, err := svc.Day(ctx, child, monday)
+	if err != nil || len(days) != 1 || days[0].StepsDone != 1 || days[0].StepsTotal != 2 || !days[0].Steps[1].Completed {
+		t.Errorf("day: %+v, %v", days, err)
+	}
+	svc.SetStepDone(ctx, rt, dressed, monday, monday, parent, false)
+	if days, _ := svc.Day(ctx, child, monday); days[0].StepsDone != 0 {
+		t.Errorf("untick: %+v", days)
+	}
+}
+
+func TestRoutineStats(t *testing.T) {
+	start := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC) // Monday
+	end := start.AddDate(0, 0, 6)
+	step := func() models.RoutineStep { return models.RoutineStep{ID: uuid.New()} }
+	bedtime := models.Routine{ID: uuid.New(), Name: "Bedtime", Active: true, CreatedAt: start.AddDate(0, -1, 0),
+		Steps: []models.RoutineStep{step(), step()}}
+	school := models.Routine{ID: uuid.New(), Name: "School", Active: true, CreatedAt: start.AddDate(0, -1, 0),
+		DaysOfWeek: []int{1, 2, 3, 4, 5}, Steps: []models.RoutineStep{step()}}
+	paused := models.Routine{ID: uuid.New(), Name: "Old", Active: false, CreatedAt: start.AddDate(0, -1, 0),
+		Steps: []models.RoutineStep{step()}}
+
+	var completions []models.RoutineCompletion
+	done := func(rt models.Routine, s int, day time.Time) {
+		completions = append(completions, models.RoutineCompletion{StepID: rt.Steps[s].ID, RoutineID: rt.ID, CompletionDate: day})
+	}
+	// Bedtime fully done Wednesday to Saturday and half done Monday;
+	// Sunday (the last day) is still to come.
+	done(bedtime, 0, start)
+	for d := 2; d <= 5; d++ {
+		done(bedtime, 0, start.AddDate(0, 0, d))
+		done(bedtime, 1, start.AddDate(0, 0, d))
+	}
+	done(school, 0, start)
+	done(school, 0, start.AddDate(0, 0, 1))
+
+	sum := routineStats([]models.Routine{bedtime, school, paused}, completions, start, end)
+	if len(sum.Daily) != 7 {
+		t.Fatalf("daily %+v", sum.Daily)
+	}
+	if got := sum.Daily[0].Value; got < 0.66 || got > 0.67 {
+		t.Errorf("Monday: %v, want 2/3", got)
+	}
+	// Weekend days only have bedtime scheduled.
+	if sum.Daily[5].Value != 1 || sum.Daily[6].Value != 0 {
+		t.Errorf("weekend: %+v", sum.Daily[5:])
+	}
+	// 11 of 14 bedtime steps and 2 of 5 school steps, out of 19.
+	if sum.CompletionRate == nil || *sum.CompletionRate != 11.0/19 {
+		t.Errorf("rate %v", sum.CompletionRate)
+	}
+	if len(sum.Routines) != 2 {
+		t.Fatalf("routines %+v", sum.Routines)
+	}
+	bt, sc := sum.Routines[0], sum.Routines[1]
+	if bt.DaysScheduled != 7 || bt.DaysCompleted != 4 || bt.CurrentStreak != 4 {
+		t.Errorf("bedtime %+v", bt)
+	}
+	if sc.DaysScheduled != 5 || sc.DaysCompleted != 2 || sc.CurrentStreak != 0 || sc.CompletionRate != 0.4 {
+		t.Errorf("school %+v", sc)
+	}
+}
+
+func TestRoutineSchedulePDF(t *testing.T) {
+	rt := models.Routine{Name: "Bedtime routine", DaysOfWeek: []int{1, 2, 3, 4, 5}}
+	rt.StartTime.String, rt.StartTime.Valid = "19:30", true
+	mins := 10
+	for i := 0; i < 14; i++ {
+		rt.Steps = append(rt.Steps, models.RoutineStep{Title: "Brush teeth with the timer song", Icon: "brush_teeth", DurationMinutes: &mins})
+	}
+	pdf, err := RoutineSchedulePDF(&models.Child{FirstName: "Zoë"}, []models.Routine{rt})
+	if err != nil || !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Fatalf("pdf: %v", err)
+	}
+	if routineDaysLabel(rt.DaysOfWeek) != "School days" || routineIconLabel("brush_teeth") != "Brush teeth" {
+		t.Errorf("labels %q %q", routineDaysLabel(rt.DaysOfWeek), routineIconLabel("brush_teeth"))
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrRubricInvalid       = apperr.Validation("invalid scoring rubric")
+	ErrRubricChildNotFound = apperr.NotFound("child not found")
+)
+
+func invalidRubric(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrRubricInvalid, fmt.Sprintf(format, args...))
+}
+
+const (
+	maxRubricLabelLen       = 40
+	maxRubricDescriptionLen = 200
+	maxRubricEmojiLen       = 4 // runes; flags and skin tones take more than one
+)
+
+// defaultRubricMetrics is the built-in rubric, version 0, used until a
+// family saves its own.
+func defaultRubricMetrics() models.RubricMetrics {
+	return models.RubricMetrics{
+		models.RubricMetricMood: {
+			{Min: 1, Max: 2, Label: "Very low", Description: "Tearful, withdrawn or distressed most of the time", Emoji: "😢"},
+			{Min: 3, Max: 4, Label: "Low", Description: "Unhappy or irritable for much of the day", Emoji: "🙁"},
+			{Min: 5, Max: 6, Label: "Okay", Description: "Neither especially happy nor upset", Emoji: "😐"},
+			{Min: 7, Max: 8, Label: "Good", Description: "Content and engaged for most of the day", Emoji: "🙂"},
+			{Min: 9, Max: 10, Label: "Great", Description: "Happy, playful and easy to settle", Emoji: "😄"},
+		},
+		models.RubricMetricEnergy: {
+			{Min: 1, Max: 2, Label: "Exhausted", Description: "Hard to rouse, wants to lie down", Emoji: "🪫"},
+			{Min: 3, Max: 4, Label: "Tired", Description: "Slower than usual, needs more breaks", Emoji: "😴"},
+			{Min: 5, Max: 6, Label: "Steady", Description: "Usual energy for the day's activities", Emoji: "🙂"},
+			{Min: 7, Max: 8, Label: "Energetic", Description: "Active and keen to move", Emoji: "⚡"},
+			{Min: 9, Max: 10, Label: "Very high", Description: "Hard to slow down or settle", Emoji: "🚀"},
+		},
+		models.RubricMetricAnxiety: {
+			{Min: 1, Max: 2, Label: "Calm", Description: "Relaxed, handles changes easily", Emoji: "😌"},
+			{Min: 3, Max: 4, Label: "Mildly anxious", Description: "Some worry that passes with reassurance", Emoji: "😕"},
+			{Min: 5, Max: 6, Label: "Anxious", Description: "Noticeable worry that affects activities", Emoji: "😟"},
+			{Min: 7, Max: 8, Label: "Very anxious", Description: "Hard to reassure, avoids situations", Emoji: "😰"},
+			{Min: 9, Max: 10, Label: "Overwhelmed", Description: "Panic or shutdown; needs support to recover", Emoji: "😱"},
+		},
+	}
+}
+
+// RubricLevelFor returns the level describing value under the rubric's
+// metric.
+func RubricLevelFor(r *models.ScoringRubric, metric string, value int) (models.RubricLevel, bool) {
+	if r == nil {
+		return models.RubricLevel{}, false
+	}
+	for _, l := range r.Metrics[metric] {
+		if value >= l.Min && value <= l.Max {
+			return l, true
+		}
+	}
+	return models.RubricLevel{}, false
+}
+
+// describeLevel renders a level as "7/10 Good", or just "7/10" when the
+// rubric doesn't cover it. Emoji are left out; the PDF fonts can't draw
+// them.
+func describeLevel(r *models.ScoringRubric, metric string, value int) string {
+	s := fmt.Sprintf("%d/%d", value, models.RubricMaxLevel)
+	if l, ok := RubricLevelFor(r, metric, value); ok {
+		s += " " + l.Label
+	}
+	return s
+}
+
+// RubricService manages each family's scoring rubric: the labels,
+// descriptions and emoji behind the 1-10 mood, energy and anxiety levels.
+type RubricService struct {
+	repo     repository.RubricRepository
+	children rubricChildSource
+}
+
+type rubricChildSource interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error)
+}
+
+func NewRubricService(repo repository.RubricRepository, children rubricChildSource) *RubricService {
+	return &RubricService{repo: repo, children: children}
+}
+
+func defaultRubric(familyID uuid.UUID) *models.ScoringRubric {
+	return &models.ScoringRubric{FamilyID: familyID, Version: 0, Metrics: defaultRubricMetrics()}
+}
+
+// Current returns the family's newest rubric, or the default.
+func (s *RubricService) Current(ctx context.Context, familyID uuid.UUID) (*models.ScoringRubric, error) {
+	r, err := s.repo.Latest(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return defaultRubric(familyID), nil
+	}
+	return r, nil
+}
+
+func (s *RubricService) childFamily(ctx context.Context, childID uuid.UUID) (uuid.UUID, error) {
+	child, err := s.children.GetByID(ctx, childID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if child == nil {
+		return uuid.Nil, ErrRubricChildNotFound
+	}
+	return child.FamilyID, nil
+}
+
+// ForChild returns the current rubric of the child's family.
+func (s *RubricService) ForChild(ctx context.Context, childID uuid.UUID) (*models.ScoringRubric, error) {
+	familyID, err := s.childFamily(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Current(ctx, familyID)
+}
+
+// CurrentVersionForChild is the version new behavior logs for the child
+// are stamped with.
+func (s *RubricService) CurrentVersionForChild(ctx context.Context, childID uuid.UUID) (int, error) {
+	r, err := s.ForChild(ctx, childID)
+	if err != nil {
+		return 0, err
+	}
+	return r.Version, nil
+}
+
+// VersionsForChild returns the child's family's rubrics for the given
+// versions, keyed by version. Version 0, and any version no longer
+// stored, maps to the default.
+func (s *RubricService) VersionsForChild(ctx context.Context, childID uuid.UUID, versions []int) (map[int]*models.ScoringRubric, error) {
+	familyID, err := s.childFamily(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	var stored []int
+	for _, v := range versions {
+		if v > 0 && !slices.Contains(stored, v) {
+			stored = append(stored, v)
+		}
+	}
+	list, err := s.repo.GetVersions(ctx, familyID, stored)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int]*models.ScoringRubric, len(versions))
+	for i := range list {
+		out[list[i].Version] = &list[i]
+	}
+	for _, v := range versions {
+		if out[v] == nil {
+			out[v] = defaultRubric(familyID)
+		}
+	}
+	return out, nil
+}
+
+// Save stores the family's rubric as a new version. Metrics the request
+// leaves out are carried over from the current version.
+func (s *RubricService) Save(ctx context.Context, familyID, userID uuid.UUID, req *models.ScoringRubricRequest) (*models.ScoringRubric, error) {
+	if len(req.Metrics) == 0 {
+		return nil, invalidRubric("metrics are required")
+	}
+	current, err := s.Current(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	metrics := models.RubricMetrics{}
+	for _, m := range models.RubricMetricNames {
+		metrics[m] = current.Metrics[m]
+	}
+	for m, levels := range req.Metrics {
+		if !slices.Contains(models.RubricMetricNames, m) {
+			return nil, invalidRubric("unknown metric %q; use %s", m, strings.Join(models.RubricMetricNames, ", "))
+		}
+		cleaned, err := validateRubricLevels(m, levels)
+		if err != nil {
+			return nil, err
+		}
+		metrics[m] = cleaned
+	}
+	r := &models.ScoringRubric{FamilyID: familyID, Metrics: metrics, CreatedBy: &userID}
+	if err := s.repo.Create(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// validateRubricLevels checks a metric's levels cover 1-10 exactly once
+// and returns them trimmed and sorted.
+func validateRubricLevels(metric string, levels []models.RubricLevel) ([]models.RubricLevel, error) {
+	if len(levels) == 0 {
+		return nil, invalidRubric("%s needs at least one level", metric)
+	}
+	out := make([]models.RubricLevel, len(levels))
+	for i, l := range levels {
+		l.Label = strings.TrimSpace(l.Label)
+		l.Description = strings.TrimSpace(l.Description)
+		l.Emoji = strings.TrimSpace(l.Emoji)
+		switch {
+		case l.Label == "":
+			return nil, invalidRubric("%s: every level needs a label", metric)
+		case utf8.RuneCountInString(l.Label) > maxRubricLabelLen:
+			return nil, invalidRubric("%s: labels can be at most %d characters", metric, maxRubricLabelLen)
+		case utf8.RuneCountInString(l.Description) > maxRubricDescriptionLen:
+			return nil, invalidRubric("%s: descriptions can be at most %d characters", metric, maxRubricDescriptionLen)
+		case utf8.RuneCountInString(l.Emoji) > maxRubricEmojiLen:
+			return nil, invalidRubric("%s: emoji must be a single emoji", metric)
+		case l.Min > l.Max:
+			return nil, invalidRubric("%s: level %q has min above max", metric, l.Label)
+		}
+		out[i] = l
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Min < out[j].Min })
+	next := models.RubricMinLevel
+	for _, l := range out {
+		if l.Min != next {
+			if l.Min < next {
+				return nil, invalidRubric("%s: levels overlap at %d", metric, l.Min)
+			}
+			return nil, invalidRubric("%s: nothing describes %d", metric, next)
+		}
+		next = l.Max + 1
+	}
+	if next != models.RubricMaxLevel+1 {
+		if next > models.RubricMaxLevel+1 {
+			return nil, invalidRubric("%s: levels go past %d", metric, models.RubricMaxLevel)
+		}
+		return nil, invalidRubric("%s: nothing describes %d", metric, next)
+	}
+	return out, nil
+}
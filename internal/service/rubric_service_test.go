@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeRubricRepo struct {
+	rubrics []models.ScoringRubric
+}
+
+func (f *fakeRubricRepo) Create(ctx context.Context, r *models.ScoringRubric) error {
+	r.Version = len(f.rubrics) + 1
+	f.rubrics = append(f.rubrics, *r)
+	return nil
+}
+
+func (f *fakeRubricRepo) Latest(ctx context.Context, familyID uuid.UUID) (*models.ScoringRubric, error) {
+	if len(f.rubrics) == 0 {
+		return nil, nil
+	}
+	r := f.rubrics[len(f.rubrics)-1]
+	return &r, nil
+}
+
+func (f *fakeRubricRepo) GetVersions(ctx context.Context, familyID uuid.UUID, versions []int) ([]models.ScoringRubric, error) {
+	var out []models.ScoringRubric
+	for _, r := range f.rubrics {
+		for _, v := range versions {
+			if r.Version == v {
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+type fakeRubricChildren struct {
+	child *models.Child
+}
+
+func (f fakeRubricChildren) GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error) {
+	return f.child, nil
+}
+
+func TestValidateRubricLevels(t *testing.T) {
+	ok := []models.RubricLevel{
+		{Min: 6, Max: 10, Label: " Good "},
+		{Min: 1, Max: 5, Label: "Not good", Emoji: "👎"},
+	}
+	got, err := validateRubricLevels("mood", ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Min != 1 || got[1].Label != "Good" {
+		t.Errorf("levels not sorted and trimmed: %+v", got)
+	}
+
+	for name, tc := range map[string]struct {
+		levels []models.RubricLevel
+		want   string
+	}{
+		"gap":          {[]models.RubricLevel{{Min: 1, Max: 4, Label: "a"}, {Min: 6, Max: 10, Label: "b"}}, "nothing describes 5"},
+		"overlap":      {[]models.RubricLevel{{Min: 1, Max: 6, Label: "a"}, {Min: 5, Max: 10, Label: "b"}}, "overlap at 5"},
+		"short":        {[]models.RubricLevel{{Min: 1, Max: 9, Label: "a"}}, "nothing describes 10"},
+		"too far":      {[]models.RubricLevel{{Min: 1, Max: 12, Label: "a"}}, "past 10"},
+		"starts late":  {[]models.RubricLevel{{Min: 2, Max: 10, Label: "a"}}, "nothing describes 1"},
+		"no label":     {[]models.RubricLevel{{Min: 1, Max: 10, Label: "  "}}, "needs a label"},
+		"inverted":     {[]models.RubricLevel{{Min: 10, Max: 1, Label: "a"}}, "min above max"},
+		"emoji string": {[]models.RubricLevel{{Min: 1, Max: 10, Label: "a", Emoji: "happy face"}}, "single emoji"},
+	} {
+		_, err := validateRubricLevels("mood", tc.levels)
+		if !errors.Is(err, ErrRubricInvalid) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.want)
+		}
+	}
+
+	for metric, levels := range defaultRubricMetrics() {
+		if _, err := validateRubricLevels(metric, levels); err != nil {
+			t.Errorf("default %s rubric: %v", metric, err)
+		}
+	}
+}
+
+func TestRubricService(t *testing.T) {
+	ctx := context.Background()
+	familyID, userID := uuid.New(), uuid.New()
+	repo := &fakeRubricRepo{}
+	svc := NewRubricService(repo, fakeRubricChildren{child: &models.Child{ID: uuid.New(), FamilyID: familyID}})
+
+	current, err := svc.Current(ctx, familyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Version != 0 || describeLevel(current, models.RubricMetricMood, 7) != "7/10 Good" {
+		t.Fatalf("default rubric: version %d, mood 7 = %q", current.Version, describeLevel(current, models.RubricMetricMood, 7))
+	}
+
+	saved, err := svc.Save(ctx, familyID, userID, &models.ScoringRubricRequest{Metrics: models.RubricMetrics{
+		models.RubricMetricMood: {{Min: 1, Max: 5, Label: "Rough day"}, {Min: 6, Max: 10, Label: "Good day"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != 1 {
+		t.Errorf("version = %d, want 1", saved.Version)
+	}
+	if got := describeLevel(saved, models.RubricMetricEnergy, 2); got != "2/10 Exhausted" {
+		t.Errorf("metrics left out should carry over: energy 2 = %q", got)
+	}
+	if _, err := svc.Save(ctx, familyID, userID, &models.ScoringRubricRequest{Metrics: models.RubricMetrics{
+		"focus": {{Min: 1, Max: 10, Label: "a"}},
+	}}); !errors.Is(err, ErrRubricInvalid) {
+		t.Errorf("unknown metric: err = %v", err)
+	}
+
+	v, err := svc.CurrentVersionForChild(ctx, uuid.New())
+	if err != nil || v != 1 {
+		t.Errorf("CurrentVersionForChild = %d, %v", v, err)
+	}
+
+	// Logs keep the meaning of the version they were entered under.
+	byVersion, err := svc.VersionsForChild(ctx, uuid.New(), []int{0, 1, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := describeLevel(byVersion[0], models.RubricMetricMood, 4); got != "4/10 Low" {
+		t.Errorf("version 0 mood 4 = %q", got)
+	}
+	if got := describeLevel(byVersion[1], models.RubricMetricMood, 4); got != "4/10 Rough day" {
+		t.Errorf("version 1 mood 4 = %q", got)
+	}
+	if byVersion[7] == nil || byVersion[7].Version != 0 {
+		t.Errorf("missing version should fall back to the default: %+v", byVersion[7])
+	}
+	if got := describeLevel(nil, models.RubricMetricMood, 4); got != "4/10" {
+		t.Errorf("no rubric: %q", got)
+	}
+}
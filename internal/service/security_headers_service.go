@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var ErrSecurityHeadersInvalid = apperr.Validation("invalid security header settings")
+
+const (
+	// SecurityHeadersSettingKey is the system_settings key holding
+	// SecurityHeaderSettings.
+	SecurityHeadersSettingKey = "security_headers"
+	// securitySettingsTTL is how long each instance serves its cached
+	// settings; a change on another instance shows up within this.
+	securitySettingsTTL = time.Minute
+	// cspReportWindow drops repeats of the same violation, so one broken
+	// page viewed a thousand times is one error log row, not a thousand.
+	cspReportWindow = 10 * time.Minute
+)
+
+// Frame option values for SecurityHeaderSettings.FrameOptions.
+const (
+	FrameOptionsSameOrigin = "SAMEORIGIN"
+	FrameOptionsDeny       = "DENY"
+	FrameOptionsOff        = "off"
+)
+
+// SecurityHeaderSettings are the response headers admins can change at
+// runtime. The CSP itself comes from config, per environment.
+type SecurityHeaderSettings struct {
+	HSTSEnabled           bool `json:"hsts_enabled"`
+	HSTSMaxAgeDays        int  `json:"hsts_max_age_days"`
+	HSTSIncludeSubdomains bool `json:"hsts_include_subdomains"`
+	// FrameOptions is the X-Frame-Options value, or "off" for none.
+	// SAMEORIGIN (not DENY) by default so the parent-facing /reports page
+	// can embed the signed PDF report in its own iframe.
+	FrameOptions string `json:"frame_options"`
+}
+
+// DefaultSecurityHeaderSettings applies until an admin saves settings.
+// HSTS starts off: once a browser has seen it, the site can't go back to
+// plain HTTP for max-age.
+var DefaultSecurityHeaderSettings = SecurityHeaderSettings{
+	HSTSMaxAgeDays: 180,
+	FrameOptions:   FrameOptionsSameOrigin,
+}
+
+// Validate checks the max-age range and the frame option value.
+func (s SecurityHeaderSettings) Validate() error {
+	if s.HSTSMaxAgeDays < 1 || s.HSTSMaxAgeDays > 730 {
+		return fmt.Errorf("%w: hsts_max_age_days must be 1-730", ErrSecurityHeadersInvalid)
+	}
+	switch s.FrameOptions {
+	case FrameOptionsSameOrigin, FrameOptionsDeny, FrameOptionsOff:
+	default:
+		return fmt.Errorf("%w: frame_options must be SAMEORIGIN, DENY or off", ErrSecurityHeadersInvalid)
+	}
+	return nil
+}
+
+// CSPViolation is one violation report, from either the report-uri or the
+// Reporting API format.
+type CSPViolation struct {
+	DocumentURI string
+	Directive   string
+	BlockedURI  string
+	SourceFile  string
+	Line        int
+	// Disposition is "enforce" or "report" (report-only policy).
+	Disposition string
+}
+
+// SecurityHeadersService stores the header settings, serves them cached to
+// the per-request middleware, and records CSP violation reports.
+type SecurityHeadersService struct {
+	settings settingsStore
+	reports  repository.CSPReportRepository
+	now      func() time.Time
+
+	mu       sync.Mutex
+	cached   SecurityHeaderSettings
+	cachedAt time.Time
+	seen     map[string]time.Time
+}
+
+func NewSecurityHeadersService(settings settingsStore, reports repository.CSPReportRepository) *SecurityHeadersService {
+	return &SecurityHeadersService{
+		settings: settings,
+		reports:  reports,
+		now:      time.Now,
+		cached:   DefaultSecurityHeaderSettings,
+		seen:     map[string]time.Time{},
+	}
+}
+
+// Settings reads the stored settings, with defaults when none are stored
+// or they don't validate.
+func (s *SecurityHeadersService) Settings(ctx context.Context) (SecurityHeaderSettings, error) {
+	out := DefaultSecurityHeaderSettings
+	val, err := s.settings.GetSetting(ctx, SecurityHeadersSettingKey)
+	if err != nil || val == nil {
+		return out, err
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return out, err
+	}
+	var stored SecurityHeaderSettings
+	if err := json.Unmarshal(raw, &stored); err != nil || stored.Validate() != nil {
+		log.Printf("[SECURITY] %s setting unreadable, using defaults: %v", SecurityHeadersSettingKey, err)
+		return out, nil
+	}
+	return stored, nil
+}
+
+// Current is Settings cached for securitySettingsTTL, for the middleware.
+// A failed read keeps serving the last good settings.
+func (s *SecurityHeadersService) Current(ctx context.Context) SecurityHeaderSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cachedAt.IsZero() && s.now().Sub(s.cachedAt) < securitySettingsTTL {
+		return s.cached
+	}
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		log.Printf("[SECURITY] load settings: %v", err)
+		settings = s.cached
+	}
+	s.cached, s.cachedAt = settings, s.now()
+	return s.cached
+}
+
+// UpdateSettings validates and stores new settings. This instance applies
+// them at once; others within securitySettingsTTL.
+func (s *SecurityHeadersService) UpdateSettings(ctx context.Context, settings SecurityHeaderSettings, by uuid.UUID) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if err := s.settings.UpdateSetting(ctx, SecurityHeadersSettingKey, settings, by); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cached, s.cachedAt = settings, s.now()
+	s.mu.Unlock()
+	return nil
+}
+
+// RecordCSPViolation writes v to error_logs unless the same violation was
+// recorded in the last cspReportWindow. It reports whether it wrote.
+func (s *SecurityHeadersService) RecordCSPViolation(ctx context.Context, v CSPViolation, userAgent, ip string) (bool, error) {
+	path := v.DocumentURI
+	if u, err := url.Parse(v.DocumentURI); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	key := strings.Join([]string{v.Directive, v.BlockedURI, path, v.SourceFile}, "\x00")
+
+	s.mu.Lock()
+	now := s.now()
+	if last, ok := s.seen[key]; ok && now.Sub(last) < cspReportWindow {
+		s.mu.Unlock()
+		return false, nil
+	}
+	if len(s.seen) >= 1000 {
+		for k, t := range s.seen {
+			if now.Sub(t) >= cspReportWindow {
+				delete(s.seen, k)
+			}
+		}
+	}
+	if len(s.seen) < 5000 {
+		s.seen[key] = now
+	}
+	s.mu.Unlock()
+
+	return true, s.reports.RecordViolation(ctx, path, cspViolationMessage(v), userAgent, ip)
+}
+
+// cspViolationMessage is the error_message for v, e.g.
+// "[report-only] script-src-elem blocked inline at /static/js/app.js:12".
+func cspViolationMessage(v CSPViolation) string {
+	var b strings.Builder
+	if v.Disposition == "report" {
+		b.WriteString("[report-only] ")
+	}
+	blocked := v.BlockedURI
+	if blocked == "" {
+		blocked = "inline"
+	}
+	fmt.Fprintf(&b, "%s blocked %s", v.Directive, blocked)
+	if v.SourceFile != "" {
+		fmt.Fprintf(&b, " at %s", v.SourceFile)
+		if v.Line > 0 {
+			fmt.Fprintf(&b, ":%d", v.Line)
+		}
+	}
+	msg := b.String()
+	if len(msg) > 1000 {
+		msg = msg[:1000]
+	}
+	return msg
+}
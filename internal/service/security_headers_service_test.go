@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cspReports records RecordViolation calls.
+type cspReports struct {
+	paths, messages []string
+}
+
+func (r *cspReports) RecordViolation(ctx context.Context, path, message, userAgent, ip string) error {
+	r.paths = append(r.paths, path)
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func TestSecurityHeaderSettingsValidate(t *testing.T) {
+	if err := DefaultSecurityHeaderSettings.Validate(); err != nil {
+		t.Fatalf("defaults invalid: %v", err)
+	}
+	for _, s := range []SecurityHeaderSettings{
+		{HSTSMaxAgeDays: 0, FrameOptions: FrameOptionsDeny},
+		{HSTSMaxAgeDays: 731, FrameOptions: FrameOptionsDeny},
+		{HSTSMaxAgeDays: 30, FrameOptions: "ALLOW-FROM https://example.com"},
+	} {
+		if err := s.Validate(); !errors.Is(err, ErrSecurityHeadersInvalid) {
+			t.Errorf("%+v: err = %v, want ErrSecurityHeadersInvalid", s, err)
+		}
+	}
+}
+
+func TestSecurityHeadersCurrentCached(t *testing.T) {
+	settings := memSettings{}
+	svc := NewSecurityHeadersService(settings, &cspReports{})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if got := svc.Current(ctx); got != DefaultSecurityHeaderSettings {
+		t.Fatalf("no setting = %+v, want defaults", got)
+	}
+
+	// Another instance saves; this one sees it once the cache expires.
+	other := SecurityHeaderSettings{HSTSEnabled: true, HSTSMaxAgeDays: 365, FrameOptions: FrameOptionsDeny}
+	settings[SecurityHeadersSettingKey] = map[string]interface{}{
+		"hsts_enabled": true, "hsts_max_age_days": 365, "frame_options": "DENY",
+	}
+	if got := svc.Current(ctx); got != DefaultSecurityHeaderSettings {
+		t.Errorf("within TTL = %+v, want cached defaults", got)
+	}
+	now = now.Add(securitySettingsTTL)
+	if got := svc.Current(ctx); got != other {
+		t.Errorf("after TTL = %+v, want %+v", got, other)
+	}
+
+	// A local save applies at once.
+	mine := SecurityHeaderSettings{HSTSMaxAgeDays: 30, FrameOptions: FrameOptionsOff}
+	if err := svc.UpdateSettings(ctx, mine, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.Current(ctx); got != mine {
+		t.Errorf("after update = %+v, want %+v", got, mine)
+	}
+	if err := svc.UpdateSettings(ctx, SecurityHeaderSettings{}, uuid.New()); !errors.Is(err, ErrSecurityHeadersInvalid) {
+		t.Errorf("invalid update err = %v", err)
+	}
+}
+
+func TestRecordCSPViolationDedupe(t *testing.T) {
+	reports := &cspReports{}
+	svc := NewSecurityHeadersService(memSettings{}, reports)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	v := CSPViolation{
+		DocumentURI: "https://app.example.com/dashboard?child=1",
+		Directive:   "script-src-elem",
+		SourceFile:  "https://app.example.com/static/js/app.js",
+		Line:        12,
+		Disposition: "report",
+	}
+	record := func(v CSPViolation) bool {
+		wrote, err := svc.RecordCSPViolation(ctx, v, "ua", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wrote
+	}
+
+	if !record(v) {
+		t.Fatal("first report not written")
+	}
+	if reports.paths[0] != "/dashboard" {
+		t.Errorf("path = %q, want /dashboard", reports.paths[0])
+	}
+	want := "[report-only] script-src-elem blocked inline at https://app.example.com/static/js/app.js:12"
+	if reports.messages[0] != want {
+		t.Errorf("message = %q, want %q", reports.messages[0], want)
+	}
+
+	// The same violation from another query string is a repeat.
+	v.DocumentURI = "https://app.example.com/dashboard?child=2"
+	if record(v) {
+		t.Error("repeat within window written")
+	}
+	other := v
+	other.BlockedURI = "https://evil.example.net/x.js"
+	if !record(other) {
+		t.Error("different blocked URI not written")
+	}
+	now = now.Add(cspReportWindow)
+	if !record(v) {
+		t.Error("repeat after window not written")
+	}
+}
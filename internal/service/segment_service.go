@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrSegmentInvalid  = apperr.Validation("invalid segment")
+	ErrSegmentNotFound = apperr.NotFound("segment not found")
+)
+
+const (
+	maxSegmentNameLen = 100
+	maxSegmentLocales = 20
+	maxSegmentPlans   = 20
+)
+
+// Promo usage filter values.
+const (
+	SegmentPromoUsed  = "used"
+	SegmentPromoNever = "never"
+)
+
+// AudienceResolver is how a sender targets users. Announcements and email
+// digests resolve Recipients at send time; experiments check Includes when
+// assigning a user to a variant. A nil segment means every active user, so
+// existing "send to everyone" paths keep working by passing nil.
+type AudienceResolver interface {
+	Recipients(ctx context.Context, segmentID *uuid.UUID) ([]repository.SegmentMember, error)
+	Includes(ctx context.Context, segmentID *uuid.UUID, userID uuid.UUID) (bool, error)
+}
+
+// SegmentService manages saved customer segments and resolves them to
+// users for targeted messaging.
+type SegmentService struct {
+	repo repository.SegmentRepository
+}
+
+// NewSegmentService creates a new segment service
+func NewSegmentService(repo repository.SegmentRepository) *SegmentService {
+	return &SegmentService{repo: repo}
+}
+
+// SegmentInput is the create/update body.
+type SegmentInput struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Filters     repository.SegmentFilters `json:"filters"`
+}
+
+// SegmentWithCount is a saved segment and its current size.
+type SegmentWithCount struct {
+	repository.Segment
+	Users int `json:"users"`
+}
+
+// normalizeSegmentFilters validates f and returns it with locales trimmed
+// and duplicates dropped.
+func normalizeSegmentFilters(f repository.SegmentFilters) (repository.SegmentFilters, error) {
+	for _, v := range []struct {
+		name string
+		val  *int
+		max  int
+	}{
+		{"tenure_min_days", f.TenureMinDays, 36500},
+		{"tenure_max_days", f.TenureMaxDays, 36500},
+		{"score_min", f.ScoreMin, 100},
+		{"score_max", f.ScoreMax, 100},
+	} {
+		if v.val != nil && (*v.val < 0 || *v.val > v.max) {
+			return f, fmt.Errorf("%w: %s must be between 0 and %d", ErrSegmentInvalid, v.name, v.max)
+		}
+	}
+	if f.TenureMinDays != nil && f.TenureMaxDays != nil && *f.TenureMinDays > *f.TenureMaxDays {
+		return f, fmt.Errorf("%w: tenure_min_days is after tenure_max_days", ErrSegmentInvalid)
+	}
+	if f.ScoreMin != nil && f.ScoreMax != nil && *f.ScoreMin > *f.ScoreMax {
+		return f, fmt.Errorf("%w: score_min is above score_max", ErrSegmentInvalid)
+	}
+	switch f.PromoUsage {
+	case "", SegmentPromoUsed, SegmentPromoNever:
+	default:
+		return f, fmt.Errorf("%w: promo_usage must be %q or %q", ErrSegmentInvalid, SegmentPromoUsed, SegmentPromoNever)
+	}
+	if len(f.PlanIDs) > maxSegmentPlans {
+		return f, fmt.Errorf("%w: at most %d plans", ErrSegmentInvalid, maxSegmentPlans)
+	}
+
+	seen := map[string]bool{}
+	var locales []string
+	for _, l := range f.Locales {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] {
+			continue
+		}
+		if len(l) > 10 {
+			return f, fmt.Errorf("%w: locale %q is too long", ErrSegmentInvalid, l)
+		}
+		seen[l] = true
+		locales = append(locales, l)
+	}
+	if len(locales) > maxSegmentLocales {
+		return f, fmt.Errorf("%w: at most %d locales", ErrSegmentInvalid, maxSegmentLocales)
+	}
+	f.Locales = locales
+	return f, nil
+}
+
+func (in SegmentInput) apply(s *repository.Segment) error {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrSegmentInvalid)
+	}
+	filters, err := normalizeSegmentFilters(in.Filters)
+	if err != nil {
+		return err
+	}
+	s.Name = truncateRunes(name, maxSegmentNameLen)
+	s.Description = strings.TrimSpace(in.Description)
+	s.Filters = filters
+	return nil
+}
+
+// CreateSegment saves a new segment.
+func (s *SegmentService) CreateSegment(ctx context.Context, in SegmentInput, createdBy uuid.UUID) (*repository.Segment, error) {
+	seg := &repository.Segment{CreatedBy: models.NullUUID{UUID: createdBy, Valid: createdBy != uuid.Nil}}
+	if err := in.apply(seg); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, seg); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+// UpdateSegment replaces a segment's name, description and filters.
+// Anything targeting the segment picks the new filters up on its next
+// resolve.
+func (s *SegmentService) UpdateSegment(ctx context.Context, id uuid.UUID, in SegmentInput) (*repository.Segment, error) {
+	seg, err := s.GetSegment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.apply(seg); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, seg); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+// DeleteSegment removes a saved segment.
+func (s *SegmentService) DeleteSegment(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSegmentNotFound
+	}
+	return err
+}
+
+// GetSegment returns one segment or ErrSegmentNotFound.
+func (s *SegmentService) GetSegment(ctx context.Context, id uuid.UUID) (*repository.Segment, error) {
+	seg, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if seg == nil {
+		return nil, ErrSegmentNotFound
+	}
+	return seg, nil
+}
+
+// ListSegments returns saved segments with their current sizes.
+func (s *SegmentService) ListSegments(ctx context.Context) ([]SegmentWithCount, error) {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SegmentWithCount, len(list))
+	for i, seg := range list {
+		n, err := s.repo.Count(ctx, seg.Filters)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = SegmentWithCount{Segment: seg, Users: n}
+	}
+	return out, nil
+}
+
+// Preview counts the users unsaved filters would match, for the builder's
+// live count.
+func (s *SegmentService) Preview(ctx context.Context, f repository.SegmentFilters) (int, error) {
+	f, err := normalizeSegmentFilters(f)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.Count(ctx, f)
+}
+
+// Options lists the plans and locales the builder can filter on.
+func (s *SegmentService) Options(ctx context.Context) (*repository.SegmentOptions, error) {
+	return s.repo.Options(ctx)
+}
+
+// filtersFor returns the filters a segment ID stands for; nil means no
+// filters (all active users).
+func (s *SegmentService) filtersFor(ctx context.Context, segmentID *uuid.UUID) (repository.SegmentFilters, error) {
+	if segmentID == nil {
+		return repository.SegmentFilters{}, nil
+	}
+	seg, err := s.GetSegment(ctx, *segmentID)
+	if err != nil {
+		return repository.SegmentFilters{}, err
+	}
+	return seg.Filters, nil
+}
+
+// Recipients returns the members of segmentID as of now, or every active
+// user when segmentID is nil. A deleted segment is an error rather than an
+// empty audience, so a send can't silently go to nobody.
+func (s *SegmentService) Recipients(ctx context.Context, segmentID *uuid.UUID) ([]repository.SegmentMember, error) {
+	f, err := s.filtersFor(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.Members(ctx, f)
+	if members == nil {
+		members = []repository.SegmentMember{}
+	}
+	return members, err
+}
+
+// Includes reports whether userID is in segmentID (always true for an
+// active user when segmentID is nil).
+func (s *SegmentService) Includes(ctx context.Context, segmentID *uuid.UUID, userID uuid.UUID) (bool, error) {
+	f, err := s.filtersFor(ctx, segmentID)
+	if err != nil {
+		return false, err
+	}
+	return s.repo.Contains(ctx, f, userID)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+var _ AudienceResolver = (*SegmentService)(nil)
+
+func TestNormalizeSegmentFilters(t *testing.T) {
+	n := func(v int) *int { return &v }
+	bad := []repository.SegmentFilters{
+		{ScoreMin: n(-1)},
+		{ScoreMax: n(101)},
+		{ScoreMin: n(60), ScoreMax: n(40)},
+		{TenureMinDays: n(90), TenureMaxDays: n(30)},
+		{PromoUsage: "sometimes"},
+		{Locales: []string{"this-is-not-a-locale"}},
+	}
+	for _, f := range bad {
+		if _, err := normalizeSegmentFilters(f); !errors.Is(err, ErrSegmentInvalid) {
+			t.Errorf("%+v: err = %v, want ErrSegmentInvalid", f, err)
+		}
+	}
+
+	f, err := normalizeSegmentFilters(repository.SegmentFilters{
+		ScoreMin:   n(0),
+		ScoreMax:   n(40),
+		PromoUsage: SegmentPromoNever,
+		Locales:    []string{" es ", "es", "", "pt-BR"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Locales) != 2 || f.Locales[0] != "es" || f.Locales[1] != "pt-BR" {
+		t.Errorf("locales = %q", f.Locales)
+	}
+}
+
+type fakeSegmentRepo struct {
+	repository.SegmentRepository
+	segments map[uuid.UUID]*repository.Segment
+	resolved []repository.SegmentFilters
+}
+
+func (f *fakeSegmentRepo) GetByID(ctx context.Context, id uuid.UUID) (*repository.Segment, error) {
+	return f.segments[id], nil
+}
+
+func (f *fakeSegmentRepo) Members(ctx context.Context, filters repository.SegmentFilters) ([]repository.SegmentMember, error) {
+	f.resolved = append(f.resolved, filters)
+	return nil, nil
+}
+
+func (f *fakeSegmentRepo) Contains(ctx context.Context, filters repository.SegmentFilters, userID uuid.UUID) (bool, error) {
+	f.resolved = append(f.resolved, filters)
+	return true, nil
+}
+
+func TestSegmentRecipients(t *testing.T) {
+	spanish := &repository.Segment{ID: uuid.New(), Filters: repository.SegmentFilters{Locales: []string{"es"}}}
+	repo := &fakeSegmentRepo{segments: map[uuid.UUID]*repository.Segment{spanish.ID: spanish}}
+	svc := NewSegmentService(repo)
+	ctx := context.Background()
+
+	members, err := svc.Recipients(ctx, nil)
+	if err != nil || members == nil {
+		t.Fatalf("all users: %v, %v", members, err)
+	}
+	if len(repo.resolved[0].Locales) != 0 {
+		t.Errorf("nil segment should resolve with no filters, got %+v", repo.resolved[0])
+	}
+
+	if _, err := svc.Recipients(ctx, &spanish.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.resolved[1].Locales; len(got) != 1 || got[0] != "es" {
+		t.Errorf("segment resolved with %+v", repo.resolved[1])
+	}
+
+	missing := uuid.New()
+	if _, err := svc.Recipients(ctx, &missing); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("deleted segment: err = %v, want ErrSegmentNotFound", err)
+	}
+	if _, err := svc.Includes(ctx, &missing, uuid.New()); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("Includes on deleted segment: err = %v, want ErrSegmentNotFound", err)
+	}
+}
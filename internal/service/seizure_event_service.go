@@ -0,0 +1,547 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrSeizureEventNotFound      = apperr.NotFound("seizure event not found")
+	ErrSeizureEventActive        = errors.New("a seizure is already being timed for this child")
+	ErrSeizureEventEnded         = errors.New("seizure event has already ended")
+	ErrSeizureChecklistItem      = errors.New("unknown checklist item")
+	ErrSeizureEventInvalidZone   = apperr.Validation("invalid timezone")
+	ErrSeizureSummaryUnavailable = errors.New("incident summary is available once the seizure has ended")
+)
+
+// Seizure checklist item kinds.
+const (
+	SeizureChecklistFirstAid   = "first_aid"
+	SeizureChecklistRescueMed  = "rescue_medication"
+	SeizureChecklistEmergency  = "emergency"
+	seizureChecklistCall911Key = "call_911"
+)
+
+const (
+	// seizureEmergencySeconds is when a seizure is usually treated as an
+	// emergency: rescue medication and 911 are due from here.
+	seizureEmergencySeconds = 5 * 60
+	// seizureLogMaxSeconds is the longest duration a seizure log holds.
+	seizureLogMaxSeconds = 3600
+	// seizureHistoryDays is how far back the incident summary looks.
+	seizureHistoryDays = 90
+	// SeizureSummaryLinkTTL is how long a shared incident summary link
+	// works.
+	SeizureSummaryLinkTTL = 14 * 24 * time.Hour
+)
+
+// seizureFirstAid are the checklist's first-aid steps, before the child's
+// rescue medications.
+var seizureFirstAid = []repository.SeizureChecklistItem{
+	{Key: "stay_safe", Kind: SeizureChecklistFirstAid, Label: "Stay with them and move hard or sharp objects away"},
+	{Key: "on_side", Kind: SeizureChecklistFirstAid, Label: "Turn them onto their side when you can",
+		Detail: "Nothing in the mouth. Loosen anything tight around the neck."},
+	{Key: "observe", Kind: SeizureChecklistFirstAid, Label: "Notice what the seizure looks like",
+		Detail: "Which parts of the body move, the eyes, breathing and color."},
+}
+
+// rescueMedicationNames are seizure rescue medications by generic and
+// brand name, matched against a medication's name.
+var rescueMedicationNames = []string{
+	"diazepam", "diastat", "valtoco", "midazolam", "nayzilam", "buccolam",
+	"lorazepam", "ativan", "clonazepam", "klonopin",
+}
+
+type seizureLogStore interface {
+	CreateSeizureLog(ctx context.Context, childID, loggedBy uuid.UUID, req *models.CreateSeizureLogRequest) (*models.SeizureLog, error)
+	GetSeizureLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SeizureLog, error)
+	GetSeizureLogByID(ctx context.Context, id uuid.UUID) (*models.SeizureLog, error)
+}
+
+type seizureMedicationSource interface {
+	GetByChildID(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Medication, error)
+}
+
+type seizureChildSource interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error)
+}
+
+type seizureFamilySource interface {
+	GetMembers(ctx context.Context, familyID uuid.UUID) ([]models.FamilyMembership, error)
+}
+
+type seizurePusher interface {
+	Send(ctx context.Context, userID uuid.UUID, msg PushMessage) error
+}
+
+// SeizureStopDetails is what the caregiver adds when stopping the timer;
+// it goes into the seizure log with the measured duration.
+type SeizureStopDetails struct {
+	SeizureType       string   `json:"seizure_type,omitempty"`
+	Triggers          []string `json:"triggers,omitempty"`
+	WarningSigns      []string `json:"warning_signs,omitempty"`
+	PostIctalSymptoms []string `json:"post_ictal_symptoms,omitempty"`
+	Notes             string   `json:"notes,omitempty"`
+	// Called911 is also set when the checklist's 911 step was ticked.
+	Called911 bool `json:"called_911"`
+}
+
+// SeizureIncidentSummary is one seizure written up for the child's
+// neurologist: what happened when, what was given, and how it compares
+// with the child's recent seizures.
+type SeizureIncidentSummary struct {
+	EventID         uuid.UUID              `json:"event_id"`
+	ChildID         uuid.UUID              `json:"child_id"`
+	ChildName       string                 `json:"child_name"`
+	AgeYears        int                    `json:"age_years"`
+	StartedAt       time.Time              `json:"started_at"`
+	EndedAt         time.Time              `json:"ended_at"`
+	Timezone        string                 `json:"timezone"`
+	DurationSeconds int                    `json:"duration_seconds"`
+	Log             *models.SeizureLog     `json:"log,omitempty"`
+	Timeline        []SeizureTimelineEntry `json:"timeline"`
+	History         SeizureHistory         `json:"history"`
+}
+
+// SeizureTimelineEntry is one moment of a seizure, OffsetSeconds after it
+// started.
+type SeizureTimelineEntry struct {
+	At            time.Time `json:"at"`
+	OffsetSeconds int       `json:"offset_seconds"`
+	Label         string    `json:"label"`
+}
+
+// SeizureHistory summarises the child's other logged seizures in the
+// days before this one.
+type SeizureHistory struct {
+	Days                   int  `json:"days"`
+	Seizures               int  `json:"seizures"`
+	AverageDurationSeconds *int `json:"average_duration_seconds,omitempty"`
+	LongestDurationSeconds *int `json:"longest_duration_seconds,omitempty"`
+	RescueMedicationUses   int  `json:"rescue_medication_uses"`
+	Called911              int  `json:"called_911"`
+}
+
+// SeizureEventService runs the seizure timer: a live event per seizure
+// with a first-aid and rescue medication checklist, family push alerts on
+// start and stop, a seizure log filled in on stop, and the incident
+// summary shared with the neurologist.
+type SeizureEventService struct {
+	repo          repository.SeizureEventRepository
+	logs          seizureLogStore
+	meds          seizureMedicationSource
+	children      seizureChildSource
+	family        seizureFamilySource
+	push          seizurePusher
+	drain         *Drain
+	appURL        string
+	signingSecret []byte
+	now           func() time.Time
+}
+
+// NewSeizureEventService creates the seizure timer service. push may be
+// nil; family members then aren't alerted. signingSecret signs shared
+// incident summary links.
+func NewSeizureEventService(repo repository.SeizureEventRepository, logs seizureLogStore, meds seizureMedicationSource,
+	children seizureChildSource, family seizureFamilySource, push seizurePusher, drain *Drain, appURL, signingSecret string) *SeizureEventService {
+	return &SeizureEventService{repo: repo, logs: logs, meds: meds, children: children, family: family, push: push,
+		drain: drain, appURL: appURL, signingSecret: []byte(signingSecret), now: time.Now}
+}
+
+// Start begins timing a seizure for child and alerts the rest of the
+// family. timezone (IANA, "" for UTC) is the caregiver's, for the log's
+// local date and time. When the child already has a live event it is
+// returned with ErrSeizureEventActive.
+func (s *SeizureEventService) Start(ctx context.Context, child *models.Child, by uuid.UUID, timezone string) (*repository.SeizureEvent, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, ErrSeizureEventInvalidZone
+	}
+	checklist, err := s.checklist(ctx, child.ID)
+	if err != nil {
+		return nil, err
+	}
+	e := &repository.SeizureEvent{
+		ChildID:   child.ID,
+		StartedAt: s.now().UTC().Truncate(time.Second),
+		StartedBy: &by,
+		Timezone:  timezone,
+		Checklist: checklist,
+	}
+	if err := s.repo.Create(ctx, e); err != nil {
+		if errors.Is(err, repository.ErrSeizureEventActive) {
+			active, aerr := s.repo.Active(ctx, child.ID)
+			if aerr != nil {
+				return nil, aerr
+			}
+			return active, ErrSeizureEventActive
+		}
+		return nil, err
+	}
+	s.alertFamily(child, by, PushMessage{
+		Title: fmt.Sprintf("Seizure started: %s", child.FirstName),
+		Body:  fmt.Sprintf("A seizure timer was started for %s. Open the app to follow along.", child.FirstName),
+	}, e, "started")
+	return e, nil
+}
+
+// checklist builds a new event's checklist: first aid, then each of the
+// child's active rescue medications, then 911.
+func (s *SeizureEventService) checklist(ctx context.Context, childID uuid.UUID) ([]repository.SeizureChecklistItem, error) {
+	items := append([]repository.SeizureChecklistItem{}, seizureFirstAid...)
+	meds, err := s.meds.GetByChildID(ctx, childID, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range meds {
+		if !isRescueMedication(m) {
+			continue
+		}
+		id := m.ID
+		item := repository.SeizureChecklistItem{
+			Key:             "rescue_med:" + m.ID.String(),
+			Kind:            SeizureChecklistRescueMed,
+			Label:           strings.TrimSpace(fmt.Sprintf("Give %s %s %s", m.Name, m.Dosage, m.DosageUnit)),
+			MedicationID:    &id,
+			DueAfterSeconds: seizureEmergencySeconds,
+		}
+		if m.Instructions.Valid {
+			item.Detail = m.Instructions.String
+		}
+		items = append(items, item)
+	}
+	return append(items, repository.SeizureChecklistItem{
+		Key:             seizureChecklistCall911Key,
+		Kind:            SeizureChecklistEmergency,
+		Label:           "Call 911",
+		Detail:          "If it lasts 5 minutes or longer, another seizure follows before they recover, or they have trouble breathing.",
+		DueAfterSeconds: seizureEmergencySeconds,
+	}), nil
+}
+
+// isRescueMedication reports whether m looks like a seizure rescue
+// medication: a known rescue drug, or instructions that mention seizures.
+func isRescueMedication(m models.Medication) bool {
+	name := strings.ToLower(m.Name)
+	for _, n := range rescueMedicationNames {
+		if strings.Contains(name, n) {
+			return true
+		}
+	}
+	return m.Instructions.Valid && strings.Contains(strings.ToLower(m.Instructions.String), "seizure")
+}
+
+// Get returns an event, or ErrSeizureEventNotFound.
+func (s *SeizureEventService) Get(ctx context.Context, id uuid.UUID) (*repository.SeizureEvent, error) {
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrSeizureEventNotFound
+	}
+	return e, nil
+}
+
+// Active returns the child's live event, nil when there is none.
+func (s *SeizureEventService) Active(ctx context.Context, childID uuid.UUID) (*repository.SeizureEvent, error) {
+	return s.repo.Active(ctx, childID)
+}
+
+// List returns the child's events, newest first.
+func (s *SeizureEventService) List(ctx context.Context, childID uuid.UUID, limit int) ([]repository.SeizureEvent, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.ListByChild(ctx, childID, limit)
+}
+
+// Check ticks (or, with done false, unticks) a checklist item on a live
+// event.
+func (s *SeizureEventService) Check(ctx context.Context, id uuid.UUID, key string, done bool, by uuid.UUID) (*repository.SeizureEvent, error) {
+	e, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.Status != repository.SeizureEventActive {
+		return nil, ErrSeizureEventEnded
+	}
+	found := false
+	for i := range e.Checklist {
+		item := &e.Checklist[i]
+		if item.Key != key {
+			continue
+		}
+		found = true
+		item.DoneAt, item.DoneBy = nil, nil
+		if done {
+			at := s.now().UTC().Truncate(time.Second)
+			item.DoneAt, item.DoneBy = &at, &by
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrSeizureChecklistItem, key)
+	}
+	ok, err := s.repo.UpdateChecklist(ctx, id, e.Checklist)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSeizureEventEnded
+	}
+	return e, nil
+}
+
+// Stop ends a live event, writes its seizure log with the measured
+// duration, the rescue medication given and whether 911 was called, and
+// alerts the family. If the log can't be written the event stays ended
+// without one and the error is returned.
+func (s *SeizureEventService) Stop(ctx context.Context, child *models.Child, id, by uuid.UUID, details SeizureStopDetails) (*repository.SeizureEvent, *models.SeizureLog, error) {
+	e, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.Status != repository.SeizureEventActive {
+		return nil, nil, ErrSeizureEventEnded
+	}
+	endedAt := s.now().UTC().Truncate(time.Second)
+	if endedAt.Before(e.StartedAt) {
+		endedAt = e.StartedAt
+	}
+	ok, err := s.repo.End(ctx, id, by, endedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrSeizureEventEnded
+	}
+	e.Status, e.EndedAt, e.EndedBy = repository.SeizureEventEnded, &endedAt, &by
+
+	duration := int(endedAt.Sub(e.StartedAt) / time.Second)
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := e.StartedAt.In(loc)
+	logDuration := min(duration, seizureLogMaxSeconds)
+	req := &models.CreateSeizureLogRequest{
+		LogDate:           models.FlexDate{Time: time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)},
+		LogTime:           local.Format("15:04:05"),
+		SeizureType:       details.SeizureType,
+		DurationSeconds:   &logDuration,
+		Triggers:          details.Triggers,
+		WarningSigns:      details.WarningSigns,
+		PostIctalSymptoms: details.PostIctalSymptoms,
+		Called911:         details.Called911,
+		Notes:             details.Notes,
+	}
+	var given []string
+	for _, item := range e.Checklist {
+		if item.DoneAt == nil {
+			continue
+		}
+		switch item.Kind {
+		case SeizureChecklistRescueMed:
+			given = append(given, strings.TrimPrefix(item.Label, "Give "))
+		case SeizureChecklistEmergency:
+			req.Called911 = req.Called911 || item.Key == seizureChecklistCall911Key
+		}
+	}
+	req.RescueMedicationGiven = len(given) > 0
+	req.RescueMedicationName = strings.Join(given, ", ")
+	if duration > seizureLogMaxSeconds {
+		req.Notes = strings.TrimSpace(fmt.Sprintf("Timed at %s. %s", FormatSeizureDuration(duration), req.Notes))
+	}
+
+	s.alertFamily(child, by, PushMessage{
+		Title: fmt.Sprintf("Seizure ended: %s", child.FirstName),
+		Body:  fmt.Sprintf("%s's seizure ended after %s.", child.FirstName, FormatSeizureDuration(duration)),
+	}, e, "ended")
+
+	sl, err := s.logs.CreateSeizureLog(ctx, e.ChildID, by, req)
+	if err != nil {
+		return e, nil, fmt.Errorf("write seizure log: %w", err)
+	}
+	if err := s.repo.SetLog(ctx, e.ID, sl.ID); err != nil {
+		return e, sl, err
+	}
+	e.SeizureLogID = &sl.ID
+	return e, sl, nil
+}
+
+// alertFamily pushes msg to the child's other family members in the
+// background.
+func (s *SeizureEventService) alertFamily(child *models.Child, by uuid.UUID, msg PushMessage, e *repository.SeizureEvent, status string) {
+	if s.push == nil || s.family == nil {
+		return
+	}
+	msg.Priority = PushPriorityHigh
+	msg.Data = map[string]string{
+		"type":             "seizure_event",
+		"status":           status,
+		"seizure_event_id": e.ID.String(),
+		"child_id":         child.ID.String(),
+	}
+	s.drain.Go("seizure push", func() {
+		ctx := context.Background()
+		members, err := s.family.GetMembers(ctx, child.FamilyID)
+		if err != nil {
+			log.Printf("[SEIZURE] family members for event %s: %v", e.ID, err)
+			return
+		}
+		for _, m := range members {
+			if m.UserID == by || !m.IsActive {
+				continue
+			}
+			if err := s.push.Send(ctx, m.UserID, msg); err != nil {
+				log.Printf("[SEIZURE] push event %s to %s: %v", e.ID, m.UserID, err)
+			}
+		}
+	})
+}
+
+// Summary writes up an ended event for the neurologist.
+func (s *SeizureEventService) Summary(ctx context.Context, child *models.Child, e *repository.SeizureEvent) (*SeizureIncidentSummary, error) {
+	if e.EndedAt == nil {
+		return nil, ErrSeizureSummaryUnavailable
+	}
+	sum := &SeizureIncidentSummary{
+		EventID:         e.ID,
+		ChildID:         child.ID,
+		ChildName:       child.FullName(),
+		AgeYears:        child.Age(),
+		StartedAt:       e.StartedAt,
+		EndedAt:         *e.EndedAt,
+		Timezone:        e.Timezone,
+		DurationSeconds: int(e.EndedAt.Sub(e.StartedAt) / time.Second),
+		History:         SeizureHistory{Days: seizureHistoryDays},
+	}
+	if e.SeizureLogID != nil {
+		sl, err := s.logs.GetSeizureLogByID(ctx, *e.SeizureLogID)
+		if err != nil {
+			return nil, err
+		}
+		sum.Log = sl
+	}
+
+	entry := func(at time.Time, label string) SeizureTimelineEntry {
+		return SeizureTimelineEntry{At: at, OffsetSeconds: int(at.Sub(e.StartedAt) / time.Second), Label: label}
+	}
+	sum.Timeline = append(sum.Timeline, entry(e.StartedAt, "Seizure started"))
+	for _, item := range e.Checklist {
+		if item.DoneAt != nil && item.Kind != SeizureChecklistFirstAid {
+			sum.Timeline = append(sum.Timeline, entry(*item.DoneAt, item.Label))
+		}
+	}
+	sum.Timeline = append(sum.Timeline, entry(*e.EndedAt, "Seizure ended"))
+	sort.SliceStable(sum.Timeline, func(i, j int) bool { return sum.Timeline[i].At.Before(sum.Timeline[j].At) })
+
+	logs, err := s.logs.GetSeizureLogs(ctx, child.ID, e.StartedAt.AddDate(0, 0, -seizureHistoryDays), e.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	total, longest, timed := 0, 0, 0
+	for _, l := range logs {
+		if e.SeizureLogID != nil && l.ID == *e.SeizureLogID {
+			continue
+		}
+		sum.History.Seizures++
+		if l.RescueMedicationGiven {
+			sum.History.RescueMedicationUses++
+		}
+		if l.Called911 {
+			sum.History.Called911++
+		}
+		if l.DurationSeconds != nil {
+			timed++
+			total += *l.DurationSeconds
+			longest = max(longest, *l.DurationSeconds)
+		}
+	}
+	if timed > 0 {
+		avg := total / timed
+		sum.History.AverageDurationSeconds, sum.History.LongestDurationSeconds = &avg, &longest
+	}
+	return sum, nil
+}
+
+// SignedSummary loads the event and child behind a verified shared link
+// and writes up the summary.
+func (s *SeizureEventService) SignedSummary(ctx context.Context, id uuid.UUID) (*SeizureIncidentSummary, error) {
+	e, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	child, err := s.children.GetByID(ctx, e.ChildID)
+	if err != nil {
+		return nil, err
+	}
+	if child == nil {
+		return nil, ErrSeizureEventNotFound
+	}
+	return s.Summary(ctx, child, e)
+}
+
+// ShareURL returns a link to an ended event's incident summary that works
+// without signing in until it expires.
+func (s *SeizureEventService) ShareURL(e *repository.SeizureEvent) (url string, exp time.Time, err error) {
+	if e.EndedAt == nil {
+		return "", time.Time{}, ErrSeizureSummaryUnavailable
+	}
+	exp = s.now().Add(SeizureSummaryLinkTTL)
+	expUnix := exp.Unix()
+	path := fmt.Sprintf("/sz/signed/%s?exp=%d&sig=%s", e.ID, expUnix, s.sign(e.ID, expUnix))
+	return s.appURL + path, exp, nil
+}
+
+// VerifySignedSummary checks a shared summary link's expiry and
+// signature.
+func (s *SeizureEventService) VerifySignedSummary(id uuid.UUID, expUnix int64, sig string) error {
+	if s.now().Unix() > expUnix {
+		return fmt.Errorf("signed url expired")
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("bad signature encoding")
+	}
+	want, _ := hex.DecodeString(s.sign(id, expUnix))
+	if !hmac.Equal(got, want) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (s *SeizureEventService) sign(id uuid.UUID, expUnix int64) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte("seizure_summary|" + id.String() + "|" + strconv.FormatInt(expUnix, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// FormatSeizureDuration renders seconds as "45s", "2m 05s" or "1h 02m".
+func FormatSeizureDuration(seconds int) string {
+	switch {
+	case seconds < 60:
+		return fmt.Sprintf("%ds", seconds)
+	case seconds < 3600:
+		return fmt.Sprintf("%dm %02ds", seconds/60, seconds%60)
+	default:
+		return fmt.Sprintf("%dh %02dm", seconds/3600, seconds%3600/60)
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// fakeSeizureEventRepo keeps events in memory with the table's one
+// active event per child.
+type fakeSeizureEventRepo struct {
+	events []*repository.SeizureEvent
+}
+
+func (f *fakeSeizureEventRepo) Create(ctx context.Context, e *repository.SeizureEvent) error {
+	if a, _ := f.Active(ctx, e.ChildID); a != nil {
+		return repository.ErrSeizureEventActive
+	}
+	e.ID, e.Status = uuid.New(), repository.SeizureEventActive
+	c := *e
+	c.Checklist = append([]repository.SeizureChecklistItem(nil), e.Checklist...)
+	f.events = append(f.events, &c)
+	return nil
+}
+
+func (f *fakeSeizureEventRepo) find(match func(*repository.SeizureEvent) bool) *repository.SeizureEvent {
+	for _, e := range f.events {
+		if match(e) {
+			c := *e
+			c.Checklist = append([]repository.SeizureChecklistItem(nil), e.Checklist...)
+			return &c
+		}
+	}
+	return nil
+}
+
+func (f *fakeSeizureEventRepo) Get(ctx context.Context, id uuid.UUID) (*repository.SeizureEvent, error) {
+	return f.find(func(e *repository.SeizureEvent) bool { return e.ID == id }), nil
+}
+
+func (f *fakeSeizureEventRepo) Active(ctx context.Context, childID uuid.UUID) (*repository.SeizureEvent, error) {
+	return f.find(func(e *repository.SeizureEvent) bool {
+		return e.ChildID == childID && e.Status == repository.SeizureEventActive
+	}), nil
+}
+
+func (f *fakeSeizureEventRepo) ListByChild(ctx context.Context, childID uuid.UUID, limit int) ([]repository.SeizureEvent, error) {
+	var out []repository.SeizureEvent
+	for i := len(f.events) - 1; i >= 0 && len(out) < limit; i-- {
+		if f.events[i].ChildID == childID {
+			out = append(out, *f.events[i])
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSeizureEventRepo) UpdateChecklist(ctx context.Context, id uuid.UUID, checklist []repository.SeizureChecklistItem) (bool, error) {
+	for _, e := range f.events {
+		if e.ID == id && e.Status == repository.SeizureEventActive {
+			e.Checklist = append([]repository.SeizureChecklistItem(nil), checklist...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeSeizureEventRepo) End(ctx context.Context, id, by uuid.UUID, endedAt time.Time) (bool, error) {
+	for _, e := range f.events {
+		if e.ID == id && e.Status == repository.SeizureEventActive {
+			e.Status, e.EndedAt, e.EndedBy = repository.SeizureEventEnded, &endedAt, &by
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeSeizureEventRepo) SetLog(ctx context.Context, id, seizureLogID uuid.UUID) error {
+	for _, e := range f.events {
+		if e.ID == id {
+			e.SeizureLogID = &seizureLogID
+		}
+	}
+	return nil
+}
+
+// fakeSeizureLogs stores seizure logs the way LogService would.
+type fakeSeizureLogs struct {
+	logs []models.SeizureLog
+}
+
+func (f *fakeSeizureLogs) CreateSeizureLog(ctx context.Context, childID, loggedBy uuid.UUID, req *models.CreateSeizureLogRequest) (*models.SeizureLog, error) {
+	l := models.SeizureLog{
+		ID: uuid.New(), ChildID: childID, LogDate: req.LogDate.Time, LogTime: req.LogTime,
+		DurationSeconds: req.DurationSeconds, RescueMedicationGiven: req.RescueMedicationGiven,
+		Called911: req.Called911, LoggedBy: loggedBy,
+	}
+	l.RescueMedicationName.String, l.RescueMedicationName.Valid = req.RescueMedicationName, req.RescueMedicationName != ""
+	l.Notes.String, l.Notes.Valid = req.Notes, req.Notes != ""
+	f.logs = append(f.logs, l)
+	return &l, nil
+}
+
+func (f *fakeSeizureLogs) GetSeizureLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SeizureLog, error) {
+	var out []models.SeizureLog
+	for _, l := range f.logs {
+		if l.ChildID == childID && !l.LogDate.Before(startDate) && !l.LogDate.After(endDate) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSeizureLogs) GetSeizureLogByID(ctx context.Context, id uuid.UUID) (*models.SeizureLog, error) {
+	for _, l := range f.logs {
+		if l.ID == id {
+			return &l, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeSeizureMeds []models.Medication
+
+func (f fakeSeizureMeds) GetByChildID(ctx context.Context, childID uuid.UUID, activeOnly bool) ([]models.Medication, error) {
+	return f, nil
+}
+
+type fakeSeizureChildren map[uuid.UUID]*models.Child
+
+func (f fakeSeizureChildren) GetByID(ctx context.Context, id uuid.UUID) (*models.Child, error) {
+	return f[id], nil
+}
+
+type fakeSeizureFamily []models.FamilyMembership
+
+func (f fakeSeizureFamily) GetMembers(ctx context.Context, familyID uuid.UUID) ([]models.FamilyMembership, error) {
+	return f, nil
+}
+
+type fakeSeizurePusher struct {
+	mu   sync.Mutex
+	sent map[uuid.UUID][]PushMessage
+}
+
+func (f *fakeSeizurePusher) Send(ctx context.Context, userID uuid.UUID, msg PushMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent[userID] = append(f.sent[userID], msg)
+	return nil
+}
+
+func TestSeizureEventLifecycle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 9, 2, 30, 0, 0, time.UTC)
+	parent, other, inactive := uuid.New(), uuid.New(), uuid.New()
+	child := &models.Child{ID: uuid.New(), FamilyID: uuid.New(), FirstName: "Sam"}
+	valtoco := models.Medication{ID: uuid.New(), Name: "Valtoco", Dosage: "10", DosageUnit: "mg"}
+	prn := models.Medication{ID: uuid.New(), Name: "Clobazam", Dosage: "5", DosageUnit: "mg"}
+	prn.Instructions.String, prn.Instructions.Valid = "Give for seizure clusters", true
+	logs := &fakeSeizureLogs{}
+	// An earlier seizure inside the summary's 90 days.
+	earlier := 90
+	logs.logs = append(logs.logs, models.SeizureLog{ID: uuid.New(), ChildID: child.ID, LogDate: now.AddDate(0, 0, -20), DurationSeconds: &earlier})
+	push := &fakeSeizurePusher{sent: map[uuid.UUID][]PushMessage{}}
+	repo := &fakeSeizureEventRepo{}
+	svc := NewSeizureEventService(repo, logs,
+		fakeSeizureMeds{valtoco, prn, {ID: uuid.New(), Name: "Melatonin"}},
+		fakeSeizureChildren{child.ID: child},
+		fakeSeizureFamily{{UserID: parent, IsActive: true}, {UserID: other, IsActive: true}, {UserID: inactive}},
+		push, NewDrain(), "https://app.example.com", "secret")
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.Start(ctx, child, parent, "Mars/Olympus"); !errors.Is(err, ErrSeizureEventInvalidZone) {
+		t.Fatalf("bad zone: %v", err)
+	}
+	e, err := svc.Start(ctx, child, parent, "America/Chicago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, item := range e.Checklist {
+		keys = append(keys, item.Key)
+	}
+	want := []string{"stay_safe", "on_side", "observe", "rescue_med:" + valtoco.ID.String(), "rescue_med:" + prn.ID.String(), "call_911"}
+	if strings.Join(keys, " ") != strings.Join(want, " ") {
+		t.Errorf("checklist %v, want %v", keys, want)
+	}
+	// A second caregiver starting lands on the same event.
+	if again, err := svc.Start(ctx, child, other, ""); !errors.Is(err, ErrSeizureEventActive) || again.ID != e.ID {
+		t.Errorf("second start: %v, %v", again, err)
+	}
+	svc.drain.Wait(time.Second, time.Second)
+	if len(push.sent[other]) != 1 || len(push.sent[parent]) != 0 || len(push.sent[inactive]) != 0 {
+		t.Errorf("start pushes %v", push.sent)
+	}
+	if msg := push.sent[other][0]; msg.Priority != PushPriorityHigh || msg.Data["seizure_event_id"] != e.ID.String() {
+		t.Errorf("start push %+v", msg)
+	}
+
+	now = now.Add(3 * time.Minute)
+	if _, err := svc.Check(ctx, e.ID, "rescue_med:"+valtoco.ID.String(), true, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Check(ctx, e.ID, "nope", true, other); !errors.Is(err, ErrSeizureChecklistItem) {
+		t.Errorf("unknown item: %v", err)
+	}
+	if _, err := svc.Summary(ctx, child, e); !errors.Is(err, ErrSeizureSummaryUnavailable) {
+		t.Errorf("summary while live: %v", err)
+	}
+
+	now = now.Add(75 * time.Second)
+	svc.drain = NewDrain()
+	ended, sl, err := svc.Stop(ctx, child, e.ID, parent, SeizureStopDetails{Notes: "Stiffening then jerking"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 02:30 UTC is 21:30 CDT the evening before in Chicago.
+	if *sl.DurationSeconds != 255 || sl.LogTime != "21:30:00" || sl.LogDate.Day() != 8 ||
+		!sl.RescueMedicationGiven || sl.RescueMedicationName.String != "Valtoco 10 mg" || sl.Called911 {
+		t.Errorf("seizure log %+v", sl)
+	}
+	if ended.SeizureLogID == nil || *ended.SeizureLogID != sl.ID {
+		t.Errorf("event not linked to log: %+v", ended)
+	}
+	if _, _, err := svc.Stop(ctx, child, e.ID, other, SeizureStopDetails{}); !errors.Is(err, ErrSeizureEventEnded) {
+		t.Errorf("second stop: %v", err)
+	}
+	if _, err := svc.Check(ctx, e.ID, "call_911", true, other); !errors.Is(err, ErrSeizureEventEnded) {
+		t.Errorf("check after stop: %v", err)
+	}
+	svc.drain.Wait(time.Second, time.Second)
+	if len(push.sent[other]) != 2 || !strings.Contains(push.sent[other][1].Body, "4m 15s") {
+		t.Errorf("stop push %+v", push.sent[other])
+	}
+
+	sum, err := svc.Summary(ctx, child, ended)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.DurationSeconds != 255 || len(sum.Timeline) != 3 || sum.Timeline[1].OffsetSeconds != 180 {
+		t.Errorf("summary %+v", sum)
+	}
+	if sum.History.Seizures != 1 || sum.History.AverageDurationSeconds == nil || *sum.History.AverageDurationSeconds != 90 {
+		t.Errorf("history %+v", sum.History)
+	}
+
+	link, exp, err := svc.ShareURL(ended)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(link)
+	expUnix, _ := strconv.ParseInt(u.Query().Get("exp"), 10, 64)
+	if u.Path != "/sz/signed/"+e.ID.String() || expUnix != exp.Unix() {
+		t.Errorf("share link %s", link)
+	}
+	if err := svc.VerifySignedSummary(e.ID, expUnix, u.Query().Get("sig")); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	if err := svc.VerifySignedSummary(uuid.New(), expUnix, u.Query().Get("sig")); err == nil {
+		t.Error("signature accepted for another event")
+	}
+	now = exp.Add(time.Second)
+	if err := svc.VerifySignedSummary(e.ID, expUnix, u.Query().Get("sig")); err == nil {
+		t.Error("expired link accepted")
+	}
+}
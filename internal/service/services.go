@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"log"
 	"runtime/debug"
+	"time"
 
 	"carecompanion/internal/config"
 	"carecompanion/internal/database"
@@ -13,44 +14,116 @@ import (
 
 // Services aggregates all service instances
 type Services struct {
-	Auth              *AuthService
-	User              *UserService
-	Family            *FamilyService
-	Child             *ChildService
-	Medication        *MedicationService
-	Log               *LogService
-	Alert             *AlertService
-	Correlation       *CorrelationService
-	Insight           *InsightService
-	Cohort            *CohortService
-	Chat              *ChatService
-	DrugDatabase      *DrugDatabaseService
-	Validation        *ValidationService
-	AlertIntelligence *AlertIntelligenceService
-	RealtimeDetection *RealtimeDetectionService
-	Transparency      *TransparencyService
-	UserSupport       *UserSupportService
-	Billing           *BillingService
-	Email             *EmailService
-	PasswordReset     *PasswordResetService
-	Push              *PushService
-	Report            *ReportService
-	Search            *SearchService
-	Roadmap           *RoadmapService
-	TicketDuplicate   *TicketDuplicateService
-	TicketAttachment  *TicketAttachmentService
-	AttachmentStorage AttachmentStorage
-	AppStoreConnect   *AppStoreConnectService
-	Beta              *BetaService
-	Bounty            *BountyService
-	Subscription      *SubscriptionService
-	Stripe            *StripeService
-	ChatHub           *ChatHub
-	LiveSessions      *LiveSessionsService
-	AccountDeletion   *AccountDeletionService
+	Auth               *AuthService
+	User               *UserService
+	Family             *FamilyService
+	Child              *ChildService
+	Medication         *MedicationService
+	Log                *LogService
+	Alert              *AlertService
+	Correlation        *CorrelationService
+	Insight            *InsightService
+	Cohort             *CohortService
+	Chat               *ChatService
+	DrugDatabase       *DrugDatabaseService
+	Validation         *ValidationService
+	AlertIntelligence  *AlertIntelligenceService
+	RealtimeDetection  *RealtimeDetectionService
+	Transparency       *TransparencyService
+	UserSupport        *UserSupportService
+	Billing            *BillingService
+	Email              *EmailService
+	PasswordReset      *PasswordResetService
+	AdminInvites       *AdminInviteService
+	Push               *PushService
+	Report             *ReportService
+	Search             *SearchService
+	Roadmap            *RoadmapService
+	TicketDuplicate    *TicketDuplicateService
+	TicketAttachment   *TicketAttachmentService
+	AttachmentStorage  AttachmentStorage
+	AppStoreConnect    *AppStoreConnectService
+	Beta               *BetaService
+	Bounty             *BountyService
+	Subscription       *SubscriptionService
+	Stripe             *StripeService
+	ChatHub            *ChatHub
+	LiveSessions       *LiveSessionsService
+	AccountDeletion    *AccountDeletionService
 	AINarrativeConsent *AINarrativeConsentService
-	ProQA             *ProQAService
-	Role              *RoleService
+	ProQA              *ProQAService
+	Role               *RoleService
+	TicketTaxonomy     *TicketTaxonomyService
+	KnowledgeBase      *KnowledgeBaseService
+	Feedback           *FeedbackService
+	Testimonial        *TestimonialService
+	FileTransfer       *FileTransferService
+	Upload             *UploadService
+	UploadScan         *UploadScanService
+	Campaign           *CampaignService
+	Backup             *BackupService
+	Cost               *CostService
+	LogSearch          *LogSearchService
+	LogRetention       *LogRetentionService
+	Performance        *PerformanceService
+	APIUsage           *APIUsageService
+	Abuse              *AbuseService
+	QueryStats         *QueryStatsService
+	SecurityHeaders    *SecurityHeadersService
+	Captcha            *CaptchaService
+	PHIWatchdog        *PHIWatchdog
+	AppVersion         *AppVersionService
+	ClientConfig       *ClientConfigService
+	AssetSigner        *AssetSigner
+	Images             *ImageService
+	Events             *EventRelay
+	Warehouse          *WarehouseExportService
+	Analytics          *ProductAnalyticsService
+	Engagement         *EngagementService
+	Segments           *SegmentService
+	Onboarding         *OnboardingService
+	EmailVerification  *EmailVerificationService
+	Legal              *LegalService
+	Research           *ResearchConsentService
+	Organizations      *OrganizationService
+	OrgBilling         *OrganizationBillingService
+	Invoices           *InvoiceService
+	Tax                *TaxService
+	Disputes           *DisputeService
+	PlanChanges        *PlanChangeService
+	ExitPackages       *ExitPackageService
+	LogUsage           *LogUsageService
+	AdminNotifications *AdminNotificationService
+	MetricAnomalies    *MetricAnomalyService
+	Integrity          *IntegrityService
+	PendingActions     *PendingActionService
+	PromoCodes         *PromoCodeService
+	PromoFraud         *PromoFraudService
+	AccessReviews      *AdminAccessReviewService
+	Elevations         *AdminElevationService
+	ComplianceExports  *ComplianceExportService
+	SessionPolicy      *AdminSessionPolicyService
+	TicketMerge        *TicketMergeService
+	SeizureEvents      *SeizureEventService
+	TherapyGoals       *TherapyGoalService
+	ProviderAccess     *ProviderAccessService
+	Routines           *RoutineService
+	RespiteHandoffs    *RespiteHandoffService
+	ChildComparison    *ChildComparisonService
+	Annotations        *AnnotationService
+	Interventions      *InterventionService
+	Restrictions       *RestrictionService
+	SleepSync          *SleepSyncService
+	Rubrics            *RubricService
+	FoodPhotos         *FoodPhotoService
+	ReportShares       *ReportShareService
+	LogDrafts          *LogDraftService
+	LogTemplates       *LogTemplateService
+	Gamification       *GamificationService
+	Tasks              *TaskQueue
+	Jobs               *JobLocker
+	// Drain tracks background work through shutdown (see drain.go).
+	Drain *Drain
 
 	// AdminRepo is exposed (vs the usual pattern of wrapping each repo in its
 	// own service) for handlers that need to read/write generic
@@ -67,8 +140,10 @@ type Services struct {
 // NewServices creates all services with their dependencies
 func NewServices(repos *repository.Repositories, redis *database.Redis, cfg *config.Config, db *sql.DB) *Services {
 	// Create services in dependency order
+	drain := NewDrain()
 	emailService := NewEmailService(&cfg.SMTP)
 	alertService := NewAlertService(repos.Alert, repos.Child)
+	alertService.SetDrain(drain)
 	insightService := NewInsightService(repos.Insight, repos.Correlation, repos.Child)
 	cohortService := NewCohortService(repos.Cohort, repos.Child, repos.Insight)
 	chatService := NewChatService(repos.Chat, repos.User, repos.Family, repos.Child)
@@ -76,10 +151,93 @@ func NewServices(repos *repository.Repositories, redis *database.Redis, cfg *con
 
 	pushService := NewPushService(repos.DeviceToken, cfg.FCM.ServerKey)
 	pushService.InitFirebase(cfg.FCM.ServiceAccountKeyFile)
+	pushService.InitAPNs(cfg.APNs)
 
 	attachmentStorage := NewAttachmentStorage(&cfg.Storage)
 	reportStorage := NewBlobStorage(&cfg.Storage, "reports", cfg.Storage.ReportS3Prefix)
 	proQAStorage := NewBlobStorage(&cfg.Storage, "pro_qa", cfg.Storage.S3Prefix+"pro-qa/")
+	transferStorage := NewBlobStorage(&cfg.Storage, "file_transfers", cfg.Storage.TransferS3Prefix)
+	uploadChunkStorage := NewBlobStorage(&cfg.Storage, "upload_chunks", cfg.Storage.UploadS3Prefix)
+	quarantineStorage := NewBlobStorage(&cfg.Storage, "quarantine", cfg.Storage.QuarantineS3Prefix)
+	imageStorage := NewBlobStorage(&cfg.Storage, "images", cfg.Storage.ImageS3Prefix)
+	invoiceStorage := NewBlobStorage(&cfg.Storage, "invoices", cfg.Storage.S3Prefix+"invoices/")
+	exitPackageStorage := NewBlobStorage(&cfg.Storage, "exit_packages", cfg.Storage.S3Prefix+"exit-packages/")
+
+	// Tax quotes before checkout. TAX_PROVIDER=stripe is switched to Stripe
+	// Tax below once Stripe is configured; until then the rate table quotes.
+	var taxProvider TaxProvider
+	if cfg.Tax.Provider != "none" {
+		taxProvider = NewRateTableTaxProvider(repos.Tax)
+	}
+
+	// Antivirus — without CLAMAV_ADDR uploads go through unscanned.
+	var virusScanner VirusScanner
+	if cfg.Storage.ClamAVAddr != "" {
+		virusScanner = NewClamdScanner(cfg.Storage.ClamAVAddr, cfg.Storage.ClamAVTimeout)
+	} else {
+		log.Printf("[SCAN] CLAMAV_ADDR not set; uploads will not be virus scanned")
+	}
+
+	// RDS snapshots — without BACKUP_RDS_INSTANCE_ID only the config
+	// dumps run.
+	dumpStorage := NewBlobStorage(&cfg.Storage, "config_dumps", cfg.Backup.DumpS3Prefix)
+	// The warehouse export can go to its own bucket so the research team's
+	// access policy never covers the app bucket.
+	warehouseStorageCfg := cfg.Storage
+	if cfg.Warehouse.S3Bucket != "" {
+		warehouseStorageCfg.S3Bucket = cfg.Warehouse.S3Bucket
+	}
+	warehouseStorage := NewBlobStorage(&warehouseStorageCfg, "warehouse", cfg.Warehouse.S3Prefix)
+	var rdsAPI RDSAPI
+	if cfg.Backup.RDSInstanceID != "" {
+		if c, err := NewRDSClient(context.Background(), cfg.Backup.RDSRegion); err != nil {
+			log.Printf("[BACKUP] RDS client init failed; snapshots disabled: %v", err)
+		} else {
+			rdsAPI = c
+		}
+	}
+
+	// Cost Explorer — opt-in (COST_EXPLORER_ENABLED) since each request is
+	// billed; without it the cost panel shows stored history only.
+	var costExplorer CostExplorerAPI
+	if cfg.Cost.ExplorerEnabled {
+		if c, err := NewCostExplorerClient(context.Background()); err != nil {
+			log.Printf("[COST] Cost Explorer client init failed; cost sync disabled: %v", err)
+		} else {
+			costExplorer = c
+		}
+	}
+
+	// Logs Insights — admin log search over the app log group.
+	var logsInsights LogsInsightsAPI
+	if cfg.LogSearch.LogGroup != "" {
+		if c, err := NewLogsInsightsClient(context.Background(), cfg.LogSearch.Region); err != nil {
+			log.Printf("[LOGS] Logs Insights client init failed; log search disabled: %v", err)
+		} else {
+			logsInsights = c
+		}
+	}
+
+	// Domain event stream — the outbox relay's sink. Off by default; the
+	// outbox is still written by triggers and pruned.
+	var eventSink EventSink
+	switch cfg.Events.Sink {
+	case "kinesis":
+		if k, err := NewKinesisSink(context.Background(), cfg.Events.KinesisRegion, cfg.Events.KinesisStream); err != nil {
+			log.Printf("[EVENTS] Kinesis sink init failed; event relay disabled: %v", err)
+		} else {
+			eventSink = k
+		}
+	case "kafka-rest":
+		if k, err := NewKafkaRESTSink(cfg.Events.KafkaRESTURL, cfg.Events.KafkaTopic); err != nil {
+			log.Printf("[EVENTS] Kafka REST sink init failed; event relay disabled: %v", err)
+		} else {
+			eventSink = k
+		}
+	case "", "off":
+	default:
+		log.Printf("[EVENTS] Unknown EVENTS_SINK %q; event relay disabled", cfg.Events.Sink)
+	}
 
 	// App Store Connect — nil when env vars are unset; BetaService falls back
 	// to manual-add in that case rather than failing.
@@ -99,47 +257,213 @@ func NewServices(repos *repository.Repositories, redis *database.Redis, cfg *con
 	sessionCache := NewSessionCache(redis)
 
 	svcs := &Services{
-		Auth:              NewAuthService(repos.User, repos.Family, repos.Session, sessionCache, redis, &cfg.JWT, emailService, cfg.App.URL, cfg.App.Env),
-		User:              NewUserService(repos.User, repos.Family),
-		Family:            NewFamilyService(repos.Family, repos.Child),
-		Child:             NewChildService(repos.Child, repos.Family),
-		Medication:        NewMedicationService(repos.Medication, repos.Transparency),
-		Log:               NewLogService(repos.Log),
-		Alert:             alertService,
-		Correlation:       NewCorrelationService(repos.Correlation, alertService, repos.Child),
-		Insight:           insightService,
-		Cohort:            cohortService,
-		Chat:              chatService,
-		DrugDatabase:      NewDrugDatabaseService(),
-		Validation:        NewValidationService(repos.Correlation, repos.Insight, repos.Medication),
-		AlertIntelligence: NewAlertIntelligenceService(repos.Alert, repos.Correlation, repos.Insight),
-		RealtimeDetection: NewRealtimeDetectionService(repos.Correlation, repos.Alert, repos.Child, repos.Medication, alertService),
-		Transparency:      transparencyService,
-		UserSupport:       NewUserSupportService(repos.UserSupport),
-		Billing:           NewBillingService(repos.Billing, repos.Child),
-		Email:             emailService,
-		PasswordReset:     NewPasswordResetService(db, repos.User, emailService, cfg.App.URL),
-		Push:              pushService,
-		Report:            NewReportService(repos.Report, repos.Log, repos.Child, repos.Chat, reportStorage, cfg.JWT.Secret),
-		AdminRepo:         repos.Admin,
+		Auth:                NewAuthService(repos.User, repos.Family, repos.Session, sessionCache, redis, &cfg.JWT, emailService, cfg.App.URL, cfg.App.Env),
+		User:                NewUserService(repos.User, repos.Family),
+		Family:              NewFamilyService(repos.Family, repos.Child),
+		Child:               NewChildService(repos.Child, repos.Family),
+		Medication:          NewMedicationService(repos.Medication, repos.Transparency),
+		Log:                 NewLogService(repos.Log),
+		Alert:               alertService,
+		Correlation:         NewCorrelationService(repos.Correlation, alertService, repos.Child),
+		Insight:             insightService,
+		Cohort:              cohortService,
+		Chat:                chatService,
+		DrugDatabase:        NewDrugDatabaseService(),
+		Validation:          NewValidationService(repos.Correlation, repos.Insight, repos.Medication),
+		AlertIntelligence:   NewAlertIntelligenceService(repos.Alert, repos.Correlation, repos.Insight),
+		RealtimeDetection:   NewRealtimeDetectionService(repos.Correlation, repos.Alert, repos.Child, repos.Medication, alertService),
+		Transparency:        transparencyService,
+		UserSupport:         NewUserSupportService(repos.UserSupport),
+		Billing:             NewBillingService(repos.Billing, repos.Child),
+		Email:               emailService,
+		PasswordReset:       NewPasswordResetService(db, repos.User, emailService, cfg.App.URL),
+		AdminInvites:        NewAdminInviteService(repos.Admin, repos.User, repos.AdminInvitations, emailService, cfg.App.URL),
+		Push:                pushService,
+		Report:              NewReportService(repos.Report, repos.Log, repos.Child, repos.Chat, reportStorage, cfg.JWT.Secret),
+		AdminRepo:           repos.Admin,
 		AccountDeletionRepo: repos.AccountDeletion,
-		Search:            NewSearchService(repos.Search),
-		Roadmap:           NewRoadmapService(repos.Roadmap, repos.Admin, emailService, db),
-		TicketDuplicate:   NewTicketDuplicateService(repos.Admin, repos.Roadmap, emailService),
-		AttachmentStorage: attachmentStorage,
-		TicketAttachment:  NewTicketAttachmentService(repos.TicketAttachment, repos.Admin, attachmentStorage, cfg.Storage.AttachmentMaxBytes, cfg.Storage.AttachmentMaxPerTkt),
-		AppStoreConnect:   ascService,
-		Beta:              NewBetaService(repos.BetaInvitation, emailService, ascService, cfg.App.URL, "/static/docs/beta-onboarding.html"),
-		Bounty:            NewBountyService(repos.BountyAward, repos.Admin, emailService, db),
-		ChatHub:           NewChatHub(),
+		Search:              NewSearchService(repos.Search),
+		Roadmap:             NewRoadmapService(repos.Roadmap, repos.Admin, emailService, db),
+		TicketDuplicate:     NewTicketDuplicateService(repos.Admin, repos.Roadmap, emailService),
+		AttachmentStorage:   attachmentStorage,
+		TicketAttachment:    NewTicketAttachmentService(repos.TicketAttachment, repos.Admin, attachmentStorage, cfg.Storage.AttachmentMaxBytes, cfg.Storage.AttachmentMaxPerTkt, cfg.JWT.Secret),
+		AppStoreConnect:     ascService,
+		Beta:                NewBetaService(repos.BetaInvitation, emailService, ascService, cfg.App.URL, "/static/docs/beta-onboarding.html"),
+		Bounty:              NewBountyService(repos.BountyAward, repos.Admin, emailService, db),
+		ChatHub:             NewChatHub(),
 		// DevModeService is constructed in cmd/server/main.go after NewServices
 		// returns; main.go calls svcs.LiveSessions.SetDevModeService(...) once
 		// it's built. SSH list is gracefully empty until then.
-		LiveSessions: NewLiveSessionsService(repos.Session, repos.SessionProd, nil, cfg.App.Env),
+		LiveSessions:       NewLiveSessionsService(repos.Session, repos.SessionProd, nil, cfg.App.Env),
 		AINarrativeConsent: NewAINarrativeConsentService(db, cfg.Claude.NarrativeOptInAvailable),
-		ProQA:             NewProQAService(repos.ProQA, proQAStorage),
-		Role:              NewRoleService(repos.Role),
+		ProQA:              NewProQAService(repos.ProQA, proQAStorage),
+		Role:               NewRoleService(repos.Role),
+		TicketTaxonomy:     NewTicketTaxonomyService(repos.TicketTaxonomy, repos.Admin),
+		KnowledgeBase:      NewKnowledgeBaseService(repos.KnowledgeBase),
+		Feedback:           NewFeedbackService(repos.Feedback),
+		Testimonial:        NewTestimonialService(repos.Testimonial),
+		Campaign:           NewCampaignService(repos.Campaign),
+		FileTransfer: NewFileTransferService(repos.FileTransfer, transferStorage, FileTransferLimits{
+			MaxFileBytes: cfg.Storage.TransferMaxFileBytes,
+			QuotaBytes:   cfg.Storage.TransferQuotaBytes,
+			DefaultTTL:   cfg.Storage.TransferDefaultTTL,
+			MaxTTL:       cfg.Storage.TransferMaxTTL,
+		}),
+		Upload: NewUploadService(repos.UploadSession, uploadChunkStorage, UploadLimits{
+			ChunkBytes:   cfg.Storage.UploadChunkBytes,
+			MaxBytes:     cfg.Storage.UploadMaxBytes,
+			AbandonAfter: cfg.Storage.UploadAbandonAfter,
+		}),
+		UploadScan: NewUploadScanService(repos.UploadScan, virusScanner, quarantineStorage, ScanOptions{
+			MaxBytes:   cfg.Storage.ScanMaxBytes,
+			FailClosed: cfg.Storage.ScanFailClosed,
+		}),
+		Backup: NewBackupService(repos.Backup, rdsAPI, dumpStorage, db, BackupOptions{
+			InstanceID:  cfg.Backup.RDSInstanceID,
+			DumpTables:  cfg.Backup.DumpTables,
+			DumpHourUTC: cfg.Backup.DumpHourUTC,
+		}),
+		Cost: NewCostService(repos.Cost, costExplorer, CostOptions{
+			MonthlyBudgetUSD: cfg.Cost.MonthlyBudgetUSD,
+			AlertThresholds:  cfg.Cost.AlertThresholds,
+			SyncHourUTC:      cfg.Cost.SyncHourUTC,
+			BackfillDays:     cfg.Cost.BackfillDays,
+		}),
+		LogSearch:   NewLogSearchService(logsInsights, cfg.LogSearch.LogGroup),
+		Performance: NewPerformanceService(repos.Performance),
+		QueryStats: NewQueryStatsService(repos.QueryStats, QueryStatsOptions{
+			TopN:          cfg.QueryStats.TopN,
+			RetentionDays: cfg.QueryStats.RetentionDays,
+		}),
+		SecurityHeaders: NewSecurityHeadersService(repos.Admin, repos.CSPReport),
+		AppVersion:      NewAppVersionService(repos.Admin),
+		AssetSigner:     NewAssetSigner(cfg.JWT.Secret, cfg.Security.SignedStaticPrefixes...),
+		Images: NewImageService(repos.Image, imageStorage, cfg.JWT.Secret, ImageOptions{
+			MaxBytes: cfg.Storage.ImageMaxBytes,
+			CWebP:    cfg.Storage.ImageCWebP,
+			AVIFEnc:  cfg.Storage.ImageAVIFEnc,
+		}),
+		Warehouse: NewWarehouseExportService(repos.WarehouseExport, repos.ResearchConsent, warehouseStorage, WarehouseExportOptions{
+			Enabled:      cfg.Warehouse.Enabled,
+			HourUTC:      cfg.Warehouse.HourUTC,
+			MinCellSize:  cfg.Warehouse.MinCellSize,
+			CohortMonths: cfg.Warehouse.CohortMonths,
+		}),
+		Analytics:         NewProductAnalyticsService(repos.ProductAnalytics),
+		Engagement:        NewEngagementService(repos.Engagement),
+		Segments:          NewSegmentService(repos.Segment),
+		Onboarding:        NewOnboardingService(repos.Onboarding, pushService),
+		EmailVerification: NewEmailVerificationService(repos.EmailVerification, emailService, cfg.App.URL),
+		Legal:             NewLegalService(repos.Legal),
+		Research:          NewResearchConsentService(repos.ResearchConsent),
+		Organizations:     NewOrganizationService(repos.Organization),
+		OrgBilling:        NewOrganizationBillingService(repos.Organization, repos.Billing),
+		Invoices:          NewInvoiceService(repos.Invoice, repos.Marketing, invoiceStorage, emailService, cfg.JWT.Secret),
+		Tax:               NewTaxService(repos.Tax, taxProvider),
+		Disputes:          NewDisputeService(repos.Dispute, emailService, cfg.App.URL),
+		PlanChanges:       NewPlanChangeService(repos.Billing, repos.PlanChange),
+		LogUsage:          NewLogUsageService(repos.LogUsage),
+		MetricAnomalies:   NewMetricAnomalyService(repos.MetricSeries, repos.AdminNotification, repos.Admin),
+		Integrity:         NewIntegrityService(repos.Integrity),
+		PromoCodes:        NewPromoCodeService(repos.PromoCodes, repos.Admin),
+		Events: NewEventRelay(repos.EventOutbox, eventSink, EventRelayOptions{
+			BatchSize:     cfg.Events.BatchSize,
+			Retention:     cfg.Events.Retention,
+			PendingMaxAge: cfg.Events.PendingMaxAge,
+		}),
+		Tasks:     NewTaskQueue(redis, drain),
+		Jobs:      NewJobLocker(redis, drain),
+		Drain:     drain,
+	}
+	svcs.LogRetention = NewLogRetentionService(repos.LogRetention, repos.Admin, svcs.Jobs)
+	// Exit packages include report PDFs, so they read through ReportService.
+	svcs.ExitPackages = NewExitPackageService(repos.ExitPackage, repos.Child, repos.Log, svcs.Report,
+		exitPackageStorage, emailService, cfg.App.URL, cfg.JWT.Secret)
+	svcs.AdminNotifications = NewAdminNotificationService(repos.AdminNotification)
+	// Action types are registered by the admin handler (see pending_action_handlers.go).
+	svcs.PendingActions = NewPendingActionService(repos.PendingActions, repos.AdminNotification, repos.Admin)
+	svcs.PromoFraud = NewPromoFraudService(repos.PromoFraud, repos.AdminNotification, repos.Admin)
+	svcs.PromoCodes.SetFraudScreening(svcs.PromoFraud)
+	svcs.AccessReviews = NewAdminAccessReviewService(repos.AccessReviews, repos.Admin, repos.Session,
+		repos.AdminNotification, repos.Admin, emailService, cfg.App.URL)
+	svcs.Elevations = NewAdminElevationService(repos.Elevations, repos.AdminNotification)
+	svcs.ComplianceExports = NewComplianceExportService(repos.ComplianceExports)
+	svcs.SessionPolicy = NewAdminSessionPolicyService(repos.Admin)
+	svcs.Auth.SetAdminSessionPolicy(svcs.SessionPolicy)
+	svcs.TicketMerge = NewTicketMergeService(repos.TicketMerges, repos.Admin, repos.Roadmap)
+	svcs.SeizureEvents = NewSeizureEventService(repos.SeizureEvents, svcs.Log, repos.Medication, repos.Child,
+		repos.Family, pushService, drain, cfg.App.URL, cfg.JWT.Secret)
+	svcs.TherapyGoals = NewTherapyGoalService(repos.TherapyGoals, svcs.Report)
+	svcs.Log.SetTherapyGoals(svcs.TherapyGoals)
+	svcs.ProviderAccess = NewProviderAccessService(repos.Providers, svcs.Log, emailService, cfg.App.URL)
+	svcs.Log.SetProviderAttribution(svcs.ProviderAccess)
+	svcs.Routines = NewRoutineService(repos.Routines)
+	svcs.RespiteHandoffs = NewRespiteHandoffService(repos.Handoffs, repos.Child, repos.Medication, svcs.Log,
+		cfg.App.URL, cfg.JWT.Secret)
+	svcs.Annotations = NewAnnotationService(repos.Annotations)
+	svcs.Report.SetAnnotations(svcs.Annotations)
+	svcs.ChildComparison = NewChildComparisonService(repos.Child, repos.Family, svcs.Correlation)
+	svcs.ChildComparison.SetAnnotations(svcs.Annotations)
+	svcs.Interventions = NewInterventionService(repos.Interventions, svcs.Correlation)
+	svcs.Restrictions = NewRestrictionService(repos.Restrictions, repos.Child, svcs.Alert)
+	svcs.Log.SetRestrictionScreening(svcs.Restrictions)
+	svcs.Report.SetRestrictions(svcs.Restrictions)
+	svcs.RespiteHandoffs.SetRestrictions(svcs.Restrictions)
+	svcs.SleepSync = NewSleepSyncService(repos.SleepSync, repos.Log, svcs.Log)
+	svcs.Rubrics = NewRubricService(repos.Rubrics, repos.Child)
+	svcs.Log.SetRubrics(svcs.Rubrics)
+	svcs.Report.SetRubrics(svcs.Rubrics)
+
+	// Meal photo recognition — off unless FOOD_VISION_PROVIDER names a
+	// provider.
+	var foodVision FoodVisionProvider
+	if cfg.FoodVision.Provider == "bedrock" {
+		if p, err := NewBedrockFoodVisionProvider(cfg.FoodVision.Model); err != nil {
+			log.Printf("[FOOD] Bedrock vision unavailable, meal photo recognition is off: %v", err)
+		} else {
+			foodVision = p
+		}
 	}
+	svcs.FoodPhotos = NewFoodPhotoService(repos.FoodRecognitions, foodVision, cfg.FoodVision.MaxImageBytes)
+	svcs.Log.SetFoodPhotos(svcs.FoodPhotos)
+	svcs.Log.SetRevisions(repos.LogRevisions)
+	svcs.Medication.SetRevisions(repos.LogRevisions)
+	svcs.ReportShares = NewReportShareService(repos.ReportShares, svcs.Report, repos.User, emailService, cfg.App.URL, cfg.JWT.Secret)
+	svcs.LogTemplates = NewLogTemplateService(repos.LogTemplates)
+	svcs.LogDrafts = NewLogDraftService(repos.LogDrafts, repos.Log, cfg.LogDrafts.TTL, cfg.LogDrafts.MaxBytes)
+	svcs.Gamification = NewGamificationService(repos.Gamification, svcs.User, emailService, cfg.App.URL)
+	svcs.APIUsage = NewAPIUsageService(redis, repos.APIUsage, svcs.AppVersion)
+	svcs.Abuse = NewAbuseService(redis, repos.AbuseBlocks, cfg.Abuse)
+	svcs.Captcha = NewCaptchaService(cfg.Captcha, repos.Admin, redis)
+	svcs.PHIWatchdog = NewPHIWatchdog(repos.PHICanaries, repos.AdminNotification)
+	repository.SetAdminQueryWatcher(svcs.PHIWatchdog)
+	svcs.ClientConfig = NewClientConfigService(ClientConfigOptions{
+		Environment: cfg.App.Env,
+		AppURL:      cfg.App.URL,
+		Features: map[string]bool{
+			"ai_insights":         cfg.Claude.Enabled,
+			"ai_narrative_opt_in": cfg.Claude.NarrativeOptInAvailable,
+			"billing":             cfg.Stripe.Enabled(),
+		},
+		Limits: ClientLimits{
+			MaxFileBytes:            cfg.Storage.MaxFileSize,
+			MaxImageBytes:           cfg.Storage.ImageMaxBytes,
+			MaxAttachmentBytes:      cfg.Storage.AttachmentMaxBytes,
+			MaxAttachmentsPerTicket: cfg.Storage.AttachmentMaxPerTkt,
+		},
+	}, repos.Admin, svcs.AppVersion, repos.Marketing, svcs.KnowledgeBase)
+	svcs.ClientConfig.SetCaptcha(svcs.Captcha)
+	svcs.Upload.RegisterSink(UploadPurposeFileTransfer, svcs.FileTransfer)
+	// Every upload path scans before the file goes live.
+	svcs.TicketAttachment.SetScanService(svcs.UploadScan)
+	svcs.FileTransfer.SetScanService(svcs.UploadScan)
+	svcs.Images.SetScanService(svcs.UploadScan)
+	svcs.Testimonial.SetImageService(svcs.Images)
+	svcs.Auth.SetCampaignService(svcs.Campaign)
+	svcs.Auth.SetTaskQueue(svcs.Tasks)
+	// Signup and email change both start email verification.
+	svcs.Auth.SetEmailVerificationService(svcs.EmailVerification)
+	svcs.User.SetEmailVerificationService(svcs.EmailVerification)
 	// AccountDeletionService needs AuthService (above) so it can revoke
 	// sessions on confirm. Constructed after the struct so Auth is set.
 	svcs.AccountDeletion = NewAccountDeletionService(
@@ -156,6 +480,7 @@ func NewServices(repos *repository.Repositories, redis *database.Redis, cfg *con
 	if subErr != nil {
 		log.Printf("[SUB] subscription service init failed; trial autoplay disabled: %v", subErr)
 	} else {
+		subSvc.SetReadOnlyGrace(time.Duration(cfg.Subscription.ReadOnlyGraceDays) * 24 * time.Hour)
 		svcs.Subscription = subSvc
 		svcs.Auth.SetSubscriptionService(subSvc)
 		svcs.Family.SetSubscriptionService(subSvc)
@@ -175,6 +500,17 @@ func NewServices(repos *repository.Repositories, redis *database.Redis, cfg *con
 		if svcs.Subscription != nil {
 			svcs.Stripe.SetSubscriptionService(svcs.Subscription)
 		}
+		svcs.Stripe.SetOrganizationBilling(svcs.OrgBilling)
+		svcs.OrgBilling.SetSeatBiller(svcs.Stripe)
+		svcs.Stripe.SetInvoiceService(svcs.Invoices)
+		svcs.Stripe.SetTaxService(svcs.Tax)
+		svcs.Stripe.SetDisputeService(svcs.Disputes)
+		svcs.Stripe.SetPlanChangeService(svcs.PlanChanges)
+		svcs.Stripe.SetExitPackageService(svcs.ExitPackages)
+		svcs.PlanChanges.SetPlanBiller(svcs.Stripe)
+		if cfg.Tax.Provider == "stripe" {
+			svcs.Tax.SetProvider(NewStripeTaxProvider())
+		}
 		go func() {
 			defer func() {
 				if r := recover(); r != nil {
@@ -187,6 +523,9 @@ func NewServices(repos *repository.Repositories, redis *database.Redis, cfg *con
 		}()
 	} else {
 		log.Printf("[STRIPE] disabled (STRIPE_SECRET_KEY not set)")
+		if cfg.Tax.Provider == "stripe" {
+			log.Printf("[TAX] TAX_PROVIDER=stripe needs Stripe; quoting from the tax rate table")
+		}
 	}
 	return svcs
 }
@@ -0,0 +1,540 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+// ErrSleepSyncInvalid is returned for an import or preference the service
+// rejects; the message says why.
+var ErrSleepSyncInvalid = apperr.Validation("invalid sleep sync request")
+
+func invalidSleepSync(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrSleepSyncInvalid, fmt.Sprintf(format, args...))
+}
+
+const (
+	maxSleepImportRecords = 500
+	// Longest single session accepted from a device.
+	maxSleepSessionMinutes = 24 * 60
+	// Manual and device durations further apart than this are a conflict
+	// unless the child's preference says otherwise.
+	defaultSleepConflictMinutes = 60
+	minSleepConflictMinutes     = 5
+	maxSleepConflictMinutes     = 600
+	// The nightly run re-reconciles this many days back, so late imports
+	// and edits to older manual entries are picked up.
+	sleepSyncLookbackDays = 14
+	sleepSyncHourUTC      = 5
+)
+
+var sleepSourceLabels = map[string]string{
+	models.SleepSourceManual:        "the manual entry",
+	models.SleepSourceAppleHealth:   "Apple Health",
+	models.SleepSourceGoogleFit:     "Google Fit",
+	models.SleepSourceHealthConnect: "Health Connect",
+	models.SleepSourceFitbit:        "Fitbit",
+	models.SleepSourceGarmin:        "Garmin",
+	models.SleepSourceOura:          "Oura",
+	models.SleepSourceOther:         "the imported device",
+}
+
+func sleepSourceLabel(source string) string {
+	if l, ok := sleepSourceLabels[source]; ok {
+		return l
+	}
+	return source
+}
+
+// sleepClockLayouts are the bedtime/wake time formats accepted: what the
+// apps send, and what a TIME column scans back as.
+var sleepClockLayouts = []string{"15:04:05", "15:04", "3:04 PM", "3:04PM", "3 PM", "3PM"}
+
+func parseSleepClock(s string) (time.Time, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, layout := range sleepClockLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sleepMinutesBetween returns the minutes from bedtime to wake time. A wake
+// time at or before the bedtime is taken to be the next morning. False
+// when either time is missing or unparseable, or they are equal.
+func sleepMinutesBetween(bedtime, wakeTime string) (int, bool) {
+	bed, ok := parseSleepClock(bedtime)
+	if !ok {
+		return 0, false
+	}
+	wake, ok := parseSleepClock(wakeTime)
+	if !ok {
+		return 0, false
+	}
+	d := wake.Sub(bed)
+	if d == 0 {
+		return 0, false
+	}
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	return int(d / time.Minute), true
+}
+
+// fillSleepMinutes computes TotalSleepMinutes from the bedtime and wake time
+// when the caregiver didn't enter it.
+func fillSleepMinutes(l *models.SleepLog) {
+	if l.TotalSleepMinutes != nil || !l.Bedtime.Valid || !l.WakeTime.Valid {
+		return
+	}
+	if m, ok := sleepMinutesBetween(l.Bedtime.String, l.WakeTime.String); ok {
+		l.TotalSleepMinutes = &m
+	}
+}
+
+// sleepLogMinutes is the log's duration, entered or computed.
+func sleepLogMinutes(l *models.SleepLog) (int, bool) {
+	if l.TotalSleepMinutes != nil {
+		return *l.TotalSleepMinutes, true
+	}
+	if !l.Bedtime.Valid || !l.WakeTime.Valid {
+		return 0, false
+	}
+	return sleepMinutesBetween(l.Bedtime.String, l.WakeTime.String)
+}
+
+func formatSleepMinutes(m int) string {
+	if m < 0 {
+		m = -m
+	}
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %02dm", m/60, m%60)
+}
+
+// sleepLogStore is the slice of LogRepository reconciliation reads and
+// creates sleep logs through.
+type sleepLogStore interface {
+	GetSleepLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SleepLog, error)
+	CreateSleepLog(ctx context.Context, log *models.SleepLog) error
+}
+
+// sleepLogEditor saves reconciled sleep logs. LogService, so changes the
+// device data makes land in the log's revision history.
+type sleepLogEditor interface {
+	UpdateSleepLog(ctx context.Context, log *models.SleepLog, editedBy uuid.UUID) error
+}
+
+// SleepSyncService imports sleep sessions from wearables and health apps
+// and reconciles them against caregivers' manual sleep logs. Each night
+// with a device record is compared to the sleep log for that date: within
+// the child's conflict threshold the log is marked consistent; beyond it
+// the preferred source wins, and the log is annotated with what the other
+// source said.
+type SleepSyncService struct {
+	repo  repository.SleepSyncRepository
+	logs  sleepLogStore
+	edits sleepLogEditor
+	now   func() time.Time
+}
+
+func NewSleepSyncService(repo repository.SleepSyncRepository, logs sleepLogStore, edits sleepLogEditor) *SleepSyncService {
+	return &SleepSyncService{repo: repo, logs: logs, edits: edits, now: time.Now}
+}
+
+// Import validates and stores a batch of device sessions for the child.
+// Night dates are taken from each session's end in loc, the importing
+// caregiver's timezone. Nothing is stored if any record is invalid.
+func (s *SleepSyncService) Import(ctx context.Context, childID, userID uuid.UUID, loc *time.Location, req *models.SleepDeviceImportRequest) ([]models.SleepDeviceRecord, error) {
+	if len(req.Records) == 0 {
+		return nil, invalidSleepSync("no records to import")
+	}
+	if len(req.Records) > maxSleepImportRecords {
+		return nil, invalidSleepSync("at most %d records can be imported at once", maxSleepImportRecords)
+	}
+	recs := make([]models.SleepDeviceRecord, len(req.Records))
+	for i, in := range req.Records {
+		rec, err := deviceRecordFromInput(in, loc)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		rec.ChildID = childID
+		rec.ImportedBy = &userID
+		recs[i] = rec
+	}
+	for i := range recs {
+		if err := s.repo.UpsertDeviceRecord(ctx, &recs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return recs, nil
+}
+
+func deviceRecordFromInput(in models.SleepDeviceRecordInput, loc *time.Location) (models.SleepDeviceRecord, error) {
+	var rec models.SleepDeviceRecord
+	if !slices.Contains(models.SleepDeviceSources, in.Source) {
+		return rec, invalidSleepSync("source must be one of %s", strings.Join(models.SleepDeviceSources, ", "))
+	}
+	externalID := strings.TrimSpace(in.ExternalID)
+	if externalID == "" || len(externalID) > 200 {
+		return rec, invalidSleepSync("external_id is required and at most 200 characters")
+	}
+	if in.SleepStart.IsZero() || in.SleepEnd.IsZero() || !in.SleepEnd.After(in.SleepStart) {
+		return rec, invalidSleepSync("sleep_end must be after sleep_start")
+	}
+	span := int(in.SleepEnd.Sub(in.SleepStart) / time.Minute)
+	if span > maxSleepSessionMinutes {
+		return rec, invalidSleepSync("a sleep session can't be longer than 24 hours")
+	}
+	total := span
+	if in.TotalSleepMinutes != nil {
+		total = *in.TotalSleepMinutes
+		if total < 0 || total > span {
+			return rec, invalidSleepSync("total_sleep_minutes must be between 0 and the session length")
+		}
+	}
+	if in.NightWakings != nil && *in.NightWakings < 0 {
+		return rec, invalidSleepSync("night_wakings can't be negative")
+	}
+	end := in.SleepEnd.In(loc)
+	rec.Source = in.Source
+	rec.ExternalID = externalID
+	rec.SleepStart = in.SleepStart
+	rec.SleepEnd = in.SleepEnd
+	rec.TotalSleepMinutes = total
+	rec.NightWakings = in.NightWakings
+	rec.NightDate = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	rec.Timezone = loc.String()
+	return rec, nil
+}
+
+// ListRecords returns the child's device records for nights in [start, end].
+func (s *SleepSyncService) ListRecords(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.SleepDeviceRecord, error) {
+	recs, err := s.repo.ListDeviceRecords(ctx, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if recs == nil {
+		recs = []models.SleepDeviceRecord{}
+	}
+	return recs, nil
+}
+
+// Preference returns the child's preferred sleep source, defaulting to
+// manual entries and a one-hour conflict threshold.
+func (s *SleepSyncService) Preference(ctx context.Context, childID uuid.UUID) (*models.SleepSourcePreference, error) {
+	p, err := s.repo.GetPreference(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = &models.SleepSourcePreference{
+			ChildID:                  childID,
+			PreferredSource:          models.SleepSourceManual,
+			ConflictThresholdMinutes: defaultSleepConflictMinutes,
+		}
+	}
+	return p, nil
+}
+
+// SavePreference updates the child's preference. It takes effect at the
+// next reconciliation.
+func (s *SleepSyncService) SavePreference(ctx context.Context, childID, userID uuid.UUID, req *models.SleepSourcePreferenceRequest) (*models.SleepSourcePreference, error) {
+	p, err := s.Preference(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	if req.PreferredSource != "" {
+		if req.PreferredSource != models.SleepSourceManual && !slices.Contains(models.SleepDeviceSources, req.PreferredSource) {
+			return nil, invalidSleepSync("preferred_source must be manual or one of %s", strings.Join(models.SleepDeviceSources, ", "))
+		}
+		p.PreferredSource = req.PreferredSource
+	}
+	if req.ConflictThresholdMinutes != nil {
+		t := *req.ConflictThresholdMinutes
+		if t < minSleepConflictMinutes || t > maxSleepConflictMinutes {
+			return nil, invalidSleepSync("conflict_threshold_minutes must be between %d and %d", minSleepConflictMinutes, maxSleepConflictMinutes)
+		}
+		p.ConflictThresholdMinutes = t
+	}
+	p.UpdatedBy = &userID
+	if err := s.repo.SavePreference(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ReconcileChild compares the child's device records with their sleep logs
+// for nights in [start, end] and saves the outcome on the logs. When the
+// preferred source is a device, its sessions replace disagreeing manual
+// values and fill nights nobody logged; otherwise manual entries are kept
+// and disagreements are flagged as conflicts.
+func (s *SleepSyncService) ReconcileChild(ctx context.Context, childID uuid.UUID, start, end time.Time) (*models.SleepReconciliationResult, error) {
+	pref, err := s.Preference(ctx, childID)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := s.repo.ListDeviceRecords(ctx, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.logs.GetSleepLogs(ctx, childID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.SleepReconciliationResult{ChildID: childID, Start: start, End: end, Nights: []models.SleepNightReconciliation{}}
+
+	recsByNight := map[string][]models.SleepDeviceRecord{}
+	for _, rec := range recs {
+		key := dateOnly(rec.NightDate).Format("2006-01-02")
+		recsByNight[key] = append(recsByNight[key], rec)
+	}
+	logsByNight := map[string][]*models.SleepLog{}
+	for i := range logs {
+		key := logs[i].LogDate.Format("2006-01-02")
+		logsByNight[key] = append(logsByNight[key], &logs[i])
+	}
+	nights := make([]string, 0, len(recsByNight))
+	for k := range recsByNight {
+		nights = append(nights, k)
+	}
+	sort.Strings(nights)
+
+	for _, night := range nights {
+		rec := mainDeviceRecord(recsByNight[night], pref.PreferredSource)
+		l := mainSleepLog(logsByNight[night])
+		outcome, err := s.reconcileNight(ctx, pref, rec, l)
+		if err != nil {
+			return nil, err
+		}
+		if outcome == nil {
+			continue
+		}
+		outcome.Date = rec.NightDate
+		switch outcome.Status {
+		case models.SleepReconciliationConsistent:
+			res.Consistent++
+		case models.SleepReconciliationConflict:
+			res.Conflicts++
+		case models.SleepReconciliationResolved:
+			if l == nil {
+				res.Created++
+			} else {
+				res.Resolved++
+			}
+		}
+		res.Nights = append(res.Nights, *outcome)
+	}
+	return res, nil
+}
+
+// reconcileNight applies the rules to one night. It returns nil when there
+// is nothing to reconcile, such as a device night nobody logged while
+// manual entries are preferred.
+func (s *SleepSyncService) reconcileNight(ctx context.Context, pref *models.SleepSourcePreference, rec models.SleepDeviceRecord, l *models.SleepLog) (*models.SleepNightReconciliation, error) {
+	apply := rec.Source == pref.PreferredSource
+	device := sleepSourceLabel(rec.Source)
+
+	if l == nil {
+		if !apply || rec.ImportedBy == nil {
+			return nil, nil
+		}
+		created := &models.SleepLog{ChildID: rec.ChildID, LogDate: rec.NightDate, LoggedBy: *rec.ImportedBy}
+		applyDeviceSleep(created, rec)
+		setReconciliation(created, models.SleepReconciliationResolved,
+			fmt.Sprintf("Added from %s; there was no manual entry for this night.", device))
+		if err := s.logs.CreateSleepLog(ctx, created); err != nil {
+			return nil, err
+		}
+		return nightOutcome(created), nil
+	}
+
+	updated := *l
+	manual, known := sleepLogMinutes(l)
+	diff := rec.TotalSleepMinutes - manual
+	switch {
+	case l.Source == rec.Source:
+		// Taken from this device before; keep it in step with re-imports.
+		applyDeviceSleep(&updated, rec)
+	case !known && apply:
+		applyDeviceSleep(&updated, rec)
+		setReconciliation(&updated, models.SleepReconciliationResolved,
+			fmt.Sprintf("No duration on the manual entry; filled in from %s.", device))
+	case !known:
+		return nil, nil
+	case diff <= pref.ConflictThresholdMinutes && diff >= -pref.ConflictThresholdMinutes:
+		setReconciliation(&updated, models.SleepReconciliationConsistent, "")
+	case apply:
+		applyDeviceSleep(&updated, rec)
+		setReconciliation(&updated, models.SleepReconciliationResolved,
+			fmt.Sprintf("Manual entry recorded %s; replaced with %s from %s, the preferred source.",
+				formatSleepMinutes(manual), formatSleepMinutes(rec.TotalSleepMinutes), device))
+	default:
+		moreLess := "more"
+		if diff < 0 {
+			moreLess = "less"
+		}
+		setReconciliation(&updated, models.SleepReconciliationConflict,
+			fmt.Sprintf("%s recorded %s, %s %s than this entry; kept %s, the preferred source.",
+				strings.ToUpper(device[:1])+device[1:], formatSleepMinutes(rec.TotalSleepMinutes),
+				formatSleepMinutes(diff), moreLess, sleepSourceLabel(pref.PreferredSource)))
+	}
+	if sleepLogChanged(l, &updated) {
+		// uuid.Nil: the system made this edit, not a caregiver.
+		if err := s.edits.UpdateSleepLog(ctx, &updated, uuid.Nil); err != nil {
+			return nil, err
+		}
+	}
+	return nightOutcome(&updated), nil
+}
+
+// applyDeviceSleep copies a device session onto a sleep log, with bedtime
+// and wake time in the importer's timezone.
+func applyDeviceSleep(l *models.SleepLog, rec models.SleepDeviceRecord) {
+	loc, err := time.LoadLocation(rec.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	total := rec.TotalSleepMinutes
+	l.Bedtime = models.NullString{}
+	l.Bedtime.String, l.Bedtime.Valid = rec.SleepStart.In(loc).Format("15:04:05"), true
+	l.WakeTime = models.NullString{}
+	l.WakeTime.String, l.WakeTime.Valid = rec.SleepEnd.In(loc).Format("15:04:05"), true
+	l.TotalSleepMinutes = &total
+	if rec.NightWakings != nil {
+		l.NightWakings = *rec.NightWakings
+	}
+	l.Source = rec.Source
+}
+
+func setReconciliation(l *models.SleepLog, status, note string) {
+	l.ReconciliationStatus = models.NullString{}
+	l.ReconciliationStatus.String, l.ReconciliationStatus.Valid = status, true
+	l.ReconciliationNote = models.NullString{}
+	l.ReconciliationNote.String, l.ReconciliationNote.Valid = note, note != ""
+}
+
+func sleepLogChanged(a, b *models.SleepLog) bool {
+	intOr := func(p *int) int {
+		if p == nil {
+			return -1
+		}
+		return *p
+	}
+	return a.Bedtime != b.Bedtime || a.WakeTime != b.WakeTime ||
+		intOr(a.TotalSleepMinutes) != intOr(b.TotalSleepMinutes) || a.NightWakings != b.NightWakings ||
+		a.Source != b.Source || a.ReconciliationStatus != b.ReconciliationStatus ||
+		a.ReconciliationNote != b.ReconciliationNote
+}
+
+func nightOutcome(l *models.SleepLog) *models.SleepNightReconciliation {
+	id := l.ID
+	return &models.SleepNightReconciliation{LogID: &id, Status: l.ReconciliationStatus.String, Note: l.ReconciliationNote.String}
+}
+
+// mainDeviceRecord picks the night's main session: the longest from the
+// preferred source, or the longest from any source when the preferred one
+// has nothing that night.
+func mainDeviceRecord(recs []models.SleepDeviceRecord, preferred string) models.SleepDeviceRecord {
+	var best, bestPreferred *models.SleepDeviceRecord
+	for i := range recs {
+		r := &recs[i]
+		if best == nil || r.TotalSleepMinutes > best.TotalSleepMinutes {
+			best = r
+		}
+		if r.Source == preferred && (bestPreferred == nil || r.TotalSleepMinutes > bestPreferred.TotalSleepMinutes) {
+			bestPreferred = r
+		}
+	}
+	if bestPreferred != nil {
+		return *bestPreferred
+	}
+	return *best
+}
+
+// mainSleepLog picks the night's main entry, the longest one, so naps
+// logged the same day aren't compared against a night's sleep.
+func mainSleepLog(logs []*models.SleepLog) *models.SleepLog {
+	var best *models.SleepLog
+	bestMinutes := -1
+	for _, l := range logs {
+		m, ok := sleepLogMinutes(l)
+		if !ok {
+			m = 0
+		}
+		if m > bestMinutes {
+			best, bestMinutes = l, m
+		}
+	}
+	return best
+}
+
+// ReconcileRecent reconciles the last sleepSyncLookbackDays nights for
+// every child with device records imported in that window. A failure for
+// one child is logged and doesn't stop the others.
+func (s *SleepSyncService) ReconcileRecent(ctx context.Context) (children, conflicts int, err error) {
+	now := s.now().UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -sleepSyncLookbackDays)
+	ids, err := s.repo.ChildrenWithRecordsSince(ctx, start)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return children, conflicts, ctx.Err()
+		}
+		res, err := s.ReconcileChild(ctx, id, start, end)
+		if err != nil {
+			log.Printf("Sleep sync: reconcile child %s: %v", id, err)
+			continue
+		}
+		children++
+		conflicts += res.Conflicts
+	}
+	return children, conflicts, nil
+}
+
+// SleepSyncScheduler runs ReconcileRecent nightly.
+type SleepSyncScheduler struct {
+	sync *SleepSyncService
+	jobs *JobLocker
+}
+
+func NewSleepSyncScheduler(sync *SleepSyncService, jobs *JobLocker) *SleepSyncScheduler {
+	return &SleepSyncScheduler{sync: sync, jobs: jobs}
+}
+
+func (s *SleepSyncScheduler) Start(ctx context.Context) {
+	log.Printf("Sleep sync scheduler started (%02d:00 UTC daily)", sleepSyncHourUTC)
+	for {
+		next := nextUTCRunAt(time.Now().UTC(), sleepSyncHourUTC, 0)
+		select {
+		case <-ctx.Done():
+			log.Println("Sleep sync scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "sleep_sync", next, 24*time.Hour, func(ctx context.Context) {
+				children, conflicts, err := s.sync.ReconcileRecent(ctx)
+				if err != nil {
+					log.Printf("Sleep sync: reconciliation failed: %v", err)
+					return
+				}
+				log.Printf("Sleep sync: reconciled %d children, %d conflicts", children, conflicts)
+			})
+		}
+	}
+}
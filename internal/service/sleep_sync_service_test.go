@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+type fakeSleepSyncRepo struct {
+	records []models.SleepDeviceRecord
+	pref    *models.SleepSourcePreference
+}
+
+func (f *fakeSleepSyncRepo) UpsertDeviceRecord(ctx context.Context, rec *models.SleepDeviceRecord) error {
+	for i, r := range f.records {
+		if r.ChildID == rec.ChildID && r.Source == rec.Source && r.ExternalID == rec.ExternalID {
+			rec.ID = r.ID
+			f.records[i] = *rec
+			return nil
+		}
+	}
+	rec.ID = uuid.New()
+	f.records = append(f.records, *rec)
+	return nil
+}
+
+func (f *fakeSleepSyncRepo) ListDeviceRecords(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.SleepDeviceRecord, error) {
+	var out []models.SleepDeviceRecord
+	for _, r := range f.records {
+		if r.ChildID == childID && !r.NightDate.Before(start) && !r.NightDate.After(end) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSleepSyncRepo) ChildrenWithRecordsSince(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	seen := map[uuid.UUID]bool{}
+	var out []uuid.UUID
+	for _, r := range f.records {
+		if !seen[r.ChildID] {
+			seen[r.ChildID] = true
+			out = append(out, r.ChildID)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSleepSyncRepo) GetPreference(ctx context.Context, childID uuid.UUID) (*models.SleepSourcePreference, error) {
+	return f.pref, nil
+}
+
+func (f *fakeSleepSyncRepo) SavePreference(ctx context.Context, p *models.SleepSourcePreference) error {
+	f.pref = p
+	return nil
+}
+
+type fakeSleepLogs struct {
+	logs    []models.SleepLog
+	updates int
+}
+
+func (f *fakeSleepLogs) GetSleepLogs(ctx context.Context, childID uuid.UUID, startDate, endDate time.Time) ([]models.SleepLog, error) {
+	return append([]models.SleepLog(nil), f.logs...), nil
+}
+
+func (f *fakeSleepLogs) CreateSleepLog(ctx context.Context, l *models.SleepLog) error {
+	l.ID = uuid.New()
+	f.logs = append(f.logs, *l)
+	return nil
+}
+
+func (f *fakeSleepLogs) UpdateSleepLog(ctx context.Context, l *models.SleepLog, editedBy uuid.UUID) error {
+	f.updates++
+	for i := range f.logs {
+		if f.logs[i].ID == l.ID {
+			f.logs[i] = *l
+		}
+	}
+	return nil
+}
+
+func TestSleepMinutesBetween(t *testing.T) {
+	for _, tc := range []struct {
+		bed, wake string
+		want      int
+		ok        bool
+	}{
+		{"20:30", "06:45", 615, true},
+		{"20:30:00", "06:45:00", 615, true},
+		{"8:30 pm", "6:45 AM", 615, true},
+		{"13:00", "15:30", 150, true},
+		{"00:15", "07:00", 405, true},
+		{"21:00", "21:00", 0, false},
+		{"", "06:00", 0, false},
+		{"bedtime", "06:00", 0, false},
+	} {
+		got, ok := sleepMinutesBetween(tc.bed, tc.wake)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("sleepMinutesBetween(%q, %q) = %d, %v; want %d, %v", tc.bed, tc.wake, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	l := &models.SleepLog{Bedtime: nullStr("21:15"), WakeTime: nullStr("06:00")}
+	fillSleepMinutes(l)
+	if l.TotalSleepMinutes == nil || *l.TotalSleepMinutes != 525 {
+		t.Fatalf("fillSleepMinutes: got %v, want 525", l.TotalSleepMinutes)
+	}
+	entered := 400
+	l = &models.SleepLog{Bedtime: nullStr("21:15"), WakeTime: nullStr("06:00"), TotalSleepMinutes: &entered}
+	fillSleepMinutes(l)
+	if *l.TotalSleepMinutes != 400 {
+		t.Errorf("fillSleepMinutes overwrote an entered duration: %d", *l.TotalSleepMinutes)
+	}
+}
+
+func TestSleepSyncImport(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeSleepSyncRepo{}
+	logs := &fakeSleepLogs{}
+	svc := NewSleepSyncService(repo, logs, logs)
+	childID, userID := uuid.New(), uuid.New()
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+
+	start := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC) // 21:00 PDT on the 15th
+	end := time.Date(2026, 10, 16, 13, 30, 0, 0, time.UTC) // 06:30 PDT on the 16th
+	recs, err := svc.Import(ctx, childID, userID, loc, &models.SleepDeviceImportRequest{Records: []models.SleepDeviceRecordInput{
+		{Source: models.SleepSourceFitbit, ExternalID: "n1", SleepStart: start, SleepEnd: end},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recs[0].NightDate.Format("2006-01-02"); got != "2026-10-16" {
+		t.Errorf("night date = %s, want the local wake date 2026-10-16", got)
+	}
+	if recs[0].TotalSleepMinutes != 570 {
+		t.Errorf("total defaulted to %d, want the 570 minute span", recs[0].TotalSleepMinutes)
+	}
+
+	// Re-importing the same session replaces it.
+	asleep := 510
+	if _, err := svc.Import(ctx, childID, userID, loc, &models.SleepDeviceImportRequest{Records: []models.SleepDeviceRecordInput{
+		{Source: models.SleepSourceFitbit, ExternalID: "n1", SleepStart: start, SleepEnd: end, TotalSleepMinutes: &asleep},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.records) != 1 || repo.records[0].TotalSleepMinutes != 510 {
+		t.Errorf("re-import: records = %+v", repo.records)
+	}
+
+	tooLong := 600
+	for name, in := range map[string]models.SleepDeviceRecordInput{
+		"unknown source": {Source: "smartwatch", ExternalID: "x", SleepStart: start, SleepEnd: end},
+		"no external id": {Source: models.SleepSourceOura, SleepStart: start, SleepEnd: end},
+		"ends first":     {Source: models.SleepSourceOura, ExternalID: "x", SleepStart: end, SleepEnd: start},
+		"over 24 hours":  {Source: models.SleepSourceOura, ExternalID: "x", SleepStart: start, SleepEnd: start.Add(25 * time.Hour)},
+		"asleep > span":  {Source: models.SleepSourceOura, ExternalID: "x", SleepStart: start, SleepEnd: end, TotalSleepMinutes: &tooLong},
+	} {
+		_, err := svc.Import(ctx, childID, userID, loc, &models.SleepDeviceImportRequest{Records: []models.SleepDeviceRecordInput{in}})
+		if !errors.Is(err, ErrSleepSyncInvalid) {
+			t.Errorf("%s: err = %v, want ErrSleepSyncInvalid", name, err)
+		}
+	}
+}
+
+func TestSleepSyncReconcile(t *testing.T) {
+	ctx := context.Background()
+	childID, userID := uuid.New(), uuid.New()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	minutes := func(m int) *int { return &m }
+	record := func(d, total int) models.SleepDeviceRecord {
+		wake := day(d).Add(6*time.Hour + 30*time.Minute)
+		return models.SleepDeviceRecord{
+			ChildID: childID, Source: models.SleepSourceOura, ExternalID: uuid.NewString(),
+			SleepStart: wake.Add(-time.Duration(total) * time.Minute), SleepEnd: wake,
+			TotalSleepMinutes: total, NightDate: day(d), Timezone: "UTC", ImportedBy: &userID,
+		}
+	}
+	manual := func(d int, total int) models.SleepLog {
+		return models.SleepLog{ID: uuid.New(), ChildID: childID, LogDate: day(d), TotalSleepMinutes: minutes(total),
+			Source: models.SleepSourceManual, LoggedBy: userID}
+	}
+	setup := func(pref string) (*SleepSyncService, *fakeSleepSyncRepo, *fakeSleepLogs) {
+		repo := &fakeSleepSyncRepo{
+			records: []models.SleepDeviceRecord{record(10, 540), record(11, 420), record(12, 480)},
+			pref:    &models.SleepSourcePreference{ChildID: childID, PreferredSource: pref, ConflictThresholdMinutes: 60},
+		}
+		logs := &fakeSleepLogs{logs: []models.SleepLog{
+			manual(10, 520), // within threshold
+			manual(11, 540), // device says two hours less
+			manual(11, 90),  // a nap the same day
+		}}
+		return NewSleepSyncService(repo, logs, logs), repo, logs
+	}
+
+	t.Run("manual preferred", func(t *testing.T) {
+		svc, _, logs := setup(models.SleepSourceManual)
+		res, err := svc.ReconcileChild(ctx, childID, day(1), day(14))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Consistent != 1 || res.Conflicts != 1 || res.Resolved != 0 || res.Created != 0 {
+			t.Fatalf("result = %+v", res)
+		}
+		conflict := logs.logs[1]
+		if conflict.ReconciliationStatus.String != models.SleepReconciliationConflict || *conflict.TotalSleepMinutes != 540 {
+			t.Errorf("manual entry should be kept and flagged: %+v", conflict)
+		}
+		if note := conflict.ReconciliationNote.String; !strings.Contains(note, "Oura recorded 7h 00m, 2h 00m less") {
+			t.Errorf("note = %q", note)
+		}
+		if logs.logs[2].ReconciliationStatus.Valid {
+			t.Error("the nap was reconciled against the night's device record")
+		}
+		if len(logs.logs) != 3 {
+			t.Error("a log was created for an unlogged night while manual entries are preferred")
+		}
+
+		// A second run with nothing new writes nothing.
+		before := logs.updates
+		if _, err := svc.ReconcileChild(ctx, childID, day(1), day(14)); err != nil {
+			t.Fatal(err)
+		}
+		if logs.updates != before {
+			t.Errorf("re-run made %d updates", logs.updates-before)
+		}
+	})
+
+	t.Run("device preferred", func(t *testing.T) {
+		svc, _, logs := setup(models.SleepSourceOura)
+		res, err := svc.ReconcileChild(ctx, childID, day(1), day(14))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Consistent != 1 || res.Conflicts != 0 || res.Resolved != 1 || res.Created != 1 {
+			t.Fatalf("result = %+v", res)
+		}
+		replaced := logs.logs[1]
+		if *replaced.TotalSleepMinutes != 420 || replaced.Source != models.SleepSourceOura ||
+			replaced.Bedtime.String != "23:30:00" || replaced.WakeTime.String != "06:30:00" {
+			t.Errorf("device values not applied: %+v", replaced)
+		}
+		if note := replaced.ReconciliationNote.String; !strings.Contains(note, "recorded 9h 00m; replaced with 7h 00m from Oura") {
+			t.Errorf("note = %q", note)
+		}
+		created := logs.logs[3]
+		if !created.LogDate.Equal(day(12)) || *created.TotalSleepMinutes != 480 || created.LoggedBy != userID {
+			t.Errorf("created log = %+v", created)
+		}
+	})
+}
@@ -5,13 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	stripe "github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/charge"
 	"github.com/stripe/stripe-go/v76/checkout/session"
+	"github.com/stripe/stripe-go/v76/invoice"
 	"github.com/stripe/stripe-go/v76/price"
 	"github.com/stripe/stripe-go/v76/product"
+	"github.com/stripe/stripe-go/v76/subscription"
+	"github.com/stripe/stripe-go/v76/subscriptionitem"
+	"github.com/stripe/stripe-go/v76/taxrate"
 	"github.com/stripe/stripe-go/v76/webhook"
 
 	"carecompanion/internal/config"
@@ -23,11 +30,19 @@ import (
 // session creation, and webhook signature verification. The SDK uses a
 // process-global API key (stripe.Key) — we set it once in the constructor.
 type StripeService struct {
-	cfg         config.StripeConfig
-	billingRepo repository.BillingRepository
-	subSvc      *SubscriptionService
-	successURL  string
-	cancelURL   string
+	cfg          config.StripeConfig
+	billingRepo  repository.BillingRepository
+	subSvc       *SubscriptionService
+	orgBilling   *OrganizationBillingService
+	invoices     *InvoiceService
+	tax          *TaxService
+	disputes     *DisputeService
+	planChanges  *PlanChangeService
+	exitPackages *ExitPackageService
+	taxRatesMu   sync.Mutex
+	taxRates     map[string]*stripe.TaxRate
+	successURL   string
+	cancelURL    string
 }
 
 // NewStripeService initializes the SDK key and returns the service. Callers
@@ -51,6 +66,43 @@ func (s *StripeService) SetSubscriptionService(sub *SubscriptionService) {
 	s.subSvc = sub
 }
 
+// SetOrganizationBilling attaches the organization billing service.
+// Subscriptions whose metadata carries an organization_id are routed to it
+// instead of the family subscription service.
+func (s *StripeService) SetOrganizationBilling(ob *OrganizationBillingService) {
+	s.orgBilling = ob
+}
+
+// SetTaxService attaches the tax service. Tax collected on paid invoices
+// is recorded through it.
+func (s *StripeService) SetTaxService(t *TaxService) {
+	s.tax = t
+}
+
+// SetDisputeService attaches the dispute service. charge.dispute.*
+// events are recorded through it.
+func (s *StripeService) SetDisputeService(d *DisputeService) {
+	s.disputes = d
+}
+
+// SetPlanChangeService attaches the plan change service. Plan switches
+// made in the Stripe Dashboard are recorded through it.
+func (s *StripeService) SetPlanChangeService(pc *PlanChangeService) {
+	s.planChanges = pc
+}
+
+// SetExitPackageService attaches the exit package service. Cancelling a
+// family subscription schedules the family's data export through it.
+func (s *StripeService) SetExitPackageService(ep *ExitPackageService) {
+	s.exitPackages = ep
+}
+
+// SetInvoiceService attaches the invoice service. Paid invoices are
+// invoiced and emailed to the payer once our state is updated.
+func (s *StripeService) SetInvoiceService(inv *InvoiceService) {
+	s.invoices = inv
+}
+
 // EnsureAllPlansSynced walks every active plan and provisions a Stripe
 // Product + Price for any that don't yet have stripe_product_id /
 // stripe_price_id set. Idempotent — safe to call on every server boot.
@@ -110,6 +162,8 @@ func (s *StripeService) syncPlan(ctx context.Context, p models.SubscriptionPlan)
 		Product:    stripe.String(productID),
 		Currency:   stripe.String(string(stripe.CurrencyUSD)),
 		UnitAmount: stripe.Int64(int64(p.PriceCents)),
+		// Plan prices are net; Stripe Tax adds tax on top.
+		TaxBehavior: stripe.String(string(stripe.PriceTaxBehaviorExclusive)),
 		Recurring: &stripe.PriceRecurringParams{
 			Interval: stripe.String(interval),
 		},
@@ -183,6 +237,7 @@ func (s *StripeService) CreateCheckoutSession(ctx context.Context, p CheckoutPar
 	} else if p.CustomerEmail != "" {
 		params.CustomerEmail = stripe.String(p.CustomerEmail)
 	}
+	s.applyAutomaticTax(params)
 	if p.TrialDays > 0 {
 		params.SubscriptionData.TrialPeriodDays = stripe.Int64(int64(p.TrialDays))
 	}
@@ -193,6 +248,19 @@ func (s *StripeService) CreateCheckoutSession(ctx context.Context, p CheckoutPar
 	return sess, nil
 }
 
+// applyAutomaticTax turns on Stripe Tax for a Checkout session when
+// STRIPE_AUTOMATIC_TAX is set. Checkout then asks for the address it needs;
+// an existing customer's address is updated from what they enter.
+func (s *StripeService) applyAutomaticTax(params *stripe.CheckoutSessionParams) {
+	if !s.cfg.AutomaticTax {
+		return
+	}
+	params.AutomaticTax = &stripe.CheckoutSessionAutomaticTaxParams{Enabled: stripe.Bool(true)}
+	if params.Customer != nil {
+		params.CustomerUpdate = &stripe.CheckoutSessionCustomerUpdateParams{Address: stripe.String("auto")}
+	}
+}
+
 // VerifyWebhookSignature verifies the Stripe-Signature header against the
 // payload using the configured webhook secret. Always reject if the secret
 // is unset — silently accepting unsigned events would let any HTTP client
@@ -221,6 +289,9 @@ func (s *StripeService) HandleEvent(ctx context.Context, ev stripe.Event) error
 		return s.handleInvoicePaid(ctx, ev)
 	case "invoice.payment_failed":
 		return s.handleInvoicePaymentFailed(ctx, ev)
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		return s.handleDispute(ctx, ev)
 	default:
 		log.Printf("[STRIPE] ignoring event type %s (id=%s)", ev.Type, ev.ID)
 		return nil
@@ -232,6 +303,9 @@ func (s *StripeService) handleCheckoutCompleted(ctx context.Context, ev stripe.E
 	if err := json.Unmarshal(ev.Data.Raw, &sess); err != nil {
 		return fmt.Errorf("decode checkout session: %w", err)
 	}
+	if _, ok := sess.Metadata["organization_id"]; ok {
+		return s.handleOrganizationCheckoutCompleted(ctx, sess)
+	}
 	familyIDStr := sess.Metadata["family_id"]
 	planIDStr := sess.Metadata["plan_id"]
 	familyID, err := uuid.Parse(familyIDStr)
@@ -260,7 +334,7 @@ func (s *StripeService) handleCheckoutCompleted(ctx context.Context, ev stripe.E
 			periodEnd = time.Unix(sess.Subscription.CurrentPeriodEnd, 0)
 		}
 		if sess.Subscription.Status != "" {
-			status = string(sess.Subscription.Status)
+			status = familyStatusFromStripe(sess.Subscription.Status)
 		}
 	}
 	log.Printf("[STRIPE] checkout.session.completed family=%s plan=%s sub=%s status=%s",
@@ -268,24 +342,66 @@ func (s *StripeService) handleCheckoutCompleted(ctx context.Context, ev stripe.E
 	return s.subSvc.ApplyCheckoutCompleted(ctx, familyID, planID, customerID, sess.Subscription.ID, status, periodEnd)
 }
 
+// familyStatusFromStripe maps a Stripe subscription status onto the
+// subscription_status enum. Stripe's unpaid (retries exhausted) and
+// incomplete (first payment failed) are past_due for us: read-only for the
+// grace period, restored when an invoice is paid. Statuses Stripe adds
+// later fall back to active rather than failing the webhook on the enum.
+func familyStatusFromStripe(st stripe.SubscriptionStatus) string {
+	switch st {
+	case stripe.SubscriptionStatusCanceled:
+		return "cancelled"
+	case stripe.SubscriptionStatusIncompleteExpired:
+		return "expired"
+	case stripe.SubscriptionStatusUnpaid, stripe.SubscriptionStatusIncomplete:
+		return "past_due"
+	case stripe.SubscriptionStatusActive, stripe.SubscriptionStatusPastDue,
+		stripe.SubscriptionStatusTrialing, stripe.SubscriptionStatusPaused:
+		return string(st)
+	}
+	return "active"
+}
+
 func (s *StripeService) handleSubscriptionUpdated(ctx context.Context, ev stripe.Event) error {
 	var sub stripe.Subscription
 	if err := json.Unmarshal(ev.Data.Raw, &sub); err != nil {
 		return fmt.Errorf("decode subscription: %w", err)
 	}
+	if _, ok := sub.Metadata["organization_id"]; ok {
+		return s.handleOrganizationSubscriptionUpdated(ctx, ev, sub)
+	}
 	periodEnd := time.Unix(sub.CurrentPeriodEnd, 0)
 	var cancelledAt *time.Time
 	if sub.CanceledAt != 0 {
 		t := time.Unix(sub.CanceledAt, 0)
 		cancelledAt = &t
 	}
-	status := string(sub.Status)
+	status := familyStatusFromStripe(sub.Status)
 	if ev.Type == "customer.subscription.deleted" {
 		status = "cancelled"
 	}
 	log.Printf("[STRIPE] %s sub=%s status=%s cancel_at_period_end=%v",
 		ev.Type, sub.ID, status, sub.CancelAtPeriodEnd)
-	return s.subSvc.ApplySubscriptionUpdated(ctx, sub.ID, status, periodEnd, sub.CancelAtPeriodEnd, cancelledAt)
+	if err := s.subSvc.ApplySubscriptionUpdated(ctx, sub.ID, status, periodEnd, sub.CancelAtPeriodEnd, cancelledAt); err != nil {
+		return err
+	}
+	// The export is built when access ends: now, or at the period end for
+	// a cancel-at-period-end. Both events share Stripe's canceled_at, so
+	// they schedule the same package.
+	if s.exitPackages != nil && cancelledAt != nil {
+		switch {
+		case status == "cancelled":
+			s.exitPackages.ScheduleForStripeSubscription(ctx, sub.ID, time.Now())
+		case sub.CancelAtPeriodEnd:
+			s.exitPackages.ScheduleForStripeSubscription(ctx, sub.ID, periodEnd)
+		}
+	}
+	if s.planChanges != nil && ev.Type == "customer.subscription.updated" && sub.Items != nil && len(sub.Items.Data) == 1 {
+		if item := sub.Items.Data[0]; item.Price != nil {
+			return s.planChanges.SyncFromStripe(ctx, sub.ID, item.Price.ID)
+		}
+	}
+	return nil
 }
 
 func (s *StripeService) handleInvoicePaid(ctx context.Context, ev stripe.Event) error {
@@ -297,6 +413,13 @@ func (s *StripeService) handleInvoicePaid(ctx context.Context, ev stripe.Event)
 		// One-off invoices (not subscription-related) don't move our state.
 		return nil
 	}
+	if isOrganizationInvoice(&inv) {
+		// Organization subscriptions sync from customer.subscription.*;
+		// only the tax record and invoice document come from here.
+		s.recordInvoiceTax(ctx, &inv, organizationIDFromInvoice(&inv))
+		s.issueOrganizationInvoice(ctx, &inv)
+		return nil
+	}
 	// Use the line item period end if present, else fall back to NOW()+30d.
 	periodEnd := time.Now().Add(30 * 24 * time.Hour)
 	if inv.Lines != nil && len(inv.Lines.Data) > 0 {
@@ -306,7 +429,76 @@ func (s *StripeService) handleInvoicePaid(ctx context.Context, ev stripe.Event)
 	}
 	log.Printf("[STRIPE] invoice paid sub=%s amount=%d period_end=%s",
 		inv.Subscription.ID, inv.AmountPaid, periodEnd.Format(time.RFC3339))
-	return s.subSvc.ApplyInvoicePaid(ctx, inv.Subscription.ID, periodEnd, inv.AmountPaid, string(inv.Currency), inv.ID)
+	if err := s.subSvc.ApplyInvoicePaid(ctx, inv.Subscription.ID, periodEnd, inv.AmountPaid, string(inv.Currency), inv.ID); err != nil {
+		return err
+	}
+	// Tax goes on the payments row before the invoice is issued from it.
+	s.recordInvoiceTax(ctx, &inv, nil)
+	if s.invoices != nil {
+		// Best-effort: a failure here shouldn't make Stripe retry the
+		// webhook. Billing history invoices any payment missed.
+		issued, err := s.invoices.IssueForStripeInvoice(ctx, inv.ID)
+		if err != nil {
+			log.Printf("[STRIPE] invoice %s paid but issuing our invoice failed: %v", inv.ID, err)
+		}
+		s.invoices.SendNew(ctx, issued)
+	}
+	return nil
+}
+
+// issueOrganizationInvoice issues and emails the invoice for a paid
+// organization seat invoice, including any proration lines from seat
+// changes mid-period. Errors are logged, not returned.
+func (s *StripeService) issueOrganizationInvoice(ctx context.Context, inv *stripe.Invoice) {
+	if s.invoices == nil || inv.AmountPaid <= 0 {
+		return
+	}
+	orgID := organizationIDFromInvoice(inv)
+	if orgID == nil {
+		log.Printf("[STRIPE] org invoice %s: bad organization_id metadata", inv.ID)
+		return
+	}
+	in := OrganizationInvoiceInput{
+		OrganizationID:  *orgID,
+		StripeInvoiceID: inv.ID,
+		SubtotalCents:   inv.Subtotal,
+		TotalCents:      inv.AmountPaid,
+		Currency:        string(inv.Currency),
+		PaidAt:          time.Now(),
+	}
+	for _, t := range inv.TotalTaxAmounts {
+		in.TaxCents += t.Amount
+		in.TaxInclusive = in.TaxInclusive || t.Inclusive
+	}
+	if inv.StatusTransitions != nil && inv.StatusTransitions.PaidAt != 0 {
+		in.PaidAt = time.Unix(inv.StatusTransitions.PaidAt, 0)
+	}
+	for _, d := range inv.TotalDiscountAmounts {
+		in.DiscountCents += d.Amount
+	}
+	if inv.Lines != nil {
+		for _, l := range inv.Lines.Data {
+			line := repository.InvoiceLine{Description: l.Description, Quantity: int(l.Quantity), AmountCents: l.Amount}
+			if line.Description == "" {
+				line.Description = "Organization seats"
+			}
+			if l.Proration {
+				// Proration lines carry their own quantity wording.
+				line.Quantity = 0
+			}
+			in.Lines = append(in.Lines, line)
+			if !l.Proration && l.Period != nil && l.Period.Start != 0 && in.PeriodStart == nil {
+				start, end := time.Unix(l.Period.Start, 0), time.Unix(l.Period.End, 0)
+				in.PeriodStart, in.PeriodEnd = &start, &end
+			}
+		}
+	}
+	issued, err := s.invoices.IssueForOrganization(ctx, in)
+	if err != nil {
+		log.Printf("[STRIPE] org invoice %s paid but issuing our invoice failed: %v", inv.ID, err)
+		return
+	}
+	s.invoices.SendNew(ctx, issued)
 }
 
 func (s *StripeService) handleInvoicePaymentFailed(ctx context.Context, ev stripe.Event) error {
@@ -314,9 +506,310 @@ func (s *StripeService) handleInvoicePaymentFailed(ctx context.Context, ev strip
 	if err := json.Unmarshal(ev.Data.Raw, &inv); err != nil {
 		return fmt.Errorf("decode invoice: %w", err)
 	}
-	if inv.Subscription == nil || inv.Subscription.ID == "" {
+	if inv.Subscription == nil || inv.Subscription.ID == "" || isOrganizationInvoice(&inv) {
 		return nil
 	}
 	log.Printf("[STRIPE] invoice payment_failed sub=%s", inv.Subscription.ID)
 	return s.subSvc.ApplyInvoicePaymentFailed(ctx, inv.Subscription.ID)
 }
+
+func (s *StripeService) handleDispute(ctx context.Context, ev stripe.Event) error {
+	var dp stripe.Dispute
+	if err := json.Unmarshal(ev.Data.Raw, &dp); err != nil {
+		return fmt.Errorf("decode dispute: %w", err)
+	}
+	if s.disputes == nil {
+		log.Printf("[STRIPE] %s %s: dispute service not wired", ev.Type, dp.ID)
+		return nil
+	}
+	// The payload names the charge only by ID; its invoice is what links
+	// the dispute to our payment or organization.
+	if dp.Charge != nil && dp.Charge.ID != "" && dp.Charge.Invoice == nil {
+		params := &stripe.ChargeParams{}
+		params.Context = ctx
+		params.AddExpand("invoice")
+		if ch, err := charge.Get(dp.Charge.ID, params); err != nil {
+			log.Printf("[STRIPE] dispute %s: look up charge %s: %v", dp.ID, dp.Charge.ID, err)
+		} else {
+			dp.Charge = ch
+		}
+	}
+	return s.disputes.Apply(ctx, disputeFromStripe(&dp, time.Now()))
+}
+
+// CreateOrganizationCheckout starts a seat-based subscription for an
+// organization: one line item on the plan's per-seat price with
+// quantity = seats. organization_id in the metadata routes the resulting
+// webhooks to OrganizationBillingService.
+func (s *StripeService) CreateOrganizationCheckout(ctx context.Context, p OrganizationCheckoutParams) (string, error) {
+	if !s.cfg.Enabled() {
+		return "", fmt.Errorf("stripe not configured")
+	}
+	meta := map[string]string{
+		"organization_id": p.OrganizationID.String(),
+		"plan_id":         p.PlanID.String(),
+	}
+	params := &stripe.CheckoutSessionParams{
+		Mode: stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				Price:    stripe.String(p.StripePriceID),
+				Quantity: stripe.Int64(int64(p.Seats)),
+			},
+		},
+		SuccessURL:       stripe.String(s.successURL),
+		CancelURL:        stripe.String(s.cancelURL),
+		Metadata:         meta,
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{Metadata: meta},
+	}
+	if p.StripeCustomerID != "" {
+		params.Customer = stripe.String(p.StripeCustomerID)
+	} else if p.CustomerEmail != "" {
+		params.CustomerEmail = stripe.String(p.CustomerEmail)
+	}
+	s.applyAutomaticTax(params)
+	sess, err := session.New(params)
+	if err != nil {
+		return "", fmt.Errorf("create organization checkout session: %w", err)
+	}
+	return sess.URL, nil
+}
+
+// UpdateSeatQuantity changes an organization's seat count. Stripe prorates
+// the change for the rest of the period and, with always_invoice, bills or
+// credits it right away rather than on the next renewal. We preview the
+// upcoming invoice at the same proration date first so the amount we
+// record matches what Stripe charges.
+func (s *StripeService) UpdateSeatQuantity(ctx context.Context, subscriptionID, itemID string, seats int) (int64, error) {
+	if !s.cfg.Enabled() {
+		return 0, fmt.Errorf("stripe not configured")
+	}
+	prorationDate := time.Now().Unix()
+	preview, err := invoice.Upcoming(&stripe.InvoiceUpcomingParams{
+		Subscription: stripe.String(subscriptionID),
+		SubscriptionItems: []*stripe.SubscriptionItemsParams{
+			{ID: stripe.String(itemID), Quantity: stripe.Int64(int64(seats))},
+		},
+		SubscriptionProrationBehavior: stripe.String("always_invoice"),
+		SubscriptionProrationDate:     stripe.Int64(prorationDate),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("preview seat proration: %w", err)
+	}
+	var proration int64
+	if preview.Lines != nil {
+		for _, line := range preview.Lines.Data {
+			if line.Proration {
+				proration += line.Amount
+			}
+		}
+	}
+	if _, err := subscriptionitem.Update(itemID, &stripe.SubscriptionItemParams{
+		Quantity:          stripe.Int64(int64(seats)),
+		ProrationBehavior: stripe.String("always_invoice"),
+		ProrationDate:     stripe.Int64(prorationDate),
+	}); err != nil {
+		return 0, fmt.Errorf("update seat quantity: %w", err)
+	}
+	log.Printf("[STRIPE] seat quantity sub=%s item=%s seats=%d proration=%d", subscriptionID, itemID, seats, proration)
+	return proration, nil
+}
+
+// familySubscriptionItem returns the single item of a family subscription.
+func familySubscriptionItem(subscriptionID string) (*stripe.SubscriptionItem, error) {
+	sub, err := subscription.Get(subscriptionID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get subscription: %w", err)
+	}
+	if sub.Items == nil || len(sub.Items.Data) != 1 {
+		return nil, fmt.Errorf("subscription %s doesn't have exactly one item", subscriptionID)
+	}
+	return sub.Items.Data[0], nil
+}
+
+// PreviewPlanChange prices swapping a family subscription's price as of
+// prorationDate, with the proration invoiced immediately.
+func (s *StripeService) PreviewPlanChange(ctx context.Context, subscriptionID, priceID string, prorationDate int64) (*PlanProration, error) {
+	if !s.cfg.Enabled() {
+		return nil, fmt.Errorf("stripe not configured")
+	}
+	item, err := familySubscriptionItem(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return previewPriceSwap(subscriptionID, item.ID, priceID, prorationDate)
+}
+
+func previewPriceSwap(subscriptionID, itemID, priceID string, prorationDate int64) (*PlanProration, error) {
+	preview, err := invoice.Upcoming(&stripe.InvoiceUpcomingParams{
+		Subscription: stripe.String(subscriptionID),
+		SubscriptionItems: []*stripe.SubscriptionItemsParams{
+			{ID: stripe.String(itemID), Price: stripe.String(priceID)},
+		},
+		SubscriptionProrationBehavior: stripe.String("always_invoice"),
+		SubscriptionProrationDate:     stripe.Int64(prorationDate),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("preview plan proration: %w", err)
+	}
+	p := &PlanProration{AmountDueCents: preview.AmountDue, Currency: strings.ToUpper(string(preview.Currency))}
+	if preview.Lines != nil {
+		for _, line := range preview.Lines.Data {
+			if line.Proration {
+				p.ProrationCents += line.Amount
+			}
+		}
+	}
+	return p, nil
+}
+
+// ChangeSubscriptionPrice moves a family subscription to another price,
+// invoicing the proration as of prorationDate straight away, and returns
+// the prorated amount.
+func (s *StripeService) ChangeSubscriptionPrice(ctx context.Context, subscriptionID, priceID string, prorationDate int64) (int64, error) {
+	if !s.cfg.Enabled() {
+		return 0, fmt.Errorf("stripe not configured")
+	}
+	item, err := familySubscriptionItem(subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	preview, err := previewPriceSwap(subscriptionID, item.ID, priceID, prorationDate)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := subscriptionitem.Update(item.ID, &stripe.SubscriptionItemParams{
+		Price:             stripe.String(priceID),
+		ProrationBehavior: stripe.String("always_invoice"),
+		ProrationDate:     stripe.Int64(prorationDate),
+	}); err != nil {
+		return 0, fmt.Errorf("update subscription price: %w", err)
+	}
+	log.Printf("[STRIPE] plan change sub=%s item=%s price=%s proration=%d", subscriptionID, item.ID, priceID, preview.ProrationCents)
+	return preview.ProrationCents, nil
+}
+
+func (s *StripeService) handleOrganizationCheckoutCompleted(ctx context.Context, sess stripe.CheckoutSession) error {
+	if s.orgBilling == nil {
+		return fmt.Errorf("organization billing not wired")
+	}
+	orgID, err := uuid.Parse(sess.Metadata["organization_id"])
+	if err != nil {
+		return fmt.Errorf("checkout invalid organization_id metadata: %q", sess.Metadata["organization_id"])
+	}
+	planID, err := uuid.Parse(sess.Metadata["plan_id"])
+	if err != nil {
+		return fmt.Errorf("checkout missing/invalid plan_id metadata: %q", sess.Metadata["plan_id"])
+	}
+	if sess.Subscription == nil || sess.Subscription.ID == "" {
+		return fmt.Errorf("checkout session has no subscription")
+	}
+	customerID := ""
+	if sess.Customer != nil {
+		customerID = sess.Customer.ID
+	}
+	periodEnd := time.Now().Add(30 * 24 * time.Hour)
+	status := "active"
+	if sess.Subscription.CurrentPeriodEnd != 0 {
+		periodEnd = time.Unix(sess.Subscription.CurrentPeriodEnd, 0)
+	}
+	if sess.Subscription.Status != "" {
+		status = string(sess.Subscription.Status)
+	}
+	log.Printf("[STRIPE] checkout.session.completed organization=%s plan=%s sub=%s status=%s",
+		orgID, planID, sess.Subscription.ID, status)
+	return s.orgBilling.ApplyCheckoutCompleted(ctx, orgID, planID, customerID, sess.Subscription.ID, status, periodEnd)
+}
+
+func (s *StripeService) handleOrganizationSubscriptionUpdated(ctx context.Context, ev stripe.Event, sub stripe.Subscription) error {
+	if s.orgBilling == nil {
+		return fmt.Errorf("organization billing not wired")
+	}
+	u := OrgSubscriptionUpdate{
+		SubscriptionID: sub.ID,
+		Status:         string(sub.Status),
+		PeriodEnd:      time.Unix(sub.CurrentPeriodEnd, 0),
+		Deleted:        ev.Type == "customer.subscription.deleted",
+	}
+	u.OrganizationID, _ = uuid.Parse(sub.Metadata["organization_id"])
+	if sub.Customer != nil {
+		u.CustomerID = sub.Customer.ID
+	}
+	// Seat plans have exactly one item; its quantity is the seat count.
+	if sub.Items != nil && len(sub.Items.Data) > 0 {
+		u.ItemID = sub.Items.Data[0].ID
+		u.Seats = int(sub.Items.Data[0].Quantity)
+	}
+	log.Printf("[STRIPE] %s organization sub=%s status=%s seats=%d", ev.Type, sub.ID, u.Status, u.Seats)
+	return s.orgBilling.ApplySubscriptionUpdated(ctx, u)
+}
+
+// recordInvoiceTax records the tax a paid invoice collected. Best-effort,
+// like invoicing: Stripe keeps the authoritative record, and a failure
+// here shouldn't make Stripe retry the webhook.
+func (s *StripeService) recordInvoiceTax(ctx context.Context, inv *stripe.Invoice, orgID *uuid.UUID) {
+	if s.tax == nil || len(inv.TotalTaxAmounts) == 0 {
+		return
+	}
+	lines, err := stripeInvoiceTaxes(inv, s.lookupTaxRate)
+	if err != nil {
+		log.Printf("[STRIPE] invoice %s: resolving tax rates failed: %v", inv.ID, err)
+		return
+	}
+	collectedAt := time.Now()
+	if inv.StatusTransitions != nil && inv.StatusTransitions.PaidAt != 0 {
+		collectedAt = time.Unix(inv.StatusTransitions.PaidAt, 0)
+	}
+	err = s.tax.RecordInvoice(ctx, TaxRecord{
+		StripeInvoiceID: inv.ID,
+		OrganizationID:  orgID,
+		Currency:        string(inv.Currency),
+		CollectedAt:     collectedAt,
+		Lines:           lines,
+	})
+	if err != nil {
+		log.Printf("[STRIPE] invoice %s: recording tax failed: %v", inv.ID, err)
+	}
+}
+
+// lookupTaxRate fetches a tax rate, caching it: rates are immutable once
+// created, so each is fetched once per process.
+func (s *StripeService) lookupTaxRate(id string) (*stripe.TaxRate, error) {
+	s.taxRatesMu.Lock()
+	defer s.taxRatesMu.Unlock()
+	if r, ok := s.taxRates[id]; ok {
+		return r, nil
+	}
+	r, err := taxrate.Get(id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.taxRates == nil {
+		s.taxRates = map[string]*stripe.TaxRate{}
+	}
+	s.taxRates[id] = r
+	return r, nil
+}
+
+// organizationIDFromInvoice returns the organization an invoice's
+// subscription belongs to, or nil.
+func organizationIDFromInvoice(inv *stripe.Invoice) *uuid.UUID {
+	if inv.SubscriptionDetails == nil {
+		return nil
+	}
+	id, err := uuid.Parse(inv.SubscriptionDetails.Metadata["organization_id"])
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// isOrganizationInvoice reports whether an invoice belongs to an
+// organization's seat subscription.
+func isOrganizationInvoice(inv *stripe.Invoice) bool {
+	if inv.SubscriptionDetails == nil {
+		return false
+	}
+	_, ok := inv.SubscriptionDetails.Metadata["organization_id"]
+	return ok
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/tax/calculation"
+
+	"carecompanion/internal/repository"
+)
+
+// StripeTaxProvider quotes tax with Stripe Tax calculations, so quotes
+// match what Checkout charges when STRIPE_AUTOMATIC_TAX is on. Each quote
+// is an API call; plan lists are short and the client caches them.
+type StripeTaxProvider struct{}
+
+func NewStripeTaxProvider() *StripeTaxProvider {
+	return &StripeTaxProvider{}
+}
+
+func (p *StripeTaxProvider) Name() string { return "stripe" }
+
+func (p *StripeTaxProvider) Quote(ctx context.Context, req TaxQuoteRequest) (*TaxQuote, error) {
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = string(stripe.CurrencyUSD)
+	}
+	addr := &stripe.AddressParams{Country: stripe.String(strings.ToUpper(req.Country))}
+	if req.Region != "" {
+		addr.State = stripe.String(req.Region)
+	}
+	if req.PostalCode != "" {
+		addr.PostalCode = stripe.String(req.PostalCode)
+	}
+	params := &stripe.TaxCalculationParams{
+		Currency: stripe.String(currency),
+		CustomerDetails: &stripe.TaxCalculationCustomerDetailsParams{
+			Address:       addr,
+			AddressSource: stripe.String("billing"),
+		},
+		LineItems: []*stripe.TaxCalculationLineItemParams{{
+			Amount:      stripe.Int64(req.AmountCents),
+			Reference:   stripe.String("plan"),
+			TaxBehavior: stripe.String("exclusive"),
+		}},
+	}
+	params.Context = ctx
+	calc, err := calculation.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe tax calculation: %w", err)
+	}
+	q := &TaxQuote{
+		NetCents:   req.AmountCents,
+		TaxCents:   calc.TaxAmountExclusive,
+		TotalCents: calc.AmountTotal,
+		Provider:   p.Name(),
+	}
+	for _, b := range calc.TaxBreakdown {
+		if b.TaxRateDetails == nil || b.Amount == 0 {
+			continue
+		}
+		pct, _ := strconv.ParseFloat(b.TaxRateDetails.PercentageDecimal, 64)
+		q.RateBps += int(math.Round(pct * 100))
+		if q.TaxName == "" {
+			q.TaxName = stripeTaxTypeName(string(b.TaxRateDetails.TaxType))
+		}
+	}
+	return q, nil
+}
+
+// stripeTaxTypeName is the label for a Stripe tax type ("vat" → "VAT").
+func stripeTaxTypeName(t string) string {
+	switch t {
+	case "vat", "gst", "hst", "pst", "qst", "rst", "jct", "igst", "cgst", "sgst":
+		return strings.ToUpper(t)
+	case "sales_tax", "":
+		return "Sales tax"
+	default:
+		return strings.ReplaceAll(t, "_", " ")
+	}
+}
+
+// stripeInvoiceTaxes turns a paid invoice's tax amounts into per
+// jurisdiction rows. Webhook payloads carry tax rates as bare IDs, so they
+// are resolved through rateLookup.
+func stripeInvoiceTaxes(inv *stripe.Invoice, rateLookup func(id string) (*stripe.TaxRate, error)) ([]repository.PaymentTax, error) {
+	var out []repository.PaymentTax
+	for _, t := range inv.TotalTaxAmounts {
+		line := repository.PaymentTax{TaxableCents: t.TaxableAmount, TaxCents: t.Amount, Inclusive: t.Inclusive}
+		rate := t.TaxRate
+		if rate != nil && rate.Country == "" && rate.ID != "" && rateLookup != nil {
+			r, err := rateLookup(rate.ID)
+			if err != nil {
+				return nil, fmt.Errorf("tax rate %s: %w", rate.ID, err)
+			}
+			rate = r
+		}
+		if rate != nil {
+			line.Country = rate.Country
+			line.Region = rate.State
+			line.Jurisdiction = rate.Jurisdiction
+			if line.Jurisdiction == "" {
+				line.Jurisdiction = rate.DisplayName
+			}
+			line.TaxType = string(rate.TaxType)
+			line.RateBps = int(math.Round(rate.Percentage * 100))
+		}
+		out = append(out, line)
+	}
+	return out, nil
+}
@@ -24,6 +24,9 @@ type SubscriptionService struct {
 	familyPlanID      uuid.UUID
 
 	trialDays int
+	// readOnlyGrace is how long a lapsed family stays read-only before
+	// the sweeper terminates it; must match middleware.SetReadOnlyGrace.
+	readOnlyGrace time.Duration
 }
 
 // NewSubscriptionService loads the active plan IDs. Returns an error if the
@@ -31,7 +34,7 @@ type SubscriptionService struct {
 // pick the right plan_id, so we'd rather fail loudly at startup than silently
 // at the first signup.
 func NewSubscriptionService(db *sql.DB) (*SubscriptionService, error) {
-	s := &SubscriptionService{db: db, trialDays: 14}
+	s := &SubscriptionService{db: db, trialDays: 14, readOnlyGrace: 14 * 24 * time.Hour}
 	row := s.db.QueryRow(`
         SELECT
             (SELECT id FROM subscription_plans WHERE name = 'Single Child' AND is_active = true LIMIT 1),
@@ -45,6 +48,14 @@ func NewSubscriptionService(db *sql.DB) (*SubscriptionService, error) {
 	return s, nil
 }
 
+// SetReadOnlyGrace sets how long past_due families stay read-only before
+// RunExpiryCheck terminates them. Non-positive values keep the default.
+func (s *SubscriptionService) SetReadOnlyGrace(d time.Duration) {
+	if d > 0 {
+		s.readOnlyGrace = d
+	}
+}
+
 // StartTrialIfNew creates a 14-day Single-Child trial for the family IF and
 // only if no family_subscriptions row exists yet. Idempotent on family_id —
 // safe to call from a signup hook even if the user retries.
@@ -159,8 +170,10 @@ func (s *SubscriptionService) startTrialOnFamilyPlan(ctx context.Context, tx *sq
 }
 
 // RunExpiryCheck transitions subscriptions whose clocks have passed:
-//   - 'trialing' with trial_end < NOW()                  → 'past_due' (begin 14-day read-only window)
-//   - 'past_due' with past_due_since < NOW() - 14 days   → 'terminated' (export-and-delete handled in Phase 5)
+//   - 'trialing' with trial_end < NOW()                  → 'past_due' (begin the read-only grace window)
+//   - 'past_due' with past_due_since < NOW() - grace     → 'terminated' (export-and-delete handled in Phase 5)
+//
+// The grace window is 14 days unless SetReadOnlyGrace changed it.
 //
 // 'comped' families are NEVER touched — comp_until is informational only at
 // this stage; Bryan will get a separate report when comps lapse so he can
@@ -181,14 +194,14 @@ func (s *SubscriptionService) RunExpiryCheck(ctx context.Context) (transitioned
 	}
 	a, _ := r.RowsAffected()
 
-	// past_due for >= 14 days → terminated. Phase 5 wires the cold-storage
-	// export hook to this transition.
+	// past_due for the whole grace window → terminated. Phase 5 wires the
+	// cold-storage export hook to this transition.
 	r, err = s.db.ExecContext(ctx, `
         UPDATE family_subscriptions
         SET status = 'terminated'
         WHERE status = 'past_due'
           AND past_due_since IS NOT NULL
-          AND past_due_since < NOW() - INTERVAL '14 days'`)
+          AND past_due_since < NOW() - make_interval(secs => $1)`, s.readOnlyGrace.Seconds())
 	if err != nil {
 		return int(a), fmt.Errorf("past_due termination sweep: %w", err)
 	}
@@ -235,6 +248,9 @@ func (s *SubscriptionService) ApplyCheckoutCompleted(
 // ApplySubscriptionUpdated is called for customer.subscription.updated and
 // customer.subscription.deleted events. We trust Stripe's view of status,
 // period_end, and cancel_at_period_end for the matching subscription.
+// status must already be one of ours (see familyStatusFromStripe). Leaving
+// past_due clears past_due_since, so a later failure gets a fresh grace
+// window.
 func (s *SubscriptionService) ApplySubscriptionUpdated(
 	ctx context.Context,
 	stripeSubscriptionID string,
@@ -255,7 +271,7 @@ func (s *SubscriptionService) ApplySubscriptionUpdated(
             current_period_end   = $3,
             cancel_at_period_end = $4,
             cancelled_at         = COALESCE($5::timestamptz, cancelled_at),
-            past_due_since       = CASE WHEN $2 = 'past_due' AND past_due_since IS NULL THEN NOW() ELSE past_due_since END,
+            past_due_since       = CASE WHEN $2 = 'past_due' THEN COALESCE(past_due_since, NOW()) END,
             updated_at           = NOW()
         WHERE stripe_subscription_id = $1`,
 		stripeSubscriptionID, status, currentPeriodEnd, cancelAtPeriodEnd, cancelledAtArg,
@@ -268,6 +284,8 @@ func (s *SubscriptionService) ApplySubscriptionUpdated(
 
 // ApplyInvoicePaid extends current_period_end after a successful renewal,
 // clears past_due_since, and inserts a payments row for revenue tracking.
+// Flipping to 'active' is what restores a read-only or terminated family:
+// entitlement is computed per request, so access returns with the webhook.
 // The payments row is best-effort: if insertion fails (e.g. no parent of
 // the family found) we still update the subscription state — better to
 // have correct entitlement than to fail the whole webhook for a logging
@@ -287,16 +305,18 @@ func (s *SubscriptionService) ApplyInvoicePaid(
 	defer tx.Rollback()
 
 	var subscriptionID, familyID uuid.UUID
+	var prevStatus string
 	err = tx.QueryRowContext(ctx, `
+        WITH prev AS (SELECT status FROM family_subscriptions WHERE stripe_subscription_id = $1)
         UPDATE family_subscriptions SET
             status             = 'active',
             current_period_end = $2,
             past_due_since     = NULL,
             updated_at         = NOW()
         WHERE stripe_subscription_id = $1
-        RETURNING id, family_id`,
+        RETURNING id, family_id, (SELECT status::text FROM prev)`,
 		stripeSubscriptionID, currentPeriodEnd,
-	).Scan(&subscriptionID, &familyID)
+	).Scan(&subscriptionID, &familyID, &prevStatus)
 	if errors.Is(err, sql.ErrNoRows) {
 		// Subscription not tracked locally — could be a test event for an
 		// unrelated account. Don't error.
@@ -305,6 +325,9 @@ func (s *SubscriptionService) ApplyInvoicePaid(
 	if err != nil {
 		return fmt.Errorf("ApplyInvoicePaid update: %w", err)
 	}
+	if prevStatus != "active" && prevStatus != "trialing" {
+		log.Printf("[BILLING] family %s restored to full access by payment (was %s)", familyID, prevStatus)
+	}
 
 	// Pick any active parent on the family for the payments.user_id FK.
 	// If the family has no parents (shouldn't happen but defensive), skip
@@ -404,21 +427,27 @@ func (s *SubscriptionService) PlanIDForStripePrice(ctx context.Context, stripePr
 // the user-visible state granularity is "days remaining" so there's no point
 // running more often than that.
 type SubscriptionScheduler struct {
-	svc *SubscriptionService
+	svc  *SubscriptionService
+	jobs *JobLocker
 }
 
-func NewSubscriptionScheduler(svc *SubscriptionService) *SubscriptionScheduler {
-	return &SubscriptionScheduler{svc: svc}
+func NewSubscriptionScheduler(svc *SubscriptionService, jobs *JobLocker) *SubscriptionScheduler {
+	return &SubscriptionScheduler{svc: svc, jobs: jobs}
 }
 
 func (s *SubscriptionScheduler) Start(ctx context.Context) {
 	log.Println("Subscription expiry scheduler started")
-	// Run once at boot so dev iteration doesn't need to wait an hour.
-	if n, err := s.svc.RunExpiryCheck(ctx); err != nil {
-		log.Printf("Subscription expiry: initial run failed: %v", err)
-	} else if n > 0 {
-		log.Printf("Subscription expiry: initial run transitioned %d row(s)", n)
+	check := func() {
+		s.jobs.RunOnce(ctx, "subscription_expiry", TickSlot(time.Now(), time.Hour), time.Hour, func(ctx context.Context) {
+			if n, err := s.svc.RunExpiryCheck(ctx); err != nil {
+				log.Printf("Subscription expiry: tick failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Subscription expiry: transitioned %d row(s)", n)
+			}
+		})
 	}
+	// Run once at boot so dev iteration doesn't need to wait an hour.
+	check()
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 	for {
@@ -427,11 +456,7 @@ func (s *SubscriptionScheduler) Start(ctx context.Context) {
 			log.Println("Subscription expiry scheduler stopped")
 			return
 		case <-ticker.C:
-			if n, err := s.svc.RunExpiryCheck(ctx); err != nil {
-				log.Printf("Subscription expiry: tick failed: %v", err)
-			} else if n > 0 {
-				log.Printf("Subscription expiry: transitioned %d row(s)", n)
-			}
+			check()
 		}
 	}
 }
@@ -0,0 +1,579 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/database"
+)
+
+var (
+	ErrTaskQueueUnavailable = errors.New("task queue unavailable")
+	ErrUnknownTaskType      = errors.New("unknown task type")
+	ErrDeadTaskNotFound     = apperr.NotFound("dead task not found")
+)
+
+// Redis layout, per task type:
+//
+//	tasks:<type>          stream of ready tasks, read by the "workers" group
+//	tasks:<type>:delayed  sorted set of tasks waiting out a retry backoff,
+//	                      scored by due time (unix ms)
+//	tasks:<type>:dead     stream of tasks that used up their attempts
+//	tasks:<type>:stats    hash of succeeded / retried / dead counters
+const (
+	taskKeyPrefix   = "tasks:"
+	taskGroup       = "workers"
+	taskDeadMaxLen  = 1000
+	taskReadBlock   = 5 * time.Second
+	taskPromoteTick = time.Second
+)
+
+// TaskHandler does the work for one task. Returning an error retries the
+// task with backoff until MaxAttempts, after which it moves to the dead
+// letter stream. Handlers must be idempotent: a task whose worker dies
+// mid-run is redelivered once its Timeout has passed.
+type TaskHandler func(ctx context.Context, payload json.RawMessage) error
+
+// TaskType describes one kind of background task.
+type TaskType struct {
+	Name string
+	// Concurrency is the number of workers per instance (default 1).
+	Concurrency int
+	// MaxAttempts including the first run (default 5).
+	MaxAttempts int
+	// Timeout bounds one attempt (default 5 minutes).
+	Timeout time.Duration
+	Handler TaskHandler
+}
+
+// Task is a queued unit of work as stored in Redis.
+type Task struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	LastError  string          `json:"last_error,omitempty"`
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`
+}
+
+// DeadTask is a task in the dead letter stream. EntryID is the stream
+// entry to pass back to RetryDead / DiscardDead.
+type DeadTask struct {
+	EntryID string `json:"entry_id"`
+	Task
+}
+
+// TaskQueueStats is the admin view of one task type.
+type TaskQueueStats struct {
+	Type        string `json:"type"`
+	Concurrency int    `json:"concurrency"`
+	MaxAttempts int    `json:"max_attempts"`
+	Ready       int64  `json:"ready"`
+	InFlight    int64  `json:"in_flight"`
+	Delayed     int64  `json:"delayed"`
+	Dead        int64  `json:"dead"`
+	Succeeded   int64  `json:"succeeded"`
+	Retried     int64  `json:"retried"`
+	DeadTotal   int64  `json:"dead_total"`
+}
+
+// TaskQueue runs background work on Redis Streams. Any instance can
+// enqueue; instances with workers enabled consume, each task type with its
+// own worker pool, so a slow export can't starve notifications.
+type TaskQueue struct {
+	r        *database.Redis
+	consumer string
+	drain    *Drain
+
+	mu    sync.RWMutex
+	types map[string]TaskType
+
+	now func() time.Time
+}
+
+// NewTaskQueue creates the queue. r may be nil (tests, tools), in which
+// case Enqueue and the admin calls return ErrTaskQueueUnavailable. drain
+// may be nil.
+func NewTaskQueue(r *database.Redis, drain *Drain) *TaskQueue {
+	host, _ := os.Hostname()
+	return &TaskQueue{
+		r:        r,
+		consumer: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		drain:    drain,
+		types:    map[string]TaskType{},
+		now:      time.Now,
+	}
+}
+
+// Register adds a task type. Call before Start.
+func (q *TaskQueue) Register(t TaskType) {
+	if t.Concurrency <= 0 {
+		t.Concurrency = 1
+	}
+	if t.MaxAttempts <= 0 {
+		t.MaxAttempts = 5
+	}
+	if t.Timeout <= 0 {
+		t.Timeout = 5 * time.Minute
+	}
+	q.mu.Lock()
+	q.types[t.Name] = t
+	q.mu.Unlock()
+}
+
+func (q *TaskQueue) taskType(name string) (TaskType, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	t, ok := q.types[name]
+	return t, ok
+}
+
+func (q *TaskQueue) typeNames() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	names := make([]string, 0, len(q.types))
+	for n := range q.types {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func streamKey(taskType string) string  { return taskKeyPrefix + taskType }
+func delayedKey(taskType string) string { return taskKeyPrefix + taskType + ":delayed" }
+func deadKey(taskType string) string    { return taskKeyPrefix + taskType + ":dead" }
+func statsKey(taskType string) string   { return taskKeyPrefix + taskType + ":stats" }
+
+// taskBackoff is the wait before retry n (1-based): 10s, 40s, 90s, ...
+// capped at 30 minutes.
+func taskBackoff(attempt int) time.Duration {
+	d := time.Duration(attempt*attempt) * 10 * time.Second
+	if d > 30*time.Minute {
+		d = 30 * time.Minute
+	}
+	return d
+}
+
+// Enqueue adds a task; payload is marshalled to JSON. Returns the task ID.
+func (q *TaskQueue) Enqueue(ctx context.Context, taskType string, payload interface{}) (string, error) {
+	if q == nil || q.r == nil {
+		return "", ErrTaskQueueUnavailable
+	}
+	if _, ok := q.taskType(taskType); !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTaskType, taskType)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal payload: %w", err)
+	}
+	t := Task{ID: uuid.New().String(), Type: taskType, Payload: raw, EnqueuedAt: q.now().UTC()}
+	if err := q.add(ctx, t); err != nil {
+		return "", err
+	}
+	return t.ID, nil
+}
+
+func (q *TaskQueue) add(ctx context.Context, t Task) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return q.r.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(t.Type),
+		Values: map[string]interface{}{"task": body},
+	}).Err()
+}
+
+// ensureGroup creates the consumer group (and stream) if missing.
+func (q *TaskQueue) ensureGroup(ctx context.Context, taskType string) error {
+	err := q.r.XGroupCreateMkStream(ctx, streamKey(taskType), taskGroup, "0").Err()
+	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+		return err
+	}
+	return nil
+}
+
+// Start runs the worker pools and the retry promoter until ctx is
+// cancelled, then waits for running tasks to finish (see handle). Call in
+// a goroutine, after every Register.
+func (q *TaskQueue) Start(ctx context.Context) {
+	if q.r == nil {
+		return
+	}
+	if len(q.typeNames()) == 0 {
+		log.Println("[TASKS] No task types registered; workers not started")
+		return
+	}
+	var wg sync.WaitGroup
+	for _, name := range q.typeNames() {
+		t, _ := q.taskType(name)
+		if err := q.ensureGroup(ctx, name); err != nil {
+			log.Printf("[TASKS] %s: create consumer group: %v", name, err)
+			continue
+		}
+		for i := 0; i < t.Concurrency; i++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				q.work(ctx, t, fmt.Sprintf("%s-%d", q.consumer, worker))
+			}(i)
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q.promote(ctx)
+	}()
+	log.Printf("[TASKS] Workers started for %d task types", len(q.typeNames()))
+	wg.Wait()
+}
+
+// work reads and runs tasks of one type until ctx is cancelled.
+func (q *TaskQueue) work(ctx context.Context, t TaskType, consumer string) {
+	for ctx.Err() == nil {
+		res, err := q.r.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    taskGroup,
+			Consumer: consumer,
+			Streams:  []string{streamKey(t.Name), ">"},
+			Count:    1,
+			Block:    taskReadBlock,
+		}).Result()
+		if err != nil {
+			if err != redis.Nil && ctx.Err() == nil {
+				log.Printf("[TASKS] %s: read: %v", t.Name, err)
+				sleepCtx(ctx, taskReadBlock)
+			}
+			continue
+		}
+		for _, s := range res {
+			for _, msg := range s.Messages {
+				q.handle(ctx, t, msg)
+			}
+		}
+	}
+}
+
+// handle runs one delivered message and settles it: acked and deleted on
+// success, otherwise rescheduled or dead-lettered.
+func (q *TaskQueue) handle(ctx context.Context, t TaskType, msg redis.XMessage) {
+	task, err := decodeTaskMessage(msg)
+	if err != nil {
+		log.Printf("[TASKS] %s: dropping malformed entry %s: %v", t.Name, msg.ID, err)
+		q.settle(ctx, t.Name, msg.ID)
+		return
+	}
+	task.Attempt++
+
+	// Shutdown cancels ctx to stop the read loop; the task itself keeps
+	// running until the drain deadline.
+	wctx, wcancel := q.drain.WorkContext(ctx)
+	runErr := q.run(wctx, t, task)
+	wcancel()
+	// Settle with a fresh context so a shutdown mid-task still records the
+	// outcome instead of leaving the entry pending for redelivery.
+	sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if runErr != nil && q.drain.HardStopped() {
+		// Cut off by the drain deadline, not a failure of the task:
+		// put it straight back for another instance, same attempt count.
+		task.Attempt--
+		if err := q.add(sctx, *task); err != nil {
+			log.Printf("[TASKS] %s %s: requeue on shutdown: %v", t.Name, task.ID, err)
+			return
+		}
+		log.Printf("[TASKS] %s %s: requeued on shutdown", t.Name, task.ID)
+		q.settle(sctx, t.Name, msg.ID)
+		return
+	}
+	if runErr == nil {
+		q.r.HIncrBy(sctx, statsKey(t.Name), "succeeded", 1)
+		q.settle(sctx, t.Name, msg.ID)
+		return
+	}
+	if err := q.fail(sctx, t, task, runErr); err != nil {
+		// Leave the entry pending; promote reclaims it after Timeout.
+		log.Printf("[TASKS] %s %s: record failure: %v", t.Name, task.ID, err)
+		return
+	}
+	q.settle(sctx, t.Name, msg.ID)
+}
+
+// run calls the handler with the per-type timeout, turning a panic into an
+// error so one bad task can't take the worker down.
+func (q *TaskQueue) run(ctx context.Context, t TaskType, task *Task) (err error) {
+	rctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("[TASKS] %s %s panicked: %v\n%s", t.Name, task.ID, p, debug.Stack())
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return t.Handler(rctx, task.Payload)
+}
+
+// fail schedules a retry, or dead-letters the task once it is out of
+// attempts.
+func (q *TaskQueue) fail(ctx context.Context, t TaskType, task *Task, runErr error) error {
+	task.LastError = runErr.Error()
+	if task.Attempt >= t.MaxAttempts {
+		now := q.now().UTC()
+		task.FailedAt = &now
+		body, err := json.Marshal(task)
+		if err != nil {
+			return err
+		}
+		log.Printf("[TASKS] %s %s dead after %d attempts: %v", t.Name, task.ID, task.Attempt, runErr)
+		if err := q.r.XAdd(ctx, &redis.XAddArgs{
+			Stream: deadKey(t.Name),
+			MaxLen: taskDeadMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"task": body},
+		}).Err(); err != nil {
+			return err
+		}
+		q.r.HIncrBy(ctx, statsKey(t.Name), "dead", 1)
+		return nil
+	}
+	body, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	due := q.now().Add(taskBackoff(task.Attempt))
+	if err := q.r.ZAdd(ctx, delayedKey(t.Name), redis.Z{Score: float64(due.UnixMilli()), Member: body}).Err(); err != nil {
+		return err
+	}
+	q.r.HIncrBy(ctx, statsKey(t.Name), "retried", 1)
+	return nil
+}
+
+func (q *TaskQueue) settle(ctx context.Context, taskType, entryID string) {
+	pipe := q.r.TxPipeline()
+	pipe.XAck(ctx, streamKey(taskType), taskGroup, entryID)
+	pipe.XDel(ctx, streamKey(taskType), entryID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[TASKS] %s: ack %s: %v", taskType, entryID, err)
+	}
+}
+
+// promote moves due retries back onto their streams and reclaims tasks
+// whose worker died, once a tick.
+func (q *TaskQueue) promote(ctx context.Context) {
+	ticker := time.NewTicker(taskPromoteTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, name := range q.typeNames() {
+				q.promoteDue(ctx, name)
+				q.reclaim(ctx, name)
+			}
+		}
+	}
+}
+
+// promoteDue re-adds retries whose backoff has passed. ZREM decides which
+// instance wins each one, so a retry is promoted once however many
+// instances are running.
+func (q *TaskQueue) promoteDue(ctx context.Context, taskType string) {
+	due, err := q.r.ZRangeByScore(ctx, delayedKey(taskType), &redis.ZRangeBy{
+		Min: "-inf", Max: strconv.FormatInt(q.now().UnixMilli(), 10), Count: 100,
+	}).Result()
+	if err != nil {
+		return
+	}
+	for _, body := range due {
+		if n, err := q.r.ZRem(ctx, delayedKey(taskType), body).Result(); err != nil || n == 0 {
+			continue
+		}
+		if err := q.r.XAdd(ctx, &redis.XAddArgs{
+			Stream: streamKey(taskType),
+			Values: map[string]interface{}{"task": body},
+		}).Err(); err != nil {
+			log.Printf("[TASKS] %s: promote retry: %v", taskType, err)
+		}
+	}
+}
+
+// reclaim takes over entries delivered to a consumer that has sat on them
+// longer than the type's timeout (its instance died or was killed
+// mid-task) and runs them here, counting the lost run as an attempt.
+func (q *TaskQueue) reclaim(ctx context.Context, taskType string) {
+	t, ok := q.taskType(taskType)
+	if !ok {
+		return
+	}
+	msgs, _, err := q.r.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   streamKey(taskType),
+		Group:    taskGroup,
+		Consumer: q.consumer + "-reclaim",
+		MinIdle:  t.Timeout + time.Minute,
+		Start:    "0",
+		Count:    10,
+	}).Result()
+	if err != nil {
+		return
+	}
+	for _, msg := range msgs {
+		task, err := decodeTaskMessage(msg)
+		if err != nil {
+			q.settle(ctx, taskType, msg.ID)
+			continue
+		}
+		task.Attempt++
+		log.Printf("[TASKS] %s %s: reclaimed from a stalled worker (attempt %d)", taskType, task.ID, task.Attempt)
+		if err := q.fail(ctx, t, task, errors.New("worker stopped before finishing")); err != nil {
+			continue
+		}
+		q.settle(ctx, taskType, msg.ID)
+	}
+}
+
+func decodeTaskMessage(msg redis.XMessage) (*Task, error) {
+	raw, ok := msg.Values["task"].(string)
+	if !ok {
+		return nil, errors.New("missing task field")
+	}
+	var t Task
+	if err := json.Unmarshal([]byte(raw), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Stats returns queue depth and counters for every registered type.
+func (q *TaskQueue) Stats(ctx context.Context) ([]TaskQueueStats, error) {
+	if q == nil || q.r == nil {
+		return nil, ErrTaskQueueUnavailable
+	}
+	out := []TaskQueueStats{}
+	for _, name := range q.typeNames() {
+		t, _ := q.taskType(name)
+		s := TaskQueueStats{Type: name, Concurrency: t.Concurrency, MaxAttempts: t.MaxAttempts}
+		pipe := q.r.Pipeline()
+		streamLen := pipe.XLen(ctx, streamKey(name))
+		delayed := pipe.ZCard(ctx, delayedKey(name))
+		dead := pipe.XLen(ctx, deadKey(name))
+		counters := pipe.HGetAll(ctx, statsKey(name))
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, err
+		}
+		// Settled entries are deleted, so the stream holds exactly the
+		// ready and in-flight tasks.
+		if p, err := q.r.XPending(ctx, streamKey(name), taskGroup).Result(); err == nil {
+			s.InFlight = p.Count
+		}
+		s.Ready = streamLen.Val() - s.InFlight
+		if s.Ready < 0 {
+			s.Ready = 0
+		}
+		s.Delayed = delayed.Val()
+		s.Dead = dead.Val()
+		c := counters.Val()
+		s.Succeeded, _ = strconv.ParseInt(c["succeeded"], 10, 64)
+		s.Retried, _ = strconv.ParseInt(c["retried"], 10, 64)
+		s.DeadTotal, _ = strconv.ParseInt(c["dead"], 10, 64)
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// DeadTasks lists the newest dead-lettered tasks of a type.
+func (q *TaskQueue) DeadTasks(ctx context.Context, taskType string, limit int) ([]DeadTask, error) {
+	if q == nil || q.r == nil {
+		return nil, ErrTaskQueueUnavailable
+	}
+	if _, ok := q.taskType(taskType); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTaskType, taskType)
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	msgs, err := q.r.XRevRangeN(ctx, deadKey(taskType), "+", "-", int64(limit)).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DeadTask, 0, len(msgs))
+	for _, m := range msgs {
+		t, err := decodeTaskMessage(m)
+		if err != nil {
+			continue
+		}
+		out = append(out, DeadTask{EntryID: m.ID, Task: *t})
+	}
+	return out, nil
+}
+
+// RetryDead puts a dead task back on its queue with a fresh set of
+// attempts.
+func (q *TaskQueue) RetryDead(ctx context.Context, taskType, entryID string) (*Task, error) {
+	t, err := q.takeDead(ctx, taskType, entryID)
+	if err != nil {
+		return nil, err
+	}
+	t.Attempt = 0
+	t.FailedAt = nil
+	if err := q.add(ctx, *t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DiscardDead deletes a dead task.
+func (q *TaskQueue) DiscardDead(ctx context.Context, taskType, entryID string) (*Task, error) {
+	return q.takeDead(ctx, taskType, entryID)
+}
+
+// takeDead removes one entry from the dead letter stream and returns it.
+// The XDEL count decides between two admins clicking at once.
+func (q *TaskQueue) takeDead(ctx context.Context, taskType, entryID string) (*Task, error) {
+	if q == nil || q.r == nil {
+		return nil, ErrTaskQueueUnavailable
+	}
+	if _, ok := q.taskType(taskType); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTaskType, taskType)
+	}
+	msgs, err := q.r.XRange(ctx, deadKey(taskType), entryID, entryID).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, ErrDeadTaskNotFound
+	}
+	t, err := decodeTaskMessage(msgs[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := q.r.XDel(ctx, deadKey(taskType), entryID).Result()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrDeadTaskNotFound
+	}
+	return t, nil
+}
+
+// sleepCtx waits for d or until ctx is cancelled.
+func sleepCtx(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"carecompanion/internal/config"
+	"carecompanion/internal/database"
+	"carecompanion/internal/models"
+)
+
+func newTestTaskQueue(t *testing.T) *TaskQueue {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return NewTaskQueue(&database.Redis{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}, nil)
+}
+
+// deliverOne reads the next ready task of type name and runs it, as one
+// worker iteration would. Reports whether there was a task.
+func deliverOne(t *testing.T, q *TaskQueue, name string) bool {
+	t.Helper()
+	ctx := context.Background()
+	typ, _ := q.taskType(name)
+	res, err := q.r.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group: taskGroup, Consumer: "test", Streams: []string{streamKey(name), ">"}, Count: 1, Block: -1,
+	}).Result()
+	if err == redis.Nil || (err == nil && len(res[0].Messages) == 0) {
+		return false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.handle(ctx, typ, res[0].Messages[0])
+	return true
+}
+
+func queueStats(t *testing.T, q *TaskQueue) TaskQueueStats {
+	t.Helper()
+	stats, err := q.Stats(context.Background())
+	if err != nil || len(stats) != 1 {
+		t.Fatalf("stats = %+v, %v", stats, err)
+	}
+	return stats[0]
+}
+
+func TestTaskQueueRetryThenDeadLetter(t *testing.T) {
+	q := newTestTaskQueue(t)
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	fail := true
+	var got []string
+	q.Register(TaskType{Name: "export", MaxAttempts: 2, Handler: func(ctx context.Context, payload json.RawMessage) error {
+		var p struct{ Family string }
+		_ = json.Unmarshal(payload, &p)
+		got = append(got, p.Family)
+		if fail {
+			return errors.New("s3 unavailable")
+		}
+		return nil
+	}})
+	if err := q.ensureGroup(ctx, "export"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(ctx, "export", map[string]string{"Family": "f1"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := queueStats(t, q); s.Ready != 1 {
+		t.Fatalf("ready = %d, want 1", s.Ready)
+	}
+
+	// First attempt fails: the task waits out its backoff.
+	deliverOne(t, q, "export")
+	if s := queueStats(t, q); s.Ready != 0 || s.Delayed != 1 || s.Retried != 1 {
+		t.Fatalf("after first failure: %+v", s)
+	}
+	q.promoteDue(ctx, "export")
+	if deliverOne(t, q, "export") {
+		t.Fatal("retry ran before its backoff")
+	}
+
+	// Second attempt fails: out of attempts, dead-lettered.
+	now = now.Add(taskBackoff(1))
+	q.promoteDue(ctx, "export")
+	deliverOne(t, q, "export")
+	if s := queueStats(t, q); s.Delayed != 0 || s.Dead != 1 || s.DeadTotal != 1 {
+		t.Fatalf("after second failure: %+v", s)
+	}
+	dead, err := q.DeadTasks(ctx, "export", 10)
+	if err != nil || len(dead) != 1 || dead[0].Attempt != 2 || dead[0].LastError != "s3 unavailable" {
+		t.Fatalf("dead = %+v, %v", dead, err)
+	}
+
+	// Admin retry puts it back with fresh attempts.
+	fail = false
+	if _, err := q.RetryDead(ctx, "export", dead[0].EntryID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.RetryDead(ctx, "export", dead[0].EntryID); !errors.Is(err, ErrDeadTaskNotFound) {
+		t.Errorf("second retry err = %v, want ErrDeadTaskNotFound", err)
+	}
+	deliverOne(t, q, "export")
+	if s := queueStats(t, q); s.Ready != 0 || s.InFlight != 0 || s.Dead != 0 || s.Succeeded != 1 {
+		t.Fatalf("after admin retry: %+v", s)
+	}
+	if len(got) != 3 || got[2] != "f1" {
+		t.Errorf("handler saw %v", got)
+	}
+}
+
+func TestTaskQueuePanicIsFailure(t *testing.T) {
+	q := newTestTaskQueue(t)
+	ctx := context.Background()
+	q.Register(TaskType{Name: "notify", MaxAttempts: 1, Handler: func(ctx context.Context, payload json.RawMessage) error {
+		panic("nil map")
+	}})
+	_ = q.ensureGroup(ctx, "notify")
+	if _, err := q.Enqueue(ctx, "notify", nil); err != nil {
+		t.Fatal(err)
+	}
+	deliverOne(t, q, "notify")
+	dead, _ := q.DeadTasks(ctx, "notify", 10)
+	if len(dead) != 1 || dead[0].LastError != "panic: nil map" {
+		t.Errorf("dead = %+v", dead)
+	}
+}
+
+func TestTaskQueueUnknownAndUnavailable(t *testing.T) {
+	q := newTestTaskQueue(t)
+	if _, err := q.Enqueue(context.Background(), "nope", nil); !errors.Is(err, ErrUnknownTaskType) {
+		t.Errorf("err = %v, want ErrUnknownTaskType", err)
+	}
+	if _, err := NewTaskQueue(nil, nil).Enqueue(context.Background(), "nope", nil); err != ErrTaskQueueUnavailable {
+		t.Errorf("err = %v, want ErrTaskQueueUnavailable", err)
+	}
+}
+
+func TestWelcomeEmailGoesThroughQueue(t *testing.T) {
+	q := newTestTaskQueue(t)
+	ctx := context.Background()
+	auth := &AuthService{emailService: NewEmailService(&config.SMTPConfig{}), appURL: "https://app.example"}
+	auth.SetTaskQueue(q)
+	_ = q.ensureGroup(ctx, TaskWelcomeEmail)
+
+	auth.sendWelcomeEmail(ctx, &models.User{Email: "new@example.com", FirstName: "Sam"})
+	if s := queueStats(t, q); s.Ready != 1 {
+		t.Fatalf("queued = %d, want 1", s.Ready)
+	}
+	if !deliverOne(t, q, TaskWelcomeEmail) {
+		t.Fatal("no welcome email task")
+	}
+	if s := queueStats(t, q); s.Ready != 0 || s.Succeeded != 1 || s.Dead != 0 {
+		t.Errorf("after run: %+v", s)
+	}
+}
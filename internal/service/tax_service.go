@@ -0,0 +1,267 @@
+package service
+
+// tax_service.go — sales tax / VAT.
+//
+// Stripe collects the tax (Stripe Tax when STRIPE_AUTOMATIC_TAX is on, or
+// tax rates configured on the account); we record what each paid invoice
+// collected per jurisdiction, stamp the total on the payment and invoice,
+// and report it. Before checkout, prices are quoted through a pluggable
+// TaxProvider — the built-in rate table or Stripe Tax calculations — and
+// shown tax-inclusive in countries that require it for consumers.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrTaxLocation     = errors.New("a two-letter country code is required")
+	ErrTaxReportPeriod = errors.New("period must be month, quarter or year")
+)
+
+// TaxQuoteRequest is a net (tax-exclusive) amount to quote tax on.
+type TaxQuoteRequest struct {
+	AmountCents int64
+	Currency    string
+	Country     string
+	Region      string
+	PostalCode  string
+}
+
+// TaxQuote is the tax a provider would add on top of a net amount.
+type TaxQuote struct {
+	NetCents   int64  `json:"net_cents"`
+	TaxCents   int64  `json:"tax_cents"`
+	TotalCents int64  `json:"total_cents"`
+	RateBps    int    `json:"rate_bps"`
+	TaxName    string `json:"tax_name"`
+	Provider   string `json:"provider"`
+}
+
+// TaxProvider quotes tax for a location. Implementations: the rate table
+// (RateTableTaxProvider) and Stripe Tax (StripeTaxProvider).
+type TaxProvider interface {
+	Name() string
+	Quote(ctx context.Context, req TaxQuoteRequest) (*TaxQuote, error)
+}
+
+// RateTableTaxProvider quotes from the tax_rates table. A location with no
+// row is quoted no tax.
+type RateTableTaxProvider struct {
+	repo repository.TaxRepository
+}
+
+func NewRateTableTaxProvider(repo repository.TaxRepository) *RateTableTaxProvider {
+	return &RateTableTaxProvider{repo: repo}
+}
+
+func (p *RateTableTaxProvider) Name() string { return "table" }
+
+func (p *RateTableTaxProvider) Quote(ctx context.Context, req TaxQuoteRequest) (*TaxQuote, error) {
+	q := &TaxQuote{NetCents: req.AmountCents, TotalCents: req.AmountCents, Provider: p.Name()}
+	rate, err := p.repo.RateFor(ctx, req.Country, req.Region)
+	if err != nil || rate == nil {
+		return q, err
+	}
+	q.RateBps, q.TaxName = rate.RateBps, rate.Name
+	q.TaxCents = taxOnNet(req.AmountCents, rate.RateBps)
+	q.TotalCents += q.TaxCents
+	return q, nil
+}
+
+// taxOnNet is net × rate, rounded half up to the cent.
+func taxOnNet(netCents int64, rateBps int) int64 {
+	return (netCents*int64(rateBps) + 5000) / 10000
+}
+
+// LocalizedPrice is a plan's price as shown in a country: with tax
+// included where consumers must see the full price, otherwise net with
+// tax added at checkout.
+type LocalizedPrice struct {
+	PlanID          uuid.UUID              `json:"plan_id"`
+	Name            string                 `json:"name"`
+	BillingInterval models.BillingInterval `json:"billing_interval"`
+	SeatBased       bool                   `json:"seat_based"`
+	PriceCents      int64                  `json:"price_cents"`
+	TaxCents        int64                  `json:"tax_cents"`
+	DisplayCents    int64                  `json:"display_cents"`
+	TaxInclusive    bool                   `json:"tax_inclusive"`
+	TaxLabel        string                 `json:"tax_label"`
+}
+
+// LocalizedPrices is the plan list for one location.
+type LocalizedPrices struct {
+	Country      string           `json:"country"`
+	Region       string           `json:"region,omitempty"`
+	Currency     string           `json:"currency"`
+	TaxInclusive bool             `json:"tax_inclusive"`
+	Provider     string           `json:"provider"`
+	Plans        []LocalizedPrice `json:"plans"`
+}
+
+// TaxRecord is what a paid Stripe invoice collected.
+type TaxRecord struct {
+	StripeInvoiceID string
+	OrganizationID  *uuid.UUID
+	Currency        string
+	CollectedAt     time.Time
+	Lines           []repository.PaymentTax
+}
+
+// TaxReport is tax collected by jurisdiction over a date range.
+type TaxReport struct {
+	From   time.Time                 `json:"from"`
+	To     time.Time                 `json:"to"`
+	Period string                    `json:"period"`
+	Rows   []repository.TaxReportRow `json:"rows"`
+	Totals []TaxReportTotal          `json:"totals"`
+}
+
+// TaxReportTotal sums a report per currency; amounts in different
+// currencies are never added together.
+type TaxReportTotal struct {
+	Currency     string `json:"currency"`
+	Invoices     int    `json:"invoices"`
+	TaxableCents int64  `json:"taxable_cents"`
+	TaxCents     int64  `json:"tax_cents"`
+}
+
+// TaxService quotes, records and reports tax.
+type TaxService struct {
+	repo     repository.TaxRepository
+	provider TaxProvider
+}
+
+// NewTaxService creates the tax service. provider may be nil (TAX_PROVIDER
+// =none): prices are then quoted without tax, but tax Stripe collects is
+// still recorded.
+func NewTaxService(repo repository.TaxRepository, provider TaxProvider) *TaxService {
+	return &TaxService{repo: repo, provider: provider}
+}
+
+// SetProvider swaps the quoting provider; Stripe Tax is attached once the
+// Stripe service exists.
+func (s *TaxService) SetProvider(p TaxProvider) {
+	s.provider = p
+}
+
+// LocalizePlans prices plans for a country (and optional region). Seat
+// prices are per seat.
+func (s *TaxService) LocalizePlans(ctx context.Context, plans []models.SubscriptionPlan, country, region string) (*LocalizedPrices, error) {
+	country, region = strings.ToUpper(strings.TrimSpace(country)), strings.ToUpper(strings.TrimSpace(region))
+	if len(country) != 2 {
+		return nil, ErrTaxLocation
+	}
+	out := &LocalizedPrices{Country: country, Region: region, Currency: "USD", Provider: "none", Plans: []LocalizedPrice{}}
+	rate, err := s.repo.RateFor(ctx, country, region)
+	if err != nil {
+		return nil, err
+	}
+	out.TaxInclusive = rate != nil && rate.InclusivePricing
+	taxName := "tax"
+	if rate != nil && rate.RateBps > 0 {
+		taxName = rate.Name
+	}
+	if s.provider != nil {
+		out.Provider = s.provider.Name()
+	}
+	for _, p := range plans {
+		lp := LocalizedPrice{
+			PlanID: p.ID, Name: p.Name, BillingInterval: p.BillingInterval, SeatBased: p.SeatBased,
+			PriceCents: int64(p.PriceCents), DisplayCents: int64(p.PriceCents),
+		}
+		if s.provider != nil && p.PriceCents > 0 {
+			q, err := s.provider.Quote(ctx, TaxQuoteRequest{
+				AmountCents: int64(p.PriceCents), Currency: out.Currency, Country: country, Region: region,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("quote tax for %s: %w", p.Name, err)
+			}
+			lp.TaxCents = q.TaxCents
+			if q.TaxName != "" {
+				taxName = q.TaxName
+			}
+		}
+		switch {
+		case out.TaxInclusive:
+			lp.DisplayCents += lp.TaxCents
+			lp.TaxInclusive = true
+			lp.TaxLabel = "incl. " + taxName
+		case lp.TaxCents > 0 || rate != nil:
+			lp.TaxLabel = "+ " + taxName
+		}
+		out.Plans = append(out.Plans, lp)
+	}
+	return out, nil
+}
+
+// RecordInvoice stores the tax a paid Stripe invoice collected, replacing
+// anything recorded for it before.
+func (s *TaxService) RecordInvoice(ctx context.Context, rec TaxRecord) error {
+	lines := make([]repository.PaymentTax, 0, len(rec.Lines))
+	for _, l := range rec.Lines {
+		if l.TaxCents == 0 && l.TaxableCents == 0 {
+			continue
+		}
+		l.OrganizationID = rec.OrganizationID
+		l.Country = strings.ToUpper(l.Country)
+		l.Region = strings.ToUpper(l.Region)
+		l.Currency = strings.ToUpper(rec.Currency)
+		if l.Currency == "" {
+			l.Currency = "USD"
+		}
+		if l.Provider == "" {
+			l.Provider = "stripe"
+		}
+		l.CollectedAt = rec.CollectedAt
+		lines = append(lines, l)
+	}
+	return s.repo.RecordInvoiceTaxes(ctx, rec.StripeInvoiceID, lines)
+}
+
+// Report returns tax collected from `from` up to and including `to`,
+// grouped by period and jurisdiction.
+func (s *TaxService) Report(ctx context.Context, from, to time.Time, period string) (*TaxReport, error) {
+	switch period {
+	case "":
+		period = "month"
+	case "month", "quarter", "year":
+	default:
+		return nil, ErrTaxReportPeriod
+	}
+	rows, err := s.repo.Report(ctx, from, to.AddDate(0, 0, 1), period)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = []repository.TaxReportRow{}
+	}
+	report := &TaxReport{From: from, To: to, Period: period, Rows: rows, Totals: []TaxReportTotal{}}
+	idx := map[string]int{}
+	for _, r := range rows {
+		i, ok := idx[r.Currency]
+		if !ok {
+			i = len(report.Totals)
+			idx[r.Currency] = i
+			report.Totals = append(report.Totals, TaxReportTotal{Currency: r.Currency})
+		}
+		// An invoice taxed in several jurisdictions counts once in each.
+		report.Totals[i].Invoices += r.Invoices
+		report.Totals[i].TaxableCents += r.TaxableCents
+		report.Totals[i].TaxCents += r.TaxCents
+	}
+	return report, nil
+}
+
+// Rates returns the built-in rate table.
+func (s *TaxService) Rates(ctx context.Context) ([]repository.TaxRate, error) {
+	return s.repo.ListRates(ctx)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v76"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+type fakeTaxRepo struct {
+	repository.TaxRepository
+	rates    []repository.TaxRate
+	recorded map[string][]repository.PaymentTax
+	report   []repository.TaxReportRow
+	to       time.Time
+}
+
+func (f *fakeTaxRepo) RateFor(ctx context.Context, country, region string) (*repository.TaxRate, error) {
+	var fallback *repository.TaxRate
+	for i, r := range f.rates {
+		if r.Country != country {
+			continue
+		}
+		if r.Region == region && region != "" {
+			return &f.rates[i], nil
+		}
+		if r.Region == "" {
+			fallback = &f.rates[i]
+		}
+	}
+	return fallback, nil
+}
+
+func (f *fakeTaxRepo) RecordInvoiceTaxes(ctx context.Context, id string, taxes []repository.PaymentTax) error {
+	f.recorded[id] = taxes
+	return nil
+}
+
+func (f *fakeTaxRepo) Report(ctx context.Context, from, to time.Time, period string) ([]repository.TaxReportRow, error) {
+	f.to = to
+	return f.report, nil
+}
+
+func newTaxTest() (*TaxService, *fakeTaxRepo) {
+	repo := &fakeTaxRepo{
+		rates: []repository.TaxRate{
+			{Country: "GB", Name: "VAT", TaxType: "vat", RateBps: 2000, InclusivePricing: true},
+			{Country: "CA", Name: "GST", TaxType: "gst", RateBps: 500},
+			{Country: "CA", Region: "ON", Name: "HST", TaxType: "hst", RateBps: 1300},
+			{Country: "US", Name: "Sales tax", TaxType: "sales_tax"},
+		},
+		recorded: map[string][]repository.PaymentTax{},
+	}
+	return NewTaxService(repo, NewRateTableTaxProvider(repo)), repo
+}
+
+func TestTaxOnNet(t *testing.T) {
+	cases := []struct {
+		net  int64
+		bps  int
+		want int64
+	}{
+		{999, 2000, 200}, // 199.8
+		{799, 500, 40},   // 39.95
+		{1000, 1300, 130},
+		{999, 0, 0},
+	}
+	for _, c := range cases {
+		if got := taxOnNet(c.net, c.bps); got != c.want {
+			t.Errorf("taxOnNet(%d, %d) = %d, want %d", c.net, c.bps, got, c.want)
+		}
+	}
+}
+
+func TestLocalizePlans(t *testing.T) {
+	svc, _ := newTaxTest()
+	ctx := context.Background()
+	plans := []models.SubscriptionPlan{
+		{ID: uuid.New(), Name: "Free"},
+		{ID: uuid.New(), Name: "Family", PriceCents: 999, BillingInterval: models.BillingIntervalMonthly},
+	}
+
+	gb, err := svc.LocalizePlans(ctx, plans, "gb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gb.TaxInclusive || gb.Provider != "table" {
+		t.Errorf("GB: inclusive %v provider %q", gb.TaxInclusive, gb.Provider)
+	}
+	if p := gb.Plans[1]; p.PriceCents != 999 || p.TaxCents != 200 || p.DisplayCents != 1199 || p.TaxLabel != "incl. VAT" {
+		t.Errorf("GB Family = %+v", p)
+	}
+
+	on, err := svc.LocalizePlans(ctx, plans, "CA", "on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := on.Plans[1]; on.TaxInclusive || p.TaxCents != 130 || p.DisplayCents != 999 || p.TaxLabel != "+ HST" {
+		t.Errorf("CA-ON Family = %+v", p)
+	}
+
+	us, err := svc.LocalizePlans(ctx, plans, "US", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := us.Plans[1]; p.TaxCents != 0 || p.DisplayCents != 999 || p.TaxLabel != "+ Sales tax" {
+		t.Errorf("US Family = %+v", p)
+	}
+
+	if _, err := svc.LocalizePlans(ctx, plans, "USA", ""); !errors.Is(err, ErrTaxLocation) {
+		t.Errorf("three-letter country: err = %v", err)
+	}
+}
+
+func TestRecordInvoiceTax(t *testing.T) {
+	svc, repo := newTaxTest()
+	orgID := uuid.New()
+	paid := time.Date(2026, 10, 3, 9, 0, 0, 0, time.UTC)
+	err := svc.RecordInvoice(context.Background(), TaxRecord{
+		StripeInvoiceID: "in_1", OrganizationID: &orgID, Currency: "gbp", CollectedAt: paid,
+		Lines: []repository.PaymentTax{
+			{Country: "gb", Jurisdiction: "United Kingdom", TaxType: "vat", RateBps: 2000, TaxableCents: 8000, TaxCents: 1600},
+			{Country: "gb"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := repo.recorded["in_1"]
+	if len(got) != 1 {
+		t.Fatalf("recorded %d lines, want the empty one dropped", len(got))
+	}
+	l := got[0]
+	if l.Country != "GB" || l.Currency != "GBP" || l.Provider != "stripe" || !l.CollectedAt.Equal(paid) || l.OrganizationID == nil || *l.OrganizationID != orgID {
+		t.Errorf("recorded %+v", l)
+	}
+}
+
+func TestTaxReport(t *testing.T) {
+	svc, repo := newTaxTest()
+	ctx := context.Background()
+	repo.report = []repository.TaxReportRow{
+		{Country: "GB", TaxType: "vat", Currency: "GBP", Invoices: 3, TaxableCents: 3000, TaxCents: 600},
+		{Country: "CA", Region: "ON", TaxType: "hst", Currency: "USD", Invoices: 1, TaxableCents: 1000, TaxCents: 130},
+		{Country: "CA", TaxType: "gst", Currency: "USD", Invoices: 2, TaxableCents: 2000, TaxCents: 100},
+	}
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	report, err := svc.Report(ctx, from, to, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Period != "month" {
+		t.Errorf("default period = %q", report.Period)
+	}
+	if !repo.to.Equal(to.AddDate(0, 0, 1)) {
+		t.Errorf("repo queried up to %v, want the end date included", repo.to)
+	}
+	if len(report.Totals) != 2 || report.Totals[0].Currency != "GBP" || report.Totals[1].TaxCents != 230 || report.Totals[1].Invoices != 3 {
+		t.Errorf("totals = %+v", report.Totals)
+	}
+
+	if _, err := svc.Report(ctx, from, to, "week"); !errors.Is(err, ErrTaxReportPeriod) {
+		t.Errorf("week period: err = %v", err)
+	}
+}
+
+func TestStripeInvoiceTaxes(t *testing.T) {
+	inv := &stripe.Invoice{
+		TotalTaxAmounts: []*stripe.InvoiceTotalTaxAmount{
+			{Amount: 130, TaxableAmount: 1000, TaxRate: &stripe.TaxRate{ID: "txr_on"}},
+			{Amount: 200, TaxableAmount: 1000, Inclusive: true, TaxRate: &stripe.TaxRate{
+				ID: "txr_gb", Country: "GB", DisplayName: "VAT", TaxType: stripe.TaxRateTaxTypeVAT, Percentage: 20,
+			}},
+		},
+	}
+	lookups := 0
+	lines, err := stripeInvoiceTaxes(inv, func(id string) (*stripe.TaxRate, error) {
+		lookups++
+		return &stripe.TaxRate{ID: id, Country: "CA", State: "ON", Jurisdiction: "Ontario", TaxType: stripe.TaxRateTaxTypeHST, Percentage: 13}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookups != 1 || len(lines) != 2 {
+		t.Fatalf("%d lookups, %d lines", lookups, len(lines))
+	}
+	if l := lines[0]; l.Country != "CA" || l.Region != "ON" || l.Jurisdiction != "Ontario" || l.RateBps != 1300 || l.TaxCents != 130 {
+		t.Errorf("expanded rate line = %+v", l)
+	}
+	if l := lines[1]; l.Jurisdiction != "VAT" || l.TaxType != "vat" || l.RateBps != 2000 || !l.Inclusive {
+		t.Errorf("inline rate line = %+v", l)
+	}
+}
+
+func TestInvoiceForTaxedPayment(t *testing.T) {
+	svc, repo, _, _ := newInvoiceTest()
+	paymentID := uuid.New()
+	repo.payments[paymentID] = &repository.InvoicePaymentSource{
+		PaymentID: paymentID, Status: "succeeded", AmountCents: 1129, TaxCents: 130,
+		Currency: "usd", PaidAt: time.Now(), PlanName: "Family", BillingInterval: "monthly",
+	}
+	inv, err := svc.IssueForPayment(context.Background(), paymentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.SubtotalCents != 999 || inv.TaxCents != 130 || inv.TotalCents != 1129 || inv.Lines[0].AmountCents != 999 {
+		t.Errorf("amounts: subtotal %d tax %d total %d line %d", inv.SubtotalCents, inv.TaxCents, inv.TotalCents, inv.Lines[0].AmountCents)
+	}
+}
@@ -0,0 +1,540 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrTestimonialInvalid        = apperr.Validation("invalid testimonial")
+	ErrTestimonialNotFound       = apperr.NotFound("testimonial not found")
+	ErrTestimonialTransition     = errors.New("testimonial status change not allowed")
+	ErrTestimonialNoConsent      = errors.New("testimonial lacks consent for this use")
+	ErrTestimonialNotPublishable = errors.New("testimonial is not approved for this channel")
+)
+
+// Testimonial kinds.
+const (
+	TestimonialKindQuote     = "testimonial"
+	TestimonialKindCaseStudy = "case_study"
+)
+
+// Review states. Only approved entries are used anywhere outside the admin.
+const (
+	TestimonialDraft         = "draft"
+	TestimonialPendingReview = "pending_review"
+	TestimonialApproved      = "approved"
+	TestimonialRejected      = "rejected"
+	TestimonialArchived      = "archived"
+)
+
+// Consent states.
+const (
+	ConsentPending  = "pending"
+	ConsentGranted  = "granted"
+	ConsentDeclined = "declined"
+	ConsentRevoked  = "revoked"
+)
+
+// Channels a consent grant can cover. Each consumer checks its own.
+const (
+	TestimonialChannelWeb    = "web"
+	TestimonialChannelPrint  = "print"
+	TestimonialChannelSocial = "social"
+)
+
+const (
+	maxTestimonialQuoteLen = 280
+	maxTestimonialBodyLen  = 20000
+	maxTestimonialTags     = 10
+	maxPublicTestimonials  = 50
+)
+
+var validConsentMethods = map[string]bool{
+	"email": true, "web_form": true, "signed_release": true, "in_app": true, "verbal": true,
+}
+
+// testimonialTransitions lists the allowed review moves. Approval has an
+// extra consent check in SetStatus.
+var testimonialTransitions = map[string][]string{
+	TestimonialDraft:         {TestimonialPendingReview, TestimonialArchived},
+	TestimonialPendingReview: {TestimonialApproved, TestimonialRejected, TestimonialDraft},
+	TestimonialApproved:      {TestimonialPendingReview, TestimonialArchived},
+	TestimonialRejected:      {TestimonialDraft, TestimonialArchived},
+	TestimonialArchived:      {TestimonialDraft},
+}
+
+// TestimonialService manages testimonials and case studies, their consent
+// records and review state, and serves approved quotes to the public API
+// and the marketing generators.
+type TestimonialService struct {
+	repo   repository.TestimonialRepository
+	images *ImageService
+	now    func() time.Time
+}
+
+// NewTestimonialService creates a new testimonial service
+func NewTestimonialService(repo repository.TestimonialRepository) *TestimonialService {
+	return &TestimonialService{repo: repo, now: time.Now}
+}
+
+// SetImageService enables author headshots. Without it uploads are
+// refused and public entries carry no photo.
+func (s *TestimonialService) SetImageService(images *ImageService) {
+	s.images = images
+}
+
+// TestimonialInput is the admin create/update payload for content.
+type TestimonialInput struct {
+	Kind             string     `json:"kind"`
+	Quote            string     `json:"quote"`
+	Title            string     `json:"title"`
+	Body             string     `json:"body"`
+	AuthorName       string     `json:"author_name"`
+	AuthorDescriptor string     `json:"author_descriptor"`
+	Anonymous        bool       `json:"anonymous"`
+	AppUserID        *uuid.UUID `json:"app_user_id"`
+	Tags             []string   `json:"tags"`
+}
+
+// normalizeTags lower-cases, trims and de-duplicates tags.
+func normalizeTags(in []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, t := range in {
+		t = truncateRunes(strings.ToLower(strings.TrimSpace(t)), 40)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// apply validates content and copies it onto t. It reports whether any
+// public-facing text changed, which sends approved entries back to review.
+func (in TestimonialInput) apply(t *repository.Testimonial) (changed bool, err error) {
+	if in.Kind == "" {
+		in.Kind = TestimonialKindQuote
+	}
+	if in.Kind != TestimonialKindQuote && in.Kind != TestimonialKindCaseStudy {
+		return false, fmt.Errorf("%w: kind must be testimonial or case_study", ErrTestimonialInvalid)
+	}
+	quote := strings.TrimSpace(in.Quote)
+	if quote == "" || len([]rune(quote)) > maxTestimonialQuoteLen {
+		return false, fmt.Errorf("%w: quote is required (max %d characters)", ErrTestimonialInvalid, maxTestimonialQuoteLen)
+	}
+	author := strings.TrimSpace(in.AuthorName)
+	if author == "" || len(author) > 100 {
+		return false, fmt.Errorf("%w: author_name is required (max 100 characters)", ErrTestimonialInvalid)
+	}
+	title, body := strings.TrimSpace(in.Title), strings.TrimSpace(in.Body)
+	if in.Kind == TestimonialKindCaseStudy && (title == "" || body == "") {
+		return false, fmt.Errorf("%w: case studies need a title and body", ErrTestimonialInvalid)
+	}
+	if len(title) > 200 || len(body) > maxTestimonialBodyLen {
+		return false, fmt.Errorf("%w: title or body is too long", ErrTestimonialInvalid)
+	}
+	tags := normalizeTags(in.Tags)
+	if len(tags) > maxTestimonialTags {
+		return false, fmt.Errorf("%w: at most %d tags", ErrTestimonialInvalid, maxTestimonialTags)
+	}
+	descriptor := truncateRunes(strings.TrimSpace(in.AuthorDescriptor), 150)
+
+	changed = t.Kind != in.Kind || t.Quote != quote || t.Title != title || t.Body != body ||
+		t.AuthorName != author || t.AuthorDescriptor != descriptor || t.Anonymous != in.Anonymous
+	t.Kind, t.Quote, t.Title, t.Body = in.Kind, quote, title, body
+	t.AuthorName, t.AuthorDescriptor, t.Anonymous = author, descriptor, in.Anonymous
+	t.Tags = tags
+	t.AppUserID = models.NullUUID{}
+	if in.AppUserID != nil && *in.AppUserID != uuid.Nil {
+		t.AppUserID = models.NullUUID{UUID: *in.AppUserID, Valid: true}
+	}
+	return changed, nil
+}
+
+// unpublish drops an approved entry back to review and out of the
+// featured set.
+func unpublish(t *repository.Testimonial) {
+	if t.Status == TestimonialApproved {
+		t.Status = TestimonialPendingReview
+	}
+	t.IsFeatured = false
+	t.FeaturedRank = 0
+}
+
+// CreateTestimonial stores a new draft with consent pending.
+func (s *TestimonialService) CreateTestimonial(ctx context.Context, in TestimonialInput, createdBy uuid.UUID) (*repository.Testimonial, error) {
+	t := &repository.Testimonial{
+		Status:          TestimonialDraft,
+		ConsentStatus:   ConsentPending,
+		ConsentChannels: []string{},
+		CreatedBy:       models.NullUUID{UUID: createdBy, Valid: createdBy != uuid.Nil},
+	}
+	if _, err := in.apply(t); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// UpdateTestimonial edits content. Approval covers the exact wording, so
+// changing the text of an approved entry sends it back to review.
+func (s *TestimonialService) UpdateTestimonial(ctx context.Context, id uuid.UUID, in TestimonialInput) (*repository.Testimonial, error) {
+	t, err := s.GetTestimonial(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := in.apply(t)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		unpublish(t)
+	}
+	if err := s.repo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTestimonial returns one entry or ErrTestimonialNotFound.
+func (s *TestimonialService) GetTestimonial(ctx context.Context, id uuid.UUID) (*repository.Testimonial, error) {
+	t, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrTestimonialNotFound
+	}
+	return t, nil
+}
+
+// ListTestimonials is the admin list.
+func (s *TestimonialService) ListTestimonials(ctx context.Context, f repository.TestimonialFilter, page, limit int) ([]repository.Testimonial, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 25
+	}
+	f.Tag = strings.ToLower(strings.TrimSpace(f.Tag))
+	items, total, err := s.repo.List(ctx, f, limit, (page-1)*limit)
+	if items == nil {
+		items = []repository.Testimonial{}
+	}
+	return items, total, err
+}
+
+// DeleteTestimonial removes an entry outright, e.g. on an author's
+// erasure request.
+func (s *TestimonialService) DeleteTestimonial(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetTestimonial(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if s.images != nil {
+		if err := s.images.DeleteOwner(ctx, ImageOwnerTestimonialHeadshot, id); err != nil {
+			log.Printf("[TESTIMONIAL] delete headshot for %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// UploadHeadshot replaces the author's headshot with responsive variants
+// of the uploaded photo.
+func (s *TestimonialService) UploadHeadshot(ctx context.Context, id, adminID uuid.UUID, filename string, body io.Reader) (*models.ImageSet, error) {
+	if s.images == nil {
+		return nil, fmt.Errorf("%w: image processing is not configured", ErrTestimonialInvalid)
+	}
+	if _, err := s.GetTestimonial(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.images.Process(ctx, ImageUpload{
+		OwnerType:  ImageOwnerTestimonialHeadshot,
+		OwnerID:    id,
+		UploadedBy: adminID,
+		Filename:   filename,
+		Body:       body,
+	})
+}
+
+// Headshot returns the author's current headshot variants.
+func (s *TestimonialService) Headshot(ctx context.Context, id uuid.UUID) (*models.ImageSet, error) {
+	if s.images == nil {
+		return nil, ErrImageNotFound
+	}
+	if _, err := s.GetTestimonial(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.images.Latest(ctx, ImageOwnerTestimonialHeadshot, id)
+}
+
+// ConsentInput records the author's consent decision.
+type ConsentInput struct {
+	Status    string   `json:"status"`
+	Method    string   `json:"method"`
+	Channels  []string `json:"channels"`
+	ExpiresOn string   `json:"expires_on"`
+	Note      string   `json:"note"`
+}
+
+// RecordConsent updates the consent record. Anything other than a grant
+// unpublishes the entry; a narrower grant unfeatures it so the featured
+// set is re-checked by a reviewer.
+func (s *TestimonialService) RecordConsent(ctx context.Context, id uuid.UUID, in ConsentInput) (*repository.Testimonial, error) {
+	t, err := s.GetTestimonial(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch in.Status {
+	case ConsentPending, ConsentGranted, ConsentDeclined, ConsentRevoked:
+	default:
+		return nil, fmt.Errorf("%w: consent status must be pending, granted, declined, or revoked", ErrTestimonialInvalid)
+	}
+	var channels []string
+	for _, c := range normalizeTags(in.Channels) {
+		if c != TestimonialChannelWeb && c != TestimonialChannelPrint && c != TestimonialChannelSocial {
+			return nil, fmt.Errorf("%w: unknown consent channel %q", ErrTestimonialInvalid, c)
+		}
+		channels = append(channels, c)
+	}
+	if in.Status == ConsentGranted {
+		if len(channels) == 0 {
+			return nil, fmt.Errorf("%w: a consent grant must name at least one channel", ErrTestimonialInvalid)
+		}
+		if !validConsentMethods[in.Method] {
+			return nil, fmt.Errorf("%w: method must be email, web_form, signed_release, in_app, or verbal", ErrTestimonialInvalid)
+		}
+	}
+	var expires *time.Time
+	if in.ExpiresOn != "" {
+		d, err := time.Parse("2006-01-02", in.ExpiresOn)
+		if err != nil {
+			return nil, fmt.Errorf("%w: expires_on must be YYYY-MM-DD", ErrTestimonialInvalid)
+		}
+		expires = &d
+	}
+
+	if in.Status != ConsentGranted {
+		unpublish(t)
+		channels = []string{}
+	} else if !containsAll(channels, t.ConsentChannels) {
+		t.IsFeatured, t.FeaturedRank = false, 0
+	}
+	now := s.now()
+	t.ConsentStatus = in.Status
+	t.ConsentMethod = in.Method
+	t.ConsentChannels = channels
+	t.ConsentExpiresOn = expires
+	t.ConsentNote = strings.TrimSpace(in.Note)
+	t.ConsentRecordedAt = &now
+	if err := s.repo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		if !containsString(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// consentCurrent reports whether consent is granted and unexpired on day
+// now. Expiry is inclusive of the expiry date.
+func consentCurrent(t *repository.Testimonial, now time.Time) bool {
+	if t.ConsentStatus != ConsentGranted {
+		return false
+	}
+	if t.ConsentExpiresOn != nil && now.Truncate(24*time.Hour).After(*t.ConsentExpiresOn) {
+		return false
+	}
+	return true
+}
+
+// publishableOn reports whether t may appear on channel right now.
+func publishableOn(t *repository.Testimonial, channel string, now time.Time) bool {
+	return t.Status == TestimonialApproved && consentCurrent(t, now) && containsString(t.ConsentChannels, channel)
+}
+
+// ReviewInput moves an entry through review.
+type ReviewInput struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// SetStatus applies a review transition. Approval requires current consent.
+func (s *TestimonialService) SetStatus(ctx context.Context, id uuid.UUID, in ReviewInput, reviewer uuid.UUID) (*repository.Testimonial, error) {
+	t, err := s.GetTestimonial(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(testimonialTransitions[t.Status], in.Status) {
+		return nil, fmt.Errorf("%w: %s → %s", ErrTestimonialTransition, t.Status, in.Status)
+	}
+	now := s.now()
+	if in.Status == TestimonialApproved && !consentCurrent(t, now) {
+		return nil, ErrTestimonialNoConsent
+	}
+	if in.Status != TestimonialApproved {
+		t.IsFeatured, t.FeaturedRank = false, 0
+	}
+	t.Status = in.Status
+	t.ReviewNote = strings.TrimSpace(in.Note)
+	t.ReviewedBy = models.NullUUID{UUID: reviewer, Valid: reviewer != uuid.Nil}
+	t.ReviewedAt = &now
+	if err := s.repo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FeatureInput sets whether an approved entry is featured. Lower ranks
+// come first.
+type FeatureInput struct {
+	Featured bool `json:"featured"`
+	Rank     int  `json:"rank"`
+}
+
+// SetFeatured features or unfeatures an approved entry.
+func (s *TestimonialService) SetFeatured(ctx context.Context, id uuid.UUID, in FeatureInput) (*repository.Testimonial, error) {
+	t, err := s.GetTestimonial(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if in.Featured && t.Status != TestimonialApproved {
+		return nil, fmt.Errorf("%w: only approved testimonials can be featured", ErrTestimonialTransition)
+	}
+	t.IsFeatured = in.Featured
+	t.FeaturedRank = 0
+	if in.Featured {
+		t.FeaturedRank = in.Rank
+	}
+	if err := s.repo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// testimonialAttribution is the byline shown with a quote. Anonymous
+// entries never expose the author's name.
+func testimonialAttribution(t *repository.Testimonial) string {
+	if t.Anonymous {
+		if t.AuthorDescriptor != "" {
+			return t.AuthorDescriptor
+		}
+		return "A parent"
+	}
+	if t.AuthorDescriptor != "" {
+		return t.AuthorName + ", " + t.AuthorDescriptor
+	}
+	return t.AuthorName
+}
+
+// PublicTestimonial is what the website sees: no consent, review or
+// internal user fields.
+type PublicTestimonial struct {
+	ID          uuid.UUID `json:"id"`
+	Kind        string    `json:"kind"`
+	Quote       string    `json:"quote"`
+	Title       string    `json:"title,omitempty"`
+	Body        string    `json:"body,omitempty"`
+	Attribution string    `json:"attribution"`
+	Tags        []string  `json:"tags"`
+	Featured    bool      `json:"featured"`
+	// Headshot lists the author photo variants; never set for anonymous
+	// entries.
+	Headshot []models.ImageVariant `json:"headshot,omitempty"`
+}
+
+// ListPublic returns approved, web-consented entries for the website.
+func (s *TestimonialService) ListPublic(ctx context.Context, tag string, featuredOnly bool, limit int) ([]PublicTestimonial, error) {
+	if limit < 1 || limit > maxPublicTestimonials {
+		limit = 12
+	}
+	rows, err := s.repo.ListPublished(ctx, TestimonialChannelWeb, strings.ToLower(strings.TrimSpace(tag)), featuredOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]PublicTestimonial, 0, len(rows))
+	for i := range rows {
+		t := &rows[i]
+		out = append(out, PublicTestimonial{
+			ID:          t.ID,
+			Kind:        t.Kind,
+			Quote:       t.Quote,
+			Title:       t.Title,
+			Body:        t.Body,
+			Attribution: testimonialAttribution(t),
+			Tags:        t.Tags,
+			Featured:    t.IsFeatured,
+			Headshot:    s.publicHeadshot(ctx, t),
+		})
+	}
+	return out, nil
+}
+
+// publicHeadshot returns the headshot variants to publish with t, if any.
+// Failures are logged; the quote goes out without a photo.
+func (s *TestimonialService) publicHeadshot(ctx context.Context, t *repository.Testimonial) []models.ImageVariant {
+	if s.images == nil || t.Anonymous {
+		return nil
+	}
+	set, err := s.images.Latest(ctx, ImageOwnerTestimonialHeadshot, t.ID)
+	if err != nil {
+		if !errors.Is(err, ErrImageNotFound) {
+			log.Printf("[TESTIMONIAL] headshot for %s: %v", t.ID, err)
+		}
+		return nil
+	}
+	return set.Variants
+}
+
+// FeaturedQuote is a consented quote ready to drop into generated material.
+type FeaturedQuote struct {
+	ID          uuid.UUID `json:"id"`
+	Quote       string    `json:"quote"`
+	Attribution string    `json:"attribution"`
+}
+
+// FeaturedQuote returns the top-ranked featured quote consented for
+// channel, or nil when there is none.
+func (s *TestimonialService) FeaturedQuote(ctx context.Context, channel string) (*FeaturedQuote, error) {
+	rows, err := s.repo.ListPublished(ctx, channel, "", true, 1)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &FeaturedQuote{ID: rows[0].ID, Quote: rows[0].Quote, Attribution: testimonialAttribution(&rows[0])}, nil
+}
+
+// QuoteForChannel returns a specific quote if it may be used on channel.
+func (s *TestimonialService) QuoteForChannel(ctx context.Context, id uuid.UUID, channel string) (*FeaturedQuote, error) {
+	t, err := s.GetTestimonial(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !publishableOn(t, channel, s.now()) {
+		return nil, ErrTestimonialNotPublishable
+	}
+	return &FeaturedQuote{ID: t.ID, Quote: t.Quote, Attribution: testimonialAttribution(t)}, nil
+}
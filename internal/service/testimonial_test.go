@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+type fakeTestimonialRepo struct {
+	repository.TestimonialRepository
+	rows map[uuid.UUID]*repository.Testimonial
+}
+
+func (f *fakeTestimonialRepo) GetByID(_ context.Context, id uuid.UUID) (*repository.Testimonial, error) {
+	t, ok := f.rows[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (f *fakeTestimonialRepo) Update(_ context.Context, t *repository.Testimonial) error {
+	cp := *t
+	f.rows[t.ID] = &cp
+	return nil
+}
+
+func TestTestimonialInputApply(t *testing.T) {
+	tm := &repository.Testimonial{}
+	changed, err := TestimonialInput{Quote: " Sleep logs changed our nights. ", AuthorName: "Dana", Tags: []string{"Sleep", "sleep ", ""}}.apply(tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || tm.Kind != TestimonialKindQuote || tm.Quote != "Sleep logs changed our nights." {
+		t.Errorf("apply = changed %v, kind %q, quote %q", changed, tm.Kind, tm.Quote)
+	}
+	if len(tm.Tags) != 1 || tm.Tags[0] != "sleep" {
+		t.Errorf("tags = %v", tm.Tags)
+	}
+	changed, err = TestimonialInput{Quote: "Sleep logs changed our nights.", AuthorName: "Dana", Tags: []string{"routine"}}.apply(tm)
+	if err != nil || changed {
+		t.Errorf("tag-only edit: changed = %v, err = %v", changed, err)
+	}
+
+	bad := []TestimonialInput{
+		{AuthorName: "Dana"},
+		{Quote: "q"},
+		{Kind: "video", Quote: "q", AuthorName: "Dana"},
+		{Kind: TestimonialKindCaseStudy, Quote: "q", AuthorName: "Dana", Title: "t"},
+	}
+	for i, in := range bad {
+		if _, err := in.apply(&repository.Testimonial{}); !errors.Is(err, ErrTestimonialInvalid) {
+			t.Errorf("case %d: err = %v, want ErrTestimonialInvalid", i, err)
+		}
+	}
+}
+
+func TestTestimonialSetStatus(t *testing.T) {
+	id := uuid.New()
+	repo := &fakeTestimonialRepo{rows: map[uuid.UUID]*repository.Testimonial{
+		id: {ID: id, Status: TestimonialPendingReview, ConsentStatus: ConsentPending},
+	}}
+	svc := NewTestimonialService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.SetStatus(ctx, id, ReviewInput{Status: TestimonialApproved}, uuid.Nil); !errors.Is(err, ErrTestimonialNoConsent) {
+		t.Fatalf("approve without consent: err = %v", err)
+	}
+	if _, err := svc.SetStatus(ctx, id, ReviewInput{Status: TestimonialArchived}, uuid.Nil); !errors.Is(err, ErrTestimonialTransition) {
+		t.Fatalf("pending_review → archived: err = %v", err)
+	}
+	if _, err := svc.RecordConsent(ctx, id, ConsentInput{Status: ConsentGranted, Method: "email", Channels: []string{"web", "print"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetStatus(ctx, id, ReviewInput{Status: TestimonialApproved}, uuid.New()); err != nil {
+		t.Fatalf("approve with consent: %v", err)
+	}
+	if _, err := svc.SetFeatured(ctx, id, FeatureInput{Featured: true, Rank: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.RecordConsent(ctx, id, ConsentInput{Status: ConsentRevoked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != TestimonialPendingReview || got.IsFeatured || len(got.ConsentChannels) != 0 {
+		t.Errorf("after revoke: status %q, featured %v, channels %v", got.Status, got.IsFeatured, got.ConsentChannels)
+	}
+}
+
+func TestPublishableOn(t *testing.T) {
+	expires := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	tm := &repository.Testimonial{
+		Status:           TestimonialApproved,
+		ConsentStatus:    ConsentGranted,
+		ConsentChannels:  []string{TestimonialChannelWeb},
+		ConsentExpiresOn: &expires,
+	}
+	onDay := time.Date(2026, 6, 30, 18, 0, 0, 0, time.UTC)
+	if !publishableOn(tm, TestimonialChannelWeb, onDay) {
+		t.Error("expiry date should be inclusive")
+	}
+	if publishableOn(tm, TestimonialChannelSocial, onDay) {
+		t.Error("social not consented")
+	}
+	if publishableOn(tm, TestimonialChannelWeb, onDay.AddDate(0, 0, 1)) {
+		t.Error("expired consent still publishable")
+	}
+}
+
+func TestTestimonialAttribution(t *testing.T) {
+	cases := []struct {
+		t    repository.Testimonial
+		want string
+	}{
+		{repository.Testimonial{AuthorName: "Dana R.", AuthorDescriptor: "mom of two"}, "Dana R., mom of two"},
+		{repository.Testimonial{AuthorName: "Dana R."}, "Dana R."},
+		{repository.Testimonial{AuthorName: "Dana R.", AuthorDescriptor: "Parent in Ohio", Anonymous: true}, "Parent in Ohio"},
+		{repository.Testimonial{AuthorName: "Dana R.", Anonymous: true}, "A parent"},
+	}
+	for _, c := range cases {
+		if got := testimonialAttribution(&c.t); got != c.want {
+			t.Errorf("attribution = %q, want %q", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrTherapyGoalNotFound = apperr.NotFound("therapy goal not found")
+	ErrTherapyGoalInvalid  = apperr.Validation("invalid therapy goal")
+	ErrGoalReportQuarter   = errors.New("quarter must be 1-4 and not in the future")
+)
+
+// Goal trend directions.
+const (
+	GoalTrendImproving        = "improving"
+	GoalTrendSteady           = "steady"
+	GoalTrendDeclining        = "declining"
+	GoalTrendInsufficientData = "insufficient_data"
+)
+
+// goalTrendMinPoints is how many ratings (or measurements) a period needs
+// before it gets a direction.
+const goalTrendMinPoints = 4
+
+type goalProgressReportWriter interface {
+	GenerateGoalProgressReport(ctx context.Context, child *models.Child, userID uuid.UUID, label string, start, end time.Time, trends []models.TherapyGoalTrend) (*models.Report, error)
+}
+
+// TherapyGoalService manages children's therapy goals, the goals each
+// therapy log worked on, and the per-goal trends and quarterly progress
+// report they roll up into.
+type TherapyGoalService struct {
+	repo    repository.TherapyGoalRepository
+	reports goalProgressReportWriter
+	now     func() time.Time
+}
+
+func NewTherapyGoalService(repo repository.TherapyGoalRepository, reports goalProgressReportWriter) *TherapyGoalService {
+	return &TherapyGoalService{repo: repo, reports: reports, now: time.Now}
+}
+
+func invalidGoal(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrTherapyGoalInvalid, fmt.Sprintf(format, args...))
+}
+
+// apply validates req onto g. Status is only taken when update is set.
+func (s *TherapyGoalService) apply(g *models.TherapyGoal, req *models.TherapyGoalRequest, update bool) error {
+	text := strings.TrimSpace(req.GoalText)
+	switch {
+	case text == "":
+		return invalidGoal("goal_text is required")
+	case len(text) > 1000:
+		return invalidGoal("goal_text must be 1000 characters or fewer")
+	case len(req.TherapyType) > 50:
+		return invalidGoal("therapy_type must be 50 characters or fewer")
+	case len(req.TargetMetric) > 200:
+		return invalidGoal("target_metric must be 200 characters or fewer")
+	}
+	g.GoalText = text
+	g.TherapyType.String, g.TherapyType.Valid = req.TherapyType, req.TherapyType != ""
+	g.TargetMetric = strings.TrimSpace(req.TargetMetric)
+	g.Baseline, g.TargetValue = req.Baseline, req.TargetValue
+	g.TargetDate = nil
+	if !req.TargetDate.Time.IsZero() {
+		d := req.TargetDate.Time
+		g.TargetDate = &d
+	}
+	if !update {
+		g.Status = models.TherapyGoalActive
+		return nil
+	}
+	switch req.Status {
+	case "":
+	case models.TherapyGoalActive, models.TherapyGoalMet, models.TherapyGoalDiscontinued:
+		if req.Status == models.TherapyGoalMet && g.Status != models.TherapyGoalMet {
+			at := s.now().UTC()
+			g.MetAt = &at
+		} else if req.Status != models.TherapyGoalMet {
+			g.MetAt = nil
+		}
+		g.Status = req.Status
+	default:
+		return invalidGoal("status must be active, met or discontinued")
+	}
+	return nil
+}
+
+// Create adds a goal for the child.
+func (s *TherapyGoalService) Create(ctx context.Context, childID, by uuid.UUID, req *models.TherapyGoalRequest) (*models.TherapyGoal, error) {
+	g := &models.TherapyGoal{ChildID: childID, CreatedBy: &by}
+	if err := s.apply(g, req, false); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Get returns a goal of the child, or ErrTherapyGoalNotFound.
+func (s *TherapyGoalService) Get(ctx context.Context, childID, id uuid.UUID) (*models.TherapyGoal, error) {
+	g, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil || g.ChildID != childID {
+		return nil, ErrTherapyGoalNotFound
+	}
+	return g, nil
+}
+
+// List returns the child's goals; status "" lists all.
+func (s *TherapyGoalService) List(ctx context.Context, childID uuid.UUID, status string) ([]models.TherapyGoal, error) {
+	return s.repo.ListByChild(ctx, childID, status)
+}
+
+// Update replaces a goal's fields and, when given, its status.
+func (s *TherapyGoalService) Update(ctx context.Context, g *models.TherapyGoal, req *models.TherapyGoalRequest) error {
+	if err := s.apply(g, req, true); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, g)
+}
+
+// Delete removes a goal along with its links to therapy logs. Goals that
+// are no longer worked on are better discontinued, which keeps them in
+// progress reports.
+func (s *TherapyGoalService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// ValidateLogGoals checks the goals a therapy log references: each one
+// the child's, once, with a 1-5 rating when rated.
+func (s *TherapyGoalService) ValidateLogGoals(ctx context.Context, childID uuid.UUID, goals []models.TherapyLogGoal) error {
+	seen := map[uuid.UUID]bool{}
+	for _, lg := range goals {
+		if seen[lg.GoalID] {
+			return invalidGoal("goal %s is listed twice", lg.GoalID)
+		}
+		seen[lg.GoalID] = true
+		if lg.ProgressRating != nil && (*lg.ProgressRating < 1 || *lg.ProgressRating > 5) {
+			return invalidGoal("progress_rating must be between 1 and 5")
+		}
+		if len(lg.Note) > 1000 {
+			return invalidGoal("goal note must be 1000 characters or fewer")
+		}
+		if _, err := s.Get(ctx, childID, lg.GoalID); errors.Is(err, ErrTherapyGoalNotFound) {
+			return invalidGoal("goal %s is not one of this child's goals", lg.GoalID)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetLogGoals replaces the goals a therapy log worked on. They must have
+// passed ValidateLogGoals.
+func (s *TherapyGoalService) SetLogGoals(ctx context.Context, therapyLogID uuid.UUID, goals []models.TherapyLogGoal) error {
+	return s.repo.SetLogGoals(ctx, therapyLogID, goals)
+}
+
+// AttachLogGoals fills in each therapy log's Goals.
+func (s *TherapyGoalService) AttachLogGoals(ctx context.Context, logs []models.TherapyLog) error {
+	ids := make([]uuid.UUID, len(logs))
+	for i, l := range logs {
+		ids[i] = l.ID
+	}
+	byLog, err := s.repo.LogGoals(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range logs {
+		logs[i].Goals = byLog[logs[i].ID]
+	}
+	return nil
+}
+
+// Trend rolls up a goal's sessions with log dates in [start, end].
+func (s *TherapyGoalService) Trend(ctx context.Context, g *models.TherapyGoal, start, end time.Time) (*models.TherapyGoalTrend, error) {
+	points, err := s.repo.Points(ctx, g.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return goalTrend(*g, points), nil
+}
+
+// Trends rolls up each of the child's goals over [start, end]: those
+// that existed by end, leaving out discontinued goals not worked on in
+// the period.
+func (s *TherapyGoalService) Trends(ctx context.Context, childID uuid.UUID, start, end time.Time) ([]models.TherapyGoalTrend, error) {
+	goals, err := s.repo.ListByChild(ctx, childID, "")
+	if err != nil {
+		return nil, err
+	}
+	out := []models.TherapyGoalTrend{}
+	for i := range goals {
+		if goals[i].CreatedAt.After(end.AddDate(0, 0, 1)) {
+			continue
+		}
+		t, err := s.Trend(ctx, &goals[i], start, end)
+		if err != nil {
+			return nil, err
+		}
+		if goals[i].Status == models.TherapyGoalDiscontinued && t.Sessions == 0 {
+			continue
+		}
+		out = append(out, *t)
+	}
+	return out, nil
+}
+
+// goalTrend summarises points, oldest first, for goal.
+func goalTrend(goal models.TherapyGoal, points []models.TherapyGoalPoint) *models.TherapyGoalTrend {
+	t := &models.TherapyGoalTrend{Goal: goal, Points: points, Sessions: len(points), Direction: GoalTrendInsufficientData}
+	if t.Points == nil {
+		t.Points = []models.TherapyGoalPoint{}
+	}
+	var ratings, values []float64
+	for _, p := range points {
+		if p.ProgressRating != nil {
+			ratings = append(ratings, float64(*p.ProgressRating))
+		}
+		if p.MeasuredValue != nil {
+			v := *p.MeasuredValue
+			values = append(values, v)
+			t.LatestValue = &v
+		}
+	}
+	if len(ratings) > 0 {
+		avg := Mean(ratings)
+		t.AverageRating = &avg
+	}
+	span := 0.0
+	if goal.Baseline != nil && goal.TargetValue != nil {
+		span = *goal.TargetValue - *goal.Baseline
+	}
+	if t.LatestValue != nil && span != 0 {
+		pct := (*t.LatestValue - *goal.Baseline) / span * 100
+		t.PercentToTarget = &pct
+	}
+
+	// Ratings are on a fixed 1-5 scale; measurements only mean something
+	// against the distance from baseline to target.
+	switch {
+	case len(ratings) >= goalTrendMinPoints:
+		t.Direction = halvesDirection(ratings, 1, 0.5)
+	case len(values) >= goalTrendMinPoints && span != 0:
+		sign := 1.0
+		if span < 0 {
+			sign = -1
+		}
+		t.Direction = halvesDirection(values, sign, 0.1*sign*span)
+	}
+	return t
+}
+
+// halvesDirection compares the mean of the second half of xs with the
+// first: a change of at least threshold in the direction of sign is
+// improving, in the other declining.
+func halvesDirection(xs []float64, sign, threshold float64) string {
+	half := len(xs) / 2
+	delta := (Mean(xs[len(xs)-half:]) - Mean(xs[:half])) * sign
+	switch {
+	case delta >= threshold:
+		return GoalTrendImproving
+	case delta <= -threshold:
+		return GoalTrendDeclining
+	default:
+		return GoalTrendSteady
+	}
+}
+
+// QuarterRange returns the first and last day of a calendar quarter, the
+// last capped at today for the quarter in progress.
+func (s *TherapyGoalService) QuarterRange(year, quarter int) (start, end time.Time, err error) {
+	now := s.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if quarter < 1 || quarter > 4 {
+		return start, end, ErrGoalReportQuarter
+	}
+	start = time.Date(year, time.Month(3*(quarter-1)+1), 1, 0, 0, 0, 0, time.UTC)
+	if start.After(today) {
+		return start, end, ErrGoalReportQuarter
+	}
+	end = start.AddDate(0, 3, -1)
+	if end.After(today) {
+		end = today
+	}
+	return start, end, nil
+}
+
+// ProgressReport generates the quarterly IEP-style progress report PDF for
+// the child's goals. It is stored and listed with the child's other
+// reports.
+func (s *TherapyGoalService) ProgressReport(ctx context.Context, child *models.Child, by uuid.UUID, year, quarter int) (*models.Report, error) {
+	start, end, err := s.QuarterRange(year, quarter)
+	if err != nil {
+		return nil, err
+	}
+	trends, err := s.Trends(ctx, child.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return s.reports.GenerateGoalProgressReport(ctx, child, by, fmt.Sprintf("Q%d %d", quarter, year), start, end, trends)
+}
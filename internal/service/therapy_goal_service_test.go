@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+)
+
+// fakeTherapyGoalRepo keeps goals and their session points in memory.
+type fakeTherapyGoalRepo struct {
+	goals   map[uuid.UUID]*models.TherapyGoal
+	points  map[uuid.UUID][]models.TherapyGoalPoint
+	logSets map[uuid.UUID][]models.TherapyLogGoal
+}
+
+func newFakeTherapyGoalRepo() *fakeTherapyGoalRepo {
+	return &fakeTherapyGoalRepo{
+		goals:   map[uuid.UUID]*models.TherapyGoal{},
+		points:  map[uuid.UUID][]models.TherapyGoalPoint{},
+		logSets: map[uuid.UUID][]models.TherapyLogGoal{},
+	}
+}
+
+func (f *fakeTherapyGoalRepo) Create(ctx context.Context, g *models.TherapyGoal) error {
+	g.ID = uuid.New()
+	c := *g
+	f.goals[g.ID] = &c
+	return nil
+}
+
+func (f *fakeTherapyGoalRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.TherapyGoal, error) {
+	if g, ok := f.goals[id]; ok {
+		c := *g
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeTherapyGoalRepo) ListByChild(ctx context.Context, childID uuid.UUID, status string) ([]models.TherapyGoal, error) {
+	var out []models.TherapyGoal
+	for _, g := range f.goals {
+		if g.ChildID == childID && (status == "" || g.Status == status) {
+			out = append(out, *g)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeTherapyGoalRepo) Update(ctx context.Context, g *models.TherapyGoal) error {
+	c := *g
+	f.goals[g.ID] = &c
+	return nil
+}
+
+func (f *fakeTherapyGoalRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.goals, id)
+	return nil
+}
+
+func (f *fakeTherapyGoalRepo) SetLogGoals(ctx context.Context, therapyLogID uuid.UUID, goals []models.TherapyLogGoal) error {
+	f.logSets[therapyLogID] = goals
+	return nil
+}
+
+func (f *fakeTherapyGoalRepo) LogGoals(ctx context.Context, therapyLogIDs []uuid.UUID) (map[uuid.UUID][]models.TherapyLogGoal, error) {
+	out := map[uuid.UUID][]models.TherapyLogGoal{}
+	for _, id := range therapyLogIDs {
+		for _, lg := range f.logSets[id] {
+			lg.GoalText = f.goals[lg.GoalID].GoalText
+			out[id] = append(out[id], lg)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeTherapyGoalRepo) Points(ctx context.Context, goalID uuid.UUID, start, end time.Time) ([]models.TherapyGoalPoint, error) {
+	var out []models.TherapyGoalPoint
+	for _, p := range f.points[goalID] {
+		if !p.Date.Before(start) && !p.Date.After(end) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func ratedPoints(start time.Time, ratings ...int) []models.TherapyGoalPoint {
+	out := make([]models.TherapyGoalPoint, len(ratings))
+	for i := range ratings {
+		out[i] = models.TherapyGoalPoint{TherapyLogID: uuid.New(), Date: start.AddDate(0, 0, 7*i), ProgressRating: &ratings[i]}
+	}
+	return out
+}
+
+func TestTherapyGoalTrendDirection(t *testing.T) {
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	f := func(v float64) *float64 { return &v }
+	goal := models.TherapyGoal{Baseline: f(20), TargetValue: f(80)}
+	tests := []struct {
+		name   string
+		points []models.TherapyGoalPoint
+		want   string
+	}{
+		{"rising ratings", ratedPoints(start, 2, 2, 3, 4), GoalTrendImproving},
+		{"falling ratings", ratedPoints(start, 4, 4, 3, 2), GoalTrendDeclining},
+		{"flat ratings", ratedPoints(start, 3, 4, 4, 3), GoalTrendSteady},
+		{"too few", ratedPoints(start, 1, 5), GoalTrendInsufficientData},
+		{"measurements toward target", []models.TherapyGoalPoint{
+			{Date: start, MeasuredValue: f(20)}, {Date: start, MeasuredValue: f(30)},
+			{Date: start, MeasuredValue: f(40)}, {Date: start, MeasuredValue: f(50)},
+		}, GoalTrendImproving},
+	}
+	for _, tt := range tests {
+		if got := goalTrend(goal, tt.points).Direction; got != tt.want {
+			t.Errorf("%s: direction %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	tr := goalTrend(goal, []models.TherapyGoalPoint{{Date: start, MeasuredValue: f(35)}, {Date: start, MeasuredValue: f(50)}})
+	if tr.LatestValue == nil || *tr.LatestValue != 50 || tr.PercentToTarget == nil || *tr.PercentToTarget != 50 {
+		t.Errorf("latest %v, percent %v", tr.LatestValue, tr.PercentToTarget)
+	}
+	// A goal measured downwards (fewer meltdowns) improves as values fall.
+	down := models.TherapyGoal{Baseline: f(10), TargetValue: f(2)}
+	if got := goalTrend(down, []models.TherapyGoalPoint{
+		{MeasuredValue: f(10)}, {MeasuredValue: f(9)}, {MeasuredValue: f(6)}, {MeasuredValue: f(5)},
+	}).Direction; got != GoalTrendImproving {
+		t.Errorf("downward goal: %s", got)
+	}
+}
+
+func TestTherapyGoalLifecycleAndReport(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 20, 15, 0, 0, 0, time.UTC)
+	repo := newFakeTherapyGoalRepo()
+	reports := &fakeGoalReportWriter{}
+	svc := NewTherapyGoalService(repo, reports)
+	svc.now = func() time.Time { return now }
+	child := &models.Child{ID: uuid.New(), FamilyID: uuid.New(), FirstName: "Ava"}
+	parent := uuid.New()
+
+	if _, err := svc.Create(ctx, child.ID, parent, &models.TherapyGoalRequest{GoalText: "  "}); !errors.Is(err, ErrTherapyGoalInvalid) {
+		t.Errorf("blank goal: %v", err)
+	}
+	g, err := svc.Create(ctx, child.ID, parent, &models.TherapyGoalRequest{GoalText: "Requests help with a 3-word phrase", TherapyType: "speech", TargetMetric: "% of opportunities"})
+	if err != nil || g.Status != models.TherapyGoalActive {
+		t.Fatalf("create: %+v, %v", g, err)
+	}
+	other, _ := svc.Create(ctx, uuid.New(), parent, &models.TherapyGoalRequest{GoalText: "Another child's goal"})
+
+	rating, bad := 4, 6
+	if err := svc.ValidateLogGoals(ctx, child.ID, []models.TherapyLogGoal{{GoalID: g.ID, ProgressRating: &rating}}); err != nil {
+		t.Errorf("valid log goals: %v", err)
+	}
+	for name, goals := range map[string][]models.TherapyLogGoal{
+		"other child": {{GoalID: other.ID}},
+		"twice":       {{GoalID: g.ID}, {GoalID: g.ID}},
+		"rating":      {{GoalID: g.ID, ProgressRating: &bad}},
+	} {
+		if err := svc.ValidateLogGoals(ctx, child.ID, goals); !errors.Is(err, ErrTherapyGoalInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	logID := uuid.New()
+	svc.SetLogGoals(ctx, logID, []models.TherapyLogGoal{{GoalID: g.ID, ProgressRating: &rating}})
+	logs := []models.TherapyLog{{ID: logID}, {ID: uuid.New()}}
+	if err := svc.AttachLogGoals(ctx, logs); err != nil || len(logs[0].Goals) != 1 || logs[0].Goals[0].GoalText != g.GoalText || logs[1].Goals != nil {
+		t.Errorf("attach: %+v, %v", logs, err)
+	}
+
+	if err := svc.Update(ctx, g, &models.TherapyGoalRequest{GoalText: g.GoalText, Status: models.TherapyGoalMet}); err != nil || g.MetAt == nil {
+		t.Errorf("mark met: %+v, %v", g, err)
+	}
+	if err := svc.Update(ctx, g, &models.TherapyGoalRequest{GoalText: g.GoalText, Status: "done"}); !errors.Is(err, ErrTherapyGoalInvalid) {
+		t.Errorf("bad status: %v", err)
+	}
+
+	q2 := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	repo.points[g.ID] = ratedPoints(q2, 2, 3, 4, 4)
+	if _, err := svc.ProgressReport(ctx, child, parent, 2026, 3); !errors.Is(err, ErrGoalReportQuarter) {
+		t.Errorf("future quarter: %v", err)
+	}
+	if _, err := svc.ProgressReport(ctx, child, parent, 2026, 2); err != nil {
+		t.Fatal(err)
+	}
+	// The quarter in progress runs to today.
+	if reports.label != "Q2 2026" || !reports.start.Equal(q2) || !reports.end.Equal(time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("report range %s %s-%s", reports.label, reports.start, reports.end)
+	}
+	if len(reports.trends) != 1 || reports.trends[0].Sessions != 4 || reports.trends[0].Direction != GoalTrendImproving {
+		t.Errorf("report trends %+v", reports.trends)
+	}
+	if err := goalProgressPDF(child, reports.label, reports.start, reports.end, reports.trends).Output(io.Discard); err != nil {
+		t.Errorf("render PDF: %v", err)
+	}
+}
+
+type fakeGoalReportWriter struct {
+	label      string
+	start, end time.Time
+	trends     []models.TherapyGoalTrend
+}
+
+func (f *fakeGoalReportWriter) GenerateGoalProgressReport(ctx context.Context, child *models.Child, userID uuid.UUID, label string, start, end time.Time, trends []models.TherapyGoalTrend) (*models.Report, error) {
+	f.label, f.start, f.end, f.trends = label, start, end, trends
+	return &models.Report{ID: uuid.New(), ReportType: ReportTypeGoalProgress}, nil
+}
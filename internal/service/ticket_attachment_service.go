@@ -1,60 +1,87 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrAttachmentTooBig         = errors.New("attachment exceeds maximum allowed size")
-	ErrAttachmentLimitReached   = errors.New("maximum number of attachments per ticket reached")
-	ErrAttachmentTypeNotAllowed = errors.New("file type not allowed")
-	ErrAttachmentNotFound       = errors.New("attachment not found")
-	ErrAttachmentForbidden      = errors.New("not allowed to access this attachment")
+	ErrAttachmentTooBig          = errors.New("attachment exceeds maximum allowed size")
+	ErrAttachmentLimitReached    = errors.New("maximum number of attachments per ticket reached")
+	ErrAttachmentTypeNotAllowed  = errors.New("file type not allowed")
+	ErrAttachmentNotFound        = apperr.NotFound("attachment not found")
+	ErrAttachmentForbidden       = apperr.Forbidden("not allowed to access this attachment")
+	ErrAttachmentContentMismatch = errors.New("file contents do not match declared type")
+	ErrAttachmentSignature       = errors.New("attachment link expired or invalid")
+)
+
+const (
+	// maxLogAttachmentBytes caps text/JSON logs well below the media cap —
+	// anything bigger than this is a crash dump, not a log excerpt.
+	maxLogAttachmentBytes = 5 * 1024 * 1024
+	// AttachmentURLTTL is how long signed download/thumbnail URLs stay valid.
+	// Minted on every message read, so short is fine.
+	AttachmentURLTTL = 15 * time.Minute
 )
 
 // allowedMimeTypes is the whitelist of content types accepted for upload.
-// Includes iOS-native HEIC/MOV and Android-native 3GP. Image/video only —
-// no PDFs, docs, etc., to keep PHI surface narrow.
+// Includes iOS-native HEIC/MOV and Android-native 3GP. Image/video plus
+// plain-text/JSON logs — no PDFs, docs, etc., to keep PHI surface narrow.
 var allowedMimeTypes = map[string]string{
-	"image/jpeg":      "image",
-	"image/jpg":       "image",
-	"image/png":       "image",
-	"image/gif":       "image",
-	"image/webp":      "image",
-	"image/heic":      "image",
-	"image/heif":      "image",
-	"video/mp4":       "video",
-	"video/quicktime": "video", // .mov, iOS native
-	"video/webm":      "video", // also used by in-browser MediaRecorder
-	"video/3gpp":      "video", // .3gp, Android
-	"video/3gpp2":     "video",
-	"video/x-m4v":     "video",
-	"audio/webm":      "video", // recording-only, no display capture
+	"image/jpeg":       "image",
+	"image/jpg":        "image",
+	"image/png":        "image",
+	"image/gif":        "image",
+	"image/webp":       "image",
+	"image/heic":       "image",
+	"image/heif":       "image",
+	"video/mp4":        "video",
+	"video/quicktime":  "video", // .mov, iOS native
+	"video/webm":       "video", // also used by in-browser MediaRecorder
+	"video/3gpp":       "video", // .3gp, Android
+	"video/3gpp2":      "video",
+	"video/x-m4v":      "video",
+	"audio/webm":       "video", // recording-only, no display capture
+	"text/plain":       "log",
+	"text/x-log":       "log",
+	"application/json": "log",
 }
 
 // TicketAttachmentService coordinates uploads, listing, and bulk deletion of
 // ticket attachments. The actual bytes are handed off to AttachmentStorage;
 // this service owns the validation + DB row + cross-table close hooks.
 type TicketAttachmentService struct {
-	repo        repository.TicketAttachmentRepository
-	adminRepo   repository.AdminRepository
-	storage     AttachmentStorage
-	maxBytes    int64
-	maxPerTkt   int
+	repo          repository.TicketAttachmentRepository
+	adminRepo     repository.AdminRepository
+	storage       AttachmentStorage
+	maxBytes      int64
+	maxPerTkt     int
+	signingSecret []byte
+	scan          *UploadScanService
 }
 
-// NewTicketAttachmentService builds the service.
-func NewTicketAttachmentService(repo repository.TicketAttachmentRepository, adminRepo repository.AdminRepository, storage AttachmentStorage, maxBytes int64, maxPerTkt int) *TicketAttachmentService {
+// NewTicketAttachmentService builds the service. signingSecret HMAC-signs
+// the short-lived download URLs returned alongside ticket messages, same
+// scheme as report PDFs.
+func NewTicketAttachmentService(repo repository.TicketAttachmentRepository, adminRepo repository.AdminRepository, storage AttachmentStorage, maxBytes int64, maxPerTkt int, signingSecret string) *TicketAttachmentService {
 	if maxBytes <= 0 {
 		maxBytes = 25 * 1024 * 1024
 	}
@@ -64,13 +91,20 @@ func NewTicketAttachmentService(repo repository.TicketAttachmentRepository, admi
 	return &TicketAttachmentService{
 		repo: repo, adminRepo: adminRepo, storage: storage,
 		maxBytes: maxBytes, maxPerTkt: maxPerTkt,
+		signingSecret: []byte(signingSecret),
 	}
 }
 
+// SetScanService routes uploads through the antivirus stage. Without it
+// attachments go live unscanned.
+func (s *TicketAttachmentService) SetScanService(scan *UploadScanService) {
+	s.scan = scan
+}
+
 // MaxBytes / MaxPerTicket expose the active limits so handlers can enforce
 // the same cap before accepting the multipart form.
-func (s *TicketAttachmentService) MaxBytes() int64    { return s.maxBytes }
-func (s *TicketAttachmentService) MaxPerTicket() int  { return s.maxPerTkt }
+func (s *TicketAttachmentService) MaxBytes() int64   { return s.maxBytes }
+func (s *TicketAttachmentService) MaxPerTicket() int { return s.maxPerTkt }
 
 // UploadInput carries everything the upload path needs.
 type UploadInput struct {
@@ -107,20 +141,47 @@ func (s *TicketAttachmentService) Upload(ctx context.Context, in UploadInput) (*
 		return nil, ErrAttachmentLimitReached
 	}
 
+	limit := s.maxBytes
+	if kind == "log" && limit > maxLogAttachmentBytes {
+		limit = maxLogAttachmentBytes
+	}
+
+	// Sniff the first bytes so an HTML/script payload can't ride in under
+	// an image/* or text/plain label and later render inline.
+	br := bufio.NewReaderSize(in.Body, 512)
+	head, _ := br.Peek(512)
+	if !sniffMatchesDeclared(contentType, kind, head) {
+		return nil, ErrAttachmentContentMismatch
+	}
+
 	// Wrap the body in a size-limited reader so a lying Content-Length
 	// can't blow past the cap.
-	limited := io.LimitReader(in.Body, s.maxBytes+1)
+	limited := io.LimitReader(br, limit+1)
 	path, sizeBytes, err := s.storage.Save(ctx, in.TicketID.String(), in.Filename, contentType, limited)
 	if err != nil {
 		return nil, err
 	}
-	if sizeBytes > s.maxBytes {
+	if sizeBytes > limit {
 		// Rollback on oversize. Best-effort; log if cleanup fails.
 		if delErr := s.storage.Delete(ctx, path); delErr != nil {
 			log.Printf("[ATTACH] cleanup of oversize upload failed: %v", delErr)
 		}
 		return nil, ErrAttachmentTooBig
 	}
+	scanStatus, err := s.scan.Check(ctx, ScanTarget{
+		Source:      ScanSourceTicketAttachment,
+		Filename:    in.Filename,
+		ContentType: contentType,
+		Size:        sizeBytes,
+		UploadedBy:  in.UploaderID,
+		Open:        blobOpener(s.storage, path),
+	})
+	if err != nil {
+		if delErr := s.storage.Delete(ctx, path); delErr != nil {
+			log.Printf("[ATTACH] cleanup of refused upload failed: %v (orphaned path %s)", delErr, path)
+		}
+		return nil, err
+	}
 
 	att := &repository.TicketAttachment{
 		TicketID:      in.TicketID,
@@ -131,6 +192,7 @@ func (s *TicketAttachmentService) Upload(ctx context.Context, in UploadInput) (*
 		StoragePath:   path,
 		StorageDriver: s.storage.Driver(),
 		SizeBytes:     sizeBytes,
+		ScanStatus:    scanStatus,
 	}
 	if err := s.repo.Create(ctx, att); err != nil {
 		// Roll back the storage write so we don't orphan bytes.
@@ -139,12 +201,210 @@ func (s *TicketAttachmentService) Upload(ctx context.Context, in UploadInput) (*
 		}
 		return nil, err
 	}
+	if kind == "image" {
+		s.generateThumbnail(ctx, att)
+	}
+	s.sign(att)
 	return att, nil
 }
 
+// sniffMatchesDeclared checks the sniffed type against the declared one.
+// HEIC/HEIF and most video containers aren't recognized by the stdlib
+// sniffer, so for those we only refuse content that sniffs as markup.
+func sniffMatchesDeclared(declared, kind string, head []byte) bool {
+	if len(head) == 0 {
+		return false
+	}
+	sniffed := http.DetectContentType(head)
+	if strings.HasPrefix(sniffed, "text/html") || strings.HasPrefix(sniffed, "text/xml") {
+		return false
+	}
+	switch {
+	case kind == "log":
+		return strings.HasPrefix(sniffed, "text/plain")
+	case declared == "image/heic" || declared == "image/heif":
+		return true
+	case strings.HasPrefix(declared, "image/"):
+		return strings.HasPrefix(sniffed, "image/")
+	}
+	return true
+}
+
+// generateThumbnail reads the stored original back and writes a JPEG
+// thumbnail next to it. Best-effort: failures are logged and the
+// attachment simply has no thumbnail.
+func (s *TicketAttachmentService) generateThumbnail(ctx context.Context, att *repository.TicketAttachment) {
+	rc, err := s.storage.Open(ctx, att.StoragePath)
+	if err != nil {
+		log.Printf("[ATTACH] thumbnail: reopen %s failed: %v", att.ID, err)
+		return
+	}
+	thumb, w, h, err := makeThumbnail(rc)
+	rc.Close()
+	if err != nil {
+		// Expected for HEIC; not worth a log line per upload.
+		return
+	}
+	path, _, err := s.storage.Save(ctx, att.TicketID.String()+"/thumbs", "thumb.jpg", "image/jpeg", bytes.NewReader(thumb))
+	if err != nil {
+		log.Printf("[ATTACH] thumbnail: save for %s failed: %v", att.ID, err)
+		return
+	}
+	if err := s.repo.SetThumbnail(ctx, att.ID, path, w, h); err != nil {
+		log.Printf("[ATTACH] thumbnail: record for %s failed: %v", att.ID, err)
+		_ = s.storage.Delete(ctx, path)
+		return
+	}
+	att.ThumbnailPath, att.HasThumbnail, att.Width, att.Height = path, true, w, h
+}
+
+// deleteStored removes an attachment's original and thumbnail objects.
+func (s *TicketAttachmentService) deleteStored(ctx context.Context, a *repository.TicketAttachment) error {
+	if a.ThumbnailPath != "" {
+		if err := s.storage.Delete(ctx, a.ThumbnailPath); err != nil {
+			log.Printf("[ATTACH] thumbnail delete failed for %s: %v", a.ID, err)
+		}
+	}
+	return s.storage.Delete(ctx, a.StoragePath)
+}
+
+// LinkToMessage ties previously-uploaded attachments to the message they
+// were sent with. Only the uploader's own pending files on this ticket are
+// linked; anything else in ids is silently ignored.
+func (s *TicketAttachmentService) LinkToMessage(ctx context.Context, ticketID, uploaderID, messageID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	n, err := s.repo.LinkToMessage(ctx, ticketID, uploaderID, messageID, ids)
+	if err != nil {
+		return err
+	}
+	if int(n) != len(ids) {
+		log.Printf("[ATTACH] linked %d/%d attachment(s) to message %s", n, len(ids), messageID)
+	}
+	return nil
+}
+
+// AttachToMessages fills in Attachments (with signed URLs) on each message.
+// When includeInternal is false, files on internal notes are never exposed
+// — the messages themselves are already filtered by the caller.
+func (s *TicketAttachmentService) AttachToMessages(ctx context.Context, ticketID uuid.UUID, messages []repository.TicketMessage, includeInternal bool) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	atts, err := s.repo.ListByTicket(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	byMsg := make(map[uuid.UUID][]repository.TicketAttachment)
+	for i := range atts {
+		a := atts[i]
+		if !a.MessageID.Valid || (a.Internal && !includeInternal) {
+			continue
+		}
+		s.sign(&a)
+		byMsg[a.MessageID.UUID] = append(byMsg[a.MessageID.UUID], a)
+	}
+	for i := range messages {
+		messages[i].Attachments = byMsg[messages[i].ID]
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// signed URLs
+// ----------------------------------------------------------------------------
+
+// Attachment URL variants.
+const (
+	AttachmentVariantOriginal = "orig"
+	AttachmentVariantThumb    = "thumb"
+)
+
+func (s *TicketAttachmentService) mac(attID uuid.UUID, variant string, expUnix int64) []byte {
+	m := hmac.New(sha256.New, s.signingSecret)
+	m.Write([]byte("ticket-attachment|" + attID.String() + "|" + variant + "|" + strconv.FormatInt(expUnix, 10)))
+	return m.Sum(nil)
+}
+
+// SignedURL returns a path ServeSigned will accept without auth until exp.
+// The attachment ID and variant are both covered by the signature.
+func (s *TicketAttachmentService) SignedURL(attID uuid.UUID, variant string, ttl time.Duration) (path string, exp time.Time) {
+	exp = time.Now().Add(ttl)
+	sig := hex.EncodeToString(s.mac(attID, variant, exp.Unix()))
+	return fmt.Sprintf("/a/signed/%s?v=%s&exp=%d&sig=%s", attID, variant, exp.Unix(), sig), exp
+}
+
+// VerifySignedURL checks expiry and signature.
+func (s *TicketAttachmentService) VerifySignedURL(attID uuid.UUID, variant string, expUnix int64, sig string) error {
+	if time.Now().Unix() > expUnix {
+		return ErrAttachmentSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(attID, variant, expUnix)) {
+		return ErrAttachmentSignature
+	}
+	return nil
+}
+
+// OpenSigned opens the requested variant after the caller has verified the
+// signature. Returns the body, the content type to serve, and the row.
+func (s *TicketAttachmentService) OpenSigned(ctx context.Context, attID uuid.UUID, variant string) (io.ReadCloser, string, *repository.TicketAttachment, error) {
+	att, err := s.repo.GetByID(ctx, attID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if att == nil {
+		return nil, "", nil, ErrAttachmentNotFound
+	}
+	path, ct := att.StoragePath, att.ContentType
+	if variant == AttachmentVariantThumb {
+		if att.ThumbnailPath == "" {
+			return nil, "", nil, ErrAttachmentNotFound
+		}
+		path, ct = att.ThumbnailPath, "image/jpeg"
+	}
+	body, err := s.storage.Open(ctx, path)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return body, ct, att, nil
+}
+
+// sign populates DownloadURL / ThumbnailURL on a before it is returned.
+func (s *TicketAttachmentService) sign(a *repository.TicketAttachment) {
+	a.DownloadURL, _ = s.SignedURL(a.ID, AttachmentVariantOriginal, AttachmentURLTTL)
+	if a.HasThumbnail {
+		a.ThumbnailURL, _ = s.SignedURL(a.ID, AttachmentVariantThumb, AttachmentURLTTL)
+	}
+}
+
 // List returns attachments for a ticket. Caller must enforce auth.
 func (s *TicketAttachmentService) List(ctx context.Context, ticketID uuid.UUID) ([]repository.TicketAttachment, error) {
-	return s.repo.ListByTicket(ctx, ticketID)
+	atts, err := s.repo.ListByTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range atts {
+		s.sign(&atts[i])
+	}
+	return atts, nil
+}
+
+// ListForOwner is List minus anything attached to an internal staff note.
+// Caller must already have verified ticket ownership.
+func (s *TicketAttachmentService) ListForOwner(ctx context.Context, ticketID uuid.UUID) ([]repository.TicketAttachment, error) {
+	atts, err := s.List(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	out := atts[:0]
+	for _, a := range atts {
+		if !a.Internal {
+			out = append(out, a)
+		}
+	}
+	return out, nil
 }
 
 // FetchForUser opens an attachment after verifying the user owns the ticket.
@@ -162,7 +422,7 @@ func (s *TicketAttachmentService) FetchForUser(ctx context.Context, attID, userI
 	if err != nil {
 		return nil, nil, err
 	}
-	if ticket == nil || !ticket.UserID.Valid || ticket.UserID.UUID != userID {
+	if ticket == nil || !ticket.UserID.Valid || ticket.UserID.UUID != userID || att.Internal {
 		return nil, nil, ErrAttachmentForbidden
 	}
 	body, err := s.storage.Open(ctx, att.StoragePath)
@@ -203,10 +463,10 @@ func (s *TicketAttachmentService) DeleteByOwner(ctx context.Context, attID, user
 	if err != nil {
 		return err
 	}
-	if ticket == nil || !ticket.UserID.Valid || ticket.UserID.UUID != userID {
+	if ticket == nil || !ticket.UserID.Valid || ticket.UserID.UUID != userID || att.Internal {
 		return ErrAttachmentForbidden
 	}
-	if err := s.storage.Delete(ctx, att.StoragePath); err != nil {
+	if err := s.deleteStored(ctx, att); err != nil {
 		log.Printf("[ATTACH] storage delete failed for %s: %v", att.ID, err)
 	}
 	return s.repo.DeleteByID(ctx, attID)
@@ -221,8 +481,9 @@ func (s *TicketAttachmentService) DeleteAllForTicket(ctx context.Context, ticket
 		log.Printf("[ATTACH] delete-all DB step failed for %s: %v", ticketID, err)
 		return
 	}
-	for _, a := range atts {
-		if err := s.storage.Delete(ctx, a.StoragePath); err != nil {
+	for i := range atts {
+		a := &atts[i]
+		if err := s.deleteStored(ctx, a); err != nil {
 			log.Printf("[ATTACH] delete-all storage step failed for %s (path %s): %v", a.ID, a.StoragePath, err)
 		}
 	}
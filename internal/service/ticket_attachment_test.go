@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestThumbSize(t *testing.T) {
+	cases := []struct {
+		w, h, wantW, wantH int
+	}{
+		{100, 50, 100, 50},
+		{640, 480, 320, 240},
+		{480, 640, 240, 320},
+		{4000, 10, 320, 1},
+	}
+	for _, c := range cases {
+		gw, gh := thumbSize(c.w, c.h, 320)
+		if gw != c.wantW || gh != c.wantH {
+			t.Errorf("thumbSize(%d,%d) = %d,%d want %d,%d", c.w, c.h, gw, gh, c.wantW, c.wantH)
+		}
+	}
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		src.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	thumb, w, h, err := makeThumbnail(&buf)
+	if err != nil {
+		t.Fatalf("makeThumbnail: %v", err)
+	}
+	if w != 800 || h != 400 {
+		t.Fatalf("source dims = %dx%d, want 800x400", w, h)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("decode thumb: %v", err)
+	}
+	if format != "jpeg" || cfg.Width != 320 || cfg.Height != 160 {
+		t.Fatalf("thumb = %s %dx%d, want jpeg 320x160", format, cfg.Width, cfg.Height)
+	}
+
+	if _, _, _, err := makeThumbnail(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Fatal("expected error for non-image input")
+	}
+}
+
+func TestSniffMatchesDeclared(t *testing.T) {
+	pngHead := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	cases := []struct {
+		name     string
+		declared string
+		kind     string
+		head     []byte
+		want     bool
+	}{
+		{"png as png", "image/png", "image", pngHead, true},
+		{"html as png", "image/png", "image", []byte("<html><script>alert(1)</script>"), false},
+		{"text as jpeg", "image/jpeg", "image", []byte("hello world"), false},
+		{"heic not sniffable", "image/heic", "image", []byte("\x00\x00\x00\x18ftypheic"), true},
+		{"plain log", "text/plain", "log", []byte("2026-01-01 INFO started\n"), true},
+		{"json log", "application/json", "log", []byte(`{"level":"info"}`), true},
+		{"html as log", "text/plain", "log", []byte("<!DOCTYPE html><html>"), false},
+		{"binary as log", "text/plain", "log", pngHead, false},
+		{"empty", "image/png", "image", nil, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := sniffMatchesDeclared(c.declared, c.kind, c.head); got != c.want {
+				t.Fatalf("got %v want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentSignedURL(t *testing.T) {
+	s := &TicketAttachmentService{signingSecret: []byte("test-secret")}
+	id := uuid.New()
+	exp := time.Now().Add(time.Minute).Unix()
+	sig := hexMAC(s, id, AttachmentVariantOriginal, exp)
+
+	if err := s.VerifySignedURL(id, AttachmentVariantOriginal, exp, sig); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := s.VerifySignedURL(id, AttachmentVariantThumb, exp, sig); err == nil {
+		t.Fatal("signature for original must not open the thumbnail variant")
+	}
+	if err := s.VerifySignedURL(uuid.New(), AttachmentVariantOriginal, exp, sig); err == nil {
+		t.Fatal("signature must be bound to the attachment id")
+	}
+	if err := s.VerifySignedURL(id, AttachmentVariantOriginal, exp+1, sig); err == nil {
+		t.Fatal("tampered expiry accepted")
+	}
+	past := time.Now().Add(-time.Minute).Unix()
+	if err := s.VerifySignedURL(id, AttachmentVariantOriginal, past, hexMAC(s, id, AttachmentVariantOriginal, past)); err == nil {
+		t.Fatal("expired link accepted")
+	}
+}
+
+func hexMAC(s *TicketAttachmentService, id uuid.UUID, variant string, exp int64) string {
+	return hex.EncodeToString(s.mac(id, variant, exp))
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// thumbMaxDim is the longest edge of a generated thumbnail, in pixels.
+	thumbMaxDim = 320
+	// thumbMaxPixels guards against decompression bombs: a 20 KB PNG can
+	// declare 50k x 50k and OOM the box once decoded. ~40 MP covers every
+	// phone camera on the market.
+	thumbMaxPixels = 40_000_000
+)
+
+var errThumbnailTooLarge = errors.New("image dimensions too large to thumbnail")
+
+// makeThumbnail decodes an image and returns a JPEG no larger than
+// thumbMaxDim on either edge, plus the source dimensions. Formats the
+// stdlib can't decode (HEIC) return an error and the caller just skips
+// the thumbnail — the original is still downloadable.
+func makeThumbnail(r io.Reader) (thumb []byte, width, height int, err error) {
+	// Buffer so DecodeConfig and Decode can both read from the start.
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, 0, 0, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > thumbMaxPixels {
+		return nil, 0, 0, errThumbnailTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	tw, th := thumbSize(cfg.Width, cfg.Height, thumbMaxDim)
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, 0, 0, err
+	}
+	return out.Bytes(), cfg.Width, cfg.Height, nil
+}
+
+// thumbSize scales (w, h) so the longer edge is at most max, preserving
+// aspect ratio. Images already within bounds are returned unchanged.
+func thumbSize(w, h, max int) (int, int) {
+	if w <= max && h <= max {
+		return w, h
+	}
+	if w >= h {
+		nh := h * max / w
+		if nh < 1 {
+			nh = 1
+		}
+		return max, nh
+	}
+	nw := w * max / h
+	if nw < 1 {
+		nw = 1
+	}
+	return nw, max
+}
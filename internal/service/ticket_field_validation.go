@@ -1,13 +1,14 @@
 package service
 
 import (
-	"errors"
 	"fmt"
+
+	"carecompanion/internal/apperr"
 )
 
 // ErrInvalidTicketField is wrapped by every ticket field-validation failure so
 // HTTP handlers can map it to 400 (vs. a 500 for genuine errors).
-var ErrInvalidTicketField = errors.New("invalid ticket field")
+var ErrInvalidTicketField = apperr.Validation("invalid ticket field")
 
 // validTicketStatuses whitelists settable ticket status values.
 var validTicketStatuses = map[string]bool{
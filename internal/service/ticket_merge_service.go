@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrTicketMergeSelf          = apperr.Validation("a ticket cannot be merged into itself")
+	ErrTicketMergeOtherUser     = apperr.Validation("only tickets from the same user can be merged")
+	ErrTicketMergeTargetMissing = apperr.NotFound("merge target not found")
+	ErrTicketAlreadyMerged      = apperr.Conflict("ticket has already been merged into another")
+	ErrTicketMergeTargetMerged  = apperr.Conflict("the chosen target was itself merged; merge into the ticket it points to")
+	ErrTicketMergeTargetIsDup   = apperr.Conflict("the chosen target is closed as a duplicate")
+	ErrTicketMergeOnRoadmap     = apperr.Conflict("ticket is on the roadmap; merge the other ticket into it instead")
+)
+
+// DefaultDuplicateWindow is how far either side of a ticket's creation
+// SuggestDuplicates looks for the same user's other tickets.
+const DefaultDuplicateWindow = 14 * 24 * time.Hour
+
+// duplicateSimilarityThreshold is the SubjectSimilarity a candidate needs
+// to be suggested; maxDuplicateSuggestions caps how many are.
+const (
+	duplicateSimilarityThreshold = 0.5
+	maxDuplicateSuggestions      = 5
+)
+
+// TicketMergeService merges a user's tickets about the same issue into one
+// thread and suggests which of their tickets look like the same issue.
+// Unlike MarkAsDuplicate (one user's report closed in favour of another
+// user's), a merge moves the whole conversation, so it's limited to
+// tickets from the same user.
+type TicketMergeService struct {
+	merges      repository.TicketMergeRepository
+	adminRepo   repository.AdminRepository
+	roadmapRepo repository.RoadmapRepository
+}
+
+// NewTicketMergeService builds the service. roadmapRepo may be nil.
+func NewTicketMergeService(merges repository.TicketMergeRepository, adminRepo repository.AdminRepository, roadmapRepo repository.RoadmapRepository) *TicketMergeService {
+	return &TicketMergeService{merges: merges, adminRepo: adminRepo, roadmapRepo: roadmapRepo}
+}
+
+// ValidateTicketMerge reports whether source may be merged into target.
+func ValidateTicketMerge(source, target *repository.SupportTicket) error {
+	switch {
+	case source.ID == target.ID:
+		return ErrTicketMergeSelf
+	case source.MergedIntoTicketID.Valid:
+		return ErrTicketAlreadyMerged
+	case target.MergedIntoTicketID.Valid:
+		return ErrTicketMergeTargetMerged
+	case target.DuplicateOfTicketID.Valid || target.DuplicateOfRoadmapID.Valid:
+		return ErrTicketMergeTargetIsDup
+	case !source.UserID.Valid || !target.UserID.Valid || source.UserID.UUID != target.UserID.UUID:
+		return ErrTicketMergeOtherUser
+	}
+	return nil
+}
+
+// Merge folds sourceID into targetID: the source's description and replies
+// join the target's thread in date order, its attachments and tags move
+// over, and the source closes with a redirect to the target. The user gets
+// a note on the target saying so; support gets an internal one with the
+// counts.
+func (s *TicketMergeService) Merge(ctx context.Context, sourceID, targetID, actorID uuid.UUID, actorEmail string) (*repository.TicketMerge, error) {
+	source, err := s.adminRepo.GetTicketByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrTicketNotFound
+	}
+	target, err := s.adminRepo.GetTicketByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrTicketMergeTargetMissing
+	}
+	if err := ValidateTicketMerge(source, target); err != nil {
+		return nil, err
+	}
+	// Roadmap items and their followers point at the source ticket.
+	if s.roadmapRepo != nil {
+		if rm, _ := s.roadmapRepo.GetByTicketID(ctx, source.ID); rm != nil {
+			return nil, ErrTicketMergeOnRoadmap
+		}
+	}
+
+	m, err := s.merges.Merge(ctx, repository.TicketMergeInput{
+		SourceID:       source.ID,
+		TargetID:       target.ID,
+		OpeningMessage: mergedOpeningMessage(source),
+		Notes:          mergeNotes,
+		ActorID:        actorID,
+		ActorEmail:     actorEmail,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mergeNotes are the notes Merge posts on the target: support's, with the
+// counts, and the user's.
+func mergeNotes(m *repository.TicketMerge) (internal, user string) {
+	internal = fmt.Sprintf("Merged ticket #%d (%s) into this one: %d message(s) and %d attachment(s) moved.",
+		m.SourceNumber, m.SourceSubject, m.MessagesMoved, m.AttachmentsMoved)
+	return internal, userFacingMergeMessage(m.SourceNumber)
+}
+
+// mergedOpeningMessage carries the source's subject and description into
+// the target's thread. The ticket number is the user's own, so it's fine
+// to show them.
+func mergedOpeningMessage(t *repository.SupportTicket) string {
+	msg := fmt.Sprintf("(Originally ticket #%d: %s)", t.Number, t.Subject)
+	if d := strings.TrimSpace(t.Description); d != "" {
+		msg += "\n\n" + d
+	}
+	return msg
+}
+
+func userFacingMergeMessage(sourceNumber int64) string {
+	return fmt.Sprintf("We've combined your ticket #%d with this one, since they're about the same issue. "+
+		"Everything you sent is here, and we'll follow up in this conversation.", sourceNumber)
+}
+
+// ListMerges returns the tickets merged into targetID, oldest first.
+func (s *TicketMergeService) ListMerges(ctx context.Context, targetID uuid.UUID) ([]repository.TicketMerge, error) {
+	return s.merges.ListForTarget(ctx, targetID)
+}
+
+// DuplicateSuggestion is one of the user's other tickets that looks like
+// the same issue. Score is the SubjectSimilarity, 0–1.
+type DuplicateSuggestion struct {
+	repository.TicketMergeCandidate
+	Score float64 `json:"score"`
+}
+
+// SuggestDuplicates returns the same user's standalone tickets created
+// within window of ticketID whose subjects look alike, best match first.
+// Tickets without a user, or already merged away, get none.
+func (s *TicketMergeService) SuggestDuplicates(ctx context.Context, ticketID uuid.UUID, window time.Duration) ([]DuplicateSuggestion, error) {
+	if window <= 0 {
+		window = DefaultDuplicateWindow
+	}
+	ticket, err := s.adminRepo.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket == nil {
+		return nil, ErrTicketNotFound
+	}
+	if !ticket.UserID.Valid || ticket.MergedIntoTicketID.Valid {
+		return []DuplicateSuggestion{}, nil
+	}
+	cands, err := s.merges.ListCandidates(ctx, ticket.UserID.UUID, ticket.ID,
+		ticket.CreatedAt.Add(-window), ticket.CreatedAt.Add(window), 50)
+	if err != nil {
+		return nil, err
+	}
+	return rankDuplicateSuggestions(ticket.Subject, ticket.CreatedAt, cands), nil
+}
+
+// rankDuplicateSuggestions keeps the candidates whose subject is similar
+// enough to subject, best score first and, on ties, closest in time.
+func rankDuplicateSuggestions(subject string, at time.Time, cands []repository.TicketMergeCandidate) []DuplicateSuggestion {
+	out := []DuplicateSuggestion{}
+	for _, c := range cands {
+		if score := SubjectSimilarity(subject, c.Subject); score >= duplicateSimilarityThreshold {
+			out = append(out, DuplicateSuggestion{TicketMergeCandidate: c, Score: score})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return absDuration(out[i].CreatedAt.Sub(at)) < absDuration(out[j].CreatedAt.Sub(at))
+	})
+	if len(out) > maxDuplicateSuggestions {
+		out = out[:maxDuplicateSuggestions]
+	}
+	return out
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// SubjectSimilarity scores two ticket subjects 0–1: the mean of the Jaccard
+// index and the overlap coefficient of their word sets, after dropping
+// filler words and crude English suffixes. Averaging in the overlap
+// coefficient lets a short subject match a longer one that contains it.
+func SubjectSimilarity(a, b string) float64 {
+	ta, tb := subjectTokens(a), subjectTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	smaller := len(ta)
+	if len(tb) < smaller {
+		smaller = len(tb)
+	}
+	jaccard := float64(shared) / float64(len(ta)+len(tb)-shared)
+	overlap := float64(shared) / float64(smaller)
+	return (jaccard + overlap) / 2
+}
+
+// subjectStopwords are words too common in ticket subjects to say anything
+// about which issue a ticket is about.
+var subjectStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "but": true,
+	"i": true, "im": true, "my": true, "me": true, "we": true, "you": true, "it": true, "its": true,
+	"is": true, "are": true, "was": true, "be": true, "been": true,
+	"to": true, "of": true, "in": true, "on": true, "at": true, "for": true, "with": true, "from": true,
+	"this": true, "that": true, "when": true, "after": true, "before": true,
+	"can": true, "cant": true, "cannot": true, "not": true, "no": true,
+	"doesnt": true, "dont": true, "wont": true, "isnt": true,
+	"please": true, "help": true, "issue": true, "problem": true, "question": true,
+}
+
+func subjectTokens(s string) map[string]bool {
+	s = strings.ReplaceAll(strings.ToLower(s), "'", "")
+	words := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	out := make(map[string]bool, len(words))
+	for _, w := range words {
+		if len(w) < 2 || subjectStopwords[w] {
+			continue
+		}
+		out[stemToken(w)] = true
+	}
+	return out
+}
+
+// stemToken strips a few English suffixes so "crashes", "crashed" and
+// "crashing" all count as "crash". Deliberately crude.
+func stemToken(t string) string {
+	switch {
+	case len(t) > 5 && strings.HasSuffix(t, "ing"):
+		return t[:len(t)-3]
+	case len(t) > 4 && strings.HasSuffix(t, "ed"):
+		return t[:len(t)-2]
+	case len(t) > 4 && strings.HasSuffix(t, "es") && hasAnySuffix(t[:len(t)-2], "s", "x", "z", "ch", "sh"):
+		return t[:len(t)-2]
+	case len(t) > 3 && strings.HasSuffix(t, "s") && !strings.HasSuffix(t, "ss"):
+		return t[:len(t)-1]
+	}
+	return t
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suf := range suffixes {
+		if strings.HasSuffix(s, suf) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+func TestSubjectSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		match bool
+	}{
+		{"App crashes on login", "app crashes when I log in", true},
+		{"Can't sync medications", "Medication sync not working", true},
+		{"Crashing after update", "crashed after update", true},
+		{"Sync", "Sync stopped working on my iPad", true},
+		{"App crashes on login", "Billing question", false},
+		{"Refund for last month", "Dark mode request", false},
+		{"Help", "Help please", false}, // nothing left once filler is dropped
+	}
+	for _, c := range cases {
+		got := SubjectSimilarity(c.a, c.b)
+		if (got >= duplicateSimilarityThreshold) != c.match {
+			t.Errorf("SubjectSimilarity(%q, %q) = %.2f, want match=%v", c.a, c.b, got, c.match)
+		}
+		if rev := SubjectSimilarity(c.b, c.a); rev != got {
+			t.Errorf("not symmetric for %q / %q: %.2f vs %.2f", c.a, c.b, got, rev)
+		}
+	}
+	if got := SubjectSimilarity("Export to PDF fails", "export to pdf FAILS!"); got != 1 {
+		t.Errorf("same words, different case and punctuation = %.2f, want 1", got)
+	}
+}
+
+func TestRankDuplicateSuggestions(t *testing.T) {
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	cand := func(subject string, offset time.Duration) repository.TicketMergeCandidate {
+		return repository.TicketMergeCandidate{ID: uuid.New(), Subject: subject, CreatedAt: at.Add(offset)}
+	}
+	far := cand("App crashes on login", -10*24*time.Hour)
+	near := cand("App crashes on login", 2*time.Hour)
+	partial := cand("App crashes on login screen sometimes", -time.Hour)
+	unrelated := cand("Billing question", -time.Minute)
+
+	got := rankDuplicateSuggestions("app crashes on login", at, []repository.TicketMergeCandidate{far, unrelated, partial, near})
+	want := []uuid.UUID{near.ID, far.ID, partial.ID}
+	if len(got) != len(want) {
+		t.Fatalf("got %d suggestions, want %d: %+v", len(got), len(want), got)
+	}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Errorf("suggestion %d = %q (%.2f), want %s", i, got[i].Subject, got[i].Score, id)
+		}
+	}
+
+	var many []repository.TicketMergeCandidate
+	for i := 0; i < maxDuplicateSuggestions+3; i++ {
+		many = append(many, cand("Sync broken", time.Duration(i)*time.Hour))
+	}
+	if got := rankDuplicateSuggestions("sync broken", at, many); len(got) != maxDuplicateSuggestions {
+		t.Errorf("got %d suggestions, want capped at %d", len(got), maxDuplicateSuggestions)
+	}
+	if got := rankDuplicateSuggestions("sync broken", at, nil); got == nil || len(got) != 0 {
+		t.Errorf("no candidates = %#v, want empty non-nil slice", got)
+	}
+}
+
+func TestValidateTicketMerge(t *testing.T) {
+	user := models.NullUUID{UUID: uuid.New(), Valid: true}
+	ticket := func() *repository.SupportTicket {
+		return &repository.SupportTicket{ID: uuid.New(), UserID: user}
+	}
+	someID := models.NullUUID{UUID: uuid.New(), Valid: true}
+
+	src, tgt := ticket(), ticket()
+	if err := ValidateTicketMerge(src, tgt); err != nil {
+		t.Fatalf("same user's tickets: %v", err)
+	}
+	if err := ValidateTicketMerge(src, src); !errors.Is(err, ErrTicketMergeSelf) {
+		t.Errorf("self = %v", err)
+	}
+
+	other := ticket()
+	other.UserID = models.NullUUID{UUID: uuid.New(), Valid: true}
+	if err := ValidateTicketMerge(src, other); !errors.Is(err, ErrTicketMergeOtherUser) {
+		t.Errorf("other user = %v", err)
+	}
+	anon := ticket()
+	anon.UserID = models.NullUUID{}
+	if err := ValidateTicketMerge(anon, tgt); !errors.Is(err, ErrTicketMergeOtherUser) {
+		t.Errorf("no user = %v", err)
+	}
+
+	merged := ticket()
+	merged.MergedIntoTicketID = someID
+	if err := ValidateTicketMerge(merged, tgt); !errors.Is(err, ErrTicketAlreadyMerged) {
+		t.Errorf("merged source = %v", err)
+	}
+	if err := ValidateTicketMerge(src, merged); !errors.Is(err, ErrTicketMergeTargetMerged) {
+		t.Errorf("merged target = %v", err)
+	}
+
+	dup := ticket()
+	dup.DuplicateOfTicketID = someID
+	if err := ValidateTicketMerge(src, dup); !errors.Is(err, ErrTicketMergeTargetIsDup) {
+		t.Errorf("duplicate target = %v", err)
+	}
+	if err := ValidateTicketMerge(dup, tgt); err != nil {
+		t.Errorf("a duplicate can still be merged into the user's other ticket: %v", err)
+	}
+}
+
+func TestMergeNotes(t *testing.T) {
+	internal, user := mergeNotes(&repository.TicketMerge{SourceNumber: 412, SourceSubject: "Sync broken", MessagesMoved: 3, AttachmentsMoved: 1})
+	if want := "Merged ticket #412 (Sync broken) into this one: 3 message(s) and 1 attachment(s) moved."; internal != want {
+		t.Errorf("internal note = %q, want %q", internal, want)
+	}
+	if user != userFacingMergeMessage(412) {
+		t.Errorf("user note = %q", user)
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrTicketTagInvalid       = apperr.Validation("invalid tag")
+	ErrTicketTooManyTags      = errors.New("too many tags on one ticket")
+	ErrTicketCategoryNotFound = apperr.NotFound("ticket category not found")
+	ErrTicketCategoryInvalid  = apperr.Validation("invalid ticket category")
+	ErrTicketReportRange      = errors.New("invalid report range")
+)
+
+const (
+	maxTagsPerTicket = 10
+	maxTagLen        = 40
+	// maxReportSpan caps the report window so a day-bucketed query over
+	// years of tickets can't run away.
+	maxReportSpan = 366 * 24 * time.Hour
+)
+
+var (
+	tagAllowed  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	slugAllowed = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,59}$`)
+
+	validReportIntervals = map[string]bool{"day": true, "week": true, "month": true}
+)
+
+// NormalizeTicketTags lowercases, trims, collapses inner whitespace to "-",
+// drops blanks and duplicates, and returns the set sorted. Any tag that is
+// still malformed after normalization fails the whole call so staff see the
+// problem instead of a silently-dropped tag.
+func NormalizeTicketTags(raw []string) ([]string, error) {
+	seen := make(map[string]bool, len(raw))
+	out := make([]string, 0, len(raw))
+	for _, t := range raw {
+		t = strings.ToLower(strings.TrimSpace(t))
+		t = strings.Join(strings.Fields(t), "-")
+		t = strings.TrimPrefix(t, "#")
+		if t == "" {
+			continue
+		}
+		if len(t) > maxTagLen || !tagAllowed.MatchString(t) {
+			return nil, fmt.Errorf("%w: %q", ErrTicketTagInvalid, t)
+		}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > maxTagsPerTicket {
+		return nil, fmt.Errorf("%w (max %d)", ErrTicketTooManyTags, maxTagsPerTicket)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// TicketTaxonomyService manages the ticket category tree, per-ticket tags,
+// and the support-load report built on top of them.
+type TicketTaxonomyService struct {
+	repo      repository.TicketTaxonomyRepository
+	adminRepo repository.AdminRepository
+}
+
+// NewTicketTaxonomyService constructs the service.
+func NewTicketTaxonomyService(repo repository.TicketTaxonomyRepository, adminRepo repository.AdminRepository) *TicketTaxonomyService {
+	return &TicketTaxonomyService{repo: repo, adminRepo: adminRepo}
+}
+
+// TicketCategoryInput is the payload accepted by CreateCategory / UpdateCategory.
+type TicketCategoryInput struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ParentID    string `json:"parent_id,omitempty"`
+	SortOrder   int    `json:"sort_order"`
+	IsActive    *bool  `json:"is_active,omitempty"`
+}
+
+// ListCategories returns the taxonomy, active-only unless asked otherwise.
+func (s *TicketTaxonomyService) ListCategories(ctx context.Context, includeInactive bool) ([]repository.TicketCategory, error) {
+	return s.repo.ListCategories(ctx, includeInactive)
+}
+
+// CreateCategory validates and inserts a new category.
+func (s *TicketTaxonomyService) CreateCategory(ctx context.Context, in TicketCategoryInput) (*repository.TicketCategory, error) {
+	slug := strings.ToLower(strings.TrimSpace(in.Slug))
+	if !slugAllowed.MatchString(slug) {
+		return nil, fmt.Errorf("%w: slug must be lowercase letters, digits, - or _", ErrTicketCategoryInvalid)
+	}
+	c := &repository.TicketCategory{
+		Slug:        slug,
+		Description: strings.TrimSpace(in.Description),
+		SortOrder:   in.SortOrder,
+		IsActive:    true,
+	}
+	if err := s.applyCategoryInput(ctx, c, in); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// UpdateCategory edits name/description/parent/order/active. The slug is
+// immutable so historical reports keep lining up.
+func (s *TicketTaxonomyService) UpdateCategory(ctx context.Context, id uuid.UUID, in TicketCategoryInput) (*repository.TicketCategory, error) {
+	c, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrTicketCategoryNotFound
+	}
+	c.Description = strings.TrimSpace(in.Description)
+	c.SortOrder = in.SortOrder
+	if err := s.applyCategoryInput(ctx, c, in); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *TicketTaxonomyService) applyCategoryInput(ctx context.Context, c *repository.TicketCategory, in TicketCategoryInput) error {
+	name := strings.TrimSpace(in.Name)
+	if name == "" || len(name) > 120 {
+		return fmt.Errorf("%w: name is required (max 120 chars)", ErrTicketCategoryInvalid)
+	}
+	c.Name = name
+	if in.IsActive != nil {
+		c.IsActive = *in.IsActive
+	}
+	c.ParentID = models.NullUUID{}
+	if in.ParentID == "" {
+		return nil
+	}
+	parentID, err := uuid.Parse(in.ParentID)
+	if err != nil {
+		return fmt.Errorf("%w: bad parent_id", ErrTicketCategoryInvalid)
+	}
+	if parentID == c.ID {
+		return fmt.Errorf("%w: a category cannot be its own parent", ErrTicketCategoryInvalid)
+	}
+	parent, err := s.repo.GetCategory(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return ErrTicketCategoryNotFound
+	}
+	// Keep the tree one level deep — reports group by top-level + child only.
+	if parent.ParentID.Valid {
+		return fmt.Errorf("%w: sub-categories cannot have children", ErrTicketCategoryInvalid)
+	}
+	if c.ID != uuid.Nil {
+		all, err := s.repo.ListCategories(ctx, true)
+		if err != nil {
+			return err
+		}
+		for _, other := range all {
+			if other.ParentID.Valid && other.ParentID.UUID == c.ID {
+				return fmt.Errorf("%w: a category with sub-categories cannot become one", ErrTicketCategoryInvalid)
+			}
+		}
+	}
+	c.ParentID = models.NullUUID{UUID: parentID, Valid: true}
+	return nil
+}
+
+// DeleteCategory removes a category; its tickets become uncategorized.
+func (s *TicketTaxonomyService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteCategory(ctx, id)
+}
+
+// SetTicketCategory assigns (or clears, with uuid.Nil) the ticket's category.
+func (s *TicketTaxonomyService) SetTicketCategory(ctx context.Context, ticketID, categoryID uuid.UUID) error {
+	if err := s.requireTicket(ctx, ticketID); err != nil {
+		return err
+	}
+	if categoryID == uuid.Nil {
+		return s.repo.SetTicketCategory(ctx, ticketID, nil)
+	}
+	c, err := s.repo.GetCategory(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return ErrTicketCategoryNotFound
+	}
+	if !c.IsActive {
+		return fmt.Errorf("%w: category %q is inactive", ErrTicketCategoryInvalid, c.Slug)
+	}
+	return s.repo.SetTicketCategory(ctx, ticketID, &categoryID)
+}
+
+// SetTicketTags replaces the ticket's tags with the normalized set and
+// returns what was stored.
+func (s *TicketTaxonomyService) SetTicketTags(ctx context.Context, ticketID uuid.UUID, raw []string, actorID uuid.UUID) ([]string, error) {
+	tags, err := NormalizeTicketTags(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireTicket(ctx, ticketID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceTicketTags(ctx, ticketID, tags, actorID); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *TicketTaxonomyService) requireTicket(ctx context.Context, ticketID uuid.UUID) error {
+	t, err := s.adminRepo.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrTicketNotFound
+	}
+	return nil
+}
+
+// ListTags returns known tags for autocomplete.
+func (s *TicketTaxonomyService) ListTags(ctx context.Context, prefix string, limit int) ([]repository.TicketTagCount, error) {
+	return s.repo.ListTags(ctx, strings.ToLower(strings.TrimSpace(prefix)), limit)
+}
+
+// TicketSupportLoadReport is the body of GET /support/tickets/report.
+type TicketSupportLoadReport struct {
+	From       time.Time                             `json:"from"`
+	To         time.Time                             `json:"to"`
+	Interval   string                                `json:"interval"`
+	ByCategory []repository.TicketCategoryBucket     `json:"by_category"`
+	Resolution []repository.TicketCategoryResolution `json:"resolution"`
+	TopTags    []repository.TicketTagCount           `json:"top_tags"`
+}
+
+// SupportLoadReport builds the tickets-by-category-over-time series plus
+// per-category resolution times for [from, to).
+func (s *TicketTaxonomyService) SupportLoadReport(ctx context.Context, from, to time.Time, interval string) (*TicketSupportLoadReport, error) {
+	if interval == "" {
+		interval = "week"
+	}
+	if !validReportIntervals[interval] {
+		return nil, fmt.Errorf("%w: interval must be day, week, or month", ErrTicketReportRange)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: 'to' must be after 'from'", ErrTicketReportRange)
+	}
+	if to.Sub(from) > maxReportSpan {
+		return nil, fmt.Errorf("%w: window is limited to one year", ErrTicketReportRange)
+	}
+
+	byCat, err := s.repo.CountByCategoryOverTime(ctx, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.repo.ResolutionByCategory(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.repo.TopTags(ctx, from, to, 20)
+	if err != nil {
+		return nil, err
+	}
+	return &TicketSupportLoadReport{
+		From: from, To: to, Interval: interval,
+		ByCategory: byCat, Resolution: res, TopTags: tags,
+	}, nil
+}
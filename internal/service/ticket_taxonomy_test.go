@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+func TestNormalizeTicketTags(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr error
+	}{
+		{"nil ok", nil, []string{}, nil},
+		{"lowercase and sort", []string{"Sync", "android"}, []string{"android", "sync"}, nil},
+		{"whitespace collapsed", []string{"  push   not  working "}, []string{"push-not-working"}, nil},
+		{"hash stripped", []string{"#ios"}, []string{"ios"}, nil},
+		{"dedupe after normalize", []string{"iOS", "ios", " IOS "}, []string{"ios"}, nil},
+		{"blanks dropped", []string{"", "   ", "a"}, []string{"a"}, nil},
+		{"punctuation rejected", []string{"bad!tag"}, nil, ErrTicketTagInvalid},
+		{"leading dash rejected", []string{"-x"}, nil, ErrTicketTagInvalid},
+		{"too long rejected", []string{strings.Repeat("a", maxTagLen+1)}, nil, ErrTicketTagInvalid},
+		{"too many rejected", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, nil, ErrTicketTooManyTags},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := NormalizeTicketTags(c.in)
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Fatalf("want %v, got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
+
+// fakeTicketCategories serves categories from a slice.
+type fakeTicketCategories struct {
+	repository.TicketTaxonomyRepository
+	cats []repository.TicketCategory
+}
+
+func (f *fakeTicketCategories) ListCategories(ctx context.Context, includeInactive bool) ([]repository.TicketCategory, error) {
+	return f.cats, nil
+}
+
+func (f *fakeTicketCategories) GetCategory(ctx context.Context, id uuid.UUID) (*repository.TicketCategory, error) {
+	for i := range f.cats {
+		if f.cats[i].ID == id {
+			return &f.cats[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func TestApplyCategoryInputKeepsTreeOneLevel(t *testing.T) {
+	billing, refunds, sync := uuid.New(), uuid.New(), uuid.New()
+	repo := &fakeTicketCategories{cats: []repository.TicketCategory{
+		{ID: billing, Name: "Billing"},
+		{ID: refunds, Name: "Refunds", ParentID: models.NullUUID{UUID: billing, Valid: true}},
+		{ID: sync, Name: "Sync"},
+	}}
+	s := &TicketTaxonomyService{repo: repo}
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		id      uuid.UUID
+		parent  uuid.UUID
+		wantErr bool
+	}{
+		{"leaf under top level", sync, billing, false},
+		{"new category under top level", uuid.Nil, billing, false},
+		{"under a sub-category", sync, refunds, true},
+		{"parent with children moved", billing, sync, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cat := &repository.TicketCategory{ID: c.id}
+			err := s.applyCategoryInput(ctx, cat, TicketCategoryInput{Name: "x", ParentID: c.parent.String()})
+			if c.wantErr != errors.Is(err, ErrTicketCategoryInvalid) {
+				t.Fatalf("err = %v, want invalid: %v", err, c.wantErr)
+			}
+			if !c.wantErr && cat.ParentID.UUID != c.parent {
+				t.Errorf("parent = %v, want %v", cat.ParentID.UUID, c.parent)
+			}
+		})
+	}
+}
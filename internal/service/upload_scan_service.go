@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrUploadQuarantined  = errors.New("upload quarantined: malware detected")
+	ErrScanUnavailable    = errors.New("virus scanning is unavailable; try again later")
+	ErrUploadScanNotFound = apperr.NotFound("scan record not found")
+	ErrUploadScanReviewed = errors.New("scan has already been reviewed")
+)
+
+// Upload sources, recorded on every verdict.
+const (
+	ScanSourceTicketAttachment    = "ticket_attachment"
+	ScanSourceBrandFont           = "brand_font"
+	ScanSourceMarketingScreenshot = "marketing_screenshot"
+	ScanSourceFileTransfer        = "file_transfer"
+	ScanSourceImage               = "image"
+)
+
+// ScanDisabled is the status handed back when no scanner is configured.
+// Nothing is recorded for it.
+const ScanDisabled = "disabled"
+
+// scanVerdictTimeout bounds one scan, including copying an infected file
+// to quarantine.
+const scanVerdictTimeout = 5 * time.Minute
+
+// ScanOptions tunes UploadScanService; NewServices fills it from the
+// ClamAV fields of config.StorageConfig.
+type ScanOptions struct {
+	// MaxBytes is the largest file sent to the scanner. Bigger files are
+	// recorded as skipped; keep it at or below clamd's StreamMaxLength.
+	MaxBytes int64
+	// FailClosed refuses uploads when the scanner errors instead of
+	// letting them through with an "error" verdict.
+	FailClosed bool
+}
+
+// ScanTarget is one freshly stored upload awaiting a verdict. Open must
+// return the stored bytes from the start; it is called again to copy an
+// infected file into quarantine.
+type ScanTarget struct {
+	Source      string
+	Filename    string
+	ContentType string
+	Size        int64
+	UploadedBy  uuid.UUID
+	Open        func(ctx context.Context) (io.ReadCloser, error)
+}
+
+// blobOpener reads a ScanTarget back from blob storage.
+func blobOpener(store BlobStorage, path string) func(context.Context) (io.ReadCloser, error) {
+	return func(ctx context.Context) (io.ReadCloser, error) { return store.Open(ctx, path) }
+}
+
+// bytesOpener serves a ScanTarget already held in memory.
+func bytesOpener(data []byte) func(context.Context) (io.ReadCloser, error) {
+	return func(context.Context) (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+}
+
+// UploadScanService runs uploads past the virus scanner before they go
+// live. Callers store the bytes, call Check, and on ErrUploadQuarantined
+// delete their copy and refuse the upload: the scanner's copy in
+// quarantine storage waits for an admin to release or delete it.
+type UploadScanService struct {
+	repo       repository.UploadScanRepository
+	scanner    VirusScanner
+	quarantine BlobStorage
+	opts       ScanOptions
+	now        func() time.Time
+}
+
+// NewUploadScanService creates the scan stage. A nil scanner disables
+// scanning: Check then reports ScanDisabled and lets everything through.
+func NewUploadScanService(repo repository.UploadScanRepository, scanner VirusScanner, quarantine BlobStorage, opts ScanOptions) *UploadScanService {
+	return &UploadScanService{repo: repo, scanner: scanner, quarantine: quarantine, opts: opts, now: time.Now}
+}
+
+// Enabled reports whether uploads are actually scanned.
+func (s *UploadScanService) Enabled() bool { return s != nil && s.scanner != nil }
+
+// Check scans t and records the verdict. It returns the status to surface
+// in the upload response and, for infected files, an error wrapping
+// ErrUploadQuarantined. Scanner failures are recorded and let through
+// unless the service is fail-closed, in which case ErrScanUnavailable is
+// returned. A nil service checks nothing.
+func (s *UploadScanService) Check(ctx context.Context, t ScanTarget) (string, error) {
+	if !s.Enabled() {
+		return ScanDisabled, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, scanVerdictTimeout)
+	defer cancel()
+
+	rec := &repository.UploadScan{
+		Source:      t.Source,
+		Filename:    truncateRunes(t.Filename, 255),
+		ContentType: truncateRunes(t.ContentType, 100),
+		SizeBytes:   t.Size,
+		Engine:      s.scanner.Engine(),
+		UploadedBy:  models.NullUUID{UUID: t.UploadedBy, Valid: t.UploadedBy != uuid.Nil},
+	}
+	if s.opts.MaxBytes > 0 && t.Size > s.opts.MaxBytes {
+		rec.Status = repository.ScanStatusSkipped
+		rec.Error = fmt.Sprintf("larger than the %d MB scan limit", s.opts.MaxBytes>>20)
+		s.record(ctx, rec)
+		return rec.Status, nil
+	}
+
+	verdict, digest, err := s.scan(ctx, t)
+	rec.SHA256 = digest
+	if err != nil {
+		rec.Status, rec.Error = repository.ScanStatusError, err.Error()
+		s.record(ctx, rec)
+		log.Printf("[SCAN] %s %q: scanner error: %v", t.Source, t.Filename, err)
+		if s.opts.FailClosed {
+			return rec.Status, ErrScanUnavailable
+		}
+		return rec.Status, nil
+	}
+	if !verdict.Infected {
+		rec.Status = repository.ScanStatusClean
+		s.record(ctx, rec)
+		return rec.Status, nil
+	}
+
+	rec.Status, rec.Signature = repository.ScanStatusInfected, truncateRunes(verdict.Signature, 255)
+	released, err := s.repo.IsReleased(ctx, digest)
+	if err != nil {
+		log.Printf("[SCAN] release lookup for %s: %v", digest, err)
+	}
+	if released {
+		rec.ReviewStatus = repository.ScanReviewReleased
+		rec.ReviewNote = "identical file previously released"
+		s.record(ctx, rec)
+		return repository.ScanReviewReleased, nil
+	}
+
+	if path, err := s.quarantineCopy(ctx, t); err != nil {
+		// The upload is still refused; the queue entry just has no file.
+		log.Printf("[SCAN] quarantine copy of %q failed: %v", t.Filename, err)
+		rec.Error = "quarantine copy failed: " + err.Error()
+	} else {
+		rec.QuarantineDriver, rec.QuarantinePath = s.quarantine.Driver(), path
+	}
+	s.record(ctx, rec)
+	log.Printf("[SCAN] %s %q quarantined: %s", t.Source, t.Filename, verdict.Signature)
+	return rec.Status, fmt.Errorf("%w (%s)", ErrUploadQuarantined, verdict.Signature)
+}
+
+// scan streams the target to the scanner, hashing it on the way.
+func (s *UploadScanService) scan(ctx context.Context, t ScanTarget) (VirusVerdict, string, error) {
+	rc, err := t.Open(ctx)
+	if err != nil {
+		return VirusVerdict{}, "", fmt.Errorf("reopen upload: %w", err)
+	}
+	defer rc.Close()
+	h := sha256.New()
+	verdict, err := s.scanner.Scan(ctx, io.TeeReader(rc, h))
+	if err != nil {
+		return verdict, "", err
+	}
+	// Drain anything the scanner didn't read so the digest covers the file.
+	if _, err := io.Copy(h, rc); err != nil {
+		return verdict, "", fmt.Errorf("reopen upload: %w", err)
+	}
+	return verdict, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *UploadScanService) quarantineCopy(ctx context.Context, t ScanTarget) (string, error) {
+	if s.quarantine == nil {
+		return "", errors.New("no quarantine storage")
+	}
+	rc, err := t.Open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	path, _, err := s.quarantine.Save(ctx, t.Source, t.Filename, "application/octet-stream", rc)
+	return path, err
+}
+
+// record persists a verdict. A failed write is logged, not fatal: the
+// verdict itself has already been acted on.
+func (s *UploadScanService) record(ctx context.Context, rec *repository.UploadScan) {
+	if err := s.repo.Create(ctx, rec); err != nil {
+		log.Printf("[SCAN] record %s verdict for %q: %v", rec.Status, rec.Filename, err)
+	}
+}
+
+// List returns recorded verdicts; see repository.UploadScanFilter.
+func (s *UploadScanService) List(ctx context.Context, f repository.UploadScanFilter) ([]repository.UploadScan, error) {
+	return s.repo.List(ctx, f)
+}
+
+// Summary tallies verdicts over the last `window` plus the open queue.
+func (s *UploadScanService) Summary(ctx context.Context, window time.Duration) (map[string]int, error) {
+	return s.repo.CountByStatus(ctx, s.now().Add(-window))
+}
+
+// ScannerVersion reports the scanner's engine/signature version, or an
+// error if it can't be reached.
+func (s *UploadScanService) ScannerVersion(ctx context.Context) (string, error) {
+	if !s.Enabled() {
+		return "", errors.New("no scanner configured (set CLAMAV_ADDR)")
+	}
+	return s.scanner.Version(ctx)
+}
+
+// Get loads one verdict.
+func (s *UploadScanService) Get(ctx context.Context, id uuid.UUID) (*repository.UploadScan, error) {
+	rec, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, ErrUploadScanNotFound
+	}
+	return rec, nil
+}
+
+// OpenQuarantined streams a quarantined file for analysis.
+func (s *UploadScanService) OpenQuarantined(ctx context.Context, id uuid.UUID) (io.ReadCloser, *repository.UploadScan, error) {
+	rec, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rec.QuarantinePath == "" || s.quarantine == nil {
+		return nil, nil, ErrUploadScanNotFound
+	}
+	body, err := s.quarantine.Open(ctx, rec.QuarantinePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, rec, nil
+}
+
+// Review closes a queued infected upload. Releasing marks it a false
+// positive so the same bytes pass on re-upload; deleting confirms it.
+// Either way the quarantined copy is purged.
+func (s *UploadScanService) Review(ctx context.Context, id uuid.UUID, outcome string, by uuid.UUID, note string) (*repository.UploadScan, error) {
+	if outcome != repository.ScanReviewReleased && outcome != repository.ScanReviewDeleted {
+		return nil, fmt.Errorf("%w: unknown review outcome %q", ErrUploadInvalid, outcome)
+	}
+	rec, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	note = truncateRunes(strings.TrimSpace(note), 1000)
+	ok, err := s.repo.Review(ctx, id, outcome, by, note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUploadScanReviewed
+	}
+	if rec.QuarantinePath != "" && s.quarantine != nil {
+		if err := s.quarantine.Delete(ctx, rec.QuarantinePath); err != nil {
+			log.Printf("[SCAN] orphaned quarantine blob %s: %v", rec.QuarantinePath, err)
+		}
+	}
+	return s.Get(ctx, id)
+}
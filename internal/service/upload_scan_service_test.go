@@ -0,0 +1,217 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// uploadScanRepo is an in-memory UploadScanRepository.
+type uploadScanRepo struct {
+	scans []*repository.UploadScan
+}
+
+func (r *uploadScanRepo) Create(ctx context.Context, s *repository.UploadScan) error {
+	s.ID, s.CreatedAt = uuid.New(), time.Now()
+	cp := *s
+	r.scans = append(r.scans, &cp)
+	return nil
+}
+
+func (r *uploadScanRepo) GetByID(ctx context.Context, id uuid.UUID) (*repository.UploadScan, error) {
+	for _, s := range r.scans {
+		if s.ID == id {
+			cp := *s
+			cp.HasQuarantined = cp.QuarantinePath != ""
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *uploadScanRepo) List(ctx context.Context, f repository.UploadScanFilter) ([]repository.UploadScan, error) {
+	var out []repository.UploadScan
+	for _, s := range r.scans {
+		if f.Queue && (s.Status != repository.ScanStatusInfected || s.ReviewStatus != "") {
+			continue
+		}
+		cp := *s
+		cp.HasQuarantined = cp.QuarantinePath != ""
+		out = append(out, cp)
+	}
+	return out, nil
+}
+
+func (r *uploadScanRepo) CountByStatus(ctx context.Context, since time.Time) (map[string]int, error) {
+	out := map[string]int{}
+	for _, s := range r.scans {
+		out[s.Status]++
+	}
+	return out, nil
+}
+
+func (r *uploadScanRepo) IsReleased(ctx context.Context, sha string) (bool, error) {
+	for _, s := range r.scans {
+		if s.SHA256 == sha && s.ReviewStatus == repository.ScanReviewReleased {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *uploadScanRepo) Review(ctx context.Context, id uuid.UUID, outcome string, by uuid.UUID, note string) (bool, error) {
+	for _, s := range r.scans {
+		if s.ID == id && s.Status == repository.ScanStatusInfected && s.ReviewStatus == "" {
+			s.ReviewStatus, s.ReviewNote, s.QuarantinePath = outcome, note, ""
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeClamd speaks enough of the clamd protocol for INSTREAM: anything
+// containing "EICAR" is infected.
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				cmd, err := br.ReadString(0)
+				if err != nil {
+					return
+				}
+				if cmd == "zVERSION\x00" {
+					conn.Write([]byte("ClamAV 1.3.1/27400\x00"))
+					return
+				}
+				var body bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(br, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&body, br, int64(size)); err != nil {
+						return
+					}
+				}
+				reply := "stream: OK\x00"
+				if bytes.Contains(body.Bytes(), []byte("EICAR")) {
+					reply = "stream: Eicar-Test-Signature FOUND\x00"
+				}
+				conn.Write([]byte(reply))
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestParseClamdReply(t *testing.T) {
+	if v, err := parseClamdReply("stream: OK"); err != nil || v.Infected {
+		t.Errorf("OK: %+v %v", v, err)
+	}
+	if v, err := parseClamdReply("stream: Win.Test.EICAR_HDB-1 FOUND"); err != nil || !v.Infected || v.Signature != "Win.Test.EICAR_HDB-1" {
+		t.Errorf("FOUND: %+v %v", v, err)
+	}
+	if _, err := parseClamdReply("INSTREAM size limit exceeded. ERROR"); err == nil {
+		t.Error("ERROR reply: want error")
+	}
+}
+
+func TestUploadScanQuarantineAndRelease(t *testing.T) {
+	ctx := context.Background()
+	scanRepo := &uploadScanRepo{}
+	quarantine := &memBlobStorage{blobs: map[string][]byte{}}
+	scan := NewUploadScanService(scanRepo, NewClamdScanner(fakeClamd(t), 5*time.Second), quarantine, ScanOptions{MaxBytes: 8})
+
+	if v, err := scan.ScannerVersion(ctx); err != nil || v != "ClamAV 1.3.1/27400" {
+		t.Errorf("version = %q, %v", v, err)
+	}
+
+	svc, repo, store := newTestFileTransferService()
+	svc.SetScanService(scan)
+
+	f, err := svc.SaveText(ctx, "ok.txt", "hello", "", 0, uuid.Nil)
+	if err != nil || f.ScanStatus != repository.ScanStatusClean {
+		t.Fatalf("clean upload: %+v %v", f, err)
+	}
+	big, err := svc.SaveText(ctx, "big.txt", "123456789", "", 0, uuid.Nil)
+	if err != nil || big.ScanStatus != repository.ScanStatusSkipped {
+		t.Fatalf("over scan limit: %+v %v", big, err)
+	}
+
+	_, err = svc.SaveText(ctx, "bad.txt", "xEICARx", "", 0, uuid.Nil)
+	if !errors.Is(err, ErrUploadQuarantined) {
+		t.Fatalf("infected upload: err = %v", err)
+	}
+	if len(repo.files) != 2 || len(store.blobs) != 2 {
+		t.Errorf("infected upload left %d rows, %d blobs", len(repo.files), len(store.blobs))
+	}
+	queue, _ := scan.List(ctx, repository.UploadScanFilter{Queue: true})
+	if len(queue) != 1 || queue[0].Signature != "Eicar-Test-Signature" || !queue[0].HasQuarantined {
+		t.Fatalf("queue = %+v", queue)
+	}
+	if len(quarantine.blobs) != 1 {
+		t.Fatalf("%d quarantined blobs", len(quarantine.blobs))
+	}
+
+	rec, err := scan.Review(ctx, queue[0].ID, repository.ScanReviewReleased, uuid.New(), "test file")
+	if err != nil || rec.ReviewStatus != repository.ScanReviewReleased {
+		t.Fatalf("release: %+v %v", rec, err)
+	}
+	if len(quarantine.blobs) != 0 {
+		t.Error("release left the quarantined copy")
+	}
+	if _, err := scan.Review(ctx, queue[0].ID, repository.ScanReviewDeleted, uuid.New(), ""); !errors.Is(err, ErrUploadScanReviewed) {
+		t.Errorf("second review: err = %v", err)
+	}
+
+	again, err := svc.SaveText(ctx, "bad.txt", "xEICARx", "", 0, uuid.Nil)
+	if err != nil || again.ScanStatus != repository.ScanReviewReleased {
+		t.Fatalf("re-upload after release: %+v %v", again, err)
+	}
+}
+
+func TestUploadScanScannerDown(t *testing.T) {
+	ctx := context.Background()
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	target := ScanTarget{Source: ScanSourceBrandFont, Filename: "a.ttf", Size: 3, Open: bytesOpener([]byte("abc"))}
+	open := NewUploadScanService(&uploadScanRepo{}, NewClamdScanner(addr, time.Second), nil, ScanOptions{})
+	if status, err := open.Check(ctx, target); err != nil || status != repository.ScanStatusError {
+		t.Errorf("fail-open: %q %v", status, err)
+	}
+	closed := NewUploadScanService(&uploadScanRepo{}, NewClamdScanner(addr, time.Second), nil, ScanOptions{FailClosed: true})
+	if _, err := closed.Check(ctx, target); !errors.Is(err, ErrScanUnavailable) {
+		t.Errorf("fail-closed: err = %v", err)
+	}
+	var disabled *UploadScanService
+	if status, err := disabled.Check(ctx, target); err != nil || status != ScanDisabled {
+		t.Errorf("nil service: %q %v", status, err)
+	}
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/apperr"
+	"carecompanion/internal/models"
+	"carecompanion/internal/repository"
+)
+
+var (
+	ErrUploadInvalid        = apperr.Validation("invalid upload request")
+	ErrUploadNotFound       = apperr.NotFound("upload not found")
+	ErrUploadTooLarge       = apperr.Validation("upload too large")
+	ErrUploadOffsetConflict = apperr.Conflict("upload offset does not match")
+	ErrUploadClosed         = errors.New("upload is no longer accepting data")
+	ErrUploadChecksum       = errors.New("checksum mismatch")
+)
+
+const (
+	uploadSweepBatch = 100
+	// uploadAssembleTimeout bounds stitching chunks into the final file.
+	uploadAssembleTimeout = 2 * time.Hour
+)
+
+// UploadSink is where a finished resumable upload goes. CheckUpload runs
+// when the session is created so an upload the sink would refuse fails
+// before any bytes move; FinishUpload consumes the assembled,
+// digest-verified body and returns the ID of whatever it created.
+type UploadSink interface {
+	CheckUpload(ctx context.Context, s *repository.UploadSession) error
+	FinishUpload(ctx context.Context, s *repository.UploadSession, body io.Reader) (uuid.UUID, error)
+}
+
+// UploadLimits bounds resumable uploads; NewServices fills it from the
+// Upload* fields of config.StorageConfig.
+type UploadLimits struct {
+	ChunkBytes   int64
+	MaxBytes     int64
+	AbandonAfter time.Duration
+}
+
+// UploadService implements the resumable upload protocol: create a
+// session with the declared length, append chunks strictly in order at
+// the current offset, and hand the result to the purpose's sink once the
+// last byte lands. Each chunk is its own blob so a dropped connection
+// costs at most one chunk.
+type UploadService struct {
+	repo    repository.UploadSessionRepository
+	storage BlobStorage
+	limits  UploadLimits
+	sinks   map[string]UploadSink
+	now     func() time.Time
+}
+
+// NewUploadService creates a new resumable upload service
+func NewUploadService(repo repository.UploadSessionRepository, storage BlobStorage, limits UploadLimits) *UploadService {
+	return &UploadService{
+		repo: repo, storage: storage, limits: limits,
+		sinks: map[string]UploadSink{}, now: time.Now,
+	}
+}
+
+// RegisterSink makes purpose a valid upload destination.
+func (s *UploadService) RegisterSink(purpose string, sink UploadSink) {
+	s.sinks[purpose] = sink
+}
+
+// Limits returns the configured chunk, size and idle bounds.
+func (s *UploadService) Limits() UploadLimits {
+	return s.limits
+}
+
+// CreateUploadInput declares a new upload. SHA256 is optional; when given
+// the assembled file must match it.
+type CreateUploadInput struct {
+	Purpose     string
+	Filename    string
+	ContentType string
+	TotalBytes  int64
+	SHA256      string
+	Metadata    map[string]string
+	CreatedBy   uuid.UUID
+}
+
+func marshalHash(h hash.Hash) ([]byte, error) {
+	return h.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+func unmarshalHash(state []byte) (hash.Hash, error) {
+	h := sha256.New()
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, fmt.Errorf("restore upload digest: %w", err)
+	}
+	return h, nil
+}
+
+// Create opens an upload session after the sink has vetted it.
+func (s *UploadService) Create(ctx context.Context, in CreateUploadInput) (*repository.UploadSession, error) {
+	sink, ok := s.sinks[in.Purpose]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown upload purpose %q", ErrUploadInvalid, in.Purpose)
+	}
+	if in.TotalBytes <= 0 {
+		return nil, fmt.Errorf("%w: upload length is required", ErrUploadInvalid)
+	}
+	if in.TotalBytes > s.limits.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrUploadTooLarge, in.TotalBytes, s.limits.MaxBytes)
+	}
+	digest := strings.ToLower(strings.TrimSpace(in.SHA256))
+	if digest != "" {
+		if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%w: sha256 must be 64 hex characters", ErrUploadInvalid)
+		}
+	}
+	ctype := strings.TrimSpace(in.ContentType)
+	if ctype == "" || len(ctype) > 100 {
+		ctype = "application/octet-stream"
+	}
+	if in.Metadata == nil {
+		in.Metadata = map[string]string{}
+	}
+	state, err := marshalHash(sha256.New())
+	if err != nil {
+		return nil, err
+	}
+
+	u := &repository.UploadSession{
+		Purpose:        in.Purpose,
+		Filename:       strings.TrimSpace(in.Filename),
+		ContentType:    ctype,
+		TotalBytes:     in.TotalBytes,
+		ExpectedSHA256: digest,
+		HashState:      state,
+		Metadata:       in.Metadata,
+		CreatedBy:      models.NullUUID{UUID: in.CreatedBy, Valid: in.CreatedBy != uuid.Nil},
+		ExpiresAt:      s.now().Add(s.limits.AbandonAfter),
+	}
+	if err := sink.CheckUpload(ctx, u); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Get loads a session for its creator. Sessions belonging to another
+// purpose or admin are reported as not found.
+func (s *UploadService) Get(ctx context.Context, id uuid.UUID, purpose string, by uuid.UUID) (*repository.UploadSession, error) {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || u.Purpose != purpose || u.CreatedBy.UUID != by {
+		return nil, ErrUploadNotFound
+	}
+	return u, nil
+}
+
+// WriteChunk appends body at offset, which must equal the bytes received
+// so far. checksum, when non-nil, is the client's SHA-256 of this chunk.
+// The chunk that completes the upload starts assembly in the background;
+// the returned session then reports status "assembling".
+func (s *UploadService) WriteChunk(ctx context.Context, u *repository.UploadSession, offset int64, body io.Reader, checksum []byte) (*repository.UploadSession, error) {
+	if u.Status != repository.UploadStatusUploading {
+		return nil, ErrUploadClosed
+	}
+	if offset != u.ReceivedBytes {
+		return nil, fmt.Errorf("%w: expected offset %d", ErrUploadOffsetConflict, u.ReceivedBytes)
+	}
+	limit := s.limits.ChunkBytes
+	if remaining := u.TotalBytes - offset; remaining < limit {
+		limit = remaining
+	}
+
+	running, err := unmarshalHash(u.HashState)
+	if err != nil {
+		return nil, err
+	}
+	chunkHash := sha256.New()
+	tee := io.TeeReader(io.LimitReader(body, limit+1), io.MultiWriter(running, chunkHash))
+	path, size, err := s.storage.Save(ctx, "sessions/"+u.ID.String(), "chunk", "application/octet-stream", tee)
+	if err != nil {
+		return nil, fmt.Errorf("store chunk: %w", err)
+	}
+	reject := func(err error) (*repository.UploadSession, error) {
+		if derr := s.storage.Delete(ctx, path); derr != nil {
+			log.Printf("[UPLOAD] could not delete rejected chunk %s: %v", path, derr)
+		}
+		return nil, err
+	}
+	switch {
+	case size > limit:
+		return reject(fmt.Errorf("%w: chunks are limited to %d bytes and must not run past the declared length", ErrUploadTooLarge, limit))
+	case size == 0:
+		return reject(fmt.Errorf("%w: empty chunk", ErrUploadInvalid))
+	case checksum != nil && !bytes.Equal(checksum, chunkHash.Sum(nil)):
+		return reject(ErrUploadChecksum)
+	}
+
+	state, err := marshalHash(running)
+	if err != nil {
+		return reject(err)
+	}
+	expires := s.now().Add(s.limits.AbandonAfter)
+	ok, err := s.repo.AppendChunk(ctx, u.ID, repository.UploadChunk{Offset: offset, SizeBytes: size, StoragePath: path}, state, expires)
+	if err != nil {
+		return reject(err)
+	}
+	if !ok {
+		return reject(fmt.Errorf("%w: a concurrent request already wrote at offset %d", ErrUploadOffsetConflict, offset))
+	}
+	u.ReceivedBytes += size
+	u.HashState = state
+	u.ExpiresAt = expires
+
+	if u.ReceivedBytes == u.TotalBytes {
+		if ok, err := s.repo.SetStatus(ctx, u.ID, repository.UploadStatusUploading, repository.UploadStatusAssembling, uuid.Nil, "", expires); err != nil {
+			return nil, err
+		} else if ok {
+			u.Status = repository.UploadStatusAssembling
+			done := *u
+			go s.assemble(&done)
+		}
+	}
+	return u, nil
+}
+
+// chunkReader streams stored chunks back to back, opening each only when
+// the previous one is exhausted.
+type chunkReader struct {
+	ctx     context.Context
+	storage BlobStorage
+	chunks  []repository.UploadChunk
+	cur     io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.cur == nil {
+			if len(c.chunks) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := c.storage.Open(c.ctx, c.chunks[0].StoragePath)
+			if err != nil {
+				return 0, err
+			}
+			c.cur, c.chunks = rc, c.chunks[1:]
+		}
+		n, err := c.cur.Read(p)
+		if err == io.EOF {
+			c.cur.Close()
+			c.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.cur != nil {
+		return c.cur.Close()
+	}
+	return nil
+}
+
+// assemble verifies the digest, feeds the chunks to the sink, records the
+// outcome and frees the chunk blobs either way.
+func (s *UploadService) assemble(u *repository.UploadSession) {
+	ctx, cancel := context.WithTimeout(context.Background(), uploadAssembleTimeout)
+	defer cancel()
+
+	resultID, err := s.finish(ctx, u)
+	status, msg := repository.UploadStatusComplete, ""
+	if err != nil {
+		status, msg = repository.UploadStatusFailed, err.Error()
+		log.Printf("[UPLOAD] session %s (%s) failed to assemble: %v", u.ID, u.Purpose, err)
+	}
+	s.deleteChunks(ctx, u.ID)
+	// Keep the finished row around for one idle window so the client can
+	// poll for the result; the sweeper removes it after that.
+	if _, err := s.repo.SetStatus(ctx, u.ID, repository.UploadStatusAssembling, status, resultID, msg, s.now().Add(s.limits.AbandonAfter)); err != nil {
+		log.Printf("[UPLOAD] session %s: could not record %s: %v", u.ID, status, err)
+	}
+}
+
+func (s *UploadService) finish(ctx context.Context, u *repository.UploadSession) (uuid.UUID, error) {
+	sink, ok := s.sinks[u.Purpose]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("%w: unknown upload purpose %q", ErrUploadInvalid, u.Purpose)
+	}
+	running, err := unmarshalHash(u.HashState)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if u.ExpectedSHA256 != "" && hex.EncodeToString(running.Sum(nil)) != u.ExpectedSHA256 {
+		return uuid.Nil, fmt.Errorf("%w: the uploaded file does not match the declared SHA-256", ErrUploadChecksum)
+	}
+	chunks, err := s.repo.ListChunks(ctx, u.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	var next int64
+	for _, c := range chunks {
+		if c.Offset != next {
+			return uuid.Nil, fmt.Errorf("chunk gap at offset %d", next)
+		}
+		next += c.SizeBytes
+	}
+	if next != u.TotalBytes {
+		return uuid.Nil, fmt.Errorf("chunks cover %d of %d bytes", next, u.TotalBytes)
+	}
+	body := &chunkReader{ctx: ctx, storage: s.storage, chunks: chunks}
+	defer body.Close()
+	return sink.FinishUpload(ctx, u, body)
+}
+
+func (s *UploadService) deleteChunks(ctx context.Context, id uuid.UUID) {
+	chunks, err := s.repo.ListChunks(ctx, id)
+	if err != nil {
+		log.Printf("[UPLOAD] session %s: list chunks: %v", id, err)
+		return
+	}
+	for _, c := range chunks {
+		if err := s.storage.Delete(ctx, c.StoragePath); err != nil {
+			log.Printf("[UPLOAD] session %s: delete chunk %s: %v", id, c.StoragePath, err)
+		}
+	}
+	if err := s.repo.DeleteChunks(ctx, id); err != nil {
+		log.Printf("[UPLOAD] session %s: delete chunk rows: %v", id, err)
+	}
+}
+
+// Abort cancels an upload and frees its chunks. Uploads being assembled
+// can't be aborted; they finish or fail on their own.
+func (s *UploadService) Abort(ctx context.Context, u *repository.UploadSession) error {
+	if u.Status == repository.UploadStatusAssembling {
+		return ErrUploadClosed
+	}
+	s.deleteChunks(ctx, u.ID)
+	return s.repo.Delete(ctx, u.ID)
+}
+
+// SweepAbandoned deletes sessions past their expiry along with any chunks
+// they still hold. Returns the number of sessions removed.
+func (s *UploadService) SweepAbandoned(ctx context.Context) (int, error) {
+	expired, err := s.repo.ListExpired(ctx, s.now(), uploadSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range expired {
+		s.deleteChunks(ctx, u.ID)
+		if err := s.repo.Delete(ctx, u.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// UploadSweeper runs SweepAbandoned periodically.
+type UploadSweeper struct {
+	svc  *UploadService
+	jobs *JobLocker
+}
+
+func NewUploadSweeper(svc *UploadService, jobs *JobLocker) *UploadSweeper {
+	return &UploadSweeper{svc: svc, jobs: jobs}
+}
+
+func (s *UploadSweeper) Start(ctx context.Context) {
+	log.Println("Abandoned upload sweeper started")
+	sweep := func() {
+		s.jobs.RunOnce(ctx, "upload_sweep", TickSlot(time.Now(), 15*time.Minute), 15*time.Minute, func(ctx context.Context) {
+			if n, err := s.svc.SweepAbandoned(ctx); err != nil {
+				log.Printf("Upload sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Upload sweep: removed %d expired session(s)", n)
+			}
+		})
+	}
+	sweep()
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Abandoned upload sweeper stopped")
+			return
+		case <-ticker.C:
+			sweep()
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// uploadSessionRepo is an in-memory UploadSessionRepository. Assembly runs
+// in a goroutine, so it locks.
+type uploadSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*repository.UploadSession
+	chunks   map[uuid.UUID][]repository.UploadChunk
+}
+
+func newUploadSessionRepo() *uploadSessionRepo {
+	return &uploadSessionRepo{
+		sessions: map[uuid.UUID]*repository.UploadSession{},
+		chunks:   map[uuid.UUID][]repository.UploadChunk{},
+	}
+}
+
+func (r *uploadSessionRepo) Create(ctx context.Context, s *repository.UploadSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.ID, s.Status = uuid.New(), repository.UploadStatusUploading
+	cp := *s
+	r.sessions[s.ID] = &cp
+	return nil
+}
+
+func (r *uploadSessionRepo) GetByID(ctx context.Context, id uuid.UUID) (*repository.UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		cp := *s
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (r *uploadSessionRepo) AppendChunk(ctx context.Context, id uuid.UUID, c repository.UploadChunk, state []byte, exp time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.sessions[id]
+	if s == nil || s.ReceivedBytes != c.Offset || s.Status != repository.UploadStatusUploading {
+		return false, nil
+	}
+	s.ReceivedBytes += c.SizeBytes
+	s.HashState, s.ExpiresAt = state, exp
+	r.chunks[id] = append(r.chunks[id], c)
+	return true, nil
+}
+
+func (r *uploadSessionRepo) SetStatus(ctx context.Context, id uuid.UUID, from, to string, result uuid.UUID, msg string, exp time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.sessions[id]
+	if s == nil || s.Status != from {
+		return false, nil
+	}
+	s.Status, s.Error, s.ExpiresAt = to, msg, exp
+	s.ResultID.UUID, s.ResultID.Valid = result, result != uuid.Nil
+	return true, nil
+}
+
+func (r *uploadSessionRepo) ListChunks(ctx context.Context, id uuid.UUID) ([]repository.UploadChunk, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := append([]repository.UploadChunk(nil), r.chunks[id]...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Offset < out[j].Offset })
+	return out, nil
+}
+
+func (r *uploadSessionRepo) DeleteChunks(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.chunks, id)
+	return nil
+}
+
+func (r *uploadSessionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+	delete(r.chunks, id)
+	return nil
+}
+
+func (r *uploadSessionRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]repository.UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []repository.UploadSession
+	for _, s := range r.sessions {
+		if !now.Before(s.ExpiresAt) {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+// recordingSink keeps what it was handed.
+type recordingSink struct {
+	mu     sync.Mutex
+	body   []byte
+	refuse error
+}
+
+func (s *recordingSink) CheckUpload(ctx context.Context, u *repository.UploadSession) error {
+	return s.refuse
+}
+
+func (s *recordingSink) FinishUpload(ctx context.Context, u *repository.UploadSession, body io.Reader) (uuid.UUID, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.mu.Lock()
+	s.body = data
+	s.mu.Unlock()
+	return uuid.New(), nil
+}
+
+// lockedBlobStorage guards memBlobStorage for the assembly goroutine.
+type lockedBlobStorage struct {
+	mu sync.Mutex
+	memBlobStorage
+}
+
+func (l *lockedBlobStorage) Save(ctx context.Context, ns, name, ct string, body io.Reader) (string, int64, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.memBlobStorage.Save(ctx, ns, name, ct, bytes.NewReader(data))
+}
+
+func (l *lockedBlobStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.memBlobStorage.Open(ctx, path)
+}
+
+func (l *lockedBlobStorage) Delete(ctx context.Context, path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.memBlobStorage.Delete(ctx, path)
+}
+
+func (l *lockedBlobStorage) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.blobs)
+}
+
+func waitForUpload(t *testing.T, repo *uploadSessionRepo, id uuid.UUID) *repository.UploadSession {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		u, _ := repo.GetByID(context.Background(), id)
+		if u.Status != repository.UploadStatusAssembling {
+			return u
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("upload never finished assembling")
+	return nil
+}
+
+func newTestUploadService() (*UploadService, *uploadSessionRepo, *lockedBlobStorage, *recordingSink) {
+	repo := newUploadSessionRepo()
+	store := &lockedBlobStorage{memBlobStorage: memBlobStorage{blobs: map[string][]byte{}}}
+	svc := NewUploadService(repo, store, UploadLimits{ChunkBytes: 4, MaxBytes: 100, AbandonAfter: time.Hour})
+	sink := &recordingSink{}
+	svc.RegisterSink("test", sink)
+	return svc, repo, store, sink
+}
+
+func TestResumableUpload(t *testing.T) {
+	svc, repo, store, sink := newTestUploadService()
+	ctx := context.Background()
+	data := "0123456789"
+	sum := sha256.Sum256([]byte(data))
+
+	if _, err := svc.Create(ctx, CreateUploadInput{Purpose: "other", TotalBytes: 10}); !errors.Is(err, ErrUploadInvalid) {
+		t.Errorf("unknown purpose: err = %v", err)
+	}
+	if _, err := svc.Create(ctx, CreateUploadInput{Purpose: "test", TotalBytes: 101}); !errors.Is(err, ErrUploadTooLarge) {
+		t.Errorf("over max: err = %v", err)
+	}
+	sink.refuse = ErrTransferQuotaExceeded
+	if _, err := svc.Create(ctx, CreateUploadInput{Purpose: "test", TotalBytes: 10}); !errors.Is(err, ErrTransferQuotaExceeded) {
+		t.Errorf("sink refusal: err = %v", err)
+	}
+	sink.refuse = nil
+
+	u, err := svc.Create(ctx, CreateUploadInput{
+		Purpose: "test", Filename: "a.bin", TotalBytes: 10, SHA256: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.WriteChunk(ctx, u, 4, strings.NewReader("4567"), nil); !errors.Is(err, ErrUploadOffsetConflict) {
+		t.Errorf("wrong offset: err = %v", err)
+	}
+	if _, err := svc.WriteChunk(ctx, u, 0, strings.NewReader("01234"), nil); !errors.Is(err, ErrUploadTooLarge) {
+		t.Errorf("oversized chunk: err = %v", err)
+	}
+	bad := sha256.Sum256([]byte("nope"))
+	if _, err := svc.WriteChunk(ctx, u, 0, strings.NewReader("0123"), bad[:]); !errors.Is(err, ErrUploadChecksum) {
+		t.Errorf("bad chunk checksum: err = %v", err)
+	}
+	if n := store.count(); n != 0 {
+		t.Errorf("rejected chunks left %d blobs", n)
+	}
+
+	for _, chunk := range []string{"0123", "4567", "89"} {
+		cs := sha256.Sum256([]byte(chunk))
+		if u, err = svc.WriteChunk(ctx, u, u.ReceivedBytes, strings.NewReader(chunk), cs[:]); err != nil {
+			t.Fatalf("chunk %q: %v", chunk, err)
+		}
+	}
+	if u.Status != repository.UploadStatusAssembling {
+		t.Fatalf("status after last chunk = %s", u.Status)
+	}
+	if _, err := svc.WriteChunk(ctx, u, 10, strings.NewReader("x"), nil); !errors.Is(err, ErrUploadClosed) {
+		t.Errorf("write after completion: err = %v", err)
+	}
+
+	done := waitForUpload(t, repo, u.ID)
+	if done.Status != repository.UploadStatusComplete || !done.ResultID.Valid {
+		t.Fatalf("session = %+v", done)
+	}
+	sink.mu.Lock()
+	got := string(sink.body)
+	sink.mu.Unlock()
+	if got != data {
+		t.Errorf("sink got %q, want %q", got, data)
+	}
+	if n := store.count(); n != 0 {
+		t.Errorf("%d chunk blobs left after assembly", n)
+	}
+}
+
+func TestResumableUploadDigestMismatchAndSweep(t *testing.T) {
+	svc, repo, store, _ := newTestUploadService()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	wrong := sha256.Sum256([]byte("something else"))
+	u, err := svc.Create(ctx, CreateUploadInput{Purpose: "test", TotalBytes: 2, SHA256: hex.EncodeToString(wrong[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.WriteChunk(ctx, u, 0, strings.NewReader("ok"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if done := waitForUpload(t, repo, u.ID); done.Status != repository.UploadStatusFailed {
+		t.Errorf("digest mismatch: status = %s", done.Status)
+	}
+
+	abandoned, err := svc.Create(ctx, CreateUploadInput{Purpose: "test", TotalBytes: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.WriteChunk(ctx, abandoned, 0, strings.NewReader("half"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := svc.SweepAbandoned(ctx); n != 0 {
+		t.Errorf("swept %d sessions before expiry", n)
+	}
+	now = now.Add(2 * time.Hour)
+	if n, err := svc.SweepAbandoned(ctx); err != nil || n != 2 {
+		t.Errorf("sweep: n=%d err=%v", n, err)
+	}
+	if n := store.count(); n != 0 || len(repo.sessions) != 0 {
+		t.Errorf("after sweep: %d blobs, %d sessions", n, len(repo.sessions))
+	}
+}
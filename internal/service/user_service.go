@@ -3,24 +3,27 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"strings"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/models"
 	"carecompanion/internal/repository"
 )
 
 var (
 	ErrPasswordMismatch = errors.New("current password is incorrect")
-	ErrEmailTaken       = errors.New("email address is already in use")
-	ErrEmailInvalid     = errors.New("invalid email address")
+	ErrEmailTaken       = apperr.Conflict("email address is already in use")
+	ErrEmailInvalid     = apperr.Validation("invalid email address")
 )
 
 type UserService struct {
 	userRepo   repository.UserRepository
 	familyRepo repository.FamilyRepository
+	verifySvc  *EmailVerificationService // wired post-construction; nil-safe
 }
 
 func NewUserService(userRepo repository.UserRepository, familyRepo repository.FamilyRepository) *UserService {
@@ -30,6 +33,12 @@ func NewUserService(userRepo repository.UserRepository, familyRepo repository.Fa
 	}
 }
 
+// SetEmailVerificationService wires email verification so an email change
+// requires the new address to be verified.
+func (s *UserService) SetEmailVerificationService(v *EmailVerificationService) {
+	s.verifySvc = v
+}
+
 func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	return s.userRepo.GetByID(ctx, id)
 }
@@ -65,6 +74,7 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *
 		user.Phone.String = *req.Phone
 		user.Phone.Valid = *req.Phone != ""
 	}
+	emailChanged := false
 	if req.Email != nil {
 		newEmail := strings.TrimSpace(strings.ToLower(*req.Email))
 		if newEmail == "" || !strings.Contains(newEmail, "@") {
@@ -81,10 +91,21 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *
 				return ErrEmailTaken
 			}
 			user.Email = newEmail
+			emailChanged = true
 		}
 	}
 
-	return s.userRepo.Update(ctx, user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if emailChanged && s.verifySvc != nil {
+		// The profile update stands; a failed send can be retried with a
+		// resend from Settings.
+		if err := s.verifySvc.Start(ctx, userID); err != nil {
+			log.Printf("[USER] Failed to start email verification for %s: %v", userID, err)
+		}
+	}
+	return nil
 }
 
 type ChangePasswordRequest struct {
@@ -8,14 +8,15 @@ import (
 
 	"github.com/google/uuid"
 
+	"carecompanion/internal/apperr"
 	"carecompanion/internal/repository"
 )
 
 var (
-	ErrTicketNotFound       = errors.New("ticket not found")
-	ErrEmptySubject         = errors.New("subject cannot be empty")
-	ErrEmptyDescription     = errors.New("description cannot be empty")
-	ErrEmptyReply           = errors.New("reply message cannot be empty")
+	ErrTicketNotFound       = apperr.NotFound("ticket not found")
+	ErrEmptySubject         = apperr.Validation("subject cannot be empty")
+	ErrEmptyDescription     = apperr.Validation("description cannot be empty")
+	ErrEmptyReply           = apperr.Validation("reply message cannot be empty")
 	ErrTicketNotReopenable  = errors.New("ticket cannot be reopened in its current state")
 )
 
@@ -67,12 +68,27 @@ func (s *UserSupportService) GetTickets(ctx context.Context, userID uuid.UUID) (
 	return s.repo.GetTickets(ctx, userID)
 }
 
-// GetTicketByID returns a specific ticket, validating user ownership
+// GetTicketByID returns a specific ticket, validating user ownership. A
+// merged ticket resolves to the ticket it was merged into; callers should
+// use the returned ID from then on.
 func (s *UserSupportService) GetTicketByID(ctx context.Context, ticketID, userID uuid.UUID) (*repository.SupportTicket, error) {
+	return s.ownTicket(ctx, ticketID, userID)
+}
+
+// ownTicket loads a ticket the user owns, following a merge redirect to
+// the ticket it was merged into. Merge chains are flattened when they're
+// made, so that's at most one hop.
+func (s *UserSupportService) ownTicket(ctx context.Context, ticketID, userID uuid.UUID) (*repository.SupportTicket, error) {
 	ticket, err := s.repo.GetTicketByID(ctx, ticketID, userID)
 	if err != nil {
 		return nil, err
 	}
+	if ticket != nil && ticket.MergedIntoTicketID.Valid {
+		ticket, err = s.repo.GetTicketByID(ctx, ticket.MergedIntoTicketID.UUID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if ticket == nil {
 		return nil, ErrTicketNotFound
 	}
@@ -81,21 +97,18 @@ func (s *UserSupportService) GetTicketByID(ctx context.Context, ticketID, userID
 
 // GetTicketWithMessages returns a ticket with its messages
 func (s *UserSupportService) GetTicketWithMessages(ctx context.Context, ticketID, userID uuid.UUID) (*repository.SupportTicket, []repository.TicketMessage, error) {
-	ticket, err := s.repo.GetTicketByID(ctx, ticketID, userID)
+	ticket, err := s.ownTicket(ctx, ticketID, userID)
 	if err != nil {
 		return nil, nil, err
 	}
-	if ticket == nil {
-		return nil, nil, ErrTicketNotFound
-	}
 
-	messages, err := s.repo.GetTicketMessages(ctx, ticketID, userID)
+	messages, err := s.repo.GetTicketMessages(ctx, ticket.ID, userID)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Mark ticket as read when viewing
-	s.repo.MarkTicketRead(ctx, ticketID, userID)
+	s.repo.MarkTicketRead(ctx, ticket.ID, userID)
 
 	return ticket, messages, nil
 }
@@ -104,39 +117,42 @@ func (s *UserSupportService) GetTicketWithMessages(ctx context.Context, ticketID
 // resolved or closed, replying acts as an implicit reopen — status
 // flips to 'open' and reopened_at is stamped. ErrTicketNotReopenable
 // from the reopen attempt is swallowed (means the ticket was already
-// open, which is the no-op happy path).
-func (s *UserSupportService) AddReply(ctx context.Context, ticketID, userID uuid.UUID, message string) error {
+// open, which is the no-op happy path). Returns the new message ID.
+func (s *UserSupportService) AddReply(ctx context.Context, ticketID, userID uuid.UUID, message string) (uuid.UUID, error) {
 	if message == "" {
-		return ErrEmptyReply
+		return uuid.Nil, ErrEmptyReply
 	}
 
-	// Verify ticket ownership
-	ticket, err := s.repo.GetTicketByID(ctx, ticketID, userID)
+	// Verify ticket ownership; a reply to a merged ticket goes to the
+	// ticket it was merged into.
+	ticket, err := s.ownTicket(ctx, ticketID, userID)
 	if err != nil {
-		return err
-	}
-	if ticket == nil {
-		return ErrTicketNotFound
+		return uuid.Nil, err
 	}
 
-	if err := s.repo.AddMessage(ctx, ticketID, userID, message); err != nil {
-		return err
+	messageID, err := s.repo.AddMessage(ctx, ticket.ID, userID, message)
+	if err != nil {
+		return uuid.Nil, err
 	}
 
 	// Implicit reopen — a reply on a resolved/closed ticket is a clear
 	// signal the user isn't done with it.
-	if reopenErr := s.repo.ReopenTicket(ctx, ticketID, userID); reopenErr != nil && !errors.Is(reopenErr, sql.ErrNoRows) {
+	if reopenErr := s.repo.ReopenTicket(ctx, ticket.ID, userID); reopenErr != nil && !errors.Is(reopenErr, sql.ErrNoRows) {
 		// Don't fail the reply just because the reopen flip errored —
 		// the message is already saved and is the load-bearing thing.
 		// Log the reopen error but return success.
 		_ = reopenErr
 	}
-	return nil
+	return messageID, nil
 }
 
 // MarkTicketRead marks a ticket as read
 func (s *UserSupportService) MarkTicketRead(ctx context.Context, ticketID, userID uuid.UUID) error {
-	return s.repo.MarkTicketRead(ctx, ticketID, userID)
+	ticket, err := s.ownTicket(ctx, ticketID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkTicketRead(ctx, ticket.ID, userID)
 }
 
 // HasUnreadSupportMessages checks if user has any tickets with unread support replies
@@ -152,26 +168,18 @@ func (s *UserSupportService) GetUnreadTicketCount(ctx context.Context, userID uu
 // ReopenTicket flips a resolved/closed ticket back to open for the
 // owning user. Returns ErrTicketNotReopenable when the ticket exists
 // but isn't currently in a reopenable state, and ErrTicketNotFound
-// when the user doesn't own it (the repo combines both cases — we
-// disambiguate here by looking up the ticket first).
+// when the user doesn't own it. Ownership is checked first so a merged
+// ticket reopens the ticket it was merged into, not the closed husk.
 func (s *UserSupportService) ReopenTicket(ctx context.Context, ticketID, userID uuid.UUID) error {
-	err := s.repo.ReopenTicket(ctx, ticketID, userID)
-	if err == nil {
-		return nil
-	}
-	if !errors.Is(err, sql.ErrNoRows) {
+	ticket, err := s.ownTicket(ctx, ticketID, userID)
+	if err != nil {
 		return err
 	}
-	// Disambiguate: not found vs found-but-wrong-state. A subsequent
-	// ownership check tells us which.
-	ticket, lookupErr := s.repo.GetTicketByID(ctx, ticketID, userID)
-	if lookupErr != nil {
-		return lookupErr
-	}
-	if ticket == nil {
-		return ErrTicketNotFound
+	err = s.repo.ReopenTicket(ctx, ticket.ID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTicketNotReopenable
 	}
-	return ErrTicketNotReopenable
+	return err
 }
 
 // UpdateTicketFieldsRequest is a user's request to change their own ticket's
@@ -185,13 +193,11 @@ type UpdateTicketFieldsRequest struct {
 // they own. Priority is capped at "high" (Urgent is staff-only) — see
 // ValidateTicketFields. Each changed field posts a visible thread note.
 func (s *UserSupportService) UpdateTicketFields(ctx context.Context, ticketID, userID uuid.UUID, req *UpdateTicketFieldsRequest) (*repository.SupportTicket, error) {
-	before, err := s.repo.GetTicketByID(ctx, ticketID, userID)
+	before, err := s.ownTicket(ctx, ticketID, userID)
 	if err != nil {
 		return nil, err
 	}
-	if before == nil {
-		return nil, ErrTicketNotFound
-	}
+	ticketID = before.ID
 
 	if err := ValidateTicketFields(false, req.Type, req.Priority, ""); err != nil {
 		return nil, err
@@ -211,7 +217,7 @@ func (s *UserSupportService) UpdateTicketFields(ctx context.Context, ticketID, u
 
 	// Visible thread notes from the user (AddMessage writes is_internal=false).
 	for _, n := range notes {
-		_ = s.repo.AddMessage(ctx, ticketID, userID, n)
+		_, _ = s.repo.AddMessage(ctx, ticketID, userID, n)
 	}
 
 	return s.repo.GetTicketByID(ctx, ticketID, userID)
@@ -0,0 +1,338 @@
+package service
+
+// warehouse_export_service.go — nightly de-identified analytics export for
+// the research and marketing teams. The anonymization pipeline, in order:
+//
+//   1. Aggregate in SQL. Queries return counts grouped by day, log type,
+//      feature or signup month — never a row per child, family or user, and
+//      never IDs, names, notes or other free text. Soft-deleted families are
+//      left out entirely, and so is any family whose research consent
+//      (research_consent_service.go) doesn't cover the dataset.
+//   2. Generalize. Ages become the two-year bands AgeBand uses ("4-5y",
+//      "18+"), taken at the entry's date; signup dates become months.
+//   3. Suppress small cells. Any row counting fewer than MinCellSize
+//      distinct children or families is dropped (and counted in the export
+//      history), so no published number describes a handful of people.
+//
+// docs/ANALYTICS-EXPORT.md is the reader-facing version of this and lists
+// each dataset's columns.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// Warehouse export datasets.
+const (
+	WarehouseEntryCounts      = "entry_counts"
+	WarehouseFeatureUsage     = "feature_usage"
+	WarehouseRetentionCohorts = "retention_cohorts"
+)
+
+// WarehouseDatasets is every dataset, in export order.
+var WarehouseDatasets = []string{WarehouseEntryCounts, WarehouseFeatureUsage, WarehouseRetentionCohorts}
+
+// warehouseDatasetScopes is the research consent scope each dataset needs.
+var warehouseDatasetScopes = map[string]string{
+	WarehouseEntryCounts:      repository.ResearchScopeCareLogs,
+	WarehouseFeatureUsage:     repository.ResearchScopeFeatureUsage,
+	WarehouseRetentionCohorts: repository.ResearchScopeRetention,
+}
+
+var (
+	ErrWarehouseExportDisabled = errors.New("warehouse export is disabled")
+	ErrWarehouseDayIncomplete  = errors.New("can only export days that have ended (UTC)")
+)
+
+// WarehouseExportOptions configures WarehouseExportService; NewServices
+// fills it from config.WarehouseExportConfig.
+type WarehouseExportOptions struct {
+	Enabled      bool
+	HourUTC      int
+	MinCellSize  int
+	CohortMonths int
+}
+
+// WarehouseExportService writes the de-identified datasets to blob storage
+// as Parquet and records each file in warehouse_exports.
+type WarehouseExportService struct {
+	repo     repository.WarehouseExportRepository
+	consents repository.ResearchConsentRepository
+	store    BlobStorage
+	opts     WarehouseExportOptions
+	now      func() time.Time
+}
+
+func NewWarehouseExportService(repo repository.WarehouseExportRepository, consents repository.ResearchConsentRepository, store BlobStorage, opts WarehouseExportOptions) *WarehouseExportService {
+	if opts.MinCellSize < 2 {
+		opts.MinCellSize = 10
+	}
+	if opts.CohortMonths <= 0 {
+		opts.CohortMonths = 24
+	}
+	return &WarehouseExportService{repo: repo, consents: consents, store: store, opts: opts, now: time.Now}
+}
+
+// Enabled reports whether the nightly export runs.
+func (s *WarehouseExportService) Enabled() bool { return s.opts.Enabled }
+
+// MinCellSize is the suppression threshold.
+func (s *WarehouseExportService) MinCellSize() int { return s.opts.MinCellSize }
+
+// List returns the export history, newest day first.
+func (s *WarehouseExportService) List(ctx context.Context, limit int) ([]repository.WarehouseExport, error) {
+	return s.repo.List(ctx, limit)
+}
+
+// warehouseTable is one dataset ready to encode.
+type warehouseTable struct {
+	cols       []parquetColumn
+	rows       [][]any
+	suppressed int64
+}
+
+// Run exports every dataset for day (a UTC date; the retention cohorts are
+// a snapshot as of day). by is the admin re-running it, or uuid.Nil for the
+// scheduler. A failed dataset doesn't stop the others; the returned error
+// joins their failures.
+func (s *WarehouseExportService) Run(ctx context.Context, day time.Time, by uuid.UUID) ([]repository.WarehouseExport, error) {
+	if !s.opts.Enabled {
+		return nil, ErrWarehouseExportDisabled
+	}
+	day = utcDay(day)
+	if !day.Before(utcDay(s.now())) {
+		return nil, ErrWarehouseDayIncomplete
+	}
+	var out []repository.WarehouseExport
+	var errs []error
+	for _, dataset := range WarehouseDatasets {
+		e, err := s.export(ctx, dataset, day, by)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dataset, err))
+		}
+		if e != nil {
+			out = append(out, *e)
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// RebuildWithdrawn re-exports every published day of the datasets that
+// families have withdrawn from since the last rebuild, so they drop out of
+// past files as well as future ones. The withdrawals are marked rebuilt
+// only once every file has been rewritten; a failure leaves them for the
+// next run. It returns how many files were rewritten.
+func (s *WarehouseExportService) RebuildWithdrawn(ctx context.Context) (int, error) {
+	if !s.opts.Enabled {
+		return 0, ErrWarehouseExportDisabled
+	}
+	pending, err := s.consents.PendingRebuilds(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	withdrawn := map[string]bool{}
+	ids := make([]uuid.UUID, len(pending))
+	for i, c := range pending {
+		ids[i] = c.ID
+		for _, scope := range c.WithdrawnScopes {
+			withdrawn[scope] = true
+		}
+	}
+
+	rebuilt := 0
+	var errs []error
+	for _, dataset := range WarehouseDatasets {
+		if !withdrawn[warehouseDatasetScopes[dataset]] {
+			continue
+		}
+		days, err := s.repo.ExportedDays(ctx, dataset)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dataset, err))
+			continue
+		}
+		for _, day := range days {
+			if _, err := s.export(ctx, dataset, day, uuid.Nil); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", dataset, day.Format("2006-01-02"), err))
+				continue
+			}
+			rebuilt++
+		}
+	}
+	if len(errs) > 0 {
+		return rebuilt, errors.Join(errs...)
+	}
+	return rebuilt, s.consents.MarkRebuilt(ctx, ids)
+}
+
+func (s *WarehouseExportService) export(ctx context.Context, dataset string, day time.Time, by uuid.UUID) (*repository.WarehouseExport, error) {
+	prev, err := s.repo.Find(ctx, dataset, day)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.repo.Start(ctx, dataset, day, by)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*repository.WarehouseExport, error) {
+		if ferr := s.repo.Fail(ctx, e.ID, err.Error()); ferr != nil {
+			log.Printf("Warehouse export: record failure: %v", ferr)
+		}
+		e.Status = repository.WarehouseExportFailed
+		e.Error = err.Error()
+		return e, err
+	}
+
+	table, err := s.build(ctx, dataset, day)
+	if err != nil {
+		return fail(err)
+	}
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, table.cols, table.rows); err != nil {
+		return fail(err)
+	}
+	// Hive-style partition directories, so Athena/Glue pick up dt as a
+	// partition column.
+	namespace := dataset + "/dt=" + day.Format("2006-01-02")
+	path, size, err := s.store.Save(ctx, namespace, dataset+".parquet", "application/vnd.apache.parquet", &buf)
+	if err != nil {
+		return fail(err)
+	}
+	rows := int64(len(table.rows))
+	if err := s.repo.Succeed(ctx, e.ID, rows, table.suppressed, s.store.Driver(), path, size); err != nil {
+		return fail(err)
+	}
+	// A rerun replaces the earlier file so the partition holds one copy.
+	if prev != nil && prev.StoragePath != "" && prev.StoragePath != path {
+		if err := s.store.Delete(ctx, prev.StoragePath); err != nil {
+			log.Printf("Warehouse export: delete previous %s: %v", prev.StoragePath, err)
+		}
+	}
+	e.Status = repository.WarehouseExportSucceeded
+	e.RowCount, e.SuppressedRows = rows, table.suppressed
+	e.StorageDriver, e.StoragePath, e.SizeBytes = s.store.Driver(), path, size
+	return e, nil
+}
+
+func (s *WarehouseExportService) build(ctx context.Context, dataset string, day time.Time) (*warehouseTable, error) {
+	k := int64(s.opts.MinCellSize)
+	t := &warehouseTable{}
+	switch dataset {
+	case WarehouseEntryCounts:
+		counts, err := s.repo.EntryCounts(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+		t.cols = []parquetColumn{
+			{"day", parquetDate}, {"log_type", parquetString}, {"age_band", parquetString},
+			{"entries", parquetInt64}, {"children", parquetInt64},
+		}
+		for _, c := range counts {
+			if c.Children < k {
+				t.suppressed++
+				continue
+			}
+			t.rows = append(t.rows, []any{day, c.LogType, c.AgeBand, c.Entries, c.Children})
+		}
+	case WarehouseFeatureUsage:
+		usage, err := s.repo.FeatureUsage(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+		t.cols = []parquetColumn{
+			{"day", parquetDate}, {"feature", parquetString},
+			{"families", parquetInt64}, {"events", parquetInt64},
+		}
+		for _, u := range usage {
+			if u.Families < k {
+				t.suppressed++
+				continue
+			}
+			t.rows = append(t.rows, []any{day, u.Feature, u.Families, u.Events})
+		}
+	case WarehouseRetentionCohorts:
+		cohorts, err := s.repo.RetentionCohorts(ctx, day, s.opts.CohortMonths)
+		if err != nil {
+			return nil, err
+		}
+		t.cols = []parquetColumn{
+			{"snapshot_date", parquetDate}, {"cohort_month", parquetDate}, {"months_since_signup", parquetInt64},
+			{"cohort_size", parquetInt64}, {"active_families", parquetInt64}, {"retention_rate", parquetDouble},
+		}
+		for _, c := range cohorts {
+			// A small cohort is dropped whole; otherwise a cell is dropped
+			// when a few (but not zero) families were active, since zero
+			// describes nobody.
+			if c.CohortSize < k || (c.ActiveFamilies > 0 && c.ActiveFamilies < k) {
+				t.suppressed++
+				continue
+			}
+			rate := float64(c.ActiveFamilies) / float64(c.CohortSize)
+			t.rows = append(t.rows, []any{day, c.CohortMonth, c.MonthsSinceSignup, c.CohortSize, c.ActiveFamilies, rate})
+		}
+	default:
+		return nil, fmt.Errorf("unknown dataset %q", dataset)
+	}
+	return t, nil
+}
+
+// WarehouseExportScheduler exports the previous UTC day once a day at
+// HourUTC, after rebuilding any files research withdrawals affect.
+type WarehouseExportScheduler struct {
+	svc  *WarehouseExportService
+	jobs *JobLocker
+}
+
+func NewWarehouseExportScheduler(svc *WarehouseExportService, jobs *JobLocker) *WarehouseExportScheduler {
+	return &WarehouseExportScheduler{svc: svc, jobs: jobs}
+}
+
+func (s *WarehouseExportScheduler) Start(ctx context.Context) {
+	if !s.svc.Enabled() {
+		log.Println("Warehouse export scheduler not started (WAREHOUSE_EXPORT_ENABLED=false)")
+		return
+	}
+	hour := s.svc.opts.HourUTC
+	log.Printf("Warehouse export scheduler started (%02d:00 UTC daily)", hour)
+	for {
+		next := nextUTCRunAt(time.Now().UTC(), hour, 0)
+		select {
+		case <-ctx.Done():
+			log.Println("Warehouse export scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+			s.jobs.RunOnce(ctx, "warehouse_export", next, 24*time.Hour, func(ctx context.Context) {
+				if n, err := s.svc.RebuildWithdrawn(ctx); err != nil {
+					log.Printf("Warehouse export: rebuild after research withdrawals: %v", err)
+				} else if n > 0 {
+					log.Printf("Warehouse export: rebuilt %d files after research withdrawals", n)
+				}
+				exports, err := s.svc.Run(ctx, next.AddDate(0, 0, -1), uuid.Nil)
+				if err != nil {
+					log.Printf("Warehouse export: %v", err)
+				}
+				log.Printf("Warehouse export: %d datasets written for %s", countSucceeded(exports), next.AddDate(0, 0, -1).Format("2006-01-02"))
+			})
+		}
+	}
+}
+
+func countSucceeded(exports []repository.WarehouseExport) int {
+	n := 0
+	for _, e := range exports {
+		if e.Status == repository.WarehouseExportSucceeded {
+			n++
+		}
+	}
+	return n
+}
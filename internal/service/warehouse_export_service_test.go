@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"carecompanion/internal/repository"
+)
+
+// fakeWarehouseRepo serves canned aggregates and keeps export rows in
+// memory.
+type fakeWarehouseRepo struct {
+	counts  []repository.EntryCountRow
+	usage   []repository.FeatureUsageRow
+	cohorts []repository.RetentionCohortRow
+	exports map[string]*repository.WarehouseExport
+}
+
+func (r *fakeWarehouseRepo) EntryCounts(ctx context.Context, day time.Time) ([]repository.EntryCountRow, error) {
+	return r.counts, nil
+}
+
+func (r *fakeWarehouseRepo) FeatureUsage(ctx context.Context, day time.Time) ([]repository.FeatureUsageRow, error) {
+	return r.usage, nil
+}
+
+func (r *fakeWarehouseRepo) RetentionCohorts(ctx context.Context, asOf time.Time, months int) ([]repository.RetentionCohortRow, error) {
+	return r.cohorts, nil
+}
+
+func warehouseKey(dataset string, day time.Time) string { return dataset + day.Format("2006-01-02") }
+
+func (r *fakeWarehouseRepo) Find(ctx context.Context, dataset string, day time.Time) (*repository.WarehouseExport, error) {
+	if e, ok := r.exports[warehouseKey(dataset, day)]; ok {
+		cp := *e
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeWarehouseRepo) Start(ctx context.Context, dataset string, day time.Time, by uuid.UUID) (*repository.WarehouseExport, error) {
+	e := &repository.WarehouseExport{ID: uuid.New(), Dataset: dataset, PeriodDate: day, Status: repository.WarehouseExportRunning}
+	r.exports[warehouseKey(dataset, day)] = e
+	cp := *e
+	return &cp, nil
+}
+
+func (r *fakeWarehouseRepo) find(id uuid.UUID) *repository.WarehouseExport {
+	for _, e := range r.exports {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (r *fakeWarehouseRepo) Succeed(ctx context.Context, id uuid.UUID, rows, suppressed int64, driver, path string, size int64) error {
+	e := r.find(id)
+	e.Status, e.RowCount, e.SuppressedRows, e.StorageDriver, e.StoragePath, e.SizeBytes =
+		repository.WarehouseExportSucceeded, rows, suppressed, driver, path, size
+	return nil
+}
+
+func (r *fakeWarehouseRepo) Fail(ctx context.Context, id uuid.UUID, msg string) error {
+	e := r.find(id)
+	e.Status, e.Error = repository.WarehouseExportFailed, msg
+	return nil
+}
+
+func (r *fakeWarehouseRepo) List(ctx context.Context, limit int) ([]repository.WarehouseExport, error) {
+	return nil, nil
+}
+
+func (r *fakeWarehouseRepo) ExportedDays(ctx context.Context, dataset string) ([]time.Time, error) {
+	var out []time.Time
+	for _, e := range r.exports {
+		if e.Dataset == dataset && e.Status == repository.WarehouseExportSucceeded {
+			out = append(out, e.PeriodDate)
+		}
+	}
+	return out, nil
+}
+
+// fakeResearchConsents holds the withdrawals waiting for a rebuild.
+type fakeResearchConsents struct {
+	repository.ResearchConsentRepository
+	pending []repository.ResearchConsent
+	rebuilt []uuid.UUID
+}
+
+func (f *fakeResearchConsents) PendingRebuilds(ctx context.Context) ([]repository.ResearchConsent, error) {
+	return f.pending, nil
+}
+
+func (f *fakeResearchConsents) MarkRebuilt(ctx context.Context, ids []uuid.UUID) error {
+	f.rebuilt = append(f.rebuilt, ids...)
+	f.pending = nil
+	return nil
+}
+
+func newTestWarehouseService() (*WarehouseExportService, *fakeWarehouseRepo, *memBlobStorage) {
+	svc, repo, store, _ := newTestWarehouseServiceWithConsents()
+	return svc, repo, store
+}
+
+func newTestWarehouseServiceWithConsents() (*WarehouseExportService, *fakeWarehouseRepo, *memBlobStorage, *fakeResearchConsents) {
+	repo := &fakeWarehouseRepo{
+		counts: []repository.EntryCountRow{
+			{LogType: "sleep", AgeBand: "4-5y", Entries: 40, Children: 12},
+			{LogType: "seizure", AgeBand: "16-17y", Entries: 3, Children: 2},
+		},
+		usage: []repository.FeatureUsageRow{
+			{Feature: "logging", Families: 30, Events: 90},
+			{Feature: "reports", Families: 4, Events: 4},
+		},
+		cohorts: []repository.RetentionCohortRow{
+			{CohortMonth: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), MonthsSinceSignup: 0, CohortSize: 50, ActiveFamilies: 40},
+			{CohortMonth: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), MonthsSinceSignup: 1, CohortSize: 50, ActiveFamilies: 3},
+			{CohortMonth: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), MonthsSinceSignup: 2, CohortSize: 50, ActiveFamilies: 0},
+			{CohortMonth: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), MonthsSinceSignup: 0, CohortSize: 6, ActiveFamilies: 6},
+		},
+		exports: map[string]*repository.WarehouseExport{},
+	}
+	store := &memBlobStorage{blobs: map[string][]byte{}}
+	consents := &fakeResearchConsents{}
+	svc := NewWarehouseExportService(repo, consents, store, WarehouseExportOptions{Enabled: true, MinCellSize: 10})
+	svc.now = func() time.Time { return time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC) }
+	return svc, repo, store, consents
+}
+
+func TestWarehouseExportSuppressesSmallCells(t *testing.T) {
+	svc, _, store := newTestWarehouseService()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	exports, err := svc.Run(context.Background(), day, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int64{ // rows, suppressed
+		WarehouseEntryCounts:      {1, 1},
+		WarehouseFeatureUsage:     {1, 1},
+		WarehouseRetentionCohorts: {2, 2},
+	}
+	if len(exports) != len(want) {
+		t.Fatalf("got %d exports", len(exports))
+	}
+	for _, e := range exports {
+		if e.Status != repository.WarehouseExportSucceeded {
+			t.Errorf("%s: status %s (%s)", e.Dataset, e.Status, e.Error)
+		}
+		if got := [2]int64{e.RowCount, e.SuppressedRows}; got != want[e.Dataset] {
+			t.Errorf("%s: rows/suppressed = %v, want %v", e.Dataset, got, want[e.Dataset])
+		}
+		if !strings.HasPrefix(e.StoragePath, e.Dataset+"/dt=2026-03-01/") {
+			t.Errorf("%s: path %q", e.Dataset, e.StoragePath)
+		}
+		data := store.blobs[e.StoragePath]
+		if !strings.HasPrefix(string(data), "PAR1") {
+			t.Errorf("%s: not a Parquet file", e.Dataset)
+		}
+	}
+}
+
+func TestWarehouseExportRerunReplacesFile(t *testing.T) {
+	svc, _, store := newTestWarehouseService()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := svc.Run(context.Background(), day, uuid.Nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Run(context.Background(), day, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.blobs) != len(WarehouseDatasets) {
+		t.Errorf("%d files after rerun, want %d", len(store.blobs), len(WarehouseDatasets))
+	}
+}
+
+func TestWarehouseExportRejectsIncompleteDay(t *testing.T) {
+	svc, _, _ := newTestWarehouseService()
+	if _, err := svc.Run(context.Background(), time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), uuid.Nil); err != ErrWarehouseDayIncomplete {
+		t.Errorf("today: err = %v", err)
+	}
+	svc.opts.Enabled = false
+	if _, err := svc.Run(context.Background(), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), uuid.Nil); err != ErrWarehouseExportDisabled {
+		t.Errorf("disabled: err = %v", err)
+	}
+}
+
+func TestWarehouseRebuildWithdrawn(t *testing.T) {
+	svc, repo, _, consents := newTestWarehouseServiceWithConsents()
+	ctx := context.Background()
+	days := []time.Time{
+		time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, day := range days {
+		if _, err := svc.Run(ctx, day, uuid.Nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing withdrawn: nothing rewritten.
+	if n, err := svc.RebuildWithdrawn(ctx); err != nil || n != 0 {
+		t.Fatalf("no withdrawals: n = %d, err = %v", n, err)
+	}
+
+	// A family that narrowed its consent to drop care logs only affects
+	// entry_counts; every published day of it is rewritten.
+	withdrawal := repository.ResearchConsent{ID: uuid.New(), WithdrawnScopes: []string{repository.ResearchScopeCareLogs}}
+	consents.pending = []repository.ResearchConsent{withdrawal}
+	before := map[string]uuid.UUID{}
+	for k, e := range repo.exports {
+		before[k] = e.ID
+	}
+	n, err := svc.RebuildWithdrawn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(days) {
+		t.Errorf("rebuilt %d files, want %d", n, len(days))
+	}
+	for _, day := range days {
+		for _, dataset := range WarehouseDatasets {
+			key := warehouseKey(dataset, day)
+			changed := repo.exports[key].ID != before[key]
+			if changed != (dataset == WarehouseEntryCounts) {
+				t.Errorf("%s: rewritten = %v", key, changed)
+			}
+		}
+	}
+	if len(consents.rebuilt) != 1 || consents.rebuilt[0] != withdrawal.ID {
+		t.Errorf("marked rebuilt = %v", consents.rebuilt)
+	}
+}
@@ -0,0 +1,59 @@
+-- Migration: 00044_ticket_tags_categories.sql
+-- Description: Category taxonomy + free-form tags on support tickets so
+-- product can see what's generating support load. Categories are a small
+-- curated tree (parent_id for one level of sub-categories); tags are
+-- free-form, normalized to lowercase slugs by the service layer.
+
+CREATE TABLE IF NOT EXISTS ticket_categories (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug        VARCHAR(60)  NOT NULL UNIQUE,
+    name        VARCHAR(120) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    parent_id   UUID REFERENCES ticket_categories(id) ON DELETE SET NULL,
+    sort_order  INT          NOT NULL DEFAULT 0,
+    is_active   BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_categories_parent ON ticket_categories(parent_id);
+
+ALTER TABLE support_tickets
+    ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES ticket_categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_support_tickets_category
+    ON support_tickets(category_id)
+    WHERE category_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS ticket_tags (
+    ticket_id  UUID        NOT NULL REFERENCES support_tickets(id) ON DELETE CASCADE,
+    tag        VARCHAR(40) NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ticket_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_tags_tag ON ticket_tags(tag);
+
+-- Seed the top-level taxonomy. Admins can add/rename/deactivate from the
+-- portal; slugs are stable so reports survive renames.
+INSERT INTO ticket_categories (slug, name, description, sort_order) VALUES
+    ('account',       'Account & Login',      'Sign-in, password reset, MFA, profile',          10),
+    ('billing',       'Billing',              'Subscriptions, payments, promo codes, refunds', 20),
+    ('logging',       'Logging & Data Entry', 'Creating, editing, or missing log entries',     30),
+    ('insights',      'Insights & Alerts',    'Correlations, alerts, AI insights',             40),
+    ('reports',       'Reports',              'PDF reports, sharing, scheduled reports',       50),
+    ('notifications', 'Notifications',        'Push, email, reminders',                        60),
+    ('family',        'Family & Care Team',   'Invitations, roles, multiple caregivers',       70),
+    ('performance',   'Performance',          'Slowness, crashes, sync issues',                80),
+    ('other',         'Other',                'Anything that does not fit above',              90)
+ON CONFLICT (slug) DO NOTHING;
+
+COMMENT ON TABLE  ticket_categories IS 'Curated support ticket taxonomy. slug is stable; name is display-only.';
+COMMENT ON COLUMN support_tickets.category_id IS 'Single category per ticket, set by staff. NULL = uncategorized.';
+COMMENT ON TABLE  ticket_tags IS 'Free-form lowercase tags on support tickets (many per ticket).';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS ticket_tags;
+-- ALTER TABLE support_tickets DROP COLUMN IF EXISTS category_id;
+-- DROP TABLE IF EXISTS ticket_categories;
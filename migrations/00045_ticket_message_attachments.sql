@@ -0,0 +1,30 @@
+-- Migration: 00045_ticket_message_attachments.sql
+-- Description: Attachments can now hang off an individual ticket message
+-- (screenshot in a reply, log file in a follow-up) rather than only the
+-- ticket as a whole. Also records an optional server-generated thumbnail
+-- for image uploads and adds a 'log' kind for plain-text/JSON log files.
+
+ALTER TYPE attachment_kind ADD VALUE IF NOT EXISTS 'log';
+
+-- NULL = ticket-level attachment (legacy rows, or uploaded but not yet
+-- linked to the message being composed).
+ALTER TABLE ticket_attachments
+    ADD COLUMN IF NOT EXISTS message_id UUID REFERENCES ticket_messages(id) ON DELETE SET NULL;
+
+ALTER TABLE ticket_attachments ADD COLUMN IF NOT EXISTS thumbnail_path TEXT;
+ALTER TABLE ticket_attachments ADD COLUMN IF NOT EXISTS width  INT;
+ALTER TABLE ticket_attachments ADD COLUMN IF NOT EXISTS height INT;
+
+CREATE INDEX IF NOT EXISTS idx_ticket_attachments_message
+    ON ticket_attachments(message_id)
+    WHERE message_id IS NOT NULL;
+
+COMMENT ON COLUMN ticket_attachments.message_id IS 'Message this file was sent with. NULL = ticket-level / not yet linked.';
+COMMENT ON COLUMN ticket_attachments.thumbnail_path IS 'Driver-relative path of the JPEG thumbnail (images only). Same driver as storage_path.';
+COMMENT ON COLUMN ticket_attachments.kind IS 'image | video | recording (in-browser screen+mic) | log (text/JSON) | other';
+
+-- ROLLBACK:
+-- DROP INDEX IF EXISTS idx_ticket_attachments_message;
+-- ALTER TABLE ticket_attachments DROP COLUMN IF EXISTS height, DROP COLUMN IF EXISTS width,
+--     DROP COLUMN IF EXISTS thumbnail_path, DROP COLUMN IF EXISTS message_id;
+-- (enum values cannot be dropped; 'log' is left in place)
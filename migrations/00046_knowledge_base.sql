@@ -0,0 +1,58 @@
+-- Migration: 00046_knowledge_base.sql
+-- Description: Help center / knowledge base. Admin-authored markdown
+-- articles grouped into categories, with a draft → published → archived
+-- lifecycle. Published articles are served publicly (no auth) with
+-- Postgres full-text search, and are suggested to users when they open a
+-- support ticket whose subject/description matches.
+--
+-- KB content is product documentation, not PHI — lives in the main DB.
+
+CREATE TABLE IF NOT EXISTS kb_categories (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug        VARCHAR(80)  NOT NULL UNIQUE,
+    name        VARCHAR(120) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    sort_order  INT          NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS kb_articles (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug         VARCHAR(120) NOT NULL UNIQUE,
+    title        VARCHAR(200) NOT NULL,
+    summary      VARCHAR(500) NOT NULL DEFAULT '',
+    body_md      TEXT         NOT NULL DEFAULT '',
+    category_id  UUID REFERENCES kb_categories(id) ON DELETE SET NULL,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'draft'
+                 CHECK (status IN ('draft', 'published', 'archived')),
+    -- Extra match terms for ticket suggestions ("login", "2fa", "sync")
+    -- that may not appear verbatim in the body.
+    keywords     TEXT[]       NOT NULL DEFAULT '{}',
+    view_count   BIGINT       NOT NULL DEFAULT 0,
+    published_at TIMESTAMPTZ,
+    created_by   UUID,
+    updated_by   UUID,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')),   'A') ||
+        setweight(to_tsvector('english', coalesce(summary, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(body_md, '')), 'C')
+    ) STORED
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_articles_search   ON kb_articles USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_kb_articles_keywords ON kb_articles USING GIN (keywords);
+CREATE INDEX IF NOT EXISTS idx_kb_articles_category ON kb_articles(category_id);
+CREATE INDEX IF NOT EXISTS idx_kb_articles_published
+    ON kb_articles(published_at DESC)
+    WHERE status = 'published';
+
+COMMENT ON TABLE  kb_articles IS 'Help center articles (markdown). Only status=published rows are served publicly.';
+COMMENT ON COLUMN kb_articles.keywords IS 'Lowercase match terms used by the ticket "suggested articles" lookup.';
+COMMENT ON COLUMN kb_articles.view_count IS 'Incremented on each public article read.';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS kb_articles;
+-- DROP TABLE IF EXISTS kb_categories;
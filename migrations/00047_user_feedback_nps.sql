@@ -0,0 +1,38 @@
+-- Migration: 00047_user_feedback_nps.sql
+-- Description: In-app NPS scores and free-text feedback. Each row
+-- snapshots the user's plan and tenure at submission time so trend
+-- segmentation stays stable when a family later upgrades or churns.
+-- Dismissals of the NPS prompt are stored too (score NULL) — they count
+-- toward the quarterly ask throttle but not toward the score.
+
+CREATE TABLE IF NOT EXISTS user_feedback (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID         NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    family_id   UUID         REFERENCES families(id) ON DELETE SET NULL,
+    kind        VARCHAR(20)  NOT NULL
+                CHECK (kind IN ('nps', 'nps_dismissed', 'feedback')),
+    score       SMALLINT     CHECK (score BETWEEN 0 AND 10),
+    comment     TEXT         NOT NULL DEFAULT '',
+    context     VARCHAR(100) NOT NULL DEFAULT '',
+    plan_name   VARCHAR(100) NOT NULL DEFAULT 'none',
+    tenure_days INT          NOT NULL DEFAULT 0,
+    platform    VARCHAR(20)  NOT NULL DEFAULT '',
+    app_version VARCHAR(40)  NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CHECK ((kind = 'nps') = (score IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_feedback_user_kind
+    ON user_feedback(user_id, kind, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_feedback_kind_created
+    ON user_feedback(kind, created_at DESC);
+
+COMMENT ON TABLE user_feedback IS
+    'In-app NPS responses, NPS prompt dismissals, and free-text feedback';
+COMMENT ON COLUMN user_feedback.plan_name IS
+    'Subscription plan name at submission time; ''none'' when the family had no subscription';
+COMMENT ON COLUMN user_feedback.tenure_days IS
+    'Days since the app user signed up, at submission time';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS user_feedback;
@@ -0,0 +1,17 @@
+-- Migration: 00048_brand_qr_target.sql
+-- Description: Where QR codes on generated marketing materials point.
+-- qr_target_url falls back to website_url when NULL; every embedded code
+-- gets UTM parameters appended so scans are attributable per placement.
+
+ALTER TABLE brand_config
+    ADD COLUMN IF NOT EXISTS qr_target_url VARCHAR(500),
+    ADD COLUMN IF NOT EXISTS qr_utm_campaign VARCHAR(100);
+
+COMMENT ON COLUMN brand_config.qr_target_url IS
+    'Landing URL encoded in generated QR codes; NULL means use website_url';
+COMMENT ON COLUMN brand_config.qr_utm_campaign IS
+    'utm_campaign value for generated QR codes; NULL means "brand_materials"';
+
+-- ROLLBACK:
+-- ALTER TABLE brand_config DROP COLUMN IF EXISTS qr_utm_campaign;
+-- ALTER TABLE brand_config DROP COLUMN IF EXISTS qr_target_url;
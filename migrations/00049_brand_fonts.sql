@@ -0,0 +1,32 @@
+-- Migration: 00049_brand_fonts.sql
+-- Description: Licensed brand font files uploaded by admins. The bytes live
+-- in BlobStorage (local disk or S3); this table maps a family + style to the
+-- stored file. Generated PDFs/PNGs use these when brand_config.heading_font
+-- or body_font names a family that has been uploaded.
+
+CREATE TABLE IF NOT EXISTS brand_fonts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family VARCHAR(100) NOT NULL,
+    style VARCHAR(20) NOT NULL CHECK (style IN ('regular', 'bold', 'italic', 'bold_italic')),
+    format VARCHAR(10) NOT NULL CHECK (format IN ('ttf', 'otf')),
+    original_filename VARCHAR(255) NOT NULL,
+    storage_driver VARCHAR(20) NOT NULL,
+    storage_path VARCHAR(500) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    license_note TEXT,
+    uploaded_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One file per family/style; re-uploading replaces it.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_brand_fonts_family_style
+    ON brand_fonts (LOWER(family), style);
+
+COMMENT ON TABLE brand_fonts IS
+    'Uploaded brand font files (TrueType outlines) embedded in generated marketing materials';
+COMMENT ON COLUMN brand_fonts.license_note IS
+    'Who licensed the font and under what terms; shown next to the upload';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS brand_fonts;
@@ -0,0 +1,72 @@
+-- Migration: 00050_marketing_campaigns.sql
+-- Description: Marketing campaigns, their recorded spend, and first-touch
+-- UTM attribution captured at signup. A campaign is matched to signups by
+-- its utm_campaign key and to promo codes by promo_codes.campaign_name
+-- (case-insensitive), so a campaign created after the fact still picks up
+-- earlier signups and redemptions.
+
+CREATE TABLE IF NOT EXISTS marketing_campaigns (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         VARCHAR(100) NOT NULL,
+    utm_campaign VARCHAR(100) NOT NULL,
+    channel      VARCHAR(50)  NOT NULL DEFAULT '',
+    starts_on    DATE,
+    ends_on      DATE,
+    notes        TEXT         NOT NULL DEFAULT '',
+    is_archived  BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_by   UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CHECK (utm_campaign = LOWER(utm_campaign)),
+    CHECK (ends_on IS NULL OR starts_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_marketing_campaigns_utm
+    ON marketing_campaigns(utm_campaign);
+
+CREATE TABLE IF NOT EXISTS campaign_spend (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    campaign_id  UUID        NOT NULL REFERENCES marketing_campaigns(id) ON DELETE CASCADE,
+    spent_on     DATE        NOT NULL,
+    amount_cents BIGINT      NOT NULL CHECK (amount_cents > 0),
+    note         TEXT        NOT NULL DEFAULT '',
+    recorded_by  UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_spend_campaign
+    ON campaign_spend(campaign_id, spent_on);
+
+CREATE TABLE IF NOT EXISTS signup_attributions (
+    user_id      UUID PRIMARY KEY REFERENCES app_users(id) ON DELETE CASCADE,
+    utm_source   VARCHAR(100) NOT NULL DEFAULT '',
+    utm_medium   VARCHAR(100) NOT NULL DEFAULT '',
+    utm_campaign VARCHAR(100) NOT NULL DEFAULT '',
+    utm_content  VARCHAR(100) NOT NULL DEFAULT '',
+    utm_term     VARCHAR(100) NOT NULL DEFAULT '',
+    referrer     VARCHAR(500) NOT NULL DEFAULT '',
+    landing_path VARCHAR(500) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_signup_attributions_campaign
+    ON signup_attributions(utm_campaign, created_at);
+
+-- Promo → campaign joins go through LOWER(campaign_name).
+CREATE INDEX IF NOT EXISTS idx_promo_codes_campaign_lower
+    ON promo_codes(LOWER(campaign_name));
+
+COMMENT ON TABLE marketing_campaigns IS
+    'Marketing campaigns tracked for attribution and ROI reporting';
+COMMENT ON COLUMN marketing_campaigns.utm_campaign IS
+    'Lower-cased utm_campaign value; also matched against promo_codes.campaign_name';
+COMMENT ON TABLE campaign_spend IS
+    'Manually recorded campaign spend, one row per spend entry';
+COMMENT ON TABLE signup_attributions IS
+    'First-touch UTM parameters captured when an app user registered';
+
+-- ROLLBACK:
+-- DROP INDEX IF EXISTS idx_promo_codes_campaign_lower;
+-- DROP TABLE IF EXISTS signup_attributions;
+-- DROP TABLE IF EXISTS campaign_spend;
+-- DROP TABLE IF EXISTS marketing_campaigns;
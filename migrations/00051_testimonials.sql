@@ -0,0 +1,64 @@
+-- Migration: 00051_testimonials.sql
+-- Description: Testimonials and case studies for marketing. Each entry
+-- records the author's consent (how it was obtained and which channels it
+-- covers) and moves through draft → pending_review → approved. Only
+-- approved entries with granted consent for a channel are ever shown on
+-- it: 'web' gates the public API, 'print' brochures, 'social' graphics.
+
+CREATE TABLE IF NOT EXISTS testimonials (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind                VARCHAR(20)  NOT NULL DEFAULT 'testimonial'
+                        CHECK (kind IN ('testimonial', 'case_study')),
+    quote               TEXT         NOT NULL,
+    title               VARCHAR(200) NOT NULL DEFAULT '',
+    body                TEXT         NOT NULL DEFAULT '',
+    author_name         VARCHAR(100) NOT NULL,
+    author_descriptor   VARCHAR(150) NOT NULL DEFAULT '',
+    anonymous           BOOLEAN      NOT NULL DEFAULT FALSE,
+    app_user_id         UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    tags                TEXT[]       NOT NULL DEFAULT '{}',
+
+    -- Consent
+    consent_status      VARCHAR(20)  NOT NULL DEFAULT 'pending'
+                        CHECK (consent_status IN ('pending', 'granted', 'declined', 'revoked')),
+    consent_method      VARCHAR(30)  NOT NULL DEFAULT '',
+    consent_channels    TEXT[]       NOT NULL DEFAULT '{}',
+    consent_recorded_at TIMESTAMPTZ,
+    consent_expires_on  DATE,
+    consent_note        TEXT         NOT NULL DEFAULT '',
+
+    -- Review
+    status              VARCHAR(20)  NOT NULL DEFAULT 'draft'
+                        CHECK (status IN ('draft', 'pending_review', 'approved', 'rejected', 'archived')),
+    review_note         TEXT         NOT NULL DEFAULT '',
+    reviewed_by         UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    reviewed_at         TIMESTAMPTZ,
+
+    is_featured         BOOLEAN      NOT NULL DEFAULT FALSE,
+    featured_rank       INT          NOT NULL DEFAULT 0,
+
+    created_by          UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+
+    -- Approval requires consent on file.
+    CHECK (status <> 'approved' OR consent_status = 'granted'),
+    CHECK (NOT is_featured OR status = 'approved')
+);
+
+CREATE INDEX IF NOT EXISTS idx_testimonials_status
+    ON testimonials(status, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_testimonials_featured
+    ON testimonials(featured_rank, updated_at DESC) WHERE is_featured;
+CREATE INDEX IF NOT EXISTS idx_testimonials_tags
+    ON testimonials USING GIN (tags);
+
+COMMENT ON TABLE testimonials IS
+    'Marketing testimonials and case studies with consent tracking and approval state';
+COMMENT ON COLUMN testimonials.consent_channels IS
+    'Channels the author agreed to: web, print, social';
+COMMENT ON COLUMN testimonials.anonymous IS
+    'Show a generic attribution instead of author_name on every channel';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS testimonials;
@@ -0,0 +1,47 @@
+-- Migration: 00052_file_transfers.sql
+-- Description: Admin file transfer utility, rebuilt from the unauthenticated
+-- /filextfer dev handlers. Files live in BlobStorage; every file has an
+-- expiry after which the sweeper deletes it, and the total stored bytes are
+-- capped by config. A file can optionally be shared through an unguessable
+-- token link, itself optionally password-protected (bcrypt) and expiring
+-- no later than the file.
+
+CREATE TABLE IF NOT EXISTS file_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT 'application/octet-stream',
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    sha256 CHAR(64) NOT NULL,
+    storage_driver VARCHAR(20) NOT NULL,
+    storage_path VARCHAR(500) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    uploaded_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+
+    -- Share link
+    share_token VARCHAR(64),
+    share_password_hash TEXT,
+    share_expires_at TIMESTAMPTZ,
+    share_created_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+
+    download_count INT NOT NULL DEFAULT 0,
+    last_downloaded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (share_token IS NULL OR share_expires_at IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_file_transfers_share_token
+    ON file_transfers (share_token) WHERE share_token IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_file_transfers_expires
+    ON file_transfers (expires_at);
+
+COMMENT ON TABLE file_transfers IS
+    'Admin file drop: expiring files with optional password-protected share links';
+COMMENT ON COLUMN file_transfers.share_token IS
+    'Random URL token for /filextfer/s/{token}; NULL when not shared';
+COMMENT ON COLUMN file_transfers.share_password_hash IS
+    'bcrypt hash; NULL means the link alone grants access';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS file_transfers;
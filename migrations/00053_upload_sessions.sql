@@ -0,0 +1,54 @@
+-- Migration: 00053_upload_sessions.sql
+-- Description: Resumable (tus-style) uploads for large files. A session is
+-- created with the declared length; the client then PATCHes sequential
+-- chunks at the current offset, each stored as its own blob. The running
+-- SHA-256 state is persisted after every chunk so the whole-file digest is
+-- known the moment the last byte lands, without re-reading. When complete,
+-- the chunks are stitched together and handed to the sink named by
+-- `purpose` (e.g. file_transfer). Sessions that stop receiving chunks are
+-- garbage collected after expires_at.
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purpose VARCHAR(40) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT 'application/octet-stream',
+    total_bytes BIGINT NOT NULL CHECK (total_bytes > 0),
+    received_bytes BIGINT NOT NULL DEFAULT 0 CHECK (received_bytes >= 0 AND received_bytes <= total_bytes),
+    expected_sha256 CHAR(64),
+    hash_state BYTEA,
+    metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'uploading'
+        CHECK (status IN ('uploading', 'assembling', 'complete', 'failed')),
+    result_id UUID,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES admin_users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires
+    ON upload_sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS upload_chunks (
+    session_id UUID NOT NULL REFERENCES upload_sessions(id) ON DELETE CASCADE,
+    offset_bytes BIGINT NOT NULL CHECK (offset_bytes >= 0),
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    storage_path VARCHAR(500) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (session_id, offset_bytes)
+);
+
+COMMENT ON TABLE upload_sessions IS
+    'Resumable upload sessions; chunks in upload_chunks, finished files handed to the purpose sink';
+COMMENT ON COLUMN upload_sessions.hash_state IS
+    'Marshaled SHA-256 state over the first received_bytes bytes';
+COMMENT ON COLUMN upload_sessions.result_id IS
+    'ID of the record the sink created (e.g. file_transfers.id) once complete';
+COMMENT ON COLUMN upload_sessions.expires_at IS
+    'Pushed forward by each chunk; past it the sweeper deletes the session and its chunks';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS upload_chunks;
+-- DROP TABLE IF EXISTS upload_sessions;
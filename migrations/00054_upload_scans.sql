@@ -0,0 +1,57 @@
+-- Migration: 00054_upload_scans.sql
+-- Description: Antivirus scan log for uploaded files. Ticket attachments
+-- (media and logs), brand fonts, marketing screenshots and file transfers
+-- are streamed through ClamAV before they go live; every verdict is
+-- recorded here. Infected files never become attachments/transfers: the
+-- bytes are copied to quarantine storage and the row waits in the admin
+-- review queue until someone releases it (false positive — the same
+-- SHA-256 is then let through on re-upload) or deletes it.
+
+CREATE TABLE IF NOT EXISTS upload_scans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source VARCHAR(40) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    sha256 CHAR(64),
+    status VARCHAR(20) NOT NULL
+        CHECK (status IN ('clean', 'infected', 'error', 'skipped')),
+    signature VARCHAR(255) NOT NULL DEFAULT '',
+    engine VARCHAR(100) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    -- Uploader may be an app user (ticket attachments) or an admin, so no FK.
+    uploaded_by UUID,
+    quarantine_driver VARCHAR(20) NOT NULL DEFAULT '',
+    quarantine_path VARCHAR(500) NOT NULL DEFAULT '',
+    review_status VARCHAR(20) NOT NULL DEFAULT ''
+        CHECK (review_status IN ('', 'released', 'deleted')),
+    reviewed_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_scans_created
+    ON upload_scans (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_upload_scans_queue
+    ON upload_scans (created_at)
+    WHERE status = 'infected' AND review_status = '';
+
+CREATE INDEX IF NOT EXISTS idx_upload_scans_released_sha
+    ON upload_scans (sha256)
+    WHERE review_status = 'released';
+
+COMMENT ON TABLE upload_scans IS
+    'Antivirus verdict for every scanned upload; infected rows form the quarantine review queue';
+COMMENT ON COLUMN upload_scans.source IS
+    'ticket_attachment, brand_font, marketing_screenshot or file_transfer';
+COMMENT ON COLUMN upload_scans.status IS
+    'skipped = over the scan size limit; error = scanner unreachable or failed (upload allowed unless fail-closed)';
+COMMENT ON COLUMN upload_scans.quarantine_path IS
+    'Copy of an infected upload in quarantine storage; removed once reviewed';
+COMMENT ON COLUMN upload_scans.review_status IS
+    'released = false positive, identical bytes may be uploaded again; deleted = confirmed and purged';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS upload_scans;
@@ -0,0 +1,69 @@
+-- Migration: 00055_db_backups.sql
+-- Description: Backup tracking and restore drills. db_backups records RDS
+-- snapshots (manual ones triggered from the admin portal and the automated
+-- ones RDS takes on its own, imported by the sync job) and the nightly
+-- logical dumps of non-PHI configuration tables written to S3.
+-- restore_drills records each run of cmd/restore-drill, which restores the
+-- latest snapshot into a scratch instance and runs sanity queries against
+-- it; only counts and pass/fail results are stored, never row data.
+
+CREATE TABLE IF NOT EXISTS db_backups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(20) NOT NULL
+        CHECK (kind IN ('snapshot', 'config_dump')),
+    -- Snapshot identifier, or the storage path of a dump.
+    identifier VARCHAR(500) NOT NULL,
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    snapshot_type VARCHAR(20) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL
+        CHECK (status IN ('creating', 'available', 'failed', 'deleted')),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    tables TEXT[] NOT NULL DEFAULT '{}',
+    error TEXT NOT NULL DEFAULT '',
+    -- NULL = started by the scheduler or picked up by the snapshot sync.
+    triggered_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    UNIQUE (kind, identifier)
+);
+
+CREATE INDEX IF NOT EXISTS idx_db_backups_kind_started
+    ON db_backups (kind, started_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_db_backups_pending
+    ON db_backups (started_at)
+    WHERE status = 'creating';
+
+CREATE TABLE IF NOT EXISTS restore_drills (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    snapshot_identifier VARCHAR(255) NOT NULL,
+    snapshot_created_at TIMESTAMPTZ,
+    scratch_instance VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL
+        CHECK (status IN ('running', 'passed', 'failed')),
+    checks JSONB NOT NULL DEFAULT '[]',
+    error TEXT NOT NULL DEFAULT '',
+    restore_seconds INT,
+    -- Free text: who ran the drill (OS user / host) since it runs from a shell.
+    run_by VARCHAR(255) NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_restore_drills_started
+    ON restore_drills (started_at DESC);
+
+COMMENT ON TABLE db_backups IS
+    'RDS snapshots and logical config-table dumps, with their status';
+COMMENT ON COLUMN db_backups.snapshot_type IS
+    'RDS SnapshotType for snapshots (manual or automated); empty for dumps';
+COMMENT ON COLUMN db_backups.tables IS
+    'Tables included in a config_dump; only non-PHI configuration tables are ever dumped';
+COMMENT ON TABLE restore_drills IS
+    'Runs of cmd/restore-drill: restore the latest snapshot into a scratch instance and sanity-check it';
+COMMENT ON COLUMN restore_drills.checks IS
+    'Array of {name, ok, detail}; details hold counts only, never row data';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS restore_drills;
+-- DROP TABLE IF EXISTS db_backups;
@@ -0,0 +1,32 @@
+-- Migration: 00056_aws_costs.sql
+-- Description: AWS cost history for the cost panel on the infrastructure
+-- page. aws_daily_costs holds Cost Explorer's daily unblended cost per
+-- service, refreshed by the daily sync (recent days are re-fetched because
+-- AWS keeps revising them until billing settles). aws_cost_active_users
+-- records the 30-day active app user count on each sync day so cost per
+-- active user can be charted for past months.
+
+CREATE TABLE IF NOT EXISTS aws_daily_costs (
+    day DATE NOT NULL,
+    service VARCHAR(255) NOT NULL,
+    amount_usd NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    -- TRUE while Cost Explorer still reports the day as estimated.
+    estimated BOOLEAN NOT NULL DEFAULT FALSE,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (day, service)
+);
+
+CREATE TABLE IF NOT EXISTS aws_cost_active_users (
+    day DATE PRIMARY KEY,
+    active_users_30d INT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE aws_daily_costs IS
+    'Daily AWS unblended cost per service from Cost Explorer';
+COMMENT ON TABLE aws_cost_active_users IS
+    'Distinct app users with a session seen in the 30 days up to day; denominator for cost per active user';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS aws_cost_active_users;
+-- DROP TABLE IF EXISTS aws_daily_costs;
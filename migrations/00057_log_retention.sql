@@ -0,0 +1,48 @@
+-- Migration: 00057_log_retention.sql
+-- Description: Retention and hourly rollups for response_time_logs and
+-- error_logs. The log retention job (hourly) rolls each completed hour of
+-- raw rows into response_time_hourly / error_log_hourly, then prunes raw
+-- rows and rollups past the windows in the log_retention system setting.
+-- The dashboard's 24h figures read the rollups plus the raw rows of the
+-- hour(s) not yet rolled up, instead of scanning a day of raw rows.
+--
+-- Raw rows are only pruned once their hour has been rolled up, so the
+-- rollups stay complete even if the job was down for a while.
+
+CREATE TABLE IF NOT EXISTS response_time_hourly (
+    hour TIMESTAMPTZ NOT NULL,
+    -- Path with UUID and numeric segments collapsed to {id} / {n} so one
+    -- endpoint is one row per hour.
+    path VARCHAR(500) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    -- Responses with status >= 500.
+    error_count BIGINT NOT NULL DEFAULT 0,
+    total_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (hour, path, method)
+);
+
+CREATE TABLE IF NOT EXISTS error_log_hourly (
+    hour TIMESTAMPTZ NOT NULL,
+    error_type VARCHAR(50) NOT NULL,
+    status_code INT NOT NULL,
+    error_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (hour, error_type, status_code)
+);
+
+INSERT INTO system_settings (key, value, description) VALUES
+    ('log_retention',
+     '{"response_time_days": 3, "error_log_days": 90, "rollup_days": 395}',
+     'Days to keep raw response_time_logs, raw error_logs, and their hourly rollups')
+ON CONFLICT (key) DO NOTHING;
+
+COMMENT ON TABLE response_time_hourly IS
+    'Hourly request count / latency per endpoint, rolled up from response_time_logs';
+COMMENT ON TABLE error_log_hourly IS
+    'Hourly error count per type and status, rolled up from error_logs';
+
+-- ROLLBACK:
+-- DELETE FROM system_settings WHERE key = 'log_retention';
+-- DROP TABLE IF EXISTS error_log_hourly;
+-- DROP TABLE IF EXISTS response_time_hourly;
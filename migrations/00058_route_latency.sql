@@ -0,0 +1,33 @@
+-- Migration: 00058_route_latency.sql
+-- Description: Per-route latency for the slow-route report. Requests are
+-- now logged with the chi route template (/api/children/{id}) instead of
+-- only the raw path, and the hourly rollup keeps one row per route, method
+-- and status class with a latency histogram, so p50/p95/p99 can be
+-- estimated over any window by merging hours.
+--
+-- Rows logged before this migration have no route; the rollup falls back
+-- to their path with ids collapsed, as before.
+
+ALTER TABLE response_time_logs ADD COLUMN IF NOT EXISTS route VARCHAR(300);
+
+-- 2 = 2xx, 4 = 4xx, ... ; 0 for rollups written before this migration.
+ALTER TABLE response_time_hourly ADD COLUMN IF NOT EXISTS status_class SMALLINT NOT NULL DEFAULT 0;
+-- Bucket index (width_bucket over the bounds in
+-- repository.LatencyBucketBoundsMs) -> request count.
+ALTER TABLE response_time_hourly ADD COLUMN IF NOT EXISTS histogram JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE response_time_hourly DROP CONSTRAINT IF EXISTS response_time_hourly_pkey;
+ALTER TABLE response_time_hourly ADD PRIMARY KEY (hour, path, method, status_class);
+
+COMMENT ON COLUMN response_time_logs.route IS
+    'chi route template the request matched, or "unmatched"';
+COMMENT ON COLUMN response_time_hourly.path IS
+    'Route template (or id-collapsed path for rows logged without one)';
+
+-- ROLLBACK:
+-- ALTER TABLE response_time_hourly DROP CONSTRAINT IF EXISTS response_time_hourly_pkey;
+-- DELETE FROM response_time_hourly WHERE status_class <> 0;
+-- ALTER TABLE response_time_hourly ADD PRIMARY KEY (hour, path, method);
+-- ALTER TABLE response_time_hourly DROP COLUMN IF EXISTS histogram;
+-- ALTER TABLE response_time_hourly DROP COLUMN IF EXISTS status_class;
+-- ALTER TABLE response_time_logs DROP COLUMN IF EXISTS route;
@@ -0,0 +1,45 @@
+-- Migration: 00059_query_stats.sql
+-- Description: Slow query history from pg_stat_statements. The query stats
+-- collector snapshots the top statements by total execution time every
+-- few minutes; each row stores the cumulative counters pg_stat_statements
+-- reported and the delta since the statement's previous snapshot, so
+-- totals over any window are a SUM of deltas and survive a stats reset.
+--
+-- pg_stat_statements must also be in shared_preload_libraries (the RDS
+-- parameter group). Creating the extension needs rds_superuser; when the
+-- migration user can't, the notice below is logged and the collector
+-- reports the panel as unavailable until someone creates it by hand.
+
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_stat_statements;
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'pg_stat_statements not created: %', SQLERRM;
+END $$;
+
+CREATE TABLE IF NOT EXISTS db_query_stats (
+    id BIGSERIAL PRIMARY KEY,
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    queryid BIGINT NOT NULL,
+    -- Normalized statement text, truncated to 4000 characters.
+    query TEXT NOT NULL,
+    -- Repository method from the statement's /* method=... */ comment.
+    method VARCHAR(200),
+    calls BIGINT NOT NULL,
+    total_exec_ms DOUBLE PRECISION NOT NULL,
+    rows BIGINT NOT NULL,
+    shared_blks_hit BIGINT NOT NULL DEFAULT 0,
+    shared_blks_read BIGINT NOT NULL DEFAULT 0,
+    delta_calls BIGINT NOT NULL DEFAULT 0,
+    delta_exec_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    delta_rows BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_db_query_stats_captured ON db_query_stats(captured_at);
+CREATE INDEX IF NOT EXISTS idx_db_query_stats_queryid ON db_query_stats(queryid, captured_at DESC);
+
+COMMENT ON TABLE db_query_stats IS
+    'pg_stat_statements snapshots (top statements by total time) for the slow query panel';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS db_query_stats;
@@ -0,0 +1,34 @@
+-- Migration: 00060_image_variants.sql
+-- Description: Responsive image variants. Each upload through the image
+-- pipeline (child photos, testimonial headshots, marketing images) becomes
+-- an image_sets row for its owner plus one image_variants row per
+-- format/width generated from it. The original upload is not kept: the
+-- variants are re-encoded from decoded pixels, so EXIF (including GPS)
+-- never reaches storage.
+
+CREATE TABLE IF NOT EXISTS image_sets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- child_photo, testimonial_headshot, marketing_asset
+    owner_type VARCHAR(40) NOT NULL,
+    owner_id UUID NOT NULL,
+    source_width INTEGER NOT NULL,
+    source_height INTEGER NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_sets_owner ON image_sets(owner_type, owner_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS image_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    image_set_id UUID NOT NULL REFERENCES image_sets(id) ON DELETE CASCADE,
+    -- avif, webp or jpeg
+    format VARCHAR(10) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    storage_path TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (image_set_id, format, width)
+);
@@ -0,0 +1,57 @@
+-- Migration: 00061_log_change_stamps.sql
+-- Description: Change stamps for conditional GETs on the daily log page.
+-- Most log tables have no updated_at and deletes are hard, so nothing in
+-- the rows themselves says whether a day changed. A row trigger on every
+-- log table bumps log_change_stamps for the (child, log_date) it touched
+-- — both dates when an update moves a log to another day — and the API
+-- derives the day's ETag from the stamp instead of re-reading 12 tables.
+--
+-- Days with no stamp yet (logs written before this migration) read as
+-- version 0, which stays valid until the next write to that day.
+--
+-- child_id deliberately has no foreign key: deleting a child cascades to
+-- its logs, and the stamp insert those deletes trigger would then fail
+-- the FK check against the child being removed.
+
+CREATE TABLE IF NOT EXISTS log_change_stamps (
+    child_id UUID NOT NULL,
+    log_date DATE NOT NULL,
+    version BIGINT NOT NULL DEFAULT 1,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (child_id, log_date)
+);
+
+CREATE OR REPLACE FUNCTION bump_log_change_stamp()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        INSERT INTO log_change_stamps (child_id, log_date)
+        VALUES (OLD.child_id, OLD.log_date)
+        ON CONFLICT (child_id, log_date)
+        DO UPDATE SET version = log_change_stamps.version + 1, changed_at = NOW();
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.log_date IS DISTINCT FROM OLD.log_date) THEN
+        INSERT INTO log_change_stamps (child_id, log_date)
+        VALUES (NEW.child_id, NEW.log_date)
+        ON CONFLICT (child_id, log_date)
+        DO UPDATE SET version = log_change_stamps.version + 1, changed_at = NOW();
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'medication_logs', 'behavior_logs', 'bowel_logs', 'speech_logs',
+        'diet_logs', 'weight_logs', 'sleep_logs', 'sensory_logs',
+        'social_logs', 'therapy_logs', 'seizure_logs', 'health_event_logs'
+    ] LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS %I ON %I', t || '_change_stamp', t);
+        EXECUTE format(
+            'CREATE TRIGGER %I AFTER INSERT OR UPDATE OR DELETE ON %I FOR EACH ROW EXECUTE FUNCTION bump_log_change_stamp()',
+            t || '_change_stamp', t);
+    END LOOP;
+END $$;
@@ -0,0 +1,146 @@
+-- Migration: 00062_event_outbox.sql
+-- Description: Transactional outbox for domain events. Row triggers write
+-- an event into event_outbox in the same transaction as the change that
+-- caused it, so an event exists if and only if the change committed; the
+-- outbox relay (service.EventRelay) publishes pending rows to the stream
+-- (Kinesis or Kafka) and marks them published.
+--
+-- Payloads are versioned per event type (schema_version) and carry IDs,
+-- dates and states only — no names, notes or other free text. Schemas are
+-- documented in docs/DOMAIN-EVENTS.md; a breaking change to a payload bumps its
+-- version here.
+--
+-- Events:
+--   log.created          v1  any of the 12 log tables, AFTER INSERT
+--   subscription.changed v1  family_subscriptions, insert or a change to
+--                            plan, status, period end or cancellation
+--   ticket.updated       v1  support_tickets, a change to status, priority,
+--                            assignee, type or category
+--
+-- support_tickets sits in the shared support DB when SUPPORT_DB_DSN points
+-- dev at prod (00027), so ticket events for both environments are written
+-- to, and relayed from, prod's outbox.
+
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    event_type VARCHAR(64) NOT NULL,
+    schema_version INT NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    family_id UUID,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_event_outbox_published_at ON event_outbox(published_at) WHERE published_at IS NOT NULL;
+
+-- log.created -------------------------------------------------------------
+
+CREATE OR REPLACE FUNCTION outbox_log_created()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_outbox (event_type, schema_version, aggregate_type, aggregate_id, family_id, payload)
+    SELECT 'log.created', 1, 'log', NEW.id, c.family_id,
+           jsonb_build_object(
+               'log_id', NEW.id,
+               'log_type', regexp_replace(TG_TABLE_NAME, '_logs$', ''),
+               'child_id', NEW.child_id,
+               'family_id', c.family_id,
+               'log_date', NEW.log_date,
+               'logged_by', NEW.logged_by,
+               'created_at', NEW.created_at)
+    FROM children c
+    WHERE c.id = NEW.child_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'medication_logs', 'behavior_logs', 'bowel_logs', 'speech_logs',
+        'diet_logs', 'weight_logs', 'sleep_logs', 'sensory_logs',
+        'social_logs', 'therapy_logs', 'seizure_logs', 'health_event_logs'
+    ] LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS %I ON %I', t || '_outbox', t);
+        EXECUTE format(
+            'CREATE TRIGGER %I AFTER INSERT ON %I FOR EACH ROW EXECUTE FUNCTION outbox_log_created()',
+            t || '_outbox', t);
+    END LOOP;
+END $$;
+
+-- subscription.changed ----------------------------------------------------
+
+CREATE OR REPLACE FUNCTION outbox_subscription_changed()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+       AND NEW.plan_id IS NOT DISTINCT FROM OLD.plan_id
+       AND NEW.status IS NOT DISTINCT FROM OLD.status
+       AND NEW.current_period_end IS NOT DISTINCT FROM OLD.current_period_end
+       AND NEW.cancel_at_period_end IS NOT DISTINCT FROM OLD.cancel_at_period_end THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO event_outbox (event_type, schema_version, aggregate_type, aggregate_id, family_id, payload)
+    VALUES ('subscription.changed', 1, 'subscription', NEW.id, NEW.family_id,
+            jsonb_build_object(
+                'subscription_id', NEW.id,
+                'family_id', NEW.family_id,
+                'plan_id', NEW.plan_id,
+                'status', NEW.status,
+                'previous_plan_id', CASE WHEN TG_OP = 'UPDATE' THEN OLD.plan_id END,
+                'previous_status', CASE WHEN TG_OP = 'UPDATE' THEN OLD.status END,
+                'current_period_end', NEW.current_period_end,
+                'cancel_at_period_end', NEW.cancel_at_period_end,
+                'promo_code_id', NEW.promo_code_id,
+                'updated_at', NEW.updated_at));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS family_subscriptions_outbox ON family_subscriptions;
+CREATE TRIGGER family_subscriptions_outbox
+    AFTER INSERT OR UPDATE ON family_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION outbox_subscription_changed();
+
+-- ticket.updated ----------------------------------------------------------
+
+CREATE OR REPLACE FUNCTION outbox_ticket_updated()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status IS NOT DISTINCT FROM OLD.status
+       AND NEW.priority IS NOT DISTINCT FROM OLD.priority
+       AND NEW.assigned_to IS NOT DISTINCT FROM OLD.assigned_to
+       AND NEW.type IS NOT DISTINCT FROM OLD.type
+       AND NEW.category_id IS NOT DISTINCT FROM OLD.category_id THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO event_outbox (event_type, schema_version, aggregate_type, aggregate_id, payload)
+    VALUES ('ticket.updated', 1, 'ticket', NEW.id,
+            jsonb_build_object(
+                'ticket_id', NEW.id,
+                'ticket_number', NEW.ticket_number,
+                'user_id', NEW.user_id,
+                'status', NEW.status,
+                'previous_status', OLD.status,
+                'priority', NEW.priority,
+                'type', NEW.type,
+                'category_id', NEW.category_id,
+                'assigned_to', NEW.assigned_to,
+                'resolved_at', NEW.resolved_at,
+                'updated_at', NEW.updated_at));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS support_tickets_outbox ON support_tickets;
+CREATE TRIGGER support_tickets_outbox
+    AFTER UPDATE ON support_tickets
+    FOR EACH ROW EXECUTE FUNCTION outbox_ticket_updated();
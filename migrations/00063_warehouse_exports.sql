@@ -0,0 +1,35 @@
+-- Migration: 00063_warehouse_exports.sql
+-- Description: History of the nightly analytics warehouse export. Each run
+-- writes one de-identified, aggregated Parquet file per dataset to S3 (see
+-- docs/ANALYTICS-EXPORT.md for the datasets and the anonymization steps);
+-- this table records what was written, where, and how many small cells
+-- were suppressed, for the admin Warehouse Exports panel. A rerun for the
+-- same dataset and day replaces the row (and the file).
+
+CREATE TABLE IF NOT EXISTS warehouse_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dataset VARCHAR(50) NOT NULL,
+    -- The day the data covers (retention_cohorts: the snapshot date).
+    period_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running'
+        CHECK (status IN ('running', 'succeeded', 'failed')),
+    row_count BIGINT NOT NULL DEFAULT 0,
+    suppressed_rows BIGINT NOT NULL DEFAULT 0,
+    storage_driver VARCHAR(20),
+    storage_path VARCHAR(500),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    -- NULL for the scheduled run; the admin who re-ran it otherwise.
+    triggered_by UUID,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    UNIQUE (dataset, period_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_exports_period ON warehouse_exports(period_date DESC);
+
+COMMENT ON TABLE warehouse_exports IS
+    'Nightly de-identified Parquet exports to S3 for research/marketing (one row per dataset per day)';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS warehouse_exports;
@@ -0,0 +1,33 @@
+-- Migration: 00064_family_engagement_scores.sql
+-- Description: Nightly per-family engagement score (0-100) for churn
+-- prediction. The score blends logging frequency, the logging trend,
+-- breadth of features used and recency of the last activity; the inputs
+-- are kept next to it so the churn-risk list can explain a low score.
+-- One row per family per day so a drop can be measured against an earlier
+-- score; rows older than 120 days are pruned by the nightly job.
+-- Counts only — nothing here identifies a child or carries log content.
+
+CREATE TABLE IF NOT EXISTS family_engagement_scores (
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    score_date DATE NOT NULL,
+    score SMALLINT NOT NULL CHECK (score BETWEEN 0 AND 100),
+    -- Days with at least one log entry in the 14 days ending score_date.
+    active_days_14d SMALLINT NOT NULL DEFAULT 0,
+    entries_14d INTEGER NOT NULL DEFAULT 0,
+    -- Entries in the 14 days before that, for the trend.
+    entries_prev_14d INTEGER NOT NULL DEFAULT 0,
+    -- Distinct log types plus chat/reports/correlations used in 30 days.
+    features_30d SMALLINT NOT NULL DEFAULT 0,
+    -- Last day with any activity in the 30-day window; NULL if none.
+    last_active_on DATE,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (family_id, score_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_family_engagement_scores_date ON family_engagement_scores(score_date);
+
+COMMENT ON TABLE family_engagement_scores IS
+    'Nightly per-family engagement score and its inputs (aggregates only, no PHI)';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS family_engagement_scores;
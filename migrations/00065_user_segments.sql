@@ -0,0 +1,26 @@
+-- Migration: 00065_user_segments.sql
+-- Description: Saved customer segments. A segment is a named set of
+-- filters over app users — plan, tenure, family engagement score, promo
+-- usage and locale — evaluated live whenever it's counted or resolved, so
+-- membership always reflects today's data. Senders (announcements, email
+-- digests, experiments) reference a segment by id instead of targeting
+-- every user.
+
+CREATE TABLE IF NOT EXISTS user_segments (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    -- repository.SegmentFilters as JSON; unset fields don't filter.
+    filters     JSONB        NOT NULL DEFAULT '{}',
+    created_by  UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_segments_name ON user_segments (LOWER(name));
+
+COMMENT ON TABLE user_segments IS
+    'Saved audience filters for targeted messaging; membership is computed live';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS user_segments;
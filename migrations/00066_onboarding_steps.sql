@@ -0,0 +1,33 @@
+-- Migration: 00066_onboarding_steps.sql
+-- Description: Server-tracked guided setup for new users. Each row records
+-- when a user resolved one setup step (verify_email, create_family,
+-- add_child, add_medication, first_log) — detected from their data by the
+-- onboarding job, or skipped by the user where the step is optional.
+-- onboarding_nudges records the one reminder push a stalled user gets per
+-- step, so nobody is nagged twice about the same thing.
+--
+-- No backfill: the hourly job reconciles every user who signed up in the
+-- last 90 days on its first pass, which is as far back as the admin funnel
+-- reports. Older accounts went through the app before this existed.
+
+CREATE TABLE IF NOT EXISTS user_onboarding_steps (
+    user_id      UUID        NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    step         VARCHAR(32) NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL,
+    skipped      BOOLEAN     NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (user_id, step)
+);
+
+CREATE TABLE IF NOT EXISTS onboarding_nudges (
+    user_id UUID        NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    step    VARCHAR(32) NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, step)
+);
+
+CREATE INDEX IF NOT EXISTS idx_app_users_created_at ON app_users (created_at);
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS onboarding_nudges;
+-- DROP TABLE IF EXISTS user_onboarding_steps;
+-- DROP INDEX IF EXISTS idx_app_users_created_at;
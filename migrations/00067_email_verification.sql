@@ -0,0 +1,34 @@
+-- Migration: 00067_email_verification.sql
+-- Description: Email verification for app users. A verification link is
+-- emailed on signup and whenever the address changes; each link is stored
+-- as a sha256 hash with the address it was sent to, so a link for an old
+-- address can't verify the new one. email_verify_due_at is the end of the
+-- grace period — once it passes, an unverified user loses the features
+-- that send mail to other people (invites, report sharing, scheduled
+-- reports) until they verify.
+--
+-- Backfill: every existing unverified account gets a fresh 7-day grace
+-- period from deploy, rather than being restricted immediately.
+
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID         NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    email      VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64)  NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ  NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user
+    ON email_verification_tokens (user_id, created_at DESC);
+
+ALTER TABLE app_users ADD COLUMN IF NOT EXISTS email_verify_due_at TIMESTAMPTZ;
+
+UPDATE app_users
+SET email_verify_due_at = NOW() + INTERVAL '7 days'
+WHERE email_verified_at IS NULL AND email_verify_due_at IS NULL AND deleted_at IS NULL;
+
+-- ROLLBACK:
+-- ALTER TABLE app_users DROP COLUMN IF EXISTS email_verify_due_at;
+-- DROP TABLE IF EXISTS email_verification_tokens;
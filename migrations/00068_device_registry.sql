@@ -0,0 +1,44 @@
+-- Migration: 00068_device_registry.sql
+-- Description: Device registry for push notifications. device_tokens
+-- learns which push provider issued the token (FCM, or raw APNs for iOS
+-- builds that don't go through Firebase), the app/OS version the device
+-- last registered with, and when it was last seen. Tokens the provider
+-- reports as invalid are deactivated with a reason; the nightly prune job
+-- deactivates devices not seen in 270 days (FCM's own staleness horizon)
+-- and deletes rows that have been inactive for 30 days.
+--
+-- A token identifies an install, not a user: when a second account signs
+-- in on the same phone the token moves to that account (see
+-- deviceTokenRepo.Upsert), so pushes never reach the previous user.
+
+ALTER TABLE device_tokens
+    ADD COLUMN IF NOT EXISTS provider           VARCHAR(10) NOT NULL DEFAULT 'fcm',
+    ADD COLUMN IF NOT EXISTS app_version        VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS os_version         VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS last_seen_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS last_push_at       TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS failure_count      INT         NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS deactivated_reason VARCHAR(32);
+
+ALTER TABLE device_tokens DROP CONSTRAINT IF EXISTS device_tokens_provider_check;
+ALTER TABLE device_tokens ADD CONSTRAINT device_tokens_provider_check CHECK (provider IN ('fcm', 'apns'));
+
+-- Existing rows were registered before we tracked activity.
+UPDATE device_tokens SET last_seen_at = updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_device_tokens_token ON device_tokens (token);
+CREATE INDEX IF NOT EXISTS idx_device_tokens_active_version
+    ON device_tokens (platform, app_version) WHERE active;
+
+-- ROLLBACK:
+-- DROP INDEX IF EXISTS idx_device_tokens_active_version;
+-- DROP INDEX IF EXISTS idx_device_tokens_token;
+-- ALTER TABLE device_tokens DROP CONSTRAINT IF EXISTS device_tokens_provider_check;
+-- ALTER TABLE device_tokens
+--     DROP COLUMN IF EXISTS deactivated_reason,
+--     DROP COLUMN IF EXISTS failure_count,
+--     DROP COLUMN IF EXISTS last_push_at,
+--     DROP COLUMN IF EXISTS last_seen_at,
+--     DROP COLUMN IF EXISTS os_version,
+--     DROP COLUMN IF EXISTS app_version,
+--     DROP COLUMN IF EXISTS provider;
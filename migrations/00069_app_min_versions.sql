@@ -0,0 +1,17 @@
+-- Migration: 00069_app_min_versions.sql
+-- Description: Minimum supported mobile app version per platform. Builds
+-- that send X-App-Version below their platform's min_version get 426
+-- Upgrade Required from /api (except /api/app/*), with the message and
+-- store link below; builds below latest_version are offered an update by
+-- GET /api/app/version-check. Edited on the admin settings page.
+
+INSERT INTO system_settings (key, value, description) VALUES
+    ('app_min_versions',
+     '{"ios": {"min_version": "1.0.0", "latest_version": "", "store_url": ""},
+       "android": {"min_version": "1.0.0", "latest_version": "", "store_url": ""},
+       "message": "This version of CareCompanion is no longer supported. Please update to the latest version to continue."}',
+     'Minimum and latest mobile app version per platform; older builds are asked to update')
+ON CONFLICT (key) DO NOTHING;
+
+-- ROLLBACK:
+-- DELETE FROM system_settings WHERE key = 'app_min_versions';
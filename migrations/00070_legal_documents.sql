@@ -0,0 +1,60 @@
+-- Migration: 00070_legal_documents.sql
+-- Description: Versioned Terms of Service / Privacy Policy and per-user
+-- acceptance records. Admins draft a new version, then publish it with an
+-- effective date (the notice period). From that date it's the version in
+-- effect for its kind, and if requires_acceptance is set, every app user
+-- must accept it — with the time, IP and user agent recorded — before the
+-- app lets them continue. Published versions are immutable.
+--
+-- The seeded 1.0 documents are the terms and policy already served from
+-- the static /terms and /privacy pages (body_md empty means "render the
+-- static page"). They don't require acceptance, so existing users aren't
+-- interrupted on deploy; new signups accept the versions in effect.
+
+CREATE TABLE IF NOT EXISTS legal_documents (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind                VARCHAR(20)  NOT NULL CHECK (kind IN ('terms', 'privacy')),
+    version             VARCHAR(20)  NOT NULL,
+    title               VARCHAR(200) NOT NULL,
+    -- What changed, shown on the re-acceptance screen.
+    summary             TEXT         NOT NULL DEFAULT '',
+    body_md             TEXT         NOT NULL DEFAULT '',
+    requires_acceptance BOOLEAN      NOT NULL DEFAULT TRUE,
+    published_at        TIMESTAMPTZ,
+    effective_at        TIMESTAMPTZ,
+    created_by          UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, version),
+    CHECK ((published_at IS NULL) = (effective_at IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_documents_effective
+    ON legal_documents (kind, effective_at DESC) WHERE published_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS legal_acceptances (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID        NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    document_id UUID        NOT NULL REFERENCES legal_documents(id) ON DELETE RESTRICT,
+    accepted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ip_address  INET,
+    user_agent  TEXT,
+    UNIQUE (user_id, document_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_acceptances_document
+    ON legal_acceptances (document_id, accepted_at);
+
+INSERT INTO legal_documents (kind, version, title, summary, requires_acceptance, published_at, effective_at) VALUES
+    ('terms',   '1.0', 'Terms of Service', 'Initial version.', FALSE, NOW(), NOW()),
+    ('privacy', '1.0', 'Privacy Policy',   'Initial version.', FALSE, NOW(), NOW())
+ON CONFLICT (kind, version) DO NOTHING;
+
+COMMENT ON TABLE legal_documents IS
+    'Versioned Terms of Service / Privacy Policy; the latest published version past its effective_at is in effect per kind';
+COMMENT ON TABLE legal_acceptances IS
+    'Which legal document versions each app user accepted, when, and from where';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS legal_acceptances;
+-- DROP TABLE IF EXISTS legal_documents;
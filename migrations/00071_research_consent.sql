@@ -0,0 +1,54 @@
+-- Migration: 00071_research_consent.sql
+-- Description: Family opt-in to contributing de-identified data to autism
+-- research. A parent grants consent for one or more scopes, each matching
+-- a warehouse export dataset; only families with an active consent
+-- covering a dataset's scope are counted in that dataset.
+--
+-- Each row is the consent artifact: the exact disclosure text shown, its
+-- version and SHA-256, the parent's typed signature, and where it was
+-- signed from. Rows are never updated except to record withdrawal. A
+-- change of scopes withdraws the active row and inserts a new one, so the
+-- history shows every consent the family gave.
+--
+-- Withdrawal is retroactive: withdrawn_scopes lists the scopes the family
+-- left, and the warehouse export rebuilds every published file for those
+-- datasets without them, then stamps rebuilt_at.
+
+CREATE TABLE IF NOT EXISTS research_consents (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id          UUID         NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    granted_by         UUID         REFERENCES app_users(id) ON DELETE SET NULL,
+    scopes             TEXT[]       NOT NULL
+        CHECK (cardinality(scopes) > 0
+               AND scopes <@ ARRAY['care_logs', 'feature_usage', 'retention']::TEXT[]),
+    disclosure_version INTEGER      NOT NULL,
+    disclosure_sha     TEXT         NOT NULL,
+    disclosure_text    TEXT         NOT NULL,
+    signature_name     VARCHAR(200) NOT NULL,
+    ip_address         INET,
+    user_agent         TEXT,
+    granted_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    withdrawn_at       TIMESTAMPTZ,
+    withdrawn_by       UUID         REFERENCES app_users(id) ON DELETE SET NULL,
+    withdrawal_reason  TEXT,
+    withdrawn_scopes   TEXT[]       NOT NULL DEFAULT '{}',
+    rebuilt_at         TIMESTAMPTZ
+);
+
+-- At most one active consent per family.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_research_consents_active
+    ON research_consents (family_id) WHERE withdrawn_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_research_consents_family
+    ON research_consents (family_id, granted_at DESC);
+
+-- Withdrawals whose exports haven't been rebuilt yet.
+CREATE INDEX IF NOT EXISTS idx_research_consents_rebuild
+    ON research_consents (withdrawn_at)
+    WHERE withdrawn_at IS NOT NULL AND rebuilt_at IS NULL AND cardinality(withdrawn_scopes) > 0;
+
+COMMENT ON TABLE research_consents IS
+    'Family consent to include de-identified data in research exports, per scope; one active row per family, history kept';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS research_consents;
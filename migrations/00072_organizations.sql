@@ -0,0 +1,58 @@
+-- Migration: 00072_organizations.sql
+-- Description: Organizations (clinics, practices) above families. An
+-- organization holds one subscription that covers every client family
+-- linked to it; each linked family uses one seat, up to seat_limit.
+--
+-- Org members are app users with an org role: admins manage members,
+-- seats and the join code; clinicians can view the client list and the
+-- aggregated report. Being an org member grants no access to a family's
+-- logs — that still needs a family membership the family grants.
+--
+-- A family joins by a parent entering the org's join code, and either side
+-- can end the link. The org only ever sees aggregated, non-PHI counts.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name                VARCHAR(200) NOT NULL,
+    contact_email       VARCHAR(255),
+    -- Covers linked families while 'active'; 'suspended' stops covering
+    -- them (they fall back to their own subscription, if any).
+    status              VARCHAR(20)  NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'suspended')),
+    seat_limit          INTEGER      NOT NULL DEFAULT 0 CHECK (seat_limit >= 0),
+    join_code           VARCHAR(20)  NOT NULL UNIQUE,
+    created_by          UUID,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID        NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id         UUID        NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    role            VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'clinician')),
+    added_by        UUID,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members (user_id);
+
+-- One organization per family; a row is one occupied seat.
+CREATE TABLE IF NOT EXISTS organization_families (
+    family_id       UUID        PRIMARY KEY REFERENCES families(id) ON DELETE CASCADE,
+    organization_id UUID        NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    added_by        UUID        REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_families_org ON organization_families (organization_id);
+
+COMMENT ON TABLE organizations IS
+    'Clinics/practices above families; one subscription covering linked client families, one seat each';
+COMMENT ON TABLE organization_families IS
+    'Family-to-organization link; each row occupies one of the organization''s seats';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS organization_families;
+-- DROP TABLE IF EXISTS organization_members;
+-- DROP TABLE IF EXISTS organizations;
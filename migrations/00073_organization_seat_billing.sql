@@ -0,0 +1,82 @@
+-- Migration: 00073_organization_seat_billing.sql
+-- Description: Seat-based plans for organizations. A seat-based plan is
+-- priced per seat; an organization's Stripe subscription has one item on
+-- that price whose quantity is the organization's seat_limit. Changing
+-- seats updates the quantity and Stripe prorates the difference.
+--
+-- Every seat change is recorded with the proration Stripe charged or
+-- credited, so support can answer "why was I billed this".
+
+ALTER TABLE subscription_plans
+    ADD COLUMN IF NOT EXISTS seat_based BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Clinic plan: $8/seat/month, one seat per client family.
+INSERT INTO subscription_plans (
+    id,
+    name,
+    description,
+    price_cents,
+    billing_interval,
+    features,
+    max_children,
+    max_family_members,
+    is_active,
+    seat_based,
+    created_at,
+    updated_at
+)
+SELECT
+    gen_random_uuid(),
+    'Clinic',
+    'For clinics and practices. One seat per client family, billed per seat each month.',
+    800,  -- $8.00 per seat
+    'monthly',
+    '{"unlimited_logs": true, "medication_tracking": true, "behavior_tracking": true, "insights": true, "chat": true, "alerts": true, "unlimited_children": true, "organization_reports": true}'::jsonb,
+    -1,
+    10,
+    TRUE,
+    TRUE,
+    NOW(),
+    NOW()
+WHERE NOT EXISTS (SELECT 1 FROM subscription_plans WHERE seat_based);
+
+ALTER TABLE organizations
+    ADD COLUMN IF NOT EXISTS plan_id                     UUID REFERENCES subscription_plans(id),
+    ADD COLUMN IF NOT EXISTS billing_status              VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS current_period_end          TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS stripe_customer_id          VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS stripe_subscription_id      VARCHAR(255) UNIQUE,
+    ADD COLUMN IF NOT EXISTS stripe_subscription_item_id VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS organization_seat_changes (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    from_seats      INTEGER     NOT NULL,
+    to_seats        INTEGER     NOT NULL,
+    -- Net of the proration lines Stripe invoiced for the change; negative
+    -- is a credit. 0 when the organization isn't billed through Stripe.
+    proration_cents BIGINT      NOT NULL DEFAULT 0,
+    -- org_admin (in the app), admin (support portal) or stripe (the
+    -- quantity was changed in the Stripe dashboard).
+    source          VARCHAR(20) NOT NULL CHECK (source IN ('org_admin', 'admin', 'stripe')),
+    changed_by      UUID,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_seat_changes_org
+    ON organization_seat_changes (organization_id, created_at DESC);
+
+COMMENT ON TABLE organization_seat_changes IS
+    'History of organization seat limit changes and the proration billed for each';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS organization_seat_changes;
+-- ALTER TABLE organizations
+--     DROP COLUMN IF EXISTS plan_id,
+--     DROP COLUMN IF EXISTS billing_status,
+--     DROP COLUMN IF EXISTS current_period_end,
+--     DROP COLUMN IF EXISTS stripe_customer_id,
+--     DROP COLUMN IF EXISTS stripe_subscription_id,
+--     DROP COLUMN IF EXISTS stripe_subscription_item_id;
+-- DELETE FROM subscription_plans WHERE seat_based;
+-- ALTER TABLE subscription_plans DROP COLUMN IF EXISTS seat_based;
@@ -0,0 +1,53 @@
+-- Migration: 00074_invoices.sql
+-- Description: Invoices for every successful payment, for reimbursement
+-- and FSA/HSA claims. A family invoice is issued from its payments row; an
+-- organization invoice from the Stripe invoice for its seat subscription
+-- (organization payments have no payments row).
+--
+-- The bill-to details and line items are snapshotted when the invoice is
+-- issued so the document doesn't change if the account does. The PDF is
+-- rendered into blob storage; support can regenerate it (refreshing the
+-- bill-to snapshot) and resend it by email.
+
+CREATE SEQUENCE IF NOT EXISTS invoice_number_seq START 1001;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- MCC-2026-001001: year of issue plus a global sequence, never reused.
+    number            VARCHAR(32)  NOT NULL UNIQUE,
+    payment_id        UUID UNIQUE REFERENCES payments(id) ON DELETE SET NULL,
+    stripe_invoice_id VARCHAR(100) UNIQUE,
+    family_id         UUID REFERENCES families(id) ON DELETE SET NULL,
+    organization_id   UUID REFERENCES organizations(id) ON DELETE SET NULL,
+    user_id           UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    bill_to_name      VARCHAR(255) NOT NULL DEFAULT '',
+    bill_to_email     VARCHAR(255) NOT NULL DEFAULT '',
+    -- [{"description": ..., "quantity": n, "amount_cents": n}]
+    lines             JSONB        NOT NULL DEFAULT '[]',
+    subtotal_cents    BIGINT       NOT NULL,
+    discount_cents    BIGINT       NOT NULL DEFAULT 0,
+    total_cents       BIGINT       NOT NULL,
+    currency          VARCHAR(3)   NOT NULL DEFAULT 'USD',
+    period_start      TIMESTAMPTZ,
+    period_end        TIMESTAMPTZ,
+    paid_at           TIMESTAMPTZ  NOT NULL,
+    issued_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    pdf_path          TEXT,
+    pdf_size          BIGINT,
+    pdf_version       INTEGER      NOT NULL DEFAULT 0,
+    pdf_generated_at  TIMESTAMPTZ,
+    sent_count        INTEGER      NOT NULL DEFAULT 0,
+    last_sent_at      TIMESTAMPTZ,
+    last_sent_to      VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_family ON invoices (family_id, paid_at DESC);
+CREATE INDEX IF NOT EXISTS idx_invoices_organization ON invoices (organization_id, paid_at DESC);
+CREATE INDEX IF NOT EXISTS idx_invoices_issued ON invoices (issued_at DESC);
+
+COMMENT ON TABLE invoices IS
+    'Invoices issued for family payments and organization seat subscriptions, with the rendered PDF';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS invoices;
+-- DROP SEQUENCE IF EXISTS invoice_number_seq;
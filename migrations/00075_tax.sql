@@ -0,0 +1,81 @@
+-- Migration: 00075_tax.sql
+-- Description: Sales tax / VAT. Tax is collected by Stripe (Stripe Tax, or
+-- tax rates on the Stripe account); every paid invoice's tax is recorded
+-- here per jurisdiction for the admin tax-collected report, and the total
+-- lands on the payment and our invoice.
+--
+-- tax_rates is the built-in rate table used to quote prices before
+-- checkout when TAX_PROVIDER=table, and to decide per country whether
+-- prices are shown tax-inclusive (required for consumers in the EU, UK,
+-- Australia and New Zealand, among others). region '' is the country-wide
+-- row; a region row (US state, Canadian province) overrides it.
+
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    country           CHAR(2)      NOT NULL,
+    region            VARCHAR(10)  NOT NULL DEFAULT '',
+    name              VARCHAR(100) NOT NULL,
+    tax_type          VARCHAR(30)  NOT NULL, -- vat, gst, hst, pst, sales_tax
+    rate_bps          INTEGER      NOT NULL CHECK (rate_bps >= 0 AND rate_bps <= 10000),
+    inclusive_pricing BOOLEAN      NOT NULL DEFAULT false,
+    is_active         BOOLEAN      NOT NULL DEFAULT true,
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    UNIQUE (country, region)
+);
+
+INSERT INTO tax_rates (country, region, name, tax_type, rate_bps, inclusive_pricing) VALUES
+    ('GB', '', 'VAT', 'vat', 2000, true),
+    ('IE', '', 'VAT', 'vat', 2300, true),
+    ('DE', '', 'MwSt.', 'vat', 1900, true),
+    ('FR', '', 'TVA', 'vat', 2000, true),
+    ('NL', '', 'btw', 'vat', 2100, true),
+    ('ES', '', 'IVA', 'vat', 2100, true),
+    ('IT', '', 'IVA', 'vat', 2200, true),
+    ('AU', '', 'GST', 'gst', 1000, true),
+    ('NZ', '', 'GST', 'gst', 1500, true),
+    ('CA', '', 'GST', 'gst', 500, false),
+    ('CA', 'ON', 'HST', 'hst', 1300, false),
+    ('US', '', 'Sales tax', 'sales_tax', 0, false)
+ON CONFLICT (country, region) DO NOTHING;
+
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS tax_amount_cents BIGINT  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive    BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS tax_cents     BIGINT  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT false;
+
+-- One row per jurisdiction per paid Stripe invoice. Family payments link
+-- to their payments row; organization seat invoices have none and carry
+-- the organization instead.
+CREATE TABLE IF NOT EXISTS payment_taxes (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stripe_invoice_id VARCHAR(100) NOT NULL,
+    payment_id        UUID REFERENCES payments(id) ON DELETE SET NULL,
+    organization_id   UUID REFERENCES organizations(id) ON DELETE SET NULL,
+    country           CHAR(2)      NOT NULL DEFAULT '',
+    region            VARCHAR(10)  NOT NULL DEFAULT '',
+    jurisdiction      VARCHAR(100) NOT NULL DEFAULT '',
+    tax_type          VARCHAR(30)  NOT NULL DEFAULT '',
+    rate_bps          INTEGER      NOT NULL DEFAULT 0,
+    inclusive         BOOLEAN      NOT NULL DEFAULT false,
+    taxable_cents     BIGINT       NOT NULL DEFAULT 0,
+    tax_cents         BIGINT       NOT NULL,
+    currency          VARCHAR(3)   NOT NULL DEFAULT 'USD',
+    provider          VARCHAR(20)  NOT NULL DEFAULT 'stripe',
+    collected_at      TIMESTAMPTZ  NOT NULL,
+    UNIQUE (stripe_invoice_id, country, region, jurisdiction, tax_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_taxes_collected ON payment_taxes (collected_at);
+CREATE INDEX IF NOT EXISTS idx_payment_taxes_payment ON payment_taxes (payment_id);
+
+COMMENT ON TABLE payment_taxes IS
+    'Tax collected per jurisdiction on each paid Stripe invoice, for the tax-collected report';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS payment_taxes;
+-- ALTER TABLE invoices DROP COLUMN IF EXISTS tax_cents, DROP COLUMN IF EXISTS tax_inclusive;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS tax_amount_cents, DROP COLUMN IF EXISTS tax_inclusive;
+-- DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,48 @@
+-- Migration: 00076_payment_disputes.sql
+-- Description: Payment disputes (chargebacks) ingested from Stripe's
+-- charge.dispute.* webhooks. Each row mirrors one Stripe dispute, linked to
+-- the payment it disputes (family payments) or the organization whose seat
+-- invoice was charged. Finance admins are emailed when a dispute opens and
+-- closes, and reminded as the evidence deadline approaches.
+--
+-- status is Stripe's dispute status: warning_needs_response,
+-- warning_under_review and warning_closed are inquiries (no funds moved);
+-- needs_response and under_review are open chargebacks; won and lost are
+-- final. balance_net_cents is the net of the dispute's balance transactions
+-- (funds withdrawn plus the dispute fee, less anything reinstated) and is
+-- what the financial overview subtracts from revenue once a dispute closes.
+
+CREATE TABLE IF NOT EXISTS payment_disputes (
+    id                       UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stripe_dispute_id        VARCHAR(100) NOT NULL UNIQUE,
+    stripe_charge_id         VARCHAR(100) NOT NULL DEFAULT '',
+    stripe_payment_intent_id VARCHAR(100) NOT NULL DEFAULT '',
+    stripe_invoice_id        VARCHAR(100) NOT NULL DEFAULT '',
+    payment_id               UUID REFERENCES payments(id) ON DELETE SET NULL,
+    organization_id          UUID REFERENCES organizations(id) ON DELETE SET NULL,
+    amount_cents             BIGINT       NOT NULL,
+    currency                 VARCHAR(3)   NOT NULL DEFAULT 'USD',
+    reason                   VARCHAR(50)  NOT NULL DEFAULT '',
+    status                   VARCHAR(30)  NOT NULL,
+    balance_net_cents        BIGINT       NOT NULL DEFAULT 0,
+    evidence_due_by          TIMESTAMPTZ,
+    evidence_submitted_at    TIMESTAMPTZ,
+    -- Smallest "days before the deadline" reminder already sent (7, 3, 1).
+    last_reminder_days       INTEGER,
+    notes                    TEXT         NOT NULL DEFAULT '',
+    opened_at                TIMESTAMPTZ  NOT NULL,
+    closed_at                TIMESTAMPTZ,
+    created_at               TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_disputes_open
+    ON payment_disputes (evidence_due_by) WHERE closed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_payment_disputes_opened ON payment_disputes (opened_at);
+CREATE INDEX IF NOT EXISTS idx_payment_disputes_payment ON payment_disputes (payment_id);
+
+COMMENT ON TABLE payment_disputes IS
+    'Stripe disputes/chargebacks with evidence deadlines and outcomes';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS payment_disputes;
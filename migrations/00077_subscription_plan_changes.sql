@@ -0,0 +1,28 @@
+-- Migration: 00077_subscription_plan_changes.sql
+-- Description: History of family plan switches. A row is written when a
+-- parent upgrades or downgrades mid-cycle in the app (Stripe prorates the
+-- difference and invoices it straight away) and when a plan is changed in
+-- the Stripe Dashboard and arrives through customer.subscription.updated.
+-- daily_revenue_snapshots.upgrades/downgrades are counted from here.
+--
+-- direction compares the plans' monthly-equivalent prices: a higher (or
+-- equal) price is an upgrade.
+
+CREATE TABLE IF NOT EXISTS subscription_plan_changes (
+    id                     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_subscription_id UUID        NOT NULL REFERENCES family_subscriptions(id) ON DELETE CASCADE,
+    family_id              UUID        NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    from_plan_id           UUID        NOT NULL REFERENCES subscription_plans(id),
+    to_plan_id             UUID        NOT NULL REFERENCES subscription_plans(id),
+    direction              VARCHAR(10) NOT NULL CHECK (direction IN ('upgrade', 'downgrade')),
+    proration_cents        BIGINT      NOT NULL DEFAULT 0, -- negative for a credit
+    source                 VARCHAR(20) NOT NULL DEFAULT 'app', -- app, stripe
+    changed_by             UUID,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_plan_changes_family ON subscription_plan_changes (family_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_plan_changes_created ON subscription_plan_changes (created_at);
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS subscription_plan_changes;
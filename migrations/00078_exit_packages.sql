@@ -0,0 +1,45 @@
+-- Migration: 00078_exit_packages.sql
+-- Description: Exit packages — a complete export of a family's logs and
+-- reports, built automatically when their subscription is cancelled and
+-- emailed to the family's parents. The ZIP stays downloadable for 90 days
+-- after it's built, then the file is deleted and the row kept as a record.
+--
+-- One package per cancellation: cancelled_at is the subscription's
+-- cancelled_at, so a cancel-at-period-end and the later final cancellation
+-- (or a redelivered webhook) land on the same row. build_after is when the
+-- family's access ends — immediately for an immediate cancellation, the
+-- period end otherwise — so the export includes everything logged until
+-- then. A package whose cancellation is withdrawn before then is marked
+-- 'withdrawn' and never built.
+
+CREATE TABLE IF NOT EXISTS exit_packages (
+    id                     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id              UUID        NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    family_subscription_id UUID        NOT NULL REFERENCES family_subscriptions(id) ON DELETE CASCADE,
+    source                 VARCHAR(10) NOT NULL CHECK (source IN ('stripe', 'admin')),
+    cancelled_at           TIMESTAMPTZ NOT NULL,
+    build_after            TIMESTAMPTZ NOT NULL,
+    status                 VARCHAR(10) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'ready', 'failed', 'withdrawn', 'expired')),
+    storage_path           TEXT,
+    size_bytes             BIGINT,
+    attempts               INTEGER     NOT NULL DEFAULT 0,
+    last_error             TEXT        NOT NULL DEFAULT '',
+    built_at               TIMESTAMPTZ,
+    expires_at             TIMESTAMPTZ,
+    emailed_at             TIMESTAMPTZ,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (family_subscription_id, cancelled_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exit_packages_pending
+    ON exit_packages (build_after) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_exit_packages_ready
+    ON exit_packages (expires_at) WHERE status = 'ready';
+CREATE INDEX IF NOT EXISTS idx_exit_packages_family ON exit_packages (family_id, created_at DESC);
+
+COMMENT ON TABLE exit_packages IS
+    'Data exports built on subscription cancellation, downloadable for 90 days';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS exit_packages;
@@ -0,0 +1,29 @@
+-- Migration: 00079_system_metrics_history.sql
+-- Description: Daily history of the admin "god view" metrics. Every metrics
+-- refresh upserts today's row with the point-in-time counts it just cached
+-- in system_metrics_cache, so the dashboard can chart trends instead of
+-- showing two numbers. The last refresh of a day wins.
+--
+-- entries and new_users are what was created on metric_date (UTC). They're
+-- recounted from created_at for today and yesterday on each refresh, so a
+-- day whose last refresh came early is completed the next day.
+-- Aggregates only, no PHI.
+
+CREATE TABLE IF NOT EXISTS system_metrics_history (
+    metric_date      DATE PRIMARY KEY,
+    total_users      INTEGER          NOT NULL DEFAULT 0,
+    active_users_24h INTEGER          NOT NULL DEFAULT 0,
+    active_users_7d  INTEGER          NOT NULL DEFAULT 0,
+    total_families   INTEGER          NOT NULL DEFAULT 0,
+    total_entries    INTEGER          NOT NULL DEFAULT 0,
+    entries          INTEGER          NOT NULL DEFAULT 0,
+    new_users        INTEGER          NOT NULL DEFAULT 0,
+    user_growth_pct  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    recorded_at      TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE system_metrics_history IS
+    'Daily snapshots of admin dashboard metrics. AGGREGATES ONLY, NO PHI.';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS system_metrics_history;
@@ -0,0 +1,39 @@
+-- Migration: 00080_log_usage.sql
+-- Description: Per-log-type usage over time, so product can see which log
+-- types families actually use (e.g. that sensory logging is barely touched)
+-- before investing in them.
+--
+-- log_usage_daily is recounted from each log table's created_at by the
+-- metrics refresh, for today and yesterday (UTC), like the entries column
+-- of system_metrics_history.
+--
+-- Log rows don't record which client created them, so log_entry_platforms
+-- is a counter bumped by the API when an entry is created. It starts at
+-- zero: history before this migration has no platform split.
+-- Aggregates only, no PHI.
+
+CREATE TABLE IF NOT EXISTS log_usage_daily (
+    usage_date  DATE        NOT NULL,
+    log_type    VARCHAR(32) NOT NULL,
+    entries     INTEGER     NOT NULL DEFAULT 0,
+    families    INTEGER     NOT NULL DEFAULT 0,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (usage_date, log_type)
+);
+
+CREATE TABLE IF NOT EXISTS log_entry_platforms (
+    usage_date DATE        NOT NULL,
+    log_type   VARCHAR(32) NOT NULL,
+    platform   VARCHAR(16) NOT NULL,
+    entries    INTEGER     NOT NULL DEFAULT 0,
+    PRIMARY KEY (usage_date, log_type, platform)
+);
+
+COMMENT ON TABLE log_usage_daily IS
+    'Entries created per log type per day. AGGREGATES ONLY, NO PHI.';
+COMMENT ON TABLE log_entry_platforms IS
+    'Entries created per log type and client platform per day. AGGREGATES ONLY, NO PHI.';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS log_entry_platforms;
+-- DROP TABLE IF EXISTS log_usage_daily;
@@ -0,0 +1,37 @@
+-- Migration: 00081_metric_anomalies.sql
+-- Description: Admin notifications, opened by background checks for admins
+-- to see and acknowledge on the dashboard. The first producer is the metric
+-- anomaly detector, which hourly compares the latest point of signups,
+-- revenue, server errors and response times against its recent history and
+-- opens a notification when it deviates sharply.
+--
+-- dedupe_key makes opening idempotent: the detector runs every hour but a
+-- given metric's bucket is only ever notified once.
+--
+-- Per-metric sensitivity lives in the metric_anomaly_detection system
+-- setting; until an admin saves one the detector uses built-in defaults.
+
+CREATE TABLE IF NOT EXISTS admin_notifications (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind            VARCHAR(50)  NOT NULL,
+    severity        VARCHAR(20)  NOT NULL DEFAULT 'warning'
+                    CHECK (severity IN ('info', 'warning', 'critical')),
+    title           VARCHAR(255) NOT NULL,
+    message         TEXT         NOT NULL DEFAULT '',
+    dedupe_key      VARCHAR(255) NOT NULL UNIQUE,
+    details         JSONB        NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    acknowledged_at TIMESTAMPTZ,
+    acknowledged_by UUID REFERENCES admin_users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_notifications_open
+    ON admin_notifications(created_at DESC) WHERE acknowledged_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_admin_notifications_created_at
+    ON admin_notifications(created_at DESC);
+
+COMMENT ON TABLE admin_notifications IS
+    'Notifications for admins raised by background checks (e.g. metric anomalies). NO PHI.';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS admin_notifications;
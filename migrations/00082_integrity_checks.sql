@@ -0,0 +1,50 @@
+-- Migration: 00082_integrity_checks.sql
+-- Description: Data integrity checker. cmd/integritycheck and the admin
+-- Integrity page scan for rows whose references the schema doesn't (or no
+-- longer) enforce — logs of missing or deactivated children, memberships of
+-- missing users or families, subscription payments with no subscription —
+-- and record each run with per-check counts and sample row IDs.
+--
+-- A run may quarantine the orphans of checks marked quarantinable: each row
+-- is moved, whole, into integrity_quarantine and deleted from its table in
+-- one statement, so it can be put back by hand. Quarantined rows can hold
+-- PHI (log rows); they stay on the main DB and are never served by the
+-- admin API, which only shows counts and IDs.
+
+CREATE TABLE IF NOT EXISTS integrity_check_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status VARCHAR(20) NOT NULL
+        CHECK (status IN ('running', 'clean', 'problems', 'failed')),
+    quarantine BOOLEAN NOT NULL DEFAULT false,
+    results JSONB NOT NULL DEFAULT '[]',
+    error TEXT NOT NULL DEFAULT '',
+    -- Free text: the admin's email, or OS user / host for the CLI.
+    run_by VARCHAR(255) NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_check_runs_started
+    ON integrity_check_runs (started_at DESC);
+
+CREATE TABLE IF NOT EXISTS integrity_quarantine (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID REFERENCES integrity_check_runs(id) ON DELETE SET NULL,
+    check_name VARCHAR(100) NOT NULL,
+    source_table VARCHAR(100) NOT NULL,
+    row_id TEXT NOT NULL,
+    row_data JSONB NOT NULL,
+    quarantined_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_quarantine_source
+    ON integrity_quarantine (source_table, row_id);
+
+COMMENT ON TABLE integrity_check_runs IS
+    'Data integrity checker runs: counts and sample IDs per check, no row data';
+COMMENT ON TABLE integrity_quarantine IS
+    'Orphan rows moved out of their tables by the integrity checker. MAY CONTAIN PHI.';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS integrity_quarantine;
+-- DROP TABLE IF EXISTS integrity_check_runs;
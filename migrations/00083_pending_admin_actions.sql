@@ -0,0 +1,50 @@
+-- Migration: 00083_pending_admin_actions.sql
+-- Description: Multi-step confirmation for destructive admin actions.
+-- Deactivating a user, bulk deleting error logs and deactivating a promo
+-- code that still has active subscribers no longer act on the first
+-- request: they record a pending action, which the requesting admin must
+-- confirm by typing its confirmation phrase (the user's email, the promo
+-- code, ...). Actions whose policy requires it then wait for a second
+-- admin's approval. Unconfirmed or unapproved actions expire.
+--
+-- Rows are never deleted; together with the admin audit log they record
+-- who requested, confirmed, approved or rejected each action and what it
+-- did.
+
+CREATE TABLE IF NOT EXISTS pending_admin_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action VARCHAR(100) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id VARCHAR(100) NOT NULL DEFAULT '',
+    params JSONB NOT NULL DEFAULT '{}',
+    summary TEXT NOT NULL,
+    confirm_phrase TEXT NOT NULL,
+    requires_approval BOOLEAN NOT NULL DEFAULT false,
+    status VARCHAR(30) NOT NULL DEFAULT 'awaiting_confirmation'
+        CHECK (status IN ('awaiting_confirmation', 'awaiting_approval', 'executing',
+                          'executed', 'failed', 'rejected', 'cancelled', 'expired')),
+    requested_by UUID NOT NULL REFERENCES admin_users(id),
+    confirmed_at TIMESTAMPTZ,
+    decided_by UUID REFERENCES admin_users(id),
+    decided_at TIMESTAMPTZ,
+    decision_note TEXT NOT NULL DEFAULT '',
+    result JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    executed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- An admin can't approve their own action.
+    CHECK (decided_by IS NULL OR decided_by <> requested_by OR status IN ('cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_admin_actions_open
+    ON pending_admin_actions (expires_at)
+    WHERE status IN ('awaiting_confirmation', 'awaiting_approval');
+CREATE INDEX IF NOT EXISTS idx_pending_admin_actions_created
+    ON pending_admin_actions (created_at DESC);
+
+COMMENT ON TABLE pending_admin_actions IS
+    'Destructive admin actions awaiting typed confirmation and/or a second admin''s approval';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS pending_admin_actions;
@@ -0,0 +1,34 @@
+-- Migration: 00084_promo_code_analytics.sql
+-- Description: Promo code analytics. Every promo code a user checks in the
+-- app (POST /api/family/billing/promo-codes/validate) is recorded with its
+-- outcome, so the admin analytics endpoint can report how many users who
+-- checked a code went on to redeem it. Codes that don't exist are kept too
+-- (promo_code_id NULL) so guessing shows up.
+
+CREATE TABLE IF NOT EXISTS promo_code_validations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promo_code_id UUID REFERENCES promo_codes(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    user_id UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    plan_id UUID REFERENCES subscription_plans(id) ON DELETE SET NULL,
+    valid BOOLEAN NOT NULL,
+    -- Why an invalid code was refused: not_found, inactive, not_started,
+    -- expired, exhausted, already_used, plan_not_eligible, user_not_eligible.
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_code_validations_code
+    ON promo_code_validations (promo_code_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_promo_code_validations_user
+    ON promo_code_validations (user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_promo_code_usages_code_used
+    ON promo_code_usages (promo_code_id, used_at);
+
+COMMENT ON TABLE promo_code_validations IS
+    'Promo codes checked by users, valid or not, for validation → redemption conversion';
+
+-- ROLLBACK:
+-- DROP INDEX IF EXISTS idx_promo_code_usages_code_used;
+-- DROP TABLE IF EXISTS promo_code_validations;
@@ -0,0 +1,57 @@
+-- Migration: 00085_promo_fraud_flags.sql
+-- Description: Promo code fraud detection. Promo code checks now record
+-- the client IP and the app's device identifier, and every successful
+-- check is screened for abuse: many accounts using the code from one IP
+-- or device, disposable email domains, codes stacked on a subscription
+-- that is already discounted, and one account cycling through codes.
+-- Suspicious checks are flagged for admin review; when the fraud policy
+-- says so, the discount is held until an admin approves the flag.
+
+ALTER TABLE promo_code_validations
+    ADD COLUMN IF NOT EXISTS ip_address INET,
+    ADD COLUMN IF NOT EXISTS device_id VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_promo_code_validations_ip
+    ON promo_code_validations (promo_code_id, ip_address, created_at)
+    WHERE ip_address IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_promo_code_validations_device
+    ON promo_code_validations (promo_code_id, device_id, created_at)
+    WHERE device_id <> '';
+
+CREATE TABLE IF NOT EXISTS promo_fraud_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promo_code_id UUID NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    validation_id UUID REFERENCES promo_code_validations(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    -- Which checks tripped and what they saw, e.g.
+    -- [{"signal": "shared_ip", "detail": "4 other accounts from 203.0.113.9"}].
+    signals JSONB NOT NULL DEFAULT '[]',
+    ip_address INET,
+    device_id VARCHAR(255) NOT NULL DEFAULT '',
+    -- Whether the discount is withheld until the flag is approved.
+    held BOOLEAN NOT NULL DEFAULT false,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewed_by UUID REFERENCES admin_users(id),
+    reviewed_at TIMESTAMPTZ,
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- At most one open flag per user and code; later checks reuse it.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_fraud_flags_open
+    ON promo_fraud_flags (promo_code_id, user_id)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_promo_fraud_flags_status
+    ON promo_fraud_flags (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promo_fraud_flags_user
+    ON promo_fraud_flags (user_id, promo_code_id, created_at DESC);
+
+COMMENT ON TABLE promo_fraud_flags IS
+    'Promo code checks flagged as likely abuse, queued for admin review';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS promo_fraud_flags;
+-- DROP INDEX IF EXISTS idx_promo_code_validations_device;
+-- DROP INDEX IF EXISTS idx_promo_code_validations_ip;
+-- ALTER TABLE promo_code_validations DROP COLUMN IF EXISTS device_id, DROP COLUMN IF EXISTS ip_address;
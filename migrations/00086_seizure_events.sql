@@ -0,0 +1,40 @@
+-- Migration: 00086_seizure_events.sql
+-- Description: Seizure emergency mode. A caregiver starts a live seizure
+-- event when a seizure begins; the rest of the family is pushed an alert
+-- and the event carries a first-aid and rescue medication checklist. On
+-- stop the duration is filled into a new seizure log. The event, its
+-- checklist times and the log make up the incident summary that can be
+-- shared with the child's neurologist by signed link.
+
+CREATE TABLE IF NOT EXISTS seizure_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'ended')),
+    started_at TIMESTAMPTZ NOT NULL,
+    started_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    ended_at TIMESTAMPTZ,
+    ended_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    -- The caregiver's zone when started; the seizure log's date and time
+    -- are local to it.
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    -- [{"key", "kind", "label", "medication_id", "due_after_seconds",
+    --   "done_at", "done_by"}, ...]
+    checklist JSONB NOT NULL DEFAULT '[]',
+    seizure_log_id UUID REFERENCES seizure_logs(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (status = 'active' OR ended_at IS NOT NULL)
+);
+
+-- One live seizure per child at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seizure_events_active
+    ON seizure_events (child_id)
+    WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_seizure_events_child
+    ON seizure_events (child_id, started_at DESC);
+
+COMMENT ON TABLE seizure_events IS
+    'Live seizure timer events: start/stop, checklist, link to the resulting seizure log';
+
+-- ROLLBACK:
+-- DROP TABLE IF EXISTS seizure_events;
@@ -0,0 +1,59 @@
+-- Migration: 00087_therapy_goals.sql
+-- Description: Therapy goal tracking. Families record each child's
+-- therapy goals (the goal, the metric it is measured by, a baseline and
+-- target, and a target date). Therapy logs reference the goals worked on
+-- in the session with a 1-5 progress rating and an optional measured
+-- value; those roll up into per-goal trends and a quarterly IEP-style
+-- progress report, stored as a 'goal_progress' report.
+
+CREATE TABLE IF NOT EXISTS therapy_goals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    goal_text TEXT NOT NULL,
+    -- speech, OT, ABA, ... as on therapy logs; NULL for any.
+    therapy_type VARCHAR(50),
+    -- What progress is measured in, e.g. "% of trials correct".
+    target_metric VARCHAR(200) NOT NULL DEFAULT '',
+    baseline NUMERIC(10, 2),
+    target_value NUMERIC(10, 2),
+    target_date DATE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'met', 'discontinued')),
+    met_at TIMESTAMPTZ,
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_therapy_goals_child
+    ON therapy_goals (child_id, status);
+
+-- The goals a therapy session worked on, with how it went for each.
+CREATE TABLE IF NOT EXISTS therapy_log_goals (
+    therapy_log_id UUID NOT NULL REFERENCES therapy_logs(id) ON DELETE CASCADE,
+    goal_id UUID NOT NULL REFERENCES therapy_goals(id) ON DELETE CASCADE,
+    progress_rating SMALLINT CHECK (progress_rating BETWEEN 1 AND 5),
+    measured_value NUMERIC(10, 2),
+    note TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (therapy_log_id, goal_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_therapy_log_goals_goal
+    ON therapy_log_goals (goal_id);
+
+ALTER TABLE reports DROP CONSTRAINT IF EXISTS reports_report_type_check;
+ALTER TABLE reports ADD CONSTRAINT reports_report_type_check
+    CHECK (report_type IN ('on_demand', 'scheduled', 'goal_progress'));
+
+COMMENT ON TABLE therapy_goals IS
+    'Per-child therapy goals with baseline, target and target date';
+COMMENT ON TABLE therapy_log_goals IS
+    'Goals worked on in a therapy log, with per-goal progress rating';
+
+-- ROLLBACK:
+-- DELETE FROM reports WHERE report_type = 'goal_progress';
+-- ALTER TABLE reports DROP CONSTRAINT IF EXISTS reports_report_type_check;
+-- ALTER TABLE reports ADD CONSTRAINT reports_report_type_check
+--     CHECK (report_type IN ('on_demand', 'scheduled'));
+-- DROP TABLE IF EXISTS therapy_log_goals;
+-- DROP TABLE IF EXISTS therapy_goals;
@@ -0,0 +1,72 @@
+-- Migration: 00088_provider_access.sql
+-- Description: External contributors. A family can invite a school or ABA
+-- provider to record logs for one child. The provider gets a grant, not a
+-- family membership: it covers that child and the log types the family
+-- picked (behavior, therapy), and nothing else in the family. Providers
+-- keep their own branding (organization name, colour, logo), every entry
+-- they make is attributed to them, and any parent can revoke the grant at
+-- any time.
+
+-- A provider account's branding, shown in the provider portal and on the
+-- entries it makes.
+CREATE TABLE IF NOT EXISTS provider_profiles (
+    user_id UUID PRIMARY KEY REFERENCES app_users(id) ON DELETE CASCADE,
+    organization_name VARCHAR(200) NOT NULL,
+    provider_type VARCHAR(20) NOT NULL DEFAULT 'school'
+        CHECK (provider_type IN ('school', 'aba', 'other')),
+    brand_color VARCHAR(7),
+    logo_url TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS provider_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    -- Set when the invitation is accepted.
+    provider_user_id UUID REFERENCES app_users(id) ON DELETE CASCADE,
+    invite_email VARCHAR(255) NOT NULL,
+    -- As the family named the provider when inviting; the provider's
+    -- profile takes over once they have one.
+    organization_name VARCHAR(200) NOT NULL,
+    provider_type VARCHAR(20) NOT NULL DEFAULT 'school'
+        CHECK (provider_type IN ('school', 'aba', 'other')),
+    log_types TEXT[] NOT NULL
+        CHECK (cardinality(log_types) > 0 AND log_types <@ ARRAY['behavior', 'therapy']),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'active', 'revoked')),
+    invite_token_hash VARCHAR(64),
+    invite_expires_at TIMESTAMPTZ,
+    invited_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    accepted_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    revoked_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_grants_child
+    ON provider_grants (child_id, status);
+CREATE INDEX IF NOT EXISTS idx_provider_grants_provider
+    ON provider_grants (provider_user_id) WHERE status = 'active';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_provider_grants_token
+    ON provider_grants (invite_token_hash) WHERE invite_token_hash IS NOT NULL;
+-- One open invitation or grant per provider address and child.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_provider_grants_open
+    ON provider_grants (child_id, lower(invite_email)) WHERE status <> 'revoked';
+
+-- The logs a provider entered, with the organization name as it was at the
+-- time. Kept after revocation so the family still sees who wrote what.
+CREATE TABLE IF NOT EXISTS provider_log_entries (
+    log_type VARCHAR(20) NOT NULL CHECK (log_type IN ('behavior', 'therapy')),
+    log_id UUID NOT NULL,
+    grant_id UUID NOT NULL REFERENCES provider_grants(id) ON DELETE CASCADE,
+    provider_user_id UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    organization_name VARCHAR(200) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (log_type, log_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_log_entries_grant
+    ON provider_log_entries (grant_id, created_at DESC);
@@ -0,0 +1,48 @@
+-- Migration: 00089_routines.sql
+-- Description: Daily routines (visual schedules). Families define a
+-- child's recurring routines — morning, after school, bedtime — as ordered
+-- steps with icons, tick steps off each day, and print the routine as a
+-- visual schedule. Daily completion feeds the correlation engine as the
+-- routine_completion metric.
+
+CREATE TABLE IF NOT EXISTS routines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    icon VARCHAR(30) NOT NULL DEFAULT '',
+    -- When the routine usually starts, for ordering and the printout.
+    start_time TIME,
+    -- Days it runs, 0 = Sunday; empty means every day.
+    days_of_week SMALLINT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_routines_child ON routines (child_id, active);
+
+CREATE TABLE IF NOT EXISTS routine_steps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    routine_id UUID NOT NULL REFERENCES routines(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    icon VARCHAR(30) NOT NULL DEFAULT '',
+    duration_minutes INT CHECK (duration_minutes BETWEEN 1 AND 240)
+);
+
+CREATE INDEX IF NOT EXISTS idx_routine_steps_routine ON routine_steps (routine_id, position);
+
+-- A step done on a day. child_id is denormalised for the daily metric.
+CREATE TABLE IF NOT EXISTS routine_completions (
+    step_id UUID NOT NULL REFERENCES routine_steps(id) ON DELETE CASCADE,
+    completion_date DATE NOT NULL,
+    routine_id UUID NOT NULL REFERENCES routines(id) ON DELETE CASCADE,
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    completed_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (step_id, completion_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_routine_completions_child
+    ON routine_completions (child_id, completion_date);
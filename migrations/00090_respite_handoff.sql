@@ -0,0 +1,19 @@
+-- Migration: 00090_respite_handoff.sql
+-- Description: Respite care handoff. What a babysitter or respite worker
+-- needs that the app doesn't already know — emergency contacts and the
+-- family's own notes on communicating with the child, calming them and
+-- allergies. The handoff document merges these with the child's current
+-- medications, logged allergic reactions and the calming strategies the
+-- logs show being used.
+
+CREATE TABLE IF NOT EXISTS child_handoff_profiles (
+    child_id UUID PRIMARY KEY REFERENCES children(id) ON DELETE CASCADE,
+    -- [{"name", "relationship", "phone", "notes"}], in the order to call.
+    emergency_contacts JSONB NOT NULL DEFAULT '[]',
+    communication_notes TEXT,
+    calming_notes TEXT,
+    allergy_notes TEXT,
+    care_notes TEXT,
+    updated_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,21 @@
+-- Migration: 00091_annotations.sql
+-- Description: Chart annotations. Caregivers mark dated changes in a
+-- child's life — started a new medication, changed school, began a GFCF
+-- diet — so charts, reports and the analysis engine can line metric
+-- changes up with what changed around them.
+
+CREATE TABLE IF NOT EXISTS annotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    annotation_date DATE NOT NULL,
+    category VARCHAR(20) NOT NULL DEFAULT 'other'
+        CHECK (category IN ('medication', 'diet', 'therapy', 'school', 'health', 'home', 'milestone', 'other')),
+    title VARCHAR(200) NOT NULL,
+    notes TEXT,
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_annotations_child_date
+    ON annotations (child_id, annotation_date);
@@ -0,0 +1,22 @@
+-- Migration: 00092_interventions.sql
+-- Description: Intervention experiments ("we tried X for 2 weeks"). A
+-- caregiver records what they tried, when, and which metrics it was meant
+-- to move; the analysis service compares those metrics before, during and
+-- after the intervention.
+
+CREATE TABLE IF NOT EXISTS interventions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    target_metrics TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_interventions_child_start
+    ON interventions (child_id, start_date DESC);
@@ -0,0 +1,25 @@
+-- Migration: 00093_allergy_registry.sql
+-- Description: Per-child allergy and dietary restriction registry. Diet
+-- logs whose foods match a registered item are flagged with the items
+-- they matched, so incidents can be alerted on and reported.
+
+CREATE TABLE IF NOT EXISTS child_restrictions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    item VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'allergy'
+        CHECK (kind IN ('allergy', 'intolerance', 'dietary')),
+    severity VARCHAR(20)
+        CHECK (severity IN ('mild', 'moderate', 'severe')),
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    notes TEXT,
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_child_restrictions_child_item
+    ON child_restrictions (child_id, LOWER(item));
+
+-- Registered items a diet log's foods matched when it was saved.
+ALTER TABLE diet_logs ADD COLUMN IF NOT EXISTS restriction_matches TEXT[];
@@ -0,0 +1,45 @@
+-- Migration: 00094_sleep_reconciliation.sql
+-- Description: Sleep records imported from wearables and health apps, a
+-- per-child preferred sleep source, and reconciliation state on sleep
+-- logs so manual entries that disagree with a device can be flagged.
+
+ALTER TABLE sleep_logs ADD COLUMN IF NOT EXISTS source VARCHAR(30) NOT NULL DEFAULT 'manual';
+ALTER TABLE sleep_logs ADD COLUMN IF NOT EXISTS reconciliation_status VARCHAR(20)
+    CHECK (reconciliation_status IN ('consistent', 'conflict', 'resolved'));
+ALTER TABLE sleep_logs ADD COLUMN IF NOT EXISTS reconciliation_note TEXT;
+
+CREATE TABLE IF NOT EXISTS sleep_device_records (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    source VARCHAR(30) NOT NULL
+        CHECK (source IN ('apple_health', 'google_fit', 'health_connect', 'fitbit', 'garmin', 'oura', 'other')),
+    external_id VARCHAR(200) NOT NULL,
+    sleep_start TIMESTAMPTZ NOT NULL,
+    sleep_end TIMESTAMPTZ NOT NULL,
+    total_sleep_minutes INTEGER NOT NULL CHECK (total_sleep_minutes BETWEEN 0 AND 1440),
+    night_wakings INTEGER,
+    -- Local date the child woke up on, which is the date a caregiver logs
+    -- the night under.
+    night_date DATE NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    imported_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (sleep_end > sleep_start)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sleep_device_records_external
+    ON sleep_device_records (child_id, source, external_id);
+CREATE INDEX IF NOT EXISTS idx_sleep_device_records_child_night
+    ON sleep_device_records (child_id, night_date);
+CREATE INDEX IF NOT EXISTS idx_sleep_device_records_updated
+    ON sleep_device_records (updated_at);
+
+CREATE TABLE IF NOT EXISTS sleep_source_preferences (
+    child_id UUID PRIMARY KEY REFERENCES children(id) ON DELETE CASCADE,
+    preferred_source VARCHAR(30) NOT NULL DEFAULT 'manual',
+    conflict_threshold_minutes INTEGER NOT NULL DEFAULT 60
+        CHECK (conflict_threshold_minutes BETWEEN 5 AND 600),
+    updated_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
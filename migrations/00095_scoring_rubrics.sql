@@ -0,0 +1,17 @@
+-- Migration: 00095_scoring_rubrics.sql
+-- Description: Per-family scoring rubrics that give the 1-10 mood, energy
+-- and anxiety levels labels, descriptions and emoji. Rubrics are
+-- versioned; each behavior log records the version it was entered under
+-- so old entries keep their meaning after a family edits its rubric.
+-- Version 0 is the built-in default and has no row.
+
+CREATE TABLE IF NOT EXISTS scoring_rubrics (
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL CHECK (version > 0),
+    metrics JSONB NOT NULL,
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (family_id, version)
+);
+
+ALTER TABLE behavior_logs ADD COLUMN IF NOT EXISTS rubric_version INTEGER NOT NULL DEFAULT 0;
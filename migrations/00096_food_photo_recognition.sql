@@ -0,0 +1,23 @@
+-- Migration: 00096_food_photo_recognition.sql
+-- Description: Food suggestions from meal photos. The photo itself isn't
+-- kept, only its hash and what the vision provider suggested. Diet logs
+-- saved from a recognition record which foods came from it.
+
+CREATE TABLE IF NOT EXISTS food_recognitions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    provider VARCHAR(30) NOT NULL,
+    model VARCHAR(100),
+    image_sha256 CHAR(64) NOT NULL,
+    suggestions JSONB NOT NULL DEFAULT '[]',
+    diet_log_id UUID REFERENCES diet_logs(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_food_recognitions_child ON food_recognitions (child_id, created_at DESC);
+
+-- Where each of foods_eaten came from: a confirmed photo suggestion (with
+-- its confidence), an edited one, or typed by the caregiver.
+ALTER TABLE diet_logs ADD COLUMN IF NOT EXISTS photo_recognition_id UUID REFERENCES food_recognitions(id) ON DELETE SET NULL;
+ALTER TABLE diet_logs ADD COLUMN IF NOT EXISTS food_provenance JSONB;
@@ -0,0 +1,33 @@
+-- Migration: 00097_report_shares.sql
+-- Description: Report sharing with clinicians. Each share is a signed
+-- link to one generated report for one recipient; every time the link is
+-- opened a view is recorded, so the caregiver can see who read what and
+-- when. The expiry lives on the share rather than in the link, so it can
+-- be extended (or the share revoked) without sending a new link.
+
+CREATE TABLE IF NOT EXISTS report_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id UUID NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    shared_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    recipient_name VARCHAR(200) NOT NULL,
+    recipient_email VARCHAR(255),
+    note TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_shares_child ON report_shares (child_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_report_shares_report ON report_shares (report_id);
+
+-- Read receipts: one row per open of the shared link.
+CREATE TABLE IF NOT EXISTS report_share_views (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    share_id UUID NOT NULL REFERENCES report_shares(id) ON DELETE CASCADE,
+    viewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ip_address INET,
+    user_agent TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_share_views_share ON report_share_views (share_id, viewed_at);
@@ -0,0 +1,33 @@
+-- Migration: 00098_log_revisions.sql
+-- Description: Amendment audit trail for log entries. Every edit that
+-- changes a log stores the fields it changed, with their previous and new
+-- values, who made it and when. Logs carry amended_at so reports and
+-- exports can flag entries that were corrected after they were logged.
+
+CREATE TABLE IF NOT EXISTS log_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- "behavior", "diet", "health_event", ...; log_id is the row in that
+    -- type's table. History outlives the log if it is deleted.
+    log_type VARCHAR(30) NOT NULL,
+    log_id UUID NOT NULL,
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    -- [{"field", "from", "to"}]
+    changes JSONB NOT NULL,
+    -- NULL for edits the system made, such as device sleep sync.
+    edited_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    edited_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_log_revisions_log ON log_revisions (log_type, log_id, edited_at);
+
+ALTER TABLE behavior_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE bowel_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE speech_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE diet_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE weight_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE sleep_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE sensory_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE social_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE therapy_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE seizure_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+ALTER TABLE health_event_logs ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
@@ -0,0 +1,21 @@
+-- Migration: 00099_log_versions.sql
+-- Description: Optimistic concurrency for log edits. Every update bumps a
+-- log's version and must name the version it was made against, so two
+-- caregivers editing the same entry can't silently overwrite each other.
+-- Revisions record the version they produced, which lets a rejected edit
+-- see what changed since the version it started from.
+
+ALTER TABLE behavior_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE bowel_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE speech_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE diet_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE weight_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE sleep_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE sensory_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE social_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE therapy_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE seizure_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE health_event_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- NULL for revisions recorded before versions existed.
+ALTER TABLE log_revisions ADD COLUMN IF NOT EXISTS version INTEGER;
@@ -0,0 +1,22 @@
+-- Migration: 00100_log_drafts.sql
+-- Description: Autosaved drafts of log forms, one per user, child and log
+-- type, so a long behavior or therapy entry survives the app being
+-- backgrounded and can be finished on another device. Drafts expire and
+-- are swept after LOG_DRAFT_TTL without a save.
+
+CREATE TABLE IF NOT EXISTS log_drafts (
+    user_id UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+    child_id UUID NOT NULL REFERENCES children(id) ON DELETE CASCADE,
+    -- "behavior", "therapy", ... as in log_usage_daily.
+    log_type VARCHAR(30) NOT NULL,
+    -- Set when the draft edits an existing log rather than a new one.
+    log_id UUID,
+    -- The form's fields as the client sent them; not validated until the
+    -- log is saved.
+    data JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, child_id, log_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_log_drafts_expires ON log_drafts (expires_at);
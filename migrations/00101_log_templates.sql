@@ -0,0 +1,19 @@
+-- Migration: 00101_log_templates.sql
+-- Description: Family-defined log entry templates ("school day
+-- breakfast"): a named, pre-filled log of one type that caregivers can
+-- log for any of the family's children in one call.
+
+CREATE TABLE IF NOT EXISTS log_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    -- "behavior", "diet", ...; data holds that type's create request
+    -- fields, without log_date.
+    log_type VARCHAR(30) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    data JSONB NOT NULL,
+    created_by UUID REFERENCES app_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_log_templates_family_name ON log_templates (family_id, log_type, LOWER(name));
@@ -0,0 +1,11 @@
+-- Migration: 00102_gamification.sql
+-- Description: Per-caregiver opt-outs for logging streaks and badges and
+-- for the weekly digest email. Users without a row have both on.
+
+CREATE TABLE IF NOT EXISTS gamification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES app_users(id) ON DELETE CASCADE,
+    -- Streaks, badges and progress stats in the app and the digest.
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    weekly_digest BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
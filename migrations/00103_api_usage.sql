@@ -0,0 +1,22 @@
+-- Migration: 00103_api_usage.sql
+-- Description: Hourly API usage per client and route, flushed from the
+-- Redis counters the API middleware keeps. A client is a mobile
+-- platform and build, "web", or "other" with the User-Agent's product
+-- for scripts and integrations. No user identifiers.
+
+CREATE TABLE IF NOT EXISTS api_usage_hourly (
+    hour TIMESTAMPTZ NOT NULL,
+    platform VARCHAR(20) NOT NULL,
+    client_version VARCHAR(40) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route TEXT NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    client_errors BIGINT NOT NULL DEFAULT 0,
+    server_errors BIGINT NOT NULL DEFAULT 0,
+    total_ms BIGINT NOT NULL DEFAULT 0,
+    -- Requests that took a second or more.
+    slow BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (hour, platform, client_version, method, route)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_usage_hourly_hour ON api_usage_hourly (hour);
@@ -0,0 +1,40 @@
+-- Migration: 00104_abuse_detection.sql
+-- Description: Temporary blocks applied by abuse and scraping detection,
+-- kept for admin review; and the error_logs classification columns the
+-- error log view filters on, filled in by the error tracker from now on
+-- ("scanner" for traffic detection flagged).
+
+CREATE TABLE IF NOT EXISTS abuse_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ip_address INET NOT NULL,
+    -- velocity, enumeration, probing or scanner_agent
+    reason VARCHAR(30) NOT NULL,
+    -- What tripped the heuristic: counts, the user agent, sample paths.
+    details JSONB NOT NULL DEFAULT '{}',
+    blocked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    -- active until it expires or an admin lifts it.
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    reviewed_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    review_note TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_abuse_blocks_ip ON abuse_blocks (ip_address, blocked_at DESC);
+CREATE INDEX IF NOT EXISTS idx_abuse_blocks_queue ON abuse_blocks (blocked_at DESC) WHERE reviewed_at IS NULL;
+
+ALTER TABLE error_logs ADD COLUMN IF NOT EXISTS error_source VARCHAR(20);
+ALTER TABLE error_logs ADD COLUMN IF NOT EXISTS is_noise BOOLEAN DEFAULT FALSE;
+ALTER TABLE error_logs ADD COLUMN IF NOT EXISTS auto_delete_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_error_logs_auto_delete ON error_logs (auto_delete_at) WHERE auto_delete_at IS NOT NULL;
+
+-- Rows logged before the tracker classified them: infrastructure for
+-- server errors, otherwise user or anonymous by whether anyone was
+-- signed in. Only new rows get an auto_delete_at.
+UPDATE error_logs
+SET error_source = CASE
+        WHEN status_code >= 500 THEN 'infrastructure'
+        WHEN user_id IS NOT NULL THEN 'user'
+        ELSE 'anonymous'
+    END
+WHERE error_source IS NULL;
@@ -0,0 +1,63 @@
+-- Migration: 00105_phi_canaries.sql
+-- Description: Canary rows in PHI tables. A canary family (created by an
+-- inactive canary app user, with no members) holds one child and a
+-- condition, a medication and a behavior log for it, each carrying a
+-- random token. No caregiver can see them, so the only way admin code
+-- meets a canary id or token is by reading PHI; the PHI watchdog raises a
+-- critical admin notification when it does. Logs are dated 2000-01-01 to
+-- stay out of usage counts.
+
+CREATE TABLE IF NOT EXISTS phi_canaries (
+    table_name VARCHAR(63) NOT NULL,
+    row_id UUID NOT NULL,
+    -- Written into the row's free text; NULL for the supporting family
+    -- and user rows.
+    token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (table_name, row_id)
+);
+
+DO $$
+DECLARE
+    canary_user UUID;
+    canary_family UUID;
+    canary_child UUID;
+    canary_row UUID;
+    tok TEXT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM phi_canaries) THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO app_users (email, password_hash, first_name, last_name, status)
+    VALUES ('phi-canary@carecompanion.invalid', '!', 'PHI', 'Canary', 'inactive')
+    RETURNING id INTO canary_user;
+    INSERT INTO phi_canaries (table_name, row_id) VALUES ('app_users', canary_user);
+
+    INSERT INTO families (name, created_by) VALUES ('PHI canary', canary_user)
+    RETURNING id INTO canary_family;
+    INSERT INTO phi_canaries (table_name, row_id) VALUES ('families', canary_family);
+
+    tok := 'phi-canary-' || substr(md5(random()::text || clock_timestamp()::text), 1, 20);
+    INSERT INTO children (family_id, first_name, last_name, date_of_birth, notes)
+    VALUES (canary_family, tok, 'Canary', '2015-01-01', tok)
+    RETURNING id INTO canary_child;
+    INSERT INTO phi_canaries (table_name, row_id, token) VALUES ('children', canary_child, tok);
+
+    tok := 'phi-canary-' || substr(md5(random()::text || clock_timestamp()::text), 1, 20);
+    INSERT INTO child_conditions (child_id, condition_name) VALUES (canary_child, tok)
+    RETURNING id INTO canary_row;
+    INSERT INTO phi_canaries (table_name, row_id, token) VALUES ('child_conditions', canary_row, tok);
+
+    tok := 'phi-canary-' || substr(md5(random()::text || clock_timestamp()::text), 1, 20);
+    INSERT INTO medications (child_id, name, dosage, dosage_unit, frequency, instructions, is_active)
+    VALUES (canary_child, tok, '1', 'mg', 'once_daily', tok, false)
+    RETURNING id INTO canary_row;
+    INSERT INTO phi_canaries (table_name, row_id, token) VALUES ('medications', canary_row, tok);
+
+    tok := 'phi-canary-' || substr(md5(random()::text || clock_timestamp()::text), 1, 20);
+    INSERT INTO behavior_logs (child_id, log_date, notes, logged_by, created_at, updated_at)
+    VALUES (canary_child, '2000-01-01', tok, canary_user, '2000-01-01', '2000-01-01')
+    RETURNING id INTO canary_row;
+    INSERT INTO phi_canaries (table_name, row_id, token) VALUES ('behavior_logs', canary_row, tok);
+END $$;
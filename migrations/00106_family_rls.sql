@@ -0,0 +1,49 @@
+-- Migration: 00106_family_rls.sql
+-- Description: Row-level security on children and every table keyed by
+-- child_id, as a second line of defence behind the services' access
+-- checks. The policies read the app.family_id session setting, which the
+-- repositories set for family-scoped API requests
+-- (repository.WithFamilyScope). Unset or empty it allows every row, so
+-- background jobs, the admin console, migrations and tools are
+-- unaffected. Set, only that family's children, and rows whose child is
+-- one of them, can be read or written; rows with no child stay visible.
+-- FORCE applies the policies to the table owner, which the app connects
+-- as. Superusers bypass RLS regardless.
+
+CREATE OR REPLACE FUNCTION app_family_scope() RETURNS UUID
+LANGUAGE sql STABLE AS $$
+    SELECT NULLIF(current_setting('app.family_id', true), '')::uuid
+$$;
+
+ALTER TABLE children ENABLE ROW LEVEL SECURITY;
+ALTER TABLE children FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS family_scope ON children;
+CREATE POLICY family_scope ON children
+    USING (app_family_scope() IS NULL OR family_id = app_family_scope());
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOR t IN
+        SELECT c.relname
+        FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        JOIN pg_attribute a ON a.attrelid = c.oid
+        WHERE n.nspname = 'public'
+          AND c.relkind IN ('r', 'p')
+          AND NOT c.relispartition
+          AND a.attname = 'child_id'
+          AND NOT a.attisdropped
+        ORDER BY c.relname
+    LOOP
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS family_scope ON %I', t);
+        EXECUTE format(
+            'CREATE POLICY family_scope ON %I USING ('
+            || 'app_family_scope() IS NULL OR child_id IS NULL'
+            || ' OR EXISTS (SELECT 1 FROM children c WHERE c.id = child_id AND c.family_id = app_family_scope()))',
+            t);
+    END LOOP;
+END $$;
@@ -0,0 +1,26 @@
+-- Migration: 00107_admin_invitations.sql
+-- Description: One-time invitation links for admins created by the bulk
+-- CSV import (POST /api/admin/super/admins/import, adminctl import).
+-- Imported admins start as pending_verification with an unusable password
+-- and become active when they open the link and choose one. Only the
+-- SHA-256 of the token is stored, like password_reset_tokens.
+--
+-- Invitations are per-env: the admin_users row replicates to the mirror
+-- env, the link only works on the env that issued it.
+
+CREATE TABLE IF NOT EXISTS admin_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL REFERENCES admin_users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    -- Rows imported from the same CSV share a batch.
+    batch_id UUID,
+    invited_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    -- Set when a newer invitation replaces this one.
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_invitations_admin ON admin_invitations (admin_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_admin_invitations_batch ON admin_invitations (batch_id) WHERE batch_id IS NOT NULL;
@@ -0,0 +1,84 @@
+-- Migration: 00108_admin_access_reviews.sql
+-- Description: Quarterly admin access reviews. Each quarter a review opens
+-- with a snapshot of every admin account (role, status, last login and
+-- the permissions the role grants); a super admin attests or revokes each
+-- one, then completes the review. Decisions are final and completed
+-- reviews can't change: the triggers below refuse edits and deletes, so
+-- the tables are a record for auditors rather than working state.
+
+CREATE TABLE IF NOT EXISTS admin_access_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- Quarter under review, e.g. 2026-Q4. One review per quarter.
+    period VARCHAR(7) NOT NULL UNIQUE,
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    due_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    completed_by UUID
+);
+
+-- admin_id and decided_by are deliberately not foreign keys: the record
+-- has to outlive the accounts it reviews.
+CREATE TABLE IF NOT EXISTS admin_access_review_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    review_id UUID NOT NULL REFERENCES admin_access_reviews(id),
+    admin_id UUID NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    role VARCHAR(50) NOT NULL,
+    status VARCHAR(30) NOT NULL,
+    last_login_at TIMESTAMPTZ,
+    -- Section -> level granted by the role when the review opened.
+    permissions JSONB NOT NULL DEFAULT '{}',
+    decision VARCHAR(10) CHECK (decision IN ('attest', 'revoke')),
+    decided_by UUID,
+    decided_at TIMESTAMPTZ,
+    note TEXT,
+    UNIQUE (review_id, admin_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_access_review_items_review ON admin_access_review_items (review_id);
+
+-- A review can be completed once, and nothing else about it changes.
+CREATE OR REPLACE FUNCTION admin_access_reviews_immutable()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'admin access reviews cannot be deleted';
+    END IF;
+    IF OLD.completed_at IS NOT NULL
+       OR NEW.id <> OLD.id OR NEW.period <> OLD.period
+       OR NEW.opened_at <> OLD.opened_at OR NEW.due_at <> OLD.due_at THEN
+        RAISE EXCEPTION 'admin access review % is immutable', OLD.period;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_access_reviews_immutable ON admin_access_reviews;
+CREATE TRIGGER admin_access_reviews_immutable
+    BEFORE UPDATE OR DELETE ON admin_access_reviews
+    FOR EACH ROW EXECUTE FUNCTION admin_access_reviews_immutable();
+
+-- An item's snapshot never changes and its decision is recorded once,
+-- while the review is open.
+CREATE OR REPLACE FUNCTION admin_access_review_items_immutable()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'admin access review items cannot be deleted';
+    END IF;
+    IF OLD.decision IS NOT NULL
+       OR (NEW.id, NEW.review_id, NEW.admin_id, NEW.email, NEW.name, NEW.role, NEW.status, NEW.permissions)
+          IS DISTINCT FROM (OLD.id, OLD.review_id, OLD.admin_id, OLD.email, OLD.name, OLD.role, OLD.status, OLD.permissions)
+       OR NEW.last_login_at IS DISTINCT FROM OLD.last_login_at
+       OR EXISTS (SELECT 1 FROM admin_access_reviews WHERE id = OLD.review_id AND completed_at IS NOT NULL) THEN
+        RAISE EXCEPTION 'admin access review item % is immutable', OLD.id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_access_review_items_immutable ON admin_access_review_items;
+CREATE TRIGGER admin_access_review_items_immutable
+    BEFORE UPDATE OR DELETE ON admin_access_review_items
+    FOR EACH ROW EXECUTE FUNCTION admin_access_review_items_immutable();
@@ -0,0 +1,30 @@
+-- Migration: 00109_admin_elevations.sql
+-- Description: Just-in-time elevated access. An admin asks for a section
+-- of the permission matrix at a higher level than their role grants
+-- (e.g. financials at write for 2 hours) with a reason; a super admin
+-- approves or denies it. An approved elevation lapses at expires_at on
+-- its own and can be revoked early. While one is active, every audit row
+-- the admin writes carries its id.
+
+CREATE TABLE IF NOT EXISTS admin_elevations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL REFERENCES admin_users(id) ON DELETE CASCADE,
+    section VARCHAR(50) NOT NULL,
+    level VARCHAR(10) NOT NULL CHECK (level IN ('read', 'write', 'full')),
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    reason TEXT NOT NULL,
+    -- pending -> approved | denied | cancelled; approved -> revoked.
+    -- An approved elevation past expires_at reads as expired.
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'denied', 'cancelled', 'revoked')),
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    decided_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMPTZ,
+    decision_note TEXT,
+    expires_at TIMESTAMPTZ,
+    revoked_by UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_elevations_active ON admin_elevations (admin_id, expires_at) WHERE status = 'approved';
+CREATE INDEX IF NOT EXISTS idx_admin_elevations_requested ON admin_elevations (requested_at DESC);
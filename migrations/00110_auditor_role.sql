@@ -0,0 +1,9 @@
+-- Migration: 00110_auditor_role.sql
+-- Adds the 'auditor' system_role enum value: compliance reviewers who read
+-- the audit log, settings history and PHI access alerts and export them,
+-- with no mutation rights anywhere. Section access is in
+-- internal/auth/perm.go; auth.ReadOnly keeps the role at read everywhere.
+--
+-- Rollback: PostgreSQL can't drop an enum value; see 00030_partner_role.sql.
+
+ALTER TYPE system_role ADD VALUE IF NOT EXISTS 'auditor';
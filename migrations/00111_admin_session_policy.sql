@@ -0,0 +1,12 @@
+-- Migration: 00111_admin_session_policy.sql
+-- Description: Admin session policy (max duration, idle timeout,
+-- concurrent sessions, step-up re-auth). The policy itself is the
+-- admin_session_policy system setting; this adds the column step-up reads:
+-- when the session's owner last entered their password, at sign-in or on
+-- re-auth. NULL (sessions from before this migration) reads as created_at.
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_auth_at TIMESTAMPTZ;
+
+-- Concurrent-session limit: an admin's active sessions, oldest first.
+CREATE INDEX IF NOT EXISTS idx_sessions_admin_active ON sessions (admin_id, created_at)
+    WHERE kind = 'admin' AND revoked_at IS NULL;
@@ -0,0 +1,53 @@
+-- Migration: 00112_ticket_merges.sql
+-- Description: Ticket merge. A user's ticket about the same issue as
+-- another of theirs is merged into it: its messages, attachments and tags
+-- move to the target, it closes, and merged_into_ticket_id records the
+-- redirect so old links (admin or /support) land on the target. Chains are
+-- flattened at merge time, so the redirect is always one hop.
+-- ticket_merges keeps the history of each merge.
+--
+-- Lives in the support DB next to support_tickets (see 00027).
+
+ALTER TABLE support_tickets
+    ADD COLUMN IF NOT EXISTS merged_into_ticket_id UUID REFERENCES support_tickets(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS merged_at TIMESTAMPTZ;
+
+DO $$ BEGIN
+    ALTER TABLE support_tickets
+        ADD CONSTRAINT support_tickets_merge_not_self
+        CHECK (merged_into_ticket_id IS NULL OR merged_into_ticket_id <> id);
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_support_tickets_merged_into
+    ON support_tickets(merged_into_ticket_id)
+    WHERE merged_into_ticket_id IS NOT NULL;
+
+-- Duplicate suggestions look for the same user's tickets around the same time.
+CREATE INDEX IF NOT EXISTS idx_support_tickets_user_created
+    ON support_tickets(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS ticket_merges (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_ticket_id  UUID NOT NULL REFERENCES support_tickets(id) ON DELETE CASCADE,
+    target_ticket_id  UUID NOT NULL REFERENCES support_tickets(id) ON DELETE CASCADE,
+    -- Snapshot of the source as it was, for the target's merge history.
+    source_number     BIGINT NOT NULL,
+    source_subject    TEXT NOT NULL,
+    -- When the user first reported the source's issue: its created_at, or
+    -- earlier when tickets had been merged into it. Support metrics time
+    -- the target's resolution from the earliest of these.
+    reported_at       TIMESTAMPTZ NOT NULL,
+    messages_moved    INTEGER NOT NULL DEFAULT 0,
+    attachments_moved INTEGER NOT NULL DEFAULT 0,
+    -- Admin ids aren't FKs: the support DB may be shared across envs (00027).
+    merged_by         UUID,
+    merged_by_email   VARCHAR(255),
+    merged_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_merges_target ON ticket_merges(target_ticket_id, merged_at);
+CREATE INDEX IF NOT EXISTS idx_ticket_merges_source ON ticket_merges(source_ticket_id);
+
+COMMENT ON COLUMN support_tickets.merged_into_ticket_id IS 'Ticket this one was merged into (its messages moved there). Reads of this ticket redirect to it.';
@@ -0,0 +1,9 @@
+-- Migration: 00113_medication_log_versions.sql
+-- Description: Optimistic concurrency for medication log edits, as 00099
+-- added for the other log types. Every update bumps the log's version and
+-- must name the version it was made against.
+
+ALTER TABLE medication_logs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- ROLLBACK:
+-- ALTER TABLE medication_logs DROP COLUMN IF EXISTS version;
@@ -10,6 +10,7 @@
     var SHOWN = false;
     var EXPIRY_TIMER = null;
     var REFRESH_PROMISE = null;
+    var STEP_UP_PROMISE = null;
 
     var REFRESH_LEAD_SECONDS = 5 * 60;
     var TIMER_CAP_MS = 60 * 60 * 1000;
@@ -128,6 +129,71 @@
         document.body.insertBefore(banner, document.body.firstChild);
     }
 
+    // Step-up auth: a sensitive change answered 403 with X-Step-Up-Required
+    // because the admin's password is older than the session policy's
+    // window. Ask for it, POST /api/admin/auth/reauth, and resolve once it
+    // was accepted (reject on cancel). Single-flight like attemptRefresh.
+    function stepUp() {
+        if (STEP_UP_PROMISE) return STEP_UP_PROMISE;
+
+        STEP_UP_PROMISE = new Promise(function (resolve, reject) {
+            var overlay = document.createElement('div');
+            overlay.className = 'fixed inset-0 z-50 flex items-center justify-center bg-black bg-opacity-40';
+            overlay.innerHTML = '<form class="bg-white rounded-lg shadow-lg p-6 w-full max-w-sm">' +
+                '<h2 class="text-lg font-semibold text-gray-800 mb-2">Confirm it\'s you</h2>' +
+                '<p class="text-sm text-gray-600 mb-4">This change needs your password again.</p>' +
+                '<input type="password" autocomplete="current-password" required ' +
+                'class="w-full px-3 py-2 border rounded mb-2">' +
+                '<p data-error class="text-sm text-red-600 mb-2 hidden"></p>' +
+                '<div class="flex justify-end gap-2">' +
+                '<button type="button" data-cancel class="px-4 py-2 bg-gray-100 text-gray-700 rounded hover:bg-gray-200">Cancel</button>' +
+                '<button type="submit" class="px-4 py-2 bg-indigo-600 text-white rounded hover:bg-indigo-700">Continue</button>' +
+                '</div></form>';
+            document.body.appendChild(overlay);
+
+            var form = overlay.querySelector('form');
+            var input = overlay.querySelector('input');
+            var error = overlay.querySelector('[data-error]');
+            input.focus();
+
+            overlay.querySelector('[data-cancel]').onclick = function () {
+                overlay.remove();
+                reject(new Error('step-up cancelled'));
+            };
+            form.onsubmit = function (evt) {
+                evt.preventDefault();
+                ORIGINAL_FETCH('/api/admin/auth/reauth', {
+                    method: 'POST',
+                    credentials: 'same-origin',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ password: input.value })
+                }).then(function (resp) {
+                    if (resp.ok) {
+                        overlay.remove();
+                        resolve();
+                        return;
+                    }
+                    return resp.json().then(function (data) {
+                        error.textContent = (data && data.message) || 'Incorrect password';
+                    }, function () {
+                        error.textContent = 'Incorrect password';
+                    }).then(function () {
+                        error.classList.remove('hidden');
+                        input.value = '';
+                        input.focus();
+                    });
+                });
+            };
+        }).then(function () {
+            STEP_UP_PROMISE = null;
+        }, function (err) {
+            STEP_UP_PROMISE = null;
+            throw err;
+        });
+
+        return STEP_UP_PROMISE;
+    }
+
     // Guard admin API and admin UI HTML routes. Skip the auth endpoints
     // themselves — login failures and the refresh call must not recurse.
     function shouldGuard(url) {
@@ -149,6 +215,13 @@
         window.fetch = function (input, init) {
             var url = typeof input === 'string' ? input : (input && input.url) || '';
             return ORIGINAL_FETCH(input, init).then(function (resp) {
+                if (resp && resp.status === 403 && resp.headers.get('X-Step-Up-Required') && shouldGuard(url)) {
+                    return stepUp().then(function () {
+                        return ORIGINAL_FETCH(input, init);
+                    }).catch(function () {
+                        return resp;
+                    });
+                }
                 if (!(resp && resp.status === 401 && shouldGuard(url))) {
                     return resp;
                 }
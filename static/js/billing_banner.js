@@ -5,7 +5,7 @@
 // users (per the billing spec — admins, doctors, caregivers don't see it).
 //
 // Banner shows when ANY of:
-//   - entitlement.mode == "read_only" (trial lapsed or payment failed)
+//   - entitlement.mode == "read_only" (trial lapsed, payment failed or subscription ended)
 //   - entitlement.mode == "blocked"
 //   - entitlement.mode == "full" AND trial_end is within 7 days
 //
@@ -48,19 +48,23 @@
         if (mode === 'blocked') {
             return {
                 tone: 'red',
-                msg: 'Your subscription has expired. Subscribe now to keep using MyCareCompanion.',
+                msg: 'Your subscription has ended. Your records are still here to view — subscribe to make changes again.',
                 cta: 'Subscribe',
                 key: 'blocked'
             };
         }
         if (mode === 'read_only') {
             var until = ent.read_only_until;
+            var msg = 'Your account is read-only: you can view, export and delete records, but not add new ones.';
+            if (until) {
+                msg += ' Subscribe within ' + humanRemaining(until) +
+                    ' (by ' + fmtDateTime(until) + ') to keep full access.';
+            }
             return {
                 tone: 'amber',
-                msg: 'Your account is read-only. Subscribe within ' + humanRemaining(until) +
-                    ' (by ' + fmtDateTime(until) + ') to avoid losing access.',
+                msg: msg,
                 cta: 'Subscribe',
-                key: 'readonly:' + until
+                key: 'readonly:' + (until || '')
             };
         }
         if (mode === 'full' && ent.trial_end) {
@@ -0,0 +1,80 @@
+// csrf.js — attaches the CSRF token to same-origin state-changing requests.
+//
+// The server (middleware.CSRF) rejects cookie-authenticated POST/PUT/PATCH/
+// DELETE requests that don't echo the page's token. Pages put the token in
+// <meta name="csrf-token">; this script sends it as X-CSRF-Token on fetch
+// and htmx requests, and adds a hidden csrf_token field to any POST form
+// rendered without {{csrfField}}.
+//
+// Load it (not deferred) before session_guard.js / admin_session_guard.js
+// so their retry wrappers see the patched fetch.
+
+(function () {
+    'use strict';
+
+    var HEADER = 'X-CSRF-Token';
+    var FIELD = 'csrf_token';
+
+    function token() {
+        var meta = document.querySelector('meta[name="csrf-token"]');
+        return meta ? meta.getAttribute('content') : '';
+    }
+
+    function unsafe(method) {
+        method = (method || 'GET').toUpperCase();
+        return method !== 'GET' && method !== 'HEAD' && method !== 'OPTIONS';
+    }
+
+    function sameOrigin(url) {
+        try {
+            return new URL(url, window.location.href).origin === window.location.origin;
+        } catch (_) {
+            return false;
+        }
+    }
+
+    function installFetch() {
+        if (!window.fetch) return;
+        var original = window.fetch.bind(window);
+        window.fetch = function (input, init) {
+            var url = typeof input === 'string' ? input : (input && input.url) || '';
+            var method = (init && init.method) || (input && typeof input !== 'string' && input.method);
+            var tok = token();
+            if (tok && unsafe(method) && sameOrigin(url)) {
+                init = Object.assign({}, init);
+                var headers = new Headers(init.headers || (typeof input !== 'string' && input.headers) || {});
+                if (!headers.has(HEADER)) headers.set(HEADER, tok);
+                init.headers = headers;
+            }
+            return original(input, init);
+        };
+    }
+
+    function installHtmx() {
+        document.addEventListener('htmx:configRequest', function (evt) {
+            var tok = token();
+            if (tok && unsafe(evt.detail.verb)) {
+                evt.detail.headers[HEADER] = tok;
+            }
+        });
+    }
+
+    function installForms() {
+        document.addEventListener('submit', function (evt) {
+            var form = evt.target;
+            if (!form || form.tagName !== 'FORM' || !unsafe(form.method)) return;
+            if (!sameOrigin(form.action) || form.querySelector('input[name="' + FIELD + '"]')) return;
+            var tok = token();
+            if (!tok) return;
+            var input = document.createElement('input');
+            input.type = 'hidden';
+            input.name = FIELD;
+            input.value = tok;
+            form.appendChild(input);
+        }, true);
+    }
+
+    installFetch();
+    installHtmx();
+    installForms();
+})();